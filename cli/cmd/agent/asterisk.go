@@ -0,0 +1,234 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ami"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// asteriskHost edits Asterisk config files and runs CLI commands, either
+// on this host or inside an Asterisk container (docker exec). Commands
+// go over AMI when it is configured, otherwise through asterisk -rx.
+type asteriskHost struct {
+	container string
+	ami       *ami.Client
+}
+
+func newAsteriskHost(container string) *asteriskHost {
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	a := &asteriskHost{container: container}
+	if client, err := ami.FromEnv(env); err == nil {
+		a.ami = client
+	}
+	return a
+}
+
+// Where names path for messages, prefixed with the container or the
+// remote host
+func (a *asteriskHost) Where(path string) string {
+	if a.container == "" {
+		if t := remote.Current(); t != nil {
+			return t.Host + ":" + path
+		}
+		return path
+	}
+	return a.container + ":" + path
+}
+
+// Via describes how commands are run
+func (a *asteriskHost) Via() string {
+	switch {
+	case a.ami != nil:
+		return "AMI " + a.ami.Address()
+	case a.container != "":
+		return "docker exec " + a.container + " asterisk -rx"
+	case remote.Active():
+		return "ssh " + remote.Current().Host + " asterisk -rx"
+	}
+	return "asterisk -rx"
+}
+
+// Command runs an Asterisk CLI command. After an AMI failure it falls
+// back to asterisk -rx for the rest of the run.
+func (a *asteriskHost) Command(ctx context.Context, command string) (string, error) {
+	if a.ami != nil {
+		out, err := a.ami.Command(ctx, command)
+		if err == nil {
+			return out, nil
+		}
+		fmt.Printf("⚠️  AMI unavailable (%v); using asterisk -rx\n", err)
+		a.ami = nil
+	}
+	if a.container != "" {
+		result, err := a.exec(ctx, nil, "asterisk", "-rx", command)
+		if err != nil {
+			return "", err
+		}
+		return string(result.Combined()), nil
+	}
+	out, err := selflog.CombinedOutput(remote.Command(ctx, "asterisk", "-rx", command))
+	if err != nil {
+		return "", execError(a.Via(), err, out)
+	}
+	return string(out), nil
+}
+
+// exec runs a command in the Asterisk container
+func (a *asteriskHost) exec(ctx context.Context, stdin []byte, cmd ...string) (*docker.ExecResult, error) {
+	client, err := docker.Default()
+	if err != nil {
+		return nil, err
+	}
+	var in io.Reader
+	if stdin != nil {
+		in = bytes.NewReader(stdin)
+	}
+	result, err := client.Exec(ctx, a.container, in, cmd...)
+	if err != nil {
+		return nil, fmt.Errorf("exec in %s: %w", a.container, err)
+	}
+	return result, nil
+}
+
+// ReadFile reads path; a missing file is not an error. In a container an
+// empty file and a missing one look the same, and both merge alike.
+func (a *asteriskHost) ReadFile(ctx context.Context, path string) ([]byte, bool, error) {
+	if a.container == "" && remote.Active() {
+		out, err := selflog.Output(remote.Shell(ctx, `[ ! -e "$1" ] || cat "$1"`+" sh "+remote.Quote([]string{path})))
+		if err != nil {
+			return nil, false, execError("ssh "+remote.Current().Host, err, nil)
+		}
+		return out, len(out) > 0, nil
+	}
+	if a.container == "" {
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return data, err == nil, err
+	}
+	result, err := a.exec(ctx, nil, "sh", "-c", `[ ! -e "$1" ] || cat "$1"`, "sh", path)
+	if err != nil {
+		return nil, false, err
+	}
+	return result.Stdout, len(result.Stdout) > 0, nil
+}
+
+// ReadTail reads the last n bytes of path, e.g. of a log; a missing
+// file is not an error
+func (a *asteriskHost) ReadTail(ctx context.Context, path string, n int64) ([]byte, error) {
+	script := `[ ! -e "$1" ] || tail -c "$2" "$1"`
+	args := []string{path, strconv.FormatInt(n, 10)}
+	if a.container == "" && remote.Active() {
+		out, err := selflog.Output(remote.Shell(ctx, script+" sh "+remote.Quote(args)))
+		if err != nil {
+			return nil, execError("ssh "+remote.Current().Host, err, nil)
+		}
+		return out, nil
+	}
+	if a.container == "" {
+		f, err := os.Open(path)
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		if info, err := f.Stat(); err == nil && info.Size() > n {
+			if _, err := f.Seek(-n, io.SeekEnd); err != nil {
+				return nil, err
+			}
+		}
+		return io.ReadAll(f)
+	}
+	result, err := a.exec(ctx, nil, append([]string{"sh", "-c", script, "sh"}, args...)...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Stdout, nil
+}
+
+// WriteFile writes path, keeping the mode of an existing file
+func (a *asteriskHost) WriteFile(ctx context.Context, path string, data []byte) error {
+	if a.container == "" && remote.Active() {
+		cmd := remote.Shell(ctx, `cat > "$1"`+" sh "+remote.Quote([]string{path}))
+		cmd.Stdin = bytes.NewReader(data)
+		if out, err := selflog.CombinedOutput(cmd); err != nil {
+			return execError("ssh "+remote.Current().Host, err, out)
+		}
+		return nil
+	}
+	if a.container == "" {
+		mode := os.FileMode(0644)
+		if fi, err := os.Stat(path); err == nil {
+			mode = fi.Mode().Perm()
+		}
+		return os.WriteFile(path, data, mode)
+	}
+	_, err := a.exec(ctx, data, "sh", "-c", `cat > "$1"`, "sh", path)
+	return err
+}
+
+// Install replaces path with data after saving the previous content as
+// <path>.aava-backup-<time>. It returns the backup name ("" when path
+// did not exist).
+func (a *asteriskHost) Install(ctx context.Context, path string, previous, data []byte, existed bool) (string, error) {
+	backup := ""
+	if existed {
+		name, err := a.backupName(ctx, path)
+		if err != nil {
+			return "", err
+		}
+		if err := a.WriteFile(ctx, name, previous); err != nil {
+			return "", fmt.Errorf("failed to back up %s: %w", a.Where(path), err)
+		}
+		backup = name
+	}
+	if err := a.WriteFile(ctx, path, data); err != nil {
+		if os.IsPermission(err) {
+			return backup, fmt.Errorf("cannot write to %s: run with sudo or use --container", path)
+		}
+		return backup, fmt.Errorf("failed to write %s: %w", a.Where(path), err)
+	}
+	return backup, nil
+}
+
+// backupName returns an unused <path>.aava-backup-<time> name, so a
+// second run within the same second keeps the first backup
+func (a *asteriskHost) backupName(ctx context.Context, path string) (string, error) {
+	base := fmt.Sprintf("%s.aava-backup-%s", path, time.Now().Format("20060102-150405"))
+	name := base
+	for i := 1; ; i++ {
+		_, found, err := a.ReadFile(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return name, nil
+		}
+		name = fmt.Sprintf("%s-%d", base, i)
+	}
+}
+
+// execError adds a failed command's output to its error
+func execError(how string, err error, out []byte) error {
+	if msg := strings.TrimSpace(string(out)); msg != "" {
+		return fmt.Errorf("%s: %v: %s", how, err, msg)
+	}
+	return fmt.Errorf("%s: %v", how, err)
+}
@@ -0,0 +1,709 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/scenario"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var callCmd = &cobra.Command{
+	Use:   "call",
+	Short: "Place synthetic test calls",
+}
+
+var callTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Run a scripted caller against the agent end-to-end",
+	Long: `Place a real call to the agent and play a scripted caller from a
+scenario file, checking the agent's responses as the call goes.
+
+The call is originated over ARI and its audio exchanged as RTP with
+this machine (as 'agent calls listen' does), so Asterisk must reach it
+over UDP (--listen-host behind NAT). The agent's responses are read
+from the engine log of the call.
+
+A scenario is YAML:
+  name: booking
+  dial: Local/7000@from-internal     # or a trunk, PJSIP/+1555...@trunk
+  timeout: 3m
+  steps:
+    - expect: "how can I help"       # substring, or /regex/
+      within: 10s
+    - say: "I'd like a table for two tomorrow"
+    - expect: /table|booking/
+    - barge_in: "Sorry, make that three"
+      after: 800ms                   # into the agent's answer
+    - dtmf: "1#"
+    - pause: 2s
+    - audio: confirm.wav             # relative to the scenario file
+    - tool: hangup_call
+    - hangup: true
+say and barge_in text is spoken with espeak-ng, espeak, pico2wave or
+say, or the command in tts: (with {text} and {out}). say and audio wait
+for the agent to finish speaking; barge_in waits for it to start and
+passes when it stops talking. The call stops at the first expect or
+tool that is not met, and the command exits non-zero.
+
+Examples:
+  agent call test --scenario booking.yaml
+  agent call test --scenario booking.yaml --dial Local/7001@from-internal
+  agent call test --scenario booking.yaml --format json --listen-host 203.0.113.7`,
+	Args: cobra.NoArgs,
+	RunE: runCallTest,
+}
+
+var (
+	callScenario   string
+	callDial       string
+	callContainer  string
+	callListenHost string
+	callFormat     string
+)
+
+// Caller-side timing: how long the agent must be quiet before say and
+// audio steps, and how soon it must stop after a barge-in
+const (
+	callQuietBefore = 700 * time.Millisecond
+	callBargeStop   = 2 * time.Second
+)
+
+func init() {
+	f := callTestCmd.Flags()
+	f.StringVar(&callScenario, "scenario", "", "scenario file (YAML)")
+	f.StringVar(&callDial, "dial", "", "endpoint to call instead of the scenario's dial")
+	f.StringVar(&callContainer, "container", engine.ContainerName, "engine container whose log holds the agent's responses")
+	f.StringVar(&callListenHost, "listen-host", "", "address Asterisk sends the audio to (default: this machine's address toward Asterisk)")
+	f.StringVar(&callFormat, "format", "text", "output format: text|json")
+	callTestCmd.MarkFlagRequired("scenario")
+
+	callCmd.AddCommand(callTestCmd)
+	rootCmd.AddCommand(callCmd)
+}
+
+func runCallTest(cmd *cobra.Command, args []string) error {
+	if callFormat != "text" && callFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", callFormat)
+	}
+	sc, err := scenario.Load(callScenario)
+	if err != nil {
+		return err
+	}
+	if callDial != "" {
+		sc.Dial = callDial
+	}
+	if sc.Dial == "" {
+		return fmt.Errorf("no endpoint to call: set dial: in the scenario or --dial")
+	}
+	_, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(sc.CallTimeout())
+	defer cancel()
+
+	report, err := runScenario(ctx, sc, callContainer, callListenHost, logLoc, callFormat == "text")
+	if err != nil {
+		return err
+	}
+	if callFormat == "json" {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		passed := 0
+		for _, r := range report.Steps {
+			if r.Passed {
+				passed++
+			}
+		}
+		fmt.Printf("\n%d of %d step(s) passed in %s\n", passed, len(report.Steps), (time.Duration(report.ElapsedMs) * time.Millisecond).Round(time.Second))
+	}
+	if !report.Passed {
+		return fmt.Errorf("scenario %s failed", sc.Name)
+	}
+	return nil
+}
+
+// runScenario places the scenario's call and plays the scripted caller,
+// printing each step as it goes when text is set. Errors are returned
+// for calls that could not be placed; a call that fails its steps
+// returns a report that did not pass.
+func runScenario(ctx context.Context, sc *scenario.Scenario, container, listenHost string, logLoc *time.Location, text bool) (*scenario.Report, error) {
+	// Render the caller's audio before dialling so the agent is not
+	// kept waiting on TTS
+	var err error
+	audio := make([][]byte, len(sc.Steps))
+	for i := range sc.Steps {
+		st := &sc.Steps[i]
+		switch st.Kind() {
+		case scenario.StepSay, scenario.StepBargeIn:
+			audio[i], err = sc.Speak(ctx, st.Text())
+		case scenario.StepAudio:
+			audio[i], err = scenario.LoadAudio(st.Audio)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+
+	client, err := ariFromEnvFile()
+	if err != nil {
+		return nil, err
+	}
+	app := fmt.Sprintf("aava-call-test-%d", os.Getpid())
+	events, err := client.Subscribe(ctx, app)
+	if err != nil {
+		return nil, fmt.Errorf("connect ARI events: %w", err)
+	}
+	defer events.Close()
+
+	media, err := newCallMedia(client, listenHost)
+	if err != nil {
+		return nil, err
+	}
+	defer media.Close()
+	go media.Run()
+
+	// A per-run caller number finds the agent's leg of the call
+	number := fmt.Sprintf("555%07d", os.Getpid()%10000000)
+	callerID := sc.CallerID
+	if callerID == "" {
+		callerID = fmt.Sprintf("Scenario %s <%s>", sc.Name, number)
+	} else if i := strings.LastIndex(callerID, "<"); i >= 0 {
+		number = strings.Trim(callerID[i:], "<> ")
+	} else {
+		number = callerID
+	}
+
+	var cleanup []string
+	bridge, err := client.CreateBridge(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		cctx, ccancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer ccancel()
+		for _, id := range cleanup {
+			client.Hangup(cctx, id)
+		}
+		client.DestroyBridge(cctx, bridge.ID)
+	}()
+
+	external, err := client.ExternalMedia(ctx, app, media.Address, "ulaw")
+	if err != nil {
+		return nil, fmt.Errorf("create external media channel: %w", err)
+	}
+	cleanup = append(cleanup, external.ID)
+	if err := media.Connect(ctx, client, external.ID); err != nil {
+		return nil, err
+	}
+	if err := client.AddChannel(ctx, bridge.ID, external.ID); err != nil {
+		return nil, err
+	}
+
+	if text {
+		fmt.Printf("📞 Scenario %s → %s\n", sc.Name, sc.Dial)
+	}
+	started := time.Now()
+	leg, err := originateInto(ctx, client, events, app, sc.Dial, callerID)
+	if leg != nil {
+		cleanup = append(cleanup, leg.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := client.AddChannel(ctx, bridge.ID, leg.ID); err != nil {
+		return nil, err
+	}
+
+	report := &scenario.Report{Scenario: sc.Name}
+	agent := newAgentLog()
+	if id, err := findAgentLeg(ctx, client, leg.ID, number, started); err != nil {
+		if text {
+			fmt.Printf("⚠️  %v: expect and tool steps cannot be checked\n", err)
+		}
+	} else {
+		report.CallID = id
+		if text {
+			fmt.Printf("   Engine call %s\n", id)
+		}
+		go troubleshoot.WatchCall(ctx, container, id, logLoc, agent.add)
+	}
+
+	failed := false
+	hungUp := false
+	for i := range sc.Steps {
+		st := &sc.Steps[i]
+		if failed || hungUp || ctx.Err() != nil {
+			// Steps after a scripted hang-up are not a failure
+			report.Steps = append(report.Steps, scenario.Result{Step: st.Describe(), Kind: st.Kind(), Skipped: true, Passed: hungUp && !failed})
+			continue
+		}
+		stepStart := time.Now()
+		result := runScenarioStep(ctx, client, leg.ID, media, agent, st, audio[i], report.CallID != "")
+		result.ElapsedMs = time.Since(stepStart).Milliseconds()
+		report.Steps = append(report.Steps, result)
+		if text {
+			printStepResult(i+1, &result)
+		}
+		if st.Kind() == scenario.StepHangup {
+			hungUp = true
+		}
+		if !result.Passed && (st.Kind() == scenario.StepExpect || st.Kind() == scenario.StepTool) {
+			failed = true
+		}
+	}
+
+	report.Transcript = agent.said()
+	report.Heard = agent.heard()
+	report.ElapsedMs = time.Since(started).Milliseconds()
+	report.Passed = true
+	for _, r := range report.Steps {
+		if !r.Passed {
+			report.Passed = false
+		}
+	}
+	if text && media.Packets() == 0 {
+		fmt.Printf("⚠️  No audio arrived: check that Asterisk can reach %s over UDP (--listen-host, firewall)\n", media.Address)
+	}
+	return report, nil
+}
+
+// runScenarioStep performs one step of the scripted caller
+func runScenarioStep(ctx context.Context, client *ari.Client, legID string, media *callMedia, agent *agentLog, st *scenario.Step, audio []byte, watched bool) scenario.Result {
+	result := scenario.Result{Step: st.Describe(), Kind: st.Kind()}
+	fail := func(format string, a ...interface{}) scenario.Result {
+		result.Detail = fmt.Sprintf(format, a...)
+		return result
+	}
+
+	switch st.Kind() {
+	case scenario.StepExpect, scenario.StepTool:
+		if !watched {
+			return fail("the engine call was not found")
+		}
+		kind := troubleshoot.LiveAgent
+		match := st.Matches
+		if st.Kind() == scenario.StepTool {
+			kind = troubleshoot.LiveTool
+			match = func(s string) bool { return strings.Contains(strings.ToLower(s), strings.ToLower(st.Tool)) }
+		}
+		e, err := agent.wait(ctx, kind, match, st.WithinDuration())
+		if err != nil {
+			if heard := agent.pending(); heard != "" {
+				return fail("%v; the agent said %q", err, heard)
+			}
+			return fail("%v", err)
+		}
+		result.Detail = e.Text
+
+	case scenario.StepSay, scenario.StepAudio:
+		media.WaitQuiet(ctx, callQuietBefore, st.WithinDuration())
+		if err := media.Send(ctx, audio); err != nil {
+			return fail("%v", err)
+		}
+
+	case scenario.StepBargeIn:
+		if !media.WaitSpeaking(ctx, st.WithinDuration()) {
+			return fail("the agent did not speak within %s", st.WithinDuration())
+		}
+		select {
+		case <-ctx.Done():
+			return fail("%v", ctx.Err())
+		case <-time.After(st.AfterDuration()):
+		}
+		bargeAt := time.Now()
+		sent := make(chan error, 1)
+		go func() { sent <- media.Send(ctx, audio) }()
+		stopped := media.WaitQuiet(ctx, 300*time.Millisecond, callBargeStop)
+		if err := <-sent; err != nil {
+			return fail("%v", err)
+		}
+		if !stopped {
+			return fail("the agent kept talking over the caller")
+		}
+		result.Detail = fmt.Sprintf("agent stopped after %s", formatLatency(time.Since(bargeAt)))
+
+	case scenario.StepPause:
+		select {
+		case <-ctx.Done():
+			return fail("%v", ctx.Err())
+		case <-time.After(st.PauseDuration()):
+		}
+
+	case scenario.StepDTMF:
+		if err := client.SendDTMF(ctx, legID, st.DTMF); err != nil {
+			return fail("%v", err)
+		}
+
+	case scenario.StepHangup:
+		if err := client.Hangup(ctx, legID); err != nil {
+			return fail("%v", err)
+		}
+	}
+	result.Passed = true
+	return result
+}
+
+func printStepResult(n int, r *scenario.Result) {
+	icon := "✅"
+	if !r.Passed {
+		icon = "❌"
+	}
+	line := fmt.Sprintf("%s %d. %s (%s)", icon, n, r.Step, formatLatency(time.Duration(r.ElapsedMs)*time.Millisecond))
+	if r.Detail != "" {
+		line += " — " + r.Detail
+	}
+	fmt.Println(line)
+}
+
+// originateInto calls endpoint into app and waits until it is answered
+func originateInto(ctx context.Context, client *ari.Client, events *ari.Events, app, endpoint, callerID string) (*ari.Channel, error) {
+	leg, err := client.Originate(ctx, endpoint, app, callerID)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", endpoint, err)
+	}
+	timeout := time.NewTimer(30 * time.Second)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return leg, ctx.Err()
+		case <-timeout.C:
+			return leg, fmt.Errorf("%s did not answer within 30s", endpoint)
+		case e, ok := <-events.C:
+			if !ok {
+				return leg, fmt.Errorf("ARI event connection lost: %v", events.Err)
+			}
+			if e.Channel == nil || e.Channel.ID != leg.ID {
+				continue
+			}
+			switch e.Type {
+			case "StasisStart":
+				return leg, nil
+			case "ChannelDestroyed":
+				return nil, fmt.Errorf("%s did not answer", endpoint)
+			}
+		}
+	}
+}
+
+// findAgentLeg finds the channel the engine answered for the test call:
+// the other channel carrying the test's caller number, e.g. the ;2 side
+// of a Local channel
+func findAgentLeg(ctx context.Context, client *ari.Client, legID, number string, since time.Time) (string, error) {
+	deadline := time.Now().Add(15 * time.Second)
+	for time.Now().Before(deadline) {
+		channels, err := client.Channels(ctx)
+		if err != nil {
+			return "", err
+		}
+		for _, ch := range channels {
+			if ch.ID == legID || ch.Caller.Number != number || strings.HasPrefix(ch.Name, "UnicastRTP/") {
+				continue
+			}
+			// ARI times look like 2025-11-19T10:15:02.123+0000
+			if created, err := time.Parse("2006-01-02T15:04:05.000-0700", ch.CreationTime); err == nil && created.Before(since.Add(-time.Second)) {
+				continue
+			}
+			return ch.ID, nil
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(250 * time.Millisecond):
+		}
+	}
+	return "", fmt.Errorf("no channel with caller number %s reached the engine", number)
+}
+
+// agentLog collects the engine log events of the test call
+type agentLog struct {
+	mu     sync.Mutex
+	events []*troubleshoot.LiveEvent
+	// next is the first event not yet matched by a step
+	next   int
+	notify chan struct{}
+}
+
+func newAgentLog() *agentLog {
+	return &agentLog{notify: make(chan struct{}, 1)}
+}
+
+func (a *agentLog) add(e *troubleshoot.LiveEvent) {
+	a.mu.Lock()
+	a.events = append(a.events, e)
+	a.mu.Unlock()
+	select {
+	case a.notify <- struct{}{}:
+	default:
+	}
+}
+
+// wait returns the first unmatched event of kind satisfying match,
+// consuming the events up to it
+func (a *agentLog) wait(ctx context.Context, kind string, match func(string) bool, within time.Duration) (*troubleshoot.LiveEvent, error) {
+	timeout := time.NewTimer(within)
+	defer timeout.Stop()
+	for {
+		a.mu.Lock()
+		for i := a.next; i < len(a.events); i++ {
+			e := a.events[i]
+			if e.Kind == kind && match(e.Text) {
+				a.next = i + 1
+				a.mu.Unlock()
+				return e, nil
+			}
+			if e.Kind == troubleshoot.LiveEnd {
+				a.mu.Unlock()
+				return nil, fmt.Errorf("the call ended")
+			}
+		}
+		a.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-timeout.C:
+			return nil, fmt.Errorf("not heard within %s", within)
+		case <-a.notify:
+		}
+	}
+}
+
+// pending is what the agent said since the last matched event
+func (a *agentLog) pending() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var said []string
+	for _, e := range a.events[a.next:] {
+		if e.Kind == troubleshoot.LiveAgent {
+			said = append(said, e.Text)
+		}
+	}
+	return strings.Join(said, " / ")
+}
+
+// said is everything the agent said
+func (a *agentLog) said() []string {
+	return a.texts(troubleshoot.LiveAgent)
+}
+
+// heard is everything the engine transcribed of the caller
+func (a *agentLog) heard() []string {
+	return a.texts(troubleshoot.LiveCaller)
+}
+
+func (a *agentLog) texts(kind string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var texts []string
+	for _, e := range a.events {
+		if e.Kind == kind {
+			texts = append(texts, e.Text)
+		}
+	}
+	return texts
+}
+
+// callMedia exchanges 8 kHz µ-law RTP with an external media channel
+// and tracks whether the agent is speaking
+type callMedia struct {
+	Address string
+	conn    *net.UDPConn
+
+	mu       sync.Mutex
+	remote   *net.UDPAddr
+	speaking bool
+	voiceAt  time.Time
+	packets  int64
+
+	seq  uint16
+	ts   uint32
+	ssrc uint32
+}
+
+// Agent audio above this RMS level counts as speech
+const callVoiceLevel = 400
+
+func newCallMedia(client *ari.Client, host string) (*callMedia, error) {
+	if host == "" {
+		var err error
+		if host, err = localAddressToward(client.Host()); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{})
+	if err != nil {
+		return nil, err
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	return &callMedia{
+		Address: net.JoinHostPort(host, strconv.Itoa(conn.LocalAddr().(*net.UDPAddr).Port)),
+		conn:    conn,
+		seq:     uint16(r.Intn(1 << 16)),
+		ts:      r.Uint32(),
+		ssrc:    r.Uint32(),
+	}, nil
+}
+
+// Connect learns where to send audio from the external media channel;
+// until then, audio goes back to where Asterisk's RTP comes from
+func (m *callMedia) Connect(ctx context.Context, client *ari.Client, channelID string) error {
+	addr, err := client.Variable(ctx, channelID, "UNICASTRTP_LOCAL_ADDRESS")
+	if err != nil || addr == "" {
+		return nil
+	}
+	port, err := client.Variable(ctx, channelID, "UNICASTRTP_LOCAL_PORT")
+	if err != nil || port == "" {
+		return nil
+	}
+	if addr == "0.0.0.0" || addr == "::" {
+		addr = client.Host()
+	}
+	remote, err := net.ResolveUDPAddr("udp", net.JoinHostPort(addr, port))
+	if err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.remote = remote
+	m.mu.Unlock()
+	return nil
+}
+
+// Run reads the agent's audio until Close
+func (m *callMedia) Run() {
+	buf := make([]byte, 2048)
+	for {
+		n, from, err := m.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		payload := rtpPayload(buf[:n])
+		if payload == nil {
+			continue
+		}
+		level := scenario.Level(payload)
+		now := time.Now()
+		m.mu.Lock()
+		m.packets++
+		if m.remote == nil {
+			m.remote = from
+		}
+		if level >= callVoiceLevel {
+			m.speaking = true
+			m.voiceAt = now
+		} else if m.speaking && now.Sub(m.voiceAt) > 300*time.Millisecond {
+			m.speaking = false
+		}
+		m.mu.Unlock()
+	}
+}
+
+// Packets is the number of RTP packets received so far
+func (m *callMedia) Packets() int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.packets
+}
+
+// WaitQuiet waits until the agent has been silent for quiet, up to max;
+// it reports whether it was
+func (m *callMedia) WaitQuiet(ctx context.Context, quiet, max time.Duration) bool {
+	deadline := time.Now().Add(max)
+	for {
+		m.mu.Lock()
+		ok := !m.speaking && time.Since(m.voiceAt) >= quiet
+		m.mu.Unlock()
+		if ok {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+// WaitSpeaking waits up to max for the agent to speak
+func (m *callMedia) WaitSpeaking(ctx context.Context, max time.Duration) bool {
+	deadline := time.Now().Add(max)
+	for time.Now().Before(deadline) {
+		m.mu.Lock()
+		ok := m.speaking
+		m.mu.Unlock()
+		if ok {
+			return true
+		}
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(20 * time.Millisecond):
+		}
+	}
+	return false
+}
+
+// Send streams µ-law audio in real time, 20ms per packet
+func (m *callMedia) Send(ctx context.Context, audio []byte) error {
+	m.mu.Lock()
+	remote := m.remote
+	m.mu.Unlock()
+	if remote == nil {
+		return fmt.Errorf("no RTP address for Asterisk yet")
+	}
+	const frame = scenario.Rate / 50
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	packet := make([]byte, 12+frame)
+	for i := 0; i < len(audio); i += frame {
+		chunk := audio[i:]
+		if len(chunk) > frame {
+			chunk = chunk[:frame]
+		}
+		packet[0] = 0x80
+		packet[1] = 0 // PCMU
+		if i == 0 {
+			packet[1] |= 0x80 // marker: start of a talkspurt
+		}
+		binary.BigEndian.PutUint16(packet[2:], m.seq)
+		binary.BigEndian.PutUint32(packet[4:], m.ts)
+		binary.BigEndian.PutUint32(packet[8:], m.ssrc)
+		n := copy(packet[12:], chunk)
+		if _, err := m.conn.WriteToUDP(packet[:12+n], remote); err != nil {
+			return err
+		}
+		m.seq++
+		m.ts += frame
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Close stops receiving
+func (m *callMedia) Close() error {
+	return m.conn.Close()
+}
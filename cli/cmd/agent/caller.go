@@ -0,0 +1,175 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var callerCmd = &cobra.Command{
+	Use:   "caller <number>",
+	Short: "Show every call from one caller with outcomes",
+	Long: `Show the journey of one caller: every call from the number in the
+window, oldest first, with its outcome (status, quality score, what the
+agent did, its most severe findings) and the tags and notes operators
+left (see 'agent calls tag').
+
+Numbers are matched on digits contained in the caller's number, so
+5551234567 finds calls from +1 (555) 123-4567 and from 5551234567;
+give the number without country code when trunks differ in how they
+present it. Each call is analyzed like 'agent export calls' does,
+reusing data collected by earlier runs.
+
+Examples:
+  agent caller +15551234567
+  agent caller 5551234567 --since 7d
+  agent caller +15551234567 --format json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCaller,
+}
+
+var (
+	callerSince     string
+	callerFormat    string
+	callerContainer string
+	callerNoCache   bool
+	callerTimeout   time.Duration
+)
+
+// callerRecent is the period in which repeated failures are pointed out
+const callerRecent = 7 * 24 * time.Hour
+
+func init() {
+	f := callerCmd.Flags()
+	f.StringVar(&callerSince, "since", "30d", "how far back: duration (7d, 24h) or timestamp")
+	f.StringVar(&callerFormat, "format", "text", "output format: text|json")
+	f.StringVar(&callerContainer, "container", troubleshoot.DefaultContainer, "engine container to read logs from")
+	f.BoolVar(&callerNoCache, "no-cache", false, "collect call logs again instead of reusing cached data")
+	f.DurationVar(&callerTimeout, "timeout", 0, "abort after this long (e.g. 5m, 0 = no limit)")
+	rootCmd.AddCommand(callerCmd)
+}
+
+func runCaller(cmd *cobra.Command, args []string) error {
+	if callerFormat != "text" && callerFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", callerFormat)
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(callerTimeout)
+	defer cancel()
+
+	runner, err := newBatchRunner(cmd, ctx, cfg, callerContainer, callerNoCache, callerSince, "", troubleshoot.CallFilter{From: args[0]})
+	if err != nil {
+		return err
+	}
+	journey, err := runner.CallerJourney()
+	if err != nil {
+		return err
+	}
+
+	if callerFormat == "json" {
+		out, err := json.MarshalIndent(journey, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	printJourney(journey, loc)
+	return nil
+}
+
+func printJourney(j *troubleshoot.Journey, loc *time.Location) {
+	who := j.Caller
+	if j.Name != "" {
+		who = fmt.Sprintf("%s (%s)", j.Caller, j.Name)
+	}
+	if len(j.Calls) == 0 {
+		fmt.Printf("No calls from %s since %s\n", who, callerSince)
+		return
+	}
+	fmt.Printf("📇 %s: %d call(s) since %s, %d failed\n", who, len(j.Calls), callerSince, j.Failed)
+	recentFailed := 0
+	for _, c := range j.Calls {
+		if c.Failed && time.Since(c.Start) < callerRecent {
+			recentFailed++
+		}
+	}
+	if recentFailed > 1 {
+		fmt.Printf("⚠️  Failed %d times in the last 7 days\n", recentFailed)
+	}
+	fmt.Println()
+
+	for _, c := range j.Calls {
+		icon := "✅"
+		switch {
+		case c.Failed:
+			icon = "❌"
+		case c.Error != "":
+			icon = "❔"
+		case len(c.Problems) > 0:
+			icon = "⚠️ "
+		}
+		line := fmt.Sprintf("%s %s  %s", icon, c.Start.In(loc).Format("2006-01-02 15:04"), c.CallID)
+		if c.DurationSeconds > 0 {
+			line += "  " + (time.Duration(c.DurationSeconds) * time.Second).String()
+		}
+		if c.Dialed != "" {
+			line += " → " + c.Dialed
+		}
+		if c.Status != "" {
+			line += fmt.Sprintf("  [%s]", c.Status)
+		}
+		if c.Error == "" && c.QualityScore > 0 {
+			line += fmt.Sprintf("  score %.0f", c.QualityScore)
+		}
+		fmt.Println(line)
+
+		var summary []string
+		if c.Persona != "" {
+			summary = append(summary, "context "+c.Persona)
+		}
+		if len(c.Intents) > 0 {
+			summary = append(summary, strings.Join(c.Intents, ", "))
+		}
+		if len(c.Problems) > 0 {
+			summary = append(summary, strings.Join(c.Problems, "; "))
+		}
+		if c.Error != "" {
+			summary = append(summary, "not analyzed: "+c.Error)
+		}
+		if len(summary) > 0 {
+			fmt.Printf("      %s\n", strings.Join(summary, " · "))
+		}
+		if len(c.Tags) > 0 {
+			fmt.Printf("      🏷️  %s\n", strings.Join(c.Tags, ", "))
+		}
+		for _, n := range c.Notes {
+			fmt.Printf("      📝 %s\n", n.Text)
+		}
+	}
+	last := j.Calls[len(j.Calls)-1]
+	fmt.Printf("\nLast call %s ago. Details: agent troubleshoot --call %s\n", callerAge(time.Since(last.Start)), last.CallID)
+}
+
+// callerAge is a coarse age: minutes, hours or days
+func callerAge(d time.Duration) string {
+	switch {
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+	return fmt.Sprintf("%dd", int(d.Hours()/24))
+}
@@ -0,0 +1,487 @@
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/spf13/cobra"
+)
+
+var callsCmd = &cobra.Command{
+	Use:   "calls",
+	Short: "Monitor live calls (listen, watch, wallboard) and annotate calls (tag)",
+}
+
+var callsListenCmd = &cobra.Command{
+	Use:   "listen <channel>",
+	Short: "Stream the audio of a live call for QA monitoring",
+	Long: `Listen to a live call between a caller and the agent without either
+side hearing you.
+
+listen snoops on the channel over ARI (the ARI equivalent of ChanSpy),
+so both the caller and the agent are heard. <channel> is a channel ID
+(the call ID), a channel name such as PJSIP/telnyx-00000012, or the
+caller's number.
+
+By default the audio is streamed as RTP to this machine and played with
+ffplay, aplay or sox's play, whichever is installed. Asterisk must be
+able to reach this machine over UDP: use --listen-host behind NAT, or
+--dial to have Asterisk call a phone instead (PJSIP/1001, or a WebRTC
+extension). --output also writes the audio to a WAV file.
+
+listen runs until the call ends, the dialed phone hangs up or Ctrl-C.
+
+Examples:
+  agent calls listen 1763582071.6214
+  agent calls listen PJSIP/telnyx-00000012 --output call.wav
+  agent calls listen +15551234567 --dial PJSIP/1001
+  agent calls listen 1763582071.6214 --listen-host 203.0.113.7 --port 40000`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCallsListen,
+}
+
+var (
+	listenDial        string
+	listenHost        string
+	listenPort        int
+	listenOutput      string
+	listenNoPlay      bool
+	listenDialTimeout time.Duration
+)
+
+func init() {
+	f := callsListenCmd.Flags()
+	f.StringVar(&listenDial, "dial", "", "call this endpoint (e.g. PJSIP/1001) instead of streaming to this machine")
+	f.StringVar(&listenHost, "listen-host", "", "address Asterisk sends the audio to (default: this machine's address toward Asterisk)")
+	f.IntVar(&listenPort, "port", 0, "UDP port to receive the audio on (default: any free port)")
+	f.StringVarP(&listenOutput, "output", "o", "", "also write the audio to this WAV file")
+	f.BoolVar(&listenNoPlay, "no-play", false, "do not play the audio (with --output)")
+	f.DurationVar(&listenDialTimeout, "dial-timeout", 30*time.Second, "how long to wait for --dial to answer")
+
+	callsCmd.AddCommand(callsListenCmd)
+	rootCmd.AddCommand(callsCmd)
+}
+
+func ariFromEnvFile() (*ari.Client, error) {
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	return ari.FromEnv(env)
+}
+
+// findChannel resolves a channel ID, name or caller number
+func findChannel(ctx context.Context, client *ari.Client, target string) (*ari.Channel, error) {
+	ch, err := client.Channel(ctx, target)
+	if err == nil {
+		return ch, nil
+	}
+	if !ari.IsStatus(err, http.StatusNotFound) {
+		return nil, err
+	}
+	channels, err := client.Channels(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var matches []ari.Channel
+	for _, c := range channels {
+		if c.Name == target || c.Caller.Number == target || strings.HasPrefix(c.Name, target+"-") {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("no active channel %q (see 'asterisk -rx \"core show channels\"')", target)
+	case 1:
+		return &matches[0], nil
+	}
+	var names []string
+	for _, m := range matches {
+		names = append(names, m.ID+" ("+m.Name+")")
+	}
+	return nil, fmt.Errorf("%q matches %d channels, pick one: %s", target, len(matches), strings.Join(names, ", "))
+}
+
+func runCallsListen(cmd *cobra.Command, args []string) error {
+	if listenNoPlay && listenOutput == "" && listenDial == "" {
+		return fmt.Errorf("--no-play needs --output")
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	client, err := ariFromEnvFile()
+	if err != nil {
+		return err
+	}
+	target, err := findChannel(ctx, client, args[0])
+	if err != nil {
+		return err
+	}
+	caller := target.Caller.Number
+	if target.Caller.Name != "" {
+		caller = target.Caller.Name + " <" + caller + ">"
+	}
+	fmt.Printf("🎧 Listening to %s (%s, %s, caller %s)\n", target.Name, target.ID, target.State, caller)
+
+	// A private application owns the bridge and the helper channels;
+	// it disappears with the event connection
+	app := fmt.Sprintf("aava-listen-%d", os.Getpid())
+	events, err := client.Subscribe(ctx, app)
+	if err != nil {
+		return fmt.Errorf("connect ARI events: %w", err)
+	}
+	defer events.Close()
+
+	var cleanup []string
+	bridge, err := client.CreateBridge(ctx, app)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// The run context is likely cancelled by now
+		cctx, ccancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer ccancel()
+		for _, id := range cleanup {
+			client.Hangup(cctx, id)
+		}
+		client.DestroyBridge(cctx, bridge.ID)
+	}()
+
+	snoop, err := client.Snoop(ctx, target.ID, app)
+	if err != nil {
+		return fmt.Errorf("snoop on %s: %w", target.Name, err)
+	}
+	cleanup = append(cleanup, snoop.ID)
+	if err := client.AddChannel(ctx, bridge.ID, snoop.ID); err != nil {
+		return err
+	}
+
+	// Channels whose end ends the session
+	watch := map[string]string{snoop.ID: "the call ended"}
+	if listenDial != "" {
+		leg, err := dialListener(ctx, client, events, app, listenDial)
+		if leg != nil {
+			cleanup = append(cleanup, leg.ID)
+		}
+		if err != nil {
+			return err
+		}
+		if err := client.AddChannel(ctx, bridge.ID, leg.ID); err != nil {
+			return err
+		}
+		watch[leg.ID] = listenDial + " hung up"
+		fmt.Printf("✅ Connected to %s — press Ctrl-C to stop\n", listenDial)
+	} else {
+		stream, err := startStream(ctx, client)
+		if err != nil {
+			return err
+		}
+		defer stream.Close()
+		media, err := client.ExternalMedia(ctx, app, stream.Address, "ulaw")
+		if err != nil {
+			return fmt.Errorf("create external media channel: %w", err)
+		}
+		cleanup = append(cleanup, media.ID)
+		if err := client.AddChannel(ctx, bridge.ID, media.ID); err != nil {
+			return err
+		}
+		watch[media.ID] = "Asterisk dropped the stream"
+		fmt.Printf("✅ Streaming to %s%s — press Ctrl-C to stop\n", stream.Address, stream.Describe())
+		go stream.Run()
+		defer func() {
+			if stream.Packets() == 0 {
+				fmt.Printf("⚠️  No audio arrived: check that Asterisk can reach %s over UDP (--listen-host, firewall) or use --dial\n", stream.Address)
+			}
+		}()
+	}
+
+	start := time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Printf("\n⏹  Stopped after %s\n", time.Since(start).Round(time.Second))
+			return nil
+		case e, ok := <-events.C:
+			if !ok {
+				return fmt.Errorf("ARI event connection lost: %v", events.Err)
+			}
+			if e.Channel == nil || (e.Type != "StasisEnd" && e.Type != "ChannelDestroyed") {
+				continue
+			}
+			if why, ok := watch[e.Channel.ID]; ok {
+				fmt.Printf("📞 Stopped after %s: %s\n", time.Since(start).Round(time.Second), why)
+				return nil
+			}
+		}
+	}
+}
+
+// dialListener calls endpoint and waits for it to answer into app
+func dialListener(ctx context.Context, client *ari.Client, events *ari.Events, app, endpoint string) (*ari.Channel, error) {
+	fmt.Printf("🔄 Calling %s...\n", endpoint)
+	leg, err := client.Originate(ctx, endpoint, app, "Agent QA <listen>")
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", endpoint, err)
+	}
+	timeout := time.NewTimer(listenDialTimeout)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return leg, ctx.Err()
+		case <-timeout.C:
+			return leg, fmt.Errorf("%s did not answer within %s", endpoint, listenDialTimeout)
+		case e, ok := <-events.C:
+			if !ok {
+				return leg, fmt.Errorf("ARI event connection lost: %v", events.Err)
+			}
+			if e.Channel == nil || e.Channel.ID != leg.ID {
+				continue
+			}
+			switch e.Type {
+			case "StasisStart":
+				return leg, nil
+			case "ChannelDestroyed":
+				return nil, fmt.Errorf("%s did not answer", endpoint)
+			}
+		}
+	}
+}
+
+// audioStream receives the RTP stream of an external media channel and
+// feeds the µ-law payload to a player and/or a WAV file
+type audioStream struct {
+	Address string
+	conn    *net.UDPConn
+	player  *exec.Cmd
+	sink    io.WriteCloser
+	wav     *wavWriter
+	packets int64
+}
+
+func startStream(ctx context.Context, client *ari.Client) (*audioStream, error) {
+	host := listenHost
+	if host == "" {
+		var err error
+		if host, err = localAddressToward(client.Host()); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{Port: listenPort})
+	if err != nil {
+		return nil, err
+	}
+	s := &audioStream{
+		Address: net.JoinHostPort(host, strconv.Itoa(conn.LocalAddr().(*net.UDPAddr).Port)),
+		conn:    conn,
+	}
+	if listenOutput != "" {
+		if s.wav, err = newWavWriter(listenOutput); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if !listenNoPlay {
+		if err := s.startPlayer(ctx); err != nil {
+			s.Close()
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// players play raw 8 kHz µ-law from stdin
+var players = [][]string{
+	{"ffplay", "-hide_banner", "-loglevel", "error", "-nodisp", "-f", "mulaw", "-ar", "8000", "-ac", "1", "-"},
+	{"aplay", "-q", "-t", "raw", "-f", "MU_LAW", "-r", "8000", "-c", "1", "-"},
+	{"play", "-q", "-t", "ul", "-r", "8000", "-c", "1", "-"},
+}
+
+func (s *audioStream) startPlayer(ctx context.Context) error {
+	for _, p := range players {
+		if _, err := exec.LookPath(p[0]); err != nil {
+			continue
+		}
+		cmd := exec.CommandContext(ctx, p[0], p[1:]...)
+		cmd.Stderr = os.Stderr
+		sink, err := cmd.StdinPipe()
+		if err != nil {
+			return err
+		}
+		if err := selflog.Start(cmd); err != nil {
+			return err
+		}
+		s.player, s.sink = cmd, sink
+		return nil
+	}
+	return fmt.Errorf("no audio player found: install ffmpeg (ffplay), alsa-utils (aplay) or sox, or use --output with --no-play, or --dial")
+}
+
+// Describe names where the audio goes
+func (s *audioStream) Describe() string {
+	var to []string
+	if s.player != nil {
+		to = append(to, s.player.Path)
+	}
+	if s.wav != nil {
+		to = append(to, listenOutput)
+	}
+	if len(to) == 0 {
+		return ""
+	}
+	return " → " + strings.Join(to, ", ")
+}
+
+// Packets is the number of RTP packets received so far
+func (s *audioStream) Packets() int64 {
+	return atomic.LoadInt64(&s.packets)
+}
+
+// Run copies RTP payloads until the stream is closed
+func (s *audioStream) Run() {
+	buf := make([]byte, 2048)
+	for {
+		n, _, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		payload := rtpPayload(buf[:n])
+		if payload == nil {
+			continue
+		}
+		atomic.AddInt64(&s.packets, 1)
+		if s.sink != nil {
+			if _, err := s.sink.Write(payload); err != nil {
+				// The player was closed; keep recording
+				s.sink = nil
+			}
+		}
+		if s.wav != nil {
+			s.wav.Write(payload)
+		}
+	}
+}
+
+// Close stops receiving, the player and the WAV file
+func (s *audioStream) Close() error {
+	s.conn.Close()
+	if s.sink != nil {
+		s.sink.Close()
+	}
+	if s.player != nil {
+		s.player.Process.Kill()
+		s.player.Wait()
+	}
+	if s.wav != nil {
+		if err := s.wav.Close(); err != nil {
+			return err
+		}
+		fmt.Printf("💾 Saved %s (%s)\n", listenOutput, formatRecordingDuration(s.wav.Duration()))
+	}
+	return nil
+}
+
+// rtpPayload strips the RTP header (RFC 3550), CSRCs, extension and
+// padding; it returns nil for anything that is not RTP
+func rtpPayload(p []byte) []byte {
+	if len(p) < 12 || p[0]>>6 != 2 {
+		return nil
+	}
+	offset := 12 + 4*int(p[0]&0x0f)
+	if p[0]&0x10 != 0 {
+		if len(p) < offset+4 {
+			return nil
+		}
+		offset += 4 + 4*int(binary.BigEndian.Uint16(p[offset+2:offset+4]))
+	}
+	end := len(p)
+	if p[0]&0x20 != 0 && end > 0 {
+		end -= int(p[end-1])
+	}
+	if offset >= end {
+		return nil
+	}
+	return p[offset:end]
+}
+
+// localAddressToward returns this machine's address on the route to host
+func localAddressToward(host string) (string, error) {
+	// UDP "connects" only pick a route; nothing is sent
+	conn, err := net.Dial("udp", net.JoinHostPort(host, "9"))
+	if err != nil {
+		return "", fmt.Errorf("find the local address toward %s: %w (set --listen-host)", host, err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
+
+// wavWriter writes 8 kHz mono µ-law WAV, patching the sizes on Close
+type wavWriter struct {
+	f    *os.File
+	size uint32
+}
+
+func newWavWriter(path string) (*wavWriter, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &wavWriter{f: f}
+	if _, err := f.Write(w.header()); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *wavWriter) header() []byte {
+	h := make([]byte, 0, 46)
+	le32 := func(v uint32) { h = append(h, byte(v), byte(v>>8), byte(v>>16), byte(v>>24)) }
+	le16 := func(v uint16) { h = append(h, byte(v), byte(v>>8)) }
+	h = append(h, "RIFF"...)
+	le32(38 + w.size)
+	h = append(h, "WAVEfmt "...)
+	le32(18)
+	le16(7) // WAVE_FORMAT_MULAW
+	le16(1)
+	le32(8000)
+	le32(8000)
+	le16(1)
+	le16(8)
+	le16(0)
+	h = append(h, "data"...)
+	le32(w.size)
+	return h
+}
+
+func (w *wavWriter) Write(p []byte) (int, error) {
+	n, err := w.f.Write(p)
+	w.size += uint32(n)
+	return n, err
+}
+
+// Duration is the length written so far
+func (w *wavWriter) Duration() time.Duration {
+	return time.Duration(w.size) * time.Second / 8000
+}
+
+func (w *wavWriter) Close() error {
+	if _, err := w.f.WriteAt(w.header(), 0); err != nil {
+		w.f.Close()
+		return err
+	}
+	return w.f.Close()
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var callsTagCmd = &cobra.Command{
+	Use:   "tag <call_id>",
+	Short: "Tag and annotate a call for triage",
+	Long: `Attach tags and notes to a call so a team triaging calls can see who
+looked at what and why.
+
+Annotations are kept in the call index (~/.agent/calls.json) with the
+call and show up in 'agent troubleshoot --list', in the analysis and
+saved report of the call, and in 'agent export calls' (tags column).
+Listings and exports filter by tag with --tag.
+
+Without --tag, --untag or --note the call's annotations are shown;
+'agent calls tag --list' shows the tags in use.
+
+Examples:
+  agent calls tag 1763582071.6214 --tag escalated --note "customer emailed"
+  agent calls tag 1763582071.6214 --untag escalated --tag resolved
+  agent calls tag 1763582071.6214
+  agent calls tag --list
+  agent troubleshoot --list --tag escalated`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCallsTag,
+}
+
+var (
+	tagAdd    []string
+	tagRemove []string
+	tagNote   string
+	tagAuthor string
+	tagList   bool
+	tagFormat string
+)
+
+func init() {
+	f := callsTagCmd.Flags()
+	f.StringSliceVar(&tagAdd, "tag", nil, "tag to add (repeatable or comma-separated)")
+	f.StringSliceVar(&tagRemove, "untag", nil, "tag to remove (repeatable or comma-separated)")
+	f.StringVar(&tagNote, "note", "", "note to append")
+	f.StringVar(&tagAuthor, "author", "", "author of the note (default: $USER)")
+	f.BoolVar(&tagList, "list", false, "list the tags in use with their call counts")
+	f.StringVar(&tagFormat, "format", "text", "output format: text|json")
+	callsCmd.AddCommand(callsTagCmd)
+}
+
+func runCallsTag(cmd *cobra.Command, args []string) error {
+	if tagFormat != "text" && tagFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", tagFormat)
+	}
+	index := troubleshoot.LoadCallIndex()
+	if tagList {
+		return printTagCounts(index.TagCounts())
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("a call ID is required (or --list)")
+	}
+	callID := args[0]
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+
+	changed := len(tagAdd) > 0 || len(tagRemove) > 0 || tagNote != ""
+	call, ok := index.Get(callID)
+	if changed {
+		var note *troubleshoot.Note
+		if tagNote != "" {
+			author := tagAuthor
+			if author == "" {
+				author = os.Getenv("USER")
+			}
+			note = &troubleshoot.Note{Time: time.Now().UTC().Truncate(time.Second), Author: author, Text: tagNote}
+		}
+		if call, err = index.Annotate(callID, tagAdd, tagRemove, note); err != nil {
+			return err
+		}
+		if err := index.Save(); err != nil {
+			return err
+		}
+	} else if !ok {
+		return fmt.Errorf("call %s has no annotations (not in the call index)", callID)
+	}
+
+	if tagFormat == "json" {
+		out, err := json.MarshalIndent(call, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	if changed {
+		fmt.Printf("✅ Updated call %s\n", callID)
+		if !ok {
+			fmt.Println("   Not seen in the logs yet: it is added to the call index")
+		}
+	}
+	if len(call.Tags) == 0 && len(call.Notes) == 0 {
+		fmt.Printf("Call %s has no tags or notes\n", callID)
+		return nil
+	}
+	if len(call.Tags) > 0 {
+		fmt.Printf("🏷️  Tags: %s\n", strings.Join(call.Tags, ", "))
+	}
+	for _, n := range call.Notes {
+		by := ""
+		if n.Author != "" {
+			by = " " + n.Author
+		}
+		fmt.Printf("📝 %s%s: %s\n", n.Time.In(loc).Format("2006-01-02 15:04"), by, n.Text)
+	}
+	return nil
+}
+
+func printTagCounts(counts map[string]int) error {
+	if tagFormat == "json" {
+		out, err := json.MarshalIndent(counts, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	if len(counts) == 0 {
+		fmt.Println("No tagged calls (tag one with 'agent calls tag <call_id> --tag <tag>')")
+		return nil
+	}
+	tags := make([]string, 0, len(counts))
+	for tag := range counts {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	for _, tag := range tags {
+		fmt.Printf("%-24s %d call(s)\n", tag, counts[tag])
+	}
+	return nil
+}
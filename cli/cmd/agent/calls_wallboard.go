@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/wallboard"
+	"github.com/spf13/cobra"
+)
+
+var callsWallboardCmd = &cobra.Command{
+	Use:   "wallboard",
+	Short: "Full-screen live call figures for a NOC screen",
+	Long: `Show the headline live call figures in large type, refreshed every
+few seconds, for a NOC screen:
+  ACTIVE CALLS       calls in progress
+  ANSWER RATE        ended calls not abandoned, last hour
+  P95 TURN LATENCY   95th percentile turn latency, last hour
+  FAILURES           failed calls, last hour
+Figures turn yellow or red past the troubleshoot latency thresholds, an
+answer rate under 90%/75% and any failure.
+
+By default the engine log of the last hour is replayed and then
+followed. --server reads the figures from 'agent serve --events'
+instead, for screens without access to Docker; the same wallboard is
+served to browsers at http://<addr>/wallboard.
+
+--scale enlarges the figures for distant screens.
+
+Examples:
+  agent calls wallboard
+  agent calls wallboard --scale 2
+  agent calls wallboard --server http://noc-host:8090 --token "$EVENTS_TOKEN"`,
+	Args: cobra.NoArgs,
+	RunE: runCallsWallboard,
+}
+
+var (
+	wallboardServer    string
+	wallboardToken     string
+	wallboardContainer string
+	wallboardRefresh   time.Duration
+	wallboardScale     int
+)
+
+func init() {
+	callsWallboardCmd.Flags().StringVar(&wallboardServer, "server", "", "read figures from agent serve --events at this URL (e.g. http://noc-host:8090)")
+	callsWallboardCmd.Flags().StringVar(&wallboardToken, "token", "", "--events-token of the agent serve instance")
+	callsWallboardCmd.Flags().StringVar(&wallboardContainer, "container", engine.ContainerName, "engine container to follow")
+	callsWallboardCmd.Flags().DurationVar(&wallboardRefresh, "refresh", 2*time.Second, "how often the figures are redrawn")
+	callsWallboardCmd.Flags().IntVar(&wallboardScale, "scale", 1, "size of the figures (1-4)")
+	callsCmd.AddCommand(callsWallboardCmd)
+}
+
+func runCallsWallboard(cmd *cobra.Command, args []string) error {
+	if wallboardScale < 1 || wallboardScale > 4 {
+		return fmt.Errorf("--scale must be between 1 and 4")
+	}
+	if wallboardRefresh < 500*time.Millisecond {
+		return fmt.Errorf("--refresh must be at least 500ms")
+	}
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	var stats func() (wallboard.Stats, error)
+	var stream *troubleshoot.CallStream
+	if wallboardServer != "" {
+		stats = remoteWallboard(ctx, strings.TrimRight(wallboardServer, "/"), wallboardToken)
+	} else {
+		board := wallboard.NewBoard()
+		stream = troubleshoot.NewCallStream(ctx, logLoc, board.Observe)
+		go followEngine(ctx, wallboardContainer, time.Now().Add(-wallboard.Window), stream, nil)
+		stats = func() (wallboard.Stats, error) { return board.Stats(time.Now()), nil }
+	}
+
+	// Hide the cursor while drawing and restore it on exit
+	fmt.Print("\033[?25l")
+	defer fmt.Print("\033[?25h\n")
+
+	ticker := time.NewTicker(wallboardRefresh)
+	defer ticker.Stop()
+	var last wallboard.Stats
+	for {
+		if stream != nil {
+			stream.Sweep()
+		}
+		current, err := stats()
+		status := ""
+		switch {
+		case err != nil && last.Updated.IsZero():
+			status = fmt.Sprintf("\n⚠️  %v", err)
+		case err != nil:
+			status = fmt.Sprintf("\n⚠️  %v (showing figures from %s)", err, last.Updated.In(loc).Format("15:04:05"))
+		default:
+			last = current
+		}
+		fmt.Print("\033[H\033[2J")
+		fmt.Println("📊 AI Voice Agent — Wallboard (Ctrl-C to stop)")
+		fmt.Println()
+		fmt.Print(wallboard.Render(last, wallboardScale, loc))
+		fmt.Print(status)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// remoteWallboard returns a function fetching the figures of an
+// 'agent serve --events' instance
+func remoteWallboard(ctx context.Context, server, token string) func() (wallboard.Stats, error) {
+	client := &http.Client{Timeout: 5 * time.Second}
+	return func() (wallboard.Stats, error) {
+		var stats wallboard.Stats
+		req, err := http.NewRequest("GET", server+"/wallboard.json", nil)
+		if err != nil {
+			return stats, err
+		}
+		req = req.WithContext(ctx)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return stats, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return stats, fmt.Errorf("%s: %s", server, resp.Status)
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&stats); err != nil {
+			return stats, fmt.Errorf("%s: %w", server, err)
+		}
+		return stats, nil
+	}
+}
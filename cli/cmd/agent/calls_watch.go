@@ -0,0 +1,113 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var callsWatchCmd = &cobra.Command{
+	Use:   "watch <call_id>",
+	Short: "Follow the transcript of a live call with per-turn latency",
+	Long: `Print what the caller says and what the agent answers as the call
+happens, read from the engine's structured log.
+
+Each agent response is annotated with the time since the caller
+finished speaking, and each turn with the latency the provider
+measured (first audio after the caller's turn); turns over 1.5s are
+flagged ⚠️, over 3s ❌. Tool calls and errors are shown inline. The
+conversation so far is replayed first, so watch can join a call in
+progress; it stops when the call ends or on Ctrl-C.
+
+Which lines carry transcripts depends on the provider; with
+LOG_LEVEL=debug every provider's messages are shown.
+
+Examples:
+  agent calls watch 1763582071.6214
+  agent calls watch 1763582071.6214 --container ai_engine_2`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCallsWatch,
+}
+
+var watchContainer string
+
+func init() {
+	callsWatchCmd.Flags().StringVar(&watchContainer, "container", engine.ContainerName, "engine container to follow")
+	callsCmd.AddCommand(callsWatchCmd)
+}
+
+func runCallsWatch(cmd *cobra.Command, args []string) error {
+	callID := args[0]
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	fmt.Printf("👀 Watching call %s in %s — press Ctrl-C to stop\n\n", callID, watchContainer)
+	var (
+		callerAt time.Time
+		turns    int
+		total    time.Duration
+		ended    bool
+	)
+	err = troubleshoot.WatchCall(ctx, watchContainer, callID, logLoc, func(e *troubleshoot.LiveEvent) {
+		stamp := e.Time.In(loc).Format("15:04:05")
+		switch e.Kind {
+		case troubleshoot.LiveCaller:
+			callerAt = e.Time
+			note := ""
+			if e.Latency > 0 {
+				note = fmt.Sprintf("  (STT %s)", formatLatency(e.Latency))
+			}
+			fmt.Printf("%s  👤 Caller: %s%s\n", stamp, e.Text, note)
+		case troubleshoot.LiveAgent:
+			note := ""
+			if !callerAt.IsZero() && e.Time.After(callerAt) {
+				note = fmt.Sprintf("  (+%s)", formatLatency(e.Time.Sub(callerAt)))
+				callerAt = time.Time{}
+			}
+			fmt.Printf("%s  🤖 Agent:  %s%s\n", stamp, e.Text, note)
+		case troubleshoot.LiveLatency:
+			turns++
+			total += e.Latency
+			icon := "⏱ "
+			switch e.Severity() {
+			case troubleshoot.SeverityCritical:
+				icon = "❌"
+			case troubleshoot.SeverityWarning:
+				icon = "⚠️ "
+			}
+			fmt.Printf("%s  %s turn %d latency %s\n", strings.Repeat(" ", len(stamp)), icon, turns, formatLatency(e.Latency))
+		case troubleshoot.LiveTool:
+			fmt.Printf("%s  🔧 Tool: %s\n", stamp, e.Text)
+		case troubleshoot.LiveError:
+			fmt.Printf("%s  ❌ %s\n", stamp, e.Text)
+		case troubleshoot.LiveEnd:
+			ended = true
+			fmt.Printf("%s  📞 Call ended\n", stamp)
+		}
+	})
+	if err != nil {
+		return err
+	}
+	if turns > 0 {
+		fmt.Printf("\n%d turn(s), average latency %s\n", turns, formatLatency(total/time.Duration(turns)))
+	}
+	if !ended && ctx.Err() == nil {
+		fmt.Println("\n⚠️  The log stream ended before the call did")
+	}
+	return nil
+}
+
+func formatLatency(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/spf13/cobra"
+)
+
+// compatSkipped lists commands that do not need the engine or check
+// compatibility themselves
+var compatSkipped = map[string]bool{
+	"version":                       true,
+	"completion":                    true,
+	"help":                          true,
+	"self-update":                   true,
+	"doctor":                        true,
+	"selfcheck":                     true,
+	cobra.ShellCompRequestCmd:       true,
+	cobra.ShellCompNoDescRequestCmd: true,
+}
+
+// warnIncompatibleEngine prints a warning before a command runs when the
+// running engine does not match this CLI. Results are cached for an hour;
+// AGENT_SKIP_VERSION_CHECK=1 disables the check.
+func warnIncompatibleEngine(cmd *cobra.Command) {
+	if os.Getenv("AGENT_SKIP_VERSION_CHECK") != "" {
+		return
+	}
+	for c := cmd; c != nil; c = c.Parent() {
+		if compatSkipped[c.Name()] {
+			return
+		}
+	}
+
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	compat := engine.CachedCheck(ctx, version, env)
+	if compat.Level != engine.CompatWarn && compat.Level != engine.CompatFail {
+		return
+	}
+	fmt.Fprintf(os.Stderr, "⚠️  %s\n", compat.Message)
+	if compat.Remediation != "" {
+		fmt.Fprintf(os.Stderr, "   %s\n", compat.Remediation)
+	}
+	fmt.Fprintln(os.Stderr)
+}
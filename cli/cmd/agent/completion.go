@@ -0,0 +1,290 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/dialplan"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/firewall"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/hardware"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/locale"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/logfwd"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/recordings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/regress"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selfupdate"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/sip"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/snapshot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// completionCallLimit caps the call IDs offered for --call
+const completionCallLimit = 30
+
+// completeCallIDs suggests recent call IDs from the call index, described
+// by start time, status and caller so the right one is easy to pick
+func completeCallIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var out []string
+	for _, call := range troubleshoot.LoadCallIndex().Recent(completionCallLimit) {
+		if !strings.HasPrefix(call.ID, toComplete) {
+			continue
+		}
+		desc := call.Timestamp.Local().Format("2006-01-02 15:04")
+		if call.Status != "" {
+			desc += " " + call.Status
+		}
+		if call.CallerNumber != "" {
+			desc += " from " + call.CallerNumber
+		}
+		out = append(out, call.ID+"\t"+desc)
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeTags suggests the tags in use in the call index, most used
+// first
+func completeTags(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	counts := troubleshoot.LoadCallIndex().TagCounts()
+	var tags []string
+	for tag := range counts {
+		if strings.HasPrefix(tag, toComplete) {
+			tags = append(tags, tag)
+		}
+	}
+	sort.Slice(tags, func(i, j int) bool { return counts[tags[i]] > counts[tags[j]] })
+	out := make([]string, len(tags))
+	for i, tag := range tags {
+		out[i] = tag + "\t" + strconv.Itoa(counts[tag]) + " call(s)"
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeTenants suggests the configured tenants and those of indexed
+// calls
+func completeTenants(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	seen := map[string]bool{}
+	var out []string
+	add := func(name string) {
+		if name != "" && !seen[name] && strings.HasPrefix(name, toComplete) {
+			seen[name] = true
+			out = append(out, name)
+		}
+	}
+	if cfg, err := settings.Load(); err == nil {
+		for _, t := range cfg.Tenants {
+			add(t.Name)
+		}
+	}
+	for _, call := range troubleshoot.LoadCallIndex().Calls {
+		add(call.Tenant)
+	}
+	sort.Strings(out)
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeRunIDs suggests saved troubleshoot run IDs
+func completeRunIDs(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	runs, _ := troubleshoot.ListRuns()
+	var out []string
+	for _, run := range runs {
+		if strings.HasPrefix(run.ID, toComplete) {
+			out = append(out, run.ID+"\tcall "+run.CallID)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeSymptoms suggests --symptom values
+func completeSymptoms(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	var out []string
+	for name, desc := range troubleshoot.Symptoms {
+		out = append(out, name+"\t"+desc)
+	}
+	sort.Strings(out)
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeContainers suggests running container names from the daemon
+func completeContainers(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	var names []string
+	client, err := docker.Default()
+	if err == nil {
+		names, err = client.ContainerNames(ctx, docker.ListOptions{})
+	}
+	if err != nil {
+		return []string{troubleshoot.DefaultContainer}, cobra.ShellCompDirectiveNoFileComp
+	}
+	return names, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeChannels suggests the IDs of active channels from ARI
+func completeChannels(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	client, err := ariFromEnvFile()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	channels, err := client.Channels(ctx)
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	var out []string
+	for _, c := range channels {
+		if strings.HasPrefix(c.ID, toComplete) {
+			out = append(out, c.ID+"\t"+c.Name)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeSnapshots suggests saved snapshot IDs
+func completeSnapshots(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 1 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	snapshots, _ := snapshot.List()
+	var out []string
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		s := snapshots[i]
+		if strings.HasPrefix(s.ID, toComplete) {
+			out = append(out, s.ID+"\t"+s.Note)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+}
+
+// completeRegressCases completes regression case names
+func completeRegressCases(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	cases, _ := regress.List()
+	var out []string
+	for _, c := range cases {
+		if strings.HasPrefix(c.Name, toComplete) {
+			out = append(out, c.Name+"\t"+c.Note)
+		}
+	}
+	return out, cobra.ShellCompDirectiveNoFileComp
+}
+
+// fixedCompletion completes a flag from a static list
+func fixedCompletion(values ...string) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return cobra.FixedCompletions(values, cobra.ShellCompDirectiveNoFileComp)
+}
+
+// registerCompletions attaches completion functions. It runs from main,
+// after every command's init has defined its flags.
+func registerCompletions() {
+	rootCmd.RegisterFlagCompletionFunc("theme", fixedCompletion(theme.Names()...))
+	troubleshootCmd.RegisterFlagCompletionFunc("call", completeCallIDs)
+	troubleshootCmd.RegisterFlagCompletionFunc("symptom", completeSymptoms)
+	troubleshootCmd.RegisterFlagCompletionFunc("status", fixedCompletion(troubleshoot.CallStatuses...))
+	troubleshootCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	troubleshootCmd.RegisterFlagCompletionFunc("tenant", completeTenants)
+	troubleshootCmd.RegisterFlagCompletionFunc("source", fixedCompletion("docker", "loki", "elasticsearch", "syslog", "journald"))
+	troubleshootShowCmd.ValidArgsFunction = completeRunIDs
+	troubleshootShowCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	feedbackCmd.ValidArgsFunction = completeRunIDs
+	feedbackCmd.RegisterFlagCompletionFunc("verdict", fixedCompletion(troubleshoot.VerdictCorrect, troubleshoot.VerdictWrong))
+	reportWeeklyCmd.RegisterFlagCompletionFunc("format", fixedCompletion("markdown", "json"))
+	diagnoseEngineCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	diagnoseEngineCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	networkRulesCmd.RegisterFlagCompletionFunc("backend", fixedCompletion(firewall.Backends...))
+	networkRulesCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	reportWeeklyCmd.RegisterFlagCompletionFunc("locale", fixedCompletion(locale.Names()...))
+	reportWeeklyCmd.RegisterFlagCompletionFunc("clock", fixedCompletion("12h", "24h"))
+	reportWeeklyCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	reportWeeklyCmd.RegisterFlagCompletionFunc("tenant", completeTenants)
+	exportCallsCmd.RegisterFlagCompletionFunc("format", fixedCompletion("csv", "json"))
+	exportCallsCmd.RegisterFlagCompletionFunc("status", fixedCompletion(troubleshoot.CallStatuses...))
+	exportCallsCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	exportCallsCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	exportCallsCmd.RegisterFlagCompletionFunc("tenant", completeTenants)
+	exportConversationsCmd.RegisterFlagCompletionFunc("format", fixedCompletion("jsonl"))
+	exportConversationsCmd.RegisterFlagCompletionFunc("status", fixedCompletion(troubleshoot.CallStatuses...))
+	exportConversationsCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	exportConversationsCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	exportConversationsCmd.RegisterFlagCompletionFunc("tenant", completeTenants)
+	exportSyncCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	tenantsQuotaCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	tenantsQuotaCmd.RegisterFlagCompletionFunc("release", completeTenants)
+	tenantsQuotaCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	crmSyncCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	integrationsTestCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	sttVocabListCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	sttVocabVerifyCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	sttVocabVerifyCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	ttsSSMLCheckCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	ttsSSMLCheckCmd.RegisterFlagCompletionFunc("provider", fixedCompletion("google", "elevenlabs", "deepgram", "openai", "local"))
+
+	dialplanCmd.RegisterFlagCompletionFunc("provider", fixedCompletion("openai_realtime", "deepgram", "local_hybrid", "google_live"))
+	dialplanGenerateCmd.RegisterFlagCompletionFunc("transport", fixedCompletion(dialplan.Transports...))
+	dialplanGenerateCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	sipWizardCmd.RegisterFlagCompletionFunc("template", fixedCompletion(sip.TemplateKeys()...))
+	sipWizardCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	routeVerifyCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	recordingsExportCmd.RegisterFlagCompletionFunc("format", fixedCompletion(recordings.Formats...))
+	for _, c := range []*cobra.Command{recordingsListCmd, recordingsExportCmd, recordingsPruneCmd} {
+		c.RegisterFlagCompletionFunc("call", completeCallIDs)
+	}
+	callsListenCmd.ValidArgsFunction = completeChannels
+	callsWatchCmd.ValidArgsFunction = completeChannels
+	callsWatchCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	callsWallboardCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	callsTagCmd.ValidArgsFunction = completeCallIDs
+	callerCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	callerCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	callsTagCmd.RegisterFlagCompletionFunc("tag", completeTags)
+	callsTagCmd.RegisterFlagCompletionFunc("untag", completeTags)
+	callsTagCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	replayCmd.RegisterFlagCompletionFunc("call", completeCallIDs)
+	replayCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	replayCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	regressAddCmd.RegisterFlagCompletionFunc("call", completeCallIDs)
+	regressRunCmd.ValidArgsFunction = completeRegressCases
+	regressRemoveCmd.ValidArgsFunction = completeRegressCases
+	regressRunCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	for _, c := range []*cobra.Command{regressAddCmd, regressRunCmd} {
+		c.RegisterFlagCompletionFunc("container", completeContainers)
+	}
+	callTestCmd.RegisterFlagCompletionFunc("scenario", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	callTestCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	callTestCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	monitorSyntheticCmd.RegisterFlagCompletionFunc("scenario", func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+		return []string{"yaml", "yml"}, cobra.ShellCompDirectiveFilterFileExt
+	})
+	monitorSyntheticCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	monitorHistoryCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json"))
+	snapshotDiffCmd.ValidArgsFunction = completeSnapshots
+	snapshotCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	serveCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	initCmd.RegisterFlagCompletionFunc("template", fixedCompletion("local", "cloud", "hybrid", "openai-agent", "deepgram-agent"))
+	doctorCmd.RegisterFlagCompletionFunc("format", fixedCompletion("text", "json", "markdown"))
+	doctorCmd.RegisterFlagCompletionFunc("profile", fixedCompletion(hardware.ProfileAuto, hardware.ProfileARM))
+	loggingForwardCmd.RegisterFlagCompletionFunc("to", fixedCompletion(logfwd.Targets...))
+	loggingLevelCmd.RegisterFlagCompletionFunc("component", fixedCompletion(logComponents...))
+	loggingLevelCmd.RegisterFlagCompletionFunc("set", fixedCompletion(logLevels...))
+	loggingLevelCmd.RegisterFlagCompletionFunc("container", completeContainers)
+	for _, c := range []*cobra.Command{debugEnableCmd, debugDisableCmd, debugStatusCmd, debugCollectCmd} {
+		c.RegisterFlagCompletionFunc("container", completeContainers)
+	}
+	selfUpdateCmd.RegisterFlagCompletionFunc("channel", fixedCompletion(selfupdate.ChannelStable, selfupdate.ChannelBeta))
+	notifyTestCmd.RegisterFlagCompletionFunc("severity", fixedCompletion(notify.SeverityCritical, notify.SeverityWarning, notify.SeverityInfo))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+	"github.com/spf13/cobra"
+)
+
+var composeCmd = &cobra.Command{
+	Use:   "compose",
+	Short: "Keep docker-compose.yml in step with the configuration",
+}
+
+var composeGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate docker-compose.yml from the config profile",
+	Long: `Generate docker-compose.yml from config/ai-agent.yaml and .env.
+
+The generated file contains:
+  ai-engine        always
+  local-ai-server  when the default provider or active pipeline uses
+                   local models; built with the STT/TTS backends .env
+                   selects, with an NVIDIA GPU reserved when
+                   LOCAL_LLM_GPU_LAYERS is not 0 (or --gpu)
+  admin-ui         unless --no-admin-ui
+
+Rerun it after changing providers, pipelines or backends. Local changes
+belong in docker-compose.override.yml, which docker compose merges
+automatically and which generate creates once and never touches again.
+
+A docker-compose.yml that was not generated is only replaced with
+--force; it is kept as docker-compose.yml.orig so its changes can be
+moved to the override file. --check reports whether the file is out of
+date without writing anything (exit status 1 if it is); --dry-run
+prints the diff that generate would apply.
+
+Examples:
+  agent compose generate
+  agent compose generate --check
+  agent compose generate --dry-run
+  agent compose generate --gpu --no-admin-ui
+  agent compose generate --force`,
+	Args: cobra.NoArgs,
+	RunE: runComposeGenerate,
+}
+
+var (
+	composeDir       string
+	composeGPU       bool
+	composeNoAdminUI bool
+	composeCheck     bool
+	composeForce     bool
+)
+
+func init() {
+	composeGenerateCmd.Flags().StringVar(&composeDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	composeGenerateCmd.Flags().BoolVar(&composeGPU, "gpu", false, "reserve an NVIDIA GPU for local-ai-server (default from LOCAL_LLM_GPU_LAYERS)")
+	composeGenerateCmd.Flags().BoolVar(&composeNoAdminUI, "no-admin-ui", false, "leave out the admin UI")
+	composeGenerateCmd.Flags().BoolVar(&composeCheck, "check", false, "only report whether docker-compose.yml is up to date")
+	composeGenerateCmd.Flags().BoolVar(&composeForce, "force", false, "replace a docker-compose.yml that was not generated")
+
+	addDryRunFlag(composeGenerateCmd)
+
+	composeCmd.AddCommand(composeGenerateCmd)
+	rootCmd.AddCommand(composeCmd)
+}
+
+func runComposeGenerate(cmd *cobra.Command, args []string) error {
+	profile, err := deploy.LoadProfile(composeDir)
+	if err != nil {
+		return err
+	}
+	opts := deploy.DefaultComposeOptions(profile)
+	if cmd.Flags().Changed("gpu") {
+		opts.GPU = composeGPU
+	}
+	opts.AdminUI = !composeNoAdminUI
+	generated := []byte(deploy.GenerateCompose(profile, opts))
+
+	path := filepath.Join(composeDir, deploy.ComposeFile)
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	upToDate := bytes.Equal(existing, generated)
+
+	if composeCheck {
+		switch {
+		case upToDate:
+			fmt.Printf("✅ %s is up to date\n", path)
+			return nil
+		case existing == nil:
+			fmt.Printf("❌ %s does not exist\n", path)
+		case !deploy.IsGenerated(existing):
+			fmt.Printf("❌ %s was not generated; run 'agent compose generate --force'\n", path)
+		default:
+			fmt.Printf("❌ %s is out of date; run 'agent compose generate'\n", path)
+		}
+		return fmt.Errorf("compose file out of date")
+	}
+
+	override := filepath.Join(composeDir, deploy.OverrideFile)
+	if dryRun {
+		if existing != nil && !deploy.IsGenerated(existing) && !upToDate {
+			if !composeForce {
+				return fmt.Errorf("%s was not generated by 'agent compose generate'; move local changes to %s and rerun with --force", path, deploy.OverrideFile)
+			}
+			fmt.Printf("📦 Would keep the previous file as %s.orig\n", path)
+		}
+		planWrite(path, existing, generated)
+		if _, err := os.Stat(override); os.IsNotExist(err) {
+			planWrite(override, nil, []byte(deploy.OverrideStub))
+		}
+		dryRunDone()
+		return nil
+	}
+
+	if upToDate {
+		fmt.Printf("✅ %s is already up to date\n", path)
+	} else {
+		if existing != nil && !deploy.IsGenerated(existing) {
+			if !composeForce {
+				return fmt.Errorf("%s was not generated by 'agent compose generate'; move local changes to %s and rerun with --force", path, deploy.OverrideFile)
+			}
+			if err := os.WriteFile(path+".orig", existing, 0644); err != nil {
+				return fmt.Errorf("failed to back up %s: %w", path, err)
+			}
+			fmt.Printf("📦 Kept the previous file as %s.orig\n", path)
+		}
+		if err := os.WriteFile(path, generated, 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", path, err)
+		}
+		fmt.Printf("✅ Wrote %s\n", path)
+	}
+
+	if _, err := os.Stat(override); os.IsNotExist(err) {
+		if err := os.WriteFile(override, []byte(deploy.OverrideStub), 0644); err != nil {
+			return fmt.Errorf("failed to write %s: %w", override, err)
+		}
+		fmt.Printf("✅ Created %s for local changes\n", override)
+	} else {
+		fmt.Printf("   Keeping local changes in %s\n", override)
+	}
+	fmt.Println()
+
+	fmt.Println("Services:")
+	fmt.Println("  ai-engine")
+	if profile.LocalAI {
+		gpu := ""
+		if opts.GPU {
+			gpu = " (NVIDIA GPU)"
+		}
+		fmt.Printf("  local-ai-server%s\n", gpu)
+	}
+	if opts.AdminUI {
+		fmt.Println("  admin-ui")
+	}
+	if !upToDate {
+		fmt.Println()
+		if composeDir == "." {
+			fmt.Println("Apply: docker compose up -d --build")
+		} else {
+			fmt.Printf("Apply: cd %s && docker compose up -d --build\n", composeDir)
+		}
+	}
+	return nil
+}
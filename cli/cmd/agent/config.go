@@ -2,7 +2,6 @@ package main
 
 import (
 	"fmt"
-	"os"
 
 	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/config"
 	"github.com/spf13/cobra"
@@ -29,7 +28,10 @@ var validateCmd = &cobra.Command{
 Exit codes:
   0 - Configuration is valid
   1 - Warnings found (non-critical)
-  2 - Errors found (critical)`,
+  2 - Errors found (critical)
+
+--fix --dry-run reports what auto-fix would attempt without changing
+the file.`,
 	RunE: runValidate,
 }
 
@@ -43,6 +45,7 @@ func init() {
 	validateCmd.Flags().StringVar(&configFile, "file", "config/ai-agent.yaml", "Path to configuration file")
 	validateCmd.Flags().BoolVar(&configFix, "fix", false, "Attempt to auto-fix issues")
 	validateCmd.Flags().BoolVar(&configStrict, "strict", false, "Treat warnings as errors")
+	addDryRunFlag(validateCmd)
 	
 	configCmd.AddCommand(validateCmd)
 	rootCmd.AddCommand(configCmd)
@@ -70,6 +73,12 @@ func runValidate(cmd *cobra.Command, args []string) error {
 		fmt.Println("")
 		fmt.Println("Attempting auto-fix...")
 		
+		if dryRun {
+			fmt.Printf("Would attempt to fix %d error(s) and %d warning(s) in %s\n", len(result.Errors), len(result.Warnings), configFile)
+			dryRunDone()
+			return nil
+		}
+		
 		fixed, err := validator.AutoFix(result)
 		if err != nil {
 			fmt.Printf("❌ Auto-fix failed: %v\n", err)
@@ -108,7 +117,7 @@ func runValidate(cmd *cobra.Command, args []string) error {
 	}
 	
 	if exitCode != 0 {
-		os.Exit(exitCode)
+		return &exitCodeError{exitCode}
 	}
 	
 	return nil
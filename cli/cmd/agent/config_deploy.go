@@ -0,0 +1,582 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/canary"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/config"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/service"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"github.com/spf13/cobra"
+)
+
+var configDeployCmd = &cobra.Command{
+	Use:   "deploy [new-config.yaml]",
+	Short: "Roll out a new engine config to a share of calls, then promote or roll back",
+	Long: `Deploy a new config/ai-agent.yaml blue/green: a canary engine
+(ai_engine_canary) runs the new configuration next to ai_engine, the
+dialplan sends --canary of the inbound calls to it, and the calls of both
+are analyzed (as by 'agent export calls') and compared:
+
+  failure rate      failed calls, or calls with a critical finding
+  quality score     the average troubleshoot quality score
+  turn latency      the average per-call p95 turn latency
+
+Every --interval the comparison is printed. The canary is rolled back as
+soon as it does worse than a threshold allows (after --min-calls calls,
+or earlier after 3 failed calls), and promoted once it has handled
+--min-calls calls within every threshold. Without enough calls within
+--duration it is rolled back.
+
+Promotion sends every new call to the canary, waits for ai_engine's
+calls to finish (--grace), installs the new configuration (the old one
+is kept as config/ai-agent.yaml.aava-backup-<time>), restarts ai_engine,
+sends calls back to it, and removes the canary once its calls finished.
+Rollback sends every call to ai_engine and removes the canary after its
+calls finished. Neither drops a call.
+
+The weighting is the [aava-canary] subroutine, written to
+--asterisk-file and reloaded; the AI agent contexts must call
+Gosub(aava-canary,s,1) instead of Stasis(asterisk-ai-voice-agent). It
+can stay there: at 0% every call goes to ai_engine. The share is the
+global AAVA_CANARY_PCT, so changes take effect at once.
+
+An interrupted deployment keeps its canary; finish it with --promote or
+--rollback. With 'agent scale', restart the other instances after a
+promotion.
+
+--dry-run validates the new configuration and prints the files, commands
+and Asterisk changes each step would make, without starting anything.
+
+Examples:
+  agent config deploy config/ai-agent.new.yaml --canary 10%
+  agent config deploy config/ai-agent.new.yaml --canary 10% --dry-run
+  agent config deploy new.yaml --canary 25% --min-calls 50 --duration 4h
+  agent config deploy new.yaml --canary 10% --no-promote
+  agent config deploy --promote
+  agent config deploy --rollback`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runConfigDeploy,
+}
+
+var (
+	canaryPercent       string
+	canaryDir           string
+	canaryContainer     string
+	canaryAsteriskFile  string
+	canaryInterval      time.Duration
+	canaryDuration      time.Duration
+	canaryMinCalls      int
+	canaryFailureRise   float64
+	canaryScoreDrop     float64
+	canaryLatencyRise   float64
+	canaryNoPromote     bool
+	canaryPromote       bool
+	canaryRollback      bool
+	canaryGrace         time.Duration
+	canaryHealthTimeout time.Duration
+)
+
+func init() {
+	t := canary.DefaultThresholds
+	f := configDeployCmd.Flags()
+	f.StringVar(&canaryPercent, "canary", "10%", "share of inbound calls sent to the new configuration")
+	f.StringVar(&canaryDir, "dir", ".", "project directory (where docker-compose.yml lives)")
+	f.StringVar(&canaryContainer, "container", "", "Asterisk container (default: Asterisk on this host)")
+	f.StringVar(&canaryAsteriskFile, "asterisk-file", "/etc/asterisk/"+canary.DialplanFile, "where the [aava-canary] dialplan is installed")
+	f.DurationVar(&canaryInterval, "interval", 5*time.Minute, "how often the canary is compared with ai_engine")
+	f.DurationVar(&canaryDuration, "duration", time.Hour, "how long the canary may take to reach --min-calls")
+	f.IntVar(&canaryMinCalls, "min-calls", t.MinCalls, "canary calls needed before promoting")
+	f.Float64Var(&canaryFailureRise, "max-failure-rise", t.MaxFailureRise, "failure rate the canary may exceed ai_engine's by (percentage points)")
+	f.Float64Var(&canaryScoreDrop, "max-score-drop", t.MaxScoreDrop, "quality score the canary may fall below ai_engine's by")
+	f.Float64Var(&canaryLatencyRise, "max-latency-rise", t.MaxLatencyRise, "turn latency p95 the canary may exceed ai_engine's by (ms)")
+	f.BoolVar(&canaryNoPromote, "no-promote", false, "keep a passing canary running instead of promoting it")
+	f.BoolVar(&canaryPromote, "promote", false, "promote the running canary now")
+	f.BoolVar(&canaryRollback, "rollback", false, "roll the running canary back now")
+	f.DurationVar(&canaryGrace, "grace", 10*time.Minute, "how long to wait for an engine's calls to finish")
+	f.DurationVar(&canaryHealthTimeout, "timeout", 2*time.Minute, "how long to wait for an engine to become healthy")
+	addDryRunFlag(configDeployCmd)
+
+	configCmd.AddCommand(configDeployCmd)
+}
+
+// canaryDeployment holds what the steps of a deployment share
+type canaryDeployment struct {
+	opts     canary.Options
+	host     *asteriskHost
+	stable   string
+	cfg      *settings.Settings
+	started  time.Time
+	dialplan bool
+}
+
+func runConfigDeploy(cmd *cobra.Command, args []string) error {
+	if canaryPromote && canaryRollback {
+		return fmt.Errorf("use either --promote or --rollback")
+	}
+	if (canaryPromote || canaryRollback) != (len(args) == 0) {
+		return fmt.Errorf("give the new configuration file, or --promote/--rollback for a running canary")
+	}
+	percent, err := parsePercent(canaryPercent)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(canaryDir, "docker-compose.yml")); err != nil {
+		return fmt.Errorf("no docker-compose.yml in %s (use --dir to point at the project)", canaryDir)
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	env, err := health.LoadEnvFile(filepath.Join(canaryDir, ".env"))
+	if err != nil {
+		env, _ = health.LoadEnvFile(filepath.Join(canaryDir, "config", ".env"))
+	}
+	d := &canaryDeployment{
+		opts:     canary.DefaultOptions(canaryDir, percent),
+		host:     newAsteriskHost(canaryContainer),
+		stable:   engine.BaseURL(env),
+		cfg:      cfg,
+		dialplan: true,
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	if canaryPromote || canaryRollback {
+		if !canary.Active(canaryDir) {
+			return fmt.Errorf("no canary deployment in %s", canaryDir)
+		}
+		if dryRun {
+			return d.planFinish(ctx, canaryPromote)
+		}
+		if canaryPromote {
+			return d.promote(ctx)
+		}
+		return d.rollback(ctx, "requested")
+	}
+	if canary.Active(canaryDir) {
+		return fmt.Errorf("a canary is already deployed: finish it with --promote or --rollback")
+	}
+	return d.deploy(ctx, args[0])
+}
+
+// parsePercent reads "10%" or "10"
+func parsePercent(s string) (int, error) {
+	n, err := strconv.Atoi(strings.TrimSuffix(strings.TrimSpace(s), "%"))
+	if err != nil || n < 1 || n > 100 {
+		return 0, fmt.Errorf("--canary must be a percentage between 1%% and 100%%, got %q", s)
+	}
+	return n, nil
+}
+
+func (d *canaryDeployment) deploy(ctx context.Context, path string) error {
+	fmt.Printf("Validating %s...\n", path)
+	result, err := config.NewValidator(path).Validate()
+	if err != nil {
+		return err
+	}
+	for _, w := range result.Warnings {
+		fmt.Printf("⚠️  %s\n", w)
+	}
+	if len(result.Errors) > 0 {
+		for _, e := range result.Errors {
+			fmt.Printf("❌ %s\n", e)
+		}
+		return fmt.Errorf("%s has %d error(s); nothing deployed", path, len(result.Errors))
+	}
+	candidate, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		return d.planDeploy(ctx, candidate)
+	}
+
+	if err := canary.Write(canaryDir, d.opts, candidate); err != nil {
+		return fmt.Errorf("failed to write the canary files: %w", err)
+	}
+	fmt.Printf("✅ Wrote %s, %s and %s\n", canary.ConfigFile, canary.ComposeFile, canary.DialplanFile)
+
+	fmt.Printf("🚀 Starting %s (AudioSocket %d, RTP %d, health %d, app %s)...\n",
+		canary.Container, d.opts.AudioSocketPort, d.opts.RTPPort, d.opts.HealthPort, d.opts.CanaryApp())
+	up, composeEnv := composeCommand(ctx, "-f", "docker-compose.yml", "-f", canary.ComposeFile, "up", "-d", canary.Service)
+	c := exec.CommandContext(ctx, up[0], up[1:]...)
+	c.Dir = canaryDir
+	c.Env = composeEnv
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := selflog.Run(c); err != nil {
+		canary.Remove(canaryDir, d.opts)
+		return fmt.Errorf("%s failed: %w", up[0], err)
+	}
+	if err := waitHealthy(ctx, d.opts.HealthURL(), canary.Container); err != nil {
+		d.removeCanary(ctx)
+		return err
+	}
+
+	if err := d.installDialplan(ctx); err != nil {
+		d.removeCanary(ctx)
+		return err
+	}
+	if err := d.setPercent(ctx, d.opts.Percent); err != nil {
+		d.removeCanary(ctx)
+		return err
+	}
+	d.started = time.Now()
+	fmt.Printf("🔀 %d%% of calls go to the canary; comparing every %s for up to %s (Ctrl-C keeps the canary)\n",
+		d.opts.Percent, canaryInterval, canaryDuration)
+	return d.observe(ctx)
+}
+
+// installDialplan writes the weighted subroutine to Asterisk, reloads
+// the dialplan and checks that the AI agent contexts call it
+func (d *canaryDeployment) installDialplan(ctx context.Context) error {
+	where := d.host.Where(canaryAsteriskFile)
+	if err := d.host.WriteFile(ctx, canaryAsteriskFile, []byte(canary.GenerateDialplan(d.opts))); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("cannot write to %s: run with sudo or use --container", canaryAsteriskFile)
+		}
+		return fmt.Errorf("failed to write %s: %w", where, err)
+	}
+	if _, err := d.host.Command(ctx, "dialplan reload"); err != nil {
+		return fmt.Errorf("dialplan reload failed: %w", err)
+	}
+	fmt.Printf("✅ Installed [%s] in %s and reloaded the dialplan (%s)\n", canary.DialplanContext, where, d.host.Via())
+
+	out, err := d.host.Command(ctx, "dialplan show "+canary.DialplanContext)
+	if err != nil || !strings.Contains(out, "Stasis") {
+		fmt.Printf("⚠️  [%s] is not loaded: add '#include %s' to extensions_custom.conf\n", canary.DialplanContext, path.Base(canaryAsteriskFile))
+	}
+	// Host paths are Linux paths, also when the CLI runs on Windows
+	custom, _, _ := d.host.ReadFile(ctx, path.Join(path.Dir(canaryAsteriskFile), "extensions_custom.conf"))
+	if !strings.Contains(string(custom), "Gosub("+canary.DialplanContext) {
+		fmt.Printf("⚠️  No context calls Gosub(%s,s,1) in extensions_custom.conf: calls are not split until\n", canary.DialplanContext)
+		fmt.Printf("   Stasis(%s) is replaced with it in the AI agent contexts\n", d.opts.AppName)
+	}
+	return nil
+}
+
+// setPercent changes the share of calls sent to the canary, at once
+// through the global and in the installed file for reloads
+func (d *canaryDeployment) setPercent(ctx context.Context, percent int) error {
+	opts := d.opts
+	opts.Percent = percent
+	if d.dialplan {
+		if err := d.host.WriteFile(ctx, canaryAsteriskFile, []byte(canary.GenerateDialplan(opts))); err != nil {
+			return fmt.Errorf("failed to write %s: %w", d.host.Where(canaryAsteriskFile), err)
+		}
+	}
+	if _, err := d.host.Command(ctx, fmt.Sprintf("dialplan set global %s %d", canary.PercentVariable, percent)); err != nil {
+		return fmt.Errorf("failed to set %s: %w", canary.PercentVariable, err)
+	}
+	return nil
+}
+
+// observe compares the canary with ai_engine every interval until it is
+// promoted or rolled back
+func (d *canaryDeployment) observe(ctx context.Context) error {
+	thresholds := canary.Thresholds{
+		MinCalls:       canaryMinCalls,
+		MaxFailureRise: canaryFailureRise,
+		MaxScoreDrop:   canaryScoreDrop,
+		MaxLatencyRise: canaryLatencyRise,
+	}
+	deadline := d.started.Add(canaryDuration)
+	ticker := time.NewTicker(canaryInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println()
+			fmt.Println("⏸️  Stopped watching; the canary keeps its share of calls.")
+			fmt.Println("   Finish with: agent config deploy --promote (or --rollback)")
+			return nil
+		case <-ticker.C:
+		}
+		stable, err := d.stats(ctx, engine.ContainerName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Cannot analyze %s: %v\n", engine.ContainerName, err)
+			continue
+		}
+		candidate, err := d.stats(ctx, canary.Container)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Cannot analyze %s: %v\n", canary.Container, err)
+			continue
+		}
+		printCanaryStats(stable, candidate)
+		decision, reasons := canary.Decide(stable, candidate, thresholds)
+		switch decision {
+		case canary.Rollback:
+			return d.rollback(ctx, strings.Join(reasons, "; "))
+		case canary.Promote:
+			fmt.Printf("✅ Canary passed: %s\n", reasons[0])
+			if canaryNoPromote {
+				fmt.Println("   Kept running (--no-promote); promote with: agent config deploy --promote")
+				return nil
+			}
+			return d.promote(ctx)
+		}
+		if time.Now().After(deadline) {
+			return d.rollback(ctx, fmt.Sprintf("only %s within %s", reasons[0], canaryDuration))
+		}
+		fmt.Printf("⏳ Waiting: %s\n", reasons[0])
+	}
+}
+
+// stats analyzes the calls one engine container took since the start
+func (d *canaryDeployment) stats(ctx context.Context, container string) (canary.Stats, error) {
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return canary.Stats{}, err
+	}
+	runner := troubleshoot.NewRunner(troubleshoot.Options{
+		Context:     ctx,
+		Container:   container,
+		NoLLM:       true,
+		Since:       d.started.Format(time.RFC3339),
+		Location:    loc,
+		LogLocation: logLoc,
+		Costs:       d.cfg.Costs,
+	})
+	rows, err := runner.ExportCalls()
+	if err != nil {
+		return canary.Stats{}, err
+	}
+	return canary.Summarize(rows), nil
+}
+
+func printCanaryStats(stable, candidate canary.Stats) {
+	fmt.Println()
+	fmt.Printf("%s  %-18s %6s %7s %7s %6s %9s\n", time.Now().Format("15:04:05"), "", "calls", "failed", "fail%", "score", "p95 ms")
+	for _, row := range []struct {
+		name  string
+		stats canary.Stats
+	}{{engine.ContainerName, stable}, {canary.Container, candidate}} {
+		fmt.Printf("          %-18s %6d %7d %6.1f%% %6.0f %9.0f\n",
+			row.name, row.stats.Calls, row.stats.Failed, row.stats.FailureRate, row.stats.Score, row.stats.LatencyP95)
+	}
+}
+
+// promote moves ai_engine to the new configuration without dropping
+// calls: the canary takes every call while ai_engine restarts
+func (d *canaryDeployment) promote(ctx context.Context) error {
+	fmt.Println("🔼 Promoting the canary configuration")
+	if err := d.setPercent(ctx, 100); err != nil {
+		return err
+	}
+	fmt.Printf("🔀 Every new call goes to %s\n", canary.Container)
+	if err := waitForCalls(ctx, d.stable, engine.ContainerName); err != nil {
+		d.setPercent(ctx, d.opts.Percent)
+		return err
+	}
+
+	current := filepath.Join(canaryDir, "config", "ai-agent.yaml")
+	previous, err := os.ReadFile(current)
+	if err != nil {
+		return err
+	}
+	candidate, err := os.ReadFile(filepath.Join(canaryDir, canary.ConfigFile))
+	if err != nil {
+		return err
+	}
+	backup := fmt.Sprintf("%s.aava-backup-%s", current, time.Now().Format("20060102-150405"))
+	if err := os.WriteFile(backup, previous, 0644); err != nil {
+		return fmt.Errorf("failed to back up %s: %w", current, err)
+	}
+	if err := os.WriteFile(current, candidate, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("📦 Backed up %s to %s\n", current, backup)
+	fmt.Printf("✅ Installed the new configuration in %s\n", current)
+
+	if err := service.RestartContainer(ctx, engine.ContainerName, 30*time.Second); err != nil {
+		return fmt.Errorf("restart %s: %w (calls stay on the canary)", engine.ContainerName, err)
+	}
+	fmt.Printf("🔄 Restarted %s\n", engine.ContainerName)
+	if err := waitHealthy(ctx, d.stable, engine.ContainerName); err != nil {
+		return fmt.Errorf("%v; calls stay on the canary, restore %s and restart it", err, backup)
+	}
+
+	if err := d.setPercent(ctx, 0); err != nil {
+		return err
+	}
+	fmt.Printf("🔀 Every new call goes to %s again\n", engine.ContainerName)
+	if err := d.removeCanary(ctx); err != nil {
+		return err
+	}
+	fmt.Println("✅ Promoted")
+	return nil
+}
+
+// rollback sends every call back to ai_engine and removes the canary
+func (d *canaryDeployment) rollback(ctx context.Context, why string) error {
+	fmt.Printf("🔽 Rolling back: %s\n", why)
+	if err := d.setPercent(ctx, 0); err != nil {
+		return err
+	}
+	fmt.Printf("🔀 Every new call goes to %s\n", engine.ContainerName)
+	if err := d.removeCanary(ctx); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Rolled back; %s keeps its configuration\n", engine.ContainerName)
+	return nil
+}
+
+// removeCanary waits for the canary's calls to finish, then removes its
+// container and files
+func (d *canaryDeployment) removeCanary(ctx context.Context) error {
+	if err := waitForCalls(ctx, d.opts.HealthURL(), canary.Container); err != nil {
+		return err
+	}
+	client, err := docker.Default()
+	if err != nil {
+		return err
+	}
+	if err := client.Remove(ctx, canary.Container, true); err != nil && !docker.IsNotFound(err) {
+		return fmt.Errorf("remove %s: %w", canary.Container, err)
+	}
+	if err := canary.Remove(canaryDir, d.opts); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Removed %s and its files\n", canary.Container)
+	return nil
+}
+
+// planDeploy prints what deploying candidate would change
+func (d *canaryDeployment) planDeploy(ctx context.Context, candidate []byte) error {
+	for _, f := range []struct {
+		name string
+		data []byte
+	}{
+		{canary.ConfigFile, candidate},
+		{canary.ComposeFile, []byte(canary.GenerateCompose(d.opts))},
+		{canary.DialplanFile, []byte(canary.GenerateDialplan(d.opts))},
+	} {
+		if err := planFile(filepath.Join(canaryDir, f.name), f.data); err != nil {
+			return err
+		}
+	}
+	up, _ := composeCommand(ctx, "-f", "docker-compose.yml", "-f", canary.ComposeFile, "up", "-d", canary.Service)
+	planCommand(canaryDir, up[0], up[1:]...)
+	if err := d.planDialplan(ctx, d.opts.Percent); err != nil {
+		return err
+	}
+	planCall("dialplan reload (%s)", d.host.Via())
+	planCall("dialplan set global %s %d (%s)", canary.PercentVariable, d.opts.Percent, d.host.Via())
+	fmt.Printf("⏱️  Would compare %s with %s every %s for up to %s, then promote or roll back\n",
+		canary.Container, engine.ContainerName, canaryInterval, canaryDuration)
+	dryRunDone()
+	return nil
+}
+
+// planFinish prints what promoting or rolling back the running canary
+// would change
+func (d *canaryDeployment) planFinish(ctx context.Context, promote bool) error {
+	if promote {
+		if err := d.planDialplan(ctx, 100); err != nil {
+			return err
+		}
+		planCall("dialplan set global %s 100 (%s)", canary.PercentVariable, d.host.Via())
+		fmt.Printf("⏳ Would wait up to %s for the calls on %s to finish\n", canaryGrace, engine.ContainerName)
+		current := filepath.Join(canaryDir, "config", "ai-agent.yaml")
+		candidate, err := os.ReadFile(filepath.Join(canaryDir, canary.ConfigFile))
+		if err != nil {
+			return err
+		}
+		fmt.Printf("📦 Would back up %s to %s.aava-backup-<time>\n", current, current)
+		if err := planFile(current, candidate); err != nil {
+			return err
+		}
+		planCall("restart container %s", engine.ContainerName)
+	}
+	if err := d.planDialplan(ctx, 0); err != nil {
+		return err
+	}
+	planCall("dialplan set global %s 0 (%s)", canary.PercentVariable, d.host.Via())
+	fmt.Printf("⏳ Would wait up to %s for the calls on %s to finish\n", canaryGrace, canary.Container)
+	planCall("remove container %s (force)", canary.Container)
+	for _, name := range []string{canary.ComposeFile, canary.ConfigFile} {
+		path := filepath.Join(canaryDir, name)
+		if _, err := os.Stat(path); err == nil {
+			if err := planFile(path, nil); err != nil {
+				return err
+			}
+		}
+	}
+	opts := d.opts
+	opts.Percent = 0
+	if err := planFile(filepath.Join(canaryDir, canary.DialplanFile), []byte(canary.GenerateDialplan(opts))); err != nil {
+		return err
+	}
+	dryRunDone()
+	return nil
+}
+
+// planDialplan prints the change to the installed [aava-canary]
+// subroutine at percent
+func (d *canaryDeployment) planDialplan(ctx context.Context, percent int) error {
+	opts := d.opts
+	opts.Percent = percent
+	existing, _, err := d.host.ReadFile(ctx, canaryAsteriskFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", d.host.Where(canaryAsteriskFile), err)
+	}
+	planWrite(d.host.Where(canaryAsteriskFile), existing, []byte(canary.GenerateDialplan(opts)))
+	return nil
+}
+
+// waitForCalls waits up to --grace for an engine's active calls to end.
+// An engine that does not answer has none.
+func waitForCalls(ctx context.Context, baseURL, name string) error {
+	count := func(ctx context.Context) (int, error) {
+		h, err := engineclient.New(baseURL, "").Health(ctx)
+		if err != nil {
+			return 0, nil
+		}
+		return h.ActiveCalls, nil
+	}
+	remaining, err := service.Drain(ctx, canaryGrace, 2*time.Second, count, func(active int, left time.Duration) {
+		fmt.Printf("\r⏳ %d active call(s) on %s, %s left   ", active, name, left.Round(time.Second))
+	})
+	if err != nil {
+		return err
+	}
+	if remaining > 0 {
+		fmt.Println()
+		return fmt.Errorf("%d call(s) still active on %s after %s; raise --grace", remaining, name, canaryGrace)
+	}
+	return nil
+}
+
+// waitHealthy waits up to --timeout for an engine to report healthy
+func waitHealthy(ctx context.Context, baseURL, name string) error {
+	err := service.WaitFor(ctx, canaryHealthTimeout, 2*time.Second, func(ctx context.Context) error {
+		h, err := engineclient.New(baseURL, "").Health(ctx)
+		if err != nil {
+			return err
+		}
+		if h.Status != "healthy" {
+			return fmt.Errorf("status %s (ARI connected: %t)", h.Status, h.ARIConnected)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("%s not healthy after %s: %v", name, canaryHealthTimeout, err)
+	}
+	fmt.Printf("✅ %s is healthy\n", name)
+	return nil
+}
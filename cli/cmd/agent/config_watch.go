@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/config"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/dialplan"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var configWatchCmd = &cobra.Command{
+	Use:   "watch",
+	Short: "Validate configuration files as soon as they are edited",
+	Long: `Watch the engine configuration and Asterisk configuration fragments,
+validate each file the moment it changes, and warn when an invalid edit
+lands, instead of finding out at the next restart or reload:
+
+  config/ai-agent.yaml      full 'agent config validate' checks
+  config/contexts/*.yaml    YAML syntax
+  extensions*.conf          sections, #include targets, exten/same syntax,
+                            priority 1, parentheses, Stasis() app name
+  pjsip*.conf               sections, #include targets, object types
+
+Asterisk files are matched by --asterisk globs in --asterisk-dir. Every
+file is checked once at start. An edit that introduces errors sends a
+critical config_invalid notification to the channels of ~/.agent/config,
+one that only adds warnings a warning, and the edit that fixes a broken
+file an info one.
+
+Examples:
+  agent config watch
+  agent config watch --asterisk 'extensions_custom.conf' --asterisk 'pjsip_custom.conf'
+  agent config watch --file /opt/aava/config/ai-agent.yaml --no-notify`,
+	Args: cobra.NoArgs,
+	RunE: runConfigWatch,
+}
+
+var (
+	watchConfigFile  string
+	watchContexts    string
+	watchAsteriskDir string
+	watchAsterisk    []string
+	watchInterval    time.Duration
+	watchNoNotify    bool
+)
+
+func init() {
+	f := configWatchCmd.Flags()
+	f.StringVar(&watchConfigFile, "file", "config/ai-agent.yaml", "engine configuration file")
+	f.StringVar(&watchContexts, "contexts", "config/contexts", "directory of context YAML files")
+	f.StringVar(&watchAsteriskDir, "asterisk-dir", "/etc/asterisk", "Asterisk configuration directory")
+	f.StringSliceVar(&watchAsterisk, "asterisk", []string{"extensions*.conf", "pjsip*.conf"}, "Asterisk files to watch, globs in --asterisk-dir (repeatable)")
+	f.DurationVar(&watchInterval, "interval", 2*time.Second, "how often files are checked for changes")
+	f.BoolVar(&watchNoNotify, "no-notify", false, "only print, send no notifications")
+
+	configCmd.AddCommand(configWatchCmd)
+}
+
+// watchedFile is the last seen state of a watched file
+type watchedFile struct {
+	modTime  time.Time
+	size     int64
+	sum      [sha256.Size]byte
+	errors   []string
+	warnings []string
+}
+
+func runConfigWatch(cmd *cobra.Command, args []string) error {
+	if watchInterval <= 0 {
+		return fmt.Errorf("--interval must be positive")
+	}
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if watchNoNotify {
+		cfg.Notifications = nil
+	}
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	files := map[string]*watchedFile{}
+	paths := configWatchPaths()
+	fmt.Printf("👀 Watching %d file(s) (Ctrl-C to stop)\n", len(paths))
+	for _, path := range paths {
+		state, err := checkWatchedFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %s: %v\n", path, err)
+			continue
+		}
+		files[path] = state
+		printWatchResult(time.Now().In(loc), path, state, false)
+		if len(state.errors) > 0 {
+			notifyConfig(ctx, notifier, path, nil, state)
+		}
+	}
+
+	ticker := time.NewTicker(watchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		seen := map[string]bool{}
+		for _, path := range configWatchPaths() {
+			info, err := os.Stat(path)
+			if err != nil {
+				continue
+			}
+			seen[path] = true
+			previous := files[path]
+			if previous != nil && info.ModTime().Equal(previous.modTime) && info.Size() == previous.size {
+				continue
+			}
+			state, err := checkWatchedFile(path)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  %s: %v\n", path, err)
+				continue
+			}
+			files[path] = state
+			// Touched or rewritten with the same content
+			if previous != nil && state.sum == previous.sum {
+				continue
+			}
+			printWatchResult(time.Now().In(loc), path, state, true)
+			notifyConfig(ctx, notifier, path, previous, state)
+		}
+		for path := range files {
+			if !seen[path] {
+				fmt.Printf("%s 🗑️  %s removed\n", time.Now().In(loc).Format("15:04:05"), path)
+				delete(files, path)
+			}
+		}
+	}
+}
+
+// configWatchPaths lists the files to watch; globs are expanded each
+// time so new fragments are picked up
+func configWatchPaths() []string {
+	paths := []string{watchConfigFile}
+	contexts, _ := filepath.Glob(filepath.Join(watchContexts, "*.yaml"))
+	paths = append(paths, contexts...)
+	var asterisk []string
+	for _, pattern := range watchAsterisk {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(watchAsteriskDir, pattern)
+		}
+		matches, _ := filepath.Glob(pattern)
+		asterisk = append(asterisk, matches...)
+	}
+	sort.Strings(asterisk)
+	for i, path := range asterisk {
+		if i == 0 || path != asterisk[i-1] {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// checkWatchedFile validates path with the checks for its kind. A file
+// that cannot be parsed is reported as an error, not returned as one.
+func checkWatchedFile(path string) (*watchedFile, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	state := &watchedFile{modTime: info.ModTime(), size: info.Size(), sum: sha256.Sum256(data)}
+
+	var result *config.ValidationResult
+	switch {
+	case path == watchConfigFile:
+		result, err = config.NewValidator(path).Validate()
+	case strings.HasSuffix(path, ".conf"):
+		// The project directory holds config/ai-agent.yaml
+		app := dialplan.DefaultExtensionOptions(filepath.Dir(filepath.Dir(watchConfigFile))).AppName
+		result, err = config.ValidateAsterisk(path, app)
+	default:
+		result, err = config.ValidateYAML(path)
+	}
+	if err != nil {
+		state.errors = []string{err.Error()}
+		return state, nil
+	}
+	state.errors, state.warnings = result.Errors, result.Warnings
+	return state, nil
+}
+
+func printWatchResult(at time.Time, path string, state *watchedFile, changed bool) {
+	verb := "checked"
+	if changed {
+		verb = "changed"
+	}
+	switch {
+	case len(state.errors) > 0:
+		fmt.Printf("%s ❌ %s %s: %d error(s), %d warning(s)\n", at.Format("15:04:05"), path, verb, len(state.errors), len(state.warnings))
+	case len(state.warnings) > 0:
+		fmt.Printf("%s ⚠️  %s %s: %d warning(s)\n", at.Format("15:04:05"), path, verb, len(state.warnings))
+	default:
+		fmt.Printf("%s ✅ %s %s: valid\n", at.Format("15:04:05"), path, verb)
+	}
+	for _, e := range state.errors {
+		fmt.Printf("   ❌ %s\n", e)
+	}
+	// At start warnings are only counted, so long-standing ones do not
+	// bury the errors
+	if changed || len(state.errors) > 0 {
+		for _, w := range state.warnings {
+			fmt.Printf("   ⚠️  %s\n", w)
+		}
+	}
+}
+
+// notifyConfig sends config_invalid when an edit introduced errors or
+// new warnings, and an info event when it fixed a broken file
+func notifyConfig(ctx context.Context, notifier *notify.Notifier, path string, previous, state *watchedFile) {
+	if previous == nil {
+		previous = &watchedFile{}
+	}
+	host, _ := os.Hostname()
+	ev := notify.Event{
+		Kind:   notify.EventConfigInvalid,
+		Fields: map[string]string{"Host": host, "File": path},
+		Time:   time.Now(),
+		Data:   map[string]interface{}{"file": path, "errors": state.errors, "warnings": state.warnings},
+	}
+	added := newMessages(previous.warnings, state.warnings)
+	switch {
+	case len(state.errors) > 0:
+		if len(newMessages(previous.errors, state.errors)) == 0 {
+			return
+		}
+		ev.Severity = notify.SeverityCritical
+		ev.Title = "Invalid configuration: " + path
+		ev.Text = strings.Join(state.errors, "\n")
+	case len(previous.errors) > 0:
+		ev.Severity = notify.SeverityInfo
+		ev.Title = "Configuration valid again: " + path
+		ev.Text = fmt.Sprintf("The %d error(s) are fixed.", len(previous.errors))
+	case len(added) > 0:
+		ev.Severity = notify.SeverityWarning
+		ev.Title = "Configuration warnings: " + path
+		ev.Text = strings.Join(added, "\n")
+	default:
+		return
+	}
+	if _, err := notifier.Notify(ctx, ev); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Notification failed: %v\n", err)
+	}
+}
+
+// newMessages returns the messages of current not in previous, ignoring
+// line numbers that shift when lines are added above
+func newMessages(previous, current []string) []string {
+	known := map[string]bool{}
+	for _, m := range previous {
+		known[stripLineNumber(m)] = true
+	}
+	var added []string
+	for _, m := range current {
+		if !known[stripLineNumber(m)] {
+			added = append(added, m)
+		}
+	}
+	return added
+}
+
+func stripLineNumber(message string) string {
+	if strings.HasPrefix(message, "line ") {
+		if i := strings.Index(message, ": "); i > 0 {
+			return message[i+2:]
+		}
+	}
+	return message
+}
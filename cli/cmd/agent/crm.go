@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/crm"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+// crmSyncOverlap is how far before the newest synced call a sync
+// starts, to pick up calls that were running during the last sync
+const crmSyncOverlap = time.Hour
+
+var crmCmd = &cobra.Command{
+	Use:   "crm",
+	Short: "Log call outcomes in HubSpot or Salesforce",
+}
+
+var crmSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Log analyzed calls as activities on the caller's CRM contact",
+	Long: `Log each call as a call activity on the contact whose phone number
+matches the caller, so sales and support see AI calls in the contact's
+timeline. Each activity carries:
+
+  summary       what the caller said first, the agent's actions and
+                how the call ended
+  intent        the tools the agent ran (transfer, email, ...)
+  disposition   completed, failed, abandoned or transferred
+  transcript    a link (transcript_url) and, with transcript: true,
+                the transcript text
+
+HubSpot gets a call engagement associated with the contact (a private
+app token with the contacts read and write scopes); Salesforce a
+completed call Task on the contact (an access token, or client_id and
+client_secret of a connected app with the client credentials flow).
+
+  crm:
+    - type: hubspot
+      token: pat-eu1-...
+      transcript_url: https://portal.example.com/calls/{call_id}
+      dids: ["+4930123450*"]      # only calls to these numbers
+    - type: salesforce
+      instance_url: https://acme.my.salesforce.com
+      client_id: ...
+      client_secret: ...
+      contexts: [support]         # only calls of these routes
+      tenants: [acme]
+
+dids, contexts and tenants select the routes whose calls a CRM gets
+(matched like tenants, see 'agent troubleshoot --help'); none logs every
+call. Calls without a caller number or matching contact are skipped.
+
+The first sync covers --since; later syncs continue from the newest
+synced call, kept with the calls already logged in ~/.agent/crm.json, so
+no call is logged twice. Run it on a schedule with --every.
+
+Examples:
+  agent crm sync --dry-run
+  agent crm sync --since 7d
+  agent crm sync --every 10m`,
+	Args: cobra.NoArgs,
+	RunE: runCRMSync,
+}
+
+var crmLookupCmd = &cobra.Command{
+	Use:   "lookup <number>",
+	Short: "Show which contact each CRM matches a caller number to",
+	Long: `Look up a caller number in every configured CRM, to check the
+credentials and how contacts' phone numbers are matched.
+
+Examples:
+  agent crm lookup +4930123456789`,
+	Args: cobra.ExactArgs(1),
+	RunE: runCRMLookup,
+}
+
+var (
+	crmSince     string
+	crmEvery     time.Duration
+	crmFull      bool
+	crmContainer string
+	crmNoCache   bool
+	crmTimeout   time.Duration
+)
+
+func init() {
+	f := crmSyncCmd.Flags()
+	f.StringVar(&crmSince, "since", "24h", "window of the first (or --full) sync")
+	f.DurationVar(&crmEvery, "every", 0, "keep running and sync at this interval (e.g. 10m)")
+	f.BoolVar(&crmFull, "full", false, "sync every call since --since again (calls already logged are skipped)")
+	f.StringVar(&crmContainer, "container", troubleshoot.DefaultContainer, "engine container to read logs from")
+	f.BoolVar(&crmNoCache, "no-cache", false, "collect every call's logs again instead of reusing cached data")
+	f.DurationVar(&crmTimeout, "timeout", 0, "abort one sync after this long (0 = no limit)")
+
+	addDryRunFlag(crmSyncCmd)
+
+	crmCmd.AddCommand(crmSyncCmd)
+	crmCmd.AddCommand(crmLookupCmd)
+	rootCmd.AddCommand(crmCmd)
+}
+
+// loadCRMs returns the configured CRM integrations
+func loadCRMs(cfg *settings.Settings) ([]*crm.Integration, error) {
+	integrations, err := crm.New(cfg.CRM)
+	if err != nil {
+		return nil, err
+	}
+	if len(integrations) == 0 {
+		return nil, fmt.Errorf("no crm configured in %s (see 'agent crm sync --help')", settings.Path())
+	}
+	return integrations, nil
+}
+
+func runCRMSync(cmd *cobra.Command, args []string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	integrations, err := loadCRMs(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	for {
+		if err := syncCRM(cmd, ctx, cfg, integrations); err != nil {
+			if crmEvery == 0 || ctx.Err() != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "❌ Sync failed: %v\n", err)
+		}
+		if crmEvery == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(crmEvery):
+		}
+	}
+}
+
+// syncCRM logs the calls since the last sync in every CRM whose routes
+// they belong to
+func syncCRM(cmd *cobra.Command, parent context.Context, cfg *settings.Settings, integrations []*crm.Integration) error {
+	ctx := parent
+	if crmTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, crmTimeout)
+		defer cancel()
+	}
+
+	state, err := crm.LoadState()
+	if err != nil {
+		return err
+	}
+	since := crmSince
+	if !state.Watermark.IsZero() && !crmFull {
+		since = state.Watermark.Add(-crmSyncOverlap).Format(time.RFC3339)
+	}
+	runner, err := newBatchRunner(cmd, ctx, cfg, crmContainer, crmNoCache, since, "", troubleshoot.CallFilter{})
+	if err != nil {
+		return err
+	}
+	outcomes, err := runner.CallOutcomes()
+	if err != nil {
+		return err
+	}
+
+	type counts struct{ logged, noContact, failed int }
+	results := make(map[string]*counts)
+	for _, in := range integrations {
+		results[in.Name()] = &counts{}
+	}
+	// The watermark stops at the first call a CRM failed on, so the next
+	// sync retries it
+	held := false
+	for _, o := range outcomes {
+		// Calls without an outcome yet may still be running; the next
+		// sync's overlap picks them up
+		if o.Disposition == "" && time.Since(o.Start) < crmSyncOverlap {
+			continue
+		}
+		for _, in := range integrations {
+			if !in.Logs(o) || state.Done(in.Name(), o.CallID) {
+				continue
+			}
+			c := results[in.Name()]
+			if dryRun {
+				planCall("%s: log %q on the contact of %s (%s)", in.Name(), in.Activity(o).Title, o.CallerNumber, o.CallID)
+				fmt.Printf("   %s\n", o.Summary)
+				c.logged++
+				continue
+			}
+			if err := logCRMCall(ctx, in, o); err == crm.ErrNoContact {
+				c.noContact++
+			} else if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				fmt.Fprintf(os.Stderr, "⚠️  %s: %s: %v\n", in.Name(), o.CallID, err)
+				c.failed++
+				held = true
+				continue
+			} else {
+				c.logged++
+			}
+			state.MarkDone(in.Name(), o.CallID)
+		}
+		if o.Start.After(state.Watermark) && !held && !dryRun {
+			state.Watermark = o.Start
+		}
+	}
+
+	for _, in := range integrations {
+		c := results[in.Name()]
+		verb := "Logged"
+		if dryRun {
+			verb = "Would log"
+		}
+		fmt.Fprintf(os.Stderr, "✅ %s %d call(s) in %s", verb, c.logged, in.Name())
+		if c.noContact > 0 {
+			fmt.Fprintf(os.Stderr, ", %d without a matching contact", c.noContact)
+		}
+		if c.failed > 0 {
+			fmt.Fprintf(os.Stderr, ", %d failed (retried next sync)", c.failed)
+		}
+		fmt.Fprintln(os.Stderr)
+	}
+	if dryRun {
+		dryRunDone()
+		return nil
+	}
+	return state.Save()
+}
+
+// logCRMCall logs one call on the caller's contact
+func logCRMCall(ctx context.Context, in *crm.Integration, o troubleshoot.CallOutcome) error {
+	if o.CallerNumber == "" {
+		return crm.ErrNoContact
+	}
+	contact, err := in.FindContact(ctx, o.CallerNumber)
+	if err != nil {
+		return err
+	}
+	_, err = in.LogCall(ctx, contact, in.Activity(o))
+	return err
+}
+
+func runCRMLookup(cmd *cobra.Command, args []string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	integrations, err := loadCRMs(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(time.Minute)
+	defer cancel()
+
+	failed := false
+	for _, in := range integrations {
+		contact, err := in.FindContact(ctx, args[0])
+		switch {
+		case err == crm.ErrNoContact:
+			fmt.Printf("➖ %s: no contact with %s\n", in.Name(), args[0])
+		case err != nil:
+			fmt.Printf("❌ %s: %v\n", in.Name(), err)
+			failed = true
+		default:
+			fmt.Printf("✅ %s: contact %s\n", in.Name(), contact)
+		}
+	}
+	if failed {
+		return fmt.Errorf("lookup failed")
+	}
+	return nil
+}
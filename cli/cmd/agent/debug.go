@@ -0,0 +1,310 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"github.com/spf13/cobra"
+)
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Capture engine debug logs for a time window",
+}
+
+var debugEnableCmd = &cobra.Command{
+	Use:   "enable",
+	Short: "Switch the engine to debug logging and bundle the logs afterwards",
+	Long: `Switch ai_engine to debug logging for a window, without a restart,
+and collect the engine logs of the window into a bundle when it ends.
+
+The level is changed through the engine's /log-level endpoint (local,
+or with HEALTH_API_TOKEN), on every scaled instance unless --container
+is set. The engine restores its previous level by itself when the
+window ends, even if this command is stopped. Keep the command running
+to get the bundle; Ctrl-C ends the window early and still bundles what
+was captured. 'agent debug collect' bundles the last window later.
+
+The bundle (debug-<start>.tar.gz) holds each container's log, with
+secrets redacted, and capture.json listing the calls seen. The window
+is recorded in the call index: troubleshoot points out calls made
+during it.
+
+Examples:
+  agent debug enable --for 15m
+  agent debug enable --for 5m --output /tmp/engine-debug.tar.gz
+  agent debug status
+  agent debug disable
+  agent debug collect`,
+	Args: cobra.NoArgs,
+	RunE: runDebugEnable,
+}
+
+var debugDisableCmd = &cobra.Command{
+	Use:   "disable",
+	Short: "Restore the engine's log level now",
+	Args:  cobra.NoArgs,
+	RunE:  runDebugDisable,
+}
+
+var debugStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show the engine's log level",
+	Args:  cobra.NoArgs,
+	RunE:  runDebugStatus,
+}
+
+var debugCollectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Bundle the engine logs of the last debug window",
+	Args:  cobra.NoArgs,
+	RunE:  runDebugCollect,
+}
+
+// debugComponent names engine windows in the call index
+const debugComponent = "engine"
+
+var (
+	debugFor       time.Duration
+	debugOutput    string
+	debugContainer string
+)
+
+func init() {
+	debugEnableCmd.Flags().DurationVar(&debugFor, "for", 15*time.Minute, "how long to log at debug level (max 4h)")
+	for _, c := range []*cobra.Command{debugEnableCmd, debugCollectCmd} {
+		c.Flags().StringVarP(&debugOutput, "output", "o", "", "bundle path (default ./debug-<start>.tar.gz)")
+	}
+	for _, c := range []*cobra.Command{debugEnableCmd, debugDisableCmd, debugStatusCmd, debugCollectCmd} {
+		c.Flags().StringVar(&debugContainer, "container", engine.ContainerName, "engine container")
+	}
+	addDryRunFlag(debugEnableCmd, debugDisableCmd)
+
+	debugCmd.AddCommand(debugEnableCmd, debugDisableCmd, debugStatusCmd, debugCollectCmd)
+	rootCmd.AddCommand(debugCmd)
+}
+
+// debugTarget is an engine container and its control API
+type debugTarget struct {
+	container string
+	api       *engineclient.Client
+}
+
+// debugTargets returns the scaled engine instances, or just --container
+// when it is set or the engine is not scaled
+func debugTargets(ctx context.Context, cmd *cobra.Command) []debugTarget {
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	token := engine.Token(env)
+	if !cmd.Flags().Changed("container") {
+		if found, err := engine.Instances(ctx); err == nil && len(found) > 1 {
+			var targets []debugTarget
+			for _, inst := range found {
+				targets = append(targets, debugTarget{container: inst.Container, api: engineclient.New(inst.HealthURL, token)})
+			}
+			return targets
+		}
+	}
+	return []debugTarget{{container: debugContainer, api: engine.Client(env)}}
+}
+
+func debugContainers(targets []debugTarget) []string {
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.container
+	}
+	return names
+}
+
+func runDebugEnable(cmd *cobra.Command, args []string) error {
+	if debugFor <= 0 || debugFor > logLevelMaxWindow {
+		return fmt.Errorf("--for must be between 1s and %s", logLevelMaxWindow)
+	}
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	targets := debugTargets(ctx, cmd)
+	start := time.Now()
+	if dryRun {
+		for _, t := range targets {
+			planCall("POST %s/log-level (%s for %s, %s)", t.api.BaseURL, logLevelDebug, debugFor, t.container)
+		}
+		fmt.Printf("📝 Would record a debug window until %s in the call index\n", start.Add(debugFor).Format("15:04:05"))
+		path := debugOutput
+		if path == "" {
+			path = "./debug-<start>.tar.gz"
+		}
+		fmt.Printf("📝 Would write the bundle to %s when the window ends\n", path)
+		dryRunDone()
+		return nil
+	}
+	for i, t := range targets {
+		if _, err := t.api.SetLogLevel(ctx, logLevelDebug, debugFor); err != nil {
+			resetEngineLogLevel(targets[:i])
+			return fmt.Errorf("%s: %w", t.container, err)
+		}
+	}
+	window := troubleshoot.LogWindow{
+		Component: debugComponent,
+		Level:     logLevelDebug,
+		Start:     start,
+		End:       start.Add(debugFor),
+	}
+	idx := troubleshoot.LoadCallIndex()
+	idx.AddLogWindow(window)
+	if err := idx.Save(); err != nil {
+		fmt.Printf("⚠️  Could not record the window in the call index: %v\n", err)
+	}
+
+	fmt.Printf("🐛 Engine debug logging on until %s (%s)\n", window.End.Format("15:04:05"), strings.Join(debugContainers(targets), ", "))
+	fmt.Println("   Reproduce the problem now. The logs are bundled when the window ends (Ctrl-C ends it now)")
+
+	timer := time.NewTimer(debugFor)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		fmt.Println()
+		resetEngineLogLevel(targets)
+		window.End = time.Now()
+		idx := troubleshoot.LoadCallIndex()
+		idx.CloseLogWindow(debugComponent, window.Start, window.End)
+		if err := idx.Save(); err != nil {
+			fmt.Printf("⚠️  Could not update the call index: %v\n", err)
+		}
+		fmt.Println("✅ Engine log level restored")
+	case <-timer.C:
+		fmt.Println("✅ Window ended; the engine restored its log level")
+	}
+	return writeDebugBundle(debugContainers(targets), window, loc, logLoc)
+}
+
+func runDebugDisable(cmd *cobra.Command, args []string) error {
+	ctx, cancel := runContext(30 * time.Second)
+	defer cancel()
+	targets := debugTargets(ctx, cmd)
+	if dryRun {
+		for _, t := range targets {
+			planCall("DELETE %s/log-level (%s)", t.api.BaseURL, t.container)
+		}
+		dryRunDone()
+		return nil
+	}
+	for _, t := range targets {
+		level, err := t.api.ResetLogLevel(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.container, err)
+		}
+		fmt.Printf("✅ %s logging at %s\n", t.container, level.Level)
+	}
+	idx := troubleshoot.LoadCallIndex()
+	if w := idx.LatestLogWindow(debugComponent); w != nil && time.Now().Before(w.End) {
+		idx.CloseLogWindow(debugComponent, w.Start, time.Now())
+		if err := idx.Save(); err != nil {
+			fmt.Printf("⚠️  Could not update the call index: %v\n", err)
+		}
+	}
+	return nil
+}
+
+func runDebugStatus(cmd *cobra.Command, args []string) error {
+	ctx, cancel := runContext(30 * time.Second)
+	defer cancel()
+	targets := debugTargets(ctx, cmd)
+	for _, t := range targets {
+		level, err := t.api.LogLevel(ctx)
+		if err != nil {
+			return fmt.Errorf("%s: %w", t.container, err)
+		}
+		if level.Raised() {
+			fmt.Printf("🐛 %s logging at %s until %s (then %s)\n", t.container, level.Level, level.Ends().Local().Format("15:04:05"), level.Default)
+		} else {
+			fmt.Printf("%s logging at %s\n", t.container, level.Level)
+		}
+	}
+	return nil
+}
+
+func runDebugCollect(cmd *cobra.Command, args []string) error {
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	w := troubleshoot.LoadCallIndex().LatestLogWindow(debugComponent)
+	if w == nil {
+		return fmt.Errorf("no engine debug window recorded (start one with 'agent debug enable')")
+	}
+	window := *w
+	if time.Now().Before(window.End) {
+		fmt.Printf("⚠️  The window runs until %s; collecting what was logged so far\n", window.End.Format("15:04:05"))
+		window.End = time.Now()
+	}
+	ctx, cancel := runContext(30 * time.Second)
+	targets := debugTargets(ctx, cmd)
+	cancel()
+	return writeDebugBundle(debugContainers(targets), window, loc, logLoc)
+}
+
+// resetEngineLogLevel restores the level on targets on a fresh context,
+// so it works after Ctrl-C
+func resetEngineLogLevel(targets []debugTarget) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, t := range targets {
+		if _, err := t.api.ResetLogLevel(ctx); err != nil {
+			fmt.Printf("⚠️  %s: could not restore the log level (it reverts by itself): %v\n", t.container, err)
+		}
+	}
+}
+
+// writeDebugBundle collects the containers' logs of window into a bundle
+func writeDebugBundle(containers []string, window troubleshoot.LogWindow, loc, logLoc *time.Location) error {
+	ctx, cancel := runContext(2 * time.Minute)
+	defer cancel()
+
+	fmt.Println("Collecting logs...")
+	capture, err := troubleshoot.CollectCapture(ctx, containers, window.Level, window.Start, window.End, logLoc)
+	if err != nil {
+		return fmt.Errorf("failed to collect logs: %w", err)
+	}
+	bundle, err := capture.Bundle()
+	if err != nil {
+		return err
+	}
+	path := debugOutput
+	if path == "" {
+		path = capture.Name() + ".tar.gz"
+	}
+	if err := os.WriteFile(path, bundle, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+
+	lines := 0
+	for _, n := range capture.Lines {
+		lines += n
+	}
+	fmt.Printf("📦 Wrote %s (%d log line(s), %d call(s))\n", path, lines, len(capture.Calls))
+	for _, call := range capture.Calls {
+		status := call.Status
+		if status == "" {
+			status = "in progress"
+		}
+		fmt.Printf("   %s  %s  %s\n", call.ID, call.Timestamp.In(loc).Format("15:04:05"), status)
+	}
+	if len(capture.Calls) > 0 {
+		fmt.Println("   Analyze one with 'agent troubleshoot --call <id>'")
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+	"github.com/spf13/cobra"
+)
+
+var deployCmd = &cobra.Command{
+	Use:   "deploy",
+	Short: "Generate deployments for other platforms",
+	Long: `Generate deployment files for the stack from the project's
+configuration (config/ai-agent.yaml and .env).`,
+}
+
+var deployK8sCmd = &cobra.Command{
+	Use:   "k8s",
+	Short: "Render Kubernetes manifests or a Helm chart",
+	Long: `Render Kubernetes manifests (or a Helm chart with --helm) for the
+engine, plus local_ai_server when the active pipeline or default
+provider uses local models, and optionally Asterisk.
+
+Generated from the profile:
+  - ConfigMap with ai-agent.yaml (pods restart when it changes)
+  - ConfigMap with the other .env settings
+  - Secret with the provider keys and passwords from .env
+  - startup/liveness probes on /live and readiness on /ready (health port)
+  - resource requests and limits sized for --concurrency calls
+
+Pods use the host network like docker-compose.yml, so Asterisk reaches
+AudioSocket and RTP on 127.0.0.1; local_ai_server and Asterisk are
+scheduled onto the engine's node. Images are the locally built ones by
+default - push them to a registry and pass --image/--local-ai-image.
+
+The output holds provider keys: keep it out of version control.
+--dry-run prints the diff against the files already in --output
+(without the content of the files holding keys) and writes nothing.
+
+Examples:
+  agent deploy k8s --concurrency 20
+  agent deploy k8s --concurrency 40 --dry-run
+  agent deploy k8s --helm --output charts/aava
+  agent deploy k8s --asterisk --namespace voice --image registry.example.com/aava/ai-engine:4.1.0`,
+	Args: cobra.NoArgs,
+	RunE: runDeployK8s,
+}
+
+var (
+	deployDir     string
+	deployOutput  string
+	deployHelm    bool
+	deployOptions = deploy.DefaultK8sOptions()
+)
+
+func init() {
+	f := deployK8sCmd.Flags()
+	f.StringVar(&deployDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	f.StringVarP(&deployOutput, "output", "o", "k8s", "output directory")
+	f.BoolVar(&deployHelm, "helm", false, "render a Helm chart instead of plain manifests")
+	f.StringVar(&deployOptions.Name, "name", deployOptions.Name, "resource name prefix (plain manifests)")
+	f.StringVarP(&deployOptions.Namespace, "namespace", "n", deployOptions.Namespace, "namespace (plain manifests)")
+	f.IntVar(&deployOptions.Concurrency, "concurrency", deployOptions.Concurrency, "expected concurrent calls, for resource sizing")
+	f.StringVar(&deployOptions.EngineImage, "image", deployOptions.EngineImage, "ai-engine image")
+	f.StringVar(&deployOptions.LocalAIImage, "local-ai-image", deployOptions.LocalAIImage, "local_ai_server image")
+	f.BoolVar(&deployOptions.Asterisk, "asterisk", false, "also deploy Asterisk")
+	f.StringVar(&deployOptions.AsteriskImage, "asterisk-image", deployOptions.AsteriskImage, "Asterisk image")
+	f.StringVar(&deployOptions.AsteriskConfigPath, "asterisk-config", deployOptions.AsteriskConfigPath, "node directory with the Asterisk configuration")
+	f.StringVar(&deployOptions.MediaHostPath, "media-path", deployOptions.MediaHostPath, "node directory Asterisk plays generated audio from")
+	f.StringVar(&deployOptions.StorageClass, "storage-class", "", "storage class for the data and models volumes")
+
+	addDryRunFlag(deployK8sCmd)
+
+	deployCmd.AddCommand(deployK8sCmd)
+	rootCmd.AddCommand(deployCmd)
+}
+
+func runDeployK8s(cmd *cobra.Command, args []string) error {
+	if deployOptions.Concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+	profile, err := deploy.LoadProfile(deployDir)
+	if err != nil {
+		return err
+	}
+
+	var files map[string]string
+	if deployHelm {
+		files, err = deploy.RenderChart(profile, deployOptions)
+	} else {
+		files, err = deploy.RenderManifests(profile, deployOptions)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to render: %w", err)
+	}
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if dryRun {
+		for _, name := range names {
+			path := filepath.Join(deployOutput, filepath.FromSlash(name))
+			plan := planFile
+			if deploy.HoldsSecrets(name) {
+				plan = planSecretFile
+			}
+			if err := plan(path, []byte(files[name])); err != nil {
+				return err
+			}
+		}
+		dryRunDone()
+		return nil
+	}
+	if err := deploy.WriteFiles(deployOutput, files); err != nil {
+		return fmt.Errorf("failed to write %s: %w", deployOutput, err)
+	}
+	for _, name := range names {
+		fmt.Printf("✅ Wrote %s\n", filepath.Join(deployOutput, filepath.FromSlash(name)))
+	}
+	fmt.Println()
+
+	fmt.Println("Profile:")
+	fmt.Printf("  Providers:   %s\n", strings.Join(profile.Providers, ", "))
+	if profile.LocalAI {
+		fmt.Println("  Local AI:    yes (local_ai_server included)")
+	} else {
+		fmt.Println("  Local AI:    no")
+	}
+	fmt.Printf("  Secrets:     %d key(s) from .env\n", len(profile.Secrets))
+	r := deploy.EngineResources(deployOptions.Concurrency)
+	fmt.Printf("  ai-engine:   %s CPU, %s memory requested for %d calls\n", r.CPURequest, r.MemoryRequest, deployOptions.Concurrency)
+	if profile.LocalAI {
+		r = deploy.LocalAIResources(deployOptions.Concurrency)
+		fmt.Printf("  local AI:    %s CPU, %s memory requested\n", r.CPURequest, r.MemoryRequest)
+	}
+	if len(profile.Secrets) == 0 {
+		fmt.Println("⚠️  No provider keys found in .env; the Secret is empty")
+	}
+	fmt.Println()
+
+	fmt.Println("Apply:")
+	if deployHelm {
+		fmt.Printf("  helm install aava %s --namespace %s --create-namespace\n", deployOutput, deployOptions.Namespace)
+	} else {
+		fmt.Printf("  kubectl apply -f %s\n", deployOutput)
+	}
+	if profile.LocalAI {
+		fmt.Println("  then copy ./models into the models volume; local_ai_server waits for them")
+	}
+	return nil
+}
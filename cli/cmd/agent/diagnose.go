@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var diagnoseCmd = &cobra.Command{
+	Use:   "diagnose",
+	Short: "Diagnose components of the stack",
+}
+
+var diagnoseEngineCmd = &cobra.Command{
+	Use:   "engine",
+	Short: "Explain why ai_engine restarted or keeps crashing",
+	Long: `Inspect the last restarts of the engine container: the docker events
+(start, kill, oom, die), each exit code and the last lines logged before
+each exit, and classify why it stopped:
+
+  oom                     killed for exceeding its memory limit
+  panic                   an unhandled exception, traceback or segfault
+  config_error            the configuration failed to load or validate
+  dependency_unreachable  Asterisk, a provider or DNS could not be reached
+  stopped                 stopped through docker (restart, compose up)
+  unknown                 none of the above; read the last lines
+
+Three exits within 10 minutes, or docker restarting the container right
+now, is reported as a crash loop. Each exit comes with targeted next
+steps. The daemon keeps a limited event history; when it has rotated
+out, the last exit is taken from the container's state.
+
+Examples:
+  agent diagnose engine
+  agent diagnose engine --last 10 --since 7d
+  agent diagnose engine --lines 40 --format json`,
+	Args: cobra.NoArgs,
+	RunE: runDiagnoseEngine,
+}
+
+var (
+	diagnoseContainer string
+	diagnoseLast      int
+	diagnoseSince     string
+	diagnoseLines     int
+	diagnoseFormat    string
+)
+
+func init() {
+	f := diagnoseEngineCmd.Flags()
+	f.StringVar(&diagnoseContainer, "container", engine.ContainerName, "engine container")
+	f.IntVarP(&diagnoseLast, "last", "n", 5, "how many of the most recent exits to show")
+	f.StringVar(&diagnoseSince, "since", "24h", "how far back to look, e.g. 24h or 7d")
+	f.IntVar(&diagnoseLines, "lines", 15, "log lines shown before each exit")
+	f.StringVar(&diagnoseFormat, "format", "text", "output format: text|json")
+
+	diagnoseCmd.AddCommand(diagnoseEngineCmd)
+	rootCmd.AddCommand(diagnoseCmd)
+}
+
+func runDiagnoseEngine(cmd *cobra.Command, args []string) error {
+	if diagnoseFormat != "text" && diagnoseFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", diagnoseFormat)
+	}
+	age, err := settings.ParseAge(diagnoseSince)
+	if err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	client, err := docker.Default()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(time.Minute)
+	defer cancel()
+
+	history, err := engine.Restarts(ctx, client, diagnoseContainer, time.Now().Add(-age), diagnoseLast, diagnoseLines)
+	if err != nil {
+		return err
+	}
+
+	if diagnoseFormat == "json" {
+		out, err := json.MarshalIndent(history, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	printRestartHistory(history, loc)
+	return nil
+}
+
+// causeLabels are the headings of the crash causes
+var causeLabels = map[string]string{
+	engine.CauseOOM:        "Out of memory",
+	engine.CausePanic:      "Crash (unhandled exception)",
+	engine.CauseConfig:     "Configuration error",
+	engine.CauseDependency: "Dependency unreachable",
+	engine.CauseStopped:    "Stopped through docker",
+	engine.CauseUnknown:    "Unknown cause",
+}
+
+func printRestartHistory(h *engine.RestartHistory, loc *time.Location) {
+	fmt.Printf("🩺 %s: %s", h.Container, h.Status)
+	if h.Status == "running" && !h.StartedAt.IsZero() {
+		fmt.Printf(" since %s (up %s)", h.StartedAt.In(loc).Format("2006-01-02 15:04:05 MST"), time.Since(h.StartedAt).Round(time.Second))
+	}
+	fmt.Printf(", %d restart(s) by its restart policy\n", h.RestartCount)
+	if h.DaemonError != "" {
+		fmt.Printf("   ❌ Docker could not start it: %s\n", h.DaemonError)
+	}
+	if h.CrashLoop {
+		fmt.Println("   ❌ Crash loop: the engine keeps exiting shortly after starting")
+	}
+	fmt.Println()
+
+	if len(h.Exits) == 0 {
+		fmt.Printf("✅ No exits since %s\n", diagnoseSince)
+		return
+	}
+	crashes := 0
+	for _, e := range h.Exits {
+		if e.Cause != engine.CauseStopped {
+			crashes++
+		}
+	}
+	fmt.Printf("Last %d exit(s), %d not requested through docker:\n\n", len(h.Exits), crashes)
+
+	shown := false
+	for i, e := range h.Exits {
+		icon := "❌"
+		if e.Cause == engine.CauseStopped {
+			icon = "⏹️ "
+		}
+		code := fmt.Sprintf("exit %d", e.ExitCode)
+		if e.Signal != "" {
+			code += " (" + e.Signal + ")"
+		}
+		fmt.Printf("%d. %s %s — %s, %s\n", i+1, icon, e.Time.In(loc).Format("2006-01-02 15:04:05"), causeLabels[e.Cause], code)
+		if e.Evidence != "" {
+			fmt.Printf("   %s\n", e.Evidence)
+		}
+		// The log only for the latest crash; earlier ones usually repeat it
+		if !shown && e.Cause != engine.CauseStopped && len(e.LastLines) > 0 {
+			shown = true
+			fmt.Println("   Last lines:")
+			for _, line := range e.LastLines {
+				fmt.Printf("   │ %s\n", line)
+			}
+		}
+		for _, step := range e.NextSteps {
+			fmt.Printf("   → %s\n", step)
+		}
+		fmt.Println()
+	}
+}
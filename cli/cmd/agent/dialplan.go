@@ -16,7 +16,8 @@ var dialplanCmd = &cobra.Command{
 	Long: `Generate Asterisk dialplan snippets for the chosen provider.
 
 This command prints the dialplan configuration that you need to add
-to your Asterisk extensions_custom.conf file.`,
+to your Asterisk extensions_custom.conf file. To generate, install and
+reload an extension in one step, use 'agent dialplan generate'.`,
 	RunE: runDialplan,
 }
 
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/dialplan"
+	"github.com/spf13/cobra"
+)
+
+var dialplanGenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generate the dialplan for an extension and optionally install it",
+	Long: `Generate an extensions.conf context that sends an extension to the agent.
+
+Both transports enter the engine through Stasis(); the engine then
+creates the media leg itself:
+  audiosocket    an AudioSocket channel back to the engine (port 8090),
+                 audio in audiosocket.format
+  externalmedia  an ARI externalMedia RTP leg in external_media.codec
+
+The format and rate of that leg are passed as AI_TRANSPORT_FORMAT and
+AI_TRANSPORT_RATE. The transport itself is engine-wide (audio_transport
+in config/ai-agent.yaml); generate warns when --transport differs.
+
+The block is marked with BEGIN/END comments, so generating the same
+extension again replaces it instead of adding a duplicate.
+
+--write puts it into --file (on the host, or inside --container) after
+saving a copy as <file>.aava-backup-<time>. --reload then runs
+'dialplan reload' over AMI (ASTERISK_AMI_USERNAME/ASTERISK_AMI_PASSWORD
+in .env, port ASTERISK_AMI_PORT or 5038), falling back to asterisk -rx.
+With --dry-run they print the diff and the reload instead.
+
+Examples:
+  agent dialplan generate --extension 7000 --transport audiosocket
+  agent dialplan generate --extension 7001 --transport externalmedia --provider deepgram --ai-context sales
+  sudo agent dialplan generate --extension 7000 --write --reload
+  agent dialplan generate --extension 7000 --write --reload --dry-run
+  agent dialplan generate --extension 7000 --write --container asterisk --reload`,
+	Args: cobra.NoArgs,
+	RunE: runDialplanGenerate,
+}
+
+var (
+	dpGenExtension string
+	dpGenTransport string
+	dpGenContext   string
+	dpGenProvider  string
+	dpGenAIContext string
+	dpGenApp       string
+	dpGenDir       string
+	dpGenWrite     bool
+	dpGenFile      string
+	dpGenContainer string
+	dpGenReload    bool
+)
+
+func init() {
+	f := dialplanGenerateCmd.Flags()
+	f.StringVar(&dpGenExtension, "extension", "", "extension number that reaches the agent (required)")
+	f.StringVar(&dpGenTransport, "transport", "", "audiosocket or externalmedia (default: audio_transport from the config)")
+	f.StringVar(&dpGenContext, "context", "from-internal-custom", "dialplan context to put the extension in")
+	f.StringVar(&dpGenProvider, "provider", "", "set AI_PROVIDER for calls to this extension")
+	f.StringVar(&dpGenAIContext, "ai-context", "", "set AI_CONTEXT (persona/prompt context) for calls to this extension")
+	f.StringVar(&dpGenApp, "app", "", "Stasis application (default: asterisk.app_name from the config)")
+	f.StringVar(&dpGenDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	f.BoolVar(&dpGenWrite, "write", false, "write the block into --file, keeping a backup")
+	f.StringVar(&dpGenFile, "file", dialplan.DefaultTarget, "dialplan file to write")
+	f.StringVar(&dpGenContainer, "container", "", "write --file inside this Asterisk container (docker exec)")
+	f.BoolVar(&dpGenReload, "reload", false, "reload the dialplan after writing")
+	dialplanGenerateCmd.MarkFlagRequired("extension")
+	addDryRunFlag(dialplanGenerateCmd)
+
+	dialplanCmd.AddCommand(dialplanGenerateCmd)
+}
+
+func runDialplanGenerate(cmd *cobra.Command, args []string) error {
+	if strings.ContainsAny(dpGenExtension, " \t,;[]") {
+		return fmt.Errorf("invalid extension %q", dpGenExtension)
+	}
+	if dpGenReload && !dpGenWrite {
+		return fmt.Errorf("--reload needs --write")
+	}
+
+	opts := dialplan.DefaultExtensionOptions(dpGenDir)
+	opts.Extension = dpGenExtension
+	opts.Context = dpGenContext
+	opts.Provider = dpGenProvider
+	opts.AIContext = dpGenAIContext
+	if dpGenApp != "" {
+		opts.AppName = dpGenApp
+	}
+	transport := dpGenTransport
+	if transport == "" {
+		transport = opts.ConfigTransport
+	}
+	if transport == "" {
+		transport = dialplan.TransportAudioSocket
+	}
+	if err := opts.SetTransport(strings.ToLower(transport)); err != nil {
+		return err
+	}
+	block := dialplan.GenerateExtension(opts)
+
+	if opts.ConfigTransport != "" && opts.ConfigTransport != opts.Transport {
+		fmt.Printf("⚠️  The engine uses audio_transport: %s; calls will use it, not %s.\n", opts.ConfigTransport, opts.Transport)
+		fmt.Printf("   Set audio_transport: %s in config/ai-agent.yaml to switch.\n\n", opts.Transport)
+	}
+
+	if !dpGenWrite {
+		fmt.Println(block)
+		fmt.Printf("Add it to %s, or rerun with --write --reload.\n", dpGenFile)
+		return nil
+	}
+
+	ctx, cancel := runContext(time.Minute)
+	defer cancel()
+
+	host := newAsteriskHost(dpGenContainer)
+	where := host.Where(dpGenFile)
+	existing, found, err := host.ReadFile(ctx, dpGenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", where, err)
+	}
+	merged := dialplan.Merge(string(existing), block, opts.Extension)
+	if dryRun {
+		if found && merged != string(existing) {
+			fmt.Printf("📦 Would back up %s to %s.aava-backup-<time>\n", where, where)
+		}
+		planWrite(where, existing, []byte(merged))
+		if dpGenReload {
+			planCall("dialplan reload (%s)", host.Via())
+		}
+		dryRunDone()
+		return nil
+	}
+	if merged == string(existing) {
+		fmt.Printf("✅ %s already has extension %s\n", where, opts.Extension)
+	} else {
+		backup, err := host.Install(ctx, dpGenFile, existing, []byte(merged), found)
+		if backup != "" {
+			fmt.Printf("📦 Backed up %s to %s\n", where, backup)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Wrote extension %s@%s to %s\n", opts.Extension, opts.Context, where)
+	}
+
+	if !dpGenReload {
+		fmt.Println("Reload: asterisk -rx 'dialplan reload'")
+		return nil
+	}
+	if _, err := host.Command(ctx, "dialplan reload"); err != nil {
+		return fmt.Errorf("dialplan reload failed: %w", err)
+	}
+	fmt.Printf("🔄 Reloaded the dialplan (%s)\n", host.Via())
+	fmt.Printf("   Check: asterisk -rx 'dialplan show %s@%s'\n", opts.Extension, opts.Context)
+	return nil
+}
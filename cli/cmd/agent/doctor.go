@@ -3,15 +3,23 @@ package main
 import (
 	"fmt"
 	"os"
+	"time"
 
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/hardware"
 	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/offline"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
 	"github.com/spf13/cobra"
 )
 
 var (
-	doctorFix    bool
-	doctorJSON   bool
-	doctorFormat string
+	doctorFix      bool
+	doctorJSON     bool
+	doctorFormat   string
+	doctorNoNotify bool
+	doctorProfile  string
+	doctorOffline  bool
 )
 
 var doctorCmd = &cobra.Command{
@@ -21,19 +29,79 @@ var doctorCmd = &cobra.Command{
 
 Checks include:
   - Docker containers and services
+  - CLI/engine version compatibility
   - Asterisk ARI connectivity
+  - Stasis app registration (credentials, read-only users, engine connected)
+  - Media encryption: live calls on SRTP endpoints that fell back to
+    cleartext RTP, and endpoints that allow the fallback
   - AudioSocket availability
   - Configuration validation
   - Provider API keys and connectivity
   - Audio pipeline status
   - Recent call history
+  - Hardware: CPU and memory against the configured providers
+  - HA pair (with ha: in ~/.agent/config): the floating IP on exactly
+    one node running Asterisk and the engine, state replication to the
+    standby (DRBD, synced paths), config parity between the nodes
+  - SBC interop (with sbc: in ~/.agent/config): topology hiding,
+    ptime, transcoding and RTP timeouts on the trunks behind a
+    Kamailio, OpenSIPS or AudioCodes SBC (see 'agent sip sbc')
+
+The version check compares this CLI with the running ai_engine (from
+/health, else the image version label) and names the upgrade command
+when they are incompatible. Other commands warn about the same mismatch
+(cached for an hour; AGENT_SKIP_VERSION_CHECK=1 disables it).
+
+Failed checks raise doctor_check_failed events on the notification
+channels in ~/.agent/config (see 'agent notify'); --no-notify skips them.
+--dry-run lists the issues --fix would attempt and the notifications
+that would be sent, without fixing or sending anything.
+
+Low-power ARM hosts (Raspberry Pi, or ARM with 4 or fewer CPUs or 8 GB
+or less) get the arm profile automatically; --profile arm applies it on
+any host, e.g. to size a Pi deployment from a workstation. It fails
+local models the CPU cannot run in real time (faster-whisper medium and
+large, local_ai_server without the memory for one call), warns about
+slow ones and suggests the cloud presets in config/, and estimates the
+concurrent calls the host can carry. It also recommends deeper jitter
+buffering (streaming.jitter_buffer_ms, low_watermark_ms) and fewer LLM
+threads and context (LOCAL_LLM_THREADS, LOCAL_LLM_CONTEXT); --fix
+writes them to config/ai-agent.yaml and .env.
+
+--offline validates an air-gapped deployment and fails anything that
+would need the internet: providers in use that are not local, cloud
+URLs in the active config (unused providers and enabled MCP servers
+warn), local_ai_server models missing from ./models or downloaded at
+runtime (faster-whisper by name, Kroko cloud, Kokoro API), and
+troubleshoot LLM analysis that would send call logs to a cloud LLM.
+Provider API keys are not expected.
 
 Exit codes:
   0 - All checks passed
   1 - Warnings detected (non-critical)
-  2 - Failures detected (critical)`,
+  2 - Failures detected (critical)
+
+Examples:
+  agent doctor
+  agent doctor --profile arm
+  agent doctor --offline
+  agent doctor --profile arm --fix --dry-run`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		checker := health.NewChecker(verbose)
+		checker.SetCLIVersion(version)
+		if doctorProfile != hardware.ProfileAuto && doctorProfile != hardware.ProfileARM {
+			return fmt.Errorf("invalid --profile %q (use auto or arm)", doctorProfile)
+		}
+		checker.AddCheck(func() health.Check { return hardware.Check(".", doctorProfile) })
+		addHAChecks(checker)
+		addSBCChecks(checker)
+		if doctorOffline {
+			checker.SetOffline(true)
+			for _, check := range offline.Validate(".") {
+				check := check
+				checker.AddCheck(func() health.Check { return check })
+			}
+		}
 		
 		// Run health checks
 		result, err := checker.RunAll()
@@ -56,8 +124,14 @@ Exit codes:
 			fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
 			fmt.Println("")
 			
-			fixed, err := checker.AutoFix(result)
-			if err != nil {
+			if dryRun {
+				fmt.Println("Would attempt to fix:")
+				for _, check := range result.Checks {
+					if check.Status == health.StatusFail || check.Status == health.StatusWarn {
+						fmt.Printf("  - %s: %s\n", check.Name, check.Message)
+					}
+				}
+			} else if fixed, err := autoFix(checker, result); err != nil {
 				fmt.Printf("❌ Auto-fix failed: %v\n", err)
 			} else if fixed > 0 {
 				fmt.Printf("✓ Fixed %d issue(s)\n", fixed)
@@ -77,11 +151,18 @@ Exit codes:
 			}
 		}
 		
+		if !doctorNoNotify {
+			notifyDoctorFailures(result)
+		}
+		if dryRun {
+			dryRunDone()
+		}
+		
 		// Exit with appropriate code
 		if result.CriticalCount > 0 {
-			os.Exit(2)
+			return &exitCodeError{2}
 		} else if result.WarnCount > 0 {
-			os.Exit(1)
+			return &exitCodeError{1}
 		}
 		
 		return nil
@@ -92,6 +173,73 @@ func init() {
 	doctorCmd.Flags().BoolVar(&doctorFix, "fix", false, "attempt to auto-fix issues")
 	doctorCmd.Flags().BoolVar(&doctorJSON, "json", false, "output results as JSON")
 	doctorCmd.Flags().StringVar(&doctorFormat, "format", "text", "output format: text|json|markdown")
+	doctorCmd.Flags().BoolVar(&doctorNoNotify, "no-notify", false, "do not send doctor_check_failed notifications")
+	doctorCmd.Flags().BoolVar(&doctorOffline, "offline", false, "validate an air-gapped deployment (everything local)")
+	doctorCmd.Flags().StringVar(&doctorProfile, "profile", hardware.ProfileAuto, "hardware profile: auto|arm")
+	addDryRunFlag(doctorCmd)
 	
 	rootCmd.AddCommand(doctorCmd)
 }
+
+// autoFix applies the hardware tuning, then the checker's fixes
+func autoFix(checker *health.Checker, result *health.HealthResult) (int, error) {
+	tuned, err := hardware.Fix(".", doctorProfile)
+	if err != nil {
+		return 0, err
+	}
+	for _, s := range tuned {
+		fmt.Printf("✓ Set %s\n", s)
+	}
+	if len(tuned) > 0 {
+		fmt.Println("   Recreate the containers to apply: docker compose up -d")
+	}
+	fixed, err := checker.AutoFix(result)
+	return fixed + len(tuned), err
+}
+
+// notifyDoctorFailures sends one doctor_check_failed event per failed check
+func notifyDoctorFailures(result *health.HealthResult) {
+	if result.CriticalCount == 0 {
+		return
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Notifications skipped: %v\n", err)
+		return
+	}
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil || notifier == nil {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Notifications skipped: %v\n", err)
+		}
+		return
+	}
+
+	ctx, cancel := runContext(30 * time.Second)
+	defer cancel()
+	host, _ := os.Hostname()
+	for _, check := range result.Checks {
+		if check.Status != health.StatusFail {
+			continue
+		}
+		ev := notify.Event{
+			Kind:     notify.EventDoctorFailed,
+			Severity: notify.SeverityCritical,
+			Title:    "Health check failed: " + check.Name,
+			Text:     check.Message,
+			Fields:   map[string]string{"Host": host},
+			Time:     result.Timestamp,
+			Data:     check,
+		}
+		if check.Remediation != "" {
+			ev.Text += "\n\nRemediation: " + check.Remediation
+		}
+		if dryRun {
+			planCall("notify %s: %s", ev.Kind, ev.Title)
+			continue
+		}
+		if _, err := notifier.Notify(ctx, ev); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Notification failed: %v\n", err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/service"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"github.com/spf13/cobra"
+)
+
+var drainCmd = &cobra.Command{
+	Use:   "drain",
+	Short: "Stop taking new calls and wait for active calls to finish",
+	Long: `Put the engine into drain mode before maintenance.
+
+While draining, the engine starts no new AudioSocket sessions: new
+callers are hung up with congestion, or continued to --fallback
+(context[,extension[,priority]]) so they hear an announcement instead.
+Active calls are unaffected. The command counts down until they have
+all finished and then reports that maintenance is safe.
+
+Drain mode lasts until 'agent drain --resume' or an engine restart.
+
+Fallback announcement, e.g. in extensions_custom.conf:
+  [ai-maintenance]
+  exten => s,1,Answer()
+   same => n,Playback(ss-noservice)
+   same => n,Hangup()
+
+Examples:
+  agent drain
+  agent drain --fallback ai-maintenance --grace 15m
+  agent drain --status
+  agent drain --resume`,
+	Args: cobra.NoArgs,
+	RunE: runDrain,
+}
+
+var (
+	drainFallback string
+	drainGrace    time.Duration
+	drainResume   bool
+	drainStatus   bool
+	drainNoWait   bool
+)
+
+func init() {
+	drainCmd.Flags().StringVar(&drainFallback, "fallback", "", "send new callers to this dialplan target (context[,extension[,priority]])")
+	drainCmd.Flags().DurationVar(&drainGrace, "grace", 30*time.Minute, "how long to wait for active calls to finish")
+	drainCmd.Flags().BoolVar(&drainResume, "resume", false, "leave drain mode and accept new calls again")
+	drainCmd.Flags().BoolVar(&drainStatus, "status", false, "show the drain state without changing it")
+	drainCmd.Flags().BoolVar(&drainNoWait, "no-wait", false, "enter drain mode and return without waiting")
+
+	addDryRunFlag(drainCmd)
+
+	rootCmd.AddCommand(drainCmd)
+}
+
+func runDrain(cmd *cobra.Command, args []string) error {
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	client := engine.Client(env)
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	switch {
+	case drainStatus:
+		st, err := client.DrainStatus(ctx)
+		if err != nil {
+			return err
+		}
+		printDrainStatus(st)
+		return nil
+	case drainResume && dryRun:
+		planCall("DELETE %s/drain", client.BaseURL)
+		dryRunDone()
+		return nil
+	case drainResume:
+		st, err := client.Resume(ctx)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Drain mode off; accepting new calls (%d active)\n", st.ActiveCalls)
+		return nil
+	}
+
+	var fallback *engineclient.Fallback
+	if drainFallback != "" {
+		if fallback, err = engineclient.ParseFallback(drainFallback); err != nil {
+			return err
+		}
+	}
+	if dryRun {
+		if fallback != nil {
+			planCall("POST %s/drain (new calls go to %s)", client.BaseURL, fallback)
+		} else {
+			planCall("POST %s/drain (new calls are refused)", client.BaseURL)
+		}
+		dryRunDone()
+		return nil
+	}
+	st, err := client.Drain(ctx, fallback)
+	if err != nil {
+		return err
+	}
+	if fallback != nil {
+		fmt.Printf("🚰 Draining: new calls go to %s\n", fallback)
+	} else {
+		fmt.Println("🚰 Draining: new calls are refused")
+	}
+	if drainNoWait {
+		fmt.Printf("   %d active call(s); check with 'agent drain --status'\n", st.ActiveCalls)
+		return nil
+	}
+
+	started := time.Now()
+	remaining, err := service.Drain(ctx, drainGrace, time.Second, func(ctx context.Context) (int, error) {
+		h, err := client.Health(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return h.ActiveCalls, nil
+	}, func(active int, left time.Duration) {
+		fmt.Printf("\r⏳ %d active call(s), %s left   ", active, left.Round(time.Second))
+	})
+	fmt.Println()
+	if err != nil {
+		return fmt.Errorf("lost contact with the engine while draining: %w", err)
+	}
+	if remaining > 0 {
+		fmt.Printf("⚠️  %d call(s) still active after %s; the engine is still draining\n", remaining, drainGrace)
+		fmt.Println("   Wait longer with 'agent drain', or resume with 'agent drain --resume'")
+		return fmt.Errorf("calls still active")
+	}
+	fmt.Printf("✅ All calls finished after %s - safe to do maintenance\n", time.Since(started).Round(time.Second))
+	fmt.Println("   Resume with 'agent drain --resume' (an engine restart also ends drain mode)")
+	return nil
+}
+
+func printDrainStatus(st *engineclient.DrainStatus) {
+	if !st.Draining {
+		fmt.Printf("Accepting calls (%d active)\n", st.ActiveCalls)
+		return
+	}
+	fmt.Printf("🚰 Draining since %s (%s)\n", st.Started().Local().Format("15:04:05"), time.Since(st.Started()).Round(time.Second))
+	if st.Fallback != nil {
+		fmt.Printf("   New calls go to %s\n", st.Fallback)
+	} else {
+		fmt.Println("   New calls are refused")
+	}
+	fmt.Printf("   %d active call(s)\n", st.ActiveCalls)
+}
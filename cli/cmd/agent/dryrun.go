@@ -0,0 +1,111 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/dryrun"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+// dryRun is set by --dry-run on the commands that write files, run
+// commands or call APIs; they then print each change instead of making it
+var dryRun bool
+
+var (
+	diffAddColor    = theme.Success
+	diffRemoveColor = theme.Error
+	diffHunkColor   = theme.Accent
+)
+
+func addDryRunFlag(cmds ...*cobra.Command) {
+	for _, c := range cmds {
+		c.Flags().BoolVar(&dryRun, "dry-run", false, "print what would change (file diffs, commands, API calls) without changing anything")
+	}
+}
+
+// planWrite prints the diff writing data to path would make; nil data
+// means the file would be removed
+func planWrite(path string, existing, data []byte) {
+	diff := dryrun.Diff(path, existing, data)
+	switch {
+	case diff == "":
+		fmt.Printf("✅ %s: no change\n", path)
+		return
+	case existing == nil:
+		fmt.Printf("📝 Would create %s\n", path)
+	case data == nil:
+		fmt.Printf("🗑  Would remove %s\n", path)
+	default:
+		fmt.Printf("📝 Would update %s\n", path)
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			fmt.Print(line)
+		case strings.HasPrefix(line, "+"):
+			diffAddColor.Print(line)
+		case strings.HasPrefix(line, "-"):
+			diffRemoveColor.Print(line)
+		case strings.HasPrefix(line, "@@"):
+			diffHunkColor.Print(line)
+		default:
+			fmt.Print(line)
+		}
+	}
+}
+
+// planFile is planWrite against the current content of a local file
+func planFile(path string, data []byte) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	planWrite(path, existing, data)
+	return nil
+}
+
+// planSecretFile reports a change to a file holding keys without
+// printing its content
+func planSecretFile(path string, data []byte) error {
+	existing, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	switch {
+	case string(existing) == string(data):
+		fmt.Printf("✅ %s: no change\n", path)
+	case existing == nil:
+		fmt.Printf("📝 Would create %s (holds keys; content not shown)\n", path)
+	default:
+		fmt.Printf("📝 Would update %s (holds keys; diff not shown)\n", path)
+	}
+	return nil
+}
+
+// planRemove prints a file or directory that would be deleted, without
+// its content
+func planRemove(path string) {
+	fmt.Printf("🗑  Would remove %s\n", path)
+}
+
+// planCommand prints a command that would run, in dir when set
+func planCommand(dir, name string, args ...string) {
+	command := strings.Join(append([]string{name}, args...), " ")
+	if dir != "" && dir != "." {
+		command = "cd " + dir + " && " + command
+	}
+	fmt.Printf("▶️  Would run: %s\n", command)
+}
+
+// planCall prints an API call that would be made
+func planCall(format string, args ...interface{}) {
+	fmt.Printf("🌐 Would call: %s\n", fmt.Sprintf(format, args...))
+}
+
+func dryRunDone() {
+	fmt.Println()
+	fmt.Println("Dry run: nothing was changed.")
+}
@@ -0,0 +1,205 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/healthz"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var eventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Receive events emitted by the engine",
+}
+
+var eventsListenCmd = &cobra.Command{
+	Use:   "listen",
+	Short: "Receive engine events over a webhook and index calls in real time",
+	Long: `Act as the webhook receiver of events the engine emits and write the
+calls they describe into the call index as they happen, instead of
+scraping logs for them. --notify also fans the events out to the
+notification channels (as engine_event).
+
+The engine POSTs JSON to http://<host>:<port>/events, one event or an
+array of events:
+  {"type": "call_started", "call_id": "1763582071.6214",
+   "time": "2025-11-19T20:34:31Z", "caller_number": "+15551234567",
+   "dialed": "6000", "context": "sales", "tenant": "acme"}
+  {"type": "call_ended", "call_id": "1763582071.6214",
+   "status": "completed", "hangup_cause": 16, "duration_s": 94}
+  {"type": "error", "call_id": "1763582071.6214", "component": "stt",
+   "message": "Deepgram websocket closed", "severity": "critical"}
+time defaults to when the event arrives. Errors fan out at their
+severity (default warning), failed calls as warnings, the rest as info.
+
+Authenticate senders with --secret (HMAC: X-Agent-Timestamp with the
+epoch seconds and X-Agent-Signature: sha256=<hex HMAC-SHA256 of
+"<timestamp>.<body>">, as the CLI's own webhooks sign) or --token
+("Authorization: Bearer <token>"). /healthz and /readyz are served on
+the same port.
+
+Examples:
+  agent events listen --port 9400 --secret "$EVENTS_SECRET"
+  agent events listen --port 9400 --token "$EVENTS_TOKEN" --notify`,
+	Args: cobra.NoArgs,
+	RunE: runEventsListen,
+}
+
+var (
+	eventsPort    int
+	eventsBind    string
+	eventsSecret  string
+	eventsToken   string
+	eventsNotify  bool
+	eventsMaxSkew time.Duration
+)
+
+// eventsMaxBody caps the size of one webhook request
+const eventsMaxBody = 1 << 20
+
+func init() {
+	f := eventsListenCmd.Flags()
+	f.IntVar(&eventsPort, "port", 9400, "port to receive events on")
+	f.StringVar(&eventsBind, "bind", "", "address to listen on (default all interfaces)")
+	f.StringVar(&eventsSecret, "secret", "", "HMAC secret senders sign requests with")
+	f.StringVar(&eventsToken, "token", "", "bearer token senders must present")
+	f.BoolVar(&eventsNotify, "notify", false, "also send the events to the notification channels in ~/.agent/config")
+	f.DurationVar(&eventsMaxSkew, "max-skew", 5*time.Minute, "reject signed requests whose timestamp is further off than this")
+
+	eventsCmd.AddCommand(eventsListenCmd)
+	rootCmd.AddCommand(eventsCmd)
+}
+
+func runEventsListen(cmd *cobra.Command, args []string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	indexAge, err := cfg.Retention.IndexMaxAge()
+	if err != nil {
+		return err
+	}
+	var notifier *notify.Notifier
+	if eventsNotify {
+		if notifier, err = notify.New(cfg.Notifications); err != nil {
+			return err
+		}
+		if notifier == nil {
+			return fmt.Errorf("--notify: no notification channels configured in %s", settings.Path())
+		}
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	recorder := troubleshoot.NewEventRecorder(indexAge)
+	health := healthz.New(time.Minute)
+	health.Expect("webhook")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/events", engineEventsHandler(ctx, recorder, notifier, health))
+	health.Register(mux)
+
+	addr := net.JoinHostPort(eventsBind, strconv.Itoa(eventsPort))
+	errs := make(chan error, 1)
+	go func() { errs <- serveHTTP(ctx, addr, mux) }()
+	fmt.Printf("📥 Receiving engine events on http://%s/events\n", displayAddr(addr))
+	if eventsSecret == "" && eventsToken == "" {
+		fmt.Println("⚠️  No --secret or --token: anyone reaching the port can write to the call index")
+	}
+	for _, name := range notifier.Channels() {
+		fmt.Printf("   Notifying %s\n", name)
+	}
+	fmt.Println("   Press Ctrl-C to stop")
+
+	ticker := time.NewTicker(15 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			fmt.Println("\nStopping...")
+			return nil
+		case err := <-errs:
+			return err
+		case <-ticker.C:
+			health.Beat()
+		}
+	}
+}
+
+// engineEventsHandler authenticates, indexes and optionally notifies
+// the events of one webhook request
+func engineEventsHandler(ctx context.Context, recorder *troubleshoot.EventRecorder, notifier *notify.Notifier, health *healthz.State) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "POST events here", http.StatusMethodNotAllowed)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, eventsMaxBody+1))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if len(body) > eventsMaxBody {
+			http.Error(w, "request too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		now := time.Now()
+		if eventsToken != "" && !validToken(r, eventsToken) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if eventsSecret != "" {
+			if err := events.VerifySignature(eventsSecret, r.Header.Get(notify.HeaderTimestamp), r.Header.Get(notify.HeaderSignature), body, now, eventsMaxSkew); err != nil {
+				http.Error(w, "unauthorized: "+err.Error(), http.StatusUnauthorized)
+				return
+			}
+		}
+		evs, err := events.DecodeEngineEvents(body, now)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		health.Seen("webhook")
+
+		calls, err := recorder.Record(evs)
+		health.Set("index", err)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to update call index: %v\n", err)
+			http.Error(w, "call index: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if verbose {
+			for _, ev := range evs {
+				fmt.Printf("[DEBUG] %s %s\n", ev.Type, ev.CallID)
+			}
+		}
+
+		// Deliver after answering, so slow channels do not hold the engine
+		if notifier != nil {
+			go func() {
+				for _, ev := range evs {
+					if _, err := notifier.Notify(ctx, ev.Notification()); err != nil {
+						fmt.Fprintf(os.Stderr, "⚠️  Notifying %s %s: %v\n", ev.Type, ev.CallID, err)
+					}
+				}
+			}()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]int{"accepted": len(evs), "calls": calls})
+	}
+}
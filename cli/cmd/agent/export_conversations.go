@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var exportConversationsCmd = &cobra.Command{
+	Use:   "conversations",
+	Short: "Export call transcripts as chat-format JSONL for fine-tuning",
+	Long: `Export the transcript of every call in the window as one chat-format
+JSON line, as fine-tuning and evaluation tools expect:
+
+  {"messages": [{"role": "system", "content": "<prompt>"},
+                {"role": "user", "content": "<caller>"},
+                {"role": "assistant", "content": "<agent>"}, ...]}
+
+The system message is the prompt of the call's context in
+config/ai-agent.yaml (else the default context's, else llm.prompt);
+--system replaces it and --no-system leaves it out. Transcripts come
+from the engine log, as in 'agent calls watch'; consecutive fragments
+of one side are joined, and tool calls are left out.
+
+--redact replaces e-mail addresses, card, social security, phone and
+other long numbers, and the caller's name with placeholders ([EMAIL],
+[CARD], [SSN], [PHONE], [NUMBER], [NAME]). Review a sample before
+sharing a dataset: names and addresses said in passing are not caught.
+
+--metadata adds call_id, start, status and context to each line, for
+evaluation datasets (fine-tuning APIs may reject the extra key).
+
+Examples:
+  agent export conversations --since 30d --redact --output calls.jsonl
+  agent export conversations --since 7d --status completed --min-turns 3 --redact
+  agent export conversations --tag good-example --metadata --redact`,
+	Args: cobra.NoArgs,
+	RunE: runExportConversations,
+}
+
+var (
+	conversationsFormat   string
+	conversationsRedact   bool
+	conversationsSystem   string
+	conversationsNoSystem bool
+	conversationsMetadata bool
+	conversationsMinTurns int
+)
+
+// chatMessage is one message of a chat-format dataset line
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// chatLine is one dataset line
+type chatLine struct {
+	Messages []chatMessage     `json:"messages"`
+	Metadata *chatLineMetadata `json:"metadata,omitempty"`
+}
+
+type chatLineMetadata struct {
+	CallID  string `json:"call_id"`
+	Start   string `json:"start"`
+	Status  string `json:"status,omitempty"`
+	Context string `json:"context,omitempty"`
+}
+
+func init() {
+	f := exportConversationsCmd.Flags()
+	f.StringVar(&exportSince, "since", "30d", "start of the window: duration (7d, 30d) or timestamp")
+	f.StringVar(&exportUntil, "until", "", "end of the window: duration or timestamp")
+	f.StringVar(&conversationsFormat, "format", "jsonl", "output format: jsonl")
+	f.StringVarP(&exportOutput, "output", "o", "", "write to this file instead of stdout")
+	f.BoolVar(&conversationsRedact, "redact", false, "replace personal data in the transcripts with placeholders")
+	f.StringVar(&conversationsSystem, "system", "", "system prompt of every conversation instead of the context's")
+	f.BoolVar(&conversationsNoSystem, "no-system", false, "leave the system message out")
+	f.BoolVar(&conversationsMetadata, "metadata", false, "add the call's ID, start, status and context to each line")
+	f.IntVar(&conversationsMinTurns, "min-turns", 1, "leave out calls with fewer answered caller turns")
+	f.StringVar(&exportFrom, "from", "", "only calls from this caller number (digits match)")
+	f.StringVar(&exportTo, "to", "", "only calls to this dialed number/extension")
+	f.StringVar(&exportStatus, "status", "", "only calls with status: completed|failed|abandoned|transferred (comma-separated)")
+	f.StringVar(&exportTag, "tag", "", "only calls with one of these tags (comma-separated, see 'agent calls tag')")
+	f.StringVar(&exportTenant, "tenant", "", "only calls of these tenants (comma-separated, none for calls of no tenant)")
+	f.StringVar(&exportContainer, "container", troubleshoot.DefaultContainer, "engine container to read logs from")
+	f.BoolVar(&exportNoCache, "no-cache", false, "collect every call's logs again instead of reusing cached data")
+	f.DurationVar(&exportTimeout, "timeout", 0, "abort the export after this long (e.g. 10m, 0 = no limit)")
+
+	exportCmd.AddCommand(exportConversationsCmd)
+}
+
+func runExportConversations(cmd *cobra.Command, args []string) error {
+	if conversationsFormat != "jsonl" {
+		return fmt.Errorf("--format must be jsonl")
+	}
+	if conversationsSystem != "" && conversationsNoSystem {
+		return fmt.Errorf("--system and --no-system are exclusive")
+	}
+	if err := troubleshoot.ValidateStatuses(exportStatus); err != nil {
+		return fmt.Errorf("--status: %w", err)
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(exportTimeout)
+	defer cancel()
+
+	runner, err := newExportRunner(cmd, ctx, cfg, exportSince, exportUntil, troubleshoot.CallFilter{
+		From:   exportFrom,
+		To:     exportTo,
+		Status: exportStatus,
+		Tag:    exportTag,
+		Tenant: exportTenant,
+	})
+	if err != nil {
+		return err
+	}
+	conversations, err := runner.ExportConversations()
+	if err != nil {
+		return err
+	}
+
+	prompts := contextPrompts()
+	var buf bytes.Buffer
+	written, short := 0, 0
+	for _, c := range conversations {
+		if c.Exchanges() < conversationsMinTurns {
+			short++
+			continue
+		}
+		line := chatLine{Messages: []chatMessage{}}
+		if !conversationsNoSystem {
+			prompt := conversationsSystem
+			if prompt == "" {
+				prompt = prompts.lookup(c.Context)
+			}
+			if prompt != "" {
+				line.Messages = append(line.Messages, chatMessage{Role: troubleshoot.RoleSystem, Content: prompt})
+			}
+		}
+		var names []string
+		if c.CallerName != "" && c.CallerName != c.CallerNumber && !strings.EqualFold(c.CallerName, "unknown") {
+			names = append(names, c.CallerName)
+		}
+		for _, turn := range c.Turns {
+			text := turn.Text
+			if conversationsRedact {
+				text = troubleshoot.RedactTranscript(text, names...)
+			}
+			line.Messages = append(line.Messages, chatMessage{Role: turn.Role, Content: text})
+		}
+		if conversationsMetadata {
+			line.Metadata = &chatLineMetadata{
+				CallID:  c.CallID,
+				Start:   c.Start.UTC().Format("2006-01-02T15:04:05Z07:00"),
+				Status:  c.Status,
+				Context: c.Context,
+			}
+		}
+		data, err := json.Marshal(line)
+		if err != nil {
+			return err
+		}
+		buf.Write(data)
+		buf.WriteByte('\n')
+		written++
+	}
+	if short > 0 {
+		fmt.Fprintf(os.Stderr, "Left out %d call(s) with fewer than %d answered turn(s)\n", short, conversationsMinTurns)
+	}
+	if !conversationsRedact && written > 0 {
+		fmt.Fprintln(os.Stderr, "⚠️  Transcripts are not redacted (use --redact before sharing them)")
+	}
+
+	if exportOutput == "" {
+		_, err = os.Stdout.Write(buf.Bytes())
+		return err
+	}
+	if err := os.WriteFile(exportOutput, buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ Exported %d conversation(s) to %s\n", written, exportOutput)
+	return nil
+}
+
+// promptConfig is the part of config/ai-agent.yaml holding prompts
+type promptConfig struct {
+	Contexts map[string]struct {
+		Prompt string `yaml:"prompt"`
+	} `yaml:"contexts"`
+	LLM struct {
+		Prompt string `yaml:"prompt"`
+	} `yaml:"llm"`
+}
+
+// contextPrompts reads the prompts of the project's config; a missing
+// config yields no prompts
+func contextPrompts() *promptConfig {
+	pc := &promptConfig{}
+	data, err := remote.ReadFile(filepath.Join("config", "ai-agent.yaml"))
+	if err != nil {
+		return pc
+	}
+	yaml.Unmarshal(data, pc)
+	return pc
+}
+
+// lookup returns the system prompt of a context, falling back as the
+// engine does: the default context, then llm.prompt
+func (pc *promptConfig) lookup(context string) string {
+	for _, name := range []string{context, "default"} {
+		if c, ok := pc.Contexts[name]; ok && name != "" && strings.TrimSpace(c.Prompt) != "" {
+			return strings.TrimSpace(c.Prompt)
+		}
+	}
+	return strings.TrimSpace(pc.LLM.Prompt)
+}
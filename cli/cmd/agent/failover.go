@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ha"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/wizard"
+	"github.com/spf13/cobra"
+)
+
+var failoverCmd = &cobra.Command{
+	Use:   "failover",
+	Short: "Active/standby pair operations",
+}
+
+var failoverDrillCmd = &cobra.Command{
+	Use:   "drill",
+	Short: "Switch the floating IP to the standby and measure the call impact",
+	Long: `Run a controlled switchover of an active/standby pair and measure what
+callers would see: how long until the standby holds the floating IP,
+until it runs Asterisk and the engine, and what became of the calls in
+progress.
+
+The pair is configured in ~/.agent/config; this host (or remote:) is
+one node and ha.peer the other:
+  ha:
+    peer:
+      host: ssh://root@pbx-b
+      dir: /opt/asterisk-ai-voice-agent
+    floating_ip: 10.0.0.50
+    manager: keepalived          # or pacemaker, or command
+    # switchover_command: ...    # with manager: command, run on the active node
+    replication:
+      drbd: r0                   # and/or paths synced by lsyncd/rsync
+      paths: [/var/lib/asterisk, /var/spool/asterisk/monitor]
+      max_lag: 5m
+    config_paths: [/etc/asterisk, config/ai-agent.yaml]
+
+keepalived is stopped on the active node and started again once the
+standby took over; pacemaker puts the active node in standby and back
+(pcs node standby/unstandby). With preemption (keepalived's default
+for a higher priority, or pacemaker location constraints) the floating
+IP moves back when the old node returns.
+
+--probe dials an address over TCP throughout, e.g. the floating IP's
+SIP TLS or ARI port, and reports the longest outage. Calls in progress
+on the active node are counted before and after; calls whose media
+went to the floating IP do not survive the switchover unless the
+engine and Asterisk replicate them. 'agent doctor' checks the pair
+(floating IP ownership, replication, config parity) without moving
+anything.
+
+Run it in a maintenance window: it moves production traffic.
+
+Examples:
+  agent failover drill --dry-run
+  agent failover drill --probe 10.0.0.50:5061
+  agent failover drill --yes --format json`,
+	Args: cobra.NoArgs,
+	RunE: runFailoverDrill,
+}
+
+var (
+	failoverYes     bool
+	failoverProbe   string
+	failoverTimeout time.Duration
+	failoverFormat  string
+)
+
+func init() {
+	f := failoverDrillCmd.Flags()
+	f.BoolVarP(&failoverYes, "yes", "y", false, "switch over without asking")
+	f.StringVar(&failoverProbe, "probe", "", "host:port to dial over TCP during the switchover (e.g. the floating IP's SIP TLS port)")
+	f.DurationVar(&failoverTimeout, "timeout", 2*time.Minute, "how long the standby has to take over")
+	f.StringVar(&failoverFormat, "format", "text", "output format: text|json")
+	addDryRunFlag(failoverDrillCmd)
+
+	failoverCmd.AddCommand(failoverDrillCmd)
+	rootCmd.AddCommand(failoverCmd)
+}
+
+// haPair returns the pair configured in ~/.agent/config
+func haPair() (*ha.Pair, error) {
+	cfg, err := settings.Load()
+	if err != nil {
+		return nil, err
+	}
+	pair, err := ha.NewPair(cfg.HA)
+	if err != nil {
+		return nil, err
+	}
+	if pair == nil {
+		return nil, fmt.Errorf("no active/standby pair configured: set ha.peer and ha.floating_ip in %s", settings.Path())
+	}
+	return pair, nil
+}
+
+// addHAChecks adds the pair's checks to doctor when a pair is configured
+func addHAChecks(checker *health.Checker) {
+	cfg, err := settings.Load()
+	if err != nil || cfg.HA.Peer.Host == "" {
+		return
+	}
+	pair, err := ha.NewPair(cfg.HA)
+	if err != nil {
+		checker.AddCheck(func() health.Check {
+			return health.Check{Name: "HA pair", Status: health.StatusFail, Message: "Invalid ha settings", Details: err.Error()}
+		})
+		return
+	}
+	for _, check := range []func(context.Context) health.Check{pair.CheckFloatingIP, pair.CheckReplication, pair.CheckConfigParity} {
+		check := check
+		checker.AddCheck(func() health.Check {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+			defer cancel()
+			return check(ctx)
+		})
+	}
+}
+
+func runFailoverDrill(cmd *cobra.Command, args []string) error {
+	if failoverFormat != "text" && failoverFormat != "json" {
+		return fmt.Errorf("invalid --format %q (use text or json)", failoverFormat)
+	}
+	pair, err := haPair()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	active, err := pair.Active(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot drill: %w (see 'agent doctor')", err)
+	}
+	standby := pair.Other(active)
+	trigger, restore, err := pair.Switchover(ctx, active)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		fmt.Printf("Active: %s, standby: %s, floating IP %s (%s)\n", active.Name, standby.Name, pair.FloatingIP(), pair.Manager())
+		planCommand("", "ssh", active.Name, trigger)
+		if restore != "" {
+			planCommand("", "ssh", active.Name, restore)
+		}
+		dryRunDone()
+		return nil
+	}
+	if !failoverYes {
+		if calls, err := active.ActiveCalls(ctx); err == nil && calls > 0 {
+			fmt.Printf("⚠️  %d call(s) in progress on %s\n", calls, active.Name)
+		}
+		if !wizard.PromptConfirm(fmt.Sprintf("Move %s from %s to %s?", pair.FloatingIP(), active.Name, standby.Name), false) {
+			return fmt.Errorf("cancelled")
+		}
+	}
+
+	opts := ha.DrillOptions{Timeout: failoverTimeout, Probe: failoverProbe}
+	if failoverFormat == "text" {
+		opts.Progress = func(step string) { fmt.Printf("   %s\n", step) }
+		fmt.Printf("🔀 Failover drill: %s → %s\n", active.Name, standby.Name)
+	}
+	res, err := pair.Drill(ctx, opts)
+	if err != nil {
+		return err
+	}
+	if failoverFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			return err
+		}
+	} else {
+		printDrill(res)
+	}
+	if res.Error != "" {
+		return fmt.Errorf("failover drill failed: %s", res.Error)
+	}
+	return nil
+}
+
+// printDrill prints the drill's measurements
+func printDrill(res *ha.DrillResult) {
+	fmt.Println()
+	if res.Error != "" {
+		fmt.Printf("❌ %s\n", res.Error)
+	} else {
+		fmt.Printf("✅ %s took over from %s\n", res.To, res.From)
+	}
+	if res.TakeoverMs > 0 {
+		fmt.Printf("   Floating IP moved after: %s\n", drillDuration(res.TakeoverMs))
+	}
+	if res.ReadyMs > 0 {
+		fmt.Printf("   Services ready after:    %s\n", drillDuration(res.ReadyMs))
+	}
+	if failoverProbe != "" {
+		fmt.Printf("   %s unreachable for: %s\n", failoverProbe, drillDuration(res.ProbeGapMs))
+	}
+	fmt.Printf("   Calls in progress:       %d\n", res.CallsBefore)
+	if res.CallsBefore > 0 {
+		fmt.Printf("   Calls dropped:           %d\n", res.CallsDropped)
+		fmt.Printf("   Calls still on %s: %d\n", res.From, res.CallsLeft)
+	}
+	fmt.Printf("   Calls on %s now: %d\n", res.To, res.CallsAfter)
+	if res.Restore != "" {
+		fmt.Printf("   Restore on %s: %s\n", res.From, res.Restore)
+	}
+}
+
+func drillDuration(ms int64) string {
+	return (time.Duration(ms) * time.Millisecond).Round(100 * time.Millisecond).String()
+}
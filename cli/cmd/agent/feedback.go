@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	feedbackVerdict     string
+	feedbackActualCause string
+)
+
+var feedbackCmd = &cobra.Command{
+	Use:   "feedback <run_id>",
+	Short: "Record whether a troubleshoot run's RCA was right",
+	Long: `Record whether the root cause analysis of a saved troubleshoot run
+(see 'agent troubleshoot history') was correct.
+
+Verdicts are stored in ~/.agent/feedback.json and improve later runs:
+  - Findings confirmed in at least 2 more runs than they were marked
+    wrong are raised one severity level; findings marked wrong at least
+    2 more times than confirmed are lowered one level.
+  - The verdicts on the most similar past calls (shared findings, same
+    symptom) are given to the LLM as examples, including the actual
+    cause of wrong diagnoses.
+Recording a verdict again for the same run replaces it.
+
+Examples:
+  agent feedback 20251026-091500 --verdict correct
+  agent feedback 20251026-091500 --verdict wrong \
+    --actual-cause "NAT: RTP blocked by the SBC, not a codec mismatch"`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		record, err := troubleshoot.RecordFeedback(args[0], feedbackVerdict, feedbackActualCause)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("✅ Recorded %s verdict for run %s (call %s)\n", record.Verdict, record.RunID, record.CallID)
+		if len(record.Patterns) == 0 {
+			fmt.Println("   The run had no critical or warning findings; the verdict is used as an LLM example for the same symptom only.")
+		} else {
+			fmt.Printf("   Applies to %d finding pattern(s) in later runs\n", len(record.Patterns))
+		}
+		return nil
+	},
+}
+
+func init() {
+	feedbackCmd.Flags().StringVar(&feedbackVerdict, "verdict", "", "was the RCA right: correct|wrong")
+	feedbackCmd.Flags().StringVar(&feedbackActualCause, "actual-cause", "", "the real root cause (required for wrong)")
+	feedbackCmd.MarkFlagRequired("verdict")
+
+	rootCmd.AddCommand(feedbackCmd)
+}
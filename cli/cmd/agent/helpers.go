@@ -1,5 +1,17 @@
 package main
 
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
 // getContextName returns the Asterisk dialplan context name for a given provider
 func getContextName(provider string) string {
 	contexts := map[string]string{
@@ -14,3 +26,68 @@ func getContextName(provider string) string {
 	}
 	return "from-ai-agent"
 }
+
+// resolveLocations returns the display zone (--tz, then the timezone
+// setting, then local time) and the zone of zone-less log timestamps.
+func resolveLocations() (*time.Location, *time.Location, error) {
+	cfg, err := settings.Load()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	name := timezone
+	if name == "" {
+		name = cfg.Timezone
+	}
+	loc, err := settings.LoadLocation(name, time.Local)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	logLoc, err := settings.LoadLocation(cfg.LogTimezone, time.UTC)
+	if err != nil {
+		return nil, nil, err
+	}
+	return loc, logLoc, nil
+}
+
+// runContext returns a context cancelled on Ctrl-C/SIGTERM and, when
+// timeout is non-zero, after timeout has elapsed
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
+
+// composeCommand returns the compose command line for the container
+// runtime in use (docker compose, podman compose or podman-compose) with
+// args appended, and the environment to run it with (nil inherits ours)
+func composeCommand(ctx context.Context, args ...string) ([]string, []string) {
+	var env []string
+	runtime := ""
+	if client, err := docker.Default(); err == nil {
+		if rt, err := client.Runtime(ctx); err == nil {
+			runtime = rt.Name
+		}
+		env = client.ComposeEnv()
+	}
+	tool, err := docker.ComposeCommand(runtime)
+	if err != nil {
+		tool = []string{"docker", "compose"}
+	}
+	return append(append([]string{}, tool...), args...), env
+}
+
+// exitCodeError ends a command with a non-zero exit status after it has
+// printed its own results. main exits with the code; the shell carries on.
+type exitCodeError struct {
+	code int
+}
+
+func (e *exitCodeError) Error() string { return fmt.Sprintf("exit status %d", e.code) }
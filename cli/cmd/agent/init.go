@@ -10,6 +10,7 @@ import (
 var (
 	initNonInteractive bool
 	initTemplate       string
+	initPostInstall    bool
 )
 
 var initCmd = &cobra.Command{
@@ -24,8 +25,21 @@ Guides you through configuration:
   - Pipeline configuration
   - Configuration validation
 
-This can be run multiple times to reconfigure the system.`,
+This can be run multiple times to reconfigure the system.
+
+--post-install is run by the OS packages after installing the CLI (see
+'agent install packaging'): instead of the wizard it looks for an
+existing deployment (stack containers, aava-* systemd units, a project
+directory with .env or config/ai-agent.yaml) and indexes the calls of
+the last 7 days, so troubleshoot --call and call ID completion work
+right away. It only warns about problems and never fails.`,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		if initPostInstall {
+			if dryRun {
+				return fmt.Errorf("--dry-run applies to the wizard, not --post-install")
+			}
+			return runPostInstall(cmd)
+		}
 		if initNonInteractive {
 			fmt.Println("⚠️  Non-interactive mode not yet implemented")
 			fmt.Println("For now, run without --non-interactive flag")
@@ -33,18 +47,44 @@ This can be run multiple times to reconfigure the system.`,
 		}
 		
 		// Create and run wizard
-		w, err := wizard.NewWizard()
+		w, err := wizard.NewWizard(dryRun)
 		if err != nil {
 			return fmt.Errorf("failed to initialize wizard: %w", err)
 		}
 		
-		return w.Run()
+		if err := w.Run(); err != nil || !dryRun {
+			return err
+		}
+		return planInit(cmd, w)
 	},
 }
 
+// planInit prints the files and rebuilds of a dry run of the wizard
+func planInit(cmd *cobra.Command, w *wizard.Wizard) error {
+	for _, f := range w.Planned {
+		plan := planFile
+		if f.Secret {
+			plan = planSecretFile
+		}
+		if err := plan(f.Path, f.Data); err != nil {
+			return err
+		}
+	}
+	for _, service := range w.PlannedRebuild {
+		build, _ := composeCommand(cmd.Context(), "build", service)
+		planCommand("", build[0], build[1:]...)
+		up, _ := composeCommand(cmd.Context(), "up", "-d", "--force-recreate", service)
+		planCommand("", up[0], up[1:]...)
+	}
+	dryRunDone()
+	return nil
+}
+
 func init() {
 	initCmd.Flags().BoolVar(&initNonInteractive, "non-interactive", false, "non-interactive mode (use defaults)")
 	initCmd.Flags().StringVar(&initTemplate, "template", "", "config template: local|cloud|hybrid|openai-agent|deepgram-agent")
+	initCmd.Flags().BoolVar(&initPostInstall, "post-install", false, "detect an existing deployment and build the call index (run by package scripts)")
+	addDryRunFlag(initCmd)
 	
 	rootCmd.AddCommand(initCmd)
 }
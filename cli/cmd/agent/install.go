@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/systemd"
+	"github.com/spf13/cobra"
+)
+
+var installCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the stack as host services",
+}
+
+var installSystemdCmd = &cobra.Command{
+	Use:   "systemd",
+	Short: "Write hardened systemd units for a bare-metal install",
+	Long: `Write systemd units for running the engine without docker.
+
+Units:
+  aava-engine.service     the engine (main.py) from --dir
+  aava-local-ai.service   local_ai_server, when the config uses local models
+  aava-exporter.timer     every --export-interval, analyzes the calls that
+                          finished (troubleshoot --all --no-llm) and pushes
+                          their metrics to the StatsD/InfluxDB/OTLP sinks
+                          configured in /var/lib/aava/config
+  aava-watch.timer        every --watch-interval, runs doctor, which sends
+                          doctor_check_failed notifications
+
+Services run as --user with a strict sandbox (read-only system, no
+capabilities or devices, system-service syscalls only) and may write only
+to data/, config/ and /mnt/asterisk_media (models/ for local_ai_server).
+They restart on failure and log to the journal; the exporter and watch
+units read it with log_source type journald, and so can you:
+  log_source:
+    type: journald
+
+--dry-run prints the diff against the installed units and the
+systemctl commands --enable would run, without writing anything.
+
+Run as root. The user must exist and be able to read --dir, e.g.:
+  useradd --system --home-dir /opt/aava --groups asterisk aava
+
+Examples:
+  sudo agent install systemd --dir /opt/aava --enable
+  sudo agent install systemd --dir /opt/aava --watch-interval 0
+  agent install systemd --dir /opt/aava --output ./units
+  agent install systemd --dir /opt/aava --enable --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runInstallSystemd,
+}
+
+var (
+	installDir            string
+	installOutput         string
+	installUser           string
+	installGroup          string
+	installPython         string
+	installEnable         bool
+	installExportInterval time.Duration
+	installWatchInterval  time.Duration
+)
+
+func init() {
+	f := installSystemdCmd.Flags()
+	f.StringVar(&installDir, "dir", ".", "project directory the engine runs from")
+	f.StringVarP(&installOutput, "output", "o", systemd.DefaultDir, "directory to write the units to")
+	f.StringVar(&installUser, "user", "aava", "user the services run as")
+	f.StringVar(&installGroup, "group", "", "group the services run as (default: the user's)")
+	f.StringVar(&installPython, "python", "", "Python interpreter (default: <dir>/.venv/bin/python, then python3)")
+	f.BoolVar(&installEnable, "enable", false, "reload systemd and enable and start the units")
+	f.DurationVar(&installExportInterval, "export-interval", 15*time.Minute, "how often the exporter runs (0 = no exporter)")
+	f.DurationVar(&installWatchInterval, "watch-interval", 5*time.Minute, "how often the watch runs doctor (0 = no watch)")
+
+	addDryRunFlag(installSystemdCmd)
+
+	installCmd.AddCommand(installSystemdCmd)
+	rootCmd.AddCommand(installCmd)
+}
+
+func runInstallSystemd(cmd *cobra.Command, args []string) error {
+	dir, err := filepath.Abs(installDir)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.py")); err != nil {
+		return fmt.Errorf("no main.py in %s (use --dir to point at the project)", dir)
+	}
+	profile, err := deploy.LoadProfile(dir)
+	if err != nil {
+		return err
+	}
+	agentPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the agent binary: %w", err)
+	}
+	if installPython == "" {
+		installPython = systemd.DefaultPython(dir)
+	}
+
+	opts := systemd.Options{
+		Dir:            dir,
+		Python:         installPython,
+		Agent:          agentPath,
+		User:           installUser,
+		Group:          installGroup,
+		LocalAI:        profile.LocalAI,
+		ExportInterval: installExportInterval,
+		WatchInterval:  installWatchInterval,
+	}
+	files := systemd.Render(opts)
+	if dryRun {
+		return planInstallSystemd(files)
+	}
+	if err := os.MkdirAll(installOutput, 0755); err != nil {
+		return err
+	}
+	if err := systemd.Write(installOutput, files); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("cannot write to %s: run with sudo or use --output", installOutput)
+		}
+		return err
+	}
+	for _, f := range files {
+		fmt.Printf("✅ Wrote %s\n", filepath.Join(installOutput, f.Name))
+	}
+	fmt.Println()
+
+	units := systemd.Enable(files)
+	if !installEnable {
+		fmt.Println("Enable:")
+		if installOutput != systemd.DefaultDir {
+			fmt.Printf("  sudo cp %s/aava-* %s/\n", installOutput, systemd.DefaultDir)
+		}
+		fmt.Println("  sudo systemctl daemon-reload")
+		fmt.Printf("  sudo systemctl enable --now %s\n", strings.Join(units, " "))
+		fmt.Printf("Logs: journalctl -u %s -f\n", systemd.EngineUnit)
+		return nil
+	}
+	if installOutput != systemd.DefaultDir {
+		return fmt.Errorf("--enable needs the units in %s", systemd.DefaultDir)
+	}
+
+	for _, step := range enableSteps(units) {
+		if out, err := selflog.CombinedOutput(exec.CommandContext(cmd.Context(), "systemctl", step...)); err != nil {
+			return fmt.Errorf("systemctl %s: %v: %s", strings.Join(step, " "), err, strings.TrimSpace(string(out)))
+		}
+	}
+	fmt.Printf("✅ Enabled and started %s\n", strings.Join(units, ", "))
+	fmt.Printf("   Logs: journalctl -u %s -f\n", systemd.EngineUnit)
+	return nil
+}
+
+// enableSteps are the systemctl invocations --enable runs
+func enableSteps(units []string) [][]string {
+	return [][]string{
+		{"daemon-reload"},
+		append([]string{"enable", "--now"}, units...),
+	}
+}
+
+func planInstallSystemd(files []systemd.File) error {
+	for _, f := range files {
+		if err := planFile(filepath.Join(installOutput, f.Name), []byte(f.Content)); err != nil {
+			return err
+		}
+	}
+	if installEnable {
+		if installOutput != systemd.DefaultDir {
+			return fmt.Errorf("--enable needs the units in %s", systemd.DefaultDir)
+		}
+		for _, step := range enableSteps(systemd.Enable(files)) {
+			planCommand("", "systemctl", step...)
+		}
+	}
+	dryRunDone()
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/calendar"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var integrationsCmd = &cobra.Command{
+	Use:   "integrations",
+	Short: "Check the external APIs the agent's tools call",
+}
+
+var integrationsTestCmd = &cobra.Command{
+	Use:   "test calendar",
+	Short: "Exercise an integration end-to-end",
+	Long: `Exercise the booking API of the agent's appointment tool end-to-end
+with a test booking, the way the tool books during a call:
+
+  auth          the credentials are accepted and the calendar exists
+  book          a test event is created at a dummy slot, its times
+                written in the booking timezone (not as UTC)
+  read back     the calendar stores it at the same instant
+  time range    a query in UTC finds it at that instant, so free/busy
+                lookups see it where it is
+  rollback      the test event is deleted and is gone afterwards
+
+The test event is titled "` + calendar.TestSummary + `". It is deleted
+also when a check after booking fails; if deleting fails, its ID is
+shown to remove it by hand.
+
+The default slot is 03:00 on the day after the booking timezone's next
+summer/winter time change, where clients that keep a fixed UTC offset
+book the wrong hour; --slot picks another (in the booking timezone).
+
+  calendar:
+    type: google                   # or caldav
+    credentials_file: /etc/agent/calendar-sa.json   # or token: ya29...
+    calendar_id: bookings@example.com               # default primary
+    timezone: Europe/Berlin        # zone the tool books in
+
+  calendar:
+    type: caldav
+    url: https://cloud.example.com/remote.php/dav/calendars/agent/bookings/
+    username: agent
+    password: ...
+
+A Google service account needs the calendar shared with it ("Make
+changes to events").
+
+Examples:
+  agent integrations test calendar
+  agent integrations test calendar --slot "2026-11-02 09:30" --duration 30m
+  agent integrations test calendar --format json`,
+	ValidArgs: []string{"calendar"},
+	Args:      cobra.ExactValidArgs(1),
+	RunE:      runIntegrationsTest,
+}
+
+var (
+	integrationsSlot     string
+	integrationsDuration time.Duration
+	integrationsFormat   string
+	integrationsTimeout  time.Duration
+)
+
+func init() {
+	f := integrationsTestCmd.Flags()
+	f.StringVar(&integrationsSlot, "slot", "", "start of the test booking, YYYY-MM-DD HH:MM in the booking timezone (default: after the next DST change)")
+	f.DurationVar(&integrationsDuration, "duration", 15*time.Minute, "length of the test booking")
+	f.StringVar(&integrationsFormat, "format", "text", "output format: text|json")
+	f.DurationVar(&integrationsTimeout, "timeout", 2*time.Minute, "abort the test after this long (the booking is still removed)")
+
+	integrationsCmd.AddCommand(integrationsTestCmd)
+	rootCmd.AddCommand(integrationsCmd)
+}
+
+func runIntegrationsTest(cmd *cobra.Command, args []string) error {
+	if integrationsFormat != "text" && integrationsFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", integrationsFormat)
+	}
+	if integrationsDuration <= 0 {
+		return fmt.Errorf("--duration must be positive")
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if cfg.Calendar.Type == "" {
+		return fmt.Errorf("no calendar configured in %s (see 'agent integrations test --help')", settings.Path())
+	}
+	cal, err := calendar.New(cfg.Calendar)
+	if err != nil {
+		return err
+	}
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	if cfg.Calendar.Timezone != "" {
+		if loc, err = time.LoadLocation(cfg.Calendar.Timezone); err != nil {
+			return fmt.Errorf("calendar timezone: %w", err)
+		}
+	}
+	slot := calendar.DefaultSlot(time.Now(), loc)
+	if integrationsSlot != "" {
+		if slot, err = time.ParseInLocation("2006-01-02 15:04", integrationsSlot, loc); err != nil {
+			return fmt.Errorf("invalid --slot %q (use YYYY-MM-DD HH:MM)", integrationsSlot)
+		}
+	}
+
+	ctx, cancel := runContext(integrationsTimeout)
+	defer cancel()
+
+	text := integrationsFormat == "text"
+	var progress func(calendar.Step)
+	if text {
+		fmt.Printf("📅 %s, booking %s in %s\n", cal.Name(), slot.Format("2006-01-02 15:04 MST"), loc)
+		progress = func(s calendar.Step) {
+			icon := "✅"
+			if !s.Passed {
+				icon = "❌"
+			}
+			line := fmt.Sprintf("%s %s (%s)", icon, s.Name, formatLatency(time.Duration(s.ElapsedMs)*time.Millisecond))
+			if s.Detail != "" {
+				line += " — " + s.Detail
+			}
+			fmt.Println(line)
+		}
+	}
+	report := calendar.Test(ctx, cal, loc, slot, integrationsDuration, progress)
+
+	if !text {
+		out, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	}
+	if report.Leftover {
+		return fmt.Errorf("the test booking %s is still in the calendar: delete it by hand", report.EventID)
+	}
+	if !report.Passed {
+		return fmt.Errorf("calendar test failed")
+	}
+	if text {
+		fmt.Println("\n✅ Booking, timezone handling and rollback work")
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/logfwd"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var loggingCmd = &cobra.Command{
+	Use:   "logging",
+	Short: "Log shipping, retention and verbosity",
+	Long: `Set up long-term retention for the stack's container logs, and raise
+log verbosity while reproducing a problem.
+
+Troubleshooting depends on engine logs still being available; local
+docker logs rotate away quickly on busy PBX hosts.`,
+}
+
+var loggingForwardCmd = &cobra.Command{
+	Use:   "forward",
+	Short: "Forward container logs to Loki, Elasticsearch or S3",
+	Long: `Generate a Vector sidecar that ships ai_engine, local_ai_server and
+admin_ui logs to Loki, Elasticsearch or S3.
+
+Writes:
+  config/vector/vector.toml      Vector pipeline (docker_logs → target)
+  docker-compose.logging.yml     Compose override adding the sidecar
+
+For loki and elastic, ~/.agent/config is also updated so
+'agent troubleshoot' reads from the backend once local logs rotate.
+
+Examples:
+  agent logging forward --to loki --url http://loki:3100
+  agent logging forward --to elastic --url http://es:9200
+  agent logging forward --to s3 --bucket pbx-logs --region eu-central-1
+  agent logging forward --to loki --url http://loki:3100 --apply`,
+	Args: cobra.NoArgs,
+	RunE: runLoggingForward,
+}
+
+var (
+	forwardOpts       logfwd.Options
+	forwardDir        string
+	forwardApply      bool
+	forwardNoSettings bool
+)
+
+func init() {
+	loggingForwardCmd.Flags().StringVar(&forwardOpts.Target, "to", "", "target: loki|elastic|s3 (required)")
+	loggingForwardCmd.Flags().StringVar(&forwardOpts.URL, "url", "", "Loki/Elasticsearch URL (S3: custom endpoint)")
+	loggingForwardCmd.Flags().StringVar(&forwardOpts.Index, "index", "", "Elasticsearch index (default ai-voice-agent-%Y.%m.%d)")
+	loggingForwardCmd.Flags().StringVar(&forwardOpts.Bucket, "bucket", "", "S3 bucket")
+	loggingForwardCmd.Flags().StringVar(&forwardOpts.Region, "region", "", "S3 region (default us-east-1)")
+	loggingForwardCmd.Flags().StringVar(&forwardOpts.Prefix, "prefix", "", "S3 key prefix (default ai-voice-agent/%Y/%m/%d/)")
+	loggingForwardCmd.Flags().StringSliceVar(&forwardOpts.Containers, "containers", nil, "containers to forward (default ai_engine,local_ai_server,admin_ui)")
+	loggingForwardCmd.Flags().StringVar(&forwardDir, "dir", ".", "project directory (where docker-compose.yml lives)")
+	loggingForwardCmd.Flags().BoolVar(&forwardApply, "apply", false, "start the sidecar with docker compose (or podman compose)")
+	loggingForwardCmd.Flags().BoolVar(&forwardNoSettings, "no-settings", false, "do not point troubleshoot at the backend")
+	loggingForwardCmd.MarkFlagRequired("to")
+
+	loggingCmd.AddCommand(loggingForwardCmd)
+	rootCmd.AddCommand(loggingCmd)
+}
+
+func runLoggingForward(cmd *cobra.Command, args []string) error {
+	if err := forwardOpts.Validate(); err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(forwardDir, "docker-compose.yml")); err != nil {
+		fmt.Printf("⚠️  No docker-compose.yml in %s (use --dir to point at the project)\n", forwardDir)
+	}
+
+	files := logfwd.DefaultFiles()
+	if err := logfwd.Write(forwardDir, files, forwardOpts); err != nil {
+		return fmt.Errorf("failed to write config: %w", err)
+	}
+	fmt.Printf("✅ Wrote %s\n", filepath.Join(forwardDir, files.VectorConfig))
+	fmt.Printf("✅ Wrote %s\n", filepath.Join(forwardDir, files.Compose))
+
+	if !forwardNoSettings && forwardOpts.Target != logfwd.TargetS3 {
+		if err := useAsLogSource(forwardOpts); err != nil {
+			fmt.Printf("⚠️  Could not update %s: %v\n", settings.Path(), err)
+		} else {
+			fmt.Printf("✅ troubleshoot will read logs from %s (%s)\n", forwardOpts.Target, settings.Path())
+		}
+	}
+	fmt.Println()
+
+	compose, composeEnv := composeCommand(cmd.Context(), "-f", "docker-compose.yml", "-f", files.Compose, "up", "-d", "log-forwarder")
+	if !forwardApply {
+		fmt.Println("Start the forwarder:")
+		fmt.Printf("  cd %s && %s\n", forwardDir, strings.Join(compose, " "))
+		if forwardOpts.Target == logfwd.TargetS3 {
+			fmt.Println("  (export AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY first)")
+		}
+		return nil
+	}
+
+	fmt.Println("Starting log forwarder...")
+	c := exec.CommandContext(cmd.Context(), compose[0], compose[1:]...)
+	c.Dir = forwardDir
+	c.Env = composeEnv
+	c.Stdout = os.Stdout
+	c.Stderr = os.Stderr
+	if err := selflog.Run(c); err != nil {
+		return fmt.Errorf("%s failed: %w", compose[0], err)
+	}
+	fmt.Println("✅ Log forwarder running (docker logs log_forwarder)")
+	return nil
+}
+
+// useAsLogSource points the troubleshoot log source at the new backend
+func useAsLogSource(o logfwd.Options) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	cfg.LogSource.URL = o.URL
+	switch o.Target {
+	case logfwd.TargetLoki:
+		cfg.LogSource.Type = "loki"
+		cfg.LogSource.Selector = ""
+	case logfwd.TargetElastic:
+		cfg.LogSource.Type = "elasticsearch"
+		cfg.LogSource.Index = strings.SplitN(o.Index, "%", 2)[0] + "*"
+		// Vector's elasticsearch sink keeps the event time in "timestamp"
+		cfg.LogSource.TimeField = "timestamp"
+		cfg.LogSource.MessageField = ""
+	}
+	return cfg.Save()
+}
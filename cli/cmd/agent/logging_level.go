@@ -0,0 +1,271 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var loggingLevelCmd = &cobra.Command{
+	Use:   "level",
+	Short: "Temporarily raise Asterisk log verbosity",
+	Long: `Raise a component's log verbosity for a limited window, then revert.
+
+For --component asterisk the commands go over AMI when it is configured
+(ASTERISK_AMI_USERNAME / ASTERISK_AMI_PASSWORD), otherwise through
+asterisk -rx:
+  verbose   core set verbose 5
+  debug     core set verbose 5, core set debug 5, pjsip set logger on
+
+The previous verbosity and debug level are restored when the window
+ends or on Ctrl-C, so keep the command running. The window is recorded
+in the call index: troubleshoot points out calls made during it, whose
+Asterisk log holds the extra detail. If the command was killed before
+reverting, --reset reverts now. Without --set or --reset the current
+levels are shown.
+
+Examples:
+  agent logging level --component asterisk --set debug --for 10m
+  agent logging level --component asterisk --set verbose --for 30m --container asterisk
+  agent logging level --component asterisk
+  agent logging level --component asterisk --reset`,
+	Args: cobra.NoArgs,
+	RunE: runLoggingLevel,
+}
+
+// Log level components and levels
+const (
+	logComponentAsterisk = "asterisk"
+	logLevelVerbose      = "verbose"
+	logLevelDebug        = "debug"
+
+	// logLevelMaxWindow bounds --for so debug logging is not left on
+	logLevelMaxWindow = 4 * time.Hour
+	// asteriskRaisedLevel is the verbose/debug level set
+	asteriskRaisedLevel = 5
+)
+
+var (
+	logComponents = []string{logComponentAsterisk}
+	logLevels     = []string{logLevelVerbose, logLevelDebug}
+
+	asteriskVerbosityPattern = regexp.MustCompile(`(?m)^\s*(?:Root console verbosity|Verbosity):\s*(\d+)`)
+	asteriskDebugPattern     = regexp.MustCompile(`(?m)^\s*Debug level:\s*(\d+)`)
+)
+
+var (
+	levelComponent string
+	levelSet       string
+	levelFor       time.Duration
+	levelReset     bool
+	levelContainer string
+)
+
+func init() {
+	loggingLevelCmd.Flags().StringVar(&levelComponent, "component", logComponentAsterisk, "component: asterisk")
+	loggingLevelCmd.Flags().StringVar(&levelSet, "set", "", "level to raise to: verbose|debug")
+	loggingLevelCmd.Flags().DurationVar(&levelFor, "for", 10*time.Minute, "how long to keep the level raised (max 4h)")
+	loggingLevelCmd.Flags().BoolVar(&levelReset, "reset", false, "revert a window that was not reverted")
+	loggingLevelCmd.Flags().StringVar(&levelContainer, "container", "", "run Asterisk commands inside this container (docker exec)")
+	addDryRunFlag(loggingLevelCmd)
+	loggingCmd.AddCommand(loggingLevelCmd)
+}
+
+func runLoggingLevel(cmd *cobra.Command, args []string) error {
+	if levelComponent != logComponentAsterisk {
+		return fmt.Errorf("unknown --component %q (use %s)", levelComponent, strings.Join(logComponents, ", "))
+	}
+	if levelSet != "" && levelReset {
+		return fmt.Errorf("--set and --reset are mutually exclusive")
+	}
+	host := newAsteriskHost(levelContainer)
+
+	switch {
+	case levelReset:
+		return resetLogLevel(host)
+	case levelSet == "":
+		return showLogLevel(host)
+	}
+
+	if levelSet != logLevelVerbose && levelSet != logLevelDebug {
+		return fmt.Errorf("unknown --set %q (use %s)", levelSet, strings.Join(logLevels, ", "))
+	}
+	if levelFor <= 0 || levelFor > logLevelMaxWindow {
+		return fmt.Errorf("--for must be between 1s and %s", logLevelMaxWindow)
+	}
+	if open := troubleshoot.LoadCallIndex().OpenLogWindow(levelComponent); open != nil {
+		return fmt.Errorf("%s logging is already raised (%s since %s); run with --reset first",
+			levelComponent, open.Level, open.Start.Format("15:04:05"))
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	verbosity, debug, err := asteriskLevels(ctx, host)
+	if err != nil {
+		return err
+	}
+	apply := []string{fmt.Sprintf("core set verbose %d", asteriskRaisedLevel)}
+	restore := []string{fmt.Sprintf("core set verbose %d", verbosity)}
+	if levelSet == logLevelDebug {
+		apply = append(apply, fmt.Sprintf("core set debug %d", asteriskRaisedLevel), "pjsip set logger on")
+		restore = append(restore, asteriskDebugCommand(debug), "pjsip set logger off")
+	}
+
+	if dryRun {
+		for _, command := range apply {
+			planCall("%s (%s)", command, host.Via())
+		}
+		fmt.Printf("📝 Would record a %s window until %s in the call index\n", levelSet, time.Now().Add(levelFor).Format("15:04:05"))
+		for _, command := range restore {
+			planCall("%s (%s, when the window ends)", command, host.Via())
+		}
+		dryRunDone()
+		return nil
+	}
+
+	var applied []string
+	for i, command := range apply {
+		out, err := host.Command(ctx, command)
+		if err == nil && strings.Contains(out, "No such command") {
+			// pjsip set logger on chan_sip systems
+			fmt.Printf("⚠️  %s: not available on this Asterisk\n", command)
+			continue
+		}
+		if err != nil {
+			runAsteriskCommands(host, applied)
+			return fmt.Errorf("%s: %w", command, err)
+		}
+		applied = append(applied, restore[i])
+	}
+
+	start := time.Now()
+	window := troubleshoot.LogWindow{
+		Component: levelComponent,
+		Level:     levelSet,
+		Start:     start,
+		End:       start.Add(levelFor),
+		Restore:   applied,
+	}
+	idx := troubleshoot.LoadCallIndex()
+	idx.AddLogWindow(window)
+	if err := idx.Save(); err != nil {
+		fmt.Printf("⚠️  Could not record the window in the call index: %v\n", err)
+	}
+
+	fmt.Printf("🐛 Asterisk %s logging on until %s (via %s)\n", levelSet, window.End.Format("15:04:05"), host.Via())
+	fmt.Println("   Keep this running: the previous levels are restored when the window ends (Ctrl-C restores now)")
+
+	timer := time.NewTimer(levelFor)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		fmt.Println()
+	case <-timer.C:
+	}
+	return revertLogLevel(host, window)
+}
+
+// showLogLevel prints the current Asterisk levels and any open window
+func showLogLevel(host *asteriskHost) error {
+	ctx, cancel := runContext(30 * time.Second)
+	defer cancel()
+	verbosity, debug, err := asteriskLevels(ctx, host)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Asterisk (via %s): verbose %d, debug %d\n", host.Via(), verbosity, debug)
+	open := troubleshoot.LoadCallIndex().OpenLogWindow(levelComponent)
+	switch {
+	case open == nil:
+		fmt.Println("No raised logging window")
+	case time.Now().After(open.End):
+		fmt.Printf("⚠️  %s window ended at %s but was not reverted: run with --reset\n", open.Level, open.End.Format("15:04:05"))
+	default:
+		fmt.Printf("🐛 %s window open until %s\n", open.Level, open.End.Format("15:04:05"))
+	}
+	return nil
+}
+
+// resetLogLevel reverts the open window of the component
+func resetLogLevel(host *asteriskHost) error {
+	open := troubleshoot.LoadCallIndex().OpenLogWindow(levelComponent)
+	if open == nil {
+		fmt.Printf("No raised %s logging to revert\n", levelComponent)
+		return nil
+	}
+	window := *open
+	if dryRun {
+		for _, command := range window.Restore {
+			planCall("%s (%s)", command, host.Via())
+		}
+		fmt.Printf("📝 Would close the %s window in the call index\n", window.Level)
+		dryRunDone()
+		return nil
+	}
+	if time.Now().Before(window.End) {
+		// Another agent logging level may still be waiting on it
+		fmt.Printf("⚠️  The %s window was due to run until %s\n", window.Level, window.End.Format("15:04:05"))
+	}
+	return revertLogLevel(host, window)
+}
+
+// revertLogLevel runs the window's restore commands and closes it in the
+// call index at the current time
+func revertLogLevel(host *asteriskHost, window troubleshoot.LogWindow) error {
+	if err := runAsteriskCommands(host, window.Restore); err != nil {
+		return fmt.Errorf("failed to restore Asterisk logging (run with --reset to retry): %w", err)
+	}
+
+	idx := troubleshoot.LoadCallIndex()
+	idx.CloseLogWindow(window.Component, window.Start, time.Now())
+	if err := idx.Save(); err != nil {
+		fmt.Printf("⚠️  Could not update the call index: %v\n", err)
+	}
+	fmt.Printf("✅ Asterisk logging restored (%s)\n", strings.Join(window.Restore, "; "))
+	return nil
+}
+
+// runAsteriskCommands runs commands on a fresh context, so reverting
+// works after Ctrl-C, stopping at the first failure
+func runAsteriskCommands(host *asteriskHost, commands []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	for _, command := range commands {
+		if _, err := host.Command(ctx, command); err != nil {
+			return fmt.Errorf("%s: %w", command, err)
+		}
+	}
+	return nil
+}
+
+// asteriskLevels reads the root verbosity and debug level from
+// 'core show settings'
+func asteriskLevels(ctx context.Context, host *asteriskHost) (verbosity, debug int, err error) {
+	out, err := host.Command(ctx, "core show settings")
+	if err != nil {
+		return 0, 0, fmt.Errorf("core show settings: %w", err)
+	}
+	m := asteriskVerbosityPattern.FindStringSubmatch(out)
+	if m == nil {
+		return 0, 0, fmt.Errorf("no verbosity in 'core show settings' output")
+	}
+	fmt.Sscanf(m[1], "%d", &verbosity)
+	if m := asteriskDebugPattern.FindStringSubmatch(out); m != nil {
+		fmt.Sscanf(m[1], "%d", &debug)
+	}
+	return verbosity, debug, nil
+}
+
+// asteriskDebugCommand sets the core debug level back to level
+func asteriskDebugCommand(level int) string {
+	if level == 0 {
+		return "core set debug off"
+	}
+	return fmt.Sprintf("core set debug %d", level)
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/logstore"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/retention"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var logsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Archive and prune locally stored troubleshoot data",
+	Long: `Manage data the CLI stores under ~/.agent: saved troubleshoot runs
+(collected logs + reports), the call cache and the call index, and the
+debug and selfcheck bundles it writes.
+
+Collected logs live in ~/.agent/store, zstd-compressed and stored once
+however many runs and cache entries refer to them. Error lines that
+recur across calls (the same provider failure or traceback on every
+call) are kept once in a shared line table. Logs no run or cache entry
+refers to any more are deleted by 'agent logs prune'.
+
+Retention policy (~/.agent/config):
+  retention:
+    runs: 30d      # saved troubleshoot runs
+    index: 30d     # call index entries
+    bundles: 30d   # debug-<time>.tar.gz and agent-selfcheck-<time>.tar.gz
+    spool: 30d     # syslog lines received by 'agent serve'
+
+Ages accept days (7d), weeks (2w) or durations (36h).
+
+Call transcripts are not stored by the CLI: they are in the engine's
+call history, pruned by CALL_HISTORY_RETENTION_DAYS in .env, and in the
+logs of saved runs, pruned with the runs.`,
+}
+
+var logsArchiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Archive old troubleshoot runs to a directory or S3",
+	Long: `Pack saved troubleshoot runs older than --older-than into a tar.zst and
+store it in a local directory or upload it to S3 (requires the aws CLI).
+Each run's logs are included as logs.txt; unpack with 'tar --zstd -xf'.
+
+Examples:
+  agent logs archive --older-than 7d --to /mnt/backup/agent
+  agent logs archive --older-than 7d --to s3://pbx-archive/agent --delete`,
+	Args: cobra.NoArgs,
+	RunE: runLogsArchive,
+}
+
+var logsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete local data past its retention period",
+	Long: `Delete saved troubleshoot runs, call index entries, bundles and
+syslog spool files older than the retention policy in ~/.agent/config (default 30 days each), then
+the stored logs no saved run or cached call refers to any more.
+
+Bundles are looked for in --bundles-dir (default the current directory,
+where 'agent debug' and 'agent selfcheck bundle' write them). Only files
+named as the CLI names its bundles are deleted; bundles written
+elsewhere with --output are left alone.
+
+Examples:
+  agent logs prune --dry-run
+  agent logs prune --older-than 14d
+  agent logs prune --bundles-dir /root/asterisk-ai-voice-agent`,
+	Args: cobra.NoArgs,
+	RunE: runLogsPrune,
+}
+
+var (
+	archiveOlderThan string
+	archiveTo        string
+	archiveDelete    bool
+	pruneOlderThan   string
+	pruneBundlesDir  string
+)
+
+func init() {
+	logsArchiveCmd.Flags().StringVar(&archiveOlderThan, "older-than", "7d", "archive runs older than this")
+	logsArchiveCmd.Flags().StringVar(&archiveTo, "to", "", "destination directory or s3://bucket/prefix (required)")
+	logsArchiveCmd.Flags().BoolVar(&archiveDelete, "delete", false, "delete runs locally once archived")
+	logsArchiveCmd.MarkFlagRequired("to")
+
+	logsPruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "override the run, index, bundle and spool retention period")
+	logsPruneCmd.Flags().StringVar(&pruneBundlesDir, "bundles-dir", ".", "directory holding debug and selfcheck bundles")
+	addDryRunFlag(logsPruneCmd)
+
+	logsCmd.AddCommand(logsArchiveCmd)
+	logsCmd.AddCommand(logsPruneCmd)
+	rootCmd.AddCommand(logsCmd)
+}
+
+func runLogsArchive(cmd *cobra.Command, args []string) error {
+	age, err := settings.ParseAge(archiveOlderThan)
+	if err != nil {
+		return fmt.Errorf("--older-than: %w", err)
+	}
+
+	runs, err := retention.OldRuns(time.Now().Add(-age))
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		fmt.Printf("No troubleshoot runs older than %s\n", archiveOlderThan)
+		return nil
+	}
+
+	var size int64
+	for _, run := range runs {
+		size += retention.RunSize(run)
+	}
+	fmt.Printf("Archiving %d run(s) (%s)...\n", len(runs), formatSize(size))
+
+	location, err := retention.Archive(cmd.Context(), runs, archiveTo)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("✅ Archived to %s\n", location)
+
+	if archiveDelete {
+		if err := retention.RemoveRuns(runs); err != nil {
+			return fmt.Errorf("archived, but deleting local runs failed: %w", err)
+		}
+		fmt.Printf("✅ Deleted %d local run(s)\n", len(runs))
+	}
+	return nil
+}
+
+func runLogsPrune(cmd *cobra.Command, args []string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	runAge, err := cfg.Retention.RunMaxAge()
+	if err != nil {
+		return err
+	}
+	indexAge, err := cfg.Retention.IndexMaxAge()
+	if err != nil {
+		return err
+	}
+	bundleAge, err := cfg.Retention.BundleMaxAge()
+	if err != nil {
+		return err
+	}
+	spoolAge, err := cfg.Retention.SpoolMaxAge()
+	if err != nil {
+		return err
+	}
+	if pruneOlderThan != "" {
+		age, err := settings.ParseAge(pruneOlderThan)
+		if err != nil {
+			return fmt.Errorf("--older-than: %w", err)
+		}
+		runAge, indexAge, bundleAge, spoolAge = age, age, age, age
+	}
+
+	now := time.Now()
+	runs, err := retention.OldRuns(now.Add(-runAge))
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, run := range runs {
+		size += retention.RunSize(run)
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+		for _, run := range runs {
+			planRemove(filepath.Join(troubleshoot.RunsDir(), run.ID))
+		}
+	} else if err := retention.RemoveRuns(runs); err != nil {
+		return err
+	}
+	fmt.Printf("%s %d troubleshoot run(s) older than %s (%s)\n", verb, len(runs), formatAge(runAge), formatSize(size))
+
+	removed, err := retention.PruneIndex(now.Add(-indexAge), dryRun)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %d call index entries older than %s\n", verb, removed, formatAge(indexAge))
+
+	bundles, bundleSize, err := retention.OldBundles(pruneBundlesDir, now.Add(-bundleAge))
+	if err != nil {
+		return fmt.Errorf("bundles: %w", err)
+	}
+	if dryRun {
+		for _, path := range bundles {
+			planRemove(path)
+		}
+	} else if err := retention.RemoveFiles(bundles); err != nil {
+		return err
+	}
+	fmt.Printf("%s %d bundle(s) older than %s in %s (%s)\n", verb, len(bundles), formatAge(bundleAge), pruneBundlesDir, formatSize(bundleSize))
+
+	spooled, spoolSize := 0, int64(0)
+	if dryRun {
+		var spool []string
+		spool, spoolSize, err = retention.OldSpoolFiles(now.Add(-spoolAge))
+		for _, path := range spool {
+			planRemove(path)
+		}
+		spooled = len(spool)
+	} else {
+		spooled, spoolSize, err = retention.PruneSpool(now.Add(-spoolAge))
+	}
+	if err != nil {
+		return fmt.Errorf("spool: %w", err)
+	}
+	fmt.Printf("%s %d syslog spool file(s) older than %s (%s)\n", verb, spooled, formatAge(spoolAge), formatSize(spoolSize))
+
+	if !dryRun {
+		logs, freed, err := retention.PruneStore()
+		if err != nil {
+			return fmt.Errorf("log store: %w", err)
+		}
+		fmt.Printf("Deleted %d stored log(s) no longer used (%s)\n", logs, formatSize(freed))
+	}
+	if usage, err := logstore.DiskUsage(); err == nil && usage.Logs > 0 {
+		fmt.Printf("Log store: %d log(s), %s in %s on disk\n", usage.Logs, formatSize(usage.Original), formatSize(usage.Stored))
+	}
+	if dryRun {
+		dryRunDone()
+	}
+	return nil
+}
+
+func formatAge(d time.Duration) string {
+	if d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", int(d/(24*time.Hour)))
+	}
+	return d.String()
+}
+
+func formatSize(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
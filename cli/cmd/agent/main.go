@@ -1,6 +1,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -11,10 +12,24 @@ var (
 	version   = "4.1.0-dev"  // Overridden at build time via -ldflags
 	buildTime = "unknown"     // Overridden at build time via -ldflags
 	verbose   bool
+	timezone  string
 )
 
+// updatePublicKey is the base64 ed25519 key release checksums and the
+// known-issue rules are signed with, set at build time via -ldflags.
+// Without it, updates and rules are refused unless the signature check
+// is skipped explicitly.
+var updatePublicKey = ""
+
 func main() {
-	if err := rootCmd.Execute(); err != nil {
+	registerCompletions()
+	err := rootCmd.Execute()
+	endSelfLog(err)
+	var exit *exitCodeError
+	if errors.As(err, &exit) {
+		os.Exit(exit.code)
+	}
+	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(1)
 	}
@@ -28,13 +43,84 @@ var rootCmd = &cobra.Command{
 Available commands:
   init        Interactive setup wizard
   doctor      System health check and diagnostics
+  diagnose    Why ai_engine restarted: crash loops, OOM, config errors
+  network     Firewall audit for the stack's ports, closest provider regions
+  config      Validate, watch and canary-deploy the configuration
   demo        Audio pipeline validation
+  dialplan    Dialplan snippets and agent extensions
+  sip         PJSIP trunk wizard for common ITSPs, SBC interop checks
+  route       Verify which route an inbound DID takes
+  deploy      Kubernetes manifests and Helm chart from the config
+  install     systemd units, shell completion and package files
+  calls       Monitor live calls (listen, watch, wallboard), tag calls
+  caller      Every call from one caller with outcomes
+  drain       Stop new calls and wait for active ones before maintenance
   troubleshoot Post-call analysis and RCA
-  version     Show version information`,
+  feedback    Rate a troubleshoot run's RCA to improve later runs
+  rules       Update and list known-issue rules for troubleshoot
+  report      Weekly quality report and trunk scorecards across calls
+  stt         Custom vocabulary (keyword boosts) across STT providers
+  tts         Check SSML against the TTS provider and preview it
+  export      Per-call metrics, transcripts as JSONL, or sync to Postgres/BigQuery
+  tenants     Per-tenant quotas with alerts and engine throttling
+  crm         Log call outcomes in HubSpot or Salesforce
+  integrations Check the external APIs the agent's tools call
+  selfcheck   Bundle the CLI's own log for support
+  remote      Check the SSH connection to the PBX host for --remote
+  theme       Preview the output themes (colorblind-safe, monochrome)
+  shell       Interactive shell with warm log cache
+  logging     Log forwarding (Loki, Elasticsearch, S3) and Asterisk log levels
+  debug       Engine debug logging window with a log bundle
+  replay      Rerun a past call's caller audio through the pipeline
+  regress     Regression suite of recorded calls with expected outcomes
+  call        Scripted synthetic test calls (call test --scenario)
+  monitor     Canary calls (monitor synthetic) and toll-fraud alerts (monitor security)
+  logs        Archive and prune local troubleshoot data
+  recordings  List, export and prune call recordings
+  snapshot    Capture and diff the deployment state
+  scale       Run several engine instances with round-robin dialplan
+  failover    Drill a switchover of an active/standby pair
+  serve       Long-lived services (syslog ingestion, live events)
+  service     Health-aware restarts of ai_engine and Asterisk
+  notify      Notification channels (Telegram, Teams, webhooks)
+  self-update Update the CLI from GitHub releases
+  version     Show version information
+  compose     Generate docker-compose.yml from the config
+  completion  Generate shell completion (bash, zsh, fish, powershell)
+
+Containers are managed through the Docker Engine API, so the docker CLI
+is not required. The daemon is found like the docker CLI finds it:
+DOCKER_HOST (with DOCKER_TLS_VERIFY/DOCKER_TLS/DOCKER_CERT_PATH), DOCKER_CONTEXT or
+the current 'docker context', else the first local Docker or Podman
+socket (rootful or rootless). Only compose steps still run a CLI.
+
+From a Windows or macOS workstation, --remote user@host (or remote.host
+in ~/.agent/config) runs every command against the PBX over SSH; see
+'agent remote check'.
+
+Output colors follow the theme in ~/.agent/config ('theme: colorblind'
+or 'theme: mono'), or --theme; see 'agent theme'.
+
+Enable completion, e.g. for bash:
+  source <(agent completion bash)`,
 	SilenceUsage:  true,
 	SilenceErrors: true,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		applyTheme()
+		if err := applyRemote(); err != nil {
+			return err
+		}
+		startSelfLog(cmd)
+		warnIncompatibleEngine(cmd)
+		return nil
+	},
 }
 
 func init() {
 	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false, "verbose output")
+	rootCmd.PersistentFlags().StringVar(&timezone, "tz", "", "timezone for displayed/parsed times (e.g. Europe/Berlin, UTC, Local)")
+	rootCmd.PersistentFlags().StringVar(&logFile, "log-file", "", "log the CLI's own commands and API calls here (default ~/.agent/cli.log, off to disable)")
+	rootCmd.PersistentFlags().BoolVar(&debugLog, "debug", false, "log command output too and echo the CLI's own log to stderr")
+	rootCmd.PersistentFlags().StringVar(&remoteHost, "remote", "", "run against this Linux host over SSH (ssh://user@host[:port]; default from ~/.agent/config, local to ignore it)")
+	rootCmd.PersistentFlags().StringVar(&themeName, "theme", "", "output theme: default, colorblind or mono (default from ~/.agent/config)")
 }
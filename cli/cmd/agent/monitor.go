@@ -0,0 +1,445 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/healthz"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/monitoring"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/scenario"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/synthetic"
+	"github.com/spf13/cobra"
+)
+
+var monitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Synthetic monitoring and security watch of the agent",
+}
+
+var monitorSyntheticCmd = &cobra.Command{
+	Use:   "synthetic",
+	Short: "Place a scripted canary call periodically and alert when it fails",
+	Long: `Place a canary call from a scenario file (see 'agent call test') every
+--every, so an outage is caught before customers call.
+
+Each run is recorded in ~/.agent/synthetic.jsonl (see 'agent monitor
+history') and pushed to the StatsD/InfluxDB sinks of the monitoring:
+section, tagged scenario and status:
+  synthetic_call_ms       the whole call
+  synthetic_response_ms   the slowest expect or tool step
+  synthetic_passed        1 or 0
+  synthetic_runs, synthetic_failures
+A failed run sends a critical synthetic_failed notification, and the
+first run passing after a failure an info one.
+
+Without --once the command runs until stopped, e.g. as a service. With
+--once it places one call and exits non-zero when it fails, for cron or
+a systemd timer; --cron prints the crontab line that does so.
+
+--health serves /healthz (liveness: a canary was placed within --every
+plus the call timeout) and /readyz (readiness: the history file and
+metrics sinks accept writes) for orchestrators supervising the monitor.
+A failing canary is an outage of the agent, not of the monitor, so it
+does not fail either.
+
+Examples:
+  agent monitor synthetic --scenario smoke.yaml --every 15m
+  agent monitor synthetic --scenario smoke.yaml --once
+  agent monitor synthetic --scenario smoke.yaml --every 30m --cron
+  agent monitor synthetic --scenario smoke.yaml --health :8091`,
+	Args: cobra.NoArgs,
+	RunE: runMonitorSynthetic,
+}
+
+var monitorHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "Show recorded canary runs: pass rate, latency and failures",
+	Long: `Summarize the canary runs recorded by 'agent monitor synthetic'.
+
+Examples:
+  agent monitor history
+  agent monitor history --scenario smoke --since 7d
+  agent monitor history --format json`,
+	Args: cobra.NoArgs,
+	RunE: runMonitorHistory,
+}
+
+var (
+	monitorScenario   string
+	monitorEvery      time.Duration
+	monitorOnce       bool
+	monitorCron       bool
+	monitorDial       string
+	monitorContainer  string
+	monitorListenHost string
+	monitorNoNotify   bool
+	monitorHealth     string
+	monitorSince      string
+	monitorFormat     string
+)
+
+func init() {
+	f := monitorSyntheticCmd.Flags()
+	f.StringVar(&monitorScenario, "scenario", "", "scenario file (YAML) of the canary call")
+	f.DurationVar(&monitorEvery, "every", 15*time.Minute, "how often the canary call is placed")
+	f.BoolVar(&monitorOnce, "once", false, "place one call and exit (for cron or a systemd timer)")
+	f.BoolVar(&monitorCron, "cron", false, "print a crontab line running --once every --every, and exit")
+	f.StringVar(&monitorDial, "dial", "", "endpoint to call instead of the scenario's dial")
+	f.StringVar(&monitorContainer, "container", engine.ContainerName, "engine container whose log holds the agent's responses")
+	f.StringVar(&monitorListenHost, "listen-host", "", "address Asterisk sends the audio to (default: this machine's address toward Asterisk)")
+	f.BoolVar(&monitorNoNotify, "no-notify", false, "do not send synthetic_failed notifications")
+	f.StringVar(&monitorHealth, "health", "", "serve /healthz and /readyz on this address (e.g. :8091)")
+	monitorSyntheticCmd.MarkFlagRequired("scenario")
+
+	h := monitorHistoryCmd.Flags()
+	h.StringVar(&monitorScenario, "scenario", "", "only this scenario (by name)")
+	h.StringVar(&monitorSince, "since", "24h", "how far back, e.g. 24h or 7d")
+	h.StringVar(&monitorFormat, "format", "text", "output format: text|json")
+
+	monitorCmd.AddCommand(monitorSyntheticCmd, monitorHistoryCmd)
+	rootCmd.AddCommand(monitorCmd)
+}
+
+func runMonitorSynthetic(cmd *cobra.Command, args []string) error {
+	if monitorEvery < time.Minute {
+		return fmt.Errorf("--every must be at least 1m")
+	}
+	sc, err := scenario.Load(monitorScenario)
+	if err != nil {
+		return err
+	}
+	if monitorDial != "" {
+		sc.Dial = monitorDial
+	}
+	if sc.Dial == "" {
+		return fmt.Errorf("no endpoint to call: set dial: in the scenario or --dial")
+	}
+	if monitorCron {
+		return printMonitorCron(cmd)
+	}
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	sinks, err := monitoring.NewSinks(monitoring.Config{
+		StatsD: monitoring.StatsDConfig{
+			Address:   cfg.Monitoring.StatsD.Address,
+			Prefix:    cfg.Monitoring.StatsD.Prefix,
+			DogStatsD: cfg.Monitoring.StatsD.DogStatsD,
+		},
+		InfluxDB: monitoring.InfluxConfig{
+			URL:         cfg.Monitoring.InfluxDB.URL,
+			Token:       cfg.Monitoring.InfluxDB.Token,
+			Username:    cfg.Monitoring.InfluxDB.Username,
+			Password:    cfg.Monitoring.InfluxDB.Password,
+			Measurement: cfg.Monitoring.InfluxDB.Measurement,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if monitorNoNotify {
+		cfg.Notifications = nil
+	}
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+	// A run starts every --every and lasts at most the call timeout
+	health := healthz.New(monitorEvery + sc.CallTimeout() + time.Minute)
+	health.Expect("canary")
+	if !monitorOnce {
+		fmt.Printf("📞 Canary %s → %s every %s (Ctrl-C to stop)\n", sc.Name, sc.Dial, monitorEvery)
+		if monitorHealth != "" {
+			mux := http.NewServeMux()
+			health.Register(mux)
+			go func() {
+				if err := serveHTTP(ctx, monitorHealth, mux); err != nil {
+					fmt.Fprintf(os.Stderr, "⚠️  Health endpoint: %v\n", err)
+					cancel()
+				}
+			}()
+			fmt.Printf("🩺 Health on http://%s/healthz and /readyz\n", displayAddr(monitorHealth))
+		}
+	}
+	for {
+		started := time.Now()
+		run := runCanary(ctx, sc, logLoc)
+		if ctx.Err() != nil {
+			// Stopped mid-call: not an outage
+			return nil
+		}
+		health.Beat()
+		health.Seen("canary")
+		previous, err := synthetic.Last(sc.Name)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Reading history: %v\n", err)
+		}
+		err = synthetic.Append(run)
+		health.Set("history", err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Recording run: %v\n", err)
+		}
+		printCanaryRun(run, loc)
+		err = monitoring.PushAll(ctx, sinks, canarySample(run))
+		health.Set("metrics", err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Metrics push failed: %v\n", err)
+		}
+		notifyCanary(ctx, notifier, run, previous)
+
+		if monitorOnce {
+			if !run.Passed {
+				return fmt.Errorf("canary %s failed", sc.Name)
+			}
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(time.Until(started.Add(monitorEvery))):
+		}
+	}
+}
+
+// runCanary places one canary call; a call that cannot be placed is a
+// failed run
+func runCanary(ctx context.Context, sc *scenario.Scenario, logLoc *time.Location) *synthetic.Run {
+	started := time.Now()
+	cctx, cancel := context.WithTimeout(ctx, sc.CallTimeout())
+	defer cancel()
+	report, err := runScenario(cctx, sc, monitorContainer, monitorListenHost, logLoc, false)
+	if err != nil {
+		return &synthetic.Run{
+			Time:       started.UTC(),
+			Scenario:   sc.Name,
+			Error:      err.Error(),
+			DurationMs: time.Since(started).Milliseconds(),
+		}
+	}
+	return synthetic.FromReport(report, started)
+}
+
+// canarySample converts a run into the metrics pushed per canary call
+func canarySample(run *synthetic.Run) monitoring.CallSample {
+	sample := monitoring.CallSample{
+		CallID:  run.CallID,
+		Time:    run.Time,
+		Tags:    map[string]string{"scenario": run.Scenario, "status": "passed"},
+		Timings: map[string]float64{"synthetic_call_ms": float64(run.DurationMs)},
+		Values:  map[string]float64{"synthetic_passed": 1},
+		Counts:  map[string]int{"synthetic_runs": 1, "synthetic_failures": 0},
+	}
+	if run.ResponseMs > 0 {
+		sample.Timings["synthetic_response_ms"] = float64(run.ResponseMs)
+	}
+	if !run.Passed {
+		sample.Tags["status"] = "failed"
+		sample.Values["synthetic_passed"] = 0
+		sample.Counts["synthetic_failures"] = 1
+	}
+	return sample
+}
+
+// notifyCanary sends synthetic_failed for a failed run, and an info
+// event when a scenario passes again after failing
+func notifyCanary(ctx context.Context, notifier *notify.Notifier, run, previous *synthetic.Run) {
+	host, _ := os.Hostname()
+	ev := notify.Event{
+		Kind:   notify.EventSyntheticFailed,
+		CallID: run.CallID,
+		Fields: map[string]string{"Host": host, "Scenario": run.Scenario},
+		Time:   run.Time,
+		Data:   run,
+	}
+	switch {
+	case !run.Passed:
+		ev.Severity = notify.SeverityCritical
+		ev.Title = "Canary call failed: " + run.Scenario
+		ev.Text = canaryFailure(run)
+	case previous != nil && !previous.Passed:
+		ev.Severity = notify.SeverityInfo
+		ev.Title = "Canary call recovered: " + run.Scenario
+		ev.Text = fmt.Sprintf("Passed in %s", formatLatency(time.Duration(run.DurationMs)*time.Millisecond))
+	default:
+		return
+	}
+	if _, err := notifier.Notify(ctx, ev); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Notification failed: %v\n", err)
+	}
+}
+
+func canaryFailure(run *synthetic.Run) string {
+	if run.Error != "" {
+		return run.Error
+	}
+	if run.Detail != "" {
+		return run.FailedStep + ": " + run.Detail
+	}
+	return run.FailedStep
+}
+
+func printCanaryRun(run *synthetic.Run, loc *time.Location) {
+	at := run.Time.In(loc).Format("2006-01-02 15:04:05")
+	if !run.Passed {
+		fmt.Printf("❌ %s  %s failed: %s\n", at, run.Scenario, canaryFailure(run))
+		return
+	}
+	fmt.Printf("✅ %s  %s passed in %s (slowest response %s)\n", at, run.Scenario,
+		(time.Duration(run.DurationMs) * time.Millisecond).Round(time.Second),
+		formatLatency(time.Duration(run.ResponseMs)*time.Millisecond))
+}
+
+// printMonitorCron prints the crontab line placing the canary call
+// every --every from the current directory, where .env is read
+func printMonitorCron(cmd *cobra.Command) error {
+	schedule, err := cronSchedule(monitorEvery)
+	if err != nil {
+		return err
+	}
+	agentPath, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot locate the agent binary: %w", err)
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	scenarioPath, err := filepath.Abs(monitorScenario)
+	if err != nil {
+		return err
+	}
+	command := []string{agentPath, "monitor", "synthetic", "--once", "--scenario", scenarioPath}
+	for _, name := range []string{"dial", "container", "listen-host", "no-notify"} {
+		if flag := cmd.Flags().Lookup(name); flag.Changed {
+			command = append(command, "--"+name+"="+flag.Value.String())
+		}
+	}
+	for i, arg := range command {
+		command[i] = shellQuote(arg)
+	}
+	fmt.Printf("%s cd %s && %s >> %s 2>&1\n", schedule, shellQuote(dir), strings.Join(command, " "),
+		shellQuote(filepath.Join(settings.Dir(), "synthetic.log")))
+	return nil
+}
+
+// cronSchedule turns an interval into a cron schedule; cron only runs
+// at intervals dividing an hour or a day
+func cronSchedule(every time.Duration) (string, error) {
+	minutes := int(every / time.Minute)
+	if every%time.Minute == 0 {
+		switch {
+		case minutes == 1:
+			return "* * * * *", nil
+		case minutes < 60 && 60%minutes == 0:
+			return fmt.Sprintf("*/%d * * * *", minutes), nil
+		case minutes == 24*60:
+			return "0 0 * * *", nil
+		case minutes%60 == 0 && minutes < 24*60 && (24*60)%minutes == 0:
+			return fmt.Sprintf("0 */%d * * *", minutes/60), nil
+		}
+	}
+	return "", fmt.Errorf("cron cannot run every %s: use an interval dividing an hour or a day, or run without --cron as a service", every)
+}
+
+func shellQuote(s string) string {
+	if s != "" && strings.IndexFunc(s, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("/._-=:@+,", r))
+	}) < 0 {
+		return s
+	}
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+func runMonitorHistory(cmd *cobra.Command, args []string) error {
+	if monitorFormat != "text" && monitorFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", monitorFormat)
+	}
+	age, err := settings.ParseAge(monitorSince)
+	if err != nil {
+		return fmt.Errorf("--since: %w", err)
+	}
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	runs, err := synthetic.Load(monitorScenario, time.Now().Add(-age))
+	if err != nil {
+		return err
+	}
+	if monitorFormat == "json" {
+		if runs == nil {
+			runs = []*synthetic.Run{}
+		}
+		out, err := json.MarshalIndent(runs, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+	if len(runs) == 0 {
+		fmt.Printf("No canary runs in the last %s (see 'agent monitor synthetic')\n", monitorSince)
+		return nil
+	}
+
+	byScenario := make(map[string][]*synthetic.Run)
+	var names []string
+	for _, run := range runs {
+		if _, ok := byScenario[run.Scenario]; !ok {
+			names = append(names, run.Scenario)
+		}
+		byScenario[run.Scenario] = append(byScenario[run.Scenario], run)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		runs := byScenario[name]
+		var failures []*synthetic.Run
+		var responses []time.Duration
+		for _, run := range runs {
+			if !run.Passed {
+				failures = append(failures, run)
+			} else if run.ResponseMs > 0 {
+				responses = append(responses, time.Duration(run.ResponseMs)*time.Millisecond)
+			}
+		}
+		status := "✅"
+		if last := runs[len(runs)-1]; !last.Passed {
+			status = "❌"
+		}
+		passRate := 100 * float64(len(runs)-len(failures)) / float64(len(runs))
+		fmt.Printf("%s %s: %d run(s), %.1f%% passed", status, name, len(runs), passRate)
+		if len(responses) > 0 {
+			sort.Slice(responses, func(i, j int) bool { return responses[i] < responses[j] })
+			fmt.Printf(", response p50 %s, max %s", formatLatency(responses[len(responses)/2]), formatLatency(responses[len(responses)-1]))
+		}
+		fmt.Println()
+		// The latest failures are the ones worth reading
+		if len(failures) > 10 {
+			fmt.Printf("   … %d earlier failure(s)\n", len(failures)-10)
+			failures = failures[len(failures)-10:]
+		}
+		for _, run := range failures {
+			fmt.Printf("   %s  %s\n", run.Time.In(loc).Format("2006-01-02 15:04"), canaryFailure(run))
+		}
+	}
+	return nil
+}
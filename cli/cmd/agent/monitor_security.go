@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/fraud"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/healthz"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var monitorSecurityCmd = &cobra.Command{
+	Use:   "security",
+	Short: "Alert on toll-fraud call patterns and SIP brute force as they happen",
+	Long: `Watch every outbound call Asterisk places (ARI Dial events of all
+channels, including calls the agent originates with its transfer and
+outbound tools) and Asterisk's security log, and alert immediately on:
+
+  volume_spike          --max-calls outbound calls within --window, or a
+                        --spike-fold jump over the last hour's average
+  premium_destination   premium-rate numbers and destinations common in
+                        international revenue share fraud (Cuba, Somalia,
+                        satellite networks, UK 070/09, US 900, ...), plus
+                        --premium prefixes; --allow exempts prefixes
+  off_hours             outbound calls outside --hours on --days
+  brute_force           --auth-failures failed SIP requests from one
+                        address within --auth-window
+
+A compromised agent configuration or SIP account can dial out for hours
+before a bill arrives, so each alert is sent at once as a critical (or,
+for off-hours calls, warning) security_alert notification to the
+channels of ~/.agent/config, and not repeated for the same cause within
+--cooldown. Numbers shorter than 7 digits are extensions and ignored;
+--country-code lets national numbers be matched by their international
+prefix.
+
+--health serves /healthz and /readyz; readiness fails while the ARI
+event stream or the security log cannot be read.
+
+Examples:
+  agent monitor security
+  agent monitor security --country-code 1 --hours 08:00-18:00 --days mon-fri
+  agent monitor security --max-calls 10 --window 5m --allow +44 --premium +4487
+  agent monitor security --health :8092`,
+	Args: cobra.NoArgs,
+	RunE: runMonitorSecurity,
+}
+
+var (
+	secWindow       time.Duration
+	secMaxCalls     int
+	secSpike        float64
+	secCountryCode  string
+	secPremium      []string
+	secAllow        []string
+	secHours        string
+	secDays         string
+	secAuthFailures int
+	secAuthWindow   time.Duration
+	secLogs         []string
+	secCooldown     time.Duration
+	secNoNotify     bool
+	secHealth       string
+)
+
+func init() {
+	d := fraud.DefaultRules
+	f := monitorSecurityCmd.Flags()
+	f.DurationVar(&secWindow, "window", d.Window, "window outbound calls are counted in")
+	f.IntVar(&secMaxCalls, "max-calls", d.MaxCalls, "outbound calls within --window that raise an alert (0 = off)")
+	f.Float64Var(&secSpike, "spike", d.SpikeFactor, "alert when --window holds this many times the hour's average (0 = off)")
+	f.StringVar(&secCountryCode, "country-code", "", "country code of national numbers, e.g. 1 or 44")
+	f.StringSliceVar(&secPremium, "premium", nil, "extra destination prefix to alert on (repeatable, e.g. +4487)")
+	f.StringSliceVar(&secAllow, "allow", nil, "destination prefix never alerted on (repeatable)")
+	f.StringVar(&secHours, "hours", "", "business hours, e.g. 08:00-18:00; calls outside alert (default: off)")
+	f.StringVar(&secDays, "days", "mon-fri", "business days for --hours, e.g. mon-fri or mon,wed,fri")
+	f.IntVar(&secAuthFailures, "auth-failures", d.AuthFailures, "failed SIP requests from one address that raise an alert (0 = off)")
+	f.DurationVar(&secAuthWindow, "auth-window", d.AuthWindow, "window failed SIP requests are counted in")
+	f.StringSliceVar(&secLogs, "security-log", troubleshoot.DefaultAsteriskLogs[:2], "Asterisk log to follow for failed SIP requests (repeatable)")
+	f.DurationVar(&secCooldown, "cooldown", d.Cooldown, "how long an alert is not repeated for the same cause")
+	f.BoolVar(&secNoNotify, "no-notify", false, "only print alerts, send no notifications")
+	f.StringVar(&secHealth, "health", "", "serve /healthz and /readyz on this address (e.g. :8092)")
+
+	monitorCmd.AddCommand(monitorSecurityCmd)
+}
+
+func runMonitorSecurity(cmd *cobra.Command, args []string) error {
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	rules := fraud.Rules{
+		MaxCalls:     secMaxCalls,
+		Window:       secWindow,
+		SpikeFactor:  secSpike,
+		CountryCode:  strings.TrimPrefix(secCountryCode, "+"),
+		Premium:      secPremium,
+		Allowed:      secAllow,
+		Hours:        secHours,
+		Location:     loc,
+		AuthFailures: secAuthFailures,
+		AuthWindow:   secAuthWindow,
+		Cooldown:     secCooldown,
+	}
+	if secHours != "" {
+		if rules.Days, err = fraud.ParseDays(secDays); err != nil {
+			return fmt.Errorf("--days: %w", err)
+		}
+	}
+	watcher, err := fraud.New(rules, time.Now())
+	if err != nil {
+		return err
+	}
+	client, err := ariFromEnvFile()
+	if err != nil {
+		return err
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	if secNoNotify {
+		cfg.Notifications = nil
+	}
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+	health := healthz.New(2 * time.Minute)
+	if secHealth != "" {
+		mux := http.NewServeMux()
+		health.Register(mux)
+		go func() {
+			if err := serveHTTP(ctx, secHealth, mux); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Health endpoint: %v\n", err)
+				cancel()
+			}
+		}()
+		fmt.Printf("🩺 Health on http://%s/healthz and /readyz\n", displayAddr(secHealth))
+	}
+
+	dials := make(chan fraud.Dial, 64)
+	failures := make(chan troubleshoot.SecurityEvent, 256)
+	go watchDials(ctx, client, dials, health)
+	for _, path := range secLogs {
+		go followSecurityLog(ctx, path, failures, health)
+	}
+	fmt.Printf("🛡️  Watching outbound calls (ARI) and %s (Ctrl-C to stop)\n", strings.Join(secLogs, ", "))
+
+	beat := time.NewTicker(30 * time.Second)
+	defer beat.Stop()
+	for {
+		var alerts []fraud.Alert
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-beat.C:
+			health.Beat()
+		case d := <-dials:
+			fmt.Printf("%s 📞 %s via %s\n", d.Time.In(loc).Format("15:04:05"), d.Number, d.Via)
+			alerts = watcher.Dial(d)
+		case ev := <-failures:
+			alerts = watcher.AuthFailure(ev.IP, ev.Time, ev.Detail)
+		}
+		for _, a := range alerts {
+			sendSecurityAlert(ctx, notifier, a, loc)
+		}
+	}
+}
+
+// watchDials streams the Dial events of every channel, reconnecting
+// when the stream drops
+func watchDials(ctx context.Context, client *ari.Client, out chan<- fraud.Dial, health *healthz.State) {
+	app := fmt.Sprintf("aava-security-%d", os.Getpid())
+	health.Expect("ari")
+	for ctx.Err() == nil {
+		events, err := client.SubscribeAll(ctx, app)
+		health.Set("ari", err)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  ARI events: %v (retrying)\n", err)
+		} else {
+			for ev := range events.C {
+				health.Seen("ari")
+				// A Dial event without a status is the attempt starting
+				if ev.Type != "Dial" || ev.DialStatus != "" || ev.Peer == nil {
+					continue
+				}
+				d := fraud.Dial{Time: ariTime(ev.Timestamp), Number: fraud.Number(ev.DialString)}
+				if d.Number == "" {
+					d.Number = ev.Peer.Dialplan.Exten
+				}
+				if ev.Caller != nil {
+					d.Via = ev.Caller.Name
+					d.CallerID = ev.Caller.Caller.Number
+				} else {
+					d.Via = "ARI/AMI originate"
+					d.CallerID = ev.Peer.Caller.Number
+				}
+				select {
+				case out <- d:
+				case <-ctx.Done():
+				}
+			}
+			events.Close()
+			if ctx.Err() == nil {
+				err = events.Err
+				health.Set("ari", fmt.Errorf("event stream closed: %v", err))
+				fmt.Fprintf(os.Stderr, "⚠️  ARI event stream closed: %v (reconnecting)\n", err)
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+func ariTime(ts string) time.Time {
+	if t, err := time.Parse("2006-01-02T15:04:05.000-0700", ts); err == nil {
+		return t
+	}
+	return time.Now()
+}
+
+// followSecurityLog reads the lines appended to an Asterisk log, reopening
+// it when logrotate replaces it, and passes on failed SIP requests
+func followSecurityLog(ctx context.Context, path string, out chan<- troubleshoot.SecurityEvent, health *healthz.State) {
+	component := "log " + path
+	var f *os.File
+	var reader *bufio.Reader
+	defer func() {
+		if f != nil {
+			f.Close()
+		}
+	}()
+	first := true
+	for ctx.Err() == nil {
+		if f == nil {
+			var err error
+			f, err = os.Open(path)
+			health.Set(component, err)
+			if err == nil {
+				// Start at the end the first time, at the top of a new file
+				if first {
+					f.Seek(0, io.SeekEnd)
+				}
+				first = false
+				reader = bufio.NewReader(f)
+			}
+		}
+		if f != nil {
+			for {
+				line, err := reader.ReadString('\n')
+				if err != nil {
+					// A partial line is read again once complete
+					if len(line) > 0 {
+						f.Seek(-int64(len(line)), io.SeekCurrent)
+						reader.Reset(f)
+					}
+					break
+				}
+				if ev, ok := troubleshoot.ParseAsteriskSecurity(strings.TrimRight(line, "\r\n")); ok {
+					switch ev.Kind {
+					case troubleshoot.SecurityFailedAuth, troubleshoot.SecurityNoEndpoint, troubleshoot.SecurityACL:
+						select {
+						case out <- ev:
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+			}
+			if rotated(f, path) {
+				f.Close()
+				f = nil
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(time.Second):
+		}
+	}
+}
+
+// rotated reports whether path is no longer the open file, or the file
+// was truncated
+func rotated(f *os.File, path string) bool {
+	open, err := f.Stat()
+	if err != nil {
+		return true
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	pos, _ := f.Seek(0, io.SeekCurrent)
+	return !os.SameFile(open, current) || current.Size() < pos
+}
+
+func sendSecurityAlert(ctx context.Context, notifier *notify.Notifier, a fraud.Alert, loc *time.Location) {
+	icon := "🚨"
+	if a.Severity == fraud.SeverityWarning {
+		icon = "⚠️ "
+	}
+	fmt.Printf("%s %s %s\n   %s\n", a.Time.In(loc).Format("15:04:05"), icon, a.Title, a.Text)
+	host, _ := os.Hostname()
+	fields := map[string]string{"Host": host, "Alert": a.Kind}
+	for k, v := range a.Fields {
+		if v != "" {
+			fields[k] = v
+		}
+	}
+	ev := notify.Event{
+		Kind:     notify.EventSecurityAlert,
+		Severity: a.Severity,
+		Title:    a.Title,
+		Text:     a.Text,
+		Fields:   fields,
+		Time:     a.Time,
+		Data:     a,
+	}
+	if _, err := notifier.Notify(ctx, ev); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Notification failed: %v\n", err)
+	}
+}
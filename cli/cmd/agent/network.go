@@ -0,0 +1,226 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/firewall"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/wizard"
+	"github.com/spf13/cobra"
+)
+
+var networkCmd = &cobra.Command{
+	Use:   "network",
+	Short: "Check the host network for the stack",
+}
+
+var networkRulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Audit the host firewall for the ports the stack needs",
+	Long: `Work out the ports the stack needs on this host and check whether the
+host firewall (ufw, firewalld, nftables or iptables, detected in that
+order) lets them in:
+
+  SIP            pjsip.conf transports (default 5060/udp)
+  RTP            rtp.conf rtpstart-rtpend (default 10000-20000/udp)
+  AudioSocket    audiosocket.port, or ExternalMedia's port_range, only
+                 from ASTERISK_HOST when Asterisk runs elsewhere
+  Dashboard      the admin UI (UVICORN_PORT), when it is deployed
+
+SIP and RTP are checked when Asterisk's configuration is on this host.
+Each port is reported as allowed, blocked, or partial when only part of a
+range (or only another source) is let in. The first matching rule decides,
+as in the firewall itself; jumps to other chains and firewalld rich rules
+are not followed. Cloud security groups are not visible from the host.
+
+--emit prints the exact commands that open the blocked ports; --apply runs
+them after a confirmation. The dashboard is only opened to the network
+given with --dashboard-from.
+
+Examples:
+  agent network rules
+  agent network rules --emit
+  agent network rules --apply --dashboard-from 10.0.0.0/24
+  agent network rules --backend nftables --emit --format json`,
+	Args: cobra.NoArgs,
+	RunE: runNetworkRules,
+}
+
+var (
+	networkDir           string
+	networkAsteriskDir   string
+	networkBackend       string
+	networkEmit          bool
+	networkApply         bool
+	networkYes           bool
+	networkDashboardFrom string
+	networkFormat        string
+)
+
+func init() {
+	f := networkRulesCmd.Flags()
+	f.StringVar(&networkDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	f.StringVar(&networkAsteriskDir, "asterisk-dir", "/etc/asterisk", "Asterisk configuration directory (pjsip.conf, rtp.conf)")
+	f.StringVar(&networkBackend, "backend", "", "firewall to audit and write rules for: ufw, firewalld, nftables, iptables (default: detect)")
+	f.BoolVar(&networkEmit, "emit", false, "print the commands that open the blocked ports")
+	f.BoolVar(&networkApply, "apply", false, "run the commands that open the blocked ports")
+	f.BoolVarP(&networkYes, "yes", "y", false, "apply without asking")
+	f.StringVar(&networkDashboardFrom, "dashboard-from", "", "network allowed to reach the dashboard, e.g. 10.0.0.0/24")
+	f.StringVar(&networkFormat, "format", "text", "output format: text|json")
+	addDryRunFlag(networkRulesCmd)
+
+	networkCmd.AddCommand(networkRulesCmd)
+	rootCmd.AddCommand(networkCmd)
+}
+
+func runNetworkRules(cmd *cobra.Command, args []string) error {
+	if networkFormat != "text" && networkFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", networkFormat)
+	}
+	ports, err := firewall.Required(firewall.Options{Dir: networkDir, AsteriskDir: networkAsteriskDir})
+	if err != nil {
+		return err
+	}
+	for i := range ports {
+		if ports[i].Service == "Dashboard" {
+			ports[i].Source = networkDashboardFrom
+		}
+	}
+
+	ctx, cancel := runContext(time.Minute)
+	defer cancel()
+	rs, err := firewall.Detect(ctx, networkBackend)
+	if err != nil {
+		return err
+	}
+	statuses := rs.Audit(ports)
+
+	// Rules for what is not fully open; the dashboard only to a network
+	var open []firewall.Port
+	var skipped []string
+	for _, s := range statuses {
+		if s.State != firewall.Blocked && s.State != firewall.Partial {
+			continue
+		}
+		if s.Service == "Dashboard" && s.Source == "" {
+			skipped = append(skipped, "the dashboard is not opened to everyone: pass --dashboard-from with your admin network")
+			continue
+		}
+		open = append(open, s.Port)
+	}
+	var cmds [][]string
+	var notes []string
+	if (networkEmit || networkApply) && len(open) > 0 {
+		if cmds, notes, err = rs.Rules(open); err != nil {
+			return err
+		}
+	}
+
+	if networkFormat == "json" {
+		rendered := make([]string, len(cmds))
+		for i, c := range cmds {
+			rendered[i] = firewall.Shell(c)
+		}
+		out, err := json.MarshalIndent(map[string]interface{}{
+			"firewall": rs,
+			"ports":    statuses,
+			"rules":    rendered,
+			"notes":    append(notes, skipped...),
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		printNetworkAudit(rs, statuses)
+		for _, s := range skipped {
+			fmt.Printf("ℹ️  %s\n", s)
+		}
+		if len(cmds) > 0 {
+			fmt.Println()
+			fmt.Printf("Rules to open them (%s):\n", rs.Backend)
+			for _, c := range cmds {
+				fmt.Printf("  %s\n", firewall.Shell(c))
+			}
+			for _, n := range notes {
+				fmt.Printf("ℹ️  %s\n", n)
+			}
+		}
+	}
+
+	if !networkApply || len(cmds) == 0 {
+		return nil
+	}
+	fmt.Println()
+	if dryRun {
+		for _, c := range cmds {
+			planCommand("", firewall.Shell(c))
+		}
+		dryRunDone()
+		return nil
+	}
+	if !networkYes && !wizard.PromptConfirm(fmt.Sprintf("Run these %d command(s)?", len(cmds)), false) {
+		fmt.Println("Nothing applied.")
+		return nil
+	}
+	// The minute starts after the prompt, however long it waited
+	applyCtx, applyCancel := runContext(time.Minute)
+	defer applyCancel()
+	if err := firewall.Apply(applyCtx, cmds); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Applied %d rule command(s) to %s\n", len(cmds), rs.Backend)
+	return nil
+}
+
+var networkStateIcons = map[string]string{
+	firewall.Allowed: "✅",
+	firewall.Blocked: "❌",
+	firewall.Partial: "⚠️ ",
+	firewall.Local:   "➖",
+	firewall.Unknown: "❓",
+}
+
+func printNetworkAudit(rs *firewall.Ruleset, statuses []firewall.Status) {
+	switch {
+	case rs.Active && rs.Zone != "":
+		fmt.Printf("🧱 Firewall: %s (zone %s)\n", rs.Backend, rs.Zone)
+	case rs.Active:
+		fmt.Printf("🧱 Firewall: %s\n", rs.Backend)
+	case rs.Backend != "":
+		fmt.Printf("🧱 Firewall: %s (not active)\n", rs.Backend)
+	default:
+		fmt.Println("🧱 Firewall: none active")
+	}
+	for _, n := range rs.Notes {
+		fmt.Printf("   %s\n", n)
+	}
+	fmt.Println()
+
+	blocked := 0
+	for _, s := range statuses {
+		from := ""
+		if s.Source != "" && !s.Local {
+			from = " from " + s.Source
+		}
+		fmt.Printf("%s %-13s %-17s %s%s\n", networkStateIcons[s.State], s.Service, s.Port.String(), s.State, from)
+		fmt.Printf("   %s\n", s.Why)
+		if s.Detail != "" {
+			fmt.Printf("   %s\n", s.Detail)
+		}
+		if s.State == firewall.Blocked || s.State == firewall.Partial {
+			blocked++
+		}
+	}
+	fmt.Println()
+	if blocked == 0 {
+		fmt.Println("✅ The firewall lets in every port the stack needs")
+		return
+	}
+	fmt.Printf("❌ %d port(s) blocked or partly open", blocked)
+	if !networkEmit && !networkApply {
+		fmt.Print(": run with --emit for the rules")
+	}
+	fmt.Println()
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/regions"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/wizard"
+	"github.com/spf13/cobra"
+)
+
+var networkRegionsCmd = &cobra.Command{
+	Use:   "regions",
+	Short: "Measure latency to each provider's regions and pick the closest",
+	Long: `Measure the round trip from this host to every regional endpoint of the
+cloud providers in config/ai-agent.yaml and recommend the closest region
+per provider. The engine defaults to the US endpoints, so a deployment in
+Europe or Asia pays an ocean crossing on every turn until the config
+names the nearer ones:
+
+  Deepgram       api.deepgram.com (US), api.eu.deepgram.com (EU)
+  OpenAI         api.openai.com (US), eu.api.openai.com (EU)
+  Google         speech/texttospeech.googleapis.com (global),
+                 us-/eu-speech, us-/eu-texttospeech
+  ElevenLabs     api.elevenlabs.io, api.eu/in.residency.elevenlabs.io
+
+The round trip is the median TCP connect time to port 443 over --samples
+probes; the TLS handshake and each request cost a multiple of it. A
+region is recommended when it is at least 20ms closer than the current
+one. Regional endpoints other than Google's only answer accounts set up
+for them (an EU data-residency project or workspace); the recommendation
+says which.
+
+The Deepgram Voice Agent, Gemini Live and ElevenLabs agents have no
+regional endpoint the engine can use; they are listed without one.
+
+--apply writes the recommended endpoints into config/ai-agent.yaml after
+a confirmation; the rest of the file is left as it is. Endpoints set
+through .env are reported for you to change there.
+
+Examples:
+  agent network regions
+  agent network regions --samples 10
+  agent network regions --apply
+  agent network regions --format json`,
+	Args: cobra.NoArgs,
+	RunE: runNetworkRegions,
+}
+
+var (
+	regionsDir     string
+	regionsSamples int
+	regionsApply   bool
+	regionsYes     bool
+	regionsFormat  string
+)
+
+func init() {
+	f := networkRegionsCmd.Flags()
+	f.StringVar(&regionsDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	f.IntVar(&regionsSamples, "samples", 5, "probes per endpoint")
+	f.BoolVar(&regionsApply, "apply", false, "write the closest regions into config/ai-agent.yaml")
+	f.BoolVarP(&regionsYes, "yes", "y", false, "apply without asking")
+	f.StringVar(&regionsFormat, "format", "text", "output format: text|json")
+
+	networkCmd.AddCommand(networkRegionsCmd)
+}
+
+func runNetworkRegions(cmd *cobra.Command, args []string) error {
+	if regionsFormat != "text" && regionsFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", regionsFormat)
+	}
+	profile, err := deploy.LoadProfile(regionsDir)
+	if err != nil {
+		return err
+	}
+	providers, err := regions.Providers(profile)
+	if err != nil {
+		return err
+	}
+	if len(providers) == 0 {
+		fmt.Println("No enabled cloud provider with regional endpoints in the config")
+		return nil
+	}
+
+	ctx, cancel := runContext(2 * time.Minute)
+	defer cancel()
+	recs := regions.Recommend(providers, regions.Measure(ctx, regions.Hosts(providers), regionsSamples))
+
+	if regionsFormat == "json" {
+		out, err := json.MarshalIndent(map[string]interface{}{
+			"min_gain_ms":     regions.MinGain.Milliseconds(),
+			"recommendations": recs,
+		}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		printRegions(recs)
+	}
+
+	switches := 0
+	for _, r := range recs {
+		if r.Switch {
+			switches++
+		}
+	}
+	if !regionsApply || switches == 0 {
+		return nil
+	}
+	fmt.Println()
+	if !regionsYes && !wizard.PromptConfirm(fmt.Sprintf("Move %d provider(s) to the closest region?", switches), false) {
+		fmt.Println("Nothing applied.")
+		return nil
+	}
+	changed, notes, err := regions.Apply(regionsDir, recs)
+	if err != nil {
+		return err
+	}
+	for _, n := range notes {
+		fmt.Printf("ℹ️  %s\n", n)
+	}
+	if changed > 0 {
+		fmt.Printf("✅ %d endpoint(s) written to config/ai-agent.yaml\n", changed)
+		fmt.Println("Restart the engine to apply: docker compose restart ai-engine")
+	}
+	return nil
+}
+
+func printRegions(recs []regions.Recommendation) {
+	switches, measured := 0, 0
+	for _, r := range recs {
+		p := r.Provider
+		use := ""
+		if !p.InUse {
+			use = " (not in use)"
+		}
+		if p.Unsupported != "" {
+			fmt.Printf("🌍 %s%s\n", p.Name, use)
+			fmt.Printf("   ➖ %s\n", p.Unsupported)
+			fmt.Println()
+			continue
+		}
+		current := r.Current
+		if current == "" {
+			current = "custom endpoint"
+		}
+		fmt.Printf("🌍 %s%s: %s\n", p.Name, use, current)
+		if r.Best != "" {
+			measured++
+		}
+		for _, rl := range r.Regions {
+			mark := "  "
+			if rl.Region == r.Best {
+				mark = "⭐"
+			}
+			if rl.Err != "" {
+				fmt.Printf("   %s %-7s %-22s unreachable: %s\n", mark, rl.Region, rl.Where, rl.Err)
+				continue
+			}
+			fmt.Printf("   %s %-7s %-22s %6.0fms\n", mark, rl.Region, rl.Where, rl.RTTMs)
+		}
+		switch {
+		case r.Switch:
+			switches++
+			fmt.Printf("   💡 Move to %s, %.0fms closer per round trip:\n", r.Best, r.Gain)
+			for _, c := range r.Changes {
+				where := "providers." + p.Name + "." + c.Key
+				if c.EnvVar != "" {
+					where = c.EnvVar + " in .env"
+				}
+				fmt.Printf("      %s: %s\n", where, c.To)
+			}
+			if r.Requires != "" {
+				fmt.Printf("      needs %s\n", r.Requires)
+			}
+		case r.Current == "":
+			fmt.Printf("   ℹ️  Endpoint %s is no known region; left as it is\n", strings.Join(settingHosts(p), ", "))
+		case r.Best != "":
+			fmt.Println("   ✅ Closest region in use")
+		}
+		fmt.Println()
+	}
+	if measured == 0 {
+		fmt.Println("⚠️  No region could be measured: check DNS and outbound HTTPS from this host")
+		return
+	}
+	if switches == 0 {
+		fmt.Println("✅ Every provider uses its closest region")
+		return
+	}
+	if !regionsApply {
+		fmt.Printf("%d provider(s) would be closer in another region: run with --apply to switch\n", switches)
+	}
+}
+
+func settingHosts(p regions.Provider) []string {
+	var hosts []string
+	for _, s := range p.Settings {
+		hosts = append(hosts, s.Host())
+	}
+	return hosts
+}
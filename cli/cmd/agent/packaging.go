@@ -0,0 +1,451 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/packaging"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/systemd"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var installCompletionCmd = &cobra.Command{
+	Use:   "completion [bash|zsh|fish|powershell]",
+	Short: "Install shell completion for the agent command",
+	Long: `Write the completion script of a shell to where the shell loads it
+from, so agent commands, flags and call IDs complete with Tab.
+
+Without an argument the shell is taken from $SHELL (PowerShell on
+Windows). Scripts go to the user's own directory, or with --system to
+the system-wide one (run as root):
+
+  bash        ~/.local/share/bash-completion/completions/agent
+              /usr/share/bash-completion/completions/agent
+  zsh         ~/.zsh/completions/_agent (add the directory to fpath)
+              /usr/local/share/zsh/site-functions/_agent
+  fish        ~/.config/fish/completions/agent.fish
+              /usr/share/fish/vendor_completions.d/agent.fish
+  powershell  ~/.agent/completion.ps1 (dot-source it from $PROFILE)
+
+Packages (Homebrew, apt, rpm) install completions already, see
+'agent install packaging'. Open a new shell after installing.
+
+Examples:
+  agent install completion
+  agent install completion zsh
+  sudo agent install completion bash --system
+  agent install completion fish --output ./agent.fish`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	RunE:      runInstallCompletion,
+}
+
+var installPackagingCmd = &cobra.Command{
+	Use:   "packaging",
+	Short: "Write the completions, man pages and default config of a package",
+	Long: `Write the files an OS package of the CLI installs next to the binary,
+for Homebrew formulas and apt/rpm package builds:
+
+  <prefix>/share/bash-completion/completions/agent
+  <prefix>/share/zsh/site-functions/_agent
+  <prefix>/share/fish/vendor_completions.d/agent.fish
+  <prefix>/share/man/man1/agent.1, agent-<command>.1, ...
+  <sysconfdir>/agent/config        default config (all commented out)
+  <prefix>/share/agent/postinstall.sh
+
+Users without a ~/.agent/config of their own get the default config:
+the CLI reads /etc/agent/config, or etc/agent/config under the
+binary's prefix for Homebrew.
+
+The post-install script runs 'agent init --post-install', which looks
+for an existing deployment (containers, systemd units, project
+directory) and indexes its recent calls so 'troubleshoot --call' and
+call ID completion work right away. Hook it into the package's
+postinst / %post script; it never fails the installation.
+
+--destdir stages the files for a package build instead of writing them
+in place. Man pages carry the version and the date of SOURCE_DATE_EPOCH
+when set, for reproducible builds.
+
+Examples:
+  agent install packaging --prefix /usr --destdir build/package
+  agent install packaging --prefix "$(brew --prefix)/Cellar/agent/4.1.0"
+  agent install packaging --prefix /usr/local --dry-run`,
+	Args: cobra.NoArgs,
+	RunE: runInstallPackaging,
+}
+
+var (
+	completionSystem bool
+	completionOutput string
+
+	packagingPrefix     string
+	packagingSysconfDir string
+	packagingDestDir    string
+)
+
+func init() {
+	installCompletionCmd.Flags().BoolVar(&completionSystem, "system", false, "install for every user (needs root)")
+	installCompletionCmd.Flags().StringVarP(&completionOutput, "output", "o", "", "write the script to this file instead")
+
+	f := installPackagingCmd.Flags()
+	f.StringVar(&packagingPrefix, "prefix", "/usr/local", "install prefix the package puts the binary under (<prefix>/bin/agent)")
+	f.StringVar(&packagingSysconfDir, "sysconfdir", "", "config directory (default: /etc for --prefix /usr, else <prefix>/etc)")
+	f.StringVar(&packagingDestDir, "destdir", "", "staging directory the files are written under for a package build")
+
+	addDryRunFlag(installCompletionCmd, installPackagingCmd)
+
+	installCmd.AddCommand(installCompletionCmd)
+	installCmd.AddCommand(installPackagingCmd)
+}
+
+// genCompletion writes the completion script of a shell
+func genCompletion(shell string) ([]byte, error) {
+	var buf bytes.Buffer
+	var err error
+	switch shell {
+	case "bash":
+		err = rootCmd.GenBashCompletionV2(&buf, true)
+	case "zsh":
+		err = rootCmd.GenZshCompletion(&buf)
+	case "fish":
+		err = rootCmd.GenFishCompletion(&buf, true)
+	case "powershell":
+		err = rootCmd.GenPowerShellCompletionWithDesc(&buf)
+	default:
+		return nil, fmt.Errorf("unsupported shell %q (use bash, zsh, fish or powershell)", shell)
+	}
+	return buf.Bytes(), err
+}
+
+// completionTarget is where a shell loads the completion script from,
+// and what the user still has to do for it to be loaded
+func completionTarget(shell string, system bool) (path, hint string, err error) {
+	home, _ := os.UserHomeDir()
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+	configHome := os.Getenv("XDG_CONFIG_HOME")
+	if configHome == "" {
+		configHome = filepath.Join(home, ".config")
+	}
+	switch shell {
+	case "bash":
+		if system {
+			return packaging.Layout{Prefix: "/usr"}.BashCompletion(), "", nil
+		}
+		return filepath.Join(dataHome, "bash-completion", "completions", "agent"),
+			"needs the bash-completion package (2.x), which loads it on demand", nil
+	case "zsh":
+		if system {
+			return packaging.Layout{Prefix: "/usr/local"}.ZshCompletion(), "", nil
+		}
+		dir := filepath.Join(home, ".zsh", "completions")
+		return filepath.Join(dir, "_agent"),
+			fmt.Sprintf("add to ~/.zshrc before compinit: fpath=(%s $fpath)", dir), nil
+	case "fish":
+		if system {
+			return packaging.Layout{Prefix: "/usr"}.FishCompletion(), "", nil
+		}
+		return filepath.Join(configHome, "fish", "completions", "agent.fish"), "", nil
+	case "powershell":
+		if system {
+			return "", "", fmt.Errorf("--system is not supported for powershell")
+		}
+		path := filepath.Join(settings.Dir(), "completion.ps1")
+		return path, fmt.Sprintf("add to your $PROFILE: . '%s'", path), nil
+	}
+	return "", "", fmt.Errorf("unsupported shell %q (use bash, zsh, fish or powershell)", shell)
+}
+
+// defaultShell is the user's login shell
+func defaultShell() (string, error) {
+	if runtime.GOOS == "windows" {
+		return "powershell", nil
+	}
+	shell := filepath.Base(os.Getenv("SHELL"))
+	switch shell {
+	case "bash", "zsh", "fish":
+		return shell, nil
+	case "pwsh":
+		return "powershell", nil
+	}
+	return "", fmt.Errorf("cannot tell the shell from $SHELL (%q): name it, e.g. agent install completion bash", os.Getenv("SHELL"))
+}
+
+func runInstallCompletion(cmd *cobra.Command, args []string) error {
+	shell := ""
+	if len(args) == 1 {
+		shell = args[0]
+	} else {
+		var err error
+		if shell, err = defaultShell(); err != nil {
+			return err
+		}
+	}
+	script, err := genCompletion(shell)
+	if err != nil {
+		return err
+	}
+	path, hint := completionOutput, ""
+	if path == "" {
+		if path, hint, err = completionTarget(shell, completionSystem); err != nil {
+			return err
+		}
+	}
+
+	if dryRun {
+		if err := planFile(path, script); err != nil {
+			return err
+		}
+		dryRunDone()
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, script, 0644); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("cannot write to %s: run with sudo or drop --system", path)
+		}
+		return err
+	}
+	fmt.Printf("✅ Installed %s completion: %s\n", shell, path)
+	if hint != "" {
+		fmt.Printf("   To load it, %s\n", hint)
+	}
+	fmt.Println("   Open a new shell to use it.")
+	return nil
+}
+
+func runInstallPackaging(cmd *cobra.Command, args []string) error {
+	if !filepath.IsAbs(packagingPrefix) {
+		return fmt.Errorf("--prefix must be an absolute path (the directory on the target system)")
+	}
+	layout := packaging.Layout{Prefix: packagingPrefix, SysconfDir: packagingSysconfDir}
+	if layout.SysconfDir == "" {
+		layout.SysconfDir = filepath.Join(packagingPrefix, "etc")
+		if packagingPrefix == "/usr" {
+			layout.SysconfDir = "/etc"
+		}
+	}
+
+	files, err := packageFiles(layout)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		for _, f := range files {
+			if err := planFile(filepath.Join(packagingDestDir, f.Path), []byte(f.Content)); err != nil {
+				return err
+			}
+		}
+		dryRunDone()
+		return nil
+	}
+	if err := packaging.Write(packagingDestDir, files); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("cannot write under %s: run with sudo or use --destdir", packagingPrefix)
+		}
+		return err
+	}
+	pages := 0
+	for _, f := range files {
+		if filepath.Dir(f.Path) == layout.ManDir() {
+			pages++
+			continue
+		}
+		fmt.Printf("✅ Wrote %s\n", filepath.Join(packagingDestDir, f.Path))
+	}
+	fmt.Printf("✅ Wrote %d man page(s) to %s\n", pages, filepath.Join(packagingDestDir, layout.ManDir()))
+	fmt.Println()
+	fmt.Printf("Run %s from the package's post-install script.\n", layout.PostInstall())
+	return nil
+}
+
+// packageFiles are the completions, man pages, default config and
+// post-install hook of a package with the given layout
+func packageFiles(layout packaging.Layout) ([]packaging.File, error) {
+	var files []packaging.File
+	for _, c := range []struct{ shell, path string }{
+		{"bash", layout.BashCompletion()},
+		{"zsh", layout.ZshCompletion()},
+		{"fish", layout.FishCompletion()},
+	} {
+		script, err := genCompletion(c.shell)
+		if err != nil {
+			return nil, fmt.Errorf("%s completion: %w", c.shell, err)
+		}
+		files = append(files, packaging.File{Path: c.path, Content: string(script)})
+	}
+
+	date := time.Now()
+	if epoch := os.Getenv("SOURCE_DATE_EPOCH"); epoch != "" {
+		secs, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("SOURCE_DATE_EPOCH: %w", err)
+		}
+		date = time.Unix(secs, 0).UTC()
+	}
+	for _, page := range manPages(rootCmd) {
+		files = append(files, packaging.File{
+			Path:    filepath.Join(layout.ManDir(), page.FileName()),
+			Content: page.Render(version, date),
+		})
+	}
+
+	files = append(files,
+		packaging.File{Path: layout.Config(), Content: packaging.DefaultConfig},
+		packaging.File{
+			Path:    layout.PostInstall(),
+			Mode:    0755,
+			Content: packaging.PostInstallScript(filepath.Join(layout.Prefix, "bin", "agent")),
+		})
+	return files, nil
+}
+
+// manPages are the pages of cmd and every available command below it
+func manPages(cmd *cobra.Command) []packaging.Page {
+	page := packaging.Page{
+		Command:       cmd.CommandPath(),
+		Short:         cmd.Short,
+		Long:          cmd.Long,
+		Synopsis:      cmd.UseLine(),
+		Options:       manOptions(cmd.NonInheritedFlags()),
+		GlobalOptions: manOptions(cmd.InheritedFlags()),
+	}
+	if cmd.HasParent() {
+		page.SeeAlso = append(page.SeeAlso, cmd.Parent().CommandPath())
+	}
+	var pages []packaging.Page
+	for _, sub := range cmd.Commands() {
+		if !sub.IsAvailableCommand() || sub.IsAdditionalHelpTopicCommand() {
+			continue
+		}
+		page.SeeAlso = append(page.SeeAlso, sub.CommandPath())
+		pages = append(pages, manPages(sub)...)
+	}
+	return append([]packaging.Page{page}, pages...)
+}
+
+// manOptions lists the visible flags of a set
+func manOptions(flags *pflag.FlagSet) []packaging.Option {
+	var opts []packaging.Option
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden {
+			return
+		}
+		name, usage := pflag.UnquoteUsage(f)
+		flag := "--" + f.Name
+		if f.Shorthand != "" {
+			flag = "-" + f.Shorthand + ", " + flag
+		}
+		if name != "" {
+			flag += " " + name
+		}
+		switch f.DefValue {
+		case "", "false", "0", "0s", "[]":
+		default:
+			usage += fmt.Sprintf(" (default %s)", f.DefValue)
+		}
+		opts = append(opts, packaging.Option{Flag: flag, Usage: usage})
+	})
+	return opts
+}
+
+// initIndexWindow is how far back 'init --post-install' indexes calls
+const initIndexWindow = "7d"
+
+// runPostInstall detects an existing deployment and builds the initial
+// call index. It runs from package post-install scripts, so it only
+// warns and never fails.
+func runPostInstall(cmd *cobra.Command) error {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	ctx, cancel := runContext(2 * time.Minute)
+	defer cancel()
+
+	fmt.Println("🔍 Looking for an existing Asterisk AI Voice Agent deployment...")
+	d := packaging.Detect(ctx)
+	if !d.Found() {
+		fmt.Println("No existing deployment found. Run 'agent init' in the project directory to set one up.")
+		return nil
+	}
+	if len(d.Containers) > 0 {
+		fmt.Printf("✅ Containers: %s\n", strings.Join(d.Containers, ", "))
+	}
+	if len(d.Units) > 0 {
+		fmt.Printf("✅ systemd units: %s\n", strings.Join(d.Units, ", "))
+	}
+	if d.ProjectDir != "" {
+		fmt.Printf("✅ Project: %s\n", d.ProjectDir)
+	}
+
+	engineUnit := false
+	for _, unit := range d.Units {
+		engineUnit = engineUnit || unit == systemd.EngineUnit
+	}
+	if !d.Docker() && !engineUnit {
+		fmt.Println("No engine installed yet; the call index is built by the first troubleshoot run.")
+		return nil
+	}
+
+	n, err := buildInitialIndex(ctx, !d.Docker(), verbose)
+	if err != nil {
+		fmt.Printf("⚠️  Could not build the call index: %v\n", err)
+		fmt.Println("   It is built by the first 'agent troubleshoot' run instead.")
+		return nil
+	}
+	fmt.Printf("✅ Indexed %d call(s) from the last %s in %s\n", n, initIndexWindow, settings.Dir())
+	fmt.Println()
+	fmt.Println("Next: agent doctor, then agent troubleshoot --last")
+	return nil
+}
+
+// buildInitialIndex records the calls of the last initIndexWindow in the
+// call index. Bare-metal installs log to the journal, which is read when
+// no log source is configured.
+func buildInitialIndex(ctx context.Context, bareMetal, verbose bool) (int, error) {
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return 0, err
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return 0, err
+	}
+	sourceCfg := cfg.LogSource
+	if bareMetal && sourceCfg.Type == "" {
+		sourceCfg = settings.LogSource{Type: "journald", Unit: systemd.EngineUnit}
+	}
+	source, err := troubleshoot.NewLogSource(sourceCfg, logLoc)
+	if err != nil {
+		return 0, err
+	}
+	indexAge, err := cfg.Retention.IndexMaxAge()
+	if err != nil {
+		return 0, err
+	}
+	runner := troubleshoot.NewRunner(troubleshoot.Options{
+		Context:        ctx,
+		Since:          initIndexWindow,
+		LogSource:      source,
+		IndexRetention: indexAge,
+		NoLLM:          true,
+		Verbose:        verbose,
+		Location:       loc,
+		LogLocation:    logLoc,
+		Tenants:        cfg.Tenants,
+	})
+	return runner.BuildIndex()
+}
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/recordings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var recordingsCmd = &cobra.Command{
+	Use:   "recordings",
+	Short: "List, export and prune call recordings",
+	Long: `Manage the call recordings Asterisk writes for the stack: ARI stored
+recordings (the engine's diagnostic recordings, diag_enable_taps) in
+/var/spool/asterisk/recording and MixMonitor recordings in
+/var/spool/asterisk/monitor. Recordings are matched to calls by the
+Asterisk unique ID (the call ID) in their file name.
+
+Other locations and the retention period are set in ~/.agent/config:
+  recordings:
+    dirs: [/var/spool/asterisk/monitor, /mnt/recordings]
+  retention:
+    recordings: 90d
+
+Ages accept days (7d), weeks (2w) or durations (36h).`,
+}
+
+var recordingsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List recordings with their size and duration",
+	Long: `List recordings, oldest first, with call ID, time, duration and size.
+
+Examples:
+  agent recordings list --since 7d
+  agent recordings list --call 1763582071.6214`,
+	Args: cobra.NoArgs,
+	RunE: runRecordingsList,
+}
+
+var recordingsExportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Copy recordings out, optionally transcoded to mp3 or opus",
+	Long: `Copy the selected recordings to --to, keeping their time stamps.
+--format mp3 or opus transcodes them with ffmpeg (which must be
+installed); without it the files are copied as they are.
+
+Examples:
+  agent recordings export --call 1763582071.6214 --to ./qa
+  agent recordings export --since 1d --to /mnt/share/calls --format mp3`,
+	Args: cobra.NoArgs,
+	RunE: runRecordingsExport,
+}
+
+var recordingsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete recordings past the retention period",
+	Long: `Delete recordings older than retention.recordings in ~/.agent/config
+(default 90 days), or --older-than, or all recordings of --call. Date
+directories left empty are removed too.
+
+Examples:
+  agent recordings prune --dry-run
+  agent recordings prune --older-than 30d
+  agent recordings prune --call 1763582071.6214`,
+	Args: cobra.NoArgs,
+	RunE: runRecordingsPrune,
+}
+
+var (
+	recordingsDirs      []string
+	recordingsCall      string
+	recordingsSince     string
+	recordingsTo        string
+	recordingsFormat    string
+	recordingsOlderThan string
+)
+
+func init() {
+	recordingsCmd.PersistentFlags().StringSliceVar(&recordingsDirs, "dir", nil, "recording directory (repeatable; default from ~/.agent/config, then Asterisk's spool)")
+	for _, c := range []*cobra.Command{recordingsListCmd, recordingsExportCmd, recordingsPruneCmd} {
+		c.Flags().StringVar(&recordingsCall, "call", "", "only recordings of this call ID")
+	}
+	for _, c := range []*cobra.Command{recordingsListCmd, recordingsExportCmd} {
+		c.Flags().StringVar(&recordingsSince, "since", "", "only recordings newer than this age (e.g. 30d)")
+	}
+	recordingsExportCmd.Flags().StringVar(&recordingsTo, "to", "", "directory to export to (required)")
+	recordingsExportCmd.Flags().StringVar(&recordingsFormat, "format", "", "transcode to wav, mp3 or opus (default: keep the original)")
+	recordingsExportCmd.MarkFlagRequired("to")
+	recordingsPruneCmd.Flags().StringVar(&recordingsOlderThan, "older-than", "", "override the recording retention period")
+	addDryRunFlag(recordingsPruneCmd)
+
+	recordingsCmd.AddCommand(recordingsListCmd)
+	recordingsCmd.AddCommand(recordingsExportCmd)
+	recordingsCmd.AddCommand(recordingsPruneCmd)
+	rootCmd.AddCommand(recordingsCmd)
+}
+
+func recordingDirs(cfg *settings.Settings) []string {
+	if len(recordingsDirs) > 0 {
+		return recordingsDirs
+	}
+	if cfg != nil && len(cfg.Recordings.Dirs) > 0 {
+		return cfg.Recordings.Dirs
+	}
+	return recordings.DefaultDirs
+}
+
+// recordingFilter builds the --call/--since selection
+func recordingFilter() (recordings.Filter, error) {
+	f := recordings.Filter{CallID: recordingsCall}
+	if recordingsSince != "" {
+		age, err := settings.ParseAge(recordingsSince)
+		if err != nil {
+			return f, fmt.Errorf("--since: %w", err)
+		}
+		f.Since = time.Now().Add(-age)
+	}
+	return f, nil
+}
+
+func runRecordingsList(cmd *cobra.Command, args []string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	filter, err := recordingFilter()
+	if err != nil {
+		return err
+	}
+	recs, err := recordings.Find(recordingDirs(cfg), filter)
+	if err != nil {
+		return err
+	}
+	if len(recs) == 0 {
+		fmt.Println("No recordings found")
+		return nil
+	}
+
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	var size int64
+	var total time.Duration
+	fmt.Printf("%-22s %-19s %9s %9s  %s\n", "CALL ID", "TIME", "DURATION", "SIZE", "FILE")
+	for _, r := range recs {
+		callID := r.CallID
+		if callID == "" {
+			callID = "-"
+		}
+		fmt.Printf("%-22s %-19s %9s %9s  %s\n", callID, r.ModTime.In(loc).Format("2006-01-02 15:04:05"),
+			formatRecordingDuration(r.Duration), formatSize(r.Size), r.Path)
+		size += r.Size
+		total += r.Duration
+	}
+	fmt.Printf("\n%d recording(s), %s, %s\n", len(recs), formatRecordingDuration(total), formatSize(size))
+	return nil
+}
+
+func runRecordingsExport(cmd *cobra.Command, args []string) error {
+	if recordingsCall == "" && recordingsSince == "" {
+		return fmt.Errorf("select recordings with --call or --since")
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	filter, err := recordingFilter()
+	if err != nil {
+		return err
+	}
+	recs, err := recordings.Find(recordingDirs(cfg), filter)
+	if err != nil {
+		return err
+	}
+	if len(recs) == 0 {
+		fmt.Println("No recordings found")
+		return nil
+	}
+	if err := os.MkdirAll(recordingsTo, 0750); err != nil {
+		return err
+	}
+
+	var size int64
+	for _, r := range recs {
+		dest, err := recordings.Export(cmd.Context(), r, recordingsTo, recordingsFormat)
+		if err != nil {
+			return err
+		}
+		if fi, err := os.Stat(dest); err == nil {
+			size += fi.Size()
+		}
+		fmt.Printf("✅ %s\n", filepath.Base(dest))
+	}
+	fmt.Printf("\nExported %d recording(s) to %s (%s)\n", len(recs), recordingsTo, formatSize(size))
+	return nil
+}
+
+func runRecordingsPrune(cmd *cobra.Command, args []string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	filter := recordings.Filter{CallID: recordingsCall}
+	what := "of call " + recordingsCall
+	if recordingsCall == "" {
+		age, err := cfg.Retention.RecordingMaxAge()
+		if err != nil {
+			return err
+		}
+		if recordingsOlderThan != "" {
+			if age, err = settings.ParseAge(recordingsOlderThan); err != nil {
+				return fmt.Errorf("--older-than: %w", err)
+			}
+		}
+		filter.Before = time.Now().Add(-age)
+		what = "older than " + formatAge(age)
+	}
+
+	dirs := recordingDirs(cfg)
+	recs, err := recordings.Find(dirs, filter)
+	if err != nil {
+		return err
+	}
+	var size int64
+	for _, r := range recs {
+		size += r.Size
+	}
+
+	verb := "Deleted"
+	if dryRun {
+		verb = "Would delete"
+		for _, r := range recs {
+			planRemove(r.Path)
+		}
+	} else if err := recordings.Remove(recs, dirs); err != nil {
+		if os.IsPermission(err) {
+			return fmt.Errorf("%w (recordings belong to asterisk: run with sudo or as that user)", err)
+		}
+		return err
+	}
+	fmt.Printf("%s %d recording(s) %s (%s)\n", verb, len(recs), what, formatSize(size))
+	if dryRun {
+		dryRunDone()
+	}
+	return nil
+}
+
+func formatRecordingDuration(d time.Duration) string {
+	if d <= 0 {
+		return "-"
+	}
+	d = d.Round(time.Second)
+	if d >= time.Hour {
+		return fmt.Sprintf("%d:%02d:%02d", int(d.Hours()), int(d.Minutes())%60, int(d.Seconds())%60)
+	}
+	return fmt.Sprintf("%d:%02d", int(d.Minutes()), int(d.Seconds())%60)
+}
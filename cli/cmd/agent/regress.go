@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/regress"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"github.com/spf13/cobra"
+)
+
+var regressCmd = &cobra.Command{
+	Use:   "regress",
+	Short: "Regression suite of recorded calls replayed through the pipeline",
+	Long: `Keep a library of recorded calls with the outcome each should reach,
+and replay the whole library through the current configuration after
+a prompt, model or provider change.
+
+A case is a call's caller audio with expectations:
+  --expect       phrase some agent response must contain
+  --forbid       phrase no agent response may contain
+  --tool         tool the pipeline must call (e.g. transfer)
+  --max-latency  budget for every turn, utterance end to first TTS audio
+  --min-turns    least number of answered turns
+The library lives in ~/.agent/regress/<case>/ (case.yaml and
+caller.wav); case.yaml can be edited by hand.
+
+'agent regress run' replays every case (see 'agent replay') and exits
+non-zero when any fails. The providers are called for real.
+
+Examples:
+  agent regress add booking --call 1763582071.6214 --expect "booked" --tool transfer --max-latency 1500ms
+  agent regress add angry-caller --audio angry.wav --forbid "I don't know" --context support
+  agent regress list
+  agent regress run
+  agent regress run booking --pipeline local_hybrid`,
+}
+
+var regressAddCmd = &cobra.Command{
+	Use:   "add <name>",
+	Short: "Add a recorded call to the library",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRegressAdd,
+}
+
+var regressListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the cases in the library",
+	Args:  cobra.NoArgs,
+	RunE:  runRegressList,
+}
+
+var regressRunCmd = &cobra.Command{
+	Use:   "run [name...]",
+	Short: "Replay the library (or the named cases) and report pass/fail",
+	RunE:  runRegressRun,
+}
+
+var regressRemoveCmd = &cobra.Command{
+	Use:   "remove <name>",
+	Short: "Remove a case from the library",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runRegressRemove,
+}
+
+var (
+	regressCall       string
+	regressAudio      string
+	regressNote       string
+	regressPipeline   string
+	regressContext    string
+	regressExpect     []string
+	regressForbid     []string
+	regressTools      []string
+	regressMaxLatency time.Duration
+	regressMinTurns   int
+	regressFormat     string
+	regressContainer  string
+)
+
+func init() {
+	f := regressAddCmd.Flags()
+	f.StringVar(&regressCall, "call", "", "call whose captured caller audio is added")
+	f.StringVar(&regressAudio, "audio", "", "WAV file of the caller's side instead of the engine's capture")
+	f.StringVar(&regressNote, "note", "", "what the case covers")
+	f.StringVar(&regressContext, "context", "", "context whose prompt the LLM gets")
+	f.StringSliceVar(&regressExpect, "expect", nil, "phrase an agent response must contain (repeatable)")
+	f.StringSliceVar(&regressForbid, "forbid", nil, "phrase no agent response may contain (repeatable)")
+	f.StringSliceVar(&regressTools, "tool", nil, "tool the pipeline must call (repeatable)")
+	f.DurationVar(&regressMaxLatency, "max-latency", 0, "latency budget for every turn")
+	f.IntVar(&regressMinTurns, "min-turns", 0, "least number of answered turns")
+	for _, c := range []*cobra.Command{regressAddCmd, regressRunCmd} {
+		c.Flags().StringVar(&regressPipeline, "pipeline", "", "pipeline to replay through (default: the case's, else the active pipeline)")
+		c.Flags().StringVar(&regressContainer, "container", engine.ContainerName, "engine container")
+	}
+	regressRunCmd.Flags().StringVar(&regressFormat, "format", "text", "output format: text|json")
+
+	regressCmd.AddCommand(regressAddCmd, regressListCmd, regressRunCmd, regressRemoveCmd)
+	rootCmd.AddCommand(regressCmd)
+}
+
+func runRegressAdd(cmd *cobra.Command, args []string) error {
+	name := args[0]
+	if !regress.ValidName(name) {
+		return fmt.Errorf("invalid case name %q (letters, digits, '.', '_' and '-')", name)
+	}
+	if regressCall == "" && regressAudio == "" {
+		return fmt.Errorf("--call or --audio is required")
+	}
+	if _, err := regress.Load(name); err == nil {
+		return fmt.Errorf("case %s exists (remove it first)", name)
+	}
+
+	var audio []byte
+	var err error
+	if regressAudio != "" {
+		audio, err = os.ReadFile(regressAudio)
+	} else {
+		ctx, cancel := runContext(30 * time.Second)
+		audio, err = engine.CallerCapture(ctx, regressContainer, regressCall)
+		cancel()
+	}
+	if err != nil {
+		return err
+	}
+
+	c := &regress.Case{
+		Name:     name,
+		CallID:   regressCall,
+		Note:     regressNote,
+		Added:    time.Now().UTC().Truncate(time.Second),
+		Pipeline: regressPipeline,
+		Context:  regressContext,
+		Expect: regress.Expect{
+			Phrases:      regressExpect,
+			Forbidden:    regressForbid,
+			Tools:        regressTools,
+			MaxLatencyMs: regressMaxLatency.Milliseconds(),
+			MinTurns:     regressMinTurns,
+		},
+	}
+	if err := regress.Save(c, audio); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Added case %s (%d KB of audio)\n", name, len(audio)/1024)
+	if len(regressExpect)+len(regressForbid)+len(regressTools) == 0 && regressMaxLatency == 0 && regressMinTurns == 0 {
+		fmt.Println("⚠️  No expectations set: the case only fails on pipeline errors")
+	}
+	return nil
+}
+
+func runRegressList(cmd *cobra.Command, args []string) error {
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	cases, err := regress.List()
+	if err != nil {
+		return err
+	}
+	if len(cases) == 0 {
+		fmt.Println("No cases yet (add one with 'agent regress add')")
+		return nil
+	}
+	for _, c := range cases {
+		var expect []string
+		for _, p := range c.Expect.Phrases {
+			expect = append(expect, fmt.Sprintf("%q", p))
+		}
+		for _, p := range c.Expect.Forbidden {
+			expect = append(expect, fmt.Sprintf("not %q", p))
+		}
+		for _, t := range c.Expect.Tools {
+			expect = append(expect, "tool "+t)
+		}
+		if c.Expect.MaxLatencyMs > 0 {
+			expect = append(expect, fmt.Sprintf("≤%dms", c.Expect.MaxLatencyMs))
+		}
+		if c.Expect.MinTurns > 0 {
+			expect = append(expect, fmt.Sprintf("≥%d turns", c.Expect.MinTurns))
+		}
+		fmt.Printf("%-20s  %s  %s\n", c.Name, c.Added.In(loc).Format("2006-01-02"), strings.Join(expect, ", "))
+		if c.Note != "" {
+			fmt.Printf("%-20s  %s\n", "", c.Note)
+		}
+	}
+	return nil
+}
+
+func runRegressRun(cmd *cobra.Command, args []string) error {
+	if regressFormat != "text" && regressFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", regressFormat)
+	}
+	var cases []*regress.Case
+	if len(args) == 0 {
+		all, err := regress.List()
+		if err != nil {
+			return err
+		}
+		cases = all
+	}
+	for _, name := range args {
+		c, err := regress.Load(name)
+		if err != nil {
+			return err
+		}
+		cases = append(cases, c)
+	}
+	if len(cases) == 0 {
+		return fmt.Errorf("no cases to run (add one with 'agent regress add')")
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+	api := replayEndpoint(ctx, cmd, regressContainer)
+
+	var results []*regress.Result
+	failed := 0
+	for _, c := range cases {
+		if ctx.Err() != nil {
+			break
+		}
+		result := runRegressCase(ctx, api, c)
+		results = append(results, result)
+		if !result.Passed {
+			failed++
+		}
+		if regressFormat == "text" {
+			printRegressResult(result)
+		}
+	}
+
+	if regressFormat == "json" {
+		out, err := json.MarshalIndent(results, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		fmt.Printf("\n%d passed, %d failed\n", len(results)-failed, failed)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d case(s) failed", failed, len(results))
+	}
+	return nil
+}
+
+// runRegressCase replays one case; errors reaching the engine fail the
+// case rather than the run
+func runRegressCase(ctx context.Context, api *engineclient.Client, c *regress.Case) *regress.Result {
+	audio, err := c.Audio()
+	if err != nil {
+		return &regress.Result{Case: c.Name, Error: err.Error()}
+	}
+	opts := engineclient.ReplayOptions{Pipeline: c.Pipeline, Context: c.Context, Audio: audio}
+	if regressPipeline != "" {
+		opts.Pipeline = regressPipeline
+	}
+	callID := c.CallID
+	if callID == "" {
+		callID = c.Name
+	}
+	replay, err := api.Replay(ctx, callID, opts)
+	if err != nil {
+		return &regress.Result{Case: c.Name, Error: err.Error()}
+	}
+	return c.Evaluate(replay)
+}
+
+func printRegressResult(r *regress.Result) {
+	switch {
+	case r.Error != "":
+		fmt.Printf("❌ %s: %s\n", r.Case, r.Error)
+		return
+	case r.Passed:
+		fmt.Printf("✅ %s", r.Case)
+	default:
+		fmt.Printf("❌ %s", r.Case)
+	}
+	var worst time.Duration
+	for _, turn := range r.Replay.Turns {
+		if latency := turn.Latency(); latency > worst {
+			worst = latency
+		}
+	}
+	fmt.Printf("  (%d turn(s), slowest %s)\n", len(r.Replay.Turns), formatLatency(worst))
+	for _, failure := range r.Failures {
+		fmt.Printf("   - %s\n", failure)
+	}
+}
+
+func runRegressRemove(cmd *cobra.Command, args []string) error {
+	if err := regress.Remove(args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("🗑️  Removed case %s\n", args[0])
+	return nil
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "Run the CLI from a workstation against the PBX host",
+}
+
+var remoteCheckCmd = &cobra.Command{
+	Use:   "check",
+	Short: "Check that the remote host is reachable for every command",
+	Long: `Check the connection to the Linux host the stack runs on, for running
+the CLI from a Windows, macOS or Linux workstation instead of on the PBX.
+
+With a remote host set, the CLI reaches everything on the host over
+SSH: the docker daemon (docker system dial-stdio), host commands such
+as asterisk -rx, journalctl, fail2ban-client and iptables, the project
+files (.env, config/ai-agent.yaml) in the project directory, and ports
+bound to 127.0.0.1 there (engine health API, ARI, AMI). Call history,
+reports and the log cache stay on the workstation in ~/.agent.
+
+SSH uses the system OpenSSH client (built into Windows 10+ and macOS),
+so keys, the ssh-agent and ~/.ssh/config work as for ssh itself.
+Password prompts are disabled: set up key auth, and connect once with
+plain ssh to accept the host key.
+
+Set the host in ~/.agent/config:
+
+  remote:
+    host: ssh://support@pbx1.example.com
+    identity_file: ~/.ssh/pbx1_ed25519
+    dir: /opt/asterisk-ai-voice-agent
+    ssh_options: [ProxyJump=bastion.example.com]
+
+or per run with --remote (or AGENT_REMOTE); --remote local runs against
+this machine despite the config.
+
+Setup commands that write the project (init, quickstart, install,
+compose) still run on the host itself.
+
+Examples:
+  agent remote check --remote support@pbx1.example.com
+  agent troubleshoot --last --remote ssh://support@pbx1.example.com:2222
+  AGENT_REMOTE=support@pbx1 agent doctor`,
+	Args: cobra.NoArgs,
+	RunE: runRemoteCheck,
+}
+
+// remoteHost is set by --remote
+var remoteHost string
+
+func init() {
+	remoteCmd.AddCommand(remoteCheckCmd)
+	rootCmd.AddCommand(remoteCmd)
+}
+
+// applyRemote sets the remote host of --remote, else AGENT_REMOTE, else
+// ~/.agent/config; "local" runs locally whatever the config says
+func applyRemote() error {
+	cfg, err := settings.Load()
+	if err != nil {
+		cfg = &settings.Settings{}
+	}
+	rs := cfg.Remote
+	host := remoteHost
+	if host == "" {
+		host = os.Getenv("AGENT_REMOTE")
+	}
+	if host == "local" {
+		return remote.Use(nil)
+	}
+	if host != "" {
+		rs.Host = host
+	}
+	t, err := remote.FromSettings(rs)
+	if err != nil {
+		return err
+	}
+	return remote.Use(t)
+}
+
+func runRemoteCheck(cmd *cobra.Command, args []string) error {
+	t := remote.Current()
+	if t == nil {
+		return fmt.Errorf("no remote host: set remote.host in %s or use --remote user@host", settings.Path())
+	}
+	ctx, cancel := context.WithTimeout(cmd.Context(), 30*time.Second)
+	defer cancel()
+
+	fmt.Printf("🌐 Remote host %s\n\n", t)
+	failed := 0
+
+	out, err := selflog.CombinedOutput(remote.Command(ctx, "uname", "-sm"))
+	if err != nil {
+		fmt.Printf("❌ SSH: %s\n", strings.TrimSpace(string(out)))
+		fmt.Printf("   Check key auth with: ssh %s\n", strings.Join(t.SSHArgs(), " "))
+		return fmt.Errorf("cannot reach %s over SSH", t.Host)
+	}
+	fmt.Printf("✅ SSH: %s\n", strings.TrimSpace(string(out)))
+
+	if remote.Exists(".env") || remote.Exists("config/ai-agent.yaml") {
+		fmt.Printf("✅ Project: %s\n", t.Dir)
+	} else {
+		failed++
+		fmt.Printf("❌ Project: no .env or config/ai-agent.yaml in %s\n", t.Dir)
+		fmt.Println("   Set remote.dir in ~/.agent/config to the project directory")
+	}
+
+	client, err := docker.Default()
+	if err == nil {
+		var v *docker.Version
+		if v, err = client.Version(ctx); err == nil {
+			fmt.Printf("✅ Docker: Engine %s (%s/%s) via %s\n", v.Version, v.Os, v.Arch, client.Host())
+		}
+	}
+	if err != nil {
+		failed++
+		fmt.Printf("❌ Docker: %v\n", err)
+		fmt.Println("   The host needs the docker CLI for 'docker system dial-stdio', and the SSH user access to the daemon")
+	}
+
+	for _, tool := range []string{"asterisk", "journalctl"} {
+		if err := remote.LookPath(tool); err != nil {
+			fmt.Printf("⚠️  %s: not on the host's PATH (commands using it fall back or skip)\n", tool)
+		} else {
+			fmt.Printf("✅ %s: found\n", tool)
+		}
+	}
+
+	fmt.Println()
+	if failed > 0 {
+		return fmt.Errorf("%d check(s) failed", failed)
+	}
+	fmt.Println("Every command now runs against the host, e.g. agent troubleshoot --last")
+	return nil
+}
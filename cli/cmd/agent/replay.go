@@ -0,0 +1,199 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"github.com/spf13/cobra"
+)
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay a past call's caller audio through the current pipeline",
+	Long: `Replay the caller audio of a past call through the current pipeline
+(STT → LLM → TTS) offline, and print the new transcript and responses
+with per-stage timings next to what the call originally logged.
+
+Nothing is dialled or played: the engine splits the audio into
+utterances on silence and runs each through the pipeline on a synthetic
+call, keeping the conversation history between turns. Use it to check a
+prompt, model or provider change against a real conversation. The
+providers are called for real, so replays cost what a call does.
+
+The audio is the engine's capture of the call
+(/tmp/ai-engine-captures/<call>/caller_inbound.wav, kept when
+DIAG_ENABLE_TAPS=true). --audio replays a WAV file instead, e.g. one
+exported with 'agent recordings export'; a mixed recording also holds
+the agent's voice, which is then transcribed too.
+
+--pipeline and --context pick the pipeline and the context whose prompt
+is used; by default the active pipeline and its prompt.
+
+Examples:
+  agent replay --call 1763582071.6214
+  agent replay --call 1763582071.6214 --pipeline local_hybrid --context support
+  agent replay --call 1763582071.6214 --audio caller.wav --format json`,
+	Args: cobra.NoArgs,
+	RunE: runReplay,
+}
+
+var (
+	replayCall      string
+	replayAudio     string
+	replayPipeline  string
+	replayContext   string
+	replayFormat    string
+	replayContainer string
+	replayTimeout   time.Duration
+)
+
+func init() {
+	replayCmd.Flags().StringVar(&replayCall, "call", "", "call ID to replay (required)")
+	replayCmd.Flags().StringVar(&replayAudio, "audio", "", "WAV file to replay instead of the engine's capture")
+	replayCmd.Flags().StringVar(&replayPipeline, "pipeline", "", "pipeline to replay through (default: the active pipeline)")
+	replayCmd.Flags().StringVar(&replayContext, "context", "", "context whose prompt the LLM gets")
+	replayCmd.Flags().StringVar(&replayFormat, "format", "text", "output format: text|json")
+	replayCmd.Flags().StringVar(&replayContainer, "container", engine.ContainerName, "engine container holding the capture")
+	replayCmd.Flags().DurationVar(&replayTimeout, "timeout", 10*time.Minute, "give up after this long")
+	replayCmd.MarkFlagRequired("call")
+	rootCmd.AddCommand(replayCmd)
+}
+
+func runReplay(cmd *cobra.Command, args []string) error {
+	if replayFormat != "text" && replayFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", replayFormat)
+	}
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	opts := engineclient.ReplayOptions{Pipeline: replayPipeline, Context: replayContext}
+	if replayAudio != "" {
+		if opts.Audio, err = os.ReadFile(replayAudio); err != nil {
+			return err
+		}
+	}
+
+	ctx, cancel := runContext(replayTimeout)
+	defer cancel()
+
+	api := replayEndpoint(ctx, cmd, replayContainer)
+	if replayFormat == "text" {
+		fmt.Printf("🔁 Replaying call %s through the pipeline (this calls the providers)...\n", replayCall)
+	}
+	replay, err := api.Replay(ctx, replayCall, opts)
+	if err != nil {
+		return err
+	}
+
+	if replayFormat == "json" {
+		out, err := json.MarshalIndent(replay, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	source := "engine capture"
+	if replay.Source == "upload" {
+		source = replayAudio
+	}
+	prompt := replay.PromptSource
+	if replay.Context != "" {
+		prompt = "context " + replay.Context
+	}
+	fmt.Printf("Pipeline %s, prompt from %s, %.1fs of audio from %s\n\n", replay.Pipeline, prompt, replay.AudioSeconds, source)
+	printReplayTurns(replay)
+
+	// The original turns are best-effort: logs may have rotated
+	original, err := troubleshoot.CallTranscript(ctx, replayContainer, replayCall, logLoc)
+	if err != nil {
+		fmt.Printf("\n⚠️  Original conversation not available: %v\n", err)
+		return nil
+	}
+	printOriginalTurns(original, loc)
+	return nil
+}
+
+// replayEndpoint returns the control API of container when --container
+// is set, otherwise the configured engine's
+func replayEndpoint(ctx context.Context, cmd *cobra.Command, container string) *engineclient.Client {
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	if cmd.Flags().Changed("container") {
+		if found, err := engine.Instances(ctx); err == nil {
+			for _, inst := range found {
+				if inst.Container == container {
+					return engineclient.New(inst.HealthURL, engine.Token(env))
+				}
+			}
+		}
+	}
+	return engine.Client(env)
+}
+
+func printReplayTurns(replay *engineclient.Replay) {
+	if len(replay.Turns) == 0 {
+		fmt.Println("No speech found in the caller audio")
+		return
+	}
+	var total time.Duration
+	answered := 0
+	for i, turn := range replay.Turns {
+		fmt.Printf("Turn %d  @%.1fs (%s of speech)\n", i+1, turn.OffsetSeconds, formatLatency(time.Duration(turn.AudioMs)*time.Millisecond))
+		fmt.Printf("  👤 Caller: %s  (STT %s)\n", turn.Transcript, formatLatency(time.Duration(turn.STTMs)*time.Millisecond))
+		if turn.Response != "" {
+			fmt.Printf("  🤖 Agent:  %s  (LLM %s)\n", turn.Response, formatLatency(time.Duration(turn.LLMMs)*time.Millisecond))
+		}
+		for _, tool := range turn.ToolCalls {
+			fmt.Printf("  🔧 Tool: %s\n", tool)
+		}
+		if latency := turn.Latency(); latency > 0 {
+			answered++
+			total += latency
+			fmt.Printf("  ⏱  TTS first audio %s, turn latency %s\n",
+				formatLatency(time.Duration(turn.TTSFirstByteMs)*time.Millisecond), formatLatency(latency))
+		}
+		if turn.Error != "" {
+			fmt.Printf("  ❌ %s\n", turn.Error)
+		}
+	}
+	if answered > 0 {
+		fmt.Printf("\n%d turn(s), average latency %s (utterance end to first TTS audio)\n", answered, formatLatency(total/time.Duration(answered)))
+	}
+}
+
+func printOriginalTurns(events []*troubleshoot.LiveEvent, loc *time.Location) {
+	fmt.Println("\nOriginal call:")
+	shown := 0
+	for _, e := range events {
+		stamp := e.Time.In(loc).Format("15:04:05")
+		switch e.Kind {
+		case troubleshoot.LiveCaller:
+			fmt.Printf("  %s  👤 Caller: %s\n", stamp, e.Text)
+		case troubleshoot.LiveAgent:
+			fmt.Printf("  %s  🤖 Agent:  %s\n", stamp, e.Text)
+		case troubleshoot.LiveLatency:
+			fmt.Printf("  %s  ⏱  turn latency %s\n", strings.Repeat(" ", len(stamp)), formatLatency(e.Latency))
+		case troubleshoot.LiveTool:
+			fmt.Printf("  %s  🔧 Tool: %s\n", stamp, e.Text)
+		default:
+			continue
+		}
+		shown++
+	}
+	if shown == 0 {
+		fmt.Println("  (no transcript lines in the engine log)")
+	}
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/locale"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	reportFormat    string
+	reportOutput    string
+	reportContainer string
+	reportNoCache   bool
+	reportTimeout   time.Duration
+	reportLocale    string
+	reportClock     string
+	reportTenant    string
+)
+
+var reportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Quality reports over many calls",
+}
+
+var reportWeeklyCmd = &cobra.Command{
+	Use:   "weekly",
+	Short: "Weekly quality report compared with the week before",
+	Long: `Analyze every call of the last 14 days and write one document
+comparing the last 7 days with the 7 before:
+
+  Volume             calls by status, failure rate, call minutes
+  Failure clusters   failed calls grouped by fingerprint, new or recurring
+  Latency            turn latency and quality score trends
+  Caller intents     the tools the agent ran (transfer, hangup, email...)
+  Provider cost      call minutes per provider, priced with 'costs'
+  SLO status         share of calls meeting the 'slo' objectives
+  Tenants            calls, failure rate, quality and minutes per tenant
+  Business hours     in-hours against after-hours calls
+
+Prices are per call minute, keyed by provider name, in ~/.agent/config:
+
+  costs:
+    deepgram: 0.0077
+    openai_realtime: 0.06
+
+--tenant reports one customer's calls against its own objectives, for
+hosting one deployment for several (see 'tenants' in 'agent
+troubleshoot --help'); without it the Tenants section compares them.
+
+With business hours configured, each call is in hours or after hours
+by the schedule of its route, and SLO status is measured for each
+apart, so after-hours failures do not skew the staffed hours:
+
+  business_hours:
+    hours: 08:00-18:00          # comma-separated ranges, 22:00-06:00 works
+    days: mon-fri
+    timezone: Europe/Berlin     # default: the display timezone
+    holidays: [12-25, 2026-12-24]  # MM-DD every year, or one date
+    holiday_calendar: /etc/agent/holidays.ics
+    routes:                     # DIDs/contexts with their own schedule,
+      - name: support           # unset fields are the default's
+        dids: ["+4930123459*"]
+        hours: 07:00-22:00
+        days: mon-sat
+
+Numbers, dates and times follow --locale and --clock, else 'locale'
+and 'clock' in ~/.agent/config (e.g. de-DE writes 1.234,5 and
+17.10.2026; en-US writes 1,234.5, 10/17/2026 and 3:04 PM). Without a
+locale the report keeps ISO dates and plain numbers. JSON output is
+never localized.
+
+Call data collected by earlier runs is reused (see 'agent troubleshoot
+--help', Caching), so last week's calls are cheap to report again.
+Progress goes to stderr; the report to stdout unless --output is set.
+
+Examples:
+  agent report weekly
+  agent report weekly --output weekly.md
+  agent report weekly --locale de-DE --output bericht.md
+  agent report weekly --locale en-GB --clock 12h
+  agent report weekly --tenant acme --output acme-weekly.md
+  agent report weekly --format json > weekly.json`,
+	Args: cobra.NoArgs,
+	RunE: runReportWeekly,
+}
+
+func init() {
+	reportWeeklyCmd.Flags().StringVar(&reportFormat, "format", "markdown", "output format: markdown|json")
+	reportWeeklyCmd.Flags().StringVarP(&reportOutput, "output", "o", "", "write the report to this file instead of stdout")
+	reportWeeklyCmd.Flags().StringVar(&reportContainer, "container", troubleshoot.DefaultContainer, "engine container to read logs from")
+	reportWeeklyCmd.Flags().BoolVar(&reportNoCache, "no-cache", false, "collect every call's logs again instead of reusing cached data")
+	reportWeeklyCmd.Flags().StringVar(&reportLocale, "locale", "", "locale for numbers, dates and times (e.g. de-DE, en-US; default from ~/.agent/config)")
+	reportWeeklyCmd.Flags().StringVar(&reportClock, "clock", "", "12h or 24h clock (default: the locale's)")
+	reportWeeklyCmd.Flags().StringVar(&reportTenant, "tenant", "", "only calls of this tenant (see 'tenants' in ~/.agent/config)")
+	reportWeeklyCmd.Flags().DurationVar(&reportTimeout, "timeout", 0, "abort the report after this long (e.g. 10m, 0 = no limit)")
+
+	reportCmd.AddCommand(reportWeeklyCmd)
+	rootCmd.AddCommand(reportCmd)
+}
+
+func runReportWeekly(cmd *cobra.Command, args []string) error {
+	if reportFormat != "markdown" && reportFormat != "json" {
+		return fmt.Errorf("--format must be markdown or json")
+	}
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	localeName, clock := cfg.Locale, cfg.Clock
+	if reportLocale != "" {
+		localeName = reportLocale
+	}
+	if reportClock != "" {
+		clock = reportClock
+	}
+	lc, err := locale.Get(localeName, clock)
+	if err != nil {
+		return err
+	}
+	source, err := troubleshoot.NewLogSource(cfg.LogSource, logLoc)
+	if err != nil {
+		return err
+	}
+	indexAge, err := cfg.Retention.IndexMaxAge()
+	if err != nil {
+		return err
+	}
+	hours, err := troubleshoot.NewBusinessHours(cfg.BusinessHours, loc)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := runContext(reportTimeout)
+	defer cancel()
+
+	var instances []string
+	if !cmd.Flags().Changed("container") {
+		if found, err := engine.Instances(ctx); err == nil && len(found) > 1 {
+			for _, inst := range found {
+				instances = append(instances, inst.Container)
+			}
+		}
+	}
+
+	runner := troubleshoot.NewRunner(troubleshoot.Options{
+		Context:        ctx,
+		Container:      reportContainer,
+		Instances:      instances,
+		LogSource:      source,
+		IndexRetention: indexAge,
+		NoLLM:          true,
+		NoCache:        reportNoCache,
+		Verbose:        verbose,
+		Filter:         troubleshoot.CallFilter{Tenant: reportTenant},
+		Location:       loc,
+		LogLocation:    logLoc,
+		SLO:            cfg.SLO,
+		Costs:          cfg.Costs,
+		Tenants:        cfg.Tenants,
+		BusinessHours:  hours,
+	})
+	report, err := runner.Weekly()
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if reportFormat == "json" {
+		data, err = json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(report.Markdown(loc, lc))
+	}
+
+	if reportOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(reportOutput, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ Weekly report written to %s\n", reportOutput)
+	return nil
+}
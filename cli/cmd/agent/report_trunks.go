@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/cdr"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/locale"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/sbc"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/sip"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	trunksSince             string
+	trunksUntil             string
+	trunksCDR               string
+	trunksCDRUTC            bool
+	trunksSIPLog            string
+	trunksNames             []string
+	trunksAsteriskContainer string
+)
+
+var reportTrunksCmd = &cobra.Command{
+	Use:   "trunks",
+	Short: "Per-trunk carrier quality scorecards from CDRs and call analysis",
+	Long: `Score every trunk, inbound and outbound apart, from the Asterisk CDRs
+(cdr_csv's Master.csv) joined by uniqueid with the analysis of the calls
+the agent handled:
+
+  ASR            answered attempts over all attempts
+  ACD            average duration of the answered calls
+  PDD            post-dial delay of outbound INVITEs: from the INVITE to
+                 the first 180, 183 or final response in the SIP log
+  Dispositions   ANSWERED, NO ANSWER, BUSY, FAILED, CONGESTION
+  Hangup causes  Q.850 causes, network failures marked
+  MOS            estimated from the RTP statistics of answered calls
+  Failures       upstream (FAILED or CONGESTION, a network failure cause,
+                 MOS below 3.6) against the agent's own, with the
+                 upstream calls listed by uniqueid and time for the
+                 carrier's ticket
+
+Trunks are the PJSIP endpoints with an identify section, or --trunk;
+with neither, every SIP endpoint in the CDRs. cdr_csv does not record
+hangup causes or RTP statistics; store them in the userfield from the
+h extension of the contexts the trunks use:
+
+  exten => h,1,Set(CDR(userfield)=cause=${HANGUPCAUSE};${RTPAUDIOQOS})
+
+and have cdr_csv write uniqueid and userfield (cdr.conf, [csv]):
+
+  loguniqueid=yes
+  loguserfield=yes
+
+PDD needs 'pjsip set logger on' output in the Asterisk log (see 'agent
+logging level --component asterisk'). CDR times are read in local time;
+--cdr-utc reads them in UTC (usegmtime=yes). Without engine logs the
+scorecards come from the CDRs alone.
+
+Numbers, dates and times follow --locale and --clock as in 'agent
+report weekly'. Progress goes to stderr; the report to stdout unless
+--output is set.
+
+Examples:
+  agent report trunks
+  agent report trunks --since 30d --output trunks.md
+  agent report trunks --trunk telnyx --trunk twilio --since 2026-10-01 --until 2026-10-08
+  agent report trunks --asterisk-container asterisk --format json > trunks.json`,
+	Args: cobra.NoArgs,
+	RunE: runReportTrunks,
+}
+
+func init() {
+	f := reportTrunksCmd.Flags()
+	f.StringVar(&trunksSince, "since", "7d", "start of the window (duration like 7d/24h or a timestamp)")
+	f.StringVar(&trunksUntil, "until", "", "end of the window (default: now)")
+	f.StringVar(&trunksCDR, "cdr", cdr.DefaultPath, "cdr_csv file to read")
+	f.BoolVar(&trunksCDRUTC, "cdr-utc", false, "CDR times are UTC (cdr.conf usegmtime=yes)")
+	f.StringVar(&trunksSIPLog, "sip-log", "/var/log/asterisk/full", "Asterisk log with pjsip logger output, for PDD")
+	f.StringSliceVar(&trunksNames, "trunk", nil, "PJSIP endpoint facing a carrier (repeatable; default: every endpoint with an identify section)")
+	f.StringVar(&trunksAsteriskContainer, "asterisk-container", "", "read the CDRs and run Asterisk commands inside this container (docker exec)")
+	f.StringVar(&reportFormat, "format", "markdown", "output format: markdown|json")
+	f.StringVarP(&reportOutput, "output", "o", "", "write the report to this file instead of stdout")
+	f.StringVar(&reportContainer, "container", troubleshoot.DefaultContainer, "engine container to read logs from")
+	f.BoolVar(&reportNoCache, "no-cache", false, "collect every call's logs again instead of reusing cached data")
+	f.StringVar(&reportLocale, "locale", "", "locale for numbers, dates and times (e.g. de-DE, en-US; default from ~/.agent/config)")
+	f.StringVar(&reportClock, "clock", "", "12h or 24h clock (default: the locale's)")
+	f.DurationVar(&reportTimeout, "timeout", 0, "abort the report after this long (e.g. 10m, 0 = no limit)")
+
+	reportCmd.AddCommand(reportTrunksCmd)
+}
+
+func runReportTrunks(cmd *cobra.Command, args []string) error {
+	if reportFormat != "markdown" && reportFormat != "json" {
+		return fmt.Errorf("--format must be markdown or json")
+	}
+	verbose, _ := cmd.Flags().GetBool("verbose")
+
+	loc, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	localeName, clock := cfg.Locale, cfg.Clock
+	if reportLocale != "" {
+		localeName = reportLocale
+	}
+	if reportClock != "" {
+		clock = reportClock
+	}
+	lc, err := locale.Get(localeName, clock)
+	if err != nil {
+		return err
+	}
+	source, err := troubleshoot.NewLogSource(cfg.LogSource, logLoc)
+	if err != nil {
+		return err
+	}
+	indexAge, err := cfg.Retention.IndexMaxAge()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := runContext(reportTimeout)
+	defer cancel()
+
+	host := newAsteriskHost(trunksAsteriskContainer)
+	data, found, err := host.ReadFile(ctx, trunksCDR)
+	if err != nil {
+		return fmt.Errorf("cannot read %s: %w", host.Where(trunksCDR), err)
+	}
+	if !found {
+		return fmt.Errorf("no CDRs in %s (is cdr_csv loaded? set --cdr or --asterisk-container)", host.Where(trunksCDR))
+	}
+	cdrLoc := time.Local
+	if trunksCDRUTC {
+		cdrLoc = time.UTC
+	}
+	records, err := cdr.Parse(bytes.NewReader(data), cdrLoc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", host.Where(trunksCDR), err)
+	}
+	endpoints := trunkEndpoints(ctx, host, trunksNames)
+	opts := troubleshoot.TrunkOptions{
+		Records: records,
+		Trunks:  endpointNames(endpoints),
+		Delays:  trunkDelays(ctx, host, trunksSIPLog, endpoints),
+	}
+
+	var instances []string
+	if !cmd.Flags().Changed("container") {
+		if found, err := engine.Instances(ctx); err == nil && len(found) > 1 {
+			for _, inst := range found {
+				instances = append(instances, inst.Container)
+			}
+		}
+	}
+
+	runner := troubleshoot.NewRunner(troubleshoot.Options{
+		Context:        ctx,
+		Container:      reportContainer,
+		Instances:      instances,
+		LogSource:      source,
+		IndexRetention: indexAge,
+		NoLLM:          true,
+		NoCache:        reportNoCache,
+		Verbose:        verbose,
+		Since:          trunksSince,
+		Until:          trunksUntil,
+		Location:       loc,
+		LogLocation:    logLoc,
+		SLO:            cfg.SLO,
+		Tenants:        cfg.Tenants,
+	})
+	report, err := runner.Trunks(opts)
+	if err != nil {
+		return err
+	}
+
+	if reportFormat == "json" {
+		data, err = json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		data = append(data, '\n')
+	} else {
+		data = []byte(report.Markdown(loc, lc))
+	}
+
+	if reportOutput == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(reportOutput, data, 0644); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "✅ Trunk scorecards written to %s\n", reportOutput)
+	return nil
+}
+
+// trunkEndpoints returns the named trunks, or the endpoints with an
+// identify section, with their match criteria. Without Asterisk the
+// named trunks come back without them; with no names either, none.
+func trunkEndpoints(ctx context.Context, host *asteriskHost, names []string) []sbc.Endpoint {
+	out, err := host.Command(ctx, "pjsip show identifies")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Cannot list PJSIP trunks (%v); PDD is not measured\n", err)
+	}
+	matches := sip.IdentifyMatches(out)
+	if len(names) == 0 {
+		for name := range matches {
+			names = append(names, name)
+		}
+	}
+	endpoints := make([]sbc.Endpoint, 0, len(names))
+	for _, name := range names {
+		endpoints = append(endpoints, sbc.Endpoint{Name: name, Matches: matches[name]})
+	}
+	return endpoints
+}
+
+// trunkDelays measures the post-dial delay of the INVITEs the SIP log
+// shows sent to a trunk, attributed by identify match
+func trunkDelays(ctx context.Context, host *asteriskHost, logPath string, endpoints []sbc.Endpoint) []troubleshoot.TrunkDelay {
+	data, err := host.ReadTail(ctx, logPath, sbcLogTail)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	var delays []troubleshoot.TrunkDelay
+	for _, d := range sbc.PostDialDelays(sbc.ParseLog(string(data), time.Local)) {
+		for _, e := range endpoints {
+			if e.Covers(d.Addr) {
+				delays = append(delays, troubleshoot.TrunkDelay{Trunk: e.Name, Time: d.Time, Delay: d.Delay})
+				break
+			}
+		}
+	}
+	return delays
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/dialplan"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/sip"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+var routeCmd = &cobra.Command{
+	Use:   "route",
+	Short: "Inspect how inbound calls are routed",
+}
+
+var routeVerifyCmd = &cobra.Command{
+	Use:   "verify <did>...",
+	Short: "Trace where an inbound DID goes and flag DIDs that miss the agent",
+	Long: `Trace an inbound DID through the live dialplan and report whether it
+reaches the agent.
+
+For each DID, starting in the inbound context of every trunk (the
+context of each PJSIP endpoint with an identify section, or --context /
+--trunk), verify follows 'dialplan show' through Goto, GotoIf and Set
+until the call enters Stasis, is dialed, queued, sent to voicemail or
+hung up. It then reports:
+  - the path, priority by priority
+  - the Stasis application, and whether it is the engine's and is
+    registered in ARI
+  - the persona (AI_CONTEXT) and provider (AI_PROVIDER) set on the way,
+    and whether config/ai-agent.yaml defines them
+  - conditional jumps and AGI calls that may send some calls elsewhere
+
+The exit status is 1 when any DID misses the agent, so verify can run
+after every dialplan change or from monitoring.
+
+Examples:
+  agent route verify +15551234567
+  agent route verify 5551234567 5557654321 --context from-pstn
+  agent route verify +15551234567 --trunk telnyx
+  agent route verify +15551234567 --container asterisk`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRouteVerify,
+}
+
+var (
+	routeContexts  []string
+	routeTrunk     string
+	routeContainer string
+	routeDir       string
+)
+
+func init() {
+	f := routeVerifyCmd.Flags()
+	f.StringSliceVar(&routeContexts, "context", nil, "inbound context to start in (repeatable; default: the trunks' contexts)")
+	f.StringVar(&routeTrunk, "trunk", "", "start in the context of this PJSIP trunk")
+	f.StringVar(&routeContainer, "container", "", "run Asterisk commands inside this container (docker exec)")
+	f.StringVar(&routeDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+
+	routeCmd.AddCommand(routeVerifyCmd)
+	rootCmd.AddCommand(routeCmd)
+}
+
+// routeConfig is what verify checks a route against
+type routeConfig struct {
+	AppName   string
+	Contexts  map[string]bool
+	Providers map[string]bool
+}
+
+func loadRouteConfig(dir string) routeConfig {
+	rc := routeConfig{
+		AppName:   dialplan.DefaultExtensionOptions(dir).AppName,
+		Contexts:  make(map[string]bool),
+		Providers: make(map[string]bool),
+	}
+	data, err := remote.ReadFile(filepath.Join(dir, "config", "ai-agent.yaml"))
+	if err != nil {
+		return rc
+	}
+	var cfg struct {
+		Contexts  map[string]interface{} `yaml:"contexts"`
+		Providers map[string]interface{} `yaml:"providers"`
+		Pipelines map[string]interface{} `yaml:"pipelines"`
+	}
+	if yaml.Unmarshal(data, &cfg) != nil {
+		return rc
+	}
+	for name := range cfg.Contexts {
+		rc.Contexts[name] = true
+	}
+	for name := range cfg.Providers {
+		rc.Providers[name] = true
+	}
+	// AI_PROVIDER also selects pipelines by name
+	for name := range cfg.Pipelines {
+		rc.Providers[name] = true
+	}
+	return rc
+}
+
+func runRouteVerify(cmd *cobra.Command, args []string) error {
+	ctx, cancel := runContext(2 * time.Minute)
+	defer cancel()
+
+	host := newAsteriskHost(routeContainer)
+	contexts, err := inboundContexts(ctx, host)
+	if err != nil {
+		return err
+	}
+	rc := loadRouteConfig(routeDir)
+
+	// Whether the app is registered matters for every route; ask ARI once
+	var registration *ari.Registration
+	env, envErr := health.LoadEnvFile(filepath.Join(routeDir, ".env"))
+	if envErr != nil {
+		env, _ = health.LoadEnvFile(filepath.Join(routeDir, "config", ".env"))
+	}
+	if client, err := ari.FromEnv(env); err == nil {
+		registration = client.Diagnose(ctx, rc.AppName)
+	}
+
+	missed := 0
+	for _, did := range args {
+		for _, start := range contexts {
+			fmt.Printf("━━━ %s in %s ━━━\n", did, start)
+			trace, err := dialplan.TraceCall(ctx, host, start, did)
+			if err != nil {
+				return fmt.Errorf("dialplan show failed: %w", err)
+			}
+			if !printRoute(trace, rc, registration) {
+				missed++
+			}
+			fmt.Println()
+		}
+	}
+
+	total := len(args) * len(contexts)
+	if missed > 0 {
+		fmt.Printf("❌ %d of %d route(s) do not reach the agent\n", missed, total)
+		return fmt.Errorf("routes missing the agent")
+	}
+	fmt.Printf("✅ All %d route(s) reach the agent\n", total)
+	return nil
+}
+
+// inboundContexts returns the contexts to trace from: --context, the
+// context of --trunk, or the contexts of every identified (trunk)
+// endpoint, falling back to FreePBX's from-pstn
+func inboundContexts(ctx context.Context, host *asteriskHost) ([]string, error) {
+	if len(routeContexts) > 0 {
+		return routeContexts, nil
+	}
+	endpoints := []string{routeTrunk}
+	if routeTrunk == "" {
+		out, err := host.Command(ctx, "pjsip show identifies")
+		if err != nil {
+			return nil, fmt.Errorf("cannot list PJSIP trunks: %w", err)
+		}
+		endpoints = sip.IdentifiedEndpoints(out)
+	}
+	var contexts []string
+	seen := make(map[string]bool)
+	for _, endpoint := range endpoints {
+		out, err := host.Command(ctx, "pjsip show endpoint "+endpoint)
+		if err != nil {
+			return nil, err
+		}
+		c := sip.EndpointContext(out)
+		if c == "" {
+			if routeTrunk != "" {
+				return nil, fmt.Errorf("no PJSIP endpoint %s", routeTrunk)
+			}
+			continue
+		}
+		if !seen[c] {
+			seen[c] = true
+			contexts = append(contexts, c)
+		}
+	}
+	if len(contexts) == 0 {
+		fmt.Println("⚠️  No PJSIP trunks with an identify section found; tracing from from-pstn")
+		contexts = []string{"from-pstn"}
+	}
+	return contexts, nil
+}
+
+// printRoute prints a trace and the checks on where it ends; it returns
+// whether the call reaches the agent
+func printRoute(t *dialplan.Trace, rc routeConfig, registration *ari.Registration) bool {
+	for _, s := range t.Steps {
+		fmt.Printf("  %s\n", s)
+	}
+	for _, b := range t.Branches {
+		fmt.Printf("  ⚠️  %s\n", b)
+	}
+
+	if t.Outcome != dialplan.OutcomeAgent {
+		switch t.Outcome {
+		case dialplan.OutcomeElsewhere:
+			fmt.Printf("  ❌ Does not reach the agent: ends at %s\n", t.Reason)
+		case dialplan.OutcomeNoMatch:
+			fmt.Printf("  ❌ Does not reach the agent: %s\n", t.Reason)
+		default:
+			fmt.Printf("  ❌ Cannot tell whether it reaches the agent: %s\n", t.Reason)
+		}
+		return false
+	}
+
+	ok := true
+	fmt.Printf("  ✅ Reaches Stasis(%s)\n", t.App)
+	if t.App != rc.AppName {
+		fmt.Printf("  ❌ The engine's application is %s (asterisk.app_name); this call would not reach it\n", rc.AppName)
+		ok = false
+	} else if registration != nil {
+		switch {
+		case registration.Failure == ari.FailureNone:
+			fmt.Printf("     %s is registered in ARI\n", t.App)
+		case registration.Failure == ari.FailureNotRegistered:
+			fmt.Printf("  ❌ %s is not registered in ARI: the engine is not connected, so the call would fail\n", t.App)
+			ok = false
+		default:
+			fmt.Printf("  ⚠️  Could not check the ARI registration (%s): %v\n", registration.Failure, registration.Err)
+		}
+	}
+
+	persona := t.Vars["AI_CONTEXT"]
+	switch {
+	case persona == "":
+		fmt.Println("     Persona: default (no AI_CONTEXT)")
+	case len(rc.Contexts) > 0 && !rc.Contexts[persona]:
+		fmt.Printf("  ⚠️  Persona: %s is not under contexts: in the config; the engine uses the default\n", persona)
+	default:
+		fmt.Printf("     Persona: %s\n", persona)
+	}
+	if provider := t.Vars["AI_PROVIDER"]; provider != "" {
+		if len(rc.Providers) > 0 && !rc.Providers[provider] && !strings.Contains(provider, "${") {
+			fmt.Printf("  ⚠️  Provider: %s is neither a provider nor a pipeline in the config\n", provider)
+		} else {
+			fmt.Printf("     Provider: %s\n", provider)
+		}
+	}
+	return ok
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var rulesCmd = &cobra.Command{
+	Use:   "rules",
+	Short: "Known-issue rules used by troubleshoot",
+	Long: `Known-issue rules are log signatures of failures with a known fix.
+'agent troubleshoot' reports every rule a call's logs match.
+
+Rules live in ~/.agent/rules:
+  upstream.yaml   curated rules installed by 'agent rules update'
+  local.yaml      your own rules; an entry with the ID of an upstream
+                  rule replaces it, 'disabled: true' turns it off
+
+  rules:
+    - id: sbc-rtp-timeout
+      severity: critical          # critical | warning | info
+      message: SBC dropped RTP
+      match: ["RTP timeout", "(?i)no rtp for \\d+s"]
+      min_count: 1                # matching lines needed
+      fix: Open UDP 10000-20000 on the SBC
+      docs: https://wiki.example.com/sbc`,
+}
+
+var (
+	rulesUpdateURL   string
+	rulesUpdateNoSig bool
+)
+
+var rulesUpdateCmd = &cobra.Command{
+	Use:   "update",
+	Short: "Fetch the latest curated rules",
+	Long: `Fetch the curated known-issue rules from the project repository and
+install them as ~/.agent/rules/upstream.yaml, so new failure signatures
+arrive without upgrading the CLI. local.yaml is never changed.
+
+The rules must carry a valid ed25519 signature (<url>.sig) by the key
+built into this binary; nothing is installed otherwise. Builds without
+a key refuse rules unless --insecure-skip-signature is given. The
+report lists rules
+added, updated and removed, and upstream rules local.yaml overrides.
+
+--url fetches from a mirror, e.g. for air-gapped sites; copy
+known-issues.yaml.sig along with the rules.
+
+Examples:
+  agent rules update
+  agent rules update --dry-run
+  agent rules update --url https://mirror.example.com/known-issues.yaml`,
+	Args: cobra.NoArgs,
+	RunE: runRulesUpdate,
+}
+
+var rulesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the active rules",
+	Long: `List the merged upstream and local rules troubleshoot applies.
+
+Examples:
+  agent rules list`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rules, err := troubleshoot.LoadRules()
+		if err != nil {
+			return err
+		}
+		if len(rules) == 0 {
+			fmt.Println("No rules installed (run: agent rules update)")
+			return nil
+		}
+		fmt.Printf("Rules (%d) in %s:\n\n", len(rules), troubleshoot.RulesDir())
+		for _, r := range rules {
+			fmt.Printf("  %-36s %-9s %-14s %s\n", r.ID, r.Severity, r.Source, r.Message)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rulesUpdateCmd.Flags().StringVar(&rulesUpdateURL, "url", troubleshoot.DefaultRulesURL, "rules file to fetch")
+	rulesUpdateCmd.Flags().BoolVar(&rulesUpdateNoSig, "insecure-skip-signature", false, "install rules without checking their signature")
+	addDryRunFlag(rulesUpdateCmd)
+
+	rulesCmd.AddCommand(rulesUpdateCmd)
+	rulesCmd.AddCommand(rulesListCmd)
+	rootCmd.AddCommand(rulesCmd)
+}
+
+func runRulesUpdate(cmd *cobra.Command, args []string) error {
+	ctx, cancel := runContext(2 * time.Minute)
+	defer cancel()
+
+	update, err := troubleshoot.FetchRules(ctx, rulesUpdateURL, updatePublicKey, rulesUpdateNoSig)
+	if err != nil {
+		return fmt.Errorf("rules update failed: %w", err)
+	}
+
+	version := update.Version
+	if version == "" {
+		version = "unversioned"
+	}
+	fmt.Printf("Fetched %d rule(s), version %s\n", update.Total, version)
+	if update.Signed {
+		fmt.Println("🔏 Signature verified")
+	} else {
+		fmt.Println("⚠️  Signature NOT verified (--insecure-skip-signature)")
+	}
+
+	if !update.Changed() {
+		fmt.Println("✅ Rules already up to date")
+		return nil
+	}
+	if update.PreviousVersion != "" && update.PreviousVersion != update.Version {
+		fmt.Printf("Version: %s → %s\n", update.PreviousVersion, version)
+	}
+	printRuleIDs("Added", update.Added)
+	printRuleIDs("Updated", update.Updated)
+	printRuleIDs("Removed", update.Removed)
+	printRuleIDs("Overridden by local.yaml", update.Overridden)
+
+	path := filepath.Join(troubleshoot.RulesDir(), troubleshoot.UpstreamRulesFile)
+	if dryRun {
+		return planFile(path, update.Data())
+	}
+	if err := troubleshoot.InstallRules(update); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Installed %s\n", path)
+	return nil
+}
+
+func printRuleIDs(label string, ids []string) {
+	if len(ids) == 0 {
+		return
+	}
+	fmt.Printf("%s (%d): %s\n", label, len(ids), strings.Join(ids, ", "))
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/scale"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/spf13/cobra"
+)
+
+var scaleCmd = &cobra.Command{
+	Use:   "scale",
+	Short: "Run several ai_engine instances and spread calls across them",
+	Long: `Run N ai_engine instances side by side and distribute calls between
+them round-robin.
+
+ai_engine stays instance 1; instances 2..N (ai_engine_2, ...) get the next
+AudioSocket, ExternalMedia RTP and health ports and their own ARI app
+name (asterisk-ai-voice-agent-2, ...). Each engine opens its own media
+leg, so calls are distributed where they enter Stasis: the generated
+dialplan subroutine picks the next instance's app and skips instances
+whose app is not registered (stopped or restarting).
+
+Writes:
+  docker-compose.scale.yml      Compose override adding instances 2..N
+  extensions_aava_scale.conf    [aava-scale] round-robin subroutine
+
+To route calls through it, copy extensions_aava_scale.conf to
+/etc/asterisk, add '#include extensions_aava_scale.conf' to
+extensions_custom.conf, replace Stasis(asterisk-ai-voice-agent) with
+Gosub(aava-scale,s,1) in the AI agent contexts, then 'dialplan reload'.
+
+doctor reports each instance's health and load, and troubleshoot reads
+logs from all instances. --engines 1 removes the extra instances.
+--dry-run prints the file diffs and the docker commands --apply would
+run, without changing anything.
+
+Examples:
+  agent scale --engines 3
+  agent scale --engines 3 --apply
+  agent scale --engines 3 --apply --dry-run
+  agent scale --engines 1 --apply`,
+	Args: cobra.NoArgs,
+	RunE: runScale,
+}
+
+var (
+	scaleEngines int
+	scaleDir     string
+	scaleApply   bool
+)
+
+func init() {
+	scaleCmd.Flags().IntVar(&scaleEngines, "engines", 0, "number of ai_engine instances (required)")
+	scaleCmd.Flags().StringVar(&scaleDir, "dir", ".", "project directory (where docker-compose.yml lives)")
+	scaleCmd.Flags().BoolVar(&scaleApply, "apply", false, "start or remove instances with docker compose (or podman compose)")
+	scaleCmd.MarkFlagRequired("engines")
+	addDryRunFlag(scaleCmd)
+
+	rootCmd.AddCommand(scaleCmd)
+}
+
+func runScale(cmd *cobra.Command, args []string) error {
+	if scaleEngines < 1 || scaleEngines > 32 {
+		return fmt.Errorf("--engines must be between 1 and 32")
+	}
+	if _, err := os.Stat(filepath.Join(scaleDir, "docker-compose.yml")); err != nil {
+		return fmt.Errorf("no docker-compose.yml in %s (use --dir to point at the project)", scaleDir)
+	}
+
+	opts := scale.DefaultOptions(scaleDir, scaleEngines)
+	if dryRun {
+		var compose []byte
+		if scaleEngines > 1 {
+			compose = []byte(scale.GenerateCompose(opts))
+		}
+		if err := planFile(filepath.Join(scaleDir, scale.ComposeFile), compose); err != nil {
+			return err
+		}
+		if err := planFile(filepath.Join(scaleDir, scale.DialplanFile), []byte(scale.GenerateDialplan(opts))); err != nil {
+			return err
+		}
+	} else {
+		if err := scale.Write(scaleDir, opts); err != nil {
+			return fmt.Errorf("failed to write config: %w", err)
+		}
+		if scaleEngines == 1 {
+			fmt.Printf("✅ Removed %s\n", filepath.Join(scaleDir, scale.ComposeFile))
+		} else {
+			fmt.Printf("✅ Wrote %s\n", filepath.Join(scaleDir, scale.ComposeFile))
+		}
+		fmt.Printf("✅ Wrote %s\n", filepath.Join(scaleDir, scale.DialplanFile))
+	}
+	fmt.Println()
+
+	fmt.Println("Instances:")
+	for _, inst := range opts.Instances() {
+		fmt.Printf("  %-12s AudioSocket %d  RTP %d  health %d  app %s\n",
+			inst.Container, inst.AudioSocketPort, inst.RTPPort, inst.HealthPort, inst.AppName)
+	}
+	fmt.Println()
+
+	// Instances beyond the new count are removed directly rather than with
+	// --remove-orphans, which would also remove other overrides' services
+	var extra []string
+	if running, err := engine.Instances(cmd.Context()); err == nil {
+		for _, inst := range running {
+			if inst.Index > scaleEngines {
+				extra = append(extra, inst.Container)
+			}
+		}
+	}
+	var services []string
+	for _, inst := range opts.Instances()[1:] {
+		services = append(services, inst.Service)
+	}
+	up, composeEnv := composeCommand(cmd.Context(), append([]string{"-f", "docker-compose.yml", "-f", scale.ComposeFile, "up", "-d"}, services...)...)
+
+	if len(extra) == 0 && len(services) == 0 {
+		if dryRun {
+			dryRunDone()
+		}
+		return nil
+	}
+	if dryRun && scaleApply {
+		for _, name := range extra {
+			planCall("remove container %s (force; drops its active calls)", name)
+		}
+		if len(services) > 0 {
+			planCommand(scaleDir, up[0], up[1:]...)
+		}
+		dryRunDone()
+		return nil
+	}
+	if !scaleApply {
+		fmt.Println("Apply:")
+		if len(extra) > 0 {
+			fmt.Printf("  docker rm -f %s   # drops their active calls\n", strings.Join(extra, " "))
+		}
+		if len(services) > 0 {
+			fmt.Printf("  cd %s && %s\n", scaleDir, strings.Join(up, " "))
+			fmt.Printf("  then include %s in the Asterisk dialplan (see 'agent scale --help')\n", scale.DialplanFile)
+		}
+		if dryRun {
+			dryRunDone()
+		}
+		return nil
+	}
+
+	if len(extra) > 0 {
+		client, err := docker.Default()
+		if err != nil {
+			return err
+		}
+		for _, name := range extra {
+			fmt.Printf("Removing %s...\n", name)
+			if err := client.Remove(cmd.Context(), name, true); err != nil {
+				return fmt.Errorf("remove %s: %w", name, err)
+			}
+		}
+	}
+	if len(services) > 0 {
+		fmt.Println("Starting engine instances...")
+		c := exec.CommandContext(cmd.Context(), up[0], up[1:]...)
+		c.Dir = scaleDir
+		c.Env = composeEnv
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+		if err := selflog.Run(c); err != nil {
+			return fmt.Errorf("%s failed: %w", up[0], err)
+		}
+	}
+	fmt.Printf("✅ %d engine instance(s) configured; check with 'agent doctor'\n", scaleEngines)
+	return nil
+}
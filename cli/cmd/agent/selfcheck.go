@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var selfcheckCmd = &cobra.Command{
+	Use:   "selfcheck",
+	Short: "Debug the agent CLI itself",
+}
+
+var selfcheckBundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Pack the CLI's own log and environment for support",
+	Long: `Pack what is needed to debug the agent CLI itself on this machine into
+a tar.gz for support, when a command fails or the troubleshooter gives
+wrong answers:
+
+  cli.log       every run of the CLI, the external commands it started
+                (docker compose, asterisk, journalctl, ...) and the HTTP
+                APIs it called, with exit codes, status and durations
+  system.txt    CLI version, OS, user, working directory and the
+                environment variables the CLI reads
+  tools.txt     which external tools are found on PATH
+  docker.txt    the Docker or Podman endpoint the CLI resolved
+  config.yaml   ~/.agent/config
+  state.txt     the files under ~/.agent (names and sizes only)
+
+Credentials are redacted from every file. The CLI always logs to
+~/.agent/cli.log (rotated at 5 MB); --log-file writes elsewhere and
+--debug adds the output of successful commands and echoes every entry
+to stderr. To capture a failing command in full:
+
+  agent troubleshoot --debug
+  agent selfcheck bundle
+
+Examples:
+  agent selfcheck bundle
+  agent selfcheck bundle --output /tmp/agent-cli.tar.gz
+  agent troubleshoot --log-file /tmp/ts.log && agent selfcheck bundle --log-file /tmp/ts.log`,
+	Args: cobra.NoArgs,
+	RunE: runSelfcheckBundle,
+}
+
+var (
+	logFile         string
+	debugLog        bool
+	selfcheckOutput string
+	selfLogStart    time.Time
+)
+
+// selfcheckTools are the external tools the CLI runs
+var selfcheckTools = []string{
+	"docker", "docker-compose", "podman", "podman-compose", "asterisk", "journalctl",
+	"systemctl", "timedatectl", "chronyc", "ffmpeg", "curl", "psql", "bq", "aws",
+	"fail2ban-client", "iptables", "nft", "ufw", "firewall-cmd", "espeak-ng", "aplay",
+	"paplay", "ffplay", "ssh",
+}
+
+// selfcheckEnv are the environment variables the CLI reads
+var selfcheckEnv = []string{
+	"AGENT_STATE_DIR", "AGENT_SKIP_VERSION_CHECK", "DOCKER_HOST", "DOCKER_CONTEXT",
+	"DOCKER_TLS_VERIFY", "DOCKER_TLS", "DOCKER_CERT_PATH", "CONTAINER_HOST", "TZ", "LANG", "PATH",
+	"SHELL", "TERM", "NO_COLOR", "AGENT_THEME", "AGENT_REMOTE",
+}
+
+func init() {
+	selfcheckBundleCmd.Flags().StringVarP(&selfcheckOutput, "output", "o", "", "bundle file (default agent-selfcheck-<time>.tar.gz)")
+
+	selfcheckCmd.AddCommand(selfcheckBundleCmd)
+	rootCmd.AddCommand(selfcheckCmd)
+}
+
+// startSelfLog opens the CLI's own log and logs the run. A default log
+// that cannot be opened (e.g. a read-only home) is skipped silently.
+func startSelfLog(cmd *cobra.Command) {
+	if cmd.Name() == cobra.ShellCompRequestCmd || cmd.Name() == cobra.ShellCompNoDescRequestCmd {
+		return
+	}
+	selfLogStart = time.Now()
+	if err := selflog.Open(logFile, debugLog); err != nil && logFile != "" {
+		fmt.Fprintf(os.Stderr, "⚠️  --log-file: %v\n", err)
+	}
+	selflog.Install()
+	selflog.Write(selflog.Entry{
+		Event:   selflog.EventRun,
+		Command: selflog.CommandLine(os.Args),
+		Detail:  fmt.Sprintf("version %s (%s/%s)", version, runtime.GOOS, runtime.GOARCH),
+	})
+}
+
+// endSelfLog logs the end of the run
+func endSelfLog(err error) {
+	if selfLogStart.IsZero() {
+		return
+	}
+	e := selflog.Entry{Event: selflog.EventExit, DurationMs: time.Since(selfLogStart).Milliseconds()}
+	if err != nil {
+		e.Level = selflog.LevelError
+		e.Error = err.Error()
+	}
+	selflog.Write(e)
+	selflog.Close()
+}
+
+func runSelfcheckBundle(cmd *cobra.Command, args []string) error {
+	name := "agent-selfcheck-" + time.Now().Format("20060102-150405")
+	var files []selflog.File
+	add := func(file, text string) {
+		files = append(files, selflog.File{Name: file, Data: []byte(troubleshoot.RedactSecrets(text))})
+	}
+
+	logs := selflog.Files(logFile)
+	entries, failed := 0, 0
+	var log bytes.Buffer
+	for _, path := range logs {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		log.Write(data)
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			var e selflog.Entry
+			if json.Unmarshal(scanner.Bytes(), &e) != nil {
+				continue
+			}
+			entries++
+			if e.Level == selflog.LevelError {
+				failed++
+			}
+		}
+	}
+	if len(logs) == 0 {
+		fmt.Printf("⚠️  No CLI log at %s (written from now on)\n", selflog.Path())
+	} else {
+		add("cli.log", log.String())
+	}
+	add("system.txt", selfcheckSystem())
+	add("tools.txt", selfcheckToolList())
+	add("docker.txt", selfcheckDocker())
+	if data, err := os.ReadFile(settings.Path()); err == nil {
+		add("config.yaml", string(data))
+	}
+	add("state.txt", selfcheckState())
+
+	bundle, err := selflog.Bundle(name, files)
+	if err != nil {
+		return err
+	}
+	path := selfcheckOutput
+	if path == "" {
+		path = name + ".tar.gz"
+	}
+	if err := os.WriteFile(path, bundle, 0600); err != nil {
+		return fmt.Errorf("failed to write bundle: %w", err)
+	}
+	fmt.Printf("📦 Wrote %s (%d log entr(ies), %d failed)\n", path, entries, failed)
+	fmt.Println("   Credentials are redacted; review it before sharing")
+	return nil
+}
+
+// selfcheckSystem describes the CLI build and the machine it runs on
+func selfcheckSystem() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "version:    %s\n", version)
+	fmt.Fprintf(&b, "built:      %s\n", buildTime)
+	fmt.Fprintf(&b, "go:         %s\n", runtime.Version())
+	fmt.Fprintf(&b, "os/arch:    %s/%s (%d CPUs)\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU())
+	if exe, err := os.Executable(); err == nil {
+		fmt.Fprintf(&b, "executable: %s\n", exe)
+	}
+	if wd, err := os.Getwd(); err == nil {
+		fmt.Fprintf(&b, "directory:  %s\n", wd)
+	}
+	fmt.Fprintf(&b, "uid/gid:    %d/%d\n", os.Getuid(), os.Getgid())
+	fmt.Fprintf(&b, "state dir:  %s\n", settings.Dir())
+	if t := remote.Current(); t != nil {
+		fmt.Fprintf(&b, "remote:     %s (project %s)\n", t, t.Dir)
+	}
+	fmt.Fprintf(&b, "time:       %s\n", time.Now().Format(time.RFC3339))
+	b.WriteString("\nenvironment:\n")
+	for _, key := range selfcheckEnv {
+		if value, ok := os.LookupEnv(key); ok {
+			fmt.Fprintf(&b, "  %s=%s\n", key, value)
+		} else {
+			fmt.Fprintf(&b, "  %s (unset)\n", key)
+		}
+	}
+	return b.String()
+}
+
+// selfcheckToolList lists where the external tools are found
+func selfcheckToolList() string {
+	var b strings.Builder
+	for _, tool := range selfcheckTools {
+		if path, err := exec.LookPath(tool); err == nil {
+			fmt.Fprintf(&b, "%-16s %s\n", tool, path)
+		} else {
+			fmt.Fprintf(&b, "%-16s not found\n", tool)
+		}
+	}
+	return b.String()
+}
+
+// selfcheckDocker describes the container runtime endpoint in use
+func selfcheckDocker() string {
+	var b strings.Builder
+	ep, err := docker.ResolveEndpoint()
+	if err != nil {
+		fmt.Fprintf(&b, "endpoint: %v\n", err)
+	} else {
+		fmt.Fprintf(&b, "endpoint: %s\n", ep.Host)
+		if ep.Context != "" {
+			fmt.Fprintf(&b, "context:  %s\n", ep.Context)
+		}
+		if ep.CertPath != "" {
+			fmt.Fprintf(&b, "tls:      %s (verify %v)\n", ep.CertPath, !ep.SkipTLSVerify)
+		}
+	}
+	b.WriteString("local sockets:\n")
+	for _, s := range docker.LocalSockets() {
+		state := "missing"
+		if _, err := os.Stat(s); err == nil {
+			state = "present"
+		}
+		fmt.Fprintf(&b, "  %s (%s)\n", s, state)
+	}
+	return b.String()
+}
+
+// selfcheckState lists the files under the state directory
+func selfcheckState() string {
+	var b strings.Builder
+	root := settings.Dir()
+	filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		rel, _ := filepath.Rel(root, path)
+		fmt.Fprintf(&b, "%10d  %s  %s\n", info.Size(), info.ModTime().Format("2006-01-02 15:04"), rel)
+		return nil
+	})
+	return b.String()
+}
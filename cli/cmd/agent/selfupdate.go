@@ -0,0 +1,154 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selfupdate"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/spf13/cobra"
+)
+
+var selfUpdateCmd = &cobra.Command{
+	Use:   "self-update",
+	Short: "Update the agent CLI from GitHub releases",
+	Long: `Check GitHub releases for a newer CLI and replace the running binary.
+
+The download is verified against the release SHA256SUMS and its
+SHA256SUMS.sig ed25519 signature, checked with the key built into this
+binary. Builds without a key refuse to update unless
+--insecure-skip-signature is given. The new binary is written next to the current one and
+renamed over it, so an interrupted update leaves the old binary intact.
+--dry-run looks up the release and prints the download and the binary
+it would replace.
+
+Channels:
+  stable   Published releases only (default)
+  beta     Includes prereleases (v4.2.0-beta.1)
+
+The channel can be set in ~/.agent/config:
+  update:
+    channel: beta
+
+Examples:
+  agent self-update --check
+  agent self-update --dry-run
+  agent self-update
+  agent self-update --channel beta
+  agent self-update --version v4.1.2 --force`,
+	Args: cobra.NoArgs,
+	RunE: runSelfUpdate,
+}
+
+var (
+	selfUpdateChannel string
+	selfUpdateCheck   bool
+	selfUpdateVersion string
+	selfUpdateForce   bool
+	selfUpdateNoSig   bool
+)
+
+func init() {
+	selfUpdateCmd.Flags().StringVar(&selfUpdateChannel, "channel", "", "release channel: stable|beta (default from ~/.agent/config, else stable)")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateCheck, "check", false, "only report whether an update is available")
+	selfUpdateCmd.Flags().StringVar(&selfUpdateVersion, "version", "", "install this release tag instead of the latest")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateForce, "force", false, "install even if not newer than the current version")
+	selfUpdateCmd.Flags().BoolVar(&selfUpdateNoSig, "insecure-skip-signature", false, "install without checking the release signature (checksum only)")
+	addDryRunFlag(selfUpdateCmd)
+
+	rootCmd.AddCommand(selfUpdateCmd)
+}
+
+func runSelfUpdate(cmd *cobra.Command, args []string) error {
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	channel := selfUpdateChannel
+	if channel == "" {
+		channel = cfg.Update.Channel
+	}
+	if channel == "" {
+		channel = selfupdate.ChannelStable
+	}
+	if updatePublicKey == "" && !selfUpdateNoSig && !selfUpdateCheck {
+		return fmt.Errorf("%w (--insecure-skip-signature installs anyway)", selfupdate.ErrNoPublicKey)
+	}
+
+	ctx, cancel := runContext(10 * time.Minute)
+	defer cancel()
+
+	client := selfupdate.NewClient()
+	var rel *selfupdate.Release
+	if selfUpdateVersion != "" {
+		rel, err = client.ByTag(ctx, selfUpdateVersion)
+	} else {
+		rel, err = client.Latest(ctx, channel)
+	}
+	if err != nil {
+		return fmt.Errorf("release lookup failed: %w", err)
+	}
+
+	fmt.Printf("Current version: %s\n", version)
+	fmt.Printf("Latest %s:    %s (%s)\n", channel, rel.Tag, rel.Published.Format("2006-01-02"))
+
+	newer := selfupdate.Compare(rel.Tag, version) > 0
+	if !newer && !selfUpdateForce {
+		fmt.Println("✅ Already up to date")
+		return nil
+	}
+	if selfUpdateCheck {
+		if newer {
+			fmt.Printf("⬆️  Update available: agent self-update%s\n", channelFlag(channel))
+			fmt.Printf("   Release notes: %s\n", rel.URL)
+		}
+		return nil
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+
+	asset := selfupdate.AssetName(runtime.GOOS, runtime.GOARCH)
+	if dryRun {
+		url, ok := rel.Assets[asset]
+		if !ok {
+			return fmt.Errorf("release %s has no %s binary", rel.Tag, asset)
+		}
+		planCall("GET %s", url)
+		verify := "SHA256SUMS and its signature"
+		if selfUpdateNoSig {
+			verify = "SHA256SUMS only (--insecure-skip-signature)"
+		}
+		fmt.Printf("   Would verify it against %s, then replace %s (%s → %s)\n", verify, exe, version, rel.Tag)
+		dryRunDone()
+		return nil
+	}
+
+	fmt.Printf("Downloading %s...\n", asset)
+	bin, signed, err := client.Download(ctx, rel, updatePublicKey, selfUpdateNoSig)
+	if err != nil {
+		return err
+	}
+	if signed {
+		fmt.Println("✅ Checksum and signature verified")
+	} else {
+		fmt.Println("⚠️  Checksum verified; signature NOT checked (--insecure-skip-signature)")
+	}
+
+	if err := selfupdate.Install(exe, bin); err != nil {
+		return fmt.Errorf("install failed: %w", err)
+	}
+	fmt.Printf("✅ Updated %s → %s (%s)\n", version, rel.Tag, exe)
+	return nil
+}
+
+func channelFlag(channel string) string {
+	if channel == selfupdate.ChannelStable {
+		return ""
+	}
+	return " --channel " + channel
+}
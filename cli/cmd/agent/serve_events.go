@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/fleet"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/healthz"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/mqtt"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/queue"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/wallboard"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/websocket"
+)
+
+// eventsClientBuffer is how many events a slow client may lag behind
+// before it misses events
+const eventsClientBuffer = 256
+
+// serveHTTP runs the HTTP endpoints of serve until ctx is done
+func serveHTTP(ctx context.Context, addr string, mux *http.ServeMux) error {
+	srv := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdown)
+	}()
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// displayAddr makes a listen address like ":8090" printable as a URL host
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+// eventsHandler streams bus events to WebSocket clients as JSON text
+// messages. ?type= (comma-separated) and ?call_id= filter the stream.
+func eventsHandler(bus *events.Bus, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && !validToken(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		types := make(map[string]bool)
+		for _, t := range strings.Split(r.URL.Query().Get("type"), ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				types[t] = true
+			}
+		}
+		callID := r.URL.Query().Get("call_id")
+
+		conn, err := websocket.Upgrade(w, r)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		ch, unsubscribe := bus.Subscribe(eventsClientBuffer)
+		defer unsubscribe()
+
+		// The client only closes; reading notices that
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			select {
+			case <-closed:
+				return
+			case <-r.Context().Done():
+				return
+			case ev, ok := <-ch:
+				if !ok {
+					return
+				}
+				if (len(types) > 0 && !types[ev.Type]) || (callID != "" && ev.CallID != callID) {
+					continue
+				}
+				data, err := json.Marshal(ev)
+				if err != nil {
+					continue
+				}
+				if err := conn.WriteMessage(websocket.TextMessage, data); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
+
+// wallboardPageHandler serves the browser wallboard. The page holds no
+// data; it passes its ?token= on to wallboard.json.
+func wallboardPageHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	fmt.Fprint(w, wallboard.Page)
+}
+
+// wallboardStatsHandler serves the wallboard figures as JSON
+func wallboardStatsHandler(board *wallboard.Board, token string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token != "" && !validToken(r, token) {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(board.Stats(time.Now()))
+	}
+}
+
+// publishMQTT sends bus events to the broker until ctx is done. A broker
+// that is down loses the events published meanwhile.
+func publishMQTT(ctx context.Context, bus *events.Bus, pub *mqtt.Publisher) {
+	ch, unsubscribe := bus.Subscribe(eventsClientBuffer)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			payload, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			tenant, _ := ev.Data["tenant"].(string)
+			if err := pub.Publish(ctx, pub.Topic(tenant, ev.Type), payload); err != nil && ctx.Err() == nil {
+				fmt.Printf("⚠️  MQTT: %v\n", err)
+			}
+		}
+	}
+}
+
+// validToken accepts the token as a bearer token or, for browsers that
+// cannot set headers on WebSockets, as ?token=
+func validToken(r *http.Request, token string) bool {
+	got := r.URL.Query().Get("token")
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		got = strings.TrimPrefix(auth, "Bearer ")
+	}
+	return subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1
+}
+
+// followEngine feeds the engine container's log from since to the call
+// stream, reconnecting when the container restarts, until ctx is done.
+// The connection state goes to health as engine_log.
+func followEngine(ctx context.Context, container string, since time.Time, stream *troubleshoot.CallStream, health *healthz.State) {
+	for ctx.Err() == nil {
+		err := troubleshoot.FollowLogs(ctx, container, since, func(line string) bool {
+			health.Set("engine_log", nil)
+			health.Seen("engine_log")
+			stream.Observe(line)
+			return true
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("log stream of %s ended", container)
+		} else {
+			fmt.Printf("⚠️  Following %s: %v (retrying)\n", container, err)
+		}
+		health.Set("engine_log", err)
+		since = time.Now()
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// nodeName is the name queued events carry: --node, else the hostname
+func nodeName() string {
+	if serveNode != "" {
+		return serveNode
+	}
+	host, _ := os.Hostname()
+	return host
+}
+
+// queueDisplay returns a queue URL without its credentials, for output
+func queueDisplay(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
+
+// publishQueue sends the node's events to the queue until ctx is done.
+// Events arriving while the broker is unreachable are lost.
+func publishQueue(ctx context.Context, ch <-chan events.Event, q queue.Queue) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-ch:
+			if err := q.Publish(ctx, ev); err != nil && ctx.Err() == nil {
+				fmt.Printf("⚠️  Queue: %v\n", err)
+			}
+		}
+	}
+}
+
+// consumeQueue feeds the fleet's events to the wallboard and bus,
+// reconnecting when the broker drops the connection, until ctx is done.
+// The connection state goes to health as queue.
+func consumeQueue(ctx context.Context, q queue.Queue, board *wallboard.Board, bus *events.Bus, health *healthz.State) {
+	for ctx.Err() == nil {
+		health.Set("queue", nil)
+		err := q.Consume(ctx, func(ev events.Event) {
+			health.Seen("queue")
+			board.Observe(ev)
+			bus.Publish(ev)
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			err = fmt.Errorf("queue connection ended")
+		}
+		fmt.Printf("⚠️  Aggregating: %v (retrying)\n", err)
+		health.Set("queue", err)
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// aggregate raises the fleet's alerts from the events on the bus
+func aggregate(ctx context.Context, bus *events.Bus, agg *fleet.Aggregator, notifier *notify.Notifier) {
+	ch, unsubscribe := bus.Subscribe(eventsClientBuffer)
+	defer unsubscribe()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-ch:
+			if !ok {
+				return
+			}
+			for _, n := range agg.Observe(ev) {
+				if verbose {
+					fmt.Printf("[DEBUG] %s: %s\n", n.Kind, n.Title)
+				}
+				if notifier == nil {
+					continue
+				}
+				if _, err := notifier.Notify(ctx, n); err != nil {
+					fmt.Printf("⚠️  Notifying %s: %v\n", n.Kind, err)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/service"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"github.com/spf13/cobra"
+)
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Manage the engine and Asterisk services",
+	Long: `Lifecycle operations on the stack's services that take active calls
+and health into account.`,
+}
+
+var serviceRestartCmd = &cobra.Command{
+	Use:   "restart ai_engine|asterisk|all",
+	Short: "Restart a service after active calls drain",
+	Long: `Restart ai_engine, Asterisk or both without cutting calls off.
+
+Steps:
+  1. Snapshot container, version and quick doctor state
+  2. Put the engine in drain mode (see 'agent drain') and wait up to
+     --grace for active calls to finish (ai_engine counts engine
+     sessions; asterisk and all count every Asterisk channel)
+  3. Restart (Asterisk first for all, so the engine reconnects to ARI)
+  4. Wait for /health and ARI to answer again
+  5. Re-run the quick doctor pass and report what changed
+
+Asterisk is restarted as the "asterisk" container when one exists,
+otherwise with systemctl. Calls still active when the grace period ends
+abort the restart unless --force is given.
+
+Examples:
+  agent service restart ai_engine
+  agent service restart all --grace 10m
+  agent service restart asterisk --grace 0 --force`,
+	Args:      cobra.ExactValidArgs(1),
+	ValidArgs: service.Names,
+	RunE:      runServiceRestart,
+}
+
+var (
+	serviceGrace   time.Duration
+	serviceForce   bool
+	serviceTimeout time.Duration
+)
+
+func init() {
+	serviceRestartCmd.Flags().DurationVar(&serviceGrace, "grace", 5*time.Minute, "how long to wait for active calls to finish")
+	serviceRestartCmd.Flags().BoolVar(&serviceForce, "force", false, "restart even if calls are still active after --grace")
+	serviceRestartCmd.Flags().DurationVar(&serviceTimeout, "timeout", 2*time.Minute, "how long to wait for services to become healthy")
+
+	addDryRunFlag(serviceRestartCmd)
+
+	serviceCmd.AddCommand(serviceRestartCmd)
+	rootCmd.AddCommand(serviceCmd)
+}
+
+// serviceSnapshot is the state compared before and after a restart
+type serviceSnapshot struct {
+	engine   *service.ContainerState
+	health   *engineclient.Health
+	asterisk *ari.Info
+	doctor   *health.HealthResult
+}
+
+func runServiceRestart(cmd *cobra.Command, args []string) error {
+	target := args[0]
+	restartEngine := target == service.Engine || target == service.All
+	restartAsterisk := target == service.Asterisk || target == service.All
+
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	engineAPI := engine.Client(env)
+	ariClient, ariErr := ari.FromEnv(env)
+	if restartAsterisk && ariErr != nil {
+		return ariErr
+	}
+
+	ctx, cancel := runContext(0)
+	defer cancel()
+
+	if dryRun {
+		planServiceRestart(ctx, engineAPI, restartEngine, restartAsterisk)
+		dryRunDone()
+		return nil
+	}
+
+	checker := health.NewChecker(verbose)
+	checker.SetCLIVersion(version)
+
+	fmt.Println("📸 Recording current state...")
+	before := takeServiceSnapshot(ctx, checker, engineAPI, ariClient)
+
+	// Stop new calls at the engine while waiting. Restarting the engine
+	// clears drain mode; otherwise it is resumed below.
+	drained := false
+	if _, err := engineAPI.Drain(ctx, nil); err != nil {
+		fmt.Printf("⚠️  Could not put the engine in drain mode: %v\n", err)
+	} else {
+		drained = true
+		fmt.Println("🚰 Engine draining: new calls are refused")
+	}
+	// The engine leaves drain mode on every way out short of its own
+	// restart, Ctrl-C included, or it refuses calls until someone resumes
+	// it by hand
+	engineRestarted := false
+	resume := func() {
+		if drained && !engineRestarted {
+			drained = false
+			if _, err := engineAPI.Resume(context.Background()); err != nil {
+				fmt.Printf("⚠️  Could not resume the engine: %v (run 'agent drain --resume')\n", err)
+			}
+		}
+	}
+	defer resume()
+
+	count := func(ctx context.Context) (int, error) {
+		h, err := engineAPI.Health(ctx)
+		if err != nil {
+			return 0, err
+		}
+		return h.ActiveCalls, nil
+	}
+	what := "engine call(s)"
+	if restartAsterisk {
+		count = func(ctx context.Context) (int, error) {
+			channels, err := ariClient.Channels(ctx)
+			return len(channels), err
+		}
+		what = "Asterisk channel(s)"
+	}
+	remaining, err := service.Drain(ctx, serviceGrace, 2*time.Second, count, func(active int, left time.Duration) {
+		fmt.Printf("\r⏳ %d active %s, %s left   ", active, what, left.Round(time.Second))
+	})
+	fmt.Println()
+	if err != nil {
+		if ctx.Err() != nil {
+			return err
+		}
+		if !serviceForce {
+			return fmt.Errorf("cannot count active calls: %w (use --force to restart anyway)", err)
+		}
+		fmt.Printf("⚠️  Cannot count active calls: %v\n", err)
+	} else if remaining > 0 {
+		if !serviceForce {
+			return fmt.Errorf("%d %s still active after %s; raise --grace or use --force", remaining, what, serviceGrace)
+		}
+		fmt.Printf("⚠️  Restarting with %d %s active (--force)\n", remaining, what)
+	} else {
+		fmt.Println("✅ No active calls")
+	}
+
+	// Restart and verify
+	var failed []string
+	if restartAsterisk {
+		how, err := service.RestartAsterisk(ctx, 30*time.Second)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("🔄 Restarted Asterisk (%s)\n", how)
+		err = service.WaitFor(ctx, serviceTimeout, 2*time.Second, func(ctx context.Context) error {
+			_, err := ariClient.Info(ctx)
+			return err
+		})
+		if err != nil {
+			fmt.Printf("❌ ARI not answering after %s: %v\n", serviceTimeout, err)
+			failed = append(failed, "asterisk")
+		} else {
+			fmt.Println("✅ ARI is answering")
+		}
+	}
+	if restartEngine {
+		if err := service.RestartContainer(ctx, engine.ContainerName, 30*time.Second); err != nil {
+			return err
+		}
+		engineRestarted = true
+		fmt.Printf("🔄 Restarted %s\n", engine.ContainerName)
+		err := service.WaitFor(ctx, serviceTimeout, 2*time.Second, func(ctx context.Context) error {
+			h, err := engineAPI.Health(ctx)
+			if err != nil {
+				return err
+			}
+			if h.Status != "healthy" {
+				return fmt.Errorf("status %s (ARI connected: %t)", h.Status, h.ARIConnected)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("❌ %s not healthy after %s: %v\n", engine.ContainerName, serviceTimeout, err)
+			failed = append(failed, engine.ContainerName)
+		} else {
+			fmt.Printf("✅ %s is healthy\n", engine.ContainerName)
+		}
+	}
+
+	resume()
+
+	fmt.Println("🩺 Running quick doctor pass...")
+	after := takeServiceSnapshot(ctx, checker, engineAPI, ariClient)
+	printServiceChanges(before, after)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("restart finished but %v did not come back healthy", failed)
+	}
+	if after.doctor != nil && after.doctor.CriticalCount > 0 {
+		return fmt.Errorf("restart finished with %d failed doctor check(s); run 'agent doctor' for details", after.doctor.CriticalCount)
+	}
+	fmt.Println("✅ Restart complete")
+	return nil
+}
+
+// planServiceRestart prints the drain, restarts and resume a restart
+// would do
+func planServiceRestart(ctx context.Context, engineAPI *engineclient.Client, restartEngine, restartAsterisk bool) {
+	if h, err := engineAPI.Health(ctx); err == nil {
+		fmt.Printf("%d active engine call(s)\n", h.ActiveCalls)
+	}
+	planCall("POST %s/drain, then wait up to %s for active calls", engineAPI.BaseURL, serviceGrace)
+	if restartAsterisk {
+		if _, err := service.InspectContainer(ctx, service.Asterisk); err == nil {
+			planCall("restart container %s", service.Asterisk)
+		} else {
+			planCommand("", "systemctl", "restart", "asterisk")
+		}
+	}
+	if restartEngine {
+		planCall("restart container %s", engine.ContainerName)
+	} else {
+		planCall("DELETE %s/drain", engineAPI.BaseURL)
+	}
+}
+
+func takeServiceSnapshot(ctx context.Context, checker *health.Checker, engineAPI *engineclient.Client, ariClient *ari.Client) serviceSnapshot {
+	var s serviceSnapshot
+	s.engine, _ = service.InspectContainer(ctx, engine.ContainerName)
+	s.health, _ = engineAPI.Health(ctx)
+	if ariClient != nil {
+		s.asterisk, _ = ariClient.Info(ctx)
+	}
+	s.doctor, _ = checker.RunQuick()
+	return s
+}
+
+// printServiceChanges reports the differences between two snapshots
+func printServiceChanges(before, after serviceSnapshot) {
+	fmt.Println("")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+	fmt.Println("📋 What changed")
+	fmt.Println("━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━━")
+
+	changes := 0
+	change := func(format string, a ...interface{}) {
+		fmt.Printf("  • "+format+"\n", a...)
+		changes++
+	}
+
+	if b, a := before.engine, after.engine; b != nil && a != nil {
+		if !a.StartedAt.Equal(b.StartedAt) {
+			change("%s restarted at %s", engine.ContainerName, a.StartedAt.Local().Format("15:04:05"))
+		}
+		if a.ImageID != b.ImageID {
+			change("%s image %s → %s (%s)", engine.ContainerName, b.ImageID, a.ImageID, a.Image)
+		}
+		if a.Status != b.Status {
+			change("%s container %s → %s", engine.ContainerName, b.Status, a.Status)
+		}
+	}
+	if b, a := before.health, after.health; a != nil {
+		switch {
+		case b == nil:
+			change("engine /health now answering (%s)", a.Status)
+		case a.Status != b.Status:
+			change("engine status %s → %s", b.Status, a.Status)
+		}
+		if b != nil && a.Version != b.Version {
+			change("engine version %s → %s", b.Version, a.Version)
+		}
+	} else if b != nil {
+		change("engine /health no longer answering")
+	}
+	if b, a := before.asterisk, after.asterisk; b != nil && a != nil {
+		if !a.Status.StartupTime.Equal(b.Status.StartupTime.Time) {
+			change("Asterisk restarted at %s", a.Status.StartupTime.Local().Format("15:04:05"))
+		}
+		if a.System.Version != b.System.Version {
+			change("Asterisk version %s → %s", b.System.Version, a.System.Version)
+		}
+	}
+
+	if before.doctor != nil && after.doctor != nil {
+		previous := make(map[string]health.CheckStatus)
+		for _, c := range before.doctor.Checks {
+			previous[c.Name] = c.Status
+		}
+		for _, c := range after.doctor.Checks {
+			if p, ok := previous[c.Name]; ok && p != c.Status {
+				change("%s: %s → %s (%s)", c.Name, p, c.Status, c.Message)
+			}
+		}
+	}
+	if changes == 0 {
+		fmt.Println("  No changes detected")
+	}
+	if after.doctor != nil {
+		fmt.Printf("  Doctor: %d passed, %d warnings, %d failures\n",
+			after.doctor.PassCount, after.doctor.WarnCount, after.doctor.CriticalCount)
+	}
+	fmt.Println("")
+}
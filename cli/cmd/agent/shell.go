@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+var shellCmd = &cobra.Command{
+	Use:   "shell",
+	Short: "Interactive shell for running agent commands",
+	Long: `Start an interactive shell that runs agent commands in one process.
+
+Container logs fetched by one command are kept in memory and reused by
+the next, and LLM provider connections stay open, so a session like
+list → analyze → analyze another call does not re-read the logs each time.
+
+Type any agent command without the leading 'agent':
+  agent> troubleshoot --list
+  agent> troubleshoot --call 1761424308.2043 --no-llm
+  agent> troubleshoot history
+
+Shell commands:
+  help          Show available commands
+  clear-cache   Drop cached container logs (fetch fresh logs)
+  exit, quit    Leave the shell (or Ctrl-D)
+
+Ctrl-C interrupts the running command, not the shell.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runShell()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(shellCmd)
+}
+
+func runShell() error {
+	troubleshoot.EnableLogCache()
+
+	// Keep Ctrl-C from killing the shell; running commands still see it
+	// through their own signal context.
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+	go func() {
+		for range interrupts {
+		}
+	}()
+
+	fmt.Println("Asterisk AI Voice Agent shell. Type 'help' for commands, 'exit' to quit.")
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		fmt.Print("agent> ")
+		if !scanner.Scan() {
+			fmt.Println()
+			return scanner.Err()
+		}
+
+		args, err := splitArgs(scanner.Text())
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			continue
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		switch args[0] {
+		case "exit", "quit":
+			return nil
+		case "clear-cache":
+			troubleshoot.ClearLogCache()
+			fmt.Println("Log cache cleared")
+			continue
+		case "shell":
+			fmt.Fprintln(os.Stderr, "already in the agent shell")
+			continue
+		case "help":
+			if len(args) == 1 {
+				args = []string{"--help"}
+			}
+		}
+
+		resetFlags(rootCmd)
+		rootCmd.SetArgs(args)
+		var exit *exitCodeError
+		if err := rootCmd.Execute(); err != nil && !errors.As(err, &exit) {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	}
+}
+
+// resetFlags restores every flag to its default so values from the
+// previous shell command do not leak into the next one
+func resetFlags(cmd *cobra.Command) {
+	reset := func(f *pflag.Flag) {
+		f.Changed = false
+		if _, ok := f.Value.(pflag.SliceValue); !ok {
+			f.Value.Set(f.DefValue)
+			return
+		}
+		// Set appends to a slice flag once it has been set, so slices get
+		// their default back through Replace, behind a wrapper that makes
+		// the next Set replace it again
+		fs, ok := f.Value.(*freshSlice)
+		if !ok {
+			fs = &freshSlice{Value: f.Value, slice: f.Value.(pflag.SliceValue)}
+			f.Value = fs
+		}
+		fs.slice.Replace(sliceDefault(f.DefValue))
+		fs.fresh = true
+	}
+	cmd.Flags().VisitAll(reset)
+	cmd.PersistentFlags().VisitAll(reset)
+	for _, sub := range cmd.Commands() {
+		resetFlags(sub)
+	}
+}
+
+// freshSlice is a slice flag whose first Set after a reset replaces the
+// default instead of appending to it, as on a fresh command line
+type freshSlice struct {
+	pflag.Value
+	slice pflag.SliceValue
+	fresh bool
+}
+
+func (s *freshSlice) Set(val string) error {
+	vals := []string{val}
+	if s.Type() != "stringArray" {
+		vals = sliceDefault("[" + val + "]")
+	}
+	if s.fresh {
+		s.fresh = false
+		return s.slice.Replace(vals)
+	}
+	for _, v := range vals {
+		if err := s.slice.Append(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *freshSlice) Append(val string) error     { return s.slice.Append(val) }
+func (s *freshSlice) Replace(vals []string) error { return s.slice.Replace(vals) }
+func (s *freshSlice) GetSlice() []string          { return s.slice.GetSlice() }
+
+// sliceDefault parses a slice flag's default as pflag prints it: CSV
+// between brackets
+func sliceDefault(def string) []string {
+	def = strings.TrimSuffix(strings.TrimPrefix(def, "["), "]")
+	if def == "" {
+		return []string{}
+	}
+	vals, err := csv.NewReader(strings.NewReader(def)).Read()
+	if err != nil {
+		return strings.Split(def, ",")
+	}
+	return vals
+}
+
+// splitArgs splits a command line into words, honoring single and double
+// quotes and backslash escapes
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	for _, c := range line {
+		switch {
+		case escaped:
+			word.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				word.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+			inWord = true
+		case c == ' ' || c == '\t':
+			if inWord {
+				args = append(args, word.String())
+				word.Reset()
+				inWord = false
+			}
+		default:
+			word.WriteRune(c)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inWord {
+		args = append(args, word.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,352 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/dialplan"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/sip"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/wizard"
+	"github.com/spf13/cobra"
+)
+
+var sipCmd = &cobra.Command{
+	Use:   "sip",
+	Short: "Set up the Asterisk SIP side",
+}
+
+var sipWizardCmd = &cobra.Command{
+	Use:   "wizard",
+	Short: "Create a PJSIP trunk to an ITSP and route a DID to the agent",
+	Long: `Interactively create a PJSIP trunk and send a DID on it to the agent.
+
+Templates fill in the usual settings of common ITSPs:
+  telnyx     credential connection, registers to sip.telnyx.com
+  twilio     Elastic SIP Trunking, IP-authenticated origination
+  voipms     VoIP.ms sub-account, registers to the chosen POP
+  flowroute  IP-authenticated inbound, tech prefix outbound
+  generic    any other ITSP; asks for everything
+
+The wizard writes auth, aor, endpoint, identify and registration
+sections to --pjsip-file, and an inbound context from-<trunk> whose DID
+extension enters the agent (with optional AI_PROVIDER / AI_CONTEXT) to
+--dialplan-file. Both blocks are marked, so rerunning the wizard for the
+same trunk replaces them; each file is backed up first.
+
+It then reloads res_pjsip and the dialplan (over AMI when
+ASTERISK_AMI_USERNAME/ASTERISK_AMI_PASSWORD are set, otherwise
+asterisk -rx) and waits until the trunk registers, or for IP-authenticated
+trunks, until the ITSP answers qualify.
+
+Examples:
+  sudo agent sip wizard
+  sudo agent sip wizard --template telnyx --did +15551234567 --ai-context sales
+  agent sip wizard --container asterisk
+  agent sip wizard --template voipms --print`,
+	Args: cobra.NoArgs,
+	RunE: runSIPWizard,
+}
+
+var (
+	sipTemplate       string
+	sipName           string
+	sipDID            string
+	sipProvider       string
+	sipAIContext      string
+	sipPJSIPTransport string
+	sipPJSIPFile      string
+	sipDialplanFile   string
+	sipContainer      string
+	sipDir            string
+	sipPrint          bool
+	sipWait           time.Duration
+)
+
+func init() {
+	f := sipWizardCmd.Flags()
+	f.StringVar(&sipTemplate, "template", "", "ITSP template: "+strings.Join(sip.TemplateKeys(), ", "))
+	f.StringVar(&sipName, "name", "", "trunk name (default: the template key)")
+	f.StringVar(&sipDID, "did", "", "DID to route to the agent, as the ITSP sends it")
+	f.StringVar(&sipProvider, "provider", "", "set AI_PROVIDER for calls to the DID")
+	f.StringVar(&sipAIContext, "ai-context", "", "set AI_CONTEXT (persona) for calls to the DID")
+	f.StringVar(&sipPJSIPTransport, "pjsip-transport", "", "PJSIP transport section for the trunk (default: Asterisk picks one)")
+	f.StringVar(&sipPJSIPFile, "pjsip-file", sip.DefaultFile, "PJSIP file to write")
+	f.StringVar(&sipDialplanFile, "dialplan-file", dialplan.DefaultTarget, "dialplan file to write")
+	f.StringVar(&sipContainer, "container", "", "write the files inside this Asterisk container (docker exec)")
+	f.StringVar(&sipDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	f.BoolVar(&sipPrint, "print", false, "print the configuration instead of writing it")
+	f.DurationVar(&sipWait, "wait", 30*time.Second, "how long to wait for the trunk to register")
+	addDryRunFlag(sipWizardCmd)
+
+	sipCmd.AddCommand(sipWizardCmd)
+	rootCmd.AddCommand(sipCmd)
+}
+
+func runSIPWizard(cmd *cobra.Command, args []string) error {
+	fmt.Println()
+	fmt.Println("📞 Asterisk AI Voice Agent - SIP Trunk Wizard")
+	fmt.Println("══════════════════════════════════════════")
+	const steps = 4
+
+	// Step 1: ITSP
+	wizard.PrintStep(1, steps, "ITSP")
+	tmpl, ok := sip.FindTemplate(sipTemplate)
+	if sipTemplate != "" && !ok {
+		return fmt.Errorf("unknown template %q (use %s)", sipTemplate, strings.Join(sip.TemplateKeys(), ", "))
+	}
+	if !ok {
+		names := make([]string, len(sip.Templates))
+		for i, t := range sip.Templates {
+			names[i] = t.Name
+		}
+		tmpl = sip.Templates[wizard.PromptSelect("Select your ITSP:", names, 0)]
+	} else {
+		wizard.PrintInfo("Template: " + tmpl.Name)
+	}
+
+	// Step 2: trunk
+	wizard.PrintStep(2, steps, "Trunk")
+	name := sipName
+	if name == "" {
+		name = wizard.PromptText("Trunk name", tmpl.Key)
+	}
+	trunk := sip.Trunk{
+		Name:      name,
+		Register:  tmpl.Register,
+		Match:     tmpl.Match,
+		Codecs:    tmpl.Codecs,
+		Transport: sipPJSIPTransport,
+		Context:   "from-" + name,
+	}
+	if tmpl.ServerHint != "" {
+		wizard.PrintInfo("Server: " + tmpl.ServerHint)
+	}
+	trunk.Server = wizard.PromptText("ITSP server", tmpl.Server)
+	port, err := strconv.Atoi(wizard.PromptText("Port", strconv.Itoa(tmpl.Port)))
+	if err != nil || port <= 0 || port > 65535 {
+		return fmt.Errorf("invalid port")
+	}
+	trunk.Port = port
+	if tmpl.Key == "generic" {
+		trunk.Register = wizard.PromptConfirm("Register with the ITSP?", true)
+	}
+	if tmpl.Auth {
+		label := "Username"
+		if tmpl.UserHint != "" {
+			label += " (" + tmpl.UserHint + ")"
+		}
+		trunk.Username = wizard.PromptText(label, "")
+		trunk.Password = wizard.PromptPassword("Password", false)
+	}
+	if err := trunk.Validate(); err != nil {
+		return err
+	}
+
+	// Step 3: DID route
+	wizard.PrintStep(3, steps, "DID Route")
+	did := sipDID
+	if did == "" {
+		did = wizard.PromptText(fmt.Sprintf("DID (%s)", tmpl.DIDFormat), "")
+	}
+	if did == "" || strings.ContainsAny(did, " \t,;[]") {
+		return fmt.Errorf("invalid DID %q", did)
+	}
+	trunk.ContactUser = did
+	aiContext := sipAIContext
+	if aiContext == "" && !cmd.Flags().Changed("ai-context") {
+		aiContext = wizard.PromptText("AI context / persona (blank for the default)", "")
+	}
+
+	route := dialplan.DefaultExtensionOptions(sipDir)
+	route.Extension = did
+	route.Context = trunk.Context
+	route.Provider = sipProvider
+	route.AIContext = aiContext
+	transport := route.ConfigTransport
+	if transport == "" {
+		transport = dialplan.TransportAudioSocket
+	}
+	if err := route.SetTransport(transport); err != nil {
+		return err
+	}
+
+	pjsipBlock := dialplan.BeginMarker(trunk.Label()) + "\n" +
+		"; Generated by: agent sip wizard (" + tmpl.Name + ")\n" +
+		sip.Render(trunk) +
+		dialplan.EndMarker(trunk.Label()) + "\n"
+	routeBlock := dialplan.GenerateExtension(route)
+
+	// Step 4: review and apply
+	wizard.PrintStep(4, steps, "Review and Apply")
+	fmt.Println()
+	fmt.Printf("━━━ %s ━━━\n", sipPJSIPFile)
+	fmt.Print(maskSIPPassword(pjsipBlock, trunk.Password))
+	fmt.Printf("━━━ %s ━━━\n", sipDialplanFile)
+	fmt.Print(routeBlock)
+	fmt.Println()
+	for _, note := range tmpl.Notes {
+		wizard.PrintInfo(note)
+	}
+	if sipPrint {
+		return nil
+	}
+	ctx, cancel := runContext(sipWait + time.Minute)
+	defer cancel()
+	host := newAsteriskHost(sipContainer)
+
+	if dryRun {
+		if err := planBlock(ctx, host, sipPJSIPFile, pjsipBlock, trunk.Label(), true); err != nil {
+			return err
+		}
+		if err := planBlock(ctx, host, sipDialplanFile, routeBlock, "extension "+did, false); err != nil {
+			return err
+		}
+		planCall("module reload res_pjsip.so (%s)", host.Via())
+		planCall("dialplan reload (%s)", host.Via())
+		dryRunDone()
+		return nil
+	}
+	if !wizard.PromptConfirm("Write these sections and reload Asterisk?", true) {
+		fmt.Println("Nothing written.")
+		return nil
+	}
+
+	if err := installBlock(ctx, host, sipPJSIPFile, pjsipBlock, trunk.Label()); err != nil {
+		return err
+	}
+	if err := installBlock(ctx, host, sipDialplanFile, routeBlock, "extension "+did); err != nil {
+		return err
+	}
+	for _, command := range []string{"module reload res_pjsip.so", "dialplan reload"} {
+		if _, err := host.Command(ctx, command); err != nil {
+			return fmt.Errorf("%s failed: %w", command, err)
+		}
+	}
+	wizard.PrintSuccess("Reloaded res_pjsip and the dialplan (" + host.Via() + ")")
+
+	fmt.Println()
+	if err := verifyTrunk(ctx, host, trunk); err != nil {
+		wizard.PrintError(err.Error())
+		fmt.Printf("  Check: asterisk -rx 'pjsip show endpoint %s'\n", trunk.Name)
+		return fmt.Errorf("trunk %s is not up", trunk.Name)
+	}
+	wizard.PrintSuccess(fmt.Sprintf("Calls to %s on %s now reach the agent (%s@%s)", did, trunk.Name, did, trunk.Context))
+	return nil
+}
+
+// installBlock merges a marked block into path, backing the file up first
+func installBlock(ctx context.Context, host *asteriskHost, path, block, label string) error {
+	existing, found, err := host.ReadFile(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", host.Where(path), err)
+	}
+	merged := dialplan.MergeBlock(string(existing), block, label)
+	if merged == string(existing) {
+		wizard.PrintSuccess(fmt.Sprintf("%s already up to date", host.Where(path)))
+		return nil
+	}
+	backup, err := host.Install(ctx, path, existing, []byte(merged), found)
+	if backup != "" {
+		wizard.PrintInfo(fmt.Sprintf("Backed up %s to %s", host.Where(path), backup))
+	}
+	if err != nil {
+		return err
+	}
+	wizard.PrintSuccess("Wrote " + host.Where(path))
+	return nil
+}
+
+// planBlock prints the diff installBlock would make; with secrets, the
+// password of every section in the file is masked
+func planBlock(ctx context.Context, host *asteriskHost, path, block, label string, secrets bool) error {
+	where := host.Where(path)
+	existing, found, err := host.ReadFile(ctx, path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", where, err)
+	}
+	merged := dialplan.MergeBlock(string(existing), block, label)
+	if found && merged != string(existing) {
+		fmt.Printf("📦 Would back up %s to %s.aava-backup-<time>\n", where, where)
+	}
+	mask := func(text string) string {
+		if !secrets {
+			return text
+		}
+		return sipPasswordLine.ReplaceAllStringFunc(text, func(line string) string {
+			key := sipPasswordLine.FindStringSubmatch(line)
+			return key[1] + wizard.GetMaskedKey(key[2])
+		})
+	}
+	var before []byte
+	if found {
+		before = []byte(mask(string(existing)))
+	}
+	planWrite(where, before, []byte(mask(merged)))
+	return nil
+}
+
+// sipPasswordLine matches a password option in a PJSIP file
+var sipPasswordLine = regexp.MustCompile(`(?m)^([ \t]*password[ \t]*=[ \t]*)(\S.*?)[ \t]*$`)
+
+// verifyTrunk waits for the registration, or for IP-authenticated trunks
+// the qualify of the ITSP's contact, until --wait
+func verifyTrunk(ctx context.Context, host *asteriskHost, trunk sip.Trunk) error {
+	deadline := time.Now().Add(sipWait)
+	if trunk.Register {
+		wizard.PrintInfo(fmt.Sprintf("Waiting for %s to register...", trunk.RegistrationName()))
+	} else {
+		wizard.PrintInfo(fmt.Sprintf("Qualifying %s...", trunk.Name))
+		host.Command(ctx, "pjsip qualify "+trunk.Name)
+	}
+	state := ""
+	for {
+		if trunk.Register {
+			out, err := host.Command(ctx, "pjsip show registrations")
+			if err != nil {
+				return err
+			}
+			state = sip.RegistrationState(out, trunk)
+			switch state {
+			case "Registered":
+				wizard.PrintSuccess(fmt.Sprintf("%s registered to %s", trunk.Name, trunk.Server))
+				return nil
+			case "Rejected":
+				return fmt.Errorf("%s rejected the registration: check the username and password", trunk.Server)
+			}
+		} else {
+			out, err := host.Command(ctx, "pjsip show contacts")
+			if err != nil {
+				return err
+			}
+			state = sip.ContactState(out, trunk)
+			if state == "Avail" || state == "Reachable" {
+				wizard.PrintSuccess(fmt.Sprintf("%s answers qualify", trunk.Server))
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	if state == "" {
+		return fmt.Errorf("%s is not loaded; check %s for errors (asterisk -rx 'pjsip show endpoints')", trunk.Name, sipPJSIPFile)
+	}
+	return fmt.Errorf("%s is %s after %s: check the server, firewall and NAT settings", trunk.Name, state, sipWait)
+}
+
+// maskSIPPassword hides the password in the review
+func maskSIPPassword(block, password string) string {
+	if password == "" {
+		return block
+	}
+	return strings.Replace(block, "password="+password+"\n", "password="+wizard.GetMaskedKey(password)+"\n", 1)
+}
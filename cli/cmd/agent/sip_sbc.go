@@ -0,0 +1,283 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/sbc"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/sip"
+	"github.com/spf13/cobra"
+)
+
+var sipSBCCmd = &cobra.Command{
+	Use:   "sbc",
+	Short: "Check the trunks behind a Kamailio, OpenSIPS or AudioCodes SBC",
+	Long: `Check the Asterisk side of trunks fronted by a session border controller
+for the misconfigurations that pass for agent audio bugs:
+
+  topology hiding  a Contact Asterisk cannot reach (Kamailio topoh/topos,
+                   OpenSIPS topology_hiding, a private address) without
+                   Record-Route or rewrite_contact, so BYE and re-INVITE
+                   are lost and callers stay connected; a private SDP
+                   address without rtp_symmetric (one-way audio); a
+                   stripped P-Asserted-Identity (no caller number)
+  ptime            the SBC's offered packetization against Asterisk's
+                   answer (choppy or clipped audio)
+  transcoding      the SBC preferring a codec Asterisk does not pick,
+                   endpoints preferring compressed codecs, live calls
+                   Asterisk decodes (latency, artifacts)
+  RTP timeouts     rtp_timeout that keeps dead calls, and rtp_keepalive
+                   against the SBC's no-RTP timeout (rtpengine and
+                   rtpproxy: 60s), which hangs up while the agent is silent
+
+The endpoints are the PJSIP endpoints with an identify section, or
+--trunk. SIP is read from the 'pjsip set logger' output in the Asterisk
+log (the last 32 MB of --sip-log); capture a few calls first with
+'agent logging level --component asterisk --set debug --for 10m'. The
+vendor is detected from User-Agent/Server and only changes the advice.
+
+The same settings in ~/.agent/config add these checks to 'agent doctor':
+  sbc:
+    vendor: kamailio
+    trunks: [kamailio]
+    media_timeout: 60s
+
+Examples:
+  agent sip sbc
+  agent sip sbc --trunk sbc-a --trunk sbc-b --vendor audiocodes --media-timeout 30s
+  agent sip sbc --container asterisk --format json`,
+	Args: cobra.NoArgs,
+	RunE: runSIPSBC,
+}
+
+// sbcLogTail is how much of the Asterisk log is searched for SIP
+const sbcLogTail = 32 << 20
+
+var (
+	sbcTrunks       []string
+	sbcVendor       string
+	sbcSIPLog       string
+	sbcMediaTimeout time.Duration
+	sbcContainer    string
+	sbcFormat       string
+)
+
+func init() {
+	f := sipSBCCmd.Flags()
+	f.StringSliceVar(&sbcTrunks, "trunk", nil, "PJSIP endpoint facing the SBC (repeatable; default: every endpoint with an identify section)")
+	f.StringVar(&sbcVendor, "vendor", "", "SBC vendor: "+strings.Join(sbc.Vendors, ", ")+" (default: detect)")
+	f.StringVar(&sbcSIPLog, "sip-log", "", "Asterisk log with pjsip logger output (default /var/log/asterisk/full)")
+	f.DurationVar(&sbcMediaTimeout, "media-timeout", 0, "how long the SBC keeps a call without RTP (default: the vendor's usual)")
+	f.StringVar(&sbcContainer, "container", "", "run Asterisk commands inside this container (docker exec)")
+	f.StringVar(&sbcFormat, "format", "text", "output format: text|json")
+
+	sipCmd.AddCommand(sipSBCCmd)
+}
+
+func runSIPSBC(cmd *cobra.Command, args []string) error {
+	if sbcFormat != "text" && sbcFormat != "json" {
+		return fmt.Errorf("invalid --format %q (use text or json)", sbcFormat)
+	}
+	cfg := settings.SBC{}
+	if s, err := settings.Load(); err == nil {
+		cfg = s.SBC
+	}
+	if len(sbcTrunks) > 0 {
+		cfg.Trunks = sbcTrunks
+	}
+	if sbcVendor != "" {
+		cfg.Vendor = sbcVendor
+	}
+	if sbcSIPLog != "" {
+		cfg.SIPLog = sbcSIPLog
+	}
+	if sbcMediaTimeout > 0 {
+		cfg.MediaTimeout = sbcMediaTimeout.String()
+	}
+
+	ctx, cancel := runContext(2 * time.Minute)
+	defer cancel()
+	ins, err := inspectSBC(ctx, newAsteriskHost(sbcContainer), cfg)
+	if err != nil {
+		return err
+	}
+	checks := ins.Checks()
+
+	if sbcFormat == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(map[string]interface{}{
+			"vendor":    ins.Vendor,
+			"endpoints": endpointNames(ins.Endpoints),
+			"sip_log":   ins.SIPLog,
+			"calls":     len(ins.Calls),
+			"channels":  len(ins.Channels),
+			"checks":    checks,
+		})
+	}
+
+	fmt.Printf("🛡️  %s in front of %s (%d call(s) in %s, %d live)\n",
+		ins.VendorName(), strings.Join(endpointNames(ins.Endpoints), ", "), len(ins.Calls), ins.SIPLog, len(ins.Channels))
+	fmt.Println()
+	failed := false
+	for _, c := range checks {
+		fmt.Printf("%s %s: %s\n", sbcStatusIcons[c.Status], c.Name, c.Message)
+		for _, line := range strings.Split(c.Details, "\n") {
+			if line != "" {
+				fmt.Printf("     %s\n", line)
+			}
+		}
+		if c.Remediation != "" && (c.Status == health.StatusWarn || c.Status == health.StatusFail) {
+			fmt.Printf("     💡 %s\n", c.Remediation)
+		}
+		failed = failed || c.Status == health.StatusFail
+	}
+	if failed {
+		return fmt.Errorf("SBC interop checks failed")
+	}
+	return nil
+}
+
+var sbcStatusIcons = map[health.CheckStatus]string{
+	health.StatusPass: "✅",
+	health.StatusWarn: "⚠️ ",
+	health.StatusFail: "❌",
+	health.StatusInfo: "ℹ️ ",
+}
+
+// inspectSBC gathers the endpoints facing the SBC, the calls it sent
+// in the Asterisk log and the live channels on those endpoints
+func inspectSBC(ctx context.Context, host *asteriskHost, cfg settings.SBC) (*sbc.Inspection, error) {
+	if cfg.Vendor != "" && !sbc.KnownVendor(cfg.Vendor) {
+		return nil, fmt.Errorf("unknown SBC vendor %q (use %s)", cfg.Vendor, strings.Join(sbc.Vendors, ", "))
+	}
+	var mediaTimeout time.Duration
+	if cfg.MediaTimeout != "" {
+		d, err := time.ParseDuration(cfg.MediaTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("sbc.media_timeout: %w", err)
+		}
+		mediaTimeout = d
+	}
+
+	out, err := host.Command(ctx, "pjsip show identifies")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list PJSIP trunks: %w", err)
+	}
+	matches := sip.IdentifyMatches(out)
+	names := cfg.Trunks
+	if len(names) == 0 {
+		for name := range matches {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+	}
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no PJSIP endpoint with an identify section; name the endpoints facing the SBC with --trunk")
+	}
+	var endpoints []sbc.Endpoint
+	for _, name := range names {
+		out, err := host.Command(ctx, "pjsip show endpoint "+name)
+		if err != nil {
+			return nil, err
+		}
+		params := sip.Params(out)
+		if params["context"] == "" {
+			return nil, fmt.Errorf("no PJSIP endpoint %s", name)
+		}
+		endpoints = append(endpoints, sbc.Endpoint{Name: name, Params: params, Matches: matches[name]})
+	}
+
+	logPath := cfg.SIPLog
+	if logPath == "" {
+		logPath = "/var/log/asterisk/full"
+	}
+	data, logErr := host.ReadTail(ctx, logPath, sbcLogTail)
+	ins := sbc.NewInspection(cfg.Vendor, endpoints, sbc.ParseLog(string(data), time.Local))
+	ins.SIPLog = host.Where(logPath)
+	ins.LogErr = logErr
+	ins.MediaTimeout = mediaTimeout
+
+	ins.Channels, err = sbcChannels(ctx, host, names)
+	if err != nil {
+		return nil, err
+	}
+	return ins, nil
+}
+
+// sbcChannels returns the live channels of the endpoints with their
+// formats from 'core show channel'
+func sbcChannels(ctx context.Context, host *asteriskHost, endpoints []string) ([]sbc.Channel, error) {
+	out, err := host.Command(ctx, "core show channels concise")
+	if err != nil {
+		return nil, err
+	}
+	facing := make(map[string]bool)
+	for _, e := range endpoints {
+		facing[e] = true
+	}
+	var channels []sbc.Channel
+	for _, line := range strings.Split(out, "\n") {
+		name := strings.TrimSpace(strings.SplitN(line, "!", 2)[0])
+		if !strings.HasPrefix(name, "PJSIP/") {
+			continue
+		}
+		endpoint := strings.TrimPrefix(name, "PJSIP/")
+		if i := strings.LastIndex(endpoint, "-"); i > 0 {
+			endpoint = endpoint[:i]
+		}
+		if !facing[endpoint] {
+			continue
+		}
+		detail, err := host.Command(ctx, "core show channel "+name)
+		if err != nil {
+			continue
+		}
+		p := sip.Params(detail)
+		channels = append(channels, sbc.Channel{
+			Name:           name,
+			Endpoint:       endpoint,
+			Native:         p["NativeFormats"],
+			ReadTranscode:  p["ReadTranscode"],
+			WriteTranscode: p["WriteTranscode"],
+		})
+	}
+	return channels, nil
+}
+
+func endpointNames(endpoints []sbc.Endpoint) []string {
+	names := make([]string, len(endpoints))
+	for i, e := range endpoints {
+		names[i] = e.Name
+	}
+	return names
+}
+
+// addSBCChecks adds the SBC interop checks to doctor when sbc: is set in
+// ~/.agent/config
+func addSBCChecks(checker *health.Checker) {
+	cfg, err := settings.Load()
+	if err != nil || (cfg.SBC.Vendor == "" && len(cfg.SBC.Trunks) == 0 && cfg.SBC.SIPLog == "" && cfg.SBC.MediaTimeout == "") {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	ins, err := inspectSBC(ctx, newAsteriskHost(""), cfg.SBC)
+	if err != nil {
+		checker.AddCheck(func() health.Check {
+			return health.Check{Name: "SBC interop", Status: health.StatusWarn, Message: "Cannot inspect the SBC trunks", Details: err.Error()}
+		})
+		return
+	}
+	for _, check := range ins.Checks() {
+		check := check
+		checker.AddCheck(func() health.Check { return check })
+	}
+}
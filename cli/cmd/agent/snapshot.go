@@ -0,0 +1,213 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/snapshot"
+	"github.com/spf13/cobra"
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Capture the deployment state for later comparison",
+	Long: `Capture a versioned snapshot of the deployment and save it in
+~/.agent/snapshots:
+  - images: every container's image reference and digest
+  - files: sha256 of .env, config/*.yaml, the compose files and the
+    Asterisk configs (extensions, pjsip, ari, http, rtp, manager)
+  - asterisk: version and loaded modules
+  - providers: provider and pipeline settings from ai-agent.yaml
+  - env: .env variables
+  - system: kernel release, audio-relevant sysctls, CPUs, memory and
+    network interfaces
+
+Secrets (keys, tokens, passwords) are stored as a short hash, so a
+changed secret shows up in a diff without being revealed.
+
+Take one before upgrades and changes; when "nothing changed but it
+broke", 'agent snapshot diff' shows what did.
+
+Examples:
+  agent snapshot --note "before 4.2 upgrade"
+  agent snapshot --container asterisk
+  agent snapshot list
+  agent snapshot diff                      # latest snapshot vs now
+  agent snapshot diff 20261017-101500 20261018-090000`,
+	Args: cobra.NoArgs,
+	RunE: runSnapshot,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved snapshots",
+	Args:  cobra.NoArgs,
+	RunE:  runSnapshotList,
+}
+
+var snapshotDiffCmd = &cobra.Command{
+	Use:   "diff [old] [new]",
+	Short: "Compare two snapshots, or a snapshot with the current state",
+	Long: `Show what differs between two snapshots. With one snapshot it is
+compared with the current state; with none, the latest snapshot is.
+Snapshots are named by ID (see 'agent snapshot list') or file path.
+
+Examples:
+  agent snapshot diff
+  agent snapshot diff 20261017-101500
+  agent snapshot diff 20261017-101500 20261018-090000
+  agent snapshot diff before.json after.json`,
+	Args: cobra.MaximumNArgs(2),
+	RunE: runSnapshotDiff,
+}
+
+var (
+	snapshotDir       string
+	snapshotContainer string
+	snapshotOutput    string
+	snapshotNote      string
+)
+
+func init() {
+	snapshotCmd.PersistentFlags().StringVar(&snapshotDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	snapshotCmd.PersistentFlags().StringVar(&snapshotContainer, "container", "", "read Asterisk state inside this container (docker exec)")
+	snapshotCmd.Flags().StringVarP(&snapshotOutput, "output", "o", "", "write the snapshot to this file instead of ~/.agent/snapshots")
+	snapshotCmd.Flags().StringVar(&snapshotNote, "note", "", "describe why the snapshot was taken")
+
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotDiffCmd)
+	rootCmd.AddCommand(snapshotCmd)
+}
+
+func captureSnapshot(note string) *snapshot.Snapshot {
+	ctx, cancel := runContext(2 * time.Minute)
+	defer cancel()
+	return snapshot.Capture(ctx, snapshot.Options{
+		ProjectDir: snapshotDir,
+		Asterisk:   newAsteriskHost(snapshotContainer),
+		CLIVersion: version,
+		Note:       note,
+	})
+}
+
+func runSnapshot(cmd *cobra.Command, args []string) error {
+	fmt.Println("📸 Capturing snapshot...")
+	s := captureSnapshot(snapshotNote)
+	path, err := snapshot.Save(s, snapshotOutput)
+	if err != nil {
+		return err
+	}
+	for _, section := range snapshot.Sections {
+		fmt.Printf("   %-10s %d item(s)\n", section, len(s.Section(section)))
+	}
+	for _, e := range s.Errors {
+		fmt.Printf("⚠️  %s\n", e)
+	}
+	fmt.Printf("✅ Saved snapshot %s to %s\n", s.ID, path)
+	return nil
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	snapshots, err := snapshot.List()
+	if err != nil {
+		return err
+	}
+	if len(snapshots) == 0 {
+		fmt.Println("No snapshots yet. Take one with: agent snapshot")
+		return nil
+	}
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%-18s %-19s %-12s %s\n", "ID", "TIME", "CLI", "NOTE")
+	for _, s := range snapshots {
+		fmt.Printf("%-18s %-19s %-12s %s\n", s.ID, s.CreatedAt.In(loc).Format("2006-01-02 15:04:05"), s.CLIVersion, s.Note)
+	}
+	return nil
+}
+
+func runSnapshotDiff(cmd *cobra.Command, args []string) error {
+	var old, cur *snapshot.Snapshot
+	var err error
+	switch len(args) {
+	case 0:
+		snapshots, err := snapshot.List()
+		if err != nil {
+			return err
+		}
+		if len(snapshots) == 0 {
+			return fmt.Errorf("no snapshots to compare with; take one with: agent snapshot")
+		}
+		old = snapshots[len(snapshots)-1]
+	default:
+		if old, err = snapshot.Load(args[0]); err != nil {
+			return err
+		}
+	}
+	if len(args) == 2 {
+		if cur, err = snapshot.Load(args[1]); err != nil {
+			return err
+		}
+	} else {
+		cur = captureSnapshot("")
+		cur.ID = "now"
+	}
+
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	describe := func(s *snapshot.Snapshot) string {
+		d := s.ID + " (" + s.CreatedAt.In(loc).Format("2006-01-02 15:04")
+		if s.Note != "" {
+			d += ", " + s.Note
+		}
+		return d + ")"
+	}
+	fmt.Printf("Comparing %s → %s\n", describe(old), describe(cur))
+	if old.Host != cur.Host {
+		fmt.Printf("⚠️  Snapshots come from different hosts: %s and %s\n", old.Host, cur.Host)
+	}
+	for _, e := range cur.Errors {
+		fmt.Printf("⚠️  %s\n", e)
+	}
+
+	changes := snapshot.Diff(old, cur)
+	if len(changes) == 0 {
+		fmt.Println("\n✅ No differences")
+		return nil
+	}
+	section := ""
+	for _, c := range changes {
+		if c.Section != section {
+			section = c.Section
+			fmt.Printf("\n━━━ %s ━━━\n", section)
+		}
+		switch c.Kind {
+		case snapshot.Added:
+			fmt.Printf("  + %s: %s\n", c.Key, snapshotValue(c.New))
+		case snapshot.Removed:
+			fmt.Printf("  - %s: %s\n", c.Key, snapshotValue(c.Old))
+		default:
+			fmt.Printf("  ~ %s: %s → %s\n", c.Key, snapshotValue(c.Old), snapshotValue(c.New))
+		}
+	}
+	fmt.Printf("\n%d difference(s)\n", len(changes))
+	return nil
+}
+
+// snapshotValue shortens hashes and multi-line values for display
+func snapshotValue(v string) string {
+	if strings.HasPrefix(v, "sha256:") && len(v) > 19 {
+		return v[:19]
+	}
+	if i := strings.IndexByte(v, '\n'); i >= 0 {
+		v = v[:i] + " ..."
+	}
+	if v == "" {
+		return `""`
+	}
+	return v
+}
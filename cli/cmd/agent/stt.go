@@ -0,0 +1,397 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/scenario"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/vocab"
+	"github.com/spf13/cobra"
+)
+
+var sttCmd = &cobra.Command{
+	Use:   "stt",
+	Short: "Speech-to-text settings",
+}
+
+var sttVocabCmd = &cobra.Command{
+	Use:   "vocab",
+	Short: "Manage the custom vocabulary (keyword boosts) of every STT provider",
+	Long: `Keep one list of words callers say that the recognizer gets wrong
+(product names, street names, people) and write it into every STT
+provider of config/ai-agent.yaml in the provider's own form:
+
+  Deepgram   keywords (term:boost) or keyterms (nova-3, flux)
+  Google     config_overrides.speechContexts (phrases, boost)
+  OpenAI     prompt (Whisper spelling hints)
+
+The list is kept under 'vocabulary' in config/ai-agent.yaml; add and
+remove rewrite the provider settings, and 'push' does after editing
+the list by hand. Providers without keyword boosting (local Vosk,
+Gemini Live, OpenAI Realtime, ElevenLabs) are listed as unsupported.
+Restart the engine to apply the config, then check with 'verify',
+which places a test call saying the terms.
+
+Examples:
+  agent stt vocab add "Acme Turbo" "Elm Street" --boost 2
+  agent stt vocab list
+  agent stt vocab remove "Elm Street"
+  agent stt vocab verify --dial Local/7000@from-internal`,
+}
+
+var sttVocabAddCmd = &cobra.Command{
+	Use:   "add <term>...",
+	Short: "Add terms and write them to the providers",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSTTVocabAdd,
+}
+
+var sttVocabRemoveCmd = &cobra.Command{
+	Use:   "remove <term>...",
+	Short: "Remove terms and write the list to the providers",
+	Args:  cobra.MinimumNArgs(1),
+	RunE:  runSTTVocabRemove,
+}
+
+var sttVocabListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "Show the vocabulary and which providers hold it",
+	Args:  cobra.NoArgs,
+	RunE:  runSTTVocabList,
+}
+
+var sttVocabPushCmd = &cobra.Command{
+	Use:   "push",
+	Short: "Write the vocabulary to the providers after editing it by hand",
+	Args:  cobra.NoArgs,
+	RunE:  runSTTVocabPush,
+}
+
+var sttVocabVerifyCmd = &cobra.Command{
+	Use:   "verify",
+	Short: "Place a test call saying the terms and check the transcripts",
+	Long: `Place a test call (as 'agent call test' does) whose caller says
+every term of the vocabulary, a few per sentence, and check that the
+engine's transcripts of the caller contain them. Terms are compared
+without case and punctuation. Run it after restarting the engine with
+the pushed config; missed terms are listed and the command exits
+non-zero.
+
+Examples:
+  agent stt vocab verify --dial Local/7000@from-internal
+  agent stt vocab verify --dial Local/7000@from-internal --term "Acme Turbo"`,
+	Args: cobra.NoArgs,
+	RunE: runSTTVocabVerify,
+}
+
+var (
+	vocabDir        string
+	vocabBoost      float64
+	vocabFormat     string
+	vocabDial       string
+	vocabTerms      []string
+	vocabContainer  string
+	vocabListenHost string
+)
+
+// vocabPerSentence is how many terms one test sentence says
+const vocabPerSentence = 4
+
+var vocabPunctuation = regexp.MustCompile(`[^\pL\pN]+`)
+
+func init() {
+	sttVocabCmd.PersistentFlags().StringVar(&vocabDir, "dir", ".", "project directory (where config/ai-agent.yaml lives)")
+	sttVocabAddCmd.Flags().Float64Var(&vocabBoost, "boost", 0, "boost weight for the terms (0 = provider default)")
+	sttVocabListCmd.Flags().StringVar(&vocabFormat, "format", "text", "output format: text|json")
+
+	f := sttVocabVerifyCmd.Flags()
+	f.StringVar(&vocabDial, "dial", "", "endpoint to call, e.g. Local/7000@from-internal")
+	f.StringSliceVar(&vocabTerms, "term", nil, "terms to say instead of the whole vocabulary (repeatable)")
+	f.StringVar(&vocabContainer, "container", engine.ContainerName, "engine container whose log holds the transcripts")
+	f.StringVar(&vocabListenHost, "listen-host", "", "address Asterisk sends the audio to (default: this machine's address toward Asterisk)")
+	f.StringVar(&vocabFormat, "format", "text", "output format: text|json")
+	sttVocabVerifyCmd.MarkFlagRequired("dial")
+	addDryRunFlag(sttVocabAddCmd, sttVocabRemoveCmd, sttVocabPushCmd)
+
+	sttVocabCmd.AddCommand(sttVocabAddCmd, sttVocabRemoveCmd, sttVocabListCmd, sttVocabPushCmd, sttVocabVerifyCmd)
+	sttCmd.AddCommand(sttVocabCmd)
+	rootCmd.AddCommand(sttCmd)
+}
+
+func runSTTVocabAdd(cmd *cobra.Command, args []string) error {
+	if vocabBoost < 0 {
+		return fmt.Errorf("--boost must not be negative")
+	}
+	return updateVocab(func(terms []vocab.Term) ([]vocab.Term, error) {
+		terms, added := vocab.Add(terms, args, vocabBoost)
+		if dryRun {
+			fmt.Printf("Would add %d term(s), update %d\n", added, len(args)-added)
+		} else {
+			fmt.Printf("✅ Added %d term(s), %d updated\n", added, len(args)-added)
+		}
+		return terms, nil
+	})
+}
+
+func runSTTVocabRemove(cmd *cobra.Command, args []string) error {
+	return updateVocab(func(terms []vocab.Term) ([]vocab.Term, error) {
+		terms, missing := vocab.Remove(terms, args)
+		if len(missing) == len(args) {
+			return nil, fmt.Errorf("not in the vocabulary: %s", strings.Join(missing, ", "))
+		}
+		for _, m := range missing {
+			fmt.Printf("⚠️  Not in the vocabulary: %s\n", m)
+		}
+		if dryRun {
+			fmt.Printf("Would remove %d term(s)\n", len(args)-len(missing))
+		} else {
+			fmt.Printf("✅ Removed %d term(s)\n", len(args)-len(missing))
+		}
+		return terms, nil
+	})
+}
+
+func runSTTVocabPush(cmd *cobra.Command, args []string) error {
+	return updateVocab(func(terms []vocab.Term) ([]vocab.Term, error) {
+		return terms, nil
+	})
+}
+
+// updateVocab changes the vocabulary, writes it to the providers and
+// saves the config
+func updateVocab(change func([]vocab.Term) ([]vocab.Term, error)) error {
+	cfg, err := vocab.Open(vocabDir)
+	if err != nil {
+		return err
+	}
+	terms, err := cfg.Terms()
+	if err != nil {
+		return err
+	}
+	if terms, err = change(terms); err != nil {
+		return err
+	}
+	if err := cfg.SetTerms(terms); err != nil {
+		return err
+	}
+	targets, err := cfg.Push(terms)
+	if err != nil {
+		return err
+	}
+	if dryRun {
+		data, err := cfg.Content()
+		if err != nil {
+			return err
+		}
+		if err := planFile(cfg.Path, data); err != nil {
+			return err
+		}
+		dryRunDone()
+		return nil
+	}
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+
+	written := 0
+	for _, t := range targets {
+		if t.Unsupported != "" {
+			fmt.Printf("   ⏭️  %s: %s\n", t.Name, t.Unsupported)
+			continue
+		}
+		written++
+		fmt.Printf("   ✏️  %s → %s\n", t.Name, t.Setting)
+	}
+	fmt.Printf("%d term(s) in %s\n", len(terms), cfg.Path)
+	if written == 0 {
+		fmt.Println("⚠️  No STT provider of the config takes a vocabulary")
+		return nil
+	}
+	fmt.Println("Restart the engine to apply: docker compose restart ai-engine")
+	return nil
+}
+
+func runSTTVocabList(cmd *cobra.Command, args []string) error {
+	if vocabFormat != "text" && vocabFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", vocabFormat)
+	}
+	cfg, err := vocab.Open(vocabDir)
+	if err != nil {
+		return err
+	}
+	terms, err := cfg.Terms()
+	if err != nil {
+		return err
+	}
+	targets := cfg.Targets(terms)
+
+	if vocabFormat == "json" {
+		if terms == nil {
+			terms = []vocab.Term{}
+		}
+		out, err := json.MarshalIndent(struct {
+			Terms   []vocab.Term   `json:"terms"`
+			Targets []vocab.Target `json:"targets"`
+		}{terms, targets}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+		return nil
+	}
+
+	if len(terms) == 0 {
+		fmt.Println("No custom vocabulary (add terms with 'agent stt vocab add <term>')")
+	} else {
+		fmt.Printf("📖 %d term(s):\n", len(terms))
+		for _, t := range terms {
+			if t.Boost != 0 {
+				fmt.Printf("  %-32s boost %g\n", t.Term, t.Boost)
+			} else {
+				fmt.Printf("  %s\n", t.Term)
+			}
+		}
+	}
+	fmt.Println()
+	fmt.Println("STT providers:")
+	stale := false
+	for _, t := range targets {
+		switch {
+		case t.Unsupported != "":
+			fmt.Printf("  ⏭️  %s: %s\n", t.Name, t.Unsupported)
+		case t.InSync:
+			fmt.Printf("  ✅ %s → %s\n", t.Name, t.Setting)
+		default:
+			stale = true
+			fmt.Printf("  ⚠️  %s → %s differs from the list\n", t.Name, t.Setting)
+		}
+	}
+	if len(targets) == 0 {
+		fmt.Println("  none found in the config")
+	}
+	if stale {
+		fmt.Println("\nWrite the list to every provider: agent stt vocab push")
+	}
+	return nil
+}
+
+// vocabCheck is whether one term was heard on the verify call
+type vocabCheck struct {
+	Term  string `json:"term"`
+	Heard bool   `json:"heard"`
+}
+
+func runSTTVocabVerify(cmd *cobra.Command, args []string) error {
+	if vocabFormat != "text" && vocabFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", vocabFormat)
+	}
+	terms := vocabTerms
+	if len(terms) == 0 {
+		cfg, err := vocab.Open(vocabDir)
+		if err != nil {
+			return err
+		}
+		list, err := cfg.Terms()
+		if err != nil {
+			return err
+		}
+		for _, t := range list {
+			terms = append(terms, t.Term)
+		}
+	}
+	if len(terms) == 0 {
+		return fmt.Errorf("the vocabulary is empty (add terms with 'agent stt vocab add')")
+	}
+
+	// The caller says a few terms per sentence, giving the recognizer
+	// some context as callers would
+	var steps []scenario.Step
+	for i := 0; i < len(terms); i += vocabPerSentence {
+		end := i + vocabPerSentence
+		if end > len(terms) {
+			end = len(terms)
+		}
+		steps = append(steps, scenario.Step{Say: "I am calling about " + joinTerms(terms[i:end]) + "."})
+	}
+	steps = append(steps, scenario.Step{Pause: "3s"}, scenario.Step{Hangup: true})
+	sc, err := scenario.New("stt-vocab", vocabDial, steps)
+	if err != nil {
+		return err
+	}
+
+	_, logLoc, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(sc.CallTimeout())
+	defer cancel()
+	report, err := runScenario(ctx, sc, vocabContainer, vocabListenHost, logLoc, vocabFormat == "text")
+	if err != nil {
+		return err
+	}
+	if report.CallID == "" {
+		return fmt.Errorf("the engine's call was not found: transcripts cannot be checked")
+	}
+
+	heard := " " + normalizeVocab(strings.Join(report.Heard, " ")) + " "
+	checks := make([]vocabCheck, 0, len(terms))
+	missed := 0
+	for _, term := range terms {
+		ok := strings.Contains(heard, " "+normalizeVocab(term)+" ")
+		if !ok {
+			missed++
+		}
+		checks = append(checks, vocabCheck{Term: term, Heard: ok})
+	}
+
+	if vocabFormat == "json" {
+		out, err := json.MarshalIndent(struct {
+			CallID string       `json:"call_id"`
+			Terms  []vocabCheck `json:"terms"`
+			Heard  []string     `json:"heard"`
+		}{report.CallID, checks, report.Heard}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		fmt.Println()
+		if len(report.Heard) == 0 {
+			fmt.Println("⚠️  No caller transcripts in the engine log of the call")
+		}
+		for _, h := range report.Heard {
+			fmt.Printf("   heard: %q\n", h)
+		}
+		for _, c := range checks {
+			if c.Heard {
+				fmt.Printf("✅ %s\n", c.Term)
+			} else {
+				fmt.Printf("❌ %s\n", c.Term)
+			}
+		}
+		fmt.Printf("\n%d of %d term(s) recognized (call %s, %s)\n", len(checks)-missed, len(checks), report.CallID,
+			(time.Duration(report.ElapsedMs) * time.Millisecond).Round(time.Second))
+	}
+	if missed > 0 {
+		return fmt.Errorf("%d term(s) not recognized: check that the engine was restarted with the pushed config ('agent stt vocab list')", missed)
+	}
+	return nil
+}
+
+// joinTerms lists terms as a sentence would: "a, b and c"
+func joinTerms(terms []string) string {
+	if len(terms) == 1 {
+		return terms[0]
+	}
+	return strings.Join(terms[:len(terms)-1], ", ") + " and " + terms[len(terms)-1]
+}
+
+// normalizeVocab lowercases text and turns punctuation into single
+// spaces, so "Acme-Turbo," matches "acme turbo"
+func normalizeVocab(text string) string {
+	return strings.TrimSpace(vocabPunctuation.ReplaceAllString(strings.ToLower(text), " "))
+}
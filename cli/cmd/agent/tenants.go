@@ -0,0 +1,377 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/quota"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"github.com/spf13/cobra"
+)
+
+var tenantsCmd = &cobra.Command{
+	Use:   "tenants",
+	Short: "Per-tenant usage quotas and call throttling",
+}
+
+var tenantsQuotaCmd = &cobra.Command{
+	Use:   "quota",
+	Short: "Track tenant minutes, tokens and cost against their quotas",
+	Long: `Total each tenant's call minutes, LLM tokens and cost in its current
+quota period and compare them with the quota in ~/.agent/config:
+
+  tenants:
+    - name: acme
+      dids: ["+4930123450*"]
+      quota:
+        period: month        # month (default), week or day
+        minutes: 10000
+        tokens: 5000000      # summed from the providers' usage logs
+        cost: 500            # priced with 'costs', see 'agent report weekly'
+        warn_at: 80          # percent of a limit that warns (default 80)
+        action: throttle     # alert (default) or throttle
+        max_calls: 2         # concurrent calls left while throttled (0 = none)
+        fallback: over-quota # dialplan target of refused calls
+
+Calls are matched to tenants as in 'agent troubleshoot' (see tenants in
+'agent troubleshoot --help'). A tenant reaching warn_at sends a warning
+quota_exceeded notification, one exceeding a limit a critical one, each
+once per period.
+
+With action: throttle, an exceeded tenant is throttled through the
+engine API: calls to its DIDs or dialplan contexts beyond max_calls
+concurrent ones are hung up with congestion or continued to fallback
+(context[,extension[,priority]]). The throttle is lifted when the tenant
+is back within its quota, e.g. in the next period or after its limits
+were raised, or with --release. Throttles last until an engine restart;
+run with --every to keep them in place. Alerts and throttles sent are
+kept in ~/.agent/quota.json, with the usage of each call of the current
+periods: calls still count after the container logs rotate, as long as
+a run saw them before.
+
+Tokens are summed from the usage the engine logs for OpenAI (realtime
+and pipeline), Google Live, Google and Ollama. Deepgram and ElevenLabs
+agents and local models report no usage, so their calls count 0 tokens
+against a tokens quota.
+
+Examples:
+  agent tenants quota
+  agent tenants quota --every 15m
+  agent tenants quota --format json
+  agent tenants quota --no-throttle --no-notify
+  agent tenants quota --release acme`,
+	Args: cobra.NoArgs,
+	RunE: runTenantsQuota,
+}
+
+var (
+	quotaEvery      time.Duration
+	quotaFormat     string
+	quotaNoThrottle bool
+	quotaNoNotify   bool
+	quotaRelease    string
+	quotaContainer  string
+	quotaNoCache    bool
+	quotaTimeout    time.Duration
+)
+
+func init() {
+	f := tenantsQuotaCmd.Flags()
+	f.DurationVar(&quotaEvery, "every", 0, "keep running and check at this interval (e.g. 15m)")
+	f.StringVar(&quotaFormat, "format", "text", "output format: text|json")
+	f.BoolVar(&quotaNoThrottle, "no-throttle", false, "only report and alert, throttle no tenant")
+	f.BoolVar(&quotaNoNotify, "no-notify", false, "send no notifications")
+	f.StringVar(&quotaRelease, "release", "", "lift the throttle of this tenant (all for every tenant) and exit")
+	f.StringVar(&quotaContainer, "container", troubleshoot.DefaultContainer, "engine container to read logs from")
+	f.BoolVar(&quotaNoCache, "no-cache", false, "collect every call's logs again instead of reusing cached data")
+	f.DurationVar(&quotaTimeout, "timeout", 0, "abort one check after this long (0 = no limit)")
+
+	tenantsCmd.AddCommand(tenantsQuotaCmd)
+	rootCmd.AddCommand(tenantsCmd)
+}
+
+// newQuotaEngine returns the engine API the throttles are set through
+func newQuotaEngine() *engineclient.Client {
+	env, err := health.LoadEnvFile(".env")
+	if err != nil {
+		env, _ = health.LoadEnvFile("config/.env")
+	}
+	return engine.Client(env)
+}
+
+func runTenantsQuota(cmd *cobra.Command, args []string) error {
+	if quotaFormat != "text" && quotaFormat != "json" {
+		return fmt.Errorf("--format must be text or json")
+	}
+	cfg, err := settings.Load()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := runContext(0)
+	defer cancel()
+	api := newQuotaEngine()
+
+	if quotaRelease != "" {
+		return releaseThrottle(ctx, api, quotaRelease)
+	}
+
+	var tenants []settings.Tenant
+	for _, t := range cfg.Tenants {
+		if err := quota.Validate(t); err != nil {
+			return err
+		}
+		if t.Quota.Action == quota.ActionThrottle && t.Quota.Fallback != "" {
+			if _, err := engineclient.ParseFallback(t.Quota.Fallback); err != nil {
+				return fmt.Errorf("tenant %s: %w", t.Name, err)
+			}
+		}
+		if quota.Limited(t.Quota) {
+			tenants = append(tenants, t)
+		}
+	}
+	if len(tenants) == 0 {
+		return fmt.Errorf("no tenant in %s sets a quota (see 'agent tenants quota --help')", settings.Path())
+	}
+	if quotaNoNotify {
+		cfg.Notifications = nil
+	}
+	notifier, err := notify.New(cfg.Notifications)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := checkQuotas(cmd, ctx, cfg, tenants, notifier, api); err != nil {
+			if quotaEvery == 0 || ctx.Err() != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "❌ Quota check failed: %v\n", err)
+		}
+		if quotaEvery == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(quotaEvery):
+		}
+	}
+}
+
+// checkQuotas totals the usage of every tenant with a quota, alerts on
+// new levels and sets or lifts throttles
+func checkQuotas(cmd *cobra.Command, parent context.Context, cfg *settings.Settings, tenants []settings.Tenant, notifier *notify.Notifier, api *engineclient.Client) error {
+	ctx := parent
+	if quotaTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(parent, quotaTimeout)
+		defer cancel()
+	}
+	loc, _, err := resolveLocations()
+	if err != nil {
+		return err
+	}
+	now := time.Now().In(loc)
+
+	// One batch covers the longest period
+	since := now
+	names := make([]string, len(tenants))
+	for i, t := range tenants {
+		names[i] = t.Name
+		if start, _ := quota.PeriodStart(t.Quota.Period, now); start.Before(since) {
+			since = start
+		}
+	}
+	runner, err := newBatchRunner(cmd, ctx, cfg, quotaContainer, quotaNoCache, since.Format(time.RFC3339), "", troubleshoot.CallFilter{
+		Tenant: strings.Join(names, ","),
+	})
+	if err != nil {
+		return err
+	}
+	rows, err := runner.ExportCalls()
+	if err != nil {
+		return err
+	}
+	state, err := quota.LoadState()
+	if err != nil {
+		return err
+	}
+	// Usage is summed from the recorded calls, so calls whose logs rotated
+	// away still count
+	state.RecordCalls(rows, since)
+	usages := quota.Compute(tenants, state.CallRows(), now)
+	byName := make(map[string]settings.Tenant)
+	for _, t := range tenants {
+		byName[t.Name] = t
+	}
+	throttled := make(map[string]bool)
+	keep := make(map[string]bool)
+	for _, u := range usages {
+		t := byName[u.Tenant]
+		throttle := u.Level == quota.Exceeded && t.Quota.Action == quota.ActionThrottle && !quotaNoThrottle
+		if throttle {
+			keep[t.Name] = true
+			if err := throttleTenant(ctx, api, t, u, state); err != nil {
+				fmt.Fprintf(os.Stderr, "⚠️  Throttling %s failed: %v\n", t.Name, err)
+			} else {
+				throttled[t.Name] = true
+			}
+		}
+		if state.ShouldAlert(u) {
+			sendQuotaAlert(ctx, notifier, u, throttled[t.Name])
+		}
+	}
+	// Lift the throttles of tenants back within their quota, or no
+	// longer configured to be throttled
+	for _, name := range append([]string(nil), state.Throttled...) {
+		if keep[name] || quotaNoThrottle {
+			continue
+		}
+		if _, err := api.ClearThrottle(ctx, name); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Releasing %s failed: %v\n", name, err)
+			continue
+		}
+		state.SetThrottled(name, false)
+		fmt.Fprintf(os.Stderr, "✅ %s is within its quota again; throttle lifted\n", name)
+	}
+	if err := state.Save(); err != nil {
+		return err
+	}
+
+	if quotaFormat == "json" {
+		data, err := json.MarshalIndent(usages, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printQuotas(usages, throttled, loc)
+	return nil
+}
+
+// throttleTenant sets the tenant's throttle on the engine. It is sent
+// on every check while exceeded, so it survives engine restarts.
+func throttleTenant(ctx context.Context, api *engineclient.Client, t settings.Tenant, u quota.Usage, state *quota.State) error {
+	th := engineclient.Throttle{
+		Tenant:   t.Name,
+		DIDs:     t.DIDs,
+		Contexts: t.Contexts,
+		MaxCalls: t.Quota.MaxCalls,
+		Reason:   fmt.Sprintf("%s quota exceeded: %s", u.Period, strings.Join(u.Reasons, ", ")),
+	}
+	if t.Quota.Fallback != "" {
+		fb, err := engineclient.ParseFallback(t.Quota.Fallback)
+		if err != nil {
+			return err
+		}
+		th.Fallback = fb
+	}
+	if _, err := api.SetThrottle(ctx, th); err != nil {
+		return err
+	}
+	if !state.IsThrottled(t.Name) {
+		fmt.Fprintf(os.Stderr, "🚦 Throttled %s: %d concurrent call(s) allowed\n", t.Name, t.Quota.MaxCalls)
+		state.SetThrottled(t.Name, true)
+	}
+	return nil
+}
+
+// releaseThrottle lifts a tenant's throttle, or every throttle for "all"
+func releaseThrottle(ctx context.Context, api *engineclient.Client, tenant string) error {
+	name := tenant
+	if strings.EqualFold(tenant, "all") {
+		name = ""
+	}
+	if _, err := api.ClearThrottle(ctx, name); err != nil {
+		return err
+	}
+	state, err := quota.LoadState()
+	if err != nil {
+		return err
+	}
+	if name == "" {
+		state.Throttled = nil
+		fmt.Println("✅ Every tenant throttle lifted")
+	} else {
+		state.SetThrottled(name, false)
+		fmt.Printf("✅ Throttle of %s lifted\n", name)
+	}
+	if quotaEvery == 0 {
+		fmt.Println("   A running 'agent tenants quota --every' throttles it again while the quota is exceeded")
+	}
+	return state.Save()
+}
+
+func sendQuotaAlert(ctx context.Context, notifier *notify.Notifier, u quota.Usage, throttled bool) {
+	severity := notify.SeverityWarning
+	title := fmt.Sprintf("Tenant %s at %.0f%% of its %s quota", u.Tenant, u.Percent(), u.Period)
+	if u.Level == quota.Exceeded {
+		severity = notify.SeverityCritical
+		title = fmt.Sprintf("Tenant %s exceeded its %s quota", u.Tenant, u.Period)
+	}
+	text := strings.Join(u.Reasons, "\n")
+	if throttled {
+		text += fmt.Sprintf("\nThrottled to %d concurrent call(s)", u.Limits.MaxCalls)
+	}
+	ev := notify.Event{
+		Kind:     notify.EventQuotaExceeded,
+		Severity: severity,
+		Title:    title,
+		Text:     text,
+		Fields: map[string]string{
+			"Tenant": u.Tenant,
+			"Period": u.Period + " since " + u.Start.Format("2006-01-02"),
+			"Calls":  fmt.Sprintf("%d", u.Calls),
+		},
+		Data: u,
+	}
+	if _, err := notifier.Notify(ctx, ev); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Notification failed: %v\n", err)
+	}
+}
+
+func printQuotas(usages []quota.Usage, throttled map[string]bool, loc *time.Location) {
+	fmt.Printf("📊 Tenant quotas at %s\n\n", time.Now().In(loc).Format("2006-01-02 15:04"))
+	fmt.Printf("  %-16s %-7s %6s  %-17s %-21s %-17s %s\n", "TENANT", "PERIOD", "CALLS", "MINUTES", "TOKENS", "COST", "STATUS")
+	for _, u := range usages {
+		status := "✅ ok"
+		switch u.Level {
+		case quota.Warning:
+			status = "⚠️  warning"
+		case quota.Exceeded:
+			status = "❌ exceeded"
+		}
+		if throttled[u.Tenant] {
+			status += ", throttled"
+		}
+		fmt.Printf("  %-16s %-7s %6d  %-17s %-21s %-17s %s\n", u.Tenant, u.Period, u.Calls,
+			quotaValue(u.Minutes, u.Limits.Minutes, "%.0f"),
+			quotaValue(float64(u.Tokens), float64(u.Limits.Tokens), "%.0f"),
+			quotaValue(u.Cost, u.Limits.Cost, "%.2f"),
+			status)
+		for _, r := range u.Reasons {
+			fmt.Printf("      %s\n", r)
+		}
+		if u.Unpriced > 0 {
+			fmt.Printf("      %d call(s) left out of cost: a provider has no price under costs\n", u.Unpriced)
+		}
+	}
+}
+
+// quotaValue shows usage against its limit, or alone when unlimited
+func quotaValue(used, limit float64, format string) string {
+	if limit <= 0 {
+		return fmt.Sprintf(format, used)
+	}
+	return fmt.Sprintf(format+"/"+format, used, limit)
+}
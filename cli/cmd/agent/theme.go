@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/fatih/color"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
+	"github.com/spf13/cobra"
+)
+
+var themeCmd = &cobra.Command{
+	Use:   "theme [name]",
+	Short: "Preview the output themes",
+	Long: `Preview how status output looks in each theme, or in one.
+
+Themes:
+  default     green/yellow/red status colors
+  colorblind  Okabe-Ito palette: blue for good, orange for warnings and
+              vermillion for errors, told apart with every common kind of
+              color blindness; wallboard tiles also name their level
+  mono        no color at all; levels are told apart by symbols and words
+
+Set the theme in ~/.agent/config:
+
+  theme: colorblind
+
+or per run with --theme (or AGENT_THEME), which override the config.
+NO_COLOR turns color off in every theme.
+
+Examples:
+  agent theme
+  agent theme colorblind
+  agent troubleshoot --theme mono`,
+	Args:      cobra.MaximumNArgs(1),
+	ValidArgs: theme.Names(),
+	RunE:      runTheme,
+}
+
+// themeName is set by --theme
+var themeName string
+
+func init() {
+	rootCmd.AddCommand(themeCmd)
+}
+
+// applyTheme switches to the theme of --theme, else AGENT_THEME, else
+// ~/.agent/config. An unknown theme is warned about and the default kept.
+func applyTheme() {
+	name, source := themeName, "--theme"
+	if name == "" {
+		name, source = os.Getenv("AGENT_THEME"), "AGENT_THEME"
+	}
+	if name == "" {
+		if cfg, err := settings.Load(); err == nil {
+			name, source = cfg.Theme, "theme in "+settings.Path()
+		}
+	}
+	if err := theme.Apply(name); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %s: %v\n", source, err)
+	}
+}
+
+func runTheme(cmd *cobra.Command, args []string) error {
+	names := theme.Names()
+	if len(args) == 1 {
+		names = args
+	}
+	active := theme.Current()
+	noColor := color.NoColor
+	defer func() {
+		theme.Apply(active)
+		color.NoColor = noColor
+	}()
+
+	for _, name := range names {
+		color.NoColor = noColor
+		if err := theme.Apply(name); err != nil {
+			return err
+		}
+		marker := ""
+		if name == active {
+			marker = " (in use)"
+		}
+		fmt.Printf("%s%s\n", theme.Bold(theme.Info).Sprint(name), marker)
+		theme.Success.Println("  ✅ PASS  Asterisk ARI reachable")
+		theme.Warning.Println("  ⚠️  WARN  P95 turn latency 1450ms")
+		theme.Error.Println("  ❌ FAIL  ai_engine restarted 3 times")
+		theme.Accent.Println("  ➜ agent troubleshoot --last")
+		theme.Muted.Println("  ────────────────────────────")
+		if theme.Symbols() {
+			fmt.Println("  Wallboard: [OK] ANSWER RATE  [WARN] P95 LATENCY  [CRIT] FAILURES")
+		}
+		fmt.Println()
+	}
+	return nil
+}
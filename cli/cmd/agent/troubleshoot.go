@@ -1,6 +1,15 @@
 package main
 
 import (
+	"fmt"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/jira"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/monitoring"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/tracing"
 	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
 	"github.com/spf13/cobra"
 )
@@ -11,7 +20,26 @@ var (
 	troubleshootInteractive bool
 	troubleshootCollectOnly bool
 	troubleshootNoLLM       bool
+	troubleshootNoCache     bool
+	troubleshootNoSample    bool
+	troubleshootChart       string
+	troubleshootFrames      bool
 	troubleshootList        bool
+	troubleshootSince       string
+	troubleshootUntil       string
+	troubleshootFrom        string
+	troubleshootTo          string
+	troubleshootStatus      string
+	troubleshootTag         string
+	troubleshootTenant      string
+	troubleshootAll         bool
+	troubleshootResume      bool
+	troubleshootTimeout     time.Duration
+	troubleshootNoHooks     bool
+	troubleshootSource      string
+	troubleshootSourceURL   string
+	troubleshootOTLP        string
+	troubleshootNoNotify    bool
 )
 
 var troubleshootCmd = &cobra.Command{
@@ -25,6 +53,21 @@ Usage Examples:
   agent troubleshoot --call 1761424308.2043
   agent troubleshoot --last --symptom garbled
   agent troubleshoot --interactive
+  agent troubleshoot --list --since 7d
+  agent troubleshoot --list --from +49301234567
+  agent troubleshoot --last --to 7000
+  agent troubleshoot --list --status failed
+  agent troubleshoot --all --since 7d --status failed,abandoned
+  agent troubleshoot --list --tag escalated
+  agent troubleshoot --all --since 7d --tenant acme
+  agent troubleshoot --list --since "2025-10-26 09:00" --until "2025-10-26 12:00"
+  agent troubleshoot --all --since 7d --timeout 5m
+  agent troubleshoot --resume
+  agent troubleshoot --last --otlp-endpoint http://tempo:4318
+  agent troubleshoot --last --chart timeline.svg
+  agent troubleshoot history
+  agent troubleshoot show 20251026-091500 --format json
+  agent feedback 20251026-091500 --verdict wrong --actual-cause "..."
 
 Symptoms:
   no-audio        Complete silence
@@ -33,10 +76,241 @@ Symptoms:
   interruption    Self-interruption loop
   one-way         Only one direction works
 
+Call Status:
+  completed       Call ended normally
+  failed          Errors, crash, or failure hangup cause
+  abandoned       Caller left before the agent engaged
+  transferred     Handed off to the dialplan/queue
+
 Requirements:
   - Docker container 'ai_engine' must be running
-  - Reads logs from Docker (last 24 hours)
+  - Reads logs from Docker (--list defaults to the last 24 hours)
   - No file logging required (uses 'docker logs ai_engine')
+
+Time Windows:
+  --since/--until accept durations (90m, 2h, 7d) or timestamps
+  (2025-10-26, "2025-10-26 09:00", RFC3339). When analyzing a call
+  without --since, the window is derived from the call's indexed
+  start/end times, falling back to the start encoded in the call ID.
+  Timestamps without a zone are read in --tz (or 'timezone' in
+  ~/.agent/config, default: local time); container log times are UTC
+  unless 'log_timezone' is set. Displayed times always show the zone.
+
+Caching:
+  The logs collected for a call are cached in ~/.agent/cache/calls,
+  keyed by call ID, log source and window, together with the format
+  settings read from the engine. Re-running --call <id> (e.g. with
+  another --symptom or TROUBLESHOOT_LLM_MODEL) reuses them without
+  reading docker logs again; windows without a known end are reused
+  for 5 minutes. --no-cache collects again and refreshes the entry.
+    agent troubleshoot --call 1761424308.2043 --no-cache
+
+Charts:
+  --chart timeline.svg (or .png) draws the call's stages (setup,
+  playback segments, turns, teardown) on a time axis and one latency
+  bar per turn against the 1500ms/3000ms thresholds, for postmortems.
+  No external tools are needed. 'troubleshoot show <run> --chart' draws
+  a saved run.
+
+Log Sources:
+  Logs come from 'docker logs' unless a remote backend is configured,
+  which keeps working after local logs have rotated:
+    log_source:
+      type: loki                     # or elasticsearch
+      url: http://loki:3100
+      selector: '{container="ai_engine"}'
+      # elasticsearch: index (logs-*), message_field (message),
+      # time_field (@timestamp); auth: token or username/password
+  type: syslog reads lines received by 'agent serve --syslog-udp'.
+  type: journald reads the units of a bare-metal install ('agent
+  install systemd'): ai_engine maps to aava-engine.service unless
+  unit is set.
+  --source/--source-url override the configured type and URL.
+
+Tracing:
+  Each analyzed call (also in --all) can be exported as an OpenTelemetry
+  trace over OTLP/HTTP so it shows up in Jaeger/Tempo: a root "call"
+  span with child spans for setup, playback segments, turns and
+  teardown, reconstructed from log timestamps. The trace ID is derived
+  from the call ID, so re-exports land in the same trace.
+    tracing:
+      otlp_endpoint: http://tempo:4318
+      headers: {Authorization: "Bearer ..."}
+      service_name: asterisk-ai-voice-agent
+  --otlp-endpoint overrides the configured endpoint.
+
+  If the engine emits its own traces, single-call analysis fetches them
+  by call ID and shows a per-span latency breakdown; turn latency is
+  then measured from spans instead of estimated from log timestamps:
+    tracing:
+      query:
+        type: tempo                  # or jaeger (needs service)
+        url: http://tempo:3200
+        call_id_attribute: call_id
+        turn_span: turn              # span names covering one turn
+
+Security Events:
+  Single-call analysis reads Asterisk's security log (and the NOTICE
+  lines of full/messages) and fail2ban's log around the call. A trunk
+  address (from pjsip identify matches and contacts) banned during the
+  call is reported as critical: "calls failing" is then the ITSP being
+  locked out. Registration attacks in the window are reported too.
+    security:
+      asterisk_logs: [/var/log/asterisk/security, /var/log/asterisk/full]
+      fail2ban_log: /var/log/fail2ban.log
+      asterisk_dir: /etc/asterisk
+
+Leaked Credentials:
+  API keys (OpenAI, ElevenLabs, Google, AWS), JWTs, authorization
+  headers, ARI and SIP passwords printed in the call's logs are a
+  critical finding naming the component (logger) that wrote them. They
+  are redacted from bundles, tickets, saved runs, finding evidence and
+  what is sent to the LLM.
+
+Metrics Push:
+  Every analyzed call (single or --all) can be pushed to StatsD and/or
+  InfluxDB: turn latency (avg/p95/max), quality score and counts of
+  errors, warnings, audio issues, critical findings and failed calls,
+  tagged with call status and provider.
+    monitoring:
+      statsd:
+        address: statsd:8125
+        prefix: aava                 # aava.call.turn_latency_p95_ms
+        dogstatsd: true              # append |#status:...,provider:...
+      influxdb:
+        url: http://influx:8086/api/v2/write?org=ops&bucket=voice
+        token: ...                   # v1: /write?db=voice + username/password
+        measurement: aava_call
+
+Notifications:
+  Analyzed calls are sent to chat channels whose min_severity they
+  meet (default warning). Failed calls and critical findings are
+  critical; --no-notify skips them for one run.
+    notifications:
+      - type: telegram
+        bot_token: "123456:ABC..."
+        chat_id: "-1001234567890"
+        min_severity: critical
+      - type: teams
+        webhook_url: https://example.webhook.office.com/...
+  Check delivery with 'agent notify test'.
+
+  A generic webhook channel POSTs the event JSON (event, severity,
+  title, text, call_id, fields, time, data = sanitized report) for n8n,
+  Zapier or custom automation:
+      - type: webhook
+        url: https://hooks.example.com/agent
+        secret: s3cr3t               # HMAC signing key
+        events: [failure_detected, slo_breached]
+  Events: call_analyzed, failure_detected, slo_breached (see slo below)
+  doctor_check_failed (from 'agent doctor'), synthetic_failed (from
+  'agent monitor synthetic'), security_alert (from 'agent monitor
+  security'), config_invalid (from 'agent config watch') and
+  quota_exceeded (from 'agent tenants quota'). Requests carry
+  X-Agent-Event, X-Agent-Timestamp and X-Agent-Signature:
+  sha256=hex(HMAC-SHA256(secret, "<timestamp>.<body>")).
+    slo:
+      turn_latency_p95_ms: 1500
+      min_quality_score: 70
+
+Tenants:
+  A deployment shared by several customers assigns each call to a
+  tenant: the one the engine logged (a tenant= field), else the first
+  whose dids match the dialed number (digits only, a trailing * matches
+  a range), else the first whose contexts list the call's AI_CONTEXT or
+  dialplan context. The tenant is kept in the call index, shown by --list, sent
+  with notifications and exported; --tenant selects calls (none for
+  calls of no tenant). A tenant's slo replaces the objectives it sets.
+    tenants:
+      - name: acme
+        dids: ["+4930123450*"]
+        contexts: [acme-support]
+        slo:
+          min_quality_score: 80
+      - name: globex
+        contexts: [from-globex]
+
+Partial Results:
+  An analyzer that panics, or a step that fails or times out (plugins,
+  engine traces, the LLM call), is listed under INCOMPLETE with the
+  reason while the others' findings are still shown. When --timeout
+  runs out during the analysis, the results so far are shown and the
+  command exits non-zero.
+
+Business Hours:
+  With business_hours configured (see 'agent report weekly --help'),
+  --list marks calls after hours, and SLO breaches of after-hours calls
+  are sent as info rather than warning notifications.
+
+Jira Tickets:
+  Failed calls (critical findings or failed status) are grouped by a
+  fingerprint of their critical findings. A new fingerprint opens an
+  issue labeled aava-failure-<fingerprint> with the sanitized analysis
+  and a log bundle attached; recurrences comment on the open issue.
+  Each call is recorded once, so re-running --all does not repeat.
+    jira:
+      url: https://example.atlassian.net
+      project: PBX
+      issue_type: Bug
+      email: ops@example.com         # omit for Data Center PATs
+      token: ...
+  --no-notify also skips Jira for one run.
+
+Analyzer Plugins:
+  Executables in ~/.agent/analyzers (or $AGENT_PLUGIN_DIR) run as extra
+  analyzers. Each receives {"call_id": ..., "events": [{"line", "event",
+  "level", "fields"}]} on stdin and prints {"findings": [{"severity":
+  "critical|warning|info", "message", "evidence"}]} on stdout.
+
+Known-Issue Rules:
+  Log signatures of known failures in ~/.agent/rules are matched too,
+  with their fix shown under the finding. 'agent rules update' fetches
+  the curated set; local.yaml adds or overrides rules.
+
+Hooks:
+  Shell commands in ~/.agent/config run around single-call analysis:
+    hooks:
+      pre_troubleshoot:
+        - agent-debug-on.sh
+      post_troubleshoot:
+        - curl -s -X POST -d @- https://ops.example.com/rca
+  Post hooks receive the analysis report JSON on stdin. AGENT_HOOK and
+  AGENT_CALL_ID are set; failures are reported without aborting.
+
+LLM Analysis:
+  TROUBLESHOOT_LLM_PROVIDER picks the model: openai or anthropic (the
+  default when OPENAI_API_KEY or ANTHROPIC_API_KEY is set), or ollama or
+  llamacpp to keep call logs on premises. TROUBLESHOOT_LLM_BASE_URL
+  (default http://localhost:11434 for Ollama, http://localhost:8080 for
+  llama-server), TROUBLESHOOT_LLM_MODEL (Ollama default llama3.2) and
+  TROUBLESHOOT_LLM_CONTEXT (tokens, default 4096 for local models) tune
+  it; TROUBLESHOOT_LLM_API_KEY is sent to local servers that need one.
+  Evidence too large for the context window is summarized in chunks
+  first, then diagnosed from the summaries.
+    TROUBLESHOOT_LLM_PROVIDER=ollama TROUBLESHOOT_LLM_MODEL=qwen2.5:3b \
+      TROUBLESHOOT_LLM_CONTEXT=2048 agent troubleshoot --last
+
+Timeouts:
+  --timeout bounds the whole run (log collection, docker exec, LLM
+  calls). Ctrl-C stops cleanly; --all prints the calls finished so far.
+
+Sampling:
+  A call with more than 50,000 log lines (debug logging left on) is
+  sampled so analysis stays fast and bundles stay shareable: errors,
+  warnings, info lines, state transitions, frame stats and the lines
+  before each warning or error are all kept; debug messages repeated
+  more than 500 times keep their first 50 lines and then 1 in N. The
+  run says so, with the kept/total count of each sampled message, and
+  the report JSON carries "sampling". --no-sample reads every line.
+
+Resuming Batches:
+  --all fixes its call list when it starts and stores each call's result
+  in ~/.agent/batch as soon as it is analyzed. A run stopped by Ctrl-C,
+  --timeout or a crash continues where it left off, with the same calls
+  and without notifying or filing tickets for finished calls twice:
+    agent troubleshoot --all --since 7d --timeout 30m
+    agent troubleshoot --resume
+  A new --all run replaces the checkpoint.
   
 Features:
   - Automatic log collection from Docker
@@ -45,21 +319,165 @@ Features:
   - Actionable recommendations`,
 	RunE: func(cmd *cobra.Command, args []string) error {
 		verbose, _ := cmd.Flags().GetBool("verbose")
+		if err := troubleshoot.ValidateStatuses(troubleshootStatus); err != nil {
+			return fmt.Errorf("--status: %w", err)
+		}
 		
 		// If --last flag is used, set callID to "last"
 		if cmd.Flags().Changed("last") || troubleshootCallID == "" {
 			troubleshootCallID = "last"
 		}
 		
-		runner := troubleshoot.NewRunner(
-			troubleshootCallID,
-			troubleshootSymptom,
-			troubleshootInteractive,
-			troubleshootCollectOnly,
-			troubleshootNoLLM,
-			troubleshootList,
-			verbose,
-		)
+		loc, logLoc, err := resolveLocations()
+		if err != nil {
+			return err
+		}
+		
+		cfg, err := settings.Load()
+		if err != nil {
+			return err
+		}
+		if troubleshootNoHooks {
+			cfg.Hooks = settings.Hooks{}
+		}
+		if troubleshootSource != "" {
+			cfg.LogSource.Type = troubleshootSource
+		}
+		if troubleshootSourceURL != "" {
+			cfg.LogSource.URL = troubleshootSourceURL
+		}
+		source, err := troubleshoot.NewLogSource(cfg.LogSource, logLoc)
+		if err != nil {
+			return err
+		}
+		indexAge, err := cfg.Retention.IndexMaxAge()
+		if err != nil {
+			return err
+		}
+		hours, err := troubleshoot.NewBusinessHours(cfg.BusinessHours, loc)
+		if err != nil {
+			return err
+		}
+		if troubleshootOTLP != "" {
+			cfg.Tracing.OTLPEndpoint = troubleshootOTLP
+		}
+		var tracer *tracing.Exporter
+		if cfg.Tracing.OTLPEndpoint != "" {
+			tracer = tracing.NewExporter(cfg.Tracing.OTLPEndpoint, cfg.Tracing.Headers, cfg.Tracing.ServiceName)
+		}
+		var traces *troubleshoot.TraceLookup
+		if q := cfg.Tracing.Query; q.URL != "" {
+			querier, err := tracing.NewQuerier(q.Type, q.URL, q.Service, cfg.Tracing.Headers)
+			if err != nil {
+				return err
+			}
+			traces = &troubleshoot.TraceLookup{
+				Querier:         querier,
+				CallIDAttribute: q.CallIDAttribute,
+				TurnSpan:        q.TurnSpan,
+			}
+		}
+		
+		sinks, err := monitoring.NewSinks(monitoring.Config{
+			StatsD: monitoring.StatsDConfig{
+				Address:   cfg.Monitoring.StatsD.Address,
+				Prefix:    cfg.Monitoring.StatsD.Prefix,
+				DogStatsD: cfg.Monitoring.StatsD.DogStatsD,
+			},
+			InfluxDB: monitoring.InfluxConfig{
+				URL:         cfg.Monitoring.InfluxDB.URL,
+				Token:       cfg.Monitoring.InfluxDB.Token,
+				Username:    cfg.Monitoring.InfluxDB.Username,
+				Password:    cfg.Monitoring.InfluxDB.Password,
+				Measurement: cfg.Monitoring.InfluxDB.Measurement,
+			},
+		})
+		if err != nil {
+			return err
+		}
+		
+		if troubleshootNoNotify {
+			cfg.Notifications = nil
+			cfg.Jira = settings.Jira{}
+		}
+		notifier, err := notify.New(cfg.Notifications)
+		if err != nil {
+			return err
+		}
+		var tickets *troubleshoot.Tickets
+		if cfg.Jira.URL != "" {
+			if cfg.Jira.Project == "" {
+				return fmt.Errorf("jira: project is required in %s", settings.Path())
+			}
+			client, err := jira.New(cfg.Jira.URL, cfg.Jira.Email, cfg.Jira.Token)
+			if err != nil {
+				return err
+			}
+			tickets = &troubleshoot.Tickets{
+				Client:    client,
+				Project:   cfg.Jira.Project,
+				IssueType: cfg.Jira.IssueType,
+				Labels:    cfg.Jira.Labels,
+			}
+		}
+		
+		ctx, cancel := runContext(troubleshootTimeout)
+		defer cancel()
+		
+		// Scaled installs (agent scale) run ai_engine_N next to ai_engine;
+		// read them all
+		var instances []string
+		if found, err := engine.Instances(ctx); err == nil && len(found) > 1 {
+			for _, inst := range found {
+				instances = append(instances, inst.Container)
+			}
+		}
+		
+		runner := troubleshoot.NewRunner(troubleshoot.Options{
+			Context:        ctx,
+			Instances:      instances,
+			LogSource:      source,
+			IndexRetention: indexAge,
+			CallID:         troubleshootCallID,
+			Symptom:        troubleshootSymptom,
+			Interactive:    troubleshootInteractive,
+			CollectOnly:    troubleshootCollectOnly,
+			NoLLM:          troubleshootNoLLM,
+			NoCache:        troubleshootNoCache,
+			NoSample:       troubleshootNoSample,
+			Chart:          troubleshootChart,
+			Frames:         troubleshootFrames,
+			List:           troubleshootList,
+			All:            troubleshootAll || troubleshootResume,
+			Resume:         troubleshootResume,
+			Verbose:        verbose,
+			Since:          troubleshootSince,
+			Until:          troubleshootUntil,
+			Filter: troubleshoot.CallFilter{
+				From:   troubleshootFrom,
+				To:     troubleshootTo,
+				Status: troubleshootStatus,
+				Tag:    troubleshootTag,
+				Tenant: troubleshootTenant,
+			},
+			Location:    loc,
+			LogLocation: logLoc,
+			PreHooks:    cfg.Hooks.PreTroubleshoot,
+			PostHooks:   cfg.Hooks.PostTroubleshoot,
+			Tracer:      tracer,
+			Traces:      traces,
+			Security: troubleshoot.SecuritySources{
+				AsteriskLogs: cfg.Security.AsteriskLogs,
+				Fail2banLog:  cfg.Security.Fail2banLog,
+				AsteriskDir:  cfg.Security.AsteriskDir,
+			},
+			MetricSinks:   sinks,
+			Notifier:      notifier,
+			Tickets:       tickets,
+			SLO:           cfg.SLO,
+			Tenants:       cfg.Tenants,
+			BusinessHours: hours,
+		})
 		return runner.Run()
 	},
 }
@@ -72,6 +490,25 @@ func init() {
 	troubleshootCmd.Flags().BoolVarP(&troubleshootInteractive, "interactive", "i", false, "interactive mode")
 	troubleshootCmd.Flags().BoolVar(&troubleshootCollectOnly, "collect-only", false, "only collect logs, no analysis")
 	troubleshootCmd.Flags().BoolVar(&troubleshootNoLLM, "no-llm", false, "skip LLM analysis")
+	troubleshootCmd.Flags().BoolVar(&troubleshootFrames, "frames", false, "draw the engine's per-second audio frame counters (logged at debug level) as sparklines")
+	troubleshootCmd.Flags().StringVar(&troubleshootChart, "chart", "", "write the call's stage timeline and turn latency chart to this .svg or .png file")
+	troubleshootCmd.Flags().BoolVar(&troubleshootNoCache, "no-cache", false, "collect the call's logs again instead of reusing cached data")
+	troubleshootCmd.Flags().BoolVar(&troubleshootNoSample, "no-sample", false, "analyze every log line of very chatty calls instead of sampling repetitive debug lines")
+	troubleshootCmd.Flags().StringVar(&troubleshootSince, "since", "", "start of log window: duration (2h, 7d) or timestamp")
+	troubleshootCmd.Flags().StringVar(&troubleshootUntil, "until", "", "end of log window: duration (30m) or timestamp")
+	troubleshootCmd.Flags().StringVar(&troubleshootFrom, "from", "", "only calls from this caller number (digits match)")
+	troubleshootCmd.Flags().StringVar(&troubleshootTo, "to", "", "only calls to this dialed number/extension")
+	troubleshootCmd.Flags().StringVar(&troubleshootStatus, "status", "", "only calls with status: completed|failed|abandoned|transferred (comma-separated)")
+	troubleshootCmd.Flags().StringVar(&troubleshootTag, "tag", "", "only calls with one of these tags (comma-separated, see 'agent calls tag')")
+	troubleshootCmd.Flags().StringVar(&troubleshootTenant, "tenant", "", "only calls of these tenants (comma-separated, none for calls of no tenant)")
+	troubleshootCmd.Flags().BoolVar(&troubleshootAll, "all", false, "analyze every call in the window (batch mode, no LLM)")
+	troubleshootCmd.Flags().BoolVar(&troubleshootResume, "resume", false, "continue the last interrupted --all run with the calls it had left")
+	troubleshootCmd.Flags().StringVar(&troubleshootSource, "source", "", "log source: docker|loki|elasticsearch|syslog|journald (default from ~/.agent/config)")
+	troubleshootCmd.Flags().StringVar(&troubleshootSourceURL, "source-url", "", "Loki/Elasticsearch base URL")
+	troubleshootCmd.Flags().StringVar(&troubleshootOTLP, "otlp-endpoint", "", "export analyzed calls as traces to this OTLP/HTTP collector")
+	troubleshootCmd.Flags().BoolVar(&troubleshootNoNotify, "no-notify", false, "do not send notifications or Jira updates for this run")
+	troubleshootCmd.Flags().BoolVar(&troubleshootNoHooks, "no-hooks", false, "skip pre/post hooks from ~/.agent/config")
+	troubleshootCmd.Flags().DurationVar(&troubleshootTimeout, "timeout", 0, "abort the run after this long (e.g. 2m, 0 = no limit)")
 	
 	rootCmd.AddCommand(troubleshootCmd)
 }
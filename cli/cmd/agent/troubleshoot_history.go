@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/spf13/cobra"
+)
+
+var (
+	historyLimit int
+	showFormat   string
+	showChart    string
+)
+
+var troubleshootHistoryCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List saved troubleshoot runs",
+	Long: `List troubleshoot runs saved under ~/.agent/runs.
+
+Every single-call analysis stores its inputs, the collected logs and the
+report, so it can be reviewed after the container logs have rotated.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		loc, logLoc, err := resolveLocations()
+		if err != nil {
+			return err
+		}
+		runner := troubleshoot.NewRunner(troubleshoot.Options{Location: loc, LogLocation: logLoc})
+		return runner.History(historyLimit)
+	},
+}
+
+var troubleshootShowCmd = &cobra.Command{
+	Use:   "show <run_id>",
+	Short: "Show a saved troubleshoot run",
+	Long: `Show a saved troubleshoot run as it was reported: its findings,
+metrics, quality score and AI diagnosis come from the stored report, not
+from today's analyzers. Batch runs (--all) save one run per call.
+
+Nothing is collected from the engine; --chart draws the timeline from
+the run's stored logs.
+
+Examples:
+  agent troubleshoot show 20251026-091500
+  agent troubleshoot show 20251026-091500 --format json
+  agent troubleshoot show 20251026-091500 --chart timeline.png`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		loc, logLoc, err := resolveLocations()
+		if err != nil {
+			return err
+		}
+		runner := troubleshoot.NewRunner(troubleshoot.Options{Location: loc, LogLocation: logLoc, Chart: showChart})
+		return runner.ShowRun(args[0], showFormat)
+	},
+}
+
+func init() {
+	troubleshootHistoryCmd.Flags().IntVarP(&historyLimit, "limit", "n", 20, "number of runs to list (0 = all)")
+	troubleshootShowCmd.Flags().StringVar(&showFormat, "format", "text", "output format: text|json")
+	troubleshootShowCmd.Flags().StringVar(&showChart, "chart", "", "also write the call's timeline chart to this .svg or .png file")
+
+	troubleshootCmd.AddCommand(troubleshootHistoryCmd)
+	troubleshootCmd.AddCommand(troubleshootShowCmd)
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ssml"
+	"github.com/spf13/cobra"
+)
+
+var ttsCmd = &cobra.Command{
+	Use:   "tts",
+	Short: "Text-to-speech settings",
+}
+
+var ttsSSMLCmd = &cobra.Command{
+	Use:   "ssml",
+	Short: "Check SSML against the TTS provider's supported subset",
+}
+
+var ttsSSMLCheckCmd = &cobra.Command{
+	Use:   "check <file.ssml>",
+	Short: "Validate an SSML file for the TTS provider and preview it",
+	Long: `Check an SSML document (a prompt, greeting or reply the LLM is told
+to produce) against the subset the configured TTS provider supports,
+and preview how it sounds.
+
+The provider is the active pipeline's tts in config/ai-agent.yaml, else
+the default provider; --provider picks a provider of the config or a
+family (google, elevenlabs, deepgram, openai, local). Reported:
+
+  errors    markup that is not well-formed or misses required attributes
+  warnings  tags, attributes and say-as values the provider ignores or
+            reads aloud, and breaks longer than it honors
+
+The engine sends replies to every TTS provider as plain text, so the
+preview does the same: it synthesizes the document through the
+provider's API as 8 kHz µ-law, the way callers hear it, and writes a
+WAV file (the provider's API key is read from the environment or .env).
+The command exits non-zero on errors, and on warnings with --strict.
+
+Examples:
+  agent tts ssml check prompts/greeting.ssml
+  agent tts ssml check reply.ssml --provider elevenlabs --play
+  agent tts ssml check reply.ssml --no-preview --strict`,
+	Args: cobra.ExactArgs(1),
+	RunE: runTTSSSMLCheck,
+}
+
+var (
+	ssmlDir       string
+	ssmlProvider  string
+	ssmlOutput    string
+	ssmlPlay      bool
+	ssmlNoPreview bool
+	ssmlStrict    bool
+	ssmlFormat    string
+)
+
+func init() {
+	f := ttsSSMLCheckCmd.Flags()
+	f.StringVar(&ssmlDir, "dir", ".", "project directory (where config/ai-agent.yaml and .env live)")
+	f.StringVar(&ssmlProvider, "provider", "", "provider of the config or family to check against (default: the active pipeline's tts)")
+	f.StringVarP(&ssmlOutput, "output", "o", "", "preview WAV file (default: <file>.preview.wav)")
+	f.BoolVar(&ssmlPlay, "play", false, "play the preview (ffplay, aplay or sox)")
+	f.BoolVar(&ssmlNoPreview, "no-preview", false, "only validate; do not call the provider")
+	f.BoolVar(&ssmlStrict, "strict", false, "exit non-zero on warnings too")
+	f.StringVar(&ssmlFormat, "format", "text", "output format: text|json")
+
+	ttsSSMLCmd.AddCommand(ttsSSMLCheckCmd)
+	ttsCmd.AddCommand(ttsSSMLCmd)
+	rootCmd.AddCommand(ttsCmd)
+}
+
+// ssmlPreview is the outcome of synthesizing the document
+type ssmlPreview struct {
+	File     string  `json:"file,omitempty"`
+	Seconds  float64 `json:"seconds,omitempty"`
+	Skipped  string  `json:"skipped,omitempty"`
+	PlayedBy string  `json:"played_by,omitempty"`
+}
+
+func runTTSSSMLCheck(cmd *cobra.Command, args []string) error {
+	if ssmlFormat != "text" && ssmlFormat != "json" {
+		return fmt.Errorf("unknown --format %q (use text or json)", ssmlFormat)
+	}
+	doc, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	target, err := ssml.Resolve(ssmlDir, ssmlProvider)
+	if err != nil {
+		return err
+	}
+	profile := ssml.Profiles[target.Family]
+	result := ssml.Check(doc, profile, target.Model)
+
+	preview := ssmlPreview{Skipped: "--no-preview"}
+	if !ssmlNoPreview {
+		preview = previewSSML(target, string(doc), args[0])
+	}
+
+	if ssmlFormat == "json" {
+		out, err := json.MarshalIndent(struct {
+			File      string       `json:"file"`
+			Target    ssml.Target  `json:"target"`
+			Supported []string     `json:"supported"`
+			Errors    int          `json:"errors"`
+			Warnings  int          `json:"warnings"`
+			Result    *ssml.Result `json:"result"`
+			Preview   ssmlPreview  `json:"preview"`
+		}{args[0], target, profile.Supported(), result.Errors(), result.Warnings(), result, preview}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(out))
+	} else {
+		printSSMLCheck(args[0], target, profile, result, preview)
+	}
+
+	if n := result.Errors(); n > 0 {
+		return fmt.Errorf("%d SSML error(s) in %s", n, args[0])
+	}
+	if n := result.Warnings(); ssmlStrict && n > 0 {
+		return fmt.Errorf("%d SSML warning(s) in %s (--strict)", n, args[0])
+	}
+	return nil
+}
+
+// previewSSML synthesizes the document and writes it as WAV, playing it
+// with --play
+func previewSSML(target ssml.Target, doc, file string) ssmlPreview {
+	env, _ := health.LoadEnvFile(filepath.Join(ssmlDir, ".env"))
+	key := ""
+	if name := target.KeyEnv(); name != "" {
+		key = health.GetEnv(name, env)
+	}
+	if key == "" && target.APIKey != "" && !strings.HasPrefix(target.APIKey, "${") {
+		key = target.APIKey
+	}
+
+	ctx, cancel := runContext(30 * time.Second)
+	defer cancel()
+	audio, err := ssml.Preview(ctx, target, doc, key)
+	if err != nil {
+		return ssmlPreview{Skipped: err.Error()}
+	}
+
+	out := ssmlOutput
+	if out == "" {
+		out = strings.TrimSuffix(file, filepath.Ext(file)) + ".preview.wav"
+	}
+	w, err := newWavWriter(out)
+	if err != nil {
+		return ssmlPreview{Skipped: err.Error()}
+	}
+	if _, err := w.Write(audio); err != nil {
+		w.Close()
+		return ssmlPreview{Skipped: err.Error()}
+	}
+	preview := ssmlPreview{File: out, Seconds: w.Duration().Seconds()}
+	if err := w.Close(); err != nil {
+		return ssmlPreview{Skipped: err.Error()}
+	}
+	if ssmlPlay {
+		preview.PlayedBy, err = playUlaw(audio)
+		if err != nil {
+			preview.Skipped = err.Error()
+		}
+	}
+	return preview
+}
+
+// playUlaw plays 8 kHz µ-law with the first player found
+func playUlaw(audio []byte) (string, error) {
+	for _, p := range players {
+		if _, err := exec.LookPath(p[0]); err != nil {
+			continue
+		}
+		play := exec.Command(p[0], p[1:]...)
+		play.Stdin = bytes.NewReader(audio)
+		play.Stderr = os.Stderr
+		if err := selflog.Run(play); err != nil {
+			return "", fmt.Errorf("%s: %v", p[0], err)
+		}
+		return p[0], nil
+	}
+	return "", fmt.Errorf("no audio player found: install ffmpeg (ffplay), alsa-utils (aplay) or sox")
+}
+
+func printSSMLCheck(file string, target ssml.Target, profile ssml.Profile, result *ssml.Result, preview ssmlPreview) {
+	fmt.Printf("🔊 SSML check: %s\n", file)
+	fmt.Printf("   TTS: %s (%s", target.Name, target.Family)
+	if target.Model != "" {
+		fmt.Printf(", %s", target.Model)
+	}
+	fmt.Println(")")
+	if supported := profile.Supported(); len(supported) > 0 {
+		fmt.Printf("   Supported tags: %s\n", strings.Join(supported, ", "))
+	} else {
+		fmt.Println("   Supported tags: none (plain text only)")
+	}
+	fmt.Println()
+
+	if len(result.Issues) == 0 {
+		fmt.Println("✅ No issues")
+	}
+	for _, i := range result.Issues {
+		icon := "ℹ️ "
+		switch i.Severity {
+		case ssml.SeverityError:
+			icon = "❌"
+		case ssml.SeverityWarning:
+			icon = "⚠️ "
+		}
+		where := ""
+		if i.Line > 0 {
+			where = fmt.Sprintf("line %d: ", i.Line)
+		}
+		fmt.Printf("  %s %s%s\n", icon, where, i.Message)
+	}
+	if result.Text != "" {
+		fmt.Printf("\nText: %q\n", result.Text)
+	}
+	fmt.Println()
+
+	switch {
+	case preview.File != "":
+		fmt.Printf("🎧 Preview: %s (%.1fs)", preview.File, preview.Seconds)
+		if preview.PlayedBy != "" {
+			fmt.Printf(", played with %s", preview.PlayedBy)
+		}
+		fmt.Println()
+		if preview.Skipped != "" {
+			fmt.Printf("⚠️  Not played: %s\n", preview.Skipped)
+		}
+	case preview.Skipped != "":
+		fmt.Printf("⏭️  Preview skipped: %s\n", preview.Skipped)
+	}
+	fmt.Printf("%d error(s), %d warning(s)\n", result.Errors(), result.Warnings())
+}
@@ -1,12 +1,16 @@
 module github.com/hkjarral/asterisk-ai-voice-agent/cli
 
-go 1.13
+// github.com/klauspost/compress v1.18 (zstd for the log store) requires
+// Go 1.22; google.golang.org/grpc v1.67 requires 1.21
+go 1.22
 
 require (
-	github.com/spf13/cobra v1.8.0
 	github.com/fatih/color v1.16.0
-	github.com/docker/docker v24.0.7+incompatible
-	github.com/docker/go-connections v0.4.0
+	github.com/klauspost/compress v1.18.0
+	github.com/spf13/cobra v1.8.0
+	github.com/spf13/pflag v1.0.5
+	google.golang.org/grpc v1.67.3
+	google.golang.org/protobuf v1.34.2
 	gopkg.in/yaml.v3 v3.0.1
 )
 
@@ -14,6 +18,8 @@ require (
 	github.com/inconshreveable/mousetrap v1.1.0 // indirect
 	github.com/mattn/go-colorable v0.1.13 // indirect
 	github.com/mattn/go-isatty v0.0.20 // indirect
-	github.com/spf13/pflag v1.0.5 // indirect
-	golang.org/x/sys v0.14.0 // indirect
+	golang.org/x/net v0.28.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+	golang.org/x/text v0.17.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20240814211410-ddb44dafa142 // indirect
 )
@@ -0,0 +1,181 @@
+package ami
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+)
+
+// DefaultPort is the Asterisk Manager Interface port
+const DefaultPort = "5038"
+
+// Client is a minimal Asterisk Manager Interface client: one login per
+// Command call, actions sent sequentially
+type Client struct {
+	address  string
+	username string
+	secret   string
+	timeout  time.Duration
+}
+
+// New creates a client for the manager at host:port
+func New(host, port, username, secret string) *Client {
+	if port == "" {
+		port = DefaultPort
+	}
+	return &Client{
+		address:  net.JoinHostPort(host, port),
+		username: username,
+		secret:   secret,
+		timeout:  10 * time.Second,
+	}
+}
+
+// FromEnv creates a client from ASTERISK_HOST, ASTERISK_AMI_USERNAME,
+// ASTERISK_AMI_PASSWORD and ASTERISK_AMI_PORT (environment first, then
+// env)
+func FromEnv(env map[string]string) (*Client, error) {
+	lookup := func(key string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return env[key]
+	}
+	host := lookup("ASTERISK_HOST")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	username := lookup("ASTERISK_AMI_USERNAME")
+	secret := lookup("ASTERISK_AMI_PASSWORD")
+	if username == "" || secret == "" {
+		return nil, fmt.Errorf("ASTERISK_AMI_USERNAME and ASTERISK_AMI_PASSWORD must be set (environment or .env)")
+	}
+	return New(host, lookup("ASTERISK_AMI_PORT"), username, secret), nil
+}
+
+// Address returns host:port of the manager
+func (c *Client) Address() string {
+	return c.address
+}
+
+// Command runs a CLI command (e.g. "dialplan reload") and returns its output
+func (c *Client) Command(ctx context.Context, command string) (string, error) {
+	dctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	conn, err := remote.Dial(dctx, "tcp", c.address)
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	r := bufio.NewReader(conn)
+	// Banner: "Asterisk Call Manager/x.y.z"
+	banner, err := r.ReadString('\n')
+	if err != nil {
+		return "", fmt.Errorf("no AMI banner from %s: %w", c.address, err)
+	}
+	if !strings.HasPrefix(banner, "Asterisk Call Manager") {
+		return "", fmt.Errorf("%s is not an Asterisk manager (%q)", c.address, strings.TrimSpace(banner))
+	}
+
+	login, err := c.action(conn, r, "Login", map[string]string{
+		"Username": c.username,
+		"Secret":   c.secret,
+		"Events":   "off",
+	})
+	if err != nil {
+		return "", err
+	}
+	if !strings.EqualFold(login.fields["Response"], "Success") {
+		return "", fmt.Errorf("AMI login failed: %s", login.fields["Message"])
+	}
+	defer c.action(conn, r, "Logoff", nil)
+
+	resp, err := c.action(conn, r, "Command", map[string]string{"Command": command})
+	if err != nil {
+		return "", err
+	}
+	if strings.EqualFold(resp.fields["Response"], "Error") {
+		return "", fmt.Errorf("AMI command %q failed: %s", command, resp.fields["Message"])
+	}
+	return strings.Join(resp.output, "\n"), nil
+}
+
+// response is one AMI response: its key/value fields and, for Command,
+// the output lines
+type response struct {
+	fields map[string]string
+	output []string
+}
+
+func (c *Client) action(conn net.Conn, r *bufio.Reader, action string, fields map[string]string) (*response, error) {
+	var sb strings.Builder
+	sb.WriteString("Action: " + action + "\r\n")
+	id := fmt.Sprintf("aava-%d", time.Now().UnixNano())
+	sb.WriteString("ActionID: " + id + "\r\n")
+	for k, v := range fields {
+		sb.WriteString(k + ": " + v + "\r\n")
+	}
+	sb.WriteString("\r\n")
+	if _, err := conn.Write([]byte(sb.String())); err != nil {
+		return nil, err
+	}
+
+	// Skip events and other responses until ours
+	for {
+		resp, err := readResponse(r)
+		if err != nil {
+			return nil, err
+		}
+		if resp.fields["ActionID"] == id {
+			return resp, nil
+		}
+	}
+}
+
+// readResponse reads one blank-line terminated message. Command output
+// arrives as "Output: ..." lines (Asterisk 14+) or, on older versions,
+// as bare lines ending with "--END COMMAND--".
+func readResponse(r *bufio.Reader) (*response, error) {
+	resp := &response{fields: make(map[string]string)}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, err
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if line == "" {
+			if len(resp.fields) == 0 && len(resp.output) == 0 {
+				continue
+			}
+			return resp, nil
+		}
+		if line == "--END COMMAND--" {
+			continue
+		}
+		i := strings.Index(line, ": ")
+		if i <= 0 {
+			resp.output = append(resp.output, line)
+			continue
+		}
+		key, value := line[:i], line[i+2:]
+		if key == "Output" {
+			resp.output = append(resp.output, value)
+			continue
+		}
+		if _, seen := resp.fields[key]; !seen {
+			resp.fields[key] = value
+		}
+	}
+}
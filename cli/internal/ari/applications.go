@@ -0,0 +1,163 @@
+package ari
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Application is a Stasis application registered over the ARI websocket
+type Application struct {
+	Name          string        `json:"name"`
+	ChannelIDs    []string      `json:"channel_ids"`
+	BridgeIDs     []string      `json:"bridge_ids"`
+	EndpointIDs   []string      `json:"endpoint_ids"`
+	DeviceNames   []string      `json:"device_names"`
+	EventsAllowed []interface{} `json:"events_allowed"`
+}
+
+// Applications lists the registered Stasis applications
+func (c *Client) Applications(ctx context.Context) ([]Application, error) {
+	var apps []Application
+	if err := c.get(ctx, "/applications", &apps); err != nil {
+		return nil, err
+	}
+	return apps, nil
+}
+
+// Ping is the response of /asterisk/ping
+type Ping struct {
+	AsteriskID string `json:"asterisk_id"`
+	Ping       string `json:"ping"`
+	Timestamp  string `json:"timestamp"`
+}
+
+// Ping makes the no-op /asterisk/ping request (Asterisk 13.25/16.2 and
+// later), falling back to /asterisk/info on older versions
+func (c *Client) Ping(ctx context.Context) (*Ping, error) {
+	var p Ping
+	err := c.get(ctx, "/asterisk/ping", &p)
+	if IsStatus(err, http.StatusNotFound) {
+		info, err := c.Info(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return &Ping{AsteriskID: info.System.EntityID, Ping: "pong"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// probeChannel is a channel ID that never exists
+const probeChannel = "aava-permission-probe"
+
+// CanWrite reports whether the credentials may make changing requests.
+// ari.conf read_only users get 403; others get 404 for the probe channel,
+// so nothing is changed either way.
+func (c *Client) CanWrite(ctx context.Context) (bool, error) {
+	err := c.do(ctx, "POST", "/channels/"+probeChannel+"/ring", nil)
+	switch {
+	case err == nil, IsStatus(err, http.StatusNotFound):
+		return true, nil
+	case IsStatus(err, http.StatusForbidden):
+		return false, nil
+	}
+	return false, err
+}
+
+// Failure classifies why ARI could not be used
+type Failure string
+
+const (
+	FailureNone          Failure = ""
+	FailureUnreachable   Failure = "unreachable"
+	FailureTimeout       Failure = "timeout"
+	FailureNotARI        Failure = "not_ari"
+	FailureAuth          Failure = "auth"
+	FailureForbidden     Failure = "forbidden"
+	FailureReadOnly      Failure = "read_only"
+	FailureNotRegistered Failure = "not_registered"
+)
+
+// Registration is the result of Diagnose
+type Registration struct {
+	Address    string
+	Failure    Failure
+	Err        error
+	Latency    time.Duration
+	AsteriskID string
+	// Apps are all registered applications; App is the engine's, when
+	// registered
+	Apps []Application
+	App  *Application
+}
+
+// Diagnose checks, in order, that ARI is reachable, that the credentials
+// are accepted and may make changes, and that app is registered. The
+// first failing step is reported, so a running Asterisk without the
+// engine's app registered is told apart from connectivity problems.
+func (c *Client) Diagnose(ctx context.Context, app string) *Registration {
+	r := &Registration{Address: net.JoinHostPort(c.host, c.port)}
+
+	start := time.Now()
+	ping, err := c.Ping(ctx)
+	r.Latency = time.Since(start)
+	if err != nil {
+		r.Failure, r.Err = classify(err), err
+		return r
+	}
+	r.AsteriskID = ping.AsteriskID
+
+	apps, err := c.Applications(ctx)
+	if err != nil {
+		r.Failure, r.Err = classify(err), err
+		return r
+	}
+	r.Apps = apps
+
+	canWrite, err := c.CanWrite(ctx)
+	if err != nil {
+		r.Failure, r.Err = classify(err), err
+		return r
+	}
+	if !canWrite {
+		r.Failure = FailureReadOnly
+		return r
+	}
+
+	for i := range apps {
+		if apps[i].Name == app {
+			r.App = &apps[i]
+			return r
+		}
+	}
+	r.Failure = FailureNotRegistered
+	return r
+}
+
+func classify(err error) Failure {
+	switch {
+	case IsStatus(err, http.StatusUnauthorized):
+		return FailureAuth
+	case IsStatus(err, http.StatusForbidden):
+		return FailureForbidden
+	}
+	var se *StatusError
+	var syntax *json.SyntaxError
+	if errors.As(err, &se) || errors.As(err, &syntax) {
+		// Something answered HTTP but not as ARI, e.g. ARI disabled in
+		// ari.conf or another server on the port
+		return FailureNotARI
+	}
+	var ue *url.Error
+	if errors.As(err, &ue) && ue.Timeout() {
+		return FailureTimeout
+	}
+	return FailureUnreachable
+}
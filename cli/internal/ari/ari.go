@@ -0,0 +1,200 @@
+package ari
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultPort is the Asterisk HTTP server port ARI is served on
+const DefaultPort = "8088"
+
+// Client is a minimal Asterisk REST Interface client
+type Client struct {
+	host     string
+	port     string
+	baseURL  string
+	username string
+	password string
+	http     *http.Client
+}
+
+// New creates a client for the ARI server at host:port
+func New(host, port, username, password string) *Client {
+	if port == "" {
+		port = DefaultPort
+	}
+	return &Client{
+		host:     host,
+		port:     port,
+		baseURL:  "http://" + host + ":" + port + "/ari",
+		username: username,
+		password: password,
+		http:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Host is the Asterisk host the client talks to
+func (c *Client) Host() string {
+	return c.host
+}
+
+// FromEnv creates a client from ASTERISK_HOST, ASTERISK_ARI_USERNAME and
+// ASTERISK_ARI_PASSWORD (environment first, then env), the same variables
+// the engine uses
+func FromEnv(env map[string]string) (*Client, error) {
+	lookup := func(keys ...string) string {
+		for _, key := range keys {
+			if v := os.Getenv(key); v != "" {
+				return v
+			}
+			if v := env[key]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	host := lookup("ASTERISK_HOST")
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	username := lookup("ASTERISK_ARI_USERNAME", "ARI_USERNAME")
+	password := lookup("ASTERISK_ARI_PASSWORD", "ARI_PASSWORD")
+	if username == "" || password == "" {
+		return nil, fmt.Errorf("ASTERISK_ARI_USERNAME and ASTERISK_ARI_PASSWORD must be set (environment or .env)")
+	}
+	return New(host, lookup("ASTERISK_ARI_PORT"), username, password), nil
+}
+
+// StatusError is a non-2xx ARI response
+type StatusError struct {
+	Method string
+	Path   string
+	Code   int
+	Status string
+	Body   string
+}
+
+func (e *StatusError) Error() string {
+	return strings.TrimSpace(fmt.Sprintf("ARI %s %s: %s %s", e.Method, e.Path, e.Status, e.Body))
+}
+
+// IsStatus reports whether err is an ARI response with the given code
+func IsStatus(err error, code int) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == code
+}
+
+func (c *Client) get(ctx context.Context, path string, out interface{}) error {
+	return c.do(ctx, "GET", path, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(c.username, c.password)
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return &StatusError{
+			Method: method,
+			Path:   path,
+			Code:   resp.StatusCode,
+			Status: resp.Status,
+			Body:   strings.TrimSpace(string(msg)),
+		}
+	}
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Info is the subset of /asterisk/info the CLI uses
+type Info struct {
+	System struct {
+		Version  string `json:"version"`
+		EntityID string `json:"entity_id"`
+	} `json:"system"`
+	Status struct {
+		StartupTime    Time `json:"startup_time"`
+		LastReloadTime Time `json:"last_reload_time"`
+	} `json:"status"`
+}
+
+// timeLayout is how ARI writes timestamps: a numeric offset without the
+// colon RFC 3339 wants, e.g. 2025-10-26T09:15:00.123+0000
+const timeLayout = "2006-01-02T15:04:05.000-0700"
+
+// Time is an ARI timestamp
+type Time struct {
+	time.Time
+}
+
+// UnmarshalJSON parses ARI's layout, and RFC 3339 from proxies that
+// rewrite it
+func (t *Time) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	if s == "" {
+		t.Time = time.Time{}
+		return nil
+	}
+	parsed, err := time.Parse(timeLayout, s)
+	if err != nil {
+		if parsed, err = time.Parse(time.RFC3339Nano, s); err != nil {
+			return fmt.Errorf("ari: bad timestamp %q", s)
+		}
+	}
+	t.Time = parsed
+	return nil
+}
+
+// Info returns Asterisk version and uptime information
+func (c *Client) Info(ctx context.Context) (*Info, error) {
+	var info Info
+	if err := c.get(ctx, "/asterisk/info", &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Channel is an active Asterisk channel
+type Channel struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	State        string `json:"state"`
+	CreationTime string `json:"creationtime"`
+	Caller       struct {
+		Name   string `json:"name"`
+		Number string `json:"number"`
+	} `json:"caller"`
+	Dialplan struct {
+		Context  string `json:"context"`
+		Exten    string `json:"exten"`
+		Priority int    `json:"priority"`
+	} `json:"dialplan"`
+}
+
+// Channels lists the active channels
+func (c *Client) Channels(ctx context.Context) ([]Channel, error) {
+	var channels []Channel
+	if err := c.get(ctx, "/channels", &channels); err != nil {
+		return nil, err
+	}
+	return channels, nil
+}
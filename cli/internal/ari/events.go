@@ -0,0 +1,89 @@
+package ari
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/url"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/websocket"
+)
+
+// Event is an ARI event; Channel is set for channel events, Caller,
+// Peer and the dial fields for Dial events
+type Event struct {
+	Type        string   `json:"type"`
+	Application string   `json:"application"`
+	Timestamp   string   `json:"timestamp"`
+	Channel     *Channel `json:"channel,omitempty"`
+	// Caller is absent for channels originated through ARI or AMI
+	Caller     *Channel        `json:"caller,omitempty"`
+	Peer       *Channel        `json:"peer,omitempty"`
+	DialString string          `json:"dialstring,omitempty"`
+	DialStatus string          `json:"dialstatus,omitempty"`
+	Raw        json.RawMessage `json:"-"`
+}
+
+// Events is an open event stream; connecting it registers the
+// application with Asterisk until Close
+type Events struct {
+	conn *websocket.Conn
+	C    <-chan Event
+	// Err is set when C is closed because the stream failed
+	Err error
+}
+
+// Subscribe registers app and streams its events. Channels and bridges
+// the CLI puts into app need it registered: Asterisk refuses Stasis
+// operations for applications nobody listens to.
+func (c *Client) Subscribe(ctx context.Context, app string) (*Events, error) {
+	return c.subscribe(ctx, app, false)
+}
+
+// SubscribeAll registers app and streams the events of every channel,
+// bridge and endpoint in Asterisk, not only of those in app
+func (c *Client) SubscribeAll(ctx context.Context, app string) (*Events, error) {
+	return c.subscribe(ctx, app, true)
+}
+
+func (c *Client) subscribe(ctx context.Context, app string, all bool) (*Events, error) {
+	q := url.Values{}
+	q.Set("app", app)
+	if all {
+		q.Set("subscribeAll", "true")
+	}
+	q.Set("api_key", c.username+":"+c.password)
+	u := "ws://" + c.host + ":" + c.port + "/ari/events?" + q.Encode()
+	conn, err := websocket.Dial(ctx, u, http.Header{})
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan Event, 64)
+	ev := &Events{conn: conn, C: ch}
+	go func() {
+		defer close(ch)
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				ev.Err = err
+				return
+			}
+			var e Event
+			if json.Unmarshal(data, &e) != nil {
+				continue
+			}
+			e.Raw = data
+			select {
+			case ch <- e:
+			default:
+				// Nobody is reading fast enough; drop rather than stall pings
+			}
+		}
+	}()
+	return ev, nil
+}
+
+// Close unregisters the application
+func (e *Events) Close() error {
+	return e.conn.Close()
+}
@@ -0,0 +1,68 @@
+package ari
+
+import (
+	"context"
+	"net/url"
+)
+
+// Endpoint is an Asterisk endpoint such as PJSIP/telnyx
+type Endpoint struct {
+	Technology string   `json:"technology"`
+	Resource   string   `json:"resource"`
+	State      string   `json:"state"`
+	ChannelIDs []string `json:"channel_ids"`
+}
+
+// Endpoints lists the endpoints of all channel technologies
+func (c *Client) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	if err := c.get(ctx, "/endpoints", &endpoints); err != nil {
+		return nil, err
+	}
+	return endpoints, nil
+}
+
+// ConfigObject returns the attributes of a sorcery object, e.g.
+// ("res_pjsip", "endpoint", "telnyx"), as Asterisk has them loaded
+func (c *Client) ConfigObject(ctx context.Context, class, objectType, id string) (map[string]string, error) {
+	var tuples []struct {
+		Attribute string `json:"attribute"`
+		Value     string `json:"value"`
+	}
+	path := "/asterisk/config/dynamic/" + url.PathEscape(class) + "/" + url.PathEscape(objectType) + "/" + url.PathEscape(id)
+	if err := c.get(ctx, path, &tuples); err != nil {
+		return nil, err
+	}
+	attrs := make(map[string]string, len(tuples))
+	for _, t := range tuples {
+		attrs[t.Attribute] = t.Value
+	}
+	return attrs, nil
+}
+
+// Variable reads a channel variable or dialplan function such as
+// CHANNEL(rtp,secure)
+func (c *Client) Variable(ctx context.Context, channelID, name string) (string, error) {
+	var v struct {
+		Value string `json:"value"`
+	}
+	q := url.Values{}
+	q.Set("variable", name)
+	if err := c.get(ctx, "/channels/"+url.PathEscape(channelID)+"/variable?"+q.Encode(), &v); err != nil {
+		return "", err
+	}
+	return v.Value, nil
+}
+
+// GlobalVariable reads a global variable or a built-in such as EPOCH
+func (c *Client) GlobalVariable(ctx context.Context, name string) (string, error) {
+	var v struct {
+		Value string `json:"value"`
+	}
+	q := url.Values{}
+	q.Set("variable", name)
+	if err := c.get(ctx, "/asterisk/variable?"+q.Encode(), &v); err != nil {
+		return "", err
+	}
+	return v.Value, nil
+}
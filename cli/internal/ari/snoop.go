@@ -0,0 +1,108 @@
+package ari
+
+import (
+	"context"
+	"net/url"
+)
+
+// Bridge is an ARI bridge
+type Bridge struct {
+	ID         string   `json:"id"`
+	Technology string   `json:"technology"`
+	BridgeType string   `json:"bridge_type"`
+	Channels   []string `json:"channels"`
+}
+
+// Channel returns the channel with the given ID
+func (c *Client) Channel(ctx context.Context, id string) (*Channel, error) {
+	var ch Channel
+	if err := c.get(ctx, "/channels/"+url.PathEscape(id), &ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// Hangup hangs up a channel
+func (c *Client) Hangup(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/channels/"+url.PathEscape(id), nil)
+}
+
+// SendDTMF sends DTMF digits on a channel, toward the party it is
+// connected to; ',' pauses
+func (c *Client) SendDTMF(ctx context.Context, id, digits string) error {
+	q := url.Values{}
+	q.Set("dtmf", digits)
+	return c.do(ctx, "POST", "/channels/"+url.PathEscape(id)+"/dtmf?"+q.Encode(), nil)
+}
+
+// CreateBridge creates a mixing bridge
+func (c *Client) CreateBridge(ctx context.Context, name string) (*Bridge, error) {
+	q := url.Values{}
+	q.Set("type", "mixing")
+	q.Set("name", name)
+	var b Bridge
+	if err := c.do(ctx, "POST", "/bridges?"+q.Encode(), &b); err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+// AddChannel puts a channel, which must be in a Stasis application, into a bridge
+func (c *Client) AddChannel(ctx context.Context, bridgeID, channelID string) error {
+	q := url.Values{}
+	q.Set("channel", channelID)
+	return c.do(ctx, "POST", "/bridges/"+url.PathEscape(bridgeID)+"/addChannel?"+q.Encode(), nil)
+}
+
+// DestroyBridge destroys a bridge; its channels stay up
+func (c *Client) DestroyBridge(ctx context.Context, id string) error {
+	return c.do(ctx, "DELETE", "/bridges/"+url.PathEscape(id), nil)
+}
+
+// Snoop creates a channel that hears both directions of id (the spied
+// channel does not hear it) and places it in app
+func (c *Client) Snoop(ctx context.Context, id, app string) (*Channel, error) {
+	q := url.Values{}
+	q.Set("spy", "both")
+	q.Set("whisper", "none")
+	q.Set("app", app)
+	var ch Channel
+	if err := c.do(ctx, "POST", "/channels/"+url.PathEscape(id)+"/snoop?"+q.Encode(), &ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// ExternalMedia creates a channel in app that sends its audio as RTP to
+// host (ip:port) in format (such as ulaw)
+func (c *Client) ExternalMedia(ctx context.Context, app, host, format string) (*Channel, error) {
+	q := url.Values{}
+	q.Set("app", app)
+	q.Set("external_host", host)
+	q.Set("format", format)
+	q.Set("encapsulation", "rtp")
+	q.Set("transport", "udp")
+	q.Set("direction", "both")
+	var ch Channel
+	if err := c.do(ctx, "POST", "/channels/externalMedia?"+q.Encode(), &ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
+
+// Originate calls endpoint (such as PJSIP/1001) and places the answered
+// channel in app
+func (c *Client) Originate(ctx context.Context, endpoint, app, callerID string) (*Channel, error) {
+	q := url.Values{}
+	q.Set("endpoint", endpoint)
+	q.Set("app", app)
+	if callerID != "" {
+		q.Set("callerId", callerID)
+	}
+	q.Set("timeout", "30")
+	var ch Channel
+	if err := c.do(ctx, "POST", "/channels?"+q.Encode(), &ch); err != nil {
+		return nil, err
+	}
+	return &ch, nil
+}
@@ -0,0 +1,322 @@
+package calendar
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// icsLocalTime and icsUTCTime are iCalendar DATE-TIME values
+const (
+	icsLocalTime = "20060102T150405"
+	icsUTCTime   = "20060102T150405Z"
+)
+
+// calDAV books events as iCalendar resources in a CalDAV collection
+// (Nextcloud, iCloud, Fastmail, Radicale, ...)
+type calDAV struct {
+	url      string
+	username string
+	password string
+	token    string
+	http     *http.Client
+}
+
+func newCalDAV(cfg settings.Calendar) (*calDAV, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("caldav: url (of the calendar collection) is required")
+	}
+	if cfg.Token == "" && cfg.Username == "" {
+		return nil, fmt.Errorf("caldav: username and password, or token, are required")
+	}
+	return &calDAV{
+		url:      strings.TrimRight(cfg.URL, "/") + "/",
+		username: cfg.Username,
+		password: cfg.Password,
+		token:    cfg.Token,
+		http:     &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (c *calDAV) Name() string {
+	return "caldav:" + c.url
+}
+
+// do sends a request to url and returns the reply of a 2xx status
+func (c *calDAV) do(ctx context.Context, method, url string, header map[string]string, body string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, strings.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	} else {
+		req.SetBasicAuth(c.username, c.password)
+	}
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, readError("caldav "+method, resp)
+	}
+	return resp, nil
+}
+
+// davMultistatus is the part of a WebDAV multistatus reply used
+type davMultistatus struct {
+	Responses []struct {
+		Href     string `xml:"href"`
+		Propstat []struct {
+			Prop struct {
+				ResourceType struct {
+					Calendar *struct{} `xml:"calendar"`
+				} `xml:"resourcetype"`
+				Timezone string `xml:"calendar-timezone"`
+			} `xml:"prop"`
+		} `xml:"propstat"`
+	} `xml:"response"`
+}
+
+// multistatus sends a PROPFIND or REPORT and parses the reply
+func (c *calDAV) multistatus(ctx context.Context, method, depth, body string) (*davMultistatus, error) {
+	resp, err := c.do(ctx, method, c.url, map[string]string{
+		"Depth":        depth,
+		"Content-Type": "application/xml; charset=utf-8",
+	}, body)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var ms davMultistatus
+	if err := xml.NewDecoder(resp.Body).Decode(&ms); err != nil {
+		return nil, fmt.Errorf("caldav %s: %w", method, err)
+	}
+	return &ms, nil
+}
+
+func (c *calDAV) Check(ctx context.Context) (string, error) {
+	ms, err := c.multistatus(ctx, "PROPFIND", "0", `<?xml version="1.0" encoding="utf-8"?>
+<d:propfind xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:resourcetype/><c:calendar-timezone/></d:prop>
+</d:propfind>`)
+	if err != nil {
+		return "", err
+	}
+	for _, r := range ms.Responses {
+		for _, ps := range r.Propstat {
+			if ps.Prop.ResourceType.Calendar != nil {
+				zone := ""
+				for _, line := range icsLines(ps.Prop.Timezone) {
+					if name, _, value := icsProperty(line); name == "TZID" {
+						zone = value
+						break
+					}
+				}
+				return zone, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("caldav: %s is not a calendar collection (use the URL of the calendar, not of the account)", c.url)
+}
+
+// eventURL is where the event with the ID is stored
+func (c *calDAV) eventURL(id string) string {
+	return c.url + id + ".ics"
+}
+
+func (c *calDAV) Book(ctx context.Context, e Event) (string, error) {
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return "", err
+	}
+	id := fmt.Sprintf("agent-test-%d-%04x", time.Now().Unix(), rand.Intn(0x10000))
+	start, end := e.Start.In(loc), e.End.In(loc)
+	_, offset := start.Zone()
+	// One STANDARD rule with the slot's offset is a valid VTIMEZONE for
+	// the event; the server still has to apply it to the TZID times
+	ics := strings.Join([]string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//asterisk-ai-voice-agent//agent integrations test//EN",
+		"BEGIN:VTIMEZONE",
+		"TZID:" + e.TimeZone,
+		"BEGIN:STANDARD",
+		"DTSTART:19700101T000000",
+		"TZOFFSETFROM:" + icsOffset(offset),
+		"TZOFFSETTO:" + icsOffset(offset),
+		"END:STANDARD",
+		"END:VTIMEZONE",
+		"BEGIN:VEVENT",
+		"UID:" + id,
+		"DTSTAMP:" + time.Now().UTC().Format(icsUTCTime),
+		"DTSTART;TZID=" + e.TimeZone + ":" + start.Format(icsLocalTime),
+		"DTEND;TZID=" + e.TimeZone + ":" + end.Format(icsLocalTime),
+		"SUMMARY:" + e.Summary,
+		"TRANSP:OPAQUE",
+		"END:VEVENT",
+		"END:VCALENDAR",
+		"",
+	}, "\r\n")
+	resp, err := c.do(ctx, "PUT", c.eventURL(id), map[string]string{
+		"Content-Type":  "text/calendar; charset=utf-8",
+		"If-None-Match": "*",
+	}, ics)
+	if err != nil {
+		return "", err
+	}
+	resp.Body.Close()
+	return id, nil
+}
+
+// icsOffset writes a UTC offset in seconds as +HHMM
+func icsOffset(seconds int) string {
+	sign := "+"
+	if seconds < 0 {
+		sign = "-"
+		seconds = -seconds
+	}
+	return fmt.Sprintf("%s%02d%02d", sign, seconds/3600, seconds%3600/60)
+}
+
+func (c *calDAV) Get(ctx context.Context, id string) (*Event, error) {
+	resp, err := c.do(ctx, "GET", c.eventURL(id), nil, "")
+	if err != nil {
+		if s := statusOf(err); s == http.StatusNotFound || s == http.StatusGone {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseEvent(id, string(data))
+}
+
+// parseEvent reads the first VEVENT of an iCalendar resource
+func parseEvent(id, ics string) (*Event, error) {
+	e := &Event{ID: id}
+	inEvent := false
+	for _, line := range icsLines(ics) {
+		name, params, value := icsProperty(line)
+		switch {
+		case name == "BEGIN" && value == "VEVENT":
+			inEvent = true
+		case name == "END" && value == "VEVENT":
+			if e.Start.IsZero() || e.End.IsZero() {
+				return nil, fmt.Errorf("caldav: event %s has no DTSTART/DTEND", id)
+			}
+			return e, nil
+		case !inEvent:
+		case name == "SUMMARY":
+			e.Summary = value
+		case name == "DTSTART", name == "DTEND":
+			t, err := icsTime(params["TZID"], value)
+			if err != nil {
+				return nil, fmt.Errorf("caldav: event %s %s: %w", id, name, err)
+			}
+			if name == "DTSTART" {
+				e.Start = t
+				e.TimeZone = params["TZID"]
+			} else {
+				e.End = t
+			}
+		}
+	}
+	return nil, fmt.Errorf("caldav: %s holds no event", id)
+}
+
+// icsTime reads a DATE-TIME value in UTC, in the zone tzid or, without
+// either, floating (read as UTC)
+func icsTime(tzid, value string) (time.Time, error) {
+	if strings.HasSuffix(value, "Z") {
+		return time.Parse(icsUTCTime, value)
+	}
+	loc := time.UTC
+	if tzid != "" {
+		l, err := time.LoadLocation(tzid)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("unknown TZID %q", tzid)
+		}
+		loc = l
+	}
+	return time.ParseInLocation(icsLocalTime, value, loc)
+}
+
+// icsLines unfolds iCalendar content lines
+func icsLines(ics string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.Replace(ics, "\r\n", "\n", -1), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// icsProperty splits "DTSTART;TZID=Europe/Berlin:20261025T030000" into
+// the property name, its parameters and its value
+func icsProperty(line string) (string, map[string]string, string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return strings.ToUpper(line), nil, ""
+	}
+	parts := strings.Split(line[:i], ";")
+	params := make(map[string]string)
+	for _, p := range parts[1:] {
+		if kv := strings.SplitN(p, "=", 2); len(kv) == 2 {
+			params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+	return strings.ToUpper(parts[0]), params, line[i+1:]
+}
+
+func (c *calDAV) Find(ctx context.Context, id string, start, end time.Time) (bool, error) {
+	ms, err := c.multistatus(ctx, "REPORT", "1", fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?>
+<c:calendar-query xmlns:d="DAV:" xmlns:c="urn:ietf:params:xml:ns:caldav">
+  <d:prop><d:getetag/></d:prop>
+  <c:filter>
+    <c:comp-filter name="VCALENDAR">
+      <c:comp-filter name="VEVENT">
+        <c:time-range start="%s" end="%s"/>
+      </c:comp-filter>
+    </c:comp-filter>
+  </c:filter>
+</c:calendar-query>`, start.UTC().Format(icsUTCTime), end.UTC().Format(icsUTCTime)))
+	if err != nil {
+		return false, err
+	}
+	for _, r := range ms.Responses {
+		if strings.HasSuffix(strings.TrimSpace(r.Href), "/"+id+".ics") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (c *calDAV) Cancel(ctx context.Context, id string) error {
+	resp, err := c.do(ctx, "DELETE", c.eventURL(id), nil, "")
+	if err != nil {
+		if s := statusOf(err); s == http.StatusNotFound || s == http.StatusGone {
+			return ErrNotFound
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,249 @@
+// Package calendar checks the booking API of the agent's appointment
+// tool end-to-end: it books a test slot, reads it back in another zone
+// than it was booked in and removes it again.
+package calendar
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Calendar types
+const (
+	TypeGoogle = "google"
+	TypeCalDAV = "caldav"
+)
+
+// Types lists the supported calendars
+var Types = []string{TypeGoogle, TypeCalDAV}
+
+// TestSummary is the title of test bookings
+const TestSummary = "agent integrations test (safe to delete)"
+
+// ErrNotFound is returned for events the calendar does not have
+var ErrNotFound = fmt.Errorf("event not found")
+
+// Event is a booking
+type Event struct {
+	ID      string
+	Summary string
+	Start   time.Time
+	End     time.Time
+	// TimeZone is the zone the event was booked or is stored in
+	TimeZone string
+}
+
+// Calendar books events through a calendar API
+type Calendar interface {
+	Name() string
+	// Check authenticates and returns the calendar's own zone, "" when
+	// the API does not tell
+	Check(ctx context.Context) (string, error)
+	// Book creates the event with its times written in e.TimeZone and
+	// returns its ID
+	Book(ctx context.Context, e Event) (string, error)
+	// Get reads an event back, ErrNotFound when it is gone
+	Get(ctx context.Context, id string) (*Event, error)
+	// Find reports whether the event is among those the calendar lists
+	// between start and end, asked for in UTC
+	Find(ctx context.Context, id string, start, end time.Time) (bool, error)
+	// Cancel deletes the event
+	Cancel(ctx context.Context, id string) error
+}
+
+// New creates the calendar of the configuration
+func New(cfg settings.Calendar) (Calendar, error) {
+	switch cfg.Type {
+	case TypeGoogle:
+		return newGoogle(cfg)
+	case TypeCalDAV:
+		return newCalDAV(cfg)
+	case "":
+		return nil, fmt.Errorf("calendar: type is required (%s)", strings.Join(Types, " or "))
+	default:
+		return nil, fmt.Errorf("calendar: unknown type %q (use %s)", cfg.Type, strings.Join(Types, " or "))
+	}
+}
+
+// apiError is an HTTP error reply of a calendar API
+type apiError struct {
+	api    string
+	status int
+	msg    string
+}
+
+func (e *apiError) Error() string {
+	hint := ""
+	switch e.status {
+	case http.StatusUnauthorized:
+		hint = " (credentials rejected or expired)"
+	case http.StatusForbidden:
+		hint = " (no access: check the token's scopes and the calendar's sharing)"
+	case http.StatusNotFound:
+		hint = " (calendar not found: check calendar_id or url)"
+	}
+	if e.msg == "" {
+		return fmt.Sprintf("%s: %d %s%s", e.api, e.status, http.StatusText(e.status), hint)
+	}
+	return fmt.Sprintf("%s: %d %s%s: %s", e.api, e.status, http.StatusText(e.status), hint, e.msg)
+}
+
+// statusOf returns the HTTP status of an API error, 0 for other errors
+func statusOf(err error) int {
+	if e, ok := err.(*apiError); ok {
+		return e.status
+	}
+	return 0
+}
+
+// readError turns a non-2xx reply into an apiError
+func readError(api string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return &apiError{api: api, status: resp.StatusCode, msg: strings.Join(strings.Fields(string(msg)), " ")}
+}
+
+// Step is one check of a test run
+type Step struct {
+	Name      string `json:"step"`
+	Passed    bool   `json:"passed"`
+	Detail    string `json:"detail,omitempty"`
+	ElapsedMs int64  `json:"elapsed_ms"`
+}
+
+// Report is the outcome of a test run
+type Report struct {
+	Calendar string    `json:"calendar"`
+	Timezone string    `json:"timezone"`
+	Slot     time.Time `json:"slot"`
+	EventID  string    `json:"event_id,omitempty"`
+	Passed   bool      `json:"passed"`
+	Steps    []Step    `json:"steps"`
+	// Leftover is set when the test booking could not be removed
+	Leftover bool `json:"leftover,omitempty"`
+}
+
+// Test books a test event of duration at slot in loc, checks that the
+// calendar stores it at the same instant and removes it again. progress
+// is called after each step. A booking is always removed, also when
+// the checks after it failed.
+func Test(ctx context.Context, c Calendar, loc *time.Location, slot time.Time, duration time.Duration, progress func(Step)) *Report {
+	slot = slot.In(loc)
+	report := &Report{Calendar: c.Name(), Timezone: loc.String(), Slot: slot, Passed: true}
+	step := func(name string, fn func() (string, error)) bool {
+		start := time.Now()
+		detail, err := fn()
+		s := Step{Name: name, Passed: err == nil, Detail: detail, ElapsedMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			s.Detail = err.Error()
+			report.Passed = false
+		}
+		report.Steps = append(report.Steps, s)
+		if progress != nil {
+			progress(s)
+		}
+		return err == nil
+	}
+
+	ok := step("auth", func() (string, error) {
+		zone, err := c.Check(ctx)
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case zone == "":
+			return "authenticated", nil
+		case zone != loc.String():
+			return fmt.Sprintf("authenticated; the calendar's zone is %s, bookings are made in %s", zone, loc), nil
+		}
+		return "authenticated; the calendar's zone is " + zone, nil
+	})
+	if !ok {
+		return report
+	}
+
+	want := Event{Summary: TestSummary, Start: slot, End: slot.Add(duration), TimeZone: loc.String()}
+	ok = step("book", func() (string, error) {
+		id, err := c.Book(ctx, want)
+		if err != nil {
+			return "", err
+		}
+		report.EventID = id
+		return fmt.Sprintf("%s %s (%s)", slot.Format("2006-01-02 15:04 MST"), loc, id), nil
+	})
+	if !ok {
+		return report
+	}
+
+	step("read back", func() (string, error) {
+		got, err := c.Get(ctx, report.EventID)
+		if err != nil {
+			return "", err
+		}
+		if !got.Start.Equal(want.Start) || !got.End.Equal(want.End) {
+			return "", fmt.Errorf("booked %s–%s, the calendar has %s–%s (%s off: a timezone is misread)",
+				want.Start.Format("15:04 MST"), want.End.Format("15:04 MST"),
+				got.Start.In(loc).Format("15:04 MST"), got.End.In(loc).Format("15:04 MST"),
+				got.Start.Sub(want.Start))
+		}
+		detail := "stored at " + got.Start.UTC().Format("2006-01-02 15:04Z")
+		if got.TimeZone != "" {
+			detail += " in " + got.TimeZone
+		}
+		return detail, nil
+	})
+	step("time range", func() (string, error) {
+		found, err := c.Find(ctx, report.EventID, want.Start.UTC(), want.End.UTC())
+		if err != nil {
+			return "", err
+		}
+		if !found {
+			return "", fmt.Errorf("not listed between %s and %s UTC: the calendar indexes it at another time",
+				want.Start.UTC().Format("15:04"), want.End.UTC().Format("15:04"))
+		}
+		return "listed between " + want.Start.UTC().Format("15:04") + " and " + want.End.UTC().Format("15:04") + " UTC", nil
+	})
+
+	// Roll back on a context of its own, so an expired --timeout does not
+	// leave the booking behind
+	rollback, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if !step("rollback", func() (string, error) {
+		if err := c.Cancel(rollback, report.EventID); err != nil && err != ErrNotFound {
+			return "", err
+		}
+		if _, err := c.Get(rollback, report.EventID); err != ErrNotFound {
+			if err != nil {
+				return "", fmt.Errorf("deleted, but checking failed: %w", err)
+			}
+			return "", fmt.Errorf("deleted, but the event is still there")
+		}
+		return "test booking deleted", nil
+	}) {
+		report.Leftover = true
+	}
+	return report
+}
+
+// DefaultSlot picks the slot tests book: 03:00 on the day after the
+// zone's next offset change (summer or winter time) within a year, so
+// clients that keep a fixed offset book the wrong hour; a week ahead in
+// zones without changes.
+func DefaultSlot(now time.Time, loc *time.Location) time.Time {
+	now = now.In(loc)
+	day := time.Date(now.Year(), now.Month(), now.Day(), 12, 0, 0, 0, loc)
+	_, offset := day.Zone()
+	for i := 1; i <= 366; i++ {
+		next := day.AddDate(0, 0, i)
+		if _, o := next.Zone(); o != offset {
+			return time.Date(next.Year(), next.Month(), next.Day()+1, 3, 0, 0, 0, loc)
+		}
+	}
+	week := day.AddDate(0, 0, 7)
+	return time.Date(week.Year(), week.Month(), week.Day(), 3, 0, 0, 0, loc)
+}
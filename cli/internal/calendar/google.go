@@ -0,0 +1,285 @@
+package calendar
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// googleAPI is the Calendar API base URL
+const googleAPI = "https://www.googleapis.com/calendar/v3"
+
+// googleScope is the OAuth scope service accounts ask for
+const googleScope = "https://www.googleapis.com/auth/calendar.events https://www.googleapis.com/auth/calendar.readonly"
+
+// googleLocalTime is how event times are sent: without an offset, so
+// the API has to apply the event's timeZone itself
+const googleLocalTime = "2006-01-02T15:04:05"
+
+// google books events through the Google Calendar API
+type google struct {
+	calendarID  string
+	token       string
+	credentials string
+	http        *http.Client
+}
+
+func newGoogle(cfg settings.Calendar) (*google, error) {
+	if cfg.Token == "" && cfg.CredentialsFile == "" {
+		return nil, fmt.Errorf("google: credentials_file (service account key) or token is required")
+	}
+	id := cfg.CalendarID
+	if id == "" {
+		id = "primary"
+	}
+	return &google{
+		calendarID:  id,
+		token:       cfg.Token,
+		credentials: cfg.CredentialsFile,
+		http:        &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (g *google) Name() string {
+	return "google:" + g.calendarID
+}
+
+// serviceAccountKey is the part of a service account key file used
+type serviceAccountKey struct {
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+	TokenURI    string `json:"token_uri"`
+}
+
+// accessToken returns the configured token, or gets one for the
+// service account with a signed JWT assertion
+func (g *google) accessToken(ctx context.Context) (string, error) {
+	if g.token != "" {
+		return g.token, nil
+	}
+	data, err := os.ReadFile(g.credentials)
+	if err != nil {
+		return "", fmt.Errorf("google: %w", err)
+	}
+	var key serviceAccountKey
+	if err := json.Unmarshal(data, &key); err != nil {
+		return "", fmt.Errorf("google: %s: %w", g.credentials, err)
+	}
+	if key.ClientEmail == "" || key.PrivateKey == "" {
+		return "", fmt.Errorf("google: %s is not a service account key", g.credentials)
+	}
+	if key.TokenURI == "" {
+		key.TokenURI = "https://oauth2.googleapis.com/token"
+	}
+	assertion, err := signJWT(key, time.Now())
+	if err != nil {
+		return "", fmt.Errorf("google: %s: %w", g.credentials, err)
+	}
+	form := url.Values{
+		"grant_type": {"urn:ietf:params:oauth:grant-type:jwt-bearer"},
+		"assertion":  {assertion},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", key.TokenURI, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", readError("google token", resp)
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("google token: %w", err)
+	}
+	// Tokens live an hour, longer than a test
+	g.token = token.AccessToken
+	return g.token, nil
+}
+
+// signJWT creates the RS256 assertion of a service account
+func signJWT(key serviceAccountKey, now time.Time) (string, error) {
+	block, _ := pem.Decode([]byte(key.PrivateKey))
+	if block == nil {
+		return "", fmt.Errorf("private_key is not PEM")
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return "", err
+	}
+	rsaKey, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return "", fmt.Errorf("private_key is not an RSA key")
+	}
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		"iss":   key.ClientEmail,
+		"scope": googleScope,
+		"aud":   key.TokenURI,
+		"iat":   now.Unix(),
+		"exp":   now.Add(time.Hour).Unix(),
+	})
+	enc := base64.RawURLEncoding
+	unsigned := enc.EncodeToString(header) + "." + enc.EncodeToString(claims)
+	sum := sha256.Sum256([]byte(unsigned))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + enc.EncodeToString(sig), nil
+}
+
+// do sends a request to the API and decodes a JSON reply into out
+func (g *google) do(ctx context.Context, method, path string, in, out interface{}) error {
+	token, err := g.accessToken(ctx)
+	if err != nil {
+		return err
+	}
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, googleAPI+path, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := g.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return readError("google", resp)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (g *google) eventsPath() string {
+	return "/calendars/" + url.PathEscape(g.calendarID) + "/events"
+}
+
+func (g *google) Check(ctx context.Context) (string, error) {
+	var cal struct {
+		TimeZone string `json:"timeZone"`
+	}
+	if err := g.do(ctx, "GET", "/calendars/"+url.PathEscape(g.calendarID), nil, &cal); err != nil {
+		return "", err
+	}
+	return cal.TimeZone, nil
+}
+
+// googleTime is an event's start or end
+type googleTime struct {
+	DateTime string `json:"dateTime,omitempty"`
+	TimeZone string `json:"timeZone,omitempty"`
+}
+
+// googleEvent is the part of an event used
+type googleEvent struct {
+	ID      string     `json:"id,omitempty"`
+	Status  string     `json:"status,omitempty"`
+	Summary string     `json:"summary,omitempty"`
+	Start   googleTime `json:"start"`
+	End     googleTime `json:"end"`
+}
+
+func (g *google) Book(ctx context.Context, e Event) (string, error) {
+	loc, err := time.LoadLocation(e.TimeZone)
+	if err != nil {
+		return "", err
+	}
+	in := googleEvent{
+		Summary: e.Summary,
+		Start:   googleTime{DateTime: e.Start.In(loc).Format(googleLocalTime), TimeZone: e.TimeZone},
+		End:     googleTime{DateTime: e.End.In(loc).Format(googleLocalTime), TimeZone: e.TimeZone},
+	}
+	var created googleEvent
+	if err := g.do(ctx, "POST", g.eventsPath()+"?sendUpdates=none", in, &created); err != nil {
+		return "", err
+	}
+	return created.ID, nil
+}
+
+func (g *google) Get(ctx context.Context, id string) (*Event, error) {
+	var ev googleEvent
+	if err := g.do(ctx, "GET", g.eventsPath()+"/"+url.PathEscape(id), nil, &ev); err != nil {
+		if s := statusOf(err); s == http.StatusNotFound || s == http.StatusGone {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+	if ev.Status == "cancelled" {
+		return nil, ErrNotFound
+	}
+	start, err := time.Parse(time.RFC3339, ev.Start.DateTime)
+	if err != nil {
+		return nil, fmt.Errorf("google: event start %q: %w", ev.Start.DateTime, err)
+	}
+	end, err := time.Parse(time.RFC3339, ev.End.DateTime)
+	if err != nil {
+		return nil, fmt.Errorf("google: event end %q: %w", ev.End.DateTime, err)
+	}
+	return &Event{ID: ev.ID, Summary: ev.Summary, Start: start, End: end, TimeZone: ev.Start.TimeZone}, nil
+}
+
+func (g *google) Find(ctx context.Context, id string, start, end time.Time) (bool, error) {
+	q := url.Values{
+		"timeMin":      {start.UTC().Format(time.RFC3339)},
+		"timeMax":      {end.UTC().Format(time.RFC3339)},
+		"singleEvents": {"true"},
+		"q":            {TestSummary},
+	}
+	var list struct {
+		Items []googleEvent `json:"items"`
+	}
+	if err := g.do(ctx, "GET", g.eventsPath()+"?"+q.Encode(), nil, &list); err != nil {
+		return false, err
+	}
+	for _, ev := range list.Items {
+		if ev.ID == id {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (g *google) Cancel(ctx context.Context, id string) error {
+	err := g.do(ctx, "DELETE", g.eventsPath()+"/"+url.PathEscape(id)+"?sendUpdates=none", nil, nil)
+	if s := statusOf(err); s == http.StatusNotFound || s == http.StatusGone {
+		return ErrNotFound
+	}
+	return err
+}
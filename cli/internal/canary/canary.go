@@ -0,0 +1,140 @@
+package canary
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/scale"
+)
+
+// Generated file names, relative to the project directory
+const (
+	ComposeFile  = "docker-compose.canary.yml"
+	DialplanFile = "extensions_aava_canary.conf"
+	ConfigFile   = "config/ai-agent.canary.yaml"
+)
+
+// The canary instance next to ai_engine
+const (
+	Service   = "ai-engine-canary"
+	Container = "ai_engine_canary"
+)
+
+// DialplanContext is the generated weighted subroutine
+const DialplanContext = "aava-canary"
+
+// PercentVariable is the Asterisk global holding the share of calls sent
+// to the canary; setting it takes effect without a dialplan reload
+const PercentVariable = "AAVA_CANARY_PCT"
+
+// PortOffset separates the canary's ports from ai_engine's and from the
+// scaled instances (agent scale allows up to 32)
+const PortOffset = 50
+
+// Options describes a canary deployment next to the existing ai_engine
+type Options struct {
+	Percent         int
+	AppName         string
+	AudioSocketPort int
+	HealthPort      int
+	RTPPort         int
+}
+
+// DefaultOptions reads the stable engine's app name and ports from
+// ai-agent.yaml under dir and derives the canary's from them
+func DefaultOptions(dir string, percent int) Options {
+	base := scale.DefaultOptions(dir, 1)
+	return Options{
+		Percent:         percent,
+		AppName:         base.AppName,
+		AudioSocketPort: base.AudioSocketPort + PortOffset,
+		HealthPort:      base.HealthPort + PortOffset,
+		RTPPort:         base.RTPPort + PortOffset,
+	}
+}
+
+// CanaryApp is the ARI app the canary registers
+func (o Options) CanaryApp() string {
+	return o.AppName + "-canary"
+}
+
+// HealthURL is the canary's health endpoint
+func (o Options) HealthURL() string {
+	return fmt.Sprintf("http://127.0.0.1:%d", o.HealthPort)
+}
+
+// GenerateCompose renders a compose override adding the canary. It
+// extends ai-engine with the candidate configuration mounted over
+// ai-agent.yaml, its own ports and ARI app name.
+func GenerateCompose(o Options) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Generated by: agent config deploy --canary %d%%\n", o.Percent))
+	sb.WriteString("# Use with: docker compose -f docker-compose.yml -f " + ComposeFile + " up -d " + Service + "\n")
+	sb.WriteString("services:\n")
+	sb.WriteString(fmt.Sprintf("  %s:\n", Service))
+	sb.WriteString("    extends:\n")
+	sb.WriteString("      file: docker-compose.yml\n")
+	sb.WriteString("      service: ai-engine\n")
+	sb.WriteString(fmt.Sprintf("    container_name: %s\n", Container))
+	sb.WriteString("    volumes:\n")
+	sb.WriteString(fmt.Sprintf("      - ./%s:/app/config/ai-agent.yaml:ro\n", ConfigFile))
+	sb.WriteString("    environment:\n")
+	sb.WriteString(fmt.Sprintf("      - AUDIOSOCKET_PORT=%d\n", o.AudioSocketPort))
+	sb.WriteString(fmt.Sprintf("      - EXTERNAL_MEDIA_RTP_PORT=%d\n", o.RTPPort))
+	sb.WriteString(fmt.Sprintf("      - HEALTH_BIND_PORT=%d\n", o.HealthPort))
+	sb.WriteString(fmt.Sprintf("      - ASTERISK_APP_NAME=%s\n", o.CanaryApp()))
+	return sb.String()
+}
+
+// GenerateDialplan renders a subroutine that sends Percent of calls to
+// the canary's Stasis app and the rest to the stable one. The global
+// AAVA_CANARY_PCT overrides Percent at runtime; a call the canary does
+// not take (stopped or restarting) goes to the stable app.
+func GenerateDialplan(o Options) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("; AI Voice Agent - %d%% of calls to the canary engine\n", o.Percent))
+	sb.WriteString(fmt.Sprintf("; Generated by: agent config deploy --canary %d%%\n", o.Percent))
+	sb.WriteString(fmt.Sprintf("; In the AI agent contexts replace Stasis(%s) with Gosub(%s,s,1)\n", o.AppName, DialplanContext))
+	sb.WriteString(fmt.Sprintf("[%s]\n", DialplanContext))
+	sb.WriteString(fmt.Sprintf("exten => s,1,Set(AAVA_PCT=${IF($[${LEN(${%s})} > 0]?${%s}:%d)})\n", PercentVariable, PercentVariable, o.Percent))
+	sb.WriteString(fmt.Sprintf(" same => n,Set(AAVA_APP=${IF($[${RAND(1,100)} <= ${AAVA_PCT}]?%s:%s)})\n", o.CanaryApp(), o.AppName))
+	sb.WriteString(" same => n,NoOp(AI Voice Agent - ${AAVA_APP} (canary ${AAVA_PCT}%))\n")
+	sb.WriteString(" same => n,Stasis(${AAVA_APP})\n")
+	sb.WriteString(fmt.Sprintf(" same => n,GotoIf($[\"${STASISSTATUS}\" != \"FAILED\" | \"${AAVA_APP}\" = \"%s\"]?done)\n", o.AppName))
+	sb.WriteString(fmt.Sprintf(" same => n,Stasis(%s)\n", o.AppName))
+	sb.WriteString(" same => n(done),Return()\n")
+	return sb.String()
+}
+
+// Write generates the compose override, the dialplan and the candidate
+// configuration under dir
+func Write(dir string, o Options, candidate []byte) error {
+	if err := os.WriteFile(filepath.Join(dir, ConfigFile), candidate, 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ComposeFile), []byte(GenerateCompose(o)), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, DialplanFile), []byte(GenerateDialplan(o)), 0644)
+}
+
+// Remove deletes the compose override and candidate configuration. The
+// dialplan is rewritten at 0% so contexts using Gosub(aava-canary,s,1)
+// keep working.
+func Remove(dir string, o Options) error {
+	for _, name := range []string{ComposeFile, ConfigFile} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	o.Percent = 0
+	return os.WriteFile(filepath.Join(dir, DialplanFile), []byte(GenerateDialplan(o)), 0644)
+}
+
+// Active reports whether a canary deployment's files are present in dir
+func Active(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ConfigFile))
+	return err == nil
+}
@@ -0,0 +1,119 @@
+package canary
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+)
+
+// Decisions on a canary
+const (
+	Promote  = "promote"
+	Rollback = "rollback"
+	Wait     = "wait"
+)
+
+// earlyFailures is how many failed canary calls allow a rollback before
+// MinCalls calls were seen
+const earlyFailures = 3
+
+// Stats summarizes the analyzed calls of one side
+type Stats struct {
+	Calls       int     `json:"calls"`
+	Failed      int     `json:"failed"`
+	FailureRate float64 `json:"failure_rate"`
+	Score       float64 `json:"quality_score"`
+	LatencyP95  float64 `json:"turn_latency_p95_ms"`
+}
+
+// Thresholds bound how much worse the canary may do than the stable
+// engine and still be promoted
+type Thresholds struct {
+	MinCalls int
+	// MaxFailureRise is in percentage points of the failure rate
+	MaxFailureRise float64
+	MaxScoreDrop   float64
+	// MaxLatencyRise is in milliseconds of the average per-call p95
+	MaxLatencyRise float64
+}
+
+// DefaultThresholds are the deploy command's defaults
+var DefaultThresholds = Thresholds{
+	MinCalls:       20,
+	MaxFailureRise: 5,
+	MaxScoreDrop:   10,
+	MaxLatencyRise: 500,
+}
+
+// failed reports whether a call failed: its status says so, or the
+// analysis found a critical problem
+func failed(row troubleshoot.CallRow) bool {
+	if row.Status == troubleshoot.CallFailed {
+		return true
+	}
+	for _, f := range row.Findings {
+		if f.Severity == troubleshoot.SeverityCritical {
+			return true
+		}
+	}
+	return false
+}
+
+// Summarize computes the stats of analyzed calls
+func Summarize(rows []troubleshoot.CallRow) Stats {
+	var s Stats
+	var score, latency float64
+	latencies := 0
+	for _, row := range rows {
+		s.Calls++
+		if failed(row) {
+			s.Failed++
+		}
+		score += row.QualityScore
+		if p95, err := strconv.ParseFloat(row.TurnLatencyP95Ms, 64); err == nil {
+			latency += p95
+			latencies++
+		}
+	}
+	if s.Calls > 0 {
+		s.FailureRate = 100 * float64(s.Failed) / float64(s.Calls)
+		s.Score = score / float64(s.Calls)
+	}
+	if latencies > 0 {
+		s.LatencyP95 = latency / float64(latencies)
+	}
+	return s
+}
+
+// Decide compares the canary with the stable engine. It rolls back as
+// soon as a threshold is exceeded with enough evidence, promotes once
+// the canary handled MinCalls calls within every threshold, and waits
+// otherwise. The reasons explain the decision.
+func Decide(stable, canary Stats, t Thresholds) (string, []string) {
+	var breaches []string
+	if rise := canary.FailureRate - stable.FailureRate; rise > t.MaxFailureRise && canary.Failed > 0 {
+		breaches = append(breaches, fmt.Sprintf("failure rate %.1f%% vs %.1f%% (+%.1f points, limit %.1f)", canary.FailureRate, stable.FailureRate, rise, t.MaxFailureRise))
+	}
+	// Quality and latency need calls on both sides to compare
+	if stable.Calls > 0 && canary.Calls > 0 {
+		if drop := stable.Score - canary.Score; drop > t.MaxScoreDrop {
+			breaches = append(breaches, fmt.Sprintf("quality score %.0f vs %.0f (-%.0f, limit %.0f)", canary.Score, stable.Score, drop, t.MaxScoreDrop))
+		}
+		if stable.LatencyP95 > 0 && canary.LatencyP95 > 0 {
+			if rise := canary.LatencyP95 - stable.LatencyP95; rise > t.MaxLatencyRise {
+				breaches = append(breaches, fmt.Sprintf("turn latency p95 %.0fms vs %.0fms (+%.0fms, limit %.0fms)", canary.LatencyP95, stable.LatencyP95, rise, t.MaxLatencyRise))
+			}
+		}
+	}
+
+	switch {
+	case canary.Calls >= t.MinCalls && len(breaches) > 0:
+		return Rollback, breaches
+	case canary.Failed >= earlyFailures && canary.FailureRate-stable.FailureRate > t.MaxFailureRise:
+		return Rollback, breaches
+	case canary.Calls >= t.MinCalls:
+		return Promote, []string{fmt.Sprintf("%d canary calls within every threshold", canary.Calls)}
+	}
+	return Wait, []string{fmt.Sprintf("%d of %d canary calls", canary.Calls, t.MinCalls)}
+}
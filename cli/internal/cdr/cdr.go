@@ -0,0 +1,290 @@
+// Package cdr reads Asterisk call detail records (cdr_csv's Master.csv)
+// and the RTP statistics Asterisk leaves in RTPAUDIOQOS, and estimates a
+// call's MOS from them.
+package cdr
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPath is where cdr_csv writes
+const DefaultPath = "/var/log/asterisk/cdr-csv/Master.csv"
+
+// Dispositions Asterisk records
+const (
+	Answered   = "ANSWERED"
+	NoAnswer   = "NO ANSWER"
+	Busy       = "BUSY"
+	Failed     = "FAILED"
+	Congestion = "CONGESTION"
+)
+
+// timeLayout is cdr_csv's start/answer/end format
+const timeLayout = "2006-01-02 15:04:05"
+
+// baseColumns are the columns cdr_csv always writes; uniqueid and
+// userfield follow with loguniqueid and loguserfield
+const baseColumns = 16
+
+var uniqueIDPattern = regexp.MustCompile(`^[0-9]+\.[0-9]+$`)
+
+// Record is one CDR
+type Record struct {
+	Src         string    `json:"src"`
+	Dst         string    `json:"dst"`
+	Context     string    `json:"dcontext"`
+	Channel     string    `json:"channel"`
+	DstChannel  string    `json:"dstchannel,omitempty"`
+	LastApp     string    `json:"lastapp,omitempty"`
+	Start       time.Time `json:"start"`
+	Answer      time.Time `json:"answer,omitempty"`
+	End         time.Time `json:"end"`
+	Duration    int       `json:"duration"`
+	Billsec     int       `json:"billsec"`
+	Disposition string    `json:"disposition"`
+	UniqueID    string    `json:"uniqueid,omitempty"`
+	UserField   string    `json:"userfield,omitempty"`
+}
+
+// Answered reports whether the call was answered
+func (r Record) Answered() bool {
+	return r.Disposition == Answered
+}
+
+// HangupCause is the Q.850 cause the dialplan stored in the userfield as
+// cause=${HANGUPCAUSE}; 0 when it did not
+func (r Record) HangupCause() int {
+	for _, part := range strings.FieldsFunc(r.UserField, userFieldSep) {
+		if value, ok := strings.CutPrefix(part, "cause="); ok {
+			n, _ := strconv.Atoi(value)
+			return n
+		}
+	}
+	return 0
+}
+
+// QoS reads the RTPAUDIOQOS the dialplan stored in the userfield
+func (r Record) QoS() (QoS, bool) {
+	return ParseQoS(r.UserField)
+}
+
+// userFieldSep splits the key=value pairs of a userfield
+func userFieldSep(r rune) bool {
+	return r == ';' || r == ',' || r == ' ' || r == '|'
+}
+
+// Parse reads cdr_csv records, whose times are in loc (UTC with
+// usegmtime=yes). Lines that are not records are skipped.
+func Parse(in io.Reader, loc *time.Location) ([]Record, error) {
+	reader := csv.NewReader(in)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	var records []Record
+	for {
+		fields, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return records, nil
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				continue
+			}
+			return records, err
+		}
+		if len(fields) < baseColumns {
+			continue
+		}
+		rec, ok := parseRecord(fields, loc)
+		if ok {
+			records = append(records, rec)
+		}
+	}
+}
+
+func parseRecord(f []string, loc *time.Location) (Record, bool) {
+	rec := Record{
+		Src:         f[1],
+		Dst:         f[2],
+		Context:     f[3],
+		Channel:     f[5],
+		DstChannel:  f[6],
+		LastApp:     f[7],
+		Disposition: f[14],
+	}
+	var err error
+	if rec.Start, err = time.ParseInLocation(timeLayout, f[9], loc); err != nil {
+		return Record{}, false
+	}
+	rec.Answer, _ = time.ParseInLocation(timeLayout, f[10], loc)
+	rec.End, _ = time.ParseInLocation(timeLayout, f[11], loc)
+	rec.Duration, _ = strconv.Atoi(f[12])
+	rec.Billsec, _ = strconv.Atoi(f[13])
+	extra := f[baseColumns:]
+	if len(extra) > 0 && uniqueIDPattern.MatchString(extra[0]) {
+		rec.UniqueID = extra[0]
+		extra = extra[1:]
+	}
+	if len(extra) > 0 {
+		rec.UserField = extra[0]
+	}
+	return rec, true
+}
+
+// Endpoint returns the endpoint of a channel name: "telnyx" for
+// PJSIP/telnyx-0000001a, "" for Local and other internal channels
+func Endpoint(channel string) string {
+	tech, rest, ok := strings.Cut(channel, "/")
+	if !ok {
+		return ""
+	}
+	switch strings.ToUpper(tech) {
+	case "PJSIP", "SIP", "IAX2", "DAHDI":
+	default:
+		return ""
+	}
+	if i := strings.LastIndex(rest, "-"); i > 0 {
+		rest = rest[:i]
+	}
+	return rest
+}
+
+// QoS are the RTP statistics of a call as Asterisk sets RTPAUDIOQOS at
+// hangup: packets lost and received in each direction, interarrival
+// jitter and round-trip time
+type QoS struct {
+	LocalLost  int
+	Received   int
+	RemoteLost int
+	Sent       int
+	RxJitterMs float64
+	TxJitterMs float64
+	RTTMs      float64
+}
+
+// ParseQoS reads an RTPAUDIOQOS value, e.g.
+// "ssrc=1;themssrc=2;lp=0;rxjitter=0.002;rxcount=1500;txjitter=0.001;txcount=1500;rlp=0;rtt=0.040",
+// wherever it appears in s (a userfield may carry other values)
+func ParseQoS(s string) (QoS, bool) {
+	if !strings.Contains(s, "rxcount=") {
+		return QoS{}, false
+	}
+	var q QoS
+	for _, part := range strings.FieldsFunc(s, userFieldSep) {
+		key, value, ok := strings.Cut(part, "=")
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "lp":
+			q.LocalLost = int(n)
+		case "rxcount":
+			q.Received = int(n)
+		case "rlp":
+			q.RemoteLost = int(n)
+		case "txcount":
+			q.Sent = int(n)
+		// Asterisk reports jitter and RTT in seconds
+		case "rxjitter":
+			q.RxJitterMs = n * 1000
+		case "txjitter":
+			q.TxJitterMs = n * 1000
+		case "rtt":
+			q.RTTMs = n * 1000
+		}
+	}
+	return q, q.Received > 0
+}
+
+// LossPct is the worse direction's packet loss in percent
+func (q QoS) LossPct() float64 {
+	loss := func(lost, total int) float64 {
+		if lost <= 0 || total+lost <= 0 {
+			return 0
+		}
+		return float64(lost) / float64(total+lost) * 100
+	}
+	return math.Max(loss(q.LocalLost, q.Received), loss(q.RemoteLost, q.Sent))
+}
+
+// MOS estimates the call's mean opinion score (1-4.5) with the
+// simplified E-model for G.711: the one-way delay (half the RTT, 20 ms
+// when unknown) plus twice the jitter and 10 ms of codec delay, and the
+// packet loss lower the R factor from 93.2
+func (q QoS) MOS() float64 {
+	delay := q.RTTMs / 2
+	if delay == 0 {
+		delay = 20
+	}
+	latency := delay + 2*math.Max(q.RxJitterMs, q.TxJitterMs) + 10
+	r := 93.2
+	if latency < 160 {
+		r -= latency / 40
+	} else {
+		r -= (latency - 120) / 10
+	}
+	r -= 2.5 * q.LossPct()
+	switch {
+	case r < 0:
+		return 1
+	case r > 100:
+		r = 100
+	}
+	return 1 + 0.035*r + 7e-6*r*(r-60)*(100-r)
+}
+
+// String renders the statistics for display
+func (q QoS) String() string {
+	return fmt.Sprintf("loss %.1f%%, jitter %.0fms, rtt %.0fms", q.LossPct(), math.Max(q.RxJitterMs, q.TxJitterMs), q.RTTMs)
+}
+
+// causeNames are the Q.850 causes Asterisk commonly reports
+var causeNames = map[int]string{
+	1:   "Unallocated number",
+	3:   "No route to destination",
+	16:  "Normal clearing",
+	17:  "User busy",
+	18:  "No user responding",
+	19:  "No answer",
+	21:  "Call rejected",
+	22:  "Number changed",
+	27:  "Destination out of order",
+	28:  "Invalid number format",
+	31:  "Normal, unspecified",
+	34:  "No circuit/channel available",
+	38:  "Network out of order",
+	41:  "Temporary failure",
+	42:  "Switching equipment congestion",
+	44:  "Requested channel not available",
+	47:  "Resource unavailable",
+	50:  "Facility not subscribed",
+	57:  "Bearer capability not authorized",
+	58:  "Bearer capability not available",
+	63:  "Service or option not available",
+	65:  "Bearer capability not implemented",
+	79:  "Service or option not implemented",
+	88:  "Incompatible destination",
+	102: "Recovery on timer expiry",
+	111: "Protocol error",
+	127: "Interworking, unspecified",
+}
+
+// CauseName names a Q.850 cause, e.g. "34 No circuit/channel available"
+func CauseName(code int) string {
+	if name, ok := causeNames[code]; ok {
+		return fmt.Sprintf("%d %s", code, name)
+	}
+	return strconv.Itoa(code)
+}
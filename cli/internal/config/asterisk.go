@@ -0,0 +1,299 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// pjsipTypes are the object types res_pjsip knows
+var pjsipTypes = map[string]bool{
+	"endpoint": true, "aor": true, "auth": true, "identify": true, "registration": true,
+	"transport": true, "global": true, "system": true, "domain_alias": true, "acl": true,
+	"contact": true, "outbound-publish": true, "inbound-publication": true,
+	"resource_list": true, "phoneprov": true,
+}
+
+var (
+	sectionPattern  = regexp.MustCompile(`^\[([^\]]+)\]\s*(?:\(([^)]*)\))?\s*$`)
+	priorityPattern = regexp.MustCompile(`^(?:[0-9]+|n|hint|[ns]?[+-][0-9]+)(?:\([A-Za-z0-9_-]+\))?$`)
+	stasisPattern   = regexp.MustCompile(`(?i)\bStasis\(([^,)]*)`)
+)
+
+// confLine is one setting of an Asterisk configuration file
+type confLine struct {
+	num   int
+	key   string
+	value string
+}
+
+// confSection is a [section] and the settings under it
+type confSection struct {
+	name     string
+	line     int
+	template bool
+	inherits []string
+	settings []confLine
+}
+
+// ValidateAsterisk checks an Asterisk configuration file the way
+// Asterisk would read it: section headers, settings outside a section,
+// #include targets and block comments, plus dialplan syntax for
+// extensions*.conf and object types for pjsip*.conf. A Stasis()
+// application other than appName is a warning.
+func ValidateAsterisk(path, appName string) (*ValidationResult, error) {
+	result := &ValidationResult{
+		Passed:   []string{},
+		Warnings: []string{},
+		Errors:   []string{},
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	defer f.Close()
+
+	var sections []*confSection
+	var current *confSection
+	inComment := 0
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for num := 1; scanner.Scan(); num++ {
+		line := scanner.Text()
+		// Block comments ;-- ... --; nest
+		if inComment > 0 || strings.Contains(line, ";--") {
+			line, inComment = stripBlockComments(line, inComment)
+		}
+		if i := strings.Index(line, ";"); i >= 0 && (i == 0 || line[i-1] != '\\') {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+			continue
+		case strings.HasPrefix(line, "#"):
+			validateDirective(path, num, line, result)
+			continue
+		case strings.HasPrefix(line, "["):
+			m := sectionPattern.FindStringSubmatch(line)
+			if m == nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: malformed section header %q", num, line))
+				current = nil
+				continue
+			}
+			current = &confSection{name: strings.TrimSpace(m[1]), line: num}
+			for _, opt := range strings.Split(m[2], ",") {
+				switch opt = strings.TrimSpace(opt); opt {
+				case "":
+				case "!":
+					current.template = true
+				case "+":
+				default:
+					current.inherits = append(current.inherits, opt)
+				}
+			}
+			sections = append(sections, current)
+			continue
+		}
+
+		sep := strings.Index(line, "=")
+		if sep <= 0 {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: expected 'key = value', got %q", num, line))
+			continue
+		}
+		if current == nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: setting outside any [section]", num))
+			continue
+		}
+		value := strings.TrimSpace(strings.TrimPrefix(line[sep+1:], ">"))
+		current.settings = append(current.settings, confLine{num: num, key: strings.TrimSpace(line[:sep]), value: value})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if inComment > 0 {
+		result.Errors = append(result.Errors, "block comment (;--) not closed before the end of the file")
+	}
+	if len(result.Errors) == 0 {
+		result.Passed = append(result.Passed, fmt.Sprintf("Syntax valid (%d sections)", len(sections)))
+	}
+
+	base := filepath.Base(path)
+	switch {
+	case strings.HasPrefix(base, "extensions"):
+		validateDialplan(sections, appName, result)
+	case strings.HasPrefix(base, "pjsip"):
+		validatePJSIP(sections, result)
+	}
+	return result, nil
+}
+
+// stripBlockComments removes ;-- --; comments from line, given the
+// nesting depth at its start
+func stripBlockComments(line string, depth int) (string, int) {
+	var out strings.Builder
+	for i := 0; i < len(line); i++ {
+		switch {
+		case strings.HasPrefix(line[i:], ";--"):
+			depth++
+			i += 2
+		case depth > 0 && strings.HasPrefix(line[i:], "--;"):
+			depth--
+			i += 2
+		case depth == 0:
+			out.WriteByte(line[i])
+		}
+	}
+	return out.String(), depth
+}
+
+// validateDirective checks #include and #tryinclude targets
+func validateDirective(path string, num int, line string, result *ValidationResult) {
+	fields := strings.Fields(line)
+	directive := fields[0]
+	switch directive {
+	case "#include", "#tryinclude":
+	case "#exec":
+		return
+	default:
+		result.Errors = append(result.Errors, fmt.Sprintf("line %d: unknown directive %s", num, directive))
+		return
+	}
+	if len(fields) < 2 {
+		result.Errors = append(result.Errors, fmt.Sprintf("line %d: %s without a file", num, directive))
+		return
+	}
+	target := strings.Trim(strings.Join(fields[1:], " "), `"<>`)
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	matches, _ := filepath.Glob(target)
+	if len(matches) == 0 && directive == "#include" {
+		result.Errors = append(result.Errors, fmt.Sprintf("line %d: #include %s: no such file", num, target))
+	}
+}
+
+// validateDialplan checks exten/same lines: extension, priority and
+// application, balanced parentheses, and each extension starting at
+// priority 1
+func validateDialplan(sections []*confSection, appName string, result *ValidationResult) {
+	extensions := 0
+	for _, s := range sections {
+		if s.name == "general" || s.name == "globals" {
+			continue
+		}
+		started := map[string]bool{}
+		last := ""
+		for _, l := range s.settings {
+			key := strings.ToLower(l.key)
+			if key != "exten" && key != "same" {
+				continue
+			}
+			var ext, prio, app string
+			if key == "exten" {
+				parts := strings.SplitN(l.value, ",", 3)
+				if len(parts) < 3 {
+					result.Errors = append(result.Errors, fmt.Sprintf("line %d: [%s] exten needs 'extension,priority,application'", l.num, s.name))
+					continue
+				}
+				ext, prio, app = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+				last = ext
+			} else {
+				parts := strings.SplitN(l.value, ",", 2)
+				if len(parts) < 2 || last == "" {
+					result.Errors = append(result.Errors, fmt.Sprintf("line %d: [%s] same needs a preceding exten and 'priority,application'", l.num, s.name))
+					continue
+				}
+				ext, prio, app = last, strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+			}
+			extensions++
+			if !priorityPattern.MatchString(prio) {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: [%s] invalid priority %q", l.num, s.name, prio))
+			}
+			if prio == "hint" {
+				continue
+			}
+			if !started[ext] && prio != "1" && !strings.HasPrefix(prio, "1(") {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: [%s] extension %s has no priority 1 (Asterisk ignores it)", l.num, s.name, ext))
+			}
+			started[ext] = true
+			if depth := parenDepth(app); depth != 0 {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: [%s] unbalanced parentheses in %s", l.num, s.name, app))
+			}
+			if m := stasisPattern.FindStringSubmatch(app); m != nil && appName != "" {
+				if name := strings.TrimSpace(m[1]); name != appName && !strings.Contains(name, "${") {
+					result.Warnings = append(result.Warnings, fmt.Sprintf("line %d: [%s] Stasis(%s) is not the engine's application %s (asterisk.app_name)", l.num, s.name, name, appName))
+				}
+			}
+		}
+	}
+	if extensions > 0 {
+		result.Passed = append(result.Passed, fmt.Sprintf("%d dialplan line(s) checked", extensions))
+	}
+}
+
+// parenDepth returns the open parentheses left at the end of s; dialplan
+// variables and functions count like any other
+func parenDepth(s string) int {
+	depth := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+	}
+	return depth
+}
+
+// validatePJSIP checks that every object has a known type, set in the
+// section or a template it inherits from
+func validatePJSIP(sections []*confSection, result *ValidationResult) {
+	typeOf := map[string]string{}
+	for _, s := range sections {
+		for _, l := range s.settings {
+			if l.key == "type" {
+				typeOf[s.name] = l.value
+			}
+		}
+		if typeOf[s.name] == "" {
+			for _, parent := range s.inherits {
+				if t := typeOf[parent]; t != "" {
+					typeOf[s.name] = t
+				}
+			}
+		}
+	}
+	objects := 0
+	for _, s := range sections {
+		if s.template {
+			continue
+		}
+		t := typeOf[s.name]
+		for _, l := range s.settings {
+			if l.key == "type" {
+				t = l.value
+			}
+		}
+		if t == "" {
+			if len(s.inherits) == 0 {
+				result.Errors = append(result.Errors, fmt.Sprintf("line %d: [%s] has no type=", s.line, s.name))
+			}
+			continue
+		}
+		if !pjsipTypes[t] {
+			result.Errors = append(result.Errors, fmt.Sprintf("line %d: [%s] unknown type=%s", s.line, s.name, t))
+			continue
+		}
+		objects++
+	}
+	if objects > 0 {
+		result.Passed = append(result.Passed, fmt.Sprintf("%d PJSIP object(s) typed", objects))
+	}
+}
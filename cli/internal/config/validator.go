@@ -274,3 +274,17 @@ func (v *Validator) AutoFix(result *ValidationResult) (int, error) {
 	
 	return fixed, nil
 }
+
+// ValidateYAML checks that a file parses as YAML, for files the engine
+// loads besides ai-agent.yaml (e.g. config/contexts/*.yaml)
+func ValidateYAML(path string) (*ValidationResult, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	var doc interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid YAML syntax: %w", err)
+	}
+	return &ValidationResult{Passed: []string{"YAML syntax valid"}, Warnings: []string{}, Errors: []string{}}, nil
+}
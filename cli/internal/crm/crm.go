@@ -0,0 +1,241 @@
+// Package crm logs call outcomes in HubSpot and Salesforce as call
+// activities on the contact whose phone number matches the caller.
+package crm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+)
+
+// CRM types
+const (
+	TypeHubSpot    = "hubspot"
+	TypeSalesforce = "salesforce"
+)
+
+// Types lists the supported CRMs
+var Types = []string{TypeHubSpot, TypeSalesforce}
+
+// ErrNoContact is returned when no contact has the caller's number
+var ErrNoContact = fmt.Errorf("no contact with the caller number")
+
+// CRM logs calls on contacts
+type CRM interface {
+	Name() string
+	// FindContact returns the ID of the contact with the phone number,
+	// ErrNoContact when there is none
+	FindContact(ctx context.Context, phone string) (string, error)
+	// LogCall records the call as an activity on the contact and returns
+	// the activity's ID
+	LogCall(ctx context.Context, contactID string, a Activity) (string, error)
+}
+
+// Activity is a call as the CRM timeline shows it
+type Activity struct {
+	troubleshoot.CallOutcome
+	Title         string
+	Body          string
+	TranscriptURL string
+}
+
+// Integration is a configured CRM with the routes it logs calls of
+type Integration struct {
+	CRM
+	cfg settings.CRM
+}
+
+// New creates the CRM integrations of the configuration
+func New(cfgs []settings.CRM) ([]*Integration, error) {
+	var out []*Integration
+	seen := make(map[string]bool)
+	for i, cfg := range cfgs {
+		var c CRM
+		var err error
+		if cfg.Name == "" {
+			cfg.Name = cfg.Type
+		}
+		switch cfg.Type {
+		case TypeHubSpot:
+			c, err = newHubSpot(cfg)
+		case TypeSalesforce:
+			c, err = newSalesforce(cfg)
+		default:
+			err = fmt.Errorf("unknown type %q (use %s)", cfg.Type, strings.Join(Types, " or "))
+		}
+		if err != nil {
+			return nil, fmt.Errorf("crm[%d]: %w", i, err)
+		}
+		if seen[cfg.Name] {
+			return nil, fmt.Errorf("crm[%d]: name %q is used twice (set name on each)", i, cfg.Name)
+		}
+		seen[cfg.Name] = true
+		out = append(out, &Integration{CRM: c, cfg: cfg})
+	}
+	return out, nil
+}
+
+// Logs reports whether the integration logs calls of the outcome's route
+func (in *Integration) Logs(o troubleshoot.CallOutcome) bool {
+	return o.Matches(in.cfg.DIDs, in.cfg.Contexts, in.cfg.Tenants)
+}
+
+// Activity renders an outcome as the activity to log
+func (in *Integration) Activity(o troubleshoot.CallOutcome) Activity {
+	a := Activity{CallOutcome: o}
+	if in.cfg.TranscriptURL != "" {
+		a.TranscriptURL = strings.Replace(in.cfg.TranscriptURL, "{call_id}", o.CallID, -1)
+	}
+
+	a.Title = "AI voice agent call"
+	if len(o.Intents) > 0 {
+		a.Title += ": " + strings.Join(o.Intents, ", ")
+	}
+	lines := []string{o.Summary, ""}
+	if o.Disposition != "" {
+		lines = append(lines, "Disposition: "+o.Disposition)
+	}
+	if len(o.Intents) > 0 {
+		lines = append(lines, "Intent: "+strings.Join(o.Intents, ", "))
+	}
+	if o.Dialed != "" {
+		lines = append(lines, "Dialed: "+o.Dialed)
+	}
+	lines = append(lines, "Call ID: "+o.CallID)
+	if a.TranscriptURL != "" {
+		lines = append(lines, "Transcript: "+a.TranscriptURL)
+	}
+	if in.cfg.Transcript && len(o.Transcript) > 0 {
+		lines = append(lines, "", "Transcript:")
+		for _, t := range o.Transcript {
+			who := "Agent"
+			if t.Role == troubleshoot.RoleUser {
+				who = "Caller"
+			}
+			lines = append(lines, who+": "+t.Text)
+		}
+	}
+	a.Body = strings.Join(lines, "\n")
+	return a
+}
+
+// phoneDigits returns the digits of a number
+func phoneDigits(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			b.WriteRune(c)
+		}
+	}
+	return b.String()
+}
+
+// doJSON sends a JSON request with a bearer token and decodes the reply
+func doJSON(ctx context.Context, client *http.Client, method, url, token string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		path := req.URL.Path
+		return fmt.Errorf("%s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// syncedKept bounds the call IDs remembered per CRM
+const syncedKept = 5000
+
+// State is the progress of 'agent crm sync'
+type State struct {
+	// Watermark is the start of the newest call synced
+	Watermark time.Time `json:"watermark"`
+	// Synced are the call IDs logged (or found without contact) per CRM
+	Synced map[string][]string `json:"synced,omitempty"`
+}
+
+func statePath() string {
+	return filepath.Join(settings.Dir(), "crm.json")
+}
+
+// LoadState reads the sync state; a missing file is a first sync
+func LoadState() (*State, error) {
+	state := &State{Synced: make(map[string][]string)}
+	data, err := os.ReadFile(statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: %w", statePath(), err)
+	}
+	if state.Synced == nil {
+		state.Synced = make(map[string][]string)
+	}
+	return state, nil
+}
+
+// Save writes the sync state
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(settings.Dir(), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(statePath(), data, 0600)
+}
+
+// Done reports whether a call was already synced to the CRM
+func (s *State) Done(crm, callID string) bool {
+	for _, id := range s.Synced[crm] {
+		if id == callID {
+			return true
+		}
+	}
+	return false
+}
+
+// MarkDone records a call as synced to the CRM
+func (s *State) MarkDone(crm, callID string) {
+	ids := append(s.Synced[crm], callID)
+	if len(ids) > syncedKept {
+		ids = ids[len(ids)-syncedKept:]
+	}
+	s.Synced[crm] = ids
+}
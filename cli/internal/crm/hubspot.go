@@ -0,0 +1,107 @@
+package crm
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// hubspotAPI is the HubSpot API base URL
+const hubspotAPI = "https://api.hubapi.com"
+
+// hubspotCallToContact is HubSpot's association type of a call with a contact
+const hubspotCallToContact = 194
+
+// hubSpot logs calls as call engagements through a private app token
+// with the crm.objects.contacts.read and .write scopes
+type hubSpot struct {
+	name    string
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newHubSpot(cfg settings.CRM) (*hubSpot, error) {
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("hubspot: token (private app access token) is required")
+	}
+	return &hubSpot{
+		name:    cfg.Name,
+		baseURL: hubspotAPI,
+		token:   cfg.Token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (h *hubSpot) Name() string {
+	return h.name
+}
+
+// FindContact searches the contacts' phone properties, first for the
+// number as given, then for its national digits
+func (h *hubSpot) FindContact(ctx context.Context, phone string) (string, error) {
+	digits := phoneDigits(phone)
+	if len(digits) < 4 {
+		return "", ErrNoContact
+	}
+	queries := []string{phone}
+	if len(digits) > 10 {
+		queries = append(queries, digits[len(digits)-10:])
+	}
+	for _, q := range queries {
+		var result struct {
+			Results []struct {
+				ID string `json:"id"`
+			} `json:"results"`
+		}
+		err := doJSON(ctx, h.http, "POST", h.baseURL+"/crm/v3/objects/contacts/search", h.token, map[string]interface{}{
+			"query":      q,
+			"limit":      1,
+			"properties": []string{"phone", "mobilephone"},
+		}, &result)
+		if err != nil {
+			return "", fmt.Errorf("hubspot: %w", err)
+		}
+		if len(result.Results) > 0 {
+			return result.Results[0].ID, nil
+		}
+	}
+	return "", ErrNoContact
+}
+
+// LogCall creates a completed inbound call engagement on the contact
+func (h *hubSpot) LogCall(ctx context.Context, contactID string, a Activity) (string, error) {
+	props := map[string]string{
+		"hs_timestamp":        a.Start.UTC().Format(time.RFC3339),
+		"hs_call_title":       a.Title,
+		"hs_call_body":        strings.Replace(html.EscapeString(a.Body), "\n", "<br>", -1),
+		"hs_call_direction":   "INBOUND",
+		"hs_call_status":      "COMPLETED",
+		"hs_call_duration":    strconv.FormatInt(int64(a.DurationSeconds*1000), 10),
+		"hs_call_from_number": a.CallerNumber,
+		"hs_call_to_number":   a.Dialed,
+	}
+	body := map[string]interface{}{
+		"properties": props,
+		"associations": []map[string]interface{}{{
+			"to": map[string]string{"id": contactID},
+			"types": []map[string]interface{}{{
+				"associationCategory": "HUBSPOT_DEFINED",
+				"associationTypeId":   hubspotCallToContact,
+			}},
+		}},
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := doJSON(ctx, h.http, "POST", h.baseURL+"/crm/v3/objects/calls", h.token, body, &created); err != nil {
+		return "", fmt.Errorf("hubspot: %w", err)
+	}
+	return created.ID, nil
+}
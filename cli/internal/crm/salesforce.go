@@ -0,0 +1,155 @@
+package crm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// salesforceAPIVersion is the REST API version used
+const salesforceAPIVersion = "v59.0"
+
+// salesforceSubjectLen is the length limit of a Task subject
+const salesforceSubjectLen = 255
+
+// salesforce logs calls as completed call Tasks on the contact
+type salesforce struct {
+	name         string
+	instanceURL  string
+	token        string
+	clientID     string
+	clientSecret string
+	http         *http.Client
+}
+
+func newSalesforce(cfg settings.CRM) (*salesforce, error) {
+	if cfg.InstanceURL == "" {
+		return nil, fmt.Errorf("salesforce: instance_url is required")
+	}
+	if cfg.Token == "" && (cfg.ClientID == "" || cfg.ClientSecret == "") {
+		return nil, fmt.Errorf("salesforce: token, or client_id and client_secret, are required")
+	}
+	return &salesforce{
+		name:         cfg.Name,
+		instanceURL:  strings.TrimRight(cfg.InstanceURL, "/"),
+		token:        cfg.Token,
+		clientID:     cfg.ClientID,
+		clientSecret: cfg.ClientSecret,
+		http:         &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+func (s *salesforce) Name() string {
+	return s.name
+}
+
+// accessToken returns the fixed token, or gets one by the OAuth client
+// credentials flow of the connected app
+func (s *salesforce) accessToken(ctx context.Context) (string, error) {
+	if s.token != "" {
+		return s.token, nil
+	}
+	form := url.Values{
+		"grant_type":    {"client_credentials"},
+		"client_id":     {s.clientID},
+		"client_secret": {s.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.instanceURL+"/services/oauth2/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("salesforce token: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var token struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return "", fmt.Errorf("salesforce token: %w", err)
+	}
+	// Tokens of the flow live for the session timeout; one sync is shorter
+	s.token = token.AccessToken
+	return s.token, nil
+}
+
+// FindContact searches the phone fields of contacts with SOSL
+func (s *salesforce) FindContact(ctx context.Context, phone string) (string, error) {
+	digits := phoneDigits(phone)
+	if len(digits) < 4 {
+		return "", ErrNoContact
+	}
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	// SOSL matches phone fields on their digits; the national part also
+	// finds numbers stored without the country code
+	terms := []string{digits}
+	if len(digits) > 10 {
+		terms = append(terms, digits[len(digits)-10:])
+	}
+	for _, term := range terms {
+		q := fmt.Sprintf("FIND {%s} IN PHONE FIELDS RETURNING Contact(Id) LIMIT 1", term)
+		var result struct {
+			SearchRecords []struct {
+				ID string `json:"Id"`
+			} `json:"searchRecords"`
+		}
+		endpoint := fmt.Sprintf("%s/services/data/%s/search/?q=%s", s.instanceURL, salesforceAPIVersion, url.QueryEscape(q))
+		if err := doJSON(ctx, s.http, "GET", endpoint, token, nil, &result); err != nil {
+			return "", fmt.Errorf("salesforce: %w", err)
+		}
+		if len(result.SearchRecords) > 0 {
+			return result.SearchRecords[0].ID, nil
+		}
+	}
+	return "", ErrNoContact
+}
+
+// LogCall creates a completed inbound call Task on the contact
+func (s *salesforce) LogCall(ctx context.Context, contactID string, a Activity) (string, error) {
+	token, err := s.accessToken(ctx)
+	if err != nil {
+		return "", err
+	}
+	subject := a.Title
+	if len(subject) > salesforceSubjectLen {
+		subject = subject[:salesforceSubjectLen]
+	}
+	task := map[string]interface{}{
+		"WhoId":                 contactID,
+		"Subject":               subject,
+		"Description":           a.Body,
+		"Status":                "Completed",
+		"TaskSubtype":           "Call",
+		"CallType":              "Inbound",
+		"CallDurationInSeconds": int(a.DurationSeconds),
+		"CallObject":            a.CallID,
+		"ActivityDate":          a.Start.UTC().Format("2006-01-02"),
+	}
+	if a.Disposition != "" {
+		task["CallDisposition"] = a.Disposition
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	endpoint := fmt.Sprintf("%s/services/data/%s/sobjects/Task", s.instanceURL, salesforceAPIVersion)
+	if err := doJSON(ctx, s.http, "POST", endpoint, token, task, &created); err != nil {
+		return "", fmt.Errorf("salesforce: %w", err)
+	}
+	return created.ID, nil
+}
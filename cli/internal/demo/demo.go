@@ -3,20 +3,20 @@ package demo
 import (
 	"context"
 	"fmt"
-	"net"
 	"os"
-	"os/exec"
 	"strings"
 	"time"
 
-	"github.com/fatih/color"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
 )
 
 var (
-	successColor = color.New(color.FgGreen)
-	errorColor   = color.New(color.FgRed)
-	warningColor = color.New(color.FgYellow)
-	infoColor    = color.New(color.FgBlue)
+	successColor = theme.Success
+	errorColor   = theme.Error
+	warningColor = theme.Warning
+	infoColor    = theme.Info
 )
 
 // Runner orchestrates demo tests
@@ -123,9 +123,12 @@ func (r *Runner) testDocker() error {
 		infoColor.Printf("  → Checking Docker daemon...\n")
 	}
 	
-	cmd := exec.Command("docker", "info")
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("Docker daemon not running")
+	client, err := docker.Default()
+	if err != nil {
+		return err
+	}
+	if err := client.Ping(r.ctx); err != nil {
+		return fmt.Errorf("Docker daemon not running (%s)", client.Host())
 	}
 	
 	if r.verbose {
@@ -140,18 +143,20 @@ func (r *Runner) testContainerHealth() error {
 		infoColor.Printf("  → Checking ai_engine container...\n")
 	}
 	
-	cmd := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Status}}", "--filter", "name=ai_engine")
-	output, err := cmd.Output()
+	client, err := docker.Default()
 	if err != nil {
-		return fmt.Errorf("failed to check container status")
+		return err
 	}
-	
-	status := strings.TrimSpace(string(output))
-	if status == "" {
+	info, err := client.Inspect(r.ctx, "ai_engine")
+	if docker.IsNotFound(err) {
 		return fmt.Errorf("ai_engine container not found")
 	}
+	if err != nil {
+		return fmt.Errorf("failed to check container status")
+	}
 	
-	if !strings.Contains(status, "Up") {
+	status := info.State.Status
+	if !info.State.Running {
 		return fmt.Errorf("ai_engine container not running: %s", status)
 	}
 	
@@ -167,7 +172,9 @@ func (r *Runner) testAudioSocket() error {
 		infoColor.Printf("  → Checking AudioSocket on port 8090...\n")
 	}
 	
-	conn, err := net.DialTimeout("tcp", "127.0.0.1:8090", 2*time.Second)
+	ctx, cancel := context.WithTimeout(r.ctx, 2*time.Second)
+	defer cancel()
+	conn, err := remote.Dial(ctx, "tcp", "127.0.0.1:8090")
 	if err != nil {
 		return fmt.Errorf("AudioSocket not listening on port 8090")
 	}
@@ -305,8 +312,11 @@ func (r *Runner) testLogs() error {
 		infoColor.Printf("  → Checking recent container logs...\n")
 	}
 	
-	cmd := exec.Command("docker", "logs", "--since", "5m", "ai_engine")
-	output, err := cmd.CombinedOutput()
+	var output []byte
+	client, err := docker.Default()
+	if err == nil {
+		output, err = client.LogsBytes(r.ctx, "ai_engine", docker.LogsOptions{Since: time.Now().Add(-5 * time.Minute)})
+	}
 	if err != nil {
 		return fmt.Errorf("warning: could not read container logs")
 	}
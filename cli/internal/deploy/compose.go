@@ -0,0 +1,270 @@
+package deploy
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Compose file names, relative to the project directory. docker compose
+// merges the override file automatically, so user changes there survive
+// regeneration.
+const (
+	ComposeFile  = "docker-compose.yml"
+	OverrideFile = "docker-compose.override.yml"
+)
+
+// composeMarker is the first line of a generated compose file
+const composeMarker = "# Generated by: agent compose generate"
+
+// ComposeOptions controls the generated compose file
+type ComposeOptions struct {
+	AdminUI bool
+	// GPU reserves an NVIDIA GPU for local_ai_server
+	GPU bool
+}
+
+// DefaultComposeOptions derives the options from the profile: the GPU
+// is used when .env offloads LLM layers to it
+func DefaultComposeOptions(p *Profile) ComposeOptions {
+	layers := p.Env["LOCAL_LLM_GPU_LAYERS"]
+	return ComposeOptions{
+		AdminUI: true,
+		GPU:     layers != "" && layers != "0",
+	}
+}
+
+// IsGenerated reports whether a compose file was written by GenerateCompose
+func IsGenerated(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(composeMarker))
+}
+
+// localBuildArgs maps local backends to the local_ai_server build args
+// that install them
+var localBuildArgs = map[string]string{
+	"kroko":          "INCLUDE_KROKO_EMBEDDED",
+	"faster_whisper": "INCLUDE_FASTER_WHISPER",
+	"whisper_cpp":    "INCLUDE_WHISPER_CPP",
+	"melotts":        "INCLUDE_MELOTTS",
+}
+
+// GenerateCompose renders docker-compose.yml for the profile: ai-engine,
+// local-ai-server when a used provider is local (built with the backends
+// .env selects), and admin-ui. Values stay ${VAR:-default} references so
+// .env keeps working.
+func GenerateCompose(p *Profile, o ComposeOptions) string {
+	var sb strings.Builder
+	w := func(s string) { sb.WriteString(s) }
+
+	w(composeMarker + "\n")
+	w("#\n")
+	w("# Regenerate after changing config/ai-agent.yaml or .env. Do not edit:\n")
+	w("# put changes in " + OverrideFile + ", which docker compose merges\n")
+	w("# automatically and which is never regenerated.\n")
+	w("#\n")
+	w(fmt.Sprintf("# Providers: %s\n", strings.Join(p.Providers, ", ")))
+	w("#\n")
+	w("# Host networking: 127.0.0.1 reaches Asterisk, no port mapping needed.\n")
+	w(fmt.Sprintf("# AudioSocket %d, ExternalMedia RTP %d, health/metrics %d.\n", p.AudioSocketPort, p.RTPPort, p.HealthPort))
+	w("#\n")
+	w("# PERMISSION ALIGNMENT:\n")
+	w("# If your Asterisk uses a different GID than 995 (FreePBX default):\n")
+	w("#   export ASTERISK_GID=$(id -g asterisk)\n")
+	w("#   docker compose build ai-engine\n")
+	w("\n")
+	w("services:\n")
+	w(`  ai-engine:
+    image: asterisk-ai-voice-agent-ai-engine:latest
+    pull_policy: build
+    build:
+      context: .
+      dockerfile: Dockerfile
+      args:
+        ASTERISK_GID: ${ASTERISK_GID:-995}
+    container_name: ai_engine
+    user: "appuser"
+    network_mode: host
+    volumes:
+      - ./src:/app/src
+      - ./main.py:/app/main.py
+      - ./config:/app/config
+      - ./scripts:/app/scripts
+      - ./models:/app/models
+      - ./asterisk_media:/mnt/asterisk_media
+      - ./data:/app/data
+    env_file:
+      - .env
+    environment:
+      - PYTHONPATH=/app
+      - PYTHONUNBUFFERED=1
+      - TZ=${TZ:-America/Phoenix}
+      # Enable health endpoint access from other containers (admin-ui needs /sessions/stats)
+      - HEALTH_BIND_HOST=0.0.0.0
+      - ASTERISK_HOST=${ASTERISK_HOST:-127.0.0.1}
+`)
+	if p.LocalAI {
+		w("    depends_on:\n")
+		w("      local-ai-server:\n")
+		w("        condition: service_started\n")
+	}
+	w(`    tty: true
+    stdin_open: true
+    restart: unless-stopped
+`)
+
+	if p.LocalAI {
+		w("\n")
+		w(`  local-ai-server:
+    image: asterisk-ai-voice-agent-local-ai-server:latest
+    pull_policy: build
+    build:
+      context: ./local_ai_server
+      dockerfile: Dockerfile
+      args:
+`)
+		for _, backend := range []string{"kroko", "faster_whisper", "whisper_cpp", "melotts"} {
+			arg := localBuildArgs[backend]
+			selected := p.Env["LOCAL_STT_BACKEND"] == backend || p.Env["LOCAL_TTS_BACKEND"] == backend
+			w(fmt.Sprintf("        - %s=${%s:-%t}\n", arg, arg, selected))
+		}
+		w(`    container_name: local_ai_server
+    network_mode: host
+    env_file:
+      - .env
+    volumes:
+      - ./models:/app/models
+    environment:
+      - PYTHONUNBUFFERED=1
+      - LOCAL_LOG_LEVEL=${LOCAL_LOG_LEVEL:-INFO}
+      - LOCAL_DEBUG=${LOCAL_DEBUG:-0}
+      # STT Configuration
+      - LOCAL_STT_BACKEND=${LOCAL_STT_BACKEND:-vosk}
+      - LOCAL_STT_MODEL_PATH=${LOCAL_STT_MODEL_PATH:-/app/models/stt/vosk-model-en-us-0.22}
+      - LOCAL_STT_IDLE_MS=${LOCAL_STT_IDLE_MS:-5000}
+      # LLM Configuration
+      - LOCAL_LLM_MODEL_PATH=${LOCAL_LLM_MODEL_PATH:-/app/models/llm/phi-3-mini-4k-instruct.Q4_K_M.gguf}
+      - LOCAL_LLM_THREADS=${LOCAL_LLM_THREADS:-16}
+      - LOCAL_LLM_CONTEXT=${LOCAL_LLM_CONTEXT:-768}
+      - LOCAL_LLM_BATCH=${LOCAL_LLM_BATCH:-128}
+      - LOCAL_LLM_MAX_TOKENS=${LOCAL_LLM_MAX_TOKENS:-64}
+      - LOCAL_LLM_TEMPERATURE=${LOCAL_LLM_TEMPERATURE:-0.4}
+      - LOCAL_LLM_TOP_P=${LOCAL_LLM_TOP_P:-0.85}
+      - LOCAL_LLM_REPEAT_PENALTY=${LOCAL_LLM_REPEAT_PENALTY:-1.05}
+      - LOCAL_LLM_USE_MLOCK=${LOCAL_LLM_USE_MLOCK:-0}
+      - LOCAL_LLM_INFER_TIMEOUT_SEC=${LOCAL_LLM_INFER_TIMEOUT_SEC:-30}
+      # GPU: 0=CPU only, -1=auto-detect, N=specific layers
+      - LOCAL_LLM_GPU_LAYERS=${LOCAL_LLM_GPU_LAYERS:-0}
+      # TTS Configuration
+      - LOCAL_TTS_BACKEND=${LOCAL_TTS_BACKEND:-piper}
+      - LOCAL_TTS_MODEL_PATH=${LOCAL_TTS_MODEL_PATH:-/app/models/tts/en_US-lessac-medium.onnx}
+      - KOKORO_MODE=${KOKORO_MODE:-local}
+      - KOKORO_API_BASE_URL=${KOKORO_API_BASE_URL:-https://voice-generator.pages.dev/api/v1}
+      - KOKORO_API_KEY=${KOKORO_API_KEY:-}
+      - KOKORO_API_MODEL=${KOKORO_API_MODEL:-model}
+      - KOKORO_VOICE=${KOKORO_VOICE:-af_heart}
+      - LOCAL_LLM_SYSTEM_PROMPT=${LOCAL_LLM_SYSTEM_PROMPT:-You are a helpful AI voice assistant. When the caller wants to end the call or says goodbye, output <tool_call>{"name":"hangup_call","arguments":{"farewell":"Goodbye, have a great day!"}}</tool_call> and say a brief farewell. When the caller asks to email the transcript, output <tool_call>{"name":"request_transcript","arguments":{"email":"caller@example.com"}}</tool_call>. Always provide a spoken response.}
+    tty: true
+    stdin_open: true
+    restart: unless-stopped
+`)
+		if o.GPU {
+			w(`    deploy:
+      resources:
+        reservations:
+          devices:
+            - driver: nvidia
+              count: 1
+              capabilities: [gpu]
+`)
+		}
+		w(`    healthcheck:
+      test:
+        - CMD-SHELL
+        - |
+          python - <<'PY'
+          import asyncio
+          import json
+          import os
+          import websockets
+
+          async def main():
+              port = os.getenv("LOCAL_WS_PORT", "8765")
+              token = (os.getenv("LOCAL_WS_AUTH_TOKEN", "") or "").strip()
+              uri = f"ws://127.0.0.1:{port}"
+
+              ws = await websockets.connect(
+                  uri,
+                  ping_interval=None,
+                  ping_timeout=None,
+                  close_timeout=2,
+                  max_size=None,
+              )
+              try:
+                  if token:
+                      await ws.send(json.dumps({"type": "auth", "auth_token": token}))
+                      raw = await ws.recv()
+                      if isinstance(raw, (bytes, bytearray)):
+                          raise RuntimeError("Unexpected binary auth response")
+                      data = json.loads(raw)
+                      if data.get("type") != "auth_response" or data.get("status") != "ok":
+                          raise RuntimeError(f"Auth rejected: {data}")
+
+                  await ws.send(json.dumps({"type": "status"}))
+                  raw = await ws.recv()
+                  if isinstance(raw, (bytes, bytearray)):
+                      raise RuntimeError("Unexpected binary status response")
+                  data = json.loads(raw)
+                  if data.get("type") != "status_response" or data.get("status") != "ok":
+                      raise RuntimeError(f"Unexpected status response: {data}")
+              finally:
+                  await ws.close()
+
+          asyncio.run(main())
+          PY
+      interval: 60s
+      timeout: 5s
+      retries: 180
+      start_period: 120s
+`)
+	}
+
+	if o.AdminUI {
+		w("\n")
+		w(`  admin-ui:
+    image: asterisk-ai-voice-agent-admin-ui:latest
+    pull_policy: build
+    build:
+      context: ./admin_ui
+      dockerfile: Dockerfile
+    container_name: admin_ui
+    network_mode: host
+    volumes:
+      - ./:/app/project
+      - ${DOCKER_SOCK:-/var/run/docker.sock}:/var/run/docker.sock
+      - /etc/os-release:/host/etc/os-release:ro
+      - ./data:/app/data
+    env_file:
+      - .env
+    environment:
+      - PROJECT_ROOT=/app/project
+      - UVICORN_HOST=${UVICORN_HOST:-0.0.0.0}
+      - UVICORN_PORT=${UVICORN_PORT:-3003}
+      - JWT_SECRET=${JWT_SECRET:-}
+      - TZ=${TZ:-America/Phoenix}
+    restart: unless-stopped
+`)
+	}
+	return sb.String()
+}
+
+// OverrideStub is written to OverrideFile when it does not exist yet
+const OverrideStub = `# Local changes to docker-compose.yml. docker compose merges this file
+# automatically; 'agent compose generate' never touches it.
+#
+# Example:
+#   services:
+#     ai-engine:
+#       environment:
+#         - LOG_LEVEL=debug
+services: {}
+`
@@ -0,0 +1,615 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// K8sOptions controls the rendered Kubernetes deployment
+type K8sOptions struct {
+	Name      string
+	Namespace string
+	// Concurrency is the expected number of simultaneous calls; resource
+	// requests are sized from it
+	Concurrency   int
+	EngineImage   string
+	LocalAIImage  string
+	Asterisk      bool
+	AsteriskImage string
+	// MediaHostPath is the node directory Asterisk plays generated audio
+	// from (its sounds directory); the engine writes to ai-generated/ in it
+	MediaHostPath      string
+	AsteriskConfigPath string
+	StorageClass       string
+}
+
+// DefaultK8sOptions returns options matching the compose deployment
+func DefaultK8sOptions() K8sOptions {
+	return K8sOptions{
+		Name:               "aava",
+		Namespace:          "default",
+		Concurrency:        10,
+		EngineImage:        "asterisk-ai-voice-agent-ai-engine:latest",
+		LocalAIImage:       "asterisk-ai-voice-agent-local-ai-server:latest",
+		AsteriskImage:      "andrius/asterisk:latest",
+		MediaHostPath:      "/var/lib/asterisk/sounds",
+		AsteriskConfigPath: "/etc/asterisk",
+	}
+}
+
+// Resources is a container's requests and limits
+type Resources struct {
+	CPURequest    string
+	MemoryRequest string
+	CPULimit      string
+	MemoryLimit   string
+}
+
+// EngineResources sizes ai-engine for calls concurrent calls. Each call
+// holds audio buffers, a VAD and provider sockets; the base covers the
+// interpreter and ARI/health servers.
+func EngineResources(calls int) Resources {
+	cpu := 250 + 75*calls
+	mem := 384 + 48*calls
+	return Resources{
+		CPURequest:    fmt.Sprintf("%dm", cpu),
+		MemoryRequest: fmt.Sprintf("%dMi", mem),
+		CPULimit:      fmt.Sprintf("%dm", cpu*2),
+		MemoryLimit:   fmt.Sprintf("%dMi", mem*3/2),
+	}
+}
+
+// LocalAIResources sizes local_ai_server for calls concurrent calls. The
+// base holds the STT, LLM and TTS models; each call adds a recognizer and
+// an LLM context.
+func LocalAIResources(calls int) Resources {
+	cpu := 2000 + 500*calls
+	mem := 4096 + 256*calls
+	return Resources{
+		CPURequest:    fmt.Sprintf("%dm", cpu),
+		MemoryRequest: fmt.Sprintf("%dMi", mem),
+		CPULimit:      fmt.Sprintf("%dm", cpu*2),
+		MemoryLimit:   fmt.Sprintf("%dMi", mem*3/2),
+	}
+}
+
+// k8sData fills the manifest templates. Every field is inserted verbatim:
+// literal YAML for plain manifests, or a Helm expression for the chart.
+type k8sData struct {
+	Name, Namespace                 string
+	EngineImage                     string
+	LocalAIImage                    string
+	AsteriskImage                   string
+	HealthPort                      string
+	AudioSocketPort                 string
+	RTPPort                         string
+	MediaHostPath                   string
+	AsteriskConfigPath              string
+	ConfigChecksum                  string
+	ConfigData, EnvData, SecretData string
+	EngineResources                 string
+	LocalAIResources                string
+	AsteriskResources               string
+	DataStorage, ModelsStorage      string
+	StorageClass                    string
+	IfLocalAI, IfAsterisk, End      string
+}
+
+// k8sTemplates are rendered in order; local-ai and asterisk are optional
+var k8sTemplates = []struct {
+	file, tmpl string
+}{
+	{"config.yaml", configTmpl},
+	{"secret.yaml", secretTmpl},
+	{"storage.yaml", storageTmpl},
+	{"ai-engine.yaml", engineTmpl},
+	{"local-ai-server.yaml", localAITmpl},
+	{"asterisk.yaml", asteriskTmpl},
+}
+
+// RenderManifests renders plain Kubernetes manifests, one file per
+// component, with the profile's config and keys filled in
+func RenderManifests(p *Profile, o K8sOptions) (map[string]string, error) {
+	sum := sha256.Sum256(p.Config)
+	d := k8sData{
+		Name:               o.Name,
+		Namespace:          o.Namespace,
+		EngineImage:        strconv.Quote(o.EngineImage),
+		LocalAIImage:       strconv.Quote(o.LocalAIImage),
+		AsteriskImage:      strconv.Quote(o.AsteriskImage),
+		HealthPort:         strconv.Itoa(p.HealthPort),
+		AudioSocketPort:    strconv.Itoa(p.AudioSocketPort),
+		RTPPort:            strconv.Itoa(p.RTPPort),
+		MediaHostPath:      strconv.Quote(o.MediaHostPath),
+		AsteriskConfigPath: strconv.Quote(o.AsteriskConfigPath),
+		ConfigChecksum:     hex.EncodeToString(sum[:]),
+		ConfigData:         indent(string(p.Config), "    "),
+		EnvData:            yamlMap(p.Env, "  "),
+		SecretData:         yamlMap(p.Secrets, "  "),
+		EngineResources:    resourcesYAML(EngineResources(o.Concurrency), "            "),
+		LocalAIResources:   resourcesYAML(LocalAIResources(o.Concurrency), "            "),
+		AsteriskResources:  resourcesYAML(asteriskResources(o.Concurrency), "            "),
+		DataStorage:        "1Gi",
+		ModelsStorage:      "20Gi",
+	}
+	if o.StorageClass != "" {
+		d.StorageClass = "\n  storageClassName: " + strconv.Quote(o.StorageClass)
+	}
+
+	files := make(map[string]string)
+	for _, t := range k8sTemplates {
+		if t.file == "local-ai-server.yaml" && !p.LocalAI {
+			continue
+		}
+		if t.file == "asterisk.yaml" && !o.Asterisk {
+			continue
+		}
+		out, err := render(t.tmpl, d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.file, err)
+		}
+		files[t.file] = out
+	}
+	if !p.LocalAI {
+		// The models claim is only used by local_ai_server
+		files["storage.yaml"] = strings.SplitN(files["storage.yaml"], "\n---\n", 2)[0] + "\n"
+	}
+	return files, nil
+}
+
+// RenderChart renders a Helm chart: the templates read values.yaml, which
+// is pre-filled from the profile, and the chart carries ai-agent.yaml in
+// files/
+func RenderChart(p *Profile, o K8sOptions) (map[string]string, error) {
+	d := k8sData{
+		Name:               "{{ .Release.Name }}",
+		Namespace:          "{{ .Release.Namespace }}",
+		EngineImage:        "{{ .Values.engine.image | quote }}",
+		LocalAIImage:       "{{ .Values.localAI.image | quote }}",
+		AsteriskImage:      "{{ .Values.asterisk.image | quote }}",
+		HealthPort:         "{{ .Values.engine.healthPort }}",
+		AudioSocketPort:    "{{ .Values.engine.audioSocketPort }}",
+		RTPPort:            "{{ .Values.engine.rtpPort }}",
+		MediaHostPath:      "{{ .Values.mediaHostPath | quote }}",
+		AsteriskConfigPath: "{{ .Values.asterisk.configPath | quote }}",
+		ConfigChecksum:     `{{ .Files.Get "files/ai-agent.yaml" | sha256sum }}`,
+		ConfigData:         `    {{- .Files.Get "files/ai-agent.yaml" | nindent 4 }}`,
+		EnvData:            "\n{{- range $k, $v := .Values.env }}\n  {{ $k }}: {{ $v | quote }}\n{{- end }}",
+		SecretData:         "\n{{- range $k, $v := .Values.secrets }}\n  {{ $k }}: {{ $v | quote }}\n{{- end }}",
+		EngineResources:    "{{- toYaml .Values.engine.resources | nindent 12 }}",
+		LocalAIResources:   "{{- toYaml .Values.localAI.resources | nindent 12 }}",
+		AsteriskResources:  "{{- toYaml .Values.asterisk.resources | nindent 12 }}",
+		DataStorage:        "{{ .Values.storage.data }}",
+		ModelsStorage:      "{{ .Values.storage.models }}",
+		StorageClass:       "\n  {{- with .Values.storage.className }}\n  storageClassName: {{ . | quote }}\n  {{- end }}",
+		IfLocalAI:          "{{- if .Values.localAI.enabled }}\n",
+		IfAsterisk:         "{{- if .Values.asterisk.enabled }}\n",
+		End:                "{{- end }}\n",
+	}
+
+	files := map[string]string{
+		"Chart.yaml":          chartYAML,
+		"values.yaml":         chartValues(p, o),
+		"files/ai-agent.yaml": string(p.Config),
+	}
+	for _, t := range k8sTemplates {
+		out, err := render(t.tmpl, d)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.file, err)
+		}
+		if t.file == "storage.yaml" {
+			// Wrap the models claim in the local AI condition
+			parts := strings.SplitN(out, "\n---\n", 2)
+			out = parts[0] + "\n" + d.IfLocalAI + "---\n" + parts[1] + d.End
+		}
+		files["templates/"+t.file] = out
+	}
+	return files, nil
+}
+
+// HoldsSecrets reports whether a rendered file contains the .env keys
+func HoldsSecrets(name string) bool {
+	return strings.HasSuffix(name, "secret.yaml") || name == "values.yaml"
+}
+
+// WriteFiles writes rendered files under dir. Files holding keys are
+// written owner-only.
+func WriteFiles(dir string, files map[string]string) error {
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		mode := os.FileMode(0644)
+		if HoldsSecrets(name) {
+			mode = 0600
+		}
+		if err := os.WriteFile(path, []byte(content), mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func asteriskResources(calls int) Resources {
+	cpu := 250 + 25*calls
+	mem := 256 + 8*calls
+	return Resources{
+		CPURequest:    fmt.Sprintf("%dm", cpu),
+		MemoryRequest: fmt.Sprintf("%dMi", mem),
+		CPULimit:      fmt.Sprintf("%dm", cpu*2),
+		MemoryLimit:   fmt.Sprintf("%dMi", mem*2),
+	}
+}
+
+func render(tmpl string, d k8sData) (string, error) {
+	t, err := template.New("").Delims("[[", "]]").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+	var sb strings.Builder
+	if err := t.Execute(&sb, d); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+func chartValues(p *Profile, o K8sOptions) string {
+	var sb strings.Builder
+	w := func(format string, a ...interface{}) {
+		sb.WriteString(fmt.Sprintf(format, a...))
+	}
+	w("# Generated by: agent deploy k8s --helm --concurrency %d\n", o.Concurrency)
+	w("# Holds provider keys from .env - keep it out of version control\n")
+	w("mediaHostPath: %s\n\n", strconv.Quote(o.MediaHostPath))
+	w("engine:\n")
+	w("  image: %s\n", strconv.Quote(o.EngineImage))
+	w("  healthPort: %d\n", p.HealthPort)
+	w("  audioSocketPort: %d\n", p.AudioSocketPort)
+	w("  rtpPort: %d\n", p.RTPPort)
+	w("  # Sized for %d concurrent calls\n", o.Concurrency)
+	w("  resources:\n%s\n\n", resourcesYAML(EngineResources(o.Concurrency), "    "))
+	w("localAI:\n")
+	w("  # Set from the profile: %s\n", strings.Join(p.Providers, ", "))
+	w("  enabled: %t\n", p.LocalAI)
+	w("  image: %s\n", strconv.Quote(o.LocalAIImage))
+	w("  resources:\n%s\n\n", resourcesYAML(LocalAIResources(o.Concurrency), "    "))
+	w("asterisk:\n")
+	w("  enabled: %t\n", o.Asterisk)
+	w("  image: %s\n", strconv.Quote(o.AsteriskImage))
+	w("  configPath: %s\n", strconv.Quote(o.AsteriskConfigPath))
+	w("  resources:\n%s\n\n", resourcesYAML(asteriskResources(o.Concurrency), "    "))
+	w("storage:\n")
+	w("  className: %s\n", strconv.Quote(o.StorageClass))
+	w("  data: 1Gi\n")
+	w("  models: 20Gi\n\n")
+	w("env:%s\n\n", yamlMap(p.Env, "  "))
+	w("secrets:%s\n", yamlMap(p.Secrets, "  "))
+	return sb.String()
+}
+
+// yamlMap renders m as the body of a YAML mapping, starting on a new
+// line, or as an empty flow mapping
+func yamlMap(m map[string]string, prefix string) string {
+	if len(m) == 0 {
+		return " {}"
+	}
+	var sb strings.Builder
+	for _, k := range SortedKeys(m) {
+		sb.WriteString("\n" + prefix + k + ": " + strconv.Quote(m[k]))
+	}
+	return sb.String()
+}
+
+func resourcesYAML(r Resources, prefix string) string {
+	return prefix + "requests:\n" +
+		prefix + "  cpu: " + r.CPURequest + "\n" +
+		prefix + "  memory: " + r.MemoryRequest + "\n" +
+		prefix + "limits:\n" +
+		prefix + "  cpu: " + r.CPULimit + "\n" +
+		prefix + "  memory: " + r.MemoryLimit
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	for i, l := range lines {
+		if l != "" {
+			lines[i] = prefix + l
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+const chartYAML = `apiVersion: v2
+name: asterisk-ai-voice-agent
+description: Asterisk AI Voice Agent engine, with optional local AI server and Asterisk
+type: application
+version: 0.1.0
+`
+
+const labelsTmpl = `
+    app.kubernetes.io/part-of: asterisk-ai-voice-agent
+    app.kubernetes.io/instance: [[.Name]]`
+
+const podLabelsTmpl = `
+        app.kubernetes.io/part-of: asterisk-ai-voice-agent
+        app.kubernetes.io/instance: [[.Name]]`
+
+const configTmpl = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: [[.Name]]-config
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+data:
+  ai-agent.yaml: |
+[[.ConfigData]]
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: [[.Name]]-env
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+data:[[.EnvData]]
+`
+
+const secretTmpl = `apiVersion: v1
+kind: Secret
+metadata:
+  name: [[.Name]]-secrets
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+type: Opaque
+stringData:[[.SecretData]]
+`
+
+const storageTmpl = `apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: [[.Name]]-data
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+spec:
+  accessModes: ["ReadWriteOnce"][[.StorageClass]]
+  resources:
+    requests:
+      storage: [[.DataStorage]]
+---
+apiVersion: v1
+kind: PersistentVolumeClaim
+metadata:
+  name: [[.Name]]-models
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+spec:
+  accessModes: ["ReadWriteOnce"][[.StorageClass]]
+  resources:
+    requests:
+      storage: [[.ModelsStorage]]
+`
+
+// The engine, local AI server and Asterisk use the host network, as in
+// docker-compose.yml: RTP and AudioSocket stay on 127.0.0.1, so the other
+// pods are kept on the engine's node.
+const engineTmpl = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: [[.Name]]-ai-engine
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+    app.kubernetes.io/name: ai-engine
+spec:
+  # One engine per ARI app name; see 'agent scale' for more
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: [[.Name]]
+      app.kubernetes.io/name: ai-engine
+  template:
+    metadata:
+      labels:` + podLabelsTmpl + `
+        app.kubernetes.io/name: ai-engine
+      annotations:
+        checksum/config: [[.ConfigChecksum]]
+    spec:
+      hostNetwork: true
+      dnsPolicy: ClusterFirstWithHostNet
+      terminationGracePeriodSeconds: 60
+      containers:
+        - name: ai-engine
+          image: [[.EngineImage]]
+          envFrom:
+            - configMapRef:
+                name: [[.Name]]-env
+            - secretRef:
+                name: [[.Name]]-secrets
+          env:
+            - name: PYTHONUNBUFFERED
+              value: "1"
+            # Probes reach the pod on the node address
+            - name: HEALTH_BIND_HOST
+              value: "0.0.0.0"
+            - name: HEALTH_BIND_PORT
+              value: "[[.HealthPort]]"
+          ports:
+            - name: health
+              containerPort: [[.HealthPort]]
+              protocol: TCP
+            - name: audiosocket
+              containerPort: [[.AudioSocketPort]]
+              protocol: TCP
+            - name: rtp
+              containerPort: [[.RTPPort]]
+              protocol: UDP
+          startupProbe:
+            httpGet:
+              path: /live
+              port: health
+            periodSeconds: 5
+            failureThreshold: 24
+          livenessProbe:
+            httpGet:
+              path: /live
+              port: health
+            periodSeconds: 20
+            failureThreshold: 3
+          readinessProbe:
+            httpGet:
+              path: /ready
+              port: health
+            periodSeconds: 10
+            failureThreshold: 3
+          resources:
+[[.EngineResources]]
+          volumeMounts:
+            - name: config
+              mountPath: /app/config/ai-agent.yaml
+              subPath: ai-agent.yaml
+              readOnly: true
+            - name: data
+              mountPath: /app/data
+            - name: media
+              mountPath: /mnt/asterisk_media
+      volumes:
+        - name: config
+          configMap:
+            name: [[.Name]]-config
+        - name: data
+          persistentVolumeClaim:
+            claimName: [[.Name]]-data
+        - name: media
+          hostPath:
+            path: [[.MediaHostPath]]
+            type: DirectoryOrCreate
+`
+
+const sameNodeTmpl = `
+      affinity:
+        podAffinity:
+          requiredDuringSchedulingIgnoredDuringExecution:
+            - topologyKey: kubernetes.io/hostname
+              labelSelector:
+                matchLabels:
+                  app.kubernetes.io/instance: [[.Name]]
+                  app.kubernetes.io/name: ai-engine`
+
+const localAITmpl = `[[.IfLocalAI]]apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: [[.Name]]-local-ai-server
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+    app.kubernetes.io/name: local-ai-server
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: [[.Name]]
+      app.kubernetes.io/name: local-ai-server
+  template:
+    metadata:
+      labels:` + podLabelsTmpl + `
+        app.kubernetes.io/name: local-ai-server
+    spec:
+      hostNetwork: true
+      dnsPolicy: ClusterFirstWithHostNet` + sameNodeTmpl + `
+      containers:
+        - name: local-ai-server
+          image: [[.LocalAIImage]]
+          envFrom:
+            - configMapRef:
+                name: [[.Name]]-env
+            - secretRef:
+                name: [[.Name]]-secrets
+          env:
+            - name: PYTHONUNBUFFERED
+              value: "1"
+          ports:
+            - name: ws
+              containerPort: 8765
+              protocol: TCP
+          # Loading models can take minutes
+          startupProbe:
+            tcpSocket:
+              port: ws
+            periodSeconds: 10
+            failureThreshold: 60
+          readinessProbe:
+            tcpSocket:
+              port: ws
+            periodSeconds: 10
+          resources:
+[[.LocalAIResources]]
+          volumeMounts:
+            - name: models
+              mountPath: /app/models
+      volumes:
+        - name: models
+          persistentVolumeClaim:
+            claimName: [[.Name]]-models
+[[.End]]`
+
+const asteriskTmpl = `[[.IfAsterisk]]apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: [[.Name]]-asterisk
+  namespace: [[.Namespace]]
+  labels:` + labelsTmpl + `
+    app.kubernetes.io/name: asterisk
+spec:
+  replicas: 1
+  strategy:
+    type: Recreate
+  selector:
+    matchLabels:
+      app.kubernetes.io/instance: [[.Name]]
+      app.kubernetes.io/name: asterisk
+  template:
+    metadata:
+      labels:` + podLabelsTmpl + `
+        app.kubernetes.io/name: asterisk
+    spec:
+      hostNetwork: true
+      dnsPolicy: ClusterFirstWithHostNet` + sameNodeTmpl + `
+      containers:
+        - name: asterisk
+          image: [[.AsteriskImage]]
+          ports:
+            - name: ari
+              containerPort: 8088
+              protocol: TCP
+            - name: sip
+              containerPort: 5060
+              protocol: UDP
+          readinessProbe:
+            tcpSocket:
+              port: ari
+            periodSeconds: 10
+          resources:
+[[.AsteriskResources]]
+          volumeMounts:
+            - name: config
+              mountPath: /etc/asterisk
+            - name: media
+              mountPath: /var/lib/asterisk/sounds
+      volumes:
+        - name: config
+          hostPath:
+            path: [[.AsteriskConfigPath]]
+            type: Directory
+        - name: media
+          hostPath:
+            path: [[.MediaHostPath]]
+            type: DirectoryOrCreate
+[[.End]]`
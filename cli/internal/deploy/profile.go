@@ -0,0 +1,162 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"gopkg.in/yaml.v3"
+)
+
+// secretName matches .env variables that hold credentials
+var secretName = regexp.MustCompile(`(?i)(KEY|TOKEN|SECRET|PASSWORD|PASS|CREDENTIALS)$`)
+
+// Profile is the deployment-relevant part of a project's configuration:
+// config/ai-agent.yaml plus .env
+type Profile struct {
+	Dir             string
+	AppName         string
+	AudioTransport  string
+	AudioSocketPort int
+	RTPPort         int
+	HealthPort      int
+	DefaultProvider string
+	ActivePipeline  string
+	// Providers are the providers the default provider and active
+	// pipeline use, sorted
+	Providers []string
+	// LocalAI is set when any of them is served by local_ai_server
+	LocalAI bool
+	// Config is the raw ai-agent.yaml
+	Config []byte
+	// Env holds the non-secret .env values, Secrets the credentials
+	Env     map[string]string
+	Secrets map[string]string
+}
+
+// LoadProfile reads config/ai-agent.yaml and .env (or config/.env) under dir
+func LoadProfile(dir string) (*Profile, error) {
+	configPath := filepath.Join(dir, "config", "ai-agent.yaml")
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s: %w", configPath, err)
+	}
+	var cfg struct {
+		ActivePipeline  string `yaml:"active_pipeline"`
+		AudioTransport  string `yaml:"audio_transport"`
+		DefaultProvider string `yaml:"default_provider"`
+		Asterisk        struct {
+			AppName string `yaml:"app_name"`
+		} `yaml:"asterisk"`
+		AudioSocket struct {
+			Port int `yaml:"port"`
+		} `yaml:"audiosocket"`
+		ExternalMedia struct {
+			RTPPort int `yaml:"rtp_port"`
+		} `yaml:"external_media"`
+		Health struct {
+			Port int `yaml:"port"`
+		} `yaml:"health"`
+		Pipelines map[string]struct {
+			STT string `yaml:"stt"`
+			LLM string `yaml:"llm"`
+			TTS string `yaml:"tts"`
+		} `yaml:"pipelines"`
+		Providers map[string]struct {
+			Type    string `yaml:"type"`
+			BaseURL string `yaml:"base_url"`
+			WSURL   string `yaml:"ws_url"`
+		} `yaml:"providers"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("cannot parse %s: %w", configPath, err)
+	}
+
+	p := &Profile{
+		Dir:             dir,
+		AppName:         "asterisk-ai-voice-agent",
+		AudioTransport:  cfg.AudioTransport,
+		AudioSocketPort: 8090,
+		RTPPort:         18080,
+		HealthPort:      15000,
+		DefaultProvider: cfg.DefaultProvider,
+		ActivePipeline:  cfg.ActivePipeline,
+		Config:          data,
+		Env:             make(map[string]string),
+		Secrets:         make(map[string]string),
+	}
+	if cfg.Asterisk.AppName != "" {
+		p.AppName = cfg.Asterisk.AppName
+	}
+	if cfg.AudioSocket.Port > 0 {
+		p.AudioSocketPort = cfg.AudioSocket.Port
+	}
+	if cfg.ExternalMedia.RTPPort > 0 {
+		p.RTPPort = cfg.ExternalMedia.RTPPort
+	}
+	if cfg.Health.Port > 0 {
+		p.HealthPort = cfg.Health.Port
+	}
+
+	used := make(map[string]bool)
+	if cfg.DefaultProvider != "" {
+		used[cfg.DefaultProvider] = true
+	}
+	if pl, ok := cfg.Pipelines[cfg.ActivePipeline]; ok {
+		for _, name := range []string{pl.STT, pl.LLM, pl.TTS} {
+			if name != "" {
+				used[name] = true
+			}
+		}
+	}
+	for name := range used {
+		// A pipeline name used as default_provider is not a provider
+		if _, ok := cfg.Providers[name]; !ok {
+			continue
+		}
+		p.Providers = append(p.Providers, name)
+		prov := cfg.Providers[name]
+		if prov.Type == "local" || strings.Contains(prov.WSURL+prov.BaseURL, ":8765") {
+			p.LocalAI = true
+		}
+	}
+	sort.Strings(p.Providers)
+
+	env, err := health.LoadEnvFile(filepath.Join(dir, ".env"))
+	if err != nil {
+		env, _ = health.LoadEnvFile(filepath.Join(dir, "config", ".env"))
+	}
+	for k, v := range env {
+		// Drop inline comments ("KEY=   # note") and quotes
+		if i := strings.Index(v, " #"); i >= 0 {
+			v = strings.TrimSpace(v[:i])
+		}
+		if strings.HasPrefix(v, "#") {
+			v = ""
+		}
+		v = strings.Trim(v, `"'`)
+		if v == "" {
+			continue
+		}
+		if secretName.MatchString(k) {
+			p.Secrets[k] = v
+		} else {
+			p.Env[k] = v
+		}
+	}
+	return p, nil
+}
+
+// SortedKeys returns m's keys in order
+func SortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
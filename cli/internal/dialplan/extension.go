@@ -0,0 +1,184 @@
+package dialplan
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"gopkg.in/yaml.v3"
+)
+
+// Transports the engine supports (audio_transport in ai-agent.yaml)
+const (
+	TransportAudioSocket   = "audiosocket"
+	TransportExternalMedia = "externalmedia"
+)
+
+// Transports lists the valid --transport values
+var Transports = []string{TransportAudioSocket, TransportExternalMedia}
+
+// DefaultTarget is the FreePBX file for hand-written dialplan; its
+// from-internal-custom context is included in from-internal
+const DefaultTarget = "/etc/asterisk/extensions_custom.conf"
+
+// ExtensionOptions describes a dialed extension that reaches the agent
+type ExtensionOptions struct {
+	Extension string
+	Context   string
+	Transport string
+	AppName   string
+	// Provider and AIContext set AI_PROVIDER / AI_CONTEXT when not empty
+	Provider  string
+	AIContext string
+	// Format and Rate are the transport leg's audio, passed to the engine
+	// as AI_TRANSPORT_FORMAT / AI_TRANSPORT_RATE
+	Format string
+	Rate   int
+	// ConfigTransport is audio_transport in ai-agent.yaml
+	ConfigTransport string
+
+	audioSocketFormat string
+	externalCodec     string
+}
+
+// DefaultExtensionOptions reads the app name, transport and audio formats
+// from ai-agent.yaml under dir
+func DefaultExtensionOptions(dir string) ExtensionOptions {
+	o := ExtensionOptions{
+		Context:           "from-internal-custom",
+		AppName:           "asterisk-ai-voice-agent",
+		audioSocketFormat: "slin",
+		externalCodec:     "ulaw",
+	}
+	data, err := remote.ReadFile(filepath.Join(dir, "config", "ai-agent.yaml"))
+	if err != nil {
+		return o
+	}
+	var cfg struct {
+		AudioTransport string `yaml:"audio_transport"`
+		Asterisk       struct {
+			AppName string `yaml:"app_name"`
+		} `yaml:"asterisk"`
+		AudioSocket struct {
+			Format string `yaml:"format"`
+		} `yaml:"audiosocket"`
+		ExternalMedia struct {
+			Codec string `yaml:"codec"`
+		} `yaml:"external_media"`
+	}
+	if yaml.Unmarshal(data, &cfg) != nil {
+		return o
+	}
+	o.ConfigTransport = cfg.AudioTransport
+	if cfg.Asterisk.AppName != "" {
+		o.AppName = cfg.Asterisk.AppName
+	}
+	if cfg.AudioSocket.Format != "" {
+		o.audioSocketFormat = cfg.AudioSocket.Format
+	}
+	if cfg.ExternalMedia.Codec != "" {
+		o.externalCodec = cfg.ExternalMedia.Codec
+	}
+	return o
+}
+
+// SetTransport selects the transport and the matching audio format: the
+// AudioSocket format, or the codec of the ExternalMedia RTP leg
+func (o *ExtensionOptions) SetTransport(transport string) error {
+	switch transport {
+	case TransportAudioSocket:
+		o.Format = o.audioSocketFormat
+	case TransportExternalMedia:
+		o.Format = o.externalCodec
+	default:
+		return fmt.Errorf("invalid transport %q (use %s)", transport, strings.Join(Transports, " or "))
+	}
+	o.Transport = transport
+	o.Rate = formatRate(o.Format)
+	return nil
+}
+
+// formatRate returns the sample rate of an Asterisk format name
+func formatRate(format string) int {
+	switch format {
+	case "slin12":
+		return 12000
+	case "slin16", "g722":
+		return 16000
+	case "slin24":
+		return 24000
+	case "slin32":
+		return 32000
+	case "slin44":
+		return 44100
+	case "slin48":
+		return 48000
+	}
+	return 8000
+}
+
+// BeginMarker and EndMarker delimit a generated block in an Asterisk
+// config file; label names what the block holds, e.g. "extension 7000"
+func BeginMarker(label string) string {
+	return "; BEGIN AI Voice Agent " + label
+}
+
+func EndMarker(label string) string {
+	return "; END AI Voice Agent " + label
+}
+
+// GenerateExtension renders the extension as a marked block, so Merge can
+// replace it when it is generated again
+func GenerateExtension(o ExtensionOptions) string {
+	var sb strings.Builder
+	sb.WriteString(BeginMarker("extension "+o.Extension) + "\n")
+	sb.WriteString(fmt.Sprintf("; Generated by: agent dialplan generate --extension %s --transport %s\n", o.Extension, o.Transport))
+	switch o.Transport {
+	case TransportAudioSocket:
+		sb.WriteString("; Requires chan_audiosocket and res_audiosocket (module show like audiosocket);\n")
+		sb.WriteString("; the engine originates AudioSocket/<host>:<port> back to itself.\n")
+	case TransportExternalMedia:
+		sb.WriteString("; Requires Asterisk 16.6+ (ARI externalMedia); the engine bridges the caller\n")
+		sb.WriteString(fmt.Sprintf("; with an RTP leg in %s.\n", o.Format))
+	}
+	sb.WriteString(fmt.Sprintf("[%s]\n", o.Context))
+	sb.WriteString(fmt.Sprintf("exten => %s,1,NoOp(AI Voice Agent - %s)\n", o.Extension, o.Transport))
+	sb.WriteString(" same => n,Answer()\n")
+	sb.WriteString(fmt.Sprintf(" same => n,Set(AI_TRANSPORT_FORMAT=%s)\n", o.Format))
+	sb.WriteString(fmt.Sprintf(" same => n,Set(AI_TRANSPORT_RATE=%d)\n", o.Rate))
+	if o.Provider != "" {
+		sb.WriteString(fmt.Sprintf(" same => n,Set(AI_PROVIDER=%s)\n", o.Provider))
+	}
+	if o.AIContext != "" {
+		sb.WriteString(fmt.Sprintf(" same => n,Set(AI_CONTEXT=%s)\n", o.AIContext))
+	}
+	sb.WriteString(fmt.Sprintf(" same => n,Stasis(%s)\n", o.AppName))
+	sb.WriteString(" same => n,Hangup()\n")
+	sb.WriteString(EndMarker("extension "+o.Extension) + "\n")
+	return sb.String()
+}
+
+// Merge puts block into an existing dialplan file: it replaces the
+// previously generated block for the same extension, or is appended
+func Merge(existing, block, extension string) string {
+	return MergeBlock(existing, block, "extension "+extension)
+}
+
+// MergeBlock replaces the block marked with label in existing, or
+// appends block when there is none
+func MergeBlock(existing, block, label string) string {
+	begin, end := BeginMarker(label), EndMarker(label)
+	if i := strings.Index(existing, begin+"\n"); i >= 0 {
+		if j := strings.Index(existing[i:], end+"\n"); j >= 0 {
+			return existing[:i] + block + existing[i+j+len(end)+1:]
+		}
+	}
+	if existing != "" && !strings.HasSuffix(existing, "\n") {
+		existing += "\n"
+	}
+	if existing != "" {
+		existing += "\n"
+	}
+	return existing + block
+}
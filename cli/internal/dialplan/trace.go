@@ -0,0 +1,309 @@
+package dialplan
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Runner runs an Asterisk CLI command and returns its output
+type Runner interface {
+	Command(ctx context.Context, command string) (string, error)
+}
+
+// maxSteps bounds a trace, so Goto loops end
+const maxSteps = 200
+
+// Outcome is how a traced call leaves the dialplan
+type Outcome string
+
+const (
+	// OutcomeAgent means the call enters Stasis
+	OutcomeAgent Outcome = "agent"
+	// OutcomeElsewhere means the call is dialed, queued, sent to
+	// voicemail or hung up before reaching Stasis
+	OutcomeElsewhere Outcome = "elsewhere"
+	// OutcomeNoMatch means no extension or priority matches
+	OutcomeNoMatch Outcome = "no_match"
+	// OutcomeUnknown means the trace depends on runtime state it cannot
+	// resolve (variables, expressions, AGI) or loops
+	OutcomeUnknown Outcome = "unknown"
+)
+
+// Step is one executed priority
+type Step struct {
+	Context   string
+	Extension string
+	Priority  int
+	App       string
+	Data      string
+	Source    string
+}
+
+// String formats the step like a dialplan location
+func (s Step) String() string {
+	return fmt.Sprintf("%s,%s,%d %s(%s)", s.Context, s.Extension, s.Priority, s.App, s.Data)
+}
+
+// Trace is the path of a call through the dialplan
+type Trace struct {
+	Steps   []Step
+	Outcome Outcome
+	// Reason explains an outcome other than OutcomeAgent
+	Reason string
+	// App is the Stasis application the call enters
+	App string
+	// Vars are the channel variables set on the way, without inheritance
+	// underscores (AI_CONTEXT, AI_PROVIDER, ...)
+	Vars map[string]string
+	// Branches notes conditional jumps whose other target was not taken
+	Branches []string
+}
+
+// Last returns the final step, or nil
+func (t *Trace) Last() *Step {
+	if len(t.Steps) == 0 {
+		return nil
+	}
+	return &t.Steps[len(t.Steps)-1]
+}
+
+// terminal apps end the trace without reaching the agent
+var terminal = map[string]bool{
+	"dial": true, "queue": true, "voicemail": true, "voicemailmain": true,
+	"hangup": true, "busy": true, "congestion": true, "confbridge": true,
+	"meetme": true, "page": true, "directory": true, "followme": true,
+	"macroexit": true, "return": true,
+}
+
+// TraceCall follows a call for exten entering contextName by running
+// 'dialplan show' and interpreting Goto, GotoIf and Set. Conditional
+// jumps take the true branch and are noted in Branches.
+func TraceCall(ctx context.Context, r Runner, contextName, exten string) (*Trace, error) {
+	t := &Trace{Vars: make(map[string]string)}
+	vars := map[string]string{"EXTEN": exten, "FROM_DID": exten}
+	cur := location{context: contextName, exten: exten, priority: "1"}
+	var prios []priority
+	loaded := location{}
+
+	for n := 0; n < maxSteps; n++ {
+		if cur.context != loaded.context || cur.exten != loaded.exten {
+			var err error
+			prios, err = loadExtension(ctx, r, cur.context, cur.exten)
+			if err != nil {
+				return nil, err
+			}
+			if prios == nil {
+				t.Outcome = OutcomeNoMatch
+				t.Reason = fmt.Sprintf("no extension %s in context %s", cur.exten, cur.context)
+				return t, nil
+			}
+			loaded = cur
+			vars["EXTEN"] = cur.exten
+		}
+		i := findPriority(prios, cur.priority)
+		if i < 0 {
+			t.Outcome = OutcomeNoMatch
+			t.Reason = fmt.Sprintf("no priority %s at %s,%s", cur.priority, cur.context, cur.exten)
+			return t, nil
+		}
+		p := prios[i]
+		data := substitute(p.data, vars)
+		t.Steps = append(t.Steps, Step{Context: cur.context, Extension: cur.exten, Priority: p.number, App: p.app, Data: data, Source: p.source})
+
+		app := strings.ToLower(p.app)
+		switch {
+		case app == "stasis":
+			t.Outcome = OutcomeAgent
+			t.App = strings.TrimSpace(strings.SplitN(data, ",", 2)[0])
+			return t, nil
+		case terminal[app]:
+			t.Outcome = OutcomeElsewhere
+			t.Reason = fmt.Sprintf("%s(%s)", p.app, data)
+			return t, nil
+		case app == "set" || app == "multiset":
+			assignments := []string{data}
+			if app == "multiset" {
+				assignments = splitArgs(data)
+			}
+			for _, assignment := range assignments {
+				kv := strings.SplitN(assignment, "=", 2)
+				if len(kv) != 2 {
+					continue
+				}
+				key := strings.TrimLeft(strings.TrimSpace(kv[0]), "_")
+				vars[key] = kv[1]
+				t.Vars[key] = kv[1]
+			}
+		case app == "goto" || app == "gotoif" || app == "gotoiftime":
+			target := data
+			if app != "goto" {
+				q := strings.Index(data, "?")
+				if q < 0 {
+					break
+				}
+				branches := strings.SplitN(data[q+1:], ":", 2)
+				target = branches[0]
+				if len(branches) == 2 && branches[1] != "" {
+					t.Branches = append(t.Branches, fmt.Sprintf("%s at %s,%s,%d may go to %s instead", p.app, cur.context, cur.exten, p.number, branches[1]))
+				}
+				if target == "" {
+					// Only a false branch: the true case falls through
+					break
+				}
+			}
+			if strings.Contains(target, "${") || strings.Contains(target, "$[") {
+				t.Outcome = OutcomeUnknown
+				t.Reason = fmt.Sprintf("%s target %s depends on runtime variables", p.app, target)
+				return t, nil
+			}
+			cur = jump(cur, target)
+			continue
+		case app == "agi" || app == "eagi" || app == "fastagi":
+			t.Branches = append(t.Branches, fmt.Sprintf("%s(%s) at %s,%s,%d may redirect the call", p.app, data, cur.context, cur.exten, p.number))
+		}
+
+		if i+1 >= len(prios) {
+			t.Outcome = OutcomeElsewhere
+			t.Reason = fmt.Sprintf("dialplan ends after %s,%s,%d (the call is hung up)", cur.context, cur.exten, p.number)
+			return t, nil
+		}
+		cur.priority = strconv.Itoa(prios[i+1].number)
+	}
+	t.Outcome = OutcomeUnknown
+	t.Reason = fmt.Sprintf("gave up after %d steps (dialplan loop?)", maxSteps)
+	return t, nil
+}
+
+type location struct {
+	context  string
+	exten    string
+	priority string
+}
+
+// jump resolves a Goto target: [[context,]extension,]priority
+func jump(cur location, target string) location {
+	parts := splitArgs(target)
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+	}
+	switch len(parts) {
+	case 1:
+		cur.priority = parts[0]
+	case 2:
+		cur.exten, cur.priority = parts[0], parts[1]
+	default:
+		cur.context, cur.exten, cur.priority = parts[0], parts[1], parts[2]
+	}
+	return cur
+}
+
+type priority struct {
+	number int
+	label  string
+	app    string
+	data   string
+	source string
+}
+
+var (
+	contextHeader  = regexp.MustCompile(`^\[ Context '([^']+)' created by`)
+	extensionStart = regexp.MustCompile(`^\s*'([^']*)'(?:\s*\(CID match '[^']*'\))?\s*=>`)
+	priorityLine   = regexp.MustCompile(`^\s*(?:\[([^\]]+)\]\s+)?(\d+)\.\s+(\w+)\((.*)\)\s*(?:\[([^\]]*)\])?\s*$`)
+)
+
+// loadExtension returns the priorities of the extension Asterisk matches
+// for exten in contextName, or nil when none does. 'dialplan show' lists the
+// context and its includes in search order with the matching extensions
+// of each; an exact match beats a pattern within the first context that
+// has one.
+func loadExtension(ctx context.Context, r Runner, contextName, exten string) ([]priority, error) {
+	out, err := r.Command(ctx, fmt.Sprintf("dialplan show %s@%s", exten, contextName))
+	if err != nil {
+		return nil, err
+	}
+	type extension struct {
+		name  string
+		prios []priority
+	}
+	var section []extension
+	var found []extension
+	flush := func() {
+		if found == nil && len(section) > 0 {
+			found = section
+		}
+		section = nil
+	}
+	for _, line := range strings.Split(out, "\n") {
+		if contextHeader.MatchString(line) {
+			flush()
+			continue
+		}
+		rest := line
+		if m := extensionStart.FindStringSubmatch(line); m != nil {
+			section = append(section, extension{name: m[1]})
+			rest = line[len(m[0]):]
+		}
+		m := priorityLine.FindStringSubmatch(rest)
+		if m == nil || len(section) == 0 {
+			continue
+		}
+		number, _ := strconv.Atoi(m[2])
+		p := priority{number: number, label: m[1], app: m[3], data: m[4], source: m[5]}
+		section[len(section)-1].prios = append(section[len(section)-1].prios, p)
+	}
+	flush()
+	if len(found) == 0 {
+		return nil, nil
+	}
+	for _, e := range found {
+		if e.name == exten {
+			return e.prios, nil
+		}
+	}
+	return found[0].prios, nil
+}
+
+func findPriority(prios []priority, want string) int {
+	n, err := strconv.Atoi(want)
+	for i, p := range prios {
+		if (err == nil && p.number == n) || (err != nil && p.label == want) {
+			return i
+		}
+	}
+	return -1
+}
+
+var varRef = regexp.MustCompile(`\$\{([A-Za-z0-9_]+)\}`)
+
+// substitute expands ${VAR} for the variables the trace knows
+func substitute(s string, vars map[string]string) string {
+	return varRef.ReplaceAllStringFunc(s, func(ref string) string {
+		if v, ok := vars[ref[2:len(ref)-1]]; ok {
+			return v
+		}
+		return ref
+	})
+}
+
+// splitArgs splits application data on commas outside parentheses
+func splitArgs(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
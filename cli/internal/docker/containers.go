@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Container is an entry of the container list
+type Container struct {
+	ID     string   `json:"Id"`
+	Names  []string `json:"Names"`
+	Image  string   `json:"Image"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+// Name is the container's primary name, without the leading slash
+func (c *Container) Name() string {
+	if len(c.Names) == 0 {
+		return ""
+	}
+	return strings.TrimPrefix(c.Names[0], "/")
+}
+
+// Running reports whether the container is up
+func (c *Container) Running() bool {
+	return c.State == "running"
+}
+
+// ListOptions selects containers: All includes stopped ones, Name
+// matches names containing the value (as docker ps --filter name=)
+type ListOptions struct {
+	All  bool
+	Name string
+}
+
+// Containers lists containers
+func (c *Client) Containers(ctx context.Context, opts ListOptions) ([]Container, error) {
+	q := url.Values{}
+	if opts.All {
+		q.Set("all", "1")
+	}
+	if opts.Name != "" {
+		filters, _ := json.Marshal(map[string][]string{"name": {opts.Name}})
+		q.Set("filters", string(filters))
+	}
+	var containers []Container
+	if err := c.do(ctx, "GET", "/containers/json", q, nil, &containers); err != nil {
+		return nil, err
+	}
+	return containers, nil
+}
+
+// ContainerNames lists the names of the containers
+func (c *Client) ContainerNames(ctx context.Context, opts ListOptions) ([]string, error) {
+	containers, err := c.Containers(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(containers))
+	for _, ct := range containers {
+		names = append(names, ct.Name())
+	}
+	return names, nil
+}
+
+// ContainerInfo is the subset of a container inspection the CLI uses
+type ContainerInfo struct {
+	ID           string `json:"Id"`
+	Name         string `json:"Name"`
+	Image        string `json:"Image"`
+	RestartCount int    `json:"RestartCount"`
+	State        struct {
+		Status     string    `json:"Status"`
+		Running    bool      `json:"Running"`
+		Restarting bool      `json:"Restarting"`
+		OOMKilled  bool      `json:"OOMKilled"`
+		ExitCode   int       `json:"ExitCode"`
+		Error      string    `json:"Error"`
+		StartedAt  time.Time `json:"StartedAt"`
+		FinishedAt time.Time `json:"FinishedAt"`
+	} `json:"State"`
+	Config struct {
+		Image  string            `json:"Image"`
+		Env    []string          `json:"Env"`
+		Labels map[string]string `json:"Labels"`
+		Tty    bool              `json:"Tty"`
+	} `json:"Config"`
+}
+
+// Env returns the container's configured environment as a map
+func (i *ContainerInfo) Env() map[string]string {
+	env := make(map[string]string, len(i.Config.Env))
+	for _, v := range i.Config.Env {
+		if k := strings.Index(v, "="); k > 0 {
+			env[v[:k]] = v[k+1:]
+		}
+	}
+	return env
+}
+
+// Inspect returns a container's configuration and state
+func (c *Client) Inspect(ctx context.Context, name string) (*ContainerInfo, error) {
+	var info ContainerInfo
+	if err := c.do(ctx, "GET", "/containers/"+url.PathEscape(name)+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	info.Name = strings.TrimPrefix(info.Name, "/")
+	return &info, nil
+}
+
+// Restart restarts a container, giving it timeout to stop
+func (c *Client) Restart(ctx context.Context, name string, timeout time.Duration) error {
+	q := url.Values{"t": {strconv.Itoa(int(timeout.Seconds()))}}
+	return c.do(ctx, "POST", "/containers/"+url.PathEscape(name)+"/restart", q, nil, nil)
+}
+
+// Remove removes a container; force kills it first when running
+func (c *Client) Remove(ctx context.Context, name string, force bool) error {
+	q := url.Values{}
+	if force {
+		q.Set("force", "1")
+	}
+	return c.do(ctx, "DELETE", "/containers/"+url.PathEscape(name), q, nil, nil)
+}
+
+// ImageInfo is the subset of an image inspection the CLI uses
+type ImageInfo struct {
+	ID          string   `json:"Id"`
+	RepoTags    []string `json:"RepoTags"`
+	RepoDigests []string `json:"RepoDigests"`
+}
+
+// Image inspects an image by ID or reference
+func (c *Client) Image(ctx context.Context, ref string) (*ImageInfo, error) {
+	var info ImageInfo
+	if err := c.do(ctx, "GET", "/images/"+ref+"/json", nil, nil, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// Network is an entry of the network list
+type Network struct {
+	Name   string `json:"Name"`
+	Driver string `json:"Driver"`
+}
+
+// Networks lists the daemon's networks
+func (c *Client) Networks(ctx context.Context) ([]Network, error) {
+	var networks []Network
+	if err := c.do(ctx, "GET", "/networks", nil, nil, &networks); err != nil {
+		return nil, err
+	}
+	return networks, nil
+}
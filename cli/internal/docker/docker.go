@@ -0,0 +1,360 @@
+package docker
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// DefaultHost is the daemon socket used when nothing else is configured
+// and no local socket is found
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// Endpoint is a daemon address with its TLS material, as configured by
+// DOCKER_HOST/DOCKER_CERT_PATH or a docker context
+type Endpoint struct {
+	Host string
+	// Context is the docker context the endpoint came from, if any
+	Context string
+	// CertPath holds ca.pem, cert.pem and key.pem; empty for no TLS
+	CertPath      string
+	SkipTLSVerify bool
+}
+
+// Client is a minimal Docker Engine API client. It speaks HTTP over the
+// daemon's unix socket, TCP (with TLS) or SSH, so no local docker binary
+// is needed.
+type Client struct {
+	endpoint Endpoint
+	network  string
+	address  string
+	// ssh is the host of ssh:// endpoints
+	ssh     *remote.Target
+	baseURL string
+	tls     *tls.Config
+	http    *http.Client
+}
+
+var (
+	defaultOnce   sync.Once
+	defaultClient *Client
+	defaultErr    error
+)
+
+// Default returns the client for the configured daemon, resolved once
+func Default() (*Client, error) {
+	defaultOnce.Do(func() {
+		var ep Endpoint
+		if ep, defaultErr = ResolveEndpoint(); defaultErr == nil {
+			defaultClient, defaultErr = New(ep)
+		}
+	})
+	return defaultClient, defaultErr
+}
+
+// configDir is the docker CLI configuration directory
+func configDir() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return dir
+	}
+	home, _ := os.UserHomeDir()
+	return filepath.Join(home, ".docker")
+}
+
+// ResolveEndpoint finds the daemon the way the docker CLI does:
+// DOCKER_HOST (TLS from DOCKER_TLS_VERIFY, DOCKER_TLS and
+// DOCKER_CERT_PATH), then
+// DOCKER_CONTEXT or the current context in ~/.docker/config.json, then
+// the first local Docker or Podman socket that exists. Podman's
+// CONTAINER_HOST is honoured after DOCKER_HOST. With a remote host set
+// (--remote), its daemon is used over SSH unless DOCKER_HOST says
+// otherwise.
+func ResolveEndpoint() (Endpoint, error) {
+	host := os.Getenv("DOCKER_HOST")
+	if host == "" {
+		host = os.Getenv("CONTAINER_HOST")
+	}
+	if host != "" {
+		ep := Endpoint{Host: host}
+		// As the docker CLI: DOCKER_TLS_VERIFY turns TLS on with
+		// verification, DOCKER_TLS without it skips verification, and
+		// the client certificates come from DOCKER_CERT_PATH, else
+		// ~/.docker. A cert path alone still verifies the daemon.
+		verify := os.Getenv("DOCKER_TLS_VERIFY") != ""
+		certPath := os.Getenv("DOCKER_CERT_PATH")
+		if verify || os.Getenv("DOCKER_TLS") != "" || certPath != "" {
+			if certPath == "" {
+				certPath = configDir()
+			}
+			ep.CertPath = certPath
+			ep.SkipTLSVerify = !verify && os.Getenv("DOCKER_TLS") != ""
+		}
+		return ep, nil
+	}
+	if t := remote.Current(); t != nil {
+		return Endpoint{Host: t.String()}, nil
+	}
+
+	name := os.Getenv("DOCKER_CONTEXT")
+	if name == "" {
+		var cfg struct {
+			CurrentContext string `json:"currentContext"`
+		}
+		if data, err := os.ReadFile(filepath.Join(configDir(), "config.json")); err == nil {
+			json.Unmarshal(data, &cfg)
+		}
+		name = cfg.CurrentContext
+	}
+	if name == "" || name == "default" {
+		return Endpoint{Host: localHost()}, nil
+	}
+	return contextEndpoint(name)
+}
+
+// contextID is the directory name of a context in the store
+func contextID(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return hex.EncodeToString(sum[:])
+}
+
+// contextEndpoint reads a docker context from the CLI's context store
+func contextEndpoint(name string) (Endpoint, error) {
+	id := contextID(name)
+	data, err := os.ReadFile(filepath.Join(configDir(), "contexts", "meta", id, "meta.json"))
+	if err != nil {
+		return Endpoint{}, fmt.Errorf("docker context %q not found: %w", name, err)
+	}
+	var meta struct {
+		Endpoints map[string]struct {
+			Host          string `json:"Host"`
+			SkipTLSVerify bool   `json:"SkipTLSVerify"`
+		} `json:"Endpoints"`
+	}
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return Endpoint{}, fmt.Errorf("docker context %q: %w", name, err)
+	}
+	d, ok := meta.Endpoints["docker"]
+	if !ok || d.Host == "" {
+		return Endpoint{}, fmt.Errorf("docker context %q has no docker endpoint", name)
+	}
+	ep := Endpoint{Host: d.Host, Context: name, SkipTLSVerify: d.SkipTLSVerify}
+	tlsDir := filepath.Join(configDir(), "contexts", "tls", id, "docker")
+	if _, err := os.Stat(tlsDir); err == nil {
+		ep.CertPath = tlsDir
+	}
+	return ep, nil
+}
+
+// New creates a client for an endpoint. unix://, tcp://, http://,
+// https:// and ssh:// hosts are supported; ssh:// runs
+// 'docker system dial-stdio' on the host through the local ssh client.
+func New(ep Endpoint) (*Client, error) {
+	u, err := url.Parse(ep.Host)
+	if err != nil {
+		return nil, fmt.Errorf("invalid docker host %q: %w", ep.Host, err)
+	}
+	c := &Client{endpoint: ep}
+	scheme := "http"
+	switch u.Scheme {
+	case "unix":
+		c.network, c.address = "unix", u.Path
+		c.baseURL = "http://docker"
+	case "tcp", "http", "https":
+		c.network, c.address = "tcp", u.Host
+		if u.Port() == "" {
+			port := "2375"
+			if ep.CertPath != "" || u.Scheme == "https" {
+				port = "2376"
+			}
+			c.address = net.JoinHostPort(u.Host, port)
+		}
+		if ep.CertPath != "" || u.Scheme == "https" {
+			scheme = "https"
+			if c.tls, err = tlsConfig(ep, u.Hostname()); err != nil {
+				return nil, err
+			}
+		}
+		c.baseURL = scheme + "://" + c.address
+	case "ssh":
+		if c.ssh, err = remote.Parse(ep.Host); err != nil {
+			return nil, err
+		}
+		// The key and options of --remote apply to the same host
+		if t := remote.Current(); c.ssh.Same(t) {
+			c.ssh = t
+		}
+		c.network, c.address = "ssh", c.ssh.Host
+		c.baseURL = "http://docker"
+	default:
+		return nil, fmt.Errorf("docker host %s: unsupported scheme %q", ep.Host, u.Scheme)
+	}
+	c.http = &http.Client{
+		Transport: selflog.Transport(&http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				if c.ssh != nil {
+					return remote.DialStdio(c.ssh)
+				}
+				var d net.Dialer
+				return d.DialContext(ctx, c.network, c.address)
+			},
+			TLSClientConfig:     c.tls,
+			MaxIdleConnsPerHost: 4,
+			IdleConnTimeout:     30 * time.Second,
+		}),
+	}
+	return c, nil
+}
+
+func tlsConfig(ep Endpoint, serverName string) (*tls.Config, error) {
+	cfg := &tls.Config{ServerName: serverName, InsecureSkipVerify: ep.SkipTLSVerify}
+	if ep.CertPath == "" {
+		return cfg, nil
+	}
+	if ca, err := os.ReadFile(filepath.Join(ep.CertPath, "ca.pem")); err == nil {
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(ca) {
+			return nil, fmt.Errorf("no certificates in %s", filepath.Join(ep.CertPath, "ca.pem"))
+		}
+		cfg.RootCAs = pool
+	}
+	certFile, keyFile := filepath.Join(ep.CertPath, "cert.pem"), filepath.Join(ep.CertPath, "key.pem")
+	if _, err := os.Stat(certFile); err == nil {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("docker client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// Host is the daemon address, for messages
+func (c *Client) Host() string {
+	if c.endpoint.Context != "" {
+		return c.endpoint.Host + " (context " + c.endpoint.Context + ")"
+	}
+	return c.endpoint.Host
+}
+
+// SocketPath is the unix socket the client connects to ("" over TCP)
+func (c *Client) SocketPath() string {
+	if c.network != "unix" {
+		return ""
+	}
+	return c.address
+}
+
+// StatusError is a non-2xx Engine API response
+type StatusError struct {
+	Method  string
+	Path    string
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("docker %s %s: %d %s", e.Method, e.Path, e.Code, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the daemon (no such
+// container, image or exec instance)
+func IsNotFound(err error) bool {
+	var se *StatusError
+	return errors.As(err, &se) && se.Code == http.StatusNotFound
+}
+
+// request sends a request and returns the response for 2xx codes
+func (c *Client) request(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = strings.NewReader(string(data))
+	}
+	u := c.baseURL + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("cannot reach the docker daemon at %s: %w", c.Host(), err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		defer resp.Body.Close()
+		return nil, responseError(method, path, resp)
+	}
+	return resp, nil
+}
+
+func responseError(method, path string, resp *http.Response) error {
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	var msg struct {
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(data, &msg) != nil || msg.Message == "" {
+		msg.Message = strings.TrimSpace(string(data))
+	}
+	return &StatusError{Method: method, Path: path, Code: resp.StatusCode, Message: msg.Message}
+}
+
+// do sends a request and decodes a JSON response into out (when not nil)
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	resp, err := c.request(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil || resp.StatusCode == http.StatusNoContent {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// Ping checks that the daemon answers
+func (c *Client) Ping(ctx context.Context) error {
+	return c.do(ctx, "GET", "/_ping", nil, nil, nil)
+}
+
+// Version is the subset of /version the CLI uses
+type Version struct {
+	Version    string `json:"Version"`
+	APIVersion string `json:"ApiVersion"`
+	Os         string `json:"Os"`
+	Arch       string `json:"Arch"`
+}
+
+// Version returns the daemon version
+func (c *Client) Version(ctx context.Context) (*Version, error) {
+	var v Version
+	if err := c.do(ctx, "GET", "/version", nil, nil, &v); err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
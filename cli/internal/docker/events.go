@@ -0,0 +1,71 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Event is an entry of the daemon's event log, e.g. a container's start,
+// die, kill or oom
+type Event struct {
+	Type   string `json:"Type"`
+	Action string `json:"Action"`
+	Actor  struct {
+		ID         string            `json:"ID"`
+		Attributes map[string]string `json:"Attributes"`
+	} `json:"Actor"`
+	TimeNano int64 `json:"timeNano"`
+}
+
+// Time is when the event happened
+func (e *Event) Time() time.Time {
+	return time.Unix(0, e.TimeNano)
+}
+
+// EventsOptions selects events: a window (a zero Until is now) and
+// filters as docker events --filter, e.g. {"container": {"ai_engine"}}
+type EventsOptions struct {
+	Since   time.Time
+	Until   time.Time
+	Filters map[string][]string
+}
+
+// Events returns the events of a past window, oldest first. The daemon
+// keeps a limited number of events (1000 by default), so old windows may
+// come back short.
+func (c *Client) Events(ctx context.Context, opts EventsOptions) ([]Event, error) {
+	until := opts.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	q := url.Values{"until": {strconv.FormatInt(until.Unix(), 10)}}
+	if !opts.Since.IsZero() {
+		q.Set("since", strconv.FormatInt(opts.Since.Unix(), 10))
+	}
+	if len(opts.Filters) > 0 {
+		filters, _ := json.Marshal(opts.Filters)
+		q.Set("filters", string(filters))
+	}
+	resp, err := c.request(ctx, "GET", "/events", q, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	// The daemon streams one JSON object per event and closes at until
+	var events []Event
+	dec := json.NewDecoder(resp.Body)
+	for {
+		var ev Event
+		if err := dec.Decode(&ev); err == io.EOF {
+			return events, nil
+		} else if err != nil {
+			return events, err
+		}
+		events = append(events, ev)
+	}
+}
@@ -0,0 +1,161 @@
+package docker
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+)
+
+// ExecResult is the output and exit code of a command run in a container
+type ExecResult struct {
+	Stdout   []byte
+	Stderr   []byte
+	ExitCode int
+}
+
+// Combined is stdout followed by stderr
+func (r *ExecResult) Combined() []byte {
+	return append(append([]byte{}, r.Stdout...), r.Stderr...)
+}
+
+// ExitError is a command that ran but exited non-zero
+type ExitError struct {
+	Container string
+	Cmd       []string
+	Code      int
+	Stderr    string
+}
+
+func (e *ExitError) Error() string {
+	msg := fmt.Sprintf("%s in %s exited with status %d", strings.Join(e.Cmd, " "), e.Container, e.Code)
+	if e.Stderr != "" {
+		msg += ": " + e.Stderr
+	}
+	return msg
+}
+
+// Exec runs cmd in a running container, like docker exec, feeding it
+// stdin when not nil. A non-zero exit is returned as *ExitError along
+// with the result.
+func (c *Client) Exec(ctx context.Context, container string, stdin io.Reader, cmd ...string) (*ExecResult, error) {
+	var created struct {
+		ID string `json:"Id"`
+	}
+	config := map[string]interface{}{
+		"AttachStdin":  stdin != nil,
+		"AttachStdout": true,
+		"AttachStderr": true,
+		"Cmd":          cmd,
+	}
+	if err := c.do(ctx, "POST", "/containers/"+url.PathEscape(container)+"/exec", nil, config, &created); err != nil {
+		return nil, err
+	}
+
+	var stdout, stderr bytes.Buffer
+	if err := c.execStart(ctx, created.ID, stdin, &stdout, &stderr); err != nil {
+		return nil, err
+	}
+	var inspect struct {
+		ExitCode int  `json:"ExitCode"`
+		Running  bool `json:"Running"`
+	}
+	if err := c.do(ctx, "GET", "/exec/"+created.ID+"/json", nil, nil, &inspect); err != nil {
+		return nil, err
+	}
+	result := &ExecResult{Stdout: stdout.Bytes(), Stderr: stderr.Bytes(), ExitCode: inspect.ExitCode}
+	if inspect.ExitCode != 0 {
+		return result, &ExitError{
+			Container: container,
+			Cmd:       cmd,
+			Code:      inspect.ExitCode,
+			Stderr:    strings.TrimSpace(stderr.String()),
+		}
+	}
+	return result, nil
+}
+
+// execStart starts an exec instance on a hijacked connection: the
+// daemon switches the connection to a raw stream, stdin is written to it
+// and closed, and the multiplexed output is read until the command exits
+func (c *Client) execStart(ctx context.Context, id string, stdin io.Reader, stdout, stderr io.Writer) error {
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("cannot reach the docker daemon at %s: %w", c.Host(), err)
+	}
+	defer conn.Close()
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	path := "/exec/" + id + "/start"
+	body, _ := json.Marshal(map[string]bool{"Detach": false, "Tty": false})
+	req, err := http.NewRequest("POST", c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "tcp")
+	if err := req.Write(conn); err != nil {
+		return err
+	}
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols && resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return responseError("POST", path, resp)
+	}
+
+	if stdin != nil {
+		go func() {
+			io.Copy(conn, stdin)
+			if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+				cw.CloseWrite()
+			}
+		}()
+	}
+	if err := demux(br, stdout, stderr); err != nil && ctx.Err() == nil {
+		return err
+	}
+	return ctx.Err()
+}
+
+// dial opens a raw connection to the daemon
+func (c *Client) dial(ctx context.Context) (net.Conn, error) {
+	if c.ssh != nil {
+		return remote.DialStdio(c.ssh)
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, c.network, c.address)
+	if err != nil || c.tls == nil {
+		return conn, err
+	}
+	tlsConn := tls.Client(conn, c.tls)
+	if err := tlsConn.Handshake(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return tlsConn, nil
+}
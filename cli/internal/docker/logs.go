@@ -0,0 +1,139 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LogsOptions selects container log lines. Zero times and Tail mean no
+// limit.
+type LogsOptions struct {
+	Since  time.Time
+	Until  time.Time
+	Tail   int
+	Follow bool
+	// Timestamps prefixes each line with its RFC3339Nano receive time
+	Timestamps bool
+}
+
+func unixTime(t time.Time) string {
+	return strconv.FormatFloat(float64(t.UnixNano())/1e9, 'f', 9, 64)
+}
+
+// Logs streams a container's stdout and stderr, merged in the order the
+// daemon sends them. Follow keeps the stream open until ctx is done or
+// the container stops; close the reader when done.
+func (c *Client) Logs(ctx context.Context, name string, opts LogsOptions) (io.ReadCloser, error) {
+	q := url.Values{"stdout": {"1"}, "stderr": {"1"}}
+	if !opts.Since.IsZero() {
+		q.Set("since", unixTime(opts.Since))
+	}
+	if !opts.Until.IsZero() {
+		q.Set("until", unixTime(opts.Until))
+	}
+	if opts.Tail > 0 {
+		q.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	if opts.Follow {
+		q.Set("follow", "1")
+	}
+	if opts.Timestamps {
+		q.Set("timestamps", "1")
+	}
+	resp, err := c.request(ctx, "GET", "/containers/"+url.PathEscape(name)+"/logs", q, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Containers with a TTY send a raw stream, others frame each chunk
+	// with its stream; daemons before API 1.42 only tell by the config
+	multiplexed := true
+	switch ct := resp.Header.Get("Content-Type"); {
+	case strings.Contains(ct, "raw-stream"):
+		multiplexed = false
+	case strings.Contains(ct, "multiplexed-stream"):
+	default:
+		if info, err := c.Inspect(ctx, name); err == nil && info.Config.Tty {
+			multiplexed = false
+		}
+	}
+	if !multiplexed {
+		return resp.Body, nil
+	}
+	return &demuxReader{r: bufio.NewReader(resp.Body), c: resp.Body}, nil
+}
+
+// LogsBytes reads a container's logs for a window into memory
+func (c *Client) LogsBytes(ctx context.Context, name string, opts LogsOptions) ([]byte, error) {
+	opts.Follow = false
+	r, err := c.Logs(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
+
+// demuxReader strips the 8-byte frame headers (stream, 0, 0, 0, size) of
+// a multiplexed stream, merging stdout and stderr
+type demuxReader struct {
+	r         *bufio.Reader
+	c         io.Closer
+	remaining uint32
+}
+
+func (d *demuxReader) Read(p []byte) (int, error) {
+	for d.remaining == 0 {
+		var header [8]byte
+		if _, err := io.ReadFull(d.r, header[:]); err != nil {
+			if err == io.ErrUnexpectedEOF {
+				err = io.EOF
+			}
+			return 0, err
+		}
+		d.remaining = binary.BigEndian.Uint32(header[4:])
+	}
+	if uint32(len(p)) > d.remaining {
+		p = p[:d.remaining]
+	}
+	n, err := d.r.Read(p)
+	d.remaining -= uint32(n)
+	return n, err
+}
+
+func (d *demuxReader) Close() error {
+	return d.c.Close()
+}
+
+// demux copies a multiplexed stream into stdout and stderr
+func demux(r io.Reader, stdout, stderr io.Writer) error {
+	br := bufio.NewReader(r)
+	var header [8]byte
+	for {
+		if _, err := io.ReadFull(br, header[:]); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return nil
+			}
+			return err
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		dst := stdout
+		switch header[0] {
+		case 0, 1:
+		case 2:
+			dst = stderr
+		default:
+			return fmt.Errorf("docker: unknown stream %d in multiplexed output", header[0])
+		}
+		if _, err := io.CopyN(dst, br, size); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// Runtime names
+const (
+	RuntimeDocker = "docker"
+	RuntimePodman = "podman"
+)
+
+// runtimeDir is the user's XDG runtime directory, where rootless Docker
+// and Podman put their sockets
+func runtimeDir() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return dir
+	}
+	dir := fmt.Sprintf("/run/user/%d", os.Getuid())
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	return ""
+}
+
+// LocalSockets are the sockets tried, in order, when no daemon is
+// configured: rootful Docker, rootless Docker, rootless Podman, rootful
+// Podman. Podman serves the Docker API on its socket.
+func LocalSockets() []string {
+	sockets := []string{"/var/run/docker.sock"}
+	if dir := runtimeDir(); dir != "" && os.Getuid() != 0 {
+		sockets = append(sockets,
+			filepath.Join(dir, "docker.sock"),
+			filepath.Join(dir, "podman", "podman.sock"))
+	}
+	return append(sockets, "/run/podman/podman.sock")
+}
+
+// localHost returns the first local socket that exists, else DefaultHost
+func localHost() string {
+	for _, path := range LocalSockets() {
+		if fi, err := os.Stat(path); err == nil && fi.Mode()&os.ModeSocket != 0 {
+			return "unix://" + path
+		}
+	}
+	return DefaultHost
+}
+
+// RuntimeInfo identifies the container engine behind the API
+type RuntimeInfo struct {
+	Name     string
+	Version  string
+	Rootless bool
+}
+
+func (r *RuntimeInfo) String() string {
+	s := "Docker"
+	if r.Name == RuntimePodman {
+		s = "Podman"
+	}
+	s += " " + r.Version
+	if r.Rootless {
+		s += " (rootless)"
+	}
+	return s
+}
+
+// Runtime reports whether the daemon is Docker or Podman, its version,
+// and whether it runs rootless
+func (c *Client) Runtime(ctx context.Context) (*RuntimeInfo, error) {
+	var v struct {
+		Version    string `json:"Version"`
+		Components []struct {
+			Name    string `json:"Name"`
+			Version string `json:"Version"`
+		} `json:"Components"`
+	}
+	if err := c.do(ctx, "GET", "/version", nil, nil, &v); err != nil {
+		return nil, err
+	}
+	r := &RuntimeInfo{Name: RuntimeDocker, Version: v.Version}
+	for _, comp := range v.Components {
+		if strings.Contains(strings.ToLower(comp.Name), "podman") {
+			r.Name, r.Version = RuntimePodman, comp.Version
+		}
+	}
+
+	var info struct {
+		SecurityOptions []string `json:"SecurityOptions"`
+	}
+	if err := c.do(ctx, "GET", "/info", nil, nil, &info); err == nil {
+		for _, opt := range info.SecurityOptions {
+			if strings.Contains(opt, "name=rootless") {
+				r.Rootless = true
+			}
+		}
+	}
+	if path := c.SocketPath(); strings.HasPrefix(path, "/run/user/") || (runtimeDir() != "" && strings.HasPrefix(path, runtimeDir()+"/")) {
+		r.Rootless = true
+	}
+	return r, nil
+}
+
+// ComposeCommand returns the compose tool to run: docker compose, podman
+// compose, podman-compose or docker-compose, whichever is installed,
+// preferring the one that matches the daemon (runtime may be empty)
+func ComposeCommand(runtime string) ([]string, error) {
+	candidates := [][]string{{"docker", "compose"}, {"podman", "compose"}, {"podman-compose"}, {"docker-compose"}}
+	if runtime == RuntimePodman {
+		candidates = [][]string{{"podman", "compose"}, {"podman-compose"}, {"docker", "compose"}, {"docker-compose"}}
+	}
+	for _, c := range candidates {
+		if _, err := exec.LookPath(c[0]); err != nil {
+			continue
+		}
+		args := append(append([]string{}, c[1:]...), "version")
+		if selflog.Run(exec.Command(c[0], args...)) == nil {
+			return c, nil
+		}
+	}
+	return nil, fmt.Errorf("no compose tool found (install the docker compose plugin, or podman-compose for Podman)")
+}
+
+// ComposeEnv is the environment for compose commands. DOCKER_SOCK points
+// the compose files' socket mounts at the daemon in use, and DOCKER_HOST
+// makes docker compose talk to Podman when that is the daemon.
+func (c *Client) ComposeEnv() []string {
+	env := os.Environ()
+	if path := c.SocketPath(); path != "" && os.Getenv("DOCKER_SOCK") == "" {
+		env = append(env, "DOCKER_SOCK="+path)
+	}
+	if os.Getenv("DOCKER_HOST") == "" && c.endpoint.Context == "" && c.endpoint.Host != DefaultHost {
+		env = append(env, "DOCKER_HOST="+c.endpoint.Host)
+	}
+	return env
+}
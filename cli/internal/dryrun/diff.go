@@ -0,0 +1,167 @@
+package dryrun
+
+import (
+	"fmt"
+	"strings"
+)
+
+// context is the number of unchanged lines shown around each change
+const context = 3
+
+// maxCells bounds the diff table; larger files are reported as replaced
+const maxCells = 16 << 20
+
+type op struct {
+	kind byte // ' ', '-' or '+'
+	text string
+	a, b int // line index in old and new before this op
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// Diff returns a unified diff of old and new labelled with name, or ""
+// when they are equal. A missing file is diffed as empty (old nil).
+func Diff(name string, old, new []byte) string {
+	if string(old) == string(new) {
+		return ""
+	}
+	a, b := splitLines(string(old)), splitLines(string(new))
+	oldName, newName := "a/"+strings.TrimPrefix(name, "/"), "b/"+strings.TrimPrefix(name, "/")
+	if old == nil {
+		oldName = "/dev/null"
+	}
+	if new == nil {
+		newName = "/dev/null"
+	}
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", oldName, newName)
+
+	ops := diffLines(a, b)
+	if ops == nil {
+		fmt.Fprintf(&sb, "@@ -1,%d +1,%d @@ (too large to diff; whole file replaced)\n", len(a), len(b))
+		return sb.String()
+	}
+	for _, h := range hunks(ops) {
+		var aLen, bLen int
+		for _, o := range h {
+			if o.kind != '+' {
+				aLen++
+			}
+			if o.kind != '-' {
+				bLen++
+			}
+		}
+		aStart, bStart := h[0].a+1, h[0].b+1
+		if aLen == 0 {
+			aStart--
+		}
+		if bLen == 0 {
+			bStart--
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", aStart, aLen, bStart, bLen)
+		for _, o := range h {
+			sb.WriteByte(o.kind)
+			sb.WriteString(o.text)
+			if !strings.HasSuffix(o.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// diffLines computes an edit script from the longest common subsequence
+// of the lines between the common prefix and suffix; nil when too large
+func diffLines(a, b []string) []op {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ma, mb := a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+	n, m := len(ma), len(mb)
+	if (n+1)*(m+1) > maxCells {
+		return nil
+	}
+
+	// lcs[i*(m+1)+j] is the LCS length of ma[i:] and mb[j:]
+	lcs := make([]int32, (n+1)*(m+1))
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if ma[i] == mb[j] {
+				lcs[i*(m+1)+j] = lcs[(i+1)*(m+1)+j+1] + 1
+			} else if x, y := lcs[(i+1)*(m+1)+j], lcs[i*(m+1)+j+1]; x >= y {
+				lcs[i*(m+1)+j] = x
+			} else {
+				lcs[i*(m+1)+j] = y
+			}
+		}
+	}
+
+	ops := make([]op, 0, len(a)+len(b))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, op{' ', a[i], i, i})
+	}
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && ma[i] == mb[j]:
+			ops = append(ops, op{' ', ma[i], prefix + i, prefix + j})
+			i++
+			j++
+		case j >= m || (i < n && lcs[(i+1)*(m+1)+j] >= lcs[i*(m+1)+j+1]):
+			ops = append(ops, op{'-', ma[i], prefix + i, prefix + j})
+			i++
+		default:
+			ops = append(ops, op{'+', mb[j], prefix + i, prefix + j})
+			j++
+		}
+	}
+	for k := 0; k < suffix; k++ {
+		ops = append(ops, op{' ', a[len(a)-suffix+k], len(a) - suffix + k, len(b) - suffix + k})
+	}
+	return ops
+}
+
+// hunks groups the changes with their surrounding context, merging
+// changes whose context overlaps
+func hunks(ops []op) [][]op {
+	var out [][]op
+	start, end := -1, -1
+	for k, o := range ops {
+		if o.kind == ' ' {
+			continue
+		}
+		lo, hi := k-context, k+context+1
+		if lo < 0 {
+			lo = 0
+		}
+		if hi > len(ops) {
+			hi = len(ops)
+		}
+		if start >= 0 && lo <= end {
+			end = hi
+			continue
+		}
+		if start >= 0 {
+			out = append(out, ops[start:end])
+		}
+		start, end = lo, hi
+	}
+	if start >= 0 {
+		out = append(out, ops[start:end])
+	}
+	return out
+}
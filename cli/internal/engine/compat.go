@@ -0,0 +1,184 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selfupdate"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+)
+
+// SupportedAPISchema is the /health schema this CLI understands
+const SupportedAPISchema = engineclient.APISchema
+
+// How long the per-command check reuses a result. Unknown results (engine
+// down) expire sooner so a restarted engine is checked promptly.
+const (
+	compatCacheTTL        = time.Hour
+	compatUnknownCacheTTL = 5 * time.Minute
+)
+
+// Compatibility levels
+const (
+	CompatOK      = "ok"
+	CompatWarn    = "warn"
+	CompatFail    = "fail"
+	CompatUnknown = "unknown"
+)
+
+// Compat is the result of comparing the CLI with the running engine
+type Compat struct {
+	CLIVersion    string    `json:"cli_version"`
+	EngineVersion string    `json:"engine_version,omitempty"`
+	APISchema     int       `json:"api_schema,omitempty"`
+	Source        string    `json:"source,omitempty"`
+	Level         string    `json:"level"`
+	Message       string    `json:"message"`
+	Remediation   string    `json:"remediation,omitempty"`
+	Checked       time.Time `json:"checked"`
+}
+
+// DetectVersion finds the running engine version and /health schema. It
+// asks /health first and falls back to the image version label or tag of
+// the ai_engine container. source names where the version came from.
+func DetectVersion(ctx context.Context, baseURL string) (version string, schema int, source string, err error) {
+	if h, herr := engineclient.New(baseURL, "").Health(ctx); herr == nil && h.Version != "" {
+		return h.Version, h.APISchema, "/health", nil
+	} else if herr != nil {
+		err = herr
+	}
+
+	var info *docker.ContainerInfo
+	client, derr := docker.Default()
+	if derr == nil {
+		info, derr = client.Inspect(ctx, ContainerName)
+	}
+	if derr != nil {
+		if err == nil {
+			err = fmt.Errorf("engine does not report a version and %s is not inspectable", ContainerName)
+		}
+		return "", 0, "", err
+	}
+	if label := info.Config.Labels["org.opencontainers.image.version"]; label != "" {
+		return label, 0, "image label", nil
+	}
+	if image := info.Config.Image; image != "" {
+		if i := strings.LastIndex(image, ":"); i >= 0 && !strings.Contains(image[i:], "/") {
+			if tag := image[i+1:]; tag != "latest" {
+				return tag, 0, "image tag", nil
+			}
+		}
+	}
+	return "", 0, "", fmt.Errorf("%s image carries no version (built before version labels, or tagged latest)", ContainerName)
+}
+
+// CheckCompat compares a CLI and engine version. Different majors or /health
+// schemas are incompatible; a different minor works but is warned about.
+// Development builds cannot be compared.
+func CheckCompat(cliVersion, engineVersion string, schema int) Compat {
+	c := Compat{
+		CLIVersion:    cliVersion,
+		EngineVersion: engineVersion,
+		APISchema:     schema,
+		Level:         CompatOK,
+		Checked:       time.Now(),
+	}
+	cliMajor, cliMinor, cliOK := selfupdate.MajorMinor(cliVersion)
+	engMajor, engMinor, engOK := selfupdate.MajorMinor(engineVersion)
+
+	switch {
+	case schema > SupportedAPISchema:
+		c.Level = CompatFail
+		c.Message = fmt.Sprintf("engine %s uses /health schema %d; this CLI understands %d", engineVersion, schema, SupportedAPISchema)
+		c.Remediation = upgradeCLI(engineVersion)
+	case schema > 0 && schema < SupportedAPISchema:
+		c.Level = CompatFail
+		c.Message = fmt.Sprintf("engine %s uses /health schema %d; this CLI requires %d", engineVersion, schema, SupportedAPISchema)
+		c.Remediation = upgradeEngine(cliVersion)
+	case !cliOK || !engOK:
+		c.Level = CompatUnknown
+		c.Message = fmt.Sprintf("CLI %s, engine %s (development build, not compared)", cliVersion, orUnknown(engineVersion))
+	case cliMajor != engMajor:
+		c.Level = CompatFail
+		c.Message = fmt.Sprintf("CLI %s is incompatible with engine %s (major version differs)", cliVersion, engineVersion)
+		c.Remediation = upgradeOlder(cliVersion, engineVersion)
+	case cliMinor != engMinor:
+		c.Level = CompatWarn
+		c.Message = fmt.Sprintf("CLI %s and engine %s differ in minor version; newer checks may not apply", cliVersion, engineVersion)
+		c.Remediation = upgradeOlder(cliVersion, engineVersion)
+	default:
+		c.Message = fmt.Sprintf("CLI %s matches engine %s", cliVersion, engineVersion)
+	}
+	return c
+}
+
+// Check detects the engine version and compares it with cliVersion
+func Check(ctx context.Context, cliVersion string, env map[string]string) Compat {
+	version, schema, source, err := DetectVersion(ctx, BaseURL(env))
+	if err != nil {
+		return Compat{
+			CLIVersion: cliVersion,
+			Level:      CompatUnknown,
+			Message:    "engine version unavailable: " + err.Error(),
+			Checked:    time.Now(),
+		}
+	}
+	c := CheckCompat(cliVersion, version, schema)
+	c.Source = source
+	return c
+}
+
+// CachedCheck is Check reusing a recent result for the same CLI version, so
+// it is cheap enough to run before every command
+func CachedCheck(ctx context.Context, cliVersion string, env map[string]string) Compat {
+	path := filepath.Join(settings.Dir(), "compat.json")
+	var cached Compat
+	if data, err := os.ReadFile(path); err == nil && json.Unmarshal(data, &cached) == nil {
+		ttl := compatCacheTTL
+		if cached.Level == CompatUnknown {
+			ttl = compatUnknownCacheTTL
+		}
+		if cached.CLIVersion == cliVersion && time.Since(cached.Checked) < ttl {
+			return cached
+		}
+	}
+	c := Check(ctx, cliVersion, env)
+	if data, err := json.Marshal(c); err == nil && os.MkdirAll(settings.Dir(), 0755) == nil {
+		os.WriteFile(path, data, 0644)
+	}
+	return c
+}
+
+// upgradeOlder points at the upgrade for whichever side is older
+func upgradeOlder(cliVersion, engineVersion string) string {
+	if selfupdate.Compare(cliVersion, engineVersion) < 0 {
+		return upgradeCLI(engineVersion)
+	}
+	return upgradeEngine(cliVersion)
+}
+
+func upgradeCLI(engineVersion string) string {
+	return "Update the CLI: agent self-update --version " + engineVersion
+}
+
+func upgradeEngine(cliVersion string) string {
+	tag := cliVersion
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+	return fmt.Sprintf("Update the engine: git fetch --tags && git checkout %s && docker compose up -d --build ai-engine", tag)
+}
+
+func orUnknown(v string) string {
+	if v == "" {
+		return "unknown"
+	}
+	return v
+}
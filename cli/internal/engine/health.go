@@ -0,0 +1,24 @@
+package engine
+
+import "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+
+// ContainerName is the compose container name of the engine
+const ContainerName = "ai_engine"
+
+// BaseURL returns the engine health endpoint from HEALTH_BIND_HOST and
+// HEALTH_BIND_PORT (environment first, then env), defaulting to
+// http://127.0.0.1:15000. Wildcard bind addresses map to loopback.
+func BaseURL(env map[string]string) string {
+	return engineclient.FromEnv(env).BaseURL
+}
+
+// Token returns HEALTH_API_TOKEN (environment first, then env), required
+// by the engine's control endpoints for non-local requests
+func Token(env map[string]string) string {
+	return engineclient.FromEnv(env).Token
+}
+
+// Client returns the API client of the engine configured in env
+func Client(env map[string]string) *engineclient.Client {
+	return engineclient.FromEnv(env)
+}
@@ -0,0 +1,58 @@
+package engine
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+)
+
+// instanceName matches ai_engine and the scaled ai_engine_N containers
+var instanceName = regexp.MustCompile(`^` + ContainerName + `(?:_(\d+))?$`)
+
+// Instance is a running engine container
+type Instance struct {
+	Container       string
+	Index           int
+	HealthURL       string
+	AudioSocketPort string
+	AppName         string
+}
+
+// Instances lists the engine containers (ai_engine, ai_engine_2, ...) in
+// index order with the endpoints their environment configures. A
+// single-engine install returns just ai_engine.
+func Instances(ctx context.Context) ([]Instance, error) {
+	client, err := docker.Default()
+	if err != nil {
+		return nil, err
+	}
+	names, err := client.ContainerNames(ctx, docker.ListOptions{Name: ContainerName})
+	if err != nil {
+		return nil, err
+	}
+	var instances []Instance
+	for _, name := range names {
+		m := instanceName.FindStringSubmatch(name)
+		if m == nil {
+			continue
+		}
+		inst := Instance{Container: name, Index: 1}
+		if m[1] != "" {
+			inst.Index, _ = strconv.Atoi(m[1])
+		}
+		env := map[string]string{}
+		if info, err := client.Inspect(ctx, name); err == nil {
+			env = info.Env()
+		}
+		inst.HealthURL = engineclient.URL(env["HEALTH_BIND_HOST"], env["HEALTH_BIND_PORT"])
+		inst.AudioSocketPort = env["AUDIOSOCKET_PORT"]
+		inst.AppName = env["ASTERISK_APP_NAME"]
+		instances = append(instances, inst)
+	}
+	sort.Slice(instances, func(i, j int) bool { return instances[i].Index < instances[j].Index })
+	return instances, nil
+}
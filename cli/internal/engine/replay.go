@@ -0,0 +1,32 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+)
+
+// CaptureDir is where the engine writes per-call audio captures
+const CaptureDir = "/tmp/ai-engine-captures"
+
+// CallerCapture reads a call's captured caller audio (WAV) from the
+// engine container
+func CallerCapture(ctx context.Context, container, callID string) ([]byte, error) {
+	if strings.ContainsAny(callID, "/\\") || strings.HasPrefix(callID, ".") {
+		return nil, fmt.Errorf("invalid call ID %q", callID)
+	}
+	client, err := docker.Default()
+	if err != nil {
+		return nil, err
+	}
+	result, err := client.Exec(ctx, container, nil, "cat", CaptureDir+"/"+callID+"/caller_inbound.wav")
+	if err != nil {
+		if _, ok := err.(*docker.ExitError); ok {
+			return nil, fmt.Errorf("no caller audio captured for %s in %s (DIAG_ENABLE_TAPS=true keeps captures)", callID, container)
+		}
+		return nil, err
+	}
+	return result.Stdout, nil
+}
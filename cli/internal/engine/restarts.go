@@ -0,0 +1,293 @@
+package engine
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+)
+
+// Crash causes of an engine exit
+const (
+	CauseOOM        = "oom"
+	CausePanic      = "panic"
+	CauseConfig     = "config_error"
+	CauseDependency = "dependency_unreachable"
+	CauseStopped    = "stopped"
+	CauseUnknown    = "unknown"
+)
+
+const (
+	// crashLoopRestarts within crashLoopWindow is a crash loop
+	crashLoopRestarts = 3
+	crashLoopWindow   = 10 * time.Minute
+	// stopGrace is how long before an exit a docker kill or stop counts
+	// as its reason
+	stopGrace = 30 * time.Second
+	// exitLogWindow bounds the logs read before an exit when the run's
+	// start is unknown
+	exitLogWindow = 10 * time.Minute
+)
+
+// Exit is one exit of the engine container, classified
+type Exit struct {
+	Time     time.Time `json:"time"`
+	ExitCode int       `json:"exit_code"`
+	// Signal is the signal an exit code above 128 stands for
+	Signal string `json:"signal,omitempty"`
+	OOM    bool   `json:"oom_killed,omitempty"`
+	// StoppedBy is the docker action that ended the run (kill, stop), if any
+	StoppedBy string   `json:"stopped_by,omitempty"`
+	Cause     string   `json:"cause"`
+	Evidence  string   `json:"evidence,omitempty"`
+	NextSteps []string `json:"next_steps,omitempty"`
+	LastLines []string `json:"last_lines,omitempty"`
+}
+
+// RestartHistory is the engine container's state and recent exits
+type RestartHistory struct {
+	Container    string    `json:"container"`
+	Status       string    `json:"status"`
+	RestartCount int       `json:"restart_count"`
+	StartedAt    time.Time `json:"started_at"`
+	// CrashLoop is set when the container restarted crashLoopRestarts
+	// times within crashLoopWindow, or docker is restarting it now
+	CrashLoop bool `json:"crash_loop"`
+	// Exits are the most recent exits, newest first
+	Exits []Exit `json:"exits"`
+	// DaemonError is the error docker recorded starting the container
+	DaemonError string `json:"daemon_error,omitempty"`
+}
+
+// Restarts inspects the last limit exits of container since since: the
+// daemon's events, the exit codes and the last lines logged before each
+// exit, and classifies their causes
+func Restarts(ctx context.Context, client *docker.Client, container string, since time.Time, limit, lines int) (*RestartHistory, error) {
+	info, err := client.Inspect(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	h := &RestartHistory{
+		Container:    info.Name,
+		Status:       info.State.Status,
+		RestartCount: info.RestartCount,
+		StartedAt:    info.State.StartedAt,
+		DaemonError:  info.State.Error,
+	}
+
+	events, err := client.Events(ctx, docker.EventsOptions{
+		Since: since,
+		Filters: map[string][]string{
+			"type":      {"container"},
+			"container": {container},
+			"event":     {"start", "die", "kill", "stop", "oom"},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var exits []Exit
+	var dies []time.Time
+	lastStart := time.Time{}
+	var stoppedBy string
+	var stoppedAt time.Time
+	oom := false
+	for _, ev := range events {
+		switch ev.Action {
+		case "start":
+			lastStart = ev.Time()
+			stoppedBy, oom = "", false
+		case "kill", "stop":
+			// docker stop sends SIGTERM as a kill event first
+			if stoppedBy == "" || ev.Action == "stop" {
+				stoppedBy = ev.Action
+				if sig := ev.Actor.Attributes["signal"]; sig != "" && ev.Action == "kill" {
+					stoppedBy = "kill (signal " + sig + ")"
+				}
+			}
+			stoppedAt = ev.Time()
+		case "oom":
+			oom = true
+		case "die":
+			code, _ := strconv.Atoi(ev.Actor.Attributes["exitCode"])
+			e := Exit{Time: ev.Time(), ExitCode: code, OOM: oom, Signal: exitSignal(code)}
+			if stoppedBy != "" && e.Time.Sub(stoppedAt) <= stopGrace {
+				e.StoppedBy = stoppedBy
+			}
+			from := lastStart
+			if from.IsZero() || e.Time.Sub(from) > exitLogWindow {
+				from = e.Time.Add(-exitLogWindow)
+			}
+			e.LastLines = lastLines(ctx, client, container, from, e.Time.Add(time.Second), lines)
+			exits = append(exits, e)
+			dies = append(dies, e.Time)
+			stoppedBy, oom = "", false
+		}
+	}
+
+	// The daemon's events may have rotated out: the last exit is still in
+	// the container's state
+	if len(exits) == 0 && !info.State.FinishedAt.IsZero() && info.State.FinishedAt.After(since) {
+		e := Exit{
+			Time:     info.State.FinishedAt,
+			ExitCode: info.State.ExitCode,
+			OOM:      info.State.OOMKilled,
+			Signal:   exitSignal(info.State.ExitCode),
+		}
+		e.LastLines = lastLines(ctx, client, container, e.Time.Add(-exitLogWindow), e.Time.Add(time.Second), lines)
+		exits = append(exits, e)
+	}
+	if info.State.OOMKilled && len(exits) > 0 {
+		exits[len(exits)-1].OOM = true
+	}
+
+	for i := range exits {
+		Classify(&exits[i])
+	}
+	sort.Slice(exits, func(i, j int) bool { return exits[i].Time.After(exits[j].Time) })
+	if limit > 0 && len(exits) > limit {
+		exits = exits[:limit]
+	}
+	h.Exits = exits
+	h.CrashLoop = info.State.Restarting || crashLoop(dies)
+	return h, nil
+}
+
+// crashLoop reports whether crashLoopRestarts exits fall within
+// crashLoopWindow
+func crashLoop(dies []time.Time) bool {
+	for i := crashLoopRestarts - 1; i < len(dies); i++ {
+		if dies[i].Sub(dies[i-crashLoopRestarts+1]) <= crashLoopWindow {
+			return true
+		}
+	}
+	return false
+}
+
+// lastLines returns up to n log lines of the container between from and
+// until
+func lastLines(ctx context.Context, client *docker.Client, container string, from, until time.Time, n int) []string {
+	data, err := client.LogsBytes(ctx, container, docker.LogsOptions{Since: from, Until: until})
+	if err != nil {
+		return nil
+	}
+	var out []string
+	for _, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if strings.TrimSpace(line) != "" {
+			out = append(out, strings.TrimRight(line, "\r"))
+		}
+	}
+	if len(out) > n {
+		out = out[len(out)-n:]
+	}
+	return out
+}
+
+// exitSignal names the signal of exit codes 128+n
+func exitSignal(code int) string {
+	switch code {
+	case 134:
+		return "SIGABRT"
+	case 137:
+		return "SIGKILL"
+	case 139:
+		return "SIGSEGV"
+	case 143:
+		return "SIGTERM"
+	}
+	return ""
+}
+
+var (
+	configPatterns     = regexp.MustCompile(`(?i)configuration validation failed|configuration errors|yaml\.(scanner|parser|constructor)|validationerror|failed to load config|ai-agent\.yaml|missing required|invalid configuration`)
+	dependencyPatterns = regexp.MustCompile(`(?i)failed to connect to ari|connection refused|cannot connect to host|clientconnectorerror|name or service not known|name resolution|connecttimeout|timed out connecting|no route to host|network is unreachable`)
+	panicPatterns      = regexp.MustCompile(`Traceback \(most recent call last\)|Fatal Python error|Segmentation fault|core dumped|panic:`)
+	// exceptionLine is the last line of a Python traceback, e.g.
+	// "RuntimeError: Configuration errors: [...]"
+	exceptionLine = regexp.MustCompile(`^([A-Za-z_][A-Za-z0-9_.]*(Error|Exception|Exit|Interrupt)):\s*(.*)$`)
+)
+
+// Classify sets the cause, evidence and next steps of an exit from its
+// exit code, the OOM flag and the last lines logged
+func Classify(e *Exit) {
+	evidence := func(re *regexp.Regexp) string {
+		// The exception closing a traceback says more than its first line
+		for i := len(e.LastLines) - 1; i >= 0; i-- {
+			line := strings.TrimSpace(e.LastLines[i])
+			if exceptionLine.MatchString(line) && (re == panicPatterns || re.MatchString(line)) {
+				return truncate(line, 200)
+			}
+		}
+		for i := len(e.LastLines) - 1; i >= 0; i-- {
+			if re.MatchString(e.LastLines[i]) {
+				return truncate(strings.TrimSpace(e.LastLines[i]), 200)
+			}
+		}
+		return ""
+	}
+
+	switch {
+	case e.OOM:
+		e.Cause = CauseOOM
+		e.Evidence = "the kernel killed the container for exceeding its memory limit"
+		e.NextSteps = []string{
+			"Check memory use: docker stats ai_engine",
+			"Raise the container's memory limit (mem_limit or deploy.resources.limits.memory in docker-compose.yml), or the host's memory",
+			"Local models (local_ai_server) and many concurrent calls use the most memory: 'agent doctor' compares them with the hardware",
+		}
+	case e.StoppedBy != "" && (e.ExitCode == 0 || e.ExitCode == 137 || e.ExitCode == 143):
+		e.Cause = CauseStopped
+		e.Evidence = "stopped through docker (" + e.StoppedBy + "), e.g. docker compose restart or up"
+		e.NextSteps = []string{"Not a crash. Use 'agent service restart ai_engine' for restarts that wait for active calls"}
+	case configPatterns.MatchString(strings.Join(e.LastLines, "\n")):
+		e.Cause = CauseConfig
+		e.Evidence = evidence(configPatterns)
+		e.NextSteps = []string{
+			"Validate the configuration: agent config validate",
+			"Fix config/ai-agent.yaml and .env, then: docker compose up -d ai_engine",
+		}
+	case dependencyPatterns.MatchString(strings.Join(e.LastLines, "\n")):
+		e.Cause = CauseDependency
+		e.Evidence = evidence(dependencyPatterns)
+		e.NextSteps = []string{
+			"Check Asterisk and provider connectivity: agent doctor",
+			"Check ASTERISK_HOST and the ARI credentials in .env, and that the host is reachable from the container's network",
+			"Start dependencies before the engine (depends_on in docker-compose.yml)",
+		}
+	case panicPatterns.MatchString(strings.Join(e.LastLines, "\n")) || e.Signal == "SIGSEGV" || e.Signal == "SIGABRT":
+		e.Cause = CausePanic
+		e.Evidence = evidence(panicPatterns)
+		if e.Evidence == "" {
+			e.Evidence = "exited on " + e.Signal
+		}
+		e.NextSteps = []string{
+			"Find the failing code in the traceback: docker logs --tail 200 ai_engine",
+			"Check for a fix in a newer release: agent version; report it with the traceback if none",
+		}
+	case e.Signal == "SIGKILL":
+		e.Cause = CauseOOM
+		e.Evidence = "killed (SIGKILL) without a docker stop: usually the kernel's OOM killer"
+		e.NextSteps = []string{
+			"Check the kernel log: dmesg -T | grep -i -E 'killed process|out of memory'",
+			"Check memory use: docker stats ai_engine",
+		}
+	default:
+		e.Cause = CauseUnknown
+		if e.ExitCode == 0 {
+			e.Evidence = "exited cleanly without a docker stop"
+		}
+		e.NextSteps = []string{"Read the log before the exit: docker logs --tail 200 ai_engine"}
+	}
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
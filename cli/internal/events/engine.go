@@ -0,0 +1,166 @@
+package events
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+)
+
+// Engine event types, as the engine POSTs them to 'agent events listen'
+const (
+	EngineCallStarted = "call_started"
+	EngineCallEnded   = "call_ended"
+	EngineError       = "error"
+)
+
+// EngineTypes lists the engine event types
+var EngineTypes = []string{EngineCallStarted, EngineCallEnded, EngineError}
+
+// EngineEvent is one event emitted by the engine. Fields not meaningful
+// for the type are empty.
+type EngineEvent struct {
+	Type   string    `json:"type"`
+	CallID string    `json:"call_id"`
+	Time   time.Time `json:"time"`
+
+	// call_started
+	CallerNumber    string `json:"caller_number,omitempty"`
+	CallerName      string `json:"caller_name,omitempty"`
+	Dialed          string `json:"dialed,omitempty"`
+	Channel         string `json:"channel,omitempty"`
+	Context         string `json:"context,omitempty"`
+	DialplanContext string `json:"dialplan_context,omitempty"`
+	Tenant          string `json:"tenant,omitempty"`
+
+	// call_ended
+	Status      string  `json:"status,omitempty"`
+	HangupCause int     `json:"hangup_cause,omitempty"`
+	DurationS   float64 `json:"duration_s,omitempty"`
+
+	// error; Severity is critical, warning (default) or info
+	Component string `json:"component,omitempty"`
+	Message   string `json:"message,omitempty"`
+	Severity  string `json:"severity,omitempty"`
+}
+
+// DecodeEngineEvents reads one event or a JSON array of events. Events
+// without a time get now.
+func DecodeEngineEvents(body []byte, now time.Time) ([]EngineEvent, error) {
+	body = bytes.TrimSpace(body)
+	var evs []EngineEvent
+	if bytes.HasPrefix(body, []byte("[")) {
+		if err := json.Unmarshal(body, &evs); err != nil {
+			return nil, err
+		}
+	} else {
+		var ev EngineEvent
+		if err := json.Unmarshal(body, &ev); err != nil {
+			return nil, err
+		}
+		evs = []EngineEvent{ev}
+	}
+	for i := range evs {
+		ev := &evs[i]
+		if !knownEngineType(ev.Type) {
+			return nil, fmt.Errorf("event %d: unknown type %q (use %s)", i, ev.Type, strings.Join(EngineTypes, ", "))
+		}
+		if ev.CallID == "" && ev.Type != EngineError {
+			return nil, fmt.Errorf("event %d: %s without call_id", i, ev.Type)
+		}
+		if ev.Time.IsZero() {
+			ev.Time = now
+		}
+	}
+	return evs, nil
+}
+
+func knownEngineType(t string) bool {
+	for _, known := range EngineTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// VerifySignature checks a request signed like the CLI's own webhooks
+// (notify.Sign over "<timestamp>.<body>", signature "sha256=<hex>") and
+// rejects timestamps more than maxSkew from now, so captured requests
+// cannot be replayed
+func VerifySignature(secret, timestamp, signature string, body []byte, now time.Time, maxSkew time.Duration) error {
+	if timestamp == "" || signature == "" {
+		return fmt.Errorf("missing %s or %s header", notify.HeaderTimestamp, notify.HeaderSignature)
+	}
+	secs, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid %s %q", notify.HeaderTimestamp, timestamp)
+	}
+	if skew := now.Sub(time.Unix(secs, 0)); skew > maxSkew || skew < -maxSkew {
+		return fmt.Errorf("timestamp is %s off (allowed %s)", skew.Round(time.Second), maxSkew)
+	}
+	want := "sha256=" + notify.Sign(secret, timestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(want)) {
+		return fmt.Errorf("signature mismatch")
+	}
+	return nil
+}
+
+// Notification returns the notification for an engine event: errors at
+// their severity (default warning), failed calls as warnings and the
+// rest as info, all of kind engine_event
+func (ev EngineEvent) Notification() notify.Event {
+	n := notify.Event{
+		Kind:     notify.EventEngineEvent,
+		Severity: notify.SeverityInfo,
+		CallID:   ev.CallID,
+		Time:     ev.Time,
+		Fields:   map[string]string{"Type": ev.Type},
+		Data:     ev,
+	}
+	switch ev.Type {
+	case EngineCallStarted:
+		n.Title = "Call " + ev.CallID + " started"
+		if ev.CallerNumber != "" {
+			n.Fields["Caller"] = ev.CallerNumber
+		}
+		if ev.Dialed != "" {
+			n.Fields["Dialed"] = ev.Dialed
+		}
+	case EngineCallEnded:
+		n.Title = "Call " + ev.CallID + " ended"
+		if ev.Status != "" {
+			n.Title += " (" + ev.Status + ")"
+			n.Fields["Status"] = ev.Status
+		}
+		if ev.Status == "failed" {
+			n.Severity = notify.SeverityWarning
+		}
+		if ev.HangupCause != 0 {
+			n.Fields["Hangup cause"] = strconv.Itoa(ev.HangupCause)
+		}
+	case EngineError:
+		n.Severity = strings.ToLower(ev.Severity)
+		if n.Severity != notify.SeverityCritical && n.Severity != notify.SeverityInfo {
+			n.Severity = notify.SeverityWarning
+		}
+		n.Title = "Engine error"
+		if ev.Component != "" {
+			n.Title += " in " + ev.Component
+			n.Fields["Component"] = ev.Component
+		}
+		if ev.CallID != "" {
+			n.Title += " on call " + ev.CallID
+		}
+		n.Text = ev.Message
+	}
+	if ev.Tenant != "" {
+		n.Fields["Tenant"] = ev.Tenant
+	}
+	return n
+}
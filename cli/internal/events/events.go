@@ -0,0 +1,73 @@
+// Package events carries live call events from the log follower in
+// 'agent serve' to the clients streaming them
+package events
+
+import (
+	"sync"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+)
+
+// Event types
+const (
+	CallStarted   = engineclient.EventCallStarted
+	TurnCompleted = engineclient.EventTurnCompleted
+	CallEnded     = engineclient.EventCallEnded
+	CallFailed    = engineclient.EventCallFailed
+)
+
+// Types lists every event type
+var Types = engineclient.EventTypes
+
+// Event is one live call event, as engineclient subscribers receive it
+type Event = engineclient.Event
+
+// Bus fans events out to subscribers. A subscriber that falls behind
+// loses events rather than blocking the publisher.
+type Bus struct {
+	mu   sync.Mutex
+	subs map[chan Event]bool
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{subs: make(map[chan Event]bool)}
+}
+
+// Subscribe returns a channel receiving every event published from now
+// on, buffering up to buffer events, and the function that unsubscribes
+func (b *Bus) Subscribe(buffer int) (<-chan Event, func()) {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	b.subs[ch] = true
+	b.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subs, ch)
+			b.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends ev to every subscriber with room in its buffer
+func (b *Bus) Publish(ev Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
+
+// Subscribers returns the number of current subscribers
+func (b *Bus) Subscribers() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
@@ -0,0 +1,549 @@
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// Port states of an audit
+const (
+	Allowed = "allowed"
+	Blocked = "blocked"
+	// Partial is a range the rules open only part of
+	Partial = "partial"
+	// Local ports are only used over loopback
+	Local   = "local"
+	Unknown = "unknown"
+)
+
+// Backends in the order they are detected: ufw and firewalld manage
+// nftables or iptables underneath, so their own view comes first
+const (
+	UFW       = "ufw"
+	Firewalld = "firewalld"
+	NFTables  = "nftables"
+	IPTables  = "iptables"
+)
+
+// Backends lists the supported backends
+var Backends = []string{UFW, Firewalld, NFTables, IPTables}
+
+// rule is one inbound rule reduced to what the audit needs
+type rule struct {
+	proto    string // tcp, udp or empty for both
+	from, to int    // 0 for every port
+	accept   bool
+	source   string // empty for anywhere
+	text     string
+}
+
+func (r rule) covers(proto string, port int) bool {
+	if r.proto != "" && r.proto != proto {
+		return false
+	}
+	return r.from == 0 || (port >= r.from && port <= r.to)
+}
+
+// Ruleset is the inbound rules of the active firewall
+type Ruleset struct {
+	Backend string `json:"backend"`
+	// Active is false when no firewall filters inbound traffic
+	Active bool `json:"active"`
+	// DefaultAccept is the policy of traffic no rule matches
+	DefaultAccept bool `json:"default_accept"`
+	// Table, Chain and Family locate the nftables input chain
+	Family string `json:"-"`
+	Table  string `json:"-"`
+	Chain  string `json:"-"`
+	// Zone is the firewalld zone audited
+	Zone  string   `json:"zone,omitempty"`
+	Notes []string `json:"notes,omitempty"`
+	rules []rule
+}
+
+// Status is the audit of one required port
+type Status struct {
+	Port
+	State  string `json:"state"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// runner runs a command and returns its output; replaced when auditing
+// from saved output
+type runner func(ctx context.Context, name string, args ...string) (string, error)
+
+func run(ctx context.Context, name string, args ...string) (string, error) {
+	if err := remote.LookPath(name); err != nil {
+		return "", err
+	}
+	out, err := selflog.CombinedOutput(remote.Command(ctx, name, args...))
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return string(out), fmt.Errorf("%s: %s", name, msg)
+	}
+	return string(out), nil
+}
+
+// Detect finds the active firewall and reads its inbound rules. backend
+// forces one of Backends; empty tries each in order.
+func Detect(ctx context.Context, backend string) (*Ruleset, error) {
+	loaders := map[string]func(context.Context, runner) (*Ruleset, error){
+		UFW:       loadUFW,
+		Firewalld: loadFirewalld,
+		NFTables:  loadNFTables,
+		IPTables:  loadIPTables,
+	}
+	if backend != "" {
+		load, ok := loaders[backend]
+		if !ok {
+			return nil, fmt.Errorf("unknown backend %q (use %s)", backend, strings.Join(Backends, ", "))
+		}
+		return load(ctx, run)
+	}
+	var errs []string
+	for _, name := range Backends {
+		rs, err := loaders[name](ctx, run)
+		if err != nil {
+			errs = append(errs, err.Error())
+			continue
+		}
+		if rs.Active {
+			return rs, nil
+		}
+	}
+	rs := &Ruleset{DefaultAccept: true}
+	for _, e := range errs {
+		// Missing tools are expected; other errors (permissions) are not
+		if !strings.Contains(e, "executable file not found") {
+			rs.Notes = append(rs.Notes, e)
+		}
+	}
+	return rs, nil
+}
+
+// Audit checks each port against the ruleset: the first rule matching a
+// port decides, as in ufw, iptables and nftables chains, else the policy
+func (rs *Ruleset) Audit(ports []Port) []Status {
+	var out []Status
+	for _, p := range ports {
+		s := Status{Port: p}
+		switch {
+		case p.Local:
+			s.State = Local
+			s.Detail = "Asterisk is on this host; traffic stays on loopback"
+		case !rs.Active && len(rs.Notes) > 0:
+			// A firewall may be there, its rules unreadable
+			s.State = Unknown
+			s.Detail = "rules unreadable; run as root"
+		case !rs.Active:
+			s.State = Allowed
+			s.Detail = "no host firewall active"
+		default:
+			s.State, s.Detail = rs.check(p)
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func (rs *Ruleset) check(p Port) (string, string) {
+	open := 0
+	total := p.To - p.From + 1
+	var matched *rule
+	var sources []string
+	for port := p.From; port <= p.To; port++ {
+		accept := rs.DefaultAccept
+		var hit *rule
+		for i := range rs.rules {
+			if rs.rules[i].covers(p.Proto, port) {
+				hit = &rs.rules[i]
+				accept = hit.accept
+				break
+			}
+		}
+		if accept {
+			open++
+			if hit != nil && hit.source != "" && !contains(sources, hit.source) {
+				sources = append(sources, hit.source)
+			}
+		}
+		if matched == nil && hit != nil {
+			matched = hit
+		}
+	}
+	policy := "default policy " + map[bool]string{true: "accept", false: "drop"}[rs.DefaultAccept]
+	if rs.Backend == Firewalld && !rs.DefaultAccept {
+		policy = "not open in zone " + rs.Zone
+	}
+	switch {
+	case open == total:
+		detail := policy
+		if matched != nil {
+			detail = matched.text
+		}
+		if len(sources) > 0 {
+			detail += " (only from " + strings.Join(sources, ", ") + ")"
+			if p.Source != "" && !isLoopback(p.Source) && !contains(sources, p.Source) {
+				return Partial, detail + "; needs " + p.Source
+			}
+		}
+		return Allowed, detail
+	case open == 0:
+		if matched != nil && !matched.accept {
+			return Blocked, matched.text
+		}
+		return Blocked, policy
+	}
+	return Partial, fmt.Sprintf("%d of %d ports open", open, total)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// loadUFW reads "ufw status verbose"
+func loadUFW(ctx context.Context, run runner) (*Ruleset, error) {
+	out, err := run(ctx, "ufw", "status", "verbose")
+	if err != nil {
+		return nil, err
+	}
+	return parseUFW(out), nil
+}
+
+var ufwPort = regexp.MustCompile(`^(\d+(?:[:,]\d+)*)(?:/(tcp|udp))?$`)
+
+func parseUFW(out string) *Ruleset {
+	rs := &Ruleset{Backend: UFW}
+	table := false
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "Status:"):
+			rs.Active = strings.Contains(line, "active") && !strings.Contains(line, "inactive")
+		case strings.HasPrefix(line, "Default:"):
+			// Default: deny (incoming), allow (outgoing), disabled (routed)
+			for _, part := range strings.Split(strings.TrimPrefix(line, "Default:"), ",") {
+				if strings.Contains(part, "(incoming)") {
+					rs.DefaultAccept = strings.HasPrefix(strings.TrimSpace(part), "allow")
+				}
+			}
+		case strings.HasPrefix(line, "--"):
+			table = true
+		case table && line != "":
+			if strings.Contains(line, "(v6)") {
+				continue
+			}
+			if i := strings.Index(line, "#"); i >= 0 {
+				line = strings.TrimSpace(line[:i])
+			}
+			fields := strings.Fields(line)
+			if len(fields) < 3 {
+				continue
+			}
+			line = strings.Join(fields, " ")
+			rest := fields[2:]
+			if rest[0] == "OUT" || rest[0] == "FWD" {
+				continue
+			}
+			if rest[0] == "IN" {
+				rest = rest[1:]
+			}
+			source := strings.Join(rest, " ")
+			if source == "Anywhere" {
+				source = ""
+			}
+			action := strings.ToUpper(fields[1])
+			if fields[0] == "Anywhere" {
+				// Every port, usually from one source
+				rs.rules = append(rs.rules, rule{accept: action == "ALLOW" || action == "LIMIT", source: source, text: "ufw: " + line})
+				continue
+			}
+			m := ufwPort.FindStringSubmatch(fields[0])
+			if m == nil {
+				// App profiles (OpenSSH) are not resolved
+				continue
+			}
+			for _, r := range portRules(m[1], ":") {
+				r.proto = m[2]
+				r.accept = action == "ALLOW" || action == "LIMIT"
+				r.source = source
+				r.text = "ufw: " + line
+				rs.rules = append(rs.rules, r)
+			}
+		}
+	}
+	return rs
+}
+
+// portRules splits "80,443,10000:20000" into rules of one range each
+func portRules(spec, rangeSep string) []rule {
+	var out []rule
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		bounds := strings.SplitN(part, rangeSep, 2)
+		from, err := strconv.Atoi(bounds[0])
+		if err != nil {
+			continue
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = strconv.Atoi(bounds[1]); err != nil {
+				continue
+			}
+		}
+		out = append(out, rule{from: from, to: to})
+	}
+	return out
+}
+
+// loadFirewalld reads the ports and services of firewalld's default zone
+func loadFirewalld(ctx context.Context, run runner) (*Ruleset, error) {
+	state, err := run(ctx, "firewall-cmd", "--state")
+	rs := &Ruleset{Backend: Firewalld}
+	if err != nil {
+		if strings.Contains(state, "not running") {
+			return rs, nil
+		}
+		return nil, err
+	}
+	rs.Active = strings.TrimSpace(state) == "running"
+	zone, err := run(ctx, "firewall-cmd", "--get-default-zone")
+	if err != nil {
+		return nil, err
+	}
+	rs.Zone = strings.TrimSpace(zone)
+	list, err := run(ctx, "firewall-cmd", "--zone="+rs.Zone, "--list-all")
+	if err != nil {
+		return nil, err
+	}
+	parseFirewalld(rs, list, func(service string) string {
+		info, _ := run(ctx, "firewall-cmd", "--info-service="+service)
+		return info
+	})
+	return rs, nil
+}
+
+// parseFirewalld reads "firewall-cmd --list-all"; service looks up the
+// ports of a service
+func parseFirewalld(rs *Ruleset, list string, service func(string) string) {
+	fields := listFields(list)
+	// Zones with target ACCEPT let everything in; default, DROP and REJECT
+	// only what is listed
+	rs.DefaultAccept = fields["target"] == "ACCEPT"
+	add := func(spec, why string) {
+		for _, p := range strings.Fields(spec) {
+			parts := strings.SplitN(p, "/", 2)
+			if len(parts) != 2 {
+				continue
+			}
+			for _, r := range portRules(parts[0], "-") {
+				r.proto = parts[1]
+				r.accept = true
+				r.text = "firewalld zone " + rs.Zone + ": " + why
+				rs.rules = append(rs.rules, r)
+			}
+		}
+	}
+	add(fields["ports"], "port "+fields["ports"])
+	for _, name := range strings.Fields(fields["services"]) {
+		add(listFields(service(name))["ports"], "service "+name)
+	}
+	if rich := fields["rich rules"]; rich != "" {
+		rs.Notes = append(rs.Notes, "rich rules in zone "+rs.Zone+" are not audited")
+	}
+	rs.Notes = append(rs.Notes, "audited firewalld zone "+rs.Zone+"; interfaces in other zones follow their own rules")
+}
+
+// listFields reads the "key: value" lines of firewall-cmd output
+func listFields(out string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		if i := strings.Index(line, ":"); i > 0 {
+			fields[strings.TrimSpace(line[:i])] = strings.TrimSpace(line[i+1:])
+		}
+	}
+	return fields
+}
+
+// loadNFTables reads "nft list ruleset"
+func loadNFTables(ctx context.Context, run runner) (*Ruleset, error) {
+	out, err := run(ctx, "nft", "list", "ruleset")
+	if err != nil {
+		return nil, err
+	}
+	return parseNFTables(out), nil
+}
+
+var (
+	nftTable  = regexp.MustCompile(`^table\s+(\S+)\s+(\S+)\s*\{`)
+	nftChain  = regexp.MustCompile(`^chain\s+(\S+)\s*\{`)
+	nftHook   = regexp.MustCompile(`type\s+filter\s+hook\s+input\b.*policy\s+(accept|drop)`)
+	nftDport  = regexp.MustCompile(`\b(tcp|udp|th)\s+dport\s+(\{[^}]*\}|\S+)`)
+	nftSaddr  = regexp.MustCompile(`\bip6?\s+saddr\s+(\{[^}]*\}|\S+)`)
+	nftL4     = regexp.MustCompile(`meta\s+l4proto\s+(tcp|udp)`)
+	nftVerdit = regexp.MustCompile(`\b(accept|drop|reject)\b`)
+	// Loopback, established connections and ICMP do not decide new
+	// inbound traffic to a service port
+	nftSkip = regexp.MustCompile(`iif(name)?\s+"?lo"?\b|ct\s+state|\bjump\b|\bgoto\b|icmp(v6)?\s+type|l4proto\s+(ipv6-)?icmp|protocol\s+icmp`)
+)
+
+func parseNFTables(out string) *Ruleset {
+	rs := &Ruleset{Backend: NFTables}
+	family, table, chain := "", "", ""
+	inInput := false
+	depth := 0
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if m := nftTable.FindStringSubmatch(line); m != nil {
+			family, table = m[1], m[2]
+		} else if m := nftChain.FindStringSubmatch(line); m != nil {
+			chain = m[1]
+			inInput = false
+		} else if m := nftHook.FindStringSubmatch(line); m != nil {
+			// The first input hook is audited; docker's and other tables'
+			// hooks rarely filter inbound service ports
+			if rs.Chain == "" {
+				inInput = true
+				rs.Active = true
+				rs.DefaultAccept = m[1] == "accept"
+				rs.Family, rs.Table, rs.Chain = family, table, chain
+			}
+		} else if inInput && line != "}" && line != "" {
+			if r, ok := nftRule(line); ok {
+				rs.rules = append(rs.rules, r...)
+			}
+		}
+		depth += strings.Count(line, "{") - strings.Count(line, "}")
+		if depth <= 1 {
+			inInput = false
+		}
+	}
+	if rs.Active && rs.DefaultAccept && len(rs.rules) == 0 {
+		// An accept-all input chain filters nothing
+		rs.Active = false
+	}
+	return rs
+}
+
+// nftRule reduces one nftables rule of an input chain
+func nftRule(line string) ([]rule, bool) {
+	v := nftVerdit.FindStringSubmatch(line)
+	if v == nil || nftSkip.MatchString(line) {
+		return nil, false
+	}
+	accept := v[1] == "accept"
+	source := ""
+	if m := nftSaddr.FindStringSubmatch(line); m != nil {
+		source = strings.Trim(m[1], "{} ")
+	}
+	m := nftDport.FindStringSubmatch(line)
+	if m == nil {
+		if strings.Contains(line, "dport") || strings.Contains(line, "saddr") {
+			return nil, false
+		}
+		// A catch-all drop or reject closes everything after it
+		r := rule{accept: accept, text: "nftables " + line}
+		if l4 := nftL4.FindStringSubmatch(line); l4 != nil {
+			r.proto = l4[1]
+		}
+		return []rule{r}, true
+	}
+	proto := m[1]
+	if proto == "th" {
+		proto = ""
+		if l4 := nftL4.FindStringSubmatch(line); l4 != nil {
+			proto = l4[1]
+		}
+	}
+	var out []rule
+	for _, r := range portRules(strings.Replace(strings.Trim(m[2], "{} "), " ", "", -1), "-") {
+		r.proto, r.accept, r.source = proto, accept, source
+		r.text = "nftables " + line
+		out = append(out, r)
+	}
+	return out, len(out) > 0
+}
+
+// loadIPTables reads the INPUT chain of "iptables-save", which needs root
+func loadIPTables(ctx context.Context, run runner) (*Ruleset, error) {
+	out, err := run(ctx, "iptables-save", "-t", "filter")
+	if err != nil {
+		return nil, err
+	}
+	return parseIPTables(out), nil
+}
+
+func parseIPTables(out string) *Ruleset {
+	rs := &Ruleset{Backend: IPTables, DefaultAccept: true}
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, ":INPUT ") {
+			rs.DefaultAccept = strings.Fields(line)[1] == "ACCEPT"
+			continue
+		}
+		if !strings.HasPrefix(line, "-A INPUT ") {
+			continue
+		}
+		if r, ok := iptablesRule(line); ok {
+			rs.rules = append(rs.rules, r...)
+		}
+	}
+	rs.Active = !rs.DefaultAccept || len(rs.rules) > 0
+	return rs
+}
+
+func iptablesRule(line string) ([]rule, bool) {
+	args := strings.Fields(line)
+	opts := make(map[string]string)
+	for i := 0; i < len(args); i++ {
+		if strings.HasPrefix(args[i], "-") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
+			opts[args[i]] = args[i+1]
+			i++
+		}
+	}
+	target := opts["-j"]
+	if target != "ACCEPT" && target != "DROP" && target != "REJECT" {
+		// Jumps to user chains (ufw-*, f2b-*) are not followed
+		return nil, false
+	}
+	if opts["-i"] == "lo" {
+		return nil, false
+	}
+	if strings.Contains(line, "--state") || strings.Contains(line, "--ctstate") || opts["-p"] == "icmp" {
+		return nil, false
+	}
+	r := rule{proto: opts["-p"], accept: target == "ACCEPT", source: opts["-s"], text: "iptables " + line}
+	if r.source == "0.0.0.0/0" {
+		r.source = ""
+	}
+	spec := opts["--dport"]
+	if spec == "" {
+		spec = opts["--dports"]
+	}
+	if spec == "" {
+		if r.source != "" {
+			return nil, false
+		}
+		return []rule{r}, true
+	}
+	var out []rule
+	for _, p := range portRules(spec, ":") {
+		p.proto, p.accept, p.source, p.text = r.proto, r.accept, r.source, r.text
+		out = append(out, p)
+	}
+	return out, len(out) > 0
+}
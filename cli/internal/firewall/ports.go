@@ -0,0 +1,284 @@
+// Package firewall works out the ports the stack needs, audits the host
+// firewall (ufw, firewalld, nftables or iptables) for them and renders
+// the rules that open them.
+package firewall
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"gopkg.in/yaml.v3"
+)
+
+// Port is a port range the stack needs reachable
+type Port struct {
+	Service string `json:"service"`
+	Proto   string `json:"proto"`
+	From    int    `json:"from"`
+	To      int    `json:"to"`
+	// Source limits who needs to connect, e.g. the Asterisk host; empty
+	// is anyone (SIP and RTP from the ITSP)
+	Source string `json:"source,omitempty"`
+	// Local ports are only used over loopback and need no rule
+	Local bool   `json:"local,omitempty"`
+	Why   string `json:"why"`
+}
+
+// Range is the port or range as ufw and iptables write it, e.g. 10000:20000
+func (p Port) Range() string {
+	if p.To > p.From {
+		return fmt.Sprintf("%d:%d", p.From, p.To)
+	}
+	return strconv.Itoa(p.From)
+}
+
+func (p Port) String() string {
+	return strings.Replace(p.Range(), ":", "-", 1) + "/" + p.Proto
+}
+
+// Defaults Asterisk uses without a config
+const (
+	defaultSIPPort  = 5060
+	defaultRTPStart = 10000
+	defaultRTPEnd   = 20000
+	defaultUIPort   = 3003
+)
+
+// Options locates the stack: Dir holds config/ai-agent.yaml, .env and
+// docker-compose.yml; AsteriskDir holds pjsip.conf and rtp.conf
+type Options struct {
+	Dir         string
+	AsteriskDir string
+}
+
+// Required lists the ports of the stack on this host: SIP and RTP when
+// Asterisk runs here, the engine's AudioSocket or ExternalMedia port for
+// Asterisk, and the admin dashboard when it is deployed
+func Required(opts Options) ([]Port, error) {
+	var cfg struct {
+		AudioTransport string `yaml:"audio_transport"`
+		AudioSocket    struct {
+			Host string `yaml:"host"`
+			Port int    `yaml:"port"`
+		} `yaml:"audiosocket"`
+		ExternalMedia struct {
+			RTPHost   string `yaml:"rtp_host"`
+			RTPPort   int    `yaml:"rtp_port"`
+			PortRange string `yaml:"port_range"`
+		} `yaml:"external_media"`
+	}
+	path := filepath.Join(opts.Dir, "config", "ai-agent.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	env, _ := health.LoadEnvFile(filepath.Join(opts.Dir, ".env"))
+	asteriskHost := health.GetEnv("ASTERISK_HOST", env)
+	asteriskLocal := isLoopback(asteriskHost)
+
+	var ports []Port
+	if _, err := os.Stat(opts.AsteriskDir); err == nil {
+		ports = append(ports, sipPorts(opts.AsteriskDir)...)
+		start, end := rtpRange(opts.AsteriskDir)
+		ports = append(ports, Port{Service: "RTP", Proto: "udp", From: start, To: end, Why: "caller audio from the ITSP (rtp.conf rtpstart-rtpend)"})
+	}
+
+	// The engine's media port only needs opening when Asterisk is elsewhere
+	source := asteriskHost
+	switch strings.ToLower(cfg.AudioTransport) {
+	case "audiosocket":
+		port := cfg.AudioSocket.Port
+		if port == 0 {
+			port = 8090
+		}
+		ports = append(ports, Port{Service: "AudioSocket", Proto: "tcp", From: port, To: port, Source: source,
+			Local: asteriskLocal, Why: "Asterisk streams call audio to the engine (audiosocket.port)"})
+	default:
+		from, to := cfg.ExternalMedia.RTPPort, cfg.ExternalMedia.RTPPort
+		if a, b, ok := parseRange(cfg.ExternalMedia.PortRange); ok {
+			from, to = a, b
+		}
+		if from == 0 {
+			from, to = 18080, 18080
+		}
+		ports = append(ports, Port{Service: "ExternalMedia", Proto: "udp", From: from, To: to, Source: source,
+			Local: asteriskLocal, Why: "Asterisk sends call audio to the engine as RTP (external_media.port_range)"})
+	}
+
+	if port, ok := dashboardPort(opts.Dir, env); ok {
+		ports = append(ports, Port{Service: "Dashboard", Proto: "tcp", From: port, To: port, Why: "admin UI (UVICORN_PORT); limit it to your admin network"})
+	}
+	return ports, nil
+}
+
+func isLoopback(host string) bool {
+	switch host {
+	case "", "127.0.0.1", "localhost", "::1":
+		return true
+	}
+	return false
+}
+
+var (
+	confSection = regexp.MustCompile(`^\s*\[([^\]]+)\]`)
+	confSetting = regexp.MustCompile(`^\s*([A-Za-z_]+)\s*=>?\s*(.*?)\s*$`)
+)
+
+// readConf reads an Asterisk .conf file into sections of settings, with
+// #include files under dir followed
+func readConf(dir, name string) map[string]map[string]string {
+	sections := make(map[string]map[string]string)
+	var read func(path string, depth int)
+	read = func(path string, depth int) {
+		f, err := os.Open(path)
+		if err != nil || depth > 4 {
+			return
+		}
+		defer f.Close()
+		current := ""
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if i := strings.Index(line, ";"); i >= 0 {
+				line = line[:i]
+			}
+			if strings.HasPrefix(strings.TrimSpace(line), "#include") {
+				inc := strings.Trim(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#include")), `"<>`)
+				if !filepath.IsAbs(inc) {
+					inc = filepath.Join(dir, inc)
+				}
+				matches, _ := filepath.Glob(inc)
+				for _, m := range matches {
+					read(m, depth+1)
+				}
+				continue
+			}
+			if m := confSection.FindStringSubmatch(line); m != nil {
+				current = m[1]
+				if sections[current] == nil {
+					sections[current] = make(map[string]string)
+				}
+				continue
+			}
+			if m := confSetting.FindStringSubmatch(line); m != nil && current != "" {
+				sections[current][strings.ToLower(m[1])] = m[2]
+			}
+		}
+	}
+	read(filepath.Join(dir, name), 0)
+	return sections
+}
+
+// sipPorts reads the PJSIP transports; without any, Asterisk's usual
+// 5060/udp
+func sipPorts(dir string) []Port {
+	var ports []Port
+	for name, s := range readConf(dir, "pjsip.conf") {
+		if s["type"] != "transport" {
+			continue
+		}
+		proto := strings.ToLower(s["protocol"])
+		port := defaultSIPPort
+		if proto == "tls" || proto == "wss" {
+			port = 5061
+		}
+		if bind := s["bind"]; bind != "" {
+			if i := strings.LastIndex(bind, ":"); i >= 0 && !strings.HasSuffix(bind, "]") {
+				if n, err := strconv.Atoi(bind[i+1:]); err == nil {
+					port = n
+				}
+			}
+		}
+		switch proto {
+		case "udp", "":
+			proto = "udp"
+		case "ws", "wss":
+			// WebSocket transports ride on Asterisk's HTTP server
+			continue
+		default:
+			proto = "tcp"
+		}
+		ports = append(ports, Port{Service: "SIP", Proto: proto, From: port, To: port, Why: "signaling from the ITSP (pjsip transport " + name + ")"})
+	}
+	if len(ports) == 0 {
+		ports = append(ports, Port{Service: "SIP", Proto: "udp", From: defaultSIPPort, To: defaultSIPPort, Why: "signaling from the ITSP (no pjsip transport found; Asterisk's default)"})
+	}
+	sortPorts(ports)
+	return dedupe(ports)
+}
+
+// rtpRange reads rtpstart and rtpend of rtp.conf
+func rtpRange(dir string) (int, int) {
+	start, end := defaultRTPStart, defaultRTPEnd
+	general := readConf(dir, "rtp.conf")["general"]
+	if n, err := strconv.Atoi(general["rtpstart"]); err == nil {
+		start = n
+	}
+	if n, err := strconv.Atoi(general["rtpend"]); err == nil {
+		end = n
+	}
+	return start, end
+}
+
+// dashboardPort is the admin UI port when the compose file deploys it
+func dashboardPort(dir string, env map[string]string) (int, bool) {
+	port := defaultUIPort
+	if v := health.GetEnv("UVICORN_PORT", env); v != "" {
+		if n, err := strconv.Atoi(v); err == nil {
+			port = n
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "docker-compose.yml"))
+	if err != nil || !strings.Contains(string(data), "admin_ui") {
+		return 0, false
+	}
+	return port, true
+}
+
+// parseRange reads "18080:18099" or "18080-18099"
+func parseRange(s string) (int, int, bool) {
+	s = strings.Replace(strings.TrimSpace(s), "-", ":", 1)
+	parts := strings.SplitN(s, ":", 2)
+	from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil {
+		return 0, 0, false
+	}
+	to := from
+	if len(parts) == 2 {
+		if to, err = strconv.Atoi(strings.TrimSpace(parts[1])); err != nil {
+			return 0, 0, false
+		}
+	}
+	if to < from {
+		from, to = to, from
+	}
+	return from, to, true
+}
+
+func sortPorts(ports []Port) {
+	for i := 1; i < len(ports); i++ {
+		for j := i; j > 0 && (ports[j].From < ports[j-1].From || (ports[j].From == ports[j-1].From && ports[j].Proto < ports[j-1].Proto)); j-- {
+			ports[j], ports[j-1] = ports[j-1], ports[j]
+		}
+	}
+}
+
+func dedupe(ports []Port) []Port {
+	var out []Port
+	for i, p := range ports {
+		if i > 0 && p.From == ports[i-1].From && p.Proto == ports[i-1].Proto {
+			continue
+		}
+		out = append(out, p)
+	}
+	return out
+}
@@ -0,0 +1,98 @@
+package firewall
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// comment labels the rules so they can be found and removed later
+const comment = "asterisk-ai-voice-agent"
+
+// Rules returns the commands that open ports on the ruleset's backend,
+// and notes on keeping them across reboots
+func (rs *Ruleset) Rules(ports []Port) ([][]string, []string, error) {
+	var cmds [][]string
+	var notes []string
+	for _, p := range ports {
+		switch rs.Backend {
+		case UFW:
+			label := comment + " " + strings.ToLower(p.Service)
+			if p.Source != "" {
+				cmds = append(cmds, []string{"ufw", "allow", "proto", p.Proto, "from", p.Source, "to", "any", "port", p.Range(), "comment", label})
+			} else {
+				cmds = append(cmds, []string{"ufw", "allow", p.Range() + "/" + p.Proto, "comment", label})
+			}
+		case Firewalld:
+			cmd := []string{"firewall-cmd", "--permanent"}
+			if rs.Zone != "" {
+				cmd = append(cmd, "--zone="+rs.Zone)
+			}
+			if p.Source != "" {
+				cmd = append(cmd, fmt.Sprintf(`--add-rich-rule=rule family="ipv4" source address="%s" port port="%s" protocol="%s" accept`,
+					p.Source, strings.Replace(p.Range(), ":", "-", 1), p.Proto))
+			} else {
+				cmd = append(cmd, "--add-port="+p.String())
+			}
+			cmds = append(cmds, cmd)
+		case NFTables:
+			family, table, chain := rs.Family, rs.Table, rs.Chain
+			if chain == "" {
+				family, table, chain = "inet", "filter", "input"
+			}
+			// insert puts the rule before a closing drop of the chain
+			cmd := []string{"nft", "insert", "rule", family, table, chain}
+			if p.Source != "" {
+				cmd = append(cmd, "ip", "saddr", p.Source)
+			}
+			cmds = append(cmds, append(cmd, p.Proto, "dport", strings.Replace(p.Range(), ":", "-", 1), "accept"))
+		case IPTables:
+			cmd := []string{"iptables", "-I", "INPUT", "-p", p.Proto}
+			if p.Source != "" {
+				cmd = append(cmd, "-s", p.Source)
+			}
+			cmds = append(cmds, append(cmd, "--dport", p.Range(), "-m", "comment", "--comment", comment, "-j", "ACCEPT"))
+		default:
+			return nil, nil, fmt.Errorf("no firewall backend to write rules for (use --backend %s)", strings.Join(Backends, "|"))
+		}
+	}
+	if len(cmds) == 0 {
+		return nil, nil, nil
+	}
+	switch rs.Backend {
+	case Firewalld:
+		cmds = append(cmds, []string{"firewall-cmd", "--reload"})
+	case NFTables:
+		notes = append(notes, "nft rules last until reboot: save them with 'nft list ruleset > /etc/nftables.conf'")
+	case IPTables:
+		notes = append(notes, "iptables rules last until reboot: save them with 'iptables-save > /etc/iptables/rules.v4' (iptables-persistent)")
+	}
+	return cmds, notes, nil
+}
+
+// Apply runs the commands in order and stops at the first that fails
+func Apply(ctx context.Context, cmds [][]string) error {
+	for _, cmd := range cmds {
+		out, err := selflog.CombinedOutput(remote.Command(ctx, cmd[0], cmd[1:]...))
+		if err != nil {
+			return fmt.Errorf("%s: %v: %s", Shell(cmd), err, strings.TrimSpace(string(out)))
+		}
+	}
+	return nil
+}
+
+// Shell writes a command the way it is typed, quoting arguments with
+// spaces or quotes
+func Shell(cmd []string) string {
+	parts := make([]string, len(cmd))
+	for i, arg := range cmd {
+		if arg == "" || strings.ContainsAny(arg, " \t\"'$`\\*?;&|<>(){}") {
+			arg = "'" + strings.Replace(arg, "'", `'\''`, -1) + "'"
+		}
+		parts[i] = arg
+	}
+	return strings.Join(parts, " ")
+}
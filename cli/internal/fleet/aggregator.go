@@ -0,0 +1,219 @@
+// Package fleet turns the live call events of several 'agent serve'
+// nodes, gathered through a queue, into one set of alerts: SLO breaches
+// per call and failures correlated across nodes, so a fleet alerts once
+// instead of once per node.
+package fleet
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/queue"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// DefaultWindow is how long failures with the same fingerprint count as
+// one incident
+const DefaultWindow = 15 * time.Minute
+
+// Aggregator evaluates the events of every node. A failure fingerprint
+// alerts on its first call, again once it shows up on a second node, and
+// not for further calls until it has been quiet for the window.
+type Aggregator struct {
+	mu       sync.Mutex
+	slo      settings.SLO
+	tenants  []settings.Tenant
+	window   time.Duration
+	failures map[string]*incident
+}
+
+// incident is one failure fingerprint seen within the window
+type incident struct {
+	first  time.Time
+	last   time.Time
+	calls  int
+	nodes  map[string]int
+	spread bool
+}
+
+// NewAggregator creates an aggregator applying slo, with the overrides of
+// tenants, to ended calls; window 0 is DefaultWindow
+func NewAggregator(slo settings.SLO, tenants []settings.Tenant, window time.Duration) *Aggregator {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Aggregator{
+		slo:      slo,
+		tenants:  tenants,
+		window:   window,
+		failures: make(map[string]*incident),
+	}
+}
+
+// Observe processes one event and returns the notifications it raises
+func (a *Aggregator) Observe(ev events.Event) []notify.Event {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	node := queue.Node(ev)
+	at := ev.Time
+	if at.IsZero() {
+		at = time.Now()
+	}
+	switch ev.Type {
+	case events.CallEnded:
+		if n, ok := a.sloBreach(ev, node); ok {
+			return []notify.Event{n}
+		}
+	case events.CallFailed:
+		if n, ok := a.failure(ev, node, at); ok {
+			return []notify.Event{n}
+		}
+	}
+	return nil
+}
+
+// sloBreach checks an ended call against the objectives of its tenant
+func (a *Aggregator) sloBreach(ev events.Event, node string) (notify.Event, bool) {
+	tenant, _ := ev.Data["tenant"].(string)
+	slo := settings.TenantSLO(a.slo, a.tenants, tenant)
+	var breaches []string
+	if limit := slo.TurnLatencyP95Ms; limit > 0 {
+		if p95, ok := number(ev.Data["turn_latency_p95_ms"]); ok && p95 > limit {
+			breaches = append(breaches, fmt.Sprintf("turn latency p95 %.0fms > %.0fms", p95, limit))
+		}
+	}
+	if min := slo.MinQualityScore; min > 0 {
+		if score, ok := number(ev.Data["quality_score"]); ok && score < min {
+			breaches = append(breaches, fmt.Sprintf("quality score %.0f < %.0f", score, min))
+		}
+	}
+	if len(breaches) == 0 {
+		return notify.Event{}, false
+	}
+	n := notify.Event{
+		Kind:     notify.EventSLOBreached,
+		Severity: notify.SeverityWarning,
+		Title:    "Call breached SLO",
+		Text:     strings.Join(breaches, "\n"),
+		CallID:   ev.CallID,
+		Time:     ev.Time,
+		Fields:   map[string]string{},
+		Data:     ev,
+	}
+	if tenant != "" {
+		n.Title = "Call of " + tenant + " breached SLO"
+		n.Fields["Tenant"] = tenant
+	}
+	if node != "" {
+		n.Title += " on " + node
+		n.Fields["Node"] = node
+	}
+	return n, true
+}
+
+// failure correlates a failed call with the incidents of the window
+func (a *Aggregator) failure(ev events.Event, node string, at time.Time) (notify.Event, bool) {
+	for fp, inc := range a.failures {
+		if at.Sub(inc.last) > a.window {
+			delete(a.failures, fp)
+		}
+	}
+	fp, _ := ev.Data["fingerprint"].(string)
+	if fp == "" {
+		fp = "unknown"
+	}
+	inc := a.failures[fp]
+	first := inc == nil
+	if first {
+		inc = &incident{first: at, nodes: make(map[string]int)}
+		a.failures[fp] = inc
+	}
+	inc.calls++
+	inc.nodes[node]++
+	if at.After(inc.last) {
+		inc.last = at
+	}
+
+	n := notify.Event{
+		Kind:     notify.EventFailureDetected,
+		Severity: notify.SeverityCritical,
+		CallID:   ev.CallID,
+		Time:     ev.Time,
+		Text:     strings.Join(stringList(ev.Data["findings"]), "\n"),
+		Fields:   map[string]string{"Fingerprint": fp},
+		Data:     ev,
+	}
+	if status, _ := ev.Data["status"].(string); status != "" {
+		n.Fields["Status"] = status
+	}
+	switch {
+	case first:
+		n.Title = "Call failed"
+		if node != "" {
+			n.Title += " on " + node
+			n.Fields["Node"] = node
+		}
+	case !inc.spread && len(inc.nodes) > 1:
+		inc.spread = true
+		n.Title = fmt.Sprintf("Failure on %d nodes: %d calls since %s", len(inc.nodes), inc.calls, inc.first.UTC().Format("15:04 MST"))
+		n.Fields["Nodes"] = inc.nodeList()
+	default:
+		return notify.Event{}, false
+	}
+	return n, true
+}
+
+// nodeList names the nodes of an incident with their call counts
+func (inc *incident) nodeList() string {
+	var names []string
+	for node := range inc.nodes {
+		names = append(names, node)
+	}
+	sort.Strings(names)
+	for i, node := range names {
+		label := node
+		if label == "" {
+			label = "local"
+		}
+		names[i] = fmt.Sprintf("%s (%d)", label, inc.nodes[node])
+	}
+	return strings.Join(names, ", ")
+}
+
+// number reads a numeric event field, which JSON carries as a number
+// and the log metrics as a string
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	case string:
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// stringList reads a list of strings from an event field
+func stringList(v interface{}) []string {
+	switch list := v.(type) {
+	case []string:
+		return list
+	case []interface{}:
+		var out []string
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
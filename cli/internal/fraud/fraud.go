@@ -0,0 +1,456 @@
+// Package fraud watches outbound calls and SIP authentication for signs
+// of toll fraud and brute force: volume spikes, premium-rate and
+// high-risk destinations, calls outside business hours and repeated
+// failed registrations. A compromised agent configuration can dial out,
+// so these are raised as they happen rather than in a report.
+package fraud
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Alert kinds
+const (
+	KindVolume     = "volume_spike"
+	KindPremium    = "premium_destination"
+	KindOffHours   = "off_hours"
+	KindBruteForce = "brute_force"
+)
+
+// Alert severities, matching notify's
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+)
+
+// Rules are the thresholds of the watch
+type Rules struct {
+	// MaxCalls outbound calls within Window raise a volume alert
+	MaxCalls int
+	Window   time.Duration
+	// SpikeFactor raises a volume alert when Window holds this many times
+	// the calls of an average Window over the last hour; 0 disables it
+	SpikeFactor float64
+	// CountryCode turns national numbers into international ones, e.g.
+	// 1 (NANP) or 44; empty matches national numbers as dialed
+	CountryCode string
+	// Premium are extra destination prefixes to alert on; Allowed are
+	// prefixes never alerted on (the ITSP's own numbers, known partners)
+	Premium []string
+	Allowed []string
+	// Hours are the business hours (08:00-18:00) on Days; calls outside
+	// them raise an off-hours alert. Empty Hours disables it.
+	Hours    string
+	Days     []time.Weekday
+	Location *time.Location
+	// AuthFailures failed SIP requests from one address within AuthWindow
+	// raise a brute-force alert
+	AuthFailures int
+	AuthWindow   time.Duration
+	// Cooldown is how long an alert is not repeated for the same cause
+	Cooldown time.Duration
+}
+
+// DefaultRules alert on 20 outbound calls in 10 minutes, a fivefold
+// spike, and 20 failed SIP requests from one address in 5 minutes
+var DefaultRules = Rules{
+	MaxCalls:     20,
+	Window:       10 * time.Minute,
+	SpikeFactor:  5,
+	AuthFailures: 20,
+	AuthWindow:   5 * time.Minute,
+	Cooldown:     15 * time.Minute,
+}
+
+// Dial is one outbound call attempt
+type Dial struct {
+	Time   time.Time
+	Number string
+	// Via is what placed the call: a channel, or "ARI/AMI originate" for
+	// calls without a calling channel (the agent's outbound tools)
+	Via      string
+	CallerID string
+}
+
+// Alert is one detected anomaly
+type Alert struct {
+	Kind     string            `json:"kind"`
+	Severity string            `json:"severity"`
+	Title    string            `json:"title"`
+	Text     string            `json:"text"`
+	Time     time.Time         `json:"time"`
+	Fields   map[string]string `json:"fields,omitempty"`
+}
+
+// Watcher keeps the recent calls and failures and raises alerts
+type Watcher struct {
+	rules    Rules
+	started  time.Time
+	from, to int // business hours in minutes of the day
+	dials    []time.Time
+	failures map[string][]time.Time
+	// offHours counts calls outside hours since the last alert
+	offHours int
+	last     map[string]time.Time
+}
+
+// New creates a watcher
+func New(rules Rules, now time.Time) (*Watcher, error) {
+	w := &Watcher{
+		rules:    rules,
+		started:  now,
+		failures: make(map[string][]time.Time),
+		last:     make(map[string]time.Time),
+	}
+	if w.rules.Location == nil {
+		w.rules.Location = time.Local
+	}
+	if rules.Hours != "" {
+		var err error
+		if w.from, w.to, err = parseHours(rules.Hours); err != nil {
+			return nil, err
+		}
+	}
+	return w, nil
+}
+
+// parseHours reads "08:00-18:00" into minutes of the day
+func parseHours(s string) (int, int, error) {
+	parts := strings.SplitN(s, "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("hours %q: use HH:MM-HH:MM", s)
+	}
+	var bounds [2]int
+	for i, p := range parts {
+		t, err := time.Parse("15:04", strings.TrimSpace(p))
+		if err != nil {
+			return 0, 0, fmt.Errorf("hours %q: use HH:MM-HH:MM", s)
+		}
+		bounds[i] = t.Hour()*60 + t.Minute()
+	}
+	return bounds[0], bounds[1], nil
+}
+
+// ParseDays reads "mon-fri" or "mon,wed,sat"
+func ParseDays(s string) ([]time.Weekday, error) {
+	names := []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+	index := func(name string) (int, error) {
+		name = strings.ToLower(strings.TrimSpace(name))
+		for i, n := range names {
+			if strings.HasPrefix(name, n) {
+				return i, nil
+			}
+		}
+		return 0, fmt.Errorf("unknown day %q (use mon..sun)", name)
+	}
+	var days []time.Weekday
+	for _, part := range strings.Split(s, ",") {
+		bounds := strings.SplitN(part, "-", 2)
+		from, err := index(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if len(bounds) == 2 {
+			if to, err = index(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		for d := from; ; d = (d + 1) % 7 {
+			days = append(days, time.Weekday(d))
+			if d == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
+
+// Dial records an outbound call attempt and returns the alerts it
+// raises. Numbers too short for the PSTN (extensions) are ignored.
+func (w *Watcher) Dial(d Dial) []Alert {
+	digits := strings.TrimPrefix(d.Number, "+")
+	if len(digits) < 7 || strings.Trim(digits, "0123456789") != "" {
+		return nil
+	}
+	var alerts []Alert
+	fields := map[string]string{"Number": d.Number, "Via": d.Via}
+	if d.CallerID != "" {
+		fields["Caller ID"] = d.CallerID
+	}
+
+	intl := w.international(d.Number)
+	if !w.allowed(d.Number, intl) {
+		if prefix, label, ok := w.premium(d.Number, intl); ok {
+			if a, ok := w.raise(KindPremium+":"+prefix, d.Time, Alert{
+				Kind:     KindPremium,
+				Severity: SeverityCritical,
+				Title:    "Call to a premium-rate or high-risk destination: " + d.Number,
+				Text:     fmt.Sprintf("%s matches %s (+%s), a common toll-fraud target", d.Number, label, prefix),
+				Fields:   fields,
+			}); ok {
+				alerts = append(alerts, a)
+			}
+		}
+	}
+
+	if w.rules.Hours != "" && !w.inHours(d.Time) {
+		w.offHours++
+		if a, ok := w.raise(KindOffHours, d.Time, Alert{
+			Kind:     KindOffHours,
+			Severity: SeverityWarning,
+			Title:    "Outbound call outside business hours: " + d.Number,
+			Text:     fmt.Sprintf("%d call(s) outside %s since the last alert", w.offHours, w.rules.Hours),
+			Fields:   fields,
+		}); ok {
+			w.offHours = 0
+			alerts = append(alerts, a)
+		}
+	}
+
+	// Volume: the calls in the window against the limit and the average
+	// window of the last hour
+	w.dials = append(w.dials, d.Time)
+	keep := time.Hour + w.rules.Window
+	for len(w.dials) > 0 && d.Time.Sub(w.dials[0]) > keep {
+		w.dials = w.dials[1:]
+	}
+	inWindow, inHour := 0, 0
+	for _, t := range w.dials {
+		age := d.Time.Sub(t)
+		if age <= w.rules.Window {
+			inWindow++
+		} else {
+			inHour++
+		}
+	}
+	reason := ""
+	if w.rules.MaxCalls > 0 && inWindow >= w.rules.MaxCalls {
+		reason = fmt.Sprintf("%d outbound calls in %s (limit %d)", inWindow, w.rules.Window, w.rules.MaxCalls)
+	} else if w.rules.SpikeFactor > 0 && d.Time.Sub(w.started) >= keep {
+		baseline := float64(inHour) * float64(w.rules.Window) / float64(time.Hour)
+		if baseline < 1 {
+			baseline = 1
+		}
+		if float64(inWindow) >= w.rules.SpikeFactor*baseline && inWindow >= 5 {
+			reason = fmt.Sprintf("%d outbound calls in %s, %.0f× the hour's average of %.1f", inWindow, w.rules.Window, float64(inWindow)/baseline, baseline)
+		}
+	}
+	if reason != "" {
+		if a, ok := w.raise(KindVolume, d.Time, Alert{
+			Kind:     KindVolume,
+			Severity: SeverityCritical,
+			Title:    "Outbound call volume spike",
+			Text:     reason,
+			Fields:   map[string]string{"Last number": d.Number, "Via": d.Via},
+		}); ok {
+			alerts = append(alerts, a)
+		}
+	}
+	return alerts
+}
+
+// AuthFailure records a failed SIP request from ip and returns the alert
+// it raises
+func (w *Watcher) AuthFailure(ip string, at time.Time, detail string) []Alert {
+	times := append(w.failures[ip], at)
+	for len(times) > 0 && at.Sub(times[0]) > w.rules.AuthWindow {
+		times = times[1:]
+	}
+	w.failures[ip] = times
+	// Forget quiet addresses
+	for other, t := range w.failures {
+		if len(t) == 0 || at.Sub(t[len(t)-1]) > w.rules.AuthWindow {
+			delete(w.failures, other)
+		}
+	}
+	if w.rules.AuthFailures <= 0 || len(times) < w.rules.AuthFailures {
+		return nil
+	}
+	a, ok := w.raise(KindBruteForce+":"+ip, at, Alert{
+		Kind:     KindBruteForce,
+		Severity: SeverityCritical,
+		Title:    "SIP brute force from " + ip,
+		Text:     fmt.Sprintf("%d failed SIP requests in %s; last: %s", len(times), w.rules.AuthWindow, detail),
+		Fields:   map[string]string{"Address": ip, "Attackers": w.attackers()},
+	})
+	if !ok {
+		return nil
+	}
+	return []Alert{a}
+}
+
+// attackers lists the addresses failing in the window, busiest first
+func (w *Watcher) attackers() string {
+	ips := make([]string, 0, len(w.failures))
+	for ip := range w.failures {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		if len(w.failures[ips[i]]) != len(w.failures[ips[j]]) {
+			return len(w.failures[ips[i]]) > len(w.failures[ips[j]])
+		}
+		return ips[i] < ips[j]
+	})
+	if len(ips) > 5 {
+		ips = append(ips[:5], fmt.Sprintf("+%d more", len(w.failures)-5))
+	}
+	return strings.Join(ips, ", ")
+}
+
+// raise returns the alert unless the same cause alerted within the
+// cooldown
+func (w *Watcher) raise(key string, at time.Time, a Alert) (Alert, bool) {
+	if last, ok := w.last[key]; ok && at.Sub(last) < w.rules.Cooldown {
+		return Alert{}, false
+	}
+	w.last[key] = at
+	a.Time = at
+	return a, true
+}
+
+func (w *Watcher) inHours(t time.Time) bool {
+	t = t.In(w.rules.Location)
+	if len(w.rules.Days) > 0 {
+		found := false
+		for _, d := range w.rules.Days {
+			if d == t.Weekday() {
+				found = true
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	m := t.Hour()*60 + t.Minute()
+	if w.from <= w.to {
+		return m >= w.from && m < w.to
+	}
+	// Hours across midnight, e.g. 22:00-06:00
+	return m >= w.from || m < w.to
+}
+
+// international returns the number's international form without +, or
+// empty when it cannot tell
+func (w *Watcher) international(number string) string {
+	switch {
+	case strings.HasPrefix(number, "+"):
+		return number[1:]
+	case strings.HasPrefix(number, "011") && w.rules.CountryCode == "1":
+		return number[3:]
+	case strings.HasPrefix(number, "00"):
+		return number[2:]
+	case w.rules.CountryCode == "1" && len(number) == 10:
+		return "1" + number
+	case w.rules.CountryCode == "1" && len(number) == 11 && number[0] == '1':
+		return number
+	case w.rules.CountryCode != "" && w.rules.CountryCode != "1" && strings.HasPrefix(number, "0"):
+		return w.rules.CountryCode + number[1:]
+	}
+	return ""
+}
+
+func (w *Watcher) allowed(number, intl string) bool {
+	for _, p := range w.rules.Allowed {
+		p = strings.TrimPrefix(p, "+")
+		if strings.HasPrefix(number, p) || (intl != "" && strings.HasPrefix(intl, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// premium matches the number against the configured and built-in
+// prefixes, longest first
+func (w *Watcher) premium(number, intl string) (string, string, bool) {
+	best, label := "", ""
+	for _, p := range w.rules.Premium {
+		p = strings.TrimPrefix(p, "+")
+		if (strings.HasPrefix(number, p) || (intl != "" && strings.HasPrefix(intl, p))) && len(p) > len(best) {
+			best, label = p, "a configured premium prefix"
+		}
+	}
+	if intl != "" {
+		for p, name := range PremiumPrefixes {
+			if strings.HasPrefix(intl, p) && len(p) > len(best) {
+				best, label = p, name
+			}
+		}
+	}
+	return best, label, best != ""
+}
+
+// PremiumPrefixes are international prefixes (without +) of premium-rate
+// services and of destinations common in international revenue share
+// fraud
+var PremiumPrefixes = map[string]string{
+	"1900":  "US/Canada premium rate (900)",
+	"1976":  "US premium rate (976)",
+	"1284":  "British Virgin Islands",
+	"1473":  "Grenada",
+	"1649":  "Turks and Caicos",
+	"1664":  "Montserrat",
+	"1767":  "Dominica",
+	"1809":  "Dominican Republic",
+	"1829":  "Dominican Republic",
+	"1849":  "Dominican Republic",
+	"1876":  "Jamaica",
+	"4470":  "UK personal numbers (070)",
+	"449":   "UK premium rate (09)",
+	"4487":  "UK revenue share (087)",
+	"49900": "Germany premium rate (0900)",
+	"3389":  "France premium rate (089)",
+	"34803": "Spain premium rate (803)",
+	"34806": "Spain premium rate (806)",
+	"34807": "Spain premium rate (807)",
+	"34905": "Spain premium rate (905)",
+	"39899": "Italy premium rate (899)",
+	"61190": "Australia premium rate (190)",
+	"53":    "Cuba",
+	"224":   "Guinea",
+	"231":   "Liberia",
+	"232":   "Sierra Leone",
+	"247":   "Ascension Island",
+	"252":   "Somalia",
+	"290":   "Saint Helena",
+	"675":   "Papua New Guinea",
+	"677":   "Solomon Islands",
+	"678":   "Vanuatu",
+	"682":   "Cook Islands",
+	"683":   "Niue",
+	"688":   "Tuvalu",
+	"690":   "Tokelau",
+	"691":   "Micronesia",
+	"692":   "Marshall Islands",
+	"870":   "Inmarsat satellite",
+	"881":   "global satellite services",
+	"882":   "international networks",
+	"883":   "international networks",
+	"979":   "international premium rate",
+}
+
+// Number extracts the dialed number from an Asterisk dial string such as
+// "+15551234@itsp", "itsp/sip:+15551234@host" or "PJSIP/itsp/+15551234"
+func Number(dialString string) string {
+	s := dialString
+	if i := strings.Index(s, "sip:"); i >= 0 {
+		s = s[i+4:]
+	} else if i := strings.LastIndex(s, "/"); i >= 0 && !strings.Contains(s[i:], "@") {
+		s = s[i+1:]
+	} else if i := strings.LastIndex(strings.Split(s, "@")[0], "/"); i >= 0 {
+		s = s[i+1:]
+	}
+	if i := strings.IndexAny(s, "@;>,:"); i >= 0 {
+		s = s[:i]
+	}
+	var b strings.Builder
+	for i, r := range s {
+		if (r >= '0' && r <= '9') || (r == '+' && i == 0) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
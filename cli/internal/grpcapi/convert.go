@@ -0,0 +1,162 @@
+package grpcapi
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/agentpb"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// timestamp converts t; the zero time is unset
+func timestamp(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+// callRecord converts an indexed call
+func callRecord(c analysis.CallRecord) *agentpb.CallRecord {
+	return &agentpb.CallRecord{
+		Id:              c.ID,
+		Start:           timestamp(c.Timestamp),
+		End:             timestamp(c.EndTime),
+		Duration:        c.Duration,
+		Status:          c.Status,
+		Channel:         c.Channel,
+		CallerNumber:    c.CallerNumber,
+		CallerName:      c.CallerName,
+		Dialed:          c.Dialed,
+		HangupCause:     int32(c.HangupCause),
+		Context:         c.Context,
+		DialplanContext: c.DialplanContext,
+		Tenant:          c.Tenant,
+		Tags:            c.Tags,
+		Notes:           notes(c.Notes),
+	}
+}
+
+// notes converts operator notes
+func notes(in []analysis.Note) []*agentpb.Note {
+	var out []*agentpb.Note
+	for _, n := range in {
+		out = append(out, &agentpb.Note{Time: timestamp(n.Time), Author: n.Author, Text: n.Text})
+	}
+	return out
+}
+
+// analysisMessage converts a saved analysis report
+func analysisMessage(a *analysis.Analysis) *agentpb.Analysis {
+	out := &agentpb.Analysis{
+		SchemaVersion: int32(a.SchemaVersion),
+		CallId:        a.CallID,
+		Symptom:       a.Symptom,
+		Pipeline: &agentpb.PipelineStatus{
+			Audiosocket:   a.Pipeline.AudioSocket,
+			Transcription: a.Pipeline.Transcription,
+			Playback:      a.Pipeline.Playback,
+		},
+		Errors:        int32(a.Errors),
+		Warnings:      int32(a.Warnings),
+		AudioIssues:   a.AudioIssues,
+		Metrics:       a.Metrics,
+		QualityScore:  a.Score,
+		QualityIssues: a.Issues,
+		Tags:          a.Tags,
+		Notes:         notes(a.Notes),
+		Tenant:        a.Tenant,
+	}
+	if out.SchemaVersion == 0 {
+		// Saved before versioning, in the version 1 layout
+		out.SchemaVersion = 1
+	}
+	for _, f := range a.Findings {
+		out.Findings = append(out.Findings, &agentpb.Finding{
+			Analyzer: f.Analyzer,
+			Severity: f.Severity,
+			Message:  f.Message,
+			Evidence: f.Evidence,
+			Fix:      f.Fix,
+			Feedback: f.Feedback,
+		})
+	}
+	for _, s := range a.Latency {
+		out.LatencyBreakdown = append(out.LatencyBreakdown, &agentpb.SpanTiming{
+			Name:   s.Name,
+			Count:  int32(s.Count),
+			AvgMs:  s.AvgMs,
+			P95Ms:  s.P95Ms,
+			MaxMs:  s.MaxMs,
+			Errors: int32(s.Errors),
+		})
+	}
+	for _, t := range a.ASRTurns {
+		out.AsrTurns = append(out.AsrTurns, &agentpb.ASRTurn{
+			Turn:         int32(t.Turn),
+			Text:         t.Text,
+			Confidence:   t.Confidence,
+			Alternatives: t.Alternatives,
+			Action:       t.Action,
+		})
+	}
+	for _, f := range a.Frames {
+		out.Frames = append(out.Frames, &agentpb.FrameSecond{
+			Second:      int32(f.Second),
+			InFrames:    int32(f.InFrames),
+			InBytes:     int32(f.InBytes),
+			InMaxGapMs:  f.InMaxGapMs,
+			OutFrames:   int32(f.OutFrames),
+			OutBytes:    int32(f.OutBytes),
+			OutMaxGapMs: f.OutMaxGapMs,
+		})
+	}
+	if d := a.Diagnosis; d != nil {
+		out.Diagnosis = &agentpb.Diagnosis{
+			Provider:     d.Provider,
+			Model:        d.Model,
+			Analysis:     d.Analysis,
+			SummaryCalls: int32(d.SummaryCalls),
+		}
+	}
+	for _, in := range a.Incomplete {
+		out.Incomplete = append(out.Incomplete, &agentpb.Incomplete{Analyzer: in.Analyzer, Reason: in.Reason})
+	}
+	if s := a.Sampling; s != nil {
+		out.Sampling = &agentpb.Sampling{
+			TotalLines: int32(s.TotalLines),
+			KeptLines:  int32(s.KeptLines),
+			Every:      int32(s.Every),
+		}
+		for _, k := range s.Kinds {
+			out.Sampling.Kinds = append(out.Sampling.Kinds, &agentpb.SampledKind{Kind: k.Kind, Total: int32(k.Total), Kept: int32(k.Kept)})
+		}
+	}
+	return out
+}
+
+// event converts a live call event. Its data holds slices and structs
+// structpb does not take, so it goes through JSON.
+func event(ev events.Event) (*agentpb.Event, error) {
+	raw, err := json.Marshal(ev.Data)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	data, err := structpb.NewStruct(fields)
+	if err != nil {
+		return nil, err
+	}
+	return &agentpb.Event{
+		Type:   ev.Type,
+		Time:   timestamp(ev.Time),
+		CallId: ev.CallID,
+		Data:   data,
+	}, nil
+}
@@ -0,0 +1,192 @@
+// Package grpcapi serves the gRPC API of 'agent serve --grpc' (see
+// pkg/agentpb): the call index, saved analyses and the live call events
+// of the events bus.
+package grpcapi
+
+import (
+	"context"
+	"crypto/subtle"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/agentpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// defaultListLimit is how many calls ListCalls returns without a limit
+const defaultListLimit = 100
+
+// streamBuffer is how many events a slow stream may lag behind before
+// it misses events
+const streamBuffer = 256
+
+// Server implements agentpb.AgentServiceServer
+type Server struct {
+	agentpb.UnimplementedAgentServiceServer
+
+	bus *events.Bus
+}
+
+// NewServer serves the call index and saved runs of this host and the
+// events published on bus
+func NewServer(bus *events.Bus) *Server {
+	return &Server{bus: bus}
+}
+
+// ListCalls returns indexed calls, newest first
+func (s *Server) ListCalls(ctx context.Context, req *agentpb.ListCallsRequest) (*agentpb.ListCallsResponse, error) {
+	limit := int(req.GetLimit())
+	if limit <= 0 {
+		limit = defaultListLimit
+	}
+	var since time.Time
+	if req.GetSince() != nil {
+		since = req.GetSince().AsTime()
+	}
+	resp := &agentpb.ListCallsResponse{}
+	for _, call := range troubleshoot.LoadCallIndex().Recent(0) {
+		if len(resp.Calls) == limit {
+			break
+		}
+		if !since.IsZero() && call.Timestamp.Before(since) {
+			continue
+		}
+		if req.GetTenant() != "" && call.Tenant != req.GetTenant() {
+			continue
+		}
+		if req.GetTag() != "" && !call.HasTag(req.GetTag()) {
+			continue
+		}
+		if req.GetStatus() != "" && !strings.EqualFold(call.Status, req.GetStatus()) {
+			continue
+		}
+		resp.Calls = append(resp.Calls, callRecord(call))
+	}
+	return resp, nil
+}
+
+// GetAnalysis returns the report of a saved troubleshoot run
+func (s *Server) GetAnalysis(ctx context.Context, req *agentpb.GetAnalysisRequest) (*agentpb.Analysis, error) {
+	if req.GetRunId() != "" {
+		run, err := troubleshoot.LoadRun(req.GetRunId())
+		if err != nil {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return runAnalysis(run)
+	}
+	if req.GetCallId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "call_id or run_id is required")
+	}
+	runs, err := troubleshoot.ListRuns()
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	for _, run := range runs {
+		if run.CallID == req.GetCallId() {
+			return runAnalysis(run)
+		}
+	}
+	return nil, status.Errorf(codes.NotFound, "no saved analysis of call %s (run: agent troubleshoot --call %s)", req.GetCallId(), req.GetCallId())
+}
+
+// runAnalysis converts a run's report
+func runAnalysis(run *troubleshoot.RunRecord) (*agentpb.Analysis, error) {
+	if run.Report == nil {
+		return nil, status.Errorf(codes.NotFound, "run %s has no analysis", run.ID)
+	}
+	a := analysisMessage(run.Report)
+	a.RunId = run.ID
+	return a, nil
+}
+
+// StreamEvents sends live call events until the client cancels
+func (s *Server) StreamEvents(req *agentpb.StreamEventsRequest, stream agentpb.AgentService_StreamEventsServer) error {
+	types := make(map[string]bool)
+	for _, t := range req.GetTypes() {
+		types[t] = true
+	}
+	ch, unsubscribe := s.bus.Subscribe(streamBuffer)
+	defer unsubscribe()
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case ev, ok := <-ch:
+			if !ok {
+				return nil
+			}
+			if (len(types) > 0 && !types[ev.Type]) || (req.GetCallId() != "" && ev.CallID != req.GetCallId()) {
+				continue
+			}
+			msg, err := event(ev)
+			if err != nil {
+				continue
+			}
+			if err := stream.Send(msg); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Serve runs the gRPC API on addr until ctx is done. Clients present
+// token, when set, as "authorization: Bearer <token>" metadata.
+func Serve(ctx context.Context, addr, token string, srv *Server) error {
+	lis, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	var opts []grpc.ServerOption
+	if token != "" {
+		opts = append(opts,
+			grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+				if err := authorize(ctx, token); err != nil {
+					return nil, err
+				}
+				return handler(ctx, req)
+			}),
+			grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+				if err := authorize(ss.Context(), token); err != nil {
+					return err
+				}
+				return handler(srv, ss)
+			}),
+		)
+	}
+	gs := grpc.NewServer(opts...)
+	agentpb.RegisterAgentServiceServer(gs, srv)
+	go func() {
+		<-ctx.Done()
+		// Event streams only end when their clients go; give unary
+		// calls a moment, then cut the streams
+		done := make(chan struct{})
+		go func() {
+			gs.GracefulStop()
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+			gs.Stop()
+		}
+	}()
+	return gs.Serve(lis)
+}
+
+// authorize checks the bearer token of a call
+func authorize(ctx context.Context, token string) error {
+	md, _ := metadata.FromIncomingContext(ctx)
+	for _, auth := range md.Get("authorization") {
+		got := strings.TrimPrefix(auth, "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(got), []byte(token)) == 1 {
+			return nil
+		}
+	}
+	return status.Error(codes.Unauthenticated, "missing or wrong bearer token")
+}
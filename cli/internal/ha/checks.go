@@ -0,0 +1,367 @@
+package ha
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// defaultMaxLag is how far the standby's replicated paths may trail
+const defaultMaxLag = 5 * time.Minute
+
+// parityListLimit caps the files named in the config parity check
+const parityListLimit = 8
+
+// CheckFloatingIP verifies that exactly one node holds the floating IP
+// and that it runs Asterisk and the engine
+func (p *Pair) CheckFloatingIP(ctx context.Context) health.Check {
+	const name = "HA floating IP"
+	owners, err := p.Owners(ctx)
+	if err != nil {
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     "Cannot check floating IP ownership",
+			Details:     err.Error(),
+			Remediation: "Set ha.floating_ip and make sure both nodes answer over SSH",
+		}
+	}
+	switch len(owners) {
+	case 0:
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     fmt.Sprintf("No node holds %s: calls cannot reach the pair", p.cfg.FloatingIP),
+			Remediation: p.managerHint("Start the cluster manager on the node that should be active"),
+		}
+	case 2:
+		return health.Check{
+			Name:    name,
+			Status:  health.StatusFail,
+			Message: fmt.Sprintf("Both %s and %s hold %s (split brain)", p.Local.Name, p.Peer.Name, p.cfg.FloatingIP),
+			Details: "Each node believes it is active; calls and replicated state diverge",
+			Remediation: p.managerHint("Restore the heartbeat between the nodes (VRRP is IP protocol 112 and " +
+				"must pass the firewalls), then stop the manager on the node that should be standby"),
+		}
+	}
+	active := owners[0]
+	ast, eng := active.Services(ctx)
+	check := health.Check{
+		Name:    name,
+		Status:  health.StatusPass,
+		Message: fmt.Sprintf("%s active on %s, %s standby", p.cfg.FloatingIP, active.Name, p.Other(active).Name),
+	}
+	var down []string
+	if !ast {
+		down = append(down, "Asterisk")
+	}
+	if !eng {
+		down = append(down, "the engine")
+	}
+	if len(down) > 0 {
+		check.Status = health.StatusFail
+		check.Details = fmt.Sprintf("%s not running on the active node %s", strings.Join(down, " and "), active.Name)
+		check.Remediation = "Start the services on " + active.Name + ", or fail over to " + p.Other(active).Name + " ('agent failover drill')"
+	}
+	return check
+}
+
+// managerHint prefixes the remediation with where the manager's state is
+func (p *Pair) managerHint(hint string) string {
+	switch p.cfg.Manager {
+	case ManagerKeepalived:
+		return hint + "; see 'systemctl status keepalived' and 'journalctl -u keepalived' on both nodes"
+	case ManagerPacemaker:
+		return hint + "; see 'pcs status' on either node"
+	}
+	return hint
+}
+
+// CheckReplication verifies that the active node's state reaches the
+// standby: the DRBD resource connected and up to date, and the
+// replicated paths no older on the standby than max_lag
+func (p *Pair) CheckReplication(ctx context.Context) health.Check {
+	const name = "HA state replication"
+	repl := p.cfg.Replication
+	if repl.DRBD == "" && len(repl.Paths) == 0 {
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusInfo,
+			Message:     "Skipped (ha.replication not configured)",
+			Remediation: "Set ha.replication.drbd or ha.replication.paths to check that state reaches the standby",
+		}
+	}
+	maxLag := defaultMaxLag
+	if repl.MaxLag != "" {
+		d, err := settings.ParseAge(repl.MaxLag)
+		if err != nil {
+			return health.Check{Name: name, Status: health.StatusFail, Message: "Invalid ha.replication.max_lag", Details: err.Error()}
+		}
+		maxLag = d
+	}
+
+	var problems, notes []string
+	status := health.StatusPass
+	worse := func(s health.CheckStatus) {
+		if s == health.StatusFail || status == health.StatusPass {
+			status = s
+		}
+	}
+	if repl.DRBD != "" {
+		st, err := p.drbdState(ctx, repl.DRBD)
+		switch {
+		case err != nil:
+			worse(health.StatusFail)
+			problems = append(problems, err.Error())
+		default:
+			s, msg := st.verdict()
+			if s != health.StatusPass {
+				worse(s)
+				problems = append(problems, "DRBD "+repl.DRBD+": "+msg)
+			} else {
+				notes = append(notes, "DRBD "+repl.DRBD+": "+msg)
+			}
+		}
+	}
+	if len(repl.Paths) > 0 {
+		s, msgs := p.pathLag(ctx, repl.Paths, maxLag)
+		if s != health.StatusPass {
+			worse(s)
+			problems = append(problems, msgs...)
+		} else {
+			notes = append(notes, msgs...)
+		}
+	}
+
+	check := health.Check{Name: name, Status: status}
+	if status == health.StatusPass {
+		check.Message = "State replicates to the standby"
+		check.Details = strings.Join(notes, "\n")
+		return check
+	}
+	check.Message = "The standby does not have the active node's state"
+	check.Details = strings.Join(problems, "\n")
+	check.Remediation = "A failover now loses state; fix replication before relying on the standby"
+	return check
+}
+
+// drbdState reads the resource's state on the local node
+func (p *Pair) drbdState(ctx context.Context, resource string) (drbdState, error) {
+	out, err := p.Local.Run(ctx, "drbdadm status "+remote.Quote([]string{resource})+" 2>/dev/null || cat /proc/drbd")
+	if err != nil {
+		return drbdState{}, fmt.Errorf("DRBD %s: %v", resource, err)
+	}
+	st, ok := parseDRBD(out)
+	if !ok {
+		return drbdState{}, fmt.Errorf("DRBD %s: no state in %q", resource, strings.TrimSpace(out))
+	}
+	return st, nil
+}
+
+// drbdState is a DRBD resource seen from one node
+type drbdState struct {
+	Role, PeerRole string
+	Disk, PeerDisk string
+	Connection     string
+	Done           string
+}
+
+// parseDRBD reads 'drbdadm status' (DRBD 9) or /proc/drbd (8.x)
+func parseDRBD(out string) (drbdState, bool) {
+	var st drbdState
+	for _, field := range strings.Fields(out) {
+		k, v, ok := strings.Cut(field, ":")
+		if !ok {
+			continue
+		}
+		switch k {
+		case "role":
+			// the peer's role follows the local one
+			if st.Role == "" {
+				st.Role = v
+			} else {
+				st.PeerRole = v
+			}
+		case "disk":
+			st.Disk = v
+		case "peer-disk":
+			st.PeerDisk = v
+		case "replication", "connection", "cs":
+			st.Connection = v
+		case "done":
+			st.Done = v
+		case "ro":
+			st.Role, st.PeerRole, _ = strings.Cut(v, "/")
+		case "ds":
+			st.Disk, st.PeerDisk, _ = strings.Cut(v, "/")
+		}
+	}
+	return st, st.Role != ""
+}
+
+// verdict rates the state: connected and up to date on both sides passes
+func (st drbdState) verdict() (health.CheckStatus, string) {
+	switch {
+	case st.Role == "Primary" && st.PeerRole == "Primary":
+		return health.StatusFail, "primary on both nodes (split brain)"
+	case st.Connection == "" || st.PeerRole == "":
+		return health.StatusFail, "peer not connected"
+	case strings.HasPrefix(st.Connection, "Sync") || st.Done != "":
+		msg := "resynchronizing (" + st.Connection
+		if st.Done != "" {
+			msg += ", " + st.Done + "% done"
+		}
+		return health.StatusWarn, msg + ")"
+	case st.Connection != "Established" && st.Connection != "Connected":
+		return health.StatusFail, "connection " + st.Connection
+	case st.Disk != "UpToDate" || st.PeerDisk != "UpToDate":
+		return health.StatusFail, fmt.Sprintf("disk %s, peer disk %s", st.Disk, st.PeerDisk)
+	}
+	return health.StatusPass, fmt.Sprintf("%s/%s, connected, up to date", st.Role, st.PeerRole)
+}
+
+// pathLag compares the newest change under each path on both nodes. The
+// standby may trail the active node by maxLag; with no single active
+// node the nodes may differ by maxLag either way.
+func (p *Pair) pathLag(ctx context.Context, paths []string, maxLag time.Duration) (health.CheckStatus, []string) {
+	active, err := p.Active(ctx)
+	if err != nil {
+		active = nil
+	}
+	status := health.StatusPass
+	var msgs []string
+	for _, path := range paths {
+		newest := map[*Node]float64{}
+		for _, n := range p.Nodes() {
+			out, err := n.Run(ctx, "find "+remote.Quote([]string{n.path(path)})+" -type f -printf '%T@\\n' 2>/dev/null | sort -n | tail -1")
+			t, perr := strconv.ParseFloat(strings.TrimSpace(out), 64)
+			if err != nil || perr != nil {
+				status = health.StatusFail
+				msgs = append(msgs, fmt.Sprintf("%s: missing on %s", path, n.Name))
+				continue
+			}
+			newest[n] = t
+		}
+		if len(newest) < 2 {
+			continue
+		}
+		lag := newest[p.Local] - newest[p.Peer]
+		behind := p.Peer
+		if active != nil {
+			lag = newest[active] - newest[p.Other(active)]
+			behind = p.Other(active)
+		} else if lag < 0 {
+			lag, behind = -lag, p.Local
+		}
+		d := time.Duration(math.Max(lag, 0) * float64(time.Second)).Round(time.Second)
+		if d > maxLag {
+			if status == health.StatusPass {
+				status = health.StatusWarn
+			}
+			msgs = append(msgs, fmt.Sprintf("%s: %s trails by %s (max %s)", path, behind.Name, d, maxLag))
+		} else {
+			msgs = append(msgs, fmt.Sprintf("%s: in sync (lag %s)", path, d))
+		}
+	}
+	return status, msgs
+}
+
+// CheckConfigParity compares the SHA-256 of the files under the config
+// paths on both nodes, so the standby does not come up with another
+// dialplan, trunk or engine configuration
+func (p *Pair) CheckConfigParity(ctx context.Context) health.Check {
+	const name = "HA config parity"
+	sums := map[*Node]map[string]string{}
+	for _, n := range p.Nodes() {
+		var quoted []string
+		for _, path := range p.cfg.ConfigPaths {
+			quoted = append(quoted, remote.Quote([]string{n.path(path)}))
+		}
+		// Missing paths are compared too: find names nothing for them
+		out, _ := n.Run(ctx, "find "+strings.Join(quoted, " ")+" -type f -exec sha256sum {} + 2>/dev/null")
+		sums[n] = parseSums(out, n, p.cfg.ConfigPaths)
+		if len(sums[n]) == 0 {
+			return health.Check{
+				Name:        name,
+				Status:      health.StatusWarn,
+				Message:     "No config files found on " + n.Name,
+				Details:     "Compared: " + strings.Join(p.cfg.ConfigPaths, ", "),
+				Remediation: "Check ha.config_paths and ha.peer.dir",
+			}
+		}
+	}
+
+	local, peer := sums[p.Local], sums[p.Peer]
+	var differ, onlyLocal, onlyPeer []string
+	for path, sum := range local {
+		other, ok := peer[path]
+		switch {
+		case !ok:
+			onlyLocal = append(onlyLocal, path)
+		case other != sum:
+			differ = append(differ, path)
+		}
+	}
+	for path := range peer {
+		if _, ok := local[path]; !ok {
+			onlyPeer = append(onlyPeer, path)
+		}
+	}
+	if len(differ)+len(onlyLocal)+len(onlyPeer) == 0 {
+		return health.Check{
+			Name:    name,
+			Status:  health.StatusPass,
+			Message: fmt.Sprintf("%d config file(s) identical on both nodes", len(local)),
+		}
+	}
+	var details []string
+	add := func(label string, paths []string) {
+		if len(paths) == 0 {
+			return
+		}
+		sort.Strings(paths)
+		if len(paths) > parityListLimit {
+			paths = append(paths[:parityListLimit], fmt.Sprintf("... and %d more", len(paths)-parityListLimit))
+		}
+		details = append(details, label+": "+strings.Join(paths, ", "))
+	}
+	add("Differ", differ)
+	add("Only on "+p.Local.Name, onlyLocal)
+	add("Only on "+p.Peer.Name, onlyPeer)
+	return health.Check{
+		Name:        name,
+		Status:      health.StatusWarn,
+		Message:     fmt.Sprintf("%d config file(s) differ between %s and %s", len(differ)+len(onlyLocal)+len(onlyPeer), p.Local.Name, p.Peer.Name),
+		Details:     strings.Join(details, "\n"),
+		Remediation: "After a failover the standby runs its own copy; sync the files (e.g. rsync -a /etc/asterisk/ " + p.Peer.Name + ":/etc/asterisk/)",
+	}
+}
+
+// parseSums reads sha256sum output into sums keyed by the configured
+// path, so the nodes' project directories may differ
+func parseSums(out string, n *Node, paths []string) map[string]string {
+	sums := make(map[string]string)
+	for _, line := range strings.Split(out, "\n") {
+		sum, file, ok := strings.Cut(strings.TrimSpace(line), "  ")
+		if !ok {
+			continue
+		}
+		for _, path := range paths {
+			root := n.path(path)
+			if file == root || strings.HasPrefix(file, strings.TrimSuffix(root, "/")+"/") {
+				file = path + strings.TrimPrefix(file, root)
+				break
+			}
+		}
+		sums[file] = sum
+	}
+	return sums
+}
@@ -0,0 +1,216 @@
+package ha
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+)
+
+const (
+	// drillPoll is how often the drill checks the nodes during the
+	// switchover
+	drillPoll = 500 * time.Millisecond
+	// probeInterval and probeTimeout pace the TCP probe of --probe
+	probeInterval = 100 * time.Millisecond
+	probeTimeout  = 500 * time.Millisecond
+	// restoreTimeout bounds bringing the old node back as standby
+	restoreTimeout = 30 * time.Second
+)
+
+// DrillOptions configures a failover drill. Probe is a host:port dialed
+// over TCP throughout, e.g. the floating IP's SIP/TLS or ARI port, whose
+// longest outage is the service gap callers see. Progress, when set,
+// receives each step.
+type DrillOptions struct {
+	Timeout  time.Duration
+	Probe    string
+	Progress func(string)
+}
+
+// DrillResult is the outcome of a failover drill
+type DrillResult struct {
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Manager string    `json:"manager"`
+	Started time.Time `json:"started"`
+	// CallsBefore were in progress on the active node at the switchover
+	CallsBefore int `json:"calls_before"`
+	// TakeoverMs is when the standby held the floating IP, ReadyMs when
+	// it also ran Asterisk and the engine
+	TakeoverMs int64 `json:"takeover_ms"`
+	ReadyMs    int64 `json:"ready_ms"`
+	// ProbeGapMs is the longest the probe address was unreachable
+	ProbeGapMs int64 `json:"probe_gap_ms,omitempty"`
+	// CallsLeft are the calls still up on the old active node afterwards;
+	// CallsDropped those that ended during the switchover
+	CallsLeft    int `json:"calls_left"`
+	CallsDropped int `json:"calls_dropped"`
+	CallsAfter   int `json:"calls_after"`
+	// Restore is what brought the old node back as standby
+	Restore string `json:"restore,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Switchover returns the commands that move the floating IP away from
+// active, and that bring it back as standby afterwards (empty when the
+// manager has none)
+func (p *Pair) Switchover(ctx context.Context, active *Node) (trigger, restore string, err error) {
+	switch p.cfg.Manager {
+	case ManagerKeepalived:
+		return "systemctl stop keepalived", "systemctl start keepalived", nil
+	case ManagerPacemaker:
+		// Pacemaker knows the node by its cluster name
+		out, err := active.Run(ctx, "crm_node -n")
+		if err != nil {
+			return "", "", err
+		}
+		node := remote.Quote([]string{strings.TrimSpace(out)})
+		return "pcs node standby " + node, "pcs node unstandby " + node, nil
+	}
+	return p.cfg.SwitchoverCommand, "", nil
+}
+
+// Drill moves the floating IP from the active node to the standby with
+// the manager and measures how long calls cannot reach the pair and what
+// happened to the calls in progress. The old node is restored as standby
+// afterwards where the manager allows.
+func (p *Pair) Drill(ctx context.Context, opts DrillOptions) (_ *DrillResult, err error) {
+	progress := opts.Progress
+	if progress == nil {
+		progress = func(string) {}
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 2 * time.Minute
+	}
+	active, err := p.Active(ctx)
+	if err != nil {
+		return nil, err
+	}
+	standby := p.Other(active)
+	trigger, restore, err := p.Switchover(ctx, active)
+	if err != nil {
+		return nil, err
+	}
+	res := &DrillResult{From: active.Name, To: standby.Name, Manager: p.cfg.Manager}
+	if res.CallsBefore, err = active.ActiveCalls(ctx); err != nil {
+		return nil, err
+	}
+	progress(fmt.Sprintf("%d call(s) in progress on %s", res.CallsBefore, active.Name))
+
+	probeCtx, stopProbe := context.WithCancel(ctx)
+	defer stopProbe()
+	gap := make(chan time.Duration, 1)
+	if opts.Probe != "" {
+		go func() { gap <- probe(probeCtx, opts.Probe) }()
+	}
+
+	res.Started = time.Now()
+	progress(fmt.Sprintf("Running %q on %s", trigger, active.Name))
+	if _, err := active.Run(ctx, trigger); err != nil {
+		return nil, fmt.Errorf("switchover: %w", err)
+	}
+	// From here on the old node is restored whatever happens, Ctrl-C
+	// included, with a context of its own: left stopped or in standby it
+	// leaves the pair without redundancy
+	restored := false
+	restoreNode := func() {
+		if restore == "" || restored {
+			return
+		}
+		restored = true
+		rctx, cancel := context.WithTimeout(context.Background(), restoreTimeout)
+		defer cancel()
+		progress(fmt.Sprintf("Running %q on %s", restore, active.Name))
+		if _, err := active.Run(rctx, restore); err != nil {
+			res.Restore = "failed: " + err.Error()
+		} else {
+			res.Restore = restore
+		}
+	}
+	defer func() {
+		restoreNode()
+		if err != nil && strings.HasPrefix(res.Restore, "failed: ") {
+			err = fmt.Errorf("%w; restoring %s %s (run %q there)", err, active.Name, res.Restore, restore)
+		}
+	}()
+
+	deadline := time.Now().Add(opts.Timeout)
+	for res.ReadyMs == 0 {
+		if time.Now().After(deadline) {
+			res.Error = fmt.Sprintf("%s did not take over within %s", standby.Name, opts.Timeout)
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(drillPoll):
+		}
+		if res.TakeoverMs == 0 {
+			if holds, err := standby.HoldsIP(ctx, p.cfg.FloatingIP); err != nil || !holds {
+				continue
+			}
+			res.TakeoverMs = time.Since(res.Started).Milliseconds()
+			progress(fmt.Sprintf("%s holds %s after %s", standby.Name, p.cfg.FloatingIP, ms(res.TakeoverMs)))
+		}
+		if ast, eng := standby.Services(ctx); ast && eng {
+			res.ReadyMs = time.Since(res.Started).Milliseconds()
+			progress(fmt.Sprintf("Asterisk and the engine run on %s after %s", standby.Name, ms(res.ReadyMs)))
+		}
+	}
+
+	if n, err := active.ActiveCalls(ctx); err == nil {
+		res.CallsLeft = n
+	}
+	if res.CallsLeft < res.CallsBefore {
+		res.CallsDropped = res.CallsBefore - res.CallsLeft
+	}
+	if n, err := standby.ActiveCalls(ctx); err == nil {
+		res.CallsAfter = n
+	}
+	if opts.Probe != "" {
+		// Let the probe see the service answer again
+		time.Sleep(time.Second)
+		stopProbe()
+		res.ProbeGapMs = (<-gap).Milliseconds()
+	}
+
+	restoreNode()
+	return res, nil
+}
+
+// probe dials addr until ctx is done and returns the longest run of
+// failed dials
+func probe(ctx context.Context, addr string) time.Duration {
+	var longest time.Duration
+	var downSince time.Time
+	for {
+		d := net.Dialer{Timeout: probeTimeout}
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		now := time.Now()
+		if err == nil {
+			conn.Close()
+			if !downSince.IsZero() && now.Sub(downSince) > longest {
+				longest = now.Sub(downSince)
+			}
+			downSince = time.Time{}
+		} else if downSince.IsZero() && ctx.Err() == nil {
+			downSince = now
+		}
+		select {
+		case <-ctx.Done():
+			if !downSince.IsZero() && now.Sub(downSince) > longest {
+				longest = now.Sub(downSince)
+			}
+			return longest
+		case <-time.After(probeInterval):
+		}
+	}
+}
+
+func ms(v int64) string {
+	return (time.Duration(v) * time.Millisecond).Round(100 * time.Millisecond).String()
+}
@@ -0,0 +1,227 @@
+// Package ha diagnoses active/standby pairs of Asterisk+engine nodes:
+// which node holds the floating IP, whether state replicates to the
+// standby and whether both nodes run the same configuration, and drills
+// a controlled switchover between them.
+package ha
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Managers that move the floating IP
+const (
+	ManagerKeepalived = "keepalived"
+	ManagerPacemaker  = "pacemaker"
+	ManagerCommand    = "command"
+)
+
+// defaultConfigPaths are compared between the nodes when the settings
+// name none
+var defaultConfigPaths = []string{"/etc/asterisk", "config/ai-agent.yaml"}
+
+// Node is one node of the pair. The local node is this host, or the
+// remote host the CLI is pointed at; the peer is reached over SSH.
+type Node struct {
+	Name   string
+	target *remote.Target
+}
+
+// Run runs a sh script on the node and returns its output
+func (n *Node) Run(ctx context.Context, script string) (string, error) {
+	var out []byte
+	var err error
+	if n.target == nil {
+		out, err = selflog.CombinedOutput(remote.Shell(ctx, script))
+	} else {
+		out, err = selflog.CombinedOutput(n.target.Command(ctx, "sh", "-c", script))
+	}
+	if err != nil {
+		msg := strings.TrimSpace(string(out))
+		if msg == "" {
+			msg = err.Error()
+		}
+		return string(out), fmt.Errorf("%s: %s", n.Name, msg)
+	}
+	return string(out), nil
+}
+
+// path maps a project-relative path to the node
+func (n *Node) path(p string) string {
+	if n.target == nil {
+		return remote.Path(p)
+	}
+	if strings.HasPrefix(p, "/") {
+		return p
+	}
+	return path.Join(n.target.Dir, p)
+}
+
+// Pair is the two nodes of an active/standby pair
+type Pair struct {
+	Local *Node
+	Peer  *Node
+	cfg   settings.HA
+
+	once   sync.Once
+	owners []*Node
+	ownErr error
+}
+
+// NewPair creates the pair of the ha settings; nil when no peer is set
+func NewPair(cfg settings.HA) (*Pair, error) {
+	if cfg.Peer.Host == "" {
+		return nil, nil
+	}
+	peer, err := remote.FromSettings(cfg.Peer)
+	if err != nil {
+		return nil, fmt.Errorf("ha.peer: %w", err)
+	}
+	switch cfg.Manager {
+	case "":
+		cfg.Manager = ManagerKeepalived
+	case ManagerKeepalived, ManagerPacemaker:
+	case ManagerCommand:
+		if cfg.SwitchoverCommand == "" {
+			return nil, fmt.Errorf("ha: manager command needs switchover_command")
+		}
+	default:
+		return nil, fmt.Errorf("ha: unknown manager %q (use keepalived, pacemaker or command)", cfg.Manager)
+	}
+	if len(cfg.ConfigPaths) == 0 {
+		cfg.ConfigPaths = defaultConfigPaths
+	}
+	local := &Node{}
+	if t := remote.Current(); t != nil {
+		local.Name = t.Host
+	} else {
+		local.Name, _ = os.Hostname()
+	}
+	return &Pair{Local: local, Peer: &Node{Name: peer.Host, target: peer}, cfg: cfg}, nil
+}
+
+// Nodes returns both nodes, local first
+func (p *Pair) Nodes() []*Node {
+	return []*Node{p.Local, p.Peer}
+}
+
+// Other returns the node that is not n
+func (p *Pair) Other(n *Node) *Node {
+	if n == p.Local {
+		return p.Peer
+	}
+	return p.Local
+}
+
+// Manager is the manager moving the floating IP
+func (p *Pair) Manager() string {
+	return p.cfg.Manager
+}
+
+// FloatingIP is the address the active node holds
+func (p *Pair) FloatingIP() string {
+	return p.cfg.FloatingIP
+}
+
+// Owners returns the nodes holding the floating IP, checked once per
+// pair; exactly one is healthy
+func (p *Pair) Owners(ctx context.Context) ([]*Node, error) {
+	p.once.Do(func() {
+		p.owners, p.ownErr = p.findOwners(ctx)
+	})
+	return p.owners, p.ownErr
+}
+
+func (p *Pair) findOwners(ctx context.Context) ([]*Node, error) {
+	if p.cfg.FloatingIP == "" {
+		return nil, fmt.Errorf("ha.floating_ip is not set")
+	}
+	var owners []*Node
+	for _, n := range p.Nodes() {
+		holds, err := n.HoldsIP(ctx, p.cfg.FloatingIP)
+		if err != nil {
+			return nil, err
+		}
+		if holds {
+			owners = append(owners, n)
+		}
+	}
+	return owners, nil
+}
+
+// Active returns the single node holding the floating IP
+func (p *Pair) Active(ctx context.Context) (*Node, error) {
+	owners, err := p.Owners(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch len(owners) {
+	case 0:
+		return nil, fmt.Errorf("no node holds the floating IP %s", p.cfg.FloatingIP)
+	case 1:
+		return owners[0], nil
+	}
+	return nil, fmt.Errorf("both nodes hold the floating IP %s (split brain)", p.cfg.FloatingIP)
+}
+
+// HoldsIP reports whether an interface of the node has ip
+func (n *Node) HoldsIP(ctx context.Context, ip string) (bool, error) {
+	out, err := n.Run(ctx, "ip -o addr show")
+	if err != nil {
+		return false, err
+	}
+	return holdsIP(out, ip), nil
+}
+
+// holdsIP finds ip among the addresses of 'ip -o addr show'
+func holdsIP(out, ip string) bool {
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		for i := 0; i+1 < len(fields); i++ {
+			if fields[i] != "inet" && fields[i] != "inet6" {
+				continue
+			}
+			addr := fields[i+1]
+			if slash := strings.IndexByte(addr, '/'); slash >= 0 {
+				addr = addr[:slash]
+			}
+			if addr == ip {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Services reports whether Asterisk and the engine container run on the
+// node
+func (n *Node) Services(ctx context.Context) (asterisk, engineUp bool) {
+	out, _ := n.Run(ctx, "pgrep -x asterisk >/dev/null && echo asterisk; docker inspect -f '{{.State.Running}}' "+engine.ContainerName+" 2>/dev/null")
+	return strings.Contains(out, "asterisk"), strings.Contains(out, "true")
+}
+
+var activeCallsPattern = regexp.MustCompile(`(\d+) active calls?`)
+
+// ActiveCalls returns the calls Asterisk carries on the node
+func (n *Node) ActiveCalls(ctx context.Context) (int, error) {
+	out, err := n.Run(ctx, "asterisk -rx 'core show channels count'")
+	if err != nil {
+		return 0, err
+	}
+	m := activeCallsPattern.FindStringSubmatch(out)
+	if m == nil {
+		return 0, fmt.Errorf("%s: no call count in %q", n.Name, strings.TrimSpace(out))
+	}
+	return strconv.Atoi(m[1])
+}
@@ -0,0 +1,102 @@
+package hardware
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+)
+
+// Host is the CPU and memory the stack runs on
+type Host struct {
+	Arch     string
+	Model    string
+	CPUs     int
+	MemoryMB int
+}
+
+// Detect reads the host's architecture, board model (Raspberry Pi and
+// other device-tree boards), CPU count and memory
+func Detect() Host {
+	h := Host{Arch: runtime.GOARCH, CPUs: runtime.NumCPU()}
+	if data, err := os.ReadFile("/proc/device-tree/model"); err == nil {
+		h.Model = strings.TrimSpace(strings.TrimRight(string(data), "\x00"))
+	}
+	if f, err := os.Open("/proc/meminfo"); err == nil {
+		defer f.Close()
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			fields := strings.Fields(scanner.Text())
+			if len(fields) >= 2 && fields[0] == "MemTotal:" {
+				kb, _ := strconv.Atoi(fields[1])
+				h.MemoryMB = kb / 1024
+				break
+			}
+		}
+	}
+	return h
+}
+
+// ARM reports whether the host is 32- or 64-bit ARM
+func (h Host) ARM() bool {
+	return h.Arch == "arm" || h.Arch == "arm64"
+}
+
+// LowPower reports whether the host is a constrained ARM board: a
+// Raspberry Pi, or ARM with at most 4 CPUs or 8 GB of memory
+func (h Host) LowPower() bool {
+	if strings.Contains(h.Model, "Raspberry Pi") {
+		return true
+	}
+	return h.ARM() && (h.CPUs <= 4 || (h.MemoryMB > 0 && h.MemoryMB <= 8192))
+}
+
+func (h Host) String() string {
+	s := fmt.Sprintf("%d CPU(s), %.1f GB, %s", h.CPUs, float64(h.MemoryMB)/1024, h.Arch)
+	if h.Model != "" {
+		s = h.Model + ": " + s
+	}
+	return s
+}
+
+// reserve is the CPU (millicores) and memory (MB) left for the OS,
+// Asterisk and Docker
+const (
+	reserveCPU    = 500
+	reserveMemory = 512
+)
+
+// Capacity is how many concurrent calls the host can carry with the
+// requests 'agent deploy' sizes the engine (and local_ai_server) by
+func (h Host) Capacity(localAI bool) int {
+	cpu := h.CPUs*1000 - reserveCPU
+	mem := h.MemoryMB - reserveMemory
+	if h.MemoryMB == 0 {
+		mem = 1 << 30
+	}
+	calls := 0
+	for calls < 1000 {
+		n := calls + 1
+		needCPU, needMem := requests(deploy.EngineResources(n))
+		if localAI {
+			c, m := requests(deploy.LocalAIResources(n))
+			needCPU, needMem = needCPU+c, needMem+m
+		}
+		if needCPU > cpu || needMem > mem {
+			break
+		}
+		calls = n
+	}
+	return calls
+}
+
+// requests parses the "250m"/"384Mi" requests of r
+func requests(r deploy.Resources) (int, int) {
+	cpu, _ := strconv.Atoi(strings.TrimSuffix(r.CPURequest, "m"))
+	mem, _ := strconv.Atoi(strings.TrimSuffix(r.MemoryRequest, "Mi"))
+	return cpu, mem
+}
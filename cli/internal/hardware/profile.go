@@ -0,0 +1,388 @@
+package hardware
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"gopkg.in/yaml.v3"
+)
+
+// Profile names accepted by doctor --profile
+const (
+	ProfileAuto = "auto"
+	ProfileARM  = "arm"
+)
+
+// Constrained-hardware tuning: a deeper jitter buffer absorbs scheduling
+// stalls, and the local LLM gets one thread per core and a small context
+const (
+	ARMJitterBufferMs = 1200
+	ARMLowWatermarkMs = 160
+	ARMLLMContext     = 512
+)
+
+// Workload is what the configured providers run on this host
+type Workload struct {
+	Providers []string
+	// LocalAI is set when local_ai_server serves any of them, LocalLLM
+	// when it also runs the LLM
+	LocalAI  bool
+	LocalLLM bool
+	// STTBackend is local_ai_server's recognizer and WhisperModel the
+	// faster-whisper model size
+	STTBackend   string
+	WhisperModel string
+	LLMThreads   int
+	LLMContext   int
+	// Streaming holds the streaming: settings (jitter_buffer_ms, ...)
+	Streaming map[string]int
+}
+
+// LoadWorkload reads the workload from the config and .env in dir
+func LoadWorkload(dir string) (*Workload, error) {
+	p, err := deploy.LoadProfile(dir)
+	if err != nil {
+		return nil, err
+	}
+	var cfg struct {
+		Providers map[string]struct {
+			Type         string   `yaml:"type"`
+			Capabilities []string `yaml:"capabilities"`
+			STTBackend   string   `yaml:"stt_backend"`
+		} `yaml:"providers"`
+		Streaming map[string]interface{} `yaml:"streaming"`
+	}
+	if err := yaml.Unmarshal(p.Config, &cfg); err != nil {
+		return nil, err
+	}
+
+	w := &Workload{
+		Providers:    p.Providers,
+		LocalAI:      p.LocalAI,
+		STTBackend:   p.Env["LOCAL_STT_BACKEND"],
+		WhisperModel: p.Env["FASTER_WHISPER_MODEL"],
+		Streaming:    map[string]int{},
+	}
+	w.LLMThreads, _ = strconv.Atoi(p.Env["LOCAL_LLM_THREADS"])
+	w.LLMContext, _ = strconv.Atoi(p.Env["LOCAL_LLM_CONTEXT"])
+	for _, name := range p.Providers {
+		prov := cfg.Providers[name]
+		if !strings.HasPrefix(name, "local") && prov.Type != "local" {
+			continue
+		}
+		if w.STTBackend == "" && prov.STTBackend != "" {
+			w.STTBackend = prov.STTBackend
+		}
+		for _, capability := range prov.Capabilities {
+			if capability == "llm" {
+				w.LocalLLM = true
+			}
+		}
+	}
+	for k, v := range cfg.Streaming {
+		if n, ok := v.(int); ok {
+			w.Streaming[k] = n
+		}
+	}
+	// docker-compose.yml defaults
+	if w.STTBackend == "" {
+		w.STTBackend = "vosk"
+	}
+	if w.WhisperModel == "" {
+		w.WhisperModel = "base"
+	}
+	if w.LLMThreads == 0 {
+		w.LLMThreads = 16
+	}
+	if w.LLMContext == 0 {
+		w.LLMContext = 768
+	}
+	return w, nil
+}
+
+// Setting is a tuning change for constrained hardware: Key in the
+// streaming: block of ai-agent.yaml (File "yaml") or in .env (File "env")
+type Setting struct {
+	File    string
+	Key     string
+	Current string
+	Value   string
+	Why     string
+}
+
+func (s Setting) String() string {
+	where := "streaming." + s.Key
+	if s.File == "env" {
+		where = s.Key
+	}
+	return fmt.Sprintf("%s: %s → %s (%s)", where, s.Current, s.Value, s.Why)
+}
+
+// Tuning returns the settings to change for a low-power host
+func Tuning(h Host, w *Workload) []Setting {
+	var out []Setting
+	if v := w.Streaming["jitter_buffer_ms"]; v < ARMJitterBufferMs {
+		out = append(out, Setting{"yaml", "jitter_buffer_ms", orUnset(v), strconv.Itoa(ARMJitterBufferMs), "absorbs CPU stalls"})
+	}
+	if v := w.Streaming["low_watermark_ms"]; v < ARMLowWatermarkMs {
+		out = append(out, Setting{"yaml", "low_watermark_ms", orUnset(v), strconv.Itoa(ARMLowWatermarkMs), "avoids underruns"})
+	}
+	if w.LocalLLM {
+		if w.LLMThreads > h.CPUs {
+			out = append(out, Setting{"env", "LOCAL_LLM_THREADS", strconv.Itoa(w.LLMThreads), strconv.Itoa(h.CPUs), "one per core"})
+		}
+		if w.LLMContext > ARMLLMContext {
+			out = append(out, Setting{"env", "LOCAL_LLM_CONTEXT", strconv.Itoa(w.LLMContext), strconv.Itoa(ARMLLMContext), "faster prompts, less memory"})
+		}
+	}
+	return out
+}
+
+func orUnset(v int) string {
+	if v == 0 {
+		return "unset"
+	}
+	return strconv.Itoa(v)
+}
+
+// whisperTooLarge reports whether a faster-whisper model cannot run in
+// real time on a low-power host (medium and large), and whisperSlow
+// whether it is marginal (small)
+func whisperTooLarge(model string) bool {
+	return strings.HasPrefix(model, "medium") || strings.HasPrefix(model, "large") || strings.HasPrefix(model, "distil-large")
+}
+
+func whisperSlow(model string) bool {
+	return strings.HasPrefix(model, "small")
+}
+
+// Presets lists the cloud-provider configs shipped in dir/config, which
+// move STT, LLM and TTS off the host
+func Presets(dir string) []string {
+	matches, _ := filepath.Glob(filepath.Join(dir, "config", "ai-agent.golden-*.yaml"))
+	var out []string
+	for _, m := range matches {
+		if !strings.Contains(m, "local") {
+			out = append(out, filepath.ToSlash(m))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Check assesses the host against the workload. With ProfileAuto the
+// low-power limits apply only on low-power ARM hosts; ProfileARM applies
+// them everywhere.
+func Check(dir, profile string) health.Check {
+	const name = "Hardware"
+	h := Detect()
+	constrained := h.LowPower() || profile == ProfileARM
+	w, err := LoadWorkload(dir)
+	if err != nil {
+		return health.Check{
+			Name:    name,
+			Status:  health.StatusInfo,
+			Message: h.String(),
+			Details: "Workload not assessed: " + err.Error(),
+		}
+	}
+
+	capacity := h.Capacity(w.LocalAI)
+	details := []string{fmt.Sprintf("Capacity: ~%d concurrent call(s)", capacity)}
+	if w.LocalAI {
+		details[0] += " with local_ai_server"
+	}
+	if !constrained {
+		return health.Check{
+			Name:    name,
+			Status:  health.StatusInfo,
+			Message: h.String(),
+			Details: details[0],
+		}
+	}
+
+	status := health.StatusPass
+	var blocked, slow []string
+	if w.LocalAI && capacity == 0 {
+		need := deploy.LocalAIResources(1)
+		blocked = append(blocked, fmt.Sprintf("local_ai_server needs %s CPU and %s memory for one call", need.CPURequest, need.MemoryRequest))
+	}
+	if w.LocalAI && w.STTBackend == "faster_whisper" {
+		if whisperTooLarge(w.WhisperModel) {
+			blocked = append(blocked, fmt.Sprintf("faster-whisper %s cannot transcribe in real time here (use tiny or base)", w.WhisperModel))
+		} else if whisperSlow(w.WhisperModel) {
+			slow = append(slow, fmt.Sprintf("faster-whisper %s is marginal here (tiny or base keep up)", w.WhisperModel))
+		}
+	}
+	if w.LocalLLM && len(blocked) == 0 {
+		slow = append(slow, "the local LLM takes seconds per turn on this CPU; a cloud LLM keeps replies fast")
+	}
+	tuning := Tuning(h, w)
+
+	message := "Suited to the configured providers (" + strings.Join(w.Providers, ", ") + ")"
+	switch {
+	case len(blocked) > 0:
+		status = health.StatusFail
+		message = "Too small for the configured providers"
+		details = append(details, blocked...)
+		details = append(details, slow...)
+	case len(slow) > 0 || len(tuning) > 0:
+		status = health.StatusWarn
+		message = "Constrained host: "
+		if len(slow) > 0 {
+			message += "local models will be slow"
+		} else {
+			message += "tuning recommended"
+		}
+		details = append(details, slow...)
+	}
+	for _, s := range tuning {
+		details = append(details, "Tune "+s.String())
+	}
+
+	var remediation []string
+	if len(blocked) > 0 || len(slow) > 0 {
+		if presets := Presets(dir); len(presets) > 0 {
+			remediation = append(remediation, "Use a cloud preset: cp <preset> config/ai-agent.yaml")
+			for _, p := range presets {
+				remediation = append(remediation, "  "+p)
+			}
+		}
+	}
+	if len(tuning) > 0 {
+		remediation = append(remediation, "Apply the tuning: agent doctor --fix")
+	}
+	return health.Check{
+		Name:        name,
+		Status:      status,
+		Message:     fmt.Sprintf("%s: %s", h.String(), message),
+		Details:     strings.Join(details, "\n"),
+		Remediation: strings.Join(remediation, "\n"),
+	}
+}
+
+// Fix applies the tuning for a constrained host to dir/config/ai-agent.yaml
+// and dir/.env, returning the settings changed. It does nothing on a host
+// Check would not tune.
+func Fix(dir, profile string) ([]Setting, error) {
+	h := Detect()
+	if !h.LowPower() && profile != ProfileARM {
+		return nil, nil
+	}
+	w, err := LoadWorkload(dir)
+	if err != nil {
+		return nil, err
+	}
+	tuning := Tuning(h, w)
+	if len(tuning) == 0 {
+		return nil, nil
+	}
+	configPath := filepath.Join(dir, "config", "ai-agent.yaml")
+	config, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, err
+	}
+	envPath := filepath.Join(dir, ".env")
+	env, err := os.ReadFile(envPath)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	envChanged, configChanged := false, false
+	for _, s := range tuning {
+		if s.File == "env" {
+			env, envChanged = SetEnv(env, s.Key, s.Value), true
+			continue
+		}
+		if config, err = SetStreaming(config, s.Key, s.Value); err != nil {
+			return nil, err
+		}
+		configChanged = true
+	}
+	if configChanged {
+		if err := os.WriteFile(configPath, config, 0644); err != nil {
+			return nil, err
+		}
+	}
+	if envChanged {
+		if err := os.WriteFile(envPath, env, 0600); err != nil {
+			return nil, err
+		}
+	}
+	return tuning, nil
+}
+
+// SetStreaming sets streaming.<key> in an ai-agent.yaml, editing only
+// that line so comments and layout survive. A missing key is added at the
+// top of the streaming block.
+func SetStreaming(data []byte, key, value string) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("ai-agent.yaml is not a mapping")
+	}
+	root := doc.Content[0]
+	var streaming, streamingKey *yaml.Node
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value == "streaming" {
+			streamingKey, streaming = root.Content[i], root.Content[i+1]
+		}
+	}
+	if streaming == nil || streaming.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("ai-agent.yaml has no streaming block")
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	for i := 0; i+1 < len(streaming.Content); i += 2 {
+		k, v := streaming.Content[i], streaming.Content[i+1]
+		if k.Value != key {
+			continue
+		}
+		if v.Kind != yaml.ScalarNode || v.Line != k.Line {
+			return nil, fmt.Errorf("streaming.%s is not a plain value", key)
+		}
+		line := lines[v.Line-1]
+		start := v.Column - 1
+		end := start + len(v.Value)
+		if end > len(line) || line[start:end] != v.Value {
+			return nil, fmt.Errorf("streaming.%s: cannot locate value", key)
+		}
+		lines[v.Line-1] = line[:start] + value + line[end:]
+		return []byte(strings.Join(lines, "")), nil
+	}
+
+	indent := strings.Repeat(" ", streamingKey.Column-1+2)
+	if len(streaming.Content) > 0 {
+		indent = strings.Repeat(" ", streaming.Content[0].Column-1)
+	}
+	at := streamingKey.Line
+	added := append([]string{}, lines[:at]...)
+	added = append(added, indent+key+": "+value+"\n")
+	added = append(added, lines[at:]...)
+	return []byte(strings.Join(added, "")), nil
+}
+
+// SetEnv sets KEY=value in a .env file, replacing the first KEY= line
+// (commented out or not) or appending one
+func SetEnv(data []byte, key, value string) []byte {
+	lines := strings.Split(string(data), "\n")
+	for i, line := range lines {
+		trimmed := strings.TrimLeft(strings.TrimSpace(line), "#")
+		if strings.HasPrefix(strings.TrimSpace(trimmed), key+"=") {
+			lines[i] = key + "=" + value
+			return []byte(strings.Join(lines, "\n"))
+		}
+	}
+	out := string(data)
+	if out != "" && !strings.HasSuffix(out, "\n") {
+		out += "\n"
+	}
+	return []byte(out + key + "=" + value + "\n")
+}
@@ -39,6 +39,9 @@ type Checker struct {
 	ctx     context.Context
 	envMap  map[string]string
 	platform *PlatformContext
+	cliVersion string
+	extra    []func() Check
+	offline  bool
 }
 
 func NewChecker(verbose bool) *Checker {
@@ -57,18 +60,35 @@ func NewChecker(verbose bool) *Checker {
 	}
 }
 
+// SetCLIVersion enables the CLI/engine version compatibility check
+func (c *Checker) SetCLIVersion(version string) {
+	c.cliVersion = version
+}
+
+// SetOffline marks an air-gapped deployment: cloud provider keys are
+// not expected
+func (c *Checker) SetOffline(offline bool) {
+	c.offline = offline
+}
+
+// AddCheck adds a check to RunAll, for checks that live outside this
+// package
+func (c *Checker) AddCheck(check func() Check) {
+	c.extra = append(c.extra, check)
+}
+
 func (c *Checker) RunAll() (*HealthResult, error) {
-	result := &HealthResult{
-		Timestamp: time.Now(),
-		Checks:    make([]Check, 0),
-	}
-	
 	// Run all checks in sequence
 	checks := []func() Check{
 		c.checkDocker,
 		c.checkCompose,
 		c.checkContainers,
+		c.checkEngineInstances,
+		c.checkVersionCompat,
 		c.checkAsteriskARI,
+		c.checkStasisApp,
+		c.checkClockSkew,
+		c.checkMediaSecurity,
 		c.checkAudioSocket,
 		c.checkConfiguration,
 		c.checkProviderKeys,
@@ -78,6 +98,33 @@ func (c *Checker) RunAll() (*HealthResult, error) {
 		c.checkLogs,
 		c.checkRecentCalls,
 	}
+	checks = append(checks, c.extra...)
+	
+	return c.run(checks), nil
+}
+
+// RunQuick runs the checks that reflect whether services came back after a
+// restart: Docker, containers, versions, ARI and the Stasis app, AudioSocket
+// and configuration
+func (c *Checker) RunQuick() (*HealthResult, error) {
+	checks := []func() Check{
+		c.checkDocker,
+		c.checkContainers,
+		c.checkEngineInstances,
+		c.checkVersionCompat,
+		c.checkAsteriskARI,
+		c.checkStasisApp,
+		c.checkAudioSocket,
+		c.checkConfiguration,
+	}
+	return c.run(checks), nil
+}
+
+func (c *Checker) run(checks []func() Check) *HealthResult {
+	result := &HealthResult{
+		Timestamp: time.Now(),
+		Checks:    make([]Check, 0),
+	}
 	
 	for i, checkFn := range checks {
 		if c.verbose {
@@ -101,7 +148,7 @@ func (c *Checker) RunAll() (*HealthResult, error) {
 	
 	result.TotalCount = len(result.Checks)
 	
-	return result, nil
+	return result
 }
 
 // AutoFix attempts to fix issues found during health checks
@@ -1,40 +1,85 @@
 package health
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
 	"gopkg.in/yaml.v3"
 )
 
 func (c *Checker) checkDocker() Check {
-	// Check if docker command exists
-	if _, err := exec.LookPath("docker"); err != nil {
-		installCmd := "curl -fsSL https://get.docker.com | sh"
-		aavaDocs := docsURL("docs/INSTALLATION.md")
-		if c.platform != nil && c.platform.Platform != nil {
-			if v := getString(c.platform.Platform, "docker", "install_cmd"); v != "" {
-				installCmd = v
-			}
-			if v := getString(c.platform.Platform, "docker", "aava_docs"); v != "" {
-				aavaDocs = docsURL(v)
-			}
-		}
+	client, err := docker.Default()
+	if err != nil {
 		return Check{
 			Name:        "Docker",
 			Status:      StatusFail,
-			Message:     "Docker not found",
-			Remediation: fmt.Sprintf("Run:\n%s\nDocs: %s", installCmd, aavaDocs),
+			Message:     "Docker endpoint misconfigured",
+			Details:     err.Error(),
+			Remediation: "Check DOCKER_HOST, DOCKER_CONTEXT and the current context (docker context ls)",
 		}
 	}
 	
 	// Check if docker daemon is running
-	cmd := exec.Command("docker", "ps")
-	if err := cmd.Run(); err != nil {
+	ctx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+	defer cancel()
+	version, err := client.Version(ctx)
+	if err != nil {
+		// No socket at all: Docker is not installed here
+		if path := client.SocketPath(); path != "" {
+			if _, serr := os.Stat(path); os.IsNotExist(serr) {
+				if _, perr := exec.LookPath("podman"); perr == nil {
+					enableCmd := "sudo systemctl enable --now podman.socket"
+					if os.Getuid() != 0 {
+						enableCmd = "systemctl --user enable --now podman.socket"
+					}
+					return Check{
+						Name:        "Docker",
+						Status:      StatusFail,
+						Message:     "Podman installed but its API socket is not running",
+						Details:     "Looked for: " + strings.Join(docker.LocalSockets(), ", "),
+						Remediation: fmt.Sprintf("Run: %s\nOr point at the socket: export CONTAINER_HOST=unix://<path to podman.sock>", enableCmd),
+					}
+				}
+				installCmd := "curl -fsSL https://get.docker.com | sh"
+				aavaDocs := docsURL("docs/INSTALLATION.md")
+				if c.platform != nil && c.platform.Platform != nil {
+					if v := getString(c.platform.Platform, "docker", "install_cmd"); v != "" {
+						installCmd = v
+					}
+					if v := getString(c.platform.Platform, "docker", "aava_docs"); v != "" {
+						aavaDocs = docsURL(v)
+					}
+				}
+				return Check{
+					Name:        "Docker",
+					Status:      StatusFail,
+					Message:     "Docker not found",
+					Details:     "No daemon socket at " + path,
+					Remediation: fmt.Sprintf("Run:\n%s\nDocs: %s\nRemote daemon: set DOCKER_HOST or 'docker context use <name>'", installCmd, aavaDocs),
+				}
+			}
+			if errors.Is(err, os.ErrPermission) {
+				return Check{
+					Name:        "Docker",
+					Status:      StatusFail,
+					Message:     "Permission denied on " + path,
+					Remediation: "Run: sudo usermod -aG docker $USER (then log in again), or run with sudo",
+				}
+			}
+		}
+		
 		startCmd := "sudo systemctl start docker"
 		rootlessStartCmd := ""
 		aavaDocs := docsURL("docs/INSTALLATION.md")
@@ -58,30 +103,53 @@ func (c *Checker) checkDocker() Check {
 		if rootlessStartCmd != "" {
 			remediation = remediation + fmt.Sprintf("\nRootless: %s\nRootless docs: %s", rootlessStartCmd, rootlessDocs)
 		}
+		message := "Docker daemon not running"
+		if client.SocketPath() == "" {
+			message = "Docker daemon at " + client.Host() + " unreachable"
+			remediation = "Check that the daemon listens on that address and the TLS certificates (DOCKER_CERT_PATH or the context's) are valid"
+		}
 		return Check{
 			Name:        "Docker",
 			Status:      StatusFail,
-			Message:     "Docker daemon not running",
+			Message:     message,
+			Details:     err.Error(),
 			Remediation: remediation,
 		}
 	}
 	
-	// Get Docker version
-	cmd = exec.Command("docker", "version", "--format", "{{.Server.Version}}")
-	output, _ := cmd.Output()
-	version := strings.TrimSpace(string(output))
-	
-	return Check{
+	check := Check{
 		Name:    "Docker",
 		Status:  StatusPass,
-		Message: fmt.Sprintf("Docker daemon running (v%s)", version),
+		Message: fmt.Sprintf("Docker daemon running (v%s)", version.Version),
+	}
+	if rt, err := client.Runtime(ctx); err == nil && (rt.Name == docker.RuntimePodman || rt.Rootless) {
+		check.Message = rt.String() + " running"
+	}
+	if client.Host() != docker.DefaultHost {
+		check.Details = "Daemon: " + client.Host()
 	}
+	return check
+}
+
+// engineLogs returns the last lines of the ai_engine container log
+func (c *Checker) engineLogs(tail int) (string, error) {
+	client, err := docker.Default()
+	if err != nil {
+		return "", err
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, 15*time.Second)
+	defer cancel()
+	output, err := client.LogsBytes(ctx, engine.ContainerName, docker.LogsOptions{Tail: tail})
+	return string(output), err
 }
 
 func (c *Checker) checkContainers() Check {
 	// Check if ai_engine container is running (note: underscore not hyphen)
-	cmd := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Status}}", "--filter", "name=ai_engine")
-	output, err := cmd.Output()
+	var containers []docker.Container
+	client, err := docker.Default()
+	if err == nil {
+		containers, err = client.Containers(c.ctx, docker.ListOptions{Name: "ai_engine"})
+	}
 	if err != nil {
 		return Check{
 			Name:        "Containers",
@@ -92,8 +160,7 @@ func (c *Checker) checkContainers() Check {
 		}
 	}
 	
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(lines) == 0 || lines[0] == "" {
+	if len(containers) == 0 {
 		return Check{
 			Name:        "Containers",
 			Status:      StatusFail,
@@ -103,10 +170,12 @@ func (c *Checker) checkContainers() Check {
 	}
 	
 	running := 0
-	for _, line := range lines {
-		if strings.Contains(line, "Up") {
+	var lines []string
+	for _, ct := range containers {
+		if ct.Running() {
 			running++
 		}
+		lines = append(lines, ct.Name()+"\t"+ct.Status)
 	}
 	
 	if running == 0 {
@@ -122,14 +191,14 @@ func (c *Checker) checkContainers() Check {
 		Name:    "Containers",
 		Status:  StatusPass,
 		Message: fmt.Sprintf("%d container(s) running", running),
-		Details: string(output),
+		Details: strings.Join(lines, "\n"),
 	}
 }
 
 func (c *Checker) checkCompose() Check {
 	// Prefer Docker Compose v2 plugin: docker compose
-	cmd := exec.Command("docker", "compose", "version", "--short")
-	output, err := cmd.Output()
+	cmd := remote.Command(context.Background(), "docker", "compose", "version", "--short")
+	output, err := selflog.Output(cmd)
 	if err == nil {
 		version := strings.TrimSpace(string(output))
 		// Version format: v2.24.1 or 2.24.1 depending on build.
@@ -166,9 +235,24 @@ func (c *Checker) checkCompose() Check {
 		return Check{Name: "Compose", Status: status, Message: message, Remediation: remediation}
 	}
 
+	// Podman: podman compose (4.7+) or the standalone podman-compose
+	for _, tool := range [][]string{{"podman", "compose", "version"}, {"podman-compose", "version"}} {
+		output, err := selflog.Output(remote.Command(context.Background(), tool[0], tool[1:]...))
+		if err != nil {
+			continue
+		}
+		version := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+		return Check{
+			Name:    "Compose",
+			Status:  StatusPass,
+			Message: "Podman Compose (" + strings.Join(tool[:len(tool)-1], " ") + ")",
+			Details: version,
+		}
+	}
+
 	// Fall back to docker-compose (v1). If present, treat as unsupported.
-	cmd = exec.Command("docker-compose", "version", "--short")
-	output, err = cmd.Output()
+	cmd = remote.Command(context.Background(), "docker-compose", "version", "--short")
+	output, err = selflog.Output(cmd)
 	if err == nil {
 		version := strings.TrimSpace(string(output))
 		version = strings.TrimPrefix(version, "v")
@@ -221,11 +305,11 @@ func (c *Checker) checkAsteriskARI() Check {
 	}
 	
 	// Try to connect to ARI HTTP endpoint
-	cmd := exec.Command("curl", "-s", "-o", "/dev/null", "-w", "%{http_code}",
+	cmd := remote.Command(context.Background(), "curl", "-s", "-o", "/dev/null", "-w", "%{http_code}",
 		"-u", fmt.Sprintf("%s:%s", ariUsername, ariPassword),
 		fmt.Sprintf("http://%s:8088/ari/asterisk/info", ariHost))
 	
-	output, err := cmd.Output()
+	output, err := selflog.Output(cmd)
 	if err != nil {
 		return Check{
 			Name:        "Asterisk ARI",
@@ -255,8 +339,8 @@ func (c *Checker) checkAsteriskARI() Check {
 
 func (c *Checker) checkAudioSocket() Check {
 	// Check if port 8090 is listening (typical AudioSocket port)
-	cmd := exec.Command("sh", "-c", "netstat -tuln 2>/dev/null | grep :8090 || ss -tuln 2>/dev/null | grep :8090")
-	if err := cmd.Run(); err != nil {
+	cmd := remote.Shell(context.Background(), "netstat -tuln 2>/dev/null | grep :8090 || ss -tuln 2>/dev/null | grep :8090")
+	if err := selflog.Run(cmd); err != nil {
 		return Check{
 			Name:    "AudioSocket",
 			Status:  StatusWarn,
@@ -298,7 +382,7 @@ func (c *Checker) checkConfiguration() Check {
 	}
 	
 	// Check if file is readable
-	raw, err := os.ReadFile(configPath)
+	raw, err := remote.ReadFile(configPath)
 	if err != nil {
 		return Check{
 			Name:        "Configuration",
@@ -443,6 +527,13 @@ func (c *Checker) checkConfiguration() Check {
 }
 
 func (c *Checker) checkProviderKeys() Check {
+	if c.offline {
+		return Check{
+			Name:    "Provider Keys",
+			Status:  StatusInfo,
+			Message: "Skipped (offline: no cloud providers)",
+		}
+	}
 	// Check for common provider API keys in environment or .env file
 	keys := map[string]string{
 		"OPENAI_API_KEY":   "OpenAI",
@@ -486,8 +577,7 @@ func (c *Checker) checkProviderKeys() Check {
 
 func (c *Checker) checkAudioPipeline() Check {
 	// Check if we can find recent audio pipeline logs (note: ai_engine with underscore)
-	cmd := exec.Command("docker", "logs", "--tail", "100", "ai_engine")
-	output, err := cmd.Output()
+	logs, err := c.engineLogs(100)
 	
 	if err != nil {
 		return Check{
@@ -498,8 +588,6 @@ func (c *Checker) checkAudioPipeline() Check {
 		}
 	}
 	
-	logs := string(output)
-	
 	// Look for key indicators
 	indicators := map[string]string{
 		"StreamingPlaybackManager initialized": "Streaming manager active",
@@ -533,8 +621,11 @@ func (c *Checker) checkAudioPipeline() Check {
 
 func (c *Checker) checkNetwork() Check {
 	// Check Docker network and ARI connectivity
-	cmd := exec.Command("docker", "network", "ls", "--format", "{{.Name}}")
-	output, err := cmd.Output()
+	var networks []docker.Network
+	client, err := docker.Default()
+	if err == nil {
+		networks, err = client.Networks(c.ctx)
+	}
 	
 	if err != nil {
 		return Check{
@@ -545,8 +636,6 @@ func (c *Checker) checkNetwork() Check {
 		}
 	}
 	
-	networks := strings.Split(strings.TrimSpace(string(output)), "\n")
-	
 	// Check if using bridge, host, or custom network
 	ariHost := GetEnv("ASTERISK_HOST", c.envMap)
 	if ariHost == "" {
@@ -604,8 +693,7 @@ func (c *Checker) checkMediaDirectory() Check {
 
 func (c *Checker) checkLogs() Check {
 	// Check for recent errors in ai_engine logs (note: underscore)
-	cmd := exec.Command("docker", "logs", "--tail", "100", "ai_engine")
-	output, err := cmd.Output()
+	logs, err := c.engineLogs(100)
 	
 	if err != nil {
 		return Check{
@@ -616,8 +704,6 @@ func (c *Checker) checkLogs() Check {
 		}
 	}
 	
-	logs := string(output)
-	
 	// Count errors and warnings
 	errorCount := strings.Count(strings.ToUpper(logs), "ERROR")
 	warnCount := strings.Count(strings.ToUpper(logs), "WARN")
@@ -650,8 +736,7 @@ func (c *Checker) checkLogs() Check {
 
 func (c *Checker) checkRecentCalls() Check {
 	// Try to find recent call info from logs (note: ai_engine with underscore)
-	cmd := exec.Command("docker", "logs", "--tail", "500", "ai_engine")
-	output, err := cmd.Output()
+	logs, err := c.engineLogs(500)
 	
 	if err != nil {
 		return Check{
@@ -662,8 +747,6 @@ func (c *Checker) checkRecentCalls() Check {
 		}
 	}
 	
-	logs := string(output)
-	
 	// Look for call indicators
 	callIndicators := []string{
 		"call_id",
@@ -695,3 +778,96 @@ func (c *Checker) checkRecentCalls() Check {
 		Details: "See logs for details",
 	}
 }
+
+func (c *Checker) checkVersionCompat() Check {
+	if c.cliVersion == "" {
+		return Check{
+			Name:    "Version compatibility",
+			Status:  StatusInfo,
+			Message: "Skipped (CLI version unknown)",
+		}
+	}
+	
+	compat := engine.Check(c.ctx, c.cliVersion, c.envMap)
+	check := Check{
+		Name:        "Version compatibility",
+		Message:     compat.Message,
+		Remediation: compat.Remediation,
+	}
+	if compat.Source != "" {
+		check.Details = "Engine version from " + compat.Source
+	}
+	switch compat.Level {
+	case engine.CompatOK:
+		check.Status = StatusPass
+	case engine.CompatWarn:
+		check.Status = StatusWarn
+	case engine.CompatFail:
+		check.Status = StatusFail
+	default:
+		check.Status = StatusInfo
+	}
+	return check
+}
+
+func (c *Checker) checkEngineInstances() Check {
+	instances, err := engine.Instances(c.ctx)
+	if err != nil || len(instances) == 0 {
+		return Check{
+			Name:    "Engine instances",
+			Status:  StatusInfo,
+			Message: "No running engine instances found",
+		}
+	}
+	if len(instances) == 1 {
+		return Check{
+			Name:    "Engine instances",
+			Status:  StatusPass,
+			Message: "Single engine instance (" + instances[0].Container + ")",
+		}
+	}
+	
+	var details []string
+	unreachable, degraded, calls := 0, 0, 0
+	for _, inst := range instances {
+		h, err := engineclient.New(inst.HealthURL, "").Health(c.ctx)
+		if err != nil {
+			unreachable++
+			details = append(details, fmt.Sprintf("%s: /health unreachable at %s", inst.Container, inst.HealthURL))
+			continue
+		}
+		line := fmt.Sprintf("%s: %s, %d active call(s), AudioSocket %s", inst.Container, h.Status, h.ActiveCalls, valueOr(inst.AudioSocketPort, "default"))
+		if h.Draining {
+			line += ", draining"
+		}
+		if h.Status != "healthy" || h.Draining {
+			degraded++
+		}
+		calls += h.ActiveCalls
+		details = append(details, line)
+	}
+	
+	check := Check{
+		Name:    "Engine instances",
+		Status:  StatusPass,
+		Message: fmt.Sprintf("%d engine instances healthy (%d active calls)", len(instances), calls),
+		Details: strings.Join(details, "\n"),
+	}
+	switch {
+	case unreachable > 0:
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("%d of %d engine instances unreachable", unreachable, len(instances))
+		check.Remediation = "Check: docker logs <instance>; regenerate with: agent scale --engines " + strconv.Itoa(len(instances))
+	case degraded > 0:
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("%d of %d engine instances degraded or draining", degraded, len(instances))
+	}
+	return check
+}
+
+func valueOr(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
@@ -0,0 +1,201 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/engine"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+const (
+	// clockWarnSkew is the skew that starts to reorder events when logs
+	// of different sources are merged into one timeline
+	clockWarnSkew = 2 * time.Second
+	// clockFailSkew is the skew that makes --since windows miss calls
+	clockFailSkew = 30 * time.Second
+)
+
+// clockReading is one source's clock compared to the host's
+type clockReading struct {
+	source string
+	skew   time.Duration
+	// precision is how far off the reading itself may be: half the round
+	// trip, plus the source's resolution
+	precision time.Duration
+	err       error
+}
+
+// checkClockSkew compares the host clock with the ai_engine container's
+// and Asterisk's, and reports whether the host is NTP synchronized. Skew
+// reorders events when troubleshoot merges engine, Asterisk and host
+// logs, and shifts --since windows.
+func (c *Checker) checkClockSkew() Check {
+	const name = "Clock skew"
+	readings := []clockReading{c.engineClock(), c.asteriskClock()}
+	synced, ntpDetail := ntpSynchronized()
+
+	var details []string
+	worst := time.Duration(0)
+	worstSource := ""
+	measured := 0
+	for _, r := range readings {
+		if r.err != nil {
+			details = append(details, fmt.Sprintf("%s: not read (%v)", r.source, r.err))
+			continue
+		}
+		measured++
+		details = append(details, fmt.Sprintf("%s: %s (±%s)", r.source, formatSkew(r.skew), r.precision.Round(time.Millisecond)))
+		// Only skew beyond the reading's own precision counts
+		skew := abs(r.skew) - r.precision
+		if skew > worst {
+			worst, worstSource = skew, r.source
+		}
+	}
+	details = append(details, "NTP: "+ntpDetail)
+
+	check := Check{Name: name, Details: strings.Join(details, "\n")}
+	ntpFix := "Enable NTP on every host: timedatectl set-ntp true (systemd-timesyncd) or install chrony"
+	switch {
+	case worst >= clockFailSkew:
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("%s clock is %s off the host", worstSource, worst.Round(time.Second))
+		check.Remediation = ntpFix + "; until then troubleshoot timelines are out of order and --since misses events"
+	case worst >= clockWarnSkew:
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("%s clock is %s off the host", worstSource, worst.Round(100*time.Millisecond))
+		check.Remediation = ntpFix + "; skew reorders events in merged troubleshoot timelines"
+	case synced != nil && !*synced:
+		check.Status = StatusWarn
+		check.Message = "Host clock is not NTP synchronized"
+		check.Remediation = ntpFix
+	case measured == 0:
+		check.Status = StatusInfo
+		check.Message = "Engine and Asterisk clocks not readable"
+	default:
+		check.Status = StatusPass
+		check.Message = fmt.Sprintf("Clocks agree within %s", clockWarnSkew)
+		if synced != nil {
+			check.Message += ", host NTP synchronized"
+		}
+	}
+	return check
+}
+
+// engineClock reads the ai_engine container's clock. Containers share
+// the kernel clock of their Docker host, so this matters when DOCKER_HOST
+// is another machine.
+func (c *Checker) engineClock() clockReading {
+	r := clockReading{source: "ai_engine"}
+	client, err := docker.Default()
+	if err != nil {
+		r.err = err
+		return r
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+	defer cancel()
+	before := time.Now()
+	res, err := client.Exec(ctx, engine.ContainerName, nil, "date", "+%s.%N")
+	after := time.Now()
+	if err != nil {
+		r.err = err
+		return r
+	}
+	secs, err := strconv.ParseFloat(strings.TrimSpace(string(res.Stdout)), 64)
+	if err != nil {
+		r.err = fmt.Errorf("unexpected date output %q", strings.TrimSpace(string(res.Stdout)))
+		return r
+	}
+	remote := time.Unix(0, int64(secs*float64(time.Second)))
+	r.skew, r.precision = skewAt(remote, before, after)
+	return r
+}
+
+// asteriskClock reads Asterisk's clock through ARI's EPOCH, which has a
+// resolution of one second
+func (c *Checker) asteriskClock() clockReading {
+	r := clockReading{source: "Asterisk"}
+	client, err := ari.FromEnv(c.envMap)
+	if err != nil {
+		r.err = fmt.Errorf("ARI credentials not configured")
+		return r
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, 10*time.Second)
+	defer cancel()
+	before := time.Now()
+	value, err := client.GlobalVariable(ctx, "EPOCH")
+	after := time.Now()
+	if err != nil {
+		r.err = err
+		return r
+	}
+	epoch, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		r.err = fmt.Errorf("unexpected EPOCH %q", value)
+		return r
+	}
+	// EPOCH truncates; the true time is somewhere in the next second
+	remote := time.Unix(epoch, int64(500*time.Millisecond))
+	r.skew, r.precision = skewAt(remote, before, after)
+	r.precision += 500 * time.Millisecond
+	return r
+}
+
+// skewAt is how far remote is from the host clock at the middle of the
+// request that read it, and half the round trip as its precision
+func skewAt(remote, before, after time.Time) (time.Duration, time.Duration) {
+	rtt := after.Sub(before)
+	mid := before.Add(rtt / 2)
+	return remote.Sub(mid), rtt / 2
+}
+
+// ntpSynchronized reports the host's NTP state from systemd-timesyncd or
+// chrony; nil when neither says
+func ntpSynchronized() (*bool, string) {
+	yes, no := true, false
+	if out, err := selflog.Output(remote.Command(context.Background(), "timedatectl", "show", "-p", "NTPSynchronized", "--value")); err == nil {
+		switch strings.TrimSpace(string(out)) {
+		case "yes":
+			return &yes, "synchronized (timedatectl)"
+		case "no":
+			return &no, "not synchronized (timedatectl)"
+		}
+	}
+	if remote.Exists("/run/systemd/timesync/synchronized") {
+		return &yes, "synchronized (systemd-timesyncd)"
+	}
+	if out, err := selflog.Output(remote.Command(context.Background(), "chronyc", "tracking")); err == nil {
+		for _, line := range strings.Split(string(out), "\n") {
+			if !strings.HasPrefix(line, "Leap status") {
+				continue
+			}
+			status := strings.TrimSpace(line[strings.Index(line, ":")+1:])
+			if status == "Normal" {
+				return &yes, "synchronized (chrony)"
+			}
+			return &no, "chrony leap status " + status
+		}
+	}
+	return nil, "unknown (no timedatectl or chronyc)"
+}
+
+// formatSkew shows a skew as ahead of or behind the host
+func formatSkew(d time.Duration) string {
+	if d < 0 {
+		return (-d).Round(time.Millisecond).String() + " behind"
+	}
+	return d.Round(time.Millisecond).String() + " ahead"
+}
+
+func abs(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
@@ -2,22 +2,26 @@ package health
 
 import (
 	"bufio"
+	"bytes"
 	"os"
+	"regexp"
 	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
 )
 
 // LoadEnvFile loads environment variables from .env file
-// Returns a map of key-value pairs
+// Returns a map of key-value pairs. With a remote host the file is read
+// from the host's project directory.
 func LoadEnvFile(path string) (map[string]string, error) {
 	envMap := make(map[string]string)
 	
-	file, err := os.Open(path)
+	data, err := remote.ReadFile(path)
 	if err != nil {
 		return envMap, err
 	}
-	defer file.Close()
 	
-	scanner := bufio.NewScanner(file)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
 		
@@ -38,6 +42,35 @@ func LoadEnvFile(path string) (map[string]string, error) {
 	return envMap, scanner.Err()
 }
 
+// envRef matches ${NAME}, ${NAME:-default} and ${NAME:=default}
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(?::?[-=]([^}]*))?\}`)
+
+// EnvRef returns the variable named by the first ${NAME} reference in v
+func EnvRef(v string) (string, bool) {
+	if m := envRef.FindStringSubmatch(v); m != nil {
+		return m[1], true
+	}
+	return "", false
+}
+
+// ExpandEnv resolves ${NAME} references in a config value the way the
+// engine does: from the first of envs that sets NAME, then the process
+// environment, falling back to the inline default
+func ExpandEnv(v string, envs ...map[string]string) string {
+	return envRef.ReplaceAllStringFunc(v, func(ref string) string {
+		m := envRef.FindStringSubmatch(ref)
+		for _, env := range envs {
+			if val := env[m[1]]; val != "" {
+				return val
+			}
+		}
+		if val := os.Getenv(m[1]); val != "" {
+			return val
+		}
+		return m[2]
+	})
+}
+
 // GetEnv gets environment variable with fallback to .env file
 func GetEnv(key string, envMap map[string]string) string {
 	// First check OS environment
@@ -0,0 +1,159 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+)
+
+// securedEndpoint is a PJSIP endpoint configured for SRTP
+type securedEndpoint struct {
+	Name       string
+	Encryption string
+	Optimistic bool
+	Transport  string
+	Protocol   string
+	ChannelIDs []string
+}
+
+func sorceryTrue(v string) bool {
+	switch strings.ToLower(v) {
+	case "yes", "true", "on", "1":
+		return true
+	}
+	return false
+}
+
+// securedEndpoints returns the PJSIP endpoints whose media_encryption is
+// sdes or dtls, with their transport protocol, as Asterisk has them loaded
+func securedEndpoints(ctx context.Context, client *ari.Client) ([]securedEndpoint, int, error) {
+	endpoints, err := client.Endpoints(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	var out []securedEndpoint
+	total := 0
+	for _, e := range endpoints {
+		if e.Technology != "PJSIP" {
+			continue
+		}
+		total++
+		attrs, err := client.ConfigObject(ctx, "res_pjsip", "endpoint", e.Resource)
+		if err != nil {
+			continue
+		}
+		enc := attrs["media_encryption"]
+		if enc == "" || enc == "no" {
+			continue
+		}
+		s := securedEndpoint{
+			Name:       e.Resource,
+			Encryption: enc,
+			Optimistic: sorceryTrue(attrs["media_encryption_optimistic"]),
+			Transport:  attrs["transport"],
+			ChannelIDs: e.ChannelIDs,
+		}
+		if s.Transport != "" {
+			if t, err := client.ConfigObject(ctx, "res_pjsip", "transport", s.Transport); err == nil {
+				s.Protocol = t["protocol"]
+			}
+		}
+		out = append(out, s)
+	}
+	return out, total, nil
+}
+
+// checkMediaSecurity verifies that media is encrypted where PJSIP
+// endpoints are configured for SRTP: live calls on those endpoints must
+// have negotiated SRTP (SDES or a completed DTLS handshake), and the
+// configuration must not allow a silent fallback to cleartext RTP
+func (c *Checker) checkMediaSecurity() Check {
+	const name = "Media encryption"
+	client, err := ari.FromEnv(c.envMap)
+	if err != nil {
+		return Check{
+			Name:    name,
+			Status:  StatusInfo,
+			Message: "Skipped (ARI credentials not configured)",
+		}
+	}
+	ctx, cancel := context.WithTimeout(c.ctx, 15*time.Second)
+	defer cancel()
+
+	endpoints, total, err := securedEndpoints(ctx, client)
+	if err != nil {
+		return Check{
+			Name:    name,
+			Status:  StatusInfo,
+			Message: "Skipped (ARI unavailable)",
+			Details: err.Error(),
+		}
+	}
+	if len(endpoints) == 0 {
+		return Check{
+			Name:    name,
+			Status:  StatusInfo,
+			Message: fmt.Sprintf("No PJSIP endpoint requires SRTP (%d endpoint(s) with media_encryption=no)", total),
+		}
+	}
+
+	var details []string
+	cleartext, risky, encrypted := 0, 0, 0
+	for _, e := range endpoints {
+		line := fmt.Sprintf("%s: %s", e.Name, e.Encryption)
+		if e.Protocol != "" {
+			line += " over " + e.Protocol
+		}
+		var notes []string
+		if e.Optimistic {
+			notes = append(notes, "media_encryption_optimistic=yes falls back to RTP when the peer declines")
+		}
+		if e.Encryption == "sdes" && e.Protocol != "tls" && e.Protocol != "wss" {
+			notes = append(notes, "SDES keys are sent in cleartext SDP without a TLS transport")
+		}
+		if len(notes) > 0 {
+			risky++
+			line += " ⚠️ " + strings.Join(notes, "; ")
+		}
+		details = append(details, line)
+
+		for _, id := range e.ChannelIDs {
+			secure, err := client.Variable(ctx, id, "CHANNEL(rtp,secure)")
+			if err != nil {
+				// Hung up meanwhile, or no RTP (yet)
+				continue
+			}
+			if secure == "1" {
+				encrypted++
+				continue
+			}
+			cleartext++
+			reason := "SRTP not negotiated"
+			if e.Encryption == "dtls" {
+				reason = "DTLS handshake did not complete"
+			}
+			details = append(details, fmt.Sprintf("  ❌ call %s uses cleartext RTP (%s)", id, reason))
+		}
+	}
+
+	check := Check{
+		Name:    name,
+		Status:  StatusPass,
+		Message: fmt.Sprintf("SRTP on %d endpoint(s), %d live call(s) encrypted", len(endpoints), encrypted),
+		Details: strings.Join(details, "\n"),
+	}
+	switch {
+	case cleartext > 0:
+		check.Status = StatusFail
+		check.Message = fmt.Sprintf("%d live call(s) fell back to cleartext RTP on SRTP endpoints", cleartext)
+		check.Remediation = "Set media_encryption_optimistic=no so calls without SRTP fail instead of going out in cleartext; for DTLS check dtls_cert_file/dtls_ca_file and that the peer offers DTLS (asterisk -rx 'pjsip set logger on')"
+	case risky > 0:
+		check.Status = StatusWarn
+		check.Message = fmt.Sprintf("%d of %d SRTP endpoint(s) can fall back to cleartext or expose keys", risky, len(endpoints))
+		check.Remediation = "In pjsip.conf set media_encryption_optimistic=no and use a TLS transport for SDES endpoints, then: asterisk -rx 'module reload res_pjsip.so'"
+	}
+	return check
+}
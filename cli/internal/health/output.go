@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"io"
 
-	"github.com/fatih/color"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
 )
 
 func (r *HealthResult) OutputJSON(w io.Writer) error {
@@ -16,11 +16,11 @@ func (r *HealthResult) OutputJSON(w io.Writer) error {
 
 func (r *HealthResult) OutputText(w io.Writer) {
 	// Color setup
-	green := color.New(color.FgGreen, color.Bold).SprintFunc()
-	yellow := color.New(color.FgYellow, color.Bold).SprintFunc()
-	red := color.New(color.FgRed, color.Bold).SprintFunc()
-	blue := color.New(color.FgBlue, color.Bold).SprintFunc()
-	gray := color.New(color.FgHiBlack).SprintFunc()
+	green := theme.Bold(theme.Success).SprintFunc()
+	yellow := theme.Bold(theme.Warning).SprintFunc()
+	red := theme.Bold(theme.Error).SprintFunc()
+	blue := theme.Bold(theme.Info).SprintFunc()
+	gray := theme.Muted.SprintFunc()
 	
 	// Header
 	fmt.Fprintln(w)
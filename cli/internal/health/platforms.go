@@ -5,6 +5,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
 	"gopkg.in/yaml.v3"
 )
 
@@ -56,7 +57,7 @@ func detectOS() (string, string) {
 	id := "unknown"
 	family := "unknown"
 
-	raw, err := os.ReadFile("/etc/os-release")
+	raw, err := remote.ReadFile("/etc/os-release")
 	if err == nil {
 		for _, line := range strings.Split(string(raw), "\n") {
 			line = strings.TrimSpace(line)
@@ -88,7 +89,7 @@ func loadPlatformsYAML() map[string]interface{} {
 		if !fileExists(p) {
 			continue
 		}
-		raw, err := os.ReadFile(p)
+		raw, err := remote.ReadFile(p)
 		if err != nil {
 			continue
 		}
@@ -207,7 +208,6 @@ func fileExists(path string) bool {
 	if strings.Contains(path, "..") {
 		path = filepath.Clean(path)
 	}
-	_, err := os.Stat(path)
-	return err == nil
+	return remote.Exists(path)
 }
 
@@ -0,0 +1,103 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/ari"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"gopkg.in/yaml.v3"
+)
+
+// defaultAppName is the engine's Stasis app when none is configured
+const defaultAppName = "asterisk-ai-voice-agent"
+
+// stasisAppName returns the app the engine registers: ASTERISK_APP_NAME,
+// then asterisk.app_name in ai-agent.yaml
+func (c *Checker) stasisAppName() string {
+	if name := GetEnv("ASTERISK_APP_NAME", c.envMap); name != "" {
+		return name
+	}
+	for _, path := range []string{"config/ai-agent.yaml", "/app/config/ai-agent.yaml", "../config/ai-agent.yaml"} {
+		data, err := remote.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var cfg struct {
+			Asterisk struct {
+				AppName string `yaml:"app_name"`
+			} `yaml:"asterisk"`
+		}
+		if yaml.Unmarshal(data, &cfg) == nil && cfg.Asterisk.AppName != "" {
+			return cfg.Asterisk.AppName
+		}
+		break
+	}
+	return defaultAppName
+}
+
+// checkStasisApp verifies that the engine's Stasis app is registered with
+// Asterisk, reporting the first step that fails: connectivity, ARI
+// itself, credentials, write permission, then registration
+func (c *Checker) checkStasisApp() Check {
+	const name = "Stasis app registration"
+	client, err := ari.FromEnv(c.envMap)
+	if err != nil {
+		return Check{
+			Name:    name,
+			Status:  StatusInfo,
+			Message: "Skipped (ARI credentials not configured)",
+		}
+	}
+	app := c.stasisAppName()
+
+	ctx, cancel := context.WithTimeout(c.ctx, 15*time.Second)
+	defer cancel()
+	r := client.Diagnose(ctx, app)
+
+	check := Check{Name: name, Status: StatusFail}
+	switch r.Failure {
+	case ari.FailureNone:
+		check.Status = StatusPass
+		check.Message = fmt.Sprintf("%s registered (%d channel(s))", app, len(r.App.ChannelIDs))
+		check.Details = fmt.Sprintf("ARI %s, ping %s", r.Address, r.Latency.Round(time.Millisecond))
+	case ari.FailureUnreachable:
+		check.Message = "Cannot connect to ARI at " + r.Address
+		check.Details = r.Err.Error()
+		check.Remediation = "Check that Asterisk is running and http.conf has enabled=yes with bindaddr/bindport matching ASTERISK_HOST/ASTERISK_ARI_PORT"
+	case ari.FailureTimeout:
+		check.Message = "ARI at " + r.Address + " did not answer in time"
+		check.Details = r.Err.Error()
+		check.Remediation = "Check firewalls between this host and Asterisk, and that Asterisk is not overloaded"
+	case ari.FailureNotARI:
+		check.Message = "Asterisk HTTP server at " + r.Address + " answers, but not with ARI"
+		check.Details = r.Err.Error()
+		check.Remediation = "Set enabled = yes in ari.conf, then: asterisk -rx 'module reload res_ari.so'"
+	case ari.FailureAuth:
+		check.Message = "ARI rejected the credentials"
+		check.Details = r.Err.Error()
+		check.Remediation = "Make ASTERISK_ARI_USERNAME/ASTERISK_ARI_PASSWORD in .env match a user in ari.conf, then: asterisk -rx 'module reload res_ari.so'"
+	case ari.FailureForbidden:
+		check.Message = "ARI refused access for this user"
+		check.Details = r.Err.Error()
+		check.Remediation = "Check the user's permissions in ari.conf and any ACL in front of the Asterisk HTTP server"
+	case ari.FailureReadOnly:
+		check.Message = "ARI user is read-only; the engine cannot answer or bridge calls"
+		check.Remediation = "Set read_only = no for the user in ari.conf, then: asterisk -rx 'module reload res_ari.so'"
+	case ari.FailureNotRegistered:
+		check.Message = fmt.Sprintf("Asterisk is up but %s is not registered", app)
+		var names []string
+		for _, a := range r.Apps {
+			names = append(names, a.Name)
+		}
+		if len(names) > 0 {
+			check.Details = "Registered apps: " + strings.Join(names, ", ")
+		} else {
+			check.Details = "No Stasis apps registered"
+		}
+		check.Remediation = "The engine is not connected to ARI: check it is running (docker logs ai_engine), and that asterisk.app_name matches Stasis() in the dialplan"
+	}
+	return check
+}
@@ -0,0 +1,198 @@
+// Package healthz reports the state of the CLI's long-running modes
+// (serve, monitor synthetic) on /healthz and /readyz, so orchestrators
+// can supervise the daemon itself.
+package healthz
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// State is the health of one daemon: a heartbeat from its main loop and
+// the readiness of its components (log source, index, ...). A nil State
+// records nothing, for code shared with commands that serve no health.
+type State struct {
+	mu         sync.Mutex
+	started    time.Time
+	beat       time.Time
+	stall      time.Duration
+	maxIdle    time.Duration
+	components map[string]*component
+}
+
+type component struct {
+	err       error
+	events    bool
+	lastEvent time.Time
+}
+
+// New creates the state of a daemon whose main loop beats at least every
+// stall; a loop that stops beating fails /healthz
+func New(stall time.Duration) *State {
+	now := time.Now()
+	return &State{
+		started:    now,
+		beat:       now,
+		stall:      stall,
+		components: make(map[string]*component),
+	}
+}
+
+// SetMaxIdle fails /readyz when a component that receives events has had
+// none for d; 0 never does, for quiet systems
+func (s *State) SetMaxIdle(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.maxIdle = d
+	s.mu.Unlock()
+}
+
+// Beat records that the main loop is running
+func (s *State) Beat() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.beat = time.Now()
+	s.mu.Unlock()
+}
+
+// Set records whether a component works: nil is ready
+func (s *State) Set(name string, err error) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.get(name).err = err
+	s.mu.Unlock()
+}
+
+// Seen records an event received by a component, e.g. a syslog message
+func (s *State) Seen(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	c := s.get(name)
+	c.events = true
+	c.lastEvent = time.Now()
+	s.mu.Unlock()
+}
+
+// Expect marks a component as receiving events before the first arrives,
+// so the max idle time counts from the start
+func (s *State) Expect(name string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.get(name).events = true
+	s.mu.Unlock()
+}
+
+func (s *State) get(name string) *component {
+	c, ok := s.components[name]
+	if !ok {
+		c = &component{}
+		s.components[name] = c
+	}
+	return c
+}
+
+// Component is one component in the JSON answer
+type Component struct {
+	Name  string `json:"name"`
+	Ready bool   `json:"ready"`
+	Error string `json:"error,omitempty"`
+	// LastEventAgeS is the age of the last event in seconds, omitted for
+	// components that take no events; -1 when none arrived yet
+	LastEventAgeS *float64 `json:"last_event_age_s,omitempty"`
+}
+
+// Report is the JSON answer of /healthz and /readyz
+type Report struct {
+	Status       string      `json:"status"`
+	Live         bool        `json:"live"`
+	Ready        bool        `json:"ready"`
+	UptimeS      float64     `json:"uptime_s"`
+	LastBeatAgeS float64     `json:"last_beat_age_s"`
+	Components   []Component `json:"components"`
+}
+
+// Report returns the state at now
+func (s *State) Report(now time.Time) Report {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := Report{
+		UptimeS:      seconds(now.Sub(s.started)),
+		LastBeatAgeS: seconds(now.Sub(s.beat)),
+		Live:         now.Sub(s.beat) <= s.stall,
+	}
+	r.Ready = r.Live
+	names := make([]string, 0, len(s.components))
+	for name := range s.components {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		c := s.components[name]
+		out := Component{Name: name, Ready: c.err == nil}
+		if c.err != nil {
+			out.Error = c.err.Error()
+		}
+		if c.events {
+			age := -1.0
+			since := s.started
+			if !c.lastEvent.IsZero() {
+				age = seconds(now.Sub(c.lastEvent))
+				since = c.lastEvent
+			}
+			out.LastEventAgeS = &age
+			if out.Ready && s.maxIdle > 0 && now.Sub(since) > s.maxIdle {
+				out.Ready = false
+				out.Error = fmt.Sprintf("no events for over %s", s.maxIdle)
+			}
+		}
+		r.Ready = r.Ready && out.Ready
+		r.Components = append(r.Components, out)
+	}
+	switch {
+	case !r.Live:
+		r.Status = "stalled"
+	case !r.Ready:
+		r.Status = "degraded"
+	default:
+		r.Status = "ok"
+	}
+	return r
+}
+
+func seconds(d time.Duration) float64 {
+	return float64(d.Round(100*time.Millisecond)) / float64(time.Second)
+}
+
+// Register adds /healthz (liveness: the main loop runs) and /readyz
+// (readiness: every component works) to mux. Both answer 200 or 503
+// with the Report as JSON.
+func (s *State) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", s.handler(func(r Report) bool { return r.Live }))
+	mux.HandleFunc("/readyz", s.handler(func(r Report) bool { return r.Ready }))
+}
+
+func (s *State) handler(ok func(Report) bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		r := s.Report(time.Now())
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if !ok(r) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		json.NewEncoder(w).Encode(r)
+	}
+}
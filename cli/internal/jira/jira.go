@@ -0,0 +1,160 @@
+package jira
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// Client talks to the Jira REST API (v2, Cloud and Data Center)
+type Client struct {
+	baseURL string
+	email   string
+	token   string
+	http    *http.Client
+}
+
+// New creates a client. With email set, token is a Cloud API token used
+// with basic auth; without it, token is a Data Center personal access token.
+func New(baseURL, email, token string) (*Client, error) {
+	if baseURL == "" || token == "" {
+		return nil, fmt.Errorf("jira: url and token are required")
+	}
+	return &Client{
+		baseURL: strings.TrimRight(baseURL, "/"),
+		email:   email,
+		token:   token,
+		http:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// IssueURL returns the browse URL of an issue
+func (c *Client) IssueURL(key string) string {
+	return c.baseURL + "/browse/" + key
+}
+
+func (c *Client) do(ctx context.Context, method, path string, body io.Reader, contentType string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	if c.email != "" {
+		req.SetBasicAuth(c.email, c.token)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	req.Header.Set("Accept", "application/json")
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// Required for attachment uploads
+	req.Header.Set("X-Atlassian-Token", "no-check")
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("jira %s %s: %s: %s", method, path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+func (c *Client) doJSON(ctx context.Context, method, path string, in, out interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, method, path, bytes.NewReader(data), "application/json", out)
+}
+
+// FindOpenIssue returns the key of the most recently updated unresolved
+// issue in project carrying label, or "" if there is none
+func (c *Client) FindOpenIssue(ctx context.Context, project, label string) (string, error) {
+	jql := fmt.Sprintf(`project = %q AND labels = %q AND statusCategory != Done ORDER BY updated DESC`, project, label)
+	params := url.Values{}
+	params.Set("jql", jql)
+	params.Set("maxResults", "1")
+	params.Set("fields", "key")
+
+	var result struct {
+		Issues []struct {
+			Key string `json:"key"`
+		} `json:"issues"`
+	}
+	if err := c.do(ctx, "GET", "/rest/api/2/search?"+params.Encode(), nil, "", &result); err != nil {
+		return "", err
+	}
+	if len(result.Issues) == 0 {
+		return "", nil
+	}
+	return result.Issues[0].Key, nil
+}
+
+// Issue is the content of a new issue
+type Issue struct {
+	Project     string
+	Type        string
+	Summary     string
+	Description string
+	Labels      []string
+}
+
+// CreateIssue opens an issue and returns its key
+func (c *Client) CreateIssue(ctx context.Context, issue Issue) (string, error) {
+	fields := map[string]interface{}{
+		"project":     map[string]string{"key": issue.Project},
+		"issuetype":   map[string]string{"name": issue.Type},
+		"summary":     truncate(issue.Summary, 250),
+		"description": issue.Description,
+		"labels":      issue.Labels,
+	}
+	var created struct {
+		Key string `json:"key"`
+	}
+	if err := c.doJSON(ctx, "POST", "/rest/api/2/issue", map[string]interface{}{"fields": fields}, &created); err != nil {
+		return "", err
+	}
+	return created.Key, nil
+}
+
+// AddComment comments on an issue
+func (c *Client) AddComment(ctx context.Context, key, body string) error {
+	return c.doJSON(ctx, "POST", "/rest/api/2/issue/"+url.PathEscape(key)+"/comment", map[string]string{"body": body}, nil)
+}
+
+// Attach uploads a file to an issue
+func (c *Client) Attach(ctx context.Context, key, filename string, data []byte) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	part, err := w.CreateFormFile("file", filename)
+	if err != nil {
+		return err
+	}
+	if _, err := part.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.do(ctx, "POST", "/rest/api/2/issue/"+url.PathEscape(key)+"/attachments", &buf, w.FormDataContentType(), nil)
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n-3] + "..."
+}
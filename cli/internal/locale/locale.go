@@ -0,0 +1,181 @@
+// Package locale formats numbers, durations and timestamps of reports
+// for readers in different regions: decimal and thousands separators,
+// date order and the 12 or 24-hour clock.
+package locale
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Locale is how one region writes numbers and times
+type Locale struct {
+	Name string
+	// Decimal separates the fraction; Group separates thousands, empty
+	// for no grouping
+	Decimal string
+	Group   string
+	// DateLayout is a Go layout of the date alone, e.g. 02.01.2006
+	DateLayout string
+	// Clock12 writes times as 3:04:05 PM instead of 15:04:05
+	Clock12 bool
+}
+
+// Default writes ISO dates, 24-hour times and plain numbers, the way
+// reports read without a locale
+var Default = &Locale{Decimal: ".", DateLayout: "2006-01-02"}
+
+// nbsp groups thousands where a space is customary, and keeps the
+// number on one line
+const nbsp = "\u00a0"
+
+var locales = map[string]*Locale{
+	"en-US": {Decimal: ".", Group: ",", DateLayout: "01/02/2006", Clock12: true},
+	"en-GB": {Decimal: ".", Group: ",", DateLayout: "02/01/2006"},
+	"en-AU": {Decimal: ".", Group: ",", DateLayout: "02/01/2006", Clock12: true},
+	"en-CA": {Decimal: ".", Group: ",", DateLayout: "2006-01-02", Clock12: true},
+	"en-IN": {Decimal: ".", Group: ",", DateLayout: "02/01/2006", Clock12: true},
+	"de-DE": {Decimal: ",", Group: ".", DateLayout: "02.01.2006"},
+	"de-CH": {Decimal: ".", Group: "’", DateLayout: "02.01.2006"},
+	"fr-FR": {Decimal: ",", Group: nbsp, DateLayout: "02/01/2006"},
+	"fr-CA": {Decimal: ",", Group: nbsp, DateLayout: "2006-01-02"},
+	"es-ES": {Decimal: ",", Group: ".", DateLayout: "02/01/2006"},
+	"es-MX": {Decimal: ".", Group: ",", DateLayout: "02/01/2006", Clock12: true},
+	"it-IT": {Decimal: ",", Group: ".", DateLayout: "02/01/2006"},
+	"pt-BR": {Decimal: ",", Group: ".", DateLayout: "02/01/2006"},
+	"pt-PT": {Decimal: ",", Group: nbsp, DateLayout: "02/01/2006"},
+	"nl-NL": {Decimal: ",", Group: ".", DateLayout: "02-01-2006"},
+	"sv-SE": {Decimal: ",", Group: nbsp, DateLayout: "2006-01-02"},
+	"pl-PL": {Decimal: ",", Group: nbsp, DateLayout: "02.01.2006"},
+	"ja-JP": {Decimal: ".", Group: ",", DateLayout: "2006/01/02"},
+	"zh-CN": {Decimal: ".", Group: ",", DateLayout: "2006/01/02"},
+	"ko-KR": {Decimal: ".", Group: ",", DateLayout: "2006. 01. 02.", Clock12: true},
+}
+
+func init() {
+	for name, l := range locales {
+		l.Name = name
+	}
+}
+
+// Names lists the known locales, sorted
+func Names() []string {
+	names := make([]string, 0, len(locales))
+	for name := range locales {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Get returns the locale of name (de-DE, de_DE.UTF-8 or just de) with
+// clock ("12h", "24h" or empty for the locale's own) applied. An empty
+// name is Default.
+func Get(name, clock string) (*Locale, error) {
+	l := Default
+	if name != "" {
+		found, ok := lookup(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown locale %q (known: %s)", name, strings.Join(Names(), ", "))
+		}
+		l = found
+	}
+	switch clock {
+	case "":
+		return l, nil
+	case "12h", "24h":
+		c := *l
+		c.Clock12 = clock == "12h"
+		return &c, nil
+	}
+	return nil, fmt.Errorf("unknown clock %q (use 12h or 24h)", clock)
+}
+
+func lookup(name string) (*Locale, bool) {
+	// de_DE.UTF-8 -> de-DE
+	if i := strings.IndexAny(name, ".@"); i >= 0 {
+		name = name[:i]
+	}
+	name = strings.Replace(name, "_", "-", -1)
+	parts := strings.SplitN(name, "-", 2)
+	lang := strings.ToLower(parts[0])
+	if len(parts) == 2 {
+		if l, ok := locales[lang+"-"+strings.ToUpper(parts[1])]; ok {
+			return l, true
+		}
+	}
+	// A language alone (or an unknown region) takes its home region (de-DE,
+	// fr-FR), en-US for English, else the first one known
+	if lang == "en" {
+		return locales["en-US"], true
+	}
+	if l, ok := locales[lang+"-"+strings.ToUpper(lang)]; ok {
+		return l, true
+	}
+	for _, n := range Names() {
+		if strings.HasPrefix(n, lang+"-") {
+			return locales[n], true
+		}
+	}
+	return nil, false
+}
+
+// Number writes v with decimals fraction digits
+func (l *Locale) Number(v float64, decimals int) string {
+	s := strconv.FormatFloat(v, 'f', decimals, 64)
+	neg := strings.HasPrefix(s, "-")
+	s = strings.TrimPrefix(s, "-")
+	whole, frac := s, ""
+	if i := strings.Index(s, "."); i >= 0 {
+		whole, frac = s[:i], s[i+1:]
+	}
+	if l.Group != "" && len(whole) > 3 {
+		var b strings.Builder
+		for i, d := range whole {
+			if i > 0 && (len(whole)-i)%3 == 0 {
+				b.WriteString(l.Group)
+			}
+			b.WriteRune(d)
+		}
+		whole = b.String()
+	}
+	if frac != "" {
+		whole += l.Decimal + frac
+	}
+	// -0 rounds to 0
+	if neg && strings.Trim(s, "0.") != "" {
+		whole = "-" + whole
+	}
+	return whole
+}
+
+// Int writes a count
+func (l *Locale) Int(n int) string {
+	return l.Number(float64(n), 0)
+}
+
+// Signed writes v with its sign, for changes such as +12
+func (l *Locale) Signed(v float64, decimals int) string {
+	s := l.Number(v, decimals)
+	if !strings.HasPrefix(s, "-") {
+		s = "+" + s
+	}
+	return s
+}
+
+// Milliseconds writes a duration in ms, e.g. 1,250ms
+func (l *Locale) Milliseconds(ms float64) string {
+	return l.Number(ms, 0) + "ms"
+}
+
+// Timestamp writes the date and time of t in loc with its zone
+func (l *Locale) Timestamp(t time.Time, loc *time.Location) string {
+	layout := "15:04:05 MST"
+	if l.Clock12 {
+		layout = "3:04:05 PM MST"
+	}
+	return t.In(loc).Format(l.DateLayout + " " + layout)
+}
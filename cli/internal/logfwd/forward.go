@@ -0,0 +1,174 @@
+package logfwd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Forwarding targets
+const (
+	TargetLoki    = "loki"
+	TargetElastic = "elastic"
+	TargetS3      = "s3"
+)
+
+// Targets lists the supported forwarding targets
+var Targets = []string{TargetLoki, TargetElastic, TargetS3}
+
+// DefaultContainers are the stack containers whose logs are forwarded
+var DefaultContainers = []string{"ai_engine", "local_ai_server", "admin_ui"}
+
+// VectorImage is the sidecar image used to ship logs
+const VectorImage = "timberio/vector:0.39.0-alpine"
+
+// Options describes where and how to forward logs
+type Options struct {
+	Target     string
+	URL        string
+	Index      string
+	Bucket     string
+	Region     string
+	Prefix     string
+	Containers []string
+}
+
+// Validate checks that the options needed by the target are set
+func (o *Options) Validate() error {
+	switch o.Target {
+	case TargetLoki, TargetElastic:
+		if o.URL == "" {
+			return fmt.Errorf("--url is required for %s", o.Target)
+		}
+	case TargetS3:
+		if o.Bucket == "" {
+			return fmt.Errorf("--bucket is required for s3")
+		}
+	default:
+		return fmt.Errorf("unknown target %q (use %s)", o.Target, strings.Join(Targets, ", "))
+	}
+	if len(o.Containers) == 0 {
+		o.Containers = DefaultContainers
+	}
+	if o.Target == TargetElastic && o.Index == "" {
+		o.Index = "ai-voice-agent-%Y.%m.%d"
+	}
+	if o.Target == TargetS3 {
+		if o.Region == "" {
+			o.Region = "us-east-1"
+		}
+		if o.Prefix == "" {
+			o.Prefix = "ai-voice-agent/%Y/%m/%d/"
+		}
+	}
+	return nil
+}
+
+// GenerateVectorConfig renders the vector.toml for the sidecar. Lines are
+// shipped unmodified so troubleshoot parses them exactly like docker logs.
+func GenerateVectorConfig(o Options) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by: agent logging forward\n")
+	sb.WriteString("# Ships Asterisk AI Voice Agent container logs unmodified.\n\n")
+
+	sb.WriteString("[sources.agent_containers]\n")
+	sb.WriteString("type = \"docker_logs\"\n")
+	sb.WriteString(fmt.Sprintf("include_containers = [%s]\n\n", quoteList(o.Containers)))
+
+	sb.WriteString("[transforms.agent_labels]\n")
+	sb.WriteString("type = \"remap\"\n")
+	sb.WriteString("inputs = [\"agent_containers\"]\n")
+	sb.WriteString("source = '''\n")
+	sb.WriteString(".container = .container_name\n")
+	sb.WriteString("del(.label)\n")
+	sb.WriteString("'''\n\n")
+
+	switch o.Target {
+	case TargetLoki:
+		sb.WriteString("[sinks.loki]\n")
+		sb.WriteString("type = \"loki\"\n")
+		sb.WriteString("inputs = [\"agent_labels\"]\n")
+		sb.WriteString(fmt.Sprintf("endpoint = %q\n", o.URL))
+		sb.WriteString("encoding.codec = \"text\"\n")
+		sb.WriteString("labels.container = \"{{ container }}\"\n")
+		sb.WriteString("labels.stack = \"ai-voice-agent\"\n")
+	case TargetElastic:
+		sb.WriteString("[sinks.elastic]\n")
+		sb.WriteString("type = \"elasticsearch\"\n")
+		sb.WriteString("inputs = [\"agent_labels\"]\n")
+		sb.WriteString(fmt.Sprintf("endpoints = [%q]\n", o.URL))
+		sb.WriteString("mode = \"bulk\"\n")
+		sb.WriteString(fmt.Sprintf("bulk.index = %q\n", o.Index))
+	case TargetS3:
+		sb.WriteString("[sinks.s3]\n")
+		sb.WriteString("type = \"aws_s3\"\n")
+		sb.WriteString("inputs = [\"agent_labels\"]\n")
+		sb.WriteString(fmt.Sprintf("bucket = %q\n", o.Bucket))
+		sb.WriteString(fmt.Sprintf("region = %q\n", o.Region))
+		sb.WriteString(fmt.Sprintf("key_prefix = %q\n", o.Prefix))
+		sb.WriteString("compression = \"gzip\"\n")
+		sb.WriteString("encoding.codec = \"text\"\n")
+		if o.URL != "" {
+			sb.WriteString(fmt.Sprintf("endpoint = %q\n", o.URL))
+		}
+	}
+	return sb.String()
+}
+
+// GenerateCompose renders a compose override adding the vector sidecar
+func GenerateCompose(o Options, vectorConfig string) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by: agent logging forward\n")
+	sb.WriteString("# Use with: docker compose -f docker-compose.yml -f docker-compose.logging.yml up -d\n")
+	sb.WriteString("services:\n")
+	sb.WriteString("  log-forwarder:\n")
+	sb.WriteString(fmt.Sprintf("    image: %s\n", VectorImage))
+	sb.WriteString("    container_name: log_forwarder\n")
+	sb.WriteString("    restart: unless-stopped\n")
+	sb.WriteString("    network_mode: host\n")
+	sb.WriteString("    volumes:\n")
+	sb.WriteString("      - ${DOCKER_SOCK:-/var/run/docker.sock}:/var/run/docker.sock:ro\n")
+	sb.WriteString(fmt.Sprintf("      - ./%s:/etc/vector/vector.toml:ro\n", filepath.ToSlash(vectorConfig)))
+	sb.WriteString("    command: [\"--config\", \"/etc/vector/vector.toml\"]\n")
+	if o.Target == TargetS3 {
+		sb.WriteString("    environment:\n")
+		sb.WriteString("      - AWS_ACCESS_KEY_ID=${AWS_ACCESS_KEY_ID}\n")
+		sb.WriteString("      - AWS_SECRET_ACCESS_KEY=${AWS_SECRET_ACCESS_KEY}\n")
+	}
+	return sb.String()
+}
+
+// Files are the paths written by Write, relative to the project directory
+type Files struct {
+	VectorConfig string
+	Compose      string
+}
+
+// DefaultFiles returns the standard file locations
+func DefaultFiles() Files {
+	return Files{
+		VectorConfig: filepath.Join("config", "vector", "vector.toml"),
+		Compose:      "docker-compose.logging.yml",
+	}
+}
+
+// Write generates both files under dir
+func Write(dir string, files Files, o Options) error {
+	vectorPath := filepath.Join(dir, files.VectorConfig)
+	if err := os.MkdirAll(filepath.Dir(vectorPath), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(vectorPath, []byte(GenerateVectorConfig(o)), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, files.Compose), []byte(GenerateCompose(o, files.VectorConfig)), 0644)
+}
+
+func quoteList(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = fmt.Sprintf("%q", v)
+	}
+	return strings.Join(quoted, ", ")
+}
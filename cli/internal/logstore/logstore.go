@@ -0,0 +1,581 @@
+// Package logstore keeps the call logs the CLI collects (saved
+// troubleshoot runs, the call cache) zstd-compressed and
+// content-addressed under ~/.agent/store. The same logs stored twice
+// are kept once, and error lines that recur across calls (the same
+// provider failure or traceback on every call) are kept once in a
+// shared line table that each log refers to.
+package logstore
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/klauspost/compress/zstd"
+)
+
+// header starts every stored log, followed by its size
+const header = "aava-log 1 "
+
+// minShared is the shortest line body worth a reference to the line table
+const minShared = 24
+
+// segmentSize is the compressed size at which a new line segment starts
+const segmentSize = 4 << 20
+
+// Lock timing: how long a writer waits for the store, and when a lock
+// left behind by a killed process is broken
+const (
+	lockWait  = 10 * time.Second
+	lockStale = 2 * time.Minute
+)
+
+// DefaultGrace protects logs stored moments ago, not yet referenced by
+// the run or cache entry being written, from Collect
+const DefaultGrace = time.Hour
+
+// errorLine finds the lines whose bodies are shared
+var errorLine = regexp.MustCompile(`(?i)\b(error|critical|fatal|exception|traceback|failed)\b`)
+
+// leadingTimestamp finds the timestamp near the start of a log line
+var leadingTimestamp = regexp.MustCompile(`^.{0,40}?\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+
+var (
+	codecOnce sync.Once
+	encoder   *zstd.Encoder
+	decoder   *zstd.Decoder
+)
+
+func codec() (*zstd.Encoder, *zstd.Decoder) {
+	codecOnce.Do(func() {
+		encoder, _ = zstd.NewWriter(nil, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+		decoder, _ = zstd.NewReader(nil)
+	})
+	return encoder, decoder
+}
+
+// Dir is the store directory
+func Dir() string {
+	return filepath.Join(settings.Dir(), "store")
+}
+
+func blobPath(ref string) string {
+	return filepath.Join(Dir(), "blobs", ref[:2], ref+".zst")
+}
+
+func linesDir() string {
+	return filepath.Join(Dir(), "lines")
+}
+
+func indexPath() string {
+	return filepath.Join(Dir(), "lines.idx")
+}
+
+// validRef reports whether ref looks like a reference Put returned
+var validRef = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Ref is the reference of a log's content
+func Ref(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return fmt.Sprintf("%x", sum[:16])
+}
+
+func lineHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return fmt.Sprintf("%x", sum[:8])
+}
+
+// splitLine splits a line into its timestamp prefix and body
+func splitLine(line string) (string, string) {
+	if loc := leadingTimestamp.FindStringIndex(line); loc != nil {
+		return line[:loc[1]], line[loc[1]:]
+	}
+	return "", line
+}
+
+// Put stores a log and returns its reference. Storing a log already in
+// the store only marks it as used.
+func Put(text string) (string, error) {
+	ref := Ref(text)
+	path := blobPath(ref)
+	now := time.Now()
+	if os.Chtimes(path, now, now) == nil {
+		return ref, nil
+	}
+
+	// A line is shared when it is an error line, or continues one (a
+	// traceback, a wrapped message) without a timestamp of its own
+	var recipe strings.Builder
+	recipe.WriteString(header + strconv.Itoa(len(text)) + "\n")
+	bodies := make(map[string]string)
+	inError := false
+	for _, line := range strings.Split(text, "\n") {
+		prefix, body := splitLine(line)
+		if prefix != "" {
+			inError = errorLine.MatchString(body)
+		} else if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inError = errorLine.MatchString(line)
+		}
+		if !inError || len(body) < minShared {
+			recipe.WriteString("=" + line + "\n")
+			continue
+		}
+		h := lineHash(body)
+		bodies[h] = body
+		recipe.WriteString("@" + h + prefix + "\n")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return "", err
+	}
+	unlock, err := lock()
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+	if err := addLines(bodies); err != nil {
+		return "", err
+	}
+	enc, _ := codec()
+	if err := writeFile(path, enc.EncodeAll([]byte(recipe.String()), nil)); err != nil {
+		return "", err
+	}
+	return ref, nil
+}
+
+// Get returns the log stored under ref
+func Get(ref string) (string, error) {
+	if !validRef.MatchString(ref) {
+		return "", fmt.Errorf("invalid log reference %q", ref)
+	}
+	recipe, err := readRecipe(ref)
+	if err != nil {
+		return "", err
+	}
+	nl := strings.IndexByte(recipe, '\n')
+	if nl < 0 || !strings.HasPrefix(recipe, header) {
+		return "", fmt.Errorf("log %s: not a stored log", ref)
+	}
+	entries := strings.Split(recipe[nl+1:], "\n")
+	entries = entries[:len(entries)-1]
+
+	var wanted []string
+	for _, e := range entries {
+		if strings.HasPrefix(e, "@") && len(e) >= 17 {
+			wanted = append(wanted, e[1:17])
+		}
+	}
+	bodies, err := readLines(wanted)
+	if err != nil {
+		return "", fmt.Errorf("log %s: %w", ref, err)
+	}
+
+	lines := make([]string, len(entries))
+	for i, e := range entries {
+		switch {
+		case strings.HasPrefix(e, "="):
+			lines[i] = e[1:]
+		case strings.HasPrefix(e, "@") && len(e) >= 17:
+			body, ok := bodies[e[1:17]]
+			if !ok {
+				return "", fmt.Errorf("log %s: shared line %s is missing", ref, e[1:17])
+			}
+			lines[i] = e[17:] + body
+		default:
+			return "", fmt.Errorf("log %s: corrupt entry %d", ref, i+1)
+		}
+	}
+	return strings.Join(lines, "\n"), nil
+}
+
+// readRecipe reads and decompresses a stored log
+func readRecipe(ref string) (string, error) {
+	data, err := os.ReadFile(blobPath(ref))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", fmt.Errorf("log %s is not in the store", ref)
+		}
+		return "", err
+	}
+	_, dec := codec()
+	out, err := dec.DecodeAll(data, nil)
+	if err != nil {
+		return "", fmt.Errorf("log %s: %w", ref, err)
+	}
+	return string(out), nil
+}
+
+// loadIndex maps each shared line to its segment
+func loadIndex() (map[string]string, error) {
+	index := make(map[string]string)
+	f, err := os.Open(indexPath())
+	if os.IsNotExist(err) {
+		return index, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) == 2 {
+			index[fields[0]] = fields[1]
+		}
+	}
+	return index, scanner.Err()
+}
+
+// addLines appends the bodies not yet in the line table to the current
+// segment, as one zstd frame. The caller holds the lock.
+func addLines(bodies map[string]string) error {
+	if len(bodies) == 0 {
+		return nil
+	}
+	index, err := loadIndex()
+	if err != nil {
+		return err
+	}
+	var hashes []string
+	for h := range bodies {
+		if _, ok := index[h]; !ok {
+			hashes = append(hashes, h)
+		}
+	}
+	if len(hashes) == 0 {
+		return nil
+	}
+	sort.Strings(hashes)
+
+	var frame bytes.Buffer
+	var idx strings.Builder
+	segment, err := currentSegment()
+	if err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		fmt.Fprintf(&frame, "%s %d\n%s", h, len(bodies[h]), bodies[h])
+		fmt.Fprintf(&idx, "%s %s\n", h, segment)
+	}
+	enc, _ := codec()
+	if err := appendFile(filepath.Join(linesDir(), segment+".zst"), enc.EncodeAll(frame.Bytes(), nil)); err != nil {
+		return err
+	}
+	return appendFile(indexPath(), []byte(idx.String()))
+}
+
+// currentSegment names the segment new lines go to
+func currentSegment() (string, error) {
+	if err := os.MkdirAll(linesDir(), 0700); err != nil {
+		return "", err
+	}
+	names, err := filepath.Glob(filepath.Join(linesDir(), "*.zst"))
+	if err != nil {
+		return "", err
+	}
+	if len(names) == 0 {
+		return "000001", nil
+	}
+	sort.Strings(names)
+	last := names[len(names)-1]
+	name := strings.TrimSuffix(filepath.Base(last), ".zst")
+	if info, err := os.Stat(last); err == nil && info.Size() < segmentSize {
+		return name, nil
+	}
+	n, _ := strconv.Atoi(name)
+	return fmt.Sprintf("%06d", n+1), nil
+}
+
+// readLines returns the bodies of the wanted shared lines
+func readLines(wanted []string) (map[string]string, error) {
+	bodies := make(map[string]string)
+	if len(wanted) == 0 {
+		return bodies, nil
+	}
+	index, err := loadIndex()
+	if err != nil {
+		return nil, err
+	}
+	segments := make(map[string]bool)
+	for _, h := range wanted {
+		if seg, ok := index[h]; ok {
+			segments[seg] = true
+		}
+	}
+	for seg := range segments {
+		if err := readSegment(seg, func(h, body string) {
+			bodies[h] = body
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return bodies, nil
+}
+
+// readSegment calls fn for every line in a segment
+func readSegment(seg string, fn func(hash, body string)) error {
+	data, err := os.ReadFile(filepath.Join(linesDir(), seg+".zst"))
+	if err != nil {
+		return err
+	}
+	_, dec := codec()
+	out, err := dec.DecodeAll(data, nil)
+	if err != nil {
+		return fmt.Errorf("line segment %s: %w", seg, err)
+	}
+	for len(out) > 0 {
+		nl := bytes.IndexByte(out, '\n')
+		if nl < 0 {
+			return fmt.Errorf("line segment %s is corrupt", seg)
+		}
+		fields := strings.Fields(string(out[:nl]))
+		if len(fields) != 2 {
+			return fmt.Errorf("line segment %s is corrupt", seg)
+		}
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || nl+1+n > len(out) {
+			return fmt.Errorf("line segment %s is corrupt", seg)
+		}
+		fn(fields[0], string(out[nl+1:nl+1+n]))
+		out = out[nl+1+n:]
+	}
+	return nil
+}
+
+// lock takes the store's write lock, breaking a stale one
+func lock() (func(), error) {
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(Dir(), "lock")
+	deadline := time.Now().Add(lockWait)
+	for {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+		if err == nil {
+			f.Close()
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if info, err := os.Stat(path); err == nil && time.Since(info.ModTime()) > lockStale {
+			os.Remove(path)
+			continue
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("log store is locked by another agent command (%s)", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// writeFile writes data through a temporary file, so readers never see
+// a partial file
+func writeFile(path string, data []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func appendFile(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// blobs lists the references of the stored logs with their files
+func blobs() (map[string]os.FileInfo, error) {
+	found := make(map[string]os.FileInfo)
+	root := filepath.Join(Dir(), "blobs")
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".zst") {
+			found[strings.TrimSuffix(info.Name(), ".zst")] = info
+		}
+		return nil
+	})
+	return found, err
+}
+
+// Collect deletes the stored logs not in live and not stored or used
+// within grace, and returns how many it deleted and the bytes freed
+func Collect(live map[string]bool, grace time.Duration) (int, int64, error) {
+	found, err := blobs()
+	if err != nil {
+		return 0, 0, err
+	}
+	cutoff := time.Now().Add(-grace)
+	removed, freed := 0, int64(0)
+	for ref, info := range found {
+		if live[ref] || info.ModTime().After(cutoff) {
+			continue
+		}
+		if err := os.Remove(blobPath(ref)); err != nil {
+			return removed, freed, err
+		}
+		removed++
+		freed += info.Size()
+	}
+	return removed, freed, nil
+}
+
+// Compact rewrites the line table with only the lines stored logs still
+// refer to, and returns the bytes freed
+func Compact() (int64, error) {
+	unlock, err := lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	found, err := blobs()
+	if err != nil {
+		return 0, err
+	}
+	used := make(map[string]bool)
+	for ref := range found {
+		recipe, err := readRecipe(ref)
+		if err != nil {
+			return 0, err
+		}
+		for _, e := range strings.Split(recipe, "\n") {
+			if strings.HasPrefix(e, "@") && len(e) >= 17 {
+				used[e[1:17]] = true
+			}
+		}
+	}
+
+	before := dirSize(linesDir()) + fileSize(indexPath())
+	index, err := loadIndex()
+	if err != nil {
+		return 0, err
+	}
+	stale := false
+	for h := range index {
+		if !used[h] {
+			stale = true
+			break
+		}
+	}
+	if !stale {
+		return 0, nil
+	}
+
+	bodies := make(map[string]string)
+	segments := make(map[string]bool)
+	for _, seg := range index {
+		segments[seg] = true
+	}
+	for seg := range segments {
+		if err := readSegment(seg, func(h, body string) {
+			if used[h] {
+				bodies[h] = body
+			}
+		}); err != nil {
+			return 0, err
+		}
+	}
+
+	// Move the old table aside and write the new one; the old one is put
+	// back if that fails
+	oldLines, oldIndex := linesDir()+".old", indexPath()+".old"
+	os.RemoveAll(oldLines)
+	if err := os.Rename(linesDir(), oldLines); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(indexPath(), oldIndex); err != nil {
+		os.Rename(oldLines, linesDir())
+		return 0, err
+	}
+	if err := addLines(bodies); err != nil {
+		os.RemoveAll(linesDir())
+		os.Remove(indexPath())
+		os.Rename(oldLines, linesDir())
+		os.Rename(oldIndex, indexPath())
+		return 0, err
+	}
+	os.RemoveAll(oldLines)
+	os.Remove(oldIndex)
+	return before - dirSize(linesDir()) - fileSize(indexPath()), nil
+}
+
+// Usage describes the store: the logs in it, their size and the bytes
+// it takes on disk
+type Usage struct {
+	Logs     int
+	Original int64
+	Stored   int64
+}
+
+// DiskUsage measures the store
+func DiskUsage() (Usage, error) {
+	var u Usage
+	found, err := blobs()
+	if err != nil {
+		return u, err
+	}
+	// Only the header of each log is decompressed
+	dec, err := zstd.NewReader(nil, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return u, err
+	}
+	defer dec.Close()
+	for ref, info := range found {
+		u.Logs++
+		u.Stored += info.Size()
+		f, err := os.Open(blobPath(ref))
+		if err != nil {
+			continue
+		}
+		if dec.Reset(f) == nil {
+			line, _ := bufio.NewReader(dec).ReadString('\n')
+			if strings.HasPrefix(line, header) {
+				n, _ := strconv.ParseInt(strings.TrimSpace(line[len(header):]), 10, 64)
+				u.Original += n
+			}
+		}
+		f.Close()
+	}
+	u.Stored += dirSize(linesDir()) + fileSize(indexPath())
+	return u, nil
+}
+
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.Walk(dir, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+func fileSize(path string) int64 {
+	if info, err := os.Stat(path); err == nil {
+		return info.Size()
+	}
+	return 0
+}
@@ -0,0 +1,125 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultMeasurement is the InfluxDB measurement for call samples
+const DefaultMeasurement = "aava_call"
+
+// InfluxConfig configures an InfluxDB line protocol write endpoint.
+// URL is the full write URL, e.g.
+//
+//	http://influx:8086/api/v2/write?org=ops&bucket=voice   (v2, Token)
+//	http://influx:8086/write?db=voice                      (v1, Username/Password)
+type InfluxConfig struct {
+	URL         string
+	Token       string
+	Username    string
+	Password    string
+	Measurement string
+}
+
+// Influx pushes samples as one line protocol point per call
+type Influx struct {
+	cfg    InfluxConfig
+	client *http.Client
+}
+
+// NewInflux validates cfg and creates the sink
+func NewInflux(cfg InfluxConfig) (*Influx, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("influxdb url %q is not a valid write URL", cfg.URL)
+	}
+	q := u.Query()
+	if q.Get("precision") == "" {
+		// v1 and v2 both accept "ns"; points are written in nanoseconds
+		q.Set("precision", "ns")
+		u.RawQuery = q.Encode()
+		cfg.URL = u.String()
+	}
+	if cfg.Measurement == "" {
+		cfg.Measurement = DefaultMeasurement
+	}
+	return &Influx{cfg: cfg, client: &http.Client{Timeout: 15 * time.Second}}, nil
+}
+
+func (s *Influx) Name() string { return "influxdb" }
+
+// Push writes the sample as a single point
+func (s *Influx) Push(ctx context.Context, sample CallSample) error {
+	req, err := http.NewRequestWithContext(ctx, "POST", s.cfg.URL, bytes.NewBufferString(s.point(sample)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if s.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+s.cfg.Token)
+	} else if s.cfg.Username != "" {
+		req.SetBasicAuth(s.cfg.Username, s.cfg.Password)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// point renders measurement,tags fields timestamp. The call ID is a
+// field rather than a tag to keep series cardinality low.
+func (s *Influx) point(sample CallSample) string {
+	var b strings.Builder
+	b.WriteString(escapeInflux(s.cfg.Measurement, ", "))
+	for _, k := range sortedKeys(sample.Tags) {
+		if v := sample.Tags[k]; v != "" {
+			fmt.Fprintf(&b, ",%s=%s", escapeInflux(k, ",= "), escapeInflux(v, ",= "))
+		}
+	}
+
+	fields := []string{fmt.Sprintf("call_id=%q", sample.CallID)}
+	for _, k := range sortedKeys(sample.Timings) {
+		fields = append(fields, escapeInflux(k, ",= ")+"="+strconv.FormatFloat(sample.Timings[k], 'f', -1, 64))
+	}
+	for _, k := range sortedKeys(sample.Values) {
+		fields = append(fields, escapeInflux(k, ",= ")+"="+strconv.FormatFloat(sample.Values[k], 'f', -1, 64))
+	}
+	for _, k := range sortedKeys(sample.Counts) {
+		fields = append(fields, escapeInflux(k, ",= ")+"="+strconv.Itoa(sample.Counts[k])+"i")
+	}
+	b.WriteByte(' ')
+	b.WriteString(strings.Join(fields, ","))
+
+	ts := sample.Time
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	fmt.Fprintf(&b, " %d\n", ts.UnixNano())
+	return b.String()
+}
+
+// escapeInflux backslash-escapes the given special characters
+func escapeInflux(s, special string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(special, r) || r == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
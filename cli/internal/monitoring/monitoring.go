@@ -0,0 +1,91 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// CallSample is the set of metrics pushed for one analyzed call
+type CallSample struct {
+	CallID string
+	Time   time.Time
+
+	// Tags are low-cardinality dimensions (status, provider)
+	Tags map[string]string
+
+	// Timings are in milliseconds (turn latency); Values are gauges
+	// (quality score); Counts are event totals (errors, audio issues)
+	Timings map[string]float64
+	Values  map[string]float64
+	Counts  map[string]int
+}
+
+// Sink receives call samples
+type Sink interface {
+	Name() string
+	Push(ctx context.Context, sample CallSample) error
+}
+
+// Config selects the sinks to push to. Empty sections are disabled.
+type Config struct {
+	StatsD   StatsDConfig
+	InfluxDB InfluxConfig
+}
+
+// NewSinks creates the sinks enabled in cfg
+func NewSinks(cfg Config) ([]Sink, error) {
+	var sinks []Sink
+	if cfg.StatsD.Address != "" {
+		s, err := NewStatsD(cfg.StatsD)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	if cfg.InfluxDB.URL != "" {
+		s, err := NewInflux(cfg.InfluxDB)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, s)
+	}
+	return sinks, nil
+}
+
+// PushAll sends sample to every sink and joins their errors
+func PushAll(ctx context.Context, sinks []Sink, sample CallSample) error {
+	var failed []string
+	for _, s := range sinks {
+		if err := s.Push(ctx, sample); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", s.Name(), err))
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+// sortedKeys returns map keys in a stable order so output is reproducible
+func sortedKeys(m interface{}) []string {
+	var keys []string
+	switch m := m.(type) {
+	case map[string]string:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]float64:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	case map[string]int:
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,110 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// DefaultPrefix namespaces pushed metric names
+const DefaultPrefix = "aava"
+
+// maxStatsDPacket keeps datagrams under a typical network MTU
+const maxStatsDPacket = 1400
+
+// StatsDConfig configures a StatsD (UDP) endpoint
+type StatsDConfig struct {
+	Address string
+	Prefix  string
+
+	// DogStatsD appends tags as |#key:value (Datadog, Telegraf)
+	DogStatsD bool
+}
+
+// StatsD pushes samples as StatsD timers, gauges and counters
+type StatsD struct {
+	cfg StatsDConfig
+}
+
+// NewStatsD validates cfg and creates the sink
+func NewStatsD(cfg StatsDConfig) (*StatsD, error) {
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("statsd address %q: %w", cfg.Address, err)
+	}
+	if cfg.Prefix == "" {
+		cfg.Prefix = DefaultPrefix
+	}
+	return &StatsD{cfg: cfg}, nil
+}
+
+func (s *StatsD) Name() string { return "statsd" }
+
+// Push sends the sample, batching lines into as few datagrams as possible
+func (s *StatsD) Push(ctx context.Context, sample CallSample) error {
+	var lines []string
+	for _, k := range sortedKeys(sample.Timings) {
+		lines = append(lines, s.line(k, fmt.Sprintf("%.0f", sample.Timings[k]), "ms", sample.Tags))
+	}
+	for _, k := range sortedKeys(sample.Values) {
+		lines = append(lines, s.line(k, fmt.Sprintf("%g", sample.Values[k]), "g", sample.Tags))
+	}
+	for _, k := range sortedKeys(sample.Counts) {
+		lines = append(lines, s.line(k, fmt.Sprintf("%d", sample.Counts[k]), "c", sample.Tags))
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	dialer := net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "udp", s.cfg.Address)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	var packet strings.Builder
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxStatsDPacket {
+			if _, err := conn.Write([]byte(packet.String())); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	_, err = conn.Write([]byte(packet.String()))
+	return err
+}
+
+// line formats prefix.call.name:value|type[|#tags]
+func (s *StatsD) line(name, value, kind string, tags map[string]string) string {
+	line := fmt.Sprintf("%s.call.%s:%s|%s", s.cfg.Prefix, statsdName(name), value, kind)
+	if s.cfg.DogStatsD && len(tags) > 0 {
+		var pairs []string
+		for _, k := range sortedKeys(tags) {
+			if tags[k] != "" {
+				pairs = append(pairs, statsdName(k)+":"+statsdName(tags[k]))
+			}
+		}
+		if len(pairs) > 0 {
+			line += "|#" + strings.Join(pairs, ",")
+		}
+	}
+	return line
+}
+
+// statsdName strips characters that are part of the StatsD syntax
+func statsdName(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ':', '|', '@', '#', ',', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, s)
+}
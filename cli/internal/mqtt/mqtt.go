@@ -0,0 +1,366 @@
+// Package mqtt is a minimal MQTT 3.1.1 publisher: enough to emit call
+// lifecycle and alert events to a broker (Mosquitto, EMQX, Home
+// Assistant's add-on) without a client library. It does not subscribe.
+package mqtt
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Packet types (high nibble of the fixed header)
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetPingreq    = 12
+	packetDisconnect = 14
+)
+
+// DefaultKeepAlive is the keep-alive interval sent to the broker
+const DefaultKeepAlive = 60 * time.Second
+
+// ackTimeout bounds the wait for a QoS 1 acknowledgement
+const ackTimeout = 10 * time.Second
+
+// ErrClosed is returned once the connection to the broker is gone
+var ErrClosed = errors.New("mqtt connection closed")
+
+// connackErrors are the CONNACK return codes of MQTT 3.1.1
+var connackErrors = map[byte]string{
+	1: "unacceptable protocol version",
+	2: "client identifier rejected",
+	3: "server unavailable",
+	4: "bad user name or password",
+	5: "not authorized",
+}
+
+// Options configures a connection
+type Options struct {
+	// ClientID identifies the connection; empty picks agent-<random>
+	ClientID  string
+	KeepAlive time.Duration
+}
+
+// Client is a connection to a broker. Publish is safe from several
+// goroutines.
+type Client struct {
+	conn   net.Conn
+	mu     sync.Mutex
+	nextID uint16
+	acks   map[uint16]chan struct{}
+	done   chan struct{}
+	err    error
+}
+
+// Dial connects to the broker at an mqtt:// (or tcp://) or mqtts:// (or
+// ssl://) URL; user info in the URL is sent as the credentials
+func Dial(ctx context.Context, rawURL string, opts Options) (*Client, error) {
+	u, err := ParseURL(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "mqtts" || u.Scheme == "ssl" {
+		tc := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	keepAlive := opts.KeepAlive
+	if keepAlive <= 0 {
+		keepAlive = DefaultKeepAlive
+	}
+	clientID := opts.ClientID
+	if clientID == "" {
+		b := make([]byte, 6)
+		rand.Read(b)
+		clientID = "agent-" + hex.EncodeToString(b)
+	}
+	var user, pass string
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+
+	r := bufio.NewReader(conn)
+	if _, err := conn.Write(connectPacket(clientID, user, pass, keepAlive)); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	typ, body, err := readPacket(r)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("waiting for CONNACK: %w", err)
+	}
+	if typ != packetConnack || len(body) != 2 {
+		conn.Close()
+		return nil, fmt.Errorf("broker answered packet type %d instead of CONNACK", typ)
+	}
+	if code := body[1]; code != 0 {
+		conn.Close()
+		if msg, ok := connackErrors[code]; ok {
+			return nil, fmt.Errorf("broker refused connection: %s", msg)
+		}
+		return nil, fmt.Errorf("broker refused connection (code %d)", code)
+	}
+	conn.SetDeadline(time.Time{})
+
+	c := &Client{conn: conn, acks: make(map[uint16]chan struct{}), done: make(chan struct{})}
+	go c.readLoop(r)
+	go c.pingLoop(keepAlive)
+	return c, nil
+}
+
+// ParseURL checks a broker URL and fills in the default port
+func ParseURL(rawURL string) (*url.URL, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	port := "1883"
+	switch u.Scheme {
+	case "mqtt", "tcp":
+	case "mqtts", "ssl":
+		port = "8883"
+	default:
+		return nil, fmt.Errorf("unsupported broker scheme %q (use mqtt:// or mqtts://)", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("broker URL %q has no host", rawURL)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+	return u, nil
+}
+
+// Publish sends payload to topic. At QoS 1 it waits for the broker's
+// acknowledgement; QoS 0 returns once the packet is written.
+func (c *Client) Publish(ctx context.Context, topic string, payload []byte, qos byte, retain bool) error {
+	if qos > 1 {
+		return fmt.Errorf("QoS %d is not supported (use 0 or 1)", qos)
+	}
+	c.mu.Lock()
+	if c.err != nil {
+		c.mu.Unlock()
+		return c.err
+	}
+	var id uint16
+	var ack chan struct{}
+	if qos == 1 {
+		c.nextID++
+		if c.nextID == 0 {
+			c.nextID = 1
+		}
+		id = c.nextID
+		ack = make(chan struct{})
+		c.acks[id] = ack
+	}
+	_, err := c.conn.Write(publishPacket(topic, payload, qos, retain, id))
+	c.mu.Unlock()
+	if err != nil || ack == nil {
+		return err
+	}
+
+	timer := time.NewTimer(ackTimeout)
+	defer timer.Stop()
+	select {
+	case <-ack:
+		return nil
+	case <-c.done:
+		return c.closedErr()
+	case <-ctx.Done():
+		err = ctx.Err()
+	case <-timer.C:
+		err = fmt.Errorf("no acknowledgement from broker within %s", ackTimeout)
+	}
+	c.mu.Lock()
+	delete(c.acks, id)
+	c.mu.Unlock()
+	return err
+}
+
+// Done is closed when the connection ends
+func (c *Client) Done() <-chan struct{} {
+	return c.done
+}
+
+// Close disconnects from the broker
+func (c *Client) Close() error {
+	c.mu.Lock()
+	if c.err == nil {
+		c.conn.Write([]byte{packetDisconnect << 4, 0})
+	}
+	c.mu.Unlock()
+	return c.conn.Close()
+}
+
+// closedErr is the reason the connection ended
+func (c *Client) closedErr() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.err
+}
+
+// readLoop handles acknowledgements until the connection fails
+func (c *Client) readLoop(r *bufio.Reader) {
+	var err error
+	for {
+		var typ byte
+		var body []byte
+		typ, body, err = readPacket(r)
+		if err != nil {
+			break
+		}
+		if typ == packetPuback && len(body) >= 2 {
+			id := binary.BigEndian.Uint16(body)
+			c.mu.Lock()
+			if ack, ok := c.acks[id]; ok {
+				close(ack)
+				delete(c.acks, id)
+			}
+			c.mu.Unlock()
+		}
+	}
+	if err == io.EOF || errors.Is(err, net.ErrClosed) {
+		err = ErrClosed
+	}
+	c.mu.Lock()
+	c.err = err
+	c.mu.Unlock()
+	close(c.done)
+	c.conn.Close()
+}
+
+// pingLoop keeps an idle connection alive
+func (c *Client) pingLoop(keepAlive time.Duration) {
+	ticker := time.NewTicker(keepAlive / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.done:
+			return
+		case <-ticker.C:
+			c.mu.Lock()
+			c.conn.Write([]byte{packetPingreq << 4, 0})
+			c.mu.Unlock()
+		}
+	}
+}
+
+// connectPacket builds CONNECT with a clean session
+func connectPacket(clientID, user, pass string, keepAlive time.Duration) []byte {
+	var vh []byte
+	vh = appendString(vh, "MQTT")
+	vh = append(vh, 4) // protocol level 3.1.1
+	flags := byte(0x02)
+	if user != "" {
+		flags |= 0x80
+		if pass != "" {
+			flags |= 0x40
+		}
+	}
+	vh = append(vh, flags)
+	vh = binary.BigEndian.AppendUint16(vh, uint16(keepAlive/time.Second))
+	vh = appendString(vh, clientID)
+	if user != "" {
+		vh = appendString(vh, user)
+		if pass != "" {
+			vh = appendString(vh, pass)
+		}
+	}
+	return packet(packetConnect<<4, vh)
+}
+
+// publishPacket builds PUBLISH; id is only sent at QoS 1
+func publishPacket(topic string, payload []byte, qos byte, retain bool, id uint16) []byte {
+	header := byte(packetPublish<<4) | qos<<1
+	if retain {
+		header |= 1
+	}
+	body := appendString(nil, topic)
+	if qos > 0 {
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	return packet(header, append(body, payload...))
+}
+
+// packet prefixes body with the fixed header and remaining length
+func packet(header byte, body []byte) []byte {
+	out := []byte{header}
+	n := len(body)
+	for {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		out = append(out, b)
+		if n == 0 {
+			break
+		}
+	}
+	return append(out, body...)
+}
+
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+// maxPacket bounds packets read from the broker
+const maxPacket = 1 << 20
+
+// readPacket reads one packet and returns its type and body
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, shift := 0, 0
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n |= int(b&0x7f) << shift
+		if b&0x80 == 0 {
+			break
+		}
+		shift += 7
+		if shift > 21 {
+			return 0, nil, fmt.Errorf("malformed remaining length")
+		}
+	}
+	if n > maxPacket {
+		return 0, nil, fmt.Errorf("packet of %d bytes exceeds %d", n, maxPacket)
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
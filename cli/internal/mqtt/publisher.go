@@ -0,0 +1,120 @@
+package mqtt
+
+import (
+	"context"
+	"strings"
+	"sync"
+)
+
+// DefaultPrefix is the first level of the topics events are published to
+const DefaultPrefix = "agent"
+
+// noTenant is the tenant level of topics of calls without a tenant
+const noTenant = "default"
+
+// Publisher publishes events to <prefix>/<tenant>/<event type> topics,
+// connecting on first use and reconnecting after the broker drops the
+// connection. Events go out at QoS 1 and are not retained.
+type Publisher struct {
+	url    string
+	prefix string
+	opts   Options
+
+	mu     sync.Mutex
+	client *Client
+}
+
+// NewPublisher checks the broker URL; prefix empty is DefaultPrefix
+func NewPublisher(rawURL, prefix string, opts Options) (*Publisher, error) {
+	if _, err := ParseURL(rawURL); err != nil {
+		return nil, err
+	}
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		prefix = DefaultPrefix
+	}
+	return &Publisher{url: rawURL, prefix: prefix, opts: opts}, nil
+}
+
+// Topic returns the topic of an event type for a tenant, e.g.
+// agent/acme/call_ended or agent/default/failure_detected. Subscribe to
+// agent/+/call_ended for every tenant or agent/acme/# for one.
+func (p *Publisher) Topic(tenant, eventType string) string {
+	if tenant == "" {
+		tenant = noTenant
+	}
+	return p.prefix + "/" + topicLevel(tenant) + "/" + topicLevel(eventType)
+}
+
+// Publish sends payload to topic, reconnecting once when the connection
+// was lost since the last event
+func (p *Publisher) Publish(ctx context.Context, topic string, payload []byte) error {
+	var err error
+	for attempt := 0; attempt < 2; attempt++ {
+		var c *Client
+		if c, err = p.connect(ctx); err != nil {
+			return err
+		}
+		if err = c.Publish(ctx, topic, payload, 1, false); err == nil {
+			return nil
+		}
+		p.drop(c)
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return err
+}
+
+// Close disconnects from the broker
+func (p *Publisher) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client == nil {
+		return nil
+	}
+	err := p.client.Close()
+	p.client = nil
+	return err
+}
+
+// connect returns the live connection, dialing when there is none
+func (p *Publisher) connect(ctx context.Context) (*Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.client != nil {
+		select {
+		case <-p.client.Done():
+			p.client = nil
+		default:
+			return p.client, nil
+		}
+	}
+	c, err := Dial(ctx, p.url, p.opts)
+	if err != nil {
+		return nil, err
+	}
+	p.client = c
+	return c, nil
+}
+
+// drop closes a connection that failed to publish
+func (p *Publisher) drop(c *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	c.Close()
+	if p.client == c {
+		p.client = nil
+	}
+}
+
+// topicLevel keeps a name from adding levels or wildcards to a topic
+func topicLevel(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '/', '+', '#':
+			return '_'
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,24 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/mqtt"
+)
+
+// mqttSender publishes events as JSON to <prefix>/<tenant>/<event kind>
+// topics of an MQTT broker, for home-automation and IoT setups
+type mqttSender struct {
+	pub *mqtt.Publisher
+}
+
+func (s *mqttSender) Name() string { return "mqtt" }
+
+func (s *mqttSender) Send(ctx context.Context, ev Event) error {
+	payload, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	return s.pub.Publish(ctx, s.pub.Topic(ev.Fields["Tenant"], ev.Kind), payload)
+}
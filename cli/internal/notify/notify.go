@@ -0,0 +1,251 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/mqtt"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Severities, most severe first (same values as analyzer findings)
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Event kinds
+const (
+	EventCallAnalyzed    = "call_analyzed"
+	EventFailureDetected = "failure_detected"
+	EventSLOBreached     = "slo_breached"
+	EventDoctorFailed    = "doctor_check_failed"
+	EventSyntheticFailed = "synthetic_failed"
+	EventSecurityAlert   = "security_alert"
+	EventConfigInvalid   = "config_invalid"
+	EventQuotaExceeded   = "quota_exceeded"
+	EventEngineEvent     = "engine_event"
+	EventTest            = "test"
+)
+
+// EventKinds lists the event kinds channels can subscribe to
+var EventKinds = []string{EventCallAnalyzed, EventFailureDetected, EventSLOBreached, EventDoctorFailed, EventSyntheticFailed, EventSecurityAlert, EventConfigInvalid, EventQuotaExceeded, EventEngineEvent, EventTest}
+
+// Event is one notification
+type Event struct {
+	Kind     string            `json:"event"`
+	Severity string            `json:"severity"`
+	Title    string            `json:"title"`
+	Text     string            `json:"text,omitempty"`
+	CallID   string            `json:"call_id,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Time     time.Time         `json:"time"`
+
+	// Data is the structured payload (e.g. the analysis report) sent to
+	// webhooks; chat channels ignore it
+	Data interface{} `json:"data,omitempty"`
+}
+
+// Sender delivers events to one channel
+type Sender interface {
+	Name() string
+	Send(ctx context.Context, ev Event) error
+}
+
+// channel is a sender with its severity and event filters
+type channel struct {
+	sender      Sender
+	minSeverity string
+	events      map[string]bool
+}
+
+// wants reports whether the channel receives ev
+func (c channel) wants(ev Event) bool {
+	if len(c.events) > 0 && !c.events[ev.Kind] && ev.Kind != EventTest {
+		return false
+	}
+	return rank(ev.Severity) >= rank(c.minSeverity)
+}
+
+// Notifier fans events out to the configured channels
+type Notifier struct {
+	channels []channel
+}
+
+// New creates a notifier for the channels in cfg. A nil notifier is
+// returned when none are configured.
+func New(cfg []settings.Notification) (*Notifier, error) {
+	n := &Notifier{}
+	for i, c := range cfg {
+		sender, err := newSender(c)
+		if err != nil {
+			return nil, fmt.Errorf("notifications[%d]: %w", i, err)
+		}
+		min := strings.ToLower(c.MinSeverity)
+		if min == "" {
+			min = SeverityWarning
+		}
+		if rank(min) < 0 {
+			return nil, fmt.Errorf("notifications[%d]: unknown min_severity %q (use critical, warning or info)", i, c.MinSeverity)
+		}
+		ch := channel{sender: sender, minSeverity: min}
+		for _, kind := range c.Events {
+			if !knownEvent(kind) {
+				return nil, fmt.Errorf("notifications[%d]: unknown event %q (use %s)", i, kind, strings.Join(EventKinds, ", "))
+			}
+			if ch.events == nil {
+				ch.events = make(map[string]bool)
+			}
+			ch.events[kind] = true
+		}
+		n.channels = append(n.channels, ch)
+	}
+	if len(n.channels) == 0 {
+		return nil, nil
+	}
+	return n, nil
+}
+
+func newSender(c settings.Notification) (Sender, error) {
+	client := &http.Client{Timeout: 15 * time.Second}
+	switch strings.ToLower(c.Type) {
+	case "telegram":
+		if c.BotToken == "" || c.ChatID == "" {
+			return nil, fmt.Errorf("telegram requires bot_token and chat_id")
+		}
+		return &telegramSender{token: c.BotToken, chatID: c.ChatID, client: client}, nil
+	case "teams", "msteams":
+		if c.WebhookURL == "" {
+			return nil, fmt.Errorf("teams requires webhook_url")
+		}
+		return &teamsSender{url: c.WebhookURL, client: client}, nil
+	case "webhook":
+		if c.URL == "" {
+			return nil, fmt.Errorf("webhook requires url")
+		}
+		return &webhookSender{url: c.URL, secret: c.Secret, headers: c.Headers, client: client}, nil
+	case "mqtt":
+		if c.URL == "" {
+			return nil, fmt.Errorf("mqtt requires url (mqtt://host:1883)")
+		}
+		pub, err := mqtt.NewPublisher(c.URL, c.TopicPrefix, mqtt.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return &mqttSender{pub: pub}, nil
+	}
+	return nil, fmt.Errorf("unknown type %q (use telegram, teams, webhook or mqtt)", c.Type)
+}
+
+func knownEvent(kind string) bool {
+	for _, k := range EventKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// rank orders severities; higher is more severe, -1 is unknown
+func rank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	case SeverityInfo:
+		return 0
+	}
+	return -1
+}
+
+// Notify sends ev to every channel whose severity and event filters it
+// passes and returns the number of channels it was delivered to
+func (n *Notifier) Notify(ctx context.Context, ev Event) (int, error) {
+	if n == nil {
+		return 0, nil
+	}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+
+	sent := 0
+	var failed []string
+	for _, c := range n.channels {
+		if !c.wants(ev) {
+			continue
+		}
+		if err := c.sender.Send(ctx, ev); err != nil {
+			failed = append(failed, fmt.Sprintf("%s: %v", c.sender.Name(), err))
+			continue
+		}
+		sent++
+	}
+	if len(failed) > 0 {
+		return sent, fmt.Errorf("%s", strings.Join(failed, "; "))
+	}
+	return sent, nil
+}
+
+// Channels returns the configured channel names with their filters
+func (n *Notifier) Channels() []string {
+	if n == nil {
+		return nil
+	}
+	names := make([]string, 0, len(n.channels))
+	for _, c := range n.channels {
+		names = append(names, fmt.Sprintf("%s (>= %s)", c.sender.Name(), c.minSeverity))
+	}
+	return names
+}
+
+// postJSON sends body to url and checks for a 2xx response
+func postJSON(ctx context.Context, client *http.Client, url string, body interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sortedFields returns field names in a stable order
+func sortedFields(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func severityIcon(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "❌"
+	case SeverityWarning:
+		return "⚠️"
+	}
+	return "ℹ️"
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// teamsSender posts MessageCards to a Microsoft Teams incoming webhook
+type teamsSender struct {
+	url    string
+	client *http.Client
+}
+
+func (s *teamsSender) Name() string { return "teams" }
+
+type teamsFact struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+func (s *teamsSender) Send(ctx context.Context, ev Event) error {
+	var facts []teamsFact
+	if ev.CallID != "" {
+		facts = append(facts, teamsFact{Name: "Call", Value: ev.CallID})
+	}
+	for _, k := range sortedFields(ev.Fields) {
+		facts = append(facts, teamsFact{Name: k, Value: ev.Fields[k]})
+	}
+	facts = append(facts, teamsFact{Name: "Severity", Value: ev.Severity})
+
+	card := map[string]interface{}{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    ev.Title,
+		"title":      severityIcon(ev.Severity) + " " + ev.Title,
+		"themeColor": teamsColor(ev.Severity),
+		"sections": []map[string]interface{}{{
+			// Teams renders card text as markdown; keep line breaks
+			"text":  strings.Replace(ev.Text, "\n", "  \n", -1),
+			"facts": facts,
+		}},
+	}
+	return postJSON(ctx, s.client, s.url, card)
+}
+
+func teamsColor(severity string) string {
+	switch severity {
+	case SeverityCritical:
+		return "D13438"
+	case SeverityWarning:
+		return "FFB900"
+	}
+	return "0078D7"
+}
@@ -0,0 +1,52 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// telegramAPI is the Bot API base URL
+const telegramAPI = "https://api.telegram.org"
+
+// telegramMaxText is Telegram's message length limit, in characters
+const telegramMaxText = 4096
+
+// telegramSender posts messages through a Telegram bot
+type telegramSender struct {
+	token  string
+	chatID string
+	client *http.Client
+}
+
+func (s *telegramSender) Name() string { return "telegram" }
+
+func (s *telegramSender) Send(ctx context.Context, ev Event) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", severityIcon(ev.Severity), ev.Title)
+	if ev.CallID != "" {
+		fmt.Fprintf(&b, "Call: %s\n", ev.CallID)
+	}
+	for _, k := range sortedFields(ev.Fields) {
+		fmt.Fprintf(&b, "%s: %s\n", k, ev.Fields[k])
+	}
+	if ev.Text != "" {
+		b.WriteString("\n" + ev.Text)
+	}
+	text := b.String()
+	if runes := []rune(text); len(runes) > telegramMaxText {
+		text = string(runes[:telegramMaxText-3]) + "..."
+	}
+
+	err := postJSON(ctx, s.client, telegramAPI+"/bot"+s.token+"/sendMessage", map[string]interface{}{
+		"chat_id":                  s.chatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	})
+	if err != nil {
+		// Errors from net/http include the URL, which contains the token
+		return fmt.Errorf("%s", strings.Replace(err.Error(), s.token, "***", -1))
+	}
+	return nil
+}
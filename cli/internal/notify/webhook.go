@@ -0,0 +1,76 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Webhook request headers
+const (
+	HeaderEvent     = "X-Agent-Event"
+	HeaderTimestamp = "X-Agent-Timestamp"
+	HeaderSignature = "X-Agent-Signature"
+)
+
+// webhookSender POSTs events as JSON to an arbitrary URL
+type webhookSender struct {
+	url     string
+	secret  string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (s *webhookSender) Name() string { return "webhook" }
+
+func (s *webhookSender) Send(ctx context.Context, ev Event) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	timestamp := strconv.FormatInt(ev.Time.Unix(), 10)
+	req.Header.Set(HeaderEvent, ev.Kind)
+	req.Header.Set(HeaderTimestamp, timestamp)
+	if s.secret != "" {
+		req.Header.Set(HeaderSignature, "sha256="+Sign(s.secret, timestamp, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of "<timestamp>.<body>". Receivers
+// recompute it with the shared secret and compare against the signature
+// header; the timestamp lets them reject replays.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,160 @@
+package offline
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+)
+
+// containerModels is where docker-compose.yml mounts ./models in
+// local_ai_server
+const containerModels = "/app/models"
+
+// model is a file or directory local_ai_server loads at startup
+type model struct {
+	Use  string
+	Path string
+}
+
+// setting returns a local_ai_server setting: .env, else the
+// docker-compose.yml/config.py default
+func (s *setup) setting(key, def string) string {
+	if v := s.env[key]; v != "" {
+		return v
+	}
+	return def
+}
+
+// capabilities returns what local_ai_server serves for the providers in
+// use (stt, llm, tts)
+func (s *setup) capabilities() map[string]bool {
+	caps := map[string]bool{}
+	for _, n := range s.profile.Providers {
+		p := s.cfg.Providers[n]
+		served := p.Type == "local"
+		for _, u := range p.urls() {
+			if strings.Contains(s.expand(u), ":8765") {
+				served = true
+			}
+		}
+		if !served {
+			continue
+		}
+		for _, c := range p.Capabilities {
+			caps[c] = true
+		}
+	}
+	return caps
+}
+
+// models lists the models the configured backends load, and the backend
+// settings that reach the internet instead
+func (s *setup) models() ([]model, []string) {
+	caps := s.capabilities()
+	var models []model
+	var online []string
+
+	if caps["stt"] {
+		backend := strings.ToLower(s.setting("LOCAL_STT_BACKEND", "vosk"))
+		switch backend {
+		case "vosk":
+			models = append(models, model{"STT (vosk)", s.setting("LOCAL_STT_MODEL_PATH", "/app/models/stt/vosk-model-en-us-0.22")})
+		case "sherpa":
+			models = append(models, model{"STT (sherpa)", s.setting("SHERPA_MODEL_PATH", "/app/models/stt/sherpa")})
+		case "whisper_cpp":
+			models = append(models, model{"STT (whisper.cpp)", s.setting("WHISPER_CPP_MODEL_PATH", "/app/models/stt/ggml-base.en.bin")})
+		case "faster_whisper":
+			m := s.setting("FASTER_WHISPER_MODEL", "base")
+			if strings.Contains(m, "/") {
+				models = append(models, model{"STT (faster-whisper)", m})
+			} else {
+				online = append(online, fmt.Sprintf("FASTER_WHISPER_MODEL=%s is downloaded from Hugging Face on first load; set it to a model directory under %s", m, containerModels))
+			}
+		case "kroko":
+			if s.setting("KROKO_EMBEDDED", "0") == "1" || strings.EqualFold(s.setting("KROKO_EMBEDDED", ""), "true") {
+				models = append(models, model{"STT (kroko)", s.setting("KROKO_MODEL_PATH", "/app/models/kroko/kroko-en-v1.0.onnx")})
+			} else if u := s.setting("KROKO_URL", "wss://app.kroko.ai/api/v1/transcripts/streaming"); !Local(u) {
+				online = append(online, "Kroko STT uses the cloud ("+u+"); set KROKO_EMBEDDED=1")
+			}
+		}
+	}
+	if caps["llm"] {
+		models = append(models, model{"LLM", s.setting("LOCAL_LLM_MODEL_PATH", "/app/models/llm/phi-3-mini-4k-instruct.Q4_K_M.gguf")})
+	}
+	if caps["tts"] {
+		backend := strings.ToLower(s.setting("LOCAL_TTS_BACKEND", "piper"))
+		switch backend {
+		case "piper":
+			models = append(models, model{"TTS (piper)", s.setting("LOCAL_TTS_MODEL_PATH", "/app/models/tts/en_US-lessac-medium.onnx")})
+		case "kokoro":
+			if strings.ToLower(s.setting("KOKORO_MODE", "local")) == "api" {
+				if u := s.setting("KOKORO_API_BASE_URL", ""); !Local(u) {
+					online = append(online, "Kokoro TTS uses its API ("+u+"); set KOKORO_MODE=local")
+				}
+			} else {
+				models = append(models, model{"TTS (kokoro)", s.setting("KOKORO_MODEL_PATH", "/app/models/tts/kokoro")})
+			}
+		case "melotts":
+			online = append(online, "MeloTTS downloads its voices on first load; pre-seed the image's cache or use piper")
+		}
+	}
+	return models, online
+}
+
+// hostPath maps a local_ai_server path to the project directory, or ""
+// when it is outside the models mount
+func (s *setup) hostPath(p string) string {
+	if strings.HasPrefix(p, containerModels+"/") {
+		return filepath.Join(s.dir, "models", strings.TrimPrefix(p, containerModels+"/"))
+	}
+	if !filepath.IsAbs(p) {
+		return filepath.Join(s.dir, p)
+	}
+	return ""
+}
+
+func (s *setup) checkModels() health.Check {
+	const name = "Offline: Models"
+	models, online := s.models()
+	if len(models) == 0 && len(online) == 0 {
+		return health.Check{
+			Name:    name,
+			Status:  health.StatusInfo,
+			Message: "No local_ai_server models in use",
+		}
+	}
+
+	var details, missing []string
+	for _, m := range models {
+		host := s.hostPath(m.Path)
+		if host == "" {
+			details = append(details, fmt.Sprintf("%s: %s (outside %s, not checked)", m.Use, m.Path, containerModels))
+			continue
+		}
+		if _, err := os.Stat(host); err != nil {
+			missing = append(missing, fmt.Sprintf("%s: %s missing", m.Use, host))
+			continue
+		}
+		details = append(details, fmt.Sprintf("%s: %s", m.Use, host))
+	}
+
+	if len(missing) > 0 || len(online) > 0 {
+		problems := append(missing, online...)
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     fmt.Sprintf("%d model(s) missing or downloaded at runtime", len(problems)),
+			Details:     strings.Join(append(problems, details...), "\n"),
+			Remediation: "Copy the models into ./models before going offline (see docs/LOCAL_ONLY_SETUP.md)",
+		}
+	}
+	return health.Check{
+		Name:    name,
+		Status:  health.StatusPass,
+		Message: fmt.Sprintf("%d model(s) present on disk", len(models)),
+		Details: strings.Join(details, "\n"),
+	}
+}
@@ -0,0 +1,292 @@
+package offline
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"gopkg.in/yaml.v3"
+)
+
+// provider is the part of a provider block that says where it runs
+type provider struct {
+	Type         string   `yaml:"type"`
+	Capabilities []string `yaml:"capabilities"`
+	BaseURL      string   `yaml:"base_url"`
+	WSURL        string   `yaml:"ws_url"`
+	ChatBaseURL  string   `yaml:"chat_base_url"`
+	TTSBaseURL   string   `yaml:"tts_base_url"`
+}
+
+func (p provider) urls() []string {
+	var out []string
+	for _, u := range []string{p.BaseURL, p.WSURL, p.ChatBaseURL, p.TTSBaseURL} {
+		if u != "" {
+			out = append(out, u)
+		}
+	}
+	return out
+}
+
+// setup is the deployment being validated
+type setup struct {
+	dir     string
+	profile *deploy.Profile
+	env     map[string]string
+	root    yaml.Node
+	cfg     struct {
+		ActivePipeline string              `yaml:"active_pipeline"`
+		Providers      map[string]provider `yaml:"providers"`
+		MCP            struct {
+			Enabled bool `yaml:"enabled"`
+			Servers map[string]struct {
+				Enabled *bool `yaml:"enabled"`
+			} `yaml:"servers"`
+		} `yaml:"mcp"`
+	}
+}
+
+// Validate checks that the deployment in dir runs without internet
+// access: every provider local, no cloud endpoints in the config, models
+// on disk and troubleshoot's LLM analysis local
+func Validate(dir string) []health.Check {
+	s, err := load(dir)
+	if err != nil {
+		return []health.Check{{
+			Name:        "Offline",
+			Status:      health.StatusFail,
+			Message:     "Cannot validate the offline setup",
+			Details:     err.Error(),
+			Remediation: "Run from the project directory (config/ai-agent.yaml)",
+		}}
+	}
+	return []health.Check{
+		s.checkProviders(),
+		s.checkEndpoints(),
+		s.checkModels(),
+		checkRCA(),
+	}
+}
+
+func load(dir string) (*setup, error) {
+	p, err := deploy.LoadProfile(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &setup{dir: dir, profile: p, env: map[string]string{}}
+	for k, v := range p.Env {
+		s.env[k] = v
+	}
+	for k, v := range p.Secrets {
+		s.env[k] = v
+	}
+	if err := yaml.Unmarshal(p.Config, &s.root); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(p.Config, &s.cfg); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// expand resolves .env references the way the engine does
+func (s *setup) expand(v string) string {
+	return health.ExpandEnv(v, s.env)
+}
+
+// Local reports whether a URL points at this host, a private network or
+// a container on it (a single-label or .local/.lan/.internal name)
+func Local(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Hostname() == "" {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified()
+	}
+	if host == "localhost" || !strings.Contains(host, ".") {
+		return true
+	}
+	for _, suffix := range []string{".localhost", ".local", ".lan", ".internal", ".home.arpa"} {
+		if strings.HasSuffix(host, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// local reports whether a provider runs on this host: local_ai_server
+// (type local) or a provider whose every endpoint is local
+func (s *setup) local(p provider) bool {
+	if p.Type == "local" {
+		return true
+	}
+	urls := p.urls()
+	if len(urls) == 0 {
+		return false
+	}
+	for _, u := range urls {
+		if !Local(s.expand(u)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *setup) checkProviders() health.Check {
+	const name = "Offline: Providers"
+	var cloud []string
+	for _, n := range s.profile.Providers {
+		if !s.local(s.cfg.Providers[n]) {
+			cloud = append(cloud, n)
+		}
+	}
+	if len(cloud) > 0 {
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     fmt.Sprintf("%d provider(s) in use need the internet", len(cloud)),
+			Details:     "Cloud: " + strings.Join(cloud, ", "),
+			Remediation: "Use local providers (local_stt, local_llm, local_tts or Ollama) in the active pipeline, e.g. config/ai-agent.golden-local-hybrid.yaml with a local LLM",
+		}
+	}
+	if len(s.profile.Providers) == 0 {
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     "No provider in use",
+			Remediation: "Set default_provider or active_pipeline in config/ai-agent.yaml",
+		}
+	}
+	return health.Check{
+		Name:    name,
+		Status:  health.StatusPass,
+		Message: "All providers in use are local",
+		Details: strings.Join(s.profile.Providers, ", "),
+	}
+}
+
+// reference is a cloud URL found in the config
+type reference struct {
+	Path string
+	Line int
+	URL  string
+}
+
+// urlValue matches config values that are URLs
+var urlValue = regexp.MustCompile(`^(?i)(https?|wss?)://\S+$`)
+
+// cloudReferences walks the config for values that are URLs to hosts
+// outside the local network
+func (s *setup) cloudReferences() []reference {
+	var out []reference
+	var walk func(n *yaml.Node, path string)
+	walk = func(n *yaml.Node, path string) {
+		switch n.Kind {
+		case yaml.DocumentNode:
+			for _, c := range n.Content {
+				walk(c, path)
+			}
+		case yaml.MappingNode:
+			for i := 0; i+1 < len(n.Content); i += 2 {
+				key := n.Content[i].Value
+				if path != "" {
+					key = path + "." + key
+				}
+				walk(n.Content[i+1], key)
+			}
+		case yaml.SequenceNode:
+			for i, c := range n.Content {
+				walk(c, fmt.Sprintf("%s[%d]", path, i))
+			}
+		case yaml.ScalarNode:
+			v := strings.TrimSpace(s.expand(n.Value))
+			if urlValue.MatchString(v) && !Local(v) {
+				out = append(out, reference{Path: path, Line: n.Line, URL: v})
+			}
+		}
+	}
+	walk(&s.root, "")
+	return out
+}
+
+// inUse reports whether a config path belongs to the running setup:
+// providers and pipelines not in use do not need the internet
+func (s *setup) inUse(path string) bool {
+	parts := strings.SplitN(path, ".", 3)
+	if len(parts) < 2 {
+		return true
+	}
+	switch parts[0] {
+	case "providers":
+		for _, n := range s.profile.Providers {
+			if n == parts[1] {
+				return true
+			}
+		}
+		return false
+	case "pipelines":
+		return parts[1] == s.cfg.ActivePipeline
+	}
+	return true
+}
+
+func (s *setup) checkEndpoints() health.Check {
+	const name = "Offline: Endpoints"
+	var used, unused []string
+	for _, r := range s.cloudReferences() {
+		line := fmt.Sprintf("line %d %s: %s", r.Line, r.Path, r.URL)
+		if s.inUse(r.Path) {
+			used = append(used, line)
+		} else {
+			unused = append(unused, line)
+		}
+	}
+	var mcp []string
+	if s.cfg.MCP.Enabled {
+		for n, srv := range s.cfg.MCP.Servers {
+			if srv.Enabled == nil || *srv.Enabled {
+				mcp = append(mcp, n)
+			}
+		}
+		sort.Strings(mcp)
+	}
+
+	switch {
+	case len(used) > 0:
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     fmt.Sprintf("%d cloud endpoint(s) in the active configuration", len(used)),
+			Details:     strings.Join(append(used, unused...), "\n"),
+			Remediation: "Point these at local services or switch the pipeline to local providers",
+		}
+	case len(unused) > 0 || len(mcp) > 0:
+		details := unused
+		if len(mcp) > 0 {
+			details = append(details, "MCP servers enabled (check they need no internet): "+strings.Join(mcp, ", "))
+		}
+		message := fmt.Sprintf("%d cloud endpoint(s) in unused providers or pipelines", len(unused))
+		if len(unused) == 0 {
+			message = "MCP servers may reach the internet"
+		}
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusWarn,
+			Message:     message,
+			Details:     strings.Join(details, "\n"),
+			Remediation: "Set enabled: false on unused cloud providers and MCP servers, or remove them",
+		}
+	}
+	return health.Check{
+		Name:    name,
+		Status:  health.StatusPass,
+		Message: "No cloud endpoints in config/ai-agent.yaml",
+	}
+}
@@ -0,0 +1,45 @@
+package offline
+
+import (
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+)
+
+// checkRCA verifies troubleshoot's LLM analysis runs on a local model
+// rather than sending call logs to a cloud LLM
+func checkRCA() health.Check {
+	const name = "Offline: RCA"
+	settings, err := troubleshoot.LoadLLMSettings()
+	switch {
+	case err != nil:
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     "troubleshoot LLM misconfigured",
+			Details:     err.Error(),
+			Remediation: "Set TROUBLESHOOT_LLM_PROVIDER=ollama or llamacpp",
+		}
+	case settings.Provider == "":
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusWarn,
+			Message:     "No local LLM configured for troubleshoot analysis",
+			Details:     "agent troubleshoot runs its rule-based analysis only",
+			Remediation: "Set TROUBLESHOOT_LLM_PROVIDER=ollama (TROUBLESHOOT_LLM_BASE_URL, TROUBLESHOOT_LLM_MODEL) for local RCA",
+		}
+	case !settings.Local() || !Local(settings.BaseURL):
+		return health.Check{
+			Name:        name,
+			Status:      health.StatusFail,
+			Message:     "troubleshoot would send call logs to " + settings.Provider + " at " + settings.BaseURL,
+			Details:     "TROUBLESHOOT_LLM_PROVIDER or an OPENAI_API_KEY/ANTHROPIC_API_KEY in the environment selects it",
+			Remediation: "Set TROUBLESHOOT_LLM_PROVIDER=ollama or llamacpp with a local TROUBLESHOOT_LLM_BASE_URL, or run troubleshoot with --no-llm",
+		}
+	}
+	return health.Check{
+		Name:    name,
+		Status:  health.StatusPass,
+		Message: "troubleshoot analysis uses " + settings.Provider + " at " + settings.BaseURL,
+		Details: "Model: " + settings.Model,
+	}
+}
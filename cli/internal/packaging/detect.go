@@ -0,0 +1,83 @@
+package packaging
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/systemd"
+)
+
+// projectDirs are where installs usually keep the project
+var projectDirs = []string{".", "/opt/asterisk-ai-voice-agent", "/opt/aava", "/root/Asterisk-AI-Voice-Agent"}
+
+// stackContainers are the containers of a docker deployment besides the
+// engines (ai_engine, ai_engine_2, ...)
+var stackContainers = map[string]bool{"local_ai_server": true, "admin_ui": true}
+
+// Deployment is an existing install of the stack found on this host
+type Deployment struct {
+	// Containers are the stack's containers, running or not
+	Containers []string
+	// Units are the installed bare-metal systemd units
+	Units []string
+	// ProjectDir holds the .env and config/ai-agent.yaml
+	ProjectDir string
+}
+
+// Found reports whether any part of a deployment was found
+func (d Deployment) Found() bool {
+	return len(d.Containers) > 0 || len(d.Units) > 0 || d.ProjectDir != ""
+}
+
+// Docker reports whether the engine runs in docker
+func (d Deployment) Docker() bool {
+	for _, name := range d.Containers {
+		if strings.HasPrefix(name, "ai_engine") {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect looks for the stack's containers, systemd units and project
+// directory. A missing or unreachable docker daemon only means no
+// containers are found.
+func Detect(ctx context.Context) Deployment {
+	var d Deployment
+	if client, err := docker.Default(); err == nil {
+		if names, err := client.ContainerNames(ctx, docker.ListOptions{All: true}); err == nil {
+			for _, name := range names {
+				if strings.HasPrefix(name, "ai_engine") || stackContainers[name] {
+					d.Containers = append(d.Containers, name)
+				}
+			}
+		}
+	}
+	for _, unit := range []string{systemd.EngineUnit, systemd.LocalAIUnit} {
+		if _, err := os.Stat(filepath.Join(systemd.DefaultDir, unit)); err == nil {
+			d.Units = append(d.Units, unit)
+		}
+	}
+	for _, dir := range projectDirs {
+		if isProject(dir) {
+			if abs, err := filepath.Abs(dir); err == nil {
+				dir = abs
+			}
+			d.ProjectDir = dir
+			break
+		}
+	}
+	return d
+}
+
+func isProject(dir string) bool {
+	for _, name := range []string{".env", filepath.Join("config", "ai-agent.yaml")} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			return true
+		}
+	}
+	return false
+}
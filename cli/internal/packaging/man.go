@@ -0,0 +1,163 @@
+package packaging
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Page is a man page of one command
+type Page struct {
+	// Command is the full command, e.g. "agent troubleshoot show"
+	Command string
+	Short   string
+	// Long is the command's help text; an "Examples:" paragraph becomes
+	// the EXAMPLES section
+	Long     string
+	Synopsis string
+	Options  []Option
+	// GlobalOptions are the flags inherited from parent commands
+	GlobalOptions []Option
+	// SeeAlso are the commands of related pages
+	SeeAlso []string
+}
+
+// Option is one flag of a page
+type Option struct {
+	// Flag is the flag as written, e.g. "-o, --output string"
+	Flag  string
+	Usage string
+}
+
+// Name is the page name: the command with dashes, e.g. agent-troubleshoot
+func (p Page) Name() string {
+	return strings.ReplaceAll(p.Command, " ", "-")
+}
+
+// FileName is the page's file in man1
+func (p Page) FileName() string {
+	return p.Name() + ".1"
+}
+
+// Render writes the page in roff (man(7) macros)
+func (p Page) Render(version string, date time.Time) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, ".TH %q 1 %q %q %q\n", strings.ToUpper(p.Name()), date.Format("January 2006"),
+		"agent "+version, "Asterisk AI Voice Agent")
+	b.WriteString(".SH NAME\n")
+	fmt.Fprintf(&b, "%s \\- %s\n", escape(p.Name()), escape(p.Short))
+
+	b.WriteString(".SH SYNOPSIS\n")
+	fmt.Fprintf(&b, ".B %s\n", escape(p.Synopsis))
+
+	long, examples := splitExamples(p.Long)
+	if long == "" {
+		long = p.Short
+	}
+	b.WriteString(".SH DESCRIPTION\n")
+	writeText(&b, long)
+
+	if len(p.Options) > 0 {
+		b.WriteString(".SH OPTIONS\n")
+		writeOptions(&b, p.Options)
+	}
+	if len(p.GlobalOptions) > 0 {
+		b.WriteString(".SH GLOBAL OPTIONS\n")
+		writeOptions(&b, p.GlobalOptions)
+	}
+	if examples != "" {
+		b.WriteString(".SH EXAMPLES\n")
+		writeText(&b, examples)
+	}
+	if len(p.SeeAlso) > 0 {
+		b.WriteString(".SH SEE ALSO\n")
+		refs := make([]string, len(p.SeeAlso))
+		for i, cmd := range p.SeeAlso {
+			refs[i] = fmt.Sprintf("\\fB%s\\fR(1)", escape(strings.ReplaceAll(cmd, " ", "-")))
+		}
+		b.WriteString(strings.Join(refs, ", ") + "\n")
+	}
+	return b.String()
+}
+
+// splitExamples separates the trailing "Examples:" paragraph the help
+// texts end with
+func splitExamples(long string) (text, examples string) {
+	long = strings.TrimSpace(long)
+	i := strings.LastIndex(long, "Examples:\n")
+	if i < 0 || (i > 0 && long[i-1] != '\n') {
+		return long, ""
+	}
+	return strings.TrimSpace(long[:i]), strings.Trim(long[i+len("Examples:\n"):], "\n")
+}
+
+func writeOptions(b *strings.Builder, opts []Option) {
+	for _, o := range opts {
+		b.WriteString(".TP\n")
+		fmt.Fprintf(b, "\\fB%s\\fR\n", strings.ReplaceAll(escape(o.Flag), "-", `\-`))
+		fmt.Fprintf(b, "%s\n", escape(o.Usage))
+	}
+}
+
+// writeText writes help text: unindented lines are filled into
+// paragraphs and indented blocks (examples, config snippets, tables)
+// are kept as they are
+func writeText(b *strings.Builder, text string) {
+	var para []string
+	inBlock, blanks := false, 0
+	flush := func() {
+		if len(para) > 0 {
+			b.WriteString(".PP\n")
+			for _, line := range para {
+				b.WriteString(escapeLine(line) + "\n")
+			}
+			para = nil
+		}
+	}
+	endBlock := func() {
+		if inBlock {
+			b.WriteString(".fi\n.RE\n")
+			inBlock, blanks = false, 0
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		switch {
+		case strings.TrimSpace(line) == "":
+			if inBlock {
+				// Kept only between lines of the block
+				blanks++
+				continue
+			}
+			flush()
+		case strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t"):
+			flush()
+			if !inBlock {
+				b.WriteString(".RS 4\n.nf\n")
+				inBlock = true
+			}
+			b.WriteString(strings.Repeat("\n", blanks))
+			blanks = 0
+			b.WriteString(escapeLine(strings.TrimPrefix(strings.TrimPrefix(line, "  "), "\t")) + "\n")
+		default:
+			endBlock()
+			para = append(para, strings.TrimSpace(line))
+		}
+	}
+	flush()
+	endBlock()
+}
+
+// escape protects roff's escape character
+func escape(s string) string {
+	return strings.ReplaceAll(s, `\`, `\e`)
+}
+
+// escapeLine also keeps lines starting with a control character from
+// being read as requests
+func escapeLine(s string) string {
+	s = escape(s)
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		return `\&` + s
+	}
+	return s
+}
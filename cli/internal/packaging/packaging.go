@@ -0,0 +1,116 @@
+// Package packaging lays out the files the OS packages of the CLI
+// (Homebrew, apt, rpm) install next to the binary: shell completions, man
+// pages, the default config and the post-install hook.
+package packaging
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// File is a file of the package, relative to the install prefix
+type File struct {
+	Path    string
+	Mode    os.FileMode
+	Content string
+}
+
+// Layout places the package files under a prefix (/usr, /usr/local or
+// Homebrew's keg) and the config under SysconfDir (/etc, or <prefix>/etc
+// for Homebrew)
+type Layout struct {
+	Prefix     string
+	SysconfDir string
+}
+
+// BashCompletion is where bash-completion loads the script from on demand
+func (l Layout) BashCompletion() string {
+	return filepath.Join(l.Prefix, "share", "bash-completion", "completions", "agent")
+}
+
+// ZshCompletion is in the site-functions directory on zsh's fpath
+func (l Layout) ZshCompletion() string {
+	return filepath.Join(l.Prefix, "share", "zsh", "site-functions", "_agent")
+}
+
+// FishCompletion is in fish's vendor completions directory
+func (l Layout) FishCompletion() string {
+	return filepath.Join(l.Prefix, "share", "fish", "vendor_completions.d", "agent.fish")
+}
+
+// ManDir is the section 1 man page directory
+func (l Layout) ManDir() string {
+	return filepath.Join(l.Prefix, "share", "man", "man1")
+}
+
+// Config is the default config settings.Load falls back to
+func (l Layout) Config() string {
+	return filepath.Join(l.SysconfDir, "agent", "config")
+}
+
+// PostInstall is the hook package scripts run after installing
+func (l Layout) PostInstall() string {
+	return filepath.Join(l.Prefix, "share", "agent", "postinstall.sh")
+}
+
+// DefaultConfig is the commented system config installed to
+// /etc/agent/config. Users' ~/.agent/config replaces it as a whole.
+const DefaultConfig = `# Default settings of the agent CLI, used by every user without a
+# ~/.agent/config of their own (which replaces this file as a whole).
+# Keys are those of ~/.agent/config; see 'agent --help' and the
+# command help for each. Everything here is commented out: the CLI
+# defaults apply until you uncomment a key.
+
+# Display timezone for times and --since/--until (IANA name, Local, UTC)
+#timezone: Local
+
+# Zone of container log timestamps without an offset
+#log_timezone: UTC
+
+# Output theme: default, colorblind or mono
+#theme: default
+
+# Where troubleshoot reads engine logs from: docker (default), loki,
+# elasticsearch, syslog or journald (bare-metal installs)
+#log_source:
+#  type: journald
+#  unit: aava-engine.service
+
+# How long runs, the call index and recordings are kept locally
+#retention:
+#  runs: 30d
+#  index: 30d
+`
+
+// PostInstallScript runs 'agent init --post-install', which detects an
+// existing deployment and builds the initial call index. It never fails
+// the package installation.
+func PostInstallScript(agent string) string {
+	return `#!/bin/sh
+# Run by the package manager after installing or upgrading the agent CLI.
+# Detects an existing Asterisk AI Voice Agent deployment and indexes its
+# recent calls; never fails the installation.
+if [ -x "` + agent + `" ]; then
+	"` + agent + `" init --post-install || true
+fi
+exit 0
+`
+}
+
+// Write writes files under root, creating directories as needed
+func Write(root string, files []File) error {
+	for _, f := range files {
+		path := filepath.Join(root, f.Path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		mode := f.Mode
+		if mode == 0 {
+			mode = 0644
+		}
+		if err := os.WriteFile(path, []byte(f.Content), mode); err != nil {
+			return err
+		}
+	}
+	return nil
+}
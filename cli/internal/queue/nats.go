@@ -0,0 +1,272 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+)
+
+// DefaultSubject is the subject prefix events are published under
+const DefaultSubject = "agent.events"
+
+// natsTimeout bounds the handshake and each write
+const natsTimeout = 10 * time.Second
+
+// natsQueue publishes each node's events to <subject>.<node> and
+// consumes <subject>.> so the aggregator hears every node
+type natsQueue struct {
+	u       *url.URL
+	subject string
+	node    string
+
+	mu   sync.Mutex
+	conn *natsConn
+}
+
+func newNATSQueue(u *url.URL, node string) (*natsQueue, error) {
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "4222")
+	}
+	subject := strings.Trim(u.Path, "/")
+	if subject == "" {
+		subject = DefaultSubject
+	}
+	if strings.ContainsAny(subject, " *>") {
+		return nil, fmt.Errorf("invalid NATS subject %q", subject)
+	}
+	return &natsQueue{u: u, subject: subject, node: node}, nil
+}
+
+// nodeToken makes a node name usable as one subject token
+func nodeToken(node string) string {
+	if node == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ' ', '*', '>', '\t', '\r', '\n':
+			return '_'
+		}
+		return r
+	}, node)
+}
+
+func (q *natsQueue) Publish(ctx context.Context, ev events.Event) error {
+	payload, err := encode(ev, q.node)
+	if err != nil {
+		return err
+	}
+	subject := q.subject + "." + nodeToken(q.node)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// A connection dropped since the last event is redialed once
+	for attempt := 0; attempt < 2; attempt++ {
+		if q.conn == nil {
+			if q.conn, err = dialNATS(ctx, q.u); err != nil {
+				return err
+			}
+			go q.conn.keepAlive()
+		}
+		if err = q.conn.publish(subject, payload); err == nil {
+			return nil
+		}
+		q.conn.Close()
+		q.conn = nil
+	}
+	return err
+}
+
+func (q *natsQueue) Consume(ctx context.Context, fn func(events.Event)) error {
+	conn, err := dialNATS(ctx, q.u)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if err := conn.write("SUB " + q.subject + ".> 1\r\n"); err != nil {
+		return err
+	}
+	for {
+		line, err := conn.readLine()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		switch {
+		case line == "PING":
+			if err := conn.write("PONG\r\n"); err != nil {
+				return err
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		case strings.HasPrefix(line, "MSG "):
+			// MSG <subject> <sid> [reply-to] <#bytes>
+			parts := strings.Fields(line)
+			n, err := strconv.Atoi(parts[len(parts)-1])
+			if err != nil || n < 0 {
+				return fmt.Errorf("nats: malformed %q", line)
+			}
+			payload := make([]byte, n+2)
+			if _, err := io.ReadFull(conn.r, payload); err != nil {
+				return err
+			}
+			if ev, err := decode(payload[:n]); err == nil {
+				fn(ev)
+			}
+		}
+	}
+}
+
+func (q *natsQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.conn == nil {
+		return nil
+	}
+	err := q.conn.Close()
+	q.conn = nil
+	return err
+}
+
+// natsConn is one connection to a NATS server
+type natsConn struct {
+	conn   net.Conn
+	r      *bufio.Reader
+	mu     sync.Mutex
+	closed atomic.Bool
+}
+
+// natsInfo is the part of the server's INFO the client needs
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+// dialNATS connects and completes the handshake: INFO from the server,
+// TLS when asked for, then CONNECT and a PING answered by PONG, or the
+// server's -ERR
+func dialNATS(ctx context.Context, u *url.URL) (*natsConn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+	c := &natsConn{conn: conn, r: bufio.NewReader(conn)}
+	line, err := c.readLine()
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		conn.Close()
+		return nil, fmt.Errorf("nats: expected INFO, got %q", line)
+	}
+	var info natsInfo
+	json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info)
+	if u.Scheme == "tls" || info.TLSRequired {
+		tc := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		tc.SetDeadline(time.Now().Add(natsTimeout))
+		c = &natsConn{conn: tc, r: bufio.NewReader(tc)}
+	}
+
+	opts := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "agent",
+		"lang":     "go",
+		"protocol": 1,
+	}
+	if u.User != nil {
+		if pass, ok := u.User.Password(); ok {
+			opts["user"] = u.User.Username()
+			opts["pass"] = pass
+		} else {
+			opts["auth_token"] = u.User.Username()
+		}
+	}
+	connect, _ := json.Marshal(opts)
+	if err := c.write("CONNECT " + string(connect) + "\r\nPING\r\n"); err != nil {
+		c.Close()
+		return nil, err
+	}
+	for {
+		line, err := c.readLine()
+		if err != nil {
+			c.Close()
+			return nil, err
+		}
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			c.Close()
+			return nil, fmt.Errorf("nats: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+	c.conn.SetDeadline(time.Time{})
+	return c, nil
+}
+
+// publish sends one message
+func (c *natsConn) publish(subject string, payload []byte) error {
+	if c.closed.Load() {
+		return fmt.Errorf("nats: connection closed")
+	}
+	return c.write(fmt.Sprintf("PUB %s %d\r\n%s\r\n", subject, len(payload), payload))
+}
+
+// keepAlive answers the server's PINGs on a publishing connection, which
+// otherwise reads nothing, until the connection fails
+func (c *natsConn) keepAlive() {
+	for {
+		line, err := c.readLine()
+		if err != nil || strings.HasPrefix(line, "-ERR") {
+			break
+		}
+		if line == "PING" && c.write("PONG\r\n") != nil {
+			break
+		}
+	}
+	c.Close()
+}
+
+func (c *natsConn) write(s string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(natsTimeout))
+	_, err := io.WriteString(c.conn, s)
+	return err
+}
+
+func (c *natsConn) readLine() (string, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func (c *natsConn) Close() error {
+	c.closed.Store(true)
+	return c.conn.Close()
+}
@@ -0,0 +1,79 @@
+// Package queue carries live call events between the 'agent serve'
+// instances of a fleet: each node publishes the events it parses into a
+// Redis stream or onto NATS subjects, and a central aggregator consumes
+// them. The clients speak just enough of RESP and the NATS protocol for
+// that, without a client library.
+package queue
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+)
+
+// NodeField is the event data key naming the node that published it
+const NodeField = "node"
+
+// Queue publishes events to, or consumes them from, a broker
+type Queue interface {
+	// Publish sends one event, tagged with the queue's node
+	Publish(ctx context.Context, ev events.Event) error
+	// Consume passes events published from now on to fn until ctx is
+	// done or the connection fails
+	Consume(ctx context.Context, fn func(events.Event)) error
+	Close() error
+}
+
+// Open returns the queue of a broker URL:
+//
+//	redis://[user:pass@]host:6379[/stream][?db=N]   (rediss:// for TLS)
+//	nats://[user:pass@|token@]host:4222[/subject]   (tls:// for TLS)
+//
+// The stream defaults to agent:events and the subject prefix to
+// agent.events. node names the publishing instance.
+func Open(rawURL, node string) (Queue, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("queue URL %q has no host", rawURL)
+	}
+	switch u.Scheme {
+	case "redis", "rediss":
+		return newRedisQueue(u, node)
+	case "nats", "tls":
+		return newNATSQueue(u, node)
+	}
+	return nil, fmt.Errorf("unsupported queue scheme %q (use redis://, rediss://, nats:// or tls://)", u.Scheme)
+}
+
+// encode returns the JSON of ev with node added to its data. The data map
+// is shared with other subscribers, so it is copied.
+func encode(ev events.Event, node string) ([]byte, error) {
+	data := make(map[string]interface{}, len(ev.Data)+1)
+	for k, v := range ev.Data {
+		data[k] = v
+	}
+	if node != "" {
+		data[NodeField] = node
+	}
+	ev.Data = data
+	return json.Marshal(ev)
+}
+
+// decode reads an event published by encode
+func decode(payload []byte) (events.Event, error) {
+	var ev events.Event
+	err := json.Unmarshal(payload, &ev)
+	return ev, err
+}
+
+// Node returns the node an event came from, empty for local events
+func Node(ev events.Event) string {
+	node, _ := ev.Data[NodeField].(string)
+	return node
+}
@@ -0,0 +1,267 @@
+package queue
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+)
+
+// DefaultStream is the Redis stream events are added to
+const DefaultStream = "agent:events"
+
+const (
+	// redisMaxLen caps the stream; older events are trimmed as new ones
+	// arrive
+	redisMaxLen = "100000"
+	// redisBlock is how long one XREAD waits for new events
+	redisBlock = 5 * time.Second
+	// redisTimeout bounds one command
+	redisTimeout = 10 * time.Second
+)
+
+// redisQueue adds events to a stream with XADD and reads them with XREAD
+type redisQueue struct {
+	u      *url.URL
+	stream string
+	db     string
+	node   string
+
+	mu   sync.Mutex
+	conn *redisConn
+}
+
+func newRedisQueue(u *url.URL, node string) (*redisQueue, error) {
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "6379")
+	}
+	stream := strings.Trim(u.Path, "/")
+	if stream == "" {
+		stream = DefaultStream
+	}
+	db := u.Query().Get("db")
+	if db != "" {
+		if _, err := strconv.Atoi(db); err != nil {
+			return nil, fmt.Errorf("invalid redis db %q", db)
+		}
+	}
+	return &redisQueue{u: u, stream: stream, db: db, node: node}, nil
+}
+
+func (q *redisQueue) Publish(ctx context.Context, ev events.Event) error {
+	payload, err := encode(ev, q.node)
+	if err != nil {
+		return err
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	// A connection dropped since the last event is redialed once
+	for attempt := 0; attempt < 2; attempt++ {
+		if q.conn == nil {
+			if q.conn, err = dialRedis(ctx, q.u, q.db); err != nil {
+				return err
+			}
+		}
+		_, err = q.conn.do("XADD", q.stream, "MAXLEN", "~", redisMaxLen, "*", "node", q.node, "event", string(payload))
+		if err == nil {
+			return nil
+		}
+		var replyErr redisError
+		if errors.As(err, &replyErr) {
+			return err
+		}
+		q.conn.Close()
+		q.conn = nil
+	}
+	return err
+}
+
+func (q *redisQueue) Consume(ctx context.Context, fn func(events.Event)) error {
+	conn, err := dialRedis(ctx, q.u, q.db)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	// Only events added from now on; a restarted aggregator does not
+	// alert on the backlog again
+	last := "$"
+	block := strconv.FormatInt(redisBlock.Milliseconds(), 10)
+	for {
+		reply, err := conn.do("XREAD", "COUNT", "100", "BLOCK", block, "STREAMS", q.stream, last)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		// [[stream, [[id, [field, value, ...]], ...]]], nil on timeout
+		streams, _ := reply.([]interface{})
+		for _, s := range streams {
+			pair, _ := s.([]interface{})
+			if len(pair) != 2 {
+				continue
+			}
+			entries, _ := pair[1].([]interface{})
+			for _, e := range entries {
+				entry, _ := e.([]interface{})
+				if len(entry) != 2 {
+					continue
+				}
+				if id, ok := entry[0].(string); ok {
+					last = id
+				}
+				fields, _ := entry[1].([]interface{})
+				for i := 0; i+1 < len(fields); i += 2 {
+					if name, _ := fields[i].(string); name != "event" {
+						continue
+					}
+					value, _ := fields[i+1].(string)
+					if ev, err := decode([]byte(value)); err == nil {
+						fn(ev)
+					}
+				}
+			}
+		}
+	}
+}
+
+func (q *redisQueue) Close() error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.conn == nil {
+		return nil
+	}
+	err := q.conn.Close()
+	q.conn = nil
+	return err
+}
+
+// redisError is an error reply of the server
+type redisError string
+
+func (e redisError) Error() string { return "redis: " + string(e) }
+
+// redisConn is one RESP connection
+type redisConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+// dialRedis connects, authenticates with the URL's user info and selects
+// db when set
+func dialRedis(ctx context.Context, u *url.URL, db string) (*redisConn, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "rediss" {
+		tc := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	}
+	c := &redisConn{conn: conn, r: bufio.NewReader(conn)}
+	if u.User != nil {
+		user := u.User.Username()
+		pass, hasPass := u.User.Password()
+		args := []string{"AUTH", pass}
+		if !hasPass {
+			// redis://secret@host: the password alone
+			args = []string{"AUTH", user}
+		} else if user != "" {
+			args = []string{"AUTH", user, pass}
+		}
+		if _, err := c.do(args...); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if db != "" {
+		if _, err := c.do("SELECT", db); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// do sends a command and reads its reply. Error replies are redisError.
+func (c *redisConn) do(args ...string) (interface{}, error) {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d\r\n", len(args))
+	for _, a := range args {
+		fmt.Fprintf(&b, "$%d\r\n%s\r\n", len(a), a)
+	}
+	timeout := redisTimeout
+	if args[0] == "XREAD" {
+		timeout += redisBlock
+	}
+	c.conn.SetDeadline(time.Now().Add(timeout))
+	if _, err := io.WriteString(c.conn, b.String()); err != nil {
+		return nil, err
+	}
+	return c.read()
+}
+
+// read reads one reply: strings, integers, nil and nested arrays
+func (c *redisConn) read() (interface{}, error) {
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil, fmt.Errorf("redis: empty reply")
+	}
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, redisError(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(c.r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil || n < 0 {
+			return nil, err
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			if items[i], err = c.read(); err != nil {
+				return nil, err
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("redis: unexpected reply %q", line)
+}
+
+func (c *redisConn) Close() error {
+	return c.conn.Close()
+}
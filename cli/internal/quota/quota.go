@@ -0,0 +1,331 @@
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+)
+
+// Quota levels, in rising order
+const (
+	OK       = "ok"
+	Warning  = "warning"
+	Exceeded = "exceeded"
+)
+
+// Actions on an exceeded quota
+const (
+	ActionAlert    = "alert"
+	ActionThrottle = "throttle"
+)
+
+// DefaultWarnAt is the share of a limit, in percent, that warns
+const DefaultWarnAt = 80
+
+// Usage is a tenant's consumption in the current quota period
+type Usage struct {
+	Tenant  string    `json:"tenant"`
+	Period  string    `json:"period"`
+	Start   time.Time `json:"period_start"`
+	Calls   int       `json:"calls"`
+	Minutes float64   `json:"minutes"`
+	Tokens  int       `json:"tokens"`
+	Cost    float64   `json:"cost"`
+	// Unpriced counts calls left out of Cost because a provider has no
+	// configured price
+	Unpriced int            `json:"unpriced_calls,omitempty"`
+	Limits   settings.Quota `json:"limits"`
+	Level    string         `json:"level"`
+	// Reasons describe each limit at or above the warning share
+	Reasons []string `json:"reasons,omitempty"`
+}
+
+// Limited reports whether a tenant has any quota to enforce
+func Limited(q settings.Quota) bool {
+	return q.Minutes > 0 || q.Tokens > 0 || q.Cost > 0
+}
+
+// Validate checks a tenant's quota settings
+func Validate(t settings.Tenant) error {
+	if _, err := PeriodStart(t.Quota.Period, time.Now()); err != nil {
+		return fmt.Errorf("tenant %s: %w", t.Name, err)
+	}
+	switch t.Quota.Action {
+	case "", ActionAlert, ActionThrottle:
+	default:
+		return fmt.Errorf("tenant %s: unknown quota action %q (use alert or throttle)", t.Name, t.Quota.Action)
+	}
+	if t.Quota.Action == ActionThrottle && len(t.DIDs) == 0 && len(t.Contexts) == 0 {
+		return fmt.Errorf("tenant %s: throttling needs dids or contexts to match its calls", t.Name)
+	}
+	if t.Quota.WarnAt < 0 || t.Quota.WarnAt > 100 {
+		return fmt.Errorf("tenant %s: quota warn_at must be between 0 and 100", t.Name)
+	}
+	return nil
+}
+
+// PeriodStart returns the start of the period containing now, in now's
+// location: the first of the month, Monday of the week or midnight
+func PeriodStart(period string, now time.Time) (time.Time, error) {
+	y, m, d := now.Date()
+	switch strings.ToLower(period) {
+	case "", "month":
+		return time.Date(y, m, 1, 0, 0, 0, 0, now.Location()), nil
+	case "week":
+		offset := (int(now.Weekday()) + 6) % 7
+		return time.Date(y, m, d-offset, 0, 0, 0, 0, now.Location()), nil
+	case "day":
+		return time.Date(y, m, d, 0, 0, 0, 0, now.Location()), nil
+	}
+	return time.Time{}, fmt.Errorf("unknown quota period %q (use month, week or day)", period)
+}
+
+// Compute totals the usage of each tenant with a quota from the
+// analyzed calls, counting the calls that started in its current period
+func Compute(tenants []settings.Tenant, rows []troubleshoot.CallRow, now time.Time) []Usage {
+	var usages []Usage
+	for _, t := range tenants {
+		if !Limited(t.Quota) {
+			continue
+		}
+		start, err := PeriodStart(t.Quota.Period, now)
+		if err != nil {
+			continue
+		}
+		u := Usage{Tenant: t.Name, Period: periodName(t.Quota.Period), Start: start, Limits: t.Quota}
+		for _, row := range rows {
+			if !strings.EqualFold(row.Tenant, t.Name) || row.Start.Before(start) {
+				continue
+			}
+			u.Calls++
+			u.Minutes += row.DurationSeconds / 60
+			u.Tokens += row.Tokens
+			if row.Cost != nil {
+				u.Cost += *row.Cost
+			} else if t.Quota.Cost > 0 {
+				u.Unpriced++
+			}
+		}
+		u.Level, u.Reasons = u.level()
+		usages = append(usages, u)
+	}
+	return usages
+}
+
+func periodName(period string) string {
+	if period == "" {
+		return "month"
+	}
+	return strings.ToLower(period)
+}
+
+// level rates the usage against each limit
+func (u *Usage) level() (string, []string) {
+	warnAt := u.Limits.WarnAt
+	if warnAt == 0 {
+		warnAt = DefaultWarnAt
+	}
+	level := OK
+	var reasons []string
+	check := func(name string, used, limit float64, format string) {
+		if limit <= 0 {
+			return
+		}
+		pct := 100 * used / limit
+		if pct < warnAt {
+			return
+		}
+		if pct >= 100 {
+			level = Exceeded
+		} else if level == OK {
+			level = Warning
+		}
+		reasons = append(reasons, fmt.Sprintf("%s "+format+" of "+format+" (%.0f%%)", name, used, limit, pct))
+	}
+	check("minutes", u.Minutes, u.Limits.Minutes, "%.0f")
+	check("tokens", float64(u.Tokens), float64(u.Limits.Tokens), "%.0f")
+	check("cost", u.Cost, u.Limits.Cost, "%.2f")
+	return level, reasons
+}
+
+// Percent returns the highest share of a limit used, in percent
+func (u *Usage) Percent() float64 {
+	pct := 0.0
+	for _, p := range []float64{
+		share(u.Minutes, u.Limits.Minutes),
+		share(float64(u.Tokens), float64(u.Limits.Tokens)),
+		share(u.Cost, u.Limits.Cost),
+	} {
+		if p > pct {
+			pct = p
+		}
+	}
+	return pct
+}
+
+func share(used, limit float64) float64 {
+	if limit <= 0 {
+		return 0
+	}
+	return 100 * used / limit
+}
+
+// State remembers the alerts sent and the throttles set, so each level
+// is reported once per period and only throttles set here are lifted.
+// It also keeps the usage of every call counted in a current period:
+// container logs rotate, so a period's calls cannot be read back from
+// them alone.
+type State struct {
+	Alerts    map[string]Alert     `json:"alerts,omitempty"`
+	Throttled []string             `json:"throttled,omitempty"`
+	Calls     map[string]CallUsage `json:"calls,omitempty"`
+}
+
+// CallUsage is what one call counts against its tenant's quota
+type CallUsage struct {
+	Tenant          string    `json:"tenant"`
+	Start           time.Time `json:"start"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Tokens          int       `json:"tokens,omitempty"`
+	Cost            *float64  `json:"cost,omitempty"`
+}
+
+// Alert is the highest level reported for a tenant's period
+type Alert struct {
+	PeriodStart time.Time `json:"period_start"`
+	Level       string    `json:"level"`
+}
+
+func statePath() string {
+	return filepath.Join(settings.Dir(), "quota.json")
+}
+
+// LoadState reads the quota state; a missing file is a first run
+func LoadState() (*State, error) {
+	state := &State{Alerts: make(map[string]Alert)}
+	data, err := os.ReadFile(statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return state, nil
+		}
+		return nil, err
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("%s: %w", statePath(), err)
+	}
+	if state.Alerts == nil {
+		state.Alerts = make(map[string]Alert)
+	}
+	if state.Calls == nil {
+		state.Calls = make(map[string]CallUsage)
+	}
+	return state, nil
+}
+
+// RecordCalls stores the usage of the tenants' calls in rows, replacing
+// earlier readings of the same calls, and forgets calls that started
+// before since
+func (s *State) RecordCalls(rows []troubleshoot.CallRow, since time.Time) {
+	if s.Calls == nil {
+		s.Calls = make(map[string]CallUsage)
+	}
+	for _, row := range rows {
+		if row.Tenant == "" || row.CallID == "" {
+			continue
+		}
+		s.Calls[row.CallID] = CallUsage{
+			Tenant:          row.Tenant,
+			Start:           row.Start,
+			DurationSeconds: row.DurationSeconds,
+			Tokens:          row.Tokens,
+			Cost:            row.Cost,
+		}
+	}
+	for id, c := range s.Calls {
+		if c.Start.Before(since) {
+			delete(s.Calls, id)
+		}
+	}
+}
+
+// CallRows returns the recorded calls as rows for Compute
+func (s *State) CallRows() []troubleshoot.CallRow {
+	rows := make([]troubleshoot.CallRow, 0, len(s.Calls))
+	for id, c := range s.Calls {
+		rows = append(rows, troubleshoot.CallRow{
+			CallID:          id,
+			Tenant:          c.Tenant,
+			Start:           c.Start,
+			DurationSeconds: c.DurationSeconds,
+			Tokens:          c.Tokens,
+			Cost:            c.Cost,
+		})
+	}
+	return rows
+}
+
+// Save writes the quota state
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(settings.Dir(), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(statePath(), data, 0600)
+}
+
+// ShouldAlert reports whether the usage reached a level not reported yet
+// in its period, and records it
+func (s *State) ShouldAlert(u Usage) bool {
+	if u.Level == OK {
+		return false
+	}
+	last, ok := s.Alerts[u.Tenant]
+	if ok && last.PeriodStart.Equal(u.Start) && rank(last.Level) >= rank(u.Level) {
+		return false
+	}
+	s.Alerts[u.Tenant] = Alert{PeriodStart: u.Start, Level: u.Level}
+	return true
+}
+
+func rank(level string) int {
+	switch level {
+	case Warning:
+		return 1
+	case Exceeded:
+		return 2
+	}
+	return 0
+}
+
+// IsThrottled reports whether a throttle was set for the tenant
+func (s *State) IsThrottled(tenant string) bool {
+	for _, t := range s.Throttled {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+// SetThrottled records whether a throttle is set for the tenant
+func (s *State) SetThrottled(tenant string, on bool) {
+	kept := s.Throttled[:0]
+	for _, t := range s.Throttled {
+		if t != tenant {
+			kept = append(kept, t)
+		}
+	}
+	if on {
+		kept = append(kept, tenant)
+	}
+	s.Throttled = kept
+}
@@ -0,0 +1,280 @@
+package recordings
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// DefaultDirs are where Asterisk writes recordings: ARI stored
+// recordings (the engine's diagnostic recordings) and MixMonitor
+// (FreePBX call recording), which nests them by date
+var DefaultDirs = []string{
+	"/var/spool/asterisk/recording",
+	"/var/spool/asterisk/monitor",
+}
+
+// Formats are the export formats; "" keeps the original file
+var Formats = []string{"wav", "mp3", "opus"}
+
+var audioExtensions = map[string]bool{
+	".wav": true, ".wav49": true, ".gsm": true, ".mp3": true,
+	".ogg": true, ".opus": true, ".sln": true, ".sln16": true,
+	".ulaw": true, ".alaw": true, ".g722": true,
+}
+
+// callIDPattern matches an Asterisk uniqueid/linkedid (the engine's call
+// ID) inside a file name
+var callIDPattern = regexp.MustCompile(`\d{9,11}\.\d+`)
+
+// Recording is one audio file
+type Recording struct {
+	Path    string
+	CallID  string
+	Size    int64
+	ModTime time.Time
+	// Duration is zero when the format has no readable length
+	Duration time.Duration
+}
+
+// Filter selects recordings; zero fields match everything
+type Filter struct {
+	CallID string
+	// Since and Before bound the modification time
+	Since  time.Time
+	Before time.Time
+}
+
+func (f Filter) match(r *Recording) bool {
+	if f.CallID != "" && r.CallID != f.CallID && !namesCall(filepath.Base(r.Path), f.CallID) {
+		return false
+	}
+	if !f.Since.IsZero() && r.ModTime.Before(f.Since) {
+		return false
+	}
+	if !f.Before.IsZero() && !r.ModTime.Before(f.Before) {
+		return false
+	}
+	return true
+}
+
+// namesCall reports whether name holds callID as a whole ID, not as the
+// prefix of a longer one: 1763582071.62 is not 1763582071.621
+func namesCall(name, callID string) bool {
+	for i := 0; ; {
+		j := strings.Index(name[i:], callID)
+		if j < 0 {
+			return false
+		}
+		start, end := i+j, i+j+len(callID)
+		if (start == 0 || !isDigit(name[start-1])) && (end == len(name) || !isDigit(name[end])) {
+			return true
+		}
+		i = start + 1
+	}
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Find walks dirs for recordings matching f, oldest first. Missing
+// directories are skipped.
+func Find(dirs []string, f Filter) ([]*Recording, error) {
+	var out []*Recording
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				if os.IsNotExist(err) && path == dir {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			if info.IsDir() || !audioExtensions[strings.ToLower(filepath.Ext(path))] {
+				return nil
+			}
+			r := &Recording{
+				Path:    path,
+				CallID:  callIDPattern.FindString(filepath.Base(path)),
+				Size:    info.Size(),
+				ModTime: info.ModTime(),
+			}
+			if !f.match(r) {
+				return nil
+			}
+			r.Duration = duration(path, info.Size())
+			out = append(out, r)
+			return nil
+		})
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ModTime.Before(out[j].ModTime) })
+	return out, nil
+}
+
+// duration reads the length of WAV files from their header; raw
+// formats are derived from the size
+func duration(path string, size int64) time.Duration {
+	perSecond := int64(0)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav":
+		return wavDuration(path)
+	case ".gsm":
+		perSecond = 1650
+	case ".wav49":
+		perSecond = 1625
+	case ".ulaw", ".alaw":
+		perSecond = 8000
+	case ".sln":
+		perSecond = 16000
+	case ".sln16", ".g722":
+		perSecond = 32000
+	}
+	if perSecond == 0 {
+		return 0
+	}
+	return time.Duration(size) * time.Second / time.Duration(perSecond)
+}
+
+// wavDuration walks the RIFF chunks for the byte rate and data size
+func wavDuration(path string) time.Duration {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0
+	}
+	defer f.Close()
+
+	var riff [12]byte
+	if _, err := io.ReadFull(f, riff[:]); err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return 0
+	}
+	var byteRate uint32
+	for {
+		var header [8]byte
+		if _, err := io.ReadFull(f, header[:]); err != nil {
+			return 0
+		}
+		id := string(header[0:4])
+		size := binary.LittleEndian.Uint32(header[4:8])
+		switch id {
+		case "fmt ":
+			var fmtChunk [16]byte
+			if size < 16 {
+				return 0
+			}
+			if _, err := io.ReadFull(f, fmtChunk[:]); err != nil {
+				return 0
+			}
+			byteRate = binary.LittleEndian.Uint32(fmtChunk[8:12])
+			size -= 16
+		case "data":
+			if byteRate == 0 {
+				return 0
+			}
+			// Recordings still being written carry a 0 or oversized length
+			if fi, err := f.Stat(); err == nil {
+				pos, _ := f.Seek(0, io.SeekCurrent)
+				if rest := fi.Size() - pos; size == 0 || int64(size) > rest {
+					size = uint32(rest)
+				}
+			}
+			return time.Duration(size) * time.Second / time.Duration(byteRate)
+		}
+		// Chunks are padded to an even size
+		if _, err := f.Seek(int64(size+size%2), io.SeekCurrent); err != nil {
+			return 0
+		}
+	}
+}
+
+// Export copies r into dir, transcoding with ffmpeg when format differs
+// from the file's own. It returns the written path.
+func Export(ctx context.Context, r *Recording, dir, format string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(r.Path))
+	base := strings.TrimSuffix(filepath.Base(r.Path), filepath.Ext(r.Path))
+	if format == "" || "."+format == ext {
+		dest := filepath.Join(dir, filepath.Base(r.Path))
+		return dest, copyFile(r.Path, dest, r.ModTime)
+	}
+
+	var codec []string
+	switch format {
+	case "mp3":
+		codec = []string{"-codec:a", "libmp3lame", "-q:a", "4"}
+	case "opus":
+		codec = []string{"-codec:a", "libopus", "-b:a", "24k"}
+	case "wav":
+		codec = []string{"-codec:a", "pcm_s16le"}
+	default:
+		return "", fmt.Errorf("unsupported format %q (use %s)", format, strings.Join(Formats, ", "))
+	}
+	if _, err := exec.LookPath("ffmpeg"); err != nil {
+		return "", fmt.Errorf("transcoding to %s needs ffmpeg in PATH", format)
+	}
+	dest := filepath.Join(dir, base+"."+format)
+	args := append([]string{"-hide_banner", "-loglevel", "error", "-y", "-i", r.Path}, codec...)
+	args = append(args, dest)
+	if out, err := selflog.CombinedOutput(exec.CommandContext(ctx, "ffmpeg", args...)); err != nil {
+		return "", fmt.Errorf("ffmpeg %s: %v: %s", filepath.Base(r.Path), err, strings.TrimSpace(string(out)))
+	}
+	os.Chtimes(dest, r.ModTime, r.ModTime)
+	return dest, nil
+}
+
+func copyFile(src, dest string, modTime time.Time) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0640)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dest, modTime, modTime)
+}
+
+// Remove deletes the recordings and then any date directories (such as
+// monitor/2024/01/31) left empty under dirs
+func Remove(recs []*Recording, dirs []string) error {
+	parents := make(map[string]bool)
+	for _, r := range recs {
+		if err := os.Remove(r.Path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		parents[filepath.Dir(r.Path)] = true
+	}
+	roots := make(map[string]bool)
+	for _, dir := range dirs {
+		roots[filepath.Clean(dir)] = true
+	}
+	for dir := range parents {
+		for !roots[dir] && dir != "/" && dir != "." {
+			// Fails, and stops, at the first non-empty directory
+			if os.Remove(dir) != nil {
+				break
+			}
+			dir = filepath.Dir(dir)
+		}
+	}
+	return nil
+}
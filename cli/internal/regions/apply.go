@@ -0,0 +1,104 @@
+package regions
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// edit is one line change of the config: a value replaced on its line,
+// or a key inserted after the provider's line
+type edit struct {
+	line   int
+	insert string
+	old    string
+	value  string
+}
+
+// Apply writes the changes of the recommendations that switch region
+// into config/ai-agent.yaml under dir, keeping the rest of the file as
+// it is. Values taken from .env are left for the operator and returned
+// as notes.
+func Apply(dir string, recs []Recommendation) (changed int, notes []string, err error) {
+	path := filepath.Join(dir, "config", "ai-agent.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0, nil, err
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return 0, nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if len(root.Content) == 0 {
+		return 0, nil, fmt.Errorf("%s: empty config", path)
+	}
+	providers := mapValue(root.Content[0], "providers")
+
+	var edits []edit
+	for _, rec := range recs {
+		if !rec.Switch {
+			continue
+		}
+		key, block := mapEntry(providers, rec.Provider.Name)
+		if block == nil || block.Kind != yaml.MappingNode {
+			continue
+		}
+		indent := strings.Repeat(" ", key.Column+1)
+		if len(block.Content) > 0 {
+			indent = strings.Repeat(" ", block.Content[0].Column-1)
+		}
+		for _, c := range rec.Changes {
+			if c.EnvVar != "" {
+				notes = append(notes, fmt.Sprintf("%s.%s comes from .env: set %s=%s", rec.Provider.Name, c.Key, c.EnvVar, c.To))
+				continue
+			}
+			if v := mapValue(block, c.Key); v != nil && v.Kind == yaml.ScalarNode {
+				edits = append(edits, edit{line: v.Line, old: v.Value, value: c.To})
+			} else {
+				edits = append(edits, edit{line: key.Line, insert: indent + c.Key + ": " + c.To})
+			}
+			changed++
+		}
+	}
+	if len(edits) == 0 {
+		return 0, notes, nil
+	}
+
+	lines := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	// Bottom up, so the line numbers above stay valid
+	sort.SliceStable(edits, func(i, j int) bool { return edits[i].line > edits[j].line })
+	for _, e := range edits {
+		i := e.line - 1
+		if e.insert != "" {
+			lines = append(lines[:i+1], append([]string{e.insert}, lines[i+1:]...)...)
+			continue
+		}
+		lines[i] = strings.Replace(lines[i], e.old, e.value, 1)
+	}
+	return changed, notes, os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), info.Mode().Perm())
+}
+
+func mapEntry(m *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i], m.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+func mapValue(m *yaml.Node, key string) *yaml.Node {
+	_, v := mapEntry(m, key)
+	return v
+}
@@ -0,0 +1,204 @@
+package regions
+
+import (
+	"context"
+	"net"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MinGain is how much closer a region must be before it is recommended;
+// smaller differences are within the jitter of a few probes
+const MinGain = 20 * time.Millisecond
+
+// Latency is the measured round trip to one host
+type Latency struct {
+	Host  string        `json:"host"`
+	RTT   time.Duration `json:"-"`
+	RTTMs float64       `json:"rtt_ms"`
+	Err   string        `json:"error,omitempty"`
+}
+
+// Dial opens the probe connections
+var Dial = func(ctx context.Context, address string) (net.Conn, error) {
+	var d net.Dialer
+	return d.DialContext(ctx, "tcp", address)
+}
+
+// Measure times TCP connects to port 443 of every host, samples times
+// each, concurrently, and keeps the median: one round trip without the
+// TLS handshake, which costs the same per round trip in every region
+func Measure(ctx context.Context, hosts []string, samples int) map[string]Latency {
+	if samples < 1 {
+		samples = 1
+	}
+	out := make(map[string]Latency, len(hosts))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, host := range hosts {
+		if _, done := out[host]; done {
+			continue
+		}
+		out[host] = Latency{Host: host}
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+			l := probe(ctx, host, samples)
+			mu.Lock()
+			out[host] = l
+			mu.Unlock()
+		}(host)
+	}
+	wg.Wait()
+	return out
+}
+
+func probe(ctx context.Context, host string, samples int) Latency {
+	var rtts []time.Duration
+	var lastErr error
+	for i := 0; i < samples; i++ {
+		attempt, cancel := context.WithTimeout(ctx, 5*time.Second)
+		start := time.Now()
+		conn, err := Dial(attempt, net.JoinHostPort(host, "443"))
+		elapsed := time.Since(start)
+		cancel()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		conn.Close()
+		rtts = append(rtts, elapsed)
+	}
+	if len(rtts) == 0 {
+		return Latency{Host: host, Err: lastErr.Error()}
+	}
+	sort.Slice(rtts, func(i, j int) bool { return rtts[i] < rtts[j] })
+	rtt := rtts[len(rtts)/2]
+	return Latency{Host: host, RTT: rtt, RTTMs: milliseconds(rtt)}
+}
+
+// Hosts returns every host the providers' regions would use, to measure
+func Hosts(providers []Provider) []string {
+	seen := make(map[string]bool)
+	var out []string
+	for _, p := range providers {
+		f := family(p.Family)
+		if f == nil || p.Unsupported != "" {
+			continue
+		}
+		for _, r := range f.Regions {
+			hosts, _ := p.hosts(f, r)
+			for _, h := range hosts {
+				if !seen[h] {
+					seen[h] = true
+					out = append(out, h)
+				}
+			}
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// RegionLatency is a provider's round trip in one region: its slowest
+// host's
+type RegionLatency struct {
+	Region   string        `json:"region"`
+	Where    string        `json:"where"`
+	RTT      time.Duration `json:"-"`
+	RTTMs    float64       `json:"rtt_ms"`
+	Err      string        `json:"error,omitempty"`
+	Requires string        `json:"requires,omitempty"`
+}
+
+// Change is a setting to move to another region
+type Change struct {
+	Key  string `json:"key"`
+	From string `json:"from"`
+	To   string `json:"to"`
+	// EnvVar is set when the value comes from .env, which is where it
+	// has to change
+	EnvVar string `json:"env_var,omitempty"`
+}
+
+// Recommendation is the closest region of one provider
+type Recommendation struct {
+	Provider Provider        `json:"provider"`
+	Current  string          `json:"current_region"`
+	Regions  []RegionLatency `json:"regions,omitempty"`
+	Best     string          `json:"closest_region,omitempty"`
+	// Switch is set when the closest region beats the current one by
+	// MinGain; Changes are the settings that move it there
+	Switch   bool     `json:"switch"`
+	Gain     float64  `json:"gain_ms,omitempty"`
+	Changes  []Change `json:"changes,omitempty"`
+	Requires string   `json:"requires,omitempty"`
+}
+
+// Recommend picks the closest region of every provider from the
+// measured latencies
+func Recommend(providers []Provider, latencies map[string]Latency) []Recommendation {
+	var out []Recommendation
+	for _, p := range providers {
+		rec := Recommendation{Provider: p, Current: p.Current()}
+		f := family(p.Family)
+		if f == nil || p.Unsupported != "" {
+			out = append(out, rec)
+			continue
+		}
+		var best *RegionLatency
+		var current *RegionLatency
+		for _, r := range f.Regions {
+			hosts, ok := p.hosts(f, r)
+			if !ok {
+				continue
+			}
+			rl := RegionLatency{Region: r.Name, Where: r.Where, Requires: r.Requires}
+			for _, h := range hosts {
+				l := latencies[h]
+				if l.Err != "" {
+					rl.Err = h + ": " + l.Err
+					break
+				}
+				if l.RTT > rl.RTT {
+					rl.RTT, rl.RTTMs = l.RTT, l.RTTMs
+				}
+			}
+			rec.Regions = append(rec.Regions, rl)
+		}
+		for i := range rec.Regions {
+			rl := &rec.Regions[i]
+			if rl.Region == rec.Current {
+				current = rl
+			}
+			if rl.Err == "" && rl.RTT > 0 && (best == nil || rl.RTT < best.RTT) {
+				best = rl
+			}
+		}
+		if best == nil {
+			out = append(out, rec)
+			continue
+		}
+		rec.Best = best.Region
+		if current != nil && current.Err == "" && best.Region != current.Region && current.RTT-best.RTT >= MinGain {
+			rec.Switch = true
+			rec.Gain = milliseconds(current.RTT - best.RTT)
+			rec.Requires = best.Requires
+			for _, r := range f.Regions {
+				if r.Name != best.Region {
+					continue
+				}
+				for _, s := range p.Settings {
+					rec.Changes = append(rec.Changes, Change{Key: s.Key, From: s.URL, To: rewrite(f, r, s.URL), EnvVar: s.EnvVar})
+				}
+			}
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
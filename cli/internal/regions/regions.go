@@ -0,0 +1,340 @@
+// Package regions measures the latency from the deployment to the
+// regional endpoints of the cloud providers in the engine config and
+// recommends, or writes into config/ai-agent.yaml, the closest region
+// per provider. Providers default to their US endpoints, so a European
+// deployment pays a transatlantic round trip on every turn unless the
+// config names the EU ones.
+package regions
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/deploy"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"gopkg.in/yaml.v3"
+)
+
+// Region is one regional deployment of a provider's API
+type Region struct {
+	Name  string `json:"name"`
+	Where string `json:"where"`
+	// Hosts maps the provider's default hosts to the region's; hosts
+	// missing here have no endpoint in the region
+	Hosts map[string]string `json:"-"`
+	// Requires is what the account needs before the region answers
+	Requires string `json:"requires,omitempty"`
+}
+
+// Family is a provider API with its regions, the default region first
+type Family struct {
+	Name    string
+	Regions []Region
+}
+
+// Families are the provider APIs with regional endpoints
+var Families = []Family{
+	{Name: "deepgram", Regions: []Region{
+		{Name: "us", Where: "United States", Hosts: map[string]string{
+			"api.deepgram.com":   "api.deepgram.com",
+			"agent.deepgram.com": "agent.deepgram.com",
+		}},
+		{Name: "eu", Where: "European Union", Hosts: map[string]string{
+			"api.deepgram.com": "api.eu.deepgram.com",
+		}, Requires: "a Deepgram project with EU processing"},
+	}},
+	{Name: "openai", Regions: []Region{
+		{Name: "us", Where: "United States", Hosts: map[string]string{
+			"api.openai.com": "api.openai.com",
+		}},
+		{Name: "eu", Where: "European Union", Hosts: map[string]string{
+			"api.openai.com": "eu.api.openai.com",
+		}, Requires: "an OpenAI project with EU data residency and its API key"},
+	}},
+	{Name: "google", Regions: []Region{
+		{Name: "global", Where: "nearest Google region", Hosts: map[string]string{
+			"speech.googleapis.com":             "speech.googleapis.com",
+			"texttospeech.googleapis.com":       "texttospeech.googleapis.com",
+			"generativelanguage.googleapis.com": "generativelanguage.googleapis.com",
+		}},
+		{Name: "us", Where: "United States", Hosts: map[string]string{
+			"speech.googleapis.com":             "us-speech.googleapis.com",
+			"texttospeech.googleapis.com":       "us-texttospeech.googleapis.com",
+			"generativelanguage.googleapis.com": "generativelanguage.googleapis.com",
+		}},
+		{Name: "eu", Where: "European Union", Hosts: map[string]string{
+			"speech.googleapis.com":             "eu-speech.googleapis.com",
+			"texttospeech.googleapis.com":       "eu-texttospeech.googleapis.com",
+			"generativelanguage.googleapis.com": "generativelanguage.googleapis.com",
+		}},
+	}},
+	{Name: "elevenlabs", Regions: []Region{
+		{Name: "global", Where: "United States", Hosts: map[string]string{
+			"api.elevenlabs.io": "api.elevenlabs.io",
+		}},
+		{Name: "eu", Where: "European Union", Hosts: map[string]string{
+			"api.elevenlabs.io": "api.eu.residency.elevenlabs.io",
+		}, Requires: "an ElevenLabs workspace with EU data residency"},
+		{Name: "in", Where: "India", Hosts: map[string]string{
+			"api.elevenlabs.io": "api.in.residency.elevenlabs.io",
+		}, Requires: "an ElevenLabs workspace with India data residency"},
+	}},
+}
+
+// family returns the family by name
+func family(name string) *Family {
+	for i := range Families {
+		if Families[i].Name == name {
+			return &Families[i]
+		}
+	}
+	return nil
+}
+
+// Setting is one endpoint setting of a provider
+type Setting struct {
+	Key string `json:"key"`
+	// URL is the endpoint the engine uses: the configured value with
+	// .env references resolved, or the engine's default
+	URL string `json:"url"`
+	// Default is set when the config leaves the key to the engine
+	Default bool `json:"default,omitempty"`
+	// EnvVar names the .env variable the value comes from
+	EnvVar string `json:"env_var,omitempty"`
+}
+
+// Host is the setting's host name
+func (s Setting) Host() string {
+	u, err := url.Parse(s.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(u.Hostname())
+}
+
+// Provider is a cloud provider of the config with regional endpoints
+type Provider struct {
+	Name     string    `json:"name"`
+	Family   string    `json:"family"`
+	InUse    bool      `json:"in_use"`
+	Settings []Setting `json:"settings"`
+	// Unsupported says why the provider's region cannot be chosen
+	Unsupported string `json:"unsupported,omitempty"`
+}
+
+// engineDefaults are the URLs the engine uses for unset keys
+var engineDefaults = map[string]string{
+	"deepgram.base_url":             "https://api.deepgram.com",
+	"deepgram.voice_agent_base_url": "wss://agent.deepgram.com/v1/agent/converse",
+	"openai.base_url":               "wss://api.openai.com/v1/realtime",
+	"openai.realtime_base_url":      "wss://api.openai.com/v1/realtime",
+	"openai.chat_base_url":          "https://api.openai.com/v1",
+	"openai.tts_base_url":           "https://api.openai.com/v1/audio/speech",
+	"google.stt_base_url":           "https://speech.googleapis.com/v1",
+	"google.tts_base_url":           "https://texttospeech.googleapis.com/v1",
+	"google.llm_base_url":           "https://generativelanguage.googleapis.com/v1",
+	"elevenlabs.base_url":           "https://api.elevenlabs.io/v1",
+}
+
+// Providers returns the enabled providers of the config whose API has
+// regions, with the endpoint settings the engine reads for them
+func Providers(p *deploy.Profile) ([]Provider, error) {
+	var cfg struct {
+		Providers map[string]yaml.Node `yaml:"providers"`
+	}
+	if err := yaml.Unmarshal(p.Config, &cfg); err != nil {
+		return nil, err
+	}
+	inUse := make(map[string]bool)
+	for _, name := range p.Providers {
+		inUse[name] = true
+	}
+	var out []Provider
+	for name, node := range cfg.Providers {
+		var block map[string]interface{}
+		if err := node.Decode(&block); err != nil {
+			continue
+		}
+		if enabled, ok := block["enabled"].(bool); ok && !enabled {
+			continue
+		}
+		prov, ok := classify(name, block)
+		if !ok {
+			continue
+		}
+		prov.InUse = inUse[name]
+		for i, s := range prov.Settings {
+			raw, set := block[s.Key].(string)
+			if !set || strings.TrimSpace(raw) == "" {
+				prov.Settings[i].URL = engineDefaults[prov.Family+"."+s.Key]
+				prov.Settings[i].Default = true
+				continue
+			}
+			prov.Settings[i].EnvVar, _ = health.EnvRef(raw)
+			prov.Settings[i].URL = health.ExpandEnv(raw, p.Env, p.Secrets)
+		}
+		out = append(out, prov)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].InUse != out[j].InUse {
+			return out[i].InUse
+		}
+		return out[i].Name < out[j].Name
+	})
+	return out, nil
+}
+
+// classify finds a provider's family and the endpoint keys the engine
+// reads for its type
+func classify(name string, block map[string]interface{}) (Provider, bool) {
+	kind, _ := block["type"].(string)
+	lower := strings.ToLower(name)
+	has := func(capability string) bool {
+		caps, ok := block["capabilities"].([]interface{})
+		if !ok {
+			return true
+		}
+		for _, c := range caps {
+			if c == capability {
+				return true
+			}
+		}
+		return false
+	}
+	settings := func(keys ...string) []Setting {
+		out := make([]Setting, len(keys))
+		for i, k := range keys {
+			out[i] = Setting{Key: k}
+		}
+		return out
+	}
+	switch {
+	case kind == "local" || kind == "ollama" || strings.HasPrefix(lower, "local"):
+		return Provider{}, false
+	case strings.Contains(lower, "deepgram") || kind == "deepgram":
+		p := Provider{Name: name, Family: "deepgram"}
+		if kind == "full" {
+			p.Settings = settings("voice_agent_base_url")
+			p.Unsupported = "the Voice Agent API has no regional endpoint"
+		} else {
+			p.Settings = settings("base_url")
+		}
+		return p, true
+	case kind == "openai_realtime":
+		return Provider{Name: name, Family: "openai", Settings: settings("base_url")}, true
+	case kind == "openai" || strings.Contains(lower, "openai"):
+		if base, _ := block["chat_base_url"].(string); base != "" && !strings.Contains(base, "openai.com") {
+			// An OpenAI-compatible API elsewhere (Groq, ...)
+			return Provider{}, false
+		}
+		var keys []string
+		if has("llm") {
+			keys = append(keys, "chat_base_url")
+		}
+		if has("tts") {
+			keys = append(keys, "tts_base_url")
+		}
+		if has("stt") {
+			keys = append(keys, "realtime_base_url")
+		}
+		return Provider{Name: name, Family: "openai", Settings: settings(keys...)}, true
+	case strings.Contains(lower, "google_live") || strings.Contains(lower, "gemini"):
+		return Provider{Name: name, Family: "google", Unsupported: "the Gemini Live API is global, with no regional endpoint"}, true
+	case kind == "google" || strings.Contains(lower, "google"):
+		var keys []string
+		if has("stt") {
+			keys = append(keys, "stt_base_url")
+		}
+		if has("tts") {
+			keys = append(keys, "tts_base_url")
+		}
+		if has("llm") {
+			keys = append(keys, "llm_base_url")
+		}
+		return Provider{Name: name, Family: "google", Settings: settings(keys...)}, true
+	case strings.Contains(lower, "elevenlabs") || kind == "elevenlabs":
+		if kind == "full" || strings.Contains(lower, "agent") {
+			return Provider{Name: name, Family: "elevenlabs", Unsupported: "the engine connects ElevenLabs agents to api.elevenlabs.io"}, true
+		}
+		return Provider{Name: name, Family: "elevenlabs", Settings: settings("base_url")}, true
+	}
+	return Provider{}, false
+}
+
+
+// Current returns the region the provider's settings point at, "" when
+// they point elsewhere (a proxy) or at different regions
+func (p Provider) Current() string {
+	f := family(p.Family)
+	if f == nil || len(p.Settings) == 0 {
+		return ""
+	}
+	for _, r := range f.Regions {
+		all := true
+		for _, s := range p.Settings {
+			if !r.covers(s.Host()) {
+				all = false
+				break
+			}
+		}
+		if all {
+			return r.Name
+		}
+	}
+	return ""
+}
+
+// covers reports whether host is one of the region's hosts
+func (r Region) covers(host string) bool {
+	for _, h := range r.Hosts {
+		if h == host {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultHost returns the default host whose counterpart in some region
+// of f is host
+func (f *Family) defaultHost(host string) string {
+	for _, r := range f.Regions {
+		for def, h := range r.Hosts {
+			if h == host {
+				return def
+			}
+		}
+	}
+	return ""
+}
+
+// hosts returns the hosts the provider would use in region r; false
+// when one of its settings has no endpoint there
+func (p Provider) hosts(f *Family, r Region) ([]string, bool) {
+	var out []string
+	for _, s := range p.Settings {
+		h, ok := r.Hosts[f.defaultHost(s.Host())]
+		if !ok {
+			return nil, false
+		}
+		out = append(out, h)
+	}
+	return out, len(out) > 0
+}
+
+// rewrite returns u with its host moved to region r
+func rewrite(f *Family, r Region, u string) string {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return u
+	}
+	h, ok := r.Hosts[f.defaultHost(strings.ToLower(parsed.Hostname()))]
+	if !ok {
+		return u
+	}
+	if port := parsed.Port(); port != "" {
+		h += ":" + port
+	}
+	parsed.Host = h
+	return parsed.String()
+}
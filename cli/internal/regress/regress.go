@@ -0,0 +1,188 @@
+package regress
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/engineclient"
+	"gopkg.in/yaml.v3"
+)
+
+// Files of a case directory
+const (
+	caseFile  = "case.yaml"
+	audioFile = "caller.wav"
+)
+
+// namePattern restricts case names to safe directory names
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// Case is a recorded call with the outcome the pipeline should reach
+// when its caller audio is replayed
+type Case struct {
+	Name   string    `yaml:"-"`
+	CallID string    `yaml:"call_id,omitempty"`
+	Note   string    `yaml:"note,omitempty"`
+	Added  time.Time `yaml:"added"`
+	// Pipeline and Context are replayed through; empty uses the
+	// engine's defaults
+	Pipeline string `yaml:"pipeline,omitempty"`
+	Context  string `yaml:"context,omitempty"`
+	Expect   Expect `yaml:"expect"`
+}
+
+// Expect is what a replay must show; zero fields are not checked
+type Expect struct {
+	// Phrases must each appear in some agent response (case-insensitive)
+	Phrases []string `yaml:"phrases,omitempty"`
+	// Forbidden phrases must not appear in any agent response
+	Forbidden []string `yaml:"forbidden,omitempty"`
+	// Tools must each be called, e.g. transfer or hangup_call
+	Tools []string `yaml:"tools,omitempty"`
+	// MaxLatencyMs bounds every turn's latency, utterance end to first
+	// TTS audio
+	MaxLatencyMs int64 `yaml:"max_latency_ms,omitempty"`
+	// MinTurns is the least number of answered turns
+	MinTurns int `yaml:"min_turns,omitempty"`
+}
+
+// Result is the outcome of running one case
+type Result struct {
+	Case     string               `json:"case"`
+	Passed   bool                 `json:"passed"`
+	Failures []string             `json:"failures,omitempty"`
+	Error    string               `json:"error,omitempty"`
+	Replay   *engineclient.Replay `json:"replay,omitempty"`
+}
+
+// Dir returns the directory holding the case library, one directory per
+// case
+func Dir() string {
+	return filepath.Join(settings.Dir(), "regress")
+}
+
+// ValidName reports whether name can name a case
+func ValidName(name string) bool {
+	return namePattern.MatchString(name)
+}
+
+// Save writes a case and its caller audio, replacing an existing case
+// of the same name
+func Save(c *Case, audio []byte) error {
+	if !ValidName(c.Name) {
+		return fmt.Errorf("invalid case name %q (letters, digits, '.', '_' and '-')", c.Name)
+	}
+	dir := filepath.Join(Dir(), c.Name)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, caseFile), data, 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, audioFile), audio, 0600)
+}
+
+// Load reads a case by name
+func Load(name string) (*Case, error) {
+	if !ValidName(name) {
+		return nil, fmt.Errorf("invalid case name %q", name)
+	}
+	data, err := os.ReadFile(filepath.Join(Dir(), name, caseFile))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("case %s not found (see 'agent regress list')", name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c := &Case{}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	c.Name = name
+	return c, nil
+}
+
+// List returns every case, by name
+func List() ([]*Case, error) {
+	matches, err := filepath.Glob(filepath.Join(Dir(), "*", caseFile))
+	if err != nil {
+		return nil, err
+	}
+	var cases []*Case
+	for _, path := range matches {
+		c, err := Load(filepath.Base(filepath.Dir(path)))
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, c)
+	}
+	sort.Slice(cases, func(i, j int) bool { return cases[i].Name < cases[j].Name })
+	return cases, nil
+}
+
+// Audio reads the case's caller audio
+func (c *Case) Audio() ([]byte, error) {
+	return os.ReadFile(filepath.Join(Dir(), c.Name, audioFile))
+}
+
+// Remove deletes a case
+func Remove(name string) error {
+	if _, err := Load(name); err != nil {
+		return err
+	}
+	return os.RemoveAll(filepath.Join(Dir(), name))
+}
+
+// Evaluate checks a replay against the case's expectations
+func (c *Case) Evaluate(replay *engineclient.Replay) *Result {
+	r := &Result{Case: c.Name, Replay: replay}
+	var responses []string
+	tools := make(map[string]bool)
+	answered := 0
+	for i, turn := range replay.Turns {
+		if turn.Error != "" {
+			r.Failures = append(r.Failures, fmt.Sprintf("turn %d: %s", i+1, turn.Error))
+		}
+		if turn.Response != "" || len(turn.ToolCalls) > 0 {
+			answered++
+		}
+		responses = append(responses, strings.ToLower(turn.Response))
+		for _, tool := range turn.ToolCalls {
+			tools[tool] = true
+		}
+		if latency := turn.Latency(); c.Expect.MaxLatencyMs > 0 && latency.Milliseconds() > c.Expect.MaxLatencyMs {
+			r.Failures = append(r.Failures, fmt.Sprintf("turn %d latency %dms over the %dms budget", i+1, latency.Milliseconds(), c.Expect.MaxLatencyMs))
+		}
+	}
+	said := strings.Join(responses, "\n")
+	for _, phrase := range c.Expect.Phrases {
+		if !strings.Contains(said, strings.ToLower(phrase)) {
+			r.Failures = append(r.Failures, fmt.Sprintf("no response says %q", phrase))
+		}
+	}
+	for _, phrase := range c.Expect.Forbidden {
+		if strings.Contains(said, strings.ToLower(phrase)) {
+			r.Failures = append(r.Failures, fmt.Sprintf("a response says %q", phrase))
+		}
+	}
+	for _, tool := range c.Expect.Tools {
+		if !tools[tool] {
+			r.Failures = append(r.Failures, fmt.Sprintf("tool %s not called", tool))
+		}
+	}
+	if answered < c.Expect.MinTurns {
+		r.Failures = append(r.Failures, fmt.Sprintf("%d answered turn(s), expected at least %d", answered, c.Expect.MinTurns))
+	}
+	r.Passed = len(r.Failures) == 0
+	return r
+}
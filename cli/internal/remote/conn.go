@@ -0,0 +1,161 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// DialStdio connects to the docker daemon of t through
+// 'docker system dial-stdio' over SSH, the way the docker CLI does for
+// ssh:// hosts. The connection lives until it is closed, not bound to
+// the context of the request that opened it.
+func DialStdio(t *Target) (net.Conn, error) {
+	return startConn(t, exec.Command("ssh", append(t.SSHArgs(), "--", "docker", "system", "dial-stdio")...))
+}
+
+// Dial connects like a net.Dialer, except that with a remote host its
+// loopback addresses are reached through the host (ssh -W): the engine
+// health API, ARI and AMI listen on 127.0.0.1 there, not on the
+// workstation
+func Dial(ctx context.Context, network, addr string) (net.Conn, error) {
+	if t := current; t != nil && strings.HasPrefix(network, "tcp") && isLoopback(addr) {
+		args := t.SSHArgs()
+		dest := args[len(args)-1]
+		args = append(append(args[:len(args)-1:len(args)-1], "-W", addr), dest)
+		return startConn(t, exec.Command("ssh", args...))
+	}
+	d := net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	return d.DialContext(ctx, network, addr)
+}
+
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// startConn starts cmd and returns a connection over its stdin and stdout
+func startConn(t *Target, cmd *exec.Cmd) (net.Conn, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	c := &cmdConn{cmd: cmd, stdin: stdin, stdout: stdout, host: t.Host}
+	cmd.Stderr = &c.stderr
+	if err := selflog.Start(cmd); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// cmdConn is a connection over the stdin and stdout of a command
+type cmdConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	stderr    lockedBuffer
+	host      string
+	closeOnce sync.Once
+
+	mu       sync.Mutex
+	deadline *time.Timer
+}
+
+// lockedBuffer collects the command's stderr while the connection reads
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.buf.Len() < 4096 {
+		b.buf.Write(p)
+	}
+	return len(p), nil
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.TrimSpace(b.buf.String())
+}
+
+// Read reads from the command's output. When ssh exits early (host key
+// or auth failures) its message is returned instead of a bare EOF.
+func (c *cmdConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if err == io.EOF && n == 0 {
+		if msg := c.stderr.String(); msg != "" {
+			return 0, fmt.Errorf("ssh %s: %s", c.host, msg)
+		}
+	}
+	return n, err
+}
+
+func (c *cmdConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+// CloseWrite ends the input, e.g. of a hijacked exec session
+func (c *cmdConn) CloseWrite() error {
+	return c.stdin.Close()
+}
+
+func (c *cmdConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		if c.cmd.Process != nil {
+			c.cmd.Process.Kill()
+		}
+		c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *cmdConn) LocalAddr() net.Addr  { return sshAddr("local") }
+func (c *cmdConn) RemoteAddr() net.Addr { return sshAddr(c.host) }
+
+// SetDeadline closes the connection when t passes, since pipes have no
+// deadlines of their own; the zero time clears it
+func (c *cmdConn) SetDeadline(t time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.deadline != nil {
+		c.deadline.Stop()
+		c.deadline = nil
+	}
+	if !t.IsZero() {
+		c.deadline = time.AfterFunc(time.Until(t), func() { c.Close() })
+	}
+	return nil
+}
+
+func (c *cmdConn) SetReadDeadline(t time.Time) error  { return c.SetDeadline(t) }
+func (c *cmdConn) SetWriteDeadline(t time.Time) error { return c.SetDeadline(t) }
+
+// sshAddr is the address of one end of an SSH connection
+type sshAddr string
+
+func (a sshAddr) Network() string { return "ssh" }
+func (a sshAddr) String() string  { return string(a) }
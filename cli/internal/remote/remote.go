@@ -0,0 +1,296 @@
+// Package remote lets the CLI run on a workstation (Windows, macOS or
+// Linux) against the Linux host the stack runs on. With a target set,
+// the host commands the CLI inspects the PBX with (asterisk -rx,
+// journalctl, iptables, ...) run over SSH and project files are read
+// from the host, while state and reports stay in the local ~/.agent.
+//
+// SSH goes through the system OpenSSH client (built into Windows 10+
+// and macOS), so keys, the ssh-agent and ~/.ssh/config work as they do
+// for ssh itself. Password prompts are disabled: use key auth.
+package remote
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// DefaultDir is the project directory on the host when none is set
+const DefaultDir = "/opt/asterisk-ai-voice-agent"
+
+// Target is a host reached over SSH
+type Target struct {
+	User string
+	Host string
+	Port string
+	// IdentityFile is the private key; empty leaves it to ssh
+	IdentityFile string
+	// Dir is the project directory on the host
+	Dir string
+	// Options are extra ssh -o options
+	Options []string
+}
+
+var current *Target
+
+// Parse reads ssh://user@host[:port], user@host or host
+func Parse(s string) (*Target, error) {
+	raw := s
+	if !strings.Contains(s, "://") {
+		raw = "ssh://" + s
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote host %q: %w", s, err)
+	}
+	if u.Scheme != "ssh" {
+		return nil, fmt.Errorf("remote host %q: only ssh:// hosts are supported", s)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("remote host %q has no host name", s)
+	}
+	t := &Target{Host: u.Hostname(), Port: u.Port(), Dir: DefaultDir}
+	if u.User != nil {
+		t.User = u.User.Username()
+	}
+	return t, nil
+}
+
+// FromSettings builds the target of the remote settings; nil when no
+// host is set
+func FromSettings(s settings.Remote) (*Target, error) {
+	if s.Host == "" {
+		return nil, nil
+	}
+	t, err := Parse(s.Host)
+	if err != nil {
+		return nil, err
+	}
+	t.IdentityFile = expandHome(s.IdentityFile)
+	if s.Dir != "" {
+		t.Dir = s.Dir
+	}
+	t.Options = s.SSHOptions
+	return t, nil
+}
+
+// expandHome resolves a leading ~ and the separators of a local path
+func expandHome(p string) string {
+	if p == "" {
+		return ""
+	}
+	if p == "~" || strings.HasPrefix(p, "~/") || strings.HasPrefix(p, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = home + p[1:]
+		}
+	}
+	return filepath.FromSlash(p)
+}
+
+// Use makes t the target of every later command, file read and
+// connection to a loopback address; nil runs them locally again. It must
+// run before selflog.Install wraps the default HTTP transport. It fails
+// when no ssh client is installed.
+func Use(t *Target) error {
+	if t != nil {
+		if _, err := exec.LookPath("ssh"); err != nil {
+			hint := "install the OpenSSH client"
+			if runtime.GOOS == "windows" {
+				hint = "install the OpenSSH Client (Settings → Apps → Optional features)"
+			}
+			return fmt.Errorf("ssh not found for remote host %s: %s", t, hint)
+		}
+	}
+	current = t
+	if tr, ok := http.DefaultTransport.(*http.Transport); ok {
+		// Every client on the default transport reaches the host's
+		// loopback ports
+		tr.DialContext = Dial
+	}
+	return nil
+}
+
+// Current is the target in use; nil when running locally
+func Current() *Target {
+	return current
+}
+
+// Active reports whether commands and files go to a remote host
+func Active() bool {
+	return current != nil
+}
+
+// String is the target as an ssh:// URL
+func (t *Target) String() string {
+	host := t.Host
+	if t.Port != "" {
+		host += ":" + t.Port
+	}
+	if t.User != "" {
+		host = t.User + "@" + host
+	}
+	return "ssh://" + host
+}
+
+// Same reports whether o is the same user and host as t
+func (t *Target) Same(o *Target) bool {
+	return o != nil && t.Host == o.Host && t.User == o.User && t.Port == o.Port
+}
+
+// SSHArgs are the ssh arguments up to and including the destination
+func (t *Target) SSHArgs() []string {
+	args := []string{"-o", "BatchMode=yes", "-o", "ConnectTimeout=10"}
+	if t.Port != "" {
+		args = append(args, "-p", t.Port)
+	}
+	if t.IdentityFile != "" {
+		args = append(args, "-i", t.IdentityFile, "-o", "IdentitiesOnly=yes")
+	}
+	if runtime.GOOS != "windows" && !t.hasOption("ControlPath") {
+		// One connection serves the many short commands of a run; the
+		// Windows ssh client cannot multiplex
+		args = append(args, "-o", "ControlMaster=auto",
+			"-o", "ControlPath="+filepath.Join(settings.Dir(), "ssh-%C"),
+			"-o", "ControlPersist=60")
+	}
+	for _, o := range t.Options {
+		args = append(args, "-o", o)
+	}
+	if t.User != "" {
+		args = append(args, "-l", t.User)
+	}
+	return append(args, t.Host)
+}
+
+func (t *Target) hasOption(name string) bool {
+	for _, o := range t.Options {
+		if strings.HasPrefix(strings.ToLower(o), strings.ToLower(name)+"=") {
+			return true
+		}
+	}
+	return false
+}
+
+// Command returns the command running name with args on the target, or
+// locally when there is none
+func Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	if current == nil {
+		return exec.CommandContext(ctx, name, args...)
+	}
+	return current.Command(ctx, name, args...)
+}
+
+// Command returns the command running name with args on t, whatever the
+// current target is, e.g. on the peer of an HA pair
+func (t *Target) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, "ssh", append(t.SSHArgs(), "--", Quote(append([]string{name}, args...)))...)
+}
+
+// Shell returns the command running a sh script on the target, or
+// locally when there is none
+func Shell(ctx context.Context, script string) *exec.Cmd {
+	return Command(ctx, "sh", "-c", script)
+}
+
+// LocalShell returns the command running a script in this machine's
+// shell: sh, or cmd.exe on Windows
+func LocalShell(ctx context.Context, script string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", script)
+	}
+	return exec.CommandContext(ctx, "sh", "-c", script)
+}
+
+// LookPath reports whether a command is installed on the target, or
+// locally when there is none
+func LookPath(name string) error {
+	if current == nil {
+		_, err := exec.LookPath(name)
+		return err
+	}
+	if err := selflog.Run(Command(context.Background(), "sh", "-c", "command -v "+Quote([]string{name})+" >/dev/null")); err != nil {
+		return fmt.Errorf("%s not found on %s", name, current.Host)
+	}
+	return nil
+}
+
+// Quote joins args into a POSIX shell command line, single-quoting
+// every argument that needs it
+func Quote(args []string) string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		if a != "" && strings.IndexFunc(a, needsQuote) < 0 {
+			out[i] = a
+			continue
+		}
+		out[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(out, " ")
+}
+
+func needsQuote(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("-_./:=@%+,", r)
+}
+
+// Path maps a path to the target: absolute paths are kept and relative
+// ones are taken from the project directory, always with forward
+// slashes, since the host is Linux whatever the workstation runs
+func Path(p string) string {
+	if current == nil {
+		return p
+	}
+	p = filepath.ToSlash(p)
+	if strings.HasPrefix(p, "/") {
+		return p
+	}
+	return path.Join(current.Dir, p)
+}
+
+// ReadFile reads a file from the target (see Path), or locally when
+// there is none. A missing file yields an error os.IsNotExist accepts.
+func ReadFile(p string) ([]byte, error) {
+	if current == nil {
+		return os.ReadFile(p)
+	}
+	var stderr bytes.Buffer
+	cmd := Command(context.Background(), "cat", "--", Path(p))
+	cmd.Stderr = &stderr
+	out, err := selflog.Output(cmd)
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if strings.Contains(msg, "No such file") || strings.Contains(msg, "Not a directory") {
+			return nil, &fs.PathError{Op: "open", Path: current.Host + ":" + Path(p), Err: fs.ErrNotExist}
+		}
+		if msg == "" {
+			msg = err.Error()
+		}
+		return nil, &fs.PathError{Op: "open", Path: current.Host + ":" + Path(p), Err: fmt.Errorf("%s", msg)}
+	}
+	return out, nil
+}
+
+// Exists reports whether a file exists on the target (see Path), or
+// locally when there is none
+func Exists(p string) bool {
+	if current == nil {
+		_, err := os.Stat(p)
+		return err == nil
+	}
+	return selflog.Run(Command(context.Background(), "test", "-e", Path(p))) == nil
+}
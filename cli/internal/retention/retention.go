@@ -0,0 +1,251 @@
+package retention
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/logstore"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+	"github.com/klauspost/compress/zstd"
+)
+
+// OldRuns returns saved troubleshoot runs created before the cutoff
+func OldRuns(cutoff time.Time) ([]*troubleshoot.RunRecord, error) {
+	runs, err := troubleshoot.ListRuns()
+	if err != nil {
+		return nil, err
+	}
+	var old []*troubleshoot.RunRecord
+	for _, run := range runs {
+		if run.CreatedAt.Before(cutoff) {
+			old = append(old, run)
+		}
+	}
+	return old, nil
+}
+
+// RunSize returns the bytes used by a saved run, apart from its logs in
+// the shared log store
+func RunSize(run *troubleshoot.RunRecord) int64 {
+	var size int64
+	filepath.Walk(filepath.Join(troubleshoot.RunsDir(), run.ID), func(_ string, info os.FileInfo, err error) error {
+		if err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// RemoveRuns deletes the given runs from disk
+func RemoveRuns(runs []*troubleshoot.RunRecord) error {
+	for _, run := range runs {
+		if err := os.RemoveAll(filepath.Join(troubleshoot.RunsDir(), run.ID)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bundleName matches the file names the CLI gives its bundles ('agent
+// debug', 'agent selfcheck bundle'); other files are never touched
+var bundleName = regexp.MustCompile(`^(?:debug|agent-selfcheck)-[0-9]{8}-[0-9]{6}\.tar\.gz$`)
+
+// OldBundles returns the CLI's bundles in dir last modified before the
+// cutoff and their total size
+func OldBundles(dir string, cutoff time.Time) ([]string, int64, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	var old []string
+	var size int64
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !bundleName.MatchString(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if info.ModTime().Before(cutoff) {
+			old = append(old, filepath.Join(dir, info.Name()))
+			size += info.Size()
+		}
+	}
+	return old, size, nil
+}
+
+// RemoveFiles deletes the given files; ones already gone are skipped
+func RemoveFiles(paths []string) error {
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// OldSpoolFiles returns the syslog spool files of days before the cutoff
+// and their total size
+func OldSpoolFiles(cutoff time.Time) ([]string, int64, error) {
+	return troubleshoot.OldSpoolFiles(cutoff)
+}
+
+// PruneSpool deletes the syslog spool files of days before the cutoff
+// and returns how many it deleted and their size
+func PruneSpool(cutoff time.Time) (int, int64, error) {
+	return troubleshoot.PruneSpool(cutoff)
+}
+
+// PruneIndex drops call index entries older than the cutoff and returns
+// how many were removed
+func PruneIndex(cutoff time.Time, dryRun bool) (int, error) {
+	index := troubleshoot.LoadCallIndex()
+	before := len(index.Calls)
+	index.Prune(cutoff)
+	removed := before - len(index.Calls)
+	if removed == 0 || dryRun {
+		return removed, nil
+	}
+	return removed, index.Save()
+}
+
+// PruneStore deletes the stored logs no saved run or cached call refers
+// to and compacts the shared line table. It returns how many logs it
+// deleted and the bytes freed.
+func PruneStore() (int, int64, error) {
+	removed, freed, err := troubleshoot.CollectLogStore()
+	if err != nil {
+		return removed, freed, err
+	}
+	compacted, err := logstore.Compact()
+	return removed, freed + compacted, err
+}
+
+// Archive packs the runs into a tar.zst and stores it at dest, which is a
+// local directory or an s3://bucket/prefix URL (uploaded with the aws CLI).
+// Each run's logs are written out as logs.txt. It returns the final
+// location of the archive.
+func Archive(ctx context.Context, runs []*troubleshoot.RunRecord, dest string) (string, error) {
+	if len(runs) == 0 {
+		return "", fmt.Errorf("nothing to archive")
+	}
+	name := fmt.Sprintf("agent-runs-%s.tar.zst", time.Now().Format("20060102-150405"))
+
+	if strings.HasPrefix(dest, "s3://") {
+		tmpDir, err := os.MkdirTemp("", "agent-archive")
+		if err != nil {
+			return "", err
+		}
+		defer os.RemoveAll(tmpDir)
+
+		local := filepath.Join(tmpDir, name)
+		if err := writeArchive(local, runs); err != nil {
+			return "", err
+		}
+		target := strings.TrimRight(dest, "/") + "/" + name
+		cmd := exec.CommandContext(ctx, "aws", "s3", "cp", "--only-show-errors", local, target)
+		if output, err := selflog.CombinedOutput(cmd); err != nil {
+			return "", fmt.Errorf("aws s3 cp failed: %v: %s", err, strings.TrimSpace(string(output)))
+		}
+		return target, nil
+	}
+
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return "", err
+	}
+	target := filepath.Join(dest, name)
+	if err := writeArchive(target, runs); err != nil {
+		os.Remove(target)
+		return "", err
+	}
+	return target, nil
+}
+
+// writeArchive writes every file of the runs into a tar.zst at path
+func writeArchive(path string, runs []*troubleshoot.RunRecord) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw, err := zstd.NewWriter(f, zstd.WithEncoderLevel(zstd.SpeedBetterCompression))
+	if err != nil {
+		return err
+	}
+	tw := tar.NewWriter(zw)
+
+	root := troubleshoot.RunsDir()
+	for _, run := range runs {
+		dir := filepath.Join(root, run.ID)
+		err := filepath.Walk(dir, func(file string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(root, file)
+			if err != nil {
+				return err
+			}
+			hdr, err := tar.FileInfoHeader(info, "")
+			if err != nil {
+				return err
+			}
+			hdr.Name = filepath.ToSlash(filepath.Join("runs", rel))
+			if info.Name() == "logs.ref" {
+				return archiveLogs(tw, hdr, run.ID)
+			}
+			if err := tw.WriteHeader(hdr); err != nil {
+				return err
+			}
+			if info.IsDir() {
+				return nil
+			}
+			src, err := os.Open(file)
+			if err != nil {
+				return err
+			}
+			defer src.Close()
+			_, err = io.Copy(tw, src)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("archiving run %s: %w", run.ID, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// archiveLogs writes a run's logs from the log store as logs.txt, so the
+// archive stands on its own
+func archiveLogs(tw *tar.Writer, hdr *tar.Header, id string) error {
+	logs, err := troubleshoot.LoadRunLogs(id)
+	if err != nil {
+		return err
+	}
+	hdr.Name = path.Join(path.Dir(hdr.Name), "logs.txt")
+	hdr.Size = int64(len(logs))
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.WriteString(tw, logs)
+	return err
+}
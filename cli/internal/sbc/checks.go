@@ -0,0 +1,430 @@
+package sbc
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+)
+
+const (
+	// defaultPtime is the packetization when the SDP states none, and
+	// what the engine streams
+	defaultPtime = 20
+	// minRTPTimeout is the shortest rtp_timeout that does not drop calls
+	// while the SBC re-anchors media or the caller holds
+	minRTPTimeout = 10
+	// maxCallDetails bounds the per-call lines of a check
+	maxCallDetails = 10
+)
+
+// cheapCodecs are the codecs Asterisk converts to the engine's slin
+// without a codec translator worth noting
+var cheapCodecs = map[string]bool{"ulaw": true, "alaw": true, "g722": true}
+
+// findings collects the details of a check and raises its status
+type findings struct {
+	status  health.CheckStatus
+	details []string
+	fixes   []string
+	seen    map[string]bool
+}
+
+var statusRank = map[health.CheckStatus]int{
+	health.StatusPass: 0,
+	health.StatusInfo: 1,
+	health.StatusWarn: 2,
+	health.StatusFail: 3,
+}
+
+func newFindings() *findings {
+	return &findings{status: health.StatusPass, seen: make(map[string]bool)}
+}
+
+// add records a detail line with its status, once
+func (f *findings) add(status health.CheckStatus, detail string) {
+	if statusRank[status] > statusRank[f.status] {
+		f.status = status
+	}
+	if f.seen[detail] {
+		return
+	}
+	f.seen[detail] = true
+	f.details = append(f.details, detail)
+}
+
+// fix records a remediation, once
+func (f *findings) fix(text string) {
+	if f.seen["fix:"+text] {
+		return
+	}
+	f.seen["fix:"+text] = true
+	f.fixes = append(f.fixes, text)
+}
+
+func (f *findings) check(name, pass, problem string) health.Check {
+	c := health.Check{Name: name, Status: f.status, Message: pass}
+	if len(f.details) > maxCallDetails {
+		more := len(f.details) - maxCallDetails
+		f.details = append(f.details[:maxCallDetails], fmt.Sprintf("... and %d more", more))
+	}
+	c.Details = strings.Join(f.details, "\n")
+	if f.status == health.StatusWarn || f.status == health.StatusFail {
+		c.Message = problem
+		c.Remediation = strings.Join(f.fixes, "; ")
+	}
+	return c
+}
+
+// Checks runs all checks
+func (ins *Inspection) Checks() []health.Check {
+	return []health.Check{
+		ins.CheckTopology(),
+		ins.CheckPtime(),
+		ins.CheckTranscoding(),
+		ins.CheckRTPTimeout(),
+	}
+}
+
+// noCalls is the result of a call-based check without calls in the log
+func (ins *Inspection) noCalls(name string) health.Check {
+	c := health.Check{
+		Name:        name,
+		Status:      health.StatusInfo,
+		Message:     "No INVITE from " + ins.VendorName() + " in the SIP log",
+		Remediation: "Capture SIP while a call comes in: agent logging level --component asterisk --set debug --for 10m (pjsip set logger on), then rerun",
+	}
+	if ins.LogErr != nil {
+		c.Message = "SIP log not readable"
+		c.Details = ins.LogErr.Error()
+	} else if ins.SIPLog != "" {
+		c.Details = ins.SIPLog
+	}
+	return c
+}
+
+// CheckTopology looks at how the SBC hides the topology behind it: a
+// Contact Asterisk cannot reach (masked by Kamailio topoh/topos or
+// OpenSIPS topology_hiding, or a private address) sends BYE and
+// re-INVITE nowhere, so callers stay connected after the agent hangs
+// up; no Record-Route lets them bypass the SBC; a private SDP address
+// without symmetric RTP is one-way audio; a stripped P-Asserted-Identity
+// leaves the agent without the caller's number.
+func (ins *Inspection) CheckTopology() health.Check {
+	const name = "SBC topology hiding"
+	if len(ins.Calls) == 0 {
+		return ins.noCalls(name)
+	}
+	f := newFindings()
+	for _, call := range ins.Calls {
+		ep := ins.endpointOf(call)
+		if ep == nil {
+			ep = &Endpoint{Params: map[string]string{}}
+		}
+		inv := call.Invite
+		label := fmt.Sprintf("%s from %s", shortID(call.CallID), inv.Addr)
+		if ep.Name != "" {
+			label += " on " + ep.Name
+		}
+
+		contact := inv.Header("Contact")
+		host := uriHost(contact)
+		masked := strings.Contains(contact, ";line=sr-") || strings.Contains(contact, "tpsh-") || strings.Contains(contact, "thinfo=")
+		recordRouted := len(inv.Headers["record-route"]) > 0
+		if host != "" && host != inv.Addr && !recordRouted {
+			switch {
+			case ep.enabled("rewrite_contact") || ep.Params["outbound_proxy"] != "":
+				// Asterisk answers to the source address regardless
+			case masked || unroutable(host, inv.Addr):
+				f.add(health.StatusFail, fmt.Sprintf("%s: Contact %s is not reachable and there is no Record-Route: BYE and re-INVITE are lost", label, host))
+				f.fix("Set rewrite_contact=yes on " + endpointName(ep) + " so in-dialog requests go to the address the INVITE came from")
+				ins.topologyFix(f)
+			default:
+				f.add(health.StatusWarn, fmt.Sprintf("%s: Contact %s bypasses the SBC and there is no Record-Route: BYE and re-INVITE skip %s", label, host, ins.VendorName()))
+				ins.recordRouteFix(f)
+			}
+		} else if masked {
+			f.add(health.StatusInfo, fmt.Sprintf("%s: Contact is masked by topology hiding; Record-Route or rewrite_contact routes in-dialog requests", label))
+		}
+
+		if call.Offer != nil && unroutable(call.Offer.Addr, inv.Addr) && !ep.enabled("rtp_symmetric") {
+			f.add(health.StatusWarn, fmt.Sprintf("%s: SDP media address %s is not reachable and rtp_symmetric is off: one-way audio", label, call.Offer.Addr))
+			f.fix("Set rtp_symmetric=yes on " + endpointName(ep) + " so RTP goes back where it comes from")
+			ins.mediaAddressFix(f)
+		}
+
+		if identityStripped(inv) {
+			f.add(health.StatusWarn, fmt.Sprintf("%s: anonymous From without P-Asserted-Identity: the agent gets no caller number", label))
+			ins.identityFix(f)
+		} else if inv.Header("P-Asserted-Identity") != "" && anonymous(inv) && !ep.enabled("trust_id_inbound") {
+			f.add(health.StatusWarn, fmt.Sprintf("%s: P-Asserted-Identity is ignored (trust_id_inbound=no): the agent sees an anonymous caller", label))
+			f.fix("Set trust_id_inbound=yes on " + endpointName(ep) + " to take the caller from P-Asserted-Identity")
+		}
+	}
+	return f.check(name,
+		fmt.Sprintf("In-dialog routing, media address and caller identity fine on %d call(s)", len(ins.Calls)),
+		"Topology hiding breaks call routing or media")
+}
+
+func (ins *Inspection) topologyFix(f *findings) {
+	switch ins.Vendor {
+	case VendorKamailio:
+		f.fix("or set topoh's mask_ip (or topos' contact_host) to an address of Kamailio that Asterisk reaches, and record_route() INVITEs to Asterisk")
+	case VendorOpenSIPS:
+		f.fix(`or call topology_hiding("C") only towards the carrier side and record_route() towards Asterisk`)
+	case VendorAudioCodes:
+		f.fix("or give the SIP Interface facing Asterisk an address Asterisk reaches (NAT Translation / Media Realm)")
+	}
+}
+
+func (ins *Inspection) recordRouteFix(f *findings) {
+	switch ins.Vendor {
+	case VendorKamailio, VendorOpenSIPS:
+		f.fix("Call record_route() for INVITEs to Asterisk so BYE and re-INVITE pass " + ins.VendorName())
+	case VendorAudioCodes:
+		f.fix("Enable topology hiding on the IP Group of Asterisk so the SBC's own address is the Contact")
+	default:
+		f.fix("Have the SBC Record-Route INVITEs to Asterisk, or set rewrite_contact=yes on the endpoint")
+	}
+}
+
+func (ins *Inspection) mediaAddressFix(f *findings) {
+	switch ins.Vendor {
+	case VendorKamailio, VendorOpenSIPS:
+		f.fix("or have rtpengine anchor the media with an address Asterisk reaches (rtpengine_manage() with the interface facing Asterisk)")
+	case VendorAudioCodes:
+		f.fix("or set the Media Realm facing Asterisk to an address Asterisk reaches")
+	}
+}
+
+func (ins *Inspection) identityFix(f *findings) {
+	switch ins.Vendor {
+	case VendorKamailio, VendorOpenSIPS:
+		f.fix("Keep P-Asserted-Identity towards Asterisk (do not remove_hf(\"P-Asserted-Identity\") on that leg)")
+	case VendorAudioCodes:
+		f.fix("Set the IP Profile towards Asterisk to send P-Asserted-Identity (Assert Identity / Message Manipulation)")
+	default:
+		f.fix("Have the SBC keep P-Asserted-Identity towards Asterisk")
+	}
+}
+
+// anonymous reports whether the From of an INVITE hides the caller
+func anonymous(inv *Message) bool {
+	user := strings.ToLower(uriUser(inv.Header("From")))
+	return user == "" || user == "anonymous" || user == "restricted" || user == "unknown"
+}
+
+// identityStripped reports whether an INVITE carries no caller identity
+func identityStripped(inv *Message) bool {
+	return anonymous(inv) && inv.Header("P-Asserted-Identity") == "" && inv.Header("Remote-Party-ID") == "" && inv.Header("P-Preferred-Identity") == ""
+}
+
+// CheckPtime compares the packetization the SBC offers with the one
+// Asterisk answers: an SBC that does not repacketize sends or expects
+// packets of the other size, which plays as choppy or clipped audio.
+func (ins *Inspection) CheckPtime() health.Check {
+	const name = "SBC ptime"
+	if len(ins.Calls) == 0 {
+		return ins.noCalls(name)
+	}
+	f := newFindings()
+	compared := 0
+	for _, call := range ins.Calls {
+		if call.Offer == nil || call.Answer == nil {
+			continue
+		}
+		compared++
+		label := shortID(call.CallID)
+		offered, answered := ptime(call.Offer), ptime(call.Answer)
+		switch {
+		case call.Offer.MaxPtime > 0 && answered > call.Offer.MaxPtime:
+			f.add(health.StatusFail, fmt.Sprintf("%s: Asterisk answers %d ms, above the offered maxptime %d ms", label, answered, call.Offer.MaxPtime))
+			f.fix(fmt.Sprintf("Set the codec framing on the endpoint to the SBC's (e.g. allow=ulaw:%d)", offered))
+		case offered != answered:
+			f.add(health.StatusWarn, fmt.Sprintf("%s: %s offers %d ms, Asterisk answers %d ms", label, ins.VendorName(), offered, answered))
+			f.fix(fmt.Sprintf("Set the codec framing on the endpoint to the SBC's (e.g. allow=ulaw:%d)", offered))
+			ins.ptimeFix(f)
+		case offered != defaultPtime:
+			f.add(health.StatusInfo, fmt.Sprintf("%s: %d ms packets; Asterisk repacketizes to the engine's %d ms frames", label, offered, defaultPtime))
+		}
+	}
+	if compared == 0 {
+		c := ins.noCalls(name)
+		c.Message = "No answered INVITE with SDP in the SIP log"
+		return c
+	}
+	return f.check(name,
+		fmt.Sprintf("Offer and answer agree on ptime on %d call(s)", compared),
+		"ptime differs between the SBC and Asterisk")
+}
+
+func (ins *Inspection) ptimeFix(f *findings) {
+	switch ins.Vendor {
+	case VendorKamailio, VendorOpenSIPS:
+		f.fix(fmt.Sprintf("or let rtpengine repacketize: add ptime=%d to the rtpengine_offer()/rtpengine_answer() flags", defaultPtime))
+	case VendorAudioCodes:
+		f.fix(fmt.Sprintf("or set the packetization time of the Coders Group used towards Asterisk to %d ms", defaultPtime))
+	}
+}
+
+func ptime(sdp *SDP) int {
+	if sdp.Ptime > 0 {
+		return sdp.Ptime
+	}
+	return defaultPtime
+}
+
+// CheckTranscoding looks for codec translation between the caller and
+// the engine: the SBC offering a codec Asterisk does not pick (so the
+// SBC transcodes), Asterisk preferring a compressed codec, and live
+// calls Asterisk decodes from one. Each step adds latency and artifacts
+// that sound like a speech recognition or TTS problem.
+func (ins *Inspection) CheckTranscoding() health.Check {
+	const name = "SBC transcoding"
+	f := newFindings()
+	for _, ep := range ins.Endpoints {
+		if codecs := ep.codecs(); len(codecs) > 0 && !cheapCodecs[codecs[0]] && !strings.HasPrefix(codecs[0], "slin") {
+			f.add(health.StatusWarn, fmt.Sprintf("%s prefers %s (allow=%s): Asterisk transcodes every call", ep.Name, codecs[0], strings.Join(codecs, ",")))
+			f.fix("Put ulaw or alaw first in allow= on " + ep.Name)
+		}
+	}
+	for _, call := range ins.Calls {
+		label := shortID(call.CallID)
+		switch {
+		case call.Final == 488:
+			f.add(health.StatusFail, fmt.Sprintf("%s: Asterisk rejected the offer (%s) with 488: no common codec", label, codecList(call.Offer)))
+			f.fix("Allow one of the SBC's codecs on the endpoint, or have the SBC offer ulaw/alaw")
+		case call.Offer != nil && call.Answer != nil && len(call.Offer.Codecs) > 0 && len(call.Answer.Codecs) > 0:
+			first, chosen := call.Offer.Codecs[0], call.Answer.Codecs[0]
+			if first != chosen && !cheapCodecs[first] {
+				f.add(health.StatusWarn, fmt.Sprintf("%s: %s prefers %s, Asterisk answered %s: the SBC transcodes", label, ins.VendorName(), first, chosen))
+				ins.transcodeFix(f)
+			}
+		}
+	}
+	translated := map[string]int{}
+	for _, ch := range ins.Channels {
+		native := strings.Trim(ch.Native, "()")
+		for _, codec := range strings.Split(native, "|") {
+			if codec != "" && !cheapCodecs[codec] && !strings.HasPrefix(codec, "slin") {
+				translated[codec]++
+				break
+			}
+		}
+		if steps := strings.Count(ch.ReadTranscode, "->"); steps > 1 {
+			f.add(health.StatusInfo, fmt.Sprintf("%s: %d translation steps: %s", ch.Name, steps, ch.ReadTranscode))
+		}
+	}
+	for _, codec := range sortedKeys(translated) {
+		f.add(health.StatusWarn, fmt.Sprintf("%d live call(s) in %s: Asterisk decodes them for the engine", translated[codec], codec))
+		f.fix("Have the SBC offer ulaw or alaw to Asterisk")
+	}
+	if len(ins.Calls) == 0 && len(ins.Channels) == 0 && f.status == health.StatusPass {
+		return ins.noCalls(name)
+	}
+	return f.check(name,
+		fmt.Sprintf("No transcoding on %d call(s), %d live", len(ins.Calls), len(ins.Channels)),
+		"Audio is transcoded between the SBC and the engine")
+}
+
+func (ins *Inspection) transcodeFix(f *findings) {
+	switch ins.Vendor {
+	case VendorKamailio, VendorOpenSIPS:
+		f.fix("Offer ulaw/alaw first towards Asterisk (rtpengine codec-accept/codec-strip flags) or allow the SBC's codec on the endpoint")
+	case VendorAudioCodes:
+		f.fix("Put G.711 first in the Coders Group of the IP Profile towards Asterisk, or disable Extension Coders")
+	default:
+		f.fix("Have the SBC offer ulaw/alaw first, or allow its codec on the endpoint")
+	}
+}
+
+func codecList(sdp *SDP) string {
+	if sdp == nil || len(sdp.Codecs) == 0 {
+		return "no audio codecs"
+	}
+	return strings.Join(sdp.Codecs, ",")
+}
+
+// CheckRTPTimeout compares the RTP timeouts on both sides: without
+// rtp_timeout Asterisk keeps calls the SBC dropped (and their provider
+// sessions) open, and without rtp_keepalive the SBC's no-RTP timeout
+// tears calls down while the agent is silent.
+func (ins *Inspection) CheckRTPTimeout() health.Check {
+	const name = "SBC RTP timeouts"
+	if len(ins.Endpoints) == 0 {
+		return health.Check{Name: name, Status: health.StatusInfo, Message: "No PJSIP endpoint facing the SBC"}
+	}
+	f := newFindings()
+	sbcTimeout := ins.mediaTimeout()
+	for _, ep := range ins.Endpoints {
+		timeout, hold, keepalive := ep.seconds("rtp_timeout"), ep.seconds("rtp_timeout_hold"), ep.seconds("rtp_keepalive")
+		switch {
+		case timeout == 0:
+			f.add(health.StatusWarn, fmt.Sprintf("%s: rtp_timeout=0: calls %s stopped sending media to stay up", ep.Name, ins.VendorName()))
+			f.fix(fmt.Sprintf("Set rtp_timeout=%d on %s", rtpTimeoutFor(sbcTimeout), ep.Name))
+		case timeout < minRTPTimeout:
+			f.add(health.StatusWarn, fmt.Sprintf("%s: rtp_timeout=%d drops calls on short media gaps", ep.Name, timeout))
+			f.fix(fmt.Sprintf("Raise rtp_timeout on %s to %d", ep.Name, rtpTimeoutFor(sbcTimeout)))
+		case hold == 0:
+			f.add(health.StatusInfo, fmt.Sprintf("%s: rtp_timeout_hold=0: calls on hold never time out", ep.Name))
+		}
+		switch {
+		case sbcTimeout > 0 && (keepalive == 0 || time.Duration(keepalive)*time.Second >= sbcTimeout):
+			f.add(health.StatusFail, fmt.Sprintf("%s: rtp_keepalive=%d while %s drops calls after %s without RTP: silent agent turns hang up", ep.Name, keepalive, ins.VendorName(), sbcTimeout))
+			f.fix(fmt.Sprintf("Set rtp_keepalive=%d on %s", keepaliveFor(sbcTimeout), ep.Name))
+		case keepalive == 0:
+			f.add(health.StatusInfo, fmt.Sprintf("%s: rtp_keepalive=0: no RTP reaches %s while the agent is silent", ep.Name, ins.VendorName()))
+		}
+	}
+	if ins.Vendor == VendorAudioCodes && ins.MediaTimeout == 0 {
+		f.add(health.StatusInfo, "AudioCodes: check Broken Connection Timeout of the IP Profile and set sbc.media_timeout to it")
+	}
+	return f.check(name,
+		fmt.Sprintf("RTP timeouts and keepalives set on %d endpoint(s)", len(ins.Endpoints)),
+		"RTP timeouts drop calls or keep dead ones")
+}
+
+// rtpTimeoutFor is a rtp_timeout that outlasts the SBC's own timeout
+func rtpTimeoutFor(sbc time.Duration) int {
+	if sbc <= 0 {
+		return 30
+	}
+	return int(sbc.Seconds()) + 10
+}
+
+// keepaliveFor sends keepalives well within the SBC's timeout
+func keepaliveFor(sbc time.Duration) int {
+	if n := int(sbc.Seconds()) / 4; n >= 1 && n < 5 {
+		return n
+	}
+	if sbc < 4*time.Second {
+		return 1
+	}
+	return 5
+}
+
+func endpointName(ep *Endpoint) string {
+	if ep.Name == "" {
+		return "the endpoint"
+	}
+	return ep.Name
+}
+
+// shortID shortens a Call-ID for display
+func shortID(id string) string {
+	if len(id) > 16 {
+		return id[:16] + "…"
+	}
+	return id
+}
+
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,195 @@
+// Package sbc checks the Asterisk side of trunks fronted by a session
+// border controller (Kamailio, OpenSIPS, AudioCodes). A misconfigured SBC
+// shows up as what looks like an agent audio bug: one-way audio and calls
+// that never hang up from topology hiding, choppy audio from ptime
+// mismatches, latency from transcoding in the path, and calls dropped
+// while the agent is silent from RTP timeouts.
+package sbc
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Vendors the checks know
+const (
+	VendorKamailio   = "kamailio"
+	VendorOpenSIPS   = "opensips"
+	VendorAudioCodes = "audiocodes"
+)
+
+// Vendors lists the known vendors
+var Vendors = []string{VendorKamailio, VendorOpenSIPS, VendorAudioCodes}
+
+// KnownVendor reports whether vendor is one of Vendors
+func KnownVendor(vendor string) bool {
+	for _, v := range Vendors {
+		if v == vendor {
+			return true
+		}
+	}
+	return false
+}
+
+// DetectVendor names the vendor of a User-Agent or Server header, "" when
+// it is not a known SBC
+func DetectVendor(userAgent string) string {
+	ua := strings.ToLower(userAgent)
+	switch {
+	case strings.Contains(ua, "kamailio"), strings.Contains(ua, "openser"):
+		return VendorKamailio
+	case strings.Contains(ua, "opensips"):
+		return VendorOpenSIPS
+	case strings.Contains(ua, "audiocodes"), strings.Contains(ua, "mediant"):
+		return VendorAudioCodes
+	}
+	return ""
+}
+
+// defaultMediaTimeouts are the no-RTP timeouts of the media relays the
+// vendors usually run: rtpengine's --timeout and rtpproxy's -T
+var defaultMediaTimeouts = map[string]time.Duration{
+	VendorKamailio: 60 * time.Second,
+	VendorOpenSIPS: 60 * time.Second,
+}
+
+// Endpoint is a PJSIP endpoint facing the SBC
+type Endpoint struct {
+	Name string
+	// Params are the endpoint's settings from 'pjsip show endpoint'
+	Params map[string]string
+	// Matches are the identify match criteria of the endpoint
+	Matches []string
+}
+
+// seconds reads a numeric endpoint setting; 0 when unset
+func (e Endpoint) seconds(name string) int {
+	n, _ := strconv.Atoi(strings.TrimSpace(e.Params[name]))
+	return n
+}
+
+// enabled reads a boolean endpoint setting
+func (e Endpoint) enabled(name string) bool {
+	switch strings.ToLower(e.Params[name]) {
+	case "yes", "true", "on", "1":
+		return true
+	}
+	return false
+}
+
+// codecs returns the endpoint's allow list in order
+func (e Endpoint) codecs() []string {
+	var out []string
+	for _, c := range strings.FieldsFunc(strings.Trim(e.Params["allow"], "()"), func(r rune) bool { return r == '|' || r == ',' }) {
+		if c = strings.TrimSpace(c); c != "" {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Covers reports whether addr is covered by the endpoint's identify
+func (e Endpoint) Covers(addr string) bool {
+	ip := net.ParseIP(addr)
+	for _, m := range e.Matches {
+		if m == addr {
+			return true
+		}
+		if ip == nil {
+			continue
+		}
+		if _, network, err := net.ParseCIDR(m); err == nil && network.Contains(ip) {
+			return true
+		}
+		if other := net.ParseIP(m); other != nil && other.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// Channel is a live call on an endpoint facing the SBC
+type Channel struct {
+	Name     string
+	Endpoint string
+	// Native is the codec negotiated with the SBC; ReadTranscode and
+	// WriteTranscode are Asterisk's translation paths, e.g.
+	// "Yes (g729@8000)->(slin@8000)", or "No"
+	Native         string
+	ReadTranscode  string
+	WriteTranscode string
+}
+
+// Inspection is what the checks look at: the endpoints facing the SBC,
+// the calls the SBC sent in the SIP log and the live channels
+type Inspection struct {
+	Vendor    string
+	Endpoints []Endpoint
+	Calls     []*Call
+	Channels  []Channel
+	// SIPLog is where the calls were read from; LogErr is why they could
+	// not be
+	SIPLog string
+	LogErr error
+	// MediaTimeout is how long the SBC tolerates no RTP; 0 uses the
+	// vendor's usual default
+	MediaTimeout time.Duration
+}
+
+// NewInspection attributes the calls of the SIP log to the endpoints
+// (by identify match, or any call when no endpoint has one) and detects
+// the vendor from them when vendor is empty
+func NewInspection(vendor string, endpoints []Endpoint, msgs []*Message) *Inspection {
+	ins := &Inspection{Vendor: vendor, Endpoints: endpoints}
+	anyMatch := false
+	for _, e := range endpoints {
+		anyMatch = anyMatch || len(e.Matches) > 0
+	}
+	for _, call := range Calls(msgs) {
+		if anyMatch && ins.endpointOf(call) == nil {
+			continue
+		}
+		ins.Calls = append(ins.Calls, call)
+		if ins.Vendor == "" {
+			ins.Vendor = DetectVendor(call.UserAgent)
+		}
+	}
+	return ins
+}
+
+// endpointOf returns the endpoint a call came in on
+func (ins *Inspection) endpointOf(call *Call) *Endpoint {
+	for i := range ins.Endpoints {
+		if ins.Endpoints[i].Covers(call.Invite.Addr) {
+			return &ins.Endpoints[i]
+		}
+	}
+	if len(ins.Endpoints) == 1 && len(ins.Endpoints[0].Matches) == 0 {
+		return &ins.Endpoints[0]
+	}
+	return nil
+}
+
+// VendorName is the vendor for display
+func (ins *Inspection) VendorName() string {
+	switch ins.Vendor {
+	case VendorKamailio:
+		return "Kamailio"
+	case VendorOpenSIPS:
+		return "OpenSIPS"
+	case VendorAudioCodes:
+		return "AudioCodes"
+	}
+	return "the SBC"
+}
+
+// mediaTimeout is the SBC's no-RTP timeout, configured or the vendor's
+// usual default; 0 when unknown
+func (ins *Inspection) mediaTimeout() time.Duration {
+	if ins.MediaTimeout > 0 {
+		return ins.MediaTimeout
+	}
+	return defaultMediaTimeouts[ins.Vendor]
+}
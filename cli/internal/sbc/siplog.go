@@ -0,0 +1,407 @@
+package sbc
+
+import (
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message is one SIP message of 'pjsip set logger' output
+type Message struct {
+	// Received is true for messages from the peer, false for the ones
+	// Asterisk transmitted
+	Received  bool
+	Transport string
+	// Addr is the peer's address, without the port
+	Addr string
+	// Time is the log line's timestamp; zero when it has none
+	Time      time.Time
+	StartLine string
+	// Headers are keyed by lowercase name, compact forms expanded
+	Headers map[string][]string
+	Body    string
+}
+
+// Header returns the first value of a header
+func (m *Message) Header(name string) string {
+	if v := m.Headers[strings.ToLower(name)]; len(v) > 0 {
+		return v[0]
+	}
+	return ""
+}
+
+// Method is the request method, or the CSeq method of a response
+func (m *Message) Method() string {
+	if !strings.HasPrefix(m.StartLine, "SIP/") {
+		return strings.Fields(m.StartLine)[0]
+	}
+	if f := strings.Fields(m.Header("CSeq")); len(f) == 2 {
+		return f[1]
+	}
+	return ""
+}
+
+// Status is the status code of a response, 0 for requests
+func (m *Message) Status() int {
+	f := strings.Fields(m.StartLine)
+	if len(f) < 2 || !strings.HasPrefix(f[0], "SIP/") {
+		return 0
+	}
+	code, _ := strconv.Atoi(f[1])
+	return code
+}
+
+// SDP is the audio part of a session description
+type SDP struct {
+	// Addr is the connection address of the audio stream
+	Addr string
+	// Codecs are the offered or answered audio codecs, in order,
+	// lowercase as Asterisk names them (ulaw, alaw, g729, opus ...),
+	// without telephone-event and comfort noise
+	Codecs   []string
+	Ptime    int
+	MaxPtime int
+}
+
+// Call is an INVITE dialog from the peer: its offer and Asterisk's answer
+type Call struct {
+	CallID string
+	Invite *Message
+	Offer  *SDP
+	Answer *SDP
+	// Final is the status of Asterisk's final response to the INVITE
+	Final int
+	// UserAgent is the peer's User-Agent or Server header
+	UserAgent string
+}
+
+var (
+	loggerHeader = regexp.MustCompile(`<--- (Received|Transmitting) SIP (?:request|response) \(\d+ bytes\) (?:from|to) (\w+):(\[[^\]]+\]|[^:\s]+):\d+ --->`)
+	// logLine starts an Asterisk log line, which ends the message before
+	logLine = regexp.MustCompile(`^\[[A-Z][a-z]{2}\s+\d+|^\[\d{4}-\d{2}-\d{2}`)
+	// logStamp is the timestamp of an Asterisk log line: the default
+	// dateformat (%b %e %T), optionally with fractions, or ISO
+	logStamp = regexp.MustCompile(`^\[([A-Z][a-z]{2}\s+\d+ \d{2}:\d{2}:\d{2}(?:\.\d+)?|\d{4}-\d{2}-\d{2}[ T]\d{2}:\d{2}:\d{2}(?:\.\d+)?)\]`)
+)
+
+// logTime parses the timestamp of an Asterisk log line in loc. Dates
+// without a year are in the last twelve months.
+func logTime(line string, loc *time.Location) time.Time {
+	m := logStamp.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}
+	}
+	stamp := strings.Join(strings.Fields(m[1]), " ")
+	if t, err := time.ParseInLocation("2006-01-02 15:04:05.999999999", strings.Replace(stamp, "T", " ", 1), loc); err == nil {
+		return t
+	}
+	t, err := time.ParseInLocation("Jan 2 15:04:05.999999999", stamp, loc)
+	if err != nil {
+		return time.Time{}
+	}
+	now := time.Now().In(loc)
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t
+}
+
+// compactHeaders are the RFC 3261 compact header forms
+var compactHeaders = map[string]string{
+	"i": "call-id",
+	"m": "contact",
+	"f": "from",
+	"t": "to",
+	"v": "via",
+	"c": "content-type",
+	"l": "content-length",
+}
+
+// ParseLog returns the SIP messages of 'pjsip set logger' output in an
+// Asterisk log or console capture, with log times read in loc
+func ParseLog(data string, loc *time.Location) []*Message {
+	var msgs []*Message
+	var cur *Message
+	var lines []string
+	flush := func() {
+		if cur != nil && len(lines) > 0 {
+			parseMessage(cur, lines)
+			if cur.StartLine != "" {
+				msgs = append(msgs, cur)
+			}
+		}
+		cur, lines = nil, nil
+	}
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if m := loggerHeader.FindStringSubmatch(line); m != nil {
+			flush()
+			cur = &Message{
+				Received:  m[1] == "Received",
+				Transport: strings.ToLower(m[2]),
+				Addr:      strings.Trim(m[3], "[]"),
+				Time:      logTime(line, loc),
+				Headers:   make(map[string][]string),
+			}
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if logLine.MatchString(line) {
+			flush()
+			continue
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return msgs
+}
+
+func parseMessage(m *Message, lines []string) {
+	// Skip blank lines before the start line
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) == 0 {
+		return
+	}
+	m.StartLine = strings.TrimSpace(lines[0])
+	i := 1
+	for ; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		name := strings.ToLower(strings.TrimSpace(kv[0]))
+		if long, ok := compactHeaders[name]; ok {
+			name = long
+		}
+		m.Headers[name] = append(m.Headers[name], strings.TrimSpace(kv[1]))
+	}
+	if i < len(lines) {
+		m.Body = strings.TrimSpace(strings.Join(lines[i+1:], "\n"))
+	}
+}
+
+// Calls groups the messages into the INVITE dialogs the peer started,
+// in order
+func Calls(msgs []*Message) []*Call {
+	var calls []*Call
+	byID := make(map[string]*Call)
+	for _, m := range msgs {
+		id := m.Header("Call-ID")
+		if id == "" {
+			continue
+		}
+		call := byID[id]
+		if call == nil {
+			if !m.Received || m.Method() != "INVITE" || m.Status() != 0 {
+				continue
+			}
+			call = &Call{CallID: id, Invite: m, Offer: ParseSDP(m.Body), UserAgent: m.Header("User-Agent")}
+			byID[id] = call
+			calls = append(calls, call)
+			continue
+		}
+		if m.Received && call.UserAgent == "" {
+			call.UserAgent = m.Header("User-Agent")
+			if call.UserAgent == "" {
+				call.UserAgent = m.Header("Server")
+			}
+		}
+		if m.Received || m.Method() != "INVITE" {
+			continue
+		}
+		status := m.Status()
+		if status >= 200 && call.Final == 0 {
+			call.Final = status
+			if status < 300 {
+				call.Answer = ParseSDP(m.Body)
+			}
+		}
+	}
+	return calls
+}
+
+// Delay is the post-dial delay of an INVITE Asterisk sent: from the
+// first INVITE to the first ringing, session progress or final response
+type Delay struct {
+	CallID string
+	Addr   string
+	Time   time.Time
+	Delay  time.Duration
+	// Status is the response that ended the delay
+	Status int
+}
+
+// PostDialDelays measures the INVITEs Asterisk sent whose log lines are
+// timestamped
+func PostDialDelays(msgs []*Message) []Delay {
+	var delays []Delay
+	invites := make(map[string]*Message)
+	done := make(map[string]bool)
+	for _, m := range msgs {
+		id := m.Header("Call-ID")
+		if id == "" || done[id] || m.Time.IsZero() || m.Method() != "INVITE" {
+			continue
+		}
+		if !m.Received && m.Status() == 0 {
+			if invites[id] == nil && !strings.Contains(m.Header("To"), "tag=") {
+				invites[id] = m
+			}
+			continue
+		}
+		inv := invites[id]
+		if inv == nil || !m.Received {
+			continue
+		}
+		// 100 Trying and auth challenges do not end the delay
+		status := m.Status()
+		if status < 180 || status == 401 || status == 407 {
+			continue
+		}
+		done[id] = true
+		delays = append(delays, Delay{CallID: id, Addr: inv.Addr, Time: inv.Time, Delay: m.Time.Sub(inv.Time), Status: status})
+	}
+	return delays
+}
+
+// staticCodecs are the static RTP payload types
+var staticCodecs = map[string]string{
+	"0":  "ulaw",
+	"3":  "gsm",
+	"8":  "alaw",
+	"9":  "g722",
+	"18": "g729",
+}
+
+// codecNames map SDP encoding names to Asterisk's codec names
+var codecNames = map[string]string{
+	"pcmu": "ulaw",
+	"pcma": "alaw",
+	"l16":  "slin",
+}
+
+// ParseSDP reads the first audio stream of an SDP body; nil without one
+func ParseSDP(body string) *SDP {
+	if !strings.Contains(body, "m=audio") {
+		return nil
+	}
+	sdp := &SDP{}
+	var payloads []string
+	rtpmap := make(map[string]string)
+	inAudio := false
+	for _, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if len(line) < 2 || line[1] != '=' {
+			continue
+		}
+		key, value := line[0], line[2:]
+		switch {
+		case key == 'm':
+			if inAudio {
+				// Only the first audio stream
+				inAudio = false
+				continue
+			}
+			f := strings.Fields(value)
+			inAudio = len(f) > 3 && f[0] == "audio" && sdp.Codecs == nil && payloads == nil
+			if inAudio {
+				payloads = f[3:]
+			}
+		case key == 'c':
+			// Session level, or overridden at media level
+			if f := strings.Fields(value); len(f) == 3 && (inAudio || payloads == nil) {
+				sdp.Addr = f[2]
+			}
+		case key == 'a' && inAudio:
+			name, arg, _ := strings.Cut(value, ":")
+			switch name {
+			case "rtpmap":
+				if pt, enc, ok := strings.Cut(arg, " "); ok {
+					enc, _, _ = strings.Cut(enc, "/")
+					rtpmap[pt] = strings.ToLower(enc)
+				}
+			case "ptime":
+				sdp.Ptime, _ = strconv.Atoi(strings.TrimSpace(arg))
+			case "maxptime":
+				sdp.MaxPtime, _ = strconv.Atoi(strings.TrimSpace(arg))
+			}
+		}
+	}
+	for _, pt := range payloads {
+		name := rtpmap[pt]
+		if name == "" {
+			name = staticCodecs[pt]
+		}
+		if long, ok := codecNames[name]; ok {
+			name = long
+		}
+		switch name {
+		case "", "telephone-event", "cn":
+			continue
+		}
+		sdp.Codecs = append(sdp.Codecs, name)
+	}
+	return sdp
+}
+
+// uriHost returns the host of the first SIP URI in a header value
+func uriHost(value string) string {
+	i := strings.Index(value, "sip:")
+	if i < 0 {
+		if i = strings.Index(value, "sips:"); i < 0 {
+			return ""
+		}
+		i++
+	}
+	rest := value[i+4:]
+	if at := strings.IndexByte(rest, '@'); at >= 0 && at < strings.IndexAny(rest+">", ">;") {
+		rest = rest[at+1:]
+	}
+	if strings.HasPrefix(rest, "[") {
+		if end := strings.IndexByte(rest, ']'); end > 0 {
+			return rest[1:end]
+		}
+	}
+	if end := strings.IndexAny(rest, ":;>? "); end >= 0 {
+		rest = rest[:end]
+	}
+	return rest
+}
+
+// uriUser returns the user part of the first SIP URI in a header value
+func uriUser(value string) string {
+	i := strings.Index(value, "sip:")
+	if i < 0 {
+		return ""
+	}
+	rest := value[i+4:]
+	at := strings.IndexByte(rest, '@')
+	if at < 0 || at > strings.IndexAny(rest+">", ">;") {
+		return ""
+	}
+	return rest[:at]
+}
+
+// unroutable reports whether a peer at from cannot be reached at addr:
+// a loopback or unspecified address, or a private one when from is public
+func unroutable(addr, from string) bool {
+	ip := net.ParseIP(addr)
+	if ip == nil {
+		return false
+	}
+	if ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() {
+		return true
+	}
+	src := net.ParseIP(from)
+	return ip.IsPrivate() && src != nil && !src.IsPrivate() && !src.IsLoopback()
+}
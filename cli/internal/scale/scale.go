@@ -0,0 +1,167 @@
+package scale
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Generated file names, relative to the project directory
+const (
+	ComposeFile  = "docker-compose.scale.yml"
+	DialplanFile = "extensions_aava_scale.conf"
+)
+
+// DialplanContext is the generated round-robin subroutine
+const DialplanContext = "aava-scale"
+
+// Options describes a scaled deployment. Instance 1 is the existing
+// ai_engine; instances 2..Engines are added next to it.
+type Options struct {
+	Engines         int
+	AppName         string
+	AudioSocketPort int
+	HealthPort      int
+	RTPPort         int
+}
+
+// Instance is one engine instance of a scaled deployment
+type Instance struct {
+	Index           int
+	Service         string
+	Container       string
+	AppName         string
+	AudioSocketPort int
+	HealthPort      int
+	RTPPort         int
+}
+
+// DefaultOptions reads the base app name and ports from ai-agent.yaml
+// under dir, falling back to the engine defaults
+func DefaultOptions(dir string, engines int) Options {
+	o := Options{
+		Engines:         engines,
+		AppName:         "asterisk-ai-voice-agent",
+		AudioSocketPort: 8090,
+		HealthPort:      15000,
+		RTPPort:         18080,
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config", "ai-agent.yaml"))
+	if err != nil {
+		return o
+	}
+	var cfg struct {
+		Asterisk struct {
+			AppName string `yaml:"app_name"`
+		} `yaml:"asterisk"`
+		AudioSocket struct {
+			Port int `yaml:"port"`
+		} `yaml:"audiosocket"`
+		ExternalMedia struct {
+			RTPPort int `yaml:"rtp_port"`
+		} `yaml:"external_media"`
+		Health struct {
+			Port int `yaml:"port"`
+		} `yaml:"health"`
+	}
+	if yaml.Unmarshal(data, &cfg) != nil {
+		return o
+	}
+	if cfg.Asterisk.AppName != "" {
+		o.AppName = cfg.Asterisk.AppName
+	}
+	if cfg.AudioSocket.Port > 0 {
+		o.AudioSocketPort = cfg.AudioSocket.Port
+	}
+	if cfg.ExternalMedia.RTPPort > 0 {
+		o.RTPPort = cfg.ExternalMedia.RTPPort
+	}
+	if cfg.Health.Port > 0 {
+		o.HealthPort = cfg.Health.Port
+	}
+	return o
+}
+
+// Instances returns every instance, the existing ai_engine first
+func (o Options) Instances() []Instance {
+	instances := make([]Instance, 0, o.Engines)
+	for i := 1; i <= o.Engines; i++ {
+		inst := Instance{
+			Index:           i,
+			Service:         "ai-engine",
+			Container:       "ai_engine",
+			AppName:         o.AppName,
+			AudioSocketPort: o.AudioSocketPort + i - 1,
+			HealthPort:      o.HealthPort + i - 1,
+			RTPPort:         o.RTPPort + i - 1,
+		}
+		if i > 1 {
+			inst.Service = fmt.Sprintf("ai-engine-%d", i)
+			inst.Container = fmt.Sprintf("ai_engine_%d", i)
+			inst.AppName = fmt.Sprintf("%s-%d", o.AppName, i)
+		}
+		instances = append(instances, inst)
+	}
+	return instances
+}
+
+// GenerateCompose renders a compose override adding instances 2..N. Each
+// extends ai-engine and differs only in its ports and ARI app name.
+func GenerateCompose(o Options) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("# Generated by: agent scale --engines %d\n", o.Engines))
+	sb.WriteString("# Use with: docker compose -f docker-compose.yml -f " + ComposeFile + " up -d\n")
+	sb.WriteString("services:\n")
+	for _, inst := range o.Instances()[1:] {
+		sb.WriteString(fmt.Sprintf("  %s:\n", inst.Service))
+		sb.WriteString("    extends:\n")
+		sb.WriteString("      file: docker-compose.yml\n")
+		sb.WriteString("      service: ai-engine\n")
+		sb.WriteString(fmt.Sprintf("    container_name: %s\n", inst.Container))
+		sb.WriteString("    environment:\n")
+		sb.WriteString(fmt.Sprintf("      - AUDIOSOCKET_PORT=%d\n", inst.AudioSocketPort))
+		sb.WriteString(fmt.Sprintf("      - EXTERNAL_MEDIA_RTP_PORT=%d\n", inst.RTPPort))
+		sb.WriteString(fmt.Sprintf("      - HEALTH_BIND_PORT=%d\n", inst.HealthPort))
+		sb.WriteString(fmt.Sprintf("      - ASTERISK_APP_NAME=%s\n", inst.AppName))
+	}
+	return sb.String()
+}
+
+// GenerateDialplan renders a subroutine that sends each call to the next
+// instance's Stasis app, skipping instances whose app is not registered
+func GenerateDialplan(o Options) string {
+	n := o.Engines
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("; AI Voice Agent - round-robin across %d engine instances\n", n))
+	sb.WriteString(fmt.Sprintf("; Generated by: agent scale --engines %d\n", n))
+	sb.WriteString(fmt.Sprintf("; In the AI agent contexts replace Stasis(%s) with Gosub(%s,s,1)\n", o.AppName, DialplanContext))
+	sb.WriteString(fmt.Sprintf("[%s]\n", DialplanContext))
+	sb.WriteString(fmt.Sprintf("exten => s,1,NoOp(AI Voice Agent - %d engine instances)\n", n))
+	sb.WriteString(" same => n,Set(AAVA_TRY=0)\n")
+	sb.WriteString(fmt.Sprintf(" same => n(next),Set(GLOBAL(AAVA_RR)=$[(0${AAVA_RR} + 1) %% %d])\n", n))
+	sb.WriteString(fmt.Sprintf(" same => n,Set(AAVA_APP=${IF($[${AAVA_RR} = 0]?%s:%s-$[${AAVA_RR} + 1])})\n", o.AppName, o.AppName))
+	sb.WriteString(" same => n,Stasis(${AAVA_APP})\n")
+	sb.WriteString(" same => n,GotoIf($[\"${STASISSTATUS}\" != \"FAILED\"]?done)\n")
+	sb.WriteString(" same => n,Set(AAVA_TRY=$[${AAVA_TRY} + 1])\n")
+	sb.WriteString(fmt.Sprintf(" same => n,GotoIf($[${AAVA_TRY} < %d]?next)\n", n))
+	sb.WriteString(" same => n(done),Return()\n")
+	return sb.String()
+}
+
+// Write generates both files under dir. With one engine the compose
+// override is removed; the dialplan is still written so contexts using
+// Gosub(aava-scale,s,1) keep working.
+func Write(dir string, o Options) error {
+	composePath := filepath.Join(dir, ComposeFile)
+	if o.Engines <= 1 {
+		if err := os.Remove(composePath); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	} else if err := os.WriteFile(composePath, []byte(GenerateCompose(o)), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, DialplanFile), []byte(GenerateDialplan(o)), 0644)
+}
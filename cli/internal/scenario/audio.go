@@ -0,0 +1,210 @@
+package scenario
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// Rate is the sample rate of the caller audio sent, 8 kHz µ-law
+const Rate = 8000
+
+// ttsCommands are tried in order when a scenario sets no tts command
+var ttsCommands = [][]string{
+	{"espeak-ng", "-w", "{out}", "{text}"},
+	{"espeak", "-w", "{out}", "{text}"},
+	{"pico2wave", "-w", "{out}", "{text}"},
+	{"say", "-o", "{out}", "--data-format=LEI16@16000", "{text}"},
+}
+
+// Speak turns text into 8 kHz µ-law with the scenario's TTS command
+func (s *Scenario) Speak(ctx context.Context, text string) ([]byte, error) {
+	command := s.TTS
+	if len(command) == 0 {
+		for _, c := range ttsCommands {
+			if _, err := exec.LookPath(c[0]); err == nil {
+				command = c
+				break
+			}
+		}
+	}
+	if len(command) == 0 {
+		return nil, fmt.Errorf("no TTS command found: install espeak-ng or pico2wave, set tts: in the scenario, or use audio: steps")
+	}
+
+	dir, err := os.MkdirTemp("", "agent-scenario")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "say.wav")
+	args := make([]string, len(command))
+	for i, arg := range command {
+		args[i] = strings.Replace(strings.Replace(arg, "{out}", out, -1), "{text}", text, -1)
+	}
+	if output, err := selflog.CombinedOutput(exec.CommandContext(ctx, args[0], args[1:]...)); err != nil {
+		return nil, fmt.Errorf("%s: %v %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+	data, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("%s wrote no audio: %w", args[0], err)
+	}
+	return WAVToUlaw(data)
+}
+
+// LoadAudio reads a WAV file as 8 kHz µ-law
+func LoadAudio(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	ulaw, err := WAVToUlaw(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return ulaw, nil
+}
+
+// WAVToUlaw converts a 16-bit PCM or µ-law WAV file, mono or stereo, to
+// 8 kHz mono µ-law
+func WAVToUlaw(data []byte) ([]byte, error) {
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		return nil, fmt.Errorf("not a WAV file")
+	}
+	var format, channels, bits uint16
+	var rate uint32
+	var samples []byte
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := int(binary.LittleEndian.Uint32(data[pos+4 : pos+8]))
+		body := data[pos+8:]
+		if size > len(body) || size < 0 {
+			// Streaming writers leave the data size unset
+			size = len(body)
+		}
+		switch id {
+		case "fmt ":
+			if size < 16 {
+				return nil, fmt.Errorf("short fmt chunk")
+			}
+			format = binary.LittleEndian.Uint16(body[0:2])
+			channels = binary.LittleEndian.Uint16(body[2:4])
+			rate = binary.LittleEndian.Uint32(body[4:8])
+			bits = binary.LittleEndian.Uint16(body[14:16])
+		case "data":
+			samples = body[:size]
+		}
+		pos += 8 + size + size%2
+	}
+	if channels == 0 || rate == 0 || samples == nil {
+		return nil, fmt.Errorf("WAV file has no audio")
+	}
+
+	var pcm []int16
+	switch {
+	case format == 1 && bits == 16:
+		for i := 0; i+1 < len(samples); i += 2 {
+			pcm = append(pcm, int16(binary.LittleEndian.Uint16(samples[i:])))
+		}
+	case format == 7 && bits == 8:
+		for _, b := range samples {
+			pcm = append(pcm, UlawDecode(b))
+		}
+	default:
+		return nil, fmt.Errorf("unsupported WAV encoding (format %d, %d bits): use 16-bit PCM or µ-law", format, bits)
+	}
+	if channels > 1 {
+		mono := make([]int16, 0, len(pcm)/int(channels))
+		for i := 0; i+int(channels) <= len(pcm); i += int(channels) {
+			sum := 0
+			for c := 0; c < int(channels); c++ {
+				sum += int(pcm[i+c])
+			}
+			mono = append(mono, int16(sum/int(channels)))
+		}
+		pcm = mono
+	}
+	pcm = resample(pcm, int(rate), Rate)
+
+	out := make([]byte, len(pcm))
+	for i, v := range pcm {
+		out[i] = UlawEncode(v)
+	}
+	return out, nil
+}
+
+// resample converts by linear interpolation; it is meant for speech
+// going to an 8 kHz call, not for music
+func resample(pcm []int16, from, to int) []int16 {
+	if from == to || len(pcm) == 0 {
+		return pcm
+	}
+	n := int(int64(len(pcm)) * int64(to) / int64(from))
+	out := make([]int16, n)
+	for i := range out {
+		pos := float64(i) * float64(from) / float64(to)
+		j := int(pos)
+		if j+1 >= len(pcm) {
+			out[i] = pcm[len(pcm)-1]
+			continue
+		}
+		frac := pos - float64(j)
+		out[i] = int16(float64(pcm[j])*(1-frac) + float64(pcm[j+1])*frac)
+	}
+	return out
+}
+
+// UlawEncode encodes a 16-bit sample as G.711 µ-law
+func UlawEncode(sample int16) byte {
+	const bias, clip = 0x84, 32635
+	v := int(sample)
+	sign := 0
+	if v < 0 {
+		v = -v
+		sign = 0x80
+	}
+	if v > clip {
+		v = clip
+	}
+	v += bias
+	exponent := 7
+	for mask := 0x4000; v&mask == 0 && exponent > 0; mask >>= 1 {
+		exponent--
+	}
+	mantissa := (v >> uint(exponent+3)) & 0x0f
+	return ^byte(sign | exponent<<4 | mantissa)
+}
+
+// UlawDecode decodes a G.711 µ-law byte
+func UlawDecode(b byte) int16 {
+	b = ^b
+	sign := b & 0x80
+	exponent := int(b>>4) & 0x07
+	mantissa := int(b & 0x0f)
+	v := ((mantissa << 3) + 0x84) << uint(exponent)
+	v -= 0x84
+	if sign != 0 {
+		return int16(-v)
+	}
+	return int16(v)
+}
+
+// Level is the RMS level of a µ-law frame
+func Level(frame []byte) float64 {
+	if len(frame) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, b := range frame {
+		v := float64(UlawDecode(b))
+		sum += v * v
+	}
+	return math.Sqrt(sum / float64(len(frame)))
+}
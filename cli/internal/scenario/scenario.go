@@ -0,0 +1,295 @@
+package scenario
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Step kinds
+const (
+	StepExpect  = "expect"
+	StepTool    = "tool"
+	StepSay     = "say"
+	StepAudio   = "audio"
+	StepBargeIn = "barge_in"
+	StepPause   = "pause"
+	StepDTMF    = "dtmf"
+	StepHangup  = "hangup"
+)
+
+// Defaults for unset step timings
+const (
+	DefaultWithin  = 15 * time.Second
+	DefaultAfter   = 500 * time.Millisecond
+	DefaultTimeout = 3 * time.Minute
+)
+
+// dtmfPattern matches the digits ARI can send
+var dtmfPattern = regexp.MustCompile(`^[0-9A-D*#,]+$`)
+
+// Scenario describes a synthetic caller: where it calls and what it says
+// and expects, step by step
+type Scenario struct {
+	Name string `yaml:"name"`
+	// Dial is the endpoint called, e.g. Local/7000@from-internal or
+	// PJSIP/+15551234567@trunk
+	Dial     string `yaml:"dial"`
+	CallerID string `yaml:"caller_id,omitempty"`
+	// Timeout bounds the whole call, e.g. 3m
+	Timeout string `yaml:"timeout,omitempty"`
+	// TTS is the command turning say/barge_in text into a WAV file;
+	// {text} and {out} are replaced. Default: espeak-ng, espeak,
+	// pico2wave or say, whichever is installed.
+	TTS   []string `yaml:"tts,omitempty"`
+	Steps []Step   `yaml:"steps"`
+
+	dir     string
+	timeout time.Duration
+}
+
+// Step is one caller action or expectation; exactly one of the action
+// fields is set
+type Step struct {
+	// Expect waits for an agent response containing the text
+	// (case-insensitive), or matching it when written /regex/
+	Expect string `yaml:"expect,omitempty"`
+	// Tool waits for the agent to call the tool
+	Tool string `yaml:"tool,omitempty"`
+	// Say speaks the text (through TTS) once the agent is quiet
+	Say string `yaml:"say,omitempty"`
+	// Audio plays a WAV file once the agent is quiet
+	Audio string `yaml:"audio,omitempty"`
+	// BargeIn speaks the text while the agent talks; the agent should
+	// stop
+	BargeIn string `yaml:"barge_in,omitempty"`
+	Pause   string `yaml:"pause,omitempty"`
+	DTMF    string `yaml:"dtmf,omitempty"`
+	Hangup  bool   `yaml:"hangup,omitempty"`
+
+	// Within bounds an expect, tool or barge_in wait (default 15s)
+	Within string `yaml:"within,omitempty"`
+	// After is how long into the agent's speech a barge_in starts
+	// (default 500ms)
+	After string `yaml:"after,omitempty"`
+
+	kind    string
+	within  time.Duration
+	after   time.Duration
+	pause   time.Duration
+	pattern *regexp.Regexp
+}
+
+// Load reads and validates a scenario file
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Scenario{}
+	if err := yaml.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	s.dir = filepath.Dir(path)
+	if s.Name == "" {
+		s.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := s.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+// New builds and validates a scenario in code, e.g. a generated test
+// call
+func New(name, dial string, steps []Step) (*Scenario, error) {
+	s := &Scenario{Name: name, Dial: dial, Steps: steps, dir: "."}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Scenario) validate() error {
+	if len(s.Steps) == 0 {
+		return fmt.Errorf("no steps")
+	}
+	s.timeout = DefaultTimeout
+	if s.Timeout != "" {
+		d, err := time.ParseDuration(s.Timeout)
+		if err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q", s.Timeout)
+		}
+		s.timeout = d
+	}
+	for i := range s.Steps {
+		if err := s.Steps[i].validate(s.dir); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func (st *Step) validate(dir string) error {
+	set := []struct {
+		kind string
+		ok   bool
+	}{
+		{StepExpect, st.Expect != ""},
+		{StepTool, st.Tool != ""},
+		{StepSay, st.Say != ""},
+		{StepAudio, st.Audio != ""},
+		{StepBargeIn, st.BargeIn != ""},
+		{StepPause, st.Pause != ""},
+		{StepDTMF, st.DTMF != ""},
+		{StepHangup, st.Hangup},
+	}
+	for _, action := range set {
+		if !action.ok {
+			continue
+		}
+		if st.kind != "" {
+			return fmt.Errorf("both %s and %s set (one action per step)", st.kind, action.kind)
+		}
+		st.kind = action.kind
+	}
+	if st.kind == "" {
+		return fmt.Errorf("no action (expect, tool, say, audio, barge_in, pause, dtmf or hangup)")
+	}
+
+	var err error
+	if st.within, err = stepDuration(st.Within, DefaultWithin); err != nil {
+		return err
+	}
+	if st.after, err = stepDuration(st.After, DefaultAfter); err != nil {
+		return err
+	}
+	switch st.kind {
+	case StepExpect:
+		if len(st.Expect) > 2 && strings.HasPrefix(st.Expect, "/") && strings.HasSuffix(st.Expect, "/") {
+			if st.pattern, err = regexp.Compile("(?i)" + st.Expect[1:len(st.Expect)-1]); err != nil {
+				return fmt.Errorf("expect: %w", err)
+			}
+		}
+	case StepPause:
+		if st.pause, err = stepDuration(st.Pause, 0); err != nil || st.pause <= 0 {
+			return fmt.Errorf("invalid pause %q", st.Pause)
+		}
+	case StepDTMF:
+		if !dtmfPattern.MatchString(st.DTMF) {
+			return fmt.Errorf("invalid dtmf %q (0-9, A-D, *, # and ',' for a pause)", st.DTMF)
+		}
+	case StepAudio:
+		if !filepath.IsAbs(st.Audio) {
+			st.Audio = filepath.Join(dir, st.Audio)
+		}
+		if _, err := os.Stat(st.Audio); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func stepDuration(value string, fallback time.Duration) (time.Duration, error) {
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	return d, nil
+}
+
+// CallTimeout bounds the whole call
+func (s *Scenario) CallTimeout() time.Duration {
+	return s.timeout
+}
+
+// Kind is the step's action
+func (st *Step) Kind() string {
+	return st.kind
+}
+
+// WithinDuration bounds the step's wait
+func (st *Step) WithinDuration() time.Duration {
+	return st.within
+}
+
+// AfterDuration is how long into the agent's speech a barge_in starts
+func (st *Step) AfterDuration() time.Duration {
+	return st.after
+}
+
+// PauseDuration is how long a pause step lasts
+func (st *Step) PauseDuration() time.Duration {
+	return st.pause
+}
+
+// Matches reports whether an agent response satisfies an expect step
+func (st *Step) Matches(text string) bool {
+	if st.pattern != nil {
+		return st.pattern.MatchString(text)
+	}
+	return strings.Contains(strings.ToLower(text), strings.ToLower(st.Expect))
+}
+
+// Describe is a one-line summary of the step
+func (st *Step) Describe() string {
+	switch st.kind {
+	case StepExpect:
+		return fmt.Sprintf("expect %q", st.Expect)
+	case StepTool:
+		return "expect tool " + st.Tool
+	case StepSay:
+		return fmt.Sprintf("say %q", st.Say)
+	case StepAudio:
+		return "play " + filepath.Base(st.Audio)
+	case StepBargeIn:
+		return fmt.Sprintf("barge in %q", st.BargeIn)
+	case StepPause:
+		return "pause " + st.pause.String()
+	case StepDTMF:
+		return "dtmf " + st.DTMF
+	}
+	return "hang up"
+}
+
+// Text is what a say or barge_in step speaks
+func (st *Step) Text() string {
+	if st.kind == StepBargeIn {
+		return st.BargeIn
+	}
+	return st.Say
+}
+
+// Result is the outcome of one step
+type Result struct {
+	Step    string `json:"step"`
+	Kind    string `json:"kind"`
+	Passed  bool   `json:"passed"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+	// ElapsedMs is how long the step took, e.g. until the expected
+	// response arrived
+	ElapsedMs int64 `json:"elapsed_ms"`
+}
+
+// Report is the outcome of a scenario run
+type Report struct {
+	Scenario string   `json:"scenario"`
+	CallID   string   `json:"call_id,omitempty"`
+	Passed   bool     `json:"passed"`
+	Steps    []Result `json:"steps"`
+	// ElapsedMs is how long the call took from dialling
+	ElapsedMs int64 `json:"elapsed_ms"`
+	// Transcript is what the agent said, in order
+	Transcript []string `json:"transcript"`
+	// Heard is what the engine transcribed of the caller, in order
+	Heard []string `json:"heard,omitempty"`
+}
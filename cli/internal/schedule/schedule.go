@@ -0,0 +1,260 @@
+// Package schedule decides whether a moment falls in business hours:
+// hour ranges on working days, less holidays from the configuration or
+// an iCalendar file.
+package schedule
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/fraud"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// maxEventDays bounds how many days one calendar event may cover
+const maxEventDays = 31
+
+// Calendar is a parsed schedule
+type Calendar struct {
+	loc    *time.Location
+	ranges [][2]int
+	days   map[time.Weekday]bool
+	// dates are holidays as 2006-01-02, yearly ones as 01-02
+	dates  map[string]bool
+	yearly map[string]bool
+}
+
+// Configured reports whether a schedule sets anything
+func Configured(s settings.Schedule) bool {
+	return s.Hours != "" || s.Days != "" || len(s.Holidays) > 0 || s.HolidayCalendar != ""
+}
+
+// Merge returns route with the fields it leaves empty taken from base
+func Merge(base, route settings.Schedule) settings.Schedule {
+	if route.Hours == "" {
+		route.Hours = base.Hours
+	}
+	if route.Days == "" {
+		route.Days = base.Days
+	}
+	if route.Timezone == "" {
+		route.Timezone = base.Timezone
+	}
+	if len(route.Holidays) == 0 {
+		route.Holidays = base.Holidays
+	}
+	if route.HolidayCalendar == "" {
+		route.HolidayCalendar = base.HolidayCalendar
+	}
+	return route
+}
+
+// New parses a schedule. Empty Hours are the whole day, empty Days every
+// day; fallback is the zone when Timezone is empty.
+func New(s settings.Schedule, fallback *time.Location) (*Calendar, error) {
+	c := &Calendar{loc: fallback, dates: make(map[string]bool), yearly: make(map[string]bool)}
+	if c.loc == nil {
+		c.loc = time.Local
+	}
+	if s.Timezone != "" {
+		loc, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return nil, fmt.Errorf("timezone %q: %w", s.Timezone, err)
+		}
+		c.loc = loc
+	}
+	if s.Hours != "" {
+		for _, r := range strings.Split(s.Hours, ",") {
+			from, to, err := parseRange(r)
+			if err != nil {
+				return nil, err
+			}
+			c.ranges = append(c.ranges, [2]int{from, to})
+		}
+	}
+	if s.Days != "" {
+		days, err := fraud.ParseDays(s.Days)
+		if err != nil {
+			return nil, fmt.Errorf("days: %w", err)
+		}
+		c.days = make(map[time.Weekday]bool)
+		for _, d := range days {
+			c.days[d] = true
+		}
+	}
+	for _, h := range s.Holidays {
+		if err := c.addHoliday(strings.TrimSpace(h)); err != nil {
+			return nil, err
+		}
+	}
+	if s.HolidayCalendar != "" {
+		if err := c.loadICS(s.HolidayCalendar); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// parseRange reads "08:00-18:00" into minutes of the day
+func parseRange(s string) (int, int, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), "-", 2)
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("hours %q: use HH:MM-HH:MM", s)
+	}
+	var bounds [2]int
+	for i, p := range parts {
+		p = strings.TrimSpace(p)
+		if i == 1 && p == "24:00" {
+			bounds[i] = 24 * 60
+			continue
+		}
+		t, err := time.Parse("15:04", p)
+		if err != nil {
+			return 0, 0, fmt.Errorf("hours %q: use HH:MM-HH:MM", s)
+		}
+		bounds[i] = t.Hour()*60 + t.Minute()
+	}
+	return bounds[0], bounds[1], nil
+}
+
+func (c *Calendar) addHoliday(h string) error {
+	if _, err := time.Parse("2006-01-02", h); err == nil {
+		c.dates[h] = true
+		return nil
+	}
+	if _, err := time.Parse("01-02", h); err == nil {
+		c.yearly[h] = true
+		return nil
+	}
+	return fmt.Errorf("holiday %q: use YYYY-MM-DD, or MM-DD for every year", h)
+}
+
+// loadICS adds the all-day events of an iCalendar file as holidays.
+// Events repeating yearly (RRULE:FREQ=YEARLY) are holidays every year.
+func (c *Calendar) loadICS(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("holiday calendar: %w", err)
+	}
+	defer f.Close()
+
+	// Unfold continuation lines first
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("holiday calendar: %w", err)
+	}
+
+	var start, end time.Time
+	yearly := false
+	inEvent := false
+	for _, line := range lines {
+		name, value := icsProperty(line)
+		switch {
+		case line == "BEGIN:VEVENT":
+			inEvent, yearly = true, false
+			start, end = time.Time{}, time.Time{}
+		case line == "END:VEVENT":
+			inEvent = false
+			if start.IsZero() {
+				continue
+			}
+			if end.IsZero() || !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			// DTEND of all-day events is exclusive
+			for d, n := start, 0; d.Before(end) && n < maxEventDays; d, n = d.AddDate(0, 0, 1), n+1 {
+				if yearly {
+					c.yearly[d.Format("01-02")] = true
+				} else {
+					c.dates[d.Format("2006-01-02")] = true
+				}
+			}
+		case !inEvent:
+		case name == "DTSTART":
+			start = icsDate(value)
+		case name == "DTEND":
+			end = icsDate(value)
+		case name == "RRULE":
+			yearly = strings.Contains(strings.ToUpper(value), "FREQ=YEARLY")
+		}
+	}
+	return nil
+}
+
+// icsProperty splits "DTSTART;VALUE=DATE:20261225" into the property
+// name without parameters and its value
+func icsProperty(line string) (string, string) {
+	i := strings.Index(line, ":")
+	if i < 0 {
+		return "", ""
+	}
+	name := line[:i]
+	if j := strings.Index(name, ";"); j >= 0 {
+		name = name[:j]
+	}
+	return strings.ToUpper(name), line[i+1:]
+}
+
+// icsDate reads the date of a DATE or DATE-TIME value
+func icsDate(value string) time.Time {
+	if len(value) < 8 {
+		return time.Time{}
+	}
+	t, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// Holiday reports whether t falls on a holiday
+func (c *Calendar) Holiday(t time.Time) bool {
+	t = t.In(c.loc)
+	return c.dates[t.Format("2006-01-02")] || c.yearly[t.Format("01-02")]
+}
+
+// InHours reports whether t falls in business hours
+func (c *Calendar) InHours(t time.Time) bool {
+	if c.Holiday(t) {
+		return false
+	}
+	t = t.In(c.loc)
+	m := t.Hour()*60 + t.Minute()
+	if len(c.ranges) == 0 {
+		return c.days == nil || c.days[t.Weekday()]
+	}
+	for _, r := range c.ranges {
+		from, to := r[0], r[1]
+		if from <= to {
+			if m >= from && m < to && c.workday(t.Weekday()) {
+				return true
+			}
+			continue
+		}
+		// Hours across midnight, e.g. 22:00-06:00: the early part
+		// belongs to the shift that started the day before
+		if m >= from && c.workday(t.Weekday()) {
+			return true
+		}
+		if m < to && c.workday((t.Weekday()+6)%7) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Calendar) workday(d time.Weekday) bool {
+	return c.days == nil || c.days[d]
+}
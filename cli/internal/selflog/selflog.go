@@ -0,0 +1,412 @@
+// Package selflog records what the CLI itself does: each run, the
+// external commands it starts and the HTTP APIs it calls, as JSON lines
+// in ~/.agent/cli.log. When the troubleshooter misbehaves on a machine,
+// 'agent selfcheck bundle' packs the log for support.
+package selflog
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Levels
+const (
+	LevelInfo  = "info"
+	LevelDebug = "debug"
+	LevelError = "error"
+)
+
+// Events
+const (
+	EventRun  = "run"
+	EventExit = "exit"
+	EventExec = "exec"
+	EventHTTP = "http"
+	// EventPanic is a recovered panic, with its stack as Detail
+	EventPanic = "panic"
+)
+
+// maxSize is the log size at which it is rotated to <file>.1
+const maxSize = 5 << 20
+
+// outputTail bounds the command output kept of failed commands
+const outputTail = 512
+
+// Off as the log file disables the log file
+const Off = "off"
+
+// Entry is one log line
+type Entry struct {
+	Time  time.Time `json:"time"`
+	Level string    `json:"level"`
+	Event string    `json:"event"`
+	PID   int       `json:"pid"`
+	// Command is the command line of run and exec events
+	Command    string `json:"command,omitempty"`
+	Method     string `json:"method,omitempty"`
+	URL        string `json:"url,omitempty"`
+	Status     int    `json:"status,omitempty"`
+	ExitCode   int    `json:"exit_code,omitempty"`
+	DurationMs int64  `json:"duration_ms,omitempty"`
+	Error      string `json:"error,omitempty"`
+	// Output is the end of a command's output: of failed commands, and
+	// of all with debug logging on
+	Output string `json:"output,omitempty"`
+	// Detail carries free text, e.g. the CLI version of a run
+	Detail string `json:"detail,omitempty"`
+}
+
+var (
+	mu       sync.Mutex
+	file     *os.File
+	filePath string
+	fileSize int64
+	debug    bool
+)
+
+// Path is the default log file
+func Path() string {
+	return filepath.Join(settings.Dir(), "cli.log")
+}
+
+// Open starts logging to path ("" for Path(), Off for no file). With
+// dbg, debug entries are logged too and every entry is also written to
+// stderr. The log is rotated once it outgrows 5 MB, also while
+// long-running commands write to it.
+func Open(path string, dbg bool) error {
+	mu.Lock()
+	defer mu.Unlock()
+	debug = dbg
+	if file != nil {
+		file.Close()
+		file = nil
+	}
+	if path == Off {
+		return nil
+	}
+	if path == "" {
+		path = Path()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	filePath = path
+	return openFile()
+}
+
+// openFile opens filePath for appending, rotating it first when full
+func openFile() error {
+	if info, err := os.Stat(filePath); err == nil && info.Size() > maxSize {
+		os.Rename(filePath, filePath+".1")
+	}
+	f, err := os.OpenFile(filePath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	file, fileSize = f, info.Size()
+	return nil
+}
+
+// Close stops logging
+func Close() {
+	mu.Lock()
+	defer mu.Unlock()
+	if file != nil {
+		file.Close()
+		file = nil
+	}
+}
+
+// Debug reports whether debug logging is on
+func Debug() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return debug
+}
+
+// Write logs an entry; debug entries only with debug logging on
+func Write(e Entry) {
+	mu.Lock()
+	defer mu.Unlock()
+	if e.Level == LevelDebug && !debug {
+		return
+	}
+	if e.Level == "" {
+		e.Level = LevelInfo
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.PID = os.Getpid()
+	if file != nil {
+		data, err := json.Marshal(e)
+		if err == nil {
+			n, _ := file.Write(append(data, '\n'))
+			fileSize += int64(n)
+		}
+		if fileSize > maxSize {
+			file.Close()
+			file = nil
+			openFile()
+		}
+	}
+	if debug {
+		fmt.Fprintln(os.Stderr, "🔎 "+e.line())
+	}
+}
+
+// line renders an entry for stderr
+func (e *Entry) line() string {
+	parts := []string{e.Event}
+	switch {
+	case e.Command != "":
+		parts = append(parts, e.Command)
+	case e.URL != "":
+		parts = append(parts, e.Method, e.URL)
+	}
+	if e.Status != 0 {
+		parts = append(parts, fmt.Sprintf("→ %d", e.Status))
+	}
+	if e.ExitCode != 0 {
+		parts = append(parts, fmt.Sprintf("→ exit %d", e.ExitCode))
+	}
+	if e.DurationMs != 0 {
+		parts = append(parts, fmt.Sprintf("(%dms)", e.DurationMs))
+	}
+	if e.Error != "" {
+		parts = append(parts, "error: "+e.Error)
+	}
+	if e.Detail != "" {
+		parts = append(parts, e.Detail)
+	}
+	return strings.Join(parts, " ")
+}
+
+// secretFlags are the flags whose value is a credential
+var secretFlags = map[string]bool{
+	"-u": true, "--user": true, "-p": true, "--password": true, "--token": true, "--secret": true,
+}
+
+// secretAssignment finds key=value arguments whose key names a credential
+var secretAssignment = regexp.MustCompile(`(?i)^([^=]*(pass|token|secret|key|auth)[^=]*=).+`)
+
+// urlPassword finds the password of URLs with user info
+var urlPassword = regexp.MustCompile(`(://[^:/@\s]*:)[^@/\s]+@`)
+
+// CommandLine renders a command's arguments for the log, with the
+// values of credential flags and assignments and URL passwords masked
+func CommandLine(args []string) string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		switch {
+		case i > 0 && secretFlags[args[i-1]]:
+			a = "***"
+		case secretAssignment.MatchString(a):
+			a = secretAssignment.ReplaceAllString(a, "${1}***")
+		case urlPassword.MatchString(a):
+			a = urlPassword.ReplaceAllString(a, "${1}***@")
+		case strings.ContainsAny(a, " \t\"'"):
+			a = fmt.Sprintf("%q", a)
+		}
+		out[i] = a
+	}
+	return strings.Join(out, " ")
+}
+
+// execDone logs a finished command, with the end of its output when
+// it failed or debug logging is on
+func execDone(cmd *exec.Cmd, start time.Time, err error, output []byte) {
+	e := Entry{
+		Event:      EventExec,
+		Command:    CommandLine(cmd.Args),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		e.Level = LevelError
+		e.Error = err.Error()
+		if exit, ok := err.(*exec.ExitError); ok {
+			e.ExitCode = exit.ExitCode()
+		}
+	}
+	if err != nil || Debug() {
+		if len(output) > outputTail {
+			output = output[len(output)-outputTail:]
+		}
+		e.Output = strings.TrimSpace(string(output))
+	}
+	Write(e)
+}
+
+// Run runs cmd like cmd.Run and logs it
+func Run(cmd *exec.Cmd) error {
+	start := time.Now()
+	err := cmd.Run()
+	execDone(cmd, start, err, nil)
+	return err
+}
+
+// Output runs cmd like cmd.Output and logs it
+func Output(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.Output()
+	tail := out
+	if exit, ok := err.(*exec.ExitError); ok {
+		tail = exit.Stderr
+	}
+	execDone(cmd, start, err, tail)
+	return out, err
+}
+
+// CombinedOutput runs cmd like cmd.CombinedOutput and logs it
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	start := time.Now()
+	out, err := cmd.CombinedOutput()
+	execDone(cmd, start, err, out)
+	return out, err
+}
+
+// Start starts cmd like cmd.Start and logs it; the command's end is not
+// logged
+func Start(cmd *exec.Cmd) error {
+	err := cmd.Start()
+	e := Entry{Event: EventExec, Command: CommandLine(cmd.Args), Detail: "started"}
+	if err != nil {
+		e.Level = LevelError
+		e.Error = err.Error()
+	}
+	Write(e)
+	return err
+}
+
+// transport logs the requests of an HTTP transport
+type transport struct {
+	base http.RoundTripper
+}
+
+// Transport wraps base so that every request is logged with its
+// status and duration. Query strings and user info are left out of the
+// log, since APIs carry credentials there.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if _, ok := base.(*transport); ok {
+		return base
+	}
+	return &transport{base: base}
+}
+
+// Install logs the requests of every client using the default transport
+func Install() {
+	http.DefaultTransport = Transport(http.DefaultTransport)
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	e := Entry{
+		Event:      EventHTTP,
+		Method:     req.Method,
+		URL:        logURL(req.URL),
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		e.Level = LevelError
+		e.Error = err.Error()
+	} else {
+		e.Status = resp.StatusCode
+		if resp.StatusCode >= 400 {
+			e.Level = LevelError
+		}
+	}
+	Write(e)
+	return resp, err
+}
+
+// secretSegment matches a path segment of letters and digits mixed,
+// the shape of webhook tokens and GUIDs; with 20 characters or more it
+// is masked, as is any segment with ':' (Telegram's bot<id>:<token>) or
+// '@' (Teams connector IDs)
+var secretSegment = regexp.MustCompile(`^[A-Za-z0-9_-]*(?:[0-9][A-Za-z0-9_-]*[A-Za-z]|[A-Za-z][A-Za-z0-9_-]*[0-9])[A-Za-z0-9_-]*$`)
+
+// logURL is a URL without user info, query and the path segments that
+// carry credentials (webhook and bot tokens)
+func logURL(u *url.URL) string {
+	c := *u
+	c.User = nil
+	segments := strings.Split(c.Path, "/")
+	for i, seg := range segments {
+		if strings.Contains(seg, ":") || strings.Contains(seg, "@") || (len(seg) >= 20 && secretSegment.MatchString(seg)) {
+			segments[i] = "***"
+		}
+	}
+	// RawPath keeps the mask readable; String falls back to escaping
+	// Path when it is not a valid encoding of it
+	c.Path = strings.Join(segments, "/")
+	c.RawPath = c.Path
+	if c.RawQuery != "" {
+		c.RawQuery = "…"
+	}
+	c.Fragment = ""
+	return c.String()
+}
+
+// Files returns the log files of path ("" for Path()), older first
+func Files(path string) []string {
+	if path == "" || path == Off {
+		path = Path()
+	}
+	var files []string
+	for _, p := range []string{path + ".1", path} {
+		if _, err := os.Stat(p); err == nil {
+			files = append(files, p)
+		}
+	}
+	return files
+}
+
+// File is one file of a bundle
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Bundle writes files under dir/ into a tar.gz
+func Bundle(dir string, files []File) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, f := range files {
+		hdr := &tar.Header{Name: dir + "/" + f.Name, Mode: 0600, Size: int64(len(f.Data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(f.Data); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,391 @@
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Repo is the GitHub repository CLI releases are published to
+const Repo = "hkjarral/Asterisk-AI-Voice-Agent"
+
+// Release channels
+const (
+	ChannelStable = "stable"
+	ChannelBeta   = "beta"
+)
+
+// Release asset names besides the binaries
+const (
+	checksumsAsset = "SHA256SUMS"
+	signatureAsset = "SHA256SUMS.sig"
+)
+
+// maxBinarySize bounds a downloaded binary
+const maxBinarySize = 200 << 20
+
+// Release is a published CLI release
+type Release struct {
+	Tag        string
+	Prerelease bool
+	Published  time.Time
+	URL        string
+	Assets     map[string]string // name -> download URL
+}
+
+// Client queries GitHub releases
+type Client struct {
+	http  *http.Client
+	api   string
+	token string
+}
+
+// NewClient creates a client. GITHUB_TOKEN, if set, raises the API rate limit.
+func NewClient() *Client {
+	return &Client{
+		http:  &http.Client{Timeout: 5 * time.Minute},
+		api:   "https://api.github.com",
+		token: os.Getenv("GITHUB_TOKEN"),
+	}
+}
+
+func (c *Client) get(ctx context.Context, url string, accept string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", accept)
+	if c.token != "" && strings.HasPrefix(url, c.api) {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		resp.Body.Close()
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+type githubRelease struct {
+	TagName     string    `json:"tag_name"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	HTMLURL     string    `json:"html_url"`
+	Assets      []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+func (g githubRelease) release() *Release {
+	r := &Release{
+		Tag:        g.TagName,
+		Prerelease: g.Prerelease,
+		Published:  g.PublishedAt,
+		URL:        g.HTMLURL,
+		Assets:     make(map[string]string),
+	}
+	for _, a := range g.Assets {
+		r.Assets[a.Name] = a.URL
+	}
+	return r
+}
+
+// Latest returns the newest release on channel. Stable skips prereleases;
+// beta includes them.
+func (c *Client) Latest(ctx context.Context, channel string) (*Release, error) {
+	if channel != ChannelStable && channel != ChannelBeta {
+		return nil, fmt.Errorf("unknown channel %q (use stable or beta)", channel)
+	}
+	resp, err := c.get(ctx, c.api+"/repos/"+Repo+"/releases?per_page=50", "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var releases []githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, err
+	}
+	var best *Release
+	for _, g := range releases {
+		if g.Draft || (g.Prerelease && channel == ChannelStable) {
+			continue
+		}
+		if _, ok := parseVersion(g.TagName); !ok {
+			continue
+		}
+		if best == nil || Compare(g.TagName, best.Tag) > 0 {
+			best = g.release()
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no %s releases found", channel)
+	}
+	return best, nil
+}
+
+// ByTag returns a specific release
+func (c *Client) ByTag(ctx context.Context, tag string) (*Release, error) {
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+	resp, err := c.get(ctx, c.api+"/repos/"+Repo+"/releases/tags/"+tag, "application/vnd.github+json")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	var g githubRelease
+	if err := json.NewDecoder(resp.Body).Decode(&g); err != nil {
+		return nil, err
+	}
+	return g.release(), nil
+}
+
+// AssetName returns the release binary name for a platform
+func AssetName(goos, goarch string) string {
+	name := "agent-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func (c *Client) download(ctx context.Context, url string, limit int64) ([]byte, error) {
+	resp, err := c.get(ctx, url, "application/octet-stream")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+// ErrNoPublicKey is returned by Download when there is no key to check
+// the release signature with and skipping the check was not asked for
+var ErrNoPublicKey = fmt.Errorf("this build has no update signing key; refusing to install a binary whose signature cannot be verified")
+
+// Download fetches this platform's binary from rel and verifies it
+// against SHA256SUMS, whose SHA256SUMS.sig must be a valid signature by
+// publicKey (base64 ed25519). The signature check is only skipped with
+// skipSignature. It reports whether the signature was verified.
+func (c *Client) Download(ctx context.Context, rel *Release, publicKey string, skipSignature bool) ([]byte, bool, error) {
+	if publicKey == "" && !skipSignature {
+		return nil, false, ErrNoPublicKey
+	}
+	asset := AssetName(runtime.GOOS, runtime.GOARCH)
+	binURL, ok := rel.Assets[asset]
+	if !ok {
+		return nil, false, fmt.Errorf("release %s has no %s binary", rel.Tag, asset)
+	}
+	sumsURL, ok := rel.Assets[checksumsAsset]
+	if !ok {
+		return nil, false, fmt.Errorf("release %s has no %s; refusing to install an unverified binary", rel.Tag, checksumsAsset)
+	}
+
+	sums, err := c.download(ctx, sumsURL, 1<<20)
+	if err != nil {
+		return nil, false, err
+	}
+
+	signed := false
+	if !skipSignature {
+		sigURL, ok := rel.Assets[signatureAsset]
+		if !ok {
+			return nil, false, fmt.Errorf("release %s is not signed (%s missing)", rel.Tag, signatureAsset)
+		}
+		sig, err := c.download(ctx, sigURL, 4096)
+		if err != nil {
+			return nil, false, err
+		}
+		if err := VerifySignature(publicKey, sums, sig); err != nil {
+			return nil, false, fmt.Errorf("%s: %w", checksumsAsset, err)
+		}
+		signed = true
+	}
+
+	expected, err := checksumFor(sums, asset)
+	if err != nil {
+		return nil, false, err
+	}
+	bin, err := c.download(ctx, binURL, maxBinarySize)
+	if err != nil {
+		return nil, false, err
+	}
+	sum := sha256.Sum256(bin)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return nil, false, fmt.Errorf("checksum mismatch for %s: expected %s, got %s", asset, expected, actual)
+	}
+	return bin, signed, nil
+}
+
+// checksumFor finds asset in sha256sum output
+func checksumFor(sums []byte, asset string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(sums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == asset {
+			return strings.ToLower(fields[0]), nil
+		}
+	}
+	return "", fmt.Errorf("%s not listed in %s", asset, checksumsAsset)
+}
+
+// VerifySignature checks an ed25519 signature (raw or base64) of data
+// against the base64 update public key. Release checksums and the
+// known-issue rules are signed with the same key.
+func VerifySignature(publicKey string, data, sig []byte) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid update public key")
+	}
+	if len(sig) != ed25519.SignatureSize {
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sig)))
+		if err != nil || len(decoded) != ed25519.SignatureSize {
+			return fmt.Errorf("invalid signature encoding")
+		}
+		sig = decoded
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("signature verification failed")
+	}
+	return nil
+}
+
+// Install atomically replaces the binary at exe with bin. The new file is
+// written next to exe and renamed over it, so an interrupted update never
+// leaves a partial binary. On Windows the running binary is moved aside
+// to exe.old first.
+func Install(exe string, bin []byte) error {
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	info, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(exe)
+	tmp, err := os.CreateTemp(dir, ".agent-update-*")
+	if err != nil {
+		return fmt.Errorf("cannot write to %s (try sudo): %w", dir, err)
+	}
+	tmpName := tmp.Name()
+	defer os.Remove(tmpName)
+
+	if _, err := tmp.Write(bin); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpName, info.Mode().Perm()|0111); err != nil {
+		return err
+	}
+
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		if err := os.Rename(exe, old); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpName, exe); err != nil {
+			os.Rename(old, exe)
+			return err
+		}
+		return nil
+	}
+	return os.Rename(tmpName, exe)
+}
+
+// parseVersion parses vMAJOR.MINOR.PATCH[-PRE]
+func parseVersion(v string) ([4]int, bool) {
+	var out [4]int
+	v = strings.TrimPrefix(strings.TrimSpace(v), "v")
+	pre := ""
+	if i := strings.IndexAny(v, "-+"); i >= 0 {
+		v, pre = v[:i], v[i+1:]
+	}
+	parts := strings.Split(v, ".")
+	if len(parts) < 2 || len(parts) > 3 {
+		return out, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, false
+		}
+		out[i] = n
+	}
+	// Releases sort after their prereleases; prerelease numbers (beta.2)
+	// order among themselves
+	out[3] = 1 << 30
+	if pre != "" {
+		out[3] = 0
+		if i := strings.LastIndexAny(pre, ".-"); i >= 0 {
+			if n, err := strconv.Atoi(pre[i+1:]); err == nil {
+				out[3] = n
+			}
+		}
+	}
+	return out, true
+}
+
+// Compare orders two versions (-1, 0, 1). Unparsable versions such as
+// "dev" sort before every release.
+func Compare(a, b string) int {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA && !okB:
+		return 0
+	case !okA:
+		return -1
+	case !okB:
+		return 1
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			if va[i] < vb[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+// MajorMinor returns the major and minor numbers of a release version
+func MajorMinor(v string) (int, int, bool) {
+	p, ok := parseVersion(v)
+	return p[0], p[1], ok
+}
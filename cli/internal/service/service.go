@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// Service names accepted by restart
+const (
+	Engine   = "ai_engine"
+	Asterisk = "asterisk"
+	All      = "all"
+)
+
+// Names lists the restartable services
+var Names = []string{Engine, Asterisk, All}
+
+// ContainerState is the identity of a running container
+type ContainerState struct {
+	ID        string
+	Image     string
+	ImageID   string
+	Status    string
+	StartedAt time.Time
+	Restarts  int
+}
+
+// InspectContainer returns the state of a container, or an error if it
+// does not exist
+func InspectContainer(ctx context.Context, name string) (*ContainerState, error) {
+	client, err := docker.Default()
+	if err != nil {
+		return nil, err
+	}
+	c, err := client.Inspect(ctx, name)
+	if err != nil {
+		if docker.IsNotFound(err) {
+			return nil, fmt.Errorf("container %s not found", name)
+		}
+		return nil, err
+	}
+	return &ContainerState{
+		ID:        shortID(c.ID),
+		Image:     c.Config.Image,
+		ImageID:   shortID(strings.TrimPrefix(c.Image, "sha256:")),
+		Status:    c.State.Status,
+		StartedAt: c.State.StartedAt,
+		Restarts:  c.RestartCount,
+	}, nil
+}
+
+// RestartContainer restarts a container, giving it stopTimeout to exit
+func RestartContainer(ctx context.Context, name string, stopTimeout time.Duration) error {
+	client, err := docker.Default()
+	if err != nil {
+		return err
+	}
+	if err := client.Restart(ctx, name, stopTimeout); err != nil {
+		return fmt.Errorf("restart %s: %w", name, err)
+	}
+	return nil
+}
+
+// RestartAsterisk restarts Asterisk, either the "asterisk" container or the
+// host's systemd unit, and returns how it was restarted
+func RestartAsterisk(ctx context.Context, stopTimeout time.Duration) (string, error) {
+	if _, err := InspectContainer(ctx, Asterisk); err == nil {
+		return "docker restart " + Asterisk, RestartContainer(ctx, Asterisk, stopTimeout)
+	}
+	if err := remote.LookPath("systemctl"); err != nil {
+		return "", fmt.Errorf("no %s container and no systemctl; restart Asterisk manually", Asterisk)
+	}
+	args := []string{"systemctl", "restart", "asterisk"}
+	out, err := selflog.CombinedOutput(remote.Command(ctx, args[0], args[1:]...))
+	if err != nil {
+		return "", fmt.Errorf("%s: %v: %s (try sudo)", strings.Join(args, " "), err, strings.TrimSpace(string(out)))
+	}
+	return strings.Join(args, " "), nil
+}
+
+// WaitFor polls check every interval until it succeeds or timeout passes,
+// returning the last error
+func WaitFor(ctx context.Context, timeout, interval time.Duration, check func(context.Context) error) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		err := check(ctx)
+		if err == nil {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(interval):
+		}
+	}
+}
+
+// Drain waits up to grace for count to reach zero, calling progress after
+// each poll. It returns the calls still active when it gave up.
+func Drain(ctx context.Context, grace, interval time.Duration, count func(context.Context) (int, error), progress func(active int, left time.Duration)) (int, error) {
+	deadline := time.Now().Add(grace)
+	for {
+		active, err := count(ctx)
+		if err != nil {
+			return 0, err
+		}
+		left := time.Until(deadline)
+		if left < 0 {
+			left = 0
+		}
+		if progress != nil {
+			progress(active, left)
+		}
+		if active == 0 || left == 0 {
+			return active, nil
+		}
+		wait := interval
+		if wait > left {
+			wait = left
+		}
+		select {
+		case <-ctx.Done():
+			return active, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
@@ -0,0 +1,350 @@
+package sip
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultFile is where FreePBX and most installs take hand-written PJSIP
+// sections; pjsip.conf includes it
+const DefaultFile = "/etc/asterisk/pjsip_custom.conf"
+
+// Template holds an ITSP's usual trunk settings. Values are defaults the
+// wizard offers; the account-specific ones (server for some ITSPs,
+// username, password, DID) are always asked.
+type Template struct {
+	Key  string
+	Name string
+	// Server is the ITSP's SIP host; empty when it is account specific
+	Server     string
+	ServerHint string
+	Port       int
+	// Register sends a REGISTER so the ITSP knows where to send calls;
+	// without it the ITSP is configured with this host's public address
+	Register bool
+	// Auth is whether outbound calls authenticate with username/password
+	Auth bool
+	// Match lists the addresses inbound calls come from; empty means the
+	// server itself
+	Match     []string
+	Codecs    string
+	UserHint  string
+	DIDFormat string
+	Notes     []string
+}
+
+// Templates are the ITSPs the wizard knows about; "generic" asks for
+// everything
+var Templates = []Template{
+	{
+		Key:       "telnyx",
+		Name:      "Telnyx (credential connection)",
+		Server:    "sip.telnyx.com",
+		Port:      5060,
+		Register:  true,
+		Auth:      true,
+		Codecs:    "ulaw,alaw",
+		UserHint:  "SIP connection username",
+		DIDFormat: "+15551234567",
+		Notes:     []string{"Set the connection's inbound number format to +E.164"},
+	},
+	{
+		Key:        "twilio",
+		Name:       "Twilio Elastic SIP Trunking",
+		ServerHint: "your termination URI, e.g. example.pstn.twilio.com",
+		Port:       5060,
+		Auth:       true,
+		// Twilio's North America signalling ranges
+		Match:     []string{"54.172.60.0/30", "54.244.51.0/30"},
+		Codecs:    "ulaw,alaw",
+		UserHint:  "credential list username",
+		DIDFormat: "+15551234567",
+		Notes: []string{
+			"Set the trunk's origination URI to sip:<this host's public address>:5060",
+			"Trunks outside North America use other signalling ranges; add them to the identify match",
+		},
+	},
+	{
+		Key:        "voipms",
+		Name:       "VoIP.ms",
+		ServerHint: "the POP the sub-account uses, e.g. newyork1.voip.ms",
+		Port:       5060,
+		Register:   true,
+		Auth:       true,
+		Codecs:     "ulaw",
+		UserHint:   "sub-account, e.g. 123456_aava",
+		DIDFormat:  "5551234567",
+		Notes:      []string{"Point the DID's routing at the sub-account"},
+	},
+	{
+		Key:       "flowroute",
+		Name:      "Flowroute",
+		Server:    "us-west-or.sip.flowroute.com",
+		Port:      5060,
+		Auth:      true,
+		Codecs:    "ulaw",
+		UserHint:  "tech prefix",
+		DIDFormat: "15551234567",
+		Notes:     []string{"Set the DID's inbound route to sip:<this host's public address>:5060"},
+	},
+	{
+		Key:       "generic",
+		Name:      "Other ITSP",
+		Port:      5060,
+		Register:  true,
+		Auth:      true,
+		Codecs:    "ulaw,alaw",
+		DIDFormat: "as the ITSP sends it",
+	},
+}
+
+// FindTemplate returns the template with key
+func FindTemplate(key string) (Template, bool) {
+	for _, t := range Templates {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// TemplateKeys returns the template keys, for flag completion
+func TemplateKeys() []string {
+	keys := make([]string, 0, len(Templates))
+	for _, t := range Templates {
+		keys = append(keys, t.Key)
+	}
+	return keys
+}
+
+// Trunk is a PJSIP trunk to one ITSP
+type Trunk struct {
+	Name      string
+	Server    string
+	Port      int
+	Register  bool
+	Username  string
+	Password  string
+	Match     []string
+	Codecs    string
+	Transport string
+	// Context is where inbound calls from the trunk enter the dialplan
+	Context string
+	// ContactUser is the user part of the registered contact, which the
+	// ITSP puts in the request URI of inbound calls; set it to the DID so
+	// the call lands on the DID's extension (default: the username)
+	ContactUser string
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Validate checks the fields the sections need
+func (t Trunk) Validate() error {
+	if !namePattern.MatchString(t.Name) {
+		return fmt.Errorf("invalid trunk name %q (letters, digits, _ and - only)", t.Name)
+	}
+	if t.Server == "" {
+		return fmt.Errorf("the ITSP server is required")
+	}
+	if (t.Register || t.Password != "") && (t.Username == "" || t.Password == "") {
+		return fmt.Errorf("username and password are required")
+	}
+	return nil
+}
+
+// AuthName, RegistrationName and IdentifyName are the section names next
+// to the endpoint/aor section named after the trunk
+func (t Trunk) AuthName() string         { return t.Name + "-auth" }
+func (t Trunk) RegistrationName() string { return t.Name + "-reg" }
+func (t Trunk) IdentifyName() string     { return t.Name + "-identify" }
+
+func (t Trunk) serverURI() string {
+	return fmt.Sprintf("sip:%s:%d", t.Server, t.Port)
+}
+
+// Label marks the trunk's block in the PJSIP file
+func (t Trunk) Label() string {
+	return "trunk " + t.Name
+}
+
+// Render returns the trunk's auth, aor, endpoint, identify and (when it
+// registers) registration sections
+func Render(t Trunk) string {
+	var sb strings.Builder
+	auth := t.Username != "" && t.Password != ""
+
+	if auth {
+		sb.WriteString(fmt.Sprintf("[%s]\n", t.AuthName()))
+		sb.WriteString("type=auth\n")
+		sb.WriteString("auth_type=userpass\n")
+		sb.WriteString(fmt.Sprintf("username=%s\n", t.Username))
+		sb.WriteString(fmt.Sprintf("password=%s\n\n", t.Password))
+	}
+
+	sb.WriteString(fmt.Sprintf("[%s]\n", t.Name))
+	sb.WriteString("type=aor\n")
+	sb.WriteString(fmt.Sprintf("contact=%s\n", t.serverURI()))
+	sb.WriteString("qualify_frequency=60\n\n")
+
+	sb.WriteString(fmt.Sprintf("[%s]\n", t.Name))
+	sb.WriteString("type=endpoint\n")
+	if t.Transport != "" {
+		sb.WriteString(fmt.Sprintf("transport=%s\n", t.Transport))
+	}
+	sb.WriteString(fmt.Sprintf("context=%s\n", t.Context))
+	sb.WriteString("disallow=all\n")
+	sb.WriteString(fmt.Sprintf("allow=%s\n", t.Codecs))
+	sb.WriteString(fmt.Sprintf("aors=%s\n", t.Name))
+	if auth {
+		sb.WriteString(fmt.Sprintf("outbound_auth=%s\n", t.AuthName()))
+		sb.WriteString(fmt.Sprintf("from_user=%s\n", t.Username))
+	}
+	sb.WriteString(fmt.Sprintf("from_domain=%s\n", t.Server))
+	sb.WriteString("direct_media=no\n")
+	sb.WriteString("rtp_symmetric=yes\n")
+	sb.WriteString("force_rport=yes\n")
+	sb.WriteString("rewrite_contact=yes\n")
+	sb.WriteString("dtmf_mode=rfc4733\n\n")
+
+	sb.WriteString(fmt.Sprintf("[%s]\n", t.IdentifyName()))
+	sb.WriteString("type=identify\n")
+	sb.WriteString(fmt.Sprintf("endpoint=%s\n", t.Name))
+	match := t.Match
+	if len(match) == 0 {
+		match = []string{t.Server}
+	}
+	for _, m := range match {
+		sb.WriteString(fmt.Sprintf("match=%s\n", m))
+	}
+
+	if t.Register {
+		sb.WriteString(fmt.Sprintf("\n[%s]\n", t.RegistrationName()))
+		sb.WriteString("type=registration\n")
+		if t.Transport != "" {
+			sb.WriteString(fmt.Sprintf("transport=%s\n", t.Transport))
+		}
+		sb.WriteString(fmt.Sprintf("outbound_auth=%s\n", t.AuthName()))
+		sb.WriteString(fmt.Sprintf("server_uri=%s\n", t.serverURI()))
+		sb.WriteString(fmt.Sprintf("client_uri=sip:%s@%s\n", t.Username, t.Server))
+		contactUser := t.ContactUser
+		if contactUser == "" {
+			contactUser = t.Username
+		}
+		sb.WriteString(fmt.Sprintf("contact_user=%s\n", contactUser))
+		sb.WriteString("retry_interval=60\n")
+		sb.WriteString("forbidden_retry_interval=600\n")
+		sb.WriteString("expiration=3600\n")
+		sb.WriteString("line=yes\n")
+		sb.WriteString(fmt.Sprintf("endpoint=%s\n", t.Name))
+	}
+	return sb.String()
+}
+
+// RegistrationState finds the trunk's line in 'pjsip show registrations'
+// output and returns its status (Registered, Unregistered, Rejected,
+// ...), or "" when the registration is not loaded
+func RegistrationState(output string, t Trunk) string {
+	prefix := t.RegistrationName() + "/"
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 3 && strings.HasPrefix(fields[0], prefix) {
+			return fields[2]
+		}
+	}
+	return ""
+}
+
+// ContactState finds the trunk's contact in 'pjsip show contacts' output
+// and returns its qualify status (Avail, Unavail, Unknown, ...), or ""
+func ContactState(output string, t Trunk) string {
+	prefix := t.Name + "/"
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) >= 4 && fields[0] == "Contact:" && strings.HasPrefix(fields[1], prefix) {
+			return fields[3]
+		}
+	}
+	return ""
+}
+
+// IdentifiedEndpoints returns the endpoints in 'pjsip show identifies'
+// output: the trunks, since phones authenticate instead
+func IdentifiedEndpoints(output string) []string {
+	var endpoints []string
+	seen := make(map[string]bool)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		// The header line is "Identify: <Identify/Endpoint...>"
+		if len(fields) < 2 || fields[0] != "Identify:" || strings.HasPrefix(fields[1], "<") {
+			continue
+		}
+		parts := strings.SplitN(fields[1], "/", 2)
+		if len(parts) != 2 || seen[parts[1]] {
+			continue
+		}
+		seen[parts[1]] = true
+		endpoints = append(endpoints, parts[1])
+	}
+	return endpoints
+}
+
+// EndpointContext returns the context parameter in 'pjsip show endpoint'
+// output
+func EndpointContext(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) == 2 && strings.TrimSpace(kv[0]) == "context" {
+			return strings.TrimSpace(kv[1])
+		}
+	}
+	return ""
+}
+
+// IdentifyMatches returns the match criteria (addresses, networks or
+// hostnames) of each endpoint in 'pjsip show identifies' output
+func IdentifyMatches(output string) map[string][]string {
+	matches := make(map[string][]string)
+	endpoint := ""
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || strings.HasPrefix(fields[1], "<") {
+			continue
+		}
+		switch fields[0] {
+		case "Identify:":
+			endpoint = ""
+			if parts := strings.SplitN(fields[1], "/", 2); len(parts) == 2 {
+				endpoint = parts[1]
+				if matches[endpoint] == nil {
+					matches[endpoint] = []string{}
+				}
+			}
+		case "Match:":
+			if endpoint != "" {
+				matches[endpoint] = append(matches[endpoint], fields[1])
+			}
+		}
+	}
+	return matches
+}
+
+// Params returns the "name : value" lines of 'pjsip show endpoint' or
+// 'core show channel' output, keyed by the trimmed name
+func Params(output string) map[string]string {
+	params := make(map[string]string)
+	for _, line := range strings.Split(output, "\n") {
+		kv := strings.SplitN(line, ":", 2)
+		if len(kv) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(kv[0])
+		if name == "" || strings.ContainsAny(name, " \t") {
+			continue
+		}
+		if _, ok := params[name]; !ok {
+			params[name] = strings.TrimSpace(kv[1])
+		}
+	}
+	return params
+}
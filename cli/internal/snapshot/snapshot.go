@@ -0,0 +1,462 @@
+package snapshot
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/health"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaVersion is bumped when the snapshot format changes incompatibly
+const SchemaVersion = 1
+
+// Snapshot is the deployment state at one point in time. Sections map
+// keys to values so any two snapshots compare key by key.
+type Snapshot struct {
+	Schema     int       `json:"schema"`
+	ID         string    `json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	Note       string    `json:"note,omitempty"`
+	Host       string    `json:"host"`
+	CLIVersion string    `json:"cli_version"`
+	// Images: container → image reference and digest
+	Images map[string]string `json:"images"`
+	// Files: path → sha256 of the project and Asterisk config files
+	Files map[string]string `json:"files"`
+	// Asterisk: version and loaded modules
+	Asterisk map[string]string `json:"asterisk"`
+	// Providers: provider and pipeline settings, secrets hashed
+	Providers map[string]string `json:"providers"`
+	// Env: .env variables, secrets hashed
+	Env map[string]string `json:"env"`
+	// System: kernel, sysctls and network interfaces
+	System map[string]string `json:"system"`
+	// Errors are the parts that could not be captured
+	Errors []string `json:"errors,omitempty"`
+}
+
+// Sections are the compared parts of a snapshot, in display order
+var Sections = []string{"images", "files", "asterisk", "providers", "env", "system"}
+
+// Section returns a section by name
+func (s *Snapshot) Section(name string) map[string]string {
+	switch name {
+	case "images":
+		return s.Images
+	case "files":
+		return s.Files
+	case "asterisk":
+		return s.Asterisk
+	case "providers":
+		return s.Providers
+	case "env":
+		return s.Env
+	case "system":
+		return s.System
+	}
+	return nil
+}
+
+// Asterisk runs CLI commands and reads config files on the Asterisk host
+type Asterisk interface {
+	Command(ctx context.Context, command string) (string, error)
+	ReadFile(ctx context.Context, path string) ([]byte, bool, error)
+}
+
+// Options select what Capture reads
+type Options struct {
+	// ProjectDir holds config/ai-agent.yaml, .env and the compose files
+	ProjectDir string
+	// Asterisk may be nil to skip the Asterisk section
+	Asterisk   Asterisk
+	CLIVersion string
+	Note       string
+}
+
+// projectFiles are hashed relative to the project directory
+var projectFiles = []string{".env", "config/.env", "config/*.yaml", "config/*.yml", "docker-compose*.yml", "docker-compose*.yaml"}
+
+// AsteriskFiles are the Asterisk configs hashed when readable
+var AsteriskFiles = []string{
+	"/etc/asterisk/extensions.conf",
+	"/etc/asterisk/extensions_custom.conf",
+	"/etc/asterisk/pjsip.conf",
+	"/etc/asterisk/pjsip_custom.conf",
+	"/etc/asterisk/ari.conf",
+	"/etc/asterisk/http.conf",
+	"/etc/asterisk/rtp.conf",
+	"/etc/asterisk/manager.conf",
+}
+
+// sysctls are the kernel settings that affect real-time audio
+var sysctls = []string{
+	"kernel.osrelease",
+	"net.core.rmem_default",
+	"net.core.rmem_max",
+	"net.core.wmem_default",
+	"net.core.wmem_max",
+	"net.core.netdev_max_backlog",
+	"net.core.somaxconn",
+	"net.ipv4.ip_local_port_range",
+	"net.ipv4.ip_forward",
+	"net.ipv4.udp_mem",
+	"net.ipv4.udp_rmem_min",
+	"net.ipv4.tcp_keepalive_time",
+	"net.netfilter.nf_conntrack_max",
+	"net.netfilter.nf_conntrack_udp_timeout",
+	"vm.swappiness",
+	"fs.file-max",
+}
+
+// secretKey matches setting names whose values must not be stored
+var secretKey = regexp.MustCompile(`(?i)(key$|secret|token$|password|passwd|credential)`)
+
+// redact replaces a secret with a short hash, so a changed secret still
+// shows up in a diff without revealing it
+func redact(value string) string {
+	if value == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "redacted:" + hex.EncodeToString(sum[:])[:12]
+}
+
+// Capture records the current state. Parts that cannot be read are
+// listed in Errors rather than failing the snapshot.
+func Capture(ctx context.Context, o Options) *Snapshot {
+	now := time.Now()
+	host, _ := os.Hostname()
+	s := &Snapshot{
+		Schema:     SchemaVersion,
+		ID:         now.Format("20060102-150405"),
+		CreatedAt:  now,
+		Note:       o.Note,
+		Host:       host,
+		CLIVersion: o.CLIVersion,
+		Images:     make(map[string]string),
+		Files:      make(map[string]string),
+		Asterisk:   make(map[string]string),
+		Providers:  make(map[string]string),
+		Env:        make(map[string]string),
+		System:     make(map[string]string),
+	}
+	s.captureImages(ctx)
+	s.captureProject(o.ProjectDir)
+	if o.Asterisk != nil {
+		s.captureAsterisk(ctx, o.Asterisk)
+	}
+	s.captureSystem()
+	return s
+}
+
+func (s *Snapshot) fail(format string, args ...interface{}) {
+	s.Errors = append(s.Errors, fmt.Sprintf(format, args...))
+}
+
+func (s *Snapshot) captureImages(ctx context.Context) {
+	client, err := docker.Default()
+	if err != nil {
+		s.fail("images: %v", err)
+		return
+	}
+	containers, err := client.Containers(ctx, docker.ListOptions{All: true})
+	if err != nil {
+		s.fail("images: %v", err)
+		return
+	}
+	for _, c := range containers {
+		info, err := client.Inspect(ctx, c.ID)
+		if err != nil {
+			s.fail("images: inspect %s: %v", c.Name(), err)
+			continue
+		}
+		digest := info.Image
+		if img, err := client.Image(ctx, info.Image); err == nil && len(img.RepoDigests) > 0 {
+			digest = strings.Join(img.RepoDigests, ",")
+		}
+		s.Images[info.Name] = fmt.Sprintf("%s@%s (%s)", info.Config.Image, digest, info.State.Status)
+	}
+}
+
+func (s *Snapshot) captureProject(dir string) {
+	seen := make(map[string]bool)
+	for _, pattern := range projectFiles {
+		matches, _ := filepath.Glob(filepath.Join(dir, pattern))
+		for _, path := range matches {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil || seen[rel] {
+				continue
+			}
+			seen[rel] = true
+			data, err := os.ReadFile(path)
+			if err != nil {
+				s.fail("files: %v", err)
+				continue
+			}
+			s.Files[rel] = hash(data)
+		}
+	}
+
+	env, err := health.LoadEnvFile(filepath.Join(dir, ".env"))
+	if err != nil {
+		env, _ = health.LoadEnvFile(filepath.Join(dir, "config", ".env"))
+	}
+	for k, v := range env {
+		if secretKey.MatchString(k) {
+			v = redact(v)
+		}
+		s.Env[k] = v
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "config", "ai-agent.yaml"))
+	if err != nil {
+		s.fail("providers: %v", err)
+		return
+	}
+	var cfg map[string]interface{}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		s.fail("providers: config/ai-agent.yaml: %v", err)
+		return
+	}
+	for _, section := range []string{"providers", "pipelines", "audio_transport", "default_provider", "active_pipeline"} {
+		if v, ok := cfg[section]; ok {
+			flatten(s.Providers, section, v)
+		}
+	}
+}
+
+// flatten stores nested settings under dotted keys, hashing secrets
+func flatten(out map[string]string, prefix string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			flatten(out, prefix+"."+k, child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flatten(out, fmt.Sprintf("%s[%d]", prefix, i), child)
+		}
+	default:
+		value := fmt.Sprint(v)
+		if v == nil {
+			value = ""
+		}
+		last := prefix[strings.LastIndex(prefix, ".")+1:]
+		// ${VAR} references are not secrets themselves
+		if secretKey.MatchString(last) && !strings.HasPrefix(value, "${") {
+			value = redact(value)
+		}
+		out[prefix] = value
+	}
+}
+
+func (s *Snapshot) captureAsterisk(ctx context.Context, a Asterisk) {
+	out, err := a.Command(ctx, "core show version")
+	if err != nil {
+		s.fail("asterisk: %v", err)
+		return
+	}
+	s.Asterisk["version"] = strings.TrimSpace(out)
+	if out, err = a.Command(ctx, "module show"); err == nil {
+		for _, line := range strings.Split(out, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) > 0 && strings.HasSuffix(fields[0], ".so") {
+				status := "loaded"
+				if strings.Contains(line, "Not Running") {
+					status = "not running"
+				}
+				s.Asterisk["module "+fields[0]] = status
+			}
+		}
+	} else {
+		s.fail("asterisk: module show: %v", err)
+	}
+	for _, path := range AsteriskFiles {
+		data, found, err := a.ReadFile(ctx, path)
+		if err != nil {
+			s.fail("files: %s: %v", path, err)
+			continue
+		}
+		if found {
+			s.Files[path] = hash(data)
+		}
+	}
+}
+
+func (s *Snapshot) captureSystem() {
+	s.System["os"] = runtime.GOOS + "/" + runtime.GOARCH
+	s.System["cpus"] = fmt.Sprint(runtime.NumCPU())
+	if data, err := os.ReadFile("/proc/meminfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.HasPrefix(line, "MemTotal:") {
+				s.System["memory"] = strings.Join(strings.Fields(line)[1:], " ")
+			}
+		}
+	}
+	for _, key := range sysctls {
+		data, err := os.ReadFile(filepath.Join("/proc/sys", strings.Replace(key, ".", "/", -1)))
+		if err == nil {
+			s.System[key] = strings.Join(strings.Fields(string(data)), " ")
+		}
+	}
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		s.fail("system: %v", err)
+		return
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagLoopback != 0 || strings.HasPrefix(iface.Name, "veth") {
+			continue
+		}
+		state := "down"
+		if iface.Flags&net.FlagUp != 0 {
+			state = "up"
+		}
+		var addrs []string
+		if as, err := iface.Addrs(); err == nil {
+			for _, a := range as {
+				addrs = append(addrs, a.String())
+			}
+		}
+		sort.Strings(addrs)
+		s.System["net "+iface.Name] = fmt.Sprintf("%s mtu %d %s", state, iface.MTU, strings.Join(addrs, " "))
+	}
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Dir returns the directory holding saved snapshots
+func Dir() string {
+	return filepath.Join(settings.Dir(), "snapshots")
+}
+
+// Save writes the snapshot to Dir, or to path when given, and returns
+// the file written
+func Save(s *Snapshot, path string) (string, error) {
+	if path == "" {
+		if err := os.MkdirAll(Dir(), 0700); err != nil {
+			return "", err
+		}
+		base := s.ID
+		for n := 2; ; n++ {
+			path = filepath.Join(Dir(), s.ID+".json")
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				break
+			}
+			s.ID = fmt.Sprintf("%s-%d", base, n)
+		}
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return path, os.WriteFile(path, append(data, '\n'), 0600)
+}
+
+// Load reads a snapshot by ID (from Dir) or file path
+func Load(ref string) (*Snapshot, error) {
+	path := ref
+	if !strings.ContainsAny(ref, `/\`) && !strings.HasSuffix(ref, ".json") {
+		path = filepath.Join(Dir(), ref+".json")
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("snapshot %s not found (see 'agent snapshot list')", ref)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var s Snapshot
+	if err := json.Unmarshal(bytes.TrimSpace(data), &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if s.Schema > SchemaVersion {
+		return nil, fmt.Errorf("%s: snapshot schema %d is newer than this CLI supports (%d); update the CLI", path, s.Schema, SchemaVersion)
+	}
+	return &s, nil
+}
+
+// List returns the saved snapshots, oldest first
+func List() ([]*Snapshot, error) {
+	matches, err := filepath.Glob(filepath.Join(Dir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var out []*Snapshot
+	for _, path := range matches {
+		if s, err := Load(path); err == nil {
+			out = append(out, s)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+// Change kinds
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+)
+
+// Change is one differing key between two snapshots
+type Change struct {
+	Section string
+	Key     string
+	Kind    string
+	Old     string
+	New     string
+}
+
+// Diff compares two snapshots section by section, keys sorted
+func Diff(a, b *Snapshot) []Change {
+	var changes []Change
+	for _, section := range Sections {
+		old, cur := a.Section(section), b.Section(section)
+		keys := make(map[string]bool)
+		for k := range old {
+			keys[k] = true
+		}
+		for k := range cur {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			o, oldOK := old[k]
+			n, newOK := cur[k]
+			c := Change{Section: section, Key: k, Kind: Changed, Old: o, New: n}
+			switch {
+			case !oldOK:
+				c.Kind = Added
+			case !newOK:
+				c.Kind = Removed
+			case o == n:
+				continue
+			}
+			changes = append(changes, c)
+		}
+	}
+	return changes
+}
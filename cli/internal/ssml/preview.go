@@ -0,0 +1,261 @@
+package ssml
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/scenario"
+	"gopkg.in/yaml.v3"
+)
+
+// Target is the TTS component replies are spoken with
+type Target struct {
+	// Name is e.g. "pipeline local_hybrid (tts google_tts)" or
+	// "provider deepgram"
+	Name     string `json:"name"`
+	Family   string `json:"family"`
+	Model    string `json:"model,omitempty"`
+	Voice    string `json:"voice,omitempty"`
+	Language string `json:"language,omitempty"`
+	// APIKey is the provider's api_key setting, e.g. ${GOOGLE_API_KEY}
+	APIKey string `json:"-"`
+}
+
+// Resolve finds the TTS component of config/ai-agent.yaml under dir: the
+// named provider, else the active pipeline's tts, else the default
+// provider. A family name (google, deepgram, ...) selects that family
+// without a config.
+func Resolve(dir, name string) (Target, error) {
+	if _, ok := Profiles[name]; ok {
+		return Target{Name: name, Family: name}, nil
+	}
+	path := filepath.Join(dir, "config", "ai-agent.yaml")
+	data, err := remote.ReadFile(path)
+	if err != nil {
+		return Target{}, err
+	}
+	var cfg struct {
+		ActivePipeline  string                            `yaml:"active_pipeline"`
+		DefaultProvider string                            `yaml:"default_provider"`
+		Pipelines       map[string]map[string]interface{} `yaml:"pipelines"`
+		Providers       map[string]map[string]interface{} `yaml:"providers"`
+	}
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		return Target{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if name != "" {
+		p, ok := cfg.Providers[name]
+		if !ok {
+			return Target{}, fmt.Errorf("no provider %q in %s (or use a family: google, elevenlabs, deepgram, openai, local)", name, path)
+		}
+		return target("provider "+name, name, p, nil), nil
+	}
+	if pipeline, ok := cfg.Pipelines[cfg.ActivePipeline]; ok {
+		tts, _ := pipeline["tts"].(string)
+		if p, ok := cfg.Providers[tts]; ok {
+			options, _ := pipeline["options"].(map[string]interface{})
+			overrides, _ := options["tts"].(map[string]interface{})
+			return target(fmt.Sprintf("pipeline %s (tts %s)", cfg.ActivePipeline, tts), tts, p, overrides), nil
+		}
+	}
+	if p, ok := cfg.Providers[cfg.DefaultProvider]; ok {
+		return target("provider "+cfg.DefaultProvider, cfg.DefaultProvider, p, nil), nil
+	}
+	return Target{}, fmt.Errorf("%s names no TTS provider: set active_pipeline or default_provider, or use --provider", path)
+}
+
+// target reads a provider's family, model and voice; pipeline options
+// override the provider's settings
+func target(label, name string, provider, overrides map[string]interface{}) Target {
+	setting := func(keys ...string) string {
+		for _, m := range []map[string]interface{}{overrides, provider} {
+			for _, k := range keys {
+				if v, ok := m[k]; ok && v != nil && fmt.Sprint(v) != "" {
+					return fmt.Sprint(v)
+				}
+			}
+		}
+		return ""
+	}
+	t := Target{
+		Name:     label,
+		Family:   family(name, setting("type")),
+		Model:    setting("model", "model_id", "tts_model"),
+		Voice:    setting("voice", "voice_id", "voice_name", "tts_voice_name"),
+		Language: setting("language_code", "tts_language_code", "stt_language_code"),
+		APIKey:   setting("api_key"),
+	}
+	if t.Family == FamilyDeepgram {
+		// The Deepgram agent's model is its STT model; it speaks with tts_model
+		if m := setting("tts_model"); m != "" {
+			t.Model = m
+		}
+	}
+	return t
+}
+
+// family returns the SSML family of a provider from its name and type
+func family(name, kind string) string {
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "google_live") || strings.Contains(lower, "gemini"):
+		return FamilyAgent
+	case kind == "openai_realtime" || strings.Contains(lower, "realtime"):
+		return FamilyAgent
+	case strings.Contains(lower, "elevenlabs") && kind == "full":
+		return FamilyAgent
+	case strings.Contains(lower, "elevenlabs"):
+		return FamilyElevenLabs
+	case strings.Contains(lower, "deepgram") || kind == "deepgram":
+		return FamilyDeepgram
+	case strings.Contains(lower, "google") || kind == "google":
+		return FamilyGoogle
+	case strings.Contains(lower, "openai") || kind == "openai":
+		return FamilyOpenAI
+	case strings.Contains(lower, "local") || kind == "local":
+		return FamilyLocal
+	}
+	return FamilyAgent
+}
+
+// KeyEnv is the environment variable holding the target's API key: the
+// one its api_key setting names, else the family's usual one
+func (t Target) KeyEnv() string {
+	if strings.HasPrefix(t.APIKey, "${") && strings.HasSuffix(t.APIKey, "}") {
+		name := strings.TrimSuffix(strings.TrimPrefix(t.APIKey, "${"), "}")
+		if i := strings.IndexAny(name, ":-="); i > 0 {
+			name = name[:i]
+		}
+		return name
+	}
+	switch t.Family {
+	case FamilyGoogle:
+		return "GOOGLE_API_KEY"
+	case FamilyElevenLabs:
+		return "ELEVENLABS_API_KEY"
+	case FamilyDeepgram:
+		return "DEEPGRAM_API_KEY"
+	case FamilyOpenAI:
+		return "OPENAI_API_KEY"
+	}
+	return ""
+}
+
+// Preview synthesizes doc through the target's API the way the engine
+// sends replies, as plain text, and returns 8 kHz µ-law audio
+func Preview(ctx context.Context, t Target, doc, key string) ([]byte, error) {
+	if t.Family == FamilyLocal || t.Family == FamilyAgent {
+		return nil, fmt.Errorf("no preview for %s: it has no TTS API to call", t.Name)
+	}
+	if key == "" {
+		return nil, fmt.Errorf("no API key for %s: set %s in the environment or .env", t.Name, t.KeyEnv())
+	}
+	switch t.Family {
+	case FamilyGoogle:
+		voice := map[string]string{"languageCode": orDefault(t.Language, "en-US")}
+		if t.Voice != "" {
+			voice["name"] = t.Voice
+		}
+		body, err := post(ctx, "https://texttospeech.googleapis.com/v1/text:synthesize?key="+url.QueryEscape(key), nil, map[string]interface{}{
+			"input":       map[string]string{"text": doc},
+			"voice":       voice,
+			"audioConfig": map[string]interface{}{"audioEncoding": "MULAW", "sampleRateHertz": scenario.Rate},
+		})
+		if err != nil {
+			return nil, err
+		}
+		var resp struct {
+			AudioContent string `json:"audioContent"`
+		}
+		if err := json.Unmarshal(body, &resp); err != nil {
+			return nil, fmt.Errorf("google TTS: %w", err)
+		}
+		audio, err := base64.StdEncoding.DecodeString(resp.AudioContent)
+		if err != nil {
+			return nil, fmt.Errorf("google TTS: %w", err)
+		}
+		return toUlaw(audio)
+	case FamilyDeepgram:
+		q := url.Values{"model": {orDefault(t.Model, "aura-2-thalia-en")}, "encoding": {"mulaw"}, "sample_rate": {"8000"}, "container": {"none"}}
+		return post(ctx, "https://api.deepgram.com/v1/speak?"+q.Encode(), map[string]string{"Authorization": "Token " + key}, map[string]string{"text": doc})
+	case FamilyOpenAI:
+		body, err := post(ctx, "https://api.openai.com/v1/audio/speech", map[string]string{"Authorization": "Bearer " + key}, map[string]string{
+			"model":           orDefault(t.Model, "gpt-4o-mini-tts"),
+			"voice":           orDefault(t.Voice, "alloy"),
+			"input":           doc,
+			"response_format": "wav",
+		})
+		if err != nil {
+			return nil, err
+		}
+		return toUlaw(body)
+	case FamilyElevenLabs:
+		if t.Voice == "" {
+			return nil, fmt.Errorf("no preview for %s: set its voice_id", t.Name)
+		}
+		payload := map[string]string{"text": doc}
+		if t.Model != "" {
+			payload["model_id"] = t.Model
+		}
+		return post(ctx, "https://api.elevenlabs.io/v1/text-to-speech/"+url.PathEscape(t.Voice)+"?output_format=ulaw_8000", map[string]string{"xi-api-key": key}, payload)
+	}
+	return nil, fmt.Errorf("no preview for %s", t.Name)
+}
+
+func post(ctx context.Context, endpoint string, headers map[string]string, payload interface{}) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode >= 400 {
+		msg := strings.TrimSpace(string(body))
+		if len(msg) > 200 {
+			msg = msg[:200]
+		}
+		return nil, fmt.Errorf("%s: HTTP %d: %s", req.URL.Host, resp.StatusCode, msg)
+	}
+	return body, nil
+}
+
+// toUlaw converts a WAV answer to 8 kHz µ-law; other answers already are
+func toUlaw(audio []byte) ([]byte, error) {
+	if len(audio) >= 4 && string(audio[0:4]) == "RIFF" {
+		return scenario.WAVToUlaw(audio)
+	}
+	return audio, nil
+}
+
+func orDefault(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
@@ -0,0 +1,342 @@
+// Package ssml checks SSML documents against the subset a TTS provider
+// supports and synthesizes previews through the provider's API.
+package ssml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Issue severities
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+	SeverityInfo    = "info"
+)
+
+// Issue is one problem of a document. Errors break synthesis; warnings
+// are markup the provider ignores or reads aloud.
+type Issue struct {
+	Severity string `json:"severity"`
+	Line     int    `json:"line,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Message  string `json:"message"`
+}
+
+// Profile is the SSML subset of a provider family
+type Profile struct {
+	Family string
+	// Label names the provider in messages
+	Label string
+	// Tags maps each supported element to its supported attributes
+	Tags map[string][]string
+	// SayAs lists the supported say-as interpret-as values
+	SayAs []string
+	// MaxBreak is the longest break the provider honors, 0 for no limit
+	MaxBreak time.Duration
+	// Models limits tags to the listed models
+	Models map[string][]string
+	// Note explains a family without SSML, or how the engine sends it
+	Note string
+}
+
+// Provider families
+const (
+	FamilyGoogle     = "google"
+	FamilyElevenLabs = "elevenlabs"
+	FamilyDeepgram   = "deepgram"
+	FamilyOpenAI     = "openai"
+	FamilyLocal      = "local"
+	FamilyAgent      = "agent"
+)
+
+// Profiles are the SSML subsets of the engine's TTS providers
+var Profiles = map[string]Profile{
+	FamilyGoogle: {
+		Family: FamilyGoogle,
+		Label:  "Google TTS",
+		Tags: map[string][]string{
+			"speak":    {"xml:lang", "version", "xmlns"},
+			"break":    {"time", "strength"},
+			"say-as":   {"interpret-as", "format", "detail", "language"},
+			"sub":      {"alias"},
+			"p":        nil,
+			"s":        nil,
+			"prosody":  {"rate", "pitch", "volume"},
+			"emphasis": {"level"},
+			"audio":    {"src", "clipBegin", "clipEnd", "speed", "repeatCount", "repeatDur", "soundLevel"},
+			"mark":     {"name"},
+			"par":      nil,
+			"seq":      nil,
+			"media":    {"xml:id", "begin", "end", "repeatCount", "repeatDur", "soundLevel", "fadeInDur", "fadeOutDur"},
+			"phoneme":  {"alphabet", "ph"},
+			"voice":    {"name", "gender", "variant", "language", "required", "ordering"},
+			"lang":     {"xml:lang"},
+		},
+		SayAs: []string{"bleep", "cardinal", "characters", "date", "expletive", "fraction", "ordinal", "spell-out", "telephone", "time", "unit", "verbatim"},
+		Note:  "ai_engine sends Google TTS replies as input.text, where SSML is not interpreted; the markup only takes effect when sent as input.ssml",
+	},
+	FamilyElevenLabs: {
+		Family: FamilyElevenLabs,
+		Label:  "ElevenLabs",
+		Tags: map[string][]string{
+			"break":   {"time"},
+			"phoneme": {"alphabet", "ph"},
+		},
+		MaxBreak: 3 * time.Second,
+		// Phoneme tags only work on the English models
+		Models: map[string][]string{
+			"phoneme": {"eleven_flash_v2", "eleven_turbo_v2", "eleven_monolingual_v1"},
+		},
+		Note: "ElevenLabs takes <break> and <phoneme> inline in plain text; other tags are spoken or dropped",
+	},
+	FamilyDeepgram: {Family: FamilyDeepgram, Label: "Deepgram Aura", Note: "Deepgram Aura takes plain text only; tags are spoken or dropped"},
+	FamilyOpenAI:   {Family: FamilyOpenAI, Label: "OpenAI TTS", Note: "OpenAI TTS takes plain text only; tags are spoken or dropped"},
+	FamilyLocal:    {Family: FamilyLocal, Label: "local TTS", Note: "local TTS (Piper) takes plain text only; tags are spoken or dropped"},
+	FamilyAgent:    {Family: FamilyAgent, Label: "full agents", Note: "full agents speak the model's own output; SSML in the prompt or replies is spoken as text"},
+}
+
+// Result is the outcome of checking one document
+type Result struct {
+	Issues []Issue `json:"issues"`
+	// Tags counts the elements of the document by name
+	Tags map[string]int `json:"tags"`
+	// Text is the document's text without markup
+	Text string `json:"text"`
+}
+
+// Errors counts the issues of severity error
+func (r *Result) Errors() int { return r.count(SeverityError) }
+
+// Warnings counts the issues of severity warning
+func (r *Result) Warnings() int { return r.count(SeverityWarning) }
+
+func (r *Result) count(severity string) int {
+	n := 0
+	for _, i := range r.Issues {
+		if i.Severity == severity {
+			n++
+		}
+	}
+	return n
+}
+
+var breakTime = regexp.MustCompile(`^\s*([0-9]+(?:\.[0-9]+)?)\s*(ms|s)\s*$`)
+
+// breakStrengths are the strength values of <break>
+var breakStrengths = []string{"none", "x-weak", "weak", "medium", "strong", "x-strong"}
+
+// Check parses doc and reports markup outside the profile's subset. model
+// is the provider's TTS model, for tags only some models support.
+func Check(doc []byte, p Profile, model string) *Result {
+	r := &Result{Tags: make(map[string]int)}
+	dec := xml.NewDecoder(bytes.NewReader(doc))
+	if p.Family != FamilyGoogle {
+		// Other providers take markup inline in plain text, where a bare
+		// & or < is just text
+		dec.Strict = false
+		dec.AutoClose = xml.HTMLAutoClose
+		dec.Entity = xml.HTMLEntity
+	}
+	// Count lines for positions; the decoder only reports them in errors
+	line := func() int {
+		return bytes.Count(doc[:dec.InputOffset()], []byte("\n")) + 1
+	}
+	add := func(severity string, ln int, tag, format string, args ...interface{}) {
+		r.Issues = append(r.Issues, Issue{Severity: severity, Line: ln, Tag: tag, Message: fmt.Sprintf(format, args...)})
+	}
+
+	var text strings.Builder
+	var stack []string
+	warned := make(map[string]bool)
+	root := ""
+	topLevel := 0
+	for {
+		start := line()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if se, ok := err.(*xml.SyntaxError); ok {
+				add(SeverityError, se.Line, "", "not well-formed: %s", se.Msg)
+			} else {
+				add(SeverityError, start, "", "not well-formed: %v", err)
+			}
+			return r
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := t.Name.Local
+			if root == "" {
+				root = name
+			}
+			if len(stack) == 0 {
+				topLevel++
+			}
+			stack = append(stack, name)
+			r.Tags[name]++
+			checkElement(p, model, t, start, warned, add)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) == 0 && strings.TrimSpace(string(t)) != "" {
+				topLevel++
+			}
+			text.Write(t)
+		}
+	}
+	switch {
+	case topLevel == 0:
+		add(SeverityError, 0, "", "document is empty")
+	case p.Family == FamilyGoogle && (root != "speak" || topLevel > 1):
+		add(SeverityError, 1, "", "SSML must be wrapped in one <speak>...</speak>")
+	}
+	if p.Note != "" && len(r.Tags) > 0 {
+		// Google validates fine but the engine does not send SSML
+		severity := SeverityInfo
+		if p.Family == FamilyGoogle {
+			severity = SeverityWarning
+		}
+		add(severity, 0, "", "%s", p.Note)
+	}
+	r.Text = strings.Join(strings.Fields(text.String()), " ")
+	return r
+}
+
+// checkElement reports an element, its attributes and values outside
+// the profile
+func checkElement(p Profile, model string, t xml.StartElement, line int, warned map[string]bool, add func(string, int, string, string, ...interface{})) {
+	name := t.Name.Local
+	attrs, ok := p.Tags[name]
+	if t.Name.Space != "" && !strings.Contains(t.Name.Space, "/") {
+		// A vendor extension such as amazon:effect
+		name = t.Name.Space + ":" + name
+		ok = false
+	}
+	if !ok {
+		// One warning per unsupported tag is enough
+		if !warned[name] {
+			warned[name] = true
+			add(SeverityWarning, line, name, "<%s> is not supported by %s", name, p.Label)
+		}
+		return
+	}
+	if models, limited := p.Models[name]; limited && !isOneOf(model, models) {
+		if !warned[name] {
+			warned[name] = true
+			if model == "" {
+				add(SeverityWarning, line, name, "<%s> only works on the %s models (model not set)", name, strings.Join(models, ", "))
+			} else {
+				add(SeverityWarning, line, name, "<%s> only works on the %s models, not %s", name, strings.Join(models, ", "), model)
+			}
+		}
+		return
+	}
+	for _, a := range t.Attr {
+		attr := a.Name.Local
+		if a.Name.Space == "xmlns" || attr == "xmlns" {
+			continue
+		}
+		if a.Name.Space == "xml" || a.Name.Space == "http://www.w3.org/XML/1998/namespace" {
+			attr = "xml:" + attr
+		}
+		if !contains(attrs, attr) {
+			add(SeverityWarning, line, name, "<%s %s=...> is ignored by %s", name, attr, p.Label)
+		}
+	}
+
+	switch name {
+	case "say-as":
+		v := attrValue(t, "interpret-as")
+		switch {
+		case v == "":
+			add(SeverityError, line, name, "<say-as> needs interpret-as")
+		case len(p.SayAs) > 0 && !contains(p.SayAs, v):
+			add(SeverityWarning, line, name, "say-as interpret-as=%q is not supported by %s (use %s)", v, p.Label, strings.Join(p.SayAs, ", "))
+		}
+	case "break":
+		if v := attrValue(t, "time"); v != "" {
+			d, err := parseBreak(v)
+			switch {
+			case err != nil:
+				add(SeverityError, line, name, "break time=%q: %v", v, err)
+			case p.MaxBreak > 0 && d > p.MaxBreak:
+				add(SeverityWarning, line, name, "break time=%q is longer than the %s %s honors", v, p.MaxBreak, p.Label)
+			}
+		}
+		if v := attrValue(t, "strength"); v != "" && !contains(breakStrengths, v) {
+			add(SeverityError, line, name, "break strength=%q is not one of %s", v, strings.Join(breakStrengths, ", "))
+		}
+	case "phoneme":
+		if attrValue(t, "ph") == "" {
+			add(SeverityError, line, name, "<phoneme> needs ph")
+		}
+	case "sub":
+		if attrValue(t, "alias") == "" {
+			add(SeverityError, line, name, "<sub> needs alias")
+		}
+	}
+}
+
+// parseBreak reads a break time such as 500ms or 1.5s
+func parseBreak(v string) (time.Duration, error) {
+	m := breakTime.FindStringSubmatch(v)
+	if m == nil {
+		return 0, fmt.Errorf("use a duration such as 500ms or 1.5s")
+	}
+	n, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, err
+	}
+	if m[2] == "s" {
+		n *= 1000
+	}
+	return time.Duration(n * float64(time.Millisecond)), nil
+}
+
+func attrValue(t xml.StartElement, name string) string {
+	for _, a := range t.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func isOneOf(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if s == p {
+			return true
+		}
+	}
+	return false
+}
+
+// Supported lists the tags of a profile, sorted
+func (p Profile) Supported() []string {
+	tags := make([]string, 0, len(p.Tags))
+	for t := range p.Tags {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
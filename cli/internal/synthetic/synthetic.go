@@ -0,0 +1,113 @@
+package synthetic
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/scenario"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Run is the recorded outcome of one canary call
+type Run struct {
+	Time     time.Time `json:"time"`
+	Scenario string    `json:"scenario"`
+	CallID   string    `json:"call_id,omitempty"`
+	Passed   bool      `json:"passed"`
+	// Error is set when the call could not be placed
+	Error string `json:"error,omitempty"`
+	// FailedStep is the first step that did not pass
+	FailedStep string `json:"failed_step,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+	// DurationMs is the whole call; ResponseMs is the slowest expect or
+	// tool step, i.e. how long the agent took to answer
+	DurationMs int64 `json:"duration_ms"`
+	ResponseMs int64 `json:"response_ms"`
+}
+
+// Path returns the history file, one JSON run per line
+func Path() string {
+	return filepath.Join(settings.Dir(), "synthetic.jsonl")
+}
+
+// FromReport summarizes a scenario report as a run
+func FromReport(report *scenario.Report, started time.Time) *Run {
+	run := &Run{
+		Time:       started.UTC(),
+		Scenario:   report.Scenario,
+		CallID:     report.CallID,
+		Passed:     report.Passed,
+		DurationMs: report.ElapsedMs,
+	}
+	for _, step := range report.Steps {
+		wait := step.Kind == scenario.StepExpect || step.Kind == scenario.StepTool
+		if wait && !step.Skipped && step.ElapsedMs > run.ResponseMs {
+			run.ResponseMs = step.ElapsedMs
+		}
+		if !step.Passed && run.FailedStep == "" {
+			run.FailedStep = step.Step
+			run.Detail = step.Detail
+			if run.Detail == "" && step.Skipped {
+				run.Detail = "skipped"
+			}
+		}
+	}
+	return run
+}
+
+// Append adds a run to the history
+func Append(run *Run) error {
+	if err := os.MkdirAll(settings.Dir(), 0700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(Path(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := json.Marshal(run)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(append(data, '\n'))
+	return err
+}
+
+// Load returns the recorded runs of a scenario (all scenarios when
+// empty) since the given time, oldest first
+func Load(name string, since time.Time) ([]*Run, error) {
+	f, err := os.Open(Path())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var runs []*Run
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		run := &Run{}
+		if json.Unmarshal(scanner.Bytes(), run) != nil {
+			continue
+		}
+		if (name != "" && run.Scenario != name) || run.Time.Before(since) {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	return runs, scanner.Err()
+}
+
+// Last returns the most recent run of a scenario, or nil
+func Last(name string) (*Run, error) {
+	runs, err := Load(name, time.Time{})
+	if err != nil || len(runs) == 0 {
+		return nil, err
+	}
+	return runs[len(runs)-1], nil
+}
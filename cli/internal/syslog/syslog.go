@@ -0,0 +1,162 @@
+package syslog
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMessageSize bounds a single syslog message
+const maxMessageSize = 64 * 1024
+
+// Message is a parsed syslog message
+type Message struct {
+	Priority int
+	Host     string
+	App      string
+	Text     string
+	Received time.Time
+}
+
+// Handler receives parsed messages
+type Handler func(Message)
+
+var (
+	// <PRI>1 TIMESTAMP HOST APP PROCID MSGID [SD] MSG
+	rfc5424Pattern = regexp.MustCompile(`^<(\d{1,3})>1 (\S+) (\S+) (\S+) (\S+) (\S+) (-|\[.*?\](?:\[.*?\])*) ?(.*)$`)
+
+	// <PRI>Mmm dd hh:mm:ss HOST TAG[PID]: MSG
+	rfc3164Pattern = regexp.MustCompile(`^<(\d{1,3})>([A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (\S+) ([^:\[\s]+)(?:\[[^\]]*\])?: ?(.*)$`)
+)
+
+// Parse parses an RFC 5424 or RFC 3164 message. Anything else is kept as
+// text so nothing sent to the listener is lost.
+func Parse(raw string, received time.Time) Message {
+	raw = strings.TrimRight(raw, "\r\n\x00")
+	msg := Message{Received: received, Text: raw}
+
+	if m := rfc5424Pattern.FindStringSubmatch(raw); m != nil {
+		msg.Priority, _ = strconv.Atoi(m[1])
+		msg.Host = m[3]
+		msg.App = m[4]
+		msg.Text = strings.TrimPrefix(m[8], "\ufeff")
+		return msg
+	}
+	if m := rfc3164Pattern.FindStringSubmatch(raw); m != nil {
+		msg.Priority, _ = strconv.Atoi(m[1])
+		msg.Host = m[3]
+		msg.App = m[4]
+		msg.Text = m[5]
+		return msg
+	}
+	return msg
+}
+
+// ListenUDP receives datagrams on addr until ctx is done
+func ListenUDP(ctx context.Context, addr string, handle Handler) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	buf := make([]byte, maxMessageSize)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		handle(Parse(string(buf[:n]), time.Now()))
+	}
+}
+
+// ListenTCP accepts connections on addr until ctx is done. Both newline
+// framing and RFC 6587 octet counting are accepted.
+func ListenTCP(ctx context.Context, addr string, handle Handler) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer conn.Close()
+			go func() {
+				<-ctx.Done()
+				conn.Close()
+			}()
+			readStream(conn, handle)
+		}()
+	}
+}
+
+// readStream splits a TCP stream into messages
+func readStream(r io.Reader, handle Handler) {
+	br := bufio.NewReaderSize(r, maxMessageSize)
+	for {
+		first, err := br.Peek(1)
+		if err != nil {
+			return
+		}
+
+		var raw string
+		if first[0] >= '0' && first[0] <= '9' {
+			// Octet counting: "<len> <msg>"
+			lenStr, err := br.ReadString(' ')
+			if err != nil {
+				return
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(lenStr))
+			if err != nil || n <= 0 || n > maxMessageSize {
+				return
+			}
+			data := make([]byte, n)
+			if _, err := io.ReadFull(br, data); err != nil {
+				return
+			}
+			raw = string(data)
+		} else {
+			line, err := br.ReadString('\n')
+			if err != nil && line == "" {
+				return
+			}
+			raw = line
+		}
+		if strings.TrimSpace(raw) != "" {
+			handle(Parse(raw, time.Now()))
+		}
+	}
+}
+
+// String renders a message for debugging
+func (m Message) String() string {
+	return fmt.Sprintf("<%d> %s %s: %s", m.Priority, m.Host, m.App, m.Text)
+}
@@ -0,0 +1,293 @@
+package systemd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Unit names
+const (
+	EngineUnit   = "aava-engine.service"
+	LocalAIUnit  = "aava-local-ai.service"
+	ExporterUnit = "aava-exporter.service"
+	WatchUnit    = "aava-watch.service"
+)
+
+// DefaultDir is where system units are installed
+const DefaultDir = "/etc/systemd/system"
+
+// StateDir is the CLI state directory of the exporter and watch units
+// (systemd StateDirectory=aava)
+const StateDir = "/var/lib/aava"
+
+// UnitForContainer maps a docker container name to the unit that runs
+// the same service on a bare-metal install
+func UnitForContainer(container string) string {
+	switch container {
+	case "ai_engine":
+		return EngineUnit
+	case "local_ai_server":
+		return LocalAIUnit
+	}
+	return container + ".service"
+}
+
+// Options describes a bare-metal install
+type Options struct {
+	// Dir is the project checkout the engine runs from
+	Dir string
+	// Python is the interpreter, usually the project's virtualenv
+	Python string
+	// Agent is the path of the agent CLI binary
+	Agent string
+	User  string
+	Group string
+	// LocalAI adds a unit for local_ai_server
+	LocalAI bool
+	// ExportInterval and WatchInterval schedule the exporter and watch
+	// timers; zero leaves the unit out
+	ExportInterval time.Duration
+	WatchInterval  time.Duration
+}
+
+// File is a rendered unit file
+type File struct {
+	Name    string
+	Content string
+}
+
+// DefaultPython returns the project's virtualenv interpreter when there
+// is one, otherwise python3 from PATH
+func DefaultPython(dir string) string {
+	for _, venv := range []string{".venv", "venv"} {
+		p := filepath.Join(dir, venv, "bin", "python")
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	if p, err := exec.LookPath("python3"); err == nil {
+		return p
+	}
+	return "/usr/bin/python3"
+}
+
+// hardening is the sandbox shared by every unit. The services need only
+// the network and their own directories: no privileges, devices, kernel
+// or namespace access.
+const hardening = `NoNewPrivileges=yes
+CapabilityBoundingSet=
+AmbientCapabilities=
+PrivateTmp=yes
+PrivateDevices=yes
+ProtectSystem=strict
+ProtectKernelTunables=yes
+ProtectKernelModules=yes
+ProtectKernelLogs=yes
+ProtectControlGroups=yes
+ProtectClock=yes
+ProtectHostname=yes
+RestrictAddressFamilies=AF_UNIX AF_INET AF_INET6 AF_NETLINK
+RestrictNamespaces=yes
+RestrictRealtime=yes
+RestrictSUIDSGID=yes
+LockPersonality=yes
+SystemCallArchitectures=native
+SystemCallFilter=@system-service
+SystemCallErrorNumber=EPERM
+UMask=0027
+`
+
+// protectHome keeps /home and /root hidden unless the project lives there
+func protectHome(dir string) string {
+	if strings.HasPrefix(dir, "/home/") || strings.HasPrefix(dir, "/root/") || dir == "/root" {
+		return "ProtectHome=read-only\n"
+	}
+	return "ProtectHome=yes\n"
+}
+
+// Render returns the unit files for o
+func Render(o Options) []File {
+	files := []File{{EngineUnit, engineUnit(o)}}
+	if o.LocalAI {
+		files = append(files, File{LocalAIUnit, localAIUnit(o)})
+	}
+	if o.ExportInterval > 0 {
+		// The window overlaps the previous run a little so calls ending
+		// during a run are not missed
+		since := o.ExportInterval + o.ExportInterval/4
+		files = append(files,
+			File{ExporterUnit, cliUnit(o, ExporterUnit, "Export call metrics from the engine journal",
+				fmt.Sprintf("troubleshoot --all --no-llm --source journald --since %s", since))},
+			File{timerName(ExporterUnit), timerUnit("Export call metrics periodically", o.ExportInterval)},
+		)
+	}
+	if o.WatchInterval > 0 {
+		files = append(files,
+			File{WatchUnit, cliUnit(o, WatchUnit, "Check AI Voice Agent health and notify on failures", "doctor")},
+			File{timerName(WatchUnit), timerUnit("Check AI Voice Agent health periodically", o.WatchInterval)},
+		)
+	}
+	return files
+}
+
+// Enable returns the units to enable: the services that run
+// continuously and the timers of the periodic ones
+func Enable(files []File) []string {
+	var names []string
+	for _, f := range files {
+		if strings.HasSuffix(f.Name, ".timer") || f.Name == EngineUnit || f.Name == LocalAIUnit {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
+
+// Write writes the unit files into dir
+func Write(dir string, files []File) error {
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(dir, f.Name), []byte(f.Content), 0644); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func timerName(service string) string {
+	return strings.TrimSuffix(service, ".service") + ".timer"
+}
+
+func identifier(unit string) string {
+	return strings.TrimSuffix(unit, ".service")
+}
+
+func engineUnit(o Options) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by: agent install systemd\n")
+	sb.WriteString("[Unit]\n")
+	sb.WriteString("Description=Asterisk AI Voice Agent engine\n")
+	sb.WriteString("Documentation=https://github.com/hkjarral/Asterisk-AI-Voice-Agent\n")
+	sb.WriteString("After=network-online.target asterisk.service\n")
+	sb.WriteString("Wants=network-online.target\n")
+	if o.LocalAI {
+		sb.WriteString("Wants=" + LocalAIUnit + "\n")
+		sb.WriteString("After=" + LocalAIUnit + "\n")
+	}
+	sb.WriteString("StartLimitIntervalSec=300\n")
+	sb.WriteString("StartLimitBurst=5\n\n")
+
+	sb.WriteString("[Service]\n")
+	sb.WriteString("Type=simple\n")
+	writeAccount(&sb, o)
+	sb.WriteString("WorkingDirectory=" + o.Dir + "\n")
+	sb.WriteString("EnvironmentFile=-" + filepath.Join(o.Dir, ".env") + "\n")
+	sb.WriteString("Environment=PYTHONPATH=" + o.Dir + "\n")
+	sb.WriteString("Environment=PYTHONUNBUFFERED=1\n")
+	sb.WriteString(fmt.Sprintf("ExecStart=%s %s\n", o.Python, filepath.Join(o.Dir, "main.py")))
+	sb.WriteString("Restart=on-failure\n")
+	sb.WriteString("RestartSec=5\n")
+	// Leaves time to end calls cleanly; drain first with 'agent drain'
+	sb.WriteString("TimeoutStopSec=60\n")
+	sb.WriteString("LimitNOFILE=65536\n")
+	sb.WriteString("StandardOutput=journal\n")
+	sb.WriteString("StandardError=journal\n")
+	sb.WriteString("SyslogIdentifier=" + identifier(EngineUnit) + "\n\n")
+
+	sb.WriteString(hardening)
+	sb.WriteString(protectHome(o.Dir))
+	// Call data, config saved by the admin UI, and the generated audio
+	// Asterisk plays back
+	sb.WriteString(fmt.Sprintf("ReadWritePaths=-%s -%s -/mnt/asterisk_media\n",
+		filepath.Join(o.Dir, "data"), filepath.Join(o.Dir, "config")))
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=multi-user.target\n")
+	return sb.String()
+}
+
+func localAIUnit(o Options) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by: agent install systemd\n")
+	sb.WriteString("[Unit]\n")
+	sb.WriteString("Description=Asterisk AI Voice Agent local AI server (STT/LLM/TTS)\n")
+	sb.WriteString("After=network-online.target\n")
+	sb.WriteString("Wants=network-online.target\n")
+	sb.WriteString("StartLimitIntervalSec=600\n")
+	sb.WriteString("StartLimitBurst=5\n\n")
+
+	sb.WriteString("[Service]\n")
+	sb.WriteString("Type=simple\n")
+	writeAccount(&sb, o)
+	sb.WriteString("WorkingDirectory=" + filepath.Join(o.Dir, "local_ai_server") + "\n")
+	sb.WriteString("EnvironmentFile=-" + filepath.Join(o.Dir, ".env") + "\n")
+	sb.WriteString("Environment=PYTHONUNBUFFERED=1\n")
+	sb.WriteString(fmt.Sprintf("ExecStart=%s %s\n", o.Python, filepath.Join(o.Dir, "local_ai_server", "main.py")))
+	sb.WriteString("Restart=on-failure\n")
+	sb.WriteString("RestartSec=10\n")
+	// Loading models can take minutes
+	sb.WriteString("TimeoutStartSec=600\n")
+	sb.WriteString("StandardOutput=journal\n")
+	sb.WriteString("StandardError=journal\n")
+	sb.WriteString("SyslogIdentifier=" + identifier(LocalAIUnit) + "\n\n")
+
+	sb.WriteString(hardening)
+	sb.WriteString(protectHome(o.Dir))
+	sb.WriteString("ReadWritePaths=-" + filepath.Join(o.Dir, "models") + "\n")
+	sb.WriteString("\n[Install]\n")
+	sb.WriteString("WantedBy=multi-user.target\n")
+	return sb.String()
+}
+
+// cliUnit runs one agent command. The CLI keeps its state (settings,
+// call index, notification config) in StateDir and reads the engine
+// journal, so it runs in the systemd-journal group.
+func cliUnit(o Options, unit, description, args string) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by: agent install systemd\n")
+	sb.WriteString("[Unit]\n")
+	sb.WriteString("Description=" + description + "\n")
+	sb.WriteString("After=" + EngineUnit + "\n\n")
+
+	sb.WriteString("[Service]\n")
+	sb.WriteString("Type=oneshot\n")
+	writeAccount(&sb, o)
+	sb.WriteString("SupplementaryGroups=systemd-journal\n")
+	sb.WriteString("WorkingDirectory=" + o.Dir + "\n")
+	sb.WriteString("StateDirectory=aava\n")
+	sb.WriteString("Environment=AGENT_STATE_DIR=" + StateDir + "\n")
+	sb.WriteString("Environment=AGENT_SKIP_VERSION_CHECK=1\n")
+	sb.WriteString(fmt.Sprintf("ExecStart=%s %s\n", o.Agent, args))
+	sb.WriteString("TimeoutStartSec=10min\n")
+	sb.WriteString("StandardOutput=journal\n")
+	sb.WriteString("StandardError=journal\n")
+	sb.WriteString("SyslogIdentifier=" + identifier(unit) + "\n\n")
+
+	sb.WriteString(hardening)
+	sb.WriteString(protectHome(o.Dir))
+	return sb.String()
+}
+
+func timerUnit(description string, interval time.Duration) string {
+	var sb strings.Builder
+	sb.WriteString("# Generated by: agent install systemd\n")
+	sb.WriteString("[Unit]\n")
+	sb.WriteString("Description=" + description + "\n\n")
+	sb.WriteString("[Timer]\n")
+	sb.WriteString("OnBootSec=2min\n")
+	sb.WriteString(fmt.Sprintf("OnUnitActiveSec=%ds\n", int(interval.Seconds())))
+	sb.WriteString("AccuracySec=10s\n\n")
+	sb.WriteString("[Install]\n")
+	sb.WriteString("WantedBy=timers.target\n")
+	return sb.String()
+}
+
+func writeAccount(sb *strings.Builder, o Options) {
+	if o.User != "" {
+		sb.WriteString("User=" + o.User + "\n")
+	}
+	if o.Group != "" {
+		sb.WriteString("Group=" + o.Group + "\n")
+	}
+}
@@ -0,0 +1,134 @@
+// Package theme holds the colors the CLI's output uses by meaning, so
+// that the palette can be switched in ~/.agent/config: the default
+// red/green/yellow, a colorblind-safe palette, or monochrome output that
+// relies on symbols alone.
+package theme
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/fatih/color"
+)
+
+// Theme names
+const (
+	Default    = "default"
+	Colorblind = "colorblind"
+	Mono       = "mono"
+)
+
+// The colors output is printed in, by meaning. Packages keep these
+// pointers; Apply changes them in place.
+var (
+	Success = color.New(color.FgGreen)
+	Error   = color.New(color.FgRed)
+	Warning = color.New(color.FgYellow)
+	Info    = color.New(color.FgBlue)
+	Accent  = color.New(color.FgCyan)
+	Muted   = color.New(color.FgHiBlack)
+	Prompt  = color.New(color.FgCyan, color.Bold)
+)
+
+// 256-color codes of the Okabe-Ito palette, told apart with every
+// common kind of color blindness
+const (
+	okabeBlue       = 32  // #0072B2
+	okabeVermillion = 166 // #D55E00
+	okabeOrange     = 178 // #E69F00
+	okabeSkyBlue    = 74  // #56B4E9
+)
+
+// palette is a theme's attributes for each color
+type palette struct {
+	success, error, warning, info, accent, muted, prompt []color.Attribute
+	// symbols marks levels with words where only color told them apart
+	symbols bool
+	noColor bool
+}
+
+func fg256(code int) []color.Attribute {
+	return []color.Attribute{38, 5, color.Attribute(code)}
+}
+
+var palettes = map[string]palette{
+	Default: {
+		success: []color.Attribute{color.FgGreen},
+		error:   []color.Attribute{color.FgRed},
+		warning: []color.Attribute{color.FgYellow},
+		info:    []color.Attribute{color.FgBlue},
+		accent:  []color.Attribute{color.FgCyan},
+		muted:   []color.Attribute{color.FgHiBlack},
+		prompt:  []color.Attribute{color.FgCyan, color.Bold},
+	},
+	// Blue for good and vermillion for bad instead of green and red,
+	// with levels also named in words
+	Colorblind: {
+		success: fg256(okabeBlue),
+		error:   append(fg256(okabeVermillion), color.Bold),
+		warning: fg256(okabeOrange),
+		info:    []color.Attribute{color.Bold},
+		accent:  fg256(okabeSkyBlue),
+		muted:   []color.Attribute{color.FgHiBlack},
+		prompt:  append(fg256(okabeSkyBlue), color.Bold),
+		symbols: true,
+	},
+	Mono: {
+		symbols: true,
+		noColor: true,
+	},
+}
+
+var current = Default
+
+// Names lists the themes
+func Names() []string {
+	var names []string
+	for name := range palettes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Apply switches to a theme; "" is the default
+func Apply(name string) error {
+	if name == "" {
+		name = Default
+	}
+	p, ok := palettes[name]
+	if !ok {
+		return fmt.Errorf("unknown theme %q (use %s)", name, strings.Join(Names(), ", "))
+	}
+	*Success = *color.New(p.success...)
+	*Error = *color.New(p.error...)
+	*Warning = *color.New(p.warning...)
+	*Info = *color.New(p.info...)
+	*Accent = *color.New(p.accent...)
+	*Muted = *color.New(p.muted...)
+	*Prompt = *color.New(p.prompt...)
+	if p.noColor {
+		color.NoColor = true
+	}
+	current = name
+	return nil
+}
+
+// Current is the theme in use
+func Current() string {
+	return current
+}
+
+// Bold returns a bold copy of one of the theme's colors
+func Bold(c *color.Color) *color.Color {
+	b := *c
+	return b.Add(color.Bold)
+}
+
+// Symbols reports whether levels shown in color alone (e.g. wallboard
+// tiles) should also be named, for themes where color cannot tell them
+// apart
+func Symbols() bool {
+	return palettes[current].symbols
+}
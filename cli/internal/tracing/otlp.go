@@ -0,0 +1,197 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultServiceName is the service.name resource attribute of exported traces
+const DefaultServiceName = "asterisk-ai-voice-agent"
+
+// Span is one timed operation of a trace. Parent is the index of the
+// parent span in the slice passed to Export, or -1 for the root.
+type Span struct {
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Parent     int
+	Attributes map[string]string
+	Error      bool
+}
+
+// Exporter sends traces to an OTLP/HTTP collector (JSON encoding)
+type Exporter struct {
+	Endpoint    string
+	Headers     map[string]string
+	ServiceName string
+	Client      *http.Client
+}
+
+// NewExporter creates an exporter for endpoint, e.g. http://tempo:4318.
+// The /v1/traces path is appended unless already present.
+func NewExporter(endpoint string, headers map[string]string, serviceName string) *Exporter {
+	endpoint = strings.TrimRight(endpoint, "/")
+	if !strings.HasSuffix(endpoint, "/v1/traces") {
+		endpoint += "/v1/traces"
+	}
+	if serviceName == "" {
+		serviceName = DefaultServiceName
+	}
+	return &Exporter{
+		Endpoint:    endpoint,
+		Headers:     headers,
+		ServiceName: serviceName,
+		Client:      &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// TraceID derives a stable 128-bit trace ID from a key such as a call ID,
+// so exporting the same call twice lands in the same trace
+func TraceID(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
+
+// spanID derives a stable 64-bit span ID for the i-th span of a trace
+func spanID(traceID string, i int, name string) string {
+	sum := sha256.Sum256([]byte(traceID + "/" + strconv.Itoa(i) + "/" + name))
+	return hex.EncodeToString(sum[:8])
+}
+
+// Export sends spans as one trace keyed by traceKey
+func (e *Exporter) Export(ctx context.Context, traceKey string, spans []Span) error {
+	if len(spans) == 0 {
+		return fmt.Errorf("no spans to export")
+	}
+	body, err := json.Marshal(e.payload(TraceID(traceKey), spans))
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s: %s", e.Endpoint, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// OTLP/JSON wire types (opentelemetry-proto, JSON mapping)
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name string `json:"name"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            *otlpStatus    `json:"status,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string    `json:"key"`
+		Value otlpValue `json:"value"`
+	}
+	otlpValue struct {
+		StringValue string `json:"stringValue"`
+	}
+	otlpStatus struct {
+		Code int `json:"code"`
+	}
+)
+
+// Span kinds and status codes used in exports
+const (
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+func (e *Exporter) payload(traceID string, spans []Span) otlpRequest {
+	ids := make([]string, len(spans))
+	for i, s := range spans {
+		ids[i] = spanID(traceID, i, s.Name)
+	}
+
+	out := make([]otlpSpan, 0, len(spans))
+	for i, s := range spans {
+		span := otlpSpan{
+			TraceID:           traceID,
+			SpanID:            ids[i],
+			Name:              s.Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.End.UnixNano(), 10),
+			Attributes:        keyValues(s.Attributes),
+		}
+		if s.Parent >= 0 && s.Parent < len(spans) && s.Parent != i {
+			span.ParentSpanID = ids[s.Parent]
+		}
+		if s.Error {
+			span.Status = &otlpStatus{Code: statusCodeError}
+		}
+		out = append(out, span)
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource: otlpResource{Attributes: keyValues(map[string]string{"service.name": e.ServiceName})},
+		ScopeSpans: []otlpScopeSpans{{
+			Scope: otlpScope{Name: "agent-cli"},
+			Spans: out,
+		}},
+	}}}
+}
+
+// keyValues converts attributes in a stable key order
+func keyValues(attrs map[string]string) []otlpKeyValue {
+	keys := make([]string, 0, len(attrs))
+	for k := range attrs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	kvs := make([]otlpKeyValue, 0, len(keys))
+	for _, k := range keys {
+		kvs = append(kvs, otlpKeyValue{Key: k, Value: otlpValue{StringValue: attrs[k]}})
+	}
+	return kvs
+}
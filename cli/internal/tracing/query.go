@@ -0,0 +1,224 @@
+package tracing
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultCallIDAttribute is the span attribute engines tag call IDs with
+const DefaultCallIDAttribute = "call_id"
+
+// maxTraces bounds how many traces a single lookup fetches
+const maxTraces = 20
+
+// Querier finds spans in a tracing backend
+type Querier interface {
+	Name() string
+	// FindSpans returns the spans of every trace carrying attr=value that
+	// started in [start, end]
+	FindSpans(ctx context.Context, attr, value string, start, end time.Time) ([]Span, error)
+}
+
+// NewQuerier returns a client for a Tempo or Jaeger query API. service
+// is required for Jaeger, whose search is scoped to one service.
+func NewQuerier(kind, baseURL, service string, headers map[string]string) (Querier, error) {
+	if baseURL == "" {
+		return nil, fmt.Errorf("tracing query: url is required")
+	}
+	base := strings.TrimRight(baseURL, "/")
+	client := &http.Client{Timeout: 30 * time.Second}
+
+	switch strings.ToLower(kind) {
+	case "tempo", "":
+		return &tempoQuerier{url: base, headers: headers, client: client}, nil
+	case "jaeger":
+		if service == "" {
+			return nil, fmt.Errorf("tracing query: jaeger requires a service name")
+		}
+		return &jaegerQuerier{url: base, service: service, headers: headers, client: client}, nil
+	}
+	return nil, fmt.Errorf("tracing query: unknown type %q (use tempo or jaeger)", kind)
+}
+
+// getJSON fetches endpoint and decodes the JSON response into out
+func getJSON(ctx context.Context, client *http.Client, endpoint string, headers map[string]string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// tempoQuerier uses Tempo's search and trace-by-ID APIs
+type tempoQuerier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (q *tempoQuerier) Name() string { return "tempo" }
+
+func (q *tempoQuerier) FindSpans(ctx context.Context, attr, value string, start, end time.Time) ([]Span, error) {
+	params := url.Values{}
+	params.Set("tags", attr+"="+value)
+	params.Set("start", strconv.FormatInt(start.Unix(), 10))
+	params.Set("end", strconv.FormatInt(end.Unix()+1, 10))
+	params.Set("limit", strconv.Itoa(maxTraces))
+
+	var search struct {
+		Traces []struct {
+			TraceID string `json:"traceID"`
+		} `json:"traces"`
+	}
+	if err := getJSON(ctx, q.client, q.url+"/api/search?"+params.Encode(), q.headers, &search); err != nil {
+		return nil, fmt.Errorf("tempo search: %w", err)
+	}
+
+	var spans []Span
+	for _, t := range search.Traces {
+		var trace otlpTrace
+		if err := getJSON(ctx, q.client, q.url+"/api/traces/"+url.PathEscape(t.TraceID), q.headers, &trace); err != nil {
+			return nil, fmt.Errorf("tempo trace %s: %w", t.TraceID, err)
+		}
+		spans = append(spans, trace.spans()...)
+	}
+	return spans, nil
+}
+
+// otlpTrace is a trace as returned by Tempo: OTLP JSON under "batches"
+// (v1 API) or "trace.resourceSpans" (v2 API)
+type otlpTrace struct {
+	Batches       []otlpQueryResource `json:"batches"`
+	ResourceSpans []otlpQueryResource `json:"resourceSpans"`
+	Trace         *struct {
+		ResourceSpans []otlpQueryResource `json:"resourceSpans"`
+	} `json:"trace"`
+}
+
+type otlpQueryResource struct {
+	ScopeSpans                  []otlpQueryScope `json:"scopeSpans"`
+	InstrumentationLibrarySpans []otlpQueryScope `json:"instrumentationLibrarySpans"`
+}
+
+type otlpQueryScope struct {
+	Spans []struct {
+		Name              string      `json:"name"`
+		StartTimeUnixNano json.Number `json:"startTimeUnixNano"`
+		EndTimeUnixNano   json.Number `json:"endTimeUnixNano"`
+		Status            struct {
+			Code interface{} `json:"code"`
+		} `json:"status"`
+	} `json:"spans"`
+}
+
+func (t *otlpTrace) spans() []Span {
+	var resources []otlpQueryResource
+	resources = append(resources, t.Batches...)
+	resources = append(resources, t.ResourceSpans...)
+	if t.Trace != nil {
+		resources = append(resources, t.Trace.ResourceSpans...)
+	}
+
+	var spans []Span
+	for _, res := range resources {
+		scopes := append([]otlpQueryScope(nil), res.ScopeSpans...)
+		for _, scope := range append(scopes, res.InstrumentationLibrarySpans...) {
+			for _, s := range scope.Spans {
+				startNs, err1 := s.StartTimeUnixNano.Int64()
+				endNs, err2 := s.EndTimeUnixNano.Int64()
+				if err1 != nil || err2 != nil {
+					continue
+				}
+				// Status codes are numeric (2) or enum names (STATUS_CODE_ERROR)
+				code := fmt.Sprint(s.Status.Code)
+				spans = append(spans, Span{
+					Name:   s.Name,
+					Start:  time.Unix(0, startNs),
+					End:    time.Unix(0, endNs),
+					Parent: -1,
+					Error:  code == "2" || code == "STATUS_CODE_ERROR",
+				})
+			}
+		}
+	}
+	return spans
+}
+
+// jaegerQuerier uses the Jaeger query service HTTP API
+type jaegerQuerier struct {
+	url     string
+	service string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (q *jaegerQuerier) Name() string { return "jaeger" }
+
+func (q *jaegerQuerier) FindSpans(ctx context.Context, attr, value string, start, end time.Time) ([]Span, error) {
+	tags, err := json.Marshal(map[string]string{attr: value})
+	if err != nil {
+		return nil, err
+	}
+	params := url.Values{}
+	params.Set("service", q.service)
+	params.Set("tags", string(tags))
+	params.Set("start", strconv.FormatInt(start.UnixNano()/1000, 10))
+	params.Set("end", strconv.FormatInt(end.UnixNano()/1000, 10))
+	params.Set("limit", strconv.Itoa(maxTraces))
+
+	var result struct {
+		Data []struct {
+			Spans []struct {
+				OperationName string `json:"operationName"`
+				StartTime     int64  `json:"startTime"`
+				Duration      int64  `json:"duration"`
+				Tags          []struct {
+					Key   string      `json:"key"`
+					Value interface{} `json:"value"`
+				} `json:"tags"`
+			} `json:"spans"`
+		} `json:"data"`
+	}
+	if err := getJSON(ctx, q.client, q.url+"/api/traces?"+params.Encode(), q.headers, &result); err != nil {
+		return nil, fmt.Errorf("jaeger search: %w", err)
+	}
+
+	var spans []Span
+	for _, trace := range result.Data {
+		for _, s := range trace.Spans {
+			span := Span{
+				Name:   s.OperationName,
+				Start:  time.Unix(0, s.StartTime*1000),
+				End:    time.Unix(0, (s.StartTime+s.Duration)*1000),
+				Parent: -1,
+			}
+			for _, tag := range s.Tags {
+				if tag.Key == "error" && fmt.Sprint(tag.Value) == "true" {
+					span.Error = true
+				}
+			}
+			spans = append(spans, span)
+		}
+	}
+	return spans, nil
+}
@@ -0,0 +1,248 @@
+package troubleshoot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+)
+
+// Finding severities
+const (
+	SeverityCritical = results.SeverityCritical
+	SeverityWarning  = results.SeverityWarning
+	SeverityInfo     = results.SeverityInfo
+)
+
+// Finding is one typed result contributed by an analyzer
+type Finding = results.Finding
+
+// LogEvent is one parsed log line. Fields is nil for non-JSON lines.
+type LogEvent struct {
+	Line   string                 `json:"line"`
+	Lower  string                 `json:"-"`
+	Event  string                 `json:"event,omitempty"`
+	Level  string                 `json:"level,omitempty"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+// parseLogEvent parses a raw log line into a LogEvent
+func parseLogEvent(line string) *LogEvent {
+	ev := &LogEvent{Line: line, Lower: strings.ToLower(line)}
+
+	trimmed := strings.TrimSpace(line)
+	if strings.HasPrefix(trimmed, "{") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(trimmed), &fields); err == nil {
+			ev.Fields = fields
+			ev.Event, _ = fields["event"].(string)
+			ev.Level, _ = fields["level"].(string)
+		}
+	}
+	return ev
+}
+
+// Number returns a numeric field of a JSON event
+func (ev *LogEvent) Number(key string) (float64, bool) {
+	v, ok := ev.Fields[key].(float64)
+	return v, ok
+}
+
+// String returns a string field of a JSON event
+func (ev *LogEvent) String(key string) string {
+	s, _ := ev.Fields[key].(string)
+	return s
+}
+
+// Analyzer consumes the event stream of one call. A fresh instance is
+// created per analysis; Finish may fill Analysis fields and returns the
+// analyzer's findings.
+type Analyzer interface {
+	Name() string
+	Observe(ev *LogEvent)
+	Finish(analysis *Analysis) []Finding
+}
+
+type analyzerFactory struct {
+	name string
+	new  func() Analyzer
+}
+
+var analyzerRegistry []analyzerFactory
+
+// RegisterAnalyzer adds an analyzer to every analysis run.
+// Registering a name twice replaces the earlier factory.
+func RegisterAnalyzer(name string, factory func() Analyzer) {
+	for i, f := range analyzerRegistry {
+		if f.name == name {
+			analyzerRegistry[i].new = factory
+			return
+		}
+	}
+	analyzerRegistry = append(analyzerRegistry, analyzerFactory{name: name, new: factory})
+}
+
+// AnalyzerNames lists the registered analyzers in run order
+func AnalyzerNames() []string {
+	names := make([]string, 0, len(analyzerRegistry))
+	for _, f := range analyzerRegistry {
+		names = append(names, f.name)
+	}
+	return names
+}
+
+func init() {
+	RegisterAnalyzer("errors", func() Analyzer { return &errorsAnalyzer{} })
+	RegisterAnalyzer("audio", func() Analyzer { return &audioAnalyzer{} })
+	RegisterAnalyzer("latency", func() Analyzer { return &latencyAnalyzer{} })
+	RegisterAnalyzer("providers", func() Analyzer { return newProvidersAnalyzer() })
+	RegisterAnalyzer("signaling", func() Analyzer { return &signalingAnalyzer{} })
+	RegisterAnalyzer("asr", func() Analyzer { return newASRAnalyzer() })
+	RegisterAnalyzer("entities", func() Analyzer { return newEntitiesAnalyzer() })
+	RegisterAnalyzer("frames", func() Analyzer { return newFramesAnalyzer() })
+	RegisterAnalyzer("secrets", func() Analyzer { return &secretsAnalyzer{} })
+	RegisterAnalyzer("rules", newRulesAnalyzer)
+}
+
+// runAnalyzers feeds every log line to the registered analyzers and any
+// external plugins, and collects their findings, most severe first
+func runAnalyzers(ctx context.Context, logData string, analysis *Analysis) {
+	analyzers := make([]Analyzer, 0, len(analyzerRegistry))
+	for _, f := range analyzerRegistry {
+		analyzers = append(analyzers, f.new())
+	}
+	analyzers = append(analyzers, pluginAnalyzers(ctx)...)
+
+	// An analyzer that panics is dropped and reported as incomplete; the
+	// others carry on
+	failed := make([]error, len(analyzers))
+	for _, line := range strings.Split(logData, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		ev := parseLogEvent(line)
+		for i, a := range analyzers {
+			if failed[i] == nil {
+				failed[i] = safely(func() { a.Observe(ev) })
+			}
+		}
+	}
+
+	for i, a := range analyzers {
+		if failed[i] != nil {
+			analysis.fail(a.Name(), failed[i])
+			continue
+		}
+		var findings []Finding
+		if err := safely(func() { findings = a.Finish(analysis) }); err != nil {
+			analysis.fail(a.Name(), err)
+			continue
+		}
+		if f, ok := a.(fallible); ok && f.Err() != nil {
+			analysis.fail(a.Name(), f.Err())
+		}
+		for _, f := range findings {
+			if f.Analyzer == "" {
+				f.Analyzer = a.Name()
+			}
+			// Evidence quotes log lines; keep credentials out of reports
+			f.Evidence = RedactSecrets(f.Evidence)
+			analysis.Findings = append(analysis.Findings, f)
+		}
+	}
+
+	sortFindings(analysis.Findings)
+}
+
+// fallible is an analyzer that can fail to complete, e.g. a plugin that
+// times out; Err reports why after Finish
+type fallible interface {
+	Err() error
+}
+
+// Incomplete is an analyzer or analysis step that did not complete, so
+// its findings are missing from the results
+type Incomplete = results.Incomplete
+
+// fail records that an analyzer or step did not complete
+func (a *Analysis) fail(name string, err error) {
+	a.Incomplete = append(a.Incomplete, Incomplete{Analyzer: name, Reason: err.Error()})
+}
+
+// step runs one analysis step. A step that panics or returns an error
+// is recorded as incomplete instead of aborting the run; step reports
+// whether it completed.
+func (a *Analysis) step(name string, fn func() error) bool {
+	var err error
+	if perr := safely(func() { err = fn() }); perr != nil {
+		err = perr
+	}
+	if err != nil {
+		a.fail(name, err)
+		return false
+	}
+	return true
+}
+
+// safely runs fn, turning a panic into an error that names where it
+// happened. The stack goes to the CLI's own log.
+func safely(fn func()) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v%s", p, panicLocation())
+			selflog.Write(selflog.Entry{
+				Level:  selflog.LevelError,
+				Event:  selflog.EventPanic,
+				Error:  err.Error(),
+				Detail: string(debug.Stack()),
+			})
+		}
+	}()
+	fn()
+	return nil
+}
+
+// panicLocation returns " at file:line" of the code that panicked
+func panicLocation() string {
+	pcs := make([]uintptr, 32)
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs)])
+	panicking := false
+	for {
+		frame, more := frames.Next()
+		if frame.Function == "runtime.gopanic" {
+			panicking = true
+		} else if panicking && !strings.HasPrefix(frame.Function, "runtime.") {
+			file := frame.File
+			if i := strings.LastIndex(file, "/"); i >= 0 {
+				file = file[i+1:]
+			}
+			return fmt.Sprintf(" at %s:%d", file, frame.Line)
+		}
+		if !more {
+			return ""
+		}
+	}
+}
+
+// sortFindings orders findings most severe first, keeping analyzer order
+func sortFindings(findings []Finding) {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return severityRank(findings[i].Severity) < severityRank(findings[j].Severity)
+	})
+}
+
+func severityRank(severity string) int {
+	switch severity {
+	case SeverityCritical:
+		return 0
+	case SeverityWarning:
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,299 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// errorsAnalyzer collects error and warning lines
+type errorsAnalyzer struct {
+	errors   []string
+	warnings []string
+}
+
+func (a *errorsAnalyzer) Name() string { return "errors" }
+
+func (a *errorsAnalyzer) Observe(ev *LogEvent) {
+	if strings.Contains(ev.Lower, "error") && !strings.Contains(ev.Lower, "0 error") {
+		a.errors = append(a.errors, ev.Line)
+	}
+	if strings.Contains(ev.Lower, "warning") || strings.Contains(ev.Lower, "warn") {
+		a.warnings = append(a.warnings, ev.Line)
+	}
+}
+
+func (a *errorsAnalyzer) Finish(analysis *Analysis) []Finding {
+	analysis.Errors = a.errors
+	analysis.Warnings = a.warnings
+
+	if len(a.errors) > 10 {
+		return []Finding{{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("High error count (%d error lines)", len(a.errors)),
+			Evidence: truncate(a.errors[0], 100),
+		}}
+	}
+	return nil
+}
+
+// audioAnalyzer tracks pipeline stages and audio quality indicators
+type audioAnalyzer struct {
+	audioSocket   bool
+	transcription bool
+	playback      bool
+	issues        []string
+}
+
+func (a *audioAnalyzer) Name() string { return "audio" }
+
+func (a *audioAnalyzer) Observe(ev *LogEvent) {
+	lower := ev.Lower
+
+	if strings.Contains(lower, "audiosocket") {
+		a.audioSocket = true
+	}
+	if strings.Contains(lower, "transcription") || strings.Contains(lower, "transcript") {
+		a.transcription = true
+	}
+	if strings.Contains(lower, "playback") || strings.Contains(lower, "playing") {
+		a.playback = true
+	}
+
+	if strings.Contains(lower, "underflow") {
+		a.issues = append(a.issues, "Jitter buffer underflow detected")
+	}
+	if strings.Contains(lower, "garbled") || strings.Contains(lower, "distorted") {
+		a.issues = append(a.issues, "Audio quality issue detected")
+	}
+	if strings.Contains(lower, "echo") {
+		a.issues = append(a.issues, "Echo detected")
+	}
+}
+
+func (a *audioAnalyzer) Finish(analysis *Analysis) []Finding {
+	analysis.HasAudioSocket = a.audioSocket
+	analysis.HasTranscription = a.transcription
+	analysis.HasPlayback = a.playback
+	analysis.AudioIssues = a.issues
+	return nil
+}
+
+const (
+	// latencyWarnMs / latencyCriticalMs: p95 turn latency thresholds
+	latencyWarnMs     = 1500.0
+	latencyCriticalMs = 3000.0
+)
+
+// latencyAnalyzer summarizes per-turn response latency
+type latencyAnalyzer struct {
+	samples []float64
+}
+
+func (a *latencyAnalyzer) Name() string { return "latency" }
+
+func (a *latencyAnalyzer) Observe(ev *LogEvent) {
+	if !strings.Contains(strings.ToLower(ev.Event), "turn latency") {
+		return
+	}
+	// "Turn latency saved to session" repeats the recorded value
+	if strings.Contains(ev.Event, "saved") {
+		return
+	}
+	if ms, ok := ev.Number("latency_ms"); ok && ms > 0 {
+		a.samples = append(a.samples, ms)
+	}
+}
+
+func (a *latencyAnalyzer) Finish(analysis *Analysis) []Finding {
+	return summarizeTurnLatency(analysis, a.samples)
+}
+
+// latencyStats returns the average, p95 and maximum of samples
+func latencyStats(samples []float64) (avg, p95, worst float64) {
+	if len(samples) == 0 {
+		return 0, 0, 0
+	}
+	sorted := append([]float64(nil), samples...)
+	sort.Float64s(sorted)
+	p95 = sorted[(len(sorted)*95+99)/100-1]
+	worst = sorted[len(sorted)-1]
+	var sum float64
+	for _, s := range sorted {
+		sum += s
+	}
+	return sum / float64(len(sorted)), p95, worst
+}
+
+// summarizeTurnLatency records turn latency metrics and grades the p95
+func summarizeTurnLatency(analysis *Analysis, samples []float64) []Finding {
+	if len(samples) == 0 {
+		return nil
+	}
+	avg, p95, worst := latencyStats(samples)
+
+	analysis.MetricsMap["turn_latency_avg_ms"] = fmt.Sprintf("%.0f", avg)
+	analysis.MetricsMap["turn_latency_p95_ms"] = fmt.Sprintf("%.0f", p95)
+	analysis.MetricsMap["turn_latency_max_ms"] = fmt.Sprintf("%.0f", worst)
+
+	summary := fmt.Sprintf("%d turns, avg %.0fms, p95 %.0fms, max %.0fms", len(samples), avg, p95, worst)
+	switch {
+	case p95 >= latencyCriticalMs:
+		return []Finding{{Severity: SeverityCritical, Message: "Very slow agent responses", Evidence: summary}}
+	case p95 >= latencyWarnMs:
+		return []Finding{{Severity: SeverityWarning, Message: "Slow agent responses", Evidence: summary}}
+	}
+	return []Finding{{Severity: SeverityInfo, Message: "Turn latency", Evidence: summary}}
+}
+
+// providerProblem maps log markers to a provider-side failure
+type providerProblem struct {
+	markers  []string
+	severity string
+	message  string
+}
+
+var providerProblems = []providerProblem{
+	{[]string{"401", "unauthorized", "invalid api key", "invalid_api_key"}, SeverityCritical, "Provider rejected credentials"},
+	{[]string{"429", "rate limit", "rate_limit", "quota"}, SeverityWarning, "Provider rate limiting or quota exceeded"},
+	{[]string{"connection closed", "connectionclosed", "websocket closed", "disconnected"}, SeverityWarning, "Provider connection dropped"},
+	{[]string{"timed out", "timeout"}, SeverityWarning, "Provider request timed out"},
+}
+
+// providersAnalyzer records which providers were used and their failures
+type providersAnalyzer struct {
+	providers map[string]bool
+	counts    []int
+	evidence  []string
+}
+
+func newProvidersAnalyzer() *providersAnalyzer {
+	return &providersAnalyzer{
+		providers: make(map[string]bool),
+		counts:    make([]int, len(providerProblems)),
+		evidence:  make([]string, len(providerProblems)),
+	}
+}
+
+func (a *providersAnalyzer) Name() string { return "providers" }
+
+func (a *providersAnalyzer) Observe(ev *LogEvent) {
+	if name := ev.String("provider"); name != "" {
+		a.providers[name] = true
+	}
+
+	level := strings.ToLower(ev.Level)
+	isProblem := level == "error" || level == "warning" || strings.Contains(ev.Lower, "error")
+	if !isProblem || (!strings.Contains(ev.Lower, "provider") && ev.String("provider") == "") {
+		return
+	}
+	for i, p := range providerProblems {
+		for _, marker := range p.markers {
+			if strings.Contains(ev.Lower, marker) {
+				a.counts[i]++
+				if a.evidence[i] == "" {
+					a.evidence[i] = truncate(ev.Line, 100)
+				}
+				break
+			}
+		}
+	}
+}
+
+func (a *providersAnalyzer) Finish(analysis *Analysis) []Finding {
+	if len(a.providers) > 0 {
+		names := make([]string, 0, len(a.providers))
+		for name := range a.providers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		analysis.MetricsMap["providers"] = strings.Join(names, ",")
+	}
+
+	var findings []Finding
+	for i, p := range providerProblems {
+		if a.counts[i] == 0 {
+			continue
+		}
+		findings = append(findings, Finding{
+			Severity: p.severity,
+			Message:  fmt.Sprintf("%s (%dx)", p.message, a.counts[i]),
+			Evidence: a.evidence[i],
+		})
+	}
+	return findings
+}
+
+// signalingAnalyzer follows the ARI channel lifecycle of the call
+type signalingAnalyzer struct {
+	stasisStart  bool
+	stasisEnd    bool
+	destroyed    bool
+	startFailed  string
+	hangupCause  int
+	ariErrors    int
+	ariErrorLine string
+}
+
+func (a *signalingAnalyzer) Name() string { return "signaling" }
+
+func (a *signalingAnalyzer) Observe(ev *LogEvent) {
+	lower := ev.Lower
+
+	switch {
+	case strings.Contains(lower, "failed to handle caller stasisstart"):
+		a.startFailed = truncate(ev.Line, 100)
+	case strings.Contains(lower, "stasisstart"):
+		a.stasisStart = true
+	case strings.Contains(lower, "stasisend"):
+		a.stasisEnd = true
+	case strings.Contains(lower, "channeldestroyed") || strings.Contains(lower, "channel destroyed"):
+		a.destroyed = true
+	}
+
+	if m := hangupCausePattern.FindStringSubmatch(ev.Line); len(m) > 1 {
+		if cause, err := strconv.Atoi(m[1]); err == nil {
+			a.hangupCause = cause
+		}
+	}
+
+	if strings.Contains(ev.Line, "ARI") && strings.Contains(lower, "error") {
+		a.ariErrors++
+		if a.ariErrorLine == "" {
+			a.ariErrorLine = truncate(ev.Line, 100)
+		}
+	}
+}
+
+func (a *signalingAnalyzer) Finish(analysis *Analysis) []Finding {
+	var findings []Finding
+
+	if a.startFailed != "" {
+		findings = append(findings, Finding{
+			Severity: SeverityCritical,
+			Message:  "Engine failed to handle StasisStart",
+			Evidence: a.startFailed,
+		})
+	}
+	if a.hangupCause != 0 && failureCauses[a.hangupCause] {
+		findings = append(findings, Finding{
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("Call ended with failure hangup cause %d", a.hangupCause),
+		})
+	}
+	if a.ariErrors > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("ARI errors (%d)", a.ariErrors),
+			Evidence: a.ariErrorLine,
+		})
+	}
+	if a.stasisStart && !a.stasisEnd && !a.destroyed {
+		findings = append(findings, Finding{
+			Severity: SeverityInfo,
+			Message:  "No StasisEnd/ChannelDestroyed seen (call still active or logs truncated)",
+		})
+	}
+	return findings
+}
@@ -0,0 +1,177 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// runAnalyzer feeds lines to a and returns the analysis and findings
+func runAnalyzer(a Analyzer, lines ...string) (*Analysis, []Finding) {
+	analysis := &Analysis{MetricsMap: make(map[string]string)}
+	for _, line := range lines {
+		a.Observe(parseLogEvent(line))
+	}
+	return analysis, a.Finish(analysis)
+}
+
+// findingKeys reduces findings to "severity: message" for comparison
+func findingKeys(findings []Finding) []string {
+	var keys []string
+	for _, f := range findings {
+		keys = append(keys, f.Severity+": "+f.Message)
+	}
+	return keys
+}
+
+func TestErrorsAnalyzer(t *testing.T) {
+	manyErrors := make([]string, 11)
+	for i := range manyErrors {
+		manyErrors[i] = fmt.Sprintf("ERROR step %d failed", i)
+	}
+	tests := []struct {
+		name         string
+		lines        []string
+		wantErrors   int
+		wantWarnings int
+		want         []string
+	}{
+		{"clean", []string{"call started", "0 errors reported"}, 0, 0, nil},
+		{"error and warning", []string{"ERROR boom", "WARN slow", "warning: retry"}, 1, 2, nil},
+		{"high error count", manyErrors, 11, 0, []string{SeverityWarning + ": High error count (11 error lines)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, findings := runAnalyzer(&errorsAnalyzer{}, tt.lines...)
+			if len(analysis.Errors) != tt.wantErrors || len(analysis.Warnings) != tt.wantWarnings {
+				t.Errorf("errors/warnings = %d/%d, want %d/%d",
+					len(analysis.Errors), len(analysis.Warnings), tt.wantErrors, tt.wantWarnings)
+			}
+			if got := findingKeys(findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAudioAnalyzer(t *testing.T) {
+	tests := []struct {
+		name       string
+		lines      []string
+		wantStages [3]bool
+		wantIssues []string
+	}{
+		{"nothing", []string{"call started"}, [3]bool{}, nil},
+		{"full pipeline", []string{"AudioSocket connected", "Transcription received", "Playback started"}, [3]bool{true, true, true}, nil},
+		{"quality issues", []string{"jitter buffer underflow", "garbled audio", "echo detected"}, [3]bool{},
+			[]string{"Jitter buffer underflow detected", "Audio quality issue detected", "Echo detected"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, findings := runAnalyzer(&audioAnalyzer{}, tt.lines...)
+			if len(findings) != 0 {
+				t.Errorf("findings = %q, want none", findingKeys(findings))
+			}
+			stages := [3]bool{analysis.HasAudioSocket, analysis.HasTranscription, analysis.HasPlayback}
+			if stages != tt.wantStages {
+				t.Errorf("stages = %v, want %v", stages, tt.wantStages)
+			}
+			if !reflect.DeepEqual(analysis.AudioIssues, tt.wantIssues) {
+				t.Errorf("issues = %q, want %q", analysis.AudioIssues, tt.wantIssues)
+			}
+		})
+	}
+}
+
+func TestLatencyAnalyzer(t *testing.T) {
+	turn := func(ms int) string {
+		return fmt.Sprintf(`{"event": "Turn latency", "latency_ms": %d}`, ms)
+	}
+	tests := []struct {
+		name    string
+		lines   []string
+		want    []string
+		wantP95 string
+	}{
+		{"no turns", []string{"call started"}, nil, ""},
+		{"fast", []string{turn(400), turn(600)}, []string{SeverityInfo + ": Turn latency"}, "600"},
+		{"slow", []string{turn(800), turn(2000)}, []string{SeverityWarning + ": Slow agent responses"}, "2000"},
+		{"very slow", []string{turn(3500)}, []string{SeverityCritical + ": Very slow agent responses"}, "3500"},
+		{"saved repeat ignored", []string{turn(500), `{"event": "Turn latency saved to session", "latency_ms": 9000}`},
+			[]string{SeverityInfo + ": Turn latency"}, "500"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, findings := runAnalyzer(&latencyAnalyzer{}, tt.lines...)
+			if got := findingKeys(findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+			if got := analysis.MetricsMap["turn_latency_p95_ms"]; got != tt.wantP95 {
+				t.Errorf("p95 = %q, want %q", got, tt.wantP95)
+			}
+		})
+	}
+}
+
+func TestProvidersAnalyzer(t *testing.T) {
+	tests := []struct {
+		name          string
+		lines         []string
+		want          []string
+		wantProviders string
+	}{
+		{"providers used", []string{`{"event": "session", "provider": "deepgram"}`, `{"event": "session", "provider": "google_live"}`},
+			nil, "deepgram,google_live"},
+		{"credentials rejected", []string{`{"event": "connect failed", "level": "error", "provider": "openai", "status": 401}`},
+			[]string{SeverityCritical + ": Provider rejected credentials (1x)"}, "openai"},
+		{"rate limited twice", []string{"ERROR provider rate limit hit", "ERROR provider quota exceeded"},
+			[]string{SeverityWarning + ": Provider rate limiting or quota exceeded (2x)"}, ""},
+		{"not a provider problem", []string{"ERROR database timeout"}, nil, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analysis, findings := runAnalyzer(newProvidersAnalyzer(), tt.lines...)
+			if got := findingKeys(findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+			if got := analysis.MetricsMap["providers"]; got != tt.wantProviders {
+				t.Errorf("providers = %q, want %q", got, tt.wantProviders)
+			}
+		})
+	}
+}
+
+func TestSignalingAnalyzer(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  []string
+	}{
+		{"clean call", []string{"StasisStart received", "StasisEnd received"}, nil},
+		{"still active", []string{"StasisStart received"},
+			[]string{SeverityInfo + ": No StasisEnd/ChannelDestroyed seen (call still active or logs truncated)"}},
+		{"start failed", []string{"Failed to handle caller StasisStart: boom", "ChannelDestroyed"},
+			[]string{SeverityCritical + ": Engine failed to handle StasisStart"}},
+		{"failure cause", []string{"StasisStart", `ChannelDestroyed "cause": 34`},
+			[]string{SeverityCritical + ": Call ended with failure hangup cause 34"}},
+		{"normal cause", []string{"StasisStart", "ChannelDestroyed cause=16"}, nil},
+		{"ARI errors", []string{"StasisStart", "ARI error: 404", "ARI request error", "StasisEnd"},
+			[]string{SeverityWarning + ": ARI errors (2)"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, findings := runAnalyzer(&signalingAnalyzer{}, tt.lines...)
+			if got := findingKeys(findings); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("findings = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzerRegistry(t *testing.T) {
+	names := strings.Join(AnalyzerNames(), ",")
+	if !strings.HasPrefix(names, "errors,audio,latency,providers,signaling") {
+		t.Errorf("AnalyzerNames() = %s", names)
+	}
+}
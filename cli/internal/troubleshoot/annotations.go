@@ -0,0 +1,118 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+)
+
+// Note is an operator's remark on a call
+type Note = results.Note
+
+// tagPattern keeps tags short words that filter and export cleanly
+var tagPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._-]*$`)
+
+// ValidTag reports whether tag can be attached to a call
+func ValidTag(tag string) bool {
+	return tagPattern.MatchString(tag)
+}
+
+// Annotate adds and removes tags and appends a note to a call, adding
+// the call to the index when it is not there yet (its times are filled
+// in once it is seen in the logs)
+func (idx *CallIndex) Annotate(id string, add, remove []string, note *Note) (*Call, error) {
+	for _, tag := range append(append([]string{}, add...), remove...) {
+		if !ValidTag(tag) {
+			return nil, fmt.Errorf("invalid tag %q (letters, digits, '.', '_' and '-')", tag)
+		}
+	}
+	call, ok := idx.Calls[id]
+	if !ok {
+		call = &Call{ID: id}
+		idx.Calls[id] = call
+	}
+	for _, tag := range add {
+		if !call.HasTag(tag) {
+			call.Tags = append(call.Tags, strings.ToLower(tag))
+		}
+	}
+	if len(remove) > 0 {
+		kept := call.Tags[:0]
+		for _, t := range call.Tags {
+			drop := false
+			for _, tag := range remove {
+				drop = drop || strings.EqualFold(t, tag)
+			}
+			if !drop {
+				kept = append(kept, t)
+			}
+		}
+		call.Tags = kept
+	}
+	sort.Strings(call.Tags)
+	if note != nil {
+		call.Notes = append(call.Notes, *note)
+	}
+	return call, nil
+}
+
+// TagCounts returns how many indexed calls carry each tag
+func (idx *CallIndex) TagCounts() map[string]int {
+	counts := make(map[string]int)
+	for _, call := range idx.Calls {
+		for _, tag := range call.Tags {
+			counts[tag]++
+		}
+	}
+	return counts
+}
+
+// attachAnnotations copies the index's tags and notes onto calls read
+// from the logs
+func (idx *CallIndex) attachAnnotations(calls []Call) {
+	for i := range calls {
+		if indexed, ok := idx.Calls[calls[i].ID]; ok {
+			calls[i].Tags = indexed.Tags
+			calls[i].Notes = indexed.Notes
+		}
+	}
+}
+
+// formatAnnotations is a one-line summary of a call's tags and latest
+// note for listings
+func formatAnnotations(call Call) string {
+	var parts []string
+	if len(call.Tags) > 0 {
+		parts = append(parts, "🏷️  "+strings.Join(call.Tags, ", "))
+	}
+	if n := len(call.Notes); n > 0 {
+		note := fmt.Sprintf("📝 %q", call.Notes[n-1].Text)
+		if n > 1 {
+			note += fmt.Sprintf(" (+%d more)", n-1)
+		}
+		parts = append(parts, note)
+	}
+	return strings.Join(parts, "  ")
+}
+
+// reportAnnotations shows the tags and notes operators left on the
+// analyzed call
+func (r *Runner) reportAnnotations(call *Call) {
+	if call == nil || (len(call.Tags) == 0 && len(call.Notes) == 0) {
+		return
+	}
+	if len(call.Tags) > 0 {
+		infoColor.Printf("🏷️  Tags: %s\n", strings.Join(call.Tags, ", "))
+	}
+	for _, n := range call.Notes {
+		by := ""
+		if n.Author != "" {
+			by = " " + n.Author
+		}
+		fmt.Printf("📝 %s%s: %s\n", formatTimestamp(n.Time, r.loc), by, n.Text)
+	}
+	fmt.Println()
+}
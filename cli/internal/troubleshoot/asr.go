@@ -0,0 +1,255 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+)
+
+const (
+	// asrLowConfidence is the confidence below which a caller turn is
+	// likely misrecognized
+	asrLowConfidence = 0.6
+	// asrChronicAvg / asrChronicShare: a call whose average confidence is
+	// below asrChronicAvg, or with at least asrChronicShare low turns, has
+	// a recognition problem rather than one bad turn
+	asrChronicAvg   = 0.75
+	asrChronicShare = 0.3
+	// asrMinTurns is the fewest scored turns a call needs to be graded
+	asrMinTurns = 3
+)
+
+// asrCorrections open a caller turn that corrects the agent
+var asrCorrections = []string{
+	"no ", "no,", "no.", "nope", "that's not", "that is not", "not what i",
+	"i said", "i didn't say", "i did not say", "i meant", "wrong",
+}
+
+// ASRTurn is one caller turn with the recognizer's confidence
+type ASRTurn = results.ASRTurn
+
+// asrAnalyzer reads STT confidence and alternative hypotheses per caller
+// turn, and flags low-confidence turns the agent acted on wrongly
+type asrAnalyzer struct {
+	turns    []ASRTurn
+	callerN  int
+	pending  int // index into turns of a low-confidence turn awaiting the agent's reaction, or -1
+	provider string
+	model    string
+	language string
+}
+
+func newASRAnalyzer() *asrAnalyzer {
+	return &asrAnalyzer{pending: -1}
+}
+
+func (a *asrAnalyzer) Name() string { return "asr" }
+
+func (a *asrAnalyzer) Observe(ev *LogEvent) {
+	lower := ev.Lower
+	if !strings.Contains(lower, "transcri") && !strings.Contains(lower, "tool") &&
+		!strings.Contains(lower, "llm") && !strings.Contains(lower, "conversation") {
+		return
+	}
+	le := ClassifyLiveLine(ev.Line, time.UTC)
+	if le == nil {
+		return
+	}
+	switch le.Kind {
+	case LiveTool:
+		if a.pending >= 0 && le.Text != "" {
+			a.turns[a.pending].Action = "agent ran " + le.Text
+			a.pending = -1
+		}
+	case LiveCaller:
+		a.observeCaller(ev, le.Text)
+	}
+}
+
+func (a *asrAnalyzer) observeCaller(ev *LogEvent, text string) {
+	a.callerN++
+	if a.pending >= 0 && isCorrection(text) {
+		a.turns[a.pending].Action = "caller corrected: " + truncate(text, 60)
+	}
+	a.pending = -1
+
+	_, fields := liveFields(ev, ev.Line)
+	if v := firstField(fields, "stt_provider", "provider"); v != "" {
+		a.provider = v
+	}
+	if v := firstField(fields, "stt_model", "model"); v != "" {
+		a.model = v
+	}
+	if v := firstField(fields, "language", "language_code", "detected_language"); v != "" {
+		a.language = v
+	}
+	confidence, err := strconv.ParseFloat(firstField(fields, "confidence", "avg_confidence", "transcript_confidence", "stt_confidence"), 64)
+	if err != nil || confidence < 0 {
+		return
+	}
+	if confidence > 1 {
+		confidence /= 100
+	}
+	a.turns = append(a.turns, ASRTurn{
+		Turn:         a.callerN,
+		Text:         text,
+		Confidence:   confidence,
+		Alternatives: asrAlternatives(ev, fields, text),
+	})
+	if confidence < asrLowConfidence {
+		a.pending = len(a.turns) - 1
+	}
+}
+
+// asrAlternatives returns the other hypotheses of a transcript: a JSON
+// list of strings or of objects with a transcript, or a console field
+// separated by "|"
+func asrAlternatives(ev *LogEvent, fields map[string]string, text string) []string {
+	var alts []string
+	add := func(s string) {
+		s = strings.TrimSpace(s)
+		if s != "" && s != text && len(alts) < 3 {
+			alts = append(alts, s)
+		}
+	}
+	switch v := ev.Fields["alternatives"].(type) {
+	case []interface{}:
+		for _, item := range v {
+			switch item := item.(type) {
+			case string:
+				add(item)
+			case map[string]interface{}:
+				for _, key := range []string{"transcript", "text"} {
+					if s, ok := item[key].(string); ok {
+						add(s)
+						break
+					}
+				}
+			}
+		}
+	case nil:
+		for _, s := range strings.Split(fields["alternatives"], "|") {
+			add(s)
+		}
+	}
+	return alts
+}
+
+func isCorrection(text string) bool {
+	lower := strings.ToLower(strings.TrimSpace(text)) + " "
+	for _, c := range asrCorrections {
+		if strings.HasPrefix(lower, c) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *asrAnalyzer) Finish(analysis *Analysis) []Finding {
+	if len(a.turns) == 0 {
+		return nil
+	}
+	analysis.ASRTurns = a.turns
+
+	var sum float64
+	worst := 1.0
+	low := 0
+	var wrong []ASRTurn
+	for _, t := range a.turns {
+		sum += t.Confidence
+		if t.Confidence < worst {
+			worst = t.Confidence
+		}
+		if t.Confidence < asrLowConfidence {
+			low++
+			if t.Action != "" {
+				wrong = append(wrong, t)
+			}
+		}
+	}
+	avg := sum / float64(len(a.turns))
+	analysis.MetricsMap["asr_confidence_avg"] = fmt.Sprintf("%.2f", avg)
+	analysis.MetricsMap["asr_confidence_min"] = fmt.Sprintf("%.2f", worst)
+	analysis.MetricsMap["asr_low_confidence_turns"] = strconv.Itoa(low)
+
+	var findings []Finding
+	if len(wrong) > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Agent acted on low-confidence transcripts (%dx)", len(wrong)),
+			Evidence: formatASRTurn(wrong[0]),
+			Fix:      "Have the agent confirm what it heard before acting when confidence is low",
+		})
+	}
+	summary := fmt.Sprintf("%d turns, avg confidence %.2f, min %.2f, %d below %.1f", len(a.turns), avg, worst, low, asrLowConfidence)
+	if len(a.turns) >= asrMinTurns && (avg < asrChronicAvg || float64(low)/float64(len(a.turns)) >= asrChronicShare) {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  "Low speech recognition confidence across the call",
+			Evidence: summary,
+			Fix:      asrRecommendation(a.provider, a.model, a.language),
+		})
+	} else {
+		findings = append(findings, Finding{Severity: SeverityInfo, Message: "ASR confidence", Evidence: summary})
+	}
+	return findings
+}
+
+// formatASRTurn renders a turn as: turn 3 "text" (0.42) → action; heard as "alt"
+func formatASRTurn(t ASRTurn) string {
+	s := fmt.Sprintf("turn %d %q (%.2f)", t.Turn, truncate(t.Text, 60), t.Confidence)
+	if t.Action != "" {
+		s += " → " + t.Action
+	}
+	if len(t.Alternatives) > 0 {
+		s += fmt.Sprintf("; also heard as %q", t.Alternatives[0])
+	}
+	return s
+}
+
+// asrRecommendation suggests STT changes for chronically low confidence,
+// naming the configured model and language when the logs show them
+func asrRecommendation(provider, model, language string) string {
+	var steps []string
+	switch {
+	case model != "":
+		steps = append(steps, fmt.Sprintf("try a telephony (8 kHz phone call) model instead of %s", model))
+	case provider != "":
+		steps = append(steps, fmt.Sprintf("use a telephony (8 kHz phone call) model of %s", provider))
+	default:
+		steps = append(steps, "use the STT provider's telephony (8 kHz phone call) model")
+	}
+	if language == "" {
+		steps = append(steps, "set the STT language explicitly (e.g. en-US) if it is left to auto-detection")
+	} else {
+		steps = append(steps, fmt.Sprintf("check that language %s matches your callers", language))
+	}
+	steps = append(steps, "add product names, people and terms callers say as keyword boosts (agent stt vocab add)")
+	return strings.Join(steps, "; ")
+}
+
+// displayASRTurns lists the low-confidence caller turns of the call
+func (r *Runner) displayASRTurns(analysis *Analysis) {
+	var low []ASRTurn
+	for _, t := range analysis.ASRTurns {
+		if t.Confidence < asrLowConfidence {
+			low = append(low, t)
+		}
+	}
+	if len(low) == 0 {
+		return
+	}
+	fmt.Printf("Low-Confidence Turns (%d of %d):\n", len(low), len(analysis.ASRTurns))
+	for _, t := range low {
+		line := "  • " + formatASRTurn(t)
+		if t.Action != "" {
+			warningColor.Println(line)
+		} else {
+			fmt.Println(line)
+		}
+	}
+	fmt.Println()
+}
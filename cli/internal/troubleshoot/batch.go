@@ -0,0 +1,205 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+)
+
+// batchCallLimit caps how many calls a single --all run analyzes
+const batchCallLimit = 200
+
+// batchResult is the per-call outcome of a batch run
+type batchResult struct {
+	Call        Call
+	Errors      int
+	AudioIssues int
+	Score       float64
+	// Incomplete names the analyzers that did not complete
+	Incomplete []string
+	Err        error
+}
+
+// analyzeAll runs the log and metrics analysis for every call in the window
+// that matches the filters. LLM diagnosis is skipped in batch mode. The
+// call list and each call's result are checkpointed as the run goes, so
+// an interrupted run continues with --resume.
+func (r *Runner) analyzeAll() error {
+	cp, err := loadCheckpoint()
+	if err != nil {
+		warningColor.Printf("⚠️  Could not read the batch checkpoint: %v\n", err)
+	}
+	saving := true
+	if r.resume {
+		if cp == nil {
+			return fmt.Errorf("no batch run to resume (start one with --all)")
+		}
+		started := cp.Plan.Started.In(r.loc).Format("2006-01-02 15:04")
+		if cp.Plan.Finished {
+			infoColor.Printf("The batch run of %s finished; nothing to resume\n", started)
+			fmt.Println()
+			r.displayBatchResults(cp.ordered())
+			return nil
+		}
+		infoColor.Printf("Resuming the batch run of %s: %d of %d call(s) done\n", started, len(cp.Results), len(cp.Plan.Calls))
+	} else {
+		if cp != nil && !cp.Plan.Finished {
+			warningColor.Printf("⚠️  Replacing the interrupted batch run of %s (%d of %d call(s) done; --resume continues it)\n",
+				cp.Plan.Started.In(r.loc).Format("2006-01-02 15:04"), len(cp.Results), len(cp.Plan.Calls))
+		}
+		calls, err := r.getRecentCalls(batchCallLimit)
+		if err != nil {
+			return fmt.Errorf("failed to get recent calls: %w", r.wrapCtxErr(err))
+		}
+		if len(calls) == 0 {
+			warningColor.Println("No calls match the given window/filters")
+			return nil
+		}
+		cp = &batchCheckpoint{Plan: batchPlan{Started: time.Now(), Since: r.since, Until: r.until, Calls: calls}}
+		if err := startCheckpoint(cp.Plan); err != nil {
+			warningColor.Printf("⚠️  Could not save the batch checkpoint (this run cannot be resumed): %v\n", err)
+			saving = false
+		}
+	}
+
+	// Per-call windows come from the index populated by the listing above,
+	// so each call reads only its own slice of the logs.
+	r.since, r.until = "", ""
+
+	pending := cp.pending()
+	infoColor.Printf("Analyzing %d call(s)...\n", len(pending))
+	fmt.Println()
+
+	for i, call := range pending {
+		if r.ctx.Err() != nil {
+			break
+		}
+		if r.verbose {
+			fmt.Printf("[DEBUG] [%d/%d] %s\n", i+1, len(pending), call.ID)
+		}
+		// A call that breaks the analysis is reported; the batch goes on
+		var result batchResult
+		if err := safely(func() { result = r.analyzeOne(call) }); err != nil {
+			result = batchResult{Call: call, Err: fmt.Errorf("analysis failed: %v", err)}
+		}
+		if result.Err != nil && r.ctx.Err() != nil {
+			// Cut off by Ctrl-C or --timeout: left for --resume
+			continue
+		}
+		cp.Results = append(cp.Results, result)
+		if saving {
+			if err := appendResult(result); err != nil {
+				warningColor.Printf("⚠️  Could not save the batch checkpoint (this run cannot be resumed): %v\n", err)
+				saving = false
+			}
+		}
+	}
+
+	if r.ctx.Err() != nil && len(cp.pending()) > 0 {
+		warningColor.Printf("⚠️  Stopped after %d of %d calls: %v\n", len(cp.Results), len(cp.Plan.Calls), r.wrapCtxErr(r.ctx.Err()))
+		if saving {
+			fmt.Println("   Continue with: agent troubleshoot --resume")
+		}
+		fmt.Println()
+	} else if saving {
+		cp.Plan.Finished = true
+		if err := savePlan(cp.Plan); err != nil && r.verbose {
+			fmt.Printf("[DEBUG] Could not mark the batch checkpoint finished: %v\n", err)
+		}
+	}
+	r.displayBatchResults(cp.ordered())
+	return nil
+}
+
+// analyzeOne collects and scores a single call without printing findings
+func (r *Runner) analyzeOne(call Call) batchResult {
+	result := batchResult{Call: call}
+
+	r.callID = call.ID
+	logData, err := r.collectCallData()
+	if err != nil {
+		result.Err = fmt.Errorf("collection failed: %w", err)
+		return result
+	}
+
+	analysis := r.analyzeLogs(logData)
+	r.exportTrace(logData)
+	analysis.step("metrics", func() error {
+		analysis.Metrics = ExtractMetrics(logData)
+		return nil
+	})
+	report := NewReport(analysis, nil)
+	report.Tags = call.Tags
+	report.Notes = call.Notes
+	report.Tenant = call.Tenant
+	if _, err := r.saveRun(logData, analysis, report); err != nil && r.verbose {
+		fmt.Printf("[DEBUG] Could not save run history for %s: %v\n", call.ID, err)
+	}
+	r.pushMetrics(report, &call)
+	r.notifyReport(report, &call)
+	r.fileTicket(report, &call, logData)
+
+	result.Errors = report.Errors
+	result.AudioIssues = len(report.AudioIssues)
+	result.Score = report.Score
+	for _, in := range analysis.Incomplete {
+		result.Incomplete = append(result.Incomplete, in.Analyzer)
+	}
+	return result
+}
+
+// displayBatchResults prints the batch table and a per-status summary
+func (r *Runner) displayBatchResults(results []batchResult) {
+	fmt.Println("═══════════════════════════════════════════")
+	fmt.Println("📊 BATCH RESULTS")
+	fmt.Println("═══════════════════════════════════════════")
+	fmt.Println()
+
+	fmt.Printf("  %-20s %-12s %6s %6s %6s\n", "CALL ID", "STATUS", "ERRORS", "AUDIO", "SCORE")
+	byStatus := make(map[string]int)
+	for _, res := range results {
+		status := res.Call.Status
+		if status == "" {
+			status = "unknown"
+		}
+		byStatus[status]++
+
+		if res.Err != nil {
+			errorColor.Printf("  %-20s %-12s %v\n", res.Call.ID, status, res.Err)
+			continue
+		}
+		fmt.Printf("  %-20s ", res.Call.ID)
+		statusColor(status).Printf("%-12s", status)
+		fmt.Printf(" %6d %6d %6.0f", res.Errors, res.AudioIssues, res.Score)
+		if len(res.Incomplete) > 0 {
+			warningColor.Printf("  ⚠️  incomplete: %s", strings.Join(res.Incomplete, ", "))
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+
+	fmt.Print("Summary:")
+	for _, status := range append(CallStatuses, "unknown") {
+		if n := byStatus[status]; n > 0 {
+			fmt.Printf("  %s: %d", status, n)
+		}
+	}
+	fmt.Println()
+	fmt.Println()
+	fmt.Println("Details: agent troubleshoot --call <id>, or the saved runs: agent troubleshoot history")
+}
+
+// statusColor picks the display color for a call status
+func statusColor(status string) *color.Color {
+	switch status {
+	case CallCompleted:
+		return successColor
+	case CallFailed:
+		return errorColor
+	case CallAbandoned, CallTransferred:
+		return warningColor
+	}
+	return infoColor
+}
@@ -0,0 +1,55 @@
+package troubleshoot
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"time"
+)
+
+// Bundle packs the sanitized report and call logs into a tar.gz for
+// attaching to tickets or sending to support
+func Bundle(report *Report, logData string) ([]byte, error) {
+	reportJSON, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+	if reportJSON, err = SanitizeJSON(reportJSON, "  "); err != nil {
+		return nil, err
+	}
+	return packBundle(report.CallID, []bundleFile{
+		{"report.json", string(reportJSON)},
+		{"logs.txt", Sanitize(logData)},
+	})
+}
+
+// bundleFile is one file of a bundle
+type bundleFile struct {
+	name string
+	data string
+}
+
+// packBundle writes files under dir/ into a tar.gz
+func packBundle(dir string, files []bundleFile) ([]byte, error) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	now := time.Now()
+	for _, f := range files {
+		hdr := &tar.Header{Name: dir + "/" + f.name, Mode: 0644, Size: int64(len(f.data)), ModTime: now}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write([]byte(f.data)); err != nil {
+			return nil, err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,169 @@
+package troubleshoot
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/logstore"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// callCacheOpenTTL is how long data collected for an open-ended window
+// (call still running or its end unknown) is reused
+const callCacheOpenTTL = 5 * time.Minute
+
+// CachedCall is the data collected for one call: its log lines and the
+// format alignment read from the live engine config. Re-running
+// troubleshoot on the same call and window reuses it instead of reading
+// docker logs again. The log lines are kept in the log store; the cache
+// file refers to them by LogsRef.
+type CachedCall struct {
+	CallID      string    `json:"call_id"`
+	Source      string    `json:"source"`
+	Containers  []string  `json:"containers"`
+	Since       string    `json:"since,omitempty"`
+	Until       string    `json:"until,omitempty"`
+	CollectedAt time.Time `json:"collected_at"`
+	Logs        string    `json:"logs,omitempty"`
+	LogsRef     string    `json:"logs_ref,omitempty"`
+
+	FormatAlignment *FormatAlignment `json:"format_alignment,omitempty"`
+}
+
+// CallCacheDir returns the directory holding one file per cached call
+func CallCacheDir() string {
+	return filepath.Join(settings.Dir(), "cache", "calls")
+}
+
+// callCacheKey identifies a call's data by call ID, log source, engine
+// containers and collection window
+func (r *Runner) callCacheKey(since, until string) string {
+	parts := []string{r.callID, r.sourceName(), strings.Join(r.containers(), ","), since, until}
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return fmt.Sprintf("%x", sum[:12])
+}
+
+func (r *Runner) sourceName() string {
+	if r.source == nil {
+		return "docker"
+	}
+	return r.source.Name()
+}
+
+// containers lists the engine containers whose logs are read
+func (r *Runner) containers() []string {
+	out := []string{r.container}
+	for _, c := range r.instances {
+		if c != r.container {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// loadCachedCall returns the cached data for the window, or nil when
+// there is none, it is stale or --no-cache is set
+func (r *Runner) loadCachedCall(since, until string) *CachedCall {
+	if r.noCache {
+		return nil
+	}
+	data, err := os.ReadFile(filepath.Join(CallCacheDir(), r.callCacheKey(since, until)+".json"))
+	if err != nil {
+		return nil
+	}
+	var entry CachedCall
+	if err := json.Unmarshal(data, &entry); err != nil || entry.CallID != r.callID {
+		return nil
+	}
+	if until == "" && time.Since(entry.CollectedAt) > callCacheOpenTTL {
+		return nil
+	}
+	if entry.LogsRef != "" {
+		logs, err := logstore.Get(entry.LogsRef)
+		if err != nil {
+			if r.verbose {
+				fmt.Printf("[DEBUG] Cached call data unreadable: %v\n", err)
+			}
+			return nil
+		}
+		entry.Logs = logs
+	}
+	return &entry
+}
+
+// saveCachedCall writes the current call's data and drops entries older
+// than the index retention
+func (r *Runner) saveCachedCall() {
+	entry := r.cached
+	if entry == nil {
+		return
+	}
+	dir := CallCacheDir()
+	stored := *entry
+	stored.Logs = ""
+	ref, err := logstore.Put(entry.Logs)
+	if err == nil {
+		stored.LogsRef = ref
+		err = os.MkdirAll(dir, 0755)
+	}
+	if err == nil {
+		var data []byte
+		if data, err = json.Marshal(stored); err == nil {
+			err = os.WriteFile(filepath.Join(dir, r.callCacheKey(entry.Since, entry.Until)+".json"), data, 0644)
+		}
+	}
+	if err != nil {
+		if r.verbose {
+			fmt.Printf("[DEBUG] Failed to cache call data: %v\n", err)
+		}
+		return
+	}
+
+	cutoff := time.Now().Add(-r.retention)
+	files, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	pruned := false
+	for _, f := range files {
+		if info, err := os.Stat(f); err == nil && info.ModTime().Before(cutoff) {
+			if os.Remove(f) == nil {
+				pruned = true
+			}
+		}
+	}
+	if pruned {
+		if _, _, err := CollectLogStore(); err != nil && r.verbose {
+			fmt.Printf("[DEBUG] Failed to clean up the log store: %v\n", err)
+		}
+	}
+}
+
+// LiveLogRefs returns the log store references of the saved runs and
+// cached calls
+func LiveLogRefs() map[string]bool {
+	live := make(map[string]bool)
+	if entries, err := os.ReadDir(RunsDir()); err == nil {
+		for _, e := range entries {
+			if ref := runLogRef(e.Name()); ref != "" {
+				live[ref] = true
+			}
+		}
+	}
+	files, _ := filepath.Glob(filepath.Join(CallCacheDir(), "*.json"))
+	for _, f := range files {
+		var entry CachedCall
+		if data, err := os.ReadFile(f); err == nil && json.Unmarshal(data, &entry) == nil && entry.LogsRef != "" {
+			live[entry.LogsRef] = true
+		}
+	}
+	return live
+}
+
+// CollectLogStore deletes the stored logs no saved run or cached call
+// refers to any more, and returns how many and the bytes freed
+func CollectLogStore() (int, int64, error) {
+	return logstore.Collect(LiveLogRefs(), logstore.DefaultGrace)
+}
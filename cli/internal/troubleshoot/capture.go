@@ -0,0 +1,83 @@
+package troubleshoot
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+)
+
+// Capture holds the engine logs of a debug logging window
+type Capture struct {
+	Level      string    `json:"level"`
+	Start      time.Time `json:"start"`
+	End        time.Time `json:"end"`
+	Containers []string  `json:"containers"`
+	// Calls are the calls seen in the window
+	Calls []Call `json:"calls"`
+	// Lines counts the log lines per container
+	Lines map[string]int `json:"lines"`
+
+	logs map[string]string
+}
+
+// CollectCapture reads the containers' logs between start and end and
+// finds the calls in them. Zone-less log timestamps are read in logLoc.
+func CollectCapture(ctx context.Context, containers []string, level string, start, end time.Time, logLoc *time.Location) (*Capture, error) {
+	client, err := docker.Default()
+	if err != nil {
+		return nil, err
+	}
+	c := &Capture{
+		Level:      level,
+		Start:      start,
+		End:        end,
+		Containers: containers,
+		Lines:      make(map[string]int),
+		logs:       make(map[string]string),
+	}
+	tracker := newCallTracker(logLoc, false)
+	for _, container := range containers {
+		data, err := client.LogsBytes(ctx, container, docker.LogsOptions{Since: start, Until: end})
+		if err != nil {
+			return nil, fmt.Errorf("logs %s: %w", container, err)
+		}
+		logData := string(data)
+		c.logs[container] = logData
+		for _, line := range strings.Split(logData, "\n") {
+			if line = ansiStripPattern.ReplaceAllString(line, ""); line != "" {
+				tracker.observe(line)
+				c.Lines[container]++
+			}
+		}
+	}
+	c.Calls = tracker.result()
+	sort.Slice(c.Calls, func(i, j int) bool { return c.Calls[i].Timestamp.Before(c.Calls[j].Timestamp) })
+	return c, nil
+}
+
+// Name is the capture's bundle directory, e.g. debug-20261017-101500
+func (c *Capture) Name() string {
+	return "debug-" + c.Start.Format("20060102-150405")
+}
+
+// Bundle packs the sanitized logs and a capture.json summary into a
+// tar.gz
+func (c *Capture) Bundle() ([]byte, error) {
+	summary, err := json.Marshal(c)
+	if err != nil {
+		return nil, err
+	}
+	if summary, err = SanitizeJSON(summary, "  "); err != nil {
+		return nil, err
+	}
+	files := []bundleFile{{"capture.json", string(summary)}}
+	for _, container := range c.Containers {
+		files = append(files, bundleFile{container + ".log", Sanitize(c.logs[container])})
+	}
+	return packBundle(c.Name(), files)
+}
@@ -0,0 +1,373 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Chart layout, in SVG pixels. PNGs are rendered at chartPNGScale.
+const (
+	chartWidth      = 900
+	chartLabelWidth = 110
+	chartRightPad   = 80
+	chartRowHeight  = 20
+	chartBarHeight  = 12
+	chartCharWidth  = 6
+	chartPNGScale   = 2
+)
+
+// Chart colors
+const (
+	chartText     = "#333333"
+	chartGrid     = "#e0e0e0"
+	chartSetup    = "#4c78a8"
+	chartPlayback = "#54a24b"
+	chartTurn     = "#f58518"
+	chartError    = "#e45756"
+	chartTeardown = "#9d9d9d"
+)
+
+// chartShape is a rectangle, a line (X,Y to W,H) or a text whose Y is
+// the baseline
+type chartShape struct {
+	kind   string
+	x, y   float64
+	w, h   float64
+	color  string
+	text   string
+	anchor string
+}
+
+// chartCanvas collects the shapes of a chart for the SVG and PNG writers
+type chartCanvas struct {
+	width, height int
+	shapes        []chartShape
+}
+
+func (c *chartCanvas) rect(x, y, w, h float64, color string) {
+	c.shapes = append(c.shapes, chartShape{kind: "rect", x: x, y: y, w: w, h: h, color: color})
+}
+
+func (c *chartCanvas) line(x1, y1, x2, y2 float64, color string) {
+	c.shapes = append(c.shapes, chartShape{kind: "line", x: x1, y: y1, w: x2, h: y2, color: color})
+}
+
+func (c *chartCanvas) text(x, y float64, s, anchor string) {
+	c.shapes = append(c.shapes, chartShape{kind: "text", x: x, y: y, text: s, color: chartText, anchor: anchor})
+}
+
+// checkChartPath rejects chart files other than .svg and .png
+func checkChartPath(path string) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg", ".png":
+		return nil
+	}
+	return fmt.Errorf("--chart: unsupported file type %q (use .svg or .png)", filepath.Ext(path))
+}
+
+// WriteChart renders the call's stage timeline and turn latency bars to
+// path, as SVG or PNG by its extension
+func WriteChart(tl *Timeline, path string, loc *time.Location) error {
+	if err := checkChartPath(path); err != nil {
+		return err
+	}
+	if len(tl.Stages) == 0 {
+		return fmt.Errorf("no timed stages in the logs of call %s", tl.CallID)
+	}
+	c := layoutChart(tl, loc)
+	var data []byte
+	if strings.ToLower(filepath.Ext(path)) == ".png" {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, c.png()); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	} else {
+		data = c.svg()
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// writeChart writes the --chart file for the current call
+func (r *Runner) writeChart(logData string) error {
+	return WriteChart(BuildTimeline(r.callID, logData, r.logLoc), r.chart, r.loc)
+}
+
+// layoutChart places the title, the stage rows on a time axis and one
+// latency bar per turn with the warning and critical thresholds
+func layoutChart(tl *Timeline, loc *time.Location) *chartCanvas {
+	c := &chartCanvas{width: chartWidth}
+	plotX := float64(chartLabelWidth)
+	plotW := float64(chartWidth - chartLabelWidth - chartRightPad)
+
+	c.text(10, 18, fmt.Sprintf("Call %s - %s - %s", tl.CallID, formatTimestamp(tl.Start, loc), chartDuration(tl.Duration())), "start")
+
+	// Stages on a shared time axis
+	total := tl.Duration()
+	if total <= 0 {
+		total = time.Second
+	}
+	at := func(t time.Time) float64 {
+		return plotX + plotW*float64(t.Sub(tl.Start))/float64(total)
+	}
+	y := 44.0
+	c.text(10, y, "Stages", "start")
+	rowsTop := y + 8
+	rowsBottom := rowsTop + float64(len(tl.Stages)*chartRowHeight)
+	step := chartStep(total.Seconds())
+	for s := 0.0; s <= total.Seconds()+1e-9; s += step {
+		x := plotX + plotW*s/total.Seconds()
+		c.line(x, rowsTop, x, rowsBottom, chartGrid)
+		c.text(x, rowsBottom+12, chartDuration(time.Duration(s*float64(time.Second))), "middle")
+	}
+	for i, st := range tl.Stages {
+		rowY := rowsTop + float64(i*chartRowHeight) + float64(chartRowHeight-chartBarHeight)/2
+		x := at(st.Start)
+		w := math.Max(at(st.End)-x, 1)
+		c.rect(x, rowY, w, chartBarHeight, stageColor(st))
+		c.text(10, rowY+chartBarHeight-2, st.Name, "start")
+		c.text(x+w+4, rowY+chartBarHeight-2, chartDuration(st.End.Sub(st.Start)), "start")
+	}
+	y = rowsBottom + 24
+
+	// Turn latency bars against the thresholds
+	var turns []Stage
+	maxMs := latencyCriticalMs
+	for _, st := range tl.Stages {
+		if ms, err := strconv.ParseFloat(st.Attributes["turn.latency_ms"], 64); err == nil {
+			turns = append(turns, st)
+			maxMs = math.Max(maxMs, ms)
+		}
+	}
+	if len(turns) > 0 {
+		scale := plotW / (maxMs * 1.1)
+		y += 20
+		c.text(10, y, "Turn latency", "start")
+		top := y + 8
+		bottom := top + float64(len(turns)*chartRowHeight)
+		for _, ms := range []float64{latencyWarnMs, latencyCriticalMs} {
+			x := plotX + ms*scale
+			c.line(x, top, x, bottom, chartError)
+			c.text(x, bottom+12, fmt.Sprintf("%.0fms", ms), "middle")
+		}
+		for i, st := range turns {
+			ms, _ := strconv.ParseFloat(st.Attributes["turn.latency_ms"], 64)
+			rowY := top + float64(i*chartRowHeight) + float64(chartRowHeight-chartBarHeight)/2
+			w := math.Max(ms*scale, 1)
+			c.rect(plotX, rowY, w, chartBarHeight, latencyColor(ms))
+			c.text(10, rowY+chartBarHeight-2, st.Name, "start")
+			c.text(plotX+w+4, rowY+chartBarHeight-2, fmt.Sprintf("%.0fms", ms), "start")
+		}
+		y = bottom + 24
+	}
+
+	// Legend
+	y += 8
+	x := 10.0
+	for _, item := range []struct{ name, color string }{
+		{"setup", chartSetup}, {"playback", chartPlayback}, {"turn", chartTurn},
+		{"teardown", chartTeardown}, {"slow/error", chartError},
+	} {
+		c.rect(x, y-9, 10, 10, item.color)
+		c.text(x+14, y, item.name, "start")
+		x += 14 + float64(len(item.name)*chartCharWidth) + 20
+	}
+	c.height = int(y) + 12
+	return c
+}
+
+func stageColor(st Stage) string {
+	switch {
+	case st.Error:
+		return chartError
+	case st.Name == "setup":
+		return chartSetup
+	case st.Name == "teardown":
+		return chartTeardown
+	case strings.HasPrefix(st.Name, "playback"):
+		return chartPlayback
+	}
+	return chartTurn
+}
+
+func latencyColor(ms float64) string {
+	switch {
+	case ms >= latencyCriticalMs:
+		return chartError
+	case ms >= latencyWarnMs:
+		return chartTurn
+	}
+	return chartPlayback
+}
+
+// chartStep picks a 1/2/5 step giving at most 10 ticks over total seconds
+func chartStep(total float64) float64 {
+	if total <= 0 {
+		return 1
+	}
+	step := math.Pow(10, math.Floor(math.Log10(total/10)))
+	for _, m := range []float64{1, 2, 5, 10} {
+		if total/(step*m) <= 10 {
+			return step * m
+		}
+	}
+	return step * 10
+}
+
+func chartDuration(d time.Duration) string {
+	if d < time.Second {
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	}
+	return fmt.Sprintf("%.1fs", d.Seconds())
+}
+
+// svg renders the canvas as an SVG document
+func (c *chartCanvas) svg() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`+"\n", c.width, c.height, c.width, c.height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	for _, s := range c.shapes {
+		switch s.kind {
+		case "rect":
+			fmt.Fprintf(&b, `<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`+"\n", s.x, s.y, s.w, s.h, s.color)
+		case "line":
+			fmt.Fprintf(&b, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", s.x, s.y, s.w, s.h, s.color)
+		case "text":
+			fmt.Fprintf(&b, `<text x="%.1f" y="%.1f" font-family="monospace" font-size="10" fill="%s" text-anchor="%s">%s</text>`+"\n",
+				s.x, s.y, s.color, s.anchor, html.EscapeString(s.text))
+		}
+	}
+	b.WriteString("</svg>\n")
+	return b.Bytes()
+}
+
+// png rasterizes the canvas. Text uses a built-in 5x7 font, so lower case
+// is drawn as upper case.
+func (c *chartCanvas) png() image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, c.width*chartPNGScale, c.height*chartPNGScale))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	px := func(v float64) int { return int(math.Round(v * chartPNGScale)) }
+	fill := func(x0, y0, x1, y1 int, col color.Color) {
+		draw.Draw(img, image.Rect(x0, y0, x1, y1), image.NewUniform(col), image.Point{}, draw.Src)
+	}
+
+	for _, s := range c.shapes {
+		col := parseHexColor(s.color)
+		switch s.kind {
+		case "rect":
+			fill(px(s.x), px(s.y), px(s.x+s.w), px(s.y+s.h), col)
+		case "line":
+			// Axis-aligned lines only
+			x0, y0, x1, y1 := px(s.x), px(s.y), px(s.w), px(s.h)
+			fill(x0, y0, x1+1, y1+1, col)
+		case "text":
+			x := s.x
+			width := float64(len([]rune(s.text)) * chartCharWidth)
+			switch s.anchor {
+			case "end":
+				x -= width
+			case "middle":
+				x -= width / 2
+			}
+			i := 0
+			for _, r := range strings.ToUpper(s.text) {
+				glyph, ok := chartFont[r]
+				if !ok {
+					glyph = chartFont['?']
+				}
+				gx := px(x) + i*chartCharWidth*chartPNGScale
+				i++
+				gy := px(s.y) - 7*chartPNGScale
+				for row, bits := range glyph {
+					for col5 := 0; col5 < 5; col5++ {
+						if bits&(0x10>>uint(col5)) != 0 {
+							fx, fy := gx+col5*chartPNGScale, gy+row*chartPNGScale
+							fill(fx, fy, fx+chartPNGScale, fy+chartPNGScale, col)
+						}
+					}
+				}
+			}
+		}
+	}
+	return img
+}
+
+func parseHexColor(s string) color.Color {
+	v, err := strconv.ParseUint(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil {
+		return color.Black
+	}
+	return color.RGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 0xff}
+}
+
+// chartFont is a 5x7 bitmap font: one byte per row, bit 4 leftmost
+var chartFont = map[rune][7]byte{
+	' ':  {},
+	'0':  {0x0E, 0x11, 0x13, 0x15, 0x19, 0x11, 0x0E},
+	'1':  {0x04, 0x0C, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'2':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x08, 0x1F},
+	'3':  {0x1F, 0x02, 0x04, 0x02, 0x01, 0x11, 0x0E},
+	'4':  {0x02, 0x06, 0x0A, 0x12, 0x1F, 0x02, 0x02},
+	'5':  {0x1F, 0x10, 0x1E, 0x01, 0x01, 0x11, 0x0E},
+	'6':  {0x06, 0x08, 0x10, 0x1E, 0x11, 0x11, 0x0E},
+	'7':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x08, 0x08},
+	'8':  {0x0E, 0x11, 0x11, 0x0E, 0x11, 0x11, 0x0E},
+	'9':  {0x0E, 0x11, 0x11, 0x0F, 0x01, 0x02, 0x0C},
+	'A':  {0x0E, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'B':  {0x1E, 0x11, 0x11, 0x1E, 0x11, 0x11, 0x1E},
+	'C':  {0x0E, 0x11, 0x10, 0x10, 0x10, 0x11, 0x0E},
+	'D':  {0x1C, 0x12, 0x11, 0x11, 0x11, 0x12, 0x1C},
+	'E':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x1F},
+	'F':  {0x1F, 0x10, 0x10, 0x1E, 0x10, 0x10, 0x10},
+	'G':  {0x0E, 0x11, 0x10, 0x17, 0x11, 0x11, 0x0F},
+	'H':  {0x11, 0x11, 0x11, 0x1F, 0x11, 0x11, 0x11},
+	'I':  {0x0E, 0x04, 0x04, 0x04, 0x04, 0x04, 0x0E},
+	'J':  {0x07, 0x02, 0x02, 0x02, 0x02, 0x12, 0x0C},
+	'K':  {0x11, 0x12, 0x14, 0x18, 0x14, 0x12, 0x11},
+	'L':  {0x10, 0x10, 0x10, 0x10, 0x10, 0x10, 0x1F},
+	'M':  {0x11, 0x1B, 0x15, 0x15, 0x11, 0x11, 0x11},
+	'N':  {0x11, 0x11, 0x19, 0x15, 0x13, 0x11, 0x11},
+	'O':  {0x0E, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'P':  {0x1E, 0x11, 0x11, 0x1E, 0x10, 0x10, 0x10},
+	'Q':  {0x0E, 0x11, 0x11, 0x11, 0x15, 0x12, 0x0D},
+	'R':  {0x1E, 0x11, 0x11, 0x1E, 0x14, 0x12, 0x11},
+	'S':  {0x0F, 0x10, 0x10, 0x0E, 0x01, 0x01, 0x1E},
+	'T':  {0x1F, 0x04, 0x04, 0x04, 0x04, 0x04, 0x04},
+	'U':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x11, 0x0E},
+	'V':  {0x11, 0x11, 0x11, 0x11, 0x11, 0x0A, 0x04},
+	'W':  {0x11, 0x11, 0x11, 0x15, 0x15, 0x15, 0x0A},
+	'X':  {0x11, 0x11, 0x0A, 0x04, 0x0A, 0x11, 0x11},
+	'Y':  {0x11, 0x11, 0x11, 0x0A, 0x04, 0x04, 0x04},
+	'Z':  {0x1F, 0x01, 0x02, 0x04, 0x08, 0x10, 0x1F},
+	'.':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x0C, 0x0C},
+	',':  {0x00, 0x00, 0x00, 0x00, 0x0C, 0x04, 0x08},
+	':':  {0x00, 0x0C, 0x0C, 0x00, 0x0C, 0x0C, 0x00},
+	'-':  {0x00, 0x00, 0x00, 0x1F, 0x00, 0x00, 0x00},
+	'_':  {0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x1F},
+	'/':  {0x00, 0x01, 0x02, 0x04, 0x08, 0x10, 0x00},
+	'(':  {0x02, 0x04, 0x08, 0x08, 0x08, 0x04, 0x02},
+	')':  {0x08, 0x04, 0x02, 0x02, 0x02, 0x04, 0x08},
+	'%':  {0x18, 0x19, 0x02, 0x04, 0x08, 0x13, 0x03},
+	'+':  {0x00, 0x04, 0x04, 0x1F, 0x04, 0x04, 0x00},
+	'=':  {0x00, 0x00, 0x1F, 0x00, 0x1F, 0x00, 0x00},
+	'<':  {0x02, 0x04, 0x08, 0x10, 0x08, 0x04, 0x02},
+	'>':  {0x08, 0x04, 0x02, 0x01, 0x02, 0x04, 0x08},
+	'#':  {0x0A, 0x0A, 0x1F, 0x0A, 0x1F, 0x0A, 0x0A},
+	'\'': {0x0C, 0x04, 0x08, 0x00, 0x00, 0x00, 0x00},
+	'[':  {0x0E, 0x08, 0x08, 0x08, 0x08, 0x08, 0x0E},
+	']':  {0x0E, 0x02, 0x02, 0x02, 0x02, 0x02, 0x0E},
+	'?':  {0x0E, 0x11, 0x01, 0x02, 0x04, 0x00, 0x04},
+	'!':  {0x04, 0x04, 0x04, 0x04, 0x04, 0x00, 0x04},
+	'*':  {0x00, 0x04, 0x15, 0x0E, 0x15, 0x04, 0x00},
+}
@@ -0,0 +1,188 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// batchPlan is the call list of a batch run, fixed when the run starts
+// so that a resumed run works through the same calls
+type batchPlan struct {
+	Started  time.Time `json:"started"`
+	Since    string    `json:"since,omitempty"`
+	Until    string    `json:"until,omitempty"`
+	Calls    []Call    `json:"calls"`
+	Finished bool      `json:"finished,omitempty"`
+}
+
+// batchRecord is a batch result as stored in the checkpoint
+type batchRecord struct {
+	Call        Call     `json:"call"`
+	Errors      int      `json:"errors"`
+	AudioIssues int      `json:"audio_issues"`
+	Score       float64  `json:"score"`
+	Incomplete  []string `json:"incomplete,omitempty"`
+	Err         string   `json:"error,omitempty"`
+}
+
+// batchCheckpoint is the progress of the last batch run: its plan and
+// one result line appended per analyzed call, so an interrupted or
+// crashed run loses at most the call in progress
+type batchCheckpoint struct {
+	Plan    batchPlan
+	Results []batchResult
+}
+
+// BatchDir holds the checkpoint of the last batch run
+func BatchDir() string {
+	return filepath.Join(settings.Dir(), "batch")
+}
+
+func batchPlanPath() string {
+	return filepath.Join(BatchDir(), "plan.json")
+}
+
+func batchResultsPath() string {
+	return filepath.Join(BatchDir(), "results.jsonl")
+}
+
+// startCheckpoint replaces the last checkpoint with a new plan
+func startCheckpoint(plan batchPlan) error {
+	if err := os.MkdirAll(BatchDir(), 0755); err != nil {
+		return err
+	}
+	if err := os.Remove(batchResultsPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return savePlan(plan)
+}
+
+// savePlan writes the plan through a temporary file, so a crash never
+// leaves it half-written
+func savePlan(plan batchPlan) error {
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp := batchPlanPath() + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, batchPlanPath())
+}
+
+// loadCheckpoint reads the last checkpoint; nil when there is none
+func loadCheckpoint() (*batchCheckpoint, error) {
+	data, err := os.ReadFile(batchPlanPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	cp := &batchCheckpoint{}
+	if err := json.Unmarshal(data, &cp.Plan); err != nil {
+		return nil, fmt.Errorf("%s: %w", batchPlanPath(), err)
+	}
+
+	f, err := os.Open(batchResultsPath())
+	if os.IsNotExist(err) {
+		return cp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		// A line cut off by a crash is skipped; its call runs again
+		var rec batchRecord
+		if json.Unmarshal(scanner.Bytes(), &rec) != nil {
+			continue
+		}
+		cp.Results = append(cp.Results, rec.result())
+	}
+	return cp, scanner.Err()
+}
+
+// appendResult stores one call's result in the checkpoint
+func appendResult(res batchResult) error {
+	data, err := json.Marshal(newBatchRecord(res))
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(batchResultsPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func newBatchRecord(res batchResult) batchRecord {
+	rec := batchRecord{
+		Call:        res.Call,
+		Errors:      res.Errors,
+		AudioIssues: res.AudioIssues,
+		Score:       res.Score,
+		Incomplete:  res.Incomplete,
+	}
+	if res.Err != nil {
+		rec.Err = res.Err.Error()
+	}
+	return rec
+}
+
+func (rec batchRecord) result() batchResult {
+	res := batchResult{
+		Call:        rec.Call,
+		Errors:      rec.Errors,
+		AudioIssues: rec.AudioIssues,
+		Score:       rec.Score,
+		Incomplete:  rec.Incomplete,
+	}
+	if rec.Err != "" {
+		res.Err = fmt.Errorf("%s", rec.Err)
+	}
+	return res
+}
+
+// pending returns the planned calls without a stored result
+func (cp *batchCheckpoint) pending() []Call {
+	done := make(map[string]bool, len(cp.Results))
+	for _, res := range cp.Results {
+		done[res.Call.ID] = true
+	}
+	var calls []Call
+	for _, call := range cp.Plan.Calls {
+		if !done[call.ID] {
+			calls = append(calls, call)
+		}
+	}
+	return calls
+}
+
+// ordered returns the stored results in plan order
+func (cp *batchCheckpoint) ordered() []batchResult {
+	byID := make(map[string]batchResult, len(cp.Results))
+	for _, res := range cp.Results {
+		byID[res.Call.ID] = res
+	}
+	results := make([]batchResult, 0, len(cp.Results))
+	for _, call := range cp.Plan.Calls {
+		if res, ok := byID[call.ID]; ok {
+			results = append(results, res)
+		}
+	}
+	return results
+}
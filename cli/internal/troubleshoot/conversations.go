@@ -0,0 +1,110 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Conversation roles, as in chat-format datasets
+const (
+	RoleSystem    = "system"
+	RoleUser      = "user"
+	RoleAssistant = "assistant"
+)
+
+// Conversation is the transcript of one call
+type Conversation struct {
+	CallID       string    `json:"call_id"`
+	Start        time.Time `json:"start"`
+	Status       string    `json:"status,omitempty"`
+	Context      string    `json:"context,omitempty"`
+	CallerNumber string    `json:"-"`
+	CallerName   string    `json:"-"`
+	Turns        []Turn    `json:"turns"`
+}
+
+// Turn is what one side said; consecutive fragments of the same side are
+// joined
+type Turn struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// ExportConversations reads the transcript of every call in the
+// --since/--until window that matches the filters, oldest first. Calls
+// whose logs cannot be collected are left out.
+func (r *Runner) ExportConversations() ([]*Conversation, error) {
+	r.all = true
+	calls, err := r.getRecentCalls(exportCallLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent calls: %w", r.wrapCtxErr(err))
+	}
+	r.since, r.until = "", ""
+
+	fmt.Fprintf(os.Stderr, "Reading %d call(s)...\n", len(calls))
+	var conversations []*Conversation
+	for i := len(calls) - 1; i >= 0; i-- {
+		if r.ctx.Err() != nil {
+			return nil, r.wrapCtxErr(r.ctx.Err())
+		}
+		call := calls[i]
+		r.callID = call.ID
+		logData, err := r.collectCallData()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %s skipped: %v\n", call.ID, err)
+			continue
+		}
+		c := &Conversation{
+			CallID:       call.ID,
+			Start:        call.Timestamp,
+			Status:       call.Status,
+			Context:      callPersona(logData),
+			CallerNumber: call.CallerNumber,
+			CallerName:   call.CallerName,
+			Turns:        r.callTurns(call.ID, logData),
+		}
+		conversations = append(conversations, c)
+	}
+	return conversations, nil
+}
+
+// callTurns reads what the caller and the agent said from a call's logs
+func (r *Runner) callTurns(callID, logData string) []Turn {
+	c := &Conversation{Turns: []Turn{}}
+	classify := callEventClassifier(callID, r.logLoc)
+	for _, line := range strings.Split(logData, "\n") {
+		le := classify(ansiStripPattern.ReplaceAllString(line, ""))
+		if le == nil || le.Text == "" {
+			continue
+		}
+		switch le.Kind {
+		case LiveCaller:
+			c.add(RoleUser, le.Text)
+		case LiveAgent:
+			c.add(RoleAssistant, le.Text)
+		}
+	}
+	return c.Turns
+}
+
+func (c *Conversation) add(role, text string) {
+	text = strings.TrimSpace(text)
+	if n := len(c.Turns); n > 0 && c.Turns[n-1].Role == role {
+		c.Turns[n-1].Text += " " + text
+		return
+	}
+	c.Turns = append(c.Turns, Turn{Role: role, Text: text})
+}
+
+// Exchanges counts the caller turns the agent answered
+func (c *Conversation) Exchanges() int {
+	n := 0
+	for i := 1; i < len(c.Turns); i++ {
+		if c.Turns[i].Role == RoleAssistant && c.Turns[i-1].Role == RoleUser {
+			n++
+		}
+	}
+	return n
+}
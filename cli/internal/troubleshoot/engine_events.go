@@ -0,0 +1,91 @@
+package troubleshoot
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// EventRecorder keeps the call index current from events the engine
+// emits ('agent events listen'), so deployments that emit events need no
+// log scraping
+type EventRecorder struct {
+	mu        sync.Mutex
+	retention time.Duration
+}
+
+// NewEventRecorder creates a recorder; index entries older than
+// retention are pruned as events arrive
+func NewEventRecorder(retention time.Duration) *EventRecorder {
+	if retention <= 0 {
+		retention = settings.DefaultIndexRetention
+	}
+	return &EventRecorder{retention: retention}
+}
+
+// Record merges the calls the events describe into the call index and
+// returns how many calls changed. Errors without a call ID touch no call.
+func (rec *EventRecorder) Record(evs []events.EngineEvent) (int, error) {
+	var calls []Call
+	for _, ev := range evs {
+		if call, ok := eventCall(ev); ok {
+			calls = append(calls, call)
+		}
+	}
+	if len(calls) == 0 {
+		return 0, nil
+	}
+
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	index := LoadCallIndex()
+	for i, call := range calls {
+		// Duration follows from the start of the call indexed earlier
+		start := call.Timestamp
+		if existing, ok := index.Get(call.ID); ok && !existing.Timestamp.IsZero() && existing.Timestamp.Before(start) {
+			start = existing.Timestamp
+		}
+		if call.Duration == "" && call.Status != "" && call.EndTime.After(start) {
+			calls[i].Duration = formatDuration(call.EndTime.Sub(start))
+		}
+		index.Merge(calls[i : i+1])
+	}
+	index.Prune(time.Now().Add(-rec.retention))
+	return len(calls), index.Save()
+}
+
+// eventCall is the call record an engine event contributes
+func eventCall(ev events.EngineEvent) (Call, bool) {
+	if ev.CallID == "" {
+		return Call{}, false
+	}
+	call := Call{
+		ID:              ev.CallID,
+		CallerNumber:    ev.CallerNumber,
+		CallerName:      ev.CallerName,
+		Dialed:          ev.Dialed,
+		Channel:         ev.Channel,
+		Context:         ev.Context,
+		DialplanContext: ev.DialplanContext,
+		Tenant:          ev.Tenant,
+	}
+	// Every event bounds the call's window; the index keeps the earliest
+	// start and latest end
+	call.Timestamp = ev.Time
+	call.EndTime = ev.Time
+	if ev.Type == events.EngineCallEnded {
+		call.Status = ev.Status
+		if call.Status == "" {
+			call.Status = CallCompleted
+		}
+		call.HangupCause = ev.HangupCause
+		if ev.DurationS > 0 {
+			d := time.Duration(ev.DurationS * float64(time.Second))
+			call.Timestamp = ev.Time.Add(-d)
+			call.Duration = formatDuration(d)
+		}
+	}
+	return call, true
+}
@@ -0,0 +1,399 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// entityMinDigits is the shortest digit run treated as a phone number,
+// order number or similar entity
+const entityMinDigits = 5
+
+var (
+	// toolCallPattern matches the adapters' "tool call: name({...})" lines
+	toolCallPattern = regexp.MustCompile(`(?i)tool call: ([\w.-]+)\((.*)\)`)
+	// entityTokenPattern splits text into words and numbers
+	entityTokenPattern = regexp.MustCompile(`[A-Za-z0-9]+`)
+	// entityDigitsPattern finds phone/order numbers written with separators
+	entityDigitsPattern = regexp.MustCompile(`\+?[0-9][0-9 ().-]*[0-9]`)
+	// entityIDPattern finds candidate IDs such as ORD-88A7X2
+	entityIDPattern = regexp.MustCompile(`\b[A-Za-z0-9][A-Za-z0-9-]{4,}\b`)
+	// Spoken forms TTS reads awkwardly: ungrouped long numbers, ISO dates,
+	// web addresses and e-mail addresses
+	ttsLongNumberPattern = regexp.MustCompile(`\b[0-9]{7,}\b`)
+	ttsISODatePattern    = regexp.MustCompile(`\b[0-9]{4}-[0-9]{2}-[0-9]{2}\b`)
+	ttsAddressPattern    = regexp.MustCompile(`(?i)https?://\S+|www\.\S+|[a-z0-9._%+-]+@[a-z0-9.-]+\.[a-z]{2,}`)
+	// entityReaskPattern matches the agent asking for a number again
+	entityReaskPattern = regexp.MustCompile(`(?i)\b(repeat|say that again|spell|one more time|didn'?t catch|did not catch|didn'?t get)\b.*\b(number|digits|phone|order|id|date|code|zip|postcode|account|confirmation)\b`)
+)
+
+// spokenDigits maps number words to digits; "oh" is how callers say 0
+var spokenDigits = map[string]string{
+	"zero": "0", "oh": "0", "one": "1", "two": "2", "three": "3", "four": "4",
+	"five": "5", "six": "6", "seven": "7", "eight": "8", "nine": "9",
+	"ten": "10", "eleven": "11", "twelve": "12", "thirteen": "13", "fourteen": "14",
+	"fifteen": "15", "sixteen": "16", "seventeen": "17", "eighteen": "18", "nineteen": "19",
+}
+
+var spokenTens = map[string]string{
+	"twenty": "2", "thirty": "3", "forty": "4", "fifty": "5",
+	"sixty": "6", "seventy": "7", "eighty": "8", "ninety": "9",
+}
+
+// entityToken is one word of a turn: digits (from numerals or number
+// words), a single spelled letter, a mixed ID, or another word
+type entityToken struct {
+	text   string
+	digits bool
+}
+
+// entityTokens normalizes a turn: number words become digits ("double
+// five" 55, "fifty five" 55), everything is lowercased
+func entityTokens(text string) []entityToken {
+	words := entityTokenPattern.FindAllString(strings.ToLower(text), -1)
+	var tokens []entityToken
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		repeat := 1
+		if (w == "double" || w == "triple") && i+1 < len(words) {
+			if d, ok := spokenDigits[words[i+1]]; ok && len(d) == 1 {
+				repeat = 2
+				if w == "triple" {
+					repeat = 3
+				}
+				tokens = append(tokens, entityToken{strings.Repeat(d, repeat), true})
+				i++
+				continue
+			}
+		}
+		if tens, ok := spokenTens[w]; ok {
+			if i+1 < len(words) {
+				if d, ok := spokenDigits[words[i+1]]; ok && len(d) == 1 && d != "0" {
+					tokens = append(tokens, entityToken{tens + d, true})
+					i++
+					continue
+				}
+			}
+			tokens = append(tokens, entityToken{tens + "0", true})
+			continue
+		}
+		if d, ok := spokenDigits[w]; ok {
+			tokens = append(tokens, entityToken{d, true})
+			continue
+		}
+		tokens = append(tokens, entityToken{w, isDigits(w)})
+	}
+	return tokens
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func hasLetterAndDigit(s string) bool {
+	letter, digit := false, false
+	for _, r := range s {
+		switch {
+		case r >= '0' && r <= '9':
+			digit = true
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+			letter = true
+		}
+	}
+	return letter && digit
+}
+
+// entityCompact keeps what can make up an entity, run together: digits,
+// spelled letters and mixed IDs. "A B 1 2, double three" is "ab1233".
+func entityCompact(text string) string {
+	var b strings.Builder
+	for _, t := range entityTokens(text) {
+		if t.digits || len(t.text) == 1 || hasLetterAndDigit(t.text) {
+			b.WriteString(t.text)
+		}
+	}
+	return b.String()
+}
+
+// digitRuns returns the numbers of at least entityMinDigits digits in a
+// turn, joining digits said one after another ("5 5 5, 1 2 3 4")
+func digitRuns(text string) []string {
+	var runs []string
+	var run strings.Builder
+	flush := func() {
+		if run.Len() >= entityMinDigits {
+			runs = append(runs, run.String())
+		}
+		run.Reset()
+	}
+	for _, t := range entityTokens(text) {
+		if t.digits {
+			run.WriteString(t.text)
+			continue
+		}
+		flush()
+	}
+	flush()
+	return runs
+}
+
+// toolEntities returns the phone/order numbers and IDs in a tool call's
+// arguments, lowercased and without separators
+func toolEntities(args string) []string {
+	seen := make(map[string]bool)
+	var entities []string
+	add := func(e string) {
+		if !seen[e] {
+			seen[e] = true
+			entities = append(entities, e)
+		}
+	}
+	for _, m := range entityIDPattern.FindAllString(args, -1) {
+		if hasLetterAndDigit(m) {
+			add(strings.ToLower(strings.Replace(m, "-", "", -1)))
+		}
+	}
+	ids := strings.Join(entities, " ")
+	for _, m := range entityDigitsPattern.FindAllString(args, -1) {
+		// Skip decimals and the digits of an ID found above
+		if digits := onlyDigits(m); len(digits) >= entityMinDigits && !strings.Contains(m, ".") && !strings.Contains(ids, digits) {
+			add(digits)
+		}
+	}
+	return entities
+}
+
+func onlyDigits(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// similarNumbers reports whether two different numbers look like one
+// number heard or read back wrong: about the same length and mostly the
+// same digits in place
+func similarNumbers(a, b string) bool {
+	if a == b || len(a)-len(b) > 1 || len(b)-len(a) > 1 {
+		return false
+	}
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+	same := 0
+	for i := 0; i < n; i++ {
+		if a[i] == b[i] {
+			same++
+		}
+	}
+	return same*10 >= n*6 || strings.HasPrefix(a, b[:3]) || strings.HasSuffix(a, b[len(b)-3:])
+}
+
+// entityProblem is one entity handling failure with its evidence
+type entityProblem struct {
+	count    int
+	evidence string
+}
+
+func (p *entityProblem) add(evidence string) {
+	p.count++
+	if p.evidence == "" {
+		p.evidence = evidence
+	}
+}
+
+// entitiesAnalyzer cross-checks the numbers and IDs of a call: tool
+// arguments and the agent's read-backs against what the caller said, and
+// the agent's text for entities TTS reads awkwardly
+type entitiesAnalyzer struct {
+	callerNumber string
+	said         strings.Builder // entityCompact of every caller turn
+	lastRuns     []string        // digit runs of the latest caller turns
+	unheard      entityProblem
+	readBack     entityProblem
+	reasked      entityProblem
+	awkward      entityProblem
+	awkwardKinds map[string]bool
+}
+
+func newEntitiesAnalyzer() *entitiesAnalyzer {
+	return &entitiesAnalyzer{awkwardKinds: make(map[string]bool)}
+}
+
+func (a *entitiesAnalyzer) Name() string { return "entities" }
+
+func (a *entitiesAnalyzer) Observe(ev *LogEvent) {
+	if n := ev.String("caller_number"); n != "" && a.callerNumber == "" {
+		a.callerNumber = onlyDigits(n)
+	}
+	lower := ev.Lower
+	if strings.Contains(lower, "tool") {
+		if name, args := toolCallArgs(ev); args != "" {
+			a.observeTool(name, args)
+			return
+		}
+	}
+	if !strings.Contains(lower, "transcri") && !strings.Contains(lower, "llm") && !strings.Contains(lower, "conversation") {
+		return
+	}
+	le := ClassifyLiveLine(ev.Line, time.UTC)
+	if le == nil || le.Text == "" {
+		return
+	}
+	switch le.Kind {
+	case LiveCaller:
+		a.said.WriteString(entityCompact(le.Text))
+		if runs := digitRuns(le.Text); len(runs) > 0 {
+			a.lastRuns = append(a.lastRuns, runs...)
+			if len(a.lastRuns) > 4 {
+				a.lastRuns = a.lastRuns[len(a.lastRuns)-4:]
+			}
+		}
+	case LiveAgent:
+		a.observeAgent(le.Text)
+	}
+}
+
+// toolCallArgs returns the name and arguments of a tool call line
+func toolCallArgs(ev *LogEvent) (string, string) {
+	if m := toolCallPattern.FindStringSubmatch(ev.Line); len(m) > 2 {
+		return m[1], m[2]
+	}
+	name := firstString(ev, "tool", "tool_name", "function_name", "name")
+	for _, key := range []string{"arguments", "parameters", "args", "tool_args"} {
+		switch v := ev.Fields[key].(type) {
+		case string:
+			return name, v
+		case map[string]interface{}:
+			data, _ := json.Marshal(v)
+			return name, string(data)
+		}
+	}
+	return "", ""
+}
+
+func firstString(ev *LogEvent, keys ...string) string {
+	for _, k := range keys {
+		if v := ev.String(k); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func (a *entitiesAnalyzer) observeTool(name, args string) {
+	said := a.said.String()
+	for _, e := range toolEntities(args) {
+		// The caller's own number comes from caller ID, not speech
+		if a.callerNumber != "" && (strings.HasSuffix(a.callerNumber, e) || strings.HasSuffix(e, a.callerNumber)) {
+			continue
+		}
+		if !strings.Contains(said, e) {
+			a.unheard.add(fmt.Sprintf("%s got %q, which the caller never said", name, e))
+		}
+	}
+}
+
+func (a *entitiesAnalyzer) observeAgent(text string) {
+	for _, run := range digitRuns(text) {
+		matched, similar := false, ""
+		for _, heard := range a.lastRuns {
+			if strings.Contains(heard, run) || strings.Contains(run, heard) {
+				matched = true
+				break
+			}
+			if similarNumbers(run, heard) {
+				similar = heard
+			}
+		}
+		if !matched && similar != "" {
+			a.readBack.add(fmt.Sprintf("caller said %s, agent read back %s", similar, run))
+		}
+	}
+	if entityReaskPattern.MatchString(text) {
+		a.reasked.add(truncate(text, 100))
+	}
+
+	kinds := []struct {
+		kind    string
+		pattern *regexp.Regexp
+	}{
+		{"long numbers", ttsLongNumberPattern},
+		{"ISO dates", ttsISODatePattern},
+		{"web/e-mail addresses", ttsAddressPattern},
+	}
+	found := false
+	for _, k := range kinds {
+		if k.pattern.MatchString(text) {
+			a.awkwardKinds[k.kind] = true
+			found = true
+		}
+	}
+	for _, m := range entityIDPattern.FindAllString(text, -1) {
+		if hasLetterAndDigit(m) && strings.ToUpper(m) == m {
+			a.awkwardKinds["IDs"] = true
+			found = true
+		}
+	}
+	if found {
+		a.awkward.add(truncate(text, 100))
+	}
+}
+
+func (a *entitiesAnalyzer) Finish(analysis *Analysis) []Finding {
+	var findings []Finding
+	if a.unheard.count > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Tool called with numbers or IDs the caller did not say (%dx)", a.unheard.count),
+			Evidence: a.unheard.evidence,
+			Fix:      "Have the agent read numbers back digit by digit and confirm before calling tools; if STT mangles digits, see the asr findings and add keyword boosts (agent stt vocab add)",
+		})
+	}
+	if a.readBack.count > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Agent read back a different number than the caller said (%dx)", a.readBack.count),
+			Evidence: a.readBack.evidence,
+			Fix:      "Add a normalization rule to the prompt: spoken digits map one to one (\"oh\" is 0, \"double five\" is 55) and numbers are never reformatted",
+		})
+	}
+	if a.reasked.count > 0 {
+		severity := SeverityInfo
+		if a.reasked.count > 1 {
+			severity = SeverityWarning
+		}
+		findings = append(findings, Finding{
+			Severity: severity,
+			Message:  fmt.Sprintf("Agent asked for a number again (%dx)", a.reasked.count),
+			Evidence: a.reasked.evidence,
+		})
+	}
+	if a.awkward.count > 0 {
+		var kinds []string
+		for _, k := range []string{"long numbers", "IDs", "ISO dates", "web/e-mail addresses"} {
+			if a.awkwardKinds[k] {
+				kinds = append(kinds, k)
+			}
+		}
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Agent spoke %s that TTS reads awkwardly (%dx)", strings.Join(kinds, ", "), a.awkward.count),
+			Evidence: a.awkward.evidence,
+			Fix:      "Tell the LLM to write numbers and IDs as separate characters (\"5 5 5, 1 2 3\"), dates in words (\"November 3rd\") and addresses as spoken (\"example dot com\"), or wrap them in SSML <say-as interpret-as=\"telephone|characters|date\"> when the TTS takes SSML",
+		})
+	}
+	return findings
+}
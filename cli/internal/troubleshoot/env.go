@@ -3,20 +3,22 @@ package troubleshoot
 import (
 	"os"
 	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
 )
 
 // LoadEnvFile loads environment variables from .env file
 func LoadEnvFile() {
 	// Try to find .env
 	envPath := ".env"
-	if _, err := os.Stat(envPath); os.IsNotExist(err) {
+	if !remote.Exists(envPath) {
 		envPath = "../.env"
-		if _, err := os.Stat(envPath); os.IsNotExist(err) {
+		if !remote.Exists(envPath) {
 			return // No .env file found
 		}
 	}
 
-	data, err := os.ReadFile(envPath)
+	data, err := remote.ReadFile(envPath)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,173 @@
+package troubleshoot
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportCallLimit caps how many calls one export analyzes
+const exportCallLimit = 10000
+
+// CallRow is the flat per-call record written by 'agent export calls'
+type CallRow struct {
+	CallID           string    `json:"call_id"`
+	Start            time.Time `json:"start"`
+	End              time.Time `json:"end,omitempty"`
+	DurationSeconds  float64   `json:"duration_seconds"`
+	Status           string    `json:"status"`
+	HangupCause      int       `json:"hangup_cause,omitempty"`
+	Persona          string    `json:"persona,omitempty"`
+	Providers        []string  `json:"providers,omitempty"`
+	TurnLatencyAvgMs string    `json:"turn_latency_avg_ms,omitempty"`
+	TurnLatencyP95Ms string    `json:"turn_latency_p95_ms,omitempty"`
+	TurnLatencyMaxMs string    `json:"turn_latency_max_ms,omitempty"`
+	QualityScore     float64   `json:"quality_score"`
+	Errors           int       `json:"errors"`
+	Warnings         int       `json:"warnings"`
+	Fingerprint      string    `json:"fingerprint,omitempty"`
+	// Cost is empty when a provider of the call has no configured price
+	Cost   *float64 `json:"cost,omitempty"`
+	Tags   []string `json:"tags,omitempty"`
+	Tenant string   `json:"tenant,omitempty"`
+	// Tokens is 0 when the providers log no usage
+	Tokens int `json:"tokens,omitempty"`
+	// Hours is in_hours or after_hours, empty without business hours
+	Hours string `json:"hours,omitempty"`
+
+	// Findings are left out of the CSV
+	Findings []Finding `json:"findings,omitempty"`
+}
+
+// callRowColumns is the CSV header, in CallRow order
+var callRowColumns = []string{
+	"call_id", "start", "end", "duration_seconds", "status", "hangup_cause",
+	"persona", "providers", "turn_latency_avg_ms", "turn_latency_p95_ms",
+	"turn_latency_max_ms", "quality_score", "errors", "warnings",
+	"fingerprint", "cost", "tags", "tenant", "tokens", "hours",
+}
+
+// ExportCalls analyzes every call in the --since/--until window that
+// matches the filters and returns one row per call, oldest first.
+// Calls whose logs cannot be collected are left out.
+func (r *Runner) ExportCalls() ([]CallRow, error) {
+	r.all = true
+	calls, err := r.getRecentCalls(exportCallLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent calls: %w", r.wrapCtxErr(err))
+	}
+	r.since, r.until = "", ""
+
+	fmt.Fprintf(os.Stderr, "Exporting %d call(s)...\n", len(calls))
+	rows := make([]CallRow, 0, len(calls))
+	for i := len(calls) - 1; i >= 0; i-- {
+		if r.ctx.Err() != nil {
+			return nil, r.wrapCtxErr(r.ctx.Err())
+		}
+		rc, err := r.analyzeReportCall(calls[i])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %s skipped: %v\n", calls[i].ID, err)
+			continue
+		}
+		rows = append(rows, newCallRow(rc, r.costs))
+	}
+	return rows, nil
+}
+
+func newCallRow(rc reportCall, rates map[string]float64) CallRow {
+	row := CallRow{
+		CallID:           rc.call.ID,
+		Start:            rc.call.Timestamp,
+		End:              rc.call.EndTime,
+		DurationSeconds:  rc.minutes * 60,
+		Status:           rc.call.Status,
+		HangupCause:      rc.call.HangupCause,
+		Persona:          rc.persona,
+		TurnLatencyAvgMs: rc.report.Metrics["turn_latency_avg_ms"],
+		TurnLatencyP95Ms: rc.report.Metrics["turn_latency_p95_ms"],
+		TurnLatencyMaxMs: rc.report.Metrics["turn_latency_max_ms"],
+		QualityScore:     rc.report.Score,
+		Errors:           rc.report.Errors,
+		Warnings:         rc.report.Warnings,
+		Fingerprint:      Fingerprint(rc.report, &rc.call),
+		Findings:         rc.report.Findings,
+		Tags:             rc.call.Tags,
+		Tenant:           rc.call.Tenant,
+		Tokens:           rc.tokens,
+		Hours:            rc.hours,
+	}
+	if providers := rc.report.Metrics["providers"]; providers != "" {
+		row.Providers = strings.Split(providers, ",")
+	}
+
+	if len(row.Providers) > 0 {
+		cost := 0.0
+		priced := true
+		for _, name := range row.Providers {
+			rate, ok := rates[name]
+			if !ok {
+				priced = false
+				break
+			}
+			cost += rate * rc.minutes
+		}
+		if priced {
+			row.Cost = &cost
+		}
+	}
+	return row
+}
+
+// WriteCallsCSV writes rows as CSV with a header line. Times are RFC 3339
+// in UTC; providers and tags are separated by '+'.
+func WriteCallsCSV(w io.Writer, rows []CallRow) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(callRowColumns); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		end := ""
+		if !row.End.IsZero() {
+			end = row.End.UTC().Format(time.RFC3339)
+		}
+		hangup := ""
+		if row.HangupCause != 0 {
+			hangup = strconv.Itoa(row.HangupCause)
+		}
+		cost := ""
+		if row.Cost != nil {
+			cost = strconv.FormatFloat(*row.Cost, 'f', 4, 64)
+		}
+		record := []string{
+			row.CallID,
+			row.Start.UTC().Format(time.RFC3339),
+			end,
+			strconv.FormatFloat(row.DurationSeconds, 'f', 0, 64),
+			row.Status,
+			hangup,
+			row.Persona,
+			strings.Join(row.Providers, "+"),
+			row.TurnLatencyAvgMs,
+			row.TurnLatencyP95Ms,
+			row.TurnLatencyMaxMs,
+			strconv.FormatFloat(row.QualityScore, 'f', 0, 64),
+			strconv.Itoa(row.Errors),
+			strconv.Itoa(row.Warnings),
+			row.Fingerprint,
+			cost,
+			strings.Join(row.Tags, "+"),
+			row.Tenant,
+			strconv.Itoa(row.Tokens),
+			row.Hours,
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,269 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Verdicts on a troubleshoot run's root cause analysis
+const (
+	VerdictCorrect = "correct"
+	VerdictWrong   = "wrong"
+)
+
+const (
+	// feedbackKept bounds the stored feedback records, newest kept
+	feedbackKept = 500
+
+	// feedbackThreshold is the net verdict count (correct minus wrong)
+	// at which a finding pattern is promoted or demoted one severity
+	feedbackThreshold = 2
+
+	// feedbackExamples caps the past verdicts given to the LLM
+	feedbackExamples = 3
+)
+
+// FeedbackRecord is an operator's verdict on a saved run. Patterns are
+// the run's critical and warning findings with numbers stripped, so the
+// verdict applies to the same findings on later calls.
+type FeedbackRecord struct {
+	RunID       string    `json:"run_id"`
+	CallID      string    `json:"call_id"`
+	Verdict     string    `json:"verdict"`
+	ActualCause string    `json:"actual_cause,omitempty"`
+	Symptom     string    `json:"symptom,omitempty"`
+	Patterns    []string  `json:"patterns,omitempty"`
+	Findings    []string  `json:"findings,omitempty"`
+	Diagnosis   string    `json:"diagnosis,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func feedbackPath() string {
+	return filepath.Join(settings.Dir(), "feedback.json")
+}
+
+// LoadFeedback reads the recorded verdicts, oldest first. A missing or
+// unreadable file yields none.
+func LoadFeedback() []FeedbackRecord {
+	var records []FeedbackRecord
+	if data, err := os.ReadFile(feedbackPath()); err == nil {
+		json.Unmarshal(data, &records)
+	}
+	return records
+}
+
+func saveFeedback(records []FeedbackRecord) error {
+	if len(records) > feedbackKept {
+		records = records[len(records)-feedbackKept:]
+	}
+	if err := os.MkdirAll(settings.Dir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(records, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(feedbackPath(), data, 0644)
+}
+
+// findingPattern identifies a finding independent of its numbers
+func findingPattern(f Finding) string {
+	return f.Analyzer + ":" + fingerprintNoise.ReplaceAllString(strings.ToLower(f.Message), "#")
+}
+
+// RecordFeedback stores a verdict on a saved run, replacing an earlier
+// verdict on the same run. A wrong verdict needs the actual cause.
+func RecordFeedback(runID, verdict, actualCause string) (*FeedbackRecord, error) {
+	verdict = strings.ToLower(strings.TrimSpace(verdict))
+	actualCause = strings.TrimSpace(actualCause)
+	switch verdict {
+	case VerdictCorrect:
+	case VerdictWrong:
+		if actualCause == "" {
+			return nil, fmt.Errorf("--actual-cause is required when the verdict is wrong")
+		}
+	default:
+		return nil, fmt.Errorf("unknown verdict %q (use correct or wrong)", verdict)
+	}
+
+	run, err := LoadRun(runID)
+	if err != nil {
+		return nil, err
+	}
+	record := FeedbackRecord{
+		RunID:       run.ID,
+		CallID:      run.CallID,
+		Verdict:     verdict,
+		ActualCause: actualCause,
+		Symptom:     run.Symptom,
+		CreatedAt:   time.Now(),
+	}
+	if run.Report != nil {
+		seen := make(map[string]bool)
+		for _, f := range run.Report.Findings {
+			if f.Severity == SeverityInfo {
+				continue
+			}
+			if p := findingPattern(f); !seen[p] {
+				seen[p] = true
+				record.Patterns = append(record.Patterns, p)
+				record.Findings = append(record.Findings, fmt.Sprintf("[%s/%s] %s", f.Analyzer, f.Severity, f.Message))
+			}
+		}
+		if run.Report.Diagnosis != nil {
+			record.Diagnosis = truncate(strings.TrimSpace(run.Report.Diagnosis.Analysis), 400)
+		}
+	}
+
+	records := LoadFeedback()
+	kept := records[:0]
+	for _, r := range records {
+		if r.RunID != record.RunID {
+			kept = append(kept, r)
+		}
+	}
+	if err := saveFeedback(append(kept, record)); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// patternVerdicts counts correct and wrong verdicts per finding pattern
+type patternVerdicts struct {
+	correct int
+	wrong   int
+}
+
+func feedbackStats(records []FeedbackRecord) map[string]*patternVerdicts {
+	stats := make(map[string]*patternVerdicts)
+	for _, r := range records {
+		for _, p := range r.Patterns {
+			v := stats[p]
+			if v == nil {
+				v = &patternVerdicts{}
+				stats[p] = v
+			}
+			if r.Verdict == VerdictWrong {
+				v.wrong++
+			} else {
+				v.correct++
+			}
+		}
+	}
+	return stats
+}
+
+// applyFeedback promotes findings whose pattern was confirmed more often
+// than not and demotes those marked wrong, one severity level each
+func applyFeedback(findings []Finding, records []FeedbackRecord) {
+	if len(records) == 0 {
+		return
+	}
+	stats := feedbackStats(records)
+	for i := range findings {
+		f := &findings[i]
+		v := stats[findingPattern(*f)]
+		if v == nil {
+			continue
+		}
+		rated := v.correct + v.wrong
+		switch net := v.correct - v.wrong; {
+		case net >= feedbackThreshold && f.Severity != SeverityCritical:
+			f.Severity = promoteSeverity(f.Severity)
+			f.Feedback = fmt.Sprintf("promoted by feedback: correct in %d of %d rated runs", v.correct, rated)
+		case -net >= feedbackThreshold && f.Severity != SeverityInfo:
+			f.Severity = demoteSeverity(f.Severity)
+			f.Feedback = fmt.Sprintf("demoted by feedback: wrong in %d of %d rated runs", v.wrong, rated)
+		}
+	}
+	sortFindings(findings)
+}
+
+func promoteSeverity(severity string) string {
+	if severity == SeverityWarning {
+		return SeverityCritical
+	}
+	return SeverityWarning
+}
+
+func demoteSeverity(severity string) string {
+	if severity == SeverityCritical {
+		return SeverityWarning
+	}
+	return SeverityInfo
+}
+
+// similarFeedback picks the verdicts closest to an analysis: most shared
+// finding patterns, then same symptom, newest first
+func similarFeedback(records []FeedbackRecord, analysis *Analysis, limit int) []FeedbackRecord {
+	current := make(map[string]bool)
+	for _, f := range analysis.Findings {
+		current[findingPattern(f)] = true
+	}
+
+	type scored struct {
+		record FeedbackRecord
+		score  int
+	}
+	var candidates []scored
+	for _, r := range records {
+		score := 0
+		for _, p := range r.Patterns {
+			if current[p] {
+				score += 2
+			}
+		}
+		if analysis.Symptom != "" && r.Symptom == analysis.Symptom {
+			score++
+		}
+		if score > 0 {
+			candidates = append(candidates, scored{r, score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].score != candidates[j].score {
+			return candidates[i].score > candidates[j].score
+		}
+		return candidates[i].record.CreatedAt.After(candidates[j].record.CreatedAt)
+	})
+
+	var out []FeedbackRecord
+	for i := 0; i < len(candidates) && i < limit; i++ {
+		out = append(out, candidates[i].record)
+	}
+	return out
+}
+
+// formatFeedbackForLLM renders past verdicts as few-shot examples
+func formatFeedbackForLLM(examples []FeedbackRecord) string {
+	var b strings.Builder
+	b.WriteString("Operator Feedback on Similar Past Calls (learn from these):\n")
+	for i, ex := range examples {
+		fmt.Fprintf(&b, "Example %d:\n", i+1)
+		if ex.Symptom != "" {
+			fmt.Fprintf(&b, "- Symptom: %s\n", ex.Symptom)
+		}
+		if len(ex.Findings) > 0 {
+			fmt.Fprintf(&b, "- Findings: %s\n", strings.Join(ex.Findings, "; "))
+		}
+		if ex.Diagnosis != "" {
+			fmt.Fprintf(&b, "- Diagnosis given: %s\n", strings.ReplaceAll(ex.Diagnosis, "\n", " "))
+		}
+		if ex.Verdict == VerdictWrong {
+			fmt.Fprintf(&b, "- Verdict: WRONG. Actual cause: %s\n", ex.ActualCause)
+		} else if ex.ActualCause != "" {
+			fmt.Fprintf(&b, "- Verdict: correct. Confirmed cause: %s\n", ex.ActualCause)
+		} else {
+			b.WriteString("- Verdict: correct\n")
+		}
+	}
+	b.WriteString("\n")
+	return b.String()
+}
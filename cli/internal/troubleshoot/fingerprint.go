@@ -0,0 +1,39 @@
+package troubleshoot
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// fingerprintNoise matches the variable parts of finding messages
+var fingerprintNoise = regexp.MustCompile(`[0-9]+(?:\.[0-9]+)?`)
+
+// Fingerprint identifies the kind of failure in a report so recurring
+// failures can be grouped. It is built from the critical findings with
+// numbers stripped and is empty when the call did not fail.
+func Fingerprint(report *Report, call *Call) string {
+	seen := make(map[string]bool)
+	for _, f := range report.Findings {
+		if f.Severity != SeverityCritical {
+			continue
+		}
+		seen[findingPattern(f)] = true
+	}
+	if len(seen) == 0 {
+		if call == nil || call.Status != CallFailed {
+			return ""
+		}
+		seen["status:failed"] = true
+	}
+
+	parts := make([]string, 0, len(seen))
+	for part := range seen {
+		parts = append(parts, part)
+	}
+	sort.Strings(parts)
+	sum := sha1.Sum([]byte(strings.Join(parts, "\n")))
+	return hex.EncodeToString(sum[:])[:12]
+}
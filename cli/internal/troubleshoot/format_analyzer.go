@@ -1,21 +1,22 @@
 package troubleshoot
 
 import (
+	"context"
 	"fmt"
-	"os/exec"
 	"strings"
 
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
 	"gopkg.in/yaml.v3"
 )
 
 // AnalyzeFormatAlignment checks config vs runtime format/sampling alignment
-func AnalyzeFormatAlignment(metrics *CallMetrics) *FormatAlignment {
+func AnalyzeFormatAlignment(ctx context.Context, container string, metrics *CallMetrics) *FormatAlignment {
 	alignment := &FormatAlignment{
 		Issues: []string{},
 	}
 	
 	// Load config from server
-	config := loadConfigFromServer()
+	config := loadConfigFromServer(ctx, container)
 	if config != nil {
 		alignment.ConfigAudioSocketFormat = getString(config, "audiosocket", "format")
 		alignment.ConfigSampleRate = getInt(config, "streaming", "sample_rate")
@@ -130,16 +131,19 @@ func normalizeFormat(format string) string {
 	}
 }
 
-func loadConfigFromServer() map[string]interface{} {
+func loadConfigFromServer(ctx context.Context, container string) map[string]interface{} {
 	// Try to fetch config from Docker container
-	cmd := exec.Command("docker", "exec", "ai_engine", "cat", "/app/config/ai-agent.yaml")
-	output, err := cmd.CombinedOutput()
+	client, err := docker.Default()
+	if err != nil {
+		return nil
+	}
+	result, err := client.Exec(ctx, container, nil, "cat", "/app/config/ai-agent.yaml")
 	if err != nil {
 		return nil
 	}
 	
 	var config map[string]interface{}
-	if err := yaml.Unmarshal(output, &config); err != nil {
+	if err := yaml.Unmarshal(result.Stdout, &config); err != nil {
 		return nil
 	}
 	
@@ -0,0 +1,254 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+)
+
+const (
+	// frameGapMs is the longest pause between two frames of a stream
+	// before it counts as a gap
+	frameGapMs = 100
+	// frameStarvedShare: an inbound second with fewer frames than this
+	// share of the usual rate is starved
+	frameStarvedShare = 0.5
+	// frameBurstShare: an outbound second with more frames than this
+	// multiple of the usual rate is a burst
+	frameBurstShare = 1.5
+	// frameRowSeconds is how many seconds one sparkline row shows
+	frameRowSeconds = 60
+)
+
+// sparkLevels draw a sparkline, from no frames to the busiest second
+var sparkLevels = []string{" ", "▁", "▂", "▃", "▄", "▅", "▆", "▇", "█"}
+
+// FrameSecond is one second of the engine's transport frame counters
+// ("Audio frame stats", logged while the engine logs at debug level)
+type FrameSecond = results.FrameSecond
+
+// framesAnalyzer collects the per-second frame counters of the call and
+// flags inbound starvation, outbound bursts and playback gaps
+type framesAnalyzer struct {
+	seconds map[int]FrameSecond
+}
+
+func newFramesAnalyzer() *framesAnalyzer {
+	return &framesAnalyzer{seconds: make(map[int]FrameSecond)}
+}
+
+func (a *framesAnalyzer) Name() string { return "frames" }
+
+func (a *framesAnalyzer) Observe(ev *LogEvent) {
+	if ev.Event != "Audio frame stats" {
+		return
+	}
+	second, ok := ev.Number("second")
+	if !ok {
+		return
+	}
+	count := func(key string) int {
+		v, _ := ev.Number(key)
+		return int(v)
+	}
+	s := FrameSecond{
+		Second:    int(second),
+		InFrames:  count("in_frames"),
+		InBytes:   count("in_bytes"),
+		OutFrames: count("out_frames"),
+		OutBytes:  count("out_bytes"),
+	}
+	s.InMaxGapMs, _ = ev.Number("in_max_gap_ms")
+	s.OutMaxGapMs, _ = ev.Number("out_max_gap_ms")
+	a.seconds[s.Second] = s
+}
+
+func (a *framesAnalyzer) Finish(analysis *Analysis) []Finding {
+	if len(a.seconds) == 0 {
+		return nil
+	}
+	// Seconds without frames are not logged; fill them in
+	last := 0
+	for sec := range a.seconds {
+		if sec > last {
+			last = sec
+		}
+	}
+	frames := make([]FrameSecond, last+1)
+	for i := range frames {
+		frames[i] = a.seconds[i]
+		frames[i].Second = i
+	}
+	analysis.Frames = frames
+
+	inRate := usualRate(frames, func(s FrameSecond) int { return s.InFrames })
+	outRate := usualRate(frames, func(s FrameSecond) int { return s.OutFrames })
+	analysis.MetricsMap["frames_in_per_sec"] = strconv.Itoa(inRate)
+	analysis.MetricsMap["frames_out_per_sec"] = strconv.Itoa(outRate)
+
+	var starved, bursts, gaps []int
+	first := -1
+	for i, s := range frames {
+		if first < 0 && s.InFrames > 0 {
+			first = i
+		}
+		// The first and last seconds of the call are partial
+		partial := i == first || i == len(frames)-1
+		if first >= 0 && !partial && float64(s.InFrames) < float64(inRate)*frameStarvedShare {
+			starved = append(starved, i)
+		}
+		if outRate > 0 && float64(s.OutFrames) > float64(outRate)*frameBurstShare {
+			bursts = append(bursts, i)
+		}
+		// A pause before the first frame of a reply is not a gap
+		if s.OutFrames > 0 && i > 0 && frames[i-1].OutFrames > 0 && s.OutMaxGapMs > frameGapMs {
+			gaps = append(gaps, i)
+		}
+	}
+
+	var findings []Finding
+	if len(starved) > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Inbound audio starved in %d second(s)", len(starved)),
+			Evidence: fmt.Sprintf("fewer than %d of the usual %d frames/s at %s", int(float64(inRate)*frameStarvedShare), inRate, formatSeconds(starved)),
+			Fix:      "Caller audio stopped arriving: check network loss and jitter between Asterisk and the engine, and CPU load on the engine host",
+		})
+	}
+	if len(bursts) > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Outbound audio sent in bursts in %d second(s)", len(bursts)),
+			Evidence: fmt.Sprintf("more than %d of the usual %d frames/s at %s", int(float64(outRate)*frameBurstShare), outRate, formatSeconds(bursts)),
+			Fix:      "Playback is catching up after stalls: check provider latency and the streaming jitter buffer (streaming.jitter_buffer_ms, low_watermark_ms)",
+		})
+	}
+	if len(gaps) > 0 {
+		findings = append(findings, Finding{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Gaps over %d ms during playback in %d second(s)", frameGapMs, len(gaps)),
+			Evidence: "at " + formatSeconds(gaps),
+			Fix:      "The agent's audio stalled mid-reply (heard as choppy speech): check provider chunk timing and streaming underflows",
+		})
+	}
+	return findings
+}
+
+// usualRate is the median frames per second of the seconds with frames
+func usualRate(frames []FrameSecond, count func(FrameSecond) int) int {
+	var rates []int
+	for _, s := range frames {
+		if n := count(s); n > 0 {
+			rates = append(rates, n)
+		}
+	}
+	if len(rates) == 0 {
+		return 0
+	}
+	sort.Ints(rates)
+	return rates[len(rates)/2]
+}
+
+// formatSeconds lists call offsets as m:ss, collapsing runs ("0:12-0:15")
+// and keeping the first few
+func formatSeconds(seconds []int) string {
+	var parts []string
+	for i := 0; i < len(seconds); {
+		j := i
+		for j+1 < len(seconds) && seconds[j+1] == seconds[j]+1 {
+			j++
+		}
+		part := clock(seconds[i])
+		if j > i {
+			part += "-" + clock(seconds[j])
+		}
+		parts = append(parts, part)
+		i = j + 1
+	}
+	if len(parts) > 5 {
+		return strings.Join(parts[:5], ", ") + fmt.Sprintf(" and %d more", len(parts)-5)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func clock(second int) string {
+	return fmt.Sprintf("%d:%02d", second/60, second%60)
+}
+
+// sparkline draws one character per value, scaled to max
+func sparkline(values []int, max int) string {
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if v > 0 && max > 0 {
+			level = 1 + v*(len(sparkLevels)-2)/max
+			if level >= len(sparkLevels) {
+				level = len(sparkLevels) - 1
+			}
+		}
+		b.WriteString(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// displayFrames draws the call's frames per second as sparklines, one
+// row per minute, with a mark under every second that had a gap
+func (r *Runner) displayFrames(analysis *Analysis) {
+	if !r.frames {
+		return
+	}
+	frames := analysis.Frames
+	if len(frames) == 0 {
+		fmt.Println("Audio Frames:")
+		fmt.Println("  No frame counters in this call's logs. The engine logs them while it")
+		fmt.Println("  logs at debug level: run 'agent debug enable --for 15m', place a call")
+		fmt.Println("  and analyze it with --frames.")
+		fmt.Println()
+		return
+	}
+	maxIn, maxOut := 0, 0
+	for _, s := range frames {
+		if s.InFrames > maxIn {
+			maxIn = s.InFrames
+		}
+		if s.OutFrames > maxOut {
+			maxOut = s.OutFrames
+		}
+	}
+	fmt.Printf("Audio Frames (1 column = 1 s; in max %d/s, out max %d/s):\n", maxIn, maxOut)
+	for start := 0; start < len(frames); start += frameRowSeconds {
+		end := start + frameRowSeconds
+		if end > len(frames) {
+			end = len(frames)
+		}
+		row := frames[start:end]
+		in := make([]int, len(row))
+		out := make([]int, len(row))
+		var gap strings.Builder
+		for i, s := range row {
+			in[i], out[i] = s.InFrames, s.OutFrames
+			inGap := s.InMaxGapMs > frameGapMs
+			outGap := s.OutFrames > 0 && start+i > 0 && frames[start+i-1].OutFrames > 0 && s.OutMaxGapMs > frameGapMs
+			switch {
+			case inGap && outGap:
+				gap.WriteString("!")
+			case inGap:
+				gap.WriteString("i")
+			case outGap:
+				gap.WriteString("o")
+			default:
+				gap.WriteString(" ")
+			}
+		}
+		fmt.Printf("  %5s in  |%s|\n", clock(start), sparkline(in, maxIn))
+		fmt.Printf("        out |%s|\n", sparkline(out, maxOut))
+		if strings.TrimSpace(gap.String()) != "" {
+			fmt.Printf("        gap |%s|\n", gap.String())
+		}
+	}
+	fmt.Printf("  gap: i inbound, o outbound, ! both paused over %d ms\n", frameGapMs)
+	fmt.Println()
+}
@@ -0,0 +1,295 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/logstore"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// RunRecord is a persisted troubleshoot run: its inputs, the collected
+// logs (in the log store, referenced from logs.ref next to it) and the
+// resulting report
+type RunRecord struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	CallID    string    `json:"call_id"`
+	Symptom   string    `json:"symptom,omitempty"`
+	Since     string    `json:"since,omitempty"`
+	Until     string    `json:"until,omitempty"`
+	Call      *Call     `json:"call,omitempty"`
+	Report    *Report   `json:"report"`
+
+	// FormatAlignment came from the live engine config when the run was
+	// made
+	FormatAlignment *FormatAlignment `json:"format_alignment,omitempty"`
+}
+
+// RunsDir returns the directory holding one subdirectory per run
+func RunsDir() string {
+	return filepath.Join(settings.Dir(), "runs")
+}
+
+// saveRun persists the run record and the collected logs
+func (r *Runner) saveRun(logData string, analysis *Analysis, report *Report) (string, error) {
+	now := time.Now()
+	id := now.Format("20060102-150405")
+	dir := filepath.Join(RunsDir(), id)
+	for n := 2; ; n++ {
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			break
+		}
+		id = fmt.Sprintf("%s-%d", now.Format("20060102-150405"), n)
+		dir = filepath.Join(RunsDir(), id)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+
+	record := RunRecord{
+		ID:        id,
+		CreatedAt: now,
+		CallID:    r.callID,
+		Symptom:   r.symptom,
+		Since:     r.since,
+		Until:     r.until,
+		Report:    report,
+	}
+	if analysis.Metrics != nil {
+		record.FormatAlignment = analysis.Metrics.FormatAlignment
+	}
+	if call, ok := LoadCallIndex().Get(r.callID); ok {
+		record.Call = call
+	}
+
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "run.json"), data, 0644); err != nil {
+		return "", err
+	}
+	ref, err := logstore.Put(RedactSecrets(logData))
+	if err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "logs.ref"), []byte(ref+"\n"), 0644); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// LoadRun reads a persisted run record
+func LoadRun(id string) (*RunRecord, error) {
+	if id == "" || filepath.Base(id) != id {
+		return nil, fmt.Errorf("invalid run ID %q", id)
+	}
+	data, err := os.ReadFile(filepath.Join(RunsDir(), id, "run.json"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("run %s not found (see: agent troubleshoot history)", id)
+		}
+		return nil, err
+	}
+	var record RunRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("run %s is corrupt: %w", id, err)
+	}
+	return &record, nil
+}
+
+// LoadRunLogs reads the logs collected for a run; runs saved before the
+// log store keep them in logs.txt
+func LoadRunLogs(id string) (string, error) {
+	if ref := runLogRef(id); ref != "" {
+		return logstore.Get(ref)
+	}
+	data, err := os.ReadFile(filepath.Join(RunsDir(), id, "logs.txt"))
+	return string(data), err
+}
+
+// runLogRef returns the log store reference of a run's logs
+func runLogRef(id string) string {
+	data, err := os.ReadFile(filepath.Join(RunsDir(), id, "logs.ref"))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// ListRuns returns persisted runs, newest first
+func ListRuns() ([]*RunRecord, error) {
+	entries, err := os.ReadDir(RunsDir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var runs []*RunRecord
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		record, err := LoadRun(entry.Name())
+		if err != nil {
+			continue
+		}
+		runs = append(runs, record)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].CreatedAt.After(runs[j].CreatedAt)
+	})
+	return runs, nil
+}
+
+// History prints the most recent persisted runs
+func (r *Runner) History(limit int) error {
+	runs, err := ListRuns()
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		warningColor.Println("No troubleshoot runs recorded yet")
+		return nil
+	}
+	if limit > 0 && len(runs) > limit {
+		runs = runs[:limit]
+	}
+
+	fmt.Printf("Troubleshoot runs (%d):\n\n", len(runs))
+	for _, run := range runs {
+		fmt.Printf("%s  %s  call %s", run.ID, formatTimestamp(run.CreatedAt, r.loc), run.CallID)
+		if run.Symptom != "" {
+			fmt.Printf(" (symptom: %s)", run.Symptom)
+		}
+		if run.Report != nil {
+			fmt.Printf("  score %.0f, %d errors", run.Report.Score, run.Report.Errors)
+		}
+		fmt.Println()
+	}
+	fmt.Println()
+	fmt.Println("Usage: agent troubleshoot show <run_id> [--format text|json]")
+	return nil
+}
+
+// ShowRun prints a persisted run as it was reported, from its stored
+// report; only --chart reads the stored logs
+func (r *Runner) ShowRun(id, format string) error {
+	record, err := LoadRun(id)
+	if err != nil {
+		return err
+	}
+	if r.chart != "" {
+		if err := checkChartPath(r.chart); err != nil {
+			return err
+		}
+	}
+	switch format {
+	case "", "text", "json":
+	default:
+		return fmt.Errorf("unknown format %q (use text or json)", format)
+	}
+
+	if format == "json" {
+		if err := r.writeRunChart(record); err != nil {
+			return err
+		}
+		data, err := json.MarshalIndent(record, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+
+	fmt.Println()
+	infoColor.Printf("Run %s (%s) — call %s\n", record.ID, formatTimestamp(record.CreatedAt, r.loc), record.CallID)
+	if record.Symptom != "" {
+		fmt.Printf("Symptom: %s\n", record.Symptom)
+	}
+	fmt.Println()
+
+	report := record.Report
+	if report == nil {
+		return fmt.Errorf("run %s has no report", id)
+	}
+	r.displaySampling(report.Sampling)
+	analysis := &Analysis{
+		CallID:           report.CallID,
+		AudioIssues:      report.AudioIssues,
+		Findings:         report.Findings,
+		LatencyBreakdown: report.Latency,
+		ASRTurns:         report.ASRTurns,
+		Frames:           report.Frames,
+		MetricsMap:       report.Metrics,
+		HasAudioSocket:   report.Pipeline.AudioSocket,
+		HasTranscription: report.Pipeline.Transcription,
+		HasPlayback:      report.Pipeline.Playback,
+		Symptom:          report.Symptom,
+		Incomplete:       report.Incomplete,
+		Sampling:         report.Sampling,
+	}
+	r.displayFindings(analysis)
+	if report.Errors > 0 || report.Warnings > 0 {
+		fmt.Printf("Errors: %d, warnings: %d (the lines are in the run's logs)\n", report.Errors, report.Warnings)
+		fmt.Println()
+	}
+	r.displayStoredMetrics(report)
+	if report.Diagnosis != nil {
+		r.displayLLMDiagnosis(report.Diagnosis)
+	}
+	r.displayIncomplete(analysis)
+	if r.chart != "" {
+		if err := r.writeRunChart(record); err != nil {
+			return err
+		}
+		infoColor.Printf("Timeline chart written to %s\n", r.chart)
+	}
+	return nil
+}
+
+// displayStoredMetrics prints the metrics and quality verdict a run
+// reported
+func (r *Runner) displayStoredMetrics(report *Report) {
+	if len(report.Metrics) > 0 {
+		fmt.Println("═══════════════════════════════════════════")
+		fmt.Println("📈 METRICS")
+		fmt.Println("═══════════════════════════════════════════")
+		keys := make([]string, 0, len(report.Metrics))
+		for k := range report.Metrics {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("  %s: %s\n", k, report.Metrics[k])
+		}
+		fmt.Println()
+	}
+	fmt.Println("═══════════════════════════════════════════")
+	fmt.Println("🎯 OVERALL CALL QUALITY")
+	fmt.Println("═══════════════════════════════════════════")
+	fmt.Println()
+	displayQualityVerdict(report.Score, report.Issues)
+}
+
+// writeRunChart writes the --chart timeline from the run's stored logs
+func (r *Runner) writeRunChart(record *RunRecord) error {
+	if r.chart == "" {
+		return nil
+	}
+	logData, err := LoadRunLogs(record.ID)
+	if err != nil {
+		return fmt.Errorf("logs for run %s unavailable: %w", record.ID, err)
+	}
+	r.callID = record.CallID
+	return r.writeChart(logData)
+}
@@ -0,0 +1,70 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// hookTimeout bounds a single hook command
+const hookTimeout = 60 * time.Second
+
+// runHooks runs each hook command through the shell. AGENT_HOOK and
+// AGENT_CALL_ID are set in the environment and stdin carries payload
+// (empty for pre hooks). Failures are reported but never abort the run.
+func (r *Runner) runHooks(stage string, commands []string, payload []byte) {
+	for _, command := range commands {
+		if strings.TrimSpace(command) == "" {
+			continue
+		}
+		if r.verbose {
+			infoColor.Printf("Running %s hook: %s\n", stage, command)
+		}
+		if err := r.runHook(stage, command, payload); err != nil {
+			warningColor.Printf("⚠️  %s hook failed (%s): %v\n", stage, truncate(command, 60), err)
+		}
+	}
+}
+
+func (r *Runner) runHook(stage, command string, payload []byte) error {
+	ctx, cancel := context.WithTimeout(r.ctx, hookTimeout)
+	defer cancel()
+
+	cmd := remote.LocalShell(ctx, command)
+	cmd.Env = append(os.Environ(), "AGENT_HOOK="+stage, "AGENT_CALL_ID="+r.callID)
+	cmd.Stdin = bytes.NewReader(payload)
+	output, err := selflog.CombinedOutput(cmd)
+	if r.verbose && len(output) > 0 {
+		fmt.Print(string(output))
+	}
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return fmt.Errorf("timed out after %s", hookTimeout)
+		}
+		if msg := strings.TrimSpace(string(output)); msg != "" {
+			return fmt.Errorf("%v: %s", err, truncate(msg, 200))
+		}
+		return err
+	}
+	return nil
+}
+
+// runPostHooks passes the analysis report as JSON to the post hooks
+func (r *Runner) runPostHooks(report *Report) {
+	if len(r.postHooks) == 0 {
+		return
+	}
+	payload, err := json.Marshal(report)
+	if err != nil {
+		warningColor.Printf("⚠️  post hook skipped: %v\n", err)
+		return
+	}
+	r.runHooks("post", r.postHooks, payload)
+}
@@ -0,0 +1,81 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/schedule"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Hours labels of calls in reports and exports
+const (
+	HoursIn    = "in_hours"
+	HoursAfter = "after_hours"
+)
+
+// BusinessHours decides whether a call came in during the business
+// hours of its route
+type BusinessHours struct {
+	base   *schedule.Calendar
+	routes []hoursRoute
+}
+
+type hoursRoute struct {
+	settings.HoursRoute
+	calendar *schedule.Calendar
+}
+
+// NewBusinessHours parses the configured schedules; it returns nil when
+// none is configured. loc is the zone of schedules without a timezone.
+func NewBusinessHours(cfg settings.BusinessHours, loc *time.Location) (*BusinessHours, error) {
+	if !schedule.Configured(cfg.Schedule) && len(cfg.Routes) == 0 {
+		return nil, nil
+	}
+	base, err := schedule.New(cfg.Schedule, loc)
+	if err != nil {
+		return nil, fmt.Errorf("business_hours: %w", err)
+	}
+	b := &BusinessHours{base: base}
+	for _, r := range cfg.Routes {
+		cal, err := schedule.New(schedule.Merge(cfg.Schedule, r.Schedule), loc)
+		if err != nil {
+			return nil, fmt.Errorf("business_hours route %s: %w", r.Name, err)
+		}
+		b.routes = append(b.routes, hoursRoute{HoursRoute: r, calendar: cal})
+	}
+	return b, nil
+}
+
+// calendar returns the schedule of the first route whose DIDs match the
+// dialed number, else whose contexts list the call's context, else the
+// default one
+func (b *BusinessHours) calendar(call Call) *schedule.Calendar {
+	for _, r := range b.routes {
+		for _, did := range r.DIDs {
+			if didMatches(call.Dialed, did) {
+				return r.calendar
+			}
+		}
+	}
+	for _, r := range b.routes {
+		for _, ctx := range r.Contexts {
+			if ctx != "" && (strings.EqualFold(ctx, call.Context) || strings.EqualFold(ctx, call.DialplanContext)) {
+				return r.calendar
+			}
+		}
+	}
+	return b.base
+}
+
+// Label returns HoursIn or HoursAfter for a call, empty when b is nil
+func (b *BusinessHours) Label(call Call) string {
+	if b == nil || call.Timestamp.IsZero() {
+		return ""
+	}
+	if b.calendar(call).InHours(call.Timestamp) {
+		return HoursIn
+	}
+	return HoursAfter
+}
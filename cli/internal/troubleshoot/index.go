@@ -0,0 +1,255 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// CallIndex persists call metadata discovered in the logs so later runs can
+// locate a call's log window without rescanning the full history.
+type CallIndex struct {
+	path       string
+	Calls      map[string]*Call `json:"calls"`
+	LogWindows []LogWindow      `json:"log_windows,omitempty"`
+}
+
+// LogWindow is a period in which a component logged more than usual
+// (e.g. Asterisk debug and SIP traces), so analyses of calls in it know
+// richer logs exist
+type LogWindow struct {
+	Component string    `json:"component"`
+	Level     string    `json:"level"`
+	Start     time.Time `json:"start"`
+	End       time.Time `json:"end"`
+	// Restore holds the commands that revert the change while it is in
+	// effect; it is empty once reverted
+	Restore []string `json:"restore,omitempty"`
+}
+
+// Open reports whether the raised logging has not been reverted yet
+func (w *LogWindow) Open() bool {
+	return len(w.Restore) > 0
+}
+
+// LoadCallIndex reads the call index from disk. A missing or unreadable
+// index yields an empty one; the index is a cache, not a source of truth.
+func LoadCallIndex() *CallIndex {
+	idx := &CallIndex{
+		path:  filepath.Join(settings.Dir(), "calls.json"),
+		Calls: make(map[string]*Call),
+	}
+
+	data, err := os.ReadFile(idx.path)
+	if err != nil {
+		return idx
+	}
+	if err := json.Unmarshal(data, idx); err != nil || idx.Calls == nil {
+		idx.Calls = make(map[string]*Call)
+	}
+	return idx
+}
+
+// Get returns the indexed call with the given ID
+func (idx *CallIndex) Get(id string) (*Call, bool) {
+	call, ok := idx.Calls[id]
+	return call, ok
+}
+
+// Recent returns up to limit indexed calls, newest first
+func (idx *CallIndex) Recent(limit int) []Call {
+	calls := make([]Call, 0, len(idx.Calls))
+	for _, call := range idx.Calls {
+		calls = append(calls, *call)
+	}
+	sort.Slice(calls, func(i, j int) bool {
+		return calls[i].Timestamp.After(calls[j].Timestamp)
+	})
+	if limit > 0 && len(calls) > limit {
+		calls = calls[:limit]
+	}
+	return calls
+}
+
+// BuildIndex scans the logs of the run's window (--since, default the
+// list window) and records every call found in the call index, without
+// analyzing any. It returns how many calls were found.
+func (r *Runner) BuildIndex() (int, error) {
+	calls, err := r.getRecentCalls(math.MaxInt)
+	if err != nil {
+		return 0, r.wrapCtxErr(err)
+	}
+	return len(calls), nil
+}
+
+// Merge records calls, widening the known time window of existing entries
+func (idx *CallIndex) Merge(calls []Call) {
+	for _, call := range calls {
+		existing, ok := idx.Calls[call.ID]
+		if !ok {
+			c := call
+			idx.Calls[call.ID] = &c
+			continue
+		}
+		if !call.Timestamp.IsZero() && (existing.Timestamp.IsZero() || call.Timestamp.Before(existing.Timestamp)) {
+			existing.Timestamp = call.Timestamp
+		}
+		if call.EndTime.After(existing.EndTime) {
+			existing.EndTime = call.EndTime
+		}
+		if call.Duration != "" {
+			existing.Duration = call.Duration
+		}
+		if call.Status != "" {
+			existing.Status = call.Status
+		}
+		if call.Channel != "" {
+			existing.Channel = call.Channel
+		}
+		if call.CallerNumber != "" {
+			existing.CallerNumber = call.CallerNumber
+		}
+		if call.CallerName != "" {
+			existing.CallerName = call.CallerName
+		}
+		if call.Dialed != "" {
+			existing.Dialed = call.Dialed
+		}
+		if call.HangupCause != 0 {
+			existing.HangupCause = call.HangupCause
+		}
+		if call.Context != "" {
+			existing.Context = call.Context
+		}
+		if call.DialplanContext != "" {
+			existing.DialplanContext = call.DialplanContext
+		}
+		if call.Tenant != "" {
+			existing.Tenant = call.Tenant
+		}
+	}
+}
+
+// Prune drops calls that started before the cutoff, and log windows
+// that ended before it and were reverted
+func (idx *CallIndex) Prune(cutoff time.Time) {
+	for id, call := range idx.Calls {
+		if !call.Timestamp.IsZero() && call.Timestamp.Before(cutoff) {
+			delete(idx.Calls, id)
+		}
+	}
+	windows := idx.LogWindows[:0]
+	for _, w := range idx.LogWindows {
+		if w.Open() || !w.End.Before(cutoff) {
+			windows = append(windows, w)
+		}
+	}
+	idx.LogWindows = windows
+}
+
+// AddLogWindow records a log window
+func (idx *CallIndex) AddLogWindow(w LogWindow) {
+	idx.LogWindows = append(idx.LogWindows, w)
+}
+
+// OpenLogWindow returns the window of component that has not been
+// reverted, if any
+func (idx *CallIndex) OpenLogWindow(component string) *LogWindow {
+	for i := range idx.LogWindows {
+		if w := &idx.LogWindows[i]; w.Component == component && w.Open() {
+			return w
+		}
+	}
+	return nil
+}
+
+// LatestLogWindow returns the most recent window of component, if any
+func (idx *CallIndex) LatestLogWindow(component string) *LogWindow {
+	var latest *LogWindow
+	for i := range idx.LogWindows {
+		if w := &idx.LogWindows[i]; w.Component == component && (latest == nil || w.Start.After(latest.Start)) {
+			latest = w
+		}
+	}
+	return latest
+}
+
+// CloseLogWindow marks the window of component that started at start as
+// reverted at end, shortening it when it was due to last longer
+func (idx *CallIndex) CloseLogWindow(component string, start, end time.Time) {
+	for i := range idx.LogWindows {
+		w := &idx.LogWindows[i]
+		if w.Component != component || !w.Start.Equal(start) {
+			continue
+		}
+		if end.Before(w.End) {
+			w.End = end
+		}
+		w.Restore = nil
+	}
+}
+
+// LogWindowsDuring returns the log windows overlapping start-end. An
+// open window counts as lasting until now.
+func (idx *CallIndex) LogWindowsDuring(start, end time.Time) []LogWindow {
+	var windows []LogWindow
+	for _, w := range idx.LogWindows {
+		wEnd := w.End
+		if w.Open() && time.Now().After(wEnd) {
+			wEnd = time.Now()
+		}
+		if !w.Start.After(end) && !wEnd.Before(start) {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// Save writes the index to disk
+func (idx *CallIndex) Save() error {
+	if err := os.MkdirAll(filepath.Dir(idx.path), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(idx, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(idx.path, data, 0644)
+}
+
+// logWindowHints tells where the extra logs of a component's log window
+// are found
+var logWindowHints = map[string]string{
+	"asterisk": "Asterisk's own log (e.g. /var/log/asterisk/full) has debug output and SIP traces for this period",
+	"engine":   "The engine log includes debug-level detail for this period",
+}
+
+// reportLogWindows points out raised logging during the analyzed call
+func (r *Runner) reportLogWindows() {
+	idx := LoadCallIndex()
+	call, ok := idx.Get(r.callID)
+	if !ok || call.Timestamp.IsZero() {
+		return
+	}
+	end := call.EndTime
+	if end.IsZero() {
+		end = call.Timestamp
+	}
+	windows := idx.LogWindowsDuring(call.Timestamp, end)
+	for _, w := range windows {
+		infoColor.Printf("🔎 %s %s logging was on during this call (%s - %s)\n",
+			w.Component, w.Level, formatTimestamp(w.Start, r.loc), formatTimestamp(w.End, r.loc))
+		if hint := logWindowHints[w.Component]; hint != "" {
+			fmt.Printf("   %s\n", hint)
+		}
+	}
+	if len(windows) > 0 {
+		fmt.Println()
+	}
+}
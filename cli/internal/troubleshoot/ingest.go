@@ -0,0 +1,291 @@
+package troubleshoot
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+const (
+	// ingestTrackWindow is how long a call stays in memory after its last
+	// line; later lines of the same call still merge through the index
+	ingestTrackWindow = time.Hour
+
+	// spoolPruneInterval is how often a running ingester drops spool
+	// files past their retention
+	spoolPruneInterval = time.Hour
+)
+
+// SpoolDir returns the directory holding ingested log lines, one
+// subdirectory per source application and one file per day
+func SpoolDir() string {
+	return filepath.Join(settings.Dir(), "spool")
+}
+
+// Ingester stores log lines received outside docker (e.g. syslog) and
+// keeps the call index current as they arrive
+type Ingester struct {
+	mu        sync.Mutex
+	tracker   *callTracker
+	files     map[string]*os.File
+	retention time.Duration
+	lines     int
+
+	spoolRetention time.Duration
+	spoolPruned    time.Time
+}
+
+// NewIngester creates an ingester. Zone-less timestamps in lines are read
+// in logLoc; index entries older than retention are pruned on flush, and
+// spool files older than spoolRetention hourly.
+func NewIngester(logLoc *time.Location, retention, spoolRetention time.Duration) *Ingester {
+	if logLoc == nil {
+		logLoc = time.UTC
+	}
+	if retention <= 0 {
+		retention = settings.DefaultIndexRetention
+	}
+	if spoolRetention <= 0 {
+		spoolRetention = settings.DefaultSpoolRetention
+	}
+	return &Ingester{
+		tracker:        newCallTracker(logLoc, false),
+		files:          make(map[string]*os.File),
+		retention:      retention,
+		spoolRetention: spoolRetention,
+	}
+}
+
+// Ingest records one line from app (container or program name)
+func (in *Ingester) Ingest(app, line string, received time.Time) error {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return nil
+	}
+	app = spoolName(app)
+
+	in.mu.Lock()
+	defer in.mu.Unlock()
+
+	f, err := in.spoolFile(app, received)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(f, line); err != nil {
+		return err
+	}
+	in.tracker.observe(line)
+	in.lines++
+	return nil
+}
+
+// spoolFile returns the open file for app and day, rotating at midnight UTC
+func (in *Ingester) spoolFile(app string, received time.Time) (*os.File, error) {
+	day := received.UTC().Format("2006-01-02")
+	key := app + "/" + day
+	if f, ok := in.files[key]; ok {
+		return f, nil
+	}
+	// Close the previous day's file for this app
+	for k, f := range in.files {
+		if strings.HasPrefix(k, app+"/") {
+			f.Close()
+			delete(in.files, k)
+		}
+	}
+
+	dir := filepath.Join(SpoolDir(), app)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, day+".log"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	in.files[key] = f
+	return f, nil
+}
+
+// Flush merges the calls seen so far into the call index and returns
+// the number of lines ingested since the previous flush
+func (in *Ingester) Flush() (int, error) {
+	in.mu.Lock()
+	calls := in.tracker.result()
+	in.tracker.forget(time.Now().Add(-ingestTrackWindow))
+	lines := in.lines
+	in.lines = 0
+	pruneSpool := time.Since(in.spoolPruned) >= spoolPruneInterval
+	if pruneSpool {
+		in.spoolPruned = time.Now()
+	}
+	in.mu.Unlock()
+
+	if pruneSpool {
+		if _, _, err := PruneSpool(time.Now().Add(-in.spoolRetention)); err != nil {
+			return lines, fmt.Errorf("spool: %w", err)
+		}
+	}
+
+	if len(calls) == 0 {
+		return lines, nil
+	}
+	index := LoadCallIndex()
+	index.Merge(calls)
+	index.Prune(time.Now().Add(-in.retention))
+	return lines, index.Save()
+}
+
+// Writable checks that the spool and the call index directory accept new
+// files, so a full or read-only disk shows before lines are lost
+func (in *Ingester) Writable() error {
+	for _, dir := range []string{SpoolDir(), settings.Dir()} {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		f, err := os.CreateTemp(dir, ".writable-")
+		if err != nil {
+			return err
+		}
+		f.Close()
+		os.Remove(f.Name())
+	}
+	return nil
+}
+
+// Close flushes and closes the spool files
+func (in *Ingester) Close() error {
+	_, err := in.Flush()
+	in.mu.Lock()
+	defer in.mu.Unlock()
+	for k, f := range in.files {
+		f.Close()
+		delete(in.files, k)
+	}
+	return err
+}
+
+// OldSpoolFiles returns the spool files of days that ended before the
+// cutoff and their total size
+func OldSpoolFiles(cutoff time.Time) ([]string, int64, error) {
+	apps, err := os.ReadDir(SpoolDir())
+	if os.IsNotExist(err) {
+		return nil, 0, nil
+	}
+	if err != nil {
+		return nil, 0, err
+	}
+	var old []string
+	var size int64
+	for _, app := range apps {
+		if !app.IsDir() {
+			continue
+		}
+		dir := filepath.Join(SpoolDir(), app.Name())
+		days, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, d := range days {
+			day, err := time.Parse("2006-01-02", strings.TrimSuffix(d.Name(), ".log"))
+			if err != nil || !strings.HasSuffix(d.Name(), ".log") || !day.Add(24*time.Hour).Before(cutoff) {
+				continue
+			}
+			info, err := d.Info()
+			if err != nil {
+				continue
+			}
+			old = append(old, filepath.Join(dir, d.Name()))
+			size += info.Size()
+		}
+	}
+	return old, size, nil
+}
+
+// PruneSpool deletes the spool files of days that ended before the
+// cutoff, and the application directories left empty. It returns how
+// many files it deleted and their size.
+func PruneSpool(cutoff time.Time) (int, int64, error) {
+	old, size, err := OldSpoolFiles(cutoff)
+	if err != nil {
+		return 0, 0, err
+	}
+	dirs := make(map[string]bool)
+	for i, path := range old {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return i, size, err
+		}
+		dirs[filepath.Dir(path)] = true
+	}
+	for dir := range dirs {
+		// Only succeeds once the directory is empty
+		os.Remove(dir)
+	}
+	return len(old), size, nil
+}
+
+// spoolName makes an application name safe to use as a directory
+func spoolName(app string) string {
+	app = strings.TrimSpace(app)
+	if app == "" || app == "-" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			return r
+		}
+		return '_'
+	}, app)
+}
+
+// spoolSource reads lines stored by an Ingester
+type spoolSource struct {
+	dir    string
+	logLoc *time.Location
+}
+
+func (s *spoolSource) Name() string { return "spool" }
+
+func (s *spoolSource) Fetch(ctx context.Context, q LogQuery) ([]byte, error) {
+	end := q.Until
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := q.Since
+	if start.IsZero() {
+		start = end.Add(-24 * time.Hour)
+	}
+
+	var buf strings.Builder
+	dir := filepath.Join(s.dir, spoolName(q.Container))
+	for day := start.UTC().Truncate(24 * time.Hour); !day.After(end); day = day.Add(24 * time.Hour) {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		data, err := os.ReadFile(filepath.Join(dir, day.Format("2006-01-02")+".log"))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if line == "" || (q.CallID != "" && !strings.Contains(line, q.CallID)) {
+				continue
+			}
+			// Lines without a timestamp are kept; callers filter by call
+			if ts, ok := parseLogTimestamp(line, s.logLoc); ok && (ts.Before(start) || ts.After(end)) {
+				continue
+			}
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return []byte(buf.String()), nil
+}
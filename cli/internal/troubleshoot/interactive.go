@@ -10,7 +10,7 @@ import (
 )
 
 // SelectCallInteractive prompts user to select a call from list
-func SelectCallInteractive(calls []Call) (string, error) {
+func SelectCallInteractive(calls []Call, loc *time.Location) (string, error) {
 	if len(calls) == 0 {
 		return "", fmt.Errorf("no calls available")
 	}
@@ -21,7 +21,7 @@ func SelectCallInteractive(calls []Call) (string, error) {
 	
 	for i, call := range calls {
 		age := formatDuration(time.Since(call.Timestamp))
-		fmt.Printf("  %d) %s (%s ago)\n", i+1, call.ID, age)
+		fmt.Printf("  %d) %s - %s (%s ago)\n", i+1, call.ID, formatTimestamp(call.Timestamp, loc), age)
 	}
 	
 	fmt.Println()
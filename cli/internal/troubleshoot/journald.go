@@ -0,0 +1,57 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/systemd"
+)
+
+// journaldSource reads a systemd unit's journal, for bare-metal installs
+// where the engine runs as aava-engine.service instead of a container
+type journaldSource struct {
+	// unit overrides the unit derived from the container name
+	unit string
+}
+
+func (s *journaldSource) Name() string { return "journald" }
+
+func (s *journaldSource) unitFor(container string) string {
+	if s.unit != "" {
+		return s.unit
+	}
+	return systemd.UnitForContainer(container)
+}
+
+func (s *journaldSource) Fetch(ctx context.Context, q LogQuery) ([]byte, error) {
+	args := []string{"--unit", s.unitFor(q.Container), "--output", "cat", "--no-pager", "--quiet"}
+	if !q.Since.IsZero() {
+		args = append(args, "--since", "@"+strconv.FormatInt(q.Since.Unix(), 10))
+	}
+	if !q.Until.IsZero() {
+		args = append(args, "--until", "@"+strconv.FormatInt(q.Until.Unix(), 10))
+	}
+	var stderr bytes.Buffer
+	cmd := remote.Command(ctx, "journalctl", args...)
+	cmd.Stderr = &stderr
+	out, err := selflog.Output(cmd)
+	if err != nil {
+		return nil, fmt.Errorf("journalctl failed: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	if q.CallID == "" {
+		return out, nil
+	}
+	var buf bytes.Buffer
+	for _, line := range strings.Split(string(out), "\n") {
+		if line != "" && strings.Contains(line, q.CallID) {
+			buf.WriteString(line)
+			buf.WriteByte('\n')
+		}
+	}
+	return buf.Bytes(), nil
+}
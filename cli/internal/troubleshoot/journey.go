@@ -0,0 +1,117 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// journeyCallLimit caps how many calls of one caller are analyzed
+const journeyCallLimit = 500
+
+// journeyProblems bounds the findings listed per call
+const journeyProblems = 3
+
+// Journey is every call from one caller in the window, oldest first
+type Journey struct {
+	Caller string        `json:"caller"`
+	Name   string        `json:"caller_name,omitempty"`
+	Calls  []JourneyCall `json:"calls"`
+	Failed int           `json:"failed"`
+}
+
+// JourneyCall is one call of a caller's journey with its outcome
+type JourneyCall struct {
+	CallID          string    `json:"call_id"`
+	Start           time.Time `json:"start"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	Dialed          string    `json:"dialed,omitempty"`
+	Status          string    `json:"status,omitempty"`
+	HangupCause     int       `json:"hangup_cause,omitempty"`
+	// Failed is set for calls with critical findings or a failed status
+	Failed       bool     `json:"failed"`
+	Persona      string   `json:"persona,omitempty"`
+	Intents      []string `json:"intents,omitempty"`
+	QualityScore float64  `json:"quality_score"`
+	// Problems are the call's most severe findings
+	Problems []string `json:"problems,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Notes    []Note   `json:"notes,omitempty"`
+	// Error is set when the call's logs could not be analyzed; the
+	// outcome then comes from the call index alone
+	Error string `json:"error,omitempty"`
+}
+
+// CallerJourney analyzes every call in the window from the caller set in
+// the runner's filter. Progress goes to stderr.
+func (r *Runner) CallerJourney() (*Journey, error) {
+	r.all = true
+	calls, err := r.getRecentCalls(journeyCallLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent calls: %w", r.wrapCtxErr(err))
+	}
+	r.since, r.until = "", ""
+
+	journey := &Journey{Caller: r.filter.From, Calls: []JourneyCall{}}
+	if len(calls) > 0 {
+		fmt.Fprintf(os.Stderr, "Analyzing %d call(s)...\n", len(calls))
+	}
+	for i := len(calls) - 1; i >= 0; i-- {
+		if r.ctx.Err() != nil {
+			return nil, r.wrapCtxErr(r.ctx.Err())
+		}
+		call := calls[i]
+		if call.CallerName != "" && call.CallerName != call.CallerNumber {
+			journey.Name = call.CallerName
+		}
+		if call.CallerNumber != "" {
+			journey.Caller = call.CallerNumber
+		}
+		jc := JourneyCall{
+			CallID:      call.ID,
+			Start:       call.Timestamp,
+			Dialed:      call.Dialed,
+			Status:      call.Status,
+			HangupCause: call.HangupCause,
+			Tags:        call.Tags,
+			Notes:       call.Notes,
+		}
+		if !call.EndTime.IsZero() && call.EndTime.After(call.Timestamp) {
+			jc.DurationSeconds = call.EndTime.Sub(call.Timestamp).Seconds()
+		}
+
+		rc, err := r.analyzeReportCall(call)
+		if err != nil {
+			jc.Error = err.Error()
+			jc.Failed = call.Status == CallFailed
+		} else {
+			jc.DurationSeconds = rc.minutes * 60
+			jc.Failed = Fingerprint(rc.report, &call) != ""
+			jc.Persona = rc.persona
+			jc.QualityScore = rc.report.Score
+			if !(len(rc.intents) == 1 && rc.intents[0] == intentNone) {
+				jc.Intents = rc.intents
+			}
+			jc.Problems = journeyFindings(rc.report.Findings)
+		}
+		if jc.Failed {
+			journey.Failed++
+		}
+		journey.Calls = append(journey.Calls, jc)
+	}
+	return journey, nil
+}
+
+// journeyFindings returns the messages of the most severe findings,
+// critical before warnings
+func journeyFindings(findings []Finding) []string {
+	var problems []string
+	for _, severity := range []string{SeverityCritical, SeverityWarning} {
+		for _, f := range findings {
+			if f.Severity == severity && len(problems) < journeyProblems {
+				problems = append(problems, f.Message)
+			}
+		}
+	}
+	return problems
+}
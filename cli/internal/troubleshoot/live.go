@@ -0,0 +1,274 @@
+package troubleshoot
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+)
+
+// Live event kinds
+const (
+	LiveCaller  = "caller"
+	LiveAgent   = "agent"
+	LiveLatency = "latency"
+	LiveTool    = "tool"
+	LiveError   = "error"
+	LiveEnd     = "end"
+)
+
+// LiveEvent is one conversation event of a call, read from the engine log
+type LiveEvent struct {
+	Time time.Time
+	Kind string
+	Text string
+	// Latency is the turn latency (LiveLatency) or the provider's own
+	// latency for the transcript, when logged
+	Latency time.Duration
+}
+
+var (
+	// consoleFieldPattern matches key=value pairs of the console log format
+	consoleFieldPattern = regexp.MustCompile(`(\w+)=('(?:[^'\\]|\\.)*'|"(?:[^"\\]|\\.)*"|\S+)`)
+	// elevenLabsLatencyPattern matches "[elevenlabs] [<call>] Turn latency: 812.3ms"
+	elevenLabsLatencyPattern = regexp.MustCompile(`(?i)turn latency:\s*([0-9.]+)ms`)
+)
+
+// liveFields returns the fields of a JSON or console-format log line
+func liveFields(ev *LogEvent, line string) (event string, fields map[string]string) {
+	fields = make(map[string]string)
+	if ev.Fields != nil {
+		for k, v := range ev.Fields {
+			switch v := v.(type) {
+			case string:
+				fields[k] = v
+			case float64:
+				fields[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			case bool:
+				fields[k] = strconv.FormatBool(v)
+			}
+		}
+		return ev.Event, fields
+	}
+	// Console format: "<time> [info     ] Event text   key=value ..."
+	rest := line
+	if i := strings.Index(rest, "] "); i >= 0 {
+		rest = rest[i+2:]
+	}
+	loc := consoleFieldPattern.FindStringSubmatchIndex(rest)
+	if loc == nil {
+		return strings.TrimSpace(rest), fields
+	}
+	event = strings.TrimSpace(rest[:loc[0]])
+	for _, m := range consoleFieldPattern.FindAllStringSubmatch(rest[loc[0]:], -1) {
+		value := m[2]
+		if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') {
+			if unquoted, err := strconv.Unquote(`"` + strings.Replace(value[1:len(value)-1], `"`, `\"`, -1) + `"`); err == nil {
+				value = unquoted
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+		fields[m[1]] = value
+	}
+	return event, fields
+}
+
+// firstField returns the first non-empty of keys
+func firstField(fields map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := strings.TrimSpace(fields[k]); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func fieldLatency(fields map[string]string) time.Duration {
+	ms, err := strconv.ParseFloat(fields["latency_ms"], 64)
+	if err != nil || ms <= 0 {
+		return 0
+	}
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+// ClassifyLiveLine turns an engine log line into a conversation event, or
+// nil when the line is not one. The providers log speech under different
+// events; the ones below cover the monolithic providers and pipelines.
+func ClassifyLiveLine(raw string, logLoc *time.Location) *LiveEvent {
+	line := ansiStripPattern.ReplaceAllString(raw, "")
+	ev := parseLogEvent(line)
+	event, fields := liveFields(ev, line)
+	lower := strings.ToLower(event)
+	text := firstField(fields, "text", "transcript", "text_preview", "transcript_preview", "preview")
+
+	le := &LiveEvent{}
+	le.Time, _ = parseLogTimestamp(line, logLoc)
+	if le.Time.IsZero() {
+		le.Time = time.Now()
+	}
+	byRole := func() string {
+		switch strings.ToLower(fields["role"]) {
+		case "user":
+			return LiveCaller
+		case "assistant", "agent":
+			return LiveAgent
+		}
+		return ""
+	}
+
+	switch {
+	case strings.Contains(lower, "tracked conversation message"),
+		strings.Contains(lower, "conversation text"):
+		le.Kind = byRole()
+	case strings.Contains(lower, "final user transcription"),
+		strings.Contains(lower, "added user transcript"),
+		strings.Contains(lower, "stt transcript received"),
+		lower == "transcript received":
+		le.Kind = LiveCaller
+		le.Latency = fieldLatency(fields)
+	case strings.Contains(lower, "streaming transcript received"):
+		if fields["is_final"] == "true" || fields["is_final"] == "True" {
+			le.Kind = LiveCaller
+		}
+	case strings.Contains(lower, "final ai transcription"),
+		strings.Contains(lower, "added agent transcript"),
+		strings.Contains(lower, "llm response"),
+		strings.Contains(lower, "llm continuation response"):
+		le.Kind = LiveAgent
+	case strings.Contains(lower, "turn latency recorded"):
+		le.Kind = LiveLatency
+		le.Latency = fieldLatency(fields)
+		text = ""
+	case strings.Contains(lower, "turn latency:"):
+		if m := elevenLabsLatencyPattern.FindStringSubmatch(event); len(m) > 1 {
+			if ms, err := strconv.ParseFloat(m[1], 64); err == nil {
+				le.Kind = LiveLatency
+				le.Latency = time.Duration(ms * float64(time.Millisecond))
+			}
+		}
+		text = ""
+	case strings.Contains(lower, "tool") && (strings.Contains(lower, "execut") || strings.Contains(lower, "parsed")):
+		le.Kind = LiveTool
+		text = firstField(fields, "tool", "tool_name", "name", "tools", "function_name")
+		if text == "" {
+			text = event
+		}
+	case strings.Contains(lower, "stasis ended"),
+		strings.Contains(lower, "channeldestroyed"),
+		strings.Contains(lower, "call cleanup completed"):
+		le.Kind = LiveEnd
+		text = event
+	case strings.EqualFold(ev.Level, "error") || strings.Contains(line, "[error"):
+		le.Kind = LiveError
+		text = event
+	}
+	if le.Kind == "" || (text == "" && le.Kind != LiveLatency) || (le.Kind == LiveLatency && le.Latency == 0) {
+		return nil
+	}
+	le.Text = strings.TrimSuffix(text, "...")
+	return le
+}
+
+// lineCallID returns the call a log line belongs to, if it names one
+func lineCallID(line string) string {
+	for _, pattern := range callIDPatterns {
+		if m := pattern.FindStringSubmatch(line); len(m) > 1 {
+			return m[1]
+		}
+	}
+	return ""
+}
+
+// WatchCall follows the engine container log and calls fn for each
+// conversation event of callID until ctx is done, the call ends or the
+// log stream stops. Lines since the call started are replayed first.
+func WatchCall(ctx context.Context, container, callID string, logLoc *time.Location, fn func(*LiveEvent)) error {
+	since := time.Now().Add(-time.Minute)
+	if start, ok := callIDTime(callID); ok {
+		since = start.Add(-windowPadding)
+	}
+	classify := callEventClassifier(callID, logLoc)
+	return FollowLogs(ctx, container, since, func(line string) bool {
+		le := classify(line)
+		if le == nil {
+			return true
+		}
+		fn(le)
+		return le.Kind != LiveEnd
+	})
+}
+
+// CallTranscript returns the conversation events of a past call from the
+// engine container log
+func CallTranscript(ctx context.Context, container, callID string, logLoc *time.Location) ([]*LiveEvent, error) {
+	start, ok := callIDTime(callID)
+	if !ok {
+		call, found := LoadCallIndex().Get(callID)
+		if !found {
+			return nil, fmt.Errorf("unknown start time for call %s", callID)
+		}
+		start = call.Timestamp
+	}
+	client, err := docker.Default()
+	if err != nil {
+		return nil, err
+	}
+	data, err := client.LogsBytes(ctx, container, docker.LogsOptions{Since: start.Add(-windowPadding)})
+	if err != nil {
+		return nil, err
+	}
+	classify := callEventClassifier(callID, logLoc)
+	var events []*LiveEvent
+	for _, line := range strings.Split(string(data), "\n") {
+		le := classify(ansiStripPattern.ReplaceAllString(line, ""))
+		if le == nil {
+			continue
+		}
+		events = append(events, le)
+		if le.Kind == LiveEnd {
+			break
+		}
+	}
+	return events, nil
+}
+
+// callEventClassifier returns a function classifying the log lines of
+// callID, skipping other calls' lines and repeated utterances
+func callEventClassifier(callID string, logLoc *time.Location) func(line string) *LiveEvent {
+	var last *LiveEvent
+	return func(line string) *LiveEvent {
+		// Some lines name the call only in the message or a JSON channel_id
+		if id := lineCallID(line); id != callID && !(id == "" && strings.Contains(line, callID)) {
+			return nil
+		}
+		le := ClassifyLiveLine(line, logLoc)
+		if le == nil {
+			return nil
+		}
+		// Providers often log the same utterance under two events
+		if last != nil && last.Kind == le.Kind && le.Text != "" &&
+			(strings.HasPrefix(last.Text, le.Text) || strings.HasPrefix(le.Text, last.Text)) {
+			return nil
+		}
+		last = le
+		return le
+	}
+}
+
+// Severity grades a turn latency against the latency analyzer's
+// thresholds; it is empty for acceptable turns
+func (e *LiveEvent) Severity() string {
+	ms := float64(e.Latency) / float64(time.Millisecond)
+	switch {
+	case ms >= latencyCriticalMs:
+		return SeverityCritical
+	case ms >= latencyWarnMs:
+		return SeverityWarning
+	}
+	return ""
+}
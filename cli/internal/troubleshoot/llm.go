@@ -2,96 +2,186 @@ package troubleshoot
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"strconv"
 	"strings"
 	"time"
+
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
 )
 
-// LLMAnalyzer performs AI-powered diagnosis
-type LLMAnalyzer struct {
-	provider string
-	apiKey   string
-	model    string
+// LLM providers for analysis. Ollama and llama.cpp run on premises, so
+// call logs never leave the deployment.
+const (
+	LLMOpenAI    = "openai"
+	LLMAnthropic = "anthropic"
+	LLMOllama    = "ollama"
+	LLMLlamaCpp  = "llamacpp"
+)
+
+// LLMSettings selects the model LLM analysis runs on
+type LLMSettings struct {
+	Provider string
+	BaseURL  string
+	Model    string
+	// Context is the model's context window in tokens; prompts that do
+	// not fit are summarized in chunks first
+	Context int
 }
 
-// NewLLMAnalyzer creates an LLM analyzer
-func NewLLMAnalyzer() (*LLMAnalyzer, error) {
-	// Check for LLM configuration in environment
-	provider := os.Getenv("TROUBLESHOOT_LLM_PROVIDER")
-	if provider == "" {
-		// Try to detect from available keys
+// Local reports whether the model runs on premises
+func (s LLMSettings) Local() bool {
+	return s.Provider == LLMOllama || s.Provider == LLMLlamaCpp
+}
+
+// LoadLLMSettings reads TROUBLESHOOT_LLM_PROVIDER (else the first
+// provider with an API key), TROUBLESHOOT_LLM_BASE_URL,
+// TROUBLESHOOT_LLM_MODEL and TROUBLESHOOT_LLM_CONTEXT. Provider is ""
+// when none is configured.
+func LoadLLMSettings() (LLMSettings, error) {
+	s := LLMSettings{Provider: strings.ToLower(os.Getenv("TROUBLESHOOT_LLM_PROVIDER"))}
+	if s.Provider == "" {
 		if os.Getenv("OPENAI_API_KEY") != "" {
-			provider = "openai"
+			s.Provider = LLMOpenAI
 		} else if os.Getenv("ANTHROPIC_API_KEY") != "" {
-			provider = "anthropic"
+			s.Provider = LLMAnthropic
 		} else {
-			return nil, fmt.Errorf("no LLM provider configured")
+			return s, nil
 		}
 	}
 
-	var apiKey, model string
-	switch provider {
-	case "openai":
+	switch s.Provider {
+	case LLMOpenAI:
+		s.BaseURL, s.Model, s.Context = "https://api.openai.com/v1", "gpt-4o-mini", 128000 // Fast and cost-effective
+	case LLMAnthropic:
+		s.BaseURL, s.Model, s.Context = "https://api.anthropic.com/v1", "claude-3-haiku-20240307", 200000 // Fast and cost-effective
+	case LLMOllama:
+		s.BaseURL, s.Model, s.Context = "http://localhost:11434", "llama3.2", 4096
+	case LLMLlamaCpp, "llama.cpp", "llama-cpp":
+		// llama-server answers with whichever model it loaded
+		s.Provider = LLMLlamaCpp
+		s.BaseURL, s.Context = "http://localhost:8080", 4096
+	default:
+		return s, fmt.Errorf("unsupported provider: %s (use openai, anthropic, ollama or llamacpp)", s.Provider)
+	}
+	if v := os.Getenv("TROUBLESHOOT_LLM_BASE_URL"); v != "" {
+		s.BaseURL = strings.TrimRight(v, "/")
+	}
+	if v := os.Getenv("TROUBLESHOOT_LLM_MODEL"); v != "" {
+		s.Model = v
+	}
+	if v := os.Getenv("TROUBLESHOOT_LLM_CONTEXT"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			return s, fmt.Errorf("invalid TROUBLESHOOT_LLM_CONTEXT %q (tokens)", v)
+		}
+		s.Context = n
+	}
+	return s, nil
+}
+
+// LLMAnalyzer performs AI-powered diagnosis
+type LLMAnalyzer struct {
+	settings LLMSettings
+	apiKey   string
+}
+
+// NewLLMAnalyzer creates an LLM analyzer
+func NewLLMAnalyzer() (*LLMAnalyzer, error) {
+	settings, err := LoadLLMSettings()
+	if err != nil {
+		return nil, err
+	}
+	var apiKey string
+	switch settings.Provider {
+	case "":
+		return nil, fmt.Errorf("no LLM provider configured")
+	case LLMOpenAI:
 		apiKey = os.Getenv("OPENAI_API_KEY")
-		model = "gpt-4o-mini" // Fast and cost-effective
-	case "anthropic":
+	case LLMAnthropic:
 		apiKey = os.Getenv("ANTHROPIC_API_KEY")
-		model = "claude-3-haiku-20240307" // Fast and cost-effective
 	default:
-		return nil, fmt.Errorf("unsupported provider: %s", provider)
+		// Local servers take an optional key
+		apiKey = os.Getenv("TROUBLESHOOT_LLM_API_KEY")
 	}
 
-	if apiKey == "" {
-		return nil, fmt.Errorf("no API key found for provider: %s", provider)
+	if apiKey == "" && !settings.Local() {
+		return nil, fmt.Errorf("no API key found for provider: %s", settings.Provider)
 	}
 
 	return &LLMAnalyzer{
-		provider: provider,
+		settings: settings,
 		apiKey:   apiKey,
-		model:    model,
 	}, nil
 }
 
-// AnalyzeWithLLM performs AI-powered analysis
-func (llm *LLMAnalyzer) AnalyzeWithLLM(analysis *Analysis, logData string) (*LLMDiagnosis, error) {
+// AnalyzeWithLLM performs AI-powered analysis. A prompt too large for
+// the model's context window is condensed first (see condense).
+func (llm *LLMAnalyzer) AnalyzeWithLLM(ctx context.Context, analysis *Analysis, logData string) (*LLMDiagnosis, error) {
 	prompt := llm.buildPrompt(analysis, logData)
-	
-	var response string
-	var err error
+	text := prompt.String()
 
-	switch llm.provider {
-	case "openai":
-		response, err = llm.callOpenAI(prompt)
-	case "anthropic":
-		response, err = llm.callAnthropic(prompt)
-	default:
-		return nil, fmt.Errorf("unsupported provider: %s", llm.provider)
+	calls := 0
+	if estimateTokens(text) > llm.promptBudget() {
+		var err error
+		text, calls, err = llm.condense(ctx, prompt)
+		if err != nil {
+			return nil, err
+		}
 	}
 
+	response, err := llm.complete(ctx, text, diagnosisTokens)
 	if err != nil {
 		return nil, err
 	}
 
 	return &LLMDiagnosis{
-		Provider: llm.provider,
-		Model:    llm.model,
-		Analysis: response,
+		Provider:     llm.settings.Provider,
+		Model:        llm.model(),
+		Analysis:     response,
+		SummaryCalls: calls,
 	}, nil
 }
 
+// model names the model for reports
+func (llm *LLMAnalyzer) model() string {
+	if llm.settings.Model == "" {
+		return "default"
+	}
+	return llm.settings.Model
+}
+
+// complete sends one prompt to the provider
+func (llm *LLMAnalyzer) complete(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	switch llm.settings.Provider {
+	case LLMOpenAI:
+		return llm.callOpenAI(ctx, llm.settings.BaseURL+"/chat/completions", prompt, maxTokens)
+	case LLMLlamaCpp:
+		return llm.callOpenAI(ctx, llm.settings.BaseURL+"/v1/chat/completions", prompt, maxTokens)
+	case LLMAnthropic:
+		return llm.callAnthropic(ctx, prompt, maxTokens)
+	case LLMOllama:
+		return llm.callOllama(ctx, prompt, maxTokens)
+	default:
+		return "", fmt.Errorf("unsupported provider: %s", llm.settings.Provider)
+	}
+}
+
 // buildPrompt constructs the LLM prompt
-func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
-	var prompt strings.Builder
+func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) *promptBuilder {
+	var prompt promptBuilder
 	
 	prompt.WriteString("You are an expert in diagnosing Asterisk AI voice agent issues. ")
 	prompt.WriteString("Analyze the following call logs and provide a concise diagnosis.\n\n")
 	
 	prompt.WriteString("Call ID: " + analysis.CallID + "\n\n")
 	
+	prompt.section()
 	// Pipeline status
 	prompt.WriteString("Pipeline Status:\n")
 	prompt.WriteString(fmt.Sprintf("- AudioSocket: %v\n", analysis.HasAudioSocket))
@@ -99,6 +189,7 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 	prompt.WriteString(fmt.Sprintf("- Playback: %v\n", analysis.HasPlayback))
 	prompt.WriteString("\n")
 	
+	prompt.section()
 	// Issues found
 	if len(analysis.Errors) > 0 {
 		prompt.WriteString(fmt.Sprintf("Errors found: %d\n", len(analysis.Errors)))
@@ -107,11 +198,12 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 			count = len(analysis.Errors)
 		}
 		for i := 0; i < count; i++ {
-			prompt.WriteString(fmt.Sprintf("- %s\n", truncate(analysis.Errors[i], 200)))
+			prompt.WriteString(fmt.Sprintf("- %s\n", truncate(RedactSecrets(analysis.Errors[i]), 200)))
 		}
 		prompt.WriteString("\n")
 	}
 	
+	prompt.section()
 	if len(analysis.AudioIssues) > 0 {
 		prompt.WriteString("Audio Issues:\n")
 		for _, issue := range analysis.AudioIssues {
@@ -120,16 +212,31 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 		prompt.WriteString("\n")
 	}
 	
+	prompt.section()
+	if len(analysis.Findings) > 0 {
+		prompt.WriteString("Analyzer Findings:\n")
+		for _, f := range analysis.Findings {
+			prompt.WriteString(fmt.Sprintf("- [%s/%s] %s\n", f.Analyzer, f.Severity, f.Message))
+			if f.Fix != "" {
+				prompt.WriteString(fmt.Sprintf("  Known fix: %s\n", f.Fix))
+			}
+		}
+		prompt.WriteString("\n")
+	}
+	
+	prompt.section()
 	// Symptom if specified
 	if analysis.Symptom != "" {
 		prompt.WriteString(fmt.Sprintf("Reported Symptom: %s\n\n", analysis.Symptom))
 	}
 	
+	prompt.section()
 	// Extracted metrics (CRITICAL for diagnosis)
 	if analysis.Metrics != nil {
 		prompt.WriteString(analysis.Metrics.FormatForLLM())
 	}
 	
+	prompt.section()
 	// Golden baseline comparison (PROVIDES EXACT FIXES)
 	if analysis.BaselineComparison != nil {
 		prompt.WriteString(analysis.BaselineComparison.FormatForLLM())
@@ -137,6 +244,7 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 		prompt.WriteString("These are VALIDATED production values that are known to work.\n\n")
 	}
 	
+	prompt.section()
 	// Format/Sampling alignment (CRITICAL FOR AUDIO QUALITY)
 	if analysis.Metrics != nil && analysis.Metrics.FormatAlignment != nil {
 		prompt.WriteString(analysis.Metrics.FormatAlignment.FormatForLLM())
@@ -144,6 +252,7 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 		prompt.WriteString("Golden baseline: audiosocket.format=slin, provider transcodes as needed.\n\n")
 	}
 	
+	prompt.section()
 	// Sample logs (truncated)
 	prompt.WriteString("Sample Log Lines:\n")
 	lines := strings.Split(logData, "\n")
@@ -153,11 +262,18 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 	}
 	for i := 0; i < count; i++ {
 		if lines[i] != "" {
-			prompt.WriteString(truncate(lines[i], 200) + "\n")
+			prompt.WriteString(truncate(RedactSecrets(lines[i]), 200) + "\n")
 		}
 	}
 	prompt.WriteString("\n")
 	
+	prompt.section()
+	// Operator verdicts on similar calls (few-shot examples)
+	if examples := similarFeedback(LoadFeedback(), analysis, feedbackExamples); len(examples) > 0 {
+		prompt.WriteString(formatFeedbackForLLM(examples))
+	}
+	
+	prompt.section()
 	prompt.WriteString("Please provide:\n")
 	prompt.WriteString("1. Root Cause: Identify the root cause based on golden baseline deviations\n")
 	prompt.WriteString("   - Prioritize CRITICAL severity deviations first\n")
@@ -184,42 +300,49 @@ func (llm *LLMAnalyzer) buildPrompt(analysis *Analysis, logData string) string {
 	prompt.WriteString("Do NOT suggest generic fixes. Use the concrete values provided.\n")
 	prompt.WriteString("\nKeep your response concise and actionable (under 400 words).")
 	
-	return prompt.String()
+	return &prompt
 }
 
-// callOpenAI makes OpenAI API request
-func (llm *LLMAnalyzer) callOpenAI(prompt string) (string, error) {
-	url := "https://api.openai.com/v1/chat/completions"
+// callOpenAI makes an OpenAI chat completions request, also used for
+// llama.cpp's OpenAI-compatible server
+func (llm *LLMAnalyzer) callOpenAI(ctx context.Context, url, prompt string, maxTokens int) (string, error) {
+	name := "OpenAI"
+	if llm.settings.Local() {
+		name = "llama.cpp"
+	}
 	
 	requestBody := map[string]interface{}{
-		"model": llm.model,
 		"messages": []map[string]string{
 			{
 				"role":    "user",
 				"content": prompt,
 			},
 		},
-		"max_tokens":  800,
+		"max_tokens":  maxTokens,
 		"temperature": 0.3,
 	}
+	if llm.settings.Model != "" {
+		requestBody["model"] = llm.settings.Model
+	}
 	
 	jsonData, err := json.Marshal(requestBody)
 	if err != nil {
 		return "", err
 	}
 	
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", err
 	}
 	
 	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+llm.apiKey)
+	if llm.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+llm.apiKey)
+	}
 	
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := llm.httpClient().Do(req)
 	if err != nil {
-		return "", fmt.Errorf("OpenAI request failed: %w", err)
+		return "", fmt.Errorf("%s request failed: %w", name, err)
 	}
 	defer resp.Body.Close()
 	
@@ -229,7 +352,7 @@ func (llm *LLMAnalyzer) callOpenAI(prompt string) (string, error) {
 	}
 	
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("OpenAI API error %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("%s API error %d: %s", name, resp.StatusCode, string(body))
 	}
 	
 	var result map[string]interface{}
@@ -239,7 +362,7 @@ func (llm *LLMAnalyzer) callOpenAI(prompt string) (string, error) {
 	
 	choices, ok := result["choices"].([]interface{})
 	if !ok || len(choices) == 0 {
-		return "", fmt.Errorf("no response from OpenAI")
+		return "", fmt.Errorf("no response from %s", name)
 	}
 	
 	message, ok := choices[0].(map[string]interface{})["message"].(map[string]interface{})
@@ -256,18 +379,18 @@ func (llm *LLMAnalyzer) callOpenAI(prompt string) (string, error) {
 }
 
 // callAnthropic makes Anthropic API request
-func (llm *LLMAnalyzer) callAnthropic(prompt string) (string, error) {
-	url := "https://api.anthropic.com/v1/messages"
+func (llm *LLMAnalyzer) callAnthropic(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	url := llm.settings.BaseURL + "/messages"
 	
 	requestBody := map[string]interface{}{
-		"model": llm.model,
+		"model": llm.settings.Model,
 		"messages": []map[string]string{
 			{
 				"role":    "user",
 				"content": prompt,
 			},
 		},
-		"max_tokens": 800,
+		"max_tokens": maxTokens,
 	}
 	
 	jsonData, err := json.Marshal(requestBody)
@@ -275,7 +398,7 @@ func (llm *LLMAnalyzer) callAnthropic(prompt string) (string, error) {
 		return "", err
 	}
 	
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return "", err
 	}
@@ -284,8 +407,7 @@ func (llm *LLMAnalyzer) callAnthropic(prompt string) (string, error) {
 	req.Header.Set("x-api-key", llm.apiKey)
 	req.Header.Set("anthropic-version", "2023-06-01")
 	
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+	resp, err := llm.httpClient().Do(req)
 	if err != nil {
 		return "", fmt.Errorf("Anthropic request failed: %w", err)
 	}
@@ -318,9 +440,78 @@ func (llm *LLMAnalyzer) callAnthropic(prompt string) (string, error) {
 	return text, nil
 }
 
-// LLMDiagnosis holds LLM analysis results
-type LLMDiagnosis struct {
-	Provider string
-	Model    string
-	Analysis string
+// callOllama makes an Ollama chat request, sizing the context window
+// (num_ctx) to the configured one
+func (llm *LLMAnalyzer) callOllama(ctx context.Context, prompt string, maxTokens int) (string, error) {
+	requestBody := map[string]interface{}{
+		"model": llm.settings.Model,
+		"messages": []map[string]string{
+			{"role": "user", "content": prompt},
+		},
+		"stream": false,
+		"options": map[string]interface{}{
+			"num_ctx":     llm.settings.Context,
+			"num_predict": maxTokens,
+			"temperature": 0.3,
+		},
+	}
+	jsonData, err := json.Marshal(requestBody)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", llm.settings.BaseURL+"/api/chat", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if llm.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+llm.apiKey)
+	}
+
+	resp, err := llm.httpClient().Do(req)
+	if err != nil {
+		return "", fmt.Errorf("Ollama request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("Ollama API error %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Error string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &result); err != nil {
+		return "", err
+	}
+	if result.Error != "" {
+		return "", fmt.Errorf("Ollama: %s", result.Error)
+	}
+	if result.Message.Content == "" {
+		return "", fmt.Errorf("no content in response")
+	}
+	return result.Message.Content, nil
 }
+
+// llmHTTPClient is shared so repeated analyses in one process reuse
+// provider connections. Local models on CPU take minutes, not seconds.
+var (
+	llmHTTPClient      = &http.Client{Timeout: 30 * time.Second}
+	localLLMHTTPClient = &http.Client{Timeout: 5 * time.Minute}
+)
+
+func (llm *LLMAnalyzer) httpClient() *http.Client {
+	if llm.settings.Local() {
+		return localLLMHTTPClient
+	}
+	return llmHTTPClient
+}
+
+// LLMDiagnosis holds LLM analysis results
+type LLMDiagnosis = results.Diagnosis
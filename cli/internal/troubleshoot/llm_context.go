@@ -0,0 +1,161 @@
+package troubleshoot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// Response sizes: the diagnosis, and each summary of a chunk of evidence
+const (
+	diagnosisTokens = 800
+	summaryTokens   = 200
+)
+
+// condenseRounds bounds how often summaries are summarized again
+const condenseRounds = 3
+
+// promptBuilder collects the prompt in sections: the preamble, one
+// section per block of evidence, and the closing instructions
+type promptBuilder struct {
+	sections []string
+	current  strings.Builder
+}
+
+func (p *promptBuilder) WriteString(s string) {
+	p.current.WriteString(s)
+}
+
+// section ends the current section
+func (p *promptBuilder) section() {
+	p.sections = append(p.sections, p.current.String())
+	p.current.Reset()
+}
+
+func (p *promptBuilder) parts() []string {
+	return append(append([]string{}, p.sections...), p.current.String())
+}
+
+func (p *promptBuilder) String() string {
+	return strings.Join(p.parts(), "")
+}
+
+// preamble, evidence and instructions split the prompt
+func (p *promptBuilder) split() (string, []string, string) {
+	parts := p.parts()
+	var evidence []string
+	for _, s := range parts[1 : len(parts)-1] {
+		if strings.TrimSpace(s) != "" {
+			evidence = append(evidence, s)
+		}
+	}
+	return parts[0], evidence, parts[len(parts)-1]
+}
+
+// estimateTokens approximates a token count: about four characters per
+// token for English text and logs
+func estimateTokens(s string) int {
+	return (len(s) + 3) / 4
+}
+
+// promptBudget is the prompt size that leaves room for the diagnosis in
+// the model's context window
+func (llm *LLMAnalyzer) promptBudget() int {
+	return llm.settings.Context - diagnosisTokens
+}
+
+// summaryInstruction asks for the facts in one chunk of evidence
+func summaryInstruction(part, total int) string {
+	return fmt.Sprintf("\nThis is part %d of %d of the evidence for this call. "+
+		"Summarize only the facts that matter for diagnosing it (errors, metrics and their values, "+
+		"deviations from the baseline, config keys) in under 120 words. Do not diagnose yet.\n", part, total)
+}
+
+// condense fits a prompt into a small context window, map-reduce style:
+// the evidence is packed into chunks that fit, each chunk is summarized,
+// and the diagnosis is asked for from the summaries. Summaries that still
+// do not fit are summarized again. It returns the final prompt and the
+// number of summary requests made.
+func (llm *LLMAnalyzer) condense(ctx context.Context, prompt *promptBuilder) (string, int, error) {
+	preamble, evidence, instructions := prompt.split()
+	budget := llm.promptBudget()
+	fixed := estimateTokens(preamble) + estimateTokens(instructions)
+	if budget-fixed < summaryTokens {
+		return "", 0, fmt.Errorf("context window of %d tokens is too small for analysis (need at least %d; set TROUBLESHOOT_LLM_CONTEXT)",
+			llm.settings.Context, fixed+summaryTokens+diagnosisTokens)
+	}
+	// Each summary request carries the preamble, one chunk and the
+	// summary instruction, and must leave room for the summary
+	chunkBudget := llm.settings.Context - summaryTokens - estimateTokens(preamble) - estimateTokens(summaryInstruction(99, 99))
+
+	calls := 0
+	for round := 0; round < condenseRounds; round++ {
+		notes := strings.Join(evidence, "")
+		if estimateTokens(notes)+fixed <= budget {
+			return preamble + notes + "\n" + instructions, calls, nil
+		}
+		chunks := packChunks(evidence, chunkBudget)
+		summaries := make([]string, 0, len(chunks))
+		for i, chunk := range chunks {
+			summary, err := llm.complete(ctx, preamble+chunk+summaryInstruction(i+1, len(chunks)), summaryTokens)
+			calls++
+			if err != nil {
+				return "", calls, fmt.Errorf("summarizing evidence part %d/%d: %w", i+1, len(chunks), err)
+			}
+			summaries = append(summaries, fmt.Sprintf("Evidence summary %d/%d:\n%s\n\n", i+1, len(chunks), strings.TrimSpace(summary)))
+		}
+		evidence = summaries
+	}
+
+	// Still too large: keep what fits
+	notes := truncateTokens(strings.Join(evidence, ""), budget-fixed)
+	return preamble + notes + "\n" + instructions, calls, nil
+}
+
+// packChunks groups evidence blocks into chunks of at most budget
+// tokens. Blocks stay whole where they fit; larger ones are split on line
+// boundaries.
+func packChunks(blocks []string, budget int) []string {
+	var chunks []string
+	var cur strings.Builder
+	flush := func() {
+		if cur.Len() > 0 {
+			chunks = append(chunks, cur.String())
+			cur.Reset()
+		}
+	}
+	fits := func(s string) bool {
+		return estimateTokens(cur.String()+s) <= budget
+	}
+	for _, b := range blocks {
+		if fits(b) {
+			cur.WriteString(b)
+			continue
+		}
+		if estimateTokens(b) <= budget {
+			flush()
+			cur.WriteString(b)
+			continue
+		}
+		for _, line := range strings.SplitAfter(b, "\n") {
+			line = truncateTokens(line, budget)
+			if !fits(line) {
+				flush()
+			}
+			cur.WriteString(line)
+		}
+	}
+	flush()
+	return chunks
+}
+
+// truncateTokens cuts s to about n tokens
+func truncateTokens(s string, n int) string {
+	if limit := n * 4; len(s) > limit {
+		if limit < 0 {
+			limit = 0
+		}
+		return s[:limit] + "\n"
+	}
+	return s
+}
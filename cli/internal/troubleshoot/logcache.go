@@ -0,0 +1,148 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+)
+
+const (
+	// logCacheTTL is how long an open-ended fetch ("until now") is reused
+	logCacheTTL = 2 * time.Minute
+
+	// logCacheEntries bounds the number of cached docker logs outputs
+	logCacheEntries = 4
+)
+
+// logCacheEntry is one docker logs output and the window it covers. The
+// output keeps docker's timestamp on each line so a narrower window can
+// be cut out of it.
+type logCacheEntry struct {
+	container string
+	since     time.Time
+	end       time.Time
+	openEnded bool
+	fetched   time.Time
+	output    []byte
+}
+
+// window returns the lines of the entry received in [since, end), without
+// their docker timestamps. A zero since or end leaves that side open.
+func (e *logCacheEntry) window(since, end time.Time) []byte {
+	var out bytes.Buffer
+	for rest := e.output; len(rest) > 0; {
+		line := rest
+		if i := bytes.IndexByte(rest, '\n'); i >= 0 {
+			line, rest = rest[:i+1], rest[i+1:]
+		} else {
+			rest = nil
+		}
+		sp := bytes.IndexByte(line, ' ')
+		if sp < 0 {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339Nano, string(line[:sp]))
+		if err != nil {
+			continue
+		}
+		if (!since.IsZero() && t.Before(since)) || (!end.IsZero() && !t.Before(end)) {
+			continue
+		}
+		out.Write(line[sp+1:])
+	}
+	return out.Bytes()
+}
+
+// covers reports whether the entry holds every line of the requested window
+func (e *logCacheEntry) covers(container string, since, end time.Time, openEnded bool, now time.Time) bool {
+	if e.container != container || e.since.After(since) {
+		return false
+	}
+	if openEnded {
+		return e.openEnded && now.Sub(e.fetched) < logCacheTTL
+	}
+	return !end.After(e.end)
+}
+
+var logCache struct {
+	sync.Mutex
+	enabled bool
+	entries []*logCacheEntry
+}
+
+// EnableLogCache keeps docker logs output in memory so repeated commands in
+// one process (agent shell) reuse it instead of re-reading the container.
+// A cached fetch is cut down to the requested window before it is returned.
+func EnableLogCache() {
+	logCache.Lock()
+	logCache.enabled = true
+	logCache.Unlock()
+}
+
+// ClearLogCache drops all cached log output
+func ClearLogCache() {
+	logCache.Lock()
+	logCache.entries = nil
+	logCache.Unlock()
+}
+
+// dockerLogs returns a container's logs for the window, from the cache
+// when enabled and a cached fetch covers it
+func (r *Runner) dockerLogs(container, since, until string) ([]byte, error) {
+	now := time.Now()
+	start, _ := time.Parse(time.RFC3339, since)
+	end, endErr := time.Parse(time.RFC3339, until)
+	openEnded := until == "" || endErr != nil
+	if openEnded {
+		end = now
+	}
+	// The cut-off is the requested end, or nothing for an open-ended read
+	cutoff := end
+	if openEnded {
+		cutoff = time.Time{}
+	}
+
+	logCache.Lock()
+	enabled := logCache.enabled
+	if enabled {
+		for _, e := range logCache.entries {
+			if e.covers(container, start, end, openEnded, now) {
+				logCache.Unlock()
+				if r.verbose {
+					infoColor.Println("Using cached logs")
+				}
+				return e.window(start, cutoff), nil
+			}
+		}
+	}
+	logCache.Unlock()
+
+	client, err := docker.Default()
+	if err != nil {
+		return nil, err
+	}
+	opts := dockerLogsOptions(since, until)
+	opts.Timestamps = enabled
+	output, err := client.LogsBytes(r.ctx, container, opts)
+	if err != nil || !enabled {
+		return output, err
+	}
+	e := &logCacheEntry{
+		container: container,
+		since:     start,
+		end:       end,
+		openEnded: openEnded,
+		fetched:   now,
+		output:    output,
+	}
+
+	logCache.Lock()
+	logCache.entries = append(logCache.entries, e)
+	if len(logCache.entries) > logCacheEntries {
+		logCache.entries = logCache.entries[len(logCache.entries)-logCacheEntries:]
+	}
+	logCache.Unlock()
+	return e.window(time.Time{}, time.Time{}), nil
+}
@@ -0,0 +1,406 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+const (
+	// remotePageSize is the number of lines requested per backend query
+	remotePageSize = 5000
+
+	// remoteMaxLines caps the lines read from a remote backend per fetch
+	remoteMaxLines = 200000
+)
+
+// LogQuery selects engine log lines from a log source. Zero times leave
+// the window open; CallID narrows the query to one call when set.
+type LogQuery struct {
+	Container string
+	CallID    string
+	Since     time.Time
+	Until     time.Time
+}
+
+// LogSource reads engine logs from a backend other than local docker logs.
+// Fetch returns the lines read with a *TruncatedError when the backend
+// holds more than it reads.
+type LogSource interface {
+	Name() string
+	Fetch(ctx context.Context, q LogQuery) ([]byte, error)
+}
+
+// TruncatedError reports that a backend stopped at remoteMaxLines with
+// more lines left in the window
+type TruncatedError struct {
+	Source    string
+	Container string
+	Lines     int
+}
+
+func (e *TruncatedError) Error() string {
+	return fmt.Sprintf("%s returned more than %d lines for %s; later lines are missing (narrow --since/--until)", e.Source, e.Lines, e.Container)
+}
+
+// NewLogSource builds the configured log source. It returns nil for the
+// default docker source. logLoc is the zone of log timestamps without an
+// offset (log_timezone); nil means UTC.
+func NewLogSource(cfg settings.LogSource, logLoc *time.Location) (LogSource, error) {
+	if logLoc == nil {
+		logLoc = time.UTC
+	}
+	switch strings.ToLower(cfg.Type) {
+	case "", "docker":
+		return nil, nil
+	case "loki":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("log_source: loki requires url")
+		}
+		return &lokiSource{cfg: cfg}, nil
+	case "spool", "syslog":
+		return &spoolSource{dir: SpoolDir(), logLoc: logLoc}, nil
+	case "elasticsearch", "elastic", "es":
+		if cfg.URL == "" {
+			return nil, fmt.Errorf("log_source: elasticsearch requires url")
+		}
+		return &elasticSource{cfg: cfg}, nil
+	case "journald", "journal":
+		return &journaldSource{unit: cfg.Unit}, nil
+	}
+	return nil, fmt.Errorf("log_source: unknown type %q (use docker, loki, elasticsearch, syslog or journald)", cfg.Type)
+}
+
+// fetchLogs reads the engine logs for a docker-style window from the
+// configured source. With scaled engines, every instance's logs are read;
+// a call's lines all come from the instance that handled it.
+func (r *Runner) fetchLogs(since, until, callID string) ([]byte, error) {
+	output, err := r.fetchContainerLogs(r.container, since, until, callID)
+	if err != nil {
+		return output, err
+	}
+	for _, container := range r.instances {
+		if container == r.container {
+			continue
+		}
+		more, err := r.fetchContainerLogs(container, since, until, callID)
+		if err != nil {
+			if r.verbose {
+				fmt.Printf("[DEBUG] Skipping %s logs: %v\n", container, err)
+			}
+			continue
+		}
+		if len(output) > 0 && output[len(output)-1] != '\n' {
+			output = append(output, '\n')
+		}
+		output = append(output, more...)
+	}
+	return output, nil
+}
+
+func (r *Runner) fetchContainerLogs(container, since, until, callID string) ([]byte, error) {
+	if r.source == nil {
+		return r.dockerLogs(container, since, until)
+	}
+
+	q := LogQuery{Container: container, CallID: callID}
+	if since != "" {
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil {
+			return nil, err
+		}
+		q.Since = t
+	}
+	if until != "" {
+		t, err := time.Parse(time.RFC3339, until)
+		if err != nil {
+			return nil, err
+		}
+		q.Until = t
+	}
+	if r.verbose {
+		fmt.Printf("[DEBUG] Querying %s for %s\n", r.source.Name(), describeQuery(q))
+	}
+	data, err := r.source.Fetch(r.ctx, q)
+	var truncated *TruncatedError
+	if errors.As(err, &truncated) {
+		truncated.Container = container
+		warningColor.Printf("⚠️  %v\n", truncated)
+		r.truncated = append(r.truncated, truncated.Error())
+		return data, nil
+	}
+	return data, err
+}
+
+func describeQuery(q LogQuery) string {
+	desc := "container " + q.Container
+	if q.CallID != "" {
+		desc += ", call " + q.CallID
+	}
+	return desc
+}
+
+// authorize applies the configured credentials to a request
+func authorize(req *http.Request, cfg settings.LogSource) {
+	if cfg.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	} else if cfg.Username != "" {
+		req.SetBasicAuth(cfg.Username, cfg.Password)
+	}
+}
+
+// doJSON performs a request and decodes a JSON response into out
+func doJSON(req *http.Request, out interface{}) error {
+	client := &http.Client{Timeout: 60 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s returned %d: %s", req.URL.Host, resp.StatusCode, truncate(string(body), 200))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// timedLine is a log line with its backend timestamp, for ordering
+type timedLine struct {
+	ts   int64
+	line string
+}
+
+func joinLines(lines []timedLine) []byte {
+	sort.SliceStable(lines, func(i, j int) bool { return lines[i].ts < lines[j].ts })
+	var buf bytes.Buffer
+	for _, l := range lines {
+		buf.WriteString(strings.TrimRight(l.line, "\n"))
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// lokiSource queries Loki's query_range API with LogQL
+type lokiSource struct {
+	cfg settings.LogSource
+}
+
+func (s *lokiSource) Name() string { return "loki" }
+
+func (s *lokiSource) selector(container string) string {
+	if s.cfg.Selector != "" {
+		return s.cfg.Selector
+	}
+	return fmt.Sprintf(`{container=%q}`, container)
+}
+
+// logQL builds the query: stream selector plus a line filter for the call
+func (s *lokiSource) logQL(q LogQuery) string {
+	query := s.selector(q.Container)
+	if q.CallID != "" {
+		query += fmt.Sprintf(` |= %q`, q.CallID)
+	}
+	return query
+}
+
+func (s *lokiSource) Fetch(ctx context.Context, q LogQuery) ([]byte, error) {
+	end := q.Until
+	if end.IsZero() {
+		end = time.Now()
+	}
+	start := q.Since
+	if start.IsZero() {
+		start = end.Add(-24 * time.Hour)
+	}
+
+	var lines []timedLine
+	// Lines at the newest timestamp of a page, which the next page starts
+	// at again: Loki may hold more lines at that nanosecond than fitted
+	seen := make(map[timedLine]bool)
+	truncated := false
+	for {
+		if len(lines) >= remoteMaxLines {
+			truncated = true
+			break
+		}
+		params := url.Values{}
+		params.Set("query", s.logQL(q))
+		params.Set("start", strconv.FormatInt(start.UnixNano(), 10))
+		params.Set("end", strconv.FormatInt(end.UnixNano(), 10))
+		params.Set("limit", strconv.Itoa(remotePageSize))
+		params.Set("direction", "forward")
+
+		endpoint := strings.TrimRight(s.cfg.URL, "/") + "/loki/api/v1/query_range?" + params.Encode()
+		req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+		if err != nil {
+			return nil, err
+		}
+		authorize(req, s.cfg)
+
+		var resp struct {
+			Data struct {
+				Result []struct {
+					Values [][2]string `json:"values"`
+				} `json:"result"`
+			} `json:"data"`
+		}
+		if err := doJSON(req, &resp); err != nil {
+			return nil, fmt.Errorf("loki query failed: %w", err)
+		}
+
+		count, added := 0, 0
+		var last int64
+		var page []timedLine
+		for _, stream := range resp.Data.Result {
+			for _, v := range stream.Values {
+				ts, _ := strconv.ParseInt(v[0], 10, 64)
+				l := timedLine{ts: ts, line: v[1]}
+				count++
+				if ts > last {
+					last = ts
+				}
+				page = append(page, l)
+				if seen[l] {
+					continue
+				}
+				lines = append(lines, l)
+				added++
+			}
+		}
+		if count < remotePageSize || last == 0 {
+			break
+		}
+		if added == 0 {
+			// A whole page at one nanosecond: the rest of it cannot be
+			// paged to
+			truncated = true
+			break
+		}
+		// The next page starts at the newest timestamp returned, so lines
+		// sharing it are not skipped; those already read are dropped
+		seen = make(map[timedLine]bool)
+		for _, l := range page {
+			if l.ts == last {
+				seen[l] = true
+			}
+		}
+		start = time.Unix(0, last)
+	}
+	if truncated {
+		return joinLines(lines), &TruncatedError{Source: s.Name(), Lines: len(lines)}
+	}
+	return joinLines(lines), nil
+}
+
+// elasticSource queries an Elasticsearch index with the query DSL
+type elasticSource struct {
+	cfg settings.LogSource
+}
+
+func (s *elasticSource) Name() string { return "elasticsearch" }
+
+func (s *elasticSource) fields() (index, message, timestamp string) {
+	index, message, timestamp = s.cfg.Index, s.cfg.MessageField, s.cfg.TimeField
+	if index == "" {
+		index = "logs-*"
+	}
+	if message == "" {
+		message = "message"
+	}
+	if timestamp == "" {
+		timestamp = "@timestamp"
+	}
+	return index, message, timestamp
+}
+
+// queryDSL builds the bool query: time range plus a phrase match on the call
+func (s *elasticSource) queryDSL(q LogQuery) map[string]interface{} {
+	_, message, timestamp := s.fields()
+
+	var filters []interface{}
+	rng := map[string]interface{}{}
+	if !q.Since.IsZero() {
+		rng["gte"] = q.Since.UTC().Format(time.RFC3339)
+	}
+	if !q.Until.IsZero() {
+		rng["lte"] = q.Until.UTC().Format(time.RFC3339)
+	}
+	if len(rng) > 0 {
+		filters = append(filters, map[string]interface{}{"range": map[string]interface{}{timestamp: rng}})
+	}
+	if q.CallID != "" {
+		filters = append(filters, map[string]interface{}{"match_phrase": map[string]interface{}{message: q.CallID}})
+	}
+	return map[string]interface{}{"bool": map[string]interface{}{"filter": filters}}
+}
+
+func (s *elasticSource) Fetch(ctx context.Context, q LogQuery) ([]byte, error) {
+	index, message, timestamp := s.fields()
+	endpoint := strings.TrimRight(s.cfg.URL, "/") + "/" + index + "/_search"
+
+	var lines []timedLine
+	var searchAfter []interface{}
+	for {
+		if len(lines) >= remoteMaxLines {
+			return joinLines(lines), &TruncatedError{Source: s.Name(), Lines: len(lines)}
+		}
+		body := map[string]interface{}{
+			"size":    remotePageSize,
+			"query":   s.queryDSL(q),
+			"sort":    []interface{}{map[string]interface{}{timestamp: "asc"}, map[string]interface{}{"_doc": "asc"}},
+			"_source": []string{message},
+		}
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", endpoint, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		authorize(req, s.cfg)
+
+		var resp struct {
+			Hits struct {
+				Hits []struct {
+					Source map[string]interface{} `json:"_source"`
+					Sort   []interface{}          `json:"sort"`
+				} `json:"hits"`
+			} `json:"hits"`
+		}
+		if err := doJSON(req, &resp); err != nil {
+			return nil, fmt.Errorf("elasticsearch query failed: %w", err)
+		}
+
+		for _, hit := range resp.Hits.Hits {
+			if line, ok := hit.Source[message].(string); ok {
+				lines = append(lines, timedLine{ts: int64(len(lines)), line: line})
+			}
+			searchAfter = hit.Sort
+		}
+		if len(resp.Hits.Hits) < remotePageSize {
+			break
+		}
+	}
+	return joinLines(lines), nil
+}
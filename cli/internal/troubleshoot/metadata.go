@@ -0,0 +1,157 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+var (
+	callerNumberPattern = regexp.MustCompile(`caller_number"?\s*[=:]\s*"?([^",\s}]+)`)
+	callerNamePattern   = regexp.MustCompile(`caller_name"?\s*[=:]\s*"([^"]*)"`)
+	channelNamePattern  = regexp.MustCompile(`"?(?:channel_name|name)"?\s*[=:]\s*"?((?:PJSIP|SIP|IAX2|DAHDI|Local)/[^",\s}]+)`)
+	dialedPattern       = regexp.MustCompile(`"?(?:exten|dialed_number|called_number)"?\s*[=:]\s*"?([0-9+*#]+)"?`)
+	aiContextPattern    = regexp.MustCompile(`(?:^|[\s{,])"?(?:context|context_name|ai_context)"?\s*[=:]\s*"?([A-Za-z0-9_.-]+)`)
+	dialplanCtxPattern  = regexp.MustCompile(`"dialplan":\s*\{[^}]*"context":\s*"([^"]+)"`)
+	tenantPattern       = regexp.MustCompile(`"?tenant(?:_id)?"?\s*[=:]\s*"?([A-Za-z0-9_.@-]+)`)
+)
+
+// internalChannelPrefixes name channel technologies the engine creates itself
+var internalChannelPrefixes = []string{"Local/", "AudioSocket/", "UnicastRTP/", "Snoop/"}
+
+// stasisCallerChannel returns the channel ID of a StasisStart event line when
+// it is a real caller leg. Those lines carry the full ARI event and no
+// call_id field, so they are otherwise missed.
+func stasisCallerChannel(line string) string {
+	if !strings.Contains(line, "StasisStart") {
+		return ""
+	}
+	var entry struct {
+		EventData struct {
+			Channel struct {
+				ID   string `json:"id"`
+				Name string `json:"name"`
+			} `json:"channel"`
+		} `json:"event_data"`
+	}
+	if err := json.Unmarshal([]byte(line), &entry); err != nil {
+		return ""
+	}
+	for _, prefix := range internalChannelPrefixes {
+		if strings.HasPrefix(entry.EventData.Channel.Name, prefix) {
+			return ""
+		}
+	}
+	return entry.EventData.Channel.ID
+}
+
+// applyCallMetadata fills caller/callee details found in a log line.
+// Values already known are kept: the first StasisStart wins over later
+// lines that may describe Local/AudioSocket legs.
+func applyCallMetadata(call *Call, line string) {
+	if call.CallerNumber == "" {
+		if m := callerNumberPattern.FindStringSubmatch(line); len(m) > 1 && m[1] != "None" && m[1] != "null" {
+			call.CallerNumber = m[1]
+		}
+	}
+	if call.CallerName == "" {
+		if m := callerNamePattern.FindStringSubmatch(line); len(m) > 1 {
+			call.CallerName = m[1]
+		}
+	}
+	if call.Channel == "" {
+		if m := channelNamePattern.FindStringSubmatch(line); len(m) > 1 && !strings.HasPrefix(m[1], "Local/") {
+			call.Channel = m[1]
+		}
+	}
+	if call.Dialed == "" {
+		if m := dialedPattern.FindStringSubmatch(line); len(m) > 1 {
+			call.Dialed = m[1]
+		}
+	}
+	if call.DialplanContext == "" {
+		if m := dialplanCtxPattern.FindStringSubmatch(line); len(m) > 1 {
+			call.DialplanContext = m[1]
+		}
+	}
+	// The ARI event's dialplan context is not the AI context
+	if call.Context == "" && !strings.Contains(line, "StasisStart") {
+		if m := aiContextPattern.FindStringSubmatch(line); len(m) > 1 && m[1] != "None" && m[1] != "null" {
+			call.Context = m[1]
+		}
+	}
+	if call.Tenant == "" {
+		if m := tenantPattern.FindStringSubmatch(line); len(m) > 1 && m[1] != "None" && m[1] != "null" {
+			call.Tenant = m[1]
+		}
+	}
+}
+
+// CallFilter selects calls by caller/callee metadata and status
+type CallFilter struct {
+	From string
+	To   string
+
+	// Status is a comma-separated list of call statuses (completed,failed,...)
+	Status string
+
+	// Tag is a comma-separated list of tags; calls carrying any match
+	Tag string
+
+	// Tenant is a comma-separated list of tenants; "none" matches calls
+	// of no tenant
+	Tenant string
+}
+
+// Match reports whether the call satisfies every set criterion.
+// Numbers are compared digits-only so "+49 30 1234567" matches "4930-1234567".
+func (f CallFilter) Match(call Call) bool {
+	if f.From != "" && !numberMatches(call.CallerNumber, f.From) {
+		return false
+	}
+	if f.To != "" && !numberMatches(call.Dialed, f.To) {
+		return false
+	}
+	if f.Status != "" && !statusMatches(call.Status, f.Status) {
+		return false
+	}
+	if f.Tag != "" && !tagMatches(call, f.Tag) {
+		return false
+	}
+	if f.Tenant != "" && !tenantMatches(call.Tenant, f.Tenant) {
+		return false
+	}
+	return true
+}
+
+// empty reports whether no criteria are set
+func (f CallFilter) empty() bool {
+	return f.From == "" && f.To == "" && f.Status == "" && f.Tag == "" && f.Tenant == ""
+}
+
+func tagMatches(call Call, tags string) bool {
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" && call.HasTag(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+func numberMatches(value, want string) bool {
+	v, w := digitsOnly(value), digitsOnly(want)
+	if w == "" {
+		return strings.Contains(strings.ToLower(value), strings.ToLower(want))
+	}
+	return v != "" && strings.Contains(v, w)
+}
+
+func digitsOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r >= '0' && r <= '9' {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,170 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+)
+
+// notifyFindingsLimit caps the findings listed in one notification
+const notifyFindingsLimit = 5
+
+// notifyReport sends the analysis outcome to the notification channels.
+// call may be nil when the call is not in the index.
+func (r *Runner) notifyReport(report *Report, call *Call) {
+	if r.notifier == nil {
+		return
+	}
+	data := sanitizedReport(report)
+	reportEv := newReportEvent(report, call)
+	tenant, hours := "", ""
+	if call != nil {
+		tenant = call.Tenant
+		hours = r.hours.Label(*call)
+	}
+	if hours == HoursAfter {
+		reportEv.Fields["Hours"] = "after hours"
+	}
+	events := []notify.Event{reportEv}
+	if breaches := r.sloBreaches(report, tenant); len(breaches) > 0 {
+		ev := notify.Event{
+			Kind:     notify.EventSLOBreached,
+			Severity: SeverityWarning,
+			Title:    "Call breached SLO",
+			Text:     strings.Join(breaches, "\n"),
+			CallID:   report.CallID,
+			Fields:   map[string]string{},
+		}
+		if tenant != "" {
+			ev.Title = "Call of " + tenant + " breached SLO"
+			ev.Fields["Tenant"] = tenant
+		}
+		// Objectives are for staffed hours; after-hours breaches are
+		// informational so warning-level channels skip them
+		if hours == HoursAfter {
+			ev.Severity = SeverityInfo
+			ev.Title += " after hours"
+			ev.Fields["Hours"] = "after hours"
+		}
+		events = append(events, ev)
+	}
+	for _, ev := range events {
+		ev.Data = data
+		if _, err := r.notifier.Notify(r.ctx, ev); err != nil {
+			warningColor.Printf("⚠️  Notification failed: %v\n", r.wrapCtxErr(err))
+		}
+	}
+}
+
+// sloBreaches lists the objectives the call missed, with the tenant's
+// objectives where it sets its own
+func (r *Runner) sloBreaches(report *Report, tenant string) []string {
+	slo := r.sloFor(tenant)
+	var breaches []string
+	if limit := slo.TurnLatencyP95Ms; limit > 0 {
+		if p95, err := strconv.ParseFloat(report.Metrics["turn_latency_p95_ms"], 64); err == nil && p95 > limit {
+			breaches = append(breaches, fmt.Sprintf("turn latency p95 %.0fms > %.0fms", p95, limit))
+		}
+	}
+	if min := slo.MinQualityScore; min > 0 && report.Score < min {
+		breaches = append(breaches, fmt.Sprintf("quality score %.0f < %.0f", report.Score, min))
+	}
+	return breaches
+}
+
+// sanitizedReport returns the report with secrets and caller PII removed,
+// for payloads that leave the host
+func sanitizedReport(report *Report) json.RawMessage {
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil
+	}
+	clean, err := SanitizeJSON(data, "")
+	if err != nil {
+		return nil
+	}
+	return json.RawMessage(clean)
+}
+
+// newReportEvent summarizes a report as a notification. Failed calls and
+// critical findings are failure events; anything else is informational.
+func newReportEvent(report *Report, call *Call) notify.Event {
+	severity := SeverityInfo
+	for _, f := range report.Findings {
+		if severityRank(f.Severity) < severityRank(severity) {
+			severity = f.Severity
+		}
+	}
+	if report.Errors > 0 && severity == SeverityInfo {
+		severity = SeverityWarning
+	}
+
+	ev := notify.Event{
+		Kind:     notify.EventCallAnalyzed,
+		Severity: severity,
+		CallID:   report.CallID,
+		Fields: map[string]string{
+			"Quality score": fmt.Sprintf("%.0f", report.Score),
+			"Errors":        fmt.Sprintf("%d", report.Errors),
+		},
+	}
+	if call != nil {
+		if call.Status != "" {
+			ev.Fields["Status"] = call.Status
+		}
+		if call.Status == CallFailed {
+			ev.Severity = SeverityCritical
+		}
+		if parties := formatParties(*call); parties != "" {
+			ev.Fields["Parties"] = parties
+		}
+		if call.Tenant != "" {
+			ev.Fields["Tenant"] = call.Tenant
+		}
+	}
+	if providers := report.Metrics["providers"]; providers != "" {
+		ev.Fields["Provider"] = providers
+	}
+	if ev.Severity == SeverityCritical {
+		ev.Kind = notify.EventFailureDetected
+	}
+
+	ev.Title = "Call analyzed"
+	for _, f := range report.Findings {
+		if f.Severity == ev.Severity {
+			ev.Title = f.Message
+			break
+		}
+	}
+	if ev.Kind == notify.EventFailureDetected && ev.Title == "Call analyzed" {
+		ev.Title = "Call failed"
+	}
+
+	var lines []string
+	for i, f := range report.Findings {
+		if i == notifyFindingsLimit {
+			lines = append(lines, fmt.Sprintf("... and %d more", len(report.Findings)-i))
+			break
+		}
+		line := fmt.Sprintf("[%s] %s: %s", f.Severity, f.Analyzer, f.Message)
+		if f.Evidence != "" {
+			line += " (" + truncate(f.Evidence, 120) + ")"
+		}
+		lines = append(lines, line)
+	}
+	if len(report.Incomplete) > 0 {
+		var names []string
+		for _, in := range report.Incomplete {
+			names = append(names, in.Analyzer)
+		}
+		lines = append(lines, "Incomplete analysis, findings may be missing: "+strings.Join(names, ", "))
+	}
+	if s := report.Sampling; s != nil {
+		lines = append(lines, fmt.Sprintf("Logs sampled: %d of %d lines analyzed (repetitive debug lines 1 in %d)", s.KeptLines, s.TotalLines, s.Every))
+	}
+	ev.Text = strings.Join(lines, "\n")
+	return ev
+}
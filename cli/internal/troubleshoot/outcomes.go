@@ -0,0 +1,135 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// outcomeSummaryLen bounds the caller's first words quoted in a summary
+const outcomeSummaryLen = 160
+
+// CallOutcome is what a CRM activity records about a call
+type CallOutcome struct {
+	CallID          string    `json:"call_id"`
+	Start           time.Time `json:"start"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	CallerNumber    string    `json:"caller_number,omitempty"`
+	CallerName      string    `json:"caller_name,omitempty"`
+	Dialed          string    `json:"dialed,omitempty"`
+	Context         string    `json:"context,omitempty"`
+	DialplanContext string    `json:"dialplan_context,omitempty"`
+	Tenant          string    `json:"tenant,omitempty"`
+	// Disposition is the call status: completed, failed, abandoned or
+	// transferred
+	Disposition  string   `json:"disposition,omitempty"`
+	Intents      []string `json:"intents,omitempty"`
+	Summary      string   `json:"summary"`
+	QualityScore float64  `json:"quality_score"`
+	Transcript   []Turn   `json:"transcript,omitempty"`
+}
+
+// CallOutcomes analyzes every call in the --since/--until window that
+// matches the filters and returns their outcomes, oldest first. Calls
+// whose logs cannot be collected are left out.
+func (r *Runner) CallOutcomes() ([]CallOutcome, error) {
+	r.all = true
+	calls, err := r.getRecentCalls(exportCallLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent calls: %w", r.wrapCtxErr(err))
+	}
+	r.since, r.until = "", ""
+
+	fmt.Fprintf(os.Stderr, "Analyzing %d call(s)...\n", len(calls))
+	outcomes := make([]CallOutcome, 0, len(calls))
+	for i := len(calls) - 1; i >= 0; i-- {
+		if r.ctx.Err() != nil {
+			return nil, r.wrapCtxErr(r.ctx.Err())
+		}
+		call := calls[i]
+		r.callID = call.ID
+		logData, err := r.collectCallData()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %s skipped: %v\n", call.ID, err)
+			continue
+		}
+		rc := r.newReportCall(call, logData)
+		o := CallOutcome{
+			CallID:          call.ID,
+			Start:           call.Timestamp,
+			DurationSeconds: rc.minutes * 60,
+			CallerNumber:    call.CallerNumber,
+			CallerName:      call.CallerName,
+			Dialed:          call.Dialed,
+			Context:         call.Context,
+			DialplanContext: call.DialplanContext,
+			Tenant:          call.Tenant,
+			Disposition:     call.Status,
+			QualityScore:    rc.report.Score,
+			Transcript:      r.callTurns(call.ID, logData),
+		}
+		if o.Context == "" {
+			o.Context = rc.persona
+		}
+		for _, intent := range rc.intents {
+			if intent != intentNone {
+				o.Intents = append(o.Intents, intent)
+			}
+		}
+		o.Summary = outcomeSummary(o)
+		outcomes = append(outcomes, o)
+	}
+	return outcomes, nil
+}
+
+// outcomeSummary describes a call in a few sentences: what the caller
+// said first, the tools the agent ran and how the call ended
+func outcomeSummary(o CallOutcome) string {
+	var parts []string
+	for _, t := range o.Transcript {
+		if t.Role == RoleUser {
+			parts = append(parts, fmt.Sprintf("Caller: %q.", truncate(t.Text, outcomeSummaryLen)))
+			break
+		}
+	}
+	if len(o.Intents) > 0 {
+		parts = append(parts, "Agent actions: "+strings.Join(o.Intents, ", ")+".")
+	}
+	ending := "Call"
+	if o.Disposition != "" {
+		ending += " " + o.Disposition
+	} else {
+		ending += " ended"
+	}
+	if o.DurationSeconds > 0 {
+		ending += " after " + formatDuration(time.Duration(o.DurationSeconds)*time.Second)
+	}
+	parts = append(parts, ending+".")
+	return strings.Join(parts, " ")
+}
+
+// Matches reports whether the call belongs to one of the routes: a
+// dialed number matching dids, a context in contexts or a tenant in
+// tenants. No routes match every call.
+func (o *CallOutcome) Matches(dids, contexts, tenants []string) bool {
+	if len(dids) == 0 && len(contexts) == 0 && len(tenants) == 0 {
+		return true
+	}
+	for _, did := range dids {
+		if didMatches(o.Dialed, did) {
+			return true
+		}
+	}
+	for _, ctx := range contexts {
+		if ctx != "" && (strings.EqualFold(ctx, o.Context) || strings.EqualFold(ctx, o.DialplanContext)) {
+			return true
+		}
+	}
+	for _, t := range tenants {
+		if t != "" && strings.EqualFold(t, o.Tenant) {
+			return true
+		}
+	}
+	return false
+}
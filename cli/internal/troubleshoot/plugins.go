@@ -0,0 +1,135 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// pluginTimeout bounds a single plugin run
+const pluginTimeout = 30 * time.Second
+
+// PluginDir returns the directory scanned for external analyzers.
+// AGENT_PLUGIN_DIR overrides the default of ~/.agent/analyzers.
+func PluginDir() string {
+	if dir := os.Getenv("AGENT_PLUGIN_DIR"); dir != "" {
+		return dir
+	}
+	return filepath.Join(settings.Dir(), "analyzers")
+}
+
+// pluginInput is written to a plugin's stdin
+type pluginInput struct {
+	CallID string      `json:"call_id"`
+	Events []*LogEvent `json:"events"`
+}
+
+// pluginOutput is read from a plugin's stdout
+type pluginOutput struct {
+	Findings []Finding `json:"findings"`
+}
+
+// execAnalyzer runs an executable as an analyzer. It receives the call's
+// events as JSON on stdin and prints {"findings": [...]} on stdout.
+type execAnalyzer struct {
+	ctx    context.Context
+	path   string
+	events []*LogEvent
+	err    error
+}
+
+func (a *execAnalyzer) Name() string {
+	return "plugin:" + filepath.Base(a.path)
+}
+
+func (a *execAnalyzer) Observe(ev *LogEvent) {
+	a.events = append(a.events, ev)
+}
+
+func (a *execAnalyzer) Finish(analysis *Analysis) []Finding {
+	findings, err := a.run(analysis.CallID)
+	a.err = err
+	return findings
+}
+
+// Err reports why the plugin did not complete
+func (a *execAnalyzer) Err() error {
+	return a.err
+}
+
+func (a *execAnalyzer) run(callID string) ([]Finding, error) {
+	input, err := json.Marshal(pluginInput{CallID: callID, Events: a.events})
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(a.ctx, pluginTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, a.path)
+	cmd.Stdin = bytes.NewReader(input)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := selflog.Output(cmd)
+	if err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, fmt.Errorf("timed out after %s", pluginTimeout)
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%v: %s", err, truncate(msg, 200))
+		}
+		return nil, err
+	}
+
+	var result pluginOutput
+	if err := json.Unmarshal(output, &result); err != nil {
+		return nil, fmt.Errorf("invalid output: %v", err)
+	}
+	for i := range result.Findings {
+		result.Findings[i].Analyzer = "plugin:" + filepath.Base(a.path)
+		if result.Findings[i].Severity == "" {
+			result.Findings[i].Severity = SeverityInfo
+		}
+	}
+	return result.Findings, nil
+}
+
+// discoverPlugins lists the executable files in the plugin directory
+func discoverPlugins() []string {
+	entries, err := os.ReadDir(PluginDir())
+	if err != nil {
+		return nil
+	}
+
+	var paths []string
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		if info, err := entry.Info(); err != nil || info.Mode()&0111 == 0 {
+			continue
+		}
+		paths = append(paths, filepath.Join(PluginDir(), entry.Name()))
+	}
+	sort.Strings(paths)
+	return paths
+}
+
+// pluginAnalyzers creates an analyzer for each discovered plugin
+func pluginAnalyzers(ctx context.Context) []Analyzer {
+	var analyzers []Analyzer
+	for _, path := range discoverPlugins() {
+		analyzers = append(analyzers, &execAnalyzer{ctx: ctx, path: path})
+	}
+	return analyzers
+}
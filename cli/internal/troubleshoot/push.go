@@ -0,0 +1,62 @@
+package troubleshoot
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/monitoring"
+)
+
+// pushMetrics sends the call's metrics to the configured StatsD/InfluxDB
+// sinks. call may be nil when the call is not in the index.
+func (r *Runner) pushMetrics(report *Report, call *Call) {
+	if len(r.sinks) == 0 {
+		return
+	}
+	sample := newCallSample(report, call)
+	if err := monitoring.PushAll(r.ctx, r.sinks, sample); err != nil {
+		warningColor.Printf("⚠️  Metrics push failed: %v\n", r.wrapCtxErr(err))
+	}
+}
+
+// newCallSample converts a report into the metrics pushed per call
+func newCallSample(report *Report, call *Call) monitoring.CallSample {
+	sample := monitoring.CallSample{
+		CallID:  report.CallID,
+		Time:    time.Now(),
+		Tags:    map[string]string{},
+		Timings: map[string]float64{},
+		Values:  map[string]float64{"quality_score": report.Score},
+		Counts: map[string]int{
+			"analyzed":     1,
+			"errors":       report.Errors,
+			"warnings":     report.Warnings,
+			"audio_issues": len(report.AudioIssues),
+		},
+	}
+	if call != nil {
+		if !call.Timestamp.IsZero() {
+			sample.Time = call.Timestamp
+		}
+		sample.Tags["status"] = call.Status
+		if call.Status == CallFailed {
+			sample.Counts["failed"] = 1
+		}
+	}
+	if providers := report.Metrics["providers"]; providers != "" {
+		sample.Tags["provider"] = providers
+	}
+	for _, key := range []string{"turn_latency_avg_ms", "turn_latency_p95_ms", "turn_latency_max_ms"} {
+		if v, err := strconv.ParseFloat(report.Metrics[key], 64); err == nil {
+			sample.Timings[key] = v
+		}
+	}
+	critical := 0
+	for _, f := range report.Findings {
+		if f.Severity == SeverityCritical {
+			critical++
+		}
+	}
+	sample.Counts["critical_findings"] = critical
+	return sample
+}
@@ -0,0 +1,39 @@
+package troubleshoot
+
+import results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+
+// Report is the machine-readable result of a single-call analysis, in
+// the versioned form of pkg/analysis
+type Report = results.Analysis
+
+// PipelineStatus records which audio pipeline stages were seen
+type PipelineStatus = results.PipelineStatus
+
+// NewReport builds a Report from an analysis and optional LLM diagnosis
+func NewReport(analysis *Analysis, diagnosis *LLMDiagnosis) *Report {
+	report := &Report{
+		SchemaVersion: results.SchemaVersion,
+		CallID:        analysis.CallID,
+		Symptom:       analysis.Symptom,
+		Pipeline: PipelineStatus{
+			AudioSocket:   analysis.HasAudioSocket,
+			Transcription: analysis.HasTranscription,
+			Playback:      analysis.HasPlayback,
+		},
+		Errors:      len(analysis.Errors),
+		Warnings:    len(analysis.Warnings),
+		AudioIssues: analysis.AudioIssues,
+		Findings:    analysis.Findings,
+		Latency:     analysis.LatencyBreakdown,
+		ASRTurns:    analysis.ASRTurns,
+		Frames:      analysis.Frames,
+		Metrics:     analysis.MetricsMap,
+		Diagnosis:   diagnosis,
+		Incomplete:  analysis.Incomplete,
+		Sampling:    analysis.Sampling,
+	}
+	if analysis.Metrics != nil {
+		report.Score, report.Issues = scoreCallQuality(analysis.Metrics)
+	}
+	return report
+}
@@ -0,0 +1,220 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"gopkg.in/yaml.v3"
+)
+
+// Rule files in RulesDir: the curated set installed by 'agent rules
+// update', and rules written by the operator, which win on equal IDs
+const (
+	UpstreamRulesFile = "upstream.yaml"
+	LocalRulesFile    = "local.yaml"
+)
+
+// Rule is a known-issue signature: when enough log lines of a call
+// match, it reports a finding with the known fix
+type Rule struct {
+	ID       string   `yaml:"id" json:"id"`
+	Severity string   `yaml:"severity" json:"severity"`
+	Message  string   `yaml:"message" json:"message"`
+	Match    []string `yaml:"match" json:"match"`
+	MinCount int      `yaml:"min_count,omitempty" json:"min_count,omitempty"`
+	Fix      string   `yaml:"fix,omitempty" json:"fix,omitempty"`
+	Docs     string   `yaml:"docs,omitempty" json:"docs,omitempty"`
+
+	// Disabled in local.yaml turns off an upstream rule of the same ID
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+
+	// Source is the file the rule came from
+	Source string `yaml:"-" json:"source"`
+}
+
+// RuleSet is the content of a rules file
+type RuleSet struct {
+	Version string `yaml:"version,omitempty"`
+	Rules   []Rule `yaml:"rules"`
+}
+
+// RulesDir returns the directory holding the rule files
+func RulesDir() string {
+	return filepath.Join(settings.Dir(), "rules")
+}
+
+// ParseRules reads and validates a rules file
+func ParseRules(data []byte) (*RuleSet, error) {
+	var set RuleSet
+	if err := yaml.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for i, r := range set.Rules {
+		if r.ID == "" {
+			return nil, fmt.Errorf("rule %d: id is required", i+1)
+		}
+		if seen[r.ID] {
+			return nil, fmt.Errorf("rule %s: duplicate id", r.ID)
+		}
+		seen[r.ID] = true
+		if r.Disabled {
+			continue
+		}
+		if err := r.validate(); err != nil {
+			return nil, fmt.Errorf("rule %s: %w", r.ID, err)
+		}
+	}
+	return &set, nil
+}
+
+func (r Rule) validate() error {
+	switch r.Severity {
+	case SeverityCritical, SeverityWarning, SeverityInfo:
+	default:
+		return fmt.Errorf("severity must be critical, warning or info")
+	}
+	if r.Message == "" {
+		return fmt.Errorf("message is required")
+	}
+	if len(r.Match) == 0 {
+		return fmt.Errorf("match needs at least one pattern")
+	}
+	for _, m := range r.Match {
+		if _, err := regexp.Compile(m); err != nil {
+			return fmt.Errorf("match %q: %v", m, err)
+		}
+	}
+	return nil
+}
+
+// loadRuleFile reads a rules file; a missing file is an empty set
+func loadRuleFile(name string) (*RuleSet, error) {
+	data, err := os.ReadFile(filepath.Join(RulesDir(), name))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &RuleSet{}, nil
+		}
+		return nil, err
+	}
+	set, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	for i := range set.Rules {
+		set.Rules[i].Source = name
+	}
+	return set, nil
+}
+
+// LoadRules merges the upstream and local rules, sorted by ID. Local
+// rules replace upstream rules with the same ID; disabled ones drop out.
+func LoadRules() ([]Rule, error) {
+	upstream, err := loadRuleFile(UpstreamRulesFile)
+	if err != nil {
+		return nil, err
+	}
+	local, err := loadRuleFile(LocalRulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]Rule)
+	for _, r := range upstream.Rules {
+		byID[r.ID] = r
+	}
+	for _, r := range local.Rules {
+		byID[r.ID] = r
+	}
+	rules := make([]Rule, 0, len(byID))
+	for _, r := range byID {
+		if !r.Disabled {
+			rules = append(rules, r)
+		}
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].ID < rules[j].ID })
+	return rules, nil
+}
+
+// compiledRule is a rule with its patterns compiled and its match count
+type compiledRule struct {
+	Rule
+	patterns []*regexp.Regexp
+	count    int
+	evidence string
+}
+
+// rulesAnalyzer reports the known-issue rules a call's logs match
+type rulesAnalyzer struct {
+	rules []*compiledRule
+	err   error
+}
+
+func newRulesAnalyzer() Analyzer {
+	rules, err := LoadRules()
+	a := &rulesAnalyzer{err: err}
+	for _, r := range rules {
+		c := &compiledRule{Rule: r}
+		for _, m := range r.Match {
+			c.patterns = append(c.patterns, regexp.MustCompile(m))
+		}
+		a.rules = append(a.rules, c)
+	}
+	return a
+}
+
+func (a *rulesAnalyzer) Name() string { return "rules" }
+
+func (a *rulesAnalyzer) Observe(ev *LogEvent) {
+	for _, r := range a.rules {
+		for _, p := range r.patterns {
+			if p.MatchString(ev.Line) {
+				r.count++
+				if r.evidence == "" {
+					r.evidence = truncate(ev.Line, 100)
+				}
+				break
+			}
+		}
+	}
+}
+
+func (a *rulesAnalyzer) Finish(analysis *Analysis) []Finding {
+	if a.err != nil {
+		return []Finding{{
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Known-issue rules not loaded: %v", a.err),
+		}}
+	}
+	var findings []Finding
+	for _, r := range a.rules {
+		need := r.MinCount
+		if need < 1 {
+			need = 1
+		}
+		if r.count < need {
+			continue
+		}
+		msg := r.Message
+		if r.count > 1 {
+			msg = fmt.Sprintf("%s (%dx)", msg, r.count)
+		}
+		fix := r.Fix
+		if r.Docs != "" {
+			fix = strings.TrimSpace(fix + " See " + r.Docs)
+		}
+		findings = append(findings, Finding{
+			Analyzer: "rules:" + r.ID,
+			Severity: r.Severity,
+			Message:  msg,
+			Evidence: r.evidence,
+			Fix:      fix,
+		})
+	}
+	return findings
+}
@@ -0,0 +1,159 @@
+package troubleshoot
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selfupdate"
+)
+
+// DefaultRulesURL is the curated known-issue rules file in the project
+// repository; its ed25519 signature is published next to it as .sig
+const DefaultRulesURL = "https://raw.githubusercontent.com/" + selfupdate.Repo + "/main/cli/rules/known-issues.yaml"
+
+// maxRulesSize bounds a downloaded rules file
+const maxRulesSize = 1 << 20
+
+// RulesUpdate is a fetched rules file and how it differs from the
+// installed one
+type RulesUpdate struct {
+	URL             string
+	Version         string
+	PreviousVersion string
+	Signed          bool
+	Total           int
+	Added           []string
+	Updated         []string
+	Removed         []string
+	// Overridden are upstream rules that local.yaml replaces or disables
+	Overridden []string
+
+	data []byte
+}
+
+// Changed reports whether installing the update changes the rules
+func (u *RulesUpdate) Changed() bool {
+	return len(u.Added)+len(u.Updated)+len(u.Removed) > 0 || u.Version != u.PreviousVersion
+}
+
+// Data returns the fetched rules file
+func (u *RulesUpdate) Data() []byte {
+	return u.data
+}
+
+// FetchRules downloads the rules file at url and compares it with the
+// installed upstream rules. The file must carry a valid signature by
+// publicKey (base64 ed25519) at url+".sig"; the check is only skipped
+// with skipSignature.
+func FetchRules(ctx context.Context, url, publicKey string, skipSignature bool) (*RulesUpdate, error) {
+	if publicKey == "" && !skipSignature {
+		return nil, fmt.Errorf("this build has no signing key; refusing rules whose signature cannot be verified")
+	}
+	client := &http.Client{Timeout: time.Minute}
+	data, err := fetchRulesURL(ctx, client, url, maxRulesSize)
+	if err != nil {
+		return nil, err
+	}
+
+	update := &RulesUpdate{URL: url, data: data}
+	if !skipSignature {
+		sig, err := fetchRulesURL(ctx, client, url+".sig", 4096)
+		if err != nil {
+			return nil, fmt.Errorf("rules are not signed: %w", err)
+		}
+		if err := selfupdate.VerifySignature(publicKey, data, sig); err != nil {
+			return nil, fmt.Errorf("rules: %w", err)
+		}
+		update.Signed = true
+	}
+
+	set, err := ParseRules(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file: %w", err)
+	}
+	installed, err := loadRuleFile(UpstreamRulesFile)
+	if err != nil {
+		// A broken installed file is replaced; every rule counts as new
+		installed = &RuleSet{}
+	}
+	local, err := loadRuleFile(LocalRulesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	update.Version = set.Version
+	update.PreviousVersion = installed.Version
+	update.Total = len(set.Rules)
+
+	old := make(map[string]Rule)
+	for _, r := range installed.Rules {
+		r.Source = ""
+		old[r.ID] = r
+	}
+	overrides := make(map[string]bool)
+	for _, r := range local.Rules {
+		overrides[r.ID] = true
+	}
+	for _, r := range set.Rules {
+		prev, ok := old[r.ID]
+		switch {
+		case !ok:
+			update.Added = append(update.Added, r.ID)
+		case !reflect.DeepEqual(prev, r):
+			update.Updated = append(update.Updated, r.ID)
+		}
+		delete(old, r.ID)
+		if overrides[r.ID] {
+			update.Overridden = append(update.Overridden, r.ID)
+		}
+	}
+	for id := range old {
+		update.Removed = append(update.Removed, id)
+	}
+	sort.Strings(update.Removed)
+	return update, nil
+}
+
+func fetchRulesURL(ctx context.Context, client *http.Client, url string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%s exceeds %d bytes", url, limit)
+	}
+	return data, nil
+}
+
+// InstallRules writes the fetched rules as the upstream rules file. The
+// file is renamed into place so an interrupted write keeps the old rules.
+func InstallRules(update *RulesUpdate) error {
+	if err := os.MkdirAll(RulesDir(), 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(RulesDir(), UpstreamRulesFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, update.data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,188 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+)
+
+const (
+	// sampleAbove is the number of log lines above which a call's logs
+	// are sampled
+	sampleAbove = 50000
+	// sampleRepeat: a debug message logged more often than this in one
+	// call is repetitive and sampled
+	sampleRepeat = 500
+	// sampleBudget is about how many repetitive lines are kept in all
+	sampleBudget = 20000
+	// sampleKeepFirst lines of each repetitive message are always kept
+	sampleKeepFirst = 50
+	// sampleContext lines before each warning or error are always kept
+	sampleContext = 5
+)
+
+// sampleKeep are debug events kept in full: per-second counters the
+// frame sparklines and the timeline are rebuilt from
+var sampleKeep = map[string]bool{
+	"Audio frame stats": true,
+}
+
+// sampleState finds debug events that are state transitions
+var sampleState = regexp.MustCompile(`(?i)\b(state|transition|start(ed|ing)?|stop(ped|ping)?|connect(ed|ing)?|disconnect(ed)?|clos(ed|ing)|open(ed)?|ready|hangup|barge|cancel(l?ed)?)\b`)
+
+// sampleDigits normalizes the variable parts of plain-text messages
+var sampleDigits = regexp.MustCompile(`[0-9]+`)
+
+// Sampling describes how the logs of a very chatty call (debug logging
+// left on) were sampled: every line but repetitive debug messages is
+// kept, and of those the first lines and then one in Every
+type Sampling = results.Sampling
+
+// SampledKind is one repetitive message and how many of its lines were kept
+type SampledKind = results.SampledKind
+
+// sampleLogs thins out the repetitive debug lines of a call with more
+// than sampleAbove lines. Errors, warnings, info lines, state transitions
+// and the lines leading up to each warning or error are kept. It returns
+// the logs unchanged and nil when there is nothing to sample.
+func sampleLogs(logData string) (string, *Sampling) {
+	lines := strings.Split(logData, "\n")
+	if len(lines) <= sampleAbove {
+		return logData, nil
+	}
+
+	// kinds[i] is the repetitive message line i belongs to, "" for lines
+	// that are always kept
+	kinds := make([]string, len(lines))
+	counts := make(map[string]int)
+	keep := make([]bool, len(lines))
+	for i, line := range lines {
+		kind, severe := sampleKind(line)
+		if severe {
+			for j := i - sampleContext; j < i; j++ {
+				if j >= 0 {
+					keep[j] = true
+				}
+			}
+		}
+		kinds[i] = kind
+		if kind != "" {
+			counts[kind]++
+		}
+	}
+
+	repetitive := 0
+	for kind, n := range counts {
+		if n > sampleRepeat {
+			repetitive += n
+		} else {
+			delete(counts, kind)
+		}
+	}
+	if repetitive <= sampleBudget {
+		return logData, nil
+	}
+	every := (repetitive + sampleBudget - 1) / sampleBudget
+
+	seen := make(map[string]int)
+	kept := make(map[string]int)
+	out := make([]string, 0, len(lines)-repetitive+sampleBudget)
+	for i, line := range lines {
+		kind := kinds[i]
+		if _, sampled := counts[kind]; sampled && !keep[i] {
+			n := seen[kind]
+			seen[kind]++
+			if n >= sampleKeepFirst && n%every != 0 {
+				continue
+			}
+			kept[kind]++
+		} else if sampled {
+			seen[kind]++
+			kept[kind]++
+		}
+		out = append(out, line)
+	}
+
+	s := &Sampling{TotalLines: len(lines), KeptLines: len(out), Every: every}
+	for kind, n := range counts {
+		s.Kinds = append(s.Kinds, SampledKind{Kind: kind, Total: n, Kept: kept[kind]})
+	}
+	sort.Slice(s.Kinds, func(i, j int) bool {
+		if s.Kinds[i].Total != s.Kinds[j].Total {
+			return s.Kinds[i].Total > s.Kinds[j].Total
+		}
+		return s.Kinds[i].Kind < s.Kinds[j].Kind
+	})
+	return strings.Join(out, "\n"), s
+}
+
+// sampleKind returns the message a debug line repeats ("" for lines
+// that are always kept) and whether the line is a warning or error
+func sampleKind(line string) (string, bool) {
+	ev := parseLogEvent(line)
+	if ev.Fields != nil {
+		level := strings.ToLower(ev.Level)
+		switch level {
+		case "warning", "warn", "error", "critical", "fatal":
+			return "", true
+		case "debug", "trace":
+		default:
+			return "", false
+		}
+		if ev.Event == "" || sampleKeep[ev.Event] || sampleState.MatchString(ev.Event) {
+			return "", false
+		}
+		return ev.Event, false
+	}
+
+	switch {
+	case strings.Contains(ev.Lower, "error"), strings.Contains(ev.Lower, "warn"),
+		strings.Contains(ev.Lower, "critical"), strings.Contains(ev.Lower, "traceback"):
+		return "", true
+	case !strings.Contains(ev.Lower, "debug"):
+		return "", false
+	}
+	// Plain-text debug lines are grouped by their text after the
+	// timestamp, with numbers masked
+	msg := line
+	if loc := logTimestampPattern.FindStringIndex(msg); loc != nil {
+		msg = msg[loc[1]:]
+	}
+	msg = strings.TrimSpace(sampleDigits.ReplaceAllString(msg, "#"))
+	if sampleState.MatchString(msg) {
+		return "", false
+	}
+	return truncate(msg, 80), false
+}
+
+// displaySampling says how much of a chatty call's logs was analyzed
+func (r *Runner) displaySampling(s *Sampling) {
+	if s == nil {
+		return
+	}
+	warningColor.Printf("🔬 SAMPLED: kept %s of %s log lines (debug logging left on?)\n",
+		formatCount(s.KeptLines), formatCount(s.TotalLines))
+	fmt.Println("  Errors, warnings, state transitions and frame stats are all kept;")
+	fmt.Printf("  repetitive debug messages were sampled, 1 line in %d:\n", s.Every)
+	for i, k := range s.Kinds {
+		if i == 5 {
+			fmt.Printf("  ... and %d more\n", len(s.Kinds)-i)
+			break
+		}
+		fmt.Printf("  • %-48s %s of %s\n", truncate(k.Kind, 48), formatCount(k.Kept), formatCount(k.Total))
+	}
+	fmt.Println("  Counts of these messages cover the kept lines only; --no-sample reads every line.")
+	fmt.Println()
+}
+
+// formatCount writes a count with thousands separators
+func formatCount(n int) string {
+	s := fmt.Sprintf("%d", n)
+	for i := len(s) - 3; i > 0; i -= 3 {
+		s = s[:i] + "," + s[i:]
+	}
+	return s
+}
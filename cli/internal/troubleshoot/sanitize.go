@@ -0,0 +1,182 @@
+package troubleshoot
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+// redacted replaces sensitive values in sanitized output
+const redacted = "[REDACTED]"
+
+var sanitizePatterns = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	// Secrets in key=value, key: value and "key": "value" form
+	{regexp.MustCompile(`(?i)("?(?:api[_-]?key|secret|token|password|passwd|authorization)"?\s*[:=]\s*"?(?:bearer\s+|basic\s+)?)([^"\s,}]+)`), "${1}" + redacted},
+	{regexp.MustCompile(`(?i)(bearer\s+)[A-Za-z0-9._~+/=-]+`), "${1}" + redacted},
+	{regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{16,}`), redacted},
+
+	// Caller identity fields
+	{regexp.MustCompile(`("(?:caller_number|caller_name|callerid|caller_id_num|caller_id_name|connected_line_num|connected_line_name|number|name)"\s*:\s*")([^"]*)(")`), "${1}" + redacted + "${3}"},
+
+	// E.164 numbers and e-mail addresses anywhere else
+	{regexp.MustCompile(`\+[1-9][0-9]{6,14}\b`), redacted},
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), redacted},
+}
+
+// Sanitize removes secrets and caller PII from log or report text so it
+// can leave the host (tickets, webhooks, support bundles). Call and
+// channel IDs are kept.
+func Sanitize(text string) string {
+	text = RedactSecrets(text)
+	for _, p := range sanitizePatterns {
+		text = p.pattern.ReplaceAllString(text, p.replace)
+	}
+	return text
+}
+
+// secretKey and callerKeys are the JSON keys whose string values are
+// redacted whole
+var (
+	secretKey  = regexp.MustCompile(`(?i)(?:api[_-]?key|secret|token|password|passwd|authorization)$`)
+	callerKeys = map[string]bool{
+		"caller_number": true, "caller_name": true, "callerid": true,
+		"caller_id_num": true, "caller_id_name": true,
+		"connected_line_num": true, "connected_line_name": true,
+		"number": true, "name": true,
+	}
+)
+
+// SanitizeJSON is Sanitize for a JSON document: it redacts the decoded
+// string values, so escapes in them survive and the result stays valid
+// JSON, and keeps the keys in their order. indent, when set, indents
+// the result as json.MarshalIndent does.
+func SanitizeJSON(data []byte, indent string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	type frame struct {
+		object bool
+		n      int // keys and values written
+		key    string
+	}
+	var stack []*frame
+	var buf bytes.Buffer
+	write := func(v interface{}) error {
+		out, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(out)
+		return nil
+	}
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		var top *frame
+		if len(stack) > 0 {
+			top = stack[len(stack)-1]
+		}
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			buf.WriteByte(byte(d))
+			stack = stack[:len(stack)-1]
+			if len(stack) > 0 {
+				stack[len(stack)-1].n++
+			}
+			continue
+		}
+		if top != nil && top.n > 0 {
+			if top.object && top.n%2 == 1 {
+				buf.WriteByte(':')
+			} else {
+				buf.WriteByte(',')
+			}
+		}
+		if top != nil && top.object && top.n%2 == 0 {
+			// A key
+			top.key, _ = tok.(string)
+			if err := write(top.key); err != nil {
+				return nil, err
+			}
+			top.n++
+			continue
+		}
+		switch t := tok.(type) {
+		case json.Delim:
+			buf.WriteByte(byte(t))
+			stack = append(stack, &frame{object: t == '{'})
+			continue
+		case string:
+			switch {
+			case top != nil && top.object && t != "" && (secretKey.MatchString(top.key) || callerKeys[top.key]):
+				t = redacted
+			default:
+				t = Sanitize(t)
+			}
+			err = write(t)
+		case json.Number:
+			buf.WriteString(t.String())
+		default:
+			err = write(t)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if top != nil {
+			top.n++
+		}
+	}
+	out := buf.Bytes()
+	if !json.Valid(out) {
+		return nil, fmt.Errorf("sanitized JSON is not valid")
+	}
+	if indent == "" {
+		return out, nil
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, out, "", indent); err != nil {
+		return nil, err
+	}
+	return indented.Bytes(), nil
+}
+
+// transcriptPatterns find personal data callers say; each match becomes
+// a placeholder naming what was removed, so redacted transcripts still
+// read naturally. Order matters: longer number shapes go first.
+var transcriptPatterns = []struct {
+	pattern *regexp.Regexp
+	replace string
+}{
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+	{regexp.MustCompile(`\b(?:[0-9][ -]?){12,18}[0-9]\b`), "[CARD]"},
+	{regexp.MustCompile(`\b[0-9]{3}-[0-9]{2}-[0-9]{4}\b`), "[SSN]"},
+	{regexp.MustCompile(`(?:\+|\b)[0-9(][0-9 ().-]{5,}[0-9]\b`), "[PHONE]"},
+	{regexp.MustCompile(`\b[0-9]{5,}\b`), "[NUMBER]"},
+}
+
+// RedactTranscript replaces e-mail addresses, card, social security,
+// phone and other long numbers in what was said, and the given names
+// (e.g. the caller's), with placeholders
+func RedactTranscript(text string, names ...string) string {
+	for _, p := range transcriptPatterns {
+		text = p.pattern.ReplaceAllString(text, p.replace)
+	}
+	for _, name := range names {
+		for _, part := range strings.Fields(name) {
+			if len(part) < 3 {
+				continue
+			}
+			text = regexp.MustCompile(`(?i)\b`+regexp.QuoteMeta(part)+`\b`).ReplaceAllString(text, "[NAME]")
+		}
+	}
+	return text
+}
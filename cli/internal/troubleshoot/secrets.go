@@ -0,0 +1,246 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Kinds of credentials found in logs
+const (
+	SecretAPIKey        = "API key"
+	SecretJWT           = "JWT"
+	SecretAuthorization = "authorization header"
+	SecretURL           = "password in URL"
+	SecretARI           = "ARI credentials"
+	SecretSIPPassword   = "SIP password"
+	SecretPassword      = "password"
+	SecretToken         = "token"
+	SecretWebhook       = "webhook URL"
+)
+
+// secretTokenPatterns match values that are credentials by their shape,
+// wherever they appear
+var secretTokenPatterns = []struct {
+	kind    string
+	pattern *regexp.Regexp
+}{
+	{SecretJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{8,}\.eyJ[A-Za-z0-9_-]{8,}\.[A-Za-z0-9_-]{8,}`)},
+	// OpenAI, Anthropic (sk-...) and ElevenLabs (sk_...) keys
+	{SecretAPIKey, regexp.MustCompile(`\bsk[-_][A-Za-z0-9_-]{20,}`)},
+	// Google Cloud and Gemini keys
+	{SecretAPIKey, regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`)},
+	// AWS access key IDs
+	{SecretAPIKey, regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	// Telegram bot tokens, alone or in the API path (/bot<id>:<token>/)
+	{SecretToken, regexp.MustCompile(`(?:\bbot|\b)[0-9]{6,12}:[A-Za-z0-9_-]{30,}`)},
+	// Webhook URLs whose path is the credential: Teams, Slack, Discord
+	{SecretWebhook, regexp.MustCompile(`(?i)\bhttps://[a-z0-9.-]*(?:webhook\.office\.com|hooks\.slack\.com|discord(?:app)?\.com/api/webhooks)/[^\s"'<>]+`)},
+}
+
+// Credentials recognized by what precedes them; the last submatch is
+// the secret
+var (
+	secretHeaderPattern = regexp.MustCompile(`(?i)(authorization['"]?\s*[:=]\s*['"]?(?:bearer|token|basic)\s+)([A-Za-z0-9._~+/=-]{8,})`)
+	secretURLPattern    = regexp.MustCompile(`(?i)(\b[a-z][a-z0-9+.-]*://[^/\s:@"']+:)([^@\s/"']+)@`)
+	secretFieldPattern  = regexp.MustCompile(`(?i)((?:^|[^A-Za-z0-9_-])['"]?(api[_-]?key|apikey|xi-api-key|access[_-]?token|auth[_-]?token|refresh[_-]?token|client[_-]?secret|token|secret|md5_cred|password|passwd)['"]?\s*[:=]\s*['"]?)([^"'\s,;&}]+)`)
+)
+
+// secretPlaceholders are values that only look like credentials
+var secretPlaceholders = []string{"redacted", "***", "xxxx", "changeme", "your_", "your-", "${", "<", "null", "none", "true", "false"}
+
+// secretMatch is one credential in a line
+type secretMatch struct {
+	kind       string
+	start, end int
+}
+
+// findSecrets returns the credentials in line, in order and without
+// overlaps
+func findSecrets(line string) []secretMatch {
+	var found []secretMatch
+	add := func(kind string, start, end int) {
+		for _, m := range found {
+			if start < m.end && end > m.start {
+				return
+			}
+		}
+		found = append(found, secretMatch{kind, start, end})
+	}
+
+	for _, p := range secretTokenPatterns {
+		for _, loc := range p.pattern.FindAllStringIndex(line, -1) {
+			add(p.kind, loc[0], loc[1])
+		}
+	}
+	for _, loc := range secretHeaderPattern.FindAllStringSubmatchIndex(line, -1) {
+		if !secretPlaceholder(line[loc[4]:loc[5]]) {
+			add(SecretAuthorization, loc[4], loc[5])
+		}
+	}
+	for _, loc := range secretURLPattern.FindAllStringSubmatchIndex(line, -1) {
+		if !secretPlaceholder(line[loc[4]:loc[5]]) {
+			add(SecretURL, loc[4], loc[5])
+		}
+	}
+	lower := strings.ToLower(line)
+	for _, loc := range secretFieldPattern.FindAllStringSubmatchIndex(line, -1) {
+		key, value := strings.ToLower(line[loc[4]:loc[5]]), line[loc[6]:loc[7]]
+		if secretPlaceholder(value) {
+			continue
+		}
+		kind := SecretAPIKey
+		switch {
+		case strings.Contains(key, "pass") || key == "secret" || key == "md5_cred":
+			// Passwords are short; other secrets are not
+			if len(value) < 4 {
+				continue
+			}
+			kind = SecretPassword
+			if strings.Contains(lower, "sip") || strings.Contains(lower, "endpoint") || key == "md5_cred" {
+				kind = SecretSIPPassword
+			}
+		case strings.Contains(key, "token"):
+			kind = SecretToken
+			if len(value) < 12 {
+				continue
+			}
+		case strings.Contains(value, ":"):
+			// ARI's websocket URL carries api_key=user:password
+			kind = SecretARI
+		default:
+			if len(value) < 12 {
+				continue
+			}
+		}
+		add(kind, loc[6], loc[7])
+	}
+
+	sort.Slice(found, func(i, j int) bool { return found[i].start < found[j].start })
+	return found
+}
+
+func secretPlaceholder(value string) bool {
+	lower := strings.ToLower(value)
+	for _, p := range secretPlaceholders {
+		if strings.Contains(lower, p) {
+			return true
+		}
+	}
+	// Counts, IDs and the like
+	if _, err := strconv.ParseFloat(value, 64); err == nil {
+		return true
+	}
+	return strings.Trim(value, value[:1]) == ""
+}
+
+// RedactSecrets replaces API keys, JWTs, passwords and other credentials
+// in text with [REDACTED], leaving the rest of each line as it was
+func RedactSecrets(text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		found := findSecrets(line)
+		for j := len(found) - 1; j >= 0; j-- {
+			line = line[:found[j].start] + redacted + line[found[j].end:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
+
+// maskSecret keeps enough of a credential to tell which one it is
+func maskSecret(secret string) string {
+	if len(secret) <= 8 {
+		return "****"
+	}
+	return fmt.Sprintf("%s…(%d chars)", secret[:4], len(secret))
+}
+
+// asteriskModulePattern finds the source file of an Asterisk log line,
+// e.g. "res_pjsip_outbound_registration.c"
+var asteriskModulePattern = regexp.MustCompile(`\]\s+([a-z0-9_]+\.c):`)
+
+// logComponent names the logger that wrote a line
+func logComponent(ev *LogEvent) string {
+	for _, key := range []string{"component", "logger", "service"} {
+		if s := ev.String(key); s != "" {
+			return s
+		}
+	}
+	if m := asteriskModulePattern.FindStringSubmatch(ev.Line); m != nil {
+		return "asterisk " + m[1]
+	}
+	return "unknown component"
+}
+
+// leak is what one component wrote
+type leak struct {
+	component string
+	kinds     map[string]int
+	count     int
+	evidence  string
+}
+
+// secretsAnalyzer finds credentials printed in the logs and names the
+// components that wrote them
+type secretsAnalyzer struct {
+	leaks []*leak
+}
+
+func (a *secretsAnalyzer) Name() string { return "secrets" }
+
+func (a *secretsAnalyzer) Observe(ev *LogEvent) {
+	found := findSecrets(ev.Line)
+	if len(found) == 0 {
+		return
+	}
+	component := logComponent(ev)
+	var l *leak
+	for _, existing := range a.leaks {
+		if existing.component == component {
+			l = existing
+		}
+	}
+	if l == nil {
+		l = &leak{component: component, kinds: make(map[string]int)}
+		a.leaks = append(a.leaks, l)
+	}
+	for _, m := range found {
+		l.kinds[m.kind]++
+		l.count++
+	}
+	if l.evidence == "" {
+		line := ev.Line
+		for j := len(found) - 1; j >= 0; j-- {
+			line = line[:found[j].start] + maskSecret(line[found[j].start:found[j].end]) + line[found[j].end:]
+		}
+		l.evidence = line
+	}
+}
+
+func (a *secretsAnalyzer) Finish(analysis *Analysis) []Finding {
+	if len(a.leaks) == 0 {
+		return nil
+	}
+	var findings []Finding
+	total := 0
+	for _, l := range a.leaks {
+		total += l.count
+		kinds := make([]string, 0, len(l.kinds))
+		for kind, n := range l.kinds {
+			kinds = append(kinds, fmt.Sprintf("%s ×%d", kind, n))
+		}
+		sort.Strings(kinds)
+		findings = append(findings, Finding{
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("%s is leaking credentials into the logs (%s)", l.component, strings.Join(kinds, ", ")),
+			Evidence: truncate(l.evidence, 200),
+			Fix: "Rotate the exposed credentials, then stop " + l.component + " from logging them (log the error, not the request, URL or headers; " +
+				"src/logging_config.py's sanitize_secrets only redacts known field names). Bundles, tickets and reports from agent troubleshoot have them redacted.",
+		})
+	}
+	analysis.MetricsMap["secrets_leaked"] = strconv.Itoa(total)
+	return findings
+}
@@ -0,0 +1,512 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/remote"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// SecuritySources locates Asterisk's security events, fail2ban's actions
+// and the trunks whose addresses matter. Empty fields use the defaults of
+// a host install.
+type SecuritySources struct {
+	// AsteriskLogs are Asterisk log files: the security log (logger.conf
+	// security => security) and full or messages for NOTICE lines
+	AsteriskLogs []string
+	Fail2banLog  string
+	// AsteriskDir holds pjsip*.conf, read for the trunks' addresses
+	AsteriskDir string
+}
+
+// Default security sources
+var (
+	DefaultAsteriskLogs = []string{"/var/log/asterisk/security", "/var/log/asterisk/full", "/var/log/asterisk/messages"}
+	DefaultFail2banLog  = "/var/log/fail2ban.log"
+	DefaultAsteriskDir  = "/etc/asterisk"
+)
+
+const (
+	// securityLookback is how long before the call failed auth attempts
+	// count; bans are followed from banLookback, as they last for hours
+	securityLookback = 15 * time.Minute
+	banLookback      = 24 * time.Hour
+	// attackAttempts failed attempts within the window are an attack
+	attackAttempts = 20
+)
+
+// Security event kinds
+const (
+	SecurityBan        = "ban"
+	SecurityUnban      = "unban"
+	SecurityFailedAuth = "failed_auth"
+	SecurityNoEndpoint = "invalid_account"
+	SecurityACL        = "acl_rejected"
+)
+
+// SecurityEvent is one Asterisk security event or fail2ban action
+type SecurityEvent struct {
+	Time   time.Time `json:"time"`
+	Source string    `json:"source"`
+	Kind   string    `json:"kind"`
+	IP     string    `json:"ip"`
+	Jail   string    `json:"jail,omitempty"`
+	Detail string    `json:"detail,omitempty"`
+}
+
+var (
+	// SecurityEvent="ChallengeResponseFailed",EventTV="2024-01-05T10:00:00.123+0000",...,RemoteAddress="IPV4/UDP/198.51.100.7/5060"
+	astSecurityEvent = regexp.MustCompile(`SecurityEvent="([^"]+)"`)
+	astEventTV       = regexp.MustCompile(`EventTV="([^"]+)"`)
+	astRemoteAddress = regexp.MustCompile(`RemoteAddress="IPV[46]/[A-Z]+/([^/"]+)/`)
+	astAccountID     = regexp.MustCompile(`AccountID="([^"]*)"`)
+	// [2024-01-05 10:00:00] NOTICE[123] res_pjsip/pjsip_distributor.c: Request 'REGISTER' from '...' failed for '198.51.100.7:5060' (callid: ...) - No matching endpoint found
+	astLogTime   = regexp.MustCompile(`^\[(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2})`)
+	astFailedFor = regexp.MustCompile(`(?:Request '(\w+)' from .* failed|Registration from .* failed) for '\[?([0-9a-fA-F.:]+?)\]?(?::\d+)?'.* - (.+)$`)
+	// 2024-01-05 10:00:00,123 fail2ban.actions [123]: NOTICE [asterisk] Ban 198.51.100.7
+	f2bAction = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2} \d{2}:\d{2}:\d{2}),\d+\s+fail2ban\.actions\s+\[\d+\]:\s+\w+\s+\[([^\]]+)\]\s+(Restore Ban|Ban|Unban)\s+(\S+)`)
+)
+
+// astSecurityKinds maps Asterisk's SecurityEvent names to kinds; the
+// others (ChallengeSent, SuccessfulAuth) are normal traffic
+var astSecurityKinds = map[string]string{
+	"InvalidAccountID":        SecurityNoEndpoint,
+	"ChallengeResponseFailed": SecurityFailedAuth,
+	"InvalidPassword":         SecurityFailedAuth,
+	"AuthMethodNotAllowed":    SecurityFailedAuth,
+	"FailedACL":               SecurityACL,
+	"RequestNotAllowed":       SecurityACL,
+	"UnexpectedAddress":       SecurityACL,
+}
+
+// readSecurityEvents reads the events between since and until from the
+// Asterisk logs and fail2ban's log; files that do not exist are skipped
+func readSecurityEvents(src SecuritySources, since, until time.Time) []SecurityEvent {
+	var events []SecurityEvent
+	keep := func(ev SecurityEvent) {
+		if !ev.Time.Before(since) && !ev.Time.After(until) && ev.IP != "" {
+			events = append(events, ev)
+		}
+	}
+	for _, path := range src.AsteriskLogs {
+		scanLog(path, func(line string) {
+			if ev, ok := ParseAsteriskSecurity(line); ok {
+				keep(ev)
+			}
+		})
+	}
+	scanLog(src.Fail2banLog, func(line string) {
+		if ev, ok := parseFail2ban(line); ok {
+			keep(ev)
+		}
+	})
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Time.Before(events[j].Time) })
+	return events
+}
+
+// scanLog feeds the lines of path and its first rotation to fn
+func scanLog(path string, fn func(string)) {
+	if path == "" {
+		return
+	}
+	for _, p := range []string{path + ".1", path} {
+		data, err := remote.ReadFile(p)
+		if err != nil {
+			continue
+		}
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			fn(scanner.Text())
+		}
+	}
+}
+
+// ParseAsteriskSecurity reads a security log event or a NOTICE line
+// about a rejected request
+func ParseAsteriskSecurity(line string) (SecurityEvent, bool) {
+	ev := SecurityEvent{Source: "asterisk"}
+	if m := astSecurityEvent.FindStringSubmatch(line); m != nil {
+		kind, ok := astSecurityKinds[m[1]]
+		if !ok {
+			return ev, false
+		}
+		ev.Kind = kind
+		ev.Detail = m[1]
+		if a := astAccountID.FindStringSubmatch(line); a != nil && a[1] != "" {
+			ev.Detail += " (account " + a[1] + ")"
+		}
+		if a := astRemoteAddress.FindStringSubmatch(line); a != nil {
+			ev.IP = a[1]
+		}
+		if tv := astEventTV.FindStringSubmatch(line); tv != nil {
+			ev.Time = parseEventTV(tv[1])
+		}
+		if ev.Time.IsZero() {
+			ev.Time = asteriskLogTime(line)
+		}
+		return ev, !ev.Time.IsZero()
+	}
+	if !strings.Contains(line, "NOTICE") || !strings.Contains(line, "failed") {
+		return ev, false
+	}
+	m := astFailedFor.FindStringSubmatch(line)
+	if m == nil {
+		return ev, false
+	}
+	ev.IP = m[2]
+	ev.Detail = strings.TrimSpace(m[3])
+	ev.Kind = SecurityFailedAuth
+	if strings.Contains(strings.ToLower(ev.Detail), "no matching") {
+		ev.Kind = SecurityNoEndpoint
+	}
+	if m[1] != "" {
+		ev.Detail = m[1] + ": " + ev.Detail
+	}
+	ev.Time = asteriskLogTime(line)
+	return ev, !ev.Time.IsZero()
+}
+
+// parseEventTV reads EventTV, ISO in current Asterisk and seconds-usecs
+// in old versions
+func parseEventTV(tv string) time.Time {
+	for _, layout := range []string{"2006-01-02T15:04:05.000-0700", "2006-01-02T15:04:05.000000-0700", "2006-01-02T15:04:05-0700"} {
+		if t, err := time.Parse(layout, tv); err == nil {
+			return t
+		}
+	}
+	var secs, usecs int64
+	if _, err := fmt.Sscanf(tv, "%d-%d", &secs, &usecs); err == nil && secs > 1000000000 {
+		return time.Unix(secs, usecs*1000)
+	}
+	return time.Time{}
+}
+
+// asteriskLogTime reads the [2024-01-05 10:00:00] prefix, in the host's
+// zone like Asterisk writes it
+func asteriskLogTime(line string) time.Time {
+	m := astLogTime.FindStringSubmatch(line)
+	if m == nil {
+		return time.Time{}
+	}
+	t, _ := time.ParseInLocation("2006-01-02 15:04:05", m[1], time.Local)
+	return t
+}
+
+func parseFail2ban(line string) (SecurityEvent, bool) {
+	m := f2bAction.FindStringSubmatch(line)
+	if m == nil {
+		return SecurityEvent{}, false
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", m[1], time.Local)
+	if err != nil {
+		return SecurityEvent{}, false
+	}
+	kind := SecurityBan
+	if m[3] == "Unban" {
+		kind = SecurityUnban
+	}
+	return SecurityEvent{Time: t, Source: "fail2ban", Kind: kind, IP: m[4], Jail: m[2], Detail: m[3]}, true
+}
+
+// currentBans asks fail2ban which IPs each jail bans now; empty without
+// fail2ban-client or the rights to use it
+func currentBans(ctx context.Context) map[string]string {
+	bans := make(map[string]string)
+	out, err := selflog.Output(remote.Command(ctx, "fail2ban-client", "status"))
+	if err != nil {
+		return bans
+	}
+	// `- Jail list:	asterisk, sshd
+	var jails []string
+	for _, line := range strings.Split(string(out), "\n") {
+		if i := strings.Index(line, "Jail list:"); i >= 0 {
+			for _, j := range strings.Split(line[i+len("Jail list:"):], ",") {
+				if j = strings.TrimSpace(j); j != "" {
+					jails = append(jails, j)
+				}
+			}
+		}
+	}
+	for _, jail := range jails {
+		out, err := selflog.Output(remote.Command(ctx, "fail2ban-client", "status", jail))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(out), "\n") {
+			if i := strings.Index(line, "Banned IP list:"); i >= 0 {
+				for _, ip := range strings.Fields(line[i+len("Banned IP list:"):]) {
+					bans[ip] = jail
+				}
+			}
+		}
+	}
+	return bans
+}
+
+var (
+	pjsipMatch   = regexp.MustCompile(`(?i)^\s*match\s*=\s*(.+)$`)
+	pjsipContact = regexp.MustCompile(`(?i)^\s*(?:contact|server_uri|outbound_proxy)\s*=\s*(?:sips?:)?(?:[^@\s]*@)?\[?([A-Za-z0-9.:-]+?)\]?(?::\d+)?(?:[;>\s].*)?$`)
+)
+
+// trunkAddresses reads the ITSPs' addresses from the pjsip
+// configuration: identify matches and the hosts of contacts and server
+// URIs, with names resolved. Each address maps to the line it came from.
+func trunkAddresses(ctx context.Context, dir string) map[string]string {
+	addrs := make(map[string]string)
+	files, _ := filepath.Glob(filepath.Join(dir, "pjsip*.conf"))
+	var resolver net.Resolver
+	add := func(host, origin string) {
+		host = strings.TrimSpace(host)
+		if i := strings.Index(host, "/"); i >= 0 {
+			// CIDR matches: the network address stands for the range
+			host = host[:i]
+		}
+		if host == "" {
+			return
+		}
+		if net.ParseIP(host) != nil {
+			addrs[host] = origin
+			return
+		}
+		lookup, cancel := context.WithTimeout(ctx, 3*time.Second)
+		defer cancel()
+		ips, err := resolver.LookupHost(lookup, host)
+		if err != nil {
+			return
+		}
+		for _, ip := range ips {
+			addrs[ip] = origin + " (" + host + ")"
+		}
+	}
+	for _, file := range files {
+		data, err := remote.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if i := strings.Index(line, ";"); i >= 0 {
+				line = line[:i]
+			}
+			if m := pjsipMatch.FindStringSubmatch(line); m != nil {
+				for _, h := range strings.Split(m[1], ",") {
+					add(h, filepath.Base(file)+": "+strings.TrimSpace(line))
+				}
+			} else if m := pjsipContact.FindStringSubmatch(line); m != nil {
+				add(m[1], filepath.Base(file)+": "+strings.TrimSpace(line))
+			}
+		}
+	}
+	return addrs
+}
+
+// correlateSecurity adds findings when the call fell in a registration
+// attack or fail2ban banned a trunk's address: calls then fail although
+// the engine is healthy
+func (r *Runner) correlateSecurity(analysis *Analysis, logData string) {
+	tl := BuildTimeline(r.callID, logData, r.logLoc)
+	start, end := tl.Start, tl.End
+	if start.IsZero() {
+		ts, ok := callIDTime(r.callID)
+		if !ok {
+			return
+		}
+		start, end = ts, ts
+	}
+	src := r.security
+	if src.AsteriskLogs == nil {
+		src.AsteriskLogs = DefaultAsteriskLogs
+	}
+	if src.Fail2banLog == "" {
+		src.Fail2banLog = DefaultFail2banLog
+	}
+	if src.AsteriskDir == "" {
+		src.AsteriskDir = DefaultAsteriskDir
+	}
+
+	events := readSecurityEvents(src, start.Add(-banLookback), end.Add(time.Minute))
+	bans := currentBans(r.ctx)
+	if len(events) == 0 && len(bans) == 0 {
+		if r.verbose {
+			fmt.Println("[DEBUG] No Asterisk security events or fail2ban actions found")
+		}
+		return
+	}
+	trunks := trunkAddresses(r.ctx, src.AsteriskDir)
+	analysis.Findings = append(analysis.Findings, securityFindings(events, bans, trunks, start, end, r.loc, analysis.MetricsMap)...)
+	sortFindings(analysis.Findings)
+}
+
+// securityFindings correlates the events with a call from start to end
+func securityFindings(events []SecurityEvent, bans, trunks map[string]string, start, end time.Time, loc *time.Location, metrics map[string]string) []Finding {
+	var findings []Finding
+	windowStart := start.Add(-securityLookback)
+
+	// Bans in force during the call: banned before its end, not lifted
+	// before its start
+	banned := make(map[string]SecurityEvent)
+	var bansInWindow []SecurityEvent
+	for _, ev := range events {
+		if ev.Time.After(end) {
+			break
+		}
+		switch ev.Kind {
+		case SecurityBan:
+			banned[ev.IP] = ev
+			if !ev.Time.Before(windowStart) {
+				bansInWindow = append(bansInWindow, ev)
+			}
+		case SecurityUnban:
+			if ev.Time.Before(start) {
+				delete(banned, ev.IP)
+			}
+		}
+	}
+	ips := make([]string, 0, len(banned))
+	for ip := range banned {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		origin, ok := trunks[ip]
+		if !ok {
+			continue
+		}
+		ev := banned[ip]
+		findings = append(findings, Finding{
+			Analyzer: "security",
+			Severity: SeverityCritical,
+			Message:  fmt.Sprintf("Trunk address %s was banned by fail2ban (jail %s) during the call", ip, ev.Jail),
+			Evidence: fmt.Sprintf("banned %s; trunk from %s", ev.Time.In(loc).Format("2006-01-02 15:04:05"), origin),
+			Fix:      fmt.Sprintf("fail2ban-client set %s unbanip %s, and add the ITSP's addresses to ignoreip in jail.local", ev.Jail, ip),
+		})
+	}
+	for _, ip := range sortedKeys(bans) {
+		jail := bans[ip]
+		if _, reported := banned[ip]; reported {
+			continue
+		}
+		if origin, ok := trunks[ip]; ok {
+			findings = append(findings, Finding{
+				Analyzer: "security",
+				Severity: SeverityCritical,
+				Message:  fmt.Sprintf("Trunk address %s is banned by fail2ban (jail %s) now", ip, jail),
+				Evidence: "trunk from " + origin,
+				Fix:      fmt.Sprintf("fail2ban-client set %s unbanip %s, and add the ITSP's addresses to ignoreip in jail.local", jail, ip),
+			})
+		}
+	}
+
+	// Failed registrations and requests around the call
+	attempts := 0
+	perIP := make(map[string]int)
+	trunkFailures := make(map[string]SecurityEvent)
+	for _, ev := range events {
+		if ev.Time.Before(windowStart) || ev.Time.After(end) {
+			continue
+		}
+		switch ev.Kind {
+		case SecurityFailedAuth, SecurityNoEndpoint, SecurityACL:
+			attempts++
+			perIP[ev.IP]++
+			if _, ok := trunks[ev.IP]; ok {
+				trunkFailures[ev.IP] = ev
+			}
+		}
+	}
+	metrics["security_failed_requests"] = fmt.Sprintf("%d", attempts)
+	metrics["security_bans"] = fmt.Sprintf("%d", len(bansInWindow))
+
+	for _, ip := range sortedSecurityIPs(trunkFailures) {
+		ev := trunkFailures[ip]
+		if _, isBanned := banned[ip]; isBanned {
+			continue
+		}
+		findings = append(findings, Finding{
+			Analyzer: "security",
+			Severity: SeverityWarning,
+			Message:  fmt.Sprintf("Requests from trunk address %s were rejected around the call (%d)", ip, perIP[ip]),
+			Evidence: ev.Detail,
+			Fix:      "Check the trunk's identify match and credentials (agent sip wizard); repeated rejections get it banned by fail2ban",
+		})
+	}
+	if attempts > 0 {
+		severity := SeverityInfo
+		message := fmt.Sprintf("%d failed SIP requests from %d address(es) around the call", attempts, len(perIP))
+		fix := ""
+		if attempts >= attackAttempts {
+			severity = SeverityWarning
+			message = fmt.Sprintf("Registration attack around the call: %d failed SIP requests from %d address(es)", attempts, len(perIP))
+			fix = "Restrict SIP to the ITSP's addresses (agent network rules), keep fail2ban's asterisk jail enabled"
+		}
+		findings = append(findings, Finding{
+			Analyzer: "security",
+			Severity: severity,
+			Message:  message,
+			Evidence: topAddresses(perIP, 3),
+			Fix:      fix,
+		})
+	}
+	if len(bansInWindow) > 0 {
+		var list []string
+		for _, ev := range bansInWindow {
+			list = append(list, ev.IP+" ("+ev.Jail+")")
+		}
+		findings = append(findings, Finding{
+			Analyzer: "security",
+			Severity: SeverityInfo,
+			Message:  fmt.Sprintf("fail2ban banned %d address(es) around the call", len(bansInWindow)),
+			Evidence: truncate(strings.Join(list, ", "), 200),
+		})
+	}
+	return findings
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedSecurityIPs(m map[string]SecurityEvent) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// topAddresses lists the n addresses with the most attempts
+func topAddresses(perIP map[string]int, n int) string {
+	ips := make([]string, 0, len(perIP))
+	for ip := range perIP {
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		if perIP[ips[i]] != perIP[ips[j]] {
+			return perIP[ips[i]] > perIP[ips[j]]
+		}
+		return ips[i] < ips[j]
+	})
+	if len(ips) > n {
+		ips = ips[:n]
+	}
+	parts := make([]string, len(ips))
+	for i, ip := range ips {
+		parts[i] = fmt.Sprintf("%s: %d", ip, perIP[ip])
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,121 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Call status values stored in Call.Status
+const (
+	CallCompleted   = "completed"
+	CallFailed      = "failed"
+	CallAbandoned   = "abandoned"
+	CallTransferred = "transferred"
+)
+
+// CallStatuses lists the valid status values
+var CallStatuses = []string{CallCompleted, CallFailed, CallAbandoned, CallTransferred}
+
+const (
+	// abandonThreshold: calls shorter than this with no agent audio were abandoned
+	abandonThreshold = 10 * time.Second
+
+	// failureErrorThreshold: this many error lines mark a call as failed
+	failureErrorThreshold = 3
+)
+
+var hangupCausePattern = regexp.MustCompile(`"?(?:hangup_cause|cause)"?\s*[=:]\s*"?([0-9]{1,3})\b`)
+
+// failureCauses are Q.850 hangup causes that indicate a network/setup failure
+var failureCauses = map[int]bool{
+	1: true, 3: true, 21: true, 27: true, 34: true, 38: true, 41: true, 42: true,
+	44: true, 47: true, 50: true, 58: true, 63: true, 65: true, 79: true, 88: true,
+	102: true, 111: true, 127: true,
+}
+
+// abandonCauses are causes where the other party never engaged
+var abandonCauses = map[int]bool{17: true, 18: true, 19: true}
+
+// callSignals accumulates per-call evidence used for status classification
+type callSignals struct {
+	errors      int
+	fatal       bool
+	transferred bool
+	agentSpoke  bool
+	hangupCause int
+}
+
+// observe records the evidence carried by one log line of the call
+func (s *callSignals) observe(line string) {
+	lower := strings.ToLower(line)
+
+	if strings.Contains(lower, "error") && !strings.Contains(lower, "0 error") {
+		s.errors++
+	}
+	if strings.Contains(lower, "traceback") || strings.Contains(lower, "failed to handle caller stasisstart") {
+		s.fatal = true
+	}
+	if strings.Contains(lower, "transferred") || strings.Contains(lower, "transfer_target") {
+		s.transferred = true
+	}
+	if strings.Contains(lower, "playback") || strings.Contains(lower, "streaming tuning summary") || strings.Contains(lower, "tts") {
+		s.agentSpoke = true
+	}
+	if m := hangupCausePattern.FindStringSubmatch(line); len(m) > 1 {
+		if cause, err := strconv.Atoi(m[1]); err == nil {
+			s.hangupCause = cause
+		}
+	}
+}
+
+// classifyCall derives the call status from hangup cause, duration and errors
+func classifyCall(call Call, s *callSignals) string {
+	if s == nil {
+		return ""
+	}
+	if s.transferred {
+		return CallTransferred
+	}
+	if s.fatal || failureCauses[s.hangupCause] || s.errors >= failureErrorThreshold {
+		return CallFailed
+	}
+	if abandonCauses[s.hangupCause] {
+		return CallAbandoned
+	}
+	if !call.EndTime.IsZero() && call.EndTime.Sub(call.Timestamp) < abandonThreshold && !s.agentSpoke {
+		return CallAbandoned
+	}
+	return CallCompleted
+}
+
+// ValidateStatuses checks each value of a comma-separated status list
+// against CallStatuses
+func ValidateStatuses(list string) error {
+	for _, s := range strings.Split(list, ",") {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		valid := false
+		for _, status := range CallStatuses {
+			valid = valid || strings.EqualFold(s, status)
+		}
+		if !valid {
+			return fmt.Errorf("unknown status %q (use %s)", s, strings.Join(CallStatuses, ", "))
+		}
+	}
+	return nil
+}
+
+// statusMatches reports whether status is one of the comma-separated wanted values
+func statusMatches(status, wanted string) bool {
+	for _, w := range strings.Split(wanted, ",") {
+		if strings.EqualFold(strings.TrimSpace(w), status) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+package troubleshoot
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+)
+
+const (
+	// streamIdleTimeout ends calls that log nothing for this long, for
+	// engines that crash or lose the end-of-call line
+	streamIdleTimeout = 10 * time.Minute
+	// streamLineLimit bounds the lines kept per active call for the
+	// end-of-call analysis
+	streamLineLimit = 20000
+)
+
+// CallStream turns engine log lines into live call events: a call
+// starting, each completed turn with its latency, and the call ending,
+// with its fingerprint when it failed. Events are timed by the log line
+// when it has a timestamp, so replayed history keeps its times.
+type CallStream struct {
+	mu      sync.Mutex
+	ctx     context.Context
+	logLoc  *time.Location
+	tracker *callTracker
+	active  map[string]*streamCall
+	ended   map[string]time.Time
+	publish func(events.Event)
+}
+
+// streamCall is an active call and the lines it logged so far
+type streamCall struct {
+	lines   []string
+	turns   int
+	lastLog time.Time
+}
+
+// NewCallStream creates a stream publishing to publish. Zone-less log
+// timestamps are read in logLoc; ctx bounds end-of-call analyses.
+func NewCallStream(ctx context.Context, logLoc *time.Location, publish func(events.Event)) *CallStream {
+	if logLoc == nil {
+		logLoc = time.UTC
+	}
+	return &CallStream{
+		ctx:     ctx,
+		logLoc:  logLoc,
+		tracker: newCallTracker(logLoc, false),
+		active:  make(map[string]*streamCall),
+		ended:   make(map[string]time.Time),
+		publish: publish,
+	}
+}
+
+// Observe processes one engine log line
+func (s *CallStream) Observe(raw string) {
+	line := ansiStripPattern.ReplaceAllString(strings.TrimRight(raw, "\r\n"), "")
+	if line == "" {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tracker.observe(line)
+	callID := lineCallID(line)
+	if callID == "" || s.tracker.audioSocket[callID] {
+		return
+	}
+	if _, done := s.ended[callID]; done {
+		return
+	}
+
+	now := time.Now()
+	at := now
+	if t, ok := parseLogTimestamp(line, s.logLoc); ok {
+		at = t
+	}
+	sc := s.active[callID]
+	if sc == nil {
+		sc = &streamCall{}
+		s.active[callID] = sc
+		data := map[string]interface{}{}
+		if call := s.tracker.calls[callID]; call != nil {
+			addCallParties(data, call)
+		}
+		s.publish(events.Event{Type: events.CallStarted, Time: at, CallID: callID, Data: data})
+	}
+	sc.lastLog = now
+	if len(sc.lines) < streamLineLimit {
+		sc.lines = append(sc.lines, line)
+	}
+
+	le := ClassifyLiveLine(line, s.logLoc)
+	if le == nil {
+		return
+	}
+	switch le.Kind {
+	case LiveLatency:
+		sc.turns++
+		data := map[string]interface{}{
+			"turn":       sc.turns,
+			"latency_ms": le.Latency.Milliseconds(),
+		}
+		if sev := le.Severity(); sev != "" {
+			data["severity"] = sev
+		}
+		s.publish(events.Event{Type: events.TurnCompleted, Time: at, CallID: callID, Data: data})
+	case LiveEnd:
+		s.finish(callID, at)
+	}
+}
+
+// Sweep ends calls idle for streamIdleTimeout and forgets old state.
+// Call it periodically.
+func (s *CallStream) Sweep() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for id, sc := range s.active {
+		if now.Sub(sc.lastLog) > streamIdleTimeout {
+			s.finish(id, now)
+		}
+	}
+	for id, at := range s.ended {
+		if now.Sub(at) > ingestTrackWindow {
+			delete(s.ended, id)
+		}
+	}
+	s.tracker.forget(now.Add(-ingestTrackWindow))
+}
+
+// Active returns the number of calls in progress
+func (s *CallStream) Active() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.active)
+}
+
+// finish publishes call_ended, and call_failed for failed calls, after
+// analyzing the call's lines off the caller's goroutine. s.mu is held.
+// at is when the call ended.
+func (s *CallStream) finish(callID string, at time.Time) {
+	sc := s.active[callID]
+	delete(s.active, callID)
+	s.ended[callID] = time.Now()
+
+	call := Call{ID: callID}
+	for _, c := range s.tracker.result() {
+		if c.ID == callID {
+			call = c
+			break
+		}
+	}
+	logData := strings.Join(sc.lines, "\n")
+	turns := sc.turns
+
+	go func() {
+		analysis := &Analysis{CallID: callID, MetricsMap: make(map[string]string)}
+		runAnalyzers(s.ctx, logData, analysis)
+		analysis.step("metrics", func() error {
+			analysis.Metrics = ExtractMetrics(logData)
+			return nil
+		})
+		report := NewReport(analysis, nil)
+
+		data := map[string]interface{}{
+			"status":        call.Status,
+			"turns":         turns,
+			"quality_score": report.Score,
+		}
+		if !call.EndTime.IsZero() && call.EndTime.After(call.Timestamp) {
+			data["duration_s"] = int(call.EndTime.Sub(call.Timestamp).Seconds())
+		}
+		addCallParties(data, &call)
+		if len(report.Incomplete) > 0 {
+			data["incomplete"] = report.Incomplete
+		}
+		if p95 := report.Metrics["turn_latency_p95_ms"]; p95 != "" {
+			data["turn_latency_p95_ms"] = p95
+		}
+		s.publish(events.Event{Type: events.CallEnded, Time: at, CallID: callID, Data: data})
+
+		if fp := Fingerprint(report, &call); fp != "" {
+			s.publish(events.Event{Type: events.CallFailed, Time: at, CallID: callID, Data: map[string]interface{}{
+				"fingerprint": fp,
+				"status":      call.Status,
+				"findings":    criticalMessages(report),
+			}})
+		}
+	}()
+}
+
+// addCallParties adds the caller details known for a call
+func addCallParties(data map[string]interface{}, call *Call) {
+	if call.CallerNumber != "" {
+		data["caller_number"] = call.CallerNumber
+	}
+	if call.CallerName != "" {
+		data["caller_name"] = call.CallerName
+	}
+	if call.Dialed != "" {
+		data["dialed"] = call.Dialed
+	}
+	if call.Tenant != "" {
+		data["tenant"] = call.Tenant
+	}
+}
+
+// FollowLogs streams a container's log lines from since to fn until ctx
+// is done or the stream ends
+func FollowLogs(ctx context.Context, container string, since time.Time, fn func(line string) bool) error {
+	client, err := docker.Default()
+	if err != nil {
+		return err
+	}
+	stream, err := client.Logs(ctx, container, docker.LogsOptions{Since: since, Follow: true})
+	if err != nil {
+		return fmt.Errorf("logs %s: %w", container, err)
+	}
+	defer stream.Close()
+
+	scanner := bufio.NewScanner(stream)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if !fn(scanner.Text()) {
+			return nil
+		}
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
@@ -5,6 +5,15 @@ import (
 	"strings"
 )
 
+// Symptoms maps the supported --symptom values to a short description
+var Symptoms = map[string]string{
+	"no-audio":     "Complete silence",
+	"garbled":      "Distorted/fast/slow audio",
+	"echo":         "Agent hears itself",
+	"interruption": "Self-interruption loop",
+	"one-way":      "Only one direction works",
+}
+
 // SymptomChecker performs symptom-specific analysis
 type SymptomChecker struct {
 	symptom string
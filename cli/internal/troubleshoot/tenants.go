@@ -0,0 +1,89 @@
+package troubleshoot
+
+import (
+	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// noTenant selects calls that belong to no tenant in a filter
+const noTenant = "none"
+
+// assignTenants sets the tenant of calls the engine did not tag, from
+// the configured DIDs and contexts
+func (r *Runner) assignTenants(calls []Call) {
+	if len(r.tenants) == 0 {
+		return
+	}
+	for i := range calls {
+		if calls[i].Tenant == "" {
+			calls[i].Tenant = TenantOf(calls[i], r.tenants)
+		}
+	}
+}
+
+// TenantOf returns the first tenant whose DIDs match the dialed number
+// or whose contexts list the call's AI or dialplan context, empty when
+// none does
+func TenantOf(call Call, tenants []settings.Tenant) string {
+	for _, t := range tenants {
+		for _, did := range t.DIDs {
+			if didMatches(call.Dialed, did) {
+				return t.Name
+			}
+		}
+	}
+	for _, t := range tenants {
+		for _, ctx := range t.Contexts {
+			if ctx == "" {
+				continue
+			}
+			if strings.EqualFold(ctx, call.Context) || strings.EqualFold(ctx, call.DialplanContext) {
+				return t.Name
+			}
+		}
+	}
+	return ""
+}
+
+// didMatches compares digits only; a trailing * matches a number range
+func didMatches(dialed, did string) bool {
+	d := digitsOnly(dialed)
+	if d == "" {
+		return false
+	}
+	if strings.HasSuffix(did, "*") {
+		prefix := digitsOnly(did)
+		return prefix != "" && strings.HasPrefix(d, prefix)
+	}
+	return d == digitsOnly(did)
+}
+
+func tenantMatches(tenant, wanted string) bool {
+	for _, w := range strings.Split(wanted, ",") {
+		w = strings.TrimSpace(w)
+		if strings.EqualFold(w, noTenant) && tenant == "" {
+			return true
+		}
+		if w != "" && strings.EqualFold(w, tenant) {
+			return true
+		}
+	}
+	return false
+}
+
+// sloFor returns the objectives of a tenant: the global ones with the
+// tenant's overrides applied
+func (r *Runner) sloFor(tenant string) settings.SLO {
+	return settings.TenantSLO(r.slo, r.tenants, tenant)
+}
+
+// filterTenant is the single tenant the filter selects, empty when it
+// selects none or several
+func (r *Runner) filterTenant() string {
+	t := strings.TrimSpace(r.filter.Tenant)
+	if t == "" || strings.Contains(t, ",") || strings.EqualFold(t, noTenant) {
+		return ""
+	}
+	return t
+}
@@ -0,0 +1,175 @@
+package troubleshoot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/jira"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// ticketCallsKept bounds the call IDs remembered per fingerprint
+const ticketCallsKept = 200
+
+// Tickets opens Jira issues for new failure fingerprints and comments on
+// the open issue when a known failure recurs
+type Tickets struct {
+	Client    *jira.Client
+	Project   string
+	IssueType string
+	Labels    []string
+}
+
+// ticketEntry remembers the issue and calls recorded for a fingerprint
+type ticketEntry struct {
+	Key     string    `json:"key"`
+	Calls   []string  `json:"calls"`
+	Updated time.Time `json:"updated"`
+}
+
+func ticketStatePath() string {
+	return filepath.Join(settings.Dir(), "tickets.json")
+}
+
+func loadTicketState() map[string]*ticketEntry {
+	state := make(map[string]*ticketEntry)
+	if data, err := os.ReadFile(ticketStatePath()); err == nil {
+		json.Unmarshal(data, &state)
+	}
+	return state
+}
+
+func saveTicketState(state map[string]*ticketEntry) error {
+	if err := os.MkdirAll(settings.Dir(), 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(state, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(ticketStatePath(), data, 0644)
+}
+
+// fileTicket records a failed call in Jira. Each call is recorded once per
+// fingerprint, so re-running batch analysis does not repeat comments.
+func (r *Runner) fileTicket(report *Report, call *Call, logData string) {
+	if r.tickets == nil {
+		return
+	}
+	fp := Fingerprint(report, call)
+	if fp == "" {
+		return
+	}
+
+	state := loadTicketState()
+	entry := state[fp]
+	if entry == nil {
+		entry = &ticketEntry{}
+		state[fp] = entry
+	}
+	for _, id := range entry.Calls {
+		if id == report.CallID {
+			return
+		}
+	}
+
+	key, created, err := r.recordFailure(fp, report, call, logData)
+	if err != nil {
+		warningColor.Printf("⚠️  Jira update failed: %v\n", r.wrapCtxErr(err))
+		return
+	}
+	if created {
+		infoColor.Printf("🎫 Opened %s for failure %s (%s)\n", key, fp, r.tickets.Client.IssueURL(key))
+	} else {
+		infoColor.Printf("🎫 Recorded call on %s (known failure %s)\n", key, fp)
+	}
+
+	entry.Key = key
+	entry.Updated = time.Now()
+	entry.Calls = append(entry.Calls, report.CallID)
+	if len(entry.Calls) > ticketCallsKept {
+		entry.Calls = entry.Calls[len(entry.Calls)-ticketCallsKept:]
+	}
+	if err := saveTicketState(state); err != nil {
+		warningColor.Printf("⚠️  Could not save ticket state: %v\n", err)
+	}
+}
+
+// recordFailure comments on the open issue for fp or creates one with the
+// sanitized report and bundle attached
+func (r *Runner) recordFailure(fp string, report *Report, call *Call, logData string) (string, bool, error) {
+	t := r.tickets
+	label := "aava-failure-" + fp
+	key, err := t.Client.FindOpenIssue(r.ctx, t.Project, label)
+	if err != nil {
+		return "", false, err
+	}
+
+	ev := newReportEvent(report, call)
+	details := ticketDetails(report, call, ev.Text)
+	if key != "" {
+		comment := fmt.Sprintf("Seen again in call %s.\n\n%s", report.CallID, details)
+		return key, false, t.Client.AddComment(r.ctx, key, comment)
+	}
+
+	issueType := t.IssueType
+	if issueType == "" {
+		issueType = "Bug"
+	}
+	key, err = t.Client.CreateIssue(r.ctx, jira.Issue{
+		Project:     t.Project,
+		Type:        issueType,
+		Summary:     "[AI voice agent] " + ev.Title,
+		Description: fmt.Sprintf("Failure fingerprint: %s\nFirst seen in call %s.\n\n%s", fp, report.CallID, details),
+		Labels:      append([]string{"aava", label}, t.Labels...),
+	})
+	if err != nil {
+		return "", false, err
+	}
+
+	reportJSON, err := json.Marshal(report)
+	if err == nil {
+		reportJSON, err = SanitizeJSON(reportJSON, "  ")
+	}
+	if err == nil {
+		err = t.Client.Attach(r.ctx, key, "analysis-"+report.CallID+".json", reportJSON)
+	}
+	if err == nil {
+		var bundle []byte
+		if bundle, err = Bundle(report, logData); err == nil {
+			err = t.Client.Attach(r.ctx, key, "bundle-"+report.CallID+".tar.gz", bundle)
+		}
+	}
+	if err != nil {
+		warningColor.Printf("⚠️  Could not attach analysis to %s: %v\n", key, err)
+	}
+	return key, true, nil
+}
+
+// ticketDetails renders the call and findings for an issue body
+func ticketDetails(report *Report, call *Call, findings string) string {
+	var lines []string
+	if call != nil {
+		if !call.Timestamp.IsZero() {
+			lines = append(lines, "Time: "+call.Timestamp.UTC().Format(time.RFC3339))
+		}
+		if call.Status != "" {
+			lines = append(lines, "Status: "+call.Status)
+		}
+		if call.HangupCause != 0 {
+			lines = append(lines, fmt.Sprintf("Hangup cause: %d", call.HangupCause))
+		}
+	}
+	lines = append(lines, fmt.Sprintf("Errors: %d, warnings: %d, quality score: %.0f", report.Errors, report.Warnings, report.Score))
+	if findings != "" {
+		lines = append(lines, "", "Findings:", Sanitize(findings))
+	}
+	if report.Diagnosis != nil && report.Diagnosis.Analysis != "" {
+		lines = append(lines, "", "AI diagnosis:", Sanitize(report.Diagnosis.Analysis))
+	}
+	return strings.Join(lines, "\n")
+}
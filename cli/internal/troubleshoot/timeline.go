@@ -0,0 +1,214 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/tracing"
+)
+
+// Stage is one timed phase of a call: setup, a playback segment, a
+// conversation turn or teardown
+type Stage struct {
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes map[string]string
+	Error      bool
+}
+
+// Timeline is a call reconstructed from its log lines
+type Timeline struct {
+	CallID string
+	Start  time.Time
+	End    time.Time
+	Stages []Stage
+	Errors int
+}
+
+// Duration returns the time between the first and last line of the call
+func (t *Timeline) Duration() time.Duration {
+	return t.End.Sub(t.Start)
+}
+
+// BuildTimeline reconstructs a call timeline from its logs. Lines without
+// a timestamp are skipped; zone-less timestamps are read in logLoc.
+func BuildTimeline(callID, logData string, logLoc *time.Location) *Timeline {
+	tl := &Timeline{CallID: callID}
+
+	var (
+		setupEnd      time.Time
+		provider      string
+		playbackStart time.Time
+		playbackKind  string
+		playbacks     int
+		turns         int
+		teardownStart time.Time
+	)
+
+	for _, raw := range strings.Split(logData, "\n") {
+		line := ansiStripPattern.ReplaceAllString(raw, "")
+		ts, ok := parseLogTimestamp(line, logLoc)
+		if !ok {
+			continue
+		}
+		if tl.Start.IsZero() || ts.Before(tl.Start) {
+			tl.Start = ts
+		}
+		if ts.After(tl.End) {
+			tl.End = ts
+		}
+
+		ev := parseLogEvent(line)
+		event := ev.Event
+		if event == "" {
+			event = line
+		}
+		lower := strings.ToLower(event)
+		if strings.EqualFold(ev.Level, "error") {
+			tl.Errors++
+		}
+
+		switch {
+		case strings.Contains(lower, "provider session started"),
+			strings.Contains(lower, "pipeline runner started"),
+			strings.Contains(lower, "ai pipeline started"):
+			if setupEnd.IsZero() {
+				setupEnd = ts
+				provider = ev.String("provider")
+				if provider == "" {
+					provider = ev.String("pipeline")
+				}
+			}
+
+		case strings.Contains(lower, "playback started"):
+			if playbackStart.IsZero() {
+				playbackStart = ts
+				playbackKind = "streaming"
+				if strings.Contains(lower, "file") || strings.Contains(lower, "bridge") {
+					playbackKind = "file"
+				}
+			}
+
+		case strings.Contains(lower, "streaming cleanup completed"),
+			strings.Contains(lower, "playbackfinished"),
+			strings.Contains(lower, "playback completed"):
+			if !playbackStart.IsZero() {
+				playbacks++
+				tl.Stages = append(tl.Stages, Stage{
+					Name:       fmt.Sprintf("playback %d", playbacks),
+					Start:      playbackStart,
+					End:        ts,
+					Attributes: map[string]string{"playback.kind": playbackKind},
+				})
+				playbackStart = time.Time{}
+			}
+
+		case strings.Contains(lower, "turn latency recorded"):
+			ms, ok := ev.Number("latency_ms")
+			if !ok || ms <= 0 {
+				continue
+			}
+			turns++
+			latency := time.Duration(ms * float64(time.Millisecond))
+			stage := Stage{
+				Name:  fmt.Sprintf("turn %d", turns),
+				Start: ts.Add(-latency),
+				End:   ts,
+				Attributes: map[string]string{
+					"turn.index":      fmt.Sprintf("%d", turns),
+					"turn.latency_ms": fmt.Sprintf("%.0f", ms),
+				},
+			}
+			stage.Error = ms >= latencyCriticalMs
+			tl.Stages = append(tl.Stages, stage)
+
+		case strings.Contains(lower, "stasis ended"),
+			strings.Contains(lower, "channeldestroyed"),
+			strings.Contains(lower, "hanging up"),
+			strings.Contains(lower, "signaling hangup"):
+			if teardownStart.IsZero() {
+				teardownStart = ts
+			}
+		}
+	}
+
+	if tl.Start.IsZero() {
+		return tl
+	}
+	if !setupEnd.IsZero() {
+		setup := Stage{Name: "setup", Start: tl.Start, End: setupEnd, Attributes: map[string]string{}}
+		if provider != "" {
+			setup.Attributes["provider"] = provider
+		}
+		tl.Stages = append(tl.Stages, setup)
+	}
+	if !playbackStart.IsZero() {
+		// Playback still running when the logs end
+		playbacks++
+		tl.Stages = append(tl.Stages, Stage{
+			Name:       fmt.Sprintf("playback %d", playbacks),
+			Start:      playbackStart,
+			End:        tl.End,
+			Attributes: map[string]string{"playback.kind": playbackKind, "playback.unterminated": "true"},
+		})
+	}
+	if !teardownStart.IsZero() {
+		tl.Stages = append(tl.Stages, Stage{Name: "teardown", Start: teardownStart, End: tl.End})
+	}
+
+	sort.SliceStable(tl.Stages, func(i, j int) bool {
+		return tl.Stages[i].Start.Before(tl.Stages[j].Start)
+	})
+	return tl
+}
+
+// Spans converts the timeline into a trace: a root span for the call with
+// one child span per stage
+func (t *Timeline) Spans(call *Call) []tracing.Span {
+	root := tracing.Span{
+		Name:   "call",
+		Start:  t.Start,
+		End:    t.End,
+		Parent: -1,
+		Attributes: map[string]string{
+			"call.id":     t.CallID,
+			"call.stages": fmt.Sprintf("%d", len(t.Stages)),
+		},
+		Error: t.Errors > 0,
+	}
+	if call != nil {
+		for key, value := range map[string]string{
+			"call.status":        call.Status,
+			"call.channel":       call.Channel,
+			"call.caller_number": call.CallerNumber,
+			"call.dialed":        call.Dialed,
+		} {
+			if value != "" {
+				root.Attributes[key] = value
+			}
+		}
+		if call.HangupCause != 0 {
+			root.Attributes["call.hangup_cause"] = fmt.Sprintf("%d", call.HangupCause)
+		}
+	}
+
+	spans := []tracing.Span{root}
+	for _, stage := range t.Stages {
+		attrs := map[string]string{"call.id": t.CallID}
+		for k, v := range stage.Attributes {
+			attrs[k] = v
+		}
+		spans = append(spans, tracing.Span{
+			Name:       stage.Name,
+			Start:      stage.Start,
+			End:        stage.End,
+			Parent:     0,
+			Attributes: attrs,
+			Error:      stage.Error,
+		})
+	}
+	return spans
+}
@@ -0,0 +1,203 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/tracing"
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
+)
+
+// exportTrace sends the call timeline to the OTLP collector, if configured,
+// and reports whether a trace was exported. Export failures are reported
+// but never fail the run.
+func (r *Runner) exportTrace(logData string) bool {
+	if r.tracer == nil {
+		return false
+	}
+	tl := BuildTimeline(r.callID, logData, r.logLoc)
+	if tl.Start.IsZero() {
+		warningColor.Println("⚠️  Trace export skipped: no timestamped log lines for this call")
+		return false
+	}
+
+	var call *Call
+	if c, ok := LoadCallIndex().Get(r.callID); ok {
+		call = c
+	}
+	if err := r.tracer.Export(r.ctx, r.callID, tl.Spans(call)); err != nil {
+		warningColor.Printf("⚠️  Trace export failed: %v\n", r.wrapCtxErr(err))
+		return false
+	}
+	if r.verbose {
+		fmt.Printf("[DEBUG] Exported %d stage span(s) to %s\n", len(tl.Stages), r.tracer.Endpoint)
+	}
+	return true
+}
+
+// TraceLookup fetches the engine's own traces for a call so latency is
+// measured from spans instead of estimated from log timestamps
+type TraceLookup struct {
+	Querier tracing.Querier
+
+	// CallIDAttribute is the span attribute holding the call ID
+	CallIDAttribute string
+
+	// TurnSpan matches (case-insensitive substring) the span names that
+	// cover one conversation turn
+	TurnSpan string
+}
+
+// SpanTiming summarizes the spans of one operation in a call's traces
+type SpanTiming = results.SpanTiming
+
+// traceWindowPadding widens the call window when searching for traces
+const traceWindowPadding = 5 * time.Minute
+
+// enrichFromTrace merges span timings from the engine's traces into the
+// analysis. Turn latency measured from spans replaces the log estimate.
+func (r *Runner) enrichFromTrace(analysis *Analysis, logData string) {
+	if r.traces == nil || r.traces.Querier == nil {
+		return
+	}
+
+	tl := BuildTimeline(r.callID, logData, r.logLoc)
+	start, end := tl.Start, tl.End
+	if start.IsZero() {
+		ts, ok := callIDTime(r.callID)
+		if !ok {
+			return
+		}
+		start, end = ts, ts.Add(time.Hour)
+	}
+
+	attr := r.traces.CallIDAttribute
+	if attr == "" {
+		attr = tracing.DefaultCallIDAttribute
+	}
+	spans, err := r.traces.Querier.FindSpans(r.ctx, attr, r.callID, start.Add(-traceWindowPadding), end.Add(traceWindowPadding))
+	if err != nil {
+		warningColor.Printf("⚠️  Trace lookup failed: %v\n", r.wrapCtxErr(err))
+		return
+	}
+	if len(spans) == 0 {
+		if r.verbose {
+			fmt.Printf("[DEBUG] No %s traces with %s=%s\n", r.traces.Querier.Name(), attr, r.callID)
+		}
+		return
+	}
+
+	analysis.TraceSource = r.traces.Querier.Name()
+	analysis.LatencyBreakdown = spanTimings(spans)
+	for _, t := range analysis.LatencyBreakdown {
+		key := "span_" + metricKey(t.Name)
+		analysis.MetricsMap[key+"_p95_ms"] = fmt.Sprintf("%.0f", t.P95Ms)
+	}
+
+	turnSpan := strings.ToLower(r.traces.TurnSpan)
+	if turnSpan == "" {
+		turnSpan = "turn"
+	}
+	var turns []float64
+	for _, s := range spans {
+		if strings.Contains(strings.ToLower(s.Name), turnSpan) {
+			turns = append(turns, msBetween(s.Start, s.End))
+		}
+	}
+	if len(turns) == 0 {
+		return
+	}
+
+	// Replace the log-estimated latency finding with the measured one
+	kept := analysis.Findings[:0]
+	for _, f := range analysis.Findings {
+		if f.Analyzer != "latency" {
+			kept = append(kept, f)
+		}
+	}
+	analysis.Findings = kept
+	for _, f := range summarizeTurnLatency(analysis, turns) {
+		f.Analyzer = "latency"
+		f.Evidence += " (measured from " + analysis.TraceSource + " spans)"
+		analysis.Findings = append(analysis.Findings, f)
+	}
+	sortFindings(analysis.Findings)
+	analysis.MetricsMap["turn_latency_source"] = analysis.TraceSource
+}
+
+// spanTimings groups spans by name, slowest operations first
+func spanTimings(spans []tracing.Span) []SpanTiming {
+	samples := make(map[string][]float64)
+	errors := make(map[string]int)
+	var names []string
+	for _, s := range spans {
+		if _, ok := samples[s.Name]; !ok {
+			names = append(names, s.Name)
+		}
+		samples[s.Name] = append(samples[s.Name], msBetween(s.Start, s.End))
+		if s.Error {
+			errors[s.Name]++
+		}
+	}
+
+	timings := make([]SpanTiming, 0, len(names))
+	for _, name := range names {
+		avg, p95, worst := latencyStats(samples[name])
+		timings = append(timings, SpanTiming{
+			Name:   name,
+			Count:  len(samples[name]),
+			AvgMs:  avg,
+			P95Ms:  p95,
+			MaxMs:  worst,
+			Errors: errors[name],
+		})
+	}
+	sort.SliceStable(timings, func(i, j int) bool {
+		return timings[i].P95Ms > timings[j].P95Ms
+	})
+	return timings
+}
+
+func msBetween(start, end time.Time) float64 {
+	return float64(end.Sub(start)) / float64(time.Millisecond)
+}
+
+// metricKey turns a span name into a metrics map key
+func metricKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '_'
+	}, name)
+}
+
+// displayLatencyBreakdown prints span timings taken from traces
+func (r *Runner) displayLatencyBreakdown(analysis *Analysis) {
+	if len(analysis.LatencyBreakdown) == 0 {
+		return
+	}
+	fmt.Printf("Latency Breakdown (%s):\n", analysis.TraceSource)
+	fmt.Printf("  %-32s %5s %8s %8s %8s\n", "SPAN", "COUNT", "AVG", "P95", "MAX")
+	limit := len(analysis.LatencyBreakdown)
+	if limit > 10 {
+		limit = 10
+	}
+	for _, t := range analysis.LatencyBreakdown[:limit] {
+		line := fmt.Sprintf("  %-32s %5d %6.0fms %6.0fms %6.0fms", truncate(t.Name, 32), t.Count, t.AvgMs, t.P95Ms, t.MaxMs)
+		if t.Errors > 0 {
+			errorColor.Printf("%s  (%d error(s))\n", line, t.Errors)
+		} else {
+			fmt.Println(line)
+		}
+	}
+	if len(analysis.LatencyBreakdown) > limit {
+		fmt.Printf("  ... and %d more operations\n", len(analysis.LatencyBreakdown)-limit)
+	}
+	fmt.Println()
+}
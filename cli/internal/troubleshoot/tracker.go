@@ -0,0 +1,144 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
+
+var (
+	ansiStripPattern   = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+	audioSocketPattern = regexp.MustCompile(`"audiosocket_channel_id":\s*"([0-9]+\.[0-9]+)"`)
+
+	callIDPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`"call_id":\s*"([0-9]+\.[0-9]+)"`),                     // JSON: "call_id": "1761518880.2191"
+		regexp.MustCompile(`(?:call_id|channel_id)[=:][\s]*"?([0-9]+\.[0-9]+)"?`), // call_id= or channel_id=
+		regexp.MustCompile(`"caller_channel_id":\s*"([0-9]+\.[0-9]+)"`),           // Explicit caller channel
+	}
+)
+
+// callTracker discovers calls in a stream of log lines: their IDs, time
+// window, caller metadata and status evidence. AudioSocket channels are
+// internal infrastructure and never reported as calls.
+type callTracker struct {
+	logLoc      *time.Location
+	verbose     bool
+	calls       map[string]*Call
+	signals     map[string]*callSignals
+	audioSocket map[string]bool
+	matches     int
+}
+
+func newCallTracker(logLoc *time.Location, verbose bool) *callTracker {
+	return &callTracker{
+		logLoc:      logLoc,
+		verbose:     verbose,
+		calls:       make(map[string]*Call),
+		signals:     make(map[string]*callSignals),
+		audioSocket: make(map[string]bool),
+	}
+}
+
+// observe processes one log line
+func (t *callTracker) observe(line string) {
+	line = ansiStripPattern.ReplaceAllString(line, "")
+
+	if m := audioSocketPattern.FindStringSubmatch(line); len(m) > 1 && !t.audioSocket[m[1]] {
+		t.audioSocket[m[1]] = true
+		if t.verbose {
+			fmt.Printf("[DEBUG] Found AudioSocket channel: %s\n", m[1])
+		}
+	}
+
+	// StasisStart lines carry caller details but no call_id field
+	if id := stasisCallerChannel(line); id != "" {
+		applyCallMetadata(t.call(id), line)
+	}
+
+	for _, pattern := range callIDPatterns {
+		m := pattern.FindStringSubmatch(line)
+		if len(m) < 2 {
+			continue
+		}
+		t.matches++
+		callID := m[1]
+		if t.audioSocket[callID] {
+			continue
+		}
+		call := t.call(callID)
+
+		// Track first/last log line to bound the call window
+		if ts, ok := parseLogTimestamp(line, t.logLoc); ok {
+			if call.Timestamp.IsZero() || ts.Before(call.Timestamp) {
+				call.Timestamp = ts
+			}
+			if ts.After(call.EndTime) {
+				call.EndTime = ts
+			}
+		}
+		applyCallMetadata(call, line)
+		if t.signals[callID] == nil {
+			t.signals[callID] = &callSignals{}
+		}
+		t.signals[callID].observe(line)
+		break // Found a match, no need to try other patterns
+	}
+}
+
+func (t *callTracker) call(id string) *Call {
+	call, ok := t.calls[id]
+	if !ok {
+		call = &Call{ID: id}
+		t.calls[id] = call
+		if t.verbose {
+			fmt.Printf("[DEBUG] Found call ID: %s\n", id)
+		}
+	}
+	return call
+}
+
+// result returns the discovered calls with derived duration and status
+func (t *callTracker) result() []Call {
+	if t.verbose {
+		fmt.Printf("[DEBUG] Total pattern matches: %d, Unique calls: %d\n", t.matches, len(t.calls))
+	}
+
+	calls := make([]Call, 0, len(t.calls))
+	for id, c := range t.calls {
+		if t.audioSocket[id] {
+			continue
+		}
+		call := *c
+		if call.Timestamp.IsZero() {
+			if ts, ok := callIDTime(call.ID); ok {
+				call.Timestamp = ts
+			} else {
+				call.Timestamp = time.Now()
+			}
+		}
+		if !call.EndTime.IsZero() && call.EndTime.After(call.Timestamp) {
+			call.Duration = formatDuration(call.EndTime.Sub(call.Timestamp))
+		}
+		if sig := t.signals[id]; sig != nil {
+			call.HangupCause = sig.hangupCause
+			call.Status = classifyCall(call, sig)
+		}
+		calls = append(calls, call)
+	}
+	return calls
+}
+
+// forget drops calls whose last line is older than the cutoff, bounding
+// memory for long-running ingestion
+func (t *callTracker) forget(cutoff time.Time) {
+	for id, call := range t.calls {
+		last := call.EndTime
+		if last.IsZero() {
+			last = call.Timestamp
+		}
+		if !last.IsZero() && last.Before(cutoff) {
+			delete(t.calls, id)
+			delete(t.signals, id)
+		}
+	}
+}
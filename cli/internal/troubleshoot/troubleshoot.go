@@ -3,54 +3,230 @@ package troubleshoot
 import (
 	"context"
 	"fmt"
-	"os/exec"
-	"regexp"
 	"sort"
 	"strings"
 	"time"
 
-	"github.com/fatih/color"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/monitoring"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/notify"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/tracing"
+	results "github.com/hkjarral/asterisk-ai-voice-agent/cli/pkg/analysis"
 )
 
 var (
-	successColor = color.New(color.FgGreen)
-	errorColor   = color.New(color.FgRed)
-	warningColor = color.New(color.FgYellow)
-	infoColor    = color.New(color.FgBlue)
+	successColor = theme.Success
+	errorColor   = theme.Error
+	warningColor = theme.Warning
+	infoColor    = theme.Info
 )
 
-// Call represents a call record
-type Call struct {
-	ID        string
-	Timestamp time.Time
-	Duration  string
-	Status    string
-	Channel   string
+// Call represents a call record. Its Tenant is the customer the call
+// belongs to (see settings.Tenant).
+type Call = results.CallRecord
+
+// DefaultContainer is the engine container read by default
+const DefaultContainer = "ai_engine"
+
+// Options configures a troubleshoot run
+type Options struct {
+	CallID      string
+	Symptom     string
+	Interactive bool
+	CollectOnly bool
+	NoLLM       bool
+	List        bool
+	All         bool
+	Verbose     bool
+
+	// Resume continues the last interrupted batch (All) run with the calls
+	// it had left, instead of listing the window again
+	Resume bool
+
+	// Chart writes the call's timeline chart to this .svg or .png file
+	Chart string
+
+	// Frames draws the engine's per-second frame counters as sparklines
+	Frames bool
+
+	// NoCache collects the call's logs again instead of reusing data an
+	// earlier run cached for the same call and window
+	NoCache bool
+
+	// NoSample analyzes every log line of very chatty calls instead of
+	// sampling their repetitive debug lines
+	NoSample bool
+
+	// Since/Until bound the docker logs window (duration or timestamp)
+	Since string
+	Until string
+
+	// Filter restricts listed/selected calls by caller and dialed number
+	Filter CallFilter
+
+	// Container is the engine container to read logs from
+	Container string
+
+	// Instances are further engine containers (scaled ai_engine_N) whose
+	// logs are read together with Container's
+	Instances []string
+
+	// IndexRetention is how long call index entries are kept (default 30d)
+	IndexRetention time.Duration
+
+	// LogSource reads logs from a remote backend; nil means docker logs
+	LogSource LogSource
+
+	// Context bounds the whole run (Ctrl-C, --timeout); defaults to Background
+	Context context.Context
+
+	// PreHooks run before log collection, PostHooks after analysis with
+	// the report JSON on stdin
+	PreHooks  []string
+	PostHooks []string
+
+	// Tracer exports each analyzed call as an OTLP trace; nil disables it
+	Tracer *tracing.Exporter
+
+	// Traces looks up the engine's traces to measure latency; nil disables it
+	Traces *TraceLookup
+
+	// Security locates Asterisk's security events and fail2ban's log,
+	// correlated with single-call analyses
+	Security SecuritySources
+
+	// MetricSinks receive per-call metrics (StatsD, InfluxDB)
+	MetricSinks []monitoring.Sink
+
+	// Notifier sends analysis outcomes to chat channels; nil disables it
+	Notifier *notify.Notifier
+
+	// Tickets files failures in Jira; nil disables it
+	Tickets *Tickets
+
+	// SLO sets objectives whose breach raises an slo_breached notification
+	SLO settings.SLO
+
+	// Costs are provider prices per call minute for the weekly report
+	Costs map[string]float64
+
+	// Tenants assign calls to customers by DID or context
+	Tenants []settings.Tenant
+
+	// BusinessHours split reports and SLOs into in-hours and after-hours
+	// calls; nil treats every call alike
+	BusinessHours *BusinessHours
+
+	// Location is the display zone, also used for zone-less --since/--until
+	// values. LogLocation is the zone of zone-less log timestamps.
+	Location    *time.Location
+	LogLocation *time.Location
 }
 
 // Runner orchestrates troubleshooting
 type Runner struct {
 	verbose     bool
 	ctx         context.Context
+	container   string
+	instances   []string
+	source      LogSource
+	retention   time.Duration
+	preHooks    []string
+	postHooks   []string
+	tracer      *tracing.Exporter
+	traces      *TraceLookup
+	security    SecuritySources
+	sinks       []monitoring.Sink
+	notifier    *notify.Notifier
+	tickets     *Tickets
+	slo         settings.SLO
+	costs       map[string]float64
+	tenants     []settings.Tenant
+	hours       *BusinessHours
 	callID      string
 	symptom     string
 	interactive bool
 	collectOnly bool
 	noLLM       bool
+	noCache     bool
+	chart       string
+	frames      bool
+	cached      *CachedCall
+	noSample    bool
+	sampling    *Sampling
+	feedback    []FeedbackRecord
 	list        bool
+	all         bool
+	resume      bool
+	since       string
+	until       string
+	filter      CallFilter
+	loc         *time.Location
+	logLoc      *time.Location
+	// truncated are the log sources that stopped short of the window
+	truncated []string
 }
 
 // NewRunner creates a new troubleshoot runner
-func NewRunner(callID, symptom string, interactive, collectOnly, noLLM, list, verbose bool) *Runner {
+func NewRunner(opts Options) *Runner {
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	container := opts.Container
+	if container == "" {
+		container = DefaultContainer
+	}
+	retention := opts.IndexRetention
+	if retention <= 0 {
+		retention = settings.DefaultIndexRetention
+	}
+	loc := opts.Location
+	if loc == nil {
+		loc = time.Local
+	}
+	logLoc := opts.LogLocation
+	if logLoc == nil {
+		logLoc = time.UTC
+	}
+
 	return &Runner{
-		verbose:     verbose,
-		ctx:         context.Background(),
-		callID:      callID,
-		symptom:     symptom,
-		interactive: interactive,
-		collectOnly: collectOnly,
-		noLLM:       noLLM,
-		list:        list,
+		verbose:     opts.Verbose,
+		ctx:         ctx,
+		container:   container,
+		instances:   opts.Instances,
+		source:      opts.LogSource,
+		retention:   retention,
+		preHooks:    opts.PreHooks,
+		postHooks:   opts.PostHooks,
+		tracer:      opts.Tracer,
+		traces:      opts.Traces,
+		security:    opts.Security,
+		sinks:       opts.MetricSinks,
+		notifier:    opts.Notifier,
+		tickets:     opts.Tickets,
+		slo:         opts.SLO,
+		costs:       opts.Costs,
+		tenants:     opts.Tenants,
+		hours:       opts.BusinessHours,
+		callID:      opts.CallID,
+		symptom:     opts.Symptom,
+		interactive: opts.Interactive,
+		collectOnly: opts.CollectOnly,
+		noLLM:       opts.NoLLM,
+		noCache:     opts.NoCache,
+		noSample:    opts.NoSample,
+		chart:       opts.Chart,
+		frames:      opts.Frames,
+		list:        opts.List,
+		all:         opts.All,
+		resume:      opts.Resume,
+		since:       opts.Since,
+		until:       opts.Until,
+		filter:      opts.Filter,
+		loc:         loc,
+		logLoc:      logLoc,
 	}
 }
 
@@ -69,30 +245,41 @@ func (r *Runner) Run() error {
 		return r.listCalls()
 	}
 
+	// Batch mode
+	if r.all {
+		return r.analyzeAll()
+	}
+
+	if r.chart != "" {
+		if err := checkChartPath(r.chart); err != nil {
+			return err
+		}
+	}
+
 	// Determine which call to analyze
 	if r.callID == "" || r.callID == "last" {
 		calls, err := r.getRecentCalls(10)
 		if err != nil {
-			return fmt.Errorf("failed to get recent calls: %w", err)
+			return fmt.Errorf("failed to get recent calls: %w", r.wrapCtxErr(err))
 		}
 		if len(calls) == 0 {
 			errorColor.Println("❌ No recent calls found")
 			fmt.Println()
 			fmt.Println("Tips:")
 			fmt.Println("  • Make a test call first")
-			fmt.Println("  • Check if ai_engine container is running")
-			fmt.Println("  • Verify logs: docker logs ai_engine")
+			fmt.Printf("  • Check if %s container is running\n", r.container)
+			fmt.Printf("  • Verify logs: docker logs %s\n", r.container)
 			return fmt.Errorf("no calls to analyze")
 		}
 		
 		// If --last flag or "last", use most recent
 		if r.callID == "last" {
 			r.callID = calls[0].ID
-			infoColor.Printf("Analyzing most recent call: %s\n", r.callID)
+			infoColor.Printf("Analyzing most recent call: %s (%s)\n", r.callID, formatTimestamp(calls[0].Timestamp, r.loc))
 			fmt.Println()
 		} else {
 			// No call ID and no --last flag: interactive selection
-			selectedID, err := SelectCallInteractive(calls)
+			selectedID, err := SelectCallInteractive(calls, r.loc)
 			if err != nil {
 				return err
 			}
@@ -102,14 +289,26 @@ func (r *Runner) Run() error {
 		}
 	}
 
+	r.runHooks("pre", r.preHooks, nil)
+
 	// Collect logs and data
 	infoColor.Println("Collecting call data...")
 	logData, err := r.collectCallData()
 	if err != nil {
-		return fmt.Errorf("failed to collect data: %w", err)
+		return fmt.Errorf("failed to collect data: %w", r.wrapCtxErr(err))
 	}
 	successColor.Println("✅ Data collected")
 	fmt.Println()
+	r.displaySampling(r.sampling)
+	r.reportLogWindows()
+	var call *Call
+	if c, ok := LoadCallIndex().Get(r.callID); ok {
+		call = c
+		if call.Tenant == "" {
+			call.Tenant = TenantOf(*call, r.tenants)
+		}
+	}
+	r.reportAnnotations(call)
 
 	if r.collectOnly {
 		fmt.Println("Data collection complete. Files saved to logs/")
@@ -118,51 +317,94 @@ func (r *Runner) Run() error {
 
 	// Analyze logs
 	infoColor.Println("Analyzing logs...")
-	analysis := r.analyzeBasic(logData)
-	
-	// Extract structured metrics
+	analysis := r.analyzeLogs(logData)
+
+	// The steps below run with analysis.step: one that panics or fails
+	// is marked incomplete and the run goes on with what the others found
 	infoColor.Println("Extracting metrics...")
-	metrics := ExtractMetrics(logData)
+	var metrics *CallMetrics
+	analysis.step("metrics", func() error {
+		metrics = ExtractMetrics(logData)
+		return nil
+	})
 	analysis.Metrics = metrics
-	
-	// Analyze format/sampling alignment
-	infoColor.Println("Analyzing format alignment...")
-	formatAlignment := AnalyzeFormatAlignment(metrics)
-	metrics.FormatAlignment = formatAlignment
-	
-	// Compare to golden baselines
-	infoColor.Println("Comparing to golden baselines...")
-	baselineName := detectBaseline(logData)
-	if baselineName != "" {
-		comparison := CompareToBaseline(metrics, baselineName)
-		analysis.BaselineComparison = comparison
-		if r.verbose && comparison != nil {
-			infoColor.Printf("  Using baseline: %s\n", comparison.BaselineName)
-		}
+
+	if r.traces != nil {
+		infoColor.Println("Fetching engine traces...")
+		analysis.step("trace", func() error {
+			r.enrichFromTrace(analysis, logData)
+			return nil
+		})
 	}
-	
+
+	infoColor.Println("Correlating security events...")
+	analysis.step("security", func() error {
+		r.correlateSecurity(analysis, logData)
+		return nil
+	})
+
+	if metrics != nil {
+		// Analyze format/sampling alignment
+		infoColor.Println("Analyzing format alignment...")
+		analysis.step("format alignment", func() error {
+			if r.cached != nil && r.cached.FormatAlignment != nil {
+				metrics.FormatAlignment = r.cached.FormatAlignment
+			} else {
+				metrics.FormatAlignment = AnalyzeFormatAlignment(r.ctx, r.container, metrics)
+				if r.cached != nil {
+					r.cached.FormatAlignment = metrics.FormatAlignment
+					r.saveCachedCall()
+				}
+			}
+			return nil
+		})
+
+		// Compare to golden baselines
+		infoColor.Println("Comparing to golden baselines...")
+		analysis.step("baseline", func() error {
+			baselineName := detectBaseline(logData)
+			if baselineName != "" {
+				comparison := CompareToBaseline(metrics, baselineName)
+				analysis.BaselineComparison = comparison
+				if r.verbose && comparison != nil {
+					infoColor.Printf("  Using baseline: %s\n", comparison.BaselineName)
+				}
+			}
+			return nil
+		})
+	}
+
 	// Apply symptom-specific analysis
 	if r.symptom != "" {
 		infoColor.Printf("Applying symptom analysis: %s\n", r.symptom)
-		checker := NewSymptomChecker(r.symptom)
-		checker.AnalyzeSymptom(analysis, logData)
+		analysis.step("symptom:"+r.symptom, func() error {
+			checker := NewSymptomChecker(r.symptom)
+			checker.AnalyzeSymptom(analysis, logData)
+			return nil
+		})
 	}
-	
-	// LLM analysis
+
+	// LLM analysis; a provider that times out leaves the diagnosis out
 	var llmDiagnosis *LLMDiagnosis
-	if !r.noLLM {
+	if !r.noLLM && r.ctx.Err() == nil {
 		infoColor.Println("Requesting AI diagnosis...")
 		llmAnalyzer, err := NewLLMAnalyzer()
 		if err != nil {
 			warningColor.Printf("⚠️  LLM analysis unavailable: %v\n", err)
-		} else {
-			llmDiagnosis, err = llmAnalyzer.AnalyzeWithLLM(analysis, logData)
+		} else if analysis.step("llm", func() error {
+			diagnosis, err := llmAnalyzer.AnalyzeWithLLM(r.ctx, analysis, logData)
 			if err != nil {
-				warningColor.Printf("⚠️  LLM analysis failed: %v\n", err)
-			} else {
-				successColor.Println("✅ AI diagnosis complete")
+				return r.wrapCtxErr(err)
 			}
+			llmDiagnosis = diagnosis
+			return nil
+		}) {
+			successColor.Println("✅ AI diagnosis complete")
+		} else {
+			warningColor.Printf("⚠️  LLM analysis failed: %s\n", analysis.Incomplete[len(analysis.Incomplete)-1].Reason)
 		}
+	} else if !r.noLLM {
+		analysis.fail("llm", r.wrapCtxErr(r.ctx.Err()))
 	}
 	fmt.Println()
 
@@ -171,17 +413,59 @@ func (r *Runner) Run() error {
 	
 	// Show detailed metrics (RCA-level)
 	if analysis.Metrics != nil {
-		r.displayMetrics(analysis.Metrics)
-		
-		// Show overall call quality verdict
-		r.displayCallQuality(analysis.Metrics)
+		analysis.step("display metrics", func() error {
+			r.displayMetrics(analysis.Metrics)
+
+			// Show overall call quality verdict
+			r.displayCallQuality(analysis.Metrics)
+			return nil
+		})
 	}
 	
 	// Show LLM diagnosis
 	if llmDiagnosis != nil {
-		r.displayLLMDiagnosis(llmDiagnosis)
+		analysis.step("display diagnosis", func() error {
+			r.displayLLMDiagnosis(llmDiagnosis)
+			return nil
+		})
+	}
+	r.displayIncomplete(analysis)
+
+	if r.chart != "" {
+		if err := r.writeChart(logData); err != nil {
+			warningColor.Printf("⚠️  Chart not written: %v\n", err)
+		} else {
+			infoColor.Printf("Timeline chart written to %s\n", r.chart)
+		}
+		fmt.Println()
+	}
+
+	report := NewReport(analysis, llmDiagnosis)
+	if call != nil {
+		report.Tags = call.Tags
+		report.Notes = call.Notes
+		report.Tenant = call.Tenant
+	}
+	if runID, err := r.saveRun(logData, analysis, report); err != nil {
+		warningColor.Printf("⚠️  Could not save run history: %v\n", err)
+	} else {
+		infoColor.Printf("Saved as run %s (agent troubleshoot show %s)\n", runID, runID)
+		fmt.Println()
+	}
+	if r.ctx.Err() != nil {
+		return fmt.Errorf("%v; the results above are partial", r.wrapCtxErr(r.ctx.Err()))
+	}
+
+	r.pushMetrics(report, call)
+	r.notifyReport(report, call)
+	r.fileTicket(report, call, logData)
+	if r.exportTrace(logData) {
+		infoColor.Printf("Exported trace %s\n", tracing.TraceID(r.callID))
+		fmt.Println()
 	}
 
+	r.runPostHooks(report)
+
 	// Interactive follow-up
 	if r.interactive {
 		return r.interactiveSession(analysis)
@@ -190,11 +474,22 @@ func (r *Runner) Run() error {
 	return nil
 }
 
+// wrapCtxErr replaces errors caused by cancellation with the reason
+func (r *Runner) wrapCtxErr(err error) error {
+	switch r.ctx.Err() {
+	case context.DeadlineExceeded:
+		return fmt.Errorf("timed out (increase --timeout)")
+	case context.Canceled:
+		return fmt.Errorf("interrupted")
+	}
+	return err
+}
+
 // listCalls lists recent calls
 func (r *Runner) listCalls() error {
 	calls, err := r.getRecentCalls(20)
 	if err != nil {
-		return err
+		return r.wrapCtxErr(err)
 	}
 
 	if len(calls) == 0 {
@@ -206,11 +501,27 @@ func (r *Runner) listCalls() error {
 	for i, call := range calls {
 		age := time.Since(call.Timestamp)
 		ageStr := formatDuration(age)
-		fmt.Printf("%2d. %s - %s ago", i+1, call.ID, ageStr)
+		fmt.Printf("%2d. %s - %s (%s ago)", i+1, call.ID, formatTimestamp(call.Timestamp, r.loc), ageStr)
 		if call.Duration != "" {
 			fmt.Printf(" (duration: %s)", call.Duration)
 		}
+		if call.Status != "" {
+			fmt.Print(" ")
+			statusColor(call.Status).Printf("[%s]", call.Status)
+		}
 		fmt.Println()
+		if party := formatParties(call); party != "" {
+			fmt.Printf("    %s\n", party)
+		}
+		if call.Tenant != "" {
+			fmt.Printf("    tenant %s\n", call.Tenant)
+		}
+		if r.hours.Label(call) == HoursAfter {
+			fmt.Println("    after hours")
+		}
+		if notes := formatAnnotations(call); notes != "" {
+			fmt.Printf("    %s\n", notes)
+		}
 	}
 	fmt.Println()
 	fmt.Println("Usage: agent troubleshoot --call <id>")
@@ -219,81 +530,49 @@ func (r *Runner) listCalls() error {
 
 // getRecentCalls extracts recent calls from logs
 func (r *Runner) getRecentCalls(limit int) ([]Call, error) {
-	cmd := exec.Command("docker", "logs", "--since", "24h", "ai_engine")
-	output, err := cmd.CombinedOutput()
+	since, until, err := r.listWindow()
 	if err != nil {
-		return nil, fmt.Errorf("failed to read logs: %w", err)
+		return nil, err
+	}
+	if r.verbose {
+		fmt.Printf("[DEBUG] Reading logs since=%s until=%s\n", since, until)
 	}
 
-	// Strip ANSI color codes from log output (console format uses colors)
-	// JSON format doesn't have ANSI codes, so this is safe for both
-	ansiStripPattern := regexp.MustCompile(`\x1b\[[0-9;]*m`)
-	cleanOutput := ansiStripPattern.ReplaceAllString(string(output), "")
+	output, err := r.fetchLogs(since, until, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read logs: %w", err)
+	}
 
-	callMap := make(map[string]*Call)
-	audioSocketChannels := make(map[string]bool)
-	
-	// First pass: identify AudioSocket channels (internal infrastructure)
-	audioSocketPattern := regexp.MustCompile(`"audiosocket_channel_id":\s*"([0-9]+\.[0-9]+)"`)
-	lines := strings.Split(cleanOutput, "\n")
-	
+	lines := strings.Split(string(output), "\n")
 	if r.verbose {
-		fmt.Printf("[DEBUG] Read %d lines from Docker logs\n", len(lines))
+		fmt.Printf("[DEBUG] Read %d lines from logs\n", len(lines))
 	}
-	
+
+	tracker := newCallTracker(r.logLoc, r.verbose)
 	for _, line := range lines {
-		matches := audioSocketPattern.FindStringSubmatch(line)
-		if len(matches) > 1 {
-			audioSocketChannels[matches[1]] = true
-			if r.verbose {
-				fmt.Printf("[DEBUG] Found AudioSocket channel: %s\n", matches[1])
-			}
-		}
+		tracker.observe(line)
 	}
-	
-	// Second pass: collect call IDs, excluding AudioSocket channels
-	patterns := []*regexp.Regexp{
-		regexp.MustCompile(`"call_id":\s*"([0-9]+\.[0-9]+)"`),           // JSON: "call_id": "1761518880.2191"
-		regexp.MustCompile(`(?:call_id|channel_id)[=:][\s]*"?([0-9]+\.[0-9]+)"?`), // call_id= or channel_id=
-		regexp.MustCompile(`"caller_channel_id":\s*"([0-9]+\.[0-9]+)"`), // Explicit caller channel
+	calls := tracker.result()
+	r.assignTenants(calls)
+
+	// Remember call windows so a later --call run collects the right range
+	index := LoadCallIndex()
+	index.Merge(calls)
+	index.Prune(time.Now().Add(-r.retention))
+	if err := index.Save(); err != nil && r.verbose {
+		fmt.Printf("[DEBUG] Failed to save call index: %v\n", err)
 	}
-	
-	matchCount := 0
-	for _, line := range lines {
-		for _, pattern := range patterns {
-			matches := pattern.FindStringSubmatch(line)
-			if len(matches) > 1 {
-				matchCount++
-				callID := matches[1]
-				// Skip AudioSocket channels
-				if audioSocketChannels[callID] {
-					if r.verbose {
-						fmt.Printf("[DEBUG] Skipping AudioSocket channel: %s\n", callID)
-					}
-					continue
-				}
-				if _, exists := callMap[callID]; !exists {
-					callMap[callID] = &Call{
-						ID:        callID,
-						Timestamp: time.Now(), // Will be refined from log timestamp
-					}
-					if r.verbose {
-						fmt.Printf("[DEBUG] Found call ID: %s\n", callID)
-					}
-				}
-				break // Found a match, no need to try other patterns
+	index.attachAnnotations(calls)
+
+	if !r.filter.empty() {
+		// Filters apply to this run's view only; the index keeps every call
+		filtered := calls[:0]
+		for _, call := range calls {
+			if r.filter.Match(call) {
+				filtered = append(filtered, call)
 			}
 		}
-	}
-	
-	if r.verbose {
-		fmt.Printf("[DEBUG] Total pattern matches: %d, Unique calls: %d\n", matchCount, len(callMap))
-	}
-
-	// Convert to slice and sort by ID (descending, newer first)
-	calls := make([]Call, 0, len(callMap))
-	for _, call := range callMap {
-		calls = append(calls, *call)
+		calls = filtered
 	}
 	
 	sort.Slice(calls, func(i, j int) bool {
@@ -307,10 +586,25 @@ func (r *Runner) getRecentCalls(limit int) ([]Call, error) {
 	return calls, nil
 }
 
-// collectCallData collects logs for specific call
+// collectCallData collects logs for specific call. Data an earlier run
+// collected for the same call and window is reused unless --no-cache.
 func (r *Runner) collectCallData() (string, error) {
-	cmd := exec.Command("docker", "logs", "--since", "1h", "ai_engine")
-	output, err := cmd.CombinedOutput()
+	r.truncated = nil
+	since, until, err := r.collectionWindow()
+	if err != nil {
+		return "", err
+	}
+	if r.cached = r.loadCachedCall(since, until); r.cached != nil {
+		if !r.all {
+			infoColor.Printf("Using call data cached %s ago (--no-cache to collect again)\n", formatDuration(time.Since(r.cached.CollectedAt)))
+		}
+		return r.sample(r.cached.Logs), nil
+	}
+	if r.verbose {
+		fmt.Printf("[DEBUG] Collecting logs since=%s until=%s\n", since, until)
+	}
+
+	output, err := r.fetchLogs(since, until, r.callID)
 	if err != nil {
 		return "", err
 	}
@@ -325,8 +619,34 @@ func (r *Runner) collectCallData() (string, error) {
 			callLogs = append(callLogs, line)
 		}
 	}
+	logData := strings.Join(callLogs, "\n")
+	if len(r.truncated) > 0 {
+		// Not cached: a later run would take the partial logs for whole
+		return r.sample(logData), nil
+	}
+
+	r.cached = &CachedCall{
+		CallID:      r.callID,
+		Source:      r.sourceName(),
+		Containers:  r.containers(),
+		Since:       since,
+		Until:       until,
+		CollectedAt: time.Now(),
+		Logs:        logData,
+	}
+	r.saveCachedCall()
+	return r.sample(logData), nil
+}
 
-	return strings.Join(callLogs, "\n"), nil
+// sample thins out the logs of very chatty calls unless --no-sample and
+// remembers what was sampled for the analysis
+func (r *Runner) sample(logData string) string {
+	r.sampling = nil
+	if r.noSample {
+		return logData
+	}
+	logData, r.sampling = sampleLogs(logData)
+	return logData
 }
 
 // Analysis holds analysis results
@@ -335,6 +655,11 @@ type Analysis struct {
 	Errors              []string
 	Warnings            []string
 	AudioIssues         []string
+	Findings            []Finding
+	LatencyBreakdown    []SpanTiming
+	ASRTurns            []ASRTurn
+	Frames              []FrameSecond
+	TraceSource         string
 	MetricsMap          map[string]string
 	Metrics             *CallMetrics
 	BaselineComparison  *BaselineComparison
@@ -343,57 +668,44 @@ type Analysis struct {
 	HasPlayback         bool
 	Symptom             string
 	SymptomAnalysis     *SymptomAnalysis
+	// Incomplete are the analyzers and steps that failed to complete
+	Incomplete []Incomplete
+	// Sampling is set when the call's logs were sampled
+	Sampling *Sampling
 }
 
-// analyzeBasic performs basic log analysis
-func (r *Runner) analyzeBasic(logData string) *Analysis {
+// analyzeLogs runs the registered analyzers over the call's log lines
+func (r *Runner) analyzeLogs(logData string) *Analysis {
 	analysis := &Analysis{
 		CallID:     r.callID,
 		MetricsMap: make(map[string]string),
 		Symptom:    r.symptom,
+		Sampling:   r.sampling,
 	}
-
-	lines := strings.Split(logData, "\n")
-	
-	for _, line := range lines {
-		lower := strings.ToLower(line)
-		
-		// Check for errors
-		if strings.Contains(lower, "error") && !strings.Contains(lower, "0 error") {
-			analysis.Errors = append(analysis.Errors, line)
-		}
-		
-		// Check for warnings
-		if strings.Contains(lower, "warning") || strings.Contains(lower, "warn") {
-			analysis.Warnings = append(analysis.Warnings, line)
-		}
-		
-		// Audio pipeline indicators
-		if strings.Contains(lower, "audiosocket") {
-			analysis.HasAudioSocket = true
-		}
-		if strings.Contains(lower, "transcription") || strings.Contains(lower, "transcript") {
-			analysis.HasTranscription = true
-		}
-		if strings.Contains(lower, "playback") || strings.Contains(lower, "playing") {
-			analysis.HasPlayback = true
-		}
-		
-		// Audio quality issues
-		if strings.Contains(lower, "underflow") {
-			analysis.AudioIssues = append(analysis.AudioIssues, "Jitter buffer underflow detected")
-		}
-		if strings.Contains(lower, "garbled") || strings.Contains(lower, "distorted") {
-			analysis.AudioIssues = append(analysis.AudioIssues, "Audio quality issue detected")
-		}
-		if strings.Contains(lower, "echo") {
-			analysis.AudioIssues = append(analysis.AudioIssues, "Echo detected")
-		}
+	for _, reason := range r.truncated {
+		analysis.Incomplete = append(analysis.Incomplete, Incomplete{Analyzer: "log collection", Reason: reason})
 	}
-
+	runAnalyzers(r.ctx, logData, analysis)
+	if r.feedback == nil {
+		r.feedback = LoadFeedback()
+	}
+	applyFeedback(analysis.Findings, r.feedback)
 	return analysis
 }
 
+// displayIncomplete lists the analyzers and steps that did not
+// complete, so partial results are not taken for the whole picture
+func (r *Runner) displayIncomplete(analysis *Analysis) {
+	if len(analysis.Incomplete) == 0 {
+		return
+	}
+	warningColor.Printf("⚠️  INCOMPLETE: %d analyzer(s) did not complete; their findings are missing\n", len(analysis.Incomplete))
+	for _, in := range analysis.Incomplete {
+		fmt.Printf("  • %s: %s\n", in.Analyzer, in.Reason)
+	}
+	fmt.Println()
+}
+
 // displayFindings shows analysis results
 func (r *Runner) displayFindings(analysis *Analysis) {
 	fmt.Println("═══════════════════════════════════════════")
@@ -422,6 +734,35 @@ func (r *Runner) displayFindings(analysis *Analysis) {
 	}
 	fmt.Println()
 
+	// Analyzer findings
+	if len(analysis.Findings) > 0 {
+		fmt.Println("Findings:")
+		for _, f := range analysis.Findings {
+			switch f.Severity {
+			case SeverityCritical:
+				errorColor.Printf("  ❌ [%s] %s\n", f.Analyzer, f.Message)
+			case SeverityWarning:
+				warningColor.Printf("  ⚠️  [%s] %s\n", f.Analyzer, f.Message)
+			default:
+				infoColor.Printf("  ℹ️  [%s] %s\n", f.Analyzer, f.Message)
+			}
+			if f.Evidence != "" {
+				fmt.Printf("     %s\n", f.Evidence)
+			}
+			if f.Fix != "" {
+				fmt.Printf("     Fix: %s\n", f.Fix)
+			}
+			if f.Feedback != "" {
+				fmt.Printf("     (%s)\n", f.Feedback)
+			}
+		}
+		fmt.Println()
+	}
+
+	r.displayLatencyBreakdown(analysis)
+	r.displayASRTurns(analysis)
+	r.displayFrames(analysis)
+
 	// Audio issues
 	if len(analysis.AudioIssues) > 0 {
 		errorColor.Printf("Audio Issues Found (%d):\n", len(analysis.AudioIssues))
@@ -439,7 +780,7 @@ func (r *Runner) displayFindings(analysis *Analysis) {
 			count = 5
 		}
 		for i := 0; i < count; i++ {
-			fmt.Printf("  %d. %s\n", i+1, truncate(analysis.Errors[i], 100))
+			fmt.Printf("  %d. %s\n", i+1, truncate(RedactSecrets(analysis.Errors[i]), 100))
 		}
 		if len(analysis.Errors) > 5 {
 			fmt.Printf("  ... and %d more\n", len(analysis.Errors)-5)
@@ -455,7 +796,7 @@ func (r *Runner) displayFindings(analysis *Analysis) {
 			count = 3
 		}
 		for i := 0; i < count; i++ {
-			fmt.Printf("  %d. %s\n", i+1, truncate(analysis.Warnings[i], 100))
+			fmt.Printf("  %d. %s\n", i+1, truncate(RedactSecrets(analysis.Warnings[i]), 100))
 		}
 		if len(analysis.Warnings) > 3 {
 			fmt.Printf("  ... and %d more\n", len(analysis.Warnings)-3)
@@ -516,7 +857,7 @@ func (r *Runner) displayRecommendations(analysis *Analysis) {
 	
 	if len(analysis.Errors) > 10 {
 		fmt.Println("  • High error count - check container logs")
-		fmt.Println("  • Run: docker logs ai_engine | grep ERROR")
+		fmt.Printf("  • Run: docker logs %s | grep ERROR\n", r.container)
 	}
 	
 	fmt.Println()
@@ -680,7 +1021,44 @@ func (r *Runner) displayCallQuality(metrics *CallMetrics) {
 	fmt.Println("═══════════════════════════════════════════")
 	fmt.Println()
 	
-	// Calculate score based on metrics
+	score, issues := scoreCallQuality(metrics)
+	displayQualityVerdict(score, issues)
+}
+
+// displayQualityVerdict prints the verdict for a quality score and the
+// issues behind it
+func displayQualityVerdict(score float64, issues []string) {
+	// Determine verdict
+	if score >= 90 {
+		successColor.Println("Verdict: ✅ EXCELLENT - No significant issues detected")
+	} else if score >= 70 {
+		warningColor.Println("Verdict: ⚠️  FAIR - Minor issues detected")
+	} else if score >= 50 {
+		warningColor.Println("Verdict: ⚠️  POOR - Multiple issues affecting quality")
+	} else {
+		errorColor.Println("Verdict: ❌ CRITICAL - Severe issues detected")
+	}
+	
+	fmt.Printf("Quality Score: %.0f/100\n", score)
+	
+	if len(issues) > 0 {
+		fmt.Println("\nIssues Detected:")
+		for _, issue := range issues {
+			fmt.Printf("  • %s\n", issue)
+		}
+	} else {
+		fmt.Println("\n✅ All metrics within acceptable thresholds")
+		fmt.Println("✅ Provider bytes ratio: ~1.0")
+		fmt.Println("✅ Drift: <10%")
+		fmt.Println("✅ No underflows")
+		fmt.Println("✅ Clean audio expected")
+	}
+	
+	fmt.Println()
+}
+
+// scoreCallQuality computes the 0-100 quality score and the issues behind it
+func scoreCallQuality(metrics *CallMetrics) (float64, []string) {
 	issues := []string{}
 	score := 100.0
 	
@@ -747,33 +1125,7 @@ func (r *Runner) displayCallQuality(metrics *CallMetrics) {
 		}
 	}
 	
-	// Determine verdict
-	if score >= 90 {
-		successColor.Println("Verdict: ✅ EXCELLENT - No significant issues detected")
-	} else if score >= 70 {
-		warningColor.Println("Verdict: ⚠️  FAIR - Minor issues detected")
-	} else if score >= 50 {
-		warningColor.Println("Verdict: ⚠️  POOR - Multiple issues affecting quality")
-	} else {
-		errorColor.Println("Verdict: ❌ CRITICAL - Severe issues detected")
-	}
-	
-	fmt.Printf("Quality Score: %.0f/100\n", score)
-	
-	if len(issues) > 0 {
-		fmt.Println("\nIssues Detected:")
-		for _, issue := range issues {
-			fmt.Printf("  • %s\n", issue)
-		}
-	} else {
-		fmt.Println("\n✅ All metrics within acceptable thresholds")
-		fmt.Println("✅ Provider bytes ratio: ~1.0")
-		fmt.Println("✅ Drift: <10%")
-		fmt.Println("✅ No underflows")
-		fmt.Println("✅ Clean audio expected")
-	}
-	
-	fmt.Println()
+	return score, issues
 }
 
 // displayLLMDiagnosis shows AI-powered diagnosis
@@ -782,6 +1134,9 @@ func (r *Runner) displayLLMDiagnosis(diagnosis *LLMDiagnosis) {
 	infoColor.Printf("🤖 AI DIAGNOSIS (%s - %s)\n", diagnosis.Provider, diagnosis.Model)
 	fmt.Println("═══════════════════════════════════════════")
 	fmt.Println()
+	if diagnosis.SummaryCalls > 0 {
+		fmt.Printf("(Evidence summarized in %d request(s) to fit the model's context window)\n\n", diagnosis.SummaryCalls)
+	}
 	fmt.Println(diagnosis.Analysis)
 	fmt.Println()
 }
@@ -829,6 +1184,29 @@ func detectBaseline(logData string) string {
 	return "streaming_performance" // Default baseline
 }
 
+// formatParties renders caller → dialed [channel] for listings
+func formatParties(call Call) string {
+	var parts []string
+	if call.CallerNumber != "" || call.Dialed != "" {
+		from := call.CallerNumber
+		if from == "" {
+			from = "unknown"
+		}
+		if call.CallerName != "" && call.CallerName != call.CallerNumber {
+			from = fmt.Sprintf("%s <%s>", call.CallerName, from)
+		}
+		to := call.Dialed
+		if to == "" {
+			to = "?"
+		}
+		parts = append(parts, fmt.Sprintf("%s → %s", from, to))
+	}
+	if call.Channel != "" {
+		parts = append(parts, "["+call.Channel+"]")
+	}
+	return strings.Join(parts, " ")
+}
+
 // Helper functions
 func formatDuration(d time.Duration) string {
 	if d < time.Minute {
@@ -0,0 +1,409 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/cdr"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/locale"
+)
+
+// trunkCallLimit caps how many engine calls of the window are joined to
+// the CDRs
+const trunkCallLimit = 5000
+
+// trunkEvidence bounds the upstream failures listed per trunk
+const trunkEvidence = 10
+
+// lowMOS is the MOS below which callers notice the audio (ITU-T G.107:
+// "many users dissatisfied")
+const lowMOS = 3.6
+
+// Call directions of a trunk scorecard
+const (
+	DirectionInbound  = "inbound"
+	DirectionOutbound = "outbound"
+)
+
+// TrunkOptions are the data a trunk report is built from besides the
+// engine's calls
+type TrunkOptions struct {
+	// Records are the CDRs; those outside the window are skipped
+	Records []cdr.Record
+	// Trunks are the endpoints facing carriers; every SIP endpoint when
+	// empty
+	Trunks []string
+	// Delays are the post-dial delays of INVITEs sent to the trunks
+	Delays []TrunkDelay
+}
+
+// TrunkDelay is the post-dial delay of one INVITE sent to a trunk
+type TrunkDelay struct {
+	Trunk string
+	Time  time.Time
+	Delay time.Duration
+}
+
+// TrunkReport scores every trunk in each direction over a window
+type TrunkReport struct {
+	GeneratedAt time.Time    `json:"generated_at"`
+	Start       time.Time    `json:"start"`
+	End         time.Time    `json:"end"`
+	Trunks      []TrunkScore `json:"trunks"`
+	// Records counts the CDRs of the window on a trunk
+	Records int `json:"records"`
+	// Skipped counts agent calls whose logs could not be collected
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// TrunkScore is the carrier quality of one trunk in one direction
+type TrunkScore struct {
+	Trunk     string `json:"trunk"`
+	Direction string `json:"direction"`
+	Attempts  int    `json:"attempts"`
+	Answered  int    `json:"answered"`
+	// ASR is the answer-seizure ratio, Answered over Attempts
+	ASR     float64 `json:"asr"`
+	Minutes float64 `json:"minutes"`
+	// ACDSeconds is the average duration of the answered calls
+	ACDSeconds   float64        `json:"acd_seconds"`
+	Dispositions map[string]int `json:"dispositions"`
+	HangupCauses []CauseCount   `json:"hangup_causes,omitempty"`
+	// PDD is measured on outbound INVITEs in the SIP log
+	PDDCalls int     `json:"pdd_calls,omitempty"`
+	PDDAvgMs float64 `json:"pdd_avg_ms,omitempty"`
+	PDDP95Ms float64 `json:"pdd_p95_ms,omitempty"`
+	// MOS is estimated from the RTPAUDIOQOS of answered calls
+	MOSCalls int     `json:"mos_calls"`
+	MOSAvg   float64 `json:"mos_avg,omitempty"`
+	MOSLow   int     `json:"mos_low_calls"`
+	// AgentCalls are the calls the engine handled; AgentFailed those it
+	// failed with the carrier leg in order
+	AgentCalls  int     `json:"agent_calls"`
+	AgentFailed int     `json:"agent_failed"`
+	AvgScore    float64 `json:"avg_quality_score,omitempty"`
+	// Upstream counts the attempts that failed in the carrier's network:
+	// FAILED or CONGESTION, a network failure cause, or poor audio
+	Upstream     int             `json:"upstream_failures"`
+	UpstreamRate float64         `json:"upstream_failure_rate"`
+	Evidence     []TrunkEvidence `json:"evidence,omitempty"`
+
+	mosSum   float64
+	scoreSum float64
+	scored   int
+	causes   map[int]int
+}
+
+// CauseCount is how often a Q.850 hangup cause ended a trunk's calls
+type CauseCount struct {
+	Cause int    `json:"cause"`
+	Name  string `json:"name"`
+	Calls int    `json:"calls"`
+}
+
+// TrunkEvidence is an upstream failure to show the carrier
+type TrunkEvidence struct {
+	UniqueID string    `json:"uniqueid"`
+	Start    time.Time `json:"start"`
+	Src      string    `json:"src"`
+	Dst      string    `json:"dst"`
+	Reason   string    `json:"reason"`
+}
+
+// Trunks scores the trunks of the CDRs in the window: attempts, ASR,
+// ACD, post-dial delay, dispositions, hangup causes and MOS, joined by
+// uniqueid with the engine's analysis of the calls the agent handled, so
+// failures in the carrier's network can be told from the agent's own
+func (r *Runner) Trunks(opts TrunkOptions) (*TrunkReport, error) {
+	now := time.Now()
+	since := r.since
+	if since == "" {
+		since = "7d"
+	}
+	start, err := parseTimeFlag(since, now, r.loc)
+	if err != nil {
+		return nil, fmt.Errorf("--since: %w", err)
+	}
+	end := now
+	if r.until != "" {
+		if end, err = parseTimeFlag(r.until, now, r.loc); err != nil {
+			return nil, fmt.Errorf("--until: %w", err)
+		}
+	}
+
+	trunks := make(map[string]bool)
+	for _, t := range opts.Trunks {
+		trunks[t] = true
+	}
+	isTrunk := func(endpoint string) bool {
+		return endpoint != "" && (len(trunks) == 0 || trunks[endpoint])
+	}
+
+	type trunkRecord struct {
+		rec   cdr.Record
+		score *TrunkScore
+	}
+	report := &TrunkReport{GeneratedAt: now, Start: start, End: end}
+	scores := make(map[string]*TrunkScore)
+	scoreOf := func(trunk, direction string) *TrunkScore {
+		key := trunk + "\x00" + direction
+		s := scores[key]
+		if s == nil {
+			s = &TrunkScore{Trunk: trunk, Direction: direction, Dispositions: make(map[string]int), causes: make(map[int]int)}
+			scores[key] = s
+		}
+		return s
+	}
+	var records []trunkRecord
+	for _, rec := range opts.Records {
+		if rec.Start.Before(start) || rec.Start.After(end) {
+			continue
+		}
+		if in := cdr.Endpoint(rec.Channel); isTrunk(in) {
+			records = append(records, trunkRecord{rec, scoreOf(in, DirectionInbound)})
+		} else if out := cdr.Endpoint(rec.DstChannel); isTrunk(out) {
+			records = append(records, trunkRecord{rec, scoreOf(out, DirectionOutbound)})
+		}
+	}
+	report.Records = len(records)
+
+	// Batch mode: per-call notices stay quiet
+	r.all = true
+	r.since, r.until = start.Format(time.RFC3339), end.Format(time.RFC3339)
+	calls, err := r.getRecentCalls(trunkCallLimit)
+	r.since, r.until = "", ""
+	if err != nil {
+		if r.ctx.Err() != nil {
+			return nil, r.wrapCtxErr(r.ctx.Err())
+		}
+		// The CDRs alone still score the trunks
+		fmt.Fprintf(os.Stderr, "⚠️  Cannot list the agent's calls (%v); scoring from the CDRs only\n", err)
+	}
+	byID := make(map[string]Call, len(calls))
+	for _, call := range calls {
+		byID[call.ID] = call
+	}
+
+	agentCalls := 0
+	for _, tr := range records {
+		if _, ok := byID[tr.rec.UniqueID]; ok {
+			agentCalls++
+		}
+	}
+	if agentCalls > 0 {
+		fmt.Fprintf(os.Stderr, "Analyzing %d agent call(s) of %d CDR(s)...\n", agentCalls, len(records))
+	}
+	for _, tr := range records {
+		if r.ctx.Err() != nil {
+			return nil, r.wrapCtxErr(r.ctx.Err())
+		}
+		s, rec := tr.score, tr.rec
+		s.Attempts++
+		s.Dispositions[rec.Disposition]++
+		var reasons []string
+		if rec.Disposition == cdr.Failed || rec.Disposition == cdr.Congestion {
+			reasons = append(reasons, "disposition "+rec.Disposition)
+		}
+		if rec.Answered() {
+			s.Answered++
+			s.Minutes += float64(rec.Billsec) / 60
+			if q, ok := rec.QoS(); ok {
+				mos := q.MOS()
+				s.MOSCalls++
+				s.mosSum += mos
+				if mos < lowMOS {
+					s.MOSLow++
+					reasons = append(reasons, fmt.Sprintf("MOS %.1f (%s)", mos, q))
+				}
+			}
+		}
+
+		cause := rec.HangupCause()
+		var rc *reportCall
+		if call, ok := byID[rec.UniqueID]; ok {
+			if cause == 0 {
+				cause = call.HangupCause
+			}
+			analyzed, err := r.analyzeReportCall(call)
+			if err != nil {
+				report.Skipped++
+				if r.verbose {
+					fmt.Fprintf(os.Stderr, "[DEBUG] %s: %v\n", call.ID, err)
+				}
+			} else {
+				rc = &analyzed
+			}
+		}
+		if cause != 0 {
+			s.causes[cause]++
+			if failureCauses[cause] {
+				reasons = append(reasons, "cause "+cdr.CauseName(cause))
+			}
+		}
+		if rc != nil {
+			s.AgentCalls++
+			if rc.report.Score > 0 {
+				s.scoreSum += rc.report.Score
+				s.scored++
+			}
+			if len(reasons) == 0 && Fingerprint(rc.report, &rc.call) != "" {
+				s.AgentFailed++
+			}
+		}
+		if len(reasons) > 0 {
+			s.Upstream++
+			if len(s.Evidence) < trunkEvidence {
+				s.Evidence = append(s.Evidence, TrunkEvidence{
+					UniqueID: rec.UniqueID,
+					Start:    rec.Start,
+					Src:      rec.Src,
+					Dst:      rec.Dst,
+					Reason:   strings.Join(reasons, ", "),
+				})
+			}
+		}
+	}
+
+	delays := make(map[string][]float64)
+	for _, d := range opts.Delays {
+		if d.Time.Before(start) || d.Time.After(end) || !isTrunk(d.Trunk) {
+			continue
+		}
+		delays[d.Trunk] = append(delays[d.Trunk], float64(d.Delay.Milliseconds()))
+	}
+	for trunk, samples := range delays {
+		s := scoreOf(trunk, DirectionOutbound)
+		s.PDDCalls = len(samples)
+		s.PDDAvgMs, s.PDDP95Ms, _ = latencyStats(samples)
+	}
+
+	for _, s := range scores {
+		s.finish()
+		report.Trunks = append(report.Trunks, *s)
+	}
+	sort.Slice(report.Trunks, func(i, j int) bool {
+		a, b := report.Trunks[i], report.Trunks[j]
+		if a.Attempts != b.Attempts {
+			return a.Attempts > b.Attempts
+		}
+		if a.Trunk != b.Trunk {
+			return a.Trunk < b.Trunk
+		}
+		return a.Direction < b.Direction
+	})
+	return report, nil
+}
+
+// finish derives the ratios and averages and sorts the hangup causes
+func (s *TrunkScore) finish() {
+	if s.Attempts > 0 {
+		s.ASR = float64(s.Answered) / float64(s.Attempts)
+		s.UpstreamRate = float64(s.Upstream) / float64(s.Attempts)
+	}
+	if s.Answered > 0 {
+		s.ACDSeconds = s.Minutes * 60 / float64(s.Answered)
+	}
+	if s.MOSCalls > 0 {
+		s.MOSAvg = s.mosSum / float64(s.MOSCalls)
+	}
+	if s.scored > 0 {
+		s.AvgScore = s.scoreSum / float64(s.scored)
+	}
+	for cause, n := range s.causes {
+		s.HangupCauses = append(s.HangupCauses, CauseCount{Cause: cause, Name: cdr.CauseName(cause), Calls: n})
+	}
+	sort.Slice(s.HangupCauses, func(i, j int) bool {
+		if s.HangupCauses[i].Calls != s.HangupCauses[j].Calls {
+			return s.HangupCauses[i].Calls > s.HangupCauses[j].Calls
+		}
+		return s.HangupCauses[i].Cause < s.HangupCauses[j].Cause
+	})
+}
+
+// Markdown renders the scorecards as a Markdown document, with times in
+// loc and numbers and dates written the way lc does (nil for
+// locale.Default)
+func (t *TrunkReport) Markdown(loc *time.Location, lc *locale.Locale) string {
+	if lc == nil {
+		lc = locale.Default
+	}
+	pct := func(share float64) string { return lc.Number(share*100, 1) + "%" }
+	dash := func(n int, s string) string {
+		if n == 0 {
+			return "–"
+		}
+		return s
+	}
+	var b strings.Builder
+	b.WriteString("# Trunk Quality Scorecard\n\n")
+	fmt.Fprintf(&b, "%s – %s, %s CDR(s) on a trunk\n\n", lc.Timestamp(t.Start, loc), lc.Timestamp(t.End, loc), lc.Int(t.Records))
+	if len(t.Trunks) == 0 {
+		b.WriteString("No calls on a trunk in the window.\n")
+		return b.String()
+	}
+
+	b.WriteString("## Summary\n\n")
+	b.WriteString("| Trunk | Direction | Attempts | ASR | ACD | PDD avg | PDD p95 | MOS | Upstream failures | Agent failures |\n")
+	b.WriteString("|---|---|---:|---:|---:|---:|---:|---:|---:|---:|\n")
+	for _, s := range t.Trunks {
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s | %s | %s (%s) | %s |\n", s.Trunk, s.Direction, lc.Int(s.Attempts), pct(s.ASR),
+			dash(s.Answered, lc.Number(s.ACDSeconds, 0)+" s"), dash(s.PDDCalls, lc.Milliseconds(s.PDDAvgMs)), dash(s.PDDCalls, lc.Milliseconds(s.PDDP95Ms)),
+			dash(s.MOSCalls, lc.Number(s.MOSAvg, 2)), lc.Int(s.Upstream), pct(s.UpstreamRate), dash(s.AgentCalls, lc.Int(s.AgentFailed)+"/"+lc.Int(s.AgentCalls)))
+	}
+	if t.Skipped > 0 {
+		fmt.Fprintf(&b, "\n%s agent call(s) skipped: logs could not be collected.\n", lc.Int(t.Skipped))
+	}
+
+	for _, s := range t.Trunks {
+		fmt.Fprintf(&b, "\n## %s (%s)\n\n", s.Trunk, s.Direction)
+		fmt.Fprintf(&b, "%s attempt(s), %s answered, %s minutes.", lc.Int(s.Attempts), lc.Int(s.Answered), lc.Number(s.Minutes, 0))
+		if s.MOSCalls > 0 {
+			fmt.Fprintf(&b, " MOS %s over %s call(s), %s below %s.", lc.Number(s.MOSAvg, 2), lc.Int(s.MOSCalls), lc.Int(s.MOSLow), lc.Number(lowMOS, 1))
+		}
+		if s.AgentCalls > 0 {
+			fmt.Fprintf(&b, " The agent handled %s call(s), quality score %s.", lc.Int(s.AgentCalls), lc.Number(s.AvgScore, 0))
+		}
+		b.WriteString("\n\n| Disposition | Calls |\n|---|---:|\n")
+		for _, d := range []string{cdr.Answered, cdr.NoAnswer, cdr.Busy, cdr.Failed, cdr.Congestion} {
+			if n := s.Dispositions[d]; n > 0 {
+				fmt.Fprintf(&b, "| %s | %s |\n", d, lc.Int(n))
+			}
+		}
+		var others []string
+		for d := range s.Dispositions {
+			switch d {
+			case cdr.Answered, cdr.NoAnswer, cdr.Busy, cdr.Failed, cdr.Congestion:
+			default:
+				others = append(others, d)
+			}
+		}
+		sort.Strings(others)
+		for _, d := range others {
+			fmt.Fprintf(&b, "| %s | %s |\n", d, lc.Int(s.Dispositions[d]))
+		}
+		if len(s.HangupCauses) > 0 {
+			b.WriteString("\n| Hangup cause | Calls | |\n|---|---:|---|\n")
+			for _, c := range s.HangupCauses {
+				network := ""
+				if failureCauses[c.Cause] {
+					network = "network failure"
+				}
+				fmt.Fprintf(&b, "| %s | %s | %s |\n", c.Name, lc.Int(c.Calls), network)
+			}
+		}
+		if len(s.Evidence) > 0 {
+			b.WriteString("\nUpstream failures to raise with the carrier")
+			if s.Upstream > len(s.Evidence) {
+				fmt.Fprintf(&b, " (first %d of %s)", len(s.Evidence), lc.Int(s.Upstream))
+			}
+			b.WriteString(":\n\n| Start | Uniqueid | From | To | Reason |\n|---|---|---|---|---|\n")
+			for _, e := range s.Evidence {
+				fmt.Fprintf(&b, "| %s | `%s` | %s | %s | %s |\n", lc.Timestamp(e.Start, loc), e.UniqueID, e.Src, e.Dst, e.Reason)
+			}
+		}
+	}
+	return b.String()
+}
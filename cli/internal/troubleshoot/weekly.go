@@ -0,0 +1,783 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/locale"
+)
+
+// weeklyCallLimit caps how many calls of the two weeks a report analyzes
+const weeklyCallLimit = 5000
+
+// weeklyTopN bounds the clusters and intents listed in the report
+const weeklyTopN = 10
+
+// intentNone labels calls in which the agent ran no tool
+const intentNone = "conversation only"
+
+// WeeklyReport combines a week of calls with the week before it
+type WeeklyReport struct {
+	GeneratedAt time.Time        `json:"generated_at"`
+	Start       time.Time        `json:"start"`
+	End         time.Time        `json:"end"`
+	ThisWeek    WeekStats        `json:"this_week"`
+	LastWeek    WeekStats        `json:"last_week"`
+	Clusters    []FailureCluster `json:"failure_clusters"`
+	Intents     []IntentCount    `json:"intents"`
+	Costs       []ProviderCost   `json:"provider_costs"`
+	SLO         []SLOStatus      `json:"slo,omitempty"`
+	// Tenant is set when the report covers one tenant's calls; Tenants
+	// splits the volume by tenant in reports over several
+	Tenant  string        `json:"tenant,omitempty"`
+	Tenants []TenantUsage `json:"tenants,omitempty"`
+	// BusinessHours compares in-hours with after-hours calls when
+	// business hours are configured
+	BusinessHours []HoursStats `json:"business_hours,omitempty"`
+	// Skipped counts calls whose logs could not be collected
+	Skipped int `json:"skipped,omitempty"`
+}
+
+// WeekStats are the volume, failure and latency figures of one week
+type WeekStats struct {
+	Calls        int            `json:"calls"`
+	ByStatus     map[string]int `json:"by_status"`
+	Failed       int            `json:"failed"`
+	FailureRate  float64        `json:"failure_rate"`
+	Minutes      float64        `json:"minutes"`
+	LatencyCalls int            `json:"latency_calls"`
+	LatencyAvgMs float64        `json:"turn_latency_avg_ms"`
+	LatencyP95Ms float64        `json:"turn_latency_p95_ms"`
+	AvgScore     float64        `json:"avg_quality_score"`
+	// ASRCalls counts calls whose STT logged confidence; ASRLowCalls
+	// those with a low average
+	ASRCalls         int     `json:"asr_calls"`
+	ASRConfidenceAvg float64 `json:"asr_confidence_avg"`
+	ASRLowCalls      int     `json:"asr_low_confidence_calls"`
+
+	latencyAvgs []float64
+	latencyP95s []float64
+	scoreSum    float64
+	scored      int
+	asrSum      float64
+}
+
+// FailureCluster is a group of failed calls sharing a fingerprint
+type FailureCluster struct {
+	Fingerprint string   `json:"fingerprint"`
+	Findings    []string `json:"findings"`
+	Calls       int      `json:"calls"`
+	LastWeek    int      `json:"last_week"`
+	Example     string   `json:"example_call"`
+}
+
+// IntentCount is how many calls ran a tool. Tool invocations are the
+// closest record of what callers wanted that the engine logs.
+type IntentCount struct {
+	Intent   string `json:"intent"`
+	Calls    int    `json:"calls"`
+	LastWeek int    `json:"last_week"`
+}
+
+// ProviderCost is a provider's call minutes and, with a configured
+// per-minute price, their cost. Every provider in a call is charged for
+// the whole call.
+type ProviderCost struct {
+	Provider     string  `json:"provider"`
+	Minutes      float64 `json:"minutes"`
+	LastMinutes  float64 `json:"last_week_minutes"`
+	RatePerMin   float64 `json:"rate_per_minute,omitempty"`
+	Cost         float64 `json:"cost,omitempty"`
+	LastWeekCost float64 `json:"last_week_cost,omitempty"`
+}
+
+// SLOStatus is the share of calls meeting one objective. With business
+// hours, objectives are measured separately on in-hours and after-hours
+// calls, named by Hours.
+type SLOStatus struct {
+	Objective  string  `json:"objective"`
+	Hours      string  `json:"hours,omitempty"`
+	Met        int     `json:"met"`
+	Total      int     `json:"total"`
+	Compliance float64 `json:"compliance"`
+	LastWeek   float64 `json:"last_week_compliance"`
+}
+
+// HoursStats are the figures of the in-hours or after-hours calls
+type HoursStats struct {
+	Hours    string    `json:"hours"`
+	ThisWeek WeekStats `json:"this_week"`
+	LastWeek WeekStats `json:"last_week"`
+}
+
+// TenantUsage is the volume, failures and quality of one tenant's calls
+type TenantUsage struct {
+	Tenant      string  `json:"tenant"`
+	Calls       int     `json:"calls"`
+	Failed      int     `json:"failed"`
+	FailureRate float64 `json:"failure_rate"`
+	Minutes     float64 `json:"minutes"`
+	AvgScore    float64 `json:"avg_quality_score"`
+	LastWeek    int     `json:"last_week_calls"`
+	LastMinutes float64 `json:"last_week_minutes"`
+	scoreSum    float64
+	scored      int
+}
+
+// tenantUnassigned labels calls of no tenant in the tenant breakdown
+const tenantUnassigned = "(no tenant)"
+
+// reportCall is one analyzed call of a multi-call report
+type reportCall struct {
+	call     Call
+	report   *Report
+	minutes  float64
+	intents  []string
+	persona  string
+	tokens   int
+	hours    string
+	lastWeek bool
+}
+
+// Weekly analyzes the calls of the last 14 days and compares the most
+// recent 7 days with the 7 before. Progress goes to stderr so the report
+// can be written to stdout.
+func (r *Runner) Weekly() (*WeeklyReport, error) {
+	now := time.Now()
+	start := now.AddDate(0, 0, -7)
+	prevStart := now.AddDate(0, 0, -14)
+
+	// Batch mode: per-call notices stay quiet
+	r.all = true
+	r.since, r.until = prevStart.Format(time.RFC3339), ""
+	calls, err := r.getRecentCalls(weeklyCallLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent calls: %w", r.wrapCtxErr(err))
+	}
+	r.since, r.until = "", ""
+
+	fmt.Fprintf(os.Stderr, "Analyzing %d call(s)...\n", len(calls))
+	report := &WeeklyReport{GeneratedAt: now, Start: start, End: now, Tenant: r.filterTenant()}
+	var analyzed []reportCall
+	for i, call := range calls {
+		if r.ctx.Err() != nil {
+			return nil, r.wrapCtxErr(r.ctx.Err())
+		}
+		if call.Timestamp.Before(prevStart) {
+			continue
+		}
+		if r.verbose {
+			fmt.Fprintf(os.Stderr, "[DEBUG] [%d/%d] %s\n", i+1, len(calls), call.ID)
+		}
+		wc, err := r.analyzeReportCall(call)
+		if err != nil {
+			report.Skipped++
+			if r.verbose {
+				fmt.Fprintf(os.Stderr, "[DEBUG] %s: %v\n", call.ID, err)
+			}
+			continue
+		}
+		wc.lastWeek = call.Timestamp.Before(start)
+		analyzed = append(analyzed, wc)
+	}
+
+	report.ThisWeek.ByStatus = make(map[string]int)
+	report.LastWeek.ByStatus = make(map[string]int)
+	for _, wc := range analyzed {
+		week := &report.ThisWeek
+		if wc.lastWeek {
+			week = &report.LastWeek
+		}
+		week.add(wc)
+	}
+	report.ThisWeek.finish()
+	report.LastWeek.finish()
+
+	report.Clusters = failureClusters(analyzed)
+	report.Intents = intentCounts(analyzed)
+	report.Costs = providerCosts(analyzed, r.costs)
+	report.SLO = r.sloStatus(analyzed, report.Tenant)
+	if r.hours != nil {
+		report.BusinessHours = hoursStats(analyzed)
+	}
+	if report.Tenant == "" {
+		report.Tenants = tenantUsage(analyzed)
+	}
+	return report, nil
+}
+
+// analyzeReportCall collects and analyzes one call for a report, without
+// notifications, tickets or pushed metrics
+func (r *Runner) analyzeReportCall(call Call) (reportCall, error) {
+	r.callID = call.ID
+	logData, err := r.collectCallData()
+	if err != nil {
+		return reportCall{}, err
+	}
+	return r.newReportCall(call, logData), nil
+}
+
+// newReportCall analyzes the collected logs of a call
+func (r *Runner) newReportCall(call Call, logData string) reportCall {
+	analysis := r.analyzeLogs(logData)
+	analysis.step("metrics", func() error {
+		analysis.Metrics = ExtractMetrics(logData)
+		return nil
+	})
+	if analysis.Metrics == nil {
+		analysis.Metrics = &CallMetrics{}
+	}
+
+	wc := reportCall{
+		call:    call,
+		report:  NewReport(analysis, nil),
+		intents: callIntents(logData),
+		persona: callPersona(logData),
+		tokens:  callTokens(logData),
+		hours:   r.hours.Label(call),
+	}
+	if !call.EndTime.IsZero() && call.EndTime.After(call.Timestamp) {
+		wc.minutes = call.EndTime.Sub(call.Timestamp).Minutes()
+	} else {
+		wc.minutes = analysis.Metrics.CallDurationSeconds / 60
+	}
+	return wc
+}
+
+// callIntents returns the tools the agent ran during a call
+func callIntents(logData string) []string {
+	seen := make(map[string]bool)
+	var intents []string
+	for _, line := range strings.Split(logData, "\n") {
+		var name string
+		ev := parseLogEvent(line)
+		switch ev.Event {
+		case "Executing pipeline tool", "Executing follow-up tool":
+			name = ev.String("tool")
+		default:
+			// Provider-side tools (ElevenLabs) log plain text
+			if i := strings.Index(line, "Tool call: "); i >= 0 {
+				if fields := strings.Fields(line[i+len("Tool call: "):]); len(fields) > 0 {
+					name = strings.Trim(fields[0], `",`)
+				}
+			}
+		}
+		if name != "" && !seen[name] {
+			seen[name] = true
+			intents = append(intents, name)
+		}
+	}
+	if len(intents) == 0 {
+		intents = []string{intentNone}
+	}
+	return intents
+}
+
+// callTokens sums the LLM tokens the providers reported for a call
+func callTokens(logData string) int {
+	tokens := 0
+	for _, line := range strings.Split(logData, "\n") {
+		ev := parseLogEvent(line)
+		if n, ok := ev.Number("total_tokens"); ok && n > 0 {
+			tokens += int(n)
+		}
+	}
+	return tokens
+}
+
+// callPersona returns the AI_CONTEXT the call ran with, empty for the
+// default context
+func callPersona(logData string) string {
+	for _, line := range strings.Split(logData, "\n") {
+		ev := parseLogEvent(line)
+		for _, key := range []string{"context", "context_name"} {
+			if name := ev.String(key); name != "" {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+func (w *WeekStats) add(wc reportCall) {
+	w.Calls++
+	status := wc.call.Status
+	if status == "" {
+		status = "unknown"
+	}
+	w.ByStatus[status]++
+	if Fingerprint(wc.report, &wc.call) != "" {
+		w.Failed++
+	}
+	w.Minutes += wc.minutes
+
+	if avg, err := strconv.ParseFloat(wc.report.Metrics["turn_latency_avg_ms"], 64); err == nil {
+		w.latencyAvgs = append(w.latencyAvgs, avg)
+	}
+	if p95, err := strconv.ParseFloat(wc.report.Metrics["turn_latency_p95_ms"], 64); err == nil {
+		w.latencyP95s = append(w.latencyP95s, p95)
+	}
+	if wc.report.Score > 0 {
+		w.scoreSum += wc.report.Score
+		w.scored++
+	}
+	if conf, err := strconv.ParseFloat(wc.report.Metrics["asr_confidence_avg"], 64); err == nil {
+		w.ASRCalls++
+		w.asrSum += conf
+		if conf < asrChronicAvg {
+			w.ASRLowCalls++
+		}
+	}
+}
+
+// finish derives the rates and averages. The latency p95 is taken over
+// the per-call p95s, so one slow call cannot dominate it.
+func (w *WeekStats) finish() {
+	if w.Calls > 0 {
+		w.FailureRate = float64(w.Failed) / float64(w.Calls)
+	}
+	w.LatencyCalls = len(w.latencyP95s)
+	if len(w.latencyAvgs) > 0 {
+		w.LatencyAvgMs, _, _ = latencyStats(w.latencyAvgs)
+	}
+	if len(w.latencyP95s) > 0 {
+		_, w.LatencyP95Ms, _ = latencyStats(w.latencyP95s)
+	}
+	if w.scored > 0 {
+		w.AvgScore = w.scoreSum / float64(w.scored)
+	}
+	if w.ASRCalls > 0 {
+		w.ASRConfidenceAvg = w.asrSum / float64(w.ASRCalls)
+	}
+}
+
+// failureClusters groups this week's failed calls by fingerprint, with
+// last week's count of the same fingerprint for comparison
+func failureClusters(calls []reportCall) []FailureCluster {
+	byPrint := make(map[string]*FailureCluster)
+	for _, wc := range calls {
+		fp := Fingerprint(wc.report, &wc.call)
+		if fp == "" {
+			continue
+		}
+		c := byPrint[fp]
+		if c == nil {
+			c = &FailureCluster{Fingerprint: fp}
+			byPrint[fp] = c
+		}
+		if wc.lastWeek {
+			c.LastWeek++
+			continue
+		}
+		c.Calls++
+		if c.Example == "" {
+			c.Example = wc.call.ID
+			c.Findings = criticalMessages(wc.report)
+		}
+	}
+
+	var clusters []FailureCluster
+	for _, c := range byPrint {
+		if c.Calls > 0 {
+			clusters = append(clusters, *c)
+		}
+	}
+	sort.Slice(clusters, func(i, j int) bool {
+		if clusters[i].Calls != clusters[j].Calls {
+			return clusters[i].Calls > clusters[j].Calls
+		}
+		return clusters[i].Fingerprint < clusters[j].Fingerprint
+	})
+	if len(clusters) > weeklyTopN {
+		clusters = clusters[:weeklyTopN]
+	}
+	return clusters
+}
+
+// criticalMessages lists a report's critical findings for a cluster label
+func criticalMessages(report *Report) []string {
+	var messages []string
+	for _, f := range report.Findings {
+		if f.Severity == SeverityCritical {
+			messages = append(messages, fmt.Sprintf("[%s] %s", f.Analyzer, f.Message))
+		}
+	}
+	if len(messages) == 0 {
+		messages = []string{"Call failed without critical findings"}
+	}
+	return messages
+}
+
+func intentCounts(calls []reportCall) []IntentCount {
+	byIntent := make(map[string]*IntentCount)
+	for _, wc := range calls {
+		for _, intent := range wc.intents {
+			c := byIntent[intent]
+			if c == nil {
+				c = &IntentCount{Intent: intent}
+				byIntent[intent] = c
+			}
+			if wc.lastWeek {
+				c.LastWeek++
+			} else {
+				c.Calls++
+			}
+		}
+	}
+
+	intents := make([]IntentCount, 0, len(byIntent))
+	for _, c := range byIntent {
+		intents = append(intents, *c)
+	}
+	sort.Slice(intents, func(i, j int) bool {
+		if intents[i].Calls != intents[j].Calls {
+			return intents[i].Calls > intents[j].Calls
+		}
+		return intents[i].Intent < intents[j].Intent
+	})
+	if len(intents) > weeklyTopN {
+		intents = intents[:weeklyTopN]
+	}
+	return intents
+}
+
+func providerCosts(calls []reportCall, rates map[string]float64) []ProviderCost {
+	byProvider := make(map[string]*ProviderCost)
+	for _, wc := range calls {
+		providers := wc.report.Metrics["providers"]
+		if providers == "" {
+			providers = "unknown"
+		}
+		for _, name := range strings.Split(providers, ",") {
+			c := byProvider[name]
+			if c == nil {
+				c = &ProviderCost{Provider: name, RatePerMin: rates[name]}
+				byProvider[name] = c
+			}
+			if wc.lastWeek {
+				c.LastMinutes += wc.minutes
+			} else {
+				c.Minutes += wc.minutes
+			}
+		}
+	}
+
+	costs := make([]ProviderCost, 0, len(byProvider))
+	for _, c := range byProvider {
+		c.Cost = c.Minutes * c.RatePerMin
+		c.LastWeekCost = c.LastMinutes * c.RatePerMin
+		costs = append(costs, *c)
+	}
+	sort.Slice(costs, func(i, j int) bool {
+		if costs[i].Cost != costs[j].Cost {
+			return costs[i].Cost > costs[j].Cost
+		}
+		return costs[i].Provider < costs[j].Provider
+	})
+	return costs
+}
+
+// tenantUsage splits the calls by tenant, busiest first. It is empty
+// when no call has a tenant.
+func tenantUsage(calls []reportCall) []TenantUsage {
+	byTenant := make(map[string]*TenantUsage)
+	tenanted := false
+	for _, wc := range calls {
+		name := wc.call.Tenant
+		if name == "" {
+			name = tenantUnassigned
+		} else {
+			tenanted = true
+		}
+		u := byTenant[name]
+		if u == nil {
+			u = &TenantUsage{Tenant: name}
+			byTenant[name] = u
+		}
+		if wc.lastWeek {
+			u.LastWeek++
+			u.LastMinutes += wc.minutes
+			continue
+		}
+		u.Calls++
+		u.Minutes += wc.minutes
+		if Fingerprint(wc.report, &wc.call) != "" {
+			u.Failed++
+		}
+		if wc.report.Score > 0 {
+			u.scoreSum += wc.report.Score
+			u.scored++
+		}
+	}
+	if !tenanted {
+		return nil
+	}
+	usage := make([]TenantUsage, 0, len(byTenant))
+	for _, u := range byTenant {
+		if u.Calls > 0 {
+			u.FailureRate = float64(u.Failed) / float64(u.Calls)
+		}
+		if u.scored > 0 {
+			u.AvgScore = u.scoreSum / float64(u.scored)
+		}
+		usage = append(usage, *u)
+	}
+	sort.Slice(usage, func(i, j int) bool {
+		if usage[i].Calls != usage[j].Calls {
+			return usage[i].Calls > usage[j].Calls
+		}
+		return usage[i].Tenant < usage[j].Tenant
+	})
+	return usage
+}
+
+// hoursStats splits the calls into in-hours and after-hours figures
+func hoursStats(calls []reportCall) []HoursStats {
+	split := []HoursStats{{Hours: HoursIn}, {Hours: HoursAfter}}
+	for i := range split {
+		s := &split[i]
+		s.ThisWeek.ByStatus = make(map[string]int)
+		s.LastWeek.ByStatus = make(map[string]int)
+		for _, wc := range calls {
+			if wc.hours != s.Hours {
+				continue
+			}
+			if wc.lastWeek {
+				s.LastWeek.add(wc)
+			} else {
+				s.ThisWeek.add(wc)
+			}
+		}
+		s.ThisWeek.finish()
+		s.LastWeek.finish()
+	}
+	return split
+}
+
+// sloStatus reports compliance with each configured objective, the
+// tenant's own where a single-tenant report has them. With business
+// hours, in-hours and after-hours calls are measured apart, so failures
+// outside staffed hours do not skew the in-hours figures.
+func (r *Runner) sloStatus(calls []reportCall, tenant string) []SLOStatus {
+	if r.hours == nil {
+		return r.sloCompliance(calls, tenant, "")
+	}
+	var statuses []SLOStatus
+	for _, hours := range []string{HoursIn, HoursAfter} {
+		var part []reportCall
+		for _, wc := range calls {
+			if wc.hours == hours {
+				part = append(part, wc)
+			}
+		}
+		statuses = append(statuses, r.sloCompliance(part, tenant, hours)...)
+	}
+	return statuses
+}
+
+func (r *Runner) sloCompliance(calls []reportCall, tenant, hours string) []SLOStatus {
+	slo := r.sloFor(tenant)
+	var statuses []SLOStatus
+	if limit := slo.TurnLatencyP95Ms; limit > 0 {
+		statuses = append(statuses, compliance(calls, fmt.Sprintf("turn latency p95 <= %.0fms", limit), func(report *Report) (bool, bool) {
+			p95, err := strconv.ParseFloat(report.Metrics["turn_latency_p95_ms"], 64)
+			return p95 <= limit, err == nil
+		}))
+	}
+	if need := slo.MinQualityScore; need > 0 {
+		statuses = append(statuses, compliance(calls, fmt.Sprintf("quality score >= %.0f", need), func(report *Report) (bool, bool) {
+			return report.Score >= need, true
+		}))
+	}
+	for i := range statuses {
+		statuses[i].Hours = hours
+	}
+	return statuses
+}
+
+// compliance applies check, which returns whether a call met the
+// objective and whether it could be measured at all
+func compliance(calls []reportCall, objective string, check func(*Report) (met, measured bool)) SLOStatus {
+	status := SLOStatus{Objective: objective}
+	var lastMet, lastTotal int
+	for _, wc := range calls {
+		met, measured := check(wc.report)
+		if !measured {
+			continue
+		}
+		if wc.lastWeek {
+			lastTotal++
+			if met {
+				lastMet++
+			}
+			continue
+		}
+		status.Total++
+		if met {
+			status.Met++
+		}
+	}
+	if status.Total > 0 {
+		status.Compliance = float64(status.Met) / float64(status.Total)
+	}
+	if lastTotal > 0 {
+		status.LastWeek = float64(lastMet) / float64(lastTotal)
+	}
+	return status
+}
+
+// Markdown renders the report as a Markdown document, with times in loc
+// and numbers and dates written the way lc does (nil for locale.Default)
+func (w *WeeklyReport) Markdown(loc *time.Location, lc *locale.Locale) string {
+	if lc == nil {
+		lc = locale.Default
+	}
+	change := func(this, last float64) string { return formatChange(this, last, lc) }
+	pct := func(share float64) string { return lc.Number(share*100, 1) + "%" }
+	var b strings.Builder
+	if w.Tenant != "" {
+		fmt.Fprintf(&b, "# Weekly Quality Report – %s\n\n", w.Tenant)
+	} else {
+		fmt.Fprintf(&b, "# Weekly Quality Report\n\n")
+	}
+	fmt.Fprintf(&b, "%s – %s (compared with the 7 days before)\n\n", lc.Timestamp(w.Start, loc), lc.Timestamp(w.End, loc))
+
+	this, last := w.ThisWeek, w.LastWeek
+	b.WriteString("## Volume\n\n")
+	b.WriteString("| | This week | Last week | Change |\n|---|---:|---:|---:|\n")
+	fmt.Fprintf(&b, "| Calls | %s | %s | %s |\n", lc.Int(this.Calls), lc.Int(last.Calls), change(float64(this.Calls), float64(last.Calls)))
+	for _, status := range append(CallStatuses, "unknown") {
+		if this.ByStatus[status] == 0 && last.ByStatus[status] == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "| %s | %s | %s | %s |\n", status, lc.Int(this.ByStatus[status]), lc.Int(last.ByStatus[status]),
+			change(float64(this.ByStatus[status]), float64(last.ByStatus[status])))
+	}
+	fmt.Fprintf(&b, "| Failure rate | %s | %s | %s pts |\n", pct(this.FailureRate), pct(last.FailureRate), lc.Signed((this.FailureRate-last.FailureRate)*100, 1))
+	fmt.Fprintf(&b, "| Call minutes | %s | %s | %s |\n", lc.Number(this.Minutes, 0), lc.Number(last.Minutes, 0), change(this.Minutes, last.Minutes))
+	if w.Skipped > 0 {
+		fmt.Fprintf(&b, "\n%s call(s) skipped: logs could not be collected.\n", lc.Int(w.Skipped))
+	}
+
+	if len(w.Tenants) > 0 {
+		b.WriteString("\n## Tenants\n\n")
+		b.WriteString("| Tenant | Calls | Last week | Failure rate | Quality score | Minutes | Last week |\n|---|---:|---:|---:|---:|---:|---:|\n")
+		for _, u := range w.Tenants {
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s |\n", u.Tenant, lc.Int(u.Calls), lc.Int(u.LastWeek), pct(u.FailureRate),
+				lc.Number(u.AvgScore, 0), lc.Number(u.Minutes, 0), lc.Number(u.LastMinutes, 0))
+		}
+	}
+
+	if len(w.BusinessHours) > 0 {
+		b.WriteString("\n## Business Hours\n\n")
+		b.WriteString("| | Calls | Last week | Failure rate | Last week | Quality score | Turn latency p95 | Minutes |\n|---|---:|---:|---:|---:|---:|---:|---:|\n")
+		for _, h := range w.BusinessHours {
+			latency := "–"
+			if h.ThisWeek.LatencyCalls > 0 {
+				latency = lc.Milliseconds(h.ThisWeek.LatencyP95Ms)
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s | %s |\n", hoursName(h.Hours), lc.Int(h.ThisWeek.Calls), lc.Int(h.LastWeek.Calls),
+				pct(h.ThisWeek.FailureRate), pct(h.LastWeek.FailureRate), lc.Number(h.ThisWeek.AvgScore, 0),
+				latency, lc.Number(h.ThisWeek.Minutes, 0))
+		}
+	}
+
+	b.WriteString("\n## Failure Clusters\n\n")
+	if len(w.Clusters) == 0 {
+		b.WriteString("No failed calls this week.\n")
+	}
+	for i, c := range w.Clusters {
+		trend := "new this week"
+		if c.LastWeek > 0 {
+			trend = lc.Int(c.LastWeek) + " last week"
+		}
+		fmt.Fprintf(&b, "%d. **%s call(s)** (%s) – fingerprint `%s`, e.g. `%s`\n", i+1, lc.Int(c.Calls), trend, c.Fingerprint, c.Example)
+		for _, f := range c.Findings {
+			fmt.Fprintf(&b, "   - %s\n", f)
+		}
+	}
+
+	b.WriteString("\n## Latency\n\n")
+	if this.LatencyCalls == 0 && last.LatencyCalls == 0 {
+		b.WriteString("No turn latency measured.\n")
+	} else {
+		b.WriteString("| Turn latency | This week | Last week | Change |\n|---|---:|---:|---:|\n")
+		fmt.Fprintf(&b, "| Average | %s | %s | %s |\n", lc.Milliseconds(this.LatencyAvgMs), lc.Milliseconds(last.LatencyAvgMs), change(this.LatencyAvgMs, last.LatencyAvgMs))
+		fmt.Fprintf(&b, "| p95 of per-call p95 | %s | %s | %s |\n", lc.Milliseconds(this.LatencyP95Ms), lc.Milliseconds(last.LatencyP95Ms), change(this.LatencyP95Ms, last.LatencyP95Ms))
+		fmt.Fprintf(&b, "| Quality score (avg) | %s | %s | %s |\n", lc.Number(this.AvgScore, 0), lc.Number(last.AvgScore, 0), lc.Signed(this.AvgScore-last.AvgScore, 0))
+	}
+
+	if this.ASRCalls > 0 || last.ASRCalls > 0 {
+		b.WriteString("\n## Speech Recognition\n\n")
+		b.WriteString("| STT confidence | This week | Last week | Change |\n|---|---:|---:|---:|\n")
+		fmt.Fprintf(&b, "| Average | %s | %s | %s |\n", lc.Number(this.ASRConfidenceAvg, 2), lc.Number(last.ASRConfidenceAvg, 2), lc.Signed(this.ASRConfidenceAvg-last.ASRConfidenceAvg, 2))
+		fmt.Fprintf(&b, "| Calls below %s | %s/%s | %s/%s | |\n", lc.Number(asrChronicAvg, 2), lc.Int(this.ASRLowCalls), lc.Int(this.ASRCalls), lc.Int(last.ASRLowCalls), lc.Int(last.ASRCalls))
+		if this.ASRCalls > 0 && float64(this.ASRLowCalls)/float64(this.ASRCalls) >= asrChronicShare {
+			b.WriteString("\nConfidence is low on many calls: " + asrRecommendation("", "", "") + ".\n")
+		}
+	}
+
+	b.WriteString("\n## Top Caller Intents\n\n")
+	b.WriteString("Tools the agent ran; calls without a tool are counted as \"" + intentNone + "\".\n\n")
+	b.WriteString("| Intent | Calls | Last week |\n|---|---:|---:|\n")
+	for _, c := range w.Intents {
+		fmt.Fprintf(&b, "| %s | %s | %s |\n", c.Intent, lc.Int(c.Calls), lc.Int(c.LastWeek))
+	}
+
+	b.WriteString("\n## Provider Cost\n\n")
+	b.WriteString("| Provider | Minutes | Rate/min | Cost | Last week |\n|---|---:|---:|---:|---:|\n")
+	var total, lastTotal float64
+	unpriced := false
+	for _, c := range w.Costs {
+		if c.RatePerMin == 0 {
+			unpriced = true
+			fmt.Fprintf(&b, "| %s | %s | – | – | – |\n", c.Provider, lc.Number(c.Minutes, 0))
+			continue
+		}
+		total += c.Cost
+		lastTotal += c.LastWeekCost
+		fmt.Fprintf(&b, "| %s | %s | %s | %s | %s |\n", c.Provider, lc.Number(c.Minutes, 0), lc.Number(c.RatePerMin, 4), lc.Number(c.Cost, 2), lc.Number(c.LastWeekCost, 2))
+	}
+	fmt.Fprintf(&b, "| **Total** | | | **%s** | %s |\n", lc.Number(total, 2), lc.Number(lastTotal, 2))
+	if unpriced {
+		b.WriteString("\nSet per-minute prices under `costs:` in ~/.agent/config to price every provider.\n")
+	}
+
+	b.WriteString("\n## SLO Status\n\n")
+	if len(w.SLO) == 0 {
+		b.WriteString("No objectives set (`slo:` in ~/.agent/config).\n")
+	} else {
+		b.WriteString("| Objective | Met | Compliance | Last week |\n|---|---:|---:|---:|\n")
+		for _, s := range w.SLO {
+			objective := s.Objective
+			if s.Hours != "" {
+				objective += " (" + strings.ToLower(hoursName(s.Hours)) + ")"
+			}
+			fmt.Fprintf(&b, "| %s | %s/%s | %s | %s |\n", objective, lc.Int(s.Met), lc.Int(s.Total), pct(s.Compliance), pct(s.LastWeek))
+		}
+	}
+	return b.String()
+}
+
+// hoursName is the heading of an hours label
+func hoursName(hours string) string {
+	if hours == HoursAfter {
+		return "After hours"
+	}
+	return "In hours"
+}
+
+// formatChange renders the relative change from last to this week
+func formatChange(this, last float64, lc *locale.Locale) string {
+	if last == 0 {
+		if this == 0 {
+			return "–"
+		}
+		return "new"
+	}
+	return lc.Signed((this-last)/last*100, 0) + "%"
+}
@@ -0,0 +1,162 @@
+package troubleshoot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+)
+
+const (
+	// defaultListWindow is how far back --list looks when --since is not set
+	defaultListWindow = "24h"
+
+	// defaultCollectWindow is used when nothing is known about the call
+	defaultCollectWindow = "1h"
+
+	// windowPadding widens an indexed call window to catch setup/teardown lines
+	windowPadding = 2 * time.Minute
+)
+
+var logTimestampPattern = regexp.MustCompile(`\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?`)
+
+// parseTimeFlag converts a --since/--until value into an absolute time.
+// Accepts Go durations (90m, 2h), day counts (7d), RFC3339 timestamps and
+// the shorter "2006-01-02 15:04" / "2006-01-02" forms, which are read in loc.
+func parseTimeFlag(value string, now time.Time, loc *time.Location) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty time value")
+	}
+
+	if strings.HasSuffix(value, "d") {
+		if days, err := strconv.Atoi(strings.TrimSuffix(value, "d")); err == nil {
+			return now.Add(-time.Duration(days) * 24 * time.Hour), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	layouts := []string{
+		"2006-01-02T15:04:05",
+		"2006-01-02 15:04:05",
+		"2006-01-02T15:04",
+		"2006-01-02 15:04",
+		"2006-01-02",
+	}
+	for _, layout := range layouts {
+		if t, err := time.ParseInLocation(layout, value, loc); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (use a duration like 2h/7d or a timestamp like 2006-01-02 15:04)", value)
+}
+
+// formatTimestamp renders a time in the display zone, always showing the zone
+func formatTimestamp(t time.Time, loc *time.Location) string {
+	return t.In(loc).Format("2006-01-02 15:04:05 MST")
+}
+
+// dockerTime formats a time for docker logs --since/--until
+func dockerTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// parseLogTimestamp extracts the timestamp of a log line, if it has one.
+// Timestamps without a zone are read in loc (UTC for most containers).
+func parseLogTimestamp(line string, loc *time.Location) (time.Time, bool) {
+	raw := logTimestampPattern.FindString(line)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	raw = strings.Replace(raw, " ", "T", 1)
+	raw = strings.Replace(raw, ",", ".", 1)
+
+	if t, err := time.Parse(time.RFC3339Nano, raw); err == nil {
+		return t, true
+	}
+	if t, err := time.Parse("2006-01-02T15:04:05.999999999Z0700", raw); err == nil {
+		return t, true
+	}
+	if t, err := time.ParseInLocation("2006-01-02T15:04:05.999999999", raw, loc); err == nil {
+		return t, true
+	}
+	return time.Time{}, false
+}
+
+// callIDTime derives the call start from an Asterisk uniqueid
+// (epoch seconds + sequence, e.g. 1761424308.2043)
+func callIDTime(callID string) (time.Time, bool) {
+	parts := strings.SplitN(callID, ".", 2)
+	secs, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil || secs < 1000000000 {
+		return time.Time{}, false
+	}
+	return time.Unix(secs, 0), true
+}
+
+// listWindow returns the docker logs window used to discover calls
+func (r *Runner) listWindow() (since, until string, err error) {
+	now := time.Now()
+	sinceValue := r.since
+	if sinceValue == "" {
+		sinceValue = defaultListWindow
+	}
+	start, err := parseTimeFlag(sinceValue, now, r.loc)
+	if err != nil {
+		return "", "", fmt.Errorf("--since: %w", err)
+	}
+	since = dockerTime(start)
+
+	if r.until != "" {
+		end, err := parseTimeFlag(r.until, now, r.loc)
+		if err != nil {
+			return "", "", fmt.Errorf("--until: %w", err)
+		}
+		until = dockerTime(end)
+	}
+	return since, until, nil
+}
+
+// collectionWindow returns the docker logs window for the selected call.
+// Explicit --since/--until win; otherwise the window comes from the call
+// index, then from the call ID itself, then from the 1h default.
+func (r *Runner) collectionWindow() (since, until string, err error) {
+	if r.since != "" || r.until != "" {
+		if r.since == "" {
+			r.since = defaultListWindow
+		}
+		return r.listWindow()
+	}
+
+	if call, ok := LoadCallIndex().Get(r.callID); ok && !call.Timestamp.IsZero() {
+		since = dockerTime(call.Timestamp.Add(-windowPadding))
+		if !call.EndTime.IsZero() {
+			until = dockerTime(call.EndTime.Add(windowPadding))
+		}
+		return since, until, nil
+	}
+
+	if start, ok := callIDTime(r.callID); ok {
+		return dockerTime(start.Add(-windowPadding)), "", nil
+	}
+
+	start, _ := parseTimeFlag(defaultCollectWindow, time.Now(), r.loc)
+	return dockerTime(start), "", nil
+}
+
+// dockerLogsOptions converts a docker-style window to log options
+func dockerLogsOptions(since, until string) docker.LogsOptions {
+	var opts docker.LogsOptions
+	opts.Since, _ = time.Parse(time.RFC3339, since)
+	opts.Until, _ = time.Parse(time.RFC3339, until)
+	return opts
+}
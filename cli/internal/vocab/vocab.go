@@ -0,0 +1,579 @@
+// Package vocab manages one custom vocabulary (keyword boost) list for
+// every STT provider of the engine config. The list is kept under
+// 'vocabulary' in config/ai-agent.yaml and written into each provider's
+// own setting: Deepgram keywords or keyterms, Google speech contexts and
+// the OpenAI transcription prompt.
+package vocab
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Key is the top-level config key holding the vocabulary
+const Key = "vocabulary"
+
+// Provider families with keyword boosting
+const (
+	FamilyDeepgram = "deepgram"
+	FamilyGoogle   = "google"
+	FamilyOpenAI   = "openai"
+)
+
+// Term is one word or phrase callers say that the recognizer should
+// favor. Boost is the provider's weight; 0 leaves it to the provider.
+type Term struct {
+	Term  string  `yaml:"term" json:"term"`
+	Boost float64 `yaml:"boost,omitempty" json:"boost,omitempty"`
+}
+
+// Target is one STT component of the config the vocabulary is written to
+type Target struct {
+	// Name is e.g. "pipeline local_hybrid (stt google_stt)" or
+	// "provider deepgram"
+	Name   string `json:"name"`
+	Family string `json:"family,omitempty"`
+	// Setting is the config path written, e.g.
+	// providers.deepgram.keyterms
+	Setting string `json:"setting,omitempty"`
+	// Unsupported says why the component cannot be boosted
+	Unsupported string `json:"unsupported,omitempty"`
+	// InSync is set when the setting holds the current vocabulary
+	InSync bool `json:"in_sync"`
+
+	path  []string
+	value interface{}
+}
+
+// Config is config/ai-agent.yaml. Saving rewrites only the blocks that
+// changed, so the rest of the file keeps its layout and comments.
+type Config struct {
+	Path    string
+	data    []byte
+	root    yaml.Node
+	changed []block
+}
+
+// block is a changed key of the config: top-level or under providers or
+// pipelines. line and col are the key's position in the file, 0 for a
+// new key.
+type block struct {
+	path      []string
+	line, col int
+}
+
+// Open reads config/ai-agent.yaml under the project directory
+func Open(dir string) (*Config, error) {
+	path := filepath.Join(dir, "config", "ai-agent.yaml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	c := &Config{Path: path, data: data}
+	if err := yaml.Unmarshal(data, &c.root); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if c.doc() == nil {
+		return nil, fmt.Errorf("%s: not a YAML mapping", path)
+	}
+	return c, nil
+}
+
+func (c *Config) doc() *yaml.Node {
+	if len(c.root.Content) == 0 || c.root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	return c.root.Content[0]
+}
+
+// mark records that the key at path changed
+func (c *Config) mark(path ...string) {
+	for _, b := range c.changed {
+		if strings.Join(b.path, ".") == strings.Join(path, ".") {
+			return
+		}
+	}
+	b := block{path: path}
+	if k, _ := c.lookup(path); k != nil {
+		b.line, b.col = k.Line, k.Column
+	}
+	c.changed = append(c.changed, b)
+}
+
+// lookup returns the key and value nodes at path
+func (c *Config) lookup(path []string) (*yaml.Node, *yaml.Node) {
+	m := c.doc()
+	for _, key := range path[:len(path)-1] {
+		m = mapGet(m, key)
+	}
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == path[len(path)-1] {
+			return m.Content[i], m.Content[i+1]
+		}
+	}
+	return nil, nil
+}
+
+// Save writes the changed blocks back into the file
+func (c *Config) Save() error {
+	info, err := os.Stat(c.Path)
+	if err != nil {
+		return err
+	}
+	data, err := c.Content()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.Path, data, info.Mode().Perm())
+}
+
+// Content returns the file with the changed blocks written back, bottom
+// up so the positions of the blocks above stay valid; new keys are
+// appended
+func (c *Config) Content() ([]byte, error) {
+	var err error
+	lines := strings.Split(strings.TrimRight(string(c.data), "\n"), "\n")
+	blocks := append([]block(nil), c.changed...)
+	sort.SliceStable(blocks, func(i, j int) bool { return blocks[i].line > blocks[j].line })
+	for _, b := range blocks {
+		var text []string
+		if k, v := c.lookup(b.path); k != nil {
+			indent := 0
+			if b.line > 0 {
+				indent = b.col - 1
+			}
+			if text, err = encodeBlock(k, v, indent); err != nil {
+				return nil, err
+			}
+		}
+		if b.line == 0 {
+			lines = append(lines, text...)
+			continue
+		}
+		start := b.line - 1
+		end := blockEnd(lines, start, b.col-1)
+		lines = append(lines[:start], append(text, lines[end:]...)...)
+	}
+	return []byte(strings.Join(lines, "\n") + "\n"), nil
+}
+
+// encodeBlock renders key: value as lines indented by indent spaces
+func encodeBlock(k, v *yaml.Node, indent int) ([]string, error) {
+	key := *k
+	key.HeadComment = ""
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(&yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{&key, v}}); err != nil {
+		return nil, err
+	}
+	enc.Close()
+	lines := strings.Split(strings.TrimRight(b.String(), "\n"), "\n")
+	pad := strings.Repeat(" ", indent)
+	for i := range lines {
+		lines[i] = pad + lines[i]
+	}
+	return lines, nil
+}
+
+// blockEnd returns the index of the first line after the block of the
+// key at lines[start]: the next line indented no deeper than the key,
+// other than a list item at the key's indentation. Trailing blank lines
+// and comments of the outer level stay outside the block.
+func blockEnd(lines []string, start, indent int) int {
+	end := start + 1
+	for ; end < len(lines); end++ {
+		trimmed := strings.TrimSpace(lines[end])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		depth := len(lines[end]) - len(strings.TrimLeft(lines[end], " "))
+		if depth < indent || (depth == indent && !strings.HasPrefix(trimmed, "- ")) {
+			break
+		}
+	}
+	for end > start+1 {
+		trimmed := strings.TrimSpace(lines[end-1])
+		depth := len(lines[end-1]) - len(strings.TrimLeft(lines[end-1], " "))
+		if trimmed != "" && !(strings.HasPrefix(trimmed, "#") && depth <= indent) {
+			break
+		}
+		end--
+	}
+	return end
+}
+
+// Terms returns the vocabulary
+func (c *Config) Terms() ([]Term, error) {
+	node := mapGet(c.doc(), Key)
+	if node == nil {
+		return nil, nil
+	}
+	var terms []Term
+	if err := node.Decode(&terms); err != nil {
+		return nil, fmt.Errorf("%s: %s: %w", c.Path, Key, err)
+	}
+	return terms, nil
+}
+
+// SetTerms replaces the vocabulary; an empty one removes the key
+func (c *Config) SetTerms(terms []Term) error {
+	c.mark(Key)
+	if len(terms) == 0 {
+		mapDelete(c.doc(), Key)
+		return nil
+	}
+	node := &yaml.Node{}
+	if err := node.Encode(terms); err != nil {
+		return err
+	}
+	mapSet(c.doc(), Key, node)
+	return nil
+}
+
+// Add adds terms, or updates the boost of terms already listed (compared
+// case-insensitively). It returns the new list and how many terms were
+// added.
+func Add(list []Term, texts []string, boost float64) ([]Term, int) {
+	added := 0
+	for _, text := range texts {
+		text = strings.Join(strings.Fields(text), " ")
+		if text == "" {
+			continue
+		}
+		found := false
+		for i := range list {
+			if strings.EqualFold(list[i].Term, text) {
+				list[i].Boost = boost
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, Term{Term: text, Boost: boost})
+			added++
+		}
+	}
+	return list, added
+}
+
+// Remove removes terms (compared case-insensitively), returning the new
+// list and the terms that were not listed
+func Remove(list []Term, texts []string) ([]Term, []string) {
+	var missing []string
+	for _, text := range texts {
+		text = strings.Join(strings.Fields(text), " ")
+		kept := list[:0]
+		found := false
+		for _, t := range list {
+			if strings.EqualFold(t.Term, text) {
+				found = true
+				continue
+			}
+			kept = append(kept, t)
+		}
+		list = kept
+		if !found {
+			missing = append(missing, text)
+		}
+	}
+	return list, missing
+}
+
+// Targets lists the config's STT components: the stt of every pipeline
+// and every enabled monolithic provider that transcribes, with the
+// setting the vocabulary goes to and whether it holds the vocabulary
+func (c *Config) Targets(terms []Term) []Target {
+	doc := c.doc()
+	providers := mapGet(doc, "providers")
+	var targets []Target
+
+	if pipelines := mapGet(doc, "pipelines"); pipelines != nil && pipelines.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(pipelines.Content); i += 2 {
+			name, p := pipelines.Content[i].Value, pipelines.Content[i+1]
+			stt := scalar(mapGet(p, "stt"))
+			if stt == "" {
+				continue
+			}
+			model := scalar(mapGet(mapGet(mapGet(p, "options"), "stt"), "model"))
+			provider := mapGet(providers, stt)
+			if model == "" {
+				model = providerModel(provider)
+			}
+			t := Target{Name: fmt.Sprintf("pipeline %s (stt %s)", name, stt)}
+			t.Family, t.Unsupported = family(stt, provider)
+			t.path = []string{"pipelines", name, "options", "stt"}
+			targets = append(targets, t.resolve(model, terms))
+		}
+	}
+
+	if providers != nil && providers.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(providers.Content); i += 2 {
+			name, p := providers.Content[i].Value, providers.Content[i+1]
+			kind := scalar(mapGet(p, "type"))
+			if kind != "full" && kind != "openai_realtime" {
+				continue
+			}
+			if scalar(mapGet(p, "enabled")) == "false" || !hasCapability(p, "stt") {
+				continue
+			}
+			t := Target{Name: "provider " + name}
+			t.Family, t.Unsupported = family(name, p)
+			t.path = []string{"providers", name}
+			// The Deepgram Voice Agent listens with nova-3 whatever the
+			// provider's model is
+			targets = append(targets, t.resolve("nova-3", terms))
+		}
+	}
+	for i := range targets {
+		targets[i].InSync = c.inSync(targets[i], terms)
+	}
+	return targets
+}
+
+// resolve sets the setting and value the vocabulary takes for the
+// target's family and model
+func (t Target) resolve(model string, terms []Term) Target {
+	if t.Unsupported != "" {
+		t.path = nil
+		return t
+	}
+	switch t.Family {
+	case FamilyDeepgram:
+		lower := strings.ToLower(model)
+		if strings.HasPrefix(lower, "nova-3") || strings.HasPrefix(lower, "flux") {
+			// Keyterm prompting takes no boost
+			t.path = append(t.path, "keyterms")
+			list := make([]string, 0, len(terms))
+			for _, term := range terms {
+				list = append(list, term.Term)
+			}
+			t.value = list
+		} else {
+			t.path = append(t.path, "keywords")
+			list := make([]string, 0, len(terms))
+			for _, term := range terms {
+				if term.Boost != 0 {
+					list = append(list, term.Term+":"+strconv.FormatFloat(term.Boost, 'f', -1, 64))
+				} else {
+					list = append(list, term.Term)
+				}
+			}
+			t.value = list
+		}
+	case FamilyGoogle:
+		// One speech context per boost value
+		t.path = append(t.path, "config_overrides", "speechContexts")
+		t.value = speechContexts(terms)
+	case FamilyOpenAI:
+		// The transcription prompt primes Whisper with the spelling
+		list := make([]string, 0, len(terms))
+		for _, term := range terms {
+			list = append(list, term.Term)
+		}
+		t.path = append(t.path, "prompt")
+		t.value = strings.Join(list, ", ")
+	}
+	t.Setting = strings.Join(t.path, ".")
+	return t
+}
+
+func speechContexts(terms []Term) []map[string]interface{} {
+	byBoost := make(map[float64][]string)
+	var boosts []float64
+	for _, term := range terms {
+		if _, ok := byBoost[term.Boost]; !ok {
+			boosts = append(boosts, term.Boost)
+		}
+		byBoost[term.Boost] = append(byBoost[term.Boost], term.Term)
+	}
+	sort.Float64s(boosts)
+	contexts := make([]map[string]interface{}, 0, len(boosts))
+	for _, boost := range boosts {
+		ctx := map[string]interface{}{"phrases": byBoost[boost]}
+		if boost != 0 {
+			ctx["boost"] = boost
+		}
+		contexts = append(contexts, ctx)
+	}
+	return contexts
+}
+
+// family returns the keyword-boost family of a provider, from its name
+// and type, or why it has none
+func family(name string, provider *yaml.Node) (string, string) {
+	kind := strings.ToLower(scalar(mapGet(provider, "type")))
+	lower := strings.ToLower(name)
+	switch {
+	case strings.Contains(lower, "deepgram") || kind == "deepgram":
+		return FamilyDeepgram, ""
+	case strings.Contains(lower, "google_live") || strings.Contains(lower, "gemini"):
+		return "", "Gemini Live takes no phrase hints"
+	case strings.Contains(lower, "google") || kind == "google":
+		return FamilyGoogle, ""
+	case kind == "openai_realtime" || strings.Contains(lower, "realtime"):
+		return "", "OpenAI Realtime transcription takes no vocabulary"
+	case kind == "openai" || strings.Contains(lower, "openai") || strings.Contains(lower, "whisper"):
+		return FamilyOpenAI, ""
+	case strings.Contains(lower, "elevenlabs"):
+		return "", "ElevenLabs agents take no vocabulary"
+	case kind == "local" || strings.Contains(lower, "local") || strings.Contains(lower, "vosk"):
+		return "", "local STT (Vosk) takes no keyword boost"
+	}
+	return "", "no known keyword boost setting"
+}
+
+func providerModel(provider *yaml.Node) string {
+	for _, key := range []string{"stt_model", "model"} {
+		if v := scalar(mapGet(provider, key)); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+func hasCapability(provider *yaml.Node, capability string) bool {
+	caps := mapGet(provider, "capabilities")
+	if caps == nil || caps.Kind != yaml.SequenceNode {
+		// Providers without capabilities are full agents
+		return true
+	}
+	for _, n := range caps.Content {
+		if n.Value == capability {
+			return true
+		}
+	}
+	return false
+}
+
+// Push writes the vocabulary into every supported target's setting, or
+// removes the settings for an empty vocabulary. It returns the targets.
+func (c *Config) Push(terms []Term) ([]Target, error) {
+	targets := c.Targets(terms)
+	for i, t := range targets {
+		if t.path == nil {
+			continue
+		}
+		c.mark(t.path[:2]...)
+		if len(terms) == 0 {
+			c.remove(t.path)
+			targets[i].InSync = true
+			continue
+		}
+		parent := c.doc()
+		for _, key := range t.path[:len(t.path)-1] {
+			parent = mapEnsure(parent, key)
+		}
+		leaf := t.path[len(t.path)-1]
+		node := &yaml.Node{}
+		if err := node.Encode(t.value); err != nil {
+			return nil, err
+		}
+		mapSet(parent, leaf, node)
+		targets[i].InSync = true
+	}
+	return targets, nil
+}
+
+// remove deletes the setting at path and the mappings it leaves empty,
+// up to the provider or pipeline
+func (c *Config) remove(path []string) {
+	parents := []*yaml.Node{c.doc()}
+	for _, key := range path[:len(path)-1] {
+		next := mapGet(parents[len(parents)-1], key)
+		if next == nil {
+			return
+		}
+		parents = append(parents, next)
+	}
+	for i := len(path) - 1; i >= 2; i-- {
+		mapDelete(parents[i], path[i])
+		if len(parents[i].Content) > 0 {
+			return
+		}
+	}
+}
+
+// inSync reports whether a target's setting holds the vocabulary
+func (c *Config) inSync(t Target, terms []Term) bool {
+	if t.path == nil {
+		return true
+	}
+	node := c.doc()
+	for _, key := range t.path {
+		node = mapGet(node, key)
+	}
+	if len(terms) == 0 {
+		return node == nil
+	}
+	if node == nil {
+		return false
+	}
+	var current interface{}
+	if err := node.Decode(&current); err != nil {
+		return false
+	}
+	a, errA := yaml.Marshal(current)
+	b, errB := yaml.Marshal(t.value)
+	return errA == nil && errB == nil && string(a) == string(b)
+}
+
+func mapGet(m *yaml.Node, key string) *yaml.Node {
+	if m == nil || m.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func mapSet(m *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content[i+1] = value
+			return
+		}
+	}
+	m.Content = append(m.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// mapEnsure returns the mapping under key, creating it when missing
+func mapEnsure(m *yaml.Node, key string) *yaml.Node {
+	if n := mapGet(m, key); n != nil && n.Kind == yaml.MappingNode {
+		return n
+	}
+	n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	mapSet(m, key, n)
+	return n
+}
+
+func mapDelete(m *yaml.Node, key string) {
+	if m == nil {
+		return
+	}
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			m.Content = append(m.Content[:i], m.Content[i+2:]...)
+			return
+		}
+	}
+}
+
+func scalar(n *yaml.Node) string {
+	if n == nil || n.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return n.Value
+}
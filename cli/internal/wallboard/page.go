@@ -0,0 +1,78 @@
+package wallboard
+
+// Page is the browser wallboard served by 'agent serve --events'. It
+// polls wallboard.json next to it, passing on its own query string so
+// ?token= reaches the stats endpoint.
+const Page = `<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>AI Voice Agent - Wallboard</title>
+<style>
+  html, body { margin: 0; height: 100%; background: #0b0f14; color: #e6edf3;
+    font-family: -apple-system, "Segoe UI", Roboto, Helvetica, Arial, sans-serif; }
+  main { display: grid; grid-template-columns: 1fr 1fr; grid-template-rows: 1fr 1fr;
+    gap: 2vmin; padding: 2vmin; height: calc(100% - 8vmin); box-sizing: border-box; }
+  section { background: #151b23; border-radius: 2vmin; display: flex; flex-direction: column;
+    justify-content: center; align-items: center; }
+  h2 { margin: 0; font-size: 3.5vmin; font-weight: 600; letter-spacing: .1em; color: #8b949e; }
+  .value { font-size: 22vmin; font-weight: 700; line-height: 1; font-variant-numeric: tabular-nums; }
+  .ok { color: #3fb950; } .warn { color: #d29922; } .crit { color: #f85149; } .info { color: #58a6ff; }
+  footer { height: 6vmin; display: flex; align-items: center; justify-content: center;
+    font-size: 2.5vmin; color: #8b949e; }
+  footer.stale { color: #f85149; }
+</style>
+</head>
+<body>
+<main>
+  <section><h2>ACTIVE CALLS</h2><div id="active" class="value info">-</div></section>
+  <section><h2>ANSWER RATE (1H)</h2><div id="answer" class="value ok">-</div></section>
+  <section><h2>P95 TURN LATENCY (1H)</h2><div id="p95" class="value ok">-</div></section>
+  <section><h2>FAILURES (1H)</h2><div id="failures" class="value ok">-</div></section>
+</main>
+<footer id="footer">Connecting...</footer>
+<script>
+function show(id, text, cls) {
+  var el = document.getElementById(id);
+  el.textContent = text;
+  el.className = "value " + cls;
+}
+function level(s, name) {
+  var l = (s.levels || {})[name];
+  return l === "critical" ? "crit" : l === "warning" ? "warn" : "ok";
+}
+function refresh() {
+  fetch("wallboard.json" + window.location.search, {cache: "no-store"})
+    .then(function (r) { if (!r.ok) { throw new Error(r.status + " " + r.statusText); } return r.json(); })
+    .then(function (s) {
+      show("active", String(s.active_calls), "info");
+      if (s.answer_rate === null) {
+        show("answer", "-", "ok");
+      } else {
+        show("answer", Math.round(s.answer_rate) + "%", level(s, "answer_rate"));
+      }
+      if (s.p95_latency_ms === null) {
+        show("p95", "-", "ok");
+      } else {
+        var ms = s.p95_latency_ms;
+        show("p95", ms < 1000 ? ms + "ms" : (ms / 1000).toFixed(1) + "s", level(s, "p95_latency_ms"));
+      }
+      show("failures", String(s.failures), level(s, "failures"));
+      var footer = document.getElementById("footer");
+      footer.className = "";
+      footer.textContent = s.calls + " call(s), " + s.turns + " turn(s) in the last hour · updated " +
+        new Date(s.updated).toLocaleTimeString();
+    })
+    .catch(function (err) {
+      var footer = document.getElementById("footer");
+      footer.className = "stale";
+      footer.textContent = "Cannot reach agent serve: " + err.message;
+    });
+}
+refresh();
+setInterval(refresh, 2000);
+</script>
+</body>
+</html>
+`
@@ -0,0 +1,173 @@
+package wallboard
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fatih/color"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+)
+
+// font is a 3x5 block font for the characters the figures use
+var font = map[rune][5]string{
+	'0': {"###", "# #", "# #", "# #", "###"},
+	'1': {" # ", "## ", " # ", " # ", "###"},
+	'2': {"###", "  #", "###", "#  ", "###"},
+	'3': {"###", "  #", "###", "  #", "###"},
+	'4': {"# #", "# #", "###", "  #", "  #"},
+	'5': {"###", "#  ", "###", "  #", "###"},
+	'6': {"###", "#  ", "###", "# #", "###"},
+	'7': {"###", "  #", "  #", "  #", "  #"},
+	'8': {"###", "# #", "###", "# #", "###"},
+	'9': {"###", "# #", "###", "  #", "###"},
+	'%': {"# #", "  #", " # ", "#  ", "# #"},
+	'-': {"   ", "   ", "###", "   ", "   "},
+}
+
+// tile is one figure of the wallboard
+type tile struct {
+	label string
+	value string
+	color *color.Color
+}
+
+// tiles turns stats into the four wallboard figures, colored by level.
+// With themes that cannot tell levels apart by color, labels also name
+// the level.
+func tiles(s Stats) []tile {
+	level := func(name string) *color.Color {
+		switch s.Levels[name] {
+		case troubleshoot.SeverityCritical:
+			return theme.Bold(theme.Error)
+		case troubleshoot.SeverityWarning:
+			return theme.Bold(theme.Warning)
+		}
+		return theme.Bold(theme.Success)
+	}
+	label := func(text, name string) string {
+		if !theme.Symbols() {
+			return text
+		}
+		switch s.Levels[name] {
+		case troubleshoot.SeverityCritical:
+			return "[CRIT] " + text
+		case troubleshoot.SeverityWarning:
+			return "[WARN] " + text
+		}
+		return "[OK] " + text
+	}
+	answer, latency := "-", "-"
+	if s.AnswerRate != nil {
+		answer = fmt.Sprintf("%.0f%%", *s.AnswerRate)
+	}
+	if s.P95LatencyMs != nil {
+		latency = fmt.Sprintf("%d", *s.P95LatencyMs)
+	}
+	return []tile{
+		{label: "ACTIVE CALLS", value: fmt.Sprintf("%d", s.Active), color: theme.Bold(theme.Accent)},
+		{label: label("ANSWER RATE (1H)", "answer_rate"), value: answer, color: level("answer_rate")},
+		{label: label("P95 TURN LATENCY MS (1H)", "p95_latency_ms"), value: latency, color: level("p95_latency_ms")},
+		{label: label("FAILURES (1H)", "failures"), value: fmt.Sprintf("%d", s.Failures), color: level("failures")},
+	}
+}
+
+// Render lays stats out as large figures, two per row, for a terminal.
+// Each block of a digit is scale rows high and 2*scale columns wide.
+func Render(s Stats, scale int, loc *time.Location) string {
+	if scale < 1 {
+		scale = 1
+	}
+	ts := tiles(s)
+	width := 0
+	for _, t := range ts {
+		for _, w := range []int{utf8.RuneCountInString(t.label), bigWidth(t.value, scale)} {
+			if w > width {
+				width = w
+			}
+		}
+	}
+	width += 4 * scale
+
+	var b strings.Builder
+	bold := color.New(color.Bold)
+	for row := 0; row < len(ts); row += 2 {
+		pair := ts[row:]
+		if len(pair) > 2 {
+			pair = pair[:2]
+		}
+		for _, t := range pair {
+			b.WriteString(bold.Sprint(pad(t.label, width)))
+		}
+		b.WriteString("\n\n")
+		rendered := make([][]string, len(pair))
+		for i, t := range pair {
+			rendered[i] = big(t.value, scale)
+		}
+		for line := 0; line < 5*scale; line++ {
+			for i, t := range pair {
+				b.WriteString(t.color.Sprint(pad(rendered[i][line], width)))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n\n")
+	}
+	if !s.Updated.IsZero() {
+		b.WriteString(fmt.Sprintf("%d call(s), %d turn(s) in the last hour · updated %s",
+			s.Calls, s.Turns, s.Updated.In(loc).Format("15:04:05")))
+	}
+	return b.String()
+}
+
+// big renders text in the block font, 5*scale lines
+func big(text string, scale int) []string {
+	lines := make([]string, 5*scale)
+	for i, r := range text {
+		glyph, ok := font[r]
+		if !ok {
+			continue
+		}
+		for row := 0; row < 5; row++ {
+			var seg strings.Builder
+			if i > 0 {
+				seg.WriteString(strings.Repeat(" ", 2*scale))
+			}
+			for _, px := range glyph[row] {
+				cell := " "
+				if px == '#' {
+					cell = "█"
+				}
+				seg.WriteString(strings.Repeat(cell, 2*scale))
+			}
+			for k := 0; k < scale; k++ {
+				lines[row*scale+k] += seg.String()
+			}
+		}
+	}
+	return lines
+}
+
+// bigWidth is the width in columns of text rendered by big
+func bigWidth(text string, scale int) int {
+	width := 0
+	for _, r := range text {
+		if _, ok := font[r]; !ok {
+			continue
+		}
+		if width > 0 {
+			width += 2 * scale
+		}
+		width += 6 * scale
+	}
+	return width
+}
+
+// pad right-pads s with spaces to width columns
+func pad(s string, width int) string {
+	if n := utf8.RuneCountInString(s); n < width {
+		return s + strings.Repeat(" ", width-n)
+	}
+	return s
+}
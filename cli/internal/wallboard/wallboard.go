@@ -0,0 +1,180 @@
+// Package wallboard keeps the headline figures of a NOC wallboard (active
+// calls, answer rate, p95 turn latency, failures) from live call events
+// and renders them in large type for terminals and browsers
+package wallboard
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/events"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/troubleshoot"
+)
+
+// Window is the period the rates and counts cover
+const Window = time.Hour
+
+// Stats are the figures shown on the wallboard
+type Stats struct {
+	Active int `json:"active_calls"`
+	// Calls, Answered, Turns and Failures cover the last Window
+	Calls    int `json:"calls"`
+	Answered int `json:"answered"`
+	Turns    int `json:"turns"`
+	Failures int `json:"failures"`
+	// AnswerRate is the percentage of ended calls not abandoned, nil
+	// without calls
+	AnswerRate *float64 `json:"answer_rate"`
+	// P95LatencyMs is the 95th percentile turn latency, nil without turns
+	P95LatencyMs *int64 `json:"p95_latency_ms"`
+	// Levels grades figures as "warning" or "critical" by their JSON
+	// name; figures that are fine are absent
+	Levels  map[string]string `json:"levels"`
+	Updated time.Time         `json:"updated"`
+}
+
+// Answer rates below these percentages are graded warning/critical
+const (
+	answerRateWarn     = 90
+	answerRateCritical = 75
+)
+
+// Board aggregates call events into Stats. It is safe for concurrent use.
+type Board struct {
+	mu        sync.Mutex
+	active    map[string]time.Time
+	ended     []endedCall
+	latencies []turnLatency
+	failures  []time.Time
+}
+
+type endedCall struct {
+	at     time.Time
+	status string
+}
+
+type turnLatency struct {
+	at time.Time
+	ms int64
+}
+
+// NewBoard creates an empty board
+func NewBoard() *Board {
+	return &Board{active: make(map[string]time.Time)}
+}
+
+// Observe adds one call event
+func (b *Board) Observe(ev events.Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	switch ev.Type {
+	case events.CallStarted:
+		b.active[ev.CallID] = ev.Time
+	case events.TurnCompleted:
+		if ms, ok := number(ev.Data["latency_ms"]); ok {
+			b.latencies = append(b.latencies, turnLatency{at: ev.Time, ms: ms})
+		}
+	case events.CallEnded:
+		delete(b.active, ev.CallID)
+		status, _ := ev.Data["status"].(string)
+		b.ended = append(b.ended, endedCall{at: ev.Time, status: status})
+	case events.CallFailed:
+		b.failures = append(b.failures, ev.Time)
+	}
+}
+
+// Stats returns the figures as of now, dropping events older than Window
+func (b *Board) Stats(now time.Time) Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	cutoff := now.Add(-Window)
+
+	ended := b.ended[:0]
+	for _, c := range b.ended {
+		if c.at.After(cutoff) {
+			ended = append(ended, c)
+		}
+	}
+	b.ended = ended
+	latencies := b.latencies[:0]
+	for _, l := range b.latencies {
+		if l.at.After(cutoff) {
+			latencies = append(latencies, l)
+		}
+	}
+	b.latencies = latencies
+	failures := b.failures[:0]
+	for _, t := range b.failures {
+		if t.After(cutoff) {
+			failures = append(failures, t)
+		}
+	}
+	b.failures = failures
+
+	stats := Stats{
+		Active:   len(b.active),
+		Calls:    len(b.ended),
+		Turns:    len(b.latencies),
+		Failures: len(b.failures),
+		Levels:   make(map[string]string),
+		Updated:  now,
+	}
+	for _, c := range b.ended {
+		if c.status != troubleshoot.CallAbandoned {
+			stats.Answered++
+		}
+	}
+	if stats.Calls > 0 {
+		rate := float64(stats.Answered) * 100 / float64(stats.Calls)
+		stats.AnswerRate = &rate
+	}
+	if len(b.latencies) > 0 {
+		ms := make([]int64, len(b.latencies))
+		for i, l := range b.latencies {
+			ms[i] = l.ms
+		}
+		sort.Slice(ms, func(i, j int) bool { return ms[i] < ms[j] })
+		p95 := ms[(len(ms)*95+99)/100-1]
+		stats.P95LatencyMs = &p95
+	}
+	stats.grade()
+	return stats
+}
+
+// grade fills Levels: the answer rate against answerRateWarn/Critical,
+// the latency against the troubleshoot latency thresholds, and any
+// failure as critical
+func (s *Stats) grade() {
+	if s.AnswerRate != nil {
+		switch {
+		case *s.AnswerRate < answerRateCritical:
+			s.Levels["answer_rate"] = troubleshoot.SeverityCritical
+		case *s.AnswerRate < answerRateWarn:
+			s.Levels["answer_rate"] = troubleshoot.SeverityWarning
+		}
+	}
+	if s.P95LatencyMs != nil {
+		ev := troubleshoot.LiveEvent{Latency: time.Duration(*s.P95LatencyMs) * time.Millisecond}
+		if sev := ev.Severity(); sev != "" {
+			s.Levels["p95_latency_ms"] = sev
+		}
+	}
+	if s.Failures > 0 {
+		s.Levels["failures"] = troubleshoot.SeverityCritical
+	}
+}
+
+// number reads an event field that is an integer in process and a
+// float64 once decoded from JSON
+func number(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case int:
+		return int64(n), true
+	case float64:
+		return int64(n), true
+	}
+	return 0, false
+}
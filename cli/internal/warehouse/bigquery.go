@@ -0,0 +1,256 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// bqTimeLayout is a TIMESTAMP format BigQuery loads from JSON
+const bqTimeLayout = "2006-01-02 15:04:05.000000+00:00"
+
+// BigQuery loads calls with the bq CLI, authenticated by gcloud. Rows
+// are loaded into staging tables and merged, so reloading a call
+// replaces it.
+type BigQuery struct {
+	project  string
+	dataset  string
+	location string
+	prefix   string
+}
+
+// NewBigQuery creates a sink for a dataset
+func NewBigQuery(project, dataset, location, prefix string) (*BigQuery, error) {
+	if project == "" || dataset == "" {
+		return nil, fmt.Errorf("warehouse: project and dataset are required for bigquery")
+	}
+	if !identifier.MatchString(dataset) {
+		return nil, fmt.Errorf("warehouse: dataset %q must be letters, digits and _", dataset)
+	}
+	return &BigQuery{project: project, dataset: dataset, location: location, prefix: prefix}, nil
+}
+
+func (q *BigQuery) Name() string { return "bigquery" }
+
+// table returns a table reference for bq commands
+func (q *BigQuery) table(name string) string {
+	return fmt.Sprintf("%s:%s.%s%s", q.project, q.dataset, q.prefix, name)
+}
+
+// sqlTable returns a table reference for standard SQL
+func (q *BigQuery) sqlTable(name string) string {
+	return fmt.Sprintf("`%s.%s.%s%s`", q.project, q.dataset, q.prefix, name)
+}
+
+// Migrate creates the dataset and tables, and adds columns new to this
+// version of the agent to existing tables (BigQuery schemas only grow)
+func (q *BigQuery) Migrate(ctx context.Context) ([]string, error) {
+	var done []string
+	dataset := q.project + ":" + q.dataset
+	if _, err := q.bq(ctx, "show", "--format=none", dataset); err != nil {
+		if _, err := q.bq(ctx, "mk", "--dataset", dataset); err != nil {
+			return done, err
+		}
+		done = append(done, "created dataset "+dataset)
+	}
+
+	tables := []struct {
+		name      string
+		cols      []column
+		partition string
+	}{
+		{"calls", callColumns, "start_time"},
+		{"findings", findingColumns, ""},
+	}
+	for _, t := range tables {
+		schema, err := q.schemaFile(t.cols)
+		if err != nil {
+			return done, err
+		}
+		defer os.Remove(schema)
+
+		ref := q.table(t.name)
+		out, err := q.bq(ctx, "show", "--schema", "--format=json", ref)
+		if err != nil {
+			args := []string{"mk", "--table"}
+			if t.partition != "" {
+				args = append(args, "--time_partitioning_field="+t.partition)
+			}
+			if _, err := q.bq(ctx, append(args, ref, schema)...); err != nil {
+				return done, err
+			}
+			done = append(done, "created table "+ref)
+			continue
+		}
+		if missing := missingColumns(out, t.cols); len(missing) > 0 {
+			if _, err := q.bq(ctx, "update", ref, schema); err != nil {
+				return done, err
+			}
+			done = append(done, fmt.Sprintf("added %s to %s", strings.Join(missing, ", "), ref))
+		}
+	}
+	return done, nil
+}
+
+// Load merges the calls into the calls table and replaces their findings
+func (q *BigQuery) Load(ctx context.Context, calls []Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", "agent-bq")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	var callRows, findingRows bytes.Buffer
+	for i := range calls {
+		if err := writeJSONRow(&callRows, callColumns, calls[i].values()); err != nil {
+			return err
+		}
+		for j := range calls[i].Findings {
+			if err := writeJSONRow(&findingRows, findingColumns, calls[i].Findings[j].values(calls[i].CallID)); err != nil {
+				return err
+			}
+		}
+	}
+	if err := q.loadStaging(ctx, dir, "calls", callColumns, callRows.Bytes()); err != nil {
+		return err
+	}
+
+	sets := make([]string, 0, len(callColumns)-1)
+	for _, c := range callColumns[1:] {
+		sets = append(sets, fmt.Sprintf("%s = S.%s", c.name, c.name))
+	}
+	script := fmt.Sprintf(`MERGE %[1]s T USING %[2]s S ON T.call_id = S.call_id
+WHEN MATCHED THEN UPDATE SET %[3]s
+WHEN NOT MATCHED THEN INSERT ROW;
+DELETE FROM %[4]s WHERE call_id IN (SELECT call_id FROM %[2]s);
+`, q.sqlTable("calls"), q.sqlTable("calls_staging"), strings.Join(sets, ", "), q.sqlTable("findings"))
+
+	if findingRows.Len() > 0 {
+		if err := q.loadStaging(ctx, dir, "findings", findingColumns, findingRows.Bytes()); err != nil {
+			return err
+		}
+		script += fmt.Sprintf("INSERT INTO %s SELECT * FROM %s;\n", q.sqlTable("findings"), q.sqlTable("findings_staging"))
+	}
+	_, err = q.bq(ctx, "query", "--use_legacy_sql=false", "--nouse_cache", script)
+	return err
+}
+
+// loadStaging replaces the <name>_staging table with the NDJSON rows
+func (q *BigQuery) loadStaging(ctx context.Context, dir, name string, cols []column, rows []byte) error {
+	data := filepath.Join(dir, name+".json")
+	if err := os.WriteFile(data, rows, 0600); err != nil {
+		return err
+	}
+	schema, err := q.schemaFile(cols)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(schema)
+	_, err = q.bq(ctx, "load", "--replace", "--source_format=NEWLINE_DELIMITED_JSON", q.table(name+"_staging"), data, schema)
+	return err
+}
+
+// schemaFile writes a bq JSON schema for the columns to a temp file
+func (q *BigQuery) schemaFile(cols []column) (string, error) {
+	type field struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+		Mode string `json:"mode"`
+	}
+	fields := make([]field, len(cols))
+	for i, c := range cols {
+		fields[i] = field{Name: c.name, Type: c.bqType, Mode: "NULLABLE"}
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp("", "agent-bq-schema-*.json")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	if _, err := f.Write(data); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// missingColumns lists the columns absent from a 'bq show --schema' output
+func missingColumns(schema string, cols []column) []string {
+	var fields []struct {
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal([]byte(schema), &fields); err != nil {
+		return nil
+	}
+	have := make(map[string]bool)
+	for _, f := range fields {
+		have[f.Name] = true
+	}
+	var missing []string
+	for _, c := range cols {
+		if !have[c.name] {
+			missing = append(missing, c.name)
+		}
+	}
+	return missing
+}
+
+// writeJSONRow appends one NDJSON row; nil values are omitted (NULL)
+func writeJSONRow(buf *bytes.Buffer, cols []column, values []interface{}) error {
+	row := make(map[string]interface{}, len(cols))
+	for i, c := range cols {
+		switch v := values[i].(type) {
+		case nil:
+		case time.Time:
+			row[c.name] = v.UTC().Format(bqTimeLayout)
+		default:
+			row[c.name] = v
+		}
+	}
+	data, err := json.Marshal(row)
+	if err != nil {
+		return err
+	}
+	buf.Write(data)
+	buf.WriteByte('\n')
+	return nil
+}
+
+// bq runs the bq CLI against the project
+func (q *BigQuery) bq(ctx context.Context, args ...string) (string, error) {
+	global := []string{"--quiet", "--headless", "--project_id=" + q.project}
+	if q.location != "" {
+		global = append(global, "--location="+q.location)
+	}
+	command := args[0]
+	args = append(global, args...)
+	cmd := exec.CommandContext(ctx, "bq", args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := selflog.Run(cmd); err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			return "", fmt.Errorf("bq not found: install the Google Cloud SDK")
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = strings.TrimSpace(stdout.String())
+		}
+		return "", fmt.Errorf("bq %s: %v: %s", command, err, msg)
+	}
+	return stdout.String(), nil
+}
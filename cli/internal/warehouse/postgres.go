@@ -0,0 +1,259 @@
+package warehouse
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
+)
+
+// pgBatch bounds the rows of one INSERT statement
+const pgBatch = 500
+
+// migration is one versioned schema change
+type migration struct {
+	version     int
+	description string
+	sql         func(prefix string) string
+}
+
+// pgMigrations are applied in order and recorded in schema_migrations.
+// Never edit a released migration; append a new one.
+var pgMigrations = []migration{
+	// The columns are spelled out: callColumns grows with later migrations
+	{1, "create calls and findings tables", func(p string) string {
+		return fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %[1]scalls (
+  call_id TEXT NOT NULL,
+  start_time TIMESTAMPTZ NOT NULL,
+  end_time TIMESTAMPTZ,
+  duration_seconds DOUBLE PRECISION,
+  status TEXT,
+  hangup_cause INTEGER,
+  persona TEXT,
+  providers TEXT,
+  turn_latency_avg_ms DOUBLE PRECISION,
+  turn_latency_p95_ms DOUBLE PRECISION,
+  turn_latency_max_ms DOUBLE PRECISION,
+  quality_score DOUBLE PRECISION,
+  errors INTEGER,
+  warnings INTEGER,
+  fingerprint TEXT,
+  cost DOUBLE PRECISION,
+  analyzed_at TIMESTAMPTZ NOT NULL,
+  PRIMARY KEY (call_id)
+);
+CREATE INDEX IF NOT EXISTS %[1]scalls_start_time ON %[1]scalls (start_time);
+CREATE INDEX IF NOT EXISTS %[1]scalls_fingerprint ON %[1]scalls (fingerprint);
+CREATE TABLE IF NOT EXISTS %[1]sfindings (
+  call_id TEXT NOT NULL,
+  analyzer TEXT,
+  severity TEXT,
+  message TEXT,
+  fix TEXT
+);
+CREATE INDEX IF NOT EXISTS %[1]sfindings_call_id ON %[1]sfindings (call_id);`, p)
+	}},
+	{2, "add calls.tenant", func(p string) string {
+		return fmt.Sprintf(`ALTER TABLE %[1]scalls ADD COLUMN IF NOT EXISTS tenant TEXT;
+CREATE INDEX IF NOT EXISTS %[1]scalls_tenant ON %[1]scalls (tenant);`, p)
+	}},
+}
+
+// pgPrefix matches table prefixes Postgres keeps as written when unquoted
+var pgPrefix = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// Postgres loads calls with psql. The DSN is passed to psql through the
+// PG* environment variables so the password is not on the command line.
+type Postgres struct {
+	env    []string
+	prefix string
+}
+
+// NewPostgres creates a sink for a postgres:// URL
+func NewPostgres(dsn, prefix string) (*Postgres, error) {
+	if dsn == "" {
+		return nil, fmt.Errorf("warehouse: dsn is required for postgres")
+	}
+	u, err := url.Parse(dsn)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return nil, fmt.Errorf("warehouse: dsn must be a postgres:// URL")
+	}
+	if prefix != "" && !pgPrefix.MatchString(prefix) {
+		return nil, fmt.Errorf("warehouse: table_prefix %q must be lowercase letters, digits and _", prefix)
+	}
+	env := os.Environ()
+	if host := u.Hostname(); host != "" {
+		env = append(env, "PGHOST="+host)
+	}
+	if port := u.Port(); port != "" {
+		env = append(env, "PGPORT="+port)
+	}
+	if u.User != nil {
+		env = append(env, "PGUSER="+u.User.Username())
+		if pw, ok := u.User.Password(); ok {
+			env = append(env, "PGPASSWORD="+pw)
+		}
+	}
+	if db := strings.TrimPrefix(u.Path, "/"); db != "" {
+		env = append(env, "PGDATABASE="+db)
+	}
+	if mode := u.Query().Get("sslmode"); mode != "" {
+		env = append(env, "PGSSLMODE="+mode)
+	}
+	return &Postgres{env: env, prefix: prefix}, nil
+}
+
+func (p *Postgres) Name() string { return "postgres" }
+
+// Migrate applies the migrations not yet recorded in schema_migrations
+func (p *Postgres) Migrate(ctx context.Context) ([]string, error) {
+	table := p.prefix + "schema_migrations"
+	if _, err := p.psql(ctx, fmt.Sprintf(`CREATE TABLE IF NOT EXISTS %s (version INTEGER PRIMARY KEY, description TEXT, applied_at TIMESTAMPTZ NOT NULL DEFAULT now());`, table)); err != nil {
+		return nil, err
+	}
+	out, err := p.psql(ctx, fmt.Sprintf("SELECT version FROM %s;", table), "-t", "-A")
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[int]bool)
+	for _, line := range strings.Fields(out) {
+		if v, err := strconv.Atoi(line); err == nil {
+			applied[v] = true
+		}
+	}
+
+	var done []string
+	for _, m := range pgMigrations {
+		if applied[m.version] {
+			continue
+		}
+		script := fmt.Sprintf("BEGIN;\n%s\nINSERT INTO %s (version, description) VALUES (%d, %s);\nCOMMIT;\n",
+			m.sql(p.prefix), table, m.version, pgLiteral(m.description))
+		if _, err := p.psql(ctx, script); err != nil {
+			return done, fmt.Errorf("migration %d: %w", m.version, err)
+		}
+		done = append(done, fmt.Sprintf("migration %d: %s", m.version, m.description))
+	}
+	return done, nil
+}
+
+// Load upserts the calls and replaces their findings in one transaction
+func (p *Postgres) Load(ctx context.Context, calls []Call) error {
+	if len(calls) == 0 {
+		return nil
+	}
+	var b strings.Builder
+	b.WriteString("BEGIN;\n")
+	for start := 0; start < len(calls); start += pgBatch {
+		end := start + pgBatch
+		if end > len(calls) {
+			end = len(calls)
+		}
+		batch := calls[start:end]
+
+		rows := make([]string, len(batch))
+		ids := make([]string, len(batch))
+		var findings []string
+		for i := range batch {
+			rows[i] = pgRow(batch[i].values())
+			ids[i] = pgLiteral(batch[i].CallID)
+			for j := range batch[i].Findings {
+				findings = append(findings, pgRow(batch[i].Findings[j].values(batch[i].CallID)))
+			}
+		}
+
+		fmt.Fprintf(&b, "INSERT INTO %scalls (%s) VALUES\n%s\nON CONFLICT (call_id) DO UPDATE SET %s;\n",
+			p.prefix, columnNames(callColumns), strings.Join(rows, ",\n"), pgExcluded(callColumns[1:]))
+		fmt.Fprintf(&b, "DELETE FROM %sfindings WHERE call_id IN (%s);\n", p.prefix, strings.Join(ids, ", "))
+		if len(findings) > 0 {
+			fmt.Fprintf(&b, "INSERT INTO %sfindings (%s) VALUES\n%s;\n",
+				p.prefix, columnNames(findingColumns), strings.Join(findings, ",\n"))
+		}
+	}
+	b.WriteString("COMMIT;\n")
+	_, err := p.psql(ctx, b.String())
+	return err
+}
+
+// pgSession starts every script: pgLiteral only doubles quotes, which
+// is safe with standard_conforming_strings whatever the server or role
+// default
+const pgSession = "SET standard_conforming_strings = on;\n"
+
+// psql runs a script (from stdin) or, with extra flags, a query
+func (p *Postgres) psql(ctx context.Context, script string, flags ...string) (string, error) {
+	args := append([]string{"-X", "-q", "-v", "ON_ERROR_STOP=1"}, flags...)
+	cmd := exec.CommandContext(ctx, "psql", append(args, "-f", "-")...)
+	cmd.Env = p.env
+	cmd.Stdin = strings.NewReader(pgSession + script)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := selflog.Run(cmd); err != nil {
+		if _, ok := err.(*exec.Error); ok {
+			return "", fmt.Errorf("psql not found: install the PostgreSQL client")
+		}
+		return "", fmt.Errorf("psql: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
+
+func columnNames(cols []column) string {
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.name
+	}
+	return strings.Join(names, ", ")
+}
+
+func pgExcluded(cols []column) string {
+	sets := make([]string, len(cols))
+	for i, c := range cols {
+		sets[i] = c.name + " = EXCLUDED." + c.name
+	}
+	return strings.Join(sets, ", ")
+}
+
+func pgRow(values []interface{}) string {
+	lits := make([]string, len(values))
+	for i, v := range values {
+		lits[i] = pgValue(v)
+	}
+	return "(" + strings.Join(lits, ", ") + ")"
+}
+
+// pgValue renders a value as a SQL literal
+func pgValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return pgLiteral(v)
+	case time.Time:
+		return pgLiteral(v.UTC().Format(time.RFC3339Nano))
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		// PostgreSQL has no literal for NaN or infinity in numeric columns
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "NULL"
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+	return pgLiteral(fmt.Sprint(v))
+}
+
+// pgLiteral quotes a string for standard_conforming_strings, which psql
+// turns on for every script (pgSession)
+func pgLiteral(s string) string {
+	return "'" + strings.Replace(strings.Replace(s, "\x00", "", -1), "'", "''", -1) + "'"
+}
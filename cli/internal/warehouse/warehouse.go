@@ -0,0 +1,180 @@
+// Package warehouse loads analyzed calls into Postgres or BigQuery for
+// SQL analytics. Both sinks drive the vendor CLI (psql, bq), so no
+// database driver is linked into the agent.
+package warehouse
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/settings"
+)
+
+// Call is one analyzed call as stored in the calls table. Nil pointers
+// are stored as NULL.
+type Call struct {
+	CallID           string
+	Start            time.Time
+	End              *time.Time
+	DurationSeconds  float64
+	Status           string
+	HangupCause      *int
+	Persona          string
+	Providers        string
+	TurnLatencyAvgMs *float64
+	TurnLatencyP95Ms *float64
+	TurnLatencyMaxMs *float64
+	QualityScore     float64
+	Errors           int
+	Warnings         int
+	Fingerprint      string
+	Cost             *float64
+	AnalyzedAt       time.Time
+	Tenant           string
+	Findings         []Finding
+}
+
+// Finding is one analyzer finding of a call, stored in the findings table
+type Finding struct {
+	Analyzer string
+	Severity string
+	Message  string
+	Fix      string
+}
+
+// column is a table column with its Postgres and BigQuery types
+type column struct {
+	name   string
+	pgType string
+	bqType string
+}
+
+var callColumns = []column{
+	{"call_id", "TEXT NOT NULL", "STRING"},
+	{"start_time", "TIMESTAMPTZ NOT NULL", "TIMESTAMP"},
+	{"end_time", "TIMESTAMPTZ", "TIMESTAMP"},
+	{"duration_seconds", "DOUBLE PRECISION", "FLOAT64"},
+	{"status", "TEXT", "STRING"},
+	{"hangup_cause", "INTEGER", "INT64"},
+	{"persona", "TEXT", "STRING"},
+	{"providers", "TEXT", "STRING"},
+	{"turn_latency_avg_ms", "DOUBLE PRECISION", "FLOAT64"},
+	{"turn_latency_p95_ms", "DOUBLE PRECISION", "FLOAT64"},
+	{"turn_latency_max_ms", "DOUBLE PRECISION", "FLOAT64"},
+	{"quality_score", "DOUBLE PRECISION", "FLOAT64"},
+	{"errors", "INTEGER", "INT64"},
+	{"warnings", "INTEGER", "INT64"},
+	{"fingerprint", "TEXT", "STRING"},
+	{"cost", "DOUBLE PRECISION", "FLOAT64"},
+	{"analyzed_at", "TIMESTAMPTZ NOT NULL", "TIMESTAMP"},
+	{"tenant", "TEXT", "STRING"},
+}
+
+var findingColumns = []column{
+	{"call_id", "TEXT NOT NULL", "STRING"},
+	{"analyzer", "TEXT", "STRING"},
+	{"severity", "TEXT", "STRING"},
+	{"message", "TEXT", "STRING"},
+	{"fix", "TEXT", "STRING"},
+}
+
+// values returns the call's columns in callColumns order; nil is NULL
+func (c *Call) values() []interface{} {
+	vals := []interface{}{
+		c.CallID, c.Start, nil, c.DurationSeconds, c.Status, nil,
+		c.Persona, c.Providers, nil, nil, nil, c.QualityScore,
+		c.Errors, c.Warnings, c.Fingerprint, nil, c.AnalyzedAt, c.Tenant,
+	}
+	if c.End != nil {
+		vals[2] = *c.End
+	}
+	if c.HangupCause != nil {
+		vals[5] = *c.HangupCause
+	}
+	for i, p := range []*float64{c.TurnLatencyAvgMs, c.TurnLatencyP95Ms, c.TurnLatencyMaxMs} {
+		if p != nil {
+			vals[8+i] = *p
+		}
+	}
+	if c.Cost != nil {
+		vals[15] = *c.Cost
+	}
+	return vals
+}
+
+func (f *Finding) values(callID string) []interface{} {
+	return []interface{}{callID, f.Analyzer, f.Severity, f.Message, f.Fix}
+}
+
+// Sink is a database calls are loaded into
+type Sink interface {
+	Name() string
+	// Migrate creates or upgrades the tables and describes what changed
+	Migrate(ctx context.Context) ([]string, error)
+	// Load inserts the calls, replacing earlier loads of the same calls
+	Load(ctx context.Context, calls []Call) error
+}
+
+// identifier matches names safe to splice into SQL
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// New creates the sink configured in cfg
+func New(cfg settings.Warehouse) (Sink, error) {
+	if cfg.TablePrefix != "" && !identifier.MatchString(cfg.TablePrefix) {
+		return nil, fmt.Errorf("warehouse: table_prefix %q must be letters, digits and _", cfg.TablePrefix)
+	}
+	switch cfg.Type {
+	case "postgres":
+		return NewPostgres(cfg.DSN, cfg.TablePrefix)
+	case "bigquery":
+		return NewBigQuery(cfg.Project, cfg.Dataset, cfg.Location, cfg.TablePrefix)
+	case "":
+		return nil, fmt.Errorf("no warehouse configured (warehouse: in %s)", settings.Path())
+	}
+	return nil, fmt.Errorf("warehouse: unknown type %q (postgres or bigquery)", cfg.Type)
+}
+
+// State is the progress of scheduled syncs
+type State struct {
+	// Watermark is the start of the newest call loaded
+	Watermark time.Time `json:"watermark"`
+	LastSync  time.Time `json:"last_sync"`
+	Calls     int       `json:"calls"`
+}
+
+func statePath() string {
+	return filepath.Join(settings.Dir(), "warehouse.json")
+}
+
+// LoadState reads the sync state; a missing file is a first sync
+func LoadState() (*State, error) {
+	data, err := os.ReadFile(statePath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{}, nil
+		}
+		return nil, err
+	}
+	var state State
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, fmt.Errorf("%s: %w", statePath(), err)
+	}
+	return &state, nil
+}
+
+// Save writes the sync state
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(settings.Dir(), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(statePath(), data, 0600)
+}
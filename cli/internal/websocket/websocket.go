@@ -0,0 +1,272 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Message types (RFC 6455 opcodes)
+const (
+	TextMessage   = 1
+	BinaryMessage = 2
+	closeMessage  = 8
+	pingMessage   = 9
+	pongMessage   = 10
+)
+
+// maxMessage bounds a message, so a broken peer cannot exhaust memory
+const maxMessage = 16 << 20
+
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrClosed is returned by ReadMessage once the peer closed the connection
+var ErrClosed = errors.New("websocket closed")
+
+// Conn is a minimal WebSocket connection: enough for event streams such
+// as ARI's. Reads must come from one goroutine; writes are safe from
+// several.
+type Conn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	mu   sync.Mutex
+	// server connections send unmasked frames
+	server bool
+}
+
+// Dial opens a WebSocket to a ws:// or wss:// URL
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	host := u.Host
+	if u.Port() == "" {
+		if u.Scheme == "wss" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Scheme {
+	case "ws":
+	case "wss":
+		tc := tls.Client(conn, &tls.Config{ServerName: u.Hostname()})
+		if err := tc.Handshake(); err != nil {
+			conn.Close()
+			return nil, err
+		}
+		conn = tc
+	default:
+		conn.Close()
+		return nil, fmt.Errorf("unsupported scheme %q (use ws or wss)", u.Scheme)
+	}
+	if dl, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(dl)
+	}
+
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(nonce)
+
+	req := &http.Request{
+		Method:     "GET",
+		URL:        u,
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+		Header:     http.Header{},
+		Host:       u.Host,
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, req)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		resp.Body.Close()
+		conn.Close()
+		return nil, &HandshakeError{Code: resp.StatusCode, Status: resp.Status, Body: string(body)}
+	}
+	sum := sha1.Sum([]byte(key + acceptGUID))
+	if resp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake: bad Sec-WebSocket-Accept")
+	}
+	conn.SetDeadline(time.Time{})
+	return &Conn{conn: conn, r: r}, nil
+}
+
+// HandshakeError is a non-101 response to the upgrade request
+type HandshakeError struct {
+	Code   int
+	Status string
+	Body   string
+}
+
+func (e *HandshakeError) Error() string {
+	return fmt.Sprintf("websocket handshake: %s", e.Status)
+}
+
+// ReadMessage returns the next text or binary message. Pings are
+// answered; a close frame ends the stream with ErrClosed.
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var message []byte
+	messageType := 0
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch opcode {
+		case pingMessage:
+			if err := c.writeFrame(pongMessage, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case pongMessage:
+			continue
+		case closeMessage:
+			c.writeFrame(closeMessage, payload)
+			return 0, nil, ErrClosed
+		case 0:
+			// Continuation of a fragmented message
+		default:
+			messageType = opcode
+		}
+		message = append(message, payload...)
+		if len(message) > maxMessage {
+			return 0, nil, fmt.Errorf("websocket message larger than %d bytes", maxMessage)
+		}
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.r, head[:]); err != nil {
+		return false, 0, nil, err
+	}
+	fin := head[0]&0x80 != 0
+	opcode := int(head[0] & 0x0f)
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.r, ext[:]); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxMessage {
+		return false, 0, nil, fmt.Errorf("websocket frame larger than %d bytes", maxMessage)
+	}
+	var mask [4]byte
+	if masked {
+		if _, err := io.ReadFull(c.r, mask[:]); err != nil {
+			return false, 0, nil, err
+		}
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.r, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return fin, opcode, payload, nil
+}
+
+// WriteMessage sends one text or binary message
+func (c *Conn) WriteMessage(messageType int, data []byte) error {
+	return c.writeFrame(messageType, data)
+}
+
+// writeFrame sends a single frame, masked when sent by a client
+func (c *Conn) writeFrame(opcode int, payload []byte) error {
+	frame := []byte{0x80 | byte(opcode)}
+	maskBit := byte(0x80)
+	if c.server {
+		maskBit = 0
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xffff:
+		frame = append(frame, maskBit|126, byte(n>>8), byte(n))
+	default:
+		var ext [8]byte
+		binary.BigEndian.PutUint64(ext[:], uint64(n))
+		frame = append(frame, maskBit|127)
+		frame = append(frame, ext[:]...)
+	}
+	if c.server {
+		frame = append(frame, payload...)
+	} else {
+		var mask [4]byte
+		if _, err := rand.Read(mask[:]); err != nil {
+			return err
+		}
+		frame = append(frame, mask[:]...)
+		for i, b := range payload {
+			frame = append(frame, b^mask[i%4])
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// Close sends a close frame and closes the connection
+func (c *Conn) Close() error {
+	c.writeFrame(closeMessage, []byte{0x03, 0xe8})
+	return c.conn.Close()
+}
@@ -29,10 +29,14 @@ type Config struct {
 	// File paths
 	EnvPath             string
 	YAMLPath            string
+
+	// dryRun leaves a missing .env uncreated
+	dryRun bool
 }
 
-// LoadConfig reads current configuration from .env and YAML
-func LoadConfig() (*Config, error) {
+// LoadConfig reads current configuration from .env and YAML. A missing
+// .env is created from .env.example, unless dryRun.
+func LoadConfig(dryRun bool) (*Config, error) {
 	// Try to find .env - check current dir and parent dir
 	envPath := ".env"
 	if _, err := os.Stat(envPath); os.IsNotExist(err) {
@@ -50,10 +54,11 @@ func LoadConfig() (*Config, error) {
 	cfg := &Config{
 		EnvPath:  envPath,
 		YAMLPath: yamlPath,
+		dryRun:   dryRun,
 	}
 	
 	// Load .env
-	if err := cfg.loadEnv(); err != nil {
+	if err := cfg.loadEnv(cfg.EnvPath); err != nil {
 		return nil, fmt.Errorf("failed to load .env: %w", err)
 	}
 	
@@ -66,11 +71,11 @@ func LoadConfig() (*Config, error) {
 	return cfg, nil
 }
 
-// loadEnv reads .env file
-func (c *Config) loadEnv() error {
-	file, err := os.Open(c.EnvPath)
+// loadEnv reads the .env file at path
+func (c *Config) loadEnv(path string) error {
+	file, err := os.Open(path)
 	if err != nil {
-		if os.IsNotExist(err) {
+		if os.IsNotExist(err) && path == c.EnvPath {
 			// .env doesn't exist, create from example if available
 			if _, err := os.Stat(".env.example"); err == nil {
 				return c.createEnvFromExample()
@@ -129,6 +134,10 @@ func (c *Config) loadEnv() error {
 
 // createEnvFromExample creates .env from .env.example
 func (c *Config) createEnvFromExample() error {
+	if c.dryRun {
+		// Read the example in place; EnvContent starts from it too
+		return c.loadEnv(".env.example")
+	}
 	input, err := os.ReadFile(".env.example")
 	if err != nil {
 		return err
@@ -140,7 +149,7 @@ func (c *Config) createEnvFromExample() error {
 	}
 	
 	PrintSuccess("Created .env from .env.example")
-	return c.loadEnv()
+	return c.loadEnv(c.EnvPath)
 }
 
 // loadYAML reads config/ai-agent.yaml
@@ -170,10 +179,19 @@ func (c *Config) loadYAML() error {
 
 // SaveEnv updates .env file in-place
 func (c *Config) SaveEnv() error {
+	return os.WriteFile(c.EnvPath, c.EnvContent(), 0644)
+}
+
+// EnvContent returns .env with the configured values applied; a missing
+// .env starts from .env.example
+func (c *Config) EnvContent() []byte {
 	// Read existing .env
 	lines := []string{}
 	
 	file, err := os.Open(c.EnvPath)
+	if os.IsNotExist(err) {
+		file, err = os.Open(".env.example")
+	}
 	if err == nil {
 		scanner := bufio.NewScanner(file)
 		for scanner.Scan() {
@@ -217,13 +235,20 @@ func (c *Config) SaveEnv() error {
 		}
 	}
 	
-	// Write back
-	content := strings.Join(lines, "\n") + "\n"
-	return os.WriteFile(c.EnvPath, []byte(content), 0644)
+	return []byte(strings.Join(lines, "\n") + "\n")
 }
 
 // SaveYAML updates config/ai-agent.yaml
 func (c *Config) SaveYAML(template string) error {
+	output, err := c.YAMLContent(template)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(c.YAMLPath, output, 0644)
+}
+
+// YAMLContent returns template with the configured pipeline or provider
+func (c *Config) YAMLContent(template string) ([]byte, error) {
 	// Try to find template in current and parent directory
 	templatePath := template
 	if _, err := os.Stat(templatePath); os.IsNotExist(err) {
@@ -233,13 +258,13 @@ func (c *Config) SaveYAML(template string) error {
 	// Copy template to config/ai-agent.yaml
 	input, err := os.ReadFile(templatePath)
 	if err != nil {
-		return fmt.Errorf("failed to read template %s: %w", templatePath, err)
+		return nil, fmt.Errorf("failed to read template %s: %w", templatePath, err)
 	}
 	
 	// Read as YAML to modify
 	var yamlData map[string]interface{}
 	if err := yaml.Unmarshal(input, &yamlData); err != nil {
-		return err
+		return nil, err
 	}
 	
 	// Update active_pipeline or default_provider
@@ -250,13 +275,7 @@ func (c *Config) SaveYAML(template string) error {
 		yamlData["default_provider"] = c.DefaultProvider
 	}
 	
-	// Write back
-	output, err := yaml.Marshal(yamlData)
-	if err != nil {
-		return err
-	}
-	
-	return os.WriteFile(c.YAMLPath, output, 0644)
+	return yaml.Marshal(yamlData)
 }
 
 // GetMaskedKey returns masked version of API key for display
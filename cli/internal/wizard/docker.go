@@ -1,14 +1,18 @@
 package wizard
 
 import (
+	"context"
 	"fmt"
 	"os/exec"
 	"strings"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
 )
 
-// RebuildContainers rebuilds and recreates containers
-func RebuildContainers(pipeline string) error {
-	// Determine which containers to rebuild based on pipeline
+// RebuildServices returns the compose services a change to pipeline
+// rebuilds
+func RebuildServices(pipeline string) []string {
 	containers := []string{"ai-engine"}
 	
 	// Add local-ai-server if using local models
@@ -17,21 +21,42 @@ func RebuildContainers(pipeline string) error {
 			containers = append(containers, "local-ai-server")
 		}
 	}
+	return containers
+}
+
+// RebuildContainers rebuilds and recreates containers
+func RebuildContainers(pipeline string) error {
+	containers := RebuildServices(pipeline)
 	
 	PrintInfo("Rebuilding containers: " + strings.Join(containers, ", "))
 	
+	runtime := ""
+	var env []string
+	if client, err := docker.Default(); err == nil {
+		if rt, err := client.Runtime(context.Background()); err == nil {
+			runtime = rt.Name
+		}
+		env = client.ComposeEnv()
+	}
+	compose, err := docker.ComposeCommand(runtime)
+	if err != nil {
+		return err
+	}
+	
 	for _, container := range containers {
 		// Build
 		PrintInfo(fmt.Sprintf("Building %s...", container))
-		buildCmd := exec.Command("docker-compose", "build", container)
-		if output, err := buildCmd.CombinedOutput(); err != nil {
+		buildCmd := exec.Command(compose[0], append(compose[1:], "build", container)...)
+		buildCmd.Env = env
+		if output, err := selflog.CombinedOutput(buildCmd); err != nil {
 			return fmt.Errorf("build failed for %s: %w\n%s", container, err, string(output))
 		}
 		
 		// Force recreate
 		PrintInfo(fmt.Sprintf("Recreating %s...", container))
-		upCmd := exec.Command("docker-compose", "up", "-d", "--force-recreate", container)
-		if output, err := upCmd.CombinedOutput(); err != nil {
+		upCmd := exec.Command(compose[0], append(compose[1:], "up", "-d", "--force-recreate", container)...)
+		upCmd.Env = env
+		if output, err := selflog.CombinedOutput(upCmd); err != nil {
 			return fmt.Errorf("recreate failed for %s: %w\n%s", container, err, string(output))
 		}
 	}
@@ -42,17 +67,17 @@ func RebuildContainers(pipeline string) error {
 
 // GetContainerStatus checks if container is running
 func GetContainerStatus(name string) (bool, error) {
-	cmd := exec.Command("docker", "ps", "--format", "{{.Names}}\t{{.Status}}", "--filter", "name="+name)
-	output, err := cmd.Output()
+	client, err := docker.Default()
 	if err != nil {
 		return false, err
 	}
-	
-	status := strings.TrimSpace(string(output))
-	if status == "" {
+	info, err := client.Inspect(context.Background(), name)
+	if docker.IsNotFound(err) {
 		return false, nil
 	}
+	if err != nil {
+		return false, err
+	}
 	
-	// Container exists and is running if output is not empty
-	return strings.Contains(status, "Up"), nil
+	return info.State.Running, nil
 }
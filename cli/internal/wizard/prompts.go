@@ -7,15 +7,15 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/fatih/color"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/theme"
 )
 
 var (
-	promptColor   = color.New(color.FgCyan, color.Bold)
-	successColor  = color.New(color.FgGreen)
-	errorColor    = color.New(color.FgRed)
-	warningColor  = color.New(color.FgYellow)
-	infoColor     = color.New(color.FgBlue)
+	promptColor   = theme.Prompt
+	successColor  = theme.Success
+	errorColor    = theme.Error
+	warningColor  = theme.Warning
+	infoColor     = theme.Info
 )
 
 // PromptText asks for text input with optional default
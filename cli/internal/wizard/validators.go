@@ -1,12 +1,16 @@
 package wizard
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"os/exec"
 	"strings"
 	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/docker"
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/selflog"
 )
 
 // TestARIConnectivity tests Asterisk ARI connection
@@ -39,7 +43,7 @@ func TestAudioSocketPort(port string) error {
 	cmd := exec.Command("sh", "-c", 
 		fmt.Sprintf("netstat -tuln 2>/dev/null | grep :%s || ss -tuln 2>/dev/null | grep :%s", port, port))
 	
-	if err := cmd.Run(); err != nil {
+	if err := selflog.Run(cmd); err != nil {
 		return fmt.Errorf("port %s not listening", port)
 	}
 	
@@ -131,8 +135,11 @@ func TestAnthropicKey(apiKey string) error {
 
 // TestDockerRunning checks if Docker daemon is running
 func TestDockerRunning() error {
-	cmd := exec.Command("docker", "info")
-	if err := cmd.Run(); err != nil {
+	client, err := docker.Default()
+	if err != nil {
+		return err
+	}
+	if err := client.Ping(context.Background()); err != nil {
 		return fmt.Errorf("Docker daemon not running")
 	}
 	return nil
@@ -140,18 +147,10 @@ func TestDockerRunning() error {
 
 // TestContainerExists checks if a container exists
 func TestContainerExists(name string) bool {
-	cmd := exec.Command("docker", "ps", "-a", "--format", "{{.Names}}", "--filter", "name="+name)
-	output, err := cmd.Output()
+	client, err := docker.Default()
 	if err != nil {
 		return false
 	}
-	
-	containers := strings.Split(strings.TrimSpace(string(output)), "\n")
-	for _, c := range containers {
-		if c == name {
-			return true
-		}
-	}
-	
-	return false
+	_, err = client.Inspect(context.Background(), name)
+	return err == nil
 }
@@ -9,11 +9,26 @@ type Wizard struct {
 	config      *Config
 	hasChanges  bool
 	totalSteps  int
+	dryRun      bool
+
+	// Planned are the files a dry run would write, and PlannedRebuild
+	// the compose services it would rebuild
+	Planned        []FileChange
+	PlannedRebuild []string
+}
+
+// FileChange is a file a dry run would write
+type FileChange struct {
+	Path string
+	Data []byte
+	// Secret is set for files holding keys, whose content is not shown
+	Secret bool
 }
 
-// NewWizard creates a new wizard instance
-func NewWizard() (*Wizard, error) {
-	cfg, err := LoadConfig()
+// NewWizard creates a new wizard instance. With dryRun, Run writes and
+// rebuilds nothing and records the changes in Planned and PlannedRebuild.
+func NewWizard(dryRun bool) (*Wizard, error) {
+	cfg, err := LoadConfig(dryRun)
 	if err != nil {
 		return nil, err
 	}
@@ -22,6 +37,7 @@ func NewWizard() (*Wizard, error) {
 		config:     cfg,
 		hasChanges: false,
 		totalSteps: 6,
+		dryRun:     dryRun,
 	}, nil
 }
 
@@ -333,6 +349,10 @@ func (w *Wizard) stepReviewAndApply() error {
 	}
 	fmt.Println()
 	
+	if w.dryRun {
+		return w.planChanges()
+	}
+	
 	// Confirm
 	if !PromptConfirm("Apply changes?", true) {
 		PrintInfo("Changes cancelled")
@@ -350,8 +370,7 @@ func (w *Wizard) stepReviewAndApply() error {
 	// Save YAML if pipeline changed
 	if w.config.ActivePipeline != "" || w.config.DefaultProvider != "" {
 		PrintInfo("Updating config/ai-agent.yaml...")
-		template := "config/ai-agent.example.yaml"
-		if err := w.config.SaveYAML(template); err != nil {
+		if err := w.config.SaveYAML(yamlTemplate); err != nil {
 			PrintWarning(fmt.Sprintf("Failed to update YAML: %v", err))
 		} else {
 			PrintSuccess("Updated config/ai-agent.yaml")
@@ -365,11 +384,7 @@ func (w *Wizard) stepReviewAndApply() error {
 		if err := TestDockerRunning(); err != nil {
 			PrintWarning("Docker not running, skipping rebuild")
 		} else {
-			pipeline := w.config.ActivePipeline
-			if pipeline == "" {
-				pipeline = w.config.DefaultProvider
-			}
-			if err := RebuildContainers(pipeline); err != nil {
+			if err := RebuildContainers(w.pipeline()); err != nil {
 				PrintError(fmt.Sprintf("Rebuild failed: %v", err))
 				PrintInfo("Run manually: docker-compose up -d --force-recreate ai-engine")
 			}
@@ -390,3 +405,29 @@ func (w *Wizard) stepReviewAndApply() error {
 	
 	return nil
 }
+
+// yamlTemplate is the config/ai-agent.yaml a changed pipeline starts from
+const yamlTemplate = "config/ai-agent.example.yaml"
+
+// pipeline returns the active pipeline, or the provider in monolithic mode
+func (w *Wizard) pipeline() string {
+	if w.config.ActivePipeline != "" {
+		return w.config.ActivePipeline
+	}
+	return w.config.DefaultProvider
+}
+
+// planChanges records what applying the changes would write and rebuild
+func (w *Wizard) planChanges() error {
+	w.Planned = append(w.Planned, FileChange{Path: w.config.EnvPath, Data: w.config.EnvContent(), Secret: true})
+	if w.config.ActivePipeline != "" || w.config.DefaultProvider != "" {
+		data, err := w.config.YAMLContent(yamlTemplate)
+		if err != nil {
+			PrintWarning(fmt.Sprintf("Failed to update YAML: %v", err))
+		} else {
+			w.Planned = append(w.Planned, FileChange{Path: w.config.YAMLPath, Data: data})
+		}
+	}
+	w.PlannedRebuild = RebuildServices(w.pipeline())
+	return nil
+}
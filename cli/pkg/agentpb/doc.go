@@ -0,0 +1,15 @@
+// Package agentpb is the generated Go code of the gRPC API that 'agent
+// serve --grpc' exposes: call listing, saved analyses and a stream of
+// live call events, for platforms that standardize on gRPC instead of
+// polling REST endpoints.
+//
+//	conn, err := grpc.NewClient("pbx:9095", grpc.WithTransportCredentials(insecure.NewCredentials()))
+//	if err != nil {
+//		return err
+//	}
+//	client := agentpb.NewAgentServiceClient(conn)
+//	ctx = metadata.AppendToOutgoingContext(ctx, "authorization", "Bearer "+token)
+//	stream, err := client.StreamEvents(ctx, &agentpb.StreamEventsRequest{Types: []string{"call_failed"}})
+//
+// The service is defined in agent.proto; regenerate with 'make cli-proto'.
+package agentpb
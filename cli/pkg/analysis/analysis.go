@@ -0,0 +1,215 @@
+// Package analysis holds the result types of call analysis: the
+// Analysis of one call that 'agent troubleshoot show --format json',
+// webhook notifications and post hooks emit, the Findings within it, and
+// the CallRecord of the call index (~/.agent/calls.json, 'agent calls tag
+// --format json').
+//
+// The JSON form is versioned. Every Analysis carries SchemaVersion; within
+// a schema version fields are only added, never renamed, retyped or given
+// another meaning, so consumers should ignore fields they do not know.
+// A breaking change raises SchemaVersion and ships a new set of schema
+// files next to the old ones (see Schema).
+//
+//	var a analysis.Analysis
+//	if err := json.Unmarshal(data, &a); err != nil {
+//		return err
+//	}
+//	if err := a.Supported(); err != nil {
+//		return err
+//	}
+//	for _, f := range a.Findings {
+//		if f.Severity == analysis.SeverityCritical {
+//			fmt.Println(a.CallID, f.Message)
+//		}
+//	}
+//
+// The Go types follow the CLI's semantic versioning like pkg/engineclient:
+// within a major version exported names keep their meaning and fields
+// are only added.
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SchemaVersion is the JSON schema version of the types in this package
+const SchemaVersion = 1
+
+// Finding severities
+const (
+	SeverityCritical = "critical"
+	SeverityWarning  = "warning"
+	SeverityInfo     = "info"
+)
+
+// Analysis is the machine-readable result of a single-call analysis
+type Analysis struct {
+	// SchemaVersion is the schema the document follows; documents
+	// written before versioning have none and read as 0, which is
+	// version 1
+	SchemaVersion int               `json:"schema_version"`
+	CallID        string            `json:"call_id"`
+	Symptom       string            `json:"symptom,omitempty"`
+	Pipeline      PipelineStatus    `json:"pipeline"`
+	Errors        int               `json:"errors"`
+	Warnings      int               `json:"warnings"`
+	AudioIssues   []string          `json:"audio_issues,omitempty"`
+	Findings      []Finding         `json:"findings,omitempty"`
+	Latency       []SpanTiming      `json:"latency_breakdown,omitempty"`
+	ASRTurns      []ASRTurn         `json:"asr_turns,omitempty"`
+	Frames        []FrameSecond     `json:"frames,omitempty"`
+	Metrics       map[string]string `json:"metrics,omitempty"`
+	Score         float64           `json:"quality_score"`
+	Issues        []string          `json:"quality_issues,omitempty"`
+	Diagnosis     *Diagnosis        `json:"diagnosis,omitempty"`
+	// Incomplete are the analyzers that did not complete, so findings
+	// may be missing
+	Incomplete []Incomplete `json:"incomplete,omitempty"`
+	// Sampling says how the logs of a very chatty call were sampled
+	Sampling *Sampling `json:"sampling,omitempty"`
+	// Tags and Notes are the operators' annotations of the call
+	Tags  []string `json:"tags,omitempty"`
+	Notes []Note   `json:"notes,omitempty"`
+	// Tenant is the customer the call belongs to
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// Supported returns an error when the document follows a newer schema
+// than this package knows
+func (a *Analysis) Supported() error {
+	if a.SchemaVersion > SchemaVersion {
+		return fmt.Errorf("analysis schema version %d is newer than %d (update the analysis package)", a.SchemaVersion, SchemaVersion)
+	}
+	return nil
+}
+
+// PipelineStatus records which audio pipeline stages were seen
+type PipelineStatus struct {
+	AudioSocket   bool `json:"audiosocket"`
+	Transcription bool `json:"transcription"`
+	Playback      bool `json:"playback"`
+}
+
+// Finding is one typed result contributed by an analyzer
+type Finding struct {
+	Analyzer string `json:"analyzer"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+	Evidence string `json:"evidence,omitempty"`
+	Fix      string `json:"fix,omitempty"`
+	// Feedback notes a severity changed by operator verdicts
+	Feedback string `json:"feedback,omitempty"`
+}
+
+// SpanTiming summarizes the spans of one operation in a call's traces
+type SpanTiming struct {
+	Name   string  `json:"name"`
+	Count  int     `json:"count"`
+	AvgMs  float64 `json:"avg_ms"`
+	P95Ms  float64 `json:"p95_ms"`
+	MaxMs  float64 `json:"max_ms"`
+	Errors int     `json:"errors,omitempty"`
+}
+
+// ASRTurn is one caller turn with the recognizer's confidence
+type ASRTurn struct {
+	Turn         int      `json:"turn"`
+	Text         string   `json:"text"`
+	Confidence   float64  `json:"confidence"`
+	Alternatives []string `json:"alternatives,omitempty"`
+	// Action is what went wrong after a low-confidence turn: the tool
+	// the agent ran on it, or the caller correcting the agent
+	Action string `json:"action,omitempty"`
+}
+
+// FrameSecond is one second of the engine's transport frame counters
+// ("Audio frame stats", logged while the engine logs at debug level)
+type FrameSecond struct {
+	Second      int     `json:"second"`
+	InFrames    int     `json:"in_frames"`
+	InBytes     int     `json:"in_bytes"`
+	InMaxGapMs  float64 `json:"in_max_gap_ms,omitempty"`
+	OutFrames   int     `json:"out_frames"`
+	OutBytes    int     `json:"out_bytes"`
+	OutMaxGapMs float64 `json:"out_max_gap_ms,omitempty"`
+}
+
+// Diagnosis holds the LLM's analysis of the call
+type Diagnosis struct {
+	Provider string `json:"provider"`
+	Model    string `json:"model"`
+	Analysis string `json:"analysis"`
+	// SummaryCalls counts the requests that condensed a prompt too
+	// large for the model's context window
+	SummaryCalls int `json:"summary_calls,omitempty"`
+}
+
+// Incomplete is an analyzer or analysis step that did not complete, so
+// its findings are missing from the results
+type Incomplete struct {
+	Analyzer string `json:"analyzer"`
+	Reason   string `json:"reason"`
+}
+
+// Sampling describes how the logs of a very chatty call (debug logging
+// left on) were sampled: every line but repetitive debug messages is
+// kept, and of those the first lines and then one in Every
+type Sampling struct {
+	TotalLines int           `json:"total_lines"`
+	KeptLines  int           `json:"kept_lines"`
+	Every      int           `json:"every"`
+	Kinds      []SampledKind `json:"kinds"`
+}
+
+// SampledKind is one repetitive message and how many of its lines were kept
+type SampledKind struct {
+	Kind  string `json:"kind"`
+	Total int    `json:"total"`
+	Kept  int    `json:"kept"`
+}
+
+// CallRecord is one call of the call index
+type CallRecord struct {
+	ID           string    `json:"id"`
+	Timestamp    time.Time `json:"start"`
+	EndTime      time.Time `json:"end,omitempty"`
+	Duration     string    `json:"duration,omitempty"`
+	Status       string    `json:"status,omitempty"`
+	Channel      string    `json:"channel,omitempty"`
+	CallerNumber string    `json:"caller_number,omitempty"`
+	CallerName   string    `json:"caller_name,omitempty"`
+	Dialed       string    `json:"dialed,omitempty"`
+	HangupCause  int       `json:"hangup_cause,omitempty"`
+
+	// Context is the AI_CONTEXT the call ran with, DialplanContext the
+	// Asterisk context it entered Stasis from
+	Context         string `json:"context,omitempty"`
+	DialplanContext string `json:"dialplan_context,omitempty"`
+
+	// Tenant is the customer the call belongs to
+	Tenant string `json:"tenant,omitempty"`
+
+	// Tags and Notes are operator annotations (agent calls tag); they
+	// live in the call index, never in the logs
+	Tags  []string `json:"tags,omitempty"`
+	Notes []Note   `json:"notes,omitempty"`
+}
+
+// HasTag reports whether the call carries tag (case-insensitive)
+func (c CallRecord) HasTag(tag string) bool {
+	for _, t := range c.Tags {
+		if strings.EqualFold(t, tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// Note is an operator's remark on a call
+type Note struct {
+	Time   time.Time `json:"time"`
+	Author string    `json:"author,omitempty"`
+	Text   string    `json:"text"`
+}
@@ -0,0 +1,27 @@
+package analysis
+
+import (
+	"embed"
+	"fmt"
+)
+
+// Schema documents, one set per schema version
+const (
+	SchemaAnalysis   = "analysis.json"
+	SchemaFinding    = "finding.json"
+	SchemaCallRecord = "call-record.json"
+)
+
+//go:embed schema
+var schemas embed.FS
+
+// Schema returns the JSON schema document name (SchemaAnalysis,
+// SchemaFinding, SchemaCallRecord) of the given schema version. The
+// files are also in schema/v<version>/ of the source tree.
+func Schema(version int, name string) ([]byte, error) {
+	data, err := schemas.ReadFile(fmt.Sprintf("schema/v%d/%s", version, name))
+	if err != nil {
+		return nil, fmt.Errorf("no schema %s for version %d", name, version)
+	}
+	return data, nil
+}
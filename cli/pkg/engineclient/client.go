@@ -0,0 +1,192 @@
+// Package engineclient is a Go client for the ai_engine HTTP API: the
+// /health status, the control endpoints (drain mode, runtime log level,
+// tenant throttling, pipeline replay) and the live call events 'agent
+// serve' streams. It is the client the agent CLI itself uses, for
+// building automation against the engine.
+//
+// The package follows the CLI's semantic versioning: within a major
+// version, exported names keep their meaning and fields are only added.
+// Engines report their /health schema in Health.APISchema; this client
+// understands APISchema.
+//
+//	c := engineclient.New("http://127.0.0.1:15000", os.Getenv("HEALTH_API_TOKEN"))
+//	h, err := c.Health(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	if h.ActiveCalls == 0 {
+//		_, err = c.Drain(ctx, nil)
+//	}
+//
+// The health API listens on HEALTH_BIND_HOST:HEALTH_BIND_PORT of the
+// engine (127.0.0.1:15000 unless set). Control endpoints require
+// HEALTH_API_TOKEN as a bearer token for requests from other hosts.
+package engineclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// APISchema is the /health schema this client understands
+const APISchema = 1
+
+// DefaultURL is where the engine's health API listens by default
+const DefaultURL = "http://127.0.0.1:15000"
+
+// Timeouts applied when the context has no earlier deadline. Replay has
+// none: it takes as long as the providers need.
+const (
+	HealthTimeout  = 3 * time.Second
+	ControlTimeout = 10 * time.Second
+)
+
+// API is the engine API, implemented by *Client. Automation can depend
+// on it to substitute a fake engine in its own tests.
+type API interface {
+	Health(ctx context.Context) (*Health, error)
+	DrainStatus(ctx context.Context) (*DrainStatus, error)
+	Drain(ctx context.Context, fallback *Fallback) (*DrainStatus, error)
+	Resume(ctx context.Context) (*DrainStatus, error)
+	LogLevel(ctx context.Context) (*LogLevel, error)
+	SetLogLevel(ctx context.Context, level string, d time.Duration) (*LogLevel, error)
+	ResetLogLevel(ctx context.Context) (*LogLevel, error)
+	Throttles(ctx context.Context) ([]Throttle, error)
+	SetThrottle(ctx context.Context, t Throttle) ([]Throttle, error)
+	ClearThrottle(ctx context.Context, tenant string) ([]Throttle, error)
+	Replay(ctx context.Context, callID string, opts ReplayOptions) (*Replay, error)
+}
+
+var _ API = (*Client)(nil)
+
+// Client calls the API of one engine
+type Client struct {
+	// BaseURL is the health API, e.g. http://127.0.0.1:15000
+	BaseURL string
+	// Token is HEALTH_API_TOKEN; empty sends no Authorization header
+	Token string
+	// HTTPClient sends the requests; nil uses http.DefaultClient
+	HTTPClient *http.Client
+}
+
+// New returns a client for the engine at baseURL
+func New(baseURL, token string) *Client {
+	return &Client{BaseURL: baseURL, Token: token}
+}
+
+// ErrUnsupported is wrapped by the errors of endpoints the engine does
+// not have, because it predates them
+var ErrUnsupported = errors.New("endpoint not supported by this engine")
+
+// unsupportedError names the feature an older engine lacks
+type unsupportedError struct {
+	feature string
+}
+
+func (e *unsupportedError) Error() string {
+	return "engine does not support " + e.feature + " (update ai_engine)"
+}
+
+func (e *unsupportedError) Unwrap() error {
+	return ErrUnsupported
+}
+
+// APIError is a request the engine answered with an error status
+type APIError struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Status     string
+	// Message is the engine's "error" field, or else the start of the
+	// response body
+	Message string
+}
+
+func (e *APIError) Error() string {
+	return strings.TrimSpace(fmt.Sprintf("%s %s: %s %s", e.Method, e.Path, e.Status, e.Message))
+}
+
+// request is one API call
+type request struct {
+	method      string
+	path        string
+	query       string
+	contentType string
+	body        []byte
+	timeout     time.Duration
+	// feature names the endpoint in unsupported errors
+	feature string
+}
+
+// do sends req and decodes the JSON answer into out
+func (c *Client) do(ctx context.Context, req request, out interface{}) error {
+	if req.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, req.timeout)
+		defer cancel()
+	}
+	var body io.Reader
+	if req.body != nil {
+		body = bytes.NewReader(req.body)
+	}
+	hr, err := http.NewRequestWithContext(ctx, req.method, strings.TrimRight(c.BaseURL, "/")+req.path+req.query, body)
+	if err != nil {
+		return err
+	}
+	if req.body != nil {
+		contentType := req.contentType
+		if contentType == "" {
+			contentType = "application/json"
+		}
+		hr.Header.Set("Content-Type", contentType)
+	}
+	if c.Token != "" {
+		hr.Header.Set("Authorization", "Bearer "+c.Token)
+	}
+	client := c.HTTPClient
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(hr)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	isJSON := strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json")
+	if resp.StatusCode == http.StatusNotFound && !isJSON && req.feature != "" {
+		// The route itself is missing, not a resource behind it
+		return &unsupportedError{feature: req.feature}
+	}
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &APIError{Method: req.method, Path: req.path, StatusCode: resp.StatusCode, Status: resp.Status}
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		var failure struct {
+			Error string `json:"error"`
+		}
+		if isJSON && json.Unmarshal(msg, &failure) == nil && failure.Error != "" {
+			apiErr.Message = failure.Error
+		} else {
+			apiErr.Message = strings.TrimSpace(string(msg))
+		}
+		return apiErr
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("%s %s: %w", req.method, req.path, err)
+	}
+	return nil
+}
+
+// epoch converts the engine's float epoch seconds; 0 is the zero time
+func epoch(secs float64) time.Time {
+	if secs == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, int64(secs*float64(time.Second)))
+}
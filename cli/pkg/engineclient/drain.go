@@ -0,0 +1,84 @@
+package engineclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fallback is the dialplan target new callers are sent to while draining
+// or throttled
+type Fallback struct {
+	Context   string `json:"context"`
+	Extension string `json:"extension"`
+	Priority  int    `json:"priority"`
+}
+
+// ParseFallback parses a Goto-style target: context[,extension[,priority]]
+func ParseFallback(s string) (*Fallback, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) > 3 || strings.TrimSpace(parts[0]) == "" {
+		return nil, fmt.Errorf("invalid fallback %q (use context[,extension[,priority]])", s)
+	}
+	fb := &Fallback{Context: strings.TrimSpace(parts[0]), Extension: "s", Priority: 1}
+	if len(parts) > 1 && strings.TrimSpace(parts[1]) != "" {
+		fb.Extension = strings.TrimSpace(parts[1])
+	}
+	if len(parts) > 2 {
+		p, err := strconv.Atoi(strings.TrimSpace(parts[2]))
+		if err != nil || p < 1 {
+			return nil, fmt.Errorf("invalid fallback priority %q", parts[2])
+		}
+		fb.Priority = p
+	}
+	return fb, nil
+}
+
+func (f *Fallback) String() string {
+	return fmt.Sprintf("%s,%s,%d", f.Context, f.Extension, f.Priority)
+}
+
+// DrainStatus is the engine's drain state
+type DrainStatus struct {
+	Draining    bool      `json:"draining"`
+	Since       float64   `json:"since"`
+	Fallback    *Fallback `json:"fallback"`
+	ActiveCalls int       `json:"active_calls"`
+}
+
+// Started returns when drain mode was entered
+func (d *DrainStatus) Started() time.Time {
+	return epoch(d.Since)
+}
+
+// Drain puts the engine into drain mode: new callers are sent to fallback,
+// or refused when it is nil, while active calls continue
+func (c *Client) Drain(ctx context.Context, fallback *Fallback) (*DrainStatus, error) {
+	body, err := json.Marshal(map[string]interface{}{"fallback": fallback})
+	if err != nil {
+		return nil, err
+	}
+	return c.drainRequest(ctx, "POST", body)
+}
+
+// Resume takes the engine out of drain mode
+func (c *Client) Resume(ctx context.Context) (*DrainStatus, error) {
+	return c.drainRequest(ctx, "DELETE", nil)
+}
+
+// DrainStatus returns the current drain state
+func (c *Client) DrainStatus(ctx context.Context) (*DrainStatus, error) {
+	return c.drainRequest(ctx, "GET", nil)
+}
+
+func (c *Client) drainRequest(ctx context.Context, method string, body []byte) (*DrainStatus, error) {
+	var status DrainStatus
+	req := request{method: method, path: "/drain", body: body, timeout: ControlTimeout, feature: "drain mode"}
+	if err := c.do(ctx, req, &status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
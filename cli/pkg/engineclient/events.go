@@ -0,0 +1,98 @@
+package engineclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hkjarral/asterisk-ai-voice-agent/cli/internal/websocket"
+)
+
+// Event types of the live call events
+const (
+	EventCallStarted   = "call_started"
+	EventTurnCompleted = "turn_completed"
+	EventCallEnded     = "call_ended"
+	EventCallFailed    = "call_failed"
+)
+
+// EventTypes lists every event type
+var EventTypes = []string{EventCallStarted, EventTurnCompleted, EventCallEnded, EventCallFailed}
+
+// Event is one live call event. Data holds the type's fields:
+//
+//	call_started     caller_number, dialed
+//	turn_completed   turn, latency_ms, severity
+//	call_ended       status, duration_s, turns, quality_score
+//	call_failed      fingerprint, status, findings
+type Event struct {
+	Type   string                 `json:"type"`
+	Time   time.Time              `json:"time"`
+	CallID string                 `json:"call_id"`
+	Data   map[string]interface{} `json:"data,omitempty"`
+}
+
+// EventFilter selects the events of a stream; empty fields match all
+type EventFilter struct {
+	Types  []string
+	CallID string
+}
+
+// EventStream is a subscription to the live call events that 'agent
+// serve --events' publishes from the engine's logs
+type EventStream struct {
+	conn *websocket.Conn
+}
+
+// SubscribeEvents connects to the events WebSocket of 'agent serve', e.g.
+// ws://localhost:8090/events. token is its --events-token, empty when
+// none is set.
+func SubscribeEvents(ctx context.Context, eventsURL, token string, filter EventFilter) (*EventStream, error) {
+	u, err := url.Parse(eventsURL)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	if len(filter.Types) > 0 {
+		q.Set("type", strings.Join(filter.Types, ","))
+	}
+	if filter.CallID != "" {
+		q.Set("call_id", filter.CallID)
+	}
+	u.RawQuery = q.Encode()
+	header := http.Header{}
+	if token != "" {
+		header.Set("Authorization", "Bearer "+token)
+	}
+	conn, err := websocket.Dial(ctx, u.String(), header)
+	if err != nil {
+		return nil, fmt.Errorf("events %s: %w", u.Redacted(), err)
+	}
+	return &EventStream{conn: conn}, nil
+}
+
+// Next blocks until the next event. It fails once the stream is closed,
+// by Close or by the server.
+func (s *EventStream) Next() (Event, error) {
+	for {
+		_, data, err := s.conn.ReadMessage()
+		if err != nil {
+			return Event{}, err
+		}
+		var ev Event
+		if err := json.Unmarshal(data, &ev); err != nil {
+			// Not an event; newer servers may send other messages
+			continue
+		}
+		return ev, nil
+	}
+}
+
+// Close ends the subscription
+func (s *EventStream) Close() error {
+	return s.conn.Close()
+}
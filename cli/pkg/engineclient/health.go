@@ -0,0 +1,56 @@
+package engineclient
+
+import (
+	"context"
+	"os"
+	"strings"
+)
+
+// Health is the engine's /health status
+type Health struct {
+	Status        string `json:"status"`
+	Version       string `json:"version"`
+	APISchema     int    `json:"api_schema"`
+	Draining      bool   `json:"draining"`
+	ARIConnected  bool   `json:"ari_connected"`
+	ActiveCalls   int    `json:"active_calls"`
+	UptimeSeconds int    `json:"uptime_seconds"`
+}
+
+// Health reads /health
+func (c *Client) Health(ctx context.Context) (*Health, error) {
+	var h Health
+	if err := c.do(ctx, request{method: "GET", path: "/health", timeout: HealthTimeout}, &h); err != nil {
+		return nil, err
+	}
+	return &h, nil
+}
+
+// URL is the health API of an engine bound to host and port, as in
+// HEALTH_BIND_HOST and HEALTH_BIND_PORT. Empty values take the defaults
+// and wildcard bind addresses map to loopback.
+func URL(host, port string) string {
+	if host == "" || host == "0.0.0.0" || host == "::" {
+		host = "127.0.0.1"
+	}
+	if port == "" {
+		port = "15000"
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return "http://" + host + ":" + port
+}
+
+// FromEnv returns a client configured like the engine: HEALTH_BIND_HOST,
+// HEALTH_BIND_PORT and HEALTH_API_TOKEN from the process environment,
+// else from env (e.g. the project's .env); env may be nil
+func FromEnv(env map[string]string) *Client {
+	lookup := func(key string) string {
+		if v := os.Getenv(key); v != "" {
+			return v
+		}
+		return env[key]
+	}
+	return New(URL(lookup("HEALTH_BIND_HOST"), lookup("HEALTH_BIND_PORT")), lookup("HEALTH_API_TOKEN"))
+}
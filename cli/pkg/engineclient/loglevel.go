@@ -0,0 +1,55 @@
+package engineclient
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+)
+
+// LogLevel is the engine's log level state
+type LogLevel struct {
+	Level   string `json:"level"`
+	Default string `json:"default"`
+	// Until is when a raised level reverts (epoch seconds), 0 when the
+	// level is the default
+	Until float64 `json:"until"`
+}
+
+// Raised reports whether a temporary level is in effect
+func (l *LogLevel) Raised() bool {
+	return l.Until != 0
+}
+
+// Ends returns when the raised level reverts
+func (l *LogLevel) Ends() time.Time {
+	return epoch(l.Until)
+}
+
+// SetLogLevel raises the engine's log level for d; the engine reverts
+// it by itself when d has passed
+func (c *Client) SetLogLevel(ctx context.Context, level string, d time.Duration) (*LogLevel, error) {
+	body, err := json.Marshal(map[string]interface{}{"level": level, "duration_s": d.Seconds()})
+	if err != nil {
+		return nil, err
+	}
+	return c.logLevelRequest(ctx, "POST", body)
+}
+
+// ResetLogLevel restores the engine's default log level now
+func (c *Client) ResetLogLevel(ctx context.Context) (*LogLevel, error) {
+	return c.logLevelRequest(ctx, "DELETE", nil)
+}
+
+// LogLevel returns the engine's log level state
+func (c *Client) LogLevel(ctx context.Context) (*LogLevel, error) {
+	return c.logLevelRequest(ctx, "GET", nil)
+}
+
+func (c *Client) logLevelRequest(ctx context.Context, method string, body []byte) (*LogLevel, error) {
+	var level LogLevel
+	req := request{method: method, path: "/log-level", body: body, timeout: ControlTimeout, feature: "runtime log levels"}
+	if err := c.do(ctx, req, &level); err != nil {
+		return nil, err
+	}
+	return &level, nil
+}
@@ -0,0 +1,76 @@
+package engineclient
+
+import (
+	"context"
+	"net/url"
+	"time"
+)
+
+// ReplayOptions selects what a replay runs through; empty fields use the
+// engine's defaults
+type ReplayOptions struct {
+	Pipeline string
+	Context  string
+	// Audio is a WAV file to replay instead of the call's caller audio
+	// capture in the engine
+	Audio []byte
+}
+
+// Replay is the trace of caller audio replayed through a pipeline
+type Replay struct {
+	CallID string `json:"call_id"`
+	// Source is "capture" (the engine's caller_inbound.wav) or "upload"
+	Source       string       `json:"source"`
+	Pipeline     string       `json:"pipeline"`
+	Context      string       `json:"context,omitempty"`
+	PromptSource string       `json:"prompt_source"`
+	AudioSeconds float64      `json:"audio_s"`
+	Turns        []ReplayTurn `json:"turns"`
+}
+
+// ReplayTurn is one caller utterance and the pipeline's answer to it;
+// stage times are zero for stages that did not run
+type ReplayTurn struct {
+	OffsetSeconds  float64  `json:"offset_s"`
+	AudioMs        int64    `json:"audio_ms"`
+	Transcript     string   `json:"transcript"`
+	Response       string   `json:"response,omitempty"`
+	ToolCalls      []string `json:"tool_calls,omitempty"`
+	STTMs          int64    `json:"stt_ms,omitempty"`
+	LLMMs          int64    `json:"llm_ms,omitempty"`
+	TTSFirstByteMs int64    `json:"tts_first_byte_ms,omitempty"`
+	TTSMs          int64    `json:"tts_ms,omitempty"`
+	TTSBytes       int64    `json:"tts_bytes,omitempty"`
+	Error          string   `json:"error,omitempty"`
+}
+
+// Latency is the time from the end of the utterance to the first TTS
+// audio, as a caller would wait for it
+func (t *ReplayTurn) Latency() time.Duration {
+	if t.TTSFirstByteMs == 0 {
+		return 0
+	}
+	return time.Duration(t.STTMs+t.LLMMs+t.TTSFirstByteMs) * time.Millisecond
+}
+
+// Replay runs a past call's caller audio through the current pipeline
+// (STT, LLM, TTS) without placing a call. It takes as long as the
+// providers need for every turn; bound it with ctx.
+func (c *Client) Replay(ctx context.Context, callID string, opts ReplayOptions) (*Replay, error) {
+	query := url.Values{"call_id": {callID}}
+	if opts.Pipeline != "" {
+		query.Set("pipeline", opts.Pipeline)
+	}
+	if opts.Context != "" {
+		query.Set("context", opts.Context)
+	}
+	req := request{method: "POST", path: "/replay", query: "?" + query.Encode(), feature: "replay"}
+	if opts.Audio != nil {
+		req.body, req.contentType = opts.Audio, "audio/wav"
+	}
+	var replay Replay
+	if err := c.do(ctx, req, &replay); err != nil {
+		return nil, err
+	}
+	return &replay, nil
+}
@@ -0,0 +1,68 @@
+package engineclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"time"
+)
+
+// Throttle limits the new calls of one tenant, matched by the dialed
+// number or the dialplan context. Calls beyond MaxCalls concurrent ones
+// are sent to Fallback, or refused when it is nil.
+type Throttle struct {
+	Tenant   string    `json:"tenant"`
+	DIDs     []string  `json:"dids,omitempty"`
+	Contexts []string  `json:"contexts,omitempty"`
+	MaxCalls int       `json:"max_calls"`
+	Fallback *Fallback `json:"fallback,omitempty"`
+	Reason   string    `json:"reason,omitempty"`
+
+	// Set by the engine
+	Since       float64 `json:"since,omitempty"`
+	Refused     int     `json:"refused,omitempty"`
+	ActiveCalls int     `json:"active_calls,omitempty"`
+}
+
+// Started returns when the tenant was throttled
+func (t *Throttle) Started() time.Time {
+	return epoch(t.Since)
+}
+
+// throttleStatus is the engine's answer on /throttle
+type throttleStatus struct {
+	Throttles []Throttle `json:"throttles"`
+}
+
+// SetThrottle throttles a tenant, replacing its previous limits, and
+// returns every throttled tenant
+func (c *Client) SetThrottle(ctx context.Context, t Throttle) ([]Throttle, error) {
+	body, err := json.Marshal(t)
+	if err != nil {
+		return nil, err
+	}
+	return c.throttleRequest(ctx, "POST", "", body)
+}
+
+// ClearThrottle releases a tenant, or every tenant when it is empty
+func (c *Client) ClearThrottle(ctx context.Context, tenant string) ([]Throttle, error) {
+	query := ""
+	if tenant != "" {
+		query = "?tenant=" + url.QueryEscape(tenant)
+	}
+	return c.throttleRequest(ctx, "DELETE", query, nil)
+}
+
+// Throttles returns the throttled tenants
+func (c *Client) Throttles(ctx context.Context) ([]Throttle, error) {
+	return c.throttleRequest(ctx, "GET", "", nil)
+}
+
+func (c *Client) throttleRequest(ctx context.Context, method, query string, body []byte) ([]Throttle, error) {
+	var status throttleStatus
+	req := request{method: method, path: "/throttle", query: query, body: body, timeout: ControlTimeout, feature: "tenant throttling"}
+	if err := c.do(ctx, req, &status); err != nil {
+		return nil, err
+	}
+	return status.Throttles, nil
+}
@@ -58,10 +58,11 @@ def apply_audiosocket_defaults(config_data: Dict[str, Any]) -> None:
     # Host default
     audiosocket_cfg.setdefault('host', os.getenv('AUDIOSOCKET_HOST', '127.0.0.1'))
     
-    # Port default with type conversion
+    # Port with type conversion. AUDIOSOCKET_PORT wins over YAML so scaled
+    # engine instances (agent scale) can share one ai-agent.yaml.
     try:
         port_default = audiosocket_cfg.get('port', 8090)
-        audiosocket_cfg.setdefault('port', int(os.getenv('AUDIOSOCKET_PORT', str(port_default))))
+        audiosocket_cfg['port'] = int(os.getenv('AUDIOSOCKET_PORT') or port_default)
     except ValueError:
         audiosocket_cfg['port'] = 8090
     
@@ -80,6 +81,7 @@ def apply_externalmedia_defaults(config_data: Dict[str, Any]) -> None:
     
     Environment variables:
     - EXTERNAL_MEDIA_RTP_HOST: Override RTP bind address
+    - EXTERNAL_MEDIA_RTP_PORT: Override RTP port (wins over YAML, for scaled instances)
     
     Args:
         config_data: Configuration dictionary to modify in-place
@@ -88,6 +90,12 @@ def apply_externalmedia_defaults(config_data: Dict[str, Any]) -> None:
     """
     external_cfg = config_data.get('external_media', {}) or {}
     external_cfg.setdefault('rtp_host', os.getenv('EXTERNAL_MEDIA_RTP_HOST', external_cfg.get('rtp_host', '127.0.0.1')))
+    rtp_port = os.getenv('EXTERNAL_MEDIA_RTP_PORT')
+    if rtp_port:
+        try:
+            external_cfg['rtp_port'] = int(rtp_port)
+        except ValueError:
+            pass
     config_data['external_media'] = external_cfg
 
 
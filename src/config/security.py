@@ -64,6 +64,7 @@ def inject_asterisk_credentials(config_data: Dict[str, Any]) -> None:
     - ASTERISK_HOST (default: 127.0.0.1)
     - ASTERISK_ARI_USERNAME or ARI_USERNAME (required)
     - ASTERISK_ARI_PASSWORD or ARI_PASSWORD (required)
+    - ASTERISK_APP_NAME (optional, overrides YAML app_name for scaled instances)
     
     Args:
         config_data: Configuration dictionary to modify in-place
@@ -76,7 +77,7 @@ def inject_asterisk_credentials(config_data: Dict[str, Any]) -> None:
         "host": os.getenv("ASTERISK_HOST", "127.0.0.1"),
         "username": os.getenv("ASTERISK_ARI_USERNAME") or os.getenv("ARI_USERNAME"),
         "password": os.getenv("ASTERISK_ARI_PASSWORD") or os.getenv("ARI_PASSWORD"),
-        "app_name": asterisk_yaml.get("app_name", "asterisk-ai-voice-agent")
+        "app_name": os.getenv("ASTERISK_APP_NAME") or asterisk_yaml.get("app_name", "asterisk-ai-voice-agent")
     }
 
 
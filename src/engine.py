@@ -12,6 +12,8 @@ import audioop
 import base64
 import json
 import ipaddress
+import io
+import wave
 from collections import deque
 from datetime import datetime, timezone
 from typing import Dict, Any, Optional, List, Set, Tuple, Callable
@@ -61,6 +63,10 @@ from src.pipelines.base import LLMResponse
 
 logger = get_logger(__name__)
 
+# Version of the /health payload layout. Bump when fields the agent CLI
+# relies on are renamed or removed.
+HEALTH_API_SCHEMA = 1
+
 # -----------------------------------------------------------------------------
 # Environment variable resolution helper
 # -----------------------------------------------------------------------------
@@ -184,6 +190,24 @@ class Engine:
     def __init__(self, config: AppConfig):
         self.config = config
         self._start_time = time.time()  # Track engine start time for uptime
+        # Drain mode (POST /drain): new callers are refused or sent to a fallback
+        # dialplan target while active calls finish. Cleared by DELETE /drain or restart.
+        self._draining = False
+        self._drain_started: Optional[float] = None
+        self._drain_fallback: Optional[Dict[str, Any]] = None
+        # Tenant throttles (POST /throttle): calls to a tenant's DIDs or dialplan
+        # contexts beyond max_calls are refused or sent to a fallback target.
+        # Set by `agent tenants quota` (action: throttle); cleared by DELETE /throttle or restart.
+        self._throttles: Dict[str, Dict[str, Any]] = {}
+        self._tenant_calls: Dict[str, Dict[str, float]] = {}
+        # Temporary log level (POST /log-level): raised for a window and
+        # reverted by the engine when it ends, by DELETE /log-level or restart.
+        self._log_level_default: Optional[int] = None
+        self._log_level_until: Optional[float] = None
+        self._log_level_task: Optional[asyncio.Task] = None
+        # Per-second transport frame counters, kept only while the log level
+        # is DEBUG and logged as "Audio frame stats" (agent troubleshoot --frames)
+        self._frame_stats: Dict[str, Dict[str, Any]] = {}
         base_url = f"http://{config.asterisk.host}:{config.asterisk.port}/ari"
         self.ari_client = ARIClient(
             username=config.asterisk.username,
@@ -1332,6 +1356,13 @@ class Engine:
             logger.warning("🎯 HYBRID ARI - Caller already in progress", channel_id=caller_channel_id)
             return
         
+        if self._draining:
+            await self._refuse_call_while_draining(caller_channel_id)
+            return
+        
+        if self._throttles and await self._refuse_call_if_throttled(caller_channel_id, channel):
+            return
+        
         try:
             # Answer the caller
             logger.info("🎯 HYBRID ARI - Step 1: Answering caller channel", channel_id=caller_channel_id)
@@ -2570,6 +2601,7 @@ class Engine:
 
             # Reset per-call alignment warning state
             self._runtime_alignment_logged.discard(call_id)
+            self._flush_frame_stats(call_id, final=True)
 
             logger.info("Call cleanup completed", call_id=call_id)
         except Exception as exc:
@@ -4407,6 +4439,8 @@ class Engine:
                 )
                 return
 
+            self._count_frame(caller_channel_id, "in", len(pcm_16k))
+
             # Record SSRC on the session for diagnostics (RTPServer maintains SSRC mapping internally).
             try:
                 if not getattr(session, "ssrc", None):
@@ -7828,8 +7862,60 @@ class Engine:
             except Exception:
                 pass
 
+    def _count_frame(self, call_id: str, direction: str, nbytes: int) -> None:
+        """Count one transport frame ("in" or "out") for the per-second stats.
+        
+        Only active while the root log level is DEBUG (e.g. an `agent debug`
+        window); each finished second is logged as "Audio frame stats".
+        """
+        if not logging.getLogger().isEnabledFor(logging.DEBUG):
+            if self._frame_stats:
+                self._frame_stats.clear()
+            return
+        now = time.monotonic()
+        stats = self._frame_stats.get(call_id)
+        if stats is None:
+            stats = {"start": now, "second": 0, "last": {}, "in": [0, 0, 0.0], "out": [0, 0, 0.0]}
+            self._frame_stats[call_id] = stats
+        second = int(now - stats["start"])
+        if second != stats["second"]:
+            self._flush_frame_stats(call_id)
+            stats["second"] = second
+        counters = stats[direction]
+        counters[0] += 1
+        counters[1] += nbytes
+        last = stats["last"].get(direction)
+        if last is not None:
+            counters[2] = max(counters[2], (now - last) * 1000.0)
+        stats["last"][direction] = now
+
+    def _flush_frame_stats(self, call_id: str, final: bool = False) -> None:
+        """Log the current second's frame counters and reset them."""
+        stats = self._frame_stats.pop(call_id, None) if final else self._frame_stats.get(call_id)
+        if not stats:
+            return
+        rx, tx = stats["in"], stats["out"]
+        if rx[0] or tx[0]:
+            logger.debug(
+                "Audio frame stats",
+                call_id=call_id,
+                second=stats["second"],
+                in_frames=rx[0],
+                in_bytes=rx[1],
+                in_max_gap_ms=round(rx[2], 1),
+                out_frames=tx[0],
+                out_bytes=tx[1],
+                out_max_gap_ms=round(tx[2], 1),
+            )
+        stats["in"] = [0, 0, 0.0]
+        stats["out"] = [0, 0, 0.0]
+
     def _update_audio_diagnostics(self, session: CallSession, stage: str, audio_bytes: bytes, encoding: str, sample_rate: int) -> None:
         """Track audio health metrics (RMS/DC offset) for observability."""
+        if stage == "transport_in":
+            self._count_frame(session.call_id, "in", len(audio_bytes))
+        elif stage.startswith("transport_out"):
+            self._count_frame(session.call_id, "out", len(audio_bytes))
         try:
             canonical = self._canonicalize_encoding(encoding) or "slin16"
             if canonical == "ulaw":
@@ -8559,6 +8645,16 @@ class Engine:
             app.router.add_get('/health', self._health_handler)
             app.router.add_get('/metrics', self._metrics_handler)
             app.router.add_post('/reload', self._reload_handler)
+            app.router.add_get('/drain', self._drain_status_handler)
+            app.router.add_post('/drain', self._drain_handler)
+            app.router.add_delete('/drain', self._drain_handler)
+            app.router.add_get('/throttle', self._throttle_status_handler)
+            app.router.add_post('/throttle', self._throttle_handler)
+            app.router.add_delete('/throttle', self._throttle_handler)
+            app.router.add_get('/log-level', self._log_level_status_handler)
+            app.router.add_post('/log-level', self._log_level_handler)
+            app.router.add_delete('/log-level', self._log_level_handler)
+            app.router.add_post('/replay', self._replay_handler)
             app.router.add_get('/mcp/status', self._mcp_status_handler)
             app.router.add_post('/mcp/test/{server_id}', self._mcp_test_handler)
             app.router.add_get('/sessions/stats', self._sessions_stats_handler)
@@ -8841,6 +8937,10 @@ class Engine:
 
             payload = {
                 "status": "healthy" if is_ready else "degraded",
+                # Release version and /health schema, checked by `agent` for CLI compatibility
+                "version": os.getenv("AAVA_VERSION", "dev"),
+                "api_schema": HEALTH_API_SCHEMA,
+                "draining": self._draining,
                 "ari_connected": ari_connected,
                 "rtp_server_running": bool(getattr(self, 'rtp_server', None)),
                 "audio_transport": self.config.audio_transport,
@@ -8872,6 +8972,490 @@ class Engine:
         except Exception as exc:
             return web.json_response({"status": "error", "error": str(exc)}, status=500)
 
+    @staticmethod
+    def _parse_fallback(body: Any) -> Tuple[Optional[Dict[str, Any]], Optional[str]]:
+        """Parse the optional "fallback" dialplan target of a request body.
+        
+        Returns (fallback, error); fallback is None when the body names none.
+        """
+        fb = body.get("fallback") if isinstance(body, dict) else None
+        if not fb:
+            return None, None
+        if not isinstance(fb, dict) or not fb.get("context"):
+            return None, "fallback.context is required"
+        try:
+            priority = int(fb.get("priority", 1))
+        except (TypeError, ValueError):
+            return None, "fallback.priority must be a number"
+        return {
+            "context": str(fb["context"]),
+            "extension": str(fb.get("extension") or "s"),
+            "priority": priority,
+        }, None
+
+    async def _send_to_fallback_or_hangup(self, channel_id: str, fallback: Optional[Dict[str, Any]], why: str, **log_ctx):
+        """Continue a refused call to fallback, or hang up with congestion without one or when continue fails."""
+        if fallback:
+            logger.info(f"{why} - sending new call to fallback", channel_id=channel_id, **log_ctx, **fallback)
+            response = await self.ari_client.send_command(
+                "POST", f"channels/{channel_id}/continue", params={k: str(v) for k, v in fallback.items()}
+            )
+            if not (response and response.get("status", 204) >= 400):
+                return
+            logger.warning(f"{why} - fallback continue failed, hanging up", channel_id=channel_id, **log_ctx)
+        else:
+            logger.info(f"{why} - refusing new call", channel_id=channel_id, **log_ctx)
+        await self.ari_client.send_command(
+            "DELETE", f"channels/{channel_id}", params={"reason": "congestion"}, tolerate_statuses=[404]
+        )
+
+    async def _refuse_call_while_draining(self, channel_id: str):
+        """Send a new caller to the drain fallback target, or hang up with congestion."""
+        await self._send_to_fallback_or_hangup(channel_id, self._drain_fallback, "Draining")
+
+    def _drain_status(self, active_calls: int) -> Dict[str, Any]:
+        return {
+            "draining": self._draining,
+            "since": self._drain_started,
+            "fallback": self._drain_fallback,
+            "active_calls": active_calls,
+        }
+
+    async def _drain_status_handler(self, request):
+        """GET /drain - current drain state and active call count."""
+        sessions = await self.session_store.get_all_sessions()
+        return web.json_response(self._drain_status(len(sessions)))
+
+    async def _drain_handler(self, request):
+        """Enter (POST) or leave (DELETE) drain mode.
+        
+        POST /drain accepts an optional JSON body
+        {"fallback": {"context": "...", "extension": "s", "priority": 1}}
+        naming where new callers are continued instead of being refused.
+        
+        SECURITY: Requires localhost or HEALTH_API_TOKEN.
+        """
+        if not self._is_request_authorized(request):
+            return web.json_response(
+                {"success": False, "error": "Forbidden: requires localhost or valid HEALTH_API_TOKEN"},
+                status=403
+            )
+        
+        if request.method == "DELETE":
+            if self._draining:
+                logger.info("🚰 Drain mode disabled - accepting new calls")
+            self._draining = False
+            self._drain_started = None
+            self._drain_fallback = None
+        else:
+            fallback = None
+            if request.can_read_body:
+                try:
+                    body = await request.json()
+                except Exception:
+                    return web.json_response({"success": False, "error": "invalid JSON body"}, status=400)
+                fallback, error = self._parse_fallback(body)
+                if error:
+                    return web.json_response({"success": False, "error": error}, status=400)
+            if not self._draining:
+                self._drain_started = time.time()
+            self._draining = True
+            self._drain_fallback = fallback
+            logger.info("🚰 Drain mode enabled - refusing new calls", fallback=fallback)
+        
+        sessions = await self.session_store.get_all_sessions()
+        payload = self._drain_status(len(sessions))
+        payload["success"] = True
+        return web.json_response(payload)
+
+    @staticmethod
+    def _did_matches(exten: str, did: str) -> bool:
+        """Compare digits only; a trailing * matches a number range."""
+        digits = "".join(c for c in str(exten or "") if c.isdigit())
+        want = "".join(c for c in str(did or "") if c.isdigit())
+        if not digits or not want:
+            return False
+        if str(did).endswith("*"):
+            return digits.startswith(want)
+        return digits == want
+
+    def _throttled_tenant(self, channel: dict) -> Optional[str]:
+        """Return the throttled tenant whose DIDs or dialplan contexts match the channel."""
+        dialplan = channel.get('dialplan', {}) or {}
+        exten = dialplan.get('exten', '')
+        context = str(dialplan.get('context', '') or '').lower()
+        for tenant, throttle in self._throttles.items():
+            if any(self._did_matches(exten, did) for did in throttle.get("dids", [])):
+                return tenant
+            if context and context in [c.lower() for c in throttle.get("contexts", [])]:
+                return tenant
+        return None
+
+    async def _tenant_active_calls(self, tenant: str) -> int:
+        """Count the tenant's calls that still have a session (or just started)."""
+        calls = self._tenant_calls.get(tenant, {})
+        now = time.time()
+        for call_id, started in list(calls.items()):
+            # Sessions are created after StasisStart; give new calls a grace period
+            if now - started > 30 and not await self.session_store.get_by_call_id(call_id):
+                calls.pop(call_id, None)
+        return len(calls)
+
+    async def _refuse_call_if_throttled(self, channel_id: str, channel: dict) -> bool:
+        """Refuse a throttled tenant's call beyond its max_calls; True when refused."""
+        tenant = self._throttled_tenant(channel)
+        if not tenant:
+            return False
+        throttle = self._throttles[tenant]
+        active = await self._tenant_active_calls(tenant)
+        if active < int(throttle.get("max_calls", 0)):
+            self._tenant_calls.setdefault(tenant, {})[channel_id] = time.time()
+            logger.info("Throttled tenant call accepted", channel_id=channel_id, tenant=tenant,
+                        active_calls=active + 1, max_calls=throttle.get("max_calls"))
+            return False
+        throttle["refused"] = int(throttle.get("refused", 0)) + 1
+        await self._send_to_fallback_or_hangup(
+            channel_id, throttle.get("fallback"), "Tenant throttled", tenant=tenant, reason=throttle.get("reason")
+        )
+        return True
+
+    async def _throttle_status(self) -> Dict[str, Any]:
+        throttles = []
+        for tenant, throttle in sorted(self._throttles.items()):
+            entry = dict(throttle)
+            entry["tenant"] = tenant
+            entry["active_calls"] = await self._tenant_active_calls(tenant)
+            throttles.append(entry)
+        return {"throttles": throttles}
+
+    async def _throttle_status_handler(self, request):
+        """GET /throttle - throttled tenants with their limits and active calls."""
+        return web.json_response(await self._throttle_status())
+
+    async def _throttle_handler(self, request):
+        """Throttle (POST) or release (DELETE) a tenant.
+        
+        POST /throttle takes {"tenant": "acme", "dids": ["+4930123450*"],
+        "contexts": ["from-acme"], "max_calls": 0, "reason": "...",
+        "fallback": {"context": "...", "extension": "s", "priority": 1}}.
+        DELETE /throttle?tenant=acme releases one tenant, without it all.
+        
+        SECURITY: Requires localhost or HEALTH_API_TOKEN.
+        """
+        if not self._is_request_authorized(request):
+            return web.json_response(
+                {"success": False, "error": "Forbidden: requires localhost or valid HEALTH_API_TOKEN"},
+                status=403
+            )
+        
+        if request.method == "DELETE":
+            tenant = request.query.get("tenant")
+            released = [tenant] if tenant else list(self._throttles)
+            for name in released:
+                if self._throttles.pop(name, None) is not None:
+                    logger.info("Tenant throttle released", tenant=name)
+                self._tenant_calls.pop(name, None)
+        else:
+            try:
+                body = await request.json()
+            except Exception:
+                return web.json_response({"success": False, "error": "invalid JSON body"}, status=400)
+            if not isinstance(body, dict) or not body.get("tenant"):
+                return web.json_response({"success": False, "error": "tenant is required"}, status=400)
+            dids = [str(d) for d in body.get("dids") or []]
+            contexts = [str(c) for c in body.get("contexts") or []]
+            if not dids and not contexts:
+                return web.json_response({"success": False, "error": "dids or contexts are required"}, status=400)
+            try:
+                max_calls = max(0, int(body.get("max_calls", 0)))
+            except (TypeError, ValueError):
+                return web.json_response({"success": False, "error": "max_calls must be a number"}, status=400)
+            fallback, error = self._parse_fallback(body)
+            if error:
+                return web.json_response({"success": False, "error": error}, status=400)
+            tenant = str(body["tenant"])
+            previous = self._throttles.get(tenant, {})
+            self._throttles[tenant] = {
+                "dids": dids,
+                "contexts": contexts,
+                "max_calls": max_calls,
+                "fallback": fallback,
+                "reason": str(body.get("reason") or ""),
+                "since": previous.get("since") or time.time(),
+                "refused": previous.get("refused", 0),
+            }
+            logger.info("Tenant throttled", tenant=tenant, max_calls=max_calls,
+                        reason=self._throttles[tenant]["reason"], fallback=fallback)
+        
+        payload = await self._throttle_status()
+        payload["success"] = True
+        return web.json_response(payload)
+
+    # Levels POST /log-level accepts, and the longest window it allows
+    _LOG_LEVELS = {"debug": logging.DEBUG, "info": logging.INFO, "warning": logging.WARNING}
+    _LOG_LEVEL_MAX_SECONDS = 4 * 3600
+
+    def _log_level_status(self) -> Dict[str, Any]:
+        root = logging.getLogger()
+        default = self._log_level_default if self._log_level_default is not None else root.level
+        return {
+            "level": logging.getLevelName(root.level).lower(),
+            "default": logging.getLevelName(default).lower(),
+            "until": self._log_level_until,
+        }
+
+    def _restore_log_level(self) -> None:
+        if self._log_level_default is not None:
+            logging.getLogger().setLevel(self._log_level_default)
+            logger.info("🔎 Log level restored", level=logging.getLevelName(self._log_level_default).lower())
+        self._log_level_default = None
+        self._log_level_until = None
+
+    async def _log_level_expiry(self, seconds: float) -> None:
+        try:
+            await asyncio.sleep(seconds)
+        except asyncio.CancelledError:
+            return
+        self._log_level_task = None
+        self._restore_log_level()
+
+    async def _log_level_status_handler(self, request):
+        """GET /log-level - current, default and temporary log level."""
+        return web.json_response(self._log_level_status())
+
+    async def _log_level_handler(self, request):
+        """Raise (POST) or restore (DELETE) the log level.
+        
+        POST /log-level takes {"level": "debug", "duration_s": 900}; the
+        previous level comes back after duration_s.
+        
+        SECURITY: Requires localhost or HEALTH_API_TOKEN.
+        """
+        if not self._is_request_authorized(request):
+            return web.json_response(
+                {"success": False, "error": "Forbidden: requires localhost or valid HEALTH_API_TOKEN"},
+                status=403
+            )
+        
+        if request.method == "POST":
+            try:
+                body = await request.json()
+            except Exception:
+                return web.json_response({"success": False, "error": "invalid JSON body"}, status=400)
+            if not isinstance(body, dict):
+                return web.json_response({"success": False, "error": "invalid JSON body"}, status=400)
+            level = str(body.get("level") or "").lower()
+            if level not in self._LOG_LEVELS:
+                return web.json_response(
+                    {"success": False, "error": f"level must be one of {', '.join(self._LOG_LEVELS)}"}, status=400
+                )
+            try:
+                duration = float(body.get("duration_s"))
+            except (TypeError, ValueError):
+                return web.json_response({"success": False, "error": "duration_s must be a number"}, status=400)
+            if duration <= 0 or duration > self._LOG_LEVEL_MAX_SECONDS:
+                return web.json_response(
+                    {"success": False, "error": f"duration_s must be between 1 and {self._LOG_LEVEL_MAX_SECONDS}"},
+                    status=400
+                )
+        
+        if self._log_level_task:
+            self._log_level_task.cancel()
+            self._log_level_task = None
+        
+        if request.method == "DELETE":
+            self._restore_log_level()
+        else:
+            root = logging.getLogger()
+            if self._log_level_default is None:
+                self._log_level_default = root.level
+            root.setLevel(self._LOG_LEVELS[level])
+            self._log_level_until = time.time() + duration
+            self._log_level_task = asyncio.create_task(self._log_level_expiry(duration))
+            logger.info("🔎 Log level raised", level=level, duration_s=duration)
+        
+        payload = self._log_level_status()
+        payload["success"] = True
+        return web.json_response(payload)
+
+    # Replayed caller audio is split into utterances on this much silence;
+    # 20ms frames under the RMS threshold count as silence
+    _REPLAY_SILENCE_MS = 700
+    _REPLAY_MIN_SPEECH_MS = 200
+    _REPLAY_SILENCE_RMS = 300
+    _REPLAY_MAX_SECONDS = 30 * 60
+
+    @classmethod
+    def _replay_utterances(cls, pcm16: bytes, rate: int) -> List[Tuple[float, bytes]]:
+        """Split 16-bit mono PCM into (offset_s, audio) utterances on silence."""
+        frame_bytes = int(rate * 0.02) * 2
+        silence_frames = cls._REPLAY_SILENCE_MS // 20
+        utterances: List[Tuple[int, bytes]] = []
+        current = bytearray()
+        start = 0
+        quiet = 0
+        for i in range(0, len(pcm16) - frame_bytes + 1, frame_bytes):
+            frame = pcm16[i:i + frame_bytes]
+            speech = audioop.rms(frame, 2) >= cls._REPLAY_SILENCE_RMS
+            if not current and not speech:
+                continue
+            if not current:
+                start = i
+            current.extend(frame)
+            quiet = 0 if speech else quiet + 1
+            if quiet >= silence_frames:
+                utterances.append((start, bytes(current[: len(current) - quiet * frame_bytes])))
+                current = bytearray()
+                quiet = 0
+        if current:
+            utterances.append((start, bytes(current[: len(current) - quiet * frame_bytes])))
+        min_bytes = rate * 2 * cls._REPLAY_MIN_SPEECH_MS // 1000
+        return [(offset / (rate * 2.0), audio) for offset, audio in utterances if len(audio) >= min_bytes]
+
+    def _replay_llm_options(self, pipeline: PipelineResolution, context_name: Optional[str]) -> Tuple[Dict[str, Any], str]:
+        """LLM options with the same prompt fallback chain as live calls."""
+        llm_options = dict(pipeline.llm_options or {})
+        context_config = self.transport_orchestrator.get_context_config(context_name) if context_name else None
+        if context_config and context_config.prompt:
+            llm_options['system_prompt'] = context_config.prompt
+            return llm_options, "context_injection"
+        if llm_options.get('system_prompt'):
+            return llm_options, "pipeline_default"
+        global_prompt = getattr(self.config.llm, 'prompt', None)
+        if global_prompt:
+            llm_options['system_prompt'] = global_prompt
+            return llm_options, "global_llm_config"
+        return llm_options, "none"
+
+    async def _replay_handler(self, request):
+        """Replay caller audio through a pipeline offline (POST /replay).
+
+        Query: call_id (required), pipeline, context. The body is a WAV
+        file; without one the call's caller_inbound.wav capture is used.
+        Each utterance runs STT -> LLM -> TTS on a synthetic call, nothing
+        is played, and the per-turn trace is returned.
+
+        SECURITY: Requires localhost or HEALTH_API_TOKEN.
+        """
+        if not self._is_request_authorized(request):
+            return web.json_response(
+                {"success": False, "error": "Forbidden: requires localhost or valid HEALTH_API_TOKEN"},
+                status=403
+            )
+
+        source_call = request.query.get("call_id", "").strip()
+        if not source_call or "/" in source_call or source_call.startswith("."):
+            return web.json_response({"success": False, "error": "call_id is required"}, status=400)
+        context_name = request.query.get("context") or None
+        if context_name and not self.transport_orchestrator.get_context_config(context_name):
+            return web.json_response({"success": False, "error": f"unknown context {context_name}"}, status=400)
+
+        source = "upload"
+        data = await request.read() if request.can_read_body else b""
+        if not data:
+            source = "capture"
+            path = os.path.join(self.audio_capture.base_dir, source_call, "caller_inbound.wav")
+            try:
+                with open(path, "rb") as fh:
+                    data = fh.read()
+            except FileNotFoundError:
+                return web.json_response(
+                    {"success": False, "error": f"no caller audio captured for {source_call} (DIAG_ENABLE_TAPS=true keeps captures)"},
+                    status=404
+                )
+        try:
+            with wave.open(io.BytesIO(data), "rb") as wav:
+                channels, width, rate = wav.getnchannels(), wav.getsampwidth(), wav.getframerate()
+                if wav.getnframes() > rate * self._REPLAY_MAX_SECONDS:
+                    return web.json_response({"success": False, "error": "audio longer than 30 minutes"}, status=400)
+                pcm = wav.readframes(wav.getnframes())
+        except (wave.Error, EOFError) as exc:
+            return web.json_response({"success": False, "error": f"not a PCM WAV file: {exc}"}, status=400)
+        if width != 2:
+            pcm = audioop.lin2lin(pcm, width, 2)
+        if channels == 2:
+            pcm = audioop.tomono(pcm, 2, 0.5, 0.5)
+        elif channels != 1:
+            return web.json_response({"success": False, "error": f"unsupported channel count {channels}"}, status=400)
+        if rate != 16000:
+            pcm, _ = audioop.ratecv(pcm, 2, 1, rate, 16000, None)
+
+        call_id = f"replay-{source_call}-{uuid.uuid4().hex[:6]}"
+        pipeline = self.pipeline_orchestrator.get_pipeline(call_id, request.query.get("pipeline") or None)
+        if not pipeline:
+            return web.json_response({"success": False, "error": "no pipeline available"}, status=409)
+        llm_options, prompt_source = self._replay_llm_options(pipeline, context_name)
+        logger.info("🔁 Replaying caller audio", call_id=call_id, source_call=source_call, pipeline=pipeline.pipeline_name)
+
+        turns: List[Dict[str, Any]] = []
+        history: List[Dict[str, str]] = []
+        try:
+            await pipeline.stt_adapter.open_call(call_id, pipeline.stt_options)
+            await pipeline.llm_adapter.open_call(call_id, llm_options)
+            await pipeline.tts_adapter.open_call(call_id, pipeline.tts_options)
+            for offset, audio in self._replay_utterances(pcm, 16000):
+                turn: Dict[str, Any] = {"offset_s": round(offset, 2), "audio_ms": len(audio) * 1000 // 32000}
+                turns.append(turn)
+                started = time.monotonic()
+                try:
+                    transcript = await pipeline.stt_adapter.transcribe(call_id, audio, 16000, pipeline.stt_options)
+                except Exception as exc:
+                    turn["error"] = f"stt: {exc}"
+                    continue
+                turn["stt_ms"] = int((time.monotonic() - started) * 1000)
+                transcript = (transcript or "").strip()
+                turn["transcript"] = transcript
+                if not transcript:
+                    continue
+
+                started = time.monotonic()
+                try:
+                    result = await pipeline.llm_adapter.generate(
+                        call_id, transcript, {"prior_messages": list(history)}, llm_options
+                    )
+                except Exception as exc:
+                    turn["error"] = f"llm: {exc}"
+                    continue
+                turn["llm_ms"] = int((time.monotonic() - started) * 1000)
+                if isinstance(result, LLMResponse):
+                    response_text = (result.text or "").strip()
+                    turn["tool_calls"] = [tc.get("name") for tc in (result.tool_calls or [])]
+                else:
+                    response_text = (str(result) or "").strip()
+                turn["response"] = response_text
+                history.append({"role": "user", "content": transcript})
+                history.append({"role": "assistant", "content": response_text or "(tool execution)"})
+                if not response_text:
+                    continue
+
+                started = time.monotonic()
+                tts_bytes = 0
+                try:
+                    async for chunk in pipeline.tts_adapter.synthesize(call_id, response_text, pipeline.tts_options):
+                        if chunk:
+                            if not tts_bytes:
+                                turn["tts_first_byte_ms"] = int((time.monotonic() - started) * 1000)
+                            tts_bytes += len(chunk)
+                except Exception as exc:
+                    turn["error"] = f"tts: {exc}"
+                    continue
+                turn["tts_ms"] = int((time.monotonic() - started) * 1000)
+                turn["tts_bytes"] = tts_bytes
+        finally:
+            await self.pipeline_orchestrator.release_pipeline(call_id)
+
+        logger.info("🔁 Replay finished", call_id=call_id, turns=len(turns))
+        return web.json_response({
+            "success": True,
+            "call_id": source_call,
+            "source": source,
+            "pipeline": pipeline.pipeline_name,
+            "context": context_name,
+            "prompt_source": prompt_source,
+            "audio_s": round(len(pcm) / 32000.0, 2),
+            "turns": turns,
+        })
+
     async def _live_handler(self, request):
         """Liveness probe: returns 200 if process is up."""
         return web.Response(text="ok", status=200)
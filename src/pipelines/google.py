@@ -362,11 +362,14 @@ class GoogleLLMAdapter(LLMComponent):
                 candidates_count=len(data.get("candidates", [])),
                 raw_response=body[:500] if len(body) <= 500 else body[:500] + "...",
             )
+        # Summed per call by `agent tenants quota`
+        usage = data.get("usageMetadata") or {}
         logger.info(
             "Google LLM response received",
             call_id=call_id,
             request_id=request_id,
             preview=text[:80] if text else "(empty)",
+            total_tokens=usage.get("totalTokenCount"),
         )
         return text
 
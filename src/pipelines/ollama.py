@@ -308,6 +308,8 @@ class OllamaLLMAdapter(LLMComponent):
                     response_length=len(text),
                     tool_calls=len(parsed_tool_calls),
                     preview=text[:80] if text else "(tool call only)",
+                    # Prompt plus generated tokens, summed per call by `agent tenants quota`
+                    total_tokens=(data.get("prompt_eval_count") or 0) + (data.get("eval_count") or 0),
                 )
                 
                 # Add assistant response to history
@@ -431,6 +431,10 @@ class OpenAILLMAdapter(LLMComponent):
                         "model": payload.get("model"),
                         "preview": (content or "")[:80],
                     }
+                    usage = data.get("usage") or {}
+                    if usage.get("total_tokens"):
+                        # Summed per call by `agent tenants quota`
+                        log_ctx["total_tokens"] = usage.get("total_tokens")
                     if tool_calls:
                         log_ctx["tool_calls"] = len(tool_calls)
                         # Parse tool calls into our standard dict format
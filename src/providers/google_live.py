@@ -643,6 +643,17 @@ class GoogleLiveProvider(AIProviderInterface):
             message_type=message_type,
         )
 
+        # Token usage rides on any message; summed per call by `agent tenants quota`
+        usage = data.get("usageMetadata") or {}
+        if usage.get("totalTokenCount"):
+            logger.info(
+                "Google Live usage",
+                call_id=self._call_id,
+                total_tokens=usage.get("totalTokenCount"),
+                input_tokens=usage.get("promptTokenCount"),
+                output_tokens=usage.get("responseTokenCount"),
+            )
+
         # Handle by type
         if message_type == "setupComplete":
             await self._handle_setup_complete(data)
@@ -1454,6 +1454,17 @@ class OpenAIRealtimeProvider(AIProviderInterface):
             # Track if audio was emitted during this response
             had_audio_burst = self._in_audio_burst
             
+            # Token usage per response, summed per call by `agent tenants quota`
+            usage = (event.get("response") or {}).get("usage") or {}
+            if usage.get("total_tokens"):
+                logger.info(
+                    "OpenAI Realtime response usage",
+                    call_id=self._call_id,
+                    total_tokens=usage.get("total_tokens"),
+                    input_tokens=usage.get("input_tokens"),
+                    output_tokens=usage.get("output_tokens"),
+                )
+            
             # Reset audio start time when response fully completes - allows interruption for next response
             self._response_audio_start_time = None
             
@@ -117,6 +117,15 @@ class TestApplyAudiosocketDefaults:
         assert config_data['audiosocket']['port'] == 7777
         assert config_data['audiosocket']['format'] == 'slin'
     
+    def test_env_port_wins_over_yaml(self, monkeypatch):
+        """Should let AUDIOSOCKET_PORT override a YAML port (scaled instances)."""
+        monkeypatch.setenv('AUDIOSOCKET_PORT', '8091')
+        
+        config_data = {'audiosocket': {'port': 8090}}
+        apply_audiosocket_defaults(config_data)
+        
+        assert config_data['audiosocket']['port'] == 8091
+    
     def test_invalid_port_uses_default(self, monkeypatch):
         """Should use default port if env var is invalid."""
         monkeypatch.setenv('AUDIOSOCKET_PORT', 'invalid')
@@ -158,6 +167,16 @@ class TestApplyExternalmediaDefaults:
         assert config_data['external_media']['rtp_host'] == 'yaml_host'
 
 
+    def test_env_rtp_port_wins_over_yaml(self, monkeypatch):
+        """Should let EXTERNAL_MEDIA_RTP_PORT override a YAML RTP port."""
+        monkeypatch.setenv('EXTERNAL_MEDIA_RTP_PORT', '18081')
+        
+        config_data = {'external_media': {'rtp_port': 18080}}
+        apply_externalmedia_defaults(config_data)
+        
+        assert config_data['external_media']['rtp_port'] == 18081
+
+
 class TestApplyDiagnosticDefaults:
     """Tests for apply_diagnostic_defaults function."""
     
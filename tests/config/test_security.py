@@ -122,6 +122,18 @@ class TestInjectAsteriskCredentials:
         
         assert config_data['asterisk']['app_name'] == 'custom-app-name'
     
+    def test_env_app_name_wins_over_yaml(self, monkeypatch):
+        """Should let ASTERISK_APP_NAME override the YAML app_name (scaled instances)."""
+        monkeypatch.setenv('ASTERISK_APP_NAME', 'asterisk-ai-voice-agent-2')
+        config_data = {
+            'asterisk': {
+                'app_name': 'custom-app-name'
+            }
+        }
+        inject_asterisk_credentials(config_data)
+        
+        assert config_data['asterisk']['app_name'] == 'asterisk-ai-voice-agent-2'
+    
     def test_overwrite_yaml_credentials(self, monkeypatch):
         """SECURITY: Should overwrite YAML credentials with env vars."""
         monkeypatch.setenv("ASTERISK_HOST", "env_host")